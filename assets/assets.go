@@ -8,7 +8,9 @@ const (
 	PostgresMigrationDir = "migrations/postgres"
 )
 
-// EmbedMigrations within the openfga binary.
+// EmbedMigrations within the openfga binary. Migrations that remove schema which older server versions
+// still use must be annotated with a '-- +openfga contract' line, so that 'openfga migrate --phase=expand'
+// doesn't apply them while older servers are running.
 //
 //go:embed migrations/*
 var EmbedMigrations embed.FS
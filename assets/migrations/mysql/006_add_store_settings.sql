@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE store_settings (
+    store CHAR(26) PRIMARY KEY,
+    default_authorization_model_id CHAR(26) NOT NULL DEFAULT '',
+    default_consistency VARCHAR(32) NOT NULL DEFAULT '',
+    check_query_cache_ttl_ms BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE store_settings;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE store_label (
+    store CHAR(26) NOT NULL,
+    label_key VARCHAR(63) NOT NULL,
+    label_value VARCHAR(63) NOT NULL,
+    PRIMARY KEY (store, label_key)
+);
+
+CREATE INDEX idx_store_label_key_value ON store_label (label_key, label_value);
+
+-- +goose Down
+DROP TABLE store_label;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE planner_statistics (
+    store CHAR(26) PRIMARY KEY,
+    statistics LONGBLOB NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE planner_statistics;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE tuple ADD COLUMN created_by VARCHAR(256);
+ALTER TABLE changelog ADD COLUMN created_by VARCHAR(256);
+
+-- +goose Down
+ALTER TABLE tuple DROP COLUMN created_by;
+ALTER TABLE changelog DROP COLUMN created_by;
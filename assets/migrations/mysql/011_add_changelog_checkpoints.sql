@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE changelog_checkpoint (
+    store CHAR(26) NOT NULL,
+    consumer VARCHAR(128) NOT NULL,
+    continuation_token VARCHAR(512) NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (store, consumer)
+);
+
+-- +goose Down
+DROP TABLE changelog_checkpoint;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE relation_usage (
+    store CHAR(26) NOT NULL,
+    object_type VARCHAR(128) NOT NULL,
+    relation VARCHAR(50) NOT NULL,
+    allowed_count BIGINT UNSIGNED NOT NULL,
+    denied_count BIGINT UNSIGNED NOT NULL,
+    last_evaluated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (store, object_type, relation)
+);
+
+-- +goose Down
+DROP TABLE relation_usage;
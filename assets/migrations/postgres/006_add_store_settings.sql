@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE store_settings (
+	store TEXT PRIMARY KEY,
+	default_authorization_model_id TEXT NOT NULL DEFAULT '',
+	default_consistency TEXT NOT NULL DEFAULT '',
+	check_query_cache_ttl_ms BIGINT NOT NULL DEFAULT 0,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE store_settings;
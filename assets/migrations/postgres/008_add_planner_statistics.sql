@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE planner_statistics (
+	store TEXT PRIMARY KEY,
+	statistics BYTEA NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE planner_statistics;
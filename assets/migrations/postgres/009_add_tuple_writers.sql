@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE tuple ADD COLUMN created_by TEXT;
+ALTER TABLE changelog ADD COLUMN created_by TEXT;
+
+-- +goose Down
+ALTER TABLE tuple DROP COLUMN created_by;
+ALTER TABLE changelog DROP COLUMN created_by;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_user_lookup ON tuple (store, _user, object_type, relation);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_lookup;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE changelog_checkpoint (
+	store TEXT NOT NULL,
+	consumer TEXT NOT NULL,
+	continuation_token TEXT NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (store, consumer)
+);
+
+-- +goose Down
+DROP TABLE changelog_checkpoint;
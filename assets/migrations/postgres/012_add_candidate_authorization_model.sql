@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE store_settings ADD COLUMN candidate_authorization_model_id TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE store_settings DROP COLUMN candidate_authorization_model_id;
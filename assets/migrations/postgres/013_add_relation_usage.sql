@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE relation_usage (
+	store TEXT NOT NULL,
+	object_type TEXT NOT NULL,
+	relation TEXT NOT NULL,
+	allowed_count BIGINT NOT NULL,
+	denied_count BIGINT NOT NULL,
+	last_evaluated_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (store, object_type, relation)
+);
+
+-- +goose Down
+DROP TABLE relation_usage;
@@ -0,0 +1,219 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/gateway"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storage/mysql"
+	"github.com/openfga/openfga/pkg/storage/postgres"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+)
+
+// cost is the cost of resolving a Check or ListObjects request, as reported by its response headers.
+type cost struct {
+	dispatches       uint64
+	datastoreQueries uint64
+}
+
+// costFromHeaders returns the cost reported by the response headers of a request.
+func costFromHeaders(md metadata.MD) cost {
+	value := func(header string) uint64 {
+		values := md.Get(strings.ToLower(header))
+		if len(values) == 0 {
+			return 0
+		}
+		v, _ := strconv.ParseUint(values[len(values)-1], 10, 64)
+		return v
+	}
+
+	return cost{
+		dispatches:       value(server.DispatchCountHeader),
+		datastoreQueries: value(server.DatastoreQueryCountHeader),
+	}
+}
+
+// backend is the server the requests of the benchmark are sent to.
+type backend interface {
+	CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequest) (*openfgav1.CreateStoreResponse, error)
+	WriteAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, error)
+	Write(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteResponse, error)
+	Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, cost, error)
+	ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (*openfgav1.ListObjectsResponse, cost, error)
+	Close()
+}
+
+var _ backend = (*embeddedBackend)(nil)
+
+// embeddedBackend is an embedded server.
+type embeddedBackend struct {
+	server    *server.Server
+	datastore storage.OpenFGADatastore
+}
+
+func newEmbeddedBackend(engine, uri string) (*embeddedBackend, error) {
+	var datastore storage.OpenFGADatastore
+	var err error
+
+	switch engine {
+	case "memory", "":
+		datastore = memory.New()
+	case "mysql":
+		datastore, err = mysql.New(uri, sqlcommon.NewConfig())
+	case "postgres":
+		datastore, err = postgres.New(uri, sqlcommon.NewConfig())
+	default:
+		return nil, fmt.Errorf("unknown datastore engine type: %s", engine)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the datastore: %w", err)
+	}
+
+	svr, err := server.NewServerWithOpts(
+		server.WithDatastore(datastore),
+		server.WithTransport(gateway.NewRPCTransport(logger.NewNoopLogger())),
+	)
+	if err != nil {
+		datastore.Close()
+		return nil, err
+	}
+
+	return &embeddedBackend{server: svr, datastore: datastore}, nil
+}
+
+func (b *embeddedBackend) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequest) (*openfgav1.CreateStoreResponse, error) {
+	return b.server.CreateStore(ctx, req)
+}
+
+func (b *embeddedBackend) WriteAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, error) {
+	return b.server.WriteAuthorizationModel(ctx, req)
+}
+
+func (b *embeddedBackend) Write(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteResponse, error) {
+	return b.server.Write(ctx, req)
+}
+
+func (b *embeddedBackend) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, cost, error) {
+	headers := &headerRecorder{method: openfgav1.OpenFGAService_Check_FullMethodName, md: metadata.MD{}}
+	res, err := b.server.Check(grpc.NewContextWithServerTransportStream(ctx, headers), req)
+	return res, costFromHeaders(headers.headers()), err
+}
+
+func (b *embeddedBackend) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (*openfgav1.ListObjectsResponse, cost, error) {
+	headers := &headerRecorder{method: openfgav1.OpenFGAService_ListObjects_FullMethodName, md: metadata.MD{}}
+	res, err := b.server.ListObjects(grpc.NewContextWithServerTransportStream(ctx, headers), req)
+	return res, costFromHeaders(headers.headers()), err
+}
+
+func (b *embeddedBackend) Close() {
+	b.server.Close()
+	b.datastore.Close()
+}
+
+// headerRecorder records the response headers of a request of the embedded server, as it isn't
+// served over gRPC.
+type headerRecorder struct {
+	method string
+
+	mu sync.Mutex
+	md metadata.MD
+}
+
+var _ grpc.ServerTransportStream = (*headerRecorder)(nil)
+
+func (h *headerRecorder) Method() string {
+	return h.method
+}
+
+func (h *headerRecorder) SetHeader(md metadata.MD) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.md = metadata.Join(h.md, md)
+	return nil
+}
+
+func (h *headerRecorder) SendHeader(md metadata.MD) error {
+	return h.SetHeader(md)
+}
+
+func (h *headerRecorder) SetTrailer(metadata.MD) error {
+	return nil
+}
+
+func (h *headerRecorder) headers() metadata.MD {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.md.Copy()
+}
+
+var _ backend = (*remoteBackend)(nil)
+
+// remoteBackend is a running server, whose API is served over gRPC.
+type remoteBackend struct {
+	conn   *grpc.ClientConn
+	client openfgav1.OpenFGAServiceClient
+	token  string
+}
+
+func newRemoteBackend(addr, token string) (*remoteBackend, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to '%s': %w", addr, err)
+	}
+
+	return &remoteBackend{
+		conn:   conn,
+		client: openfgav1.NewOpenFGAServiceClient(conn),
+		token:  token,
+	}, nil
+}
+
+// outgoingContext authenticates the request with the token, if any.
+func (b *remoteBackend) outgoingContext(ctx context.Context) context.Context {
+	if b.token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+b.token)
+}
+
+func (b *remoteBackend) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequest) (*openfgav1.CreateStoreResponse, error) {
+	return b.client.CreateStore(b.outgoingContext(ctx), req)
+}
+
+func (b *remoteBackend) WriteAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, error) {
+	return b.client.WriteAuthorizationModel(b.outgoingContext(ctx), req)
+}
+
+func (b *remoteBackend) Write(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteResponse, error) {
+	return b.client.Write(b.outgoingContext(ctx), req)
+}
+
+func (b *remoteBackend) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, cost, error) {
+	var md metadata.MD
+	res, err := b.client.Check(b.outgoingContext(ctx), req, grpc.Header(&md))
+	return res, costFromHeaders(md), err
+}
+
+func (b *remoteBackend) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (*openfgav1.ListObjectsResponse, cost, error) {
+	var md metadata.MD
+	res, err := b.client.ListObjects(b.outgoingContext(ctx), req, grpc.Header(&md))
+	return res, costFromHeaders(md), err
+}
+
+func (b *remoteBackend) Close() {
+	_ = b.conn.Close()
+}
@@ -0,0 +1,153 @@
+// Package bench contains the command to benchmark a server with synthetic stores and workloads.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	apiAddrFlag         = "api-addr"
+	apiTokenFlag        = "api-token"
+	datastoreEngineFlag = "datastore-engine"
+	datastoreURIFlag    = "datastore-uri"
+	typesFlag           = "types"
+	objectsFlag         = "objects"
+	usersFlag           = "users"
+	fanOutFlag          = "fan-out"
+	depthFlag           = "depth"
+	seedFlag            = "seed"
+	durationFlag        = "duration"
+	concurrencyFlag     = "concurrency"
+	mixFlag             = "mix"
+	outputFlag          = "output"
+)
+
+func NewBenchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark a server with a synthetic store and workload",
+		Long: `The bench command generates a synthetic store, and runs a mixed workload of Check, ListObjects and
+Write requests against it for a duration, e.g. for capacity planning. It reports the throughput, the
+latency percentiles and the errors of each kind of request, and the dispatches and datastore queries of
+the Check and ListObjects requests.
+
+The requests are sent to a running server if 'api-addr' is set, or else to an embedded server with the
+'datastore-engine' datastore: an in-memory datastore by default, or a Postgres or MySQL datastore migrated
+with 'openfga migrate'.
+
+The model of the store has 'types' resource types, whose objects are in hierarchies of 'depth' levels of
+parents. The viewers of each object are 'fan-out' users or groups of 'fan-out' users, and a viewer of an
+object is a viewer of its descendants, so that a Check resolves up to 'depth' levels of tuple to usersets
+and their usersets:
+
+  type user
+  type group
+    relations
+      define member: [user]
+  type resource0
+    relations
+      define parent: [resource0]
+      define owner: [user]
+      define viewer: [user, group#member] or owner or viewer from parent
+
+The workload is a mix of 'check' (viewer of a random object and user), 'list-objects' (the objects of a
+random type a random user is a viewer of) and 'write' (a viewer tuple that is deleted by the next write
+of its worker) requests, e.g. 'check=80,list-objects=15,write=5'.`,
+		RunE: runBench,
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+
+	flags.String(apiAddrFlag, "", "(optional) the gRPC address of a running server, e.g. 'localhost:8081'. If empty, the requests are sent to an embedded server")
+	flags.String(apiTokenFlag, "", "(optional) the preshared key sent as a bearer token to the running server")
+	flags.String(datastoreEngineFlag, "memory", "the datastore engine of the embedded server: 'memory', 'postgres' or 'mysql'")
+	flags.String(datastoreURIFlag, "", "the connection uri of the 'postgres' or 'mysql' datastore of the embedded server")
+	flags.Int(typesFlag, 2, "the number of resource types of the model of the store")
+	flags.Int(objectsFlag, 1000, "the number of objects of each resource type")
+	flags.Int(usersFlag, 1000, "the number of users")
+	flags.Int(fanOutFlag, 10, "the number of viewers of each object, and of members of each group")
+	flags.Int(depthFlag, 3, "the number of levels of the hierarchies of parents of the objects")
+	flags.Int64(seedFlag, 1, "the seed of the random generation of the store and of the workload")
+	flags.Duration(durationFlag, 30*time.Second, "how long the workload runs")
+	flags.Int(concurrencyFlag, 10, "the number of concurrent requests of the workload")
+	flags.String(mixFlag, "check=80,list-objects=15,write=5", "the relative weights of the 'check', 'list-objects' and 'write' requests of the workload")
+	flags.String(outputFlag, outputText, "the format of the report: 'text' or 'json'")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runBench(cmd *cobra.Command, _ []string) error {
+	spec := storeSpec{
+		Types:   viper.GetInt(typesFlag),
+		Objects: viper.GetInt(objectsFlag),
+		Users:   viper.GetInt(usersFlag),
+		FanOut:  viper.GetInt(fanOutFlag),
+		Depth:   viper.GetInt(depthFlag),
+		Seed:    viper.GetInt64(seedFlag),
+	}
+	if err := spec.validate(); err != nil {
+		return err
+	}
+
+	mix, err := parseMix(viper.GetString(mixFlag))
+	if err != nil {
+		return err
+	}
+
+	duration, concurrency := viper.GetDuration(durationFlag), viper.GetInt(concurrencyFlag)
+	if duration <= 0 || concurrency <= 0 {
+		return fmt.Errorf("'%s' and '%s' must be positive", durationFlag, concurrencyFlag)
+	}
+
+	output := viper.GetString(outputFlag)
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("unknown output '%s', it must be '%s' or '%s'", output, outputText, outputJSON)
+	}
+
+	var b backend
+	if addr := viper.GetString(apiAddrFlag); addr != "" {
+		b, err = newRemoteBackend(addr, viper.GetString(apiTokenFlag))
+	} else {
+		b, err = newEmbeddedBackend(viper.GetString(datastoreEngineFlag), viper.GetString(datastoreURIFlag))
+	}
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	start := time.Now()
+	store, err := generateStore(ctx, b, spec)
+	if err != nil {
+		return fmt.Errorf("failed to generate the store: %w", err)
+	}
+	setup := time.Since(start)
+
+	w := &workload{
+		backend:     b,
+		store:       store,
+		mix:         mix,
+		concurrency: concurrency,
+		seed:        spec.Seed,
+	}
+	report := w.run(ctx, duration)
+	report.StoreID = store.id
+	report.Tuples = store.tuples
+	report.SetupDuration = setup
+
+	return writeReport(cmd.OutOrStdout(), output, report)
+}
@@ -0,0 +1,135 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	parser "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+func runBenchCommand(t *testing.T, args ...string) string {
+	var out bytes.Buffer
+	cmd := NewBenchCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	require.NoError(t, cmd.Execute())
+
+	return out.String()
+}
+
+var smallStoreArgs = []string{"--types", "2", "--objects", "30", "--users", "20", "--fan-out", "3", "--depth", "3", "--duration", "300ms", "--concurrency", "2"}
+
+func TestBenchCommand(t *testing.T) {
+	out := runBenchCommand(t, append(smallStoreArgs, "--output", "json")...)
+
+	var r report
+	require.NoError(t, json.Unmarshal([]byte(out), &r))
+
+	// 3 members of each of the 6 groups, and for each object an owner or a parent and 3 viewers
+	require.Equal(t, 6*3+2*30*4, r.Tuples)
+	require.Equal(t, 2, r.Concurrency)
+	require.Len(t, r.Operations, 3)
+
+	for _, op := range r.Operations {
+		require.Positive(t, op.Requests, op.Operation)
+		require.Zero(t, op.Errors, op.Operation)
+		require.Positive(t, op.Latency.Max, op.Operation)
+		require.LessOrEqual(t, op.Latency.P50, op.Latency.P99, op.Operation)
+
+		if op.Operation == operationWrite {
+			require.Nil(t, op.Dispatches)
+			continue
+		}
+		require.Positive(t, op.DatastoreQueries.Max, op.Operation)
+	}
+
+	check := r.Operations[0]
+	require.Equal(t, operationCheck, check.Operation)
+	require.Positive(t, check.Dispatches.Max)
+
+	out = runBenchCommand(t, append(smallStoreArgs, "--mix", "check=1")...)
+	require.Contains(t, out, "tuples written in")
+	require.Regexp(t, `check +\d+ +0 `, out)
+	require.NotContains(t, out, "list-objects")
+}
+
+func TestBenchCommandWithRunningServer(t *testing.T) {
+	datastore := memory.New()
+	t.Cleanup(datastore.Close)
+
+	svr := server.MustNewServerWithOpts(server.WithDatastore(datastore))
+	t.Cleanup(svr.Close)
+
+	grpcServer := grpc.NewServer()
+	openfgav1.RegisterOpenFGAServiceServer(grpcServer, svr)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	out := runBenchCommand(t, append(smallStoreArgs, "--api-addr", lis.Addr().String(), "--mix", "check=1,list-objects=1", "--output", "json")...)
+
+	var r report
+	require.NoError(t, json.Unmarshal([]byte(out), &r))
+	require.Len(t, r.Operations, 2)
+	for _, op := range r.Operations {
+		require.Positive(t, op.Requests, op.Operation)
+		require.Zero(t, op.Errors, op.Operation)
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := parseMix("check=80, list-objects=15,write=5")
+	require.NoError(t, err)
+	require.Equal(t, map[operation]int{operationCheck: 80, operationListObjects: 15, operationWrite: 5}, mix)
+
+	for _, value := range []string{"check", "read=1", "check=-1", "check=x", "check=0"} {
+		_, err := parseMix(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestStoreSpec(t *testing.T) {
+	spec := storeSpec{Types: 3, Objects: 7, Users: 10, FanOut: 4, Depth: 2, Seed: 1}
+
+	_, err := parser.TransformDSLToProto(spec.model())
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	var first []*openfgav1.TupleKey
+	require.NoError(t, spec.tuples(func(tk *openfgav1.TupleKey) error {
+		counts[tk.GetRelation()]++
+		first = append(first, tk)
+		return nil
+	}))
+
+	require.Equal(t, map[string]int{"member": 2 * 4, "owner": 3 * 4, "parent": 3 * 3, "viewer": 3 * 7 * 4}, counts)
+
+	// the generation is deterministic
+	var second []*openfgav1.TupleKey
+	require.NoError(t, spec.tuples(func(tk *openfgav1.TupleKey) error {
+		second = append(second, tk)
+		return nil
+	}))
+	require.Equal(t, first, second)
+
+	require.Error(t, storeSpec{Types: 1, Objects: 1, Users: 1, FanOut: 1}.validate())
+}
+
+func TestPercentile(t *testing.T) {
+	values := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	require.Equal(t, 5, percentile(values, 50))
+	require.Equal(t, 10, percentile(values, 99))
+	require.Equal(t, 1, percentile(values, 0))
+}
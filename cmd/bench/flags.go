@@ -0,0 +1,56 @@
+package bench
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/openfga/openfga/cmd/util"
+)
+
+// bindRunFlagsFunc binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(apiAddrFlag, flags.Lookup(apiAddrFlag))
+		util.MustBindEnv(apiAddrFlag, "OPENFGA_API_ADDR")
+
+		util.MustBindPFlag(apiTokenFlag, flags.Lookup(apiTokenFlag))
+		util.MustBindEnv(apiTokenFlag, "OPENFGA_API_TOKEN")
+
+		util.MustBindPFlag(datastoreEngineFlag, flags.Lookup(datastoreEngineFlag))
+		util.MustBindEnv(datastoreEngineFlag, "OPENFGA_DATASTORE_ENGINE")
+
+		util.MustBindPFlag(datastoreURIFlag, flags.Lookup(datastoreURIFlag))
+		util.MustBindEnv(datastoreURIFlag, "OPENFGA_DATASTORE_URI")
+
+		util.MustBindPFlag(typesFlag, flags.Lookup(typesFlag))
+		util.MustBindEnv(typesFlag, "OPENFGA_BENCH_TYPES")
+
+		util.MustBindPFlag(objectsFlag, flags.Lookup(objectsFlag))
+		util.MustBindEnv(objectsFlag, "OPENFGA_BENCH_OBJECTS")
+
+		util.MustBindPFlag(usersFlag, flags.Lookup(usersFlag))
+		util.MustBindEnv(usersFlag, "OPENFGA_BENCH_USERS")
+
+		util.MustBindPFlag(fanOutFlag, flags.Lookup(fanOutFlag))
+		util.MustBindEnv(fanOutFlag, "OPENFGA_BENCH_FAN_OUT")
+
+		util.MustBindPFlag(depthFlag, flags.Lookup(depthFlag))
+		util.MustBindEnv(depthFlag, "OPENFGA_BENCH_DEPTH")
+
+		util.MustBindPFlag(seedFlag, flags.Lookup(seedFlag))
+		util.MustBindEnv(seedFlag, "OPENFGA_BENCH_SEED")
+
+		util.MustBindPFlag(durationFlag, flags.Lookup(durationFlag))
+		util.MustBindEnv(durationFlag, "OPENFGA_BENCH_DURATION")
+
+		util.MustBindPFlag(concurrencyFlag, flags.Lookup(concurrencyFlag))
+		util.MustBindEnv(concurrencyFlag, "OPENFGA_BENCH_CONCURRENCY")
+
+		util.MustBindPFlag(mixFlag, flags.Lookup(mixFlag))
+		util.MustBindEnv(mixFlag, "OPENFGA_BENCH_MIX")
+
+		util.MustBindPFlag(outputFlag, flags.Lookup(outputFlag))
+		util.MustBindEnv(outputFlag, "OPENFGA_BENCH_OUTPUT")
+	}
+}
@@ -0,0 +1,204 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	parser "github.com/openfga/language/pkg/go/transformer"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// writeBatchSize is the number of tuples of each Write request that populates the store, the default
+// maximum number of tuples per Write of the servers.
+const writeBatchSize = 100
+
+// storeSpec is the shape of the synthetic store of the benchmark.
+type storeSpec struct {
+	// Types is the number of resource types.
+	Types int
+
+	// Objects is the number of objects of each resource type.
+	Objects int
+
+	// Users is the number of users.
+	Users int
+
+	// FanOut is the number of viewers of each object, and of members of each group.
+	FanOut int
+
+	// Depth is the number of levels of the hierarchies of parents of the objects.
+	Depth int
+
+	// Seed is the seed of the random viewers and members.
+	Seed int64
+}
+
+func (s storeSpec) validate() error {
+	if s.Types <= 0 || s.Objects <= 0 || s.Users <= 0 || s.FanOut <= 0 || s.Depth <= 0 {
+		return fmt.Errorf("'%s', '%s', '%s', '%s' and '%s' must be positive", typesFlag, objectsFlag, usersFlag, fanOutFlag, depthFlag)
+	}
+	return nil
+}
+
+// groups returns the number of groups, so that each user is a member of about one group.
+func (s storeSpec) groups() int {
+	return max(1, s.Users/s.FanOut)
+}
+
+func resourceType(i int) string {
+	return fmt.Sprintf("resource%d", i)
+}
+
+func object(objectType string, i int) string {
+	return fmt.Sprintf("%s:%d", objectType, i)
+}
+
+func user(i int) string {
+	return fmt.Sprintf("user:%d", i)
+}
+
+// model returns the DSL of the model of the store.
+func (s storeSpec) model() string {
+	var b strings.Builder
+	b.WriteString("model\n  schema 1.1\ntype user\ntype group\n  relations\n    define member: [user]\n")
+
+	for i := 0; i < s.Types; i++ {
+		name := resourceType(i)
+		fmt.Fprintf(&b, "type %s\n  relations\n", name)
+		fmt.Fprintf(&b, "    define parent: [%s]\n", name)
+		b.WriteString("    define owner: [user]\n")
+		b.WriteString("    define viewer: [user, group#member] or owner or viewer from parent\n")
+	}
+
+	return b.String()
+}
+
+// tuples calls fn with the tuples of the store: the members of the groups, and the parent, the owner
+// and the viewers of each object. The objects of each type are in hierarchies of Depth levels, the
+// parent of the object i being the object i-1 unless i is a multiple of Depth.
+func (s storeSpec) tuples(fn func(tk *openfgav1.TupleKey) error) error {
+	random := rand.New(rand.NewSource(s.Seed))
+	groups := s.groups()
+
+	for g := 0; g < groups; g++ {
+		members := distinct(min(s.FanOut, s.Users), func() string {
+			return user(random.Intn(s.Users))
+		})
+		for _, member := range members {
+			if err := fn(tuple.NewTupleKey(object("group", g), "member", member)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for t := 0; t < s.Types; t++ {
+		objectType := resourceType(t)
+		for i := 0; i < s.Objects; i++ {
+			obj := object(objectType, i)
+
+			if i%s.Depth == 0 {
+				if err := fn(tuple.NewTupleKey(obj, "owner", user(random.Intn(s.Users)))); err != nil {
+					return err
+				}
+			} else if err := fn(tuple.NewTupleKey(obj, "parent", object(objectType, i-1))); err != nil {
+				return err
+			}
+
+			viewers := distinct(min(s.FanOut, s.Users+groups), func() string {
+				if random.Intn(2) == 0 {
+					return user(random.Intn(s.Users))
+				}
+				return object("group", random.Intn(groups)) + "#member"
+			})
+			for _, viewer := range viewers {
+				if err := fn(tuple.NewTupleKey(obj, "viewer", viewer)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// distinct returns the first n distinct values of next, in their order.
+func distinct(n int, next func() string) []string {
+	values := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	for len(values) < n {
+		value := next()
+		if _, ok := seen[value]; !ok {
+			seen[value] = struct{}{}
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// store is a generated store.
+type store struct {
+	id      string
+	modelID string
+	spec    storeSpec
+	tuples  int
+}
+
+// generateStore creates a store with the model and the tuples of the spec.
+func generateStore(ctx context.Context, b backend, spec storeSpec) (*store, error) {
+	model, err := parser.TransformDSLToProto(spec.model())
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := b.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "bench"})
+	if err != nil {
+		return nil, err
+	}
+
+	written, err := b.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         created.GetId(),
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &store{id: created.GetId(), modelID: written.GetAuthorizationModelId(), spec: spec}
+
+	batch := make([]*openfgav1.TupleKey, 0, writeBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := b.Write(ctx, &openfgav1.WriteRequest{
+			StoreId:              s.id,
+			AuthorizationModelId: s.modelID,
+			Writes:               &openfgav1.WriteRequestWrites{TupleKeys: batch},
+		})
+		s.tuples += len(batch)
+		batch = make([]*openfgav1.TupleKey, 0, writeBatchSize)
+		return err
+	}
+
+	err = spec.tuples(func(tk *openfgav1.TupleKey) error {
+		batch = append(batch, tk)
+		if len(batch) < writeBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,58 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+func writeReport(w io.Writer, output string, r *report) error {
+	if output == outputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+
+	return writeText(w, r)
+}
+
+func writeText(w io.Writer, r *report) error {
+	fmt.Fprintf(w, "store %s: %d tuples written in %s\n", r.StoreID, r.Tuples, r.SetupDuration.Round(time.Millisecond))
+	fmt.Fprintf(w, "workload: %s with %d concurrent requests\n\n", r.Duration.Round(time.Millisecond), r.Concurrency)
+
+	fmt.Fprintf(w, "%-13s %9s %7s %9s %9s %9s %9s %9s %9s %9s\n",
+		"operation", "requests", "errors", "req/s", "mean(ms)", "p50(ms)", "p90(ms)", "p95(ms)", "p99(ms)", "max(ms)")
+	for _, op := range r.Operations {
+		fmt.Fprintf(w, "%-13s %9d %7d %9.1f %9.2f %9.2f %9.2f %9.2f %9.2f %9.2f\n",
+			op.Operation, op.Requests, op.Errors, op.Throughput,
+			op.Latency.Mean, op.Latency.P50, op.Latency.P90, op.Latency.P95, op.Latency.P99, op.Latency.Max)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-13s %16s %16s %9s   (mean/p99/max)\n", "operation", "dispatches", "datastore reads", "allowed")
+	for _, op := range r.Operations {
+		if op.Dispatches == nil {
+			continue
+		}
+
+		allowed := "-"
+		if op.Operation == operationCheck {
+			allowed = fmt.Sprintf("%.1f%%", op.AllowedRatio*100)
+		}
+
+		fmt.Fprintf(w, "%-13s %16s %16s %9s\n", op.Operation, formatCost(op.Dispatches), formatCost(op.DatastoreQueries), allowed)
+	}
+
+	return nil
+}
+
+// formatCost formats the mean, the 99th percentile and the maximum of a cost.
+func formatCost(c *costReport) string {
+	return fmt.Sprintf("%.1f/%d/%d", c.Mean, c.P99, c.Max)
+}
@@ -0,0 +1,338 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// operation is a kind of request of the workload.
+type operation string
+
+const (
+	operationCheck       operation = "check"
+	operationListObjects operation = "list-objects"
+	operationWrite       operation = "write"
+)
+
+// operations are the operations of the workload, in the order of the reports.
+var operations = []operation{operationCheck, operationListObjects, operationWrite}
+
+// parseMix parses the relative weights of the operations of the workload, e.g.
+// 'check=80,list-objects=15,write=5'. The operations that aren't listed aren't run.
+func parseMix(value string) (map[operation]int, error) {
+	mix := map[operation]int{}
+	total := 0
+
+	for _, entry := range strings.Split(value, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry '%s', it must be of the form 'operation=weight'", entry)
+		}
+
+		op := operation(name)
+		switch op {
+		case operationCheck, operationListObjects, operationWrite:
+		default:
+			return nil, fmt.Errorf("unknown mix operation '%s', it must be '%s', '%s' or '%s'", name, operationCheck, operationListObjects, operationWrite)
+		}
+
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight '%s' of the mix operation '%s'", weight, name)
+		}
+
+		mix[op] = w
+		total += w
+	}
+
+	if total == 0 {
+		return nil, fmt.Errorf("the mix must have a positive weight")
+	}
+
+	return mix, nil
+}
+
+// sample is the outcome of a request of the workload.
+type sample struct {
+	latency time.Duration
+	cost    cost
+	failed  bool
+	allowed bool
+}
+
+// recorder records the samples of the requests of a worker, by operation.
+type recorder map[operation][]sample
+
+// workload runs the requests of the mix against a generated store.
+type workload struct {
+	backend     backend
+	store       *store
+	mix         map[operation]int
+	concurrency int
+	seed        int64
+}
+
+// run runs the workload with concurrency workers for the duration, and returns its report.
+func (w *workload) run(ctx context.Context, duration time.Duration) *report {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	recorders := make([]recorder, w.concurrency)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorders[i] = w.work(ctx, i)
+		}()
+	}
+	wg.Wait()
+
+	return newReport(time.Since(start), w.concurrency, recorders)
+}
+
+// work runs random requests of the mix until the context is done.
+func (w *workload) work(ctx context.Context, worker int) recorder {
+	random := rand.New(rand.NewSource(w.seed + int64(worker) + 1))
+	rec := recorder{}
+
+	// the weights of the operations, in their order
+	var weights []int
+	total := 0
+	for _, op := range operations {
+		weights = append(weights, w.mix[op])
+		total += w.mix[op]
+	}
+
+	// the viewer tuple of the last write of the worker, which is deleted by its next write
+	var written *openfgav1.TupleKey
+
+	for n := 0; ctx.Err() == nil; n++ {
+		pick := random.Intn(total)
+		op := operations[len(operations)-1]
+		for i, weight := range weights {
+			if pick < weight {
+				op = operations[i]
+				break
+			}
+			pick -= weight
+		}
+
+		var s sample
+		start := time.Now()
+		switch op {
+		case operationCheck:
+			s = w.check(ctx, random)
+		case operationListObjects:
+			s = w.listObjects(ctx, random)
+		case operationWrite:
+			tk := tuple.NewTupleKey(w.randomObject(random), "viewer", fmt.Sprintf("user:bench-%d-%d", worker, n))
+			s = w.write(ctx, tk, written)
+			if !s.failed {
+				written = tk
+			}
+		}
+		s.latency = time.Since(start)
+
+		// the requests interrupted by the end of the workload aren't recorded
+		if s.failed && ctx.Err() != nil {
+			break
+		}
+		rec[op] = append(rec[op], s)
+	}
+
+	return rec
+}
+
+func (w *workload) randomObject(random *rand.Rand) string {
+	return object(resourceType(random.Intn(w.store.spec.Types)), random.Intn(w.store.spec.Objects))
+}
+
+func (w *workload) check(ctx context.Context, random *rand.Rand) sample {
+	res, c, err := w.backend.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:              w.store.id,
+		AuthorizationModelId: w.store.modelID,
+		TupleKey:             tuple.NewCheckRequestTupleKey(w.randomObject(random), "viewer", user(random.Intn(w.store.spec.Users))),
+	})
+	return sample{cost: c, failed: err != nil, allowed: res.GetAllowed()}
+}
+
+func (w *workload) listObjects(ctx context.Context, random *rand.Rand) sample {
+	_, c, err := w.backend.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+		StoreId:              w.store.id,
+		AuthorizationModelId: w.store.modelID,
+		Type:                 resourceType(random.Intn(w.store.spec.Types)),
+		Relation:             "viewer",
+		User:                 user(random.Intn(w.store.spec.Users)),
+	})
+	return sample{cost: c, failed: err != nil}
+}
+
+// write writes the tuple, and deletes the previous tuple of the worker, if any.
+func (w *workload) write(ctx context.Context, tk, previous *openfgav1.TupleKey) sample {
+	req := &openfgav1.WriteRequest{
+		StoreId:              w.store.id,
+		AuthorizationModelId: w.store.modelID,
+		Writes:               &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tk}},
+	}
+	if previous != nil {
+		req.Deletes = &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(previous)}}
+	}
+
+	_, err := w.backend.Write(ctx, req)
+	return sample{failed: err != nil}
+}
+
+// operationReport is the report of the requests of an operation.
+type operationReport struct {
+	Operation  operation `json:"operation"`
+	Requests   int       `json:"requests"`
+	Errors     int       `json:"errors"`
+	Throughput float64   `json:"throughput_per_second"`
+
+	// AllowedRatio is the ratio of the successful Check requests that were allowed.
+	AllowedRatio float64 `json:"allowed_ratio,omitempty"`
+
+	Latency latencyReport `json:"latency_ms"`
+
+	// Dispatches and DatastoreQueries are the costs of the Check and ListObjects requests.
+	Dispatches       *costReport `json:"dispatches,omitempty"`
+	DatastoreQueries *costReport `json:"datastore_queries,omitempty"`
+}
+
+// latencyReport are the percentiles of the latencies of the requests of an operation, in milliseconds.
+type latencyReport struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// costReport is the mean, the 99th percentile and the maximum of a cost of the successful requests of
+// an operation.
+type costReport struct {
+	Mean float64 `json:"mean"`
+	P99  uint64  `json:"p99"`
+	Max  uint64  `json:"max"`
+}
+
+// report is the report of a benchmark.
+type report struct {
+	StoreID       string            `json:"store_id"`
+	Tuples        int               `json:"tuples"`
+	SetupDuration time.Duration     `json:"setup_duration_ns"`
+	Duration      time.Duration     `json:"duration_ns"`
+	Concurrency   int               `json:"concurrency"`
+	Operations    []operationReport `json:"operations"`
+}
+
+func newReport(duration time.Duration, concurrency int, recorders []recorder) *report {
+	r := &report{Duration: duration, Concurrency: concurrency}
+
+	for _, op := range operations {
+		var samples []sample
+		for _, rec := range recorders {
+			samples = append(samples, rec[op]...)
+		}
+		if len(samples) == 0 {
+			continue
+		}
+
+		opReport := operationReport{
+			Operation:  op,
+			Requests:   len(samples),
+			Throughput: float64(len(samples)) / duration.Seconds(),
+		}
+
+		latencies := make([]time.Duration, 0, len(samples))
+		var dispatches, datastoreQueries []uint64
+		allowed := 0
+		for _, s := range samples {
+			latencies = append(latencies, s.latency)
+			if s.failed {
+				opReport.Errors++
+				continue
+			}
+			if s.allowed {
+				allowed++
+			}
+			dispatches = append(dispatches, s.cost.dispatches)
+			datastoreQueries = append(datastoreQueries, s.cost.datastoreQueries)
+		}
+
+		opReport.Latency = newLatencyReport(latencies)
+		if op != operationWrite {
+			opReport.Dispatches = newCostReport(dispatches)
+			opReport.DatastoreQueries = newCostReport(datastoreQueries)
+		}
+		if op == operationCheck && len(dispatches) > 0 {
+			opReport.AllowedRatio = float64(allowed) / float64(len(dispatches))
+		}
+
+		r.Operations = append(r.Operations, opReport)
+	}
+
+	return r
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func newLatencyReport(latencies []time.Duration) latencyReport {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+
+	return latencyReport{
+		Mean: milliseconds(total / time.Duration(len(latencies))),
+		P50:  milliseconds(percentile(latencies, 50)),
+		P90:  milliseconds(percentile(latencies, 90)),
+		P95:  milliseconds(percentile(latencies, 95)),
+		P99:  milliseconds(percentile(latencies, 99)),
+		Max:  milliseconds(latencies[len(latencies)-1]),
+	}
+}
+
+func newCostReport(costs []uint64) *costReport {
+	if len(costs) == 0 {
+		return nil
+	}
+
+	sort.Slice(costs, func(i, j int) bool { return costs[i] < costs[j] })
+
+	var total uint64
+	for _, c := range costs {
+		total += c
+	}
+
+	return &costReport{
+		Mean: float64(total) / float64(len(costs)),
+		P99:  percentile(costs, 99),
+		Max:  costs[len(costs)-1],
+	}
+}
+
+// percentile returns the p-th percentile of the sorted values, with the nearest-rank method.
+func percentile[T any](sorted []T, p int) T {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
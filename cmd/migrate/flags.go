@@ -30,5 +30,14 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 
 		util.MustBindPFlag(verboseMigrationFlag, flags.Lookup(verboseMigrationFlag))
 		util.MustBindEnv(verboseMigrationFlag, "OPENFGA_VERBOSE")
+
+		util.MustBindPFlag(phaseFlag, flags.Lookup(phaseFlag))
+		util.MustBindEnv(phaseFlag, "OPENFGA_MIGRATION_PHASE")
+
+		util.MustBindPFlag(postgresTuplePartitioningFlag, flags.Lookup(postgresTuplePartitioningFlag))
+		util.MustBindEnv(postgresTuplePartitioningFlag, "OPENFGA_POSTGRES_TUPLE_PARTITIONING")
+
+		util.MustBindPFlag(postgresTuplePartitionsFlag, flags.Lookup(postgresTuplePartitionsFlag))
+		util.MustBindEnv(postgresTuplePartitionsFlag, "OPENFGA_POSTGRES_TUPLE_PARTITIONS")
 	}
 }
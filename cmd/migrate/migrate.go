@@ -3,6 +3,7 @@ package migrate
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"net/url"
@@ -16,6 +17,8 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/openfga/openfga/assets"
+	"github.com/openfga/openfga/internal/migrations"
+	"github.com/openfga/openfga/pkg/storage/postgres"
 )
 
 const (
@@ -26,15 +29,29 @@ const (
 	versionFlag           = "version"
 	timeoutFlag           = "timeout"
 	verboseMigrationFlag  = "verbose"
+	phaseFlag             = "phase"
+
+	postgresTuplePartitioningFlag = "postgres-tuple-partitioning"
+	postgresTuplePartitionsFlag   = "postgres-tuple-partitions"
 )
 
 func NewMigrateCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "migrate",
 		Short: "Run database schema migrations needed for the OpenFGA server",
-		Long:  `The migrate command is used to migrate the database schema needed for OpenFGA.`,
-		RunE:  runMigration,
-		Args:  cobra.NoArgs,
+		Long: `The migrate command is used to migrate the database schema needed for OpenFGA.
+
+For zero-downtime upgrades, run the migrations in two phases: run with '--phase=expand' before
+rolling out the new server version, which only applies the additive migrations that older servers
+can run against, and run with '--phase=contract' once no older servers are running, which applies
+the remaining destructive migrations.
+
+With the postgres engine, '--postgres-tuple-partitioning' partitions the tuple table by the hash of
+the store or of the object type, to keep the sizes of its indexes manageable with billions of tuples.
+The tuples are copied into the partitioned table in batches while the servers keep using the tuple
+table, which is only locked while the tables are swapped.`,
+		RunE: runMigration,
+		Args: cobra.NoArgs,
 	}
 
 	flags := cmd.Flags()
@@ -46,6 +63,9 @@ func NewMigrateCommand() *cobra.Command {
 	flags.Uint(versionFlag, 0, "the version to migrate to (if omitted the latest schema will be used)")
 	flags.Duration(timeoutFlag, 1*time.Minute, "a timeout for the time it takes the migrate process to connect to the database")
 	flags.Bool(verboseMigrationFlag, false, "enable verbose migration logs (default false)")
+	flags.String(phaseFlag, "", "the migration phase to run: 'expand' only applies the migrations that are safe to apply while older servers are running, 'contract' applies all the migrations (if omitted all the migrations are applied)")
+	flags.String(postgresTuplePartitioningFlag, "", "the partitioning of the tuple table of the postgres engine: 'store' or 'object_type' partitions it by the hash of the store or of the object type, and 'none' makes it unpartitioned again (if omitted the partitioning is unchanged)")
+	flags.Int(postgresTuplePartitionsFlag, 16, "the number of partitions of the tuple table of the postgres engine")
 
 	// NOTE: if you add a new flag here, update the function below, too
 
@@ -62,6 +82,26 @@ func runMigration(_ *cobra.Command, _ []string) error {
 	verbose := viper.GetBool(verboseMigrationFlag)
 	username := viper.GetString(datastoreUsernameFlag)
 	password := viper.GetString(datastorePasswordFlag)
+	phase := viper.GetString(phaseFlag)
+	partitioning := viper.GetString(postgresTuplePartitioningFlag)
+	tuplePartitions := viper.GetInt(postgresTuplePartitionsFlag)
+
+	if phase != "" && phase != migrations.PhaseExpand && phase != migrations.PhaseContract {
+		return fmt.Errorf("unknown migration phase: %s", phase)
+	}
+
+	var tuplePartitioning postgres.TuplePartitioning
+	if partitioning != "" {
+		if engine != "postgres" {
+			return fmt.Errorf("'--%s' requires the postgres engine", postgresTuplePartitioningFlag)
+		}
+
+		var err error
+		tuplePartitioning, err = postgres.ParseTuplePartitioning(partitioning)
+		if err != nil {
+			return err
+		}
+	}
 
 	goose.SetLogger(goose.NopLogger())
 	goose.SetVerbose(verbose)
@@ -137,17 +177,21 @@ func runMigration(_ *cobra.Command, _ []string) error {
 
 	log.Printf("current version %d", currentVersion)
 
-	if targetVersion == 0 {
-		log.Println("running all migrations")
-		if err := goose.Up(db, migrationsPath); err != nil {
-			return fmt.Errorf("failed to run migrations: %w", err)
-		}
-		log.Println("migration done")
-		return nil
+	files, err := migrations.Collect(assets.EmbedMigrations, migrationsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
 	}
 
-	log.Printf("migrating to %d", targetVersion)
-	targetInt64Version := int64(targetVersion)
+	targetInt64Version, err := migrations.Plan(files, currentVersion, int64(targetVersion), phase)
+	if err != nil {
+		return err
+	}
+
+	if latest, _ := migrations.Plan(files, currentVersion, int64(targetVersion), migrations.PhaseContract); latest > targetInt64Version {
+		log.Printf("expand phase: migrating to %d, run with '--phase=%s' to apply the remaining migrations once no older servers are running", targetInt64Version, migrations.PhaseContract)
+	}
+
+	log.Printf("migrating to %d", targetInt64Version)
 
 	switch {
 	case targetInt64Version < currentVersion:
@@ -160,9 +204,26 @@ func runMigration(_ *cobra.Command, _ []string) error {
 		}
 	default:
 		log.Println("nothing to do")
-		return nil
+	}
+
+	if tuplePartitioning != "" {
+		if err := partitionTupleTable(db, tuplePartitioning, tuplePartitions); err != nil {
+			return err
+		}
 	}
 
 	log.Println("migration done")
 	return nil
 }
+
+// partitionTupleTable partitions the tuple table of the postgres engine, if it isn't partitioned this
+// way yet.
+func partitionTupleTable(db *sql.DB, partitioning postgres.TuplePartitioning, partitions int) error {
+	log.Printf("partitioning the tuple table: %s", partitioning)
+
+	if err := postgres.PartitionTupleTable(context.Background(), db, partitioning, partitions); err != nil {
+		return fmt.Errorf("failed to partition the tuple table: %w", err)
+	}
+
+	return nil
+}
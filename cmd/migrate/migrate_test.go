@@ -49,6 +49,9 @@ func TestMigrateCommandNoConfigDefaultValues(t *testing.T) {
 		require.Equal(t, uint(0), viper.GetUint(versionFlag))
 		require.Equal(t, defaultDuration, viper.GetDuration(timeoutFlag))
 		require.False(t, viper.GetBool(verboseMigrationFlag))
+		require.Equal(t, "", viper.GetString(phaseFlag))
+		require.Equal(t, "", viper.GetString(postgresTuplePartitioningFlag))
+		require.Equal(t, 16, viper.GetInt(postgresTuplePartitionsFlag))
 		return nil
 	}
 
@@ -105,3 +108,29 @@ func TestMigrateCommandConfigIsMerged(t *testing.T) {
 	cmd.SetArgs([]string{"migrate"})
 	require.NoError(t, cmd.Execute())
 }
+
+func TestMigrateCommandTuplePartitioningIsValidated(t *testing.T) {
+	tests := map[string]struct {
+		args        []string
+		expectedErr string
+	}{
+		"other_engine": {
+			args:        []string{"--datastore-engine", "mysql", "--postgres-tuple-partitioning", "store"},
+			expectedErr: "requires the postgres engine",
+		},
+		"unknown_partitioning": {
+			args:        []string{"--datastore-engine", "postgres", "--postgres-tuple-partitioning", "relation"},
+			expectedErr: "unknown tuple partitioning 'relation'",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			util.PrepareTempConfigDir(t)
+
+			migrateCommand := NewMigrateCommand()
+			migrateCommand.SetArgs(test.args)
+			require.ErrorContains(t, migrateCommand.Execute(), test.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package migratedata
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/openfga/openfga/cmd/util"
+)
+
+// bindRunFlagsFunc binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(sourceEngineFlag, flags.Lookup(sourceEngineFlag))
+		util.MustBindEnv(sourceEngineFlag, "OPENFGA_SOURCE_ENGINE")
+
+		util.MustBindPFlag(sourceURIFlag, flags.Lookup(sourceURIFlag))
+		util.MustBindEnv(sourceURIFlag, "OPENFGA_SOURCE_URI")
+
+		util.MustBindPFlag(targetEngineFlag, flags.Lookup(targetEngineFlag))
+		util.MustBindEnv(targetEngineFlag, "OPENFGA_TARGET_ENGINE")
+
+		util.MustBindPFlag(targetURIFlag, flags.Lookup(targetURIFlag))
+		util.MustBindEnv(targetURIFlag, "OPENFGA_TARGET_URI")
+
+		util.MustBindPFlag(progressFileFlag, flags.Lookup(progressFileFlag))
+		util.MustBindEnv(progressFileFlag, "OPENFGA_PROGRESS_FILE")
+	}
+}
@@ -0,0 +1,431 @@
+// Package migratedata contains the command to copy the data of an OpenFGA server between datastores.
+package migratedata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storage/mysql"
+	"github.com/openfga/openfga/pkg/storage/postgres"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const (
+	sourceEngineFlag = "source-engine"
+	sourceURIFlag    = "source-uri"
+	targetEngineFlag = "target-engine"
+	targetURIFlag    = "target-uri"
+	progressFileFlag = "progress-file"
+)
+
+func NewMigrateDataCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate-data",
+		Short: "Copy the stores, authorization models, assertions and tuples between datastores",
+		Long: `The migrate-data command copies the stores, authorization models, assertions and tuples of an OpenFGA
+server from a source datastore to a target datastore (e.g. from 'postgres' to 'mysql'). The target datastore
+must be migrated with 'openfga migrate' first.
+
+The tuples are copied by replaying the changelog of each store, so the changelog is copied too, although
+the changes get new timestamps. Only the changes made before the command started are copied, so that each
+store is copied as of the same point in time. For the 'memory' engine, the uri is the path of a snapshot file
+of the memory datastore (see 'datastore-memory-snapshot-path').
+
+If a progress file is set, the command records its progress in it and resumes from it when it is run again.`,
+		RunE: runMigrateData,
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+
+	flags.String(sourceEngineFlag, "", "(required) the datastore engine to copy the data from")
+	flags.String(sourceURIFlag, "", "(required) the connection uri of the datastore to copy the data from")
+	flags.String(targetEngineFlag, "", "(required) the datastore engine to copy the data to")
+	flags.String(targetURIFlag, "", "(required) the connection uri of the datastore to copy the data to")
+	flags.String(progressFileFlag, "", "(optional) the path of the file that the progress is recorded in, to resume an interrupted copy")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runMigrateData(cmd *cobra.Command, _ []string) error {
+	source, err := openDatastore(viper.GetString(sourceEngineFlag), viper.GetString(sourceURIFlag))
+	if err != nil {
+		return fmt.Errorf("failed to open the source datastore: %w", err)
+	}
+	defer source.Close()
+
+	target, err := openDatastore(viper.GetString(targetEngineFlag), viper.GetString(targetURIFlag))
+	if err != nil {
+		return fmt.Errorf("failed to open the target datastore: %w", err)
+	}
+	defer target.Close()
+
+	progress, err := loadProgress(viper.GetString(progressFileFlag))
+	if err != nil {
+		return fmt.Errorf("failed to load the progress file: %w", err)
+	}
+
+	return MigrateData(cmd.Context(), source, target, progress)
+}
+
+func openDatastore(engine, uri string) (storage.OpenFGADatastore, error) {
+	switch engine {
+	case "memory":
+		if uri == "" {
+			return nil, fmt.Errorf("the uri of the 'memory' engine must be the path of a snapshot file")
+		}
+		return memory.NewFromSnapshot(uri)
+	case "mysql":
+		return mysql.New(uri, sqlcommon.NewConfig())
+	case "postgres":
+		return postgres.New(uri, sqlcommon.NewConfig())
+	case "":
+		return nil, fmt.Errorf("missing datastore engine type")
+	default:
+		return nil, fmt.Errorf("unknown datastore engine type: %s", engine)
+	}
+}
+
+// Progress records how far a copy between datastores went, so that it can be resumed.
+type Progress struct {
+	// Cutoff is the time that the copy started at. Changes made after it are not copied.
+	Cutoff time.Time `json:"cutoff"`
+
+	// Stores is the progress of each store, by store ID.
+	Stores map[string]*StoreProgress `json:"stores"`
+
+	path string
+}
+
+// StoreProgress records how far the changelog of a store was replayed.
+type StoreProgress struct {
+	// From is the continuation token of the page of changes being replayed.
+	From string `json:"from,omitempty"`
+
+	// Applied is the number of changes of the page that were already replayed.
+	Applied int `json:"applied,omitempty"`
+
+	// Copied is the total number of changes that were replayed.
+	Copied int `json:"copied"`
+
+	// Done is true once all the changes before the cutoff were replayed.
+	Done bool `json:"done"`
+}
+
+// NewProgress returns the progress of a new copy, starting now.
+func NewProgress() *Progress {
+	return &Progress{Cutoff: time.Now().UTC(), Stores: map[string]*StoreProgress{}}
+}
+
+// loadProgress loads the progress from the file at path. If path is empty, the progress isn't persisted.
+// If the file doesn't exist, a new copy is started.
+func loadProgress(path string) (*Progress, error) {
+	progress := NewProgress()
+	progress.path = path
+
+	if path == "" {
+		return progress, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return progress, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, err
+	}
+
+	log.Printf("resuming the copy started at %s", progress.Cutoff.Format(time.RFC3339))
+
+	return progress, nil
+}
+
+// save persists the progress, if a progress file is set.
+func (p *Progress) save() error {
+	if p.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Clean(p.path))
+}
+
+// MigrateData copies the stores, store labels, store settings, authorization models, assertions and tuples from the source
+// datastore to the target datastore, resuming from the given progress.
+func MigrateData(ctx context.Context, source, target storage.OpenFGADatastore, progress *Progress) error {
+	var from string
+	for {
+		stores, token, err := source.ListStores(ctx, storage.ListStoresFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize, From: from})
+		if err != nil {
+			return fmt.Errorf("failed to read stores: %w", err)
+		}
+
+		for _, store := range stores {
+			if err := migrateStore(ctx, source, target, store, progress); err != nil {
+				return fmt.Errorf("failed to copy store '%s': %w", store.GetId(), err)
+			}
+		}
+
+		from = string(token)
+		if from == "" {
+			break
+		}
+	}
+
+	log.Printf("copied %d stores", len(progress.Stores))
+
+	return nil
+}
+
+func migrateStore(ctx context.Context, source, target storage.OpenFGADatastore, store *openfgav1.Store, progress *Progress) error {
+	storeProgress, ok := progress.Stores[store.GetId()]
+	if !ok {
+		storeProgress = &StoreProgress{}
+		progress.Stores[store.GetId()] = storeProgress
+	}
+
+	if storeProgress.Done {
+		log.Printf("store '%s': already copied", store.GetId())
+		return nil
+	}
+
+	if _, err := target.GetStore(ctx, store.GetId()); err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+
+		if _, err := target.CreateStore(ctx, store); err != nil {
+			return fmt.Errorf("failed to create store: %w", err)
+		}
+	}
+
+	if err := migrateAuthorizationModels(ctx, source, target, store.GetId()); err != nil {
+		return err
+	}
+
+	settings, err := source.ReadStoreSettings(ctx, store.GetId())
+	if err != nil {
+		return fmt.Errorf("failed to read store settings: %w", err)
+	}
+
+	if *settings != (storage.StoreSettings{}) {
+		if err := target.WriteStoreSettings(ctx, store.GetId(), settings); err != nil {
+			return fmt.Errorf("failed to write store settings: %w", err)
+		}
+	}
+
+	labels, err := source.ReadStoreLabels(ctx, []string{store.GetId()})
+	if err != nil {
+		return fmt.Errorf("failed to read store labels: %w", err)
+	}
+
+	if storeLabels := labels[store.GetId()]; len(storeLabels) > 0 {
+		if err := target.WriteStoreLabels(ctx, store.GetId(), storeLabels); err != nil {
+			return fmt.Errorf("failed to write store labels: %w", err)
+		}
+	}
+
+	if err := migrateChanges(ctx, source, target, store.GetId(), progress); err != nil {
+		return err
+	}
+
+	storeProgress.Done = true
+	if err := progress.save(); err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+
+	log.Printf("store '%s': copied %d changes", store.GetId(), storeProgress.Copied)
+
+	return nil
+}
+
+// migrateAuthorizationModels copies the models that are missing in the target, from oldest to newest so
+// that the latest model stays the same, and their assertions.
+func migrateAuthorizationModels(ctx context.Context, source, target storage.OpenFGADatastore, storeID string) error {
+	var models []*openfgav1.AuthorizationModel
+
+	var from string
+	for {
+		page, token, err := source.ReadAuthorizationModels(ctx, storeID, storage.PaginationOptions{PageSize: storage.DefaultPageSize, From: from})
+		if err != nil {
+			return fmt.Errorf("failed to read authorization models: %w", err)
+		}
+		models = append(models, page...)
+
+		from = string(token)
+		if from == "" {
+			break
+		}
+	}
+
+	for i := len(models) - 1; i >= 0; i-- {
+		model := models[i]
+
+		_, err := target.ReadAuthorizationModel(ctx, storeID, model.GetId())
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			if err := target.WriteAuthorizationModel(ctx, storeID, model); err != nil {
+				return fmt.Errorf("failed to write authorization model '%s': %w", model.GetId(), err)
+			}
+		case err != nil:
+			return err
+		}
+
+		assertions, err := source.ReadAssertions(ctx, storeID, model.GetId())
+		if err != nil {
+			return fmt.Errorf("failed to read assertions: %w", err)
+		}
+
+		if len(assertions) > 0 {
+			if err := target.WriteAssertions(ctx, storeID, model.GetId(), assertions); err != nil {
+				return fmt.Errorf("failed to write assertions: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateChanges replays the changelog of the store up to the cutoff of the progress into the target,
+// which writes both the tuples and the changelog. A batch is recorded in the progress after it is written,
+// so the first batch of a resumed copy may already have been written by the interrupted run: it is
+// skipped if so.
+func migrateChanges(ctx context.Context, source, target storage.OpenFGADatastore, storeID string, progress *Progress) error {
+	storeProgress := progress.Stores[storeID]
+	resumed := true
+
+	for {
+		changes, token, err := source.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{
+			PageSize: target.MaxTuplesPerWrite(),
+			From:     storeProgress.From,
+		}, time.Since(progress.Cutoff).Truncate(time.Millisecond))
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to read changes: %w", err)
+		}
+
+		if storeProgress.Applied > len(changes) {
+			return fmt.Errorf("the progress file doesn't match the changes of the source datastore")
+		}
+
+		for _, batch := range batchChanges(changes[storeProgress.Applied:]) {
+			written := false
+			if resumed {
+				resumed = false
+
+				written, err = batchWritten(ctx, target, storeID, batch)
+				if err != nil {
+					return fmt.Errorf("failed to read tuples: %w", err)
+				}
+			}
+
+			var deletes storage.Deletes
+			var writes storage.Writes
+			for _, change := range batch {
+				switch change.GetOperation() {
+				case openfgav1.TupleOperation_TUPLE_OPERATION_DELETE:
+					deletes = append(deletes, tuple.TupleKeyToTupleKeyWithoutCondition(change.GetTupleKey()))
+				default:
+					writes = append(writes, change.GetTupleKey())
+				}
+			}
+
+			if !written {
+				if err := target.Write(ctx, storeID, deletes, writes); err != nil {
+					return fmt.Errorf("failed to write tuples: %w", err)
+				}
+			}
+
+			storeProgress.Applied += len(batch)
+			storeProgress.Copied += len(batch)
+			if err := progress.save(); err != nil {
+				return fmt.Errorf("failed to save progress: %w", err)
+			}
+		}
+
+		storeProgress.From = string(token)
+		storeProgress.Applied = 0
+		if err := progress.save(); err != nil {
+			return fmt.Errorf("failed to save progress: %w", err)
+		}
+
+		log.Printf("store '%s': copied %d changes", storeID, storeProgress.Copied)
+	}
+}
+
+// batchWritten reports whether the batch of changes was already written to the target. A batch is
+// written in a single transaction and changes each tuple at most once, and the changelog only writes
+// missing tuples and deletes existing ones, so the tuple of any change of the batch tells whether it was.
+func batchWritten(ctx context.Context, target storage.OpenFGADatastore, storeID string, batch []*openfgav1.TupleChange) (bool, error) {
+	change := batch[0]
+	deleted := change.GetOperation() == openfgav1.TupleOperation_TUPLE_OPERATION_DELETE
+
+	_, err := target.ReadUserTuple(ctx, storeID, change.GetTupleKey())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return deleted, nil
+		}
+		return false, err
+	}
+
+	return !deleted, nil
+}
+
+// batchChanges splits the changes into consecutive batches that can each be applied with a single write,
+// which applies the deletes before the writes: a batch never changes the same tuple twice.
+func batchChanges(changes []*openfgav1.TupleChange) [][]*openfgav1.TupleChange {
+	var batches [][]*openfgav1.TupleChange
+
+	var batch []*openfgav1.TupleChange
+	keys := map[string]struct{}{}
+	for _, change := range changes {
+		key := tuple.TupleKeyToString(change.GetTupleKey())
+		if _, ok := keys[key]; ok {
+			batches = append(batches, batch)
+			batch = nil
+			keys = map[string]struct{}{}
+		}
+
+		batch = append(batch, change)
+		keys[key] = struct{}{}
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
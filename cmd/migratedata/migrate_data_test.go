@@ -0,0 +1,170 @@
+package migratedata
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestMigrateDataCommand(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "source.snapshot")
+	targetPath := filepath.Join(dir, "target.snapshot")
+
+	source, err := memory.NewFromSnapshot(sourcePath)
+	require.NoError(t, err)
+
+	storeID := ulid.Make().String()
+	_, err = source.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "openfga"})
+	require.NoError(t, err)
+
+	oldModel := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
+type user`)
+	require.NoError(t, source.WriteAuthorizationModel(ctx, storeID, oldModel))
+
+	latestModel := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
+type user
+
+type document
+  relations
+	define viewer: [user]`)
+	require.NoError(t, source.WriteAuthorizationModel(ctx, storeID, latestModel))
+
+	assertions := []*openfgav1.Assertion{
+		{TupleKey: tuple.NewAssertionTupleKey("document:1", "viewer", "user:jon"), Expectation: true},
+	}
+	require.NoError(t, source.WriteAssertions(ctx, storeID, latestModel.GetId(), assertions))
+
+	settings := &storage.StoreSettings{DefaultAuthorizationModelID: oldModel.GetId()}
+	require.NoError(t, source.WriteStoreSettings(ctx, storeID, settings))
+
+	labels := map[string]string{"env": "prod"}
+	require.NoError(t, source.WriteStoreLabels(ctx, storeID, labels))
+
+	jon := tuple.NewTupleKey("document:1", "viewer", "user:jon")
+	maria := tuple.NewTupleKey("document:1", "viewer", "user:maria")
+	require.NoError(t, source.Write(ctx, storeID, nil, []*openfgav1.TupleKey{jon, maria}))
+	require.NoError(t, source.Write(ctx, storeID, []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(maria)}, nil))
+	require.NoError(t, source.Write(ctx, storeID, nil, []*openfgav1.TupleKey{maria}))
+	source.Close()
+
+	progressPath := filepath.Join(dir, "progress.json")
+	for i := 0; i < 2; i++ {
+		// the second run resumes from the progress file and has nothing left to copy
+		cmd := NewMigrateDataCommand()
+		cmd.SetArgs([]string{
+			"--source-engine", "memory", "--source-uri", sourcePath,
+			"--target-engine", "memory", "--target-uri", targetPath,
+			"--progress-file", progressPath,
+		})
+		require.NoError(t, cmd.Execute())
+	}
+
+	target, err := memory.NewFromSnapshot(targetPath)
+	require.NoError(t, err)
+	t.Cleanup(target.Close)
+
+	store, err := target.GetStore(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, "openfga", store.GetName())
+
+	latest, err := target.FindLatestAuthorizationModel(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, latestModel.GetId(), latest.GetId())
+
+	_, err = target.ReadAuthorizationModel(ctx, storeID, oldModel.GetId())
+	require.NoError(t, err)
+
+	gotAssertions, err := target.ReadAssertions(ctx, storeID, latestModel.GetId())
+	require.NoError(t, err)
+	require.Len(t, gotAssertions, 1)
+
+	gotSettings, err := target.ReadStoreSettings(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, settings, gotSettings)
+
+	gotLabels, err := target.ReadStoreLabels(ctx, []string{storeID})
+	require.NoError(t, err)
+	require.Equal(t, labels, gotLabels[storeID])
+
+	for _, tk := range []*openfgav1.TupleKey{jon, maria} {
+		_, err := target.ReadUserTuple(ctx, storeID, tk)
+		require.NoError(t, err)
+	}
+
+	changes, _, err := target.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: 10}, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+	require.Equal(t, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, changes[2].GetOperation())
+}
+
+func TestMigrateChangesResumesInterruptedBatch(t *testing.T) {
+	ctx := context.Background()
+
+	source := memory.New()
+	t.Cleanup(source.Close)
+	target := memory.New()
+	t.Cleanup(target.Close)
+
+	storeID := ulid.Make().String()
+	for _, ds := range []storage.OpenFGADatastore{source, target} {
+		_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "openfga"})
+		require.NoError(t, err)
+	}
+
+	jon := tuple.NewTupleKey("document:1", "viewer", "user:jon")
+	maria := tuple.NewTupleKey("document:1", "viewer", "user:maria")
+	require.NoError(t, source.Write(ctx, storeID, nil, []*openfgav1.TupleKey{jon, maria}))
+	require.NoError(t, source.Write(ctx, storeID, []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(maria)}, nil))
+	require.NoError(t, source.Write(ctx, storeID, nil, []*openfgav1.TupleKey{maria}))
+
+	progress := NewProgress()
+	progress.Stores[storeID] = &StoreProgress{}
+	require.NoError(t, migrateChanges(ctx, source, target, storeID, progress))
+
+	// the last batch was written, but the run was interrupted before it was recorded
+	progress.Stores[storeID] = &StoreProgress{Applied: 3, Copied: 3}
+	require.NoError(t, migrateChanges(ctx, source, target, storeID, progress))
+	require.Equal(t, 4, progress.Stores[storeID].Copied)
+
+	changes, _, err := target.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: 10}, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+}
+
+func TestMigrateDataCommandInvalidEngine(t *testing.T) {
+	cmd := NewMigrateDataCommand()
+	cmd.SetArgs([]string{"--source-engine", "memory", "--target-engine", "memory"})
+	require.ErrorContains(t, cmd.Execute(), "the uri of the 'memory' engine must be the path of a snapshot file")
+}
+
+func TestBatchChanges(t *testing.T) {
+	change := func(tk *openfgav1.TupleKey, operation openfgav1.TupleOperation) *openfgav1.TupleChange {
+		return &openfgav1.TupleChange{TupleKey: tk, Operation: operation}
+	}
+
+	jon := tuple.NewTupleKey("document:1", "viewer", "user:jon")
+	maria := tuple.NewTupleKey("document:1", "viewer", "user:maria")
+
+	changes := []*openfgav1.TupleChange{
+		change(jon, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE),
+		change(maria, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE),
+		change(jon, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE),
+		change(jon, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE),
+	}
+
+	require.Equal(t, [][]*openfgav1.TupleChange{changes[0:2], changes[2:3], changes[3:4]}, batchChanges(changes))
+	require.Empty(t, batchChanges(nil))
+}
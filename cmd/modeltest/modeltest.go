@@ -0,0 +1,329 @@
+// Package modeltest contains the command to run authorization model tests against an embedded server.
+package modeltest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	parser "github.com/openfga/language/pkg/go/transformer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/structpb"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+const outputFlag = "output"
+
+func NewTestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [files...]",
+		Short: "Run authorization model tests",
+		Long: `The test command runs authorization model test files (in the '.fga.yaml' format) against an embedded
+server with an in-memory datastore. Each test file has a model (inline or in a 'model_file'), tuples (inline
+or in a 'tuple_file' or 'tuple_files' with a YAML or JSON list of tuples) and a list of tests with check and
+list_objects assertions. Each test runs in its own store, with the tuples of the file and of the test.
+
+The command fails if any assertion fails.`,
+		RunE: runTests,
+		Args: cobra.MinimumNArgs(1),
+	}
+
+	flags := cmd.Flags()
+
+	flags.String(outputFlag, outputText, "the format of the results: 'text', 'json' or 'junit'")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+// testFile is a file in the '.fga.yaml' format.
+type testFile struct {
+	Name       string      `json:"name"`
+	Model      string      `json:"model"`
+	ModelFile  string      `json:"model_file"`
+	Tuples     []testTuple `json:"tuples"`
+	TupleFile  string      `json:"tuple_file"`
+	TupleFiles []string    `json:"tuple_files"`
+	Tests      []testCase  `json:"tests"`
+}
+
+type testCase struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Tuples      []testTuple       `json:"tuples"`
+	Check       []checkTest       `json:"check"`
+	ListObjects []listObjectsTest `json:"list_objects"`
+}
+
+type testTuple struct {
+	User      string         `json:"user"`
+	Relation  string         `json:"relation"`
+	Object    string         `json:"object"`
+	Condition *testCondition `json:"condition,omitempty"`
+}
+
+type testCondition struct {
+	Name    string                 `json:"name"`
+	Context map[string]interface{} `json:"context,omitempty"`
+}
+
+type checkTest struct {
+	User       string                 `json:"user"`
+	Object     string                 `json:"object"`
+	Context    map[string]interface{} `json:"context"`
+	Assertions map[string]bool        `json:"assertions"`
+}
+
+type listObjectsTest struct {
+	User       string                 `json:"user"`
+	Type       string                 `json:"type"`
+	Context    map[string]interface{} `json:"context"`
+	Assertions map[string][]string    `json:"assertions"`
+}
+
+func runTests(cmd *cobra.Command, args []string) error {
+	output := viper.GetString(outputFlag)
+	if output != outputText && output != outputJSON && output != outputJUnit {
+		return fmt.Errorf("unknown output format: %s", output)
+	}
+
+	var results []*fileResult
+	for _, path := range args {
+		result, err := runTestFile(cmd.Context(), path)
+		if err != nil {
+			return fmt.Errorf("failed to run '%s': %w", path, err)
+		}
+		results = append(results, result)
+	}
+
+	if err := writeResults(cmd.OutOrStdout(), output, results); err != nil {
+		return err
+	}
+
+	if failed := countFailures(results); failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d assertions failed", failed)
+	}
+
+	return nil
+}
+
+// runTestFile runs the tests of the test file at path against an embedded server with an in-memory datastore.
+func runTestFile(ctx context.Context, path string) (*fileResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file testFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid test file: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+
+	model := file.Model
+	if file.ModelFile != "" {
+		data, err := os.ReadFile(filepath.Join(dir, file.ModelFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read model file: %w", err)
+		}
+		model = string(data)
+	}
+
+	authorizationModel, err := parser.TransformDSLToProto(model)
+	if err != nil {
+		return nil, fmt.Errorf("invalid model: %w", err)
+	}
+
+	tuples := file.Tuples
+	for _, tupleFile := range append([]string{file.TupleFile}, file.TupleFiles...) {
+		if tupleFile == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, tupleFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tuple file: %w", err)
+		}
+
+		var fileTuples []testTuple
+		if err := yaml.Unmarshal(data, &fileTuples); err != nil {
+			return nil, fmt.Errorf("invalid tuple file '%s': %w", tupleFile, err)
+		}
+		tuples = append(tuples, fileTuples...)
+	}
+
+	datastore := memory.New()
+	defer datastore.Close()
+
+	svr := server.MustNewServerWithOpts(server.WithDatastore(datastore))
+	defer svr.Close()
+
+	name := file.Name
+	if name == "" {
+		name = path
+	}
+
+	result := &fileResult{Name: name, Path: path}
+	for _, test := range file.Tests {
+		testResult, err := runTestCase(ctx, svr, authorizationModel, append(tuples[:len(tuples):len(tuples)], test.Tuples...), test)
+		if err != nil {
+			return nil, fmt.Errorf("test '%s': %w", test.Name, err)
+		}
+		result.Tests = append(result.Tests, testResult)
+	}
+
+	return result, nil
+}
+
+// runTestCase runs the assertions of a test in a new store with the model and the tuples.
+func runTestCase(ctx context.Context, svr *server.Server, model *openfgav1.AuthorizationModel, tuples []testTuple, test testCase) (*testResult, error) {
+	store, err := svr.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: test.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	writeModel, err := svr.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+		Conditions:      model.GetConditions(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid model: %w", err)
+	}
+	modelID := writeModel.GetAuthorizationModelId()
+
+	for start := 0; start < len(tuples); start += storage.DefaultMaxTuplesPerWrite {
+		end := min(start+storage.DefaultMaxTuplesPerWrite, len(tuples))
+
+		var writes []*openfgav1.TupleKey
+		for _, t := range tuples[start:end] {
+			tk, err := t.toTupleKey()
+			if err != nil {
+				return nil, err
+			}
+			writes = append(writes, tk)
+		}
+
+		_, err := svr.Write(ctx, &openfgav1.WriteRequest{
+			StoreId:              store.GetId(),
+			AuthorizationModelId: modelID,
+			Writes:               &openfgav1.WriteRequestWrites{TupleKeys: writes},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid tuples: %w", err)
+		}
+	}
+
+	result := &testResult{Name: test.Name, Description: test.Description}
+
+	for _, check := range test.Check {
+		checkContext, err := structpb.NewStruct(check.Context)
+		if err != nil {
+			return nil, fmt.Errorf("invalid check context: %w", err)
+		}
+
+		for _, relation := range sortedKeys(check.Assertions) {
+			expected := check.Assertions[relation]
+			assertion := assertionResult{
+				Name:     fmt.Sprintf("check %s %s %s", check.User, relation, check.Object),
+				Expected: expected,
+			}
+
+			resp, err := svr.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:              store.GetId(),
+				AuthorizationModelId: modelID,
+				TupleKey:             &openfgav1.CheckRequestTupleKey{User: check.User, Relation: relation, Object: check.Object},
+				Context:              checkContext,
+			})
+			if err != nil {
+				assertion.Error = err.Error()
+			} else {
+				assertion.Got = resp.GetAllowed()
+				assertion.Passed = resp.GetAllowed() == expected
+			}
+
+			result.Assertions = append(result.Assertions, assertion)
+		}
+	}
+
+	for _, listObjects := range test.ListObjects {
+		listObjectsContext, err := structpb.NewStruct(listObjects.Context)
+		if err != nil {
+			return nil, fmt.Errorf("invalid list_objects context: %w", err)
+		}
+
+		for _, relation := range sortedKeys(listObjects.Assertions) {
+			expected := sorted(listObjects.Assertions[relation])
+			assertion := assertionResult{
+				Name:     fmt.Sprintf("list_objects %s %s %s", listObjects.User, relation, listObjects.Type),
+				Expected: expected,
+			}
+
+			resp, err := svr.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+				StoreId:              store.GetId(),
+				AuthorizationModelId: modelID,
+				User:                 listObjects.User,
+				Relation:             relation,
+				Type:                 listObjects.Type,
+				Context:              listObjectsContext,
+			})
+			if err != nil {
+				assertion.Error = err.Error()
+			} else {
+				got := sorted(resp.GetObjects())
+				assertion.Got = got
+				assertion.Passed = strings.Join(got, ",") == strings.Join(expected, ",")
+			}
+
+			result.Assertions = append(result.Assertions, assertion)
+		}
+	}
+
+	return result, nil
+}
+
+func (t testTuple) toTupleKey() (*openfgav1.TupleKey, error) {
+	tk := &openfgav1.TupleKey{User: t.User, Relation: t.Relation, Object: t.Object}
+
+	if t.Condition != nil {
+		conditionContext, err := structpb.NewStruct(t.Condition.Context)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition context of tuple '%s#%s@%s': %w", t.Object, t.Relation, t.User, err)
+		}
+		tk.Condition = &openfgav1.RelationshipCondition{Name: t.Condition.Name, Context: conditionContext}
+	}
+
+	return tk, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sorted(values []string) []string {
+	s := append([]string{}, values...)
+	sort.Strings(s)
+
+	return s
+}
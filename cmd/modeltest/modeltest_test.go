@@ -0,0 +1,133 @@
+package modeltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = `model
+  schema 1.1
+type user
+type document
+  relations
+    define owner: [user]
+    define viewer: [user, user with non_expired] or owner
+
+condition non_expired(current_time: timestamp, expires_at: timestamp) {
+  current_time < expires_at
+}
+`
+
+func writeTestFiles(t *testing.T, tests string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "model.fga"), []byte(testModel), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tuples.yaml"), []byte(`
+- user: user:anne
+  relation: owner
+  object: document:1
+`), 0o600))
+
+	path := filepath.Join(dir, "store.fga.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`name: documents
+model_file: ./model.fga
+tuple_file: ./tuples.yaml
+tuples:
+  - user: user:bob
+    relation: viewer
+    object: document:2
+    condition:
+      name: non_expired
+      context:
+        expires_at: "2024-01-01T00:00:00Z"
+`+tests), 0o600))
+
+	return path
+}
+
+func TestTestCommand(t *testing.T) {
+	path := writeTestFiles(t, `tests:
+  - name: owners_can_view
+    tuples:
+      - user: user:charlie
+        relation: viewer
+        object: document:1
+    check:
+      - user: user:anne
+        object: document:1
+        assertions:
+          owner: true
+          viewer: true
+      - user: user:bob
+        object: document:2
+        context:
+          current_time: "2023-01-01T00:00:00Z"
+        assertions:
+          viewer: true
+    list_objects:
+      - user: user:charlie
+        type: document
+        assertions:
+          viewer:
+            - document:1
+  - name: test_tuples_are_isolated
+    check:
+      - user: user:charlie
+        object: document:1
+        assertions:
+          viewer: false
+`)
+
+	var out bytes.Buffer
+	cmd := NewTestCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{path, "--output", "json"})
+	require.NoError(t, cmd.Execute())
+
+	var results []*fileResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+	require.Len(t, results, 1)
+	require.Equal(t, "documents", results[0].Name)
+	require.Len(t, results[0].Tests, 2)
+	require.Len(t, results[0].Tests[0].Assertions, 4)
+	require.Zero(t, countFailures(results))
+}
+
+func TestTestCommandFailures(t *testing.T) {
+	path := writeTestFiles(t, `tests:
+  - name: wrong_expectations
+    check:
+      - user: user:anne
+        object: document:1
+        assertions:
+          viewer: false
+    list_objects:
+      - user: user:anne
+        type: document
+        assertions:
+          viewer: []
+`)
+
+	var out bytes.Buffer
+	cmd := NewTestCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{path, "--output", "junit"})
+	require.ErrorContains(t, cmd.Execute(), "2 assertions failed")
+
+	require.Contains(t, out.String(), `<testsuite name="documents" tests="2" failures="2">`)
+	require.Contains(t, out.String(), `<failure message="expected false, got true"></failure>`)
+	require.Contains(t, out.String(), `<failure message="expected [], got [document:1]"></failure>`)
+}
+
+func TestTestCommandInvalidOutput(t *testing.T) {
+	cmd := NewTestCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"store.fga.yaml", "--output", "xml"})
+	require.ErrorContains(t, cmd.Execute(), "unknown output format: xml")
+}
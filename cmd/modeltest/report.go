@@ -0,0 +1,155 @@
+package modeltest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputJUnit = "junit"
+)
+
+// fileResult is the result of the tests of a test file.
+type fileResult struct {
+	Name  string        `json:"name"`
+	Path  string        `json:"path"`
+	Tests []*testResult `json:"tests"`
+}
+
+// testResult is the result of the assertions of a test.
+type testResult struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Assertions  []assertionResult `json:"assertions"`
+}
+
+// assertionResult is the result of a single check or list_objects assertion.
+type assertionResult struct {
+	Name     string      `json:"name"`
+	Passed   bool        `json:"passed"`
+	Expected interface{} `json:"expected"`
+	Got      interface{} `json:"got,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+func (a assertionResult) failureMessage() string {
+	if a.Error != "" {
+		return fmt.Sprintf("expected %v, got error: %s", a.Expected, a.Error)
+	}
+
+	return fmt.Sprintf("expected %v, got %v", a.Expected, a.Got)
+}
+
+func countFailures(results []*fileResult) int {
+	failed := 0
+	for _, file := range results {
+		for _, test := range file.Tests {
+			for _, assertion := range test.Assertions {
+				if !assertion.Passed {
+					failed++
+				}
+			}
+		}
+	}
+
+	return failed
+}
+
+func writeResults(w io.Writer, output string, results []*fileResult) error {
+	switch output {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case outputJUnit:
+		return writeJUnit(w, results)
+	default:
+		return writeText(w, results)
+	}
+}
+
+func writeText(w io.Writer, results []*fileResult) error {
+	total := 0
+	for _, file := range results {
+		fmt.Fprintf(w, "# %s\n", file.Name)
+
+		for _, test := range file.Tests {
+			for _, assertion := range test.Assertions {
+				total++
+				if assertion.Passed {
+					fmt.Fprintf(w, "PASS %s: %s\n", test.Name, assertion.Name)
+					continue
+				}
+				fmt.Fprintf(w, "FAIL %s: %s: %s\n", test.Name, assertion.Name, assertion.failureMessage())
+			}
+		}
+	}
+
+	failed := countFailures(results)
+	_, err := fmt.Fprintf(w, "\n%d/%d assertions passed\n", total-failed, total)
+
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes a test suite per test file, with a test case per assertion.
+func writeJUnit(w io.Writer, results []*fileResult) error {
+	suites := junitTestSuites{}
+	for _, file := range results {
+		suite := junitTestSuite{Name: file.Name}
+
+		for _, test := range file.Tests {
+			for _, assertion := range test.Assertions {
+				testCase := junitTestCase{Name: assertion.Name, ClassName: test.Name}
+				if !assertion.Passed {
+					testCase.Failure = &junitFailure{Message: assertion.failureMessage()}
+					suite.Failures++
+				}
+
+				suite.Tests++
+				suite.TestCases = append(suite.TestCases, testCase)
+			}
+		}
+
+		suites.TestSuites = append(suites.TestSuites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
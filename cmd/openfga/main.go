@@ -5,7 +5,12 @@ import (
 	"os"
 
 	"github.com/openfga/openfga/cmd"
+	"github.com/openfga/openfga/cmd/bench"
 	"github.com/openfga/openfga/cmd/migrate"
+	"github.com/openfga/openfga/cmd/migratedata"
+	"github.com/openfga/openfga/cmd/modeltest"
+	"github.com/openfga/openfga/cmd/query"
+	"github.com/openfga/openfga/cmd/restore"
 	"github.com/openfga/openfga/cmd/run"
 	"github.com/openfga/openfga/cmd/validatemodels"
 )
@@ -19,9 +24,24 @@ func main() {
 	migrateCmd := migrate.NewMigrateCommand()
 	rootCmd.AddCommand(migrateCmd)
 
+	migrateDataCmd := migratedata.NewMigrateDataCommand()
+	rootCmd.AddCommand(migrateDataCmd)
+
+	restoreCmd := restore.NewRestoreCommand()
+	rootCmd.AddCommand(restoreCmd)
+
 	validateModelsCmd := validatemodels.NewValidateCommand()
 	rootCmd.AddCommand(validateModelsCmd)
 
+	testCmd := modeltest.NewTestCommand()
+	rootCmd.AddCommand(testCmd)
+
+	queryCmd := query.NewQueryCommand()
+	rootCmd.AddCommand(queryCmd)
+
+	benchCmd := bench.NewBenchCommand()
+	rootCmd.AddCommand(benchCmd)
+
 	versionCmd := cmd.NewVersionCommand()
 	rootCmd.AddCommand(versionCmd)
 
@@ -0,0 +1,172 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+// backend is the server the queries of the REPL are sent to.
+type backend interface {
+	CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequest) (*openfgav1.CreateStoreResponse, error)
+	WriteAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, error)
+	Write(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteResponse, error)
+	Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, error)
+	Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*openfgav1.ExpandResponse, error)
+	ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (*openfgav1.ListObjectsResponse, error)
+
+	// DebugCheck resolves the Check request and returns the trace of its resolution.
+	DebugCheck(ctx context.Context, req *openfgav1.CheckRequest) (*server.DebugCheckResult, error)
+
+	Close()
+}
+
+var _ backend = (*embeddedBackend)(nil)
+
+// embeddedBackend is an embedded server with an in-memory datastore.
+type embeddedBackend struct {
+	*server.Server
+
+	datastore storage.OpenFGADatastore
+}
+
+func newEmbeddedBackend() *embeddedBackend {
+	datastore := memory.New()
+	return &embeddedBackend{
+		Server:    server.MustNewServerWithOpts(server.WithDatastore(datastore)),
+		datastore: datastore,
+	}
+}
+
+func (b *embeddedBackend) DebugCheck(ctx context.Context, req *openfgav1.CheckRequest) (*server.DebugCheckResult, error) {
+	return b.Server.DebugCheck(ctx, req, false), nil
+}
+
+func (b *embeddedBackend) Close() {
+	b.Server.Close()
+	b.datastore.Close()
+}
+
+var _ backend = (*remoteBackend)(nil)
+
+// remoteBackend is a running server, whose API is served over gRPC. The traces of its Checks are
+// read from its admin server.
+type remoteBackend struct {
+	conn   *grpc.ClientConn
+	client openfgav1.OpenFGAServiceClient
+	token  string
+
+	adminURL   string
+	adminToken string
+	httpClient *http.Client
+}
+
+func newRemoteBackend(addr, token, adminURL, adminToken string) (*remoteBackend, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to '%s': %w", addr, err)
+	}
+
+	return &remoteBackend{
+		conn:       conn,
+		client:     openfgav1.NewOpenFGAServiceClient(conn),
+		token:      token,
+		adminURL:   strings.TrimSuffix(adminURL, "/"),
+		adminToken: adminToken,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// outgoingContext authenticates the request with the token, if any.
+func (b *remoteBackend) outgoingContext(ctx context.Context) context.Context {
+	if b.token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+b.token)
+}
+
+func (b *remoteBackend) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequest) (*openfgav1.CreateStoreResponse, error) {
+	return b.client.CreateStore(b.outgoingContext(ctx), req)
+}
+
+func (b *remoteBackend) WriteAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, error) {
+	return b.client.WriteAuthorizationModel(b.outgoingContext(ctx), req)
+}
+
+func (b *remoteBackend) Write(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteResponse, error) {
+	return b.client.Write(b.outgoingContext(ctx), req)
+}
+
+func (b *remoteBackend) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, error) {
+	return b.client.Check(b.outgoingContext(ctx), req)
+}
+
+func (b *remoteBackend) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*openfgav1.ExpandResponse, error) {
+	return b.client.Expand(b.outgoingContext(ctx), req)
+}
+
+func (b *remoteBackend) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (*openfgav1.ListObjectsResponse, error) {
+	return b.client.ListObjects(b.outgoingContext(ctx), req)
+}
+
+// DebugCheck replays the Check request with the '/debug/check' endpoint of the admin server.
+func (b *remoteBackend) DebugCheck(ctx context.Context, req *openfgav1.CheckRequest) (*server.DebugCheckResult, error) {
+	if b.adminURL == "" {
+		return nil, fmt.Errorf("the traces of a running server are read from its admin server, set '%s'", adminURLFlag)
+	}
+
+	checkRequest, err := protojson.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(server.DebugCheckRequest{Request: checkRequest})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, b.adminURL+"/debug/check", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if b.adminToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+b.adminToken)
+	}
+
+	resp, err := b.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("debug check failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var result server.DebugCheckResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid debug check result: %w", err)
+	}
+
+	return &result, nil
+}
+
+func (b *remoteBackend) Close() {
+	_ = b.conn.Close()
+}
@@ -0,0 +1,35 @@
+package query
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/openfga/openfga/cmd/util"
+)
+
+// bindRunFlagsFunc binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(apiAddrFlag, flags.Lookup(apiAddrFlag))
+		util.MustBindEnv(apiAddrFlag, "OPENFGA_API_ADDR")
+
+		util.MustBindPFlag(apiTokenFlag, flags.Lookup(apiTokenFlag))
+		util.MustBindEnv(apiTokenFlag, "OPENFGA_API_TOKEN")
+
+		util.MustBindPFlag(adminURLFlag, flags.Lookup(adminURLFlag))
+		util.MustBindEnv(adminURLFlag, "OPENFGA_ADMIN_URL")
+
+		util.MustBindPFlag(adminTokenFlag, flags.Lookup(adminTokenFlag))
+		util.MustBindEnv(adminTokenFlag, "OPENFGA_ADMIN_TOKEN")
+
+		util.MustBindPFlag(storeIDFlag, flags.Lookup(storeIDFlag))
+		util.MustBindEnv(storeIDFlag, "OPENFGA_STORE_ID")
+
+		util.MustBindPFlag(modelIDFlag, flags.Lookup(modelIDFlag))
+		util.MustBindEnv(modelIDFlag, "OPENFGA_MODEL_ID")
+
+		util.MustBindPFlag(modelFileFlag, flags.Lookup(modelFileFlag))
+		util.MustBindEnv(modelFileFlag, "OPENFGA_MODEL_FILE")
+	}
+}
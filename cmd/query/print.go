@@ -0,0 +1,94 @@
+package query
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/server"
+)
+
+// printTree prints the userset tree of an Expand request, one node per line, indented by its depth.
+func printTree(w io.Writer, node *openfgav1.UsersetTree_Node, indent int, label string) {
+	prefix := strings.Repeat("  ", indent) + label
+
+	switch value := node.GetValue().(type) {
+	case *openfgav1.UsersetTree_Node_Leaf:
+		leaf := value.Leaf
+		switch {
+		case leaf.GetUsers() != nil:
+			fmt.Fprintf(w, "%s%s: users [%s]\n", prefix, node.GetName(), strings.Join(leaf.GetUsers().GetUsers(), ", "))
+		case leaf.GetComputed() != nil:
+			fmt.Fprintf(w, "%s%s: computed %s\n", prefix, node.GetName(), leaf.GetComputed().GetUserset())
+		case leaf.GetTupleToUserset() != nil:
+			computed := make([]string, 0, len(leaf.GetTupleToUserset().GetComputed()))
+			for _, c := range leaf.GetTupleToUserset().GetComputed() {
+				computed = append(computed, c.GetUserset())
+			}
+			fmt.Fprintf(w, "%s%s: tuple to userset %s -> [%s]\n", prefix, node.GetName(), leaf.GetTupleToUserset().GetTupleset(), strings.Join(computed, ", "))
+		default:
+			fmt.Fprintf(w, "%s%s: users []\n", prefix, node.GetName())
+		}
+	case *openfgav1.UsersetTree_Node_Union:
+		fmt.Fprintf(w, "%s%s: union\n", prefix, node.GetName())
+		for _, child := range value.Union.GetNodes() {
+			printTree(w, child, indent+1, "")
+		}
+	case *openfgav1.UsersetTree_Node_Intersection:
+		fmt.Fprintf(w, "%s%s: intersection\n", prefix, node.GetName())
+		for _, child := range value.Intersection.GetNodes() {
+			printTree(w, child, indent+1, "")
+		}
+	case *openfgav1.UsersetTree_Node_Difference:
+		fmt.Fprintf(w, "%s%s: difference\n", prefix, node.GetName())
+		printTree(w, value.Difference.GetBase(), indent+1, "base ")
+		printTree(w, value.Difference.GetSubtract(), indent+1, "subtract ")
+	}
+}
+
+// printTrace prints the events of the resolution of a Check request, one per line, indented by the
+// depth of their sub-problem. The datastore queries are indented below the last sub-problem.
+func printTrace(w io.Writer, result *server.DebugCheckResult) {
+	var maxDepth uint32
+	for _, event := range result.Events {
+		maxDepth = max(maxDepth, event.Depth)
+	}
+
+	indent := 0
+	for _, event := range result.Events {
+		if event.Depth > 0 {
+			indent = int(maxDepth - event.Depth)
+		}
+
+		line := fmt.Sprintf("%10s %s%-15s", formatDuration(event.Offset), strings.Repeat("  ", indent), event.Kind)
+		switch {
+		case event.Kind == graph.CheckTraceDatastoreQuery:
+			line += " " + event.Query
+		case event.TupleKey != "":
+			line += " " + event.TupleKey
+		}
+		if event.Allowed != nil {
+			line += fmt.Sprintf(" allowed=%t", *event.Allowed)
+		}
+		if event.Duration > 0 {
+			line += fmt.Sprintf(" (%s)", formatDuration(event.Duration))
+		}
+		if event.Error != "" {
+			line += " error: " + event.Error
+		}
+
+		fmt.Fprintln(w, line)
+	}
+
+	if result.Truncated {
+		fmt.Fprintln(w, "(the later events were dropped)")
+	}
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,423 @@
+// Package query contains the command to query a server interactively.
+package query
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	parser "github.com/openfga/language/pkg/go/transformer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const (
+	apiAddrFlag    = "api-addr"
+	apiTokenFlag   = "api-token"
+	adminURLFlag   = "admin-url"
+	adminTokenFlag = "admin-token"
+	storeIDFlag    = "store-id"
+	modelIDFlag    = "model-id"
+	modelFileFlag  = "model-file"
+
+	prompt = "fga> "
+)
+
+const helpText = `Commands:
+  load <file>                      write the model of the DSL file to the store and query it
+  store <id>                       query the store
+  model <id|latest>                query the model of the store, or its latest model
+  write <user> <relation> <object> write a tuple to the store
+  delete <user> <relation> <object>
+                                   delete a tuple of the store
+  tuple <user> <relation> <object> add a contextual tuple to the checks and list-objects
+  tuples [clear]                   list or clear the contextual tuples
+  context [<json>|clear]           show, set or clear the context of the conditions of the queries
+  check <user> <relation> <object> check whether the user has the relation with the object
+  expand <relation> <object>       print the userset tree of the relation of the object
+  list-objects <user> <relation> <type>
+                                   list the objects of the type the user has the relation with
+  trace [on|off]                   print the resolution traces of the checks
+  help                             print this help
+  exit                             exit the REPL
+`
+
+func NewQueryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Query a server interactively",
+		Long: `The query command starts a REPL that runs check, expand and list-objects queries against a running
+server, or against an embedded server with an in-memory datastore if 'api-addr' isn't set, e.g. to author
+a model: load a model file into a store, write tuples or add contextual tuples, and query it.
+
+The traces of the checks print the dispatches of their sub-problems and their results, the decisions of
+the Check cache and the datastore queries. The traces of a running server are read from the
+'/debug/check' endpoint of its admin server (see 'admin-url').
+
+Type 'help' in the REPL for its commands.`,
+		RunE: runQuery,
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+
+	flags.String(apiAddrFlag, "", "(optional) the gRPC address of a running server, e.g. 'localhost:8081'. If empty, the queries are sent to an embedded server with an in-memory datastore")
+	flags.String(apiTokenFlag, "", "(optional) the preshared key sent as a bearer token to the running server")
+	flags.String(adminURLFlag, "", "(optional) the URL of the admin server of the running server, e.g. 'http://localhost:8083', which the traces of the checks are read from")
+	flags.String(adminTokenFlag, "", "(optional) the admin preshared key sent as a bearer token to the admin server")
+	flags.String(storeIDFlag, "", "(optional) the ID of the store to query")
+	flags.String(modelIDFlag, "", "(optional) the ID of the model to query. If empty, the latest model of the store is queried")
+	flags.String(modelFileFlag, "", "(optional) the DSL file of a model to load into the store at the start")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runQuery(cmd *cobra.Command, _ []string) error {
+	var b backend
+	if addr := viper.GetString(apiAddrFlag); addr != "" {
+		remote, err := newRemoteBackend(addr, viper.GetString(apiTokenFlag), viper.GetString(adminURLFlag), viper.GetString(adminTokenFlag))
+		if err != nil {
+			return err
+		}
+		b = remote
+	} else {
+		b = newEmbeddedBackend()
+	}
+	defer b.Close()
+
+	r := &repl{
+		backend: b,
+		out:     cmd.OutOrStdout(),
+		storeID: viper.GetString(storeIDFlag),
+		modelID: viper.GetString(modelIDFlag),
+	}
+
+	if modelFile := viper.GetString(modelFileFlag); modelFile != "" {
+		if err := r.load(cmd.Context(), modelFile); err != nil {
+			return err
+		}
+	}
+
+	return r.run(cmd.Context(), cmd.InOrStdin())
+}
+
+// errExit is returned by the 'exit' command of the REPL.
+var errExit = errors.New("exit")
+
+// repl is the state of the REPL: the store and the model it queries, and the contextual tuples and
+// the context of its queries.
+type repl struct {
+	backend backend
+	out     io.Writer
+
+	storeID          string
+	modelID          string
+	contextualTuples []*openfgav1.TupleKey
+	context          *structpb.Struct
+	trace            bool
+}
+
+// run runs the commands of the lines of in until it ends or the 'exit' command. The errors of the
+// commands are printed and don't stop the REPL.
+func (r *repl) run(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	fmt.Fprint(r.out, prompt)
+	for scanner.Scan() {
+		err := r.execute(ctx, strings.TrimSpace(scanner.Text()))
+		if errors.Is(err, errExit) {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(r.out, "error: %v\n", err)
+		}
+		fmt.Fprint(r.out, prompt)
+	}
+	fmt.Fprintln(r.out)
+
+	return scanner.Err()
+}
+
+// execute runs the command of the line.
+func (r *repl) execute(ctx context.Context, line string) error {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	command, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+	args := strings.Fields(rest)
+
+	switch command {
+	case "help":
+		fmt.Fprint(r.out, helpText)
+		return nil
+	case "exit", "quit":
+		return errExit
+	case "load":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: load <file>")
+		}
+		return r.load(ctx, args[0])
+	case "store":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: store <id>")
+		}
+		r.storeID, r.modelID = args[0], ""
+		return nil
+	case "model":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: model <id|latest>")
+		}
+		r.modelID = args[0]
+		if r.modelID == "latest" {
+			r.modelID = ""
+		}
+		return nil
+	case "write", "delete":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: %s <user> <relation> <object>", command)
+		}
+		return r.write(ctx, command == "delete", tuple.NewTupleKey(args[2], args[1], args[0]))
+	case "tuple":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: tuple <user> <relation> <object>")
+		}
+		r.contextualTuples = append(r.contextualTuples, tuple.NewTupleKey(args[2], args[1], args[0]))
+		return nil
+	case "tuples":
+		if len(args) == 1 && args[0] == "clear" {
+			r.contextualTuples = nil
+			return nil
+		}
+		for _, tk := range r.contextualTuples {
+			fmt.Fprintf(r.out, "%s %s %s\n", tk.GetUser(), tk.GetRelation(), tk.GetObject())
+		}
+		return nil
+	case "context":
+		return r.setContext(rest)
+	case "trace":
+		switch rest {
+		case "", "on":
+			r.trace = true
+		case "off":
+			r.trace = false
+		default:
+			return fmt.Errorf("usage: trace [on|off]")
+		}
+		return nil
+	case "check":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: check <user> <relation> <object>")
+		}
+		return r.check(ctx, args[0], args[1], args[2])
+	case "expand":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: expand <relation> <object>")
+		}
+		return r.expand(ctx, args[0], args[1])
+	case "list-objects":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: list-objects <user> <relation> <type>")
+		}
+		return r.listObjects(ctx, args[0], args[1], args[2])
+	default:
+		return fmt.Errorf("unknown command '%s', type 'help' for the commands", command)
+	}
+}
+
+// load writes the model of the DSL file to the store, which is created if none is set, and queries it.
+func (r *repl) load(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	model, err := parser.TransformDSLToProto(string(data))
+	if err != nil {
+		return fmt.Errorf("invalid model: %w", err)
+	}
+
+	if r.storeID == "" {
+		store, err := r.backend.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "query"})
+		if err != nil {
+			return err
+		}
+		r.storeID = store.GetId()
+	}
+
+	res, err := r.backend.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         r.storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+		Conditions:      model.GetConditions(),
+	})
+	if err != nil {
+		return err
+	}
+	r.modelID = res.GetAuthorizationModelId()
+
+	fmt.Fprintf(r.out, "loaded model %s into store %s\n", r.modelID, r.storeID)
+
+	return nil
+}
+
+func (r *repl) requireStore() error {
+	if r.storeID == "" {
+		return fmt.Errorf("no store, load a model with 'load <file>' or set one with 'store <id>'")
+	}
+	return nil
+}
+
+func (r *repl) write(ctx context.Context, deleteTuple bool, tk *openfgav1.TupleKey) error {
+	if err := r.requireStore(); err != nil {
+		return err
+	}
+
+	req := &openfgav1.WriteRequest{StoreId: r.storeID, AuthorizationModelId: r.modelID}
+	if deleteTuple {
+		req.Deletes = &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk)}}
+	} else {
+		req.Writes = &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tk}}
+	}
+
+	_, err := r.backend.Write(ctx, req)
+	return err
+}
+
+// setContext prints the context of the queries if value is empty, or else sets it to the JSON object
+// of value, or clears it.
+func (r *repl) setContext(value string) error {
+	switch value {
+	case "":
+		if r.context != nil {
+			data, err := json.Marshal(r.context.AsMap())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(r.out, string(data))
+		}
+		return nil
+	case "clear":
+		r.context = nil
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return fmt.Errorf("invalid context, it must be a JSON object: %w", err)
+	}
+
+	queryContext, err := structpb.NewStruct(fields)
+	if err != nil {
+		return fmt.Errorf("invalid context: %w", err)
+	}
+	r.context = queryContext
+
+	return nil
+}
+
+func (r *repl) contextualTupleKeys() *openfgav1.ContextualTupleKeys {
+	if len(r.contextualTuples) == 0 {
+		return nil
+	}
+	return &openfgav1.ContextualTupleKeys{TupleKeys: r.contextualTuples}
+}
+
+func (r *repl) check(ctx context.Context, user, relation, object string) error {
+	if err := r.requireStore(); err != nil {
+		return err
+	}
+
+	req := &openfgav1.CheckRequest{
+		StoreId:              r.storeID,
+		AuthorizationModelId: r.modelID,
+		TupleKey:             tuple.NewCheckRequestTupleKey(object, relation, user),
+		ContextualTuples:     r.contextualTupleKeys(),
+		Context:              r.context,
+	}
+
+	if !r.trace {
+		res, err := r.backend.Check(ctx, req)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "allowed: %t\n", res.GetAllowed())
+		return nil
+	}
+
+	result, err := r.backend.DebugCheck(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	printTrace(r.out, result)
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	fmt.Fprintf(r.out, "allowed: %t (%s)\n", result.Allowed, formatDuration(result.Duration))
+
+	return nil
+}
+
+func (r *repl) expand(ctx context.Context, relation, object string) error {
+	if err := r.requireStore(); err != nil {
+		return err
+	}
+
+	res, err := r.backend.Expand(ctx, &openfgav1.ExpandRequest{
+		StoreId:              r.storeID,
+		AuthorizationModelId: r.modelID,
+		TupleKey:             &openfgav1.ExpandRequestTupleKey{Relation: relation, Object: object},
+	})
+	if err != nil {
+		return err
+	}
+
+	printTree(r.out, res.GetTree().GetRoot(), 0, "")
+
+	return nil
+}
+
+func (r *repl) listObjects(ctx context.Context, user, relation, objectType string) error {
+	if err := r.requireStore(); err != nil {
+		return err
+	}
+
+	res, err := r.backend.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+		StoreId:              r.storeID,
+		AuthorizationModelId: r.modelID,
+		User:                 user,
+		Relation:             relation,
+		Type:                 objectType,
+		ContextualTuples:     r.contextualTupleKeys(),
+		Context:              r.context,
+	})
+	if err != nil {
+		return err
+	}
+
+	objects := res.GetObjects()
+	sort.Strings(objects)
+	for _, object := range objects {
+		fmt.Fprintln(r.out, object)
+	}
+	fmt.Fprintf(r.out, "(%d objects)\n", len(objects))
+
+	return nil
+}
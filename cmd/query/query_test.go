@@ -0,0 +1,114 @@
+package query
+
+import (
+	"bytes"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+const testModel = `model
+  schema 1.1
+type user
+type document
+  relations
+    define owner: [user]
+    define viewer: [user] or owner
+`
+
+func writeModelFile(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "model.fga")
+	require.NoError(t, os.WriteFile(path, []byte(testModel), 0o600))
+	return path
+}
+
+func runREPL(t *testing.T, args []string, commands ...string) string {
+	var out bytes.Buffer
+	cmd := NewQueryCommand()
+	cmd.SetOut(&out)
+	cmd.SetIn(strings.NewReader(strings.Join(commands, "\n")))
+	cmd.SetArgs(args)
+	require.NoError(t, cmd.Execute())
+
+	return out.String()
+}
+
+func TestQueryCommand(t *testing.T) {
+	out := runREPL(t, []string{"--model-file", writeModelFile(t)},
+		"check user:anne viewer document:1",
+		"write user:anne owner document:1",
+		"check user:anne viewer document:1",
+		"tuple user:bob owner document:2",
+		"list-objects user:bob viewer document",
+		"tuples clear",
+		"list-objects user:bob viewer document",
+		"expand viewer document:1",
+		"trace on",
+		"check user:anne viewer document:1",
+		"undefined",
+		"exit",
+		"check user:anne viewer document:1",
+	)
+
+	require.Contains(t, out, "loaded model ")
+	require.Equal(t, 1, strings.Count(out, "allowed: false\n"))
+	require.Equal(t, 1, strings.Count(out, "allowed: true\n"))
+	require.Contains(t, out, "document:2\n(1 objects)\n")
+	require.Contains(t, out, "(0 objects)\n")
+	require.Contains(t, out, "document:1#viewer: union\n  document:1#viewer: users []\n  document:1#viewer: computed document:1#owner\n")
+	require.Contains(t, out, "dispatch")
+	require.Regexp(t, `allowed: true \([0-9.]+ms\)`, out)
+	require.Contains(t, out, "error: unknown command 'undefined'")
+}
+
+func TestQueryCommandWithoutStore(t *testing.T) {
+	out := runREPL(t, nil, "check user:anne viewer document:1")
+	require.Contains(t, out, "error: no store")
+}
+
+func TestQueryCommandWithRunningServer(t *testing.T) {
+	datastore := memory.New()
+	t.Cleanup(datastore.Close)
+
+	svr := server.MustNewServerWithOpts(server.WithDatastore(datastore))
+	t.Cleanup(svr.Close)
+
+	grpcServer := grpc.NewServer()
+	openfgav1.RegisterOpenFGAServiceServer(grpcServer, svr)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	admin := httptest.NewServer(svr.DebugCheckHandler())
+	t.Cleanup(admin.Close)
+
+	out := runREPL(t, []string{"--api-addr", lis.Addr().String(), "--model-file", writeModelFile(t)},
+		"check user:anne viewer document:1",
+		"trace on",
+		"check user:anne viewer document:1",
+	)
+	require.Contains(t, out, "allowed: false\n")
+	require.Contains(t, out, "error: the traces of a running server are read from its admin server")
+
+	out = runREPL(t, []string{"--api-addr", lis.Addr().String(), "--admin-url", admin.URL, "--model-file", writeModelFile(t)},
+		"tuple user:anne owner document:1",
+		"trace on",
+		"check user:anne viewer document:1",
+	)
+	require.Contains(t, out, "dispatch")
+	require.Regexp(t, `allowed: true \([0-9.]+ms\)`, out)
+}
@@ -0,0 +1,35 @@
+package restore
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/openfga/openfga/cmd/util"
+)
+
+// bindRunFlagsFunc binds the cobra cmd flags to the equivalent config value being managed
+// by viper. This bridges the config between cobra flags and viper flags.
+func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		util.MustBindPFlag(datastoreEngineFlag, flags.Lookup(datastoreEngineFlag))
+		util.MustBindEnv(datastoreEngineFlag, "OPENFGA_DATASTORE_ENGINE")
+
+		util.MustBindPFlag(datastoreURIFlag, flags.Lookup(datastoreURIFlag))
+		util.MustBindEnv(datastoreURIFlag, "OPENFGA_DATASTORE_URI")
+
+		util.MustBindPFlag(backupURLFlag, flags.Lookup(backupURLFlag))
+		util.MustBindEnv(backupURLFlag, "OPENFGA_BACKUP_URL")
+
+		util.MustBindPFlag(backupEndpointFlag, flags.Lookup(backupEndpointFlag))
+		util.MustBindEnv(backupEndpointFlag, "OPENFGA_BACKUP_ENDPOINT")
+
+		util.MustBindPFlag(backupRegionFlag, flags.Lookup(backupRegionFlag))
+		util.MustBindEnv(backupRegionFlag, "OPENFGA_BACKUP_REGION")
+
+		util.MustBindPFlag(snapshotFlag, flags.Lookup(snapshotFlag))
+		util.MustBindEnv(snapshotFlag, "OPENFGA_SNAPSHOT")
+
+		util.MustBindPFlag(storeIDFlag, flags.Lookup(storeIDFlag))
+		util.MustBindEnv(storeIDFlag, "OPENFGA_STORE_ID")
+	}
+}
@@ -0,0 +1,134 @@
+// Package restore contains the command to restore a store from a snapshot of the scheduled backups.
+package restore
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/openfga/openfga/pkg/backup"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storage/mysql"
+	"github.com/openfga/openfga/pkg/storage/postgres"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+)
+
+const (
+	datastoreEngineFlag = "datastore-engine"
+	datastoreURIFlag    = "datastore-uri"
+	backupURLFlag       = "backup-url"
+	backupEndpointFlag  = "backup-endpoint"
+	backupRegionFlag    = "backup-region"
+	snapshotFlag        = "snapshot"
+	storeIDFlag         = "store-id"
+)
+
+func NewRestoreCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a store from a snapshot of the scheduled backups",
+		Long: `The restore command creates a store from a snapshot written by the scheduled backups of 'openfga run'
+(see 'backup-enabled'), with the authorization models, assertions, settings, labels and tuples of the
+store. The datastore must be migrated with 'openfga migrate' first, and the store must not exist in it.
+
+The snapshot is the name of an object in the backup location, e.g. '<store_id>/20240102T150405Z.jsonl.gz'.
+The credentials of S3 and GCS buckets are read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+environment variables. For the 'memory' engine, the uri is the path of a snapshot file of the memory
+datastore (see 'datastore-memory-snapshot-path').
+
+The tuples are written like any other tuples, so the changelog of the restored store starts with their
+writes rather than with the changes of the original store.`,
+		RunE: runRestore,
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.Flags()
+
+	flags.String(datastoreEngineFlag, "", "(required) the datastore engine to restore the store into")
+	flags.String(datastoreURIFlag, "", "(required) the connection uri of the datastore to restore the store into")
+	flags.String(backupURLFlag, "", "(required) the location of the backups, e.g. 'file:///var/backups/openfga', 's3://bucket/openfga' or 'gs://bucket/openfga'")
+	flags.String(backupEndpointFlag, "", "(optional) the URL of an S3 compatible API (e.g. of MinIO) that 's3' backup URLs are read from, instead of AWS S3")
+	flags.String(backupRegionFlag, "us-east-1", "(optional) the region of the S3 bucket of the backups")
+	flags.String(snapshotFlag, "", "(required) the name of the snapshot to restore, e.g. '<store_id>/20240102T150405Z.jsonl.gz'")
+	flags.String(storeIDFlag, "", "(optional) the ID of the restored store, instead of the ID of the store of the snapshot")
+
+	// NOTE: if you add a new flag here, update the function below, too
+
+	cmd.PreRun = bindRunFlagsFunc(flags)
+
+	return cmd
+}
+
+func runRestore(cmd *cobra.Command, _ []string) error {
+	snapshot := viper.GetString(snapshotFlag)
+	if snapshot == "" {
+		return fmt.Errorf("missing snapshot")
+	}
+
+	if viper.GetString(backupURLFlag) == "" {
+		return fmt.Errorf("missing backup url")
+	}
+
+	objects, err := backup.OpenObjectStorage(viper.GetString(backupURLFlag),
+		backup.WithEndpoint(viper.GetString(backupEndpointFlag)),
+		backup.WithRegion(viper.GetString(backupRegionFlag)),
+	)
+	if err != nil {
+		return err
+	}
+
+	datastore, err := openDatastore(viper.GetString(datastoreEngineFlag), viper.GetString(datastoreURIFlag))
+	if err != nil {
+		return fmt.Errorf("failed to open the datastore: %w", err)
+	}
+	defer datastore.Close()
+
+	r, err := objects.Get(cmd.Context(), snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot '%s': %w", snapshot, err)
+	}
+	defer r.Close()
+
+	storeID := viper.GetString(storeIDFlag)
+
+	var opts []backup.RestoreOption
+	if storeID != "" {
+		opts = append(opts, backup.WithStoreID(storeID))
+	}
+
+	manifest, err := backup.Restore(cmd.Context(), datastore, r, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot '%s': %w", snapshot, err)
+	}
+
+	if storeID == "" {
+		storeID = manifest.StoreID
+	}
+
+	log.Printf("restored store '%s' (%s) as of %s: %d authorization models and %d tuples",
+		storeID, manifest.StoreName, manifest.CreatedAt.Format(time.RFC3339), manifest.ModelCount, manifest.TupleCount)
+
+	return nil
+}
+
+func openDatastore(engine, uri string) (storage.OpenFGADatastore, error) {
+	switch engine {
+	case "memory":
+		if uri == "" {
+			return nil, fmt.Errorf("the uri of the 'memory' engine must be the path of a snapshot file")
+		}
+		return memory.NewFromSnapshot(uri)
+	case "mysql":
+		return mysql.New(uri, sqlcommon.NewConfig())
+	case "postgres":
+		return postgres.New(uri, sqlcommon.NewConfig())
+	case "":
+		return nil, fmt.Errorf("missing datastore engine type")
+	default:
+		return nil, fmt.Errorf("unknown datastore engine type: %s", engine)
+	}
+}
@@ -0,0 +1,88 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/backup"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestRestoreCommand(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	source := memory.New()
+	t.Cleanup(source.Close)
+
+	storeID := ulid.Make().String()
+	_, err := source.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "openfga"})
+	require.NoError(t, err)
+
+	model := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
+type user
+
+type document
+  relations
+	define viewer: [user]`)
+	require.NoError(t, source.WriteAuthorizationModel(ctx, storeID, model))
+
+	jon := tuple.NewTupleKey("document:1", "viewer", "user:jon")
+	require.NoError(t, source.Write(ctx, storeID, nil, []*openfgav1.TupleKey{jon}))
+
+	objects, err := backup.OpenObjectStorage(filepath.Join(dir, "backups"))
+	require.NoError(t, err)
+
+	var snapshot bytes.Buffer
+	manifest, err := backup.Export(ctx, source, storeID, &snapshot)
+	require.NoError(t, err)
+
+	name := backup.SnapshotName(storeID, manifest.CreatedAt)
+	require.NoError(t, objects.Put(ctx, name, bytes.NewReader(snapshot.Bytes())))
+
+	targetPath := filepath.Join(dir, "target.snapshot")
+	restoredID := ulid.Make().String()
+
+	cmd := NewRestoreCommand()
+	cmd.SetArgs([]string{
+		"--datastore-engine", "memory", "--datastore-uri", targetPath,
+		"--backup-url", "file://" + filepath.Join(dir, "backups"),
+		"--snapshot", name,
+		"--store-id", restoredID,
+	})
+	require.NoError(t, cmd.Execute())
+
+	target, err := memory.NewFromSnapshot(targetPath)
+	require.NoError(t, err)
+	t.Cleanup(target.Close)
+
+	store, err := target.GetStore(ctx, restoredID)
+	require.NoError(t, err)
+	require.Equal(t, "openfga", store.GetName())
+
+	latest, err := target.FindLatestAuthorizationModel(ctx, restoredID)
+	require.NoError(t, err)
+	require.Equal(t, model.GetId(), latest.GetId())
+
+	_, err = target.ReadUserTuple(ctx, restoredID, jon)
+	require.NoError(t, err)
+}
+
+func TestRestoreCommandMissingSnapshot(t *testing.T) {
+	cmd := NewRestoreCommand()
+	cmd.SetArgs([]string{
+		"--datastore-engine", "memory", "--datastore-uri", filepath.Join(t.TempDir(), "target.snapshot"),
+		"--backup-url", t.TempDir(),
+		"--snapshot", "missing.jsonl.gz",
+	})
+	require.ErrorContains(t, cmd.Execute(), "failed to read snapshot 'missing.jsonl.gz'")
+}
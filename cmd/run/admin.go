@@ -0,0 +1,81 @@
+package run
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	goruntime "runtime"
+	"strings"
+	"time"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/middleware/drain"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/server/health"
+)
+
+// adminStats are the runtime statistics of the server served by the admin server on '/stats'.
+type adminStats struct {
+	Version                string `json:"version"`
+	Uptime                 string `json:"uptime"`
+	Goroutines             int    `json:"goroutines"`
+	HeapAllocBytes         uint64 `json:"heap_alloc_bytes"`
+	InflightRequests       int    `json:"inflight_requests"`
+	Draining               bool   `json:"draining"`
+	CheckQueryCacheEntries int    `json:"check_query_cache_entries"`
+}
+
+// adminAuthn wraps the handler of the admin server so that it only serves the requests whose
+// Authorization header has one of keys as a bearer token. Without keys, every request is served.
+func adminAuthn(keys []string, next http.Handler) http.Handler {
+	if len(keys) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		// every key is compared so that the time taken doesn't reveal which one matched
+		valid := 0
+		for _, key := range keys {
+			valid |= subtle.ConstantTimeCompare([]byte(token), []byte(key))
+		}
+		if valid != 1 {
+			http.Error(w, "unauthenticated", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statsHandler returns the handler of the admin server that returns the adminStats of the server
+// started at start.
+func statsHandler(start time.Time, svr *server.Server, healthServer *health.Checker, drainer *drain.Drainer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var memStats goruntime.MemStats
+		goruntime.ReadMemStats(&memStats)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(adminStats{
+			Version:                build.Version,
+			Uptime:                 time.Since(start).Round(time.Second).String(),
+			Goroutines:             goruntime.NumGoroutine(),
+			HeapAllocBytes:         memStats.HeapAlloc,
+			InflightRequests:       drainer.Inflight(),
+			Draining:               healthServer.Draining(),
+			CheckQueryCacheEntries: svr.CheckQueryCacheSize(),
+		})
+	})
+}
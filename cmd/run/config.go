@@ -0,0 +1,93 @@
+package run
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/openfga/openfga/cmd/util"
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+)
+
+// kubernetesServiceEnvPattern matches the environment variables that Kubernetes sets for a service
+// named 'openfga', e.g. OPENFGA_SERVICE_HOST and OPENFGA_PORT_8081_TCP, which aren't configuration.
+var kubernetesServiceEnvPattern = regexp.MustCompile(`^OPENFGA_(SERVICE_HOST|SERVICE_PORT|PORT)(_|$)`)
+
+// ValidateConfig reads the configuration like ReadConfig and returns an error if it is invalid, or if
+// the config file or the environment variables set keys that aren't configuration keys, which would
+// otherwise be ignored.
+func ValidateConfig() (*serverconfig.Config, error) {
+	config, err := ReadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	if err := config.Verify(); err != nil {
+		errs = append(errs, err)
+	}
+
+	unknown, err := unknownConfig()
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range unknown {
+		errs = append(errs, errors.New(problem))
+	}
+
+	return config, errors.Join(errs...)
+}
+
+// unknownConfig returns the keys of the config file and the OPENFGA_ environment variables that
+// aren't configuration keys, e.g. because of a typo. They are ignored, so that the configuration
+// silently keeps the values they were meant to change.
+func unknownConfig() ([]string, error) {
+	var unknown []string
+
+	if file := viper.ConfigFileUsed(); file != "" {
+		v := viper.New()
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to load server config: %w", err)
+		}
+
+		for _, key := range v.AllKeys() {
+			if !serverconfig.IsKey(key) {
+				unknown = append(unknown, fmt.Sprintf("unknown key '%s' in config file '%s'", key, file))
+			}
+		}
+	}
+
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, "OPENFGA_") || util.IsBoundEnv(name) || kubernetesServiceEnvPattern.MatchString(name) {
+			continue
+		}
+		unknown = append(unknown, fmt.Sprintf("unknown environment variable '%s'", name))
+	}
+
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// configHandler returns the handler of the admin server that returns the effective configuration of
+// the server, with the secrets redacted. After a reload, it is the reloaded configuration.
+func (s *ServerContext) configHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.config.Load().Effective())
+	})
+}
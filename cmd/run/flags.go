@@ -14,6 +14,9 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("experimentals", flags.Lookup("experimentals"))
 		util.MustBindEnv("experimentals", "OPENFGA_EXPERIMENTALS")
 
+		util.MustBindPFlag("featureFlags", flags.Lookup("feature-flags"))
+		util.MustBindEnv("featureFlags", "OPENFGA_FEATURE_FLAGS")
+
 		util.MustBindPFlag("grpc.addr", flags.Lookup("grpc-addr"))
 		util.MustBindEnv("grpc.addr", "OPENFGA_GRPC_ADDR")
 
@@ -28,6 +31,39 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 
 		command.MarkFlagsRequiredTogether("grpc-tls-enabled", "grpc-tls-cert", "grpc-tls-key")
 
+		util.MustBindPFlag("grpc.reflectionEnabled", flags.Lookup("grpc-reflection-enabled"))
+		util.MustBindEnv("grpc.reflectionEnabled", "OPENFGA_GRPC_REFLECTION_ENABLED")
+
+		util.MustBindPFlag("grpc.compression", flags.Lookup("grpc-compression"))
+		util.MustBindEnv("grpc.compression", "OPENFGA_GRPC_COMPRESSION")
+
+		util.MustBindPFlag("grpc.maxRecvMsgSizeInBytes", flags.Lookup("grpc-max-recv-msg-size-in-bytes"))
+		util.MustBindEnv("grpc.maxRecvMsgSizeInBytes", "OPENFGA_GRPC_MAX_RECV_MSG_SIZE_IN_BYTES")
+
+		util.MustBindPFlag("grpc.maxSendMsgSizeInBytes", flags.Lookup("grpc-max-send-msg-size-in-bytes"))
+		util.MustBindEnv("grpc.maxSendMsgSizeInBytes", "OPENFGA_GRPC_MAX_SEND_MSG_SIZE_IN_BYTES")
+
+		util.MustBindPFlag("grpc.maxConcurrentStreams", flags.Lookup("grpc-max-concurrent-streams"))
+		util.MustBindEnv("grpc.maxConcurrentStreams", "OPENFGA_GRPC_MAX_CONCURRENT_STREAMS")
+
+		util.MustBindPFlag("grpc.maxConnectionAge", flags.Lookup("grpc-max-connection-age"))
+		util.MustBindEnv("grpc.maxConnectionAge", "OPENFGA_GRPC_MAX_CONNECTION_AGE")
+
+		util.MustBindPFlag("grpc.maxConnectionAgeGrace", flags.Lookup("grpc-max-connection-age-grace"))
+		util.MustBindEnv("grpc.maxConnectionAgeGrace", "OPENFGA_GRPC_MAX_CONNECTION_AGE_GRACE")
+
+		util.MustBindPFlag("grpc.keepaliveTime", flags.Lookup("grpc-keepalive-time"))
+		util.MustBindEnv("grpc.keepaliveTime", "OPENFGA_GRPC_KEEPALIVE_TIME")
+
+		util.MustBindPFlag("grpc.keepaliveTimeout", flags.Lookup("grpc-keepalive-timeout"))
+		util.MustBindEnv("grpc.keepaliveTimeout", "OPENFGA_GRPC_KEEPALIVE_TIMEOUT")
+
+		util.MustBindPFlag("grpc.keepaliveEnforcementMinTime", flags.Lookup("grpc-keepalive-enforcement-min-time"))
+		util.MustBindEnv("grpc.keepaliveEnforcementMinTime", "OPENFGA_GRPC_KEEPALIVE_ENFORCEMENT_MIN_TIME")
+
+		util.MustBindPFlag("grpc.keepaliveEnforcementPermitWithoutStream", flags.Lookup("grpc-keepalive-enforcement-permit-without-stream"))
+		util.MustBindEnv("grpc.keepaliveEnforcementPermitWithoutStream", "OPENFGA_GRPC_KEEPALIVE_ENFORCEMENT_PERMIT_WITHOUT_STREAM")
+
 		util.MustBindPFlag("http.enabled", flags.Lookup("http-enabled"))
 		util.MustBindEnv("http.enabled", "OPENFGA_HTTP_ENABLED")
 
@@ -54,6 +90,21 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("http.corsAllowedHeaders", flags.Lookup("http-cors-allowed-headers"))
 		util.MustBindEnv("http.corsAllowedHeaders", "OPENFGA_HTTP_CORS_ALLOWED_HEADERS", "OPENFGA_HTTP_CORSALLOWEDHEADERS")
 
+		util.MustBindPFlag("http.corsExposedHeaders", flags.Lookup("http-cors-exposed-headers"))
+		util.MustBindEnv("http.corsExposedHeaders", "OPENFGA_HTTP_CORS_EXPOSED_HEADERS")
+
+		util.MustBindPFlag("http.corsMaxAge", flags.Lookup("http-cors-max-age"))
+		util.MustBindEnv("http.corsMaxAge", "OPENFGA_HTTP_CORS_MAX_AGE")
+
+		util.MustBindPFlag("http.openapiEnabled", flags.Lookup("http-openapi-enabled"))
+		util.MustBindEnv("http.openapiEnabled", "OPENFGA_HTTP_OPENAPI_ENABLED")
+
+		util.MustBindPFlag("http.compressionEnabled", flags.Lookup("http-compression-enabled"))
+		util.MustBindEnv("http.compressionEnabled", "OPENFGA_HTTP_COMPRESSION_ENABLED")
+
+		util.MustBindPFlag("http.compressionMinSizeInBytes", flags.Lookup("http-compression-min-size-in-bytes"))
+		util.MustBindEnv("http.compressionMinSizeInBytes", "OPENFGA_HTTP_COMPRESSION_MIN_SIZE_IN_BYTES")
+
 		util.MustBindPFlag("authn.method", flags.Lookup("authn-method"))
 		util.MustBindEnv("authn.method", "OPENFGA_AUTHN_METHOD")
 
@@ -69,6 +120,27 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("authn.oidc.issuerAliases", flags.Lookup("authn-oidc-issuer-aliases"))
 		util.MustBindEnv("authn.oidc.issuerAliases", "OPENFGA_AUTHN_OIDC_ISSUER_ALIASES")
 
+		util.MustBindPFlag("controlPlane.enabled", flags.Lookup("control-plane-enabled"))
+		util.MustBindEnv("controlPlane.enabled", "OPENFGA_CONTROL_PLANE_ENABLED")
+
+		util.MustBindPFlag("controlPlane.addr", flags.Lookup("control-plane-addr"))
+		util.MustBindEnv("controlPlane.addr", "OPENFGA_CONTROL_PLANE_ADDR")
+
+		util.MustBindPFlag("controlPlane.authn.method", flags.Lookup("control-plane-authn-method"))
+		util.MustBindEnv("controlPlane.authn.method", "OPENFGA_CONTROL_PLANE_AUTHN_METHOD")
+
+		util.MustBindPFlag("controlPlane.authn.preshared.keys", flags.Lookup("control-plane-authn-preshared-keys"))
+		util.MustBindEnv("controlPlane.authn.preshared.keys", "OPENFGA_CONTROL_PLANE_AUTHN_PRESHARED_KEYS")
+
+		util.MustBindPFlag("controlPlane.authn.oidc.audience", flags.Lookup("control-plane-authn-oidc-audience"))
+		util.MustBindEnv("controlPlane.authn.oidc.audience", "OPENFGA_CONTROL_PLANE_AUTHN_OIDC_AUDIENCE")
+
+		util.MustBindPFlag("controlPlane.authn.oidc.issuer", flags.Lookup("control-plane-authn-oidc-issuer"))
+		util.MustBindEnv("controlPlane.authn.oidc.issuer", "OPENFGA_CONTROL_PLANE_AUTHN_OIDC_ISSUER")
+
+		util.MustBindPFlag("controlPlane.authn.oidc.issuerAliases", flags.Lookup("control-plane-authn-oidc-issuer-aliases"))
+		util.MustBindEnv("controlPlane.authn.oidc.issuerAliases", "OPENFGA_CONTROL_PLANE_AUTHN_OIDC_ISSUER_ALIASES")
+
 		util.MustBindPFlag("datastore.engine", flags.Lookup("datastore-engine"))
 		util.MustBindEnv("datastore.engine", "OPENFGA_DATASTORE_ENGINE")
 
@@ -99,18 +171,108 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("datastore.metrics.enabled", flags.Lookup("datastore-metrics-enabled"))
 		util.MustBindEnv("datastore.metrics.enabled", "OPENFGA_DATASTORE_METRICS_ENABLED")
 
+		util.MustBindPFlag("datastore.metrics.storeLabelLimit", flags.Lookup("datastore-metrics-store-label-limit"))
+		util.MustBindEnv("datastore.metrics.storeLabelLimit", "OPENFGA_DATASTORE_METRICS_STORE_LABEL_LIMIT")
+
+		util.MustBindPFlag("datastore.hedging.enabled", flags.Lookup("datastore-hedging-enabled"))
+		util.MustBindEnv("datastore.hedging.enabled", "OPENFGA_DATASTORE_HEDGING_ENABLED")
+
+		util.MustBindPFlag("datastore.hedging.percentile", flags.Lookup("datastore-hedging-percentile"))
+		util.MustBindEnv("datastore.hedging.percentile", "OPENFGA_DATASTORE_HEDGING_PERCENTILE")
+
+		util.MustBindPFlag("datastore.hedging.minDelay", flags.Lookup("datastore-hedging-min-delay"))
+		util.MustBindEnv("datastore.hedging.minDelay", "OPENFGA_DATASTORE_HEDGING_MIN_DELAY")
+
+		util.MustBindPFlag("datastore.circuitBreaker.enabled", flags.Lookup("datastore-circuit-breaker-enabled"))
+		util.MustBindEnv("datastore.circuitBreaker.enabled", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_ENABLED")
+
+		util.MustBindPFlag("datastore.circuitBreaker.failureRatio", flags.Lookup("datastore-circuit-breaker-failure-ratio"))
+		util.MustBindEnv("datastore.circuitBreaker.failureRatio", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_FAILURE_RATIO")
+
+		util.MustBindPFlag("datastore.circuitBreaker.minRequests", flags.Lookup("datastore-circuit-breaker-min-requests"))
+		util.MustBindEnv("datastore.circuitBreaker.minRequests", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_MIN_REQUESTS")
+
+		util.MustBindPFlag("datastore.circuitBreaker.window", flags.Lookup("datastore-circuit-breaker-window"))
+		util.MustBindEnv("datastore.circuitBreaker.window", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_WINDOW")
+
+		util.MustBindPFlag("datastore.circuitBreaker.latencyThreshold", flags.Lookup("datastore-circuit-breaker-latency-threshold"))
+		util.MustBindEnv("datastore.circuitBreaker.latencyThreshold", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_LATENCY_THRESHOLD")
+
+		util.MustBindPFlag("datastore.circuitBreaker.openDuration", flags.Lookup("datastore-circuit-breaker-open-duration"))
+		util.MustBindEnv("datastore.circuitBreaker.openDuration", "OPENFGA_DATASTORE_CIRCUIT_BREAKER_OPEN_DURATION")
+
+		util.MustBindPFlag("datastore.memory.snapshotPath", flags.Lookup("datastore-memory-snapshot-path"))
+		util.MustBindEnv("datastore.memory.snapshotPath", "OPENFGA_DATASTORE_MEMORY_SNAPSHOT_PATH")
+
+		util.MustBindPFlag("datastore.memory.snapshotInterval", flags.Lookup("datastore-memory-snapshot-interval"))
+		util.MustBindEnv("datastore.memory.snapshotInterval", "OPENFGA_DATASTORE_MEMORY_SNAPSHOT_INTERVAL")
+
+		util.MustBindPFlag("datastore.memory.loadSnapshot", flags.Lookup("datastore-memory-load-snapshot"))
+		util.MustBindEnv("datastore.memory.loadSnapshot", "OPENFGA_DATASTORE_MEMORY_LOAD_SNAPSHOT")
+
+		util.MustBindPFlag("datastore.postgres.statementCacheCapacity", flags.Lookup("datastore-postgres-statement-cache-capacity"))
+		util.MustBindEnv("datastore.postgres.statementCacheCapacity", "OPENFGA_DATASTORE_POSTGRES_STATEMENT_CACHE_CAPACITY")
+
+		util.MustBindPFlag("datastore.postgres.queryExecMode", flags.Lookup("datastore-postgres-query-exec-mode"))
+		util.MustBindEnv("datastore.postgres.queryExecMode", "OPENFGA_DATASTORE_POSTGRES_QUERY_EXEC_MODE")
+
+		util.MustBindPFlag("datastore.postgres.notifyTupleChanges", flags.Lookup("datastore-postgres-notify-tuple-changes"))
+		util.MustBindEnv("datastore.postgres.notifyTupleChanges", "OPENFGA_DATASTORE_POSTGRES_NOTIFY_TUPLE_CHANGES")
+
+		util.MustBindPFlag("datastore.mysql.interpolateParams", flags.Lookup("datastore-mysql-interpolate-params"))
+		util.MustBindEnv("datastore.mysql.interpolateParams", "OPENFGA_DATASTORE_MYSQL_INTERPOLATE_PARAMS")
+
+		util.MustBindPFlag("datastore.requestIDComments", flags.Lookup("datastore-request-id-comments"))
+		util.MustBindEnv("datastore.requestIDComments", "OPENFGA_DATASTORE_REQUEST_ID_COMMENTS")
+
+		util.MustBindPFlag("datastore.sqlCommenter", flags.Lookup("datastore-sqlcommenter"))
+		util.MustBindEnv("datastore.sqlCommenter", "OPENFGA_DATASTORE_SQLCOMMENTER")
+
+		util.MustBindPFlag("datastore.iteratorBatchSize", flags.Lookup("datastore-iterator-batch-size"))
+		util.MustBindEnv("datastore.iteratorBatchSize", "OPENFGA_DATASTORE_ITERATOR_BATCH_SIZE")
+
+		util.MustBindPFlag("datastore.conditionContextEncryptionKeys", flags.Lookup("datastore-condition-context-encryption-keys"))
+		util.MustBindEnv("datastore.conditionContextEncryptionKeys", "OPENFGA_DATASTORE_CONDITION_CONTEXT_ENCRYPTION_KEYS")
+
+		util.MustBindPFlag("datastore.residency.name", flags.Lookup("datastore-residency-name"))
+		util.MustBindEnv("datastore.residency.name", "OPENFGA_DATASTORE_RESIDENCY_NAME")
+
+		util.MustBindPFlag("datastore.residency.datastores", flags.Lookup("datastore-residency-datastores"))
+		util.MustBindEnv("datastore.residency.datastores", "OPENFGA_DATASTORE_RESIDENCY_DATASTORES")
+
+		util.MustBindPFlag("datastore.residency.stores", flags.Lookup("datastore-residency-stores"))
+		util.MustBindEnv("datastore.residency.stores", "OPENFGA_DATASTORE_RESIDENCY_STORES")
+
+		util.MustBindPFlag("datastore.residency.labelKey", flags.Lookup("datastore-residency-label-key"))
+		util.MustBindEnv("datastore.residency.labelKey", "OPENFGA_DATASTORE_RESIDENCY_LABEL_KEY")
+
 		util.MustBindPFlag("playground.enabled", flags.Lookup("playground-enabled"))
 		util.MustBindEnv("playground.enabled", "OPENFGA_PLAYGROUND_ENABLED")
 
 		util.MustBindPFlag("playground.port", flags.Lookup("playground-port"))
 		util.MustBindEnv("playground.port", "OPENFGA_PLAYGROUND_PORT")
 
+		util.MustBindPFlag("modelEditor.enabled", flags.Lookup("model-editor-enabled"))
+		util.MustBindEnv("modelEditor.enabled", "OPENFGA_MODEL_EDITOR_ENABLED")
+
+		util.MustBindPFlag("modelEditor.maxTuples", flags.Lookup("model-editor-max-tuples"))
+		util.MustBindEnv("modelEditor.maxTuples", "OPENFGA_MODEL_EDITOR_MAX_TUPLES")
+
 		util.MustBindPFlag("profiler.enabled", flags.Lookup("profiler-enabled"))
 		util.MustBindEnv("profiler.enabled", "OPENFGA_PROFILER_ENABLED")
 
 		util.MustBindPFlag("profiler.addr", flags.Lookup("profiler-addr"))
 		util.MustBindEnv("profiler.addr", "OPENFGA_PROFILER_ADDRESS")
 
+		util.MustBindPFlag("admin.enabled", flags.Lookup("admin-enabled"))
+		util.MustBindEnv("admin.enabled", "OPENFGA_ADMIN_ENABLED")
+
+		util.MustBindPFlag("admin.addr", flags.Lookup("admin-addr"))
+		util.MustBindEnv("admin.addr", "OPENFGA_ADMIN_ADDR")
+
+		util.MustBindPFlag("admin.presharedKeys", flags.Lookup("admin-preshared-keys"))
+		util.MustBindEnv("admin.presharedKeys", "OPENFGA_ADMIN_PRESHARED_KEYS")
+
 		util.MustBindPFlag("log.format", flags.Lookup("log-format"))
 		util.MustBindEnv("log.format", "OPENFGA_LOG_FORMAT")
 
@@ -120,6 +282,9 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("log.timestampFormat", flags.Lookup("log-timestamp-format"))
 		util.MustBindEnv("log.timestampFormat", "OPENFGA_LOG_TIMESTAMP_FORMAT")
 
+		util.MustBindPFlag("log.moduleLevels", flags.Lookup("log-module-levels"))
+		util.MustBindEnv("log.moduleLevels", "OPENFGA_LOG_MODULE_LEVELS")
+
 		util.MustBindPFlag("trace.enabled", flags.Lookup("trace-enabled"))
 		util.MustBindEnv("trace.enabled", "OPENFGA_TRACE_ENABLED")
 
@@ -144,6 +309,18 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("metrics.enableRPCHistograms", flags.Lookup("metrics-enable-rpc-histograms"))
 		util.MustBindEnv("metrics.enableRPCHistograms", "OPENFGA_METRICS_ENABLE_RPC_HISTOGRAMS")
 
+		util.MustBindPFlag("metrics.otlp.enabled", flags.Lookup("metrics-otlp-enabled"))
+		util.MustBindEnv("metrics.otlp.enabled", "OPENFGA_METRICS_OTLP_ENABLED")
+
+		util.MustBindPFlag("metrics.otlp.endpoint", flags.Lookup("metrics-otlp-endpoint"))
+		util.MustBindEnv("metrics.otlp.endpoint", "OPENFGA_METRICS_OTLP_ENDPOINT")
+
+		util.MustBindPFlag("metrics.otlp.tls.enabled", flags.Lookup("metrics-otlp-tls-enabled"))
+		util.MustBindEnv("metrics.otlp.tls.enabled", "OPENFGA_METRICS_OTLP_TLS_ENABLED")
+
+		util.MustBindPFlag("metrics.otlp.exportInterval", flags.Lookup("metrics-otlp-export-interval"))
+		util.MustBindEnv("metrics.otlp.exportInterval", "OPENFGA_METRICS_OTLP_EXPORT_INTERVAL")
+
 		util.MustBindPFlag("maxTuplesPerWrite", flags.Lookup("max-tuples-per-write"))
 		util.MustBindEnv("maxTuplesPerWrite", "OPENFGA_MAX_TUPLES_PER_WRITE", "OPENFGA_MAXTUPLESPERWRITE")
 
@@ -153,12 +330,33 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("maxAuthorizationModelSizeInBytes", flags.Lookup("max-authorization-model-size-in-bytes"))
 		util.MustBindEnv("maxAuthorizationModelSizeInBytes", "OPENFGA_MAX_AUTHORIZATION_MODEL_SIZE_IN_BYTES", "OPENFGA_MAXAUTHORIZATIONMODELSIZEINBYTES")
 
+		util.MustBindPFlag("maxRelationsPerTypeDefinition", flags.Lookup("max-relations-per-type-definition"))
+		util.MustBindEnv("maxRelationsPerTypeDefinition", "OPENFGA_MAX_RELATIONS_PER_TYPE_DEFINITION")
+
+		util.MustBindPFlag("maxAuthorizationModelRewriteDepth", flags.Lookup("max-authorization-model-rewrite-depth"))
+		util.MustBindEnv("maxAuthorizationModelRewriteDepth", "OPENFGA_MAX_AUTHORIZATION_MODEL_REWRITE_DEPTH")
+
+		util.MustBindPFlag("maxConditionExpressionSizeInBytes", flags.Lookup("max-condition-expression-size-in-bytes"))
+		util.MustBindEnv("maxConditionExpressionSizeInBytes", "OPENFGA_MAX_CONDITION_EXPRESSION_SIZE_IN_BYTES")
+
+		util.MustBindPFlag("maxContextualTuples", flags.Lookup("max-contextual-tuples"))
+		util.MustBindEnv("maxContextualTuples", "OPENFGA_MAX_CONTEXTUAL_TUPLES")
+
+		util.MustBindPFlag("maxContextSizeInBytes", flags.Lookup("max-context-size-in-bytes"))
+		util.MustBindEnv("maxContextSizeInBytes", "OPENFGA_MAX_CONTEXT_SIZE_IN_BYTES")
+
+		util.MustBindPFlag("consistencyTokenTimeout", flags.Lookup("consistency-token-timeout"))
+		util.MustBindEnv("consistencyTokenTimeout", "OPENFGA_CONSISTENCY_TOKEN_TIMEOUT")
+
 		util.MustBindPFlag("maxConcurrentReadsForListObjects", flags.Lookup("max-concurrent-reads-for-list-objects"))
 		util.MustBindEnv("maxConcurrentReadsForListObjects", "OPENFGA_MAX_CONCURRENT_READS_FOR_LIST_OBJECTS", "OPENFGA_MAXCONCURRENTREADSFORLISTOBJECTS")
 
 		util.MustBindPFlag("maxConcurrentReadsForCheck", flags.Lookup("max-concurrent-reads-for-check"))
 		util.MustBindEnv("maxConcurrentReadsForCheck", "OPENFGA_MAX_CONCURRENT_READS_FOR_CHECK", "OPENFGA_MAXCONCURRENTREADSFORCHECK")
 
+		util.MustBindPFlag("checkUsersetsPageSize", flags.Lookup("check-usersets-page-size"))
+		util.MustBindEnv("checkUsersetsPageSize", "OPENFGA_CHECK_USERSETS_PAGE_SIZE", "OPENFGA_CHECKUSERSETSPAGESIZE")
+
 		util.MustBindPFlag("changelogHorizonOffset", flags.Lookup("changelog-horizon-offset"))
 		util.MustBindEnv("changelogHorizonOffset", "OPENFGA_CHANGELOG_HORIZON_OFFSET", "OPENFGA_CHANGELOGHORIZONOFFSET")
 
@@ -174,6 +372,12 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("listObjectsMaxResults", flags.Lookup("listObjects-max-results"))
 		util.MustBindEnv("listObjectsMaxResults", "OPENFGA_LIST_OBJECTS_MAX_RESULTS", "OPENFGA_LISTOBJECTSMAXRESULTS")
 
+		util.MustBindPFlag("listObjectsShards", flags.Lookup("listObjects-shards"))
+		util.MustBindEnv("listObjectsShards", "OPENFGA_LIST_OBJECTS_SHARDS", "OPENFGA_LISTOBJECTSSHARDS")
+
+		util.MustBindPFlag("listObjectsShardConcurrency", flags.Lookup("listObjects-shard-concurrency"))
+		util.MustBindEnv("listObjectsShardConcurrency", "OPENFGA_LIST_OBJECTS_SHARD_CONCURRENCY", "OPENFGA_LISTOBJECTSSHARDCONCURRENCY")
+
 		util.MustBindPFlag("checkQueryCache.enabled", flags.Lookup("check-query-cache-enabled"))
 		util.MustBindEnv("checkQueryCache.enabled", "OPENFGA_CHECK_QUERY_CACHE_ENABLED")
 
@@ -183,6 +387,33 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("checkQueryCache.ttl", flags.Lookup("check-query-cache-ttl"))
 		util.MustBindEnv("checkQueryCache.ttl", "OPENFGA_CHECK_QUERY_CACHE_TTL")
 
+		util.MustBindPFlag("checkQueryCache.negativeTTL", flags.Lookup("check-query-cache-negative-ttl"))
+		util.MustBindEnv("checkQueryCache.negativeTTL", "OPENFGA_CHECK_QUERY_CACHE_NEGATIVE_TTL")
+
+		util.MustBindPFlag("checkQueryCache.maxBytes", flags.Lookup("check-query-cache-max-bytes"))
+		util.MustBindEnv("checkQueryCache.maxBytes", "OPENFGA_CHECK_QUERY_CACHE_MAX_BYTES")
+
+		util.MustBindPFlag("checkQueryCache.serveStale", flags.Lookup("check-query-cache-serve-stale"))
+		util.MustBindEnv("checkQueryCache.serveStale", "OPENFGA_CHECK_QUERY_CACHE_SERVE_STALE")
+
+		util.MustBindPFlag("checkQueryCache.watermarkEnabled", flags.Lookup("check-query-cache-watermark-enabled"))
+		util.MustBindEnv("checkQueryCache.watermarkEnabled", "OPENFGA_CHECK_QUERY_CACHE_WATERMARK_ENABLED")
+
+		util.MustBindPFlag("checkQueryCache.watermarkRefreshInterval", flags.Lookup("check-query-cache-watermark-refresh-interval"))
+		util.MustBindEnv("checkQueryCache.watermarkRefreshInterval", "OPENFGA_CHECK_QUERY_CACHE_WATERMARK_REFRESH_INTERVAL")
+
+		util.MustBindPFlag("listObjectsCache.enabled", flags.Lookup("list-objects-cache-enabled"))
+		util.MustBindEnv("listObjectsCache.enabled", "OPENFGA_LIST_OBJECTS_CACHE_ENABLED")
+
+		util.MustBindPFlag("listObjectsCache.limit", flags.Lookup("list-objects-cache-limit"))
+		util.MustBindEnv("listObjectsCache.limit", "OPENFGA_LIST_OBJECTS_CACHE_LIMIT")
+
+		util.MustBindPFlag("listObjectsCache.ttl", flags.Lookup("list-objects-cache-ttl"))
+		util.MustBindEnv("listObjectsCache.ttl", "OPENFGA_LIST_OBJECTS_CACHE_TTL")
+
+		util.MustBindPFlag("listObjectsCache.watermarkRefreshInterval", flags.Lookup("list-objects-cache-watermark-refresh-interval"))
+		util.MustBindEnv("listObjectsCache.watermarkRefreshInterval", "OPENFGA_LIST_OBJECTS_CACHE_WATERMARK_REFRESH_INTERVAL")
+
 		util.MustBindPFlag("requestDurationDatastoreQueryCountBuckets", flags.Lookup("request-duration-datastore-query-count-buckets"))
 		util.MustBindEnv("requestDurationDatastoreQueryCountBuckets", "OPENFGA_REQUEST_DURATION_DATASTORE_QUERY_COUNT_BUCKETS")
 
@@ -201,7 +432,280 @@ func bindRunFlagsFunc(flags *pflag.FlagSet) func(*cobra.Command, []string) {
 		util.MustBindPFlag("dispatchThrottling.maxThreshold", flags.Lookup("dispatch-throttling-max-threshold"))
 		util.MustBindEnv("dispatchThrottling.maxThreshold", "OPENFGA_DISPATCH_THROTTLING_MAX_THRESHOLD")
 
+		util.MustBindPFlag("slowRequestLog.enabled", flags.Lookup("slow-request-log-enabled"))
+		util.MustBindEnv("slowRequestLog.enabled", "OPENFGA_SLOW_REQUEST_LOG_ENABLED")
+
+		util.MustBindPFlag("slowRequestLog.threshold", flags.Lookup("slow-request-log-threshold"))
+		util.MustBindEnv("slowRequestLog.threshold", "OPENFGA_SLOW_REQUEST_LOG_THRESHOLD")
+
+		util.MustBindPFlag("admissionControl.enabled", flags.Lookup("admission-control-enabled"))
+		util.MustBindEnv("admissionControl.enabled", "OPENFGA_ADMISSION_CONTROL_ENABLED")
+
+		util.MustBindPFlag("admissionControl.maxInflightRequests", flags.Lookup("admission-control-max-inflight-requests"))
+		util.MustBindEnv("admissionControl.maxInflightRequests", "OPENFGA_ADMISSION_CONTROL_MAX_INFLIGHT_REQUESTS")
+
+		util.MustBindPFlag("admissionControl.maxGoroutines", flags.Lookup("admission-control-max-goroutines"))
+		util.MustBindEnv("admissionControl.maxGoroutines", "OPENFGA_ADMISSION_CONTROL_MAX_GOROUTINES")
+
+		util.MustBindPFlag("admissionControl.maxMemoryBytes", flags.Lookup("admission-control-max-memory-bytes"))
+		util.MustBindEnv("admissionControl.maxMemoryBytes", "OPENFGA_ADMISSION_CONTROL_MAX_MEMORY_BYTES")
+
+		util.MustBindPFlag("admissionControl.lowPriorityRatio", flags.Lookup("admission-control-low-priority-ratio"))
+		util.MustBindEnv("admissionControl.lowPriorityRatio", "OPENFGA_ADMISSION_CONTROL_LOW_PRIORITY_RATIO")
+
+		util.MustBindPFlag("admissionControl.highDispatchCount", flags.Lookup("admission-control-high-dispatch-count"))
+		util.MustBindEnv("admissionControl.highDispatchCount", "OPENFGA_ADMISSION_CONTROL_HIGH_DISPATCH_COUNT")
+
+		util.MustBindPFlag("admissionControl.retryAfter", flags.Lookup("admission-control-retry-after"))
+		util.MustBindEnv("admissionControl.retryAfter", "OPENFGA_ADMISSION_CONTROL_RETRY_AFTER")
+
+		util.MustBindPFlag("checkBudget.maxDispatchCount", flags.Lookup("check-budget-max-dispatch-count"))
+		util.MustBindEnv("checkBudget.maxDispatchCount", "OPENFGA_CHECK_BUDGET_MAX_DISPATCH_COUNT")
+
+		util.MustBindPFlag("checkBudget.maxDatastoreReadCount", flags.Lookup("check-budget-max-datastore-read-count"))
+		util.MustBindEnv("checkBudget.maxDatastoreReadCount", "OPENFGA_CHECK_BUDGET_MAX_DATASTORE_READ_COUNT")
+
+		util.MustBindPFlag("checkReadDeduplication.enabled", flags.Lookup("check-read-deduplication-enabled"))
+		util.MustBindEnv("checkReadDeduplication.enabled", "OPENFGA_CHECK_READ_DEDUPLICATION_ENABLED")
+
+		util.MustBindPFlag("checkReadDeduplication.maxTuplesPerRead", flags.Lookup("check-read-deduplication-max-tuples-per-read"))
+		util.MustBindEnv("checkReadDeduplication.maxTuplesPerRead", "OPENFGA_CHECK_READ_DEDUPLICATION_MAX_TUPLES_PER_READ")
+
+		util.MustBindPFlag("checkDispatchPool.enabled", flags.Lookup("check-dispatch-pool-enabled"))
+		util.MustBindEnv("checkDispatchPool.enabled", "OPENFGA_CHECK_DISPATCH_POOL_ENABLED")
+
+		util.MustBindPFlag("checkDispatchPool.size", flags.Lookup("check-dispatch-pool-size"))
+		util.MustBindEnv("checkDispatchPool.size", "OPENFGA_CHECK_DISPATCH_POOL_SIZE")
+
+		util.MustBindPFlag("typesystemCache.maxSize", flags.Lookup("typesystem-cache-max-size"))
+		util.MustBindEnv("typesystemCache.maxSize", "OPENFGA_TYPESYSTEM_CACHE_MAX_SIZE")
+
+		util.MustBindPFlag("checkPlanner.enabled", flags.Lookup("check-planner-enabled"))
+		util.MustBindEnv("checkPlanner.enabled", "OPENFGA_CHECK_PLANNER_ENABLED")
+
+		util.MustBindPFlag("checkPlanner.statisticsInterval", flags.Lookup("check-planner-statistics-interval"))
+		util.MustBindEnv("checkPlanner.statisticsInterval", "OPENFGA_CHECK_PLANNER_STATISTICS_INTERVAL")
+
+		util.MustBindPFlag("checkPlanner.maxStores", flags.Lookup("check-planner-max-stores"))
+		util.MustBindEnv("checkPlanner.maxStores", "OPENFGA_CHECK_PLANNER_MAX_STORES")
+
+		util.MustBindPFlag("tupleStatistics.enabled", flags.Lookup("tuple-statistics-enabled"))
+		util.MustBindEnv("tupleStatistics.enabled", "OPENFGA_TUPLE_STATISTICS_ENABLED")
+
+		util.MustBindPFlag("tupleStatistics.interval", flags.Lookup("tuple-statistics-interval"))
+		util.MustBindEnv("tupleStatistics.interval", "OPENFGA_TUPLE_STATISTICS_INTERVAL")
+
+		util.MustBindPFlag("tupleStatistics.maxTuplesPerStore", flags.Lookup("tuple-statistics-max-tuples-per-store"))
+		util.MustBindEnv("tupleStatistics.maxTuplesPerStore", "OPENFGA_TUPLE_STATISTICS_MAX_TUPLES_PER_STORE")
+
+		util.MustBindPFlag("relationUsage.enabled", flags.Lookup("relation-usage-enabled"))
+		util.MustBindEnv("relationUsage.enabled", "OPENFGA_RELATION_USAGE_ENABLED")
+
+		util.MustBindPFlag("relationUsage.sampleRatio", flags.Lookup("relation-usage-sample-ratio"))
+		util.MustBindEnv("relationUsage.sampleRatio", "OPENFGA_RELATION_USAGE_SAMPLE_RATIO")
+
+		util.MustBindPFlag("relationUsage.flushInterval", flags.Lookup("relation-usage-flush-interval"))
+		util.MustBindEnv("relationUsage.flushInterval", "OPENFGA_RELATION_USAGE_FLUSH_INTERVAL")
+
+		util.MustBindPFlag("idGeneration.scheme", flags.Lookup("id-generation-scheme"))
+		util.MustBindEnv("idGeneration.scheme", "OPENFGA_ID_GENERATION_SCHEME")
+
+		util.MustBindPFlag("idGeneration.callerSuppliedStoreIDs", flags.Lookup("id-generation-caller-supplied-store-ids"))
+		util.MustBindEnv("idGeneration.callerSuppliedStoreIDs", "OPENFGA_ID_GENERATION_CALLER_SUPPLIED_STORE_IDS")
+
+		util.MustBindPFlag("writeAdmissionWebhook.url", flags.Lookup("write-admission-webhook-url"))
+		util.MustBindEnv("writeAdmissionWebhook.url", "OPENFGA_WRITE_ADMISSION_WEBHOOK_URL")
+
+		util.MustBindPFlag("writeAdmissionWebhook.timeout", flags.Lookup("write-admission-webhook-timeout"))
+		util.MustBindEnv("writeAdmissionWebhook.timeout", "OPENFGA_WRITE_ADMISSION_WEBHOOK_TIMEOUT")
+
+		util.MustBindPFlag("writeAdmissionWebhook.failurePolicy", flags.Lookup("write-admission-webhook-failure-policy"))
+		util.MustBindEnv("writeAdmissionWebhook.failurePolicy", "OPENFGA_WRITE_ADMISSION_WEBHOOK_FAILURE_POLICY")
+
+		util.MustBindPFlag("writeGroups.sagaEnabled", flags.Lookup("write-groups-saga-enabled"))
+		util.MustBindEnv("writeGroups.sagaEnabled", "OPENFGA_WRITE_GROUPS_SAGA_ENABLED")
+
+		util.MustBindPFlag("delegatedCheck.relations", flags.Lookup("delegated-check-relations"))
+		util.MustBindEnv("delegatedCheck.relations", "OPENFGA_DELEGATED_CHECK_RELATIONS")
+
+		util.MustBindPFlag("delegatedCheck.operator", flags.Lookup("delegated-check-operator"))
+		util.MustBindEnv("delegatedCheck.operator", "OPENFGA_DELEGATED_CHECK_OPERATOR")
+
+		util.MustBindPFlag("delegatedCheck.timeout", flags.Lookup("delegated-check-timeout"))
+		util.MustBindEnv("delegatedCheck.timeout", "OPENFGA_DELEGATED_CHECK_TIMEOUT")
+
+		util.MustBindPFlag("checkCanary.enabled", flags.Lookup("check-canary-enabled"))
+		util.MustBindEnv("checkCanary.enabled", "OPENFGA_CHECK_CANARY_ENABLED")
+
+		util.MustBindPFlag("checkCanary.sampleRatio", flags.Lookup("check-canary-sample-ratio"))
+		util.MustBindEnv("checkCanary.sampleRatio", "OPENFGA_CHECK_CANARY_SAMPLE_RATIO")
+
+		util.MustBindPFlag("checkCanary.timeout", flags.Lookup("check-canary-timeout"))
+		util.MustBindEnv("checkCanary.timeout", "OPENFGA_CHECK_CANARY_TIMEOUT")
+
+		util.MustBindPFlag("checkCanary.maxConcurrentEvaluations", flags.Lookup("check-canary-max-concurrent-evaluations"))
+		util.MustBindEnv("checkCanary.maxConcurrentEvaluations", "OPENFGA_CHECK_CANARY_MAX_CONCURRENT_EVALUATIONS")
+
+		util.MustBindPFlag("writeCoalescing.enabled", flags.Lookup("write-coalescing-enabled"))
+		util.MustBindEnv("writeCoalescing.enabled", "OPENFGA_WRITE_COALESCING_ENABLED")
+
+		util.MustBindPFlag("writeCoalescing.window", flags.Lookup("write-coalescing-window"))
+		util.MustBindEnv("writeCoalescing.window", "OPENFGA_WRITE_COALESCING_WINDOW")
+
+		util.MustBindPFlag("peerDispatch.enabled", flags.Lookup("peer-dispatch-enabled"))
+		util.MustBindEnv("peerDispatch.enabled", "OPENFGA_PEER_DISPATCH_ENABLED")
+
+		util.MustBindPFlag("peerDispatch.self", flags.Lookup("peer-dispatch-self"))
+		util.MustBindEnv("peerDispatch.self", "OPENFGA_PEER_DISPATCH_SELF")
+
+		util.MustBindPFlag("peerDispatch.peers", flags.Lookup("peer-dispatch-peers"))
+		util.MustBindEnv("peerDispatch.peers", "OPENFGA_PEER_DISPATCH_PEERS")
+
+		util.MustBindPFlag("peerDispatch.dnsName", flags.Lookup("peer-dispatch-dns-name"))
+		util.MustBindEnv("peerDispatch.dnsName", "OPENFGA_PEER_DISPATCH_DNS_NAME")
+
+		util.MustBindPFlag("peerDispatch.dnsRefreshInterval", flags.Lookup("peer-dispatch-dns-refresh-interval"))
+		util.MustBindEnv("peerDispatch.dnsRefreshInterval", "OPENFGA_PEER_DISPATCH_DNS_REFRESH_INTERVAL")
+
+		util.MustBindPFlag("peerDispatch.timeout", flags.Lookup("peer-dispatch-timeout"))
+		util.MustBindEnv("peerDispatch.timeout", "OPENFGA_PEER_DISPATCH_TIMEOUT")
+
+		util.MustBindPFlag("peerDispatch.presharedKey", flags.Lookup("peer-dispatch-preshared-key"))
+		util.MustBindEnv("peerDispatch.presharedKey", "OPENFGA_PEER_DISPATCH_PRESHARED_KEY")
+
+		util.MustBindPFlag("tupleValidation.maxObjectLength", flags.Lookup("tuple-validation-max-object-length"))
+		util.MustBindEnv("tupleValidation.maxObjectLength", "OPENFGA_TUPLE_VALIDATION_MAX_OBJECT_LENGTH")
+
+		util.MustBindPFlag("tupleValidation.maxUserLength", flags.Lookup("tuple-validation-max-user-length"))
+		util.MustBindEnv("tupleValidation.maxUserLength", "OPENFGA_TUPLE_VALIDATION_MAX_USER_LENGTH")
+
+		util.MustBindPFlag("tupleValidation.idPatterns", flags.Lookup("tuple-validation-id-patterns"))
+		util.MustBindEnv("tupleValidation.idPatterns", "OPENFGA_TUPLE_VALIDATION_ID_PATTERNS")
+
+		util.MustBindPFlag("timeTravel.enabled", flags.Lookup("time-travel-enabled"))
+		util.MustBindEnv("timeTravel.enabled", "OPENFGA_TIME_TRAVEL_ENABLED")
+
+		util.MustBindPFlag("timeTravel.maxChanges", flags.Lookup("time-travel-max-changes"))
+		util.MustBindEnv("timeTravel.maxChanges", "OPENFGA_TIME_TRAVEL_MAX_CHANGES")
+
+		util.MustBindPFlag("timeTravel.retention", flags.Lookup("time-travel-retention"))
+		util.MustBindEnv("timeTravel.retention", "OPENFGA_TIME_TRAVEL_RETENTION")
+
+		util.MustBindPFlag("continuationTokens.signingKeys", flags.Lookup("continuation-tokens-signing-keys"))
+		util.MustBindEnv("continuationTokens.signingKeys", "OPENFGA_CONTINUATION_TOKENS_SIGNING_KEYS")
+
+		util.MustBindPFlag("continuationTokens.encryptionKey", flags.Lookup("continuation-tokens-encryption-key"))
+		util.MustBindEnv("continuationTokens.encryptionKey", "OPENFGA_CONTINUATION_TOKENS_ENCRYPTION_KEY")
+
+		util.MustBindPFlag("continuationTokens.readChangesLifetime", flags.Lookup("continuation-tokens-read-changes-lifetime"))
+		util.MustBindEnv("continuationTokens.readChangesLifetime", "OPENFGA_CONTINUATION_TOKENS_READ_CHANGES_LIFETIME")
+
+		util.MustBindPFlag("import.modelFile", flags.Lookup("import-model-file"))
+		util.MustBindEnv("import.modelFile", "OPENFGA_IMPORT_MODEL_FILE")
+
+		util.MustBindPFlag("import.tuplesFile", flags.Lookup("import-tuples-file"))
+		util.MustBindEnv("import.tuplesFile", "OPENFGA_IMPORT_TUPLES_FILE")
+
+		util.MustBindPFlag("import.storeName", flags.Lookup("import-store-name"))
+		util.MustBindEnv("import.storeName", "OPENFGA_IMPORT_STORE_NAME")
+
+		util.MustBindPFlag("backup.enabled", flags.Lookup("backup-enabled"))
+		util.MustBindEnv("backup.enabled", "OPENFGA_BACKUP_ENABLED")
+
+		util.MustBindPFlag("backup.url", flags.Lookup("backup-url"))
+		util.MustBindEnv("backup.url", "OPENFGA_BACKUP_URL")
+
+		util.MustBindPFlag("backup.interval", flags.Lookup("backup-interval"))
+		util.MustBindEnv("backup.interval", "OPENFGA_BACKUP_INTERVAL")
+
+		util.MustBindPFlag("backup.endpoint", flags.Lookup("backup-endpoint"))
+		util.MustBindEnv("backup.endpoint", "OPENFGA_BACKUP_ENDPOINT")
+
+		util.MustBindPFlag("backup.region", flags.Lookup("backup-region"))
+		util.MustBindEnv("backup.region", "OPENFGA_BACKUP_REGION")
+
+		util.MustBindPFlag("integrityChecker.enabled", flags.Lookup("integrity-checker-enabled"))
+		util.MustBindEnv("integrityChecker.enabled", "OPENFGA_INTEGRITY_CHECKER_ENABLED")
+
+		util.MustBindPFlag("integrityChecker.interval", flags.Lookup("integrity-checker-interval"))
+		util.MustBindEnv("integrityChecker.interval", "OPENFGA_INTEGRITY_CHECKER_INTERVAL")
+
+		util.MustBindPFlag("integrityChecker.cleanup", flags.Lookup("integrity-checker-cleanup"))
+		util.MustBindEnv("integrityChecker.cleanup", "OPENFGA_INTEGRITY_CHECKER_CLEANUP")
+
+		util.MustBindPFlag("integrityChecker.quarantineURL", flags.Lookup("integrity-checker-quarantine-url"))
+		util.MustBindEnv("integrityChecker.quarantineURL", "OPENFGA_INTEGRITY_CHECKER_QUARANTINE_URL")
+
+		util.MustBindPFlag("integrityChecker.maxReportedTuples", flags.Lookup("integrity-checker-max-reported-tuples"))
+		util.MustBindEnv("integrityChecker.maxReportedTuples", "OPENFGA_INTEGRITY_CHECKER_MAX_REPORTED_TUPLES")
+
+		util.MustBindPFlag("metering.enabled", flags.Lookup("metering-enabled"))
+		util.MustBindEnv("metering.enabled", "OPENFGA_METERING_ENABLED")
+
+		util.MustBindPFlag("metering.window", flags.Lookup("metering-window"))
+		util.MustBindEnv("metering.window", "OPENFGA_METERING_WINDOW")
+
+		util.MustBindPFlag("metering.retention", flags.Lookup("metering-retention"))
+		util.MustBindEnv("metering.retention", "OPENFGA_METERING_RETENTION")
+
+		util.MustBindPFlag("metering.instance", flags.Lookup("metering-instance"))
+		util.MustBindEnv("metering.instance", "OPENFGA_METERING_INSTANCE")
+
+		util.MustBindPFlag("metering.exportURL", flags.Lookup("metering-export-url"))
+		util.MustBindEnv("metering.exportURL", "OPENFGA_METERING_EXPORT_URL")
+
+		util.MustBindPFlag("metering.exportFormat", flags.Lookup("metering-export-format"))
+		util.MustBindEnv("metering.exportFormat", "OPENFGA_METERING_EXPORT_FORMAT")
+
+		util.MustBindPFlag("warmup.enabled", flags.Lookup("warmup-enabled"))
+		util.MustBindEnv("warmup.enabled", "OPENFGA_WARMUP_ENABLED")
+
+		util.MustBindPFlag("warmup.stores", flags.Lookup("warmup-stores"))
+		util.MustBindEnv("warmup.stores", "OPENFGA_WARMUP_STORES")
+
+		util.MustBindPFlag("warmup.timeout", flags.Lookup("warmup-timeout"))
+		util.MustBindEnv("warmup.timeout", "OPENFGA_WARMUP_TIMEOUT")
+
+		util.MustBindPFlag("changePublisher.enabled", flags.Lookup("change-publisher-enabled"))
+		util.MustBindEnv("changePublisher.enabled", "OPENFGA_CHANGE_PUBLISHER_ENABLED")
+
+		util.MustBindPFlag("changePublisher.url", flags.Lookup("change-publisher-url"))
+		util.MustBindEnv("changePublisher.url", "OPENFGA_CHANGE_PUBLISHER_URL")
+
+		util.MustBindPFlag("changePublisher.interval", flags.Lookup("change-publisher-interval"))
+		util.MustBindEnv("changePublisher.interval", "OPENFGA_CHANGE_PUBLISHER_INTERVAL")
+
+		util.MustBindPFlag("changePublisher.pageSize", flags.Lookup("change-publisher-page-size"))
+		util.MustBindEnv("changePublisher.pageSize", "OPENFGA_CHANGE_PUBLISHER_PAGE_SIZE")
+
+		util.MustBindPFlag("changePublisher.horizonOffset", flags.Lookup("change-publisher-horizon-offset"))
+		util.MustBindEnv("changePublisher.horizonOffset", "OPENFGA_CHANGE_PUBLISHER_HORIZON_OFFSET")
+
+		util.MustBindPFlag("changePublisher.consumer", flags.Lookup("change-publisher-consumer"))
+		util.MustBindEnv("changePublisher.consumer", "OPENFGA_CHANGE_PUBLISHER_CONSUMER")
+
+		util.MustBindPFlag("changePublisher.topic", flags.Lookup("change-publisher-topic"))
+		util.MustBindEnv("changePublisher.topic", "OPENFGA_CHANGE_PUBLISHER_TOPIC")
+
+		util.MustBindPFlag("changePublisher.topics", flags.Lookup("change-publisher-topics"))
+		util.MustBindEnv("changePublisher.topics", "OPENFGA_CHANGE_PUBLISHER_TOPICS")
+
+		util.MustBindPFlag("changePublisher.timeout", flags.Lookup("change-publisher-timeout"))
+		util.MustBindEnv("changePublisher.timeout", "OPENFGA_CHANGE_PUBLISHER_TIMEOUT")
+
 		util.MustBindPFlag("requestTimeout", flags.Lookup("request-timeout"))
 		util.MustBindEnv("requestTimeout", "OPENFGA_REQUEST_TIMEOUT")
+
+		util.MustBindPFlag("requestTimeouts.check", flags.Lookup("request-timeouts-check"))
+		util.MustBindEnv("requestTimeouts.check", "OPENFGA_REQUEST_TIMEOUTS_CHECK")
+
+		util.MustBindPFlag("requestTimeouts.listObjects", flags.Lookup("request-timeouts-list-objects"))
+		util.MustBindEnv("requestTimeouts.listObjects", "OPENFGA_REQUEST_TIMEOUTS_LIST_OBJECTS")
+
+		util.MustBindPFlag("requestTimeouts.write", flags.Lookup("request-timeouts-write"))
+		util.MustBindEnv("requestTimeouts.write", "OPENFGA_REQUEST_TIMEOUTS_WRITE")
+
+		util.MustBindPFlag("drainTimeout", flags.Lookup("drain-timeout"))
+		util.MustBindEnv("drainTimeout", "OPENFGA_DRAIN_TIMEOUT")
 	}
 }
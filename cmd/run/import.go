@@ -0,0 +1,301 @@
+package run
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	parser "github.com/openfga/language/pkg/go/transformer"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/server/idgen"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// maxImportErrors is the maximum number of invalid tuples reported when an import fails.
+const maxImportErrors = 20
+
+// importedTuple is a tuple read from a tuples file, with the line it was read from.
+type importedTuple struct {
+	line int
+	key  *openfgav1.TupleKey
+}
+
+// importData creates a store with the authorization model and tuples of the import config. The model and
+// all the tuples are validated before anything is written, and the store is deleted if they fail to be
+// written, so that a failed import leaves no partially imported store behind. The IDs of the store and
+// of the model are generated by the generator.
+func importData(ctx context.Context, datastore storage.OpenFGADatastore, generator idgen.Generator, cfg serverconfig.ImportConfig, l logger.Logger) error {
+	if cfg.ModelFile == "" {
+		return nil
+	}
+
+	existing, err := findStoreByName(ctx, datastore, cfg.StoreName)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		l.Info("skipping import, the store already exists", zap.String("store_id", existing.GetId()), zap.String("store_name", cfg.StoreName))
+		return nil
+	}
+
+	model, err := readModelFile(cfg.ModelFile)
+	if err != nil {
+		return fmt.Errorf("failed to read authorization model file '%s': %w", cfg.ModelFile, err)
+	}
+
+	storeID, err := generator.NewStoreID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to generate store ID: %w", err)
+	}
+
+	model.Id, err = generator.NewAuthorizationModelID(ctx, storeID)
+	if err != nil {
+		return fmt.Errorf("failed to generate authorization model ID: %w", err)
+	}
+
+	typesys, err := typesystem.NewAndValidate(ctx, model)
+	if err != nil {
+		return fmt.Errorf("invalid authorization model in '%s': %w", cfg.ModelFile, err)
+	}
+
+	var tuples []importedTuple
+	if cfg.TuplesFile != "" {
+		tuples, err = readTuplesFile(cfg.TuplesFile)
+		if err != nil {
+			return fmt.Errorf("failed to read tuples file '%s': %w", cfg.TuplesFile, err)
+		}
+
+		if err := validateImportedTuples(typesys, tuples); err != nil {
+			return fmt.Errorf("invalid tuples in '%s': %w", cfg.TuplesFile, err)
+		}
+	}
+
+	store, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: cfg.StoreName})
+	if err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+
+	if err := populateStore(ctx, datastore, store.GetId(), model, tuples); err != nil {
+		// the import is retried on the next startup, which only imports into a new store
+		if deleteErr := datastore.DeleteStore(ctx, store.GetId()); deleteErr != nil {
+			return fmt.Errorf("%w (failed to delete the partially imported store '%s': %v)", err, store.GetId(), deleteErr)
+		}
+		return err
+	}
+
+	l.Info("imported authorization model and tuples",
+		zap.String("store_id", store.GetId()),
+		zap.String("store_name", store.GetName()),
+		zap.String("authorization_model_id", model.GetId()),
+		zap.Int("tuple_count", len(tuples)))
+
+	return nil
+}
+
+// populateStore writes the authorization model and the tuples into the store.
+func populateStore(ctx context.Context, datastore storage.OpenFGADatastore, storeID string, model *openfgav1.AuthorizationModel, tuples []importedTuple) error {
+	if err := datastore.WriteAuthorizationModel(ctx, storeID, model); err != nil {
+		return fmt.Errorf("failed to write authorization model: %w", err)
+	}
+
+	for start := 0; start < len(tuples); start += datastore.MaxTuplesPerWrite() {
+		end := min(start+datastore.MaxTuplesPerWrite(), len(tuples))
+
+		writes := make([]*openfgav1.TupleKey, 0, end-start)
+		for _, t := range tuples[start:end] {
+			writes = append(writes, t.key)
+		}
+
+		if err := datastore.Write(ctx, storeID, nil, writes); err != nil {
+			return fmt.Errorf("failed to write tuples: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func findStoreByName(ctx context.Context, datastore storage.OpenFGADatastore, name string) (*openfgav1.Store, error) {
+	var from string
+	for {
+		stores, token, err := datastore.ListStores(ctx, storage.ListStoresFilter{NameContains: name}, storage.PaginationOptions{PageSize: storage.DefaultPageSize, From: from})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stores: %w", err)
+		}
+
+		for _, store := range stores {
+			if store.GetName() == name {
+				return store, nil
+			}
+		}
+
+		from = string(token)
+		if from == "" {
+			return nil, nil
+		}
+	}
+}
+
+// readModelFile reads an authorization model in JSON, if the file has a '.json' extension, or in the DSL.
+func readModelFile(path string) (*openfgav1.AuthorizationModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return parser.LoadJSONStringToProto(string(data))
+	}
+
+	return parser.TransformDSLToProto(string(data))
+}
+
+// readTuplesFile reads tuples in JSONL, if the file has a '.jsonl' extension, or in CSV.
+func readTuplesFile(path string) ([]importedTuple, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		return readJSONLTuples(f)
+	}
+
+	return readCSVTuples(f)
+}
+
+// readJSONLTuples reads a JSON tuple key (e.g. {"user": "user:anne", "relation": "viewer", "object": "document:1"})
+// per line. Blank lines are skipped.
+func readJSONLTuples(r io.Reader) ([]importedTuple, error) {
+	var tuples []importedTuple
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		tk := &openfgav1.TupleKey{}
+		if err := protojson.Unmarshal([]byte(text), tk); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		tuples = append(tuples, importedTuple{line: line, key: tk})
+	}
+
+	return tuples, scanner.Err()
+}
+
+// readCSVTuples reads tuples from a CSV file with a header that names the 'user', 'relation' and 'object'
+// columns, and optionally the 'condition_name' and 'condition_context' (a JSON object) columns.
+func readCSVTuples(r io.Reader) ([]importedTuple, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, required := range []string{"user", "relation", "object"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing '%s' column in header", required)
+		}
+	}
+
+	column := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var tuples []importedTuple
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		var conditionContext *structpb.Struct
+		if value := column(record, "condition_context"); value != "" {
+			conditionContext = &structpb.Struct{}
+			if err := protojson.Unmarshal([]byte(value), conditionContext); err != nil {
+				return nil, fmt.Errorf("line %d: invalid condition_context: %w", line, err)
+			}
+		}
+
+		tk := tuple.NewTupleKeyWithCondition(
+			column(record, "object"),
+			column(record, "relation"),
+			column(record, "user"),
+			column(record, "condition_name"),
+			conditionContext,
+		)
+
+		tuples = append(tuples, importedTuple{line: line, key: tk})
+	}
+
+	return tuples, nil
+}
+
+// validateImportedTuples validates the tuples against the model and reports the lines of the invalid
+// and duplicate tuples.
+func validateImportedTuples(typesys *typesystem.TypeSystem, tuples []importedTuple) error {
+	var errs []error
+	lines := make(map[string]int, len(tuples))
+
+	for _, t := range tuples {
+		if len(errs) == maxImportErrors {
+			errs = append(errs, errors.New("too many invalid tuples, the remaining tuples were not validated"))
+			break
+		}
+
+		if err := validation.ValidateTuple(typesys, t.key); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", t.line, err))
+			continue
+		}
+
+		key := tuple.TupleKeyToString(t.key)
+		if line, ok := lines[key]; ok {
+			errs = append(errs, fmt.Errorf("line %d: duplicate of the tuple on line %d", t.line, line))
+			continue
+		}
+		lines[key] = t.line
+	}
+
+	return errors.Join(errs...)
+}
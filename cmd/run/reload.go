@@ -0,0 +1,158 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/server"
+)
+
+// levelSetter is implemented by loggers whose level can be changed at runtime.
+type levelSetter interface {
+	SetLevel(level string) error
+}
+
+// watchConfigReload reloads the server configuration every time the process receives a SIGHUP,
+// until the provided context is done.
+func (s *ServerContext) watchConfigReload(ctx context.Context, svr *server.Server, config *serverconfig.Config) {
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(reload)
+
+		current := config
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reload:
+				s.Logger.Info("received SIGHUP, reloading the server configuration")
+
+				updated, err := s.reloadConfig(svr, current)
+				if err != nil {
+					s.Logger.Error("failed to reload the server configuration, keeping the current configuration", zap.Error(err))
+					continue
+				}
+				current = updated
+				s.config.Store(updated)
+			}
+		}
+	}()
+}
+
+// reloadConfig reads the server configuration again and applies the settings that can be changed
+// without a restart to the running server. These are the log level, the Check query cache TTL, the
+// dispatch throttling frequency and thresholds, and the admission control thresholds. If the new configuration is invalid it is rejected
+// and the current configuration is kept. The configuration in effect is returned.
+func (s *ServerContext) reloadConfig(svr *server.Server, current *serverconfig.Config) (*serverconfig.Config, error) {
+	updated, err := ReadConfig()
+	if err != nil {
+		return current, err
+	}
+
+	if err := updated.Verify(); err != nil {
+		return current, fmt.Errorf("invalid config: %w", err)
+	}
+
+	// Verify only checks the dispatch throttling settings of a config with throttling enabled, but
+	// they are applied to the running server whenever it throttles. All the settings are validated
+	// before any of them is applied, so that a rejected reload leaves the running server unchanged.
+	if current.DispatchThrottling.Enabled {
+		if updated.DispatchThrottling.Frequency <= 0 {
+			return current, fmt.Errorf("invalid config: 'dispatchThrottling.frequency' must be positive")
+		}
+
+		if updated.DispatchThrottling.Threshold == 0 {
+			return current, fmt.Errorf("invalid config: 'dispatchThrottling.threshold' must be positive")
+		}
+
+		if updated.DispatchThrottling.MaxThreshold != 0 && updated.DispatchThrottling.Threshold > updated.DispatchThrottling.MaxThreshold {
+			return current, fmt.Errorf("invalid config: 'dispatchThrottling.threshold' must be less than or equal to 'dispatchThrottling.maxThreshold'")
+		}
+	}
+
+	// likewise the admission control thresholds are applied whenever admission control is enabled
+	if s.admissionController != nil {
+		if updated.AdmissionControl.MaxInflightRequests < 0 || updated.AdmissionControl.MaxGoroutines < 0 {
+			return current, fmt.Errorf("invalid config: 'admissionControl.maxInflightRequests' and 'admissionControl.maxGoroutines' must be non-negative")
+		}
+
+		if updated.AdmissionControl.MaxInflightRequests == 0 && updated.AdmissionControl.MaxGoroutines == 0 && updated.AdmissionControl.MaxMemoryBytes == 0 {
+			return current, fmt.Errorf("invalid config: 'admissionControl' requires at least one of 'admissionControl.maxInflightRequests', 'admissionControl.maxGoroutines' and 'admissionControl.maxMemoryBytes' to be set")
+		}
+	}
+
+	// the log level is applied first, it is the only setting that can still fail to apply
+	if updated.Log.Level != current.Log.Level {
+		setter, ok := s.Logger.(levelSetter)
+		if !ok {
+			return current, fmt.Errorf("the log level cannot be changed at runtime")
+		}
+
+		if err := setter.SetLevel(updated.Log.Level); err != nil {
+			return current, err
+		}
+	}
+
+	if current.DispatchThrottling.Enabled {
+		err := svr.SetDispatchThrottlingConfig(
+			updated.DispatchThrottling.Frequency,
+			updated.DispatchThrottling.Threshold,
+			updated.DispatchThrottling.MaxThreshold,
+		)
+		if err != nil {
+			return current, err
+		}
+	}
+
+	if current.CheckQueryCache.Enabled {
+		svr.SetCheckQueryCacheTTL(updated.CheckQueryCache.TTL)
+	}
+
+	if s.admissionController != nil {
+		s.admissionController.SetThresholds(
+			updated.AdmissionControl.MaxInflightRequests,
+			updated.AdmissionControl.MaxGoroutines,
+			updated.AdmissionControl.MaxMemoryBytes,
+		)
+	}
+
+	// only the settings above take effect without a restart, so the configuration in effect is the
+	// current configuration with them updated
+	effective := *current
+	effective.Log.Level = updated.Log.Level
+	effective.CheckQueryCache.TTL = updated.CheckQueryCache.TTL
+	effective.DispatchThrottling.Frequency = updated.DispatchThrottling.Frequency
+	effective.DispatchThrottling.Threshold = updated.DispatchThrottling.Threshold
+	effective.DispatchThrottling.MaxThreshold = updated.DispatchThrottling.MaxThreshold
+	if s.admissionController != nil {
+		effective.AdmissionControl.MaxInflightRequests = updated.AdmissionControl.MaxInflightRequests
+		effective.AdmissionControl.MaxGoroutines = updated.AdmissionControl.MaxGoroutines
+		effective.AdmissionControl.MaxMemoryBytes = updated.AdmissionControl.MaxMemoryBytes
+	}
+
+	if !reflect.DeepEqual(&effective, updated) {
+		s.Logger.Warn("some of the changed settings can only take effect after a restart")
+	}
+
+	s.Logger.Info("reloaded the server configuration",
+		zap.String("log_level", effective.Log.Level),
+		zap.Duration("check_query_cache_ttl", effective.CheckQueryCache.TTL),
+		zap.Duration("dispatch_throttling_frequency", effective.DispatchThrottling.Frequency),
+		zap.Uint32("dispatch_throttling_threshold", effective.DispatchThrottling.Threshold),
+		zap.Uint32("dispatch_throttling_max_threshold", effective.DispatchThrottling.MaxThreshold),
+		zap.Int("admission_control_max_inflight_requests", effective.AdmissionControl.MaxInflightRequests),
+		zap.Int("admission_control_max_goroutines", effective.AdmissionControl.MaxGoroutines),
+		zap.Uint64("admission_control_max_memory_bytes", effective.AdmissionControl.MaxMemoryBytes),
+	)
+
+	return &effective, nil
+}
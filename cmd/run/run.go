@@ -14,6 +14,7 @@ import (
 	goruntime "runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -37,8 +38,10 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
+	"sigs.k8s.io/yaml"
 
 	"github.com/openfga/openfga/pkg/gateway"
 
@@ -47,19 +50,45 @@ import (
 	"github.com/openfga/openfga/internal/authn/oidc"
 	"github.com/openfga/openfga/internal/authn/presharedkey"
 	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/compression"
 	authnmw "github.com/openfga/openfga/internal/middleware/authn"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/backup"
+	"github.com/openfga/openfga/pkg/changepublisher"
+	"github.com/openfga/openfga/pkg/encoder"
+	"github.com/openfga/openfga/pkg/encrypter"
+	"github.com/openfga/openfga/pkg/featureflags"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/middleware"
+	"github.com/openfga/openfga/pkg/middleware/admission"
+	"github.com/openfga/openfga/pkg/middleware/connections"
+	"github.com/openfga/openfga/pkg/middleware/controlplane"
+	"github.com/openfga/openfga/pkg/middleware/drain"
 	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
 	"github.com/openfga/openfga/pkg/middleware/logging"
+	"github.com/openfga/openfga/pkg/middleware/profiling"
 	"github.com/openfga/openfga/pkg/middleware/recovery"
 	"github.com/openfga/openfga/pkg/middleware/requestid"
+	"github.com/openfga/openfga/pkg/middleware/requestlimits"
 	"github.com/openfga/openfga/pkg/middleware/storeid"
+	"github.com/openfga/openfga/pkg/middleware/storelabels"
 	"github.com/openfga/openfga/pkg/middleware/validator"
 	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/server/assertions"
+	"github.com/openfga/openfga/pkg/server/cachewarming"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/server/health"
+	"github.com/openfga/openfga/pkg/server/idgen"
+	"github.com/openfga/openfga/pkg/server/integrity"
+	"github.com/openfga/openfga/pkg/server/metering"
+	"github.com/openfga/openfga/pkg/server/modeleditor"
+	"github.com/openfga/openfga/pkg/server/modelgraph"
+	"github.com/openfga/openfga/pkg/server/multicheck"
+	"github.com/openfga/openfga/pkg/server/openapi"
+	"github.com/openfga/openfga/pkg/server/relationusage"
+	"github.com/openfga/openfga/pkg/server/statistics"
+	"github.com/openfga/openfga/pkg/server/storesettings"
+	"github.com/openfga/openfga/pkg/server/writegroup"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/storage/mysql"
@@ -72,6 +101,9 @@ import (
 const (
 	datastoreEngineFlag = "datastore-engine"
 	datastoreURIFlag    = "datastore-uri"
+
+	validateConfigFlag = "validate-config"
+	printConfigFlag    = "print-config"
 )
 
 func NewRunCommand() *cobra.Command {
@@ -86,8 +118,14 @@ func NewRunCommand() *cobra.Command {
 	defaultConfig := serverconfig.DefaultConfig()
 	flags := cmd.Flags()
 
+	flags.Bool(validateConfigFlag, false, "validate the configuration resolved from the flags, the environment variables and the config file, reporting the keys of the config file and the OPENFGA_ environment variables that aren't configuration keys, and exit without running the server")
+
+	flags.Bool(printConfigFlag, false, "print the configuration resolved from the flags, the environment variables and the config file, with the secrets redacted, and exit without running the server")
+
 	flags.StringSlice("experimentals", defaultConfig.Experimentals, "a list of experimental features to enable")
 
+	flags.StringSlice("feature-flags", defaultConfig.FeatureFlags, "rules of the form 'flag=value' that gate the experimental behaviors per store, e.g. 'check-planner=10%'. The flags are 'check-planner', 'list-objects-shards' and 'consistency-tokens', and the value is 'on', 'off', a store ID or a percentage of the stores. A flag without rules is on for all the stores")
+
 	flags.String("grpc-addr", defaultConfig.GRPC.Addr, "the host:port address to serve the grpc server on")
 
 	flags.Bool("grpc-tls-enabled", defaultConfig.GRPC.TLS.Enabled, "enable/disable transport layer security (TLS)")
@@ -98,6 +136,28 @@ func NewRunCommand() *cobra.Command {
 
 	cmd.MarkFlagsRequiredTogether("grpc-tls-enabled", "grpc-tls-cert", "grpc-tls-key")
 
+	flags.Bool("grpc-reflection-enabled", defaultConfig.GRPC.ReflectionEnabled, "enable/disable the gRPC server reflection service")
+
+	flags.StringSlice("grpc-compression", defaultConfig.GRPC.Compression, "the compression algorithms ('gzip', 'zstd') that clients can compress requests with. Responses are compressed with the algorithm of the request")
+
+	flags.Int("grpc-max-recv-msg-size-in-bytes", defaultConfig.GRPC.MaxRecvMsgSizeInBytes, "the maximum size in bytes of a request message")
+
+	flags.Int("grpc-max-send-msg-size-in-bytes", defaultConfig.GRPC.MaxSendMsgSizeInBytes, "the maximum size in bytes of a response message, also for the responses of the HTTP server")
+
+	flags.Uint32("grpc-max-concurrent-streams", defaultConfig.GRPC.MaxConcurrentStreams, "the maximum number of concurrent requests on a client connection")
+
+	flags.Duration("grpc-max-connection-age", defaultConfig.GRPC.MaxConnectionAge, "the maximum duration of a client connection before it is gracefully closed so that the client reconnects, possibly to another server. 0 means that the connections are never closed")
+
+	flags.Duration("grpc-max-connection-age-grace", defaultConfig.GRPC.MaxConnectionAgeGrace, "how long the in-flight requests of a connection that reached the max connection age have to finish before it is forcibly closed. 0 means that they always finish")
+
+	flags.Duration("grpc-keepalive-time", defaultConfig.GRPC.KeepaliveTime, "how long a client connection has to be idle before the server pings the client to check that it is still alive")
+
+	flags.Duration("grpc-keepalive-timeout", defaultConfig.GRPC.KeepaliveTimeout, "how long the server waits for a keepalive ping to be acknowledged before closing the connection")
+
+	flags.Duration("grpc-keepalive-enforcement-min-time", defaultConfig.GRPC.KeepaliveEnforcementMinTime, "the minimum interval between the keepalive pings of a client. The connections of the clients that ping more often are closed")
+
+	flags.Bool("grpc-keepalive-enforcement-permit-without-stream", defaultConfig.GRPC.KeepaliveEnforcementPermitWithoutStream, "allow the clients to send keepalive pings on connections without in-flight requests")
+
 	flags.Bool("http-enabled", defaultConfig.HTTP.Enabled, "enable/disable the OpenFGA HTTP server")
 
 	flags.String("http-addr", defaultConfig.HTTP.Addr, "the host:port address to serve the HTTP server on")
@@ -116,6 +176,16 @@ func NewRunCommand() *cobra.Command {
 
 	flags.StringSlice("http-cors-allowed-headers", defaultConfig.HTTP.CORSAllowedHeaders, "specifies the CORS allowed headers")
 
+	flags.StringSlice("http-cors-exposed-headers", defaultConfig.HTTP.CORSExposedHeaders, "specifies the response headers that browsers expose to the CORS allowed origins (e.g. 'Openfga-Consistency-Token')")
+
+	flags.Duration("http-cors-max-age", defaultConfig.HTTP.CORSMaxAge, "how long browsers cache the responses of CORS preflight requests. If 0, browsers use their default")
+
+	flags.Bool("http-openapi-enabled", defaultConfig.HTTP.OpenAPIEnabled, "enable/disable serving the OpenAPI document of the API on /openapi.json and a minimal API explorer on /docs")
+
+	flags.Bool("http-compression-enabled", defaultConfig.HTTP.CompressionEnabled, "enable/disable zstd or gzip compression of the responses of clients that accept it")
+
+	flags.Int("http-compression-min-size-in-bytes", defaultConfig.HTTP.CompressionMinSizeInBytes, "the minimum size in bytes of a response to compress it")
+
 	flags.String("authn-method", defaultConfig.Authn.Method, "the authentication method to use")
 
 	flags.StringSlice("authn-preshared-keys", defaultConfig.Authn.Keys, "one or more preshared keys to use for authentication")
@@ -126,6 +196,20 @@ func NewRunCommand() *cobra.Command {
 
 	flags.StringSlice("authn-oidc-issuer-aliases", defaultConfig.Authn.IssuerAliases, "the OIDC issuer DNS aliases that will be accepted as valid when verifying tokens")
 
+	flags.Bool("control-plane-enabled", defaultConfig.ControlPlane.Enabled, "enable/disable the gRPC listener of the control-plane RPCs (CreateStore, UpdateStore, DeleteStore, WriteAuthorizationModel and WriteAssertions). Once enabled, they are only served on this listener, with its own authentication, and the gRPC and HTTP servers reject them")
+
+	flags.String("control-plane-addr", defaultConfig.ControlPlane.Addr, "the host:port address to serve the gRPC listener of the control-plane RPCs on")
+
+	flags.String("control-plane-authn-method", defaultConfig.ControlPlane.Authn.Method, "the authentication method of the control-plane RPCs")
+
+	flags.StringSlice("control-plane-authn-preshared-keys", defaultConfig.ControlPlane.Authn.Keys, "one or more preshared keys to use for the authentication of the control-plane RPCs")
+
+	flags.String("control-plane-authn-oidc-audience", defaultConfig.ControlPlane.Authn.Audience, "the OIDC audience of the tokens of the control-plane RPCs")
+
+	flags.String("control-plane-authn-oidc-issuer", defaultConfig.ControlPlane.Authn.Issuer, "the OIDC issuer (authorization server) signing the tokens of the control-plane RPCs")
+
+	flags.StringSlice("control-plane-authn-oidc-issuer-aliases", defaultConfig.ControlPlane.Authn.IssuerAliases, "the OIDC issuer DNS aliases that will be accepted as valid when verifying the tokens of the control-plane RPCs")
+
 	flags.String("datastore-engine", defaultConfig.Datastore.Engine, "the datastore engine that will be used for persistence")
 
 	flags.String("datastore-uri", defaultConfig.Datastore.URI, "the connection uri to use to connect to the datastore (for any engine other than 'memory')")
@@ -144,22 +228,84 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Duration("datastore-conn-max-lifetime", defaultConfig.Datastore.ConnMaxLifetime, "the maximum amount of time a connection to the datastore may be reused")
 
-	flags.Bool("datastore-metrics-enabled", defaultConfig.Datastore.Metrics.Enabled, "enable/disable sql metrics")
+	flags.Bool("datastore-metrics-enabled", defaultConfig.Datastore.Metrics.Enabled, "enable/disable datastore metrics: sql connection pool metrics and the latency, errors and rows returned by each datastore method")
+
+	flags.Int("datastore-metrics-store-label-limit", defaultConfig.Datastore.Metrics.StoreLabelLimit, "the maximum number of distinct stores that the per-method datastore metrics are labeled with. Other stores are labeled as 'other'. 0 disables the store_id label")
+
+	flags.Bool("datastore-hedging-enabled", defaultConfig.Datastore.Hedging.Enabled, "enable/disable hedging of datastore reads. When enabled, a second identical read is sent if a read is slower than the configured latency percentile, and the first response is used")
+
+	flags.Float64("datastore-hedging-percentile", defaultConfig.Datastore.Hedging.Percentile, "the percentile (between 0 and 100) of the observed datastore read latency after which a hedged read is sent")
+
+	flags.Duration("datastore-hedging-min-delay", defaultConfig.Datastore.Hedging.MinDelay, "the minimum amount of time to wait before sending a hedged datastore read")
+
+	flags.Bool("datastore-circuit-breaker-enabled", defaultConfig.Datastore.CircuitBreaker.Enabled, "enable/disable the datastore circuit breaker. When enabled, datastore calls fail fast with an unavailable error once too many of the recent ones failed or were slow")
+
+	flags.Float64("datastore-circuit-breaker-failure-ratio", defaultConfig.Datastore.CircuitBreaker.FailureRatio, "the ratio (between 0 and 1) of the datastore calls of a window that must fail for the circuit breaker to open")
+
+	flags.Int("datastore-circuit-breaker-min-requests", defaultConfig.Datastore.CircuitBreaker.MinRequests, "the minimum number of datastore calls of a window before the circuit breaker can open")
+
+	flags.Duration("datastore-circuit-breaker-window", defaultConfig.Datastore.CircuitBreaker.Window, "the duration of the windows the datastore calls and their failures are counted in")
+
+	flags.Duration("datastore-circuit-breaker-latency-threshold", defaultConfig.Datastore.CircuitBreaker.LatencyThreshold, "the duration after which a datastore call is counted as failed by the circuit breaker, even if it succeeds. 0 only counts the errors")
+
+	flags.Duration("datastore-circuit-breaker-open-duration", defaultConfig.Datastore.CircuitBreaker.OpenDuration, "how long the circuit breaker stays open before a single datastore call is let through to probe the datastore")
+
+	flags.String("datastore-memory-snapshot-path", defaultConfig.Datastore.Memory.SnapshotPath, "the path of the file that the contents of the memory datastore are persisted to. If empty, the memory datastore is ephemeral")
+
+	flags.Duration("datastore-memory-snapshot-interval", defaultConfig.Datastore.Memory.SnapshotInterval, "how often the contents of the memory datastore are persisted to the snapshot file, if they changed. They are also persisted on shutdown. 0 only persists them on shutdown")
+
+	flags.Bool("datastore-memory-load-snapshot", defaultConfig.Datastore.Memory.LoadSnapshot, "load the contents of the memory datastore snapshot file, if it exists, on startup")
+
+	flags.Int("datastore-postgres-statement-cache-capacity", defaultConfig.Datastore.Postgres.StatementCacheCapacity, "the number of prepared statements cached per postgres connection. 0 uses the driver default")
+
+	flags.String("datastore-postgres-query-exec-mode", defaultConfig.Datastore.Postgres.QueryExecMode, "how postgres queries are executed: 'cache_statement', 'cache_describe', 'describe_exec', 'exec' or 'simple_protocol'. Use 'exec' or 'simple_protocol' behind connection poolers that don't support prepared statements. Empty uses the driver default")
+
+	flags.Bool("datastore-postgres-notify-tuple-changes", defaultConfig.Datastore.Postgres.NotifyTupleChanges, "notify the stores of the writes with postgres NOTIFY and listen to the notifications of all the servers, so that every server invalidates the check query cache entries of the stores written by any of them within milliseconds. Requires a postgres session, so it doesn't work through a connection pooler in transaction mode")
+
+	flags.Bool("datastore-mysql-interpolate-params", defaultConfig.Datastore.MySQL.InterpolateParams, "interpolate mysql query placeholders on the client instead of preparing each statement on the server, saving a round trip per query")
+
+	flags.Bool("datastore-request-id-comments", defaultConfig.Datastore.RequestIDComments, "prefix the SQL queries with a comment with the request ID, so that they can be correlated with the request in the database logs. Prepared statements can't be reused between requests")
+
+	flags.Bool("datastore-sqlcommenter", defaultConfig.Datastore.SQLCommenter, "append a comment in the sqlcommenter format to the SQL queries, with the RPC, the request ID and the trace context, so that APM tools can link the database load to the requests. Prepared statements can't be reused between requests")
+
+	flags.Int("datastore-iterator-batch-size", defaultConfig.Datastore.IteratorBatchSize, "the number of tuples that the postgres and mysql engines read ahead of the consumer of a tuple iterator, e.g. Read or Expand on a huge relation. Once a batch is read ahead, the reads wait for the consumer, so that the memory of an iterator is bounded. 0 only reads the rows when the next tuple is requested")
+
+	flags.StringSlice("datastore-condition-context-encryption-keys", defaultConfig.Datastore.ConditionContextEncryptionKeys, "keys to encrypt the condition contexts of the tuples with in the postgres and mysql engines (envelope encryption). The first key encrypts, all of them decrypt, so keys can be rotated by prepending a new one. Condition contexts stored in plain text stay readable")
+
+	flags.String("datastore-residency-name", defaultConfig.Datastore.Residency.Name, "the name of the default datastore in the routes of the stores, e.g. 'us'")
+
+	flags.StringSlice("datastore-residency-datastores", defaultConfig.Datastore.Residency.Datastores, "additional datastores of the same engine, e.g. regional databases for data residency, as 'name=uri' pairs, e.g. 'eu=postgres://...'. They use the other connection settings of the default datastore. The stores and their labels stay in the default datastore")
+
+	flags.StringSlice("datastore-residency-stores", defaultConfig.Datastore.Residency.Stores, "rules of the form 'storeID=name' that route the data of the stores (tuples, changelog, models, assertions and settings) to the named datastores. They take precedence over the label key")
+
+	flags.String("datastore-residency-label-key", defaultConfig.Datastore.Residency.LabelKey, "the key of the store label whose value is the name of the datastore of the store, e.g. 'region'. The label must name a datastore. The stores without it use the default datastore. Changing the datastore of a store doesn't move its data")
 
 	flags.Bool("playground-enabled", defaultConfig.Playground.Enabled, "enable/disable the OpenFGA Playground")
 
 	flags.Int("playground-port", defaultConfig.Playground.Port, "the port to serve the local OpenFGA Playground on")
 
+	flags.Bool("model-editor-enabled", defaultConfig.ModelEditor.Enabled, "enable/disable the endpoints of the HTTP server that back a local model editor, on /model-editor/validate, /model-editor/evaluate and /model-editor/graph")
+
+	flags.Int("model-editor-max-tuples", defaultConfig.ModelEditor.MaxTuples, "the maximum number of sample tuples of a request to /model-editor/evaluate")
+
 	flags.Bool("profiler-enabled", defaultConfig.Profiler.Enabled, "enable/disable pprof profiling")
 
 	flags.String("profiler-addr", defaultConfig.Profiler.Addr, "the host:port address to serve the pprof profiler server on")
 
+	flags.Bool("admin-enabled", defaultConfig.Admin.Enabled, "enable/disable the admin server, which serves the log levels on '/log/levels' to change them at runtime with a PUT of {\"module\": ..., \"level\": ...}, and replays a Check request with the trace of its resolution on '/debug/check' with a POST of {\"request\": ..., \"bypass_cache\": ...}, serves the feature flags on '/feature-flags' to change them at runtime with a PUT of {\"flag\": ..., \"values\": [...]}, serves the configuration in effect, with the secrets redacted, on '/config', flushes the Check query cache on '/cache/flush' with a POST, of the store of its 'store_id' query parameter if any, toggles the draining of the server on '/drain' with a PUT of {\"draining\": ...}, serves the runtime statistics of the server on '/stats', and serves the reports of the integrity checker of the orphaned tuples on '/integrity', of the store of its 'store_id' query parameter if any, and checks the stores right away with a POST, with the cleanup mode of its 'cleanup' query parameter ('none' by default), and serves the usage of the stores metered by this instance on '/metering' if the metering is enabled")
+
+	flags.String("admin-addr", defaultConfig.Admin.Addr, "the host:port address to serve the admin server on. It should only be reachable by the operators")
+
+	flags.StringSlice("admin-preshared-keys", defaultConfig.Admin.PresharedKeys, "one or more preshared keys to authenticate the requests of the admin server with, as a bearer token. They are separate from the keys of the OpenFGA API. Without keys, the admin server isn't authenticated")
+
 	flags.String("log-format", defaultConfig.Log.Format, "the log format to output logs in")
 
 	flags.String("log-level", defaultConfig.Log.Level, "the log level to use")
 
 	flags.String("log-timestamp-format", defaultConfig.Log.TimestampFormat, "the timestamp format to use for log messages")
 
+	flags.StringSlice("log-module-levels", defaultConfig.Log.ModuleLevels, "rules of the form 'module=level' that set the log level of a module apart from the log level, e.g. 'graph=debug'. The modules are 'authn', 'graph', 'server' and 'storage'")
+
 	flags.Bool("trace-enabled", defaultConfig.Trace.Enabled, "enable tracing")
 
 	flags.String("trace-otlp-endpoint", defaultConfig.Trace.OTLP.Endpoint, "the endpoint of the trace collector")
@@ -176,16 +322,38 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Bool("metrics-enable-rpc-histograms", defaultConfig.Metrics.EnableRPCHistograms, "enables prometheus histogram metrics for RPC latency distributions")
 
+	flags.Bool("metrics-otlp-enabled", defaultConfig.Metrics.OTLP.Enabled, "enable/disable exporting metrics to an OpenTelemetry collector. The metrics keep the names they have on the prometheus '/metrics' endpoint")
+
+	flags.String("metrics-otlp-endpoint", defaultConfig.Metrics.OTLP.Endpoint, "the grpc endpoint of the OpenTelemetry collector to export metrics to")
+
+	flags.Bool("metrics-otlp-tls-enabled", defaultConfig.Metrics.OTLP.TLS.Enabled, "use TLS connection for the OpenTelemetry metrics collector")
+
+	flags.Duration("metrics-otlp-export-interval", defaultConfig.Metrics.OTLP.ExportInterval, "the interval at which metrics are exported to the OpenTelemetry collector")
+
 	flags.Int("max-tuples-per-write", defaultConfig.MaxTuplesPerWrite, "the maximum allowed number of tuples per Write transaction")
 
 	flags.Int("max-types-per-authorization-model", defaultConfig.MaxTypesPerAuthorizationModel, "the maximum allowed number of type definitions per authorization model")
 
 	flags.Int("max-authorization-model-size-in-bytes", defaultConfig.MaxAuthorizationModelSizeInBytes, "the maximum size in bytes allowed for persisting an Authorization Model.")
 
+	flags.Int("max-relations-per-type-definition", defaultConfig.MaxRelationsPerTypeDefinition, "the maximum allowed number of relations of each type definition of an authorization model written with WriteAuthorizationModel. 0 means no limit")
+
+	flags.Int("max-authorization-model-rewrite-depth", defaultConfig.MaxAuthorizationModelRewriteDepth, "the maximum allowed nesting of the unions, intersections and exclusions of the rewrite of each relation of an authorization model written with WriteAuthorizationModel, e.g. 'define viewer: [user] or editor' has a depth of 2. 0 means no limit")
+
+	flags.Int("max-condition-expression-size-in-bytes", defaultConfig.MaxConditionExpressionSizeInBytes, "the maximum allowed size in bytes of the expression of each condition of an authorization model written with WriteAuthorizationModel. 0 means no limit")
+
+	flags.Int("max-contextual-tuples", defaultConfig.MaxContextualTuples, "the maximum allowed number of contextual tuples in a single Check, ListObjects or StreamedListObjects request")
+
+	flags.Int("max-context-size-in-bytes", defaultConfig.MaxContextSizeInBytes, "the maximum allowed size in bytes of the context of a single Check, ListObjects or StreamedListObjects request, and of the condition context of each written or contextual tuple")
+
+	flags.Duration("consistency-token-timeout", defaultConfig.ConsistencyTokenTimeout, "the maximum amount of time a Check, ListObjects or StreamedListObjects request with a consistency token (the Openfga-Consistency-Token header returned by Write) waits for the datastore to catch up with the write of the token")
+
 	flags.Uint32("max-concurrent-reads-for-list-objects", defaultConfig.MaxConcurrentReadsForListObjects, "the maximum allowed number of concurrent datastore reads in a single ListObjects or StreamedListObjects query. A high number will consume more connections from the datastore pool and will attempt to prioritize performance for the request at the expense of other queries performance.")
 
 	flags.Uint32("max-concurrent-reads-for-check", defaultConfig.MaxConcurrentReadsForCheck, "the maximum allowed number of concurrent datastore reads in a single Check query. A high number will consume more connections from the datastore pool and will attempt to prioritize performance for the request at the expense of other queries performance.")
 
+	flags.Uint32("check-usersets-page-size", defaultConfig.CheckUsersetsPageSize, "the number of usersets of an object that a Check query reads from the datastore at once. The usersets of a page are resolved before the next page is read. If 0, all the usersets are read at once")
+
 	flags.Int("changelog-horizon-offset", defaultConfig.ChangelogHorizonOffset, "the offset (in minutes) from the current time. Changes that occur after this offset will not be included in the response of ReadChanges")
 
 	flags.Uint32("resolve-node-limit", defaultConfig.ResolveNodeLimit, "maximum resolution depth to attempt before throwing an error (defines how deeply nested an authorization model can be before a query errors out).")
@@ -196,12 +364,34 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Uint32("listObjects-max-results", defaultConfig.ListObjectsMaxResults, "the maximum results to return in non-streaming ListObjects API responses. If 0, all results can be returned")
 
+	flags.Uint32("listObjects-shards", defaultConfig.ListObjectsShards, "the number of worker pools that the objects of a ListObjects request that need a Check are partitioned into by the hash of their IDs")
+
+	flags.Uint32("listObjects-shard-concurrency", defaultConfig.ListObjectsShardConcurrency, "the number of concurrent Checks of each ListObjects worker pool. If 0, it is the resolve node breadth limit")
+
 	flags.Bool("check-query-cache-enabled", defaultConfig.CheckQueryCache.Enabled, "when executing Check and ListObjects requests, enables caching. This will turn Check and ListObjects responses into eventually consistent responses")
 
 	flags.Uint32("check-query-cache-limit", defaultConfig.CheckQueryCache.Limit, "if caching of Check and ListObjects calls is enabled, this is the size limit of the cache")
 
 	flags.Duration("check-query-cache-ttl", defaultConfig.CheckQueryCache.TTL, "if caching of Check and ListObjects is enabled, this is the TTL of each value")
 
+	flags.Duration("check-query-cache-negative-ttl", defaultConfig.CheckQueryCache.NegativeTTL, "if caching of Check and ListObjects is enabled and this is not 0, this is the TTL of each value whose result is not allowed, instead of the check-query-cache-ttl")
+
+	flags.Uint64("check-query-cache-max-bytes", defaultConfig.CheckQueryCache.MaxBytes, "if caching of Check and ListObjects is enabled and this is not 0, the cache is bounded by the estimated number of bytes of its entries instead of the limit in items")
+
+	flags.Bool("check-query-cache-serve-stale", defaultConfig.CheckQueryCache.ServeStale, "if caching of Check and ListObjects is enabled, serve the expired cached values that are still in the cache when the datastore is unavailable (e.g. the datastore circuit breaker is open), instead of failing. Check responses with such results have the Openfga-Stale-Result header")
+
+	flags.Bool("check-query-cache-watermark-enabled", defaultConfig.CheckQueryCache.WatermarkEnabled, "if caching of Check and ListObjects is enabled, key the cached values by the changelog watermark of their store, so that they are no longer served once the tuples of the store change and can be cached with a much longer TTL")
+
+	flags.Duration("check-query-cache-watermark-refresh-interval", defaultConfig.CheckQueryCache.WatermarkRefreshInterval, "how often the changelog of a store is read to move its watermark when the check query cache watermark is enabled, which bounds the staleness of the cached values")
+
+	flags.Bool("list-objects-cache-enabled", defaultConfig.ListObjectsCache.Enabled, "cache the results of the ListObjects requests by store, model, type, relation, user, contextual tuples and context, until the changelog watermark of their store moves or their TTL expires")
+
+	flags.Uint32("list-objects-cache-limit", defaultConfig.ListObjectsCache.Limit, "if caching of ListObjects results is enabled, this is the size limit (in items) of the cache")
+
+	flags.Duration("list-objects-cache-ttl", defaultConfig.ListObjectsCache.TTL, "if caching of ListObjects results is enabled, this is the TTL of each result")
+
+	flags.Duration("list-objects-cache-watermark-refresh-interval", defaultConfig.ListObjectsCache.WatermarkRefreshInterval, "if caching of ListObjects results is enabled, how often the changelog of a store is read to move its watermark, which bounds the staleness of the cached results")
+
 	// Unfortunately UintSlice/IntSlice does not work well when used as environment variable, we need to stick with string slice and convert back to integer
 	flags.StringSlice("request-duration-datastore-query-count-buckets", defaultConfig.RequestDurationDatastoreQueryCountBuckets, "datastore query count buckets used in labelling request_duration_ms.")
 
@@ -215,8 +405,190 @@ func NewRunCommand() *cobra.Command {
 
 	flags.Uint32("dispatch-throttling-max-threshold", defaultConfig.DispatchThrottling.MaxThreshold, "define the maximum dispatch threshold beyond which requests will be throttled. 0 will use the 'dispatch-throttling-threshold' value as maximum")
 
+	flags.Bool("slow-request-log-enabled", defaultConfig.SlowRequestLog.Enabled, "enables logging of Check and ListObjects requests that take longer than the slow request log threshold to resolve")
+
+	flags.Duration("slow-request-log-threshold", defaultConfig.SlowRequestLog.Threshold, "the duration after which a Check or ListObjects request is logged as slow")
+
+	flags.Bool("admission-control-enabled", defaultConfig.AdmissionControl.Enabled, "enables shedding of requests, with a RESOURCE_EXHAUSTED error and a retry-after header, while the load of the server exceeds one of the admission control thresholds")
+
+	flags.Int("admission-control-max-inflight-requests", defaultConfig.AdmissionControl.MaxInflightRequests, "the number of in-flight requests from which requests are shed. 0 means no limit")
+
+	flags.Int("admission-control-max-goroutines", defaultConfig.AdmissionControl.MaxGoroutines, "the number of goroutines from which requests are shed. 0 means no limit")
+
+	flags.Uint64("admission-control-max-memory-bytes", defaultConfig.AdmissionControl.MaxMemoryBytes, "the number of bytes of heap objects from which requests are shed. 0 means no limit")
+
+	flags.Float64("admission-control-low-priority-ratio", defaultConfig.AdmissionControl.LowPriorityRatio, "the ratio (between 0 and 1) of the admission control thresholds from which the low priority requests (ListObjects, StreamedListObjects, Expand and the Check requests of stores with a high dispatch count) are shed")
+
+	flags.Uint32("admission-control-high-dispatch-count", defaultConfig.AdmissionControl.HighDispatchCount, "the average dispatch count of the recent Check requests of a store from which the Check requests of the store have a low priority. 0 gives all the Check requests a high priority")
+
+	flags.Duration("admission-control-retry-after", defaultConfig.AdmissionControl.RetryAfter, "the delay after which the clients are told to retry a shed request, in the retry-after header")
+
+	flags.Uint32("check-budget-max-dispatch-count", defaultConfig.CheckBudget.MaxDispatchCount, "the maximum number of dispatches allowed to resolve a single Check request. Requests that exceed it fail with a resolution too complex error. 0 means no limit")
+
+	flags.Uint32("check-budget-max-datastore-read-count", defaultConfig.CheckBudget.MaxDatastoreReadCount, "the maximum number of datastore reads allowed to resolve a single Check request. Requests that exceed it fail with a resolution too complex error. 0 means no limit")
+
+	flags.Bool("check-read-deduplication-enabled", defaultConfig.CheckReadDeduplication.Enabled, "memoize the datastore reads of each Check request, so that the identical reads of the branches of its resolution only reach the datastore once")
+
+	flags.Int("check-read-deduplication-max-tuples-per-read", defaultConfig.CheckReadDeduplication.MaxTuplesPerRead, "the maximum number of tuples of a memoized read of a Check request. The larger reads are repeated")
+
+	flags.Bool("check-dispatch-pool-enabled", defaultConfig.CheckDispatchPool.Enabled, "run the concurrent dispatches of the Check resolutions on a pool of reusable goroutines, instead of a new goroutine for each of them")
+
+	flags.Int("check-dispatch-pool-size", defaultConfig.CheckDispatchPool.Size, "the maximum number of goroutines of the Check dispatch pool. The dispatches that are started while all of them are busy run on new goroutines")
+
+	flags.Int("typesystem-cache-max-size", defaultConfig.TypesystemCache.MaxSize, "the maximum number of compiled authorization models, across all the stores, kept in memory. The least recently used ones are evicted once it is exceeded")
+
+	flags.Bool("check-planner-enabled", defaultConfig.CheckPlanner.Enabled, "enable the planner that chooses how Check resolves usersets and tuple to userset rewrites (forward expansion, reverse lookup or direct tuple probe) based on statistics about the cardinality of the relations of each store")
+
+	flags.Duration("check-planner-statistics-interval", defaultConfig.CheckPlanner.StatisticsInterval, "how often the Check planner statistics are persisted to the datastore, if they changed. They are also persisted on shutdown. 0 only persists them on shutdown")
+
+	flags.Int("check-planner-max-stores", defaultConfig.CheckPlanner.MaxStores, "the maximum number of stores whose Check planner statistics are kept in memory. The statistics of the least recently used store are dropped once it is reached")
+
+	flags.Bool("tuple-statistics-enabled", defaultConfig.TupleStatistics.Enabled, "enable the background collection of approximate statistics about the tuples of each store (tuple counts and fan-out per relation), exposed on '/stores/{store_id}/statistics' and as metrics, and used by the Check planner")
+
+	flags.Duration("tuple-statistics-interval", defaultConfig.TupleStatistics.Interval, "how often the tuple statistics are collected")
+
+	flags.Int("tuple-statistics-max-tuples-per-store", defaultConfig.TupleStatistics.MaxTuplesPerStore, "the maximum number of tuples scanned per store on each collection of the tuple statistics. The statistics of larger stores are sampled. 0 scans all the tuples")
+
+	flags.Bool("relation-usage-enabled", defaultConfig.RelationUsage.Enabled, "enable the tracking of how often Check evaluates each relation of each store and how often it allows it, exposed on '/stores/{store_id}/relation-usage'")
+
+	flags.Float64("relation-usage-sample-ratio", defaultConfig.RelationUsage.SampleRatio, "the fraction (between 0 and 1) of the relation evaluations that are recorded. The counts are estimated from them")
+
+	flags.Duration("relation-usage-flush-interval", defaultConfig.RelationUsage.FlushInterval, "how often the recorded relation evaluations are added to the usage kept in the datastore. They are also flushed on shutdown")
+
+	flags.String("id-generation-scheme", defaultConfig.IDGeneration.Scheme, "the scheme of the generated store and authorization model IDs: 'ulid', or 'uuidv7' for version 7 UUIDs. Both are encoded as ULIDs")
+
+	flags.Bool("id-generation-caller-supplied-store-ids", defaultConfig.IDGeneration.CallerSuppliedStoreIDs, "allow the CreateStore requests to set the ID of the created store with the 'Openfga-Store-Id' header, e.g. so that a store has the same ID in every environment. The ID must be a ULID, and can't be the ID of an existing or deleted store")
+
+	flags.String("write-admission-webhook-url", defaultConfig.WriteAdmissionWebhook.URL, "the URL of the admission webhook that the writes and deletes of each Write request are POSTed to before they are committed, and that can reject or mutate them. If empty, the writes are not reviewed")
+
+	flags.Duration("write-admission-webhook-timeout", defaultConfig.WriteAdmissionWebhook.Timeout, "the timeout of a review by the write admission webhook")
+
+	flags.String("write-admission-webhook-failure-policy", defaultConfig.WriteAdmissionWebhook.FailurePolicy, "what happens to the writes when the write admission webhook fails or times out: 'fail' rejects them, and 'ignore' commits them without review")
+
+	flags.Bool("write-groups-saga-enabled", defaultConfig.WriteGroups.SagaEnabled, "allow the write groups whose stores can't be written in a single transaction, e.g. because they are in different datastores, to be applied one store after the other with compensations. The compensations are only kept in memory, so the stores of a group stay diverged if the server stops while applying it. If false, these groups are rejected")
+
+	flags.StringSlice("delegated-check-relations", defaultConfig.DelegatedCheck.Relations, "rules of the form 'type#relation=url' that delegate the Check of the relation of the type to the external evaluator at the URL, e.g. an OPA policy, which is POSTed the store, model, object, relation, user and context of the Check as the 'input' of an OPA data API request, and responds with a boolean 'result', or a 'result' with an 'allowed' boolean. The decision is combined with the rewrite of the relation in the model")
+
+	flags.String("delegated-check-operator", defaultConfig.DelegatedCheck.Operator, "how the decision of the external evaluator of a delegated relation is combined with the rewrite of the relation: 'intersection' allows the Check if both allow it, 'union' if either allows it, and 'override' only calls the evaluator")
+
+	flags.Duration("delegated-check-timeout", defaultConfig.DelegatedCheck.Timeout, "the timeout of a decision of the external evaluator of a delegated relation, after which the Check fails")
+
+	flags.Bool("check-canary-enabled", defaultConfig.CheckCanary.Enabled, "enables the canary evaluation of the candidate authorization models of the stores: the Check requests of a store with a candidate model (the candidate_authorization_model_id store setting) are also evaluated against it in the background, and the results that differ from those of the model of the requests are logged and counted in the openfga_check_canary_evaluations_total metric. The requests are served the results of their model")
+
+	flags.Float64("check-canary-sample-ratio", defaultConfig.CheckCanary.SampleRatio, "the fraction (between 0 and 1) of the Check requests of the stores with a candidate model that are also evaluated against it")
+
+	flags.Duration("check-canary-timeout", defaultConfig.CheckCanary.Timeout, "the timeout of the evaluation of a Check request against the candidate model of its store")
+
+	flags.Int("check-canary-max-concurrent-evaluations", defaultConfig.CheckCanary.MaxConcurrentEvaluations, "the maximum number of evaluations against the candidate models in progress. The Check requests sampled while it is reached aren't evaluated against them")
+
+	flags.Bool("write-coalescing-enabled", defaultConfig.WriteCoalescing.Enabled, "enables the coalescing of the Write requests of a store into larger datastore transactions, for the high-throughput ingestion of many small writes. Each request then waits up to the write coalescing window before it is committed")
+
+	flags.Duration("write-coalescing-window", defaultConfig.WriteCoalescing.Window, "the maximum time a Write request waits for the other requests of its store when the write coalescing is enabled")
+
+	flags.Bool("peer-dispatch-enabled", defaultConfig.PeerDispatch.Enabled, "enables the dispatch of each Check subproblem to the member of the cluster of servers that owns it by the consistent hash of its object and relation, so that the cache of the subproblems is sharded across the cluster")
+
+	flags.String("peer-dispatch-self", defaultConfig.PeerDispatch.Self, "the address of this server among the members of the peer dispatch cluster, e.g. '10.0.0.1:8081'")
+
+	flags.StringSlice("peer-dispatch-peers", defaultConfig.PeerDispatch.Peers, "the static addresses of the members of the peer dispatch cluster. They are ignored if the peer dispatch DNS name is set")
+
+	flags.String("peer-dispatch-dns-name", defaultConfig.PeerDispatch.DNSName, "a host and port, e.g. 'openfga-headless:8081', whose host resolves to the addresses of the members of the peer dispatch cluster")
+
+	flags.Duration("peer-dispatch-dns-refresh-interval", defaultConfig.PeerDispatch.DNSRefreshInterval, "how often the peer dispatch DNS name is resolved again")
+
+	flags.Duration("peer-dispatch-timeout", defaultConfig.PeerDispatch.Timeout, "the timeout of a Check subproblem dispatched to a peer, after which the server resolves it itself. If 0, it only times out with the request")
+
+	flags.String("peer-dispatch-preshared-key", defaultConfig.PeerDispatch.PresharedKey, "the key that authenticates the Check subproblems the members of the peer dispatch cluster dispatch to each other")
+
+	flags.Int("tuple-validation-max-object-length", defaultConfig.TupleValidation.MaxObjectLength, "the maximum number of bytes of the object of a tuple written, at most 256")
+
+	flags.Int("tuple-validation-max-user-length", defaultConfig.TupleValidation.MaxUserLength, "the maximum number of bytes of the user of a tuple written, at most 512")
+
+	flags.StringSlice("tuple-validation-id-patterns", defaultConfig.TupleValidation.IDPatterns, "rules of the form 'type=pattern' that the IDs of the objects of a type must match when they are the object or the user of a tuple written. The pattern is 'uuid' or a regular expression that the whole ID must match, e.g. 'document=uuid'")
+
+	flags.Bool("time-travel-enabled", defaultConfig.TimeTravel.Enabled, "enable the evaluation of Check, ListObjects and StreamedListObjects requests with the Openfga-As-Of header against the tuples of the store at that time, reconstructed from the changelog of the store")
+
+	flags.Int("time-travel-max-changes", defaultConfig.TimeTravel.MaxChanges, "the maximum number of changes replayed to reconstruct the tuples of a store at a past time. 0 means no limit")
+
+	flags.Duration("time-travel-retention", defaultConfig.TimeTravel.Retention, "how long the changelog is retained. The requests evaluated at an older time are rejected, as the changelog no longer has all the changes before it. 0 means that the changelog is retained forever")
+
+	flags.StringSlice("continuation-tokens-signing-keys", defaultConfig.ContinuationTokens.SigningKeys, "the keys of the HMAC signature of the continuation tokens of Read, ReadChanges, ReadAuthorizationModels and ListStores. The first one signs the tokens, and all of them verify them, so that the signing key can be rotated. Tampered tokens are rejected. If empty, the tokens are not signed")
+
+	flags.String("continuation-tokens-encryption-key", defaultConfig.ContinuationTokens.EncryptionKey, "the key the continuation tokens are encrypted with. If empty, the tokens are not encrypted. Requires a signing key")
+
+	flags.Duration("continuation-tokens-read-changes-lifetime", defaultConfig.ContinuationTokens.ReadChangesLifetime, "how long a continuation token of ReadChanges is valid after the last change it was returned for. An older token is rejected with a 'continuation_token_expired' error with the time to restart from, as the changes after it may have been removed from the changelog. 0 means that the time travel retention is used, and that the tokens never expire without one")
+
+	flags.String("import-model-file", defaultConfig.Import.ModelFile, "the path of an authorization model (in the DSL or, with a '.json' extension, in JSON) to import into a new store on startup")
+
+	flags.String("import-tuples-file", defaultConfig.Import.TuplesFile, "the path of the tuples (in CSV with a 'user,relation,object[,condition_name,condition_context]' header or, with a '.jsonl' extension, a JSON tuple key per line) to import into the new store on startup. Requires an authorization model to import")
+
+	flags.String("import-store-name", defaultConfig.Import.StoreName, "the name of the store that the authorization model and tuples are imported into. If a store with that name already exists, nothing is imported")
+
+	flags.Bool("backup-enabled", defaultConfig.Backup.Enabled, "enable the scheduled backups of the stores (authorization models, tuples and changelog high-water mark) to object storage, which can be restored with 'openfga restore'. Enable them on a single instance")
+
+	flags.String("backup-url", defaultConfig.Backup.URL, "the location of the backups: a directory (e.g. 'file:///var/backups/openfga'), an S3 bucket and prefix (e.g. 's3://bucket/openfga') or a GCS bucket and prefix (e.g. 'gs://bucket/openfga'). The credentials of the buckets are read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, which are HMAC keys for GCS")
+
+	flags.Duration("backup-interval", defaultConfig.Backup.Interval, "how often the stores are backed up")
+
+	flags.String("backup-endpoint", defaultConfig.Backup.Endpoint, "the URL of an S3 compatible API (e.g. of MinIO) that 's3' backup URLs are written to, instead of AWS S3")
+
+	flags.String("backup-region", defaultConfig.Backup.Region, "the region of the S3 bucket of the backups")
+
+	flags.Bool("integrity-checker-enabled", defaultConfig.IntegrityChecker.Enabled, "enable the periodic check of the tuples of the stores against the latest authorization model of their store, which finds the orphaned tuples whose object type, relation, user type or condition no longer exists. The reports are served by the admin server on '/integrity' and the orphaned tuples are counted by the 'integrity_orphaned_tuples' metric. Enable it on a single instance")
+
+	flags.Duration("integrity-checker-interval", defaultConfig.IntegrityChecker.Interval, "how often the integrity of the stores is checked")
+
+	flags.String("integrity-checker-cleanup", defaultConfig.IntegrityChecker.Cleanup, "what the periodic integrity checks do with the orphaned tuples: 'none' only reports them, 'delete' deletes them, and 'quarantine' writes them to the quarantine URL, as a JSON tuple key per line, and deletes them")
+
+	flags.String("integrity-checker-quarantine-url", defaultConfig.IntegrityChecker.QuarantineURL, "the location the orphaned tuples are quarantined to: a directory (e.g. 'file:///var/quarantine/openfga'), an S3 bucket and prefix (e.g. 's3://bucket/quarantine') or a GCS bucket and prefix (e.g. 'gs://bucket/quarantine'), with the endpoint and region of the backups")
+
+	flags.Int("integrity-checker-max-reported-tuples", defaultConfig.IntegrityChecker.MaxReportedTuples, "the maximum number of orphaned tuples listed in the integrity report of a store")
+
+	flags.Bool("metering-enabled", defaultConfig.Metering.Enabled, "enable the metering of the usage of each store for chargeback: the requests and errors of each RPC, and the datastore queries, dispatches and cache hits of the Check and ListObjects requests, aggregated over windows, with the tuple counts of the tuple statistics if they are enabled. The windows are served by the admin server on '/metering', of the store of its 'store_id' query parameter if any, as JSON or CSV with its 'format' query parameter. Each instance meters the requests it serves")
+
+	flags.Duration("metering-window", defaultConfig.Metering.Window, "the duration of the metering windows, which are aligned on multiples of it")
+
+	flags.Int("metering-retention", defaultConfig.Metering.Retention, "the number of closed metering windows served by the admin server")
+
+	flags.String("metering-instance", defaultConfig.Metering.Instance, "the name of the instance in the metering windows and in the names of the exported windows. If empty, it is the hostname")
+
+	flags.String("metering-export-url", defaultConfig.Metering.ExportURL, "the location the metering windows are exported to once they close, as '<start>-<instance>.json' or '.csv': a directory (e.g. 'file:///var/metering/openfga'), an S3 bucket and prefix (e.g. 's3://bucket/metering') or a GCS bucket and prefix (e.g. 'gs://bucket/metering'), with the endpoint and region of the backups. If empty, they aren't exported")
+
+	flags.String("metering-export-format", defaultConfig.Metering.ExportFormat, "the format of the exported metering windows: 'json' or 'csv'")
+
+	flags.Bool("warmup-enabled", defaultConfig.Warmup.Enabled, "enable the warm-up of the server before it is marked ready: the connections of the pool of the datastore are established, up to the maximum number of idle connections, and the settings, labels and latest models of the warm-up stores are cached. The readiness probe reports the server as starting until the warm-up is done or times out")
+
+	flags.StringSlice("warmup-stores", defaultConfig.Warmup.Stores, "the IDs of the stores whose settings, labels and latest (or default) models are cached by the warm-up")
+
+	flags.Duration("warmup-timeout", defaultConfig.Warmup.Timeout, "the maximum duration of the warm-up, after which the server is marked ready anyway")
+
+	flags.Bool("change-publisher-enabled", defaultConfig.ChangePublisher.Enabled, "enable the publisher of the changes of the stores, as change events, to Kafka or NATS. The published changes are checkpointed in the datastore. Enable it on a single instance")
+
+	flags.String("change-publisher-url", defaultConfig.ChangePublisher.URL, "the broker the change events are published to: Kafka brokers (e.g. 'kafka://user:pass@b1:9092,b2:9092', with SASL/PLAIN if credentials are set) or a NATS server (e.g. 'nats://token@localhost:4222', with 'jetstream=true' to wait for the acknowledgements of JetStream). 'tls=true' connects with TLS")
+
+	flags.Duration("change-publisher-interval", defaultConfig.ChangePublisher.Interval, "how often the changelogs of the stores are read")
+
+	flags.Int("change-publisher-page-size", defaultConfig.ChangePublisher.PageSize, "the number of changes of a store that are read, published and checkpointed at once")
+
+	flags.Duration("change-publisher-horizon-offset", defaultConfig.ChangePublisher.HorizonOffset, "how old the changes must be to be published, so that the changes committed out of order aren't skipped")
+
+	flags.String("change-publisher-consumer", defaultConfig.ChangePublisher.Consumer, "the name of the changelog checkpoints of the publisher. Publishers to different brokers must have different names")
+
+	flags.String("change-publisher-topic", defaultConfig.ChangePublisher.Topic, "the topic (or NATS subject) of the change events without a route. It may have the '{store_id}' and '{object_type}' placeholders")
+
+	flags.StringSlice("change-publisher-topics", defaultConfig.ChangePublisher.Topics, "rules that route the change events of stores and object types to topics, of the form 'storeID/objectType=topic' where either may be '*', or 'storeID=topic'. The most specific rule wins")
+
+	flags.Duration("change-publisher-timeout", defaultConfig.ChangePublisher.Timeout, "the timeout of the publication of a page of change events")
+
 	flags.Duration("request-timeout", defaultConfig.RequestTimeout, "configures request timeout.  If both HTTP upstream timeout and request timeout are specified, request timeout will be used.")
 
+	flags.Duration("request-timeouts-check", defaultConfig.RequestTimeouts.Check, "the timeout of Check requests. If it is 0, the request timeout is used.")
+
+	flags.Duration("request-timeouts-list-objects", defaultConfig.RequestTimeouts.ListObjects, "the timeout of ListObjects and StreamedListObjects requests. If it is 0, the request timeout is used.")
+
+	flags.Duration("request-timeouts-write", defaultConfig.RequestTimeouts.Write, "the timeout of Write requests. If it is 0, the request timeout is used.")
+
+	flags.Duration("drain-timeout", defaultConfig.DrainTimeout, "the maximum duration to wait for in-flight requests to finish when the server shuts down. New requests are rejected while draining.")
+
 	// NOTE: if you add a new flag here, update the function below, too
 
 	cmd.PreRun = bindRunFlagsFunc(flags)
@@ -245,19 +617,58 @@ func ReadConfig() (*serverconfig.Config, error) {
 	return config, nil
 }
 
-func run(_ *cobra.Command, _ []string) {
+func run(cmd *cobra.Command, _ []string) {
+	if validate, _ := cmd.Flags().GetBool(validateConfigFlag); validate {
+		if _, err := ValidateConfig(); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "invalid config:\n%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "the config is valid")
+		return
+	}
+
 	config, err := ReadConfig()
 	if err != nil {
 		panic(err)
 	}
 
+	if printConfig, _ := cmd.Flags().GetBool(printConfigFlag); printConfig {
+		effective, err := yaml.Marshal(config.Effective())
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), string(effective))
+		return
+	}
+
 	if err := config.Verify(); err != nil {
 		panic(err)
 	}
 
-	logger := logger.MustNewLogger(config.Log.Format, config.Log.Level, config.Log.TimestampFormat)
+	moduleLevels, err := logger.ParseModuleLevels(config.Log.ModuleLevels)
+	if err != nil {
+		panic(err)
+	}
+
+	log, err := logger.NewLogger(
+		logger.WithFormat(config.Log.Format),
+		logger.WithLevel(config.Log.Level),
+		logger.WithTimestampFormat(config.Log.TimestampFormat),
+		logger.WithModuleLevels(moduleLevels),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	unknown, err := unknownConfig()
+	if err != nil {
+		panic(err)
+	}
+	for _, problem := range unknown {
+		log.Warn(problem + ", it is ignored")
+	}
 
-	serverCtx := &ServerContext{Logger: logger}
+	serverCtx := &ServerContext{Logger: log}
 	if err := serverCtx.Run(context.Background(), config); err != nil {
 		panic(err)
 	}
@@ -265,6 +676,70 @@ func run(_ *cobra.Command, _ []string) {
 
 type ServerContext struct {
 	Logger logger.Logger
+
+	// unaryInterceptors and streamInterceptors are the custom interceptors of the gRPC server, by
+	// their position in the chain.
+	unaryInterceptors  map[InterceptorPosition][]grpc.UnaryServerInterceptor
+	streamInterceptors map[InterceptorPosition][]grpc.StreamServerInterceptor
+
+	// config is the configuration in effect, which changes when it is reloaded.
+	config atomic.Pointer[serverconfig.Config]
+
+	// admissionController sheds the requests while the server is overloaded, if admission control is
+	// enabled. Its thresholds change when the configuration is reloaded.
+	admissionController *admission.Controller
+}
+
+// InterceptorPosition is a position in the chain of the interceptors of the gRPC server that custom
+// interceptors can be added at. The interceptors of a position run in the order they were added.
+type InterceptorPosition int
+
+const (
+	// BeforeValidation is before the request is validated. The request ID is in the context, and the
+	// store ID too for unary RPCs.
+	BeforeValidation InterceptorPosition = iota
+
+	// BeforeAuthn is after the request was validated, and before it is authenticated.
+	BeforeAuthn
+
+	// AfterAuthn is after the request was authenticated, just before it is handled.
+	AfterAuthn
+)
+
+// ServerOption defines an option that can be used to change the behavior of the server run by a
+// [ServerContext].
+type ServerOption func(s *ServerContext)
+
+// WithUnaryInterceptors adds the interceptors of the unary RPCs at the position of the chain of the
+// gRPC server, e.g. to extract a tenant from the request metadata into the context.
+func WithUnaryInterceptors(position InterceptorPosition, interceptors ...grpc.UnaryServerInterceptor) ServerOption {
+	return func(s *ServerContext) {
+		if s.unaryInterceptors == nil {
+			s.unaryInterceptors = map[InterceptorPosition][]grpc.UnaryServerInterceptor{}
+		}
+		s.unaryInterceptors[position] = append(s.unaryInterceptors[position], interceptors...)
+	}
+}
+
+// WithStreamInterceptors adds the interceptors of the streaming RPCs at the position of the chain of
+// the gRPC server.
+func WithStreamInterceptors(position InterceptorPosition, interceptors ...grpc.StreamServerInterceptor) ServerOption {
+	return func(s *ServerContext) {
+		if s.streamInterceptors == nil {
+			s.streamInterceptors = map[InterceptorPosition][]grpc.StreamServerInterceptor{}
+		}
+		s.streamInterceptors[position] = append(s.streamInterceptors[position], interceptors...)
+	}
+}
+
+// NewServerContext returns a [ServerContext] that logs with the logger, to run a server
+// programmatically with the options.
+func NewServerContext(logger logger.Logger, opts ...ServerOption) *ServerContext {
+	s := &ServerContext{Logger: logger}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func convertStringArrayToUintArray(stringArray []string) []uint {
@@ -308,72 +783,283 @@ func (s *ServerContext) telemetryConfig(ctx context.Context, config *serverconfi
 	} else {
 		otel.SetTracerProvider(noop.NewTracerProvider())
 	}
-	return tracerProviderCloser
-}
+	return tracerProviderCloser
+}
+
+func (s *ServerContext) metricsConfig(ctx context.Context, config *serverconfig.Config) func() {
+	if !config.Metrics.OTLP.Enabled {
+		return nil
+	}
+
+	s.Logger.Info(fmt.Sprintf("📈 exporting metrics every %s to '%s', tls: %t", config.Metrics.OTLP.ExportInterval, config.Metrics.OTLP.Endpoint, config.Metrics.OTLP.TLS.Enabled))
+
+	options := []telemetry.MeterOption{
+		telemetry.WithMeterOTLPEndpoint(config.Metrics.OTLP.Endpoint),
+		telemetry.WithMeterExportInterval(config.Metrics.OTLP.ExportInterval),
+		telemetry.WithMeterAttributes(
+			semconv.ServiceNameKey.String(config.Trace.ServiceName),
+			semconv.ServiceVersionKey.String(build.Version),
+		),
+	}
+
+	if !config.Metrics.OTLP.TLS.Enabled {
+		options = append(options, telemetry.WithMeterOTLPInsecure())
+	}
+
+	mp := telemetry.MustNewMeterProvider(options...)
+	return func() {
+		_ = mp.ForceFlush(ctx)
+		_ = mp.Shutdown(ctx)
+	}
+}
+
+// continuationTokenEncoder returns the encoder of the continuation tokens. The tokens are only signed,
+// versioned and encrypted by a TokenCodec if signing keys are configured.
+func continuationTokenEncoder(config serverconfig.ContinuationTokensConfig) (encoder.Encoder, error) {
+	if len(config.SigningKeys) == 0 {
+		return encoder.NewBase64Encoder(), nil
+	}
+
+	opts := []encoder.TokenCodecOption{
+		encoder.WithTokenCodecVerificationKeys(config.SigningKeys[1:]...),
+	}
+
+	if config.EncryptionKey != "" {
+		gcmEncrypter, err := encrypter.NewGCMEncrypter(config.EncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, encoder.WithTokenCodecEncrypter(gcmEncrypter))
+	}
+
+	return encoder.NewTokenCodec(config.SigningKeys[0], opts...), nil
+}
+
+// datastoreConfig returns the datastore of config, the pools of connections of its datastores that
+// can be warmed up and, if the tuple changes of a Postgres datastore are notified, the notifier of
+// the tuple changes.
+func (s *ServerContext) datastoreConfig(config *serverconfig.Config) (storage.OpenFGADatastore, []storage.ConnectionPoolWarmer, storage.TupleChangeNotifier, error) {
+	datastoreOptions := []sqlcommon.DatastoreOption{
+		sqlcommon.WithUsername(config.Datastore.Username),
+		sqlcommon.WithPassword(config.Datastore.Password),
+		sqlcommon.WithLogger(logger.ForModule(s.Logger, logger.ModuleStorage)),
+		sqlcommon.WithMaxTuplesPerWrite(config.MaxTuplesPerWrite),
+		sqlcommon.WithMaxTypesPerAuthorizationModel(config.MaxTypesPerAuthorizationModel),
+		sqlcommon.WithMaxOpenConns(config.Datastore.MaxOpenConns),
+		sqlcommon.WithMaxIdleConns(config.Datastore.MaxIdleConns),
+		sqlcommon.WithConnMaxIdleTime(config.Datastore.ConnMaxIdleTime),
+		sqlcommon.WithConnMaxLifetime(config.Datastore.ConnMaxLifetime),
+		sqlcommon.WithPostgresStatementCacheCapacity(config.Datastore.Postgres.StatementCacheCapacity),
+		sqlcommon.WithPostgresQueryExecMode(config.Datastore.Postgres.QueryExecMode),
+		sqlcommon.WithIteratorBatchSize(config.Datastore.IteratorBatchSize),
+	}
+
+	if config.Datastore.Metrics.Enabled {
+		datastoreOptions = append(datastoreOptions, sqlcommon.WithMetrics())
+	}
+
+	if config.Datastore.MySQL.InterpolateParams {
+		datastoreOptions = append(datastoreOptions, sqlcommon.WithMySQLInterpolateParams())
+	}
+
+	if config.Datastore.RequestIDComments {
+		datastoreOptions = append(datastoreOptions, sqlcommon.WithRequestIDComments())
+	}
+
+	if config.Datastore.SQLCommenter {
+		datastoreOptions = append(datastoreOptions, sqlcommon.WithSQLCommenter())
+	}
+
+	if config.Datastore.Postgres.NotifyTupleChanges {
+		datastoreOptions = append(datastoreOptions, sqlcommon.WithPostgresNotifyTupleChanges())
+	}
+
+	if len(config.Datastore.ConditionContextEncryptionKeys) > 0 {
+		keyWrapper, err := encrypter.NewLocalKeyWrapper(config.Datastore.ConditionContextEncryptionKeys...)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("initialize condition context encryption: %w", err)
+		}
+		datastoreOptions = append(datastoreOptions, sqlcommon.WithConditionContextEncrypter(encrypter.NewEnvelopeEncrypter(keyWrapper)))
+	}
+
+	dsCfg := sqlcommon.NewConfig(datastoreOptions...)
+
+	datastore, err := s.engineDatastore(config, config.Datastore.URI, dsCfg, true)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var poolWarmers []storage.ConnectionPoolWarmer
+	if warmer, ok := datastore.(storage.ConnectionPoolWarmer); ok {
+		poolWarmers = append(poolWarmers, warmer)
+	}
+
+	var tupleChangeNotifier storage.TupleChangeNotifier
+	if config.Datastore.Postgres.NotifyTupleChanges {
+		tupleChangeNotifier, _ = datastore.(storage.TupleChangeNotifier)
+	}
+
+	if len(config.Datastore.Residency.Datastores) > 0 || len(config.Datastore.Residency.Stores) > 0 || config.Datastore.Residency.LabelKey != "" {
+		datastores := map[string]storage.OpenFGADatastore{config.Datastore.Residency.Name: datastore}
+		names := []string{config.Datastore.Residency.Name}
+
+		// the connection pool metrics of the default datastore would be registered again
+		residencyCfg := *dsCfg
+		residencyCfg.ExportMetrics = false
+		for _, pair := range config.Datastore.Residency.Datastores {
+			name, uri, _ := strings.Cut(pair, "=")
+			residencyDatastore, err := s.engineDatastore(config, uri, &residencyCfg, false)
+			if err != nil {
+				for _, datastore := range datastores {
+					datastore.Close()
+				}
+				return nil, nil, nil, fmt.Errorf("datastore '%s': %w", name, err)
+			}
+			datastores[name] = residencyDatastore
+			if warmer, ok := residencyDatastore.(storage.ConnectionPoolWarmer); ok {
+				poolWarmers = append(poolWarmers, warmer)
+			}
+			names = append(names, name)
+		}
+
+		stores := map[string]string{}
+		for _, route := range config.Datastore.Residency.Stores {
+			store, name, _ := strings.Cut(route, "=")
+			stores[store] = name
+		}
+
+		router, err := storagewrappers.NewRoutingOpenFGADatastore(config.Datastore.Residency.Name, datastores,
+			storagewrappers.WithRoutingStores(stores),
+			storagewrappers.WithRoutingLabelKey(config.Datastore.Residency.LabelKey),
+		)
+		if err != nil {
+			for _, datastore := range datastores {
+				datastore.Close()
+			}
+			return nil, nil, nil, err
+		}
+		datastore = router
+
+		if tupleChangeNotifier != nil {
+			tupleChangeNotifier = router
+		}
+
+		s.Logger.Info("the data of the stores is routed to the residency datastores",
+			zap.Strings("datastores", names),
+			zap.Int("stores", len(stores)),
+			zap.String("label_key", config.Datastore.Residency.LabelKey))
+	}
+
+	if config.Datastore.Metrics.Enabled {
+		datastore = storagewrappers.NewMetricsOpenFGADatastore(datastore,
+			storagewrappers.WithStoreLabelLimit(config.Datastore.Metrics.StoreLabelLimit),
+		)
+	}
+
+	datastore = storagewrappers.NewContextWrapper(datastore)
+
+	if config.Trace.Enabled {
+		datastore = storagewrappers.NewTracingOpenFGADatastore(datastore)
+	}
 
-func (s *ServerContext) datastoreConfig(config *serverconfig.Config) (storage.OpenFGADatastore, error) {
-	datastoreOptions := []sqlcommon.DatastoreOption{
-		sqlcommon.WithUsername(config.Datastore.Username),
-		sqlcommon.WithPassword(config.Datastore.Password),
-		sqlcommon.WithLogger(s.Logger),
-		sqlcommon.WithMaxTuplesPerWrite(config.MaxTuplesPerWrite),
-		sqlcommon.WithMaxTypesPerAuthorizationModel(config.MaxTypesPerAuthorizationModel),
-		sqlcommon.WithMaxOpenConns(config.Datastore.MaxOpenConns),
-		sqlcommon.WithMaxIdleConns(config.Datastore.MaxIdleConns),
-		sqlcommon.WithConnMaxIdleTime(config.Datastore.ConnMaxIdleTime),
-		sqlcommon.WithConnMaxLifetime(config.Datastore.ConnMaxLifetime),
+	if config.Datastore.Hedging.Enabled {
+		s.Logger.Info("datastore read hedging is enabled",
+			zap.Float64("percentile", config.Datastore.Hedging.Percentile),
+			zap.Duration("min_delay", config.Datastore.Hedging.MinDelay))
+
+		datastore = storagewrappers.NewHedgedOpenFGADatastore(datastore,
+			storagewrappers.WithHedgingPercentile(config.Datastore.Hedging.Percentile),
+			storagewrappers.WithHedgingMinDelay(config.Datastore.Hedging.MinDelay),
+		)
 	}
 
-	if config.Datastore.Metrics.Enabled {
-		datastoreOptions = append(datastoreOptions, sqlcommon.WithMetrics())
+	if config.Datastore.CircuitBreaker.Enabled {
+		s.Logger.Info("datastore circuit breaker is enabled",
+			zap.Float64("failure_ratio", config.Datastore.CircuitBreaker.FailureRatio),
+			zap.Int("min_requests", config.Datastore.CircuitBreaker.MinRequests),
+			zap.Duration("window", config.Datastore.CircuitBreaker.Window),
+			zap.Duration("latency_threshold", config.Datastore.CircuitBreaker.LatencyThreshold),
+			zap.Duration("open_duration", config.Datastore.CircuitBreaker.OpenDuration))
+
+		datastore = storagewrappers.NewCircuitBreakerOpenFGADatastore(datastore,
+			storagewrappers.WithCircuitBreakerFailureRatio(config.Datastore.CircuitBreaker.FailureRatio),
+			storagewrappers.WithCircuitBreakerMinRequests(config.Datastore.CircuitBreaker.MinRequests),
+			storagewrappers.WithCircuitBreakerWindow(config.Datastore.CircuitBreaker.Window),
+			storagewrappers.WithCircuitBreakerLatencyThreshold(config.Datastore.CircuitBreaker.LatencyThreshold),
+			storagewrappers.WithCircuitBreakerOpenDuration(config.Datastore.CircuitBreaker.OpenDuration),
+		)
 	}
 
-	dsCfg := sqlcommon.NewConfig(datastoreOptions...)
+	datastore = storagewrappers.NewCachedOpenFGADatastore(datastore, config.Datastore.MaxCacheSize)
 
-	var datastore storage.OpenFGADatastore
-	var err error
+	s.Logger.Info(fmt.Sprintf("using '%v' storage engine", config.Datastore.Engine))
+	return datastore, poolWarmers, tupleChangeNotifier, nil
+}
+
+// engineDatastore returns a datastore of the engine of config at uri. The contents of a memory
+// datastore are only persisted to the snapshot file if snapshot is set.
+func (s *ServerContext) engineDatastore(config *serverconfig.Config, uri string, dsCfg *sqlcommon.Config, snapshot bool) (storage.OpenFGADatastore, error) {
 	switch config.Datastore.Engine {
 	case "memory":
 		opts := []memory.StorageOption{
 			memory.WithMaxTypesPerAuthorizationModel(config.MaxTypesPerAuthorizationModel),
 			memory.WithMaxTuplesPerWrite(config.MaxTuplesPerWrite),
+			memory.WithLogger(logger.ForModule(s.Logger, logger.ModuleStorage)),
+		}
+
+		if !snapshot || config.Datastore.Memory.SnapshotPath == "" {
+			return memory.New(opts...), nil
+		}
+
+		opts = append(opts,
+			memory.WithSnapshotPath(config.Datastore.Memory.SnapshotPath),
+			memory.WithSnapshotInterval(config.Datastore.Memory.SnapshotInterval),
+		)
+
+		if !config.Datastore.Memory.LoadSnapshot {
+			return memory.New(opts...), nil
+		}
+
+		datastore, err := memory.NewFromSnapshot(config.Datastore.Memory.SnapshotPath, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("initialize memory datastore: %w", err)
 		}
-		datastore = memory.New(opts...)
+		return datastore, nil
 	case "mysql":
-		datastore, err = mysql.New(config.Datastore.URI, dsCfg)
+		datastore, err := mysql.New(uri, dsCfg)
 		if err != nil {
 			return nil, fmt.Errorf("initialize mysql datastore: %w", err)
 		}
+		return datastore, nil
 	case "postgres":
-		datastore, err = postgres.New(config.Datastore.URI, dsCfg)
+		datastore, err := postgres.New(uri, dsCfg)
 		if err != nil {
 			return nil, fmt.Errorf("initialize postgres datastore: %w", err)
 		}
+		return datastore, nil
 	default:
 		return nil, fmt.Errorf("storage engine '%s' is unsupported", config.Datastore.Engine)
 	}
-	datastore = storagewrappers.NewCachedOpenFGADatastore(storagewrappers.NewContextWrapper(datastore), config.Datastore.MaxCacheSize)
-
-	s.Logger.Info(fmt.Sprintf("using '%v' storage engine", config.Datastore.Engine))
-	return datastore, nil
 }
 
-func (s *ServerContext) authenticatorConfig(config *serverconfig.Config) (authn.Authenticator, error) {
+// authenticatorConfig returns the authenticator of config, which is the authentication of the kind
+// of requests that kind describes, e.g. 'authentication' or 'control-plane authentication'.
+func (s *ServerContext) authenticatorConfig(config serverconfig.AuthnConfig, kind string) (authn.Authenticator, error) {
 	var authenticator authn.Authenticator
 	var err error
 
-	switch config.Authn.Method {
+	switch config.Method {
 	case "none":
-		s.Logger.Warn("authentication is disabled")
+		s.Logger.Warn(fmt.Sprintf("%s is disabled", kind))
 		authenticator = authn.NoopAuthenticator{}
 	case "preshared":
-		s.Logger.Info("using 'preshared' authentication")
-		authenticator, err = presharedkey.NewPresharedKeyAuthenticator(config.Authn.Keys)
+		s.Logger.Info(fmt.Sprintf("using 'preshared' %s", kind))
+		authenticator, err = presharedkey.NewPresharedKeyAuthenticator(config.Keys)
 	case "oidc":
-		s.Logger.Info("using 'oidc' authentication")
-		authenticator, err = oidc.NewRemoteOidcAuthenticator(config.Authn.Issuer, config.Authn.IssuerAliases, config.Authn.Audience)
+		s.Logger.Info(fmt.Sprintf("using 'oidc' %s", kind))
+		authenticator, err = oidc.NewRemoteOidcAuthenticator(config.Issuer, config.IssuerAliases, config.Audience)
 	default:
-		return nil, fmt.Errorf("unsupported authentication method '%v'", config.Authn.Method)
+		return nil, fmt.Errorf("unsupported authentication method '%v'", config.Method)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize authenticator: %w", err)
@@ -384,7 +1070,10 @@ func (s *ServerContext) authenticatorConfig(config *serverconfig.Config) (authn.
 // Run returns an error if the server was unable to start successfully.
 // If it started and terminated successfully, it returns a nil error.
 func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) error {
+	s.config.Store(config)
+
 	tracerProviderCloser := s.telemetryConfig(ctx, config)
+	meterProviderCloser := s.metricsConfig(ctx, config)
 
 	s.Logger.Info(fmt.Sprintf("🧪 experimental features enabled: %v", config.Experimentals))
 
@@ -393,19 +1082,53 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		experimentals = append(experimentals, server.ExperimentalFeatureFlag(feature))
 	}
 
-	datastore, err := s.datastoreConfig(config)
+	featureFlags, err := featureflags.Parse(config.FeatureFlags)
+	if err != nil {
+		return fmt.Errorf("failed to parse the feature flags: %w", err)
+	}
+	if len(config.FeatureFlags) > 0 {
+		s.Logger.Info(fmt.Sprintf("🚩 feature flags: %v", config.FeatureFlags))
+	}
+
+	datastore, poolWarmers, tupleChangeNotifier, err := s.datastoreConfig(config)
 	if err != nil {
 		return err
 	}
 
-	authenticator, err := s.authenticatorConfig(config)
+	authenticator, err := s.authenticatorConfig(config.Authn, "authentication")
 
 	if err != nil {
 		return err
 	}
 
+	var controlPlaneAuthenticator authn.Authenticator
+	if config.ControlPlane.Enabled {
+		controlPlaneAuthenticator, err = s.authenticatorConfig(config.ControlPlane.Authn, "control-plane authentication")
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := compression.EnableGRPCCompressors(config.GRPC.Compression, config.GRPC.MaxRecvMsgSizeInBytes); err != nil {
+		return err
+	}
+
+	drainer := drain.NewDrainer()
+
 	serverOpts := []grpc.ServerOption{
-		grpc.MaxRecvMsgSize(serverconfig.DefaultMaxRPCMessageSizeInBytes),
+		grpc.MaxRecvMsgSize(config.GRPC.MaxRecvMsgSizeInBytes),
+		grpc.MaxSendMsgSize(config.GRPC.MaxSendMsgSizeInBytes),
+		grpc.MaxConcurrentStreams(config.GRPC.MaxConcurrentStreams),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionAge:      config.GRPC.MaxConnectionAge,
+			MaxConnectionAgeGrace: config.GRPC.MaxConnectionAgeGrace,
+			Time:                  config.GRPC.KeepaliveTime,
+			Timeout:               config.GRPC.KeepaliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             config.GRPC.KeepaliveEnforcementMinTime,
+			PermitWithoutStream: config.GRPC.KeepaliveEnforcementPermitWithoutStream,
+		}),
 		grpc.ChainUnaryInterceptor(
 			[]grpc.UnaryServerInterceptor{
 				grpc_recovery.UnaryServerInterceptor( // panic middleware must be 1st in chain
@@ -415,6 +1138,7 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 				),
 				grpc_ctxtags.UnaryServerInterceptor(), // needed for logging
 				requestid.NewUnaryInterceptor(),       // add request_id to ctxtags
+				drainer.NewUnaryInterceptor(),         // track in-flight requests
 			}...,
 		),
 		grpc.ChainStreamInterceptor(
@@ -425,37 +1149,81 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 					),
 				),
 				requestid.NewStreamingInterceptor(),
+				drainer.NewStreamingInterceptor(),
 			}...,
 		),
 	}
 
-	if config.RequestTimeout > 0 {
-		timeoutMiddleware := middleware.NewTimeoutInterceptor(config.RequestTimeout, s.Logger)
+	if config.AdmissionControl.Enabled {
+		s.Logger.Info("admission control is enabled",
+			zap.Int("max_inflight_requests", config.AdmissionControl.MaxInflightRequests),
+			zap.Int("max_goroutines", config.AdmissionControl.MaxGoroutines),
+			zap.Uint64("max_memory_bytes", config.AdmissionControl.MaxMemoryBytes),
+			zap.Float64("low_priority_ratio", config.AdmissionControl.LowPriorityRatio))
+
+		s.admissionController = admission.NewController(
+			admission.WithMaxInflightRequests(config.AdmissionControl.MaxInflightRequests),
+			admission.WithMaxGoroutines(config.AdmissionControl.MaxGoroutines),
+			admission.WithMaxMemoryBytes(config.AdmissionControl.MaxMemoryBytes),
+			admission.WithLowPriorityRatio(config.AdmissionControl.LowPriorityRatio),
+			admission.WithHighDispatchCount(config.AdmissionControl.HighDispatchCount),
+			admission.WithRetryAfter(config.AdmissionControl.RetryAfter),
+		)
+
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(s.admissionController.NewUnaryInterceptor()))
+		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(s.admissionController.NewStreamingInterceptor()))
+	}
+
+	if config.RequestTimeout > 0 || config.RequestTimeouts.Check > 0 || config.RequestTimeouts.ListObjects > 0 || config.RequestTimeouts.Write > 0 {
+		var timeoutOpts []middleware.TimeoutInterceptorOption
+		for method, timeout := range map[string]time.Duration{
+			openfgav1.OpenFGAService_Check_FullMethodName:               config.RequestTimeouts.Check,
+			openfgav1.OpenFGAService_ListObjects_FullMethodName:         config.RequestTimeouts.ListObjects,
+			openfgav1.OpenFGAService_StreamedListObjects_FullMethodName: config.RequestTimeouts.ListObjects,
+			openfgav1.OpenFGAService_Write_FullMethodName:               config.RequestTimeouts.Write,
+		} {
+			if timeout > 0 {
+				timeoutOpts = append(timeoutOpts, middleware.WithMethodTimeout(method, timeout))
+			}
+		}
+
+		timeoutMiddleware := middleware.NewTimeoutInterceptor(config.RequestTimeout, s.Logger, timeoutOpts...)
 
 		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(timeoutMiddleware.NewUnaryTimeoutInterceptor()))
 		serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(timeoutMiddleware.NewStreamTimeoutInterceptor()))
 	}
 
+	// the limits of the requests are enforced once they are valid, before they are handled
+	requestLimits := requestlimits.NewValidator(
+		requestlimits.WithMaxContextualTuples(config.MaxContextualTuples),
+		requestlimits.WithMaxTuplesPerWrite(config.MaxTuplesPerWrite),
+		requestlimits.WithMaxContextSizeInBytes(config.MaxContextSizeInBytes),
+	)
+
 	serverOpts = append(serverOpts,
 		grpc.ChainUnaryInterceptor(
 			[]grpc.UnaryServerInterceptor{
 				storeid.NewUnaryInterceptor(),           // if available, add store_id to ctxtags
 				logging.NewLoggingInterceptor(s.Logger), // needed to log invalid requests
-				validator.UnaryServerInterceptor(),
 			}...,
 		),
+		grpc.ChainUnaryInterceptor(s.unaryInterceptors[BeforeValidation]...),
+		grpc.ChainUnaryInterceptor(validator.UnaryServerInterceptor(), requestLimits.NewUnaryInterceptor()),
+		grpc.ChainStreamInterceptor(s.streamInterceptors[BeforeValidation]...),
 		grpc.ChainStreamInterceptor(
 			[]grpc.StreamServerInterceptor{
 				validator.StreamServerInterceptor(),
+				requestLimits.NewStreamingInterceptor(),
 				grpc_ctxtags.StreamServerInterceptor(),
 			}...,
 		),
 	)
 
-	if config.Metrics.Enabled {
+	if config.Metrics.Enabled || config.Metrics.OTLP.Enabled {
 		serverOpts = append(serverOpts,
 			grpc.ChainUnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
-			grpc.ChainStreamInterceptor(grpc_prometheus.StreamServerInterceptor))
+			grpc.ChainStreamInterceptor(grpc_prometheus.StreamServerInterceptor),
+			grpc.StatsHandler(connections.NewHandler()))
 
 		if config.Metrics.EnableRPCHistograms {
 			grpc_prometheus.EnableHandlingTimeHistogram()
@@ -466,20 +1234,12 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		serverOpts = append(serverOpts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
 	}
 
-	serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(
-		[]grpc.UnaryServerInterceptor{
-			grpcauth.UnaryServerInterceptor(authnmw.AuthFunc(authenticator)),
-		}...),
-		grpc.ChainStreamInterceptor(
-			[]grpc.StreamServerInterceptor{
-				grpcauth.StreamServerInterceptor(authnmw.AuthFunc(authenticator)),
-				// The following interceptors wrap the server stream with our own
-				// wrapper and must come last.
-				storeid.NewStreamingInterceptor(),
-				logging.NewStreamingLoggingInterceptor(s.Logger),
-			}...,
-		),
-	)
+	if config.Profiler.Enabled {
+		// add pprof labels with the RPC method and store_id to the goroutines serving each request
+		serverOpts = append(serverOpts,
+			grpc.ChainUnaryInterceptor(profiling.NewUnaryInterceptor()),
+			grpc.ChainStreamInterceptor(profiling.NewStreamingInterceptor()))
+	}
 
 	if config.GRPC.TLS.Enabled {
 		if config.GRPC.TLS.CertPath == "" || config.GRPC.TLS.KeyPath == "" {
@@ -499,7 +1259,7 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 
 	if config.Profiler.Enabled {
 		mux := http.NewServeMux()
-		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/", pprof.Index) // also serves the named profiles such as heap, goroutine, allocs and mutex
 		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
@@ -530,30 +1290,248 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		}()
 	}
 
+	idGenerator, err := idgen.New(config.IDGeneration.Scheme)
+	if err != nil {
+		return err
+	}
+
+	if err := importData(ctx, datastore, idGenerator, config.Import, s.Logger); err != nil {
+		return fmt.Errorf("failed to import authorization model and tuples: %w", err)
+	}
+
+	var backupScheduler *backup.Scheduler
+	if config.Backup.Enabled {
+		objects, err := backup.OpenObjectStorage(config.Backup.URL,
+			backup.WithEndpoint(config.Backup.Endpoint),
+			backup.WithRegion(config.Backup.Region),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to open the backup object storage: %w", err)
+		}
+
+		backupScheduler = backup.NewScheduler(datastore, objects,
+			backup.WithLogger(s.Logger),
+			backup.WithInterval(config.Backup.Interval),
+		)
+
+		s.Logger.Info("scheduled backups are enabled",
+			zap.String("url", config.Backup.URL),
+			zap.Duration("interval", config.Backup.Interval))
+	}
+
+	// the integrity checker also checks the stores on demand on the admin server
+	var integrityChecker *integrity.Checker
+	if config.IntegrityChecker.Enabled || config.Admin.Enabled {
+		opts := []integrity.CheckerOption{
+			integrity.WithLogger(s.Logger),
+			integrity.WithInterval(0),
+			integrity.WithCleanup(integrity.CleanupMode(config.IntegrityChecker.Cleanup)),
+			integrity.WithMaxReportedTuples(config.IntegrityChecker.MaxReportedTuples),
+		}
+
+		if config.IntegrityChecker.QuarantineURL != "" {
+			quarantine, err := backup.OpenObjectStorage(config.IntegrityChecker.QuarantineURL,
+				backup.WithEndpoint(config.Backup.Endpoint),
+				backup.WithRegion(config.Backup.Region),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to open the quarantine object storage: %w", err)
+			}
+			opts = append(opts, integrity.WithQuarantine(quarantine))
+		}
+
+		if config.IntegrityChecker.Enabled {
+			opts = append(opts, integrity.WithInterval(config.IntegrityChecker.Interval))
+
+			s.Logger.Info("integrity checker is enabled",
+				zap.Duration("interval", config.IntegrityChecker.Interval),
+				zap.String("cleanup", config.IntegrityChecker.Cleanup))
+		}
+
+		integrityChecker = integrity.NewChecker(datastore, opts...)
+	}
+
+	var changePublisher *changepublisher.Publisher
+	if config.ChangePublisher.Enabled {
+		router, err := changepublisher.NewTopicRouter(config.ChangePublisher.Topic, config.ChangePublisher.Topics)
+		if err != nil {
+			return fmt.Errorf("failed to initialize the change publisher topics: %w", err)
+		}
+
+		sink, err := changepublisher.OpenSink(config.ChangePublisher.URL,
+			changepublisher.WithTimeout(config.ChangePublisher.Timeout),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to open the change publisher sink: %w", err)
+		}
+
+		changePublisher = changepublisher.NewPublisher(datastore, sink,
+			changepublisher.WithLogger(s.Logger),
+			changepublisher.WithInterval(config.ChangePublisher.Interval),
+			changepublisher.WithPageSize(config.ChangePublisher.PageSize),
+			changepublisher.WithHorizonOffset(config.ChangePublisher.HorizonOffset),
+			changepublisher.WithConsumer(config.ChangePublisher.Consumer),
+			changepublisher.WithTopicRouter(router),
+		)
+
+		s.Logger.Info("change publisher is enabled",
+			zap.String("consumer", config.ChangePublisher.Consumer),
+			zap.Duration("interval", config.ChangePublisher.Interval))
+	}
+
+	tokenEncoder, err := continuationTokenEncoder(config.ContinuationTokens)
+	if err != nil {
+		return fmt.Errorf("failed to initialize the continuation token encoder: %w", err)
+	}
+
+	// the peers of the peer dispatch are dialed like the gateway dials the server
+	peerDispatchDialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if config.PeerDispatch.Enabled && config.GRPC.TLS.Enabled {
+		creds, err := credentials.NewClientTLSFromFile(config.GRPC.TLS.CertPath, "")
+		if err != nil {
+			return fmt.Errorf("failed to load the TLS credentials of the peer dispatch: %w", err)
+		}
+		peerDispatchDialOpts = []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	}
+
 	svr := server.MustNewServerWithOpts(
 		server.WithDatastore(datastore),
-		server.WithLogger(s.Logger),
+		server.WithTokenEncoder(tokenEncoder),
+		server.WithLogger(logger.ForModule(s.Logger, logger.ModuleServer)),
 		server.WithTransport(gateway.NewRPCTransport(s.Logger)),
 		server.WithResolveNodeLimit(config.ResolveNodeLimit),
 		server.WithResolveNodeBreadthLimit(config.ResolveNodeBreadthLimit),
+		server.WithCheckUsersetsPageSize(config.CheckUsersetsPageSize),
 		server.WithChangelogHorizonOffset(config.ChangelogHorizonOffset),
 		server.WithListObjectsDeadline(config.ListObjectsDeadline),
 		server.WithListObjectsMaxResults(config.ListObjectsMaxResults),
+		server.WithListObjectsShards(config.ListObjectsShards),
+		server.WithListObjectsShardConcurrency(config.ListObjectsShardConcurrency),
 		server.WithMaxConcurrentReadsForListObjects(config.MaxConcurrentReadsForListObjects),
 		server.WithMaxConcurrentReadsForCheck(config.MaxConcurrentReadsForCheck),
 		server.WithCheckQueryCacheEnabled(config.CheckQueryCache.Enabled),
 		server.WithCheckQueryCacheLimit(config.CheckQueryCache.Limit),
 		server.WithCheckQueryCacheTTL(config.CheckQueryCache.TTL),
+		server.WithCheckQueryCacheNegativeTTL(config.CheckQueryCache.NegativeTTL),
+		server.WithCheckQueryCacheMaxBytes(config.CheckQueryCache.MaxBytes),
+		server.WithCheckQueryCacheServeStale(config.CheckQueryCache.ServeStale),
+		server.WithCheckQueryCacheWatermarkEnabled(config.CheckQueryCache.WatermarkEnabled),
+		server.WithCheckQueryCacheWatermarkRefreshInterval(config.CheckQueryCache.WatermarkRefreshInterval),
+		server.WithListObjectsCacheEnabled(config.ListObjectsCache.Enabled),
+		server.WithListObjectsCacheLimit(config.ListObjectsCache.Limit),
+		server.WithListObjectsCacheTTL(config.ListObjectsCache.TTL),
+		server.WithListObjectsCacheWatermarkRefreshInterval(config.ListObjectsCache.WatermarkRefreshInterval),
 		server.WithRequestDurationByQueryHistogramBuckets(convertStringArrayToUintArray(config.RequestDurationDatastoreQueryCountBuckets)),
 		server.WithRequestDurationByDispatchCountHistogramBuckets(convertStringArrayToUintArray(config.RequestDurationDispatchCountBuckets)),
 		server.WithMaxAuthorizationModelSizeInBytes(config.MaxAuthorizationModelSizeInBytes),
+		server.WithMaxRelationsPerTypeDefinition(config.MaxRelationsPerTypeDefinition),
+		server.WithMaxAuthorizationModelRewriteDepth(config.MaxAuthorizationModelRewriteDepth),
+		server.WithMaxConditionExpressionSizeInBytes(config.MaxConditionExpressionSizeInBytes),
+		server.WithMaxContextualTuples(config.MaxContextualTuples),
+		server.WithMaxContextSizeInBytes(config.MaxContextSizeInBytes),
+		server.WithConsistencyTokenTimeout(config.ConsistencyTokenTimeout),
 		server.WithDispatchThrottlingCheckResolverEnabled(config.DispatchThrottling.Enabled),
 		server.WithDispatchThrottlingCheckResolverFrequency(config.DispatchThrottling.Frequency),
 		server.WithDispatchThrottlingCheckResolverThreshold(config.DispatchThrottling.Threshold),
 		server.WithDispatchThrottlingCheckResolverMaxThreshold(config.DispatchThrottling.MaxThreshold),
+		server.WithSlowRequestLogEnabled(config.SlowRequestLog.Enabled),
+		server.WithSlowRequestLogThreshold(config.SlowRequestLog.Threshold),
+		server.WithCheckBudgetMaxDispatchCount(config.CheckBudget.MaxDispatchCount),
+		server.WithCheckBudgetMaxDatastoreReadCount(config.CheckBudget.MaxDatastoreReadCount),
+		server.WithCheckReadDeduplicationEnabled(config.CheckReadDeduplication.Enabled),
+		server.WithCheckReadDeduplicationMaxTuplesPerRead(config.CheckReadDeduplication.MaxTuplesPerRead),
+		server.WithCheckDispatchPoolEnabled(config.CheckDispatchPool.Enabled),
+		server.WithCheckDispatchPoolSize(config.CheckDispatchPool.Size),
+		server.WithTypesystemCacheMaxSize(config.TypesystemCache.MaxSize),
+		server.WithCheckPlannerEnabled(config.CheckPlanner.Enabled),
+		server.WithCheckPlannerStatisticsInterval(config.CheckPlanner.StatisticsInterval),
+		server.WithCheckPlannerMaxStores(config.CheckPlanner.MaxStores),
+		server.WithTupleStatisticsEnabled(config.TupleStatistics.Enabled),
+		server.WithTupleStatisticsInterval(config.TupleStatistics.Interval),
+		server.WithTupleStatisticsMaxTuplesPerStore(config.TupleStatistics.MaxTuplesPerStore),
+		server.WithRelationUsageEnabled(config.RelationUsage.Enabled),
+		server.WithRelationUsageSampleRatio(config.RelationUsage.SampleRatio),
+		server.WithRelationUsageFlushInterval(config.RelationUsage.FlushInterval),
+		server.WithIDScheme(config.IDGeneration.Scheme),
+		server.WithCallerSuppliedStoreIDsEnabled(config.IDGeneration.CallerSuppliedStoreIDs),
+		server.WithWriteAdmissionWebhookURL(config.WriteAdmissionWebhook.URL),
+		server.WithWriteAdmissionWebhookTimeout(config.WriteAdmissionWebhook.Timeout),
+		server.WithWriteAdmissionWebhookFailurePolicy(config.WriteAdmissionWebhook.FailurePolicy),
+		server.WithWriteGroupSagaEnabled(config.WriteGroups.SagaEnabled),
+		server.WithDelegatedCheckRelations(config.DelegatedCheck.Relations),
+		server.WithDelegatedCheckOperator(config.DelegatedCheck.Operator),
+		server.WithDelegatedCheckTimeout(config.DelegatedCheck.Timeout),
+		server.WithCheckCanaryEnabled(config.CheckCanary.Enabled),
+		server.WithCheckCanarySampleRatio(config.CheckCanary.SampleRatio),
+		server.WithCheckCanaryTimeout(config.CheckCanary.Timeout),
+		server.WithCheckCanaryMaxConcurrentEvaluations(config.CheckCanary.MaxConcurrentEvaluations),
+		server.WithWriteCoalescingEnabled(config.WriteCoalescing.Enabled),
+		server.WithWriteCoalescingWindow(config.WriteCoalescing.Window),
+		server.WithPeerDispatchEnabled(config.PeerDispatch.Enabled),
+		server.WithPeerDispatchSelf(config.PeerDispatch.Self),
+		server.WithPeerDispatchPeers(config.PeerDispatch.Peers),
+		server.WithPeerDispatchDNSName(config.PeerDispatch.DNSName),
+		server.WithPeerDispatchDNSRefreshInterval(config.PeerDispatch.DNSRefreshInterval),
+		server.WithPeerDispatchTimeout(config.PeerDispatch.Timeout),
+		server.WithPeerDispatchPresharedKey(config.PeerDispatch.PresharedKey),
+		server.WithPeerDispatchDialOptions(peerDispatchDialOpts...),
+		server.WithTupleMaxObjectLength(config.TupleValidation.MaxObjectLength),
+		server.WithTupleMaxUserLength(config.TupleValidation.MaxUserLength),
+		server.WithTupleIDPatterns(config.TupleValidation.IDPatterns...),
+		server.WithTimeTravelEnabled(config.TimeTravel.Enabled),
+		server.WithTimeTravelMaxChanges(config.TimeTravel.MaxChanges),
+		server.WithTimeTravelRetention(config.TimeTravel.Retention),
+		server.WithChangelogContinuationTokenLifetime(config.ContinuationTokens.ReadChangesLifetime),
+		server.WithTupleChangeNotifier(tupleChangeNotifier),
+		server.WithFeatureFlags(featureFlags),
 		server.WithExperimentals(experimentals...),
 	)
 
+	var meter *metering.Meter
+	if config.Metering.Enabled {
+		instance := config.Metering.Instance
+		if instance == "" {
+			instance, _ = os.Hostname()
+		}
+
+		opts := []metering.MeterOption{
+			metering.WithLogger(s.Logger),
+			metering.WithInstance(instance),
+			metering.WithWindow(config.Metering.Window),
+			metering.WithRetention(config.Metering.Retention),
+		}
+
+		if collector := svr.TupleStatistics(); collector != nil {
+			opts = append(opts, metering.WithTupleCounts(func(storeID string) (int64, bool) {
+				stats, ok := collector.Get(storeID)
+				if !ok {
+					return 0, false
+				}
+				return stats.TupleCount, true
+			}))
+		}
+
+		if config.Metering.ExportURL != "" {
+			export, err := backup.OpenObjectStorage(config.Metering.ExportURL,
+				backup.WithEndpoint(config.Backup.Endpoint),
+				backup.WithRegion(config.Backup.Region),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to open the metering object storage: %w", err)
+			}
+			opts = append(opts, metering.WithExport(export, metering.Format(config.Metering.ExportFormat)))
+		}
+
+		meter = metering.NewMeter(opts...)
+
+		s.Logger.Info("metering is enabled",
+			zap.String("instance", instance),
+			zap.Duration("window", config.Metering.Window),
+			zap.String("export_url", config.Metering.ExportURL))
+	}
+
 	s.Logger.Info(
 		"🚀 starting openfga service...",
 		zap.String("version", build.Version),
@@ -562,12 +1540,95 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		zap.String("go-version", goruntime.Version()),
 	)
 
-	// nosemgrep: grpc-server-insecure-connection
-	grpcServer := grpc.NewServer(serverOpts...)
-	openfgav1.RegisterOpenFGAServiceServer(grpcServer, svr)
 	healthServer := &health.Checker{TargetService: svr, TargetServiceName: openfgav1.OpenFGAService_ServiceDesc.ServiceName}
-	healthv1pb.RegisterHealthServer(grpcServer, healthServer)
-	reflection.Register(grpcServer)
+	healthServer.SetStarting(true)
+
+	if config.Admin.Enabled {
+		zapLogger, ok := s.Logger.(*logger.ZapLogger)
+		if !ok {
+			return fmt.Errorf("the admin server requires a logger whose levels can be changed at runtime")
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/log/levels", logger.LevelsHandler(zapLogger))
+		mux.Handle("/debug/check", svr.DebugCheckHandler())
+		mux.Handle("/feature-flags", featureflags.Handler(featureFlags))
+		mux.Handle("/config", s.configHandler())
+		mux.Handle("/cache/flush", svr.CheckQueryCacheFlushHandler())
+		mux.Handle("/drain", healthServer.DrainHandler())
+		mux.Handle("/stats", statsHandler(time.Now(), svr, healthServer, drainer))
+		mux.Handle("/integrity", integrityChecker.Handler())
+		if meter != nil {
+			mux.Handle("/metering", meter.Handler())
+		}
+
+		if len(config.Admin.PresharedKeys) == 0 {
+			s.Logger.Warn("the admin server isn't authenticated, set admin preshared keys to authenticate it")
+		}
+
+		go func() {
+			s.Logger.Info(fmt.Sprintf("🛠️ starting admin server on '%s'", config.Admin.Addr))
+
+			if err := http.ListenAndServe(config.Admin.Addr, adminAuthn(config.Admin.PresharedKeys, mux)); err != nil {
+				if err != http.ErrServerClosed {
+					s.Logger.Fatal("failed to start admin server", zap.Error(err))
+				}
+			}
+		}()
+	}
+
+	// newGRPCServer returns a gRPC server that authenticates the requests with authenticator. Once the
+	// control plane is enabled, it only serves the control-plane RPCs if controlPlane is true, or else
+	// the data-plane RPCs.
+	newGRPCServer := func(authenticator authn.Authenticator, controlPlane bool) *grpc.Server {
+		opts := append([]grpc.ServerOption{}, serverOpts...)
+		if config.ControlPlane.Enabled {
+			opts = append(opts,
+				grpc.ChainUnaryInterceptor(controlplane.NewUnaryInterceptor(controlPlane)),
+				grpc.ChainStreamInterceptor(controlplane.NewStreamingInterceptor(controlPlane)),
+			)
+		}
+
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(s.unaryInterceptors[BeforeAuthn]...),
+			grpc.ChainUnaryInterceptor(
+				[]grpc.UnaryServerInterceptor{
+					grpcauth.UnaryServerInterceptor(authnmw.AuthFunc(authenticator, logger.ForModule(s.Logger, logger.ModuleAuthn))),
+				}...),
+			grpc.ChainUnaryInterceptor(s.unaryInterceptors[AfterAuthn]...),
+			grpc.ChainStreamInterceptor(s.streamInterceptors[BeforeAuthn]...),
+			grpc.ChainStreamInterceptor(
+				[]grpc.StreamServerInterceptor{
+					grpcauth.StreamServerInterceptor(authnmw.AuthFunc(authenticator, logger.ForModule(s.Logger, logger.ModuleAuthn))),
+					// The following interceptors wrap the server stream with our own
+					// wrapper and must come last, but for the custom ones.
+					storeid.NewStreamingInterceptor(),
+					logging.NewStreamingLoggingInterceptor(s.Logger),
+				}...,
+			),
+			grpc.ChainStreamInterceptor(s.streamInterceptors[AfterAuthn]...),
+			// add the labels of the store to the context, logs and traces of the authenticated requests
+			grpc.ChainUnaryInterceptor(storelabels.NewUnaryInterceptor(svr.GetStoreLabels)),
+			grpc.ChainStreamInterceptor(storelabels.NewStreamingInterceptor(svr.GetStoreLabels)),
+		)
+		if meter != nil {
+			opts = append(opts,
+				grpc.ChainUnaryInterceptor(meter.NewUnaryInterceptor()),
+				grpc.ChainStreamInterceptor(meter.NewStreamingInterceptor()),
+			)
+		}
+
+		// nosemgrep: grpc-server-insecure-connection
+		grpcServer := grpc.NewServer(opts...)
+		openfgav1.RegisterOpenFGAServiceServer(grpcServer, svr)
+		healthv1pb.RegisterHealthServer(grpcServer, healthServer)
+		if config.GRPC.ReflectionEnabled {
+			reflection.Register(grpcServer)
+		}
+		return grpcServer
+	}
+
+	grpcServer := newGRPCServer(authenticator, false)
 
 	lis, err := net.Listen("tcp", config.GRPC.Addr)
 	if err != nil {
@@ -585,12 +1646,38 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 	}()
 	s.Logger.Info(fmt.Sprintf("grpc server listening on '%s'...", config.GRPC.Addr))
 
+	var controlPlaneServer *grpc.Server
+	if config.ControlPlane.Enabled {
+		controlPlaneServer = newGRPCServer(controlPlaneAuthenticator, true)
+
+		controlPlaneLis, err := net.Listen("tcp", config.ControlPlane.Addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen: %w", err)
+		}
+
+		go func() {
+			if err := controlPlaneServer.Serve(controlPlaneLis); err != nil {
+				if !errors.Is(err, grpc.ErrServerStopped) {
+					s.Logger.Fatal("failed to start control-plane grpc server", zap.Error(err))
+				}
+
+				s.Logger.Info("control-plane grpc server shut down..")
+			}
+		}()
+		s.Logger.Info(fmt.Sprintf("control-plane grpc server listening on '%s'...", config.ControlPlane.Addr))
+	}
+
 	var httpServer *http.Server
 	if config.HTTP.Enabled {
 		runtime.DefaultContextTimeout = serverconfig.DefaultContextTimeout(config)
 
 		dialOpts := []grpc.DialOption{
 			grpc.WithBlock(),
+			// the gateway proxies the requests and responses, so it has the same message size limits as the server
+			grpc.WithDefaultCallOptions(
+				grpc.MaxCallSendMsgSize(config.GRPC.MaxRecvMsgSizeInBytes),
+				grpc.MaxCallRecvMsgSize(config.GRPC.MaxSendMsgSizeInBytes),
+			),
 		}
 		if config.GRPC.TLS.Enabled {
 			creds, err := credentials.NewClientTLSFromFile(config.GRPC.TLS.CertPath, "")
@@ -614,31 +1701,173 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		muxOpts := []runtime.ServeMuxOption{
 			runtime.WithForwardResponseOption(httpmiddleware.HTTPResponseModifier),
 			runtime.WithErrorHandler(func(c context.Context, sr *runtime.ServeMux, mm runtime.Marshaler, w http.ResponseWriter, r *http.Request, e error) {
-				intCode := serverErrors.ConvertToEncodedErrorCode(status.Convert(e))
-				httpmiddleware.CustomHTTPErrorHandler(c, w, r, serverErrors.NewEncodedError(intCode, e.Error()))
+				httpmiddleware.CustomHTTPErrorHandler(c, w, r, serverErrors.EncodeError(e))
 			}),
 			runtime.WithStreamErrorHandler(func(ctx context.Context, e error) *status.Status {
 				intCode := serverErrors.ConvertToEncodedErrorCode(status.Convert(e))
 				encodedErr := serverErrors.NewEncodedError(intCode, e.Error())
 				return status.Convert(encodedErr)
 			}),
-			runtime.WithHealthzEndpoint(healthv1pb.NewHealthClient(conn)),
 			runtime.WithOutgoingHeaderMatcher(func(s string) (string, bool) { return s, true }),
+			runtime.WithIncomingHeaderMatcher(func(s string) (string, bool) {
+				switch s {
+				// forward the request ID of the client, so that it is used for the request
+				case requestid.RequestIDHeader,
+					// and the filters of ReadChanges
+					server.ChangesObjectIDPrefixHeader, server.ChangesRelationHeader, server.ChangesUserHeader, server.ChangesStartTimeHeader,
+					// and the condition filters of Read
+					server.ReadConditionNameHeader, server.ReadConditionContextHeader,
+					// and the consistency token and consistency of Check and ListObjects
+					server.ConsistencyTokenHeader, server.ConsistencyHeader,
+					// and the cache directives of Check
+					server.CheckCacheControlHeader,
+					// and the dry run flag of Write
+					server.DryRunHeader,
+					// and the time Check and ListObjects are evaluated at
+					server.AsOfHeader,
+					// and the contextual tuples and context of Expand
+					server.ExpandContextualTuplesHeader, server.ExpandContextHeader,
+					// and the shard, continuation token, partial results and candidates of ListObjects
+					server.ListObjectsShardHeader, server.ListObjectsContinuationTokenHeader,
+					server.ListObjectsPartialResultsHeader, server.ListObjectsCandidatesHeader,
+					// and the labels of the stores and the filters of ListStores
+					server.StoreLabelsHeader, server.StoresNameContainsHeader, server.StoresCreatedAfterHeader,
+					server.StoresCreatedBeforeHeader, server.StoresLabelsHeader,
+					// and the caller-supplied ID of the store of CreateStore
+					server.StoreIDHeader,
+					// and the header that forces the request to be traced
+					telemetry.ForceTraceHeader:
+					return s, true
+				}
+				return runtime.DefaultHeaderMatcher(s)
+			}),
 		}
 		mux := runtime.NewServeMux(muxOpts...)
 		if err := openfgav1.RegisterOpenFGAServiceHandler(ctx, mux, conn); err != nil {
 			return err
 		}
 
+		// the assertions are evaluated through the gRPC server, so that the requests are authenticated and validated
+		err = mux.HandlePath(http.MethodGet, "/stores/{store_id}/assertions/{authorization_model_id}/evaluate",
+			assertions.NewHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn)))
+		if err != nil {
+			return err
+		}
+
+		err = mux.HandlePath(http.MethodGet, "/stores/{store_id}/assertions/{authorization_model_id}/coverage",
+			assertions.NewCoverageHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn)))
+		if err != nil {
+			return err
+		}
+
+		err = mux.HandlePath(http.MethodGet, "/stores/{store_id}/assertions",
+			assertions.NewHistoryHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn)))
+		if err != nil {
+			return err
+		}
+
+		err = mux.HandlePath(http.MethodPost, "/stores/{store_id}/check-multiple",
+			multicheck.NewHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn)))
+		if err != nil {
+			return err
+		}
+
+		err = mux.HandlePath(http.MethodGet, "/stores/{store_id}/authorization-models/{authorization_model_id}/graph",
+			modelgraph.NewHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn)))
+		if err != nil {
+			return err
+		}
+
+		if config.CheckQueryCache.Enabled {
+			err = mux.HandlePath(http.MethodPost, "/stores/{store_id}/check-cache/warm",
+				cachewarming.NewHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn)))
+			if err != nil {
+				return err
+			}
+		}
+
+		storeSettingsHandler := storesettings.NewHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn), svr)
+		for _, method := range []string{http.MethodGet, http.MethodPut} {
+			if err := mux.HandlePath(method, "/stores/{store_id}/settings", storeSettingsHandler); err != nil {
+				return err
+			}
+		}
+
+		err = mux.HandlePath(http.MethodPost, "/write-groups",
+			writegroup.NewHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn), svr))
+		if err != nil {
+			return err
+		}
+
+		if collector := svr.TupleStatistics(); collector != nil {
+			err = mux.HandlePath(http.MethodGet, "/stores/{store_id}/statistics",
+				statistics.NewHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn), collector))
+			if err != nil {
+				return err
+			}
+		}
+
+		if config.RelationUsage.Enabled {
+			err = mux.HandlePath(http.MethodGet, "/stores/{store_id}/relation-usage",
+				relationusage.NewHTTPHandler(mux, openfgav1.NewOpenFGAServiceClient(conn), svr))
+			if err != nil {
+				return err
+			}
+		}
+
+		if config.HTTP.OpenAPIEnabled {
+			documentHandler, err := openapi.NewDocumentHandler()
+			if err != nil {
+				return err
+			}
+
+			if err := mux.HandlePath(http.MethodGet, openapi.DocumentPath, documentHandler); err != nil {
+				return err
+			}
+
+			if err := mux.HandlePath(http.MethodGet, openapi.ExplorerPath, openapi.NewExplorerHandler()); err != nil {
+				return err
+			}
+		}
+
+		if config.ModelEditor.Enabled {
+			if err := modeleditor.NewHandlers(mux, authenticator, config.ModelEditor.MaxTuples).Register(); err != nil {
+				return err
+			}
+		}
+
+		for path, handler := range map[string]http.HandlerFunc{
+			"/healthz": healthServer.LivenessHandler(),
+			"/readyz":  healthServer.ReadinessHandler(),
+		} {
+			handler := handler
+			err := mux.HandlePath(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				handler(w, r)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		var handler http.Handler = mux
+		if config.HTTP.CompressionEnabled {
+			handler, err = compression.NewHTTPHandler(mux, config.HTTP.CompressionMinSizeInBytes)
+			if err != nil {
+				return err
+			}
+		}
+
 		httpServer = &http.Server{
 			Addr: config.HTTP.Addr,
 			Handler: recovery.HTTPPanicRecoveryHandler(cors.New(cors.Options{
 				AllowedOrigins:   config.HTTP.CORSAllowedOrigins,
 				AllowCredentials: true,
 				AllowedHeaders:   config.HTTP.CORSAllowedHeaders,
+				ExposedHeaders:   config.HTTP.CORSExposedHeaders,
+				MaxAge:           int(config.HTTP.CORSMaxAge.Seconds()),
 				AllowedMethods: []string{http.MethodGet, http.MethodPost,
 					http.MethodHead, http.MethodPatch, http.MethodDelete, http.MethodPut},
-			}).Handler(mux), s.Logger),
+			}).Handler(handler), s.Logger),
 		}
 
 		go func() {
@@ -736,6 +1965,15 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 		}()
 	}
 
+	if config.Warmup.Enabled {
+		s.warmup(ctx, config, svr, poolWarmers)
+	}
+
+	healthServer.SetStarting(false)
+
+	reloadCtx, stopReload := context.WithCancel(ctx)
+	s.watchConfigReload(reloadCtx, svr, config)
+
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
 
@@ -743,8 +1981,23 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 	case <-done:
 	case <-ctx.Done():
 	}
+	stopReload()
 	s.Logger.Info("attempting to shutdown gracefully")
 
+	healthServer.SetDraining(true)
+	svr.Drain()
+
+	s.Logger.Info("draining in-flight requests",
+		zap.Int("inflight", drainer.Inflight()),
+		zap.Duration("timeout", config.DrainTimeout),
+	)
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), config.DrainTimeout)
+	if remaining, err := drainer.Drain(drainCtx); err != nil {
+		s.Logger.Warn("drain timeout elapsed before all in-flight requests finished", zap.Int("inflight", remaining))
+	}
+	cancelDrain()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -762,16 +2015,45 @@ func (s *ServerContext) Run(ctx context.Context, config *serverconfig.Config) er
 
 	grpcServer.GracefulStop()
 
+	if controlPlaneServer != nil {
+		controlPlaneServer.GracefulStop()
+	}
+
+	if backupScheduler != nil {
+		backupScheduler.Close()
+	}
+
+	if integrityChecker != nil {
+		integrityChecker.Close()
+	}
+
+	if changePublisher != nil {
+		changePublisher.Close()
+	}
+
+	// the usage of the last requests is exported once the servers stopped
+	if meter != nil {
+		meter.Close()
+	}
+
 	svr.Close()
 
 	authenticator.Close()
 
+	if controlPlaneAuthenticator != nil {
+		controlPlaneAuthenticator.Close()
+	}
+
 	datastore.Close()
 
 	if tracerProviderCloser != nil {
 		tracerProviderCloser()
 	}
 
+	if meterProviderCloser != nil {
+		meterProviderCloser()
+	}
+
 	s.Logger.Info("server exited. goodbye 👋")
 
 	return nil
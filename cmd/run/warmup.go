@@ -0,0 +1,36 @@
+package run
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// warmup establishes the connections of the pools of the datastores, up to their maximum number of
+// idle connections, and warms up the server for the warm-up stores, until the warm-up timeout. A
+// failed warm-up is only logged, since the server is marked ready anyway once it is done.
+func (s *ServerContext) warmup(ctx context.Context, config *serverconfig.Config, svr *server.Server, poolWarmers []storage.ConnectionPoolWarmer) {
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, config.Warmup.Timeout)
+	defer cancel()
+
+	for _, warmer := range poolWarmers {
+		if err := warmer.WarmConnectionPool(ctx, config.Datastore.MaxIdleConns); err != nil {
+			s.Logger.Warn("failed to warm up the connection pool of the datastore", zap.Error(err))
+		}
+	}
+
+	if err := svr.Warmup(ctx, config.Warmup.Stores); err != nil {
+		s.Logger.Warn("failed to warm up the stores", zap.Error(err))
+	}
+
+	s.Logger.Info("the server is warmed up",
+		zap.Int("stores", len(config.Warmup.Stores)),
+		zap.Duration("duration", time.Since(start)))
+}
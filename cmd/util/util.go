@@ -5,6 +5,7 @@ package util
 import (
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/spf13/pflag"
@@ -28,10 +29,25 @@ func MustBindPFlag(key string, flag *pflag.Flag) {
 	}
 }
 
+// boundEnvs are the environment variables bound to a key by MustBindEnv.
+var boundEnvs sync.Map
+
+// MustBindEnv attempts to bind a key to the environment variables that follow it (see viper.BindEnv)
+// and panics if the binding fails with a non-nil error.
 func MustBindEnv(input ...string) {
 	if err := viper.BindEnv(input...); err != nil {
 		panic("failed to bind env key: " + err.Error())
 	}
+
+	for _, env := range input[1:] {
+		boundEnvs.Store(env, input[0])
+	}
+}
+
+// IsBoundEnv reports whether the environment variable is bound to a key by MustBindEnv.
+func IsBoundEnv(env string) bool {
+	_, ok := boundEnvs.Load(env)
+	return ok
 }
 
 func Contains[E comparable](s []E, v E) bool {
@@ -98,7 +98,7 @@ func ValidateAllAuthorizationModels(ctx context.Context, db storage.OpenFGADatas
 
 	for {
 		// fetch a page of stores
-		stores, tokenStores, err := db.ListStores(ctx, storage.PaginationOptions{
+		stores, tokenStores, err := db.ListStores(ctx, storage.ListStoresFilter{}, storage.PaginationOptions{
 			PageSize: 100,
 			From:     continuationTokenStores,
 		})
@@ -44,6 +44,21 @@ func (n NoopAuthenticator) Close() {}
 type AuthClaims struct {
 	Subject string
 	Scopes  map[string]bool
+
+	// ClientID identifies the caller, e.g. the subject of an OIDC token or the ID of a preshared key, so
+	// that the work done for a request can be attributed to it. It is empty if the caller is unknown.
+	ClientID string
+}
+
+// ClientIDFromContext returns the ClientID of the AuthClaims of the provided ctx, or an empty string if
+// there are none.
+func ClientIDFromContext(ctx context.Context) string {
+	claims, ok := AuthClaimsFromContext(ctx)
+	if !ok || claims == nil {
+		return ""
+	}
+
+	return claims.ClientID
 }
 
 // ContextWithAuthClaims injects the provided AuthClaims into the parent context.
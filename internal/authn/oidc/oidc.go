@@ -115,8 +115,9 @@ func (oidc *RemoteOidcAuthenticator) Authenticate(requestContext context.Context
 	}
 
 	principal := &authn.AuthClaims{
-		Subject: subject,
-		Scopes:  make(map[string]bool),
+		Subject:  subject,
+		Scopes:   make(map[string]bool),
+		ClientID: subject,
 	}
 
 	// optional scopes
@@ -206,6 +206,7 @@ func TestRemoteOidcAuthenticator_Authenticate(t *testing.T) {
 			authClaims, err := oidc.Authenticate(requestContext)
 			require.NoError(t, err)
 			require.Equal(t, "openfga client", authClaims.Subject)
+			require.Equal(t, "openfga client", authClaims.ClientID)
 			scopesList := strings.Split(scopes, " ")
 			require.Equal(t, len(scopesList), len(authClaims.Scopes))
 			for _, scope := range scopesList {
@@ -2,6 +2,8 @@ package presharedkey
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 
 	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
@@ -35,7 +37,8 @@ func (pka *PresharedKeyAuthenticator) Authenticate(ctx context.Context) (*authn.
 
 	if _, found := pka.ValidKeys[authHeader]; found {
 		return &authn.AuthClaims{
-			Subject: "", // no user information in this auth method
+			Subject:  "", // no user information in this auth method
+			ClientID: KeyID(authHeader),
 		}, nil
 	}
 
@@ -43,3 +46,10 @@ func (pka *PresharedKeyAuthenticator) Authenticate(ctx context.Context) (*authn.
 }
 
 func (pka *PresharedKeyAuthenticator) Close() {}
+
+// KeyID returns the ID of a preshared key, which identifies the key without revealing it: the first 8
+// bytes of its SHA-256 hash, hex encoded.
+func KeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
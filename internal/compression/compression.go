@@ -0,0 +1,382 @@
+// Package compression contains the compressors of the gRPC server and the compression of the HTTP gateway responses.
+package compression
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/gzhttp"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+
+	// the gzip compressor of gRPC is registered when its package is initialized, so it must be initialized
+	// before this package to be replaced
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// Algorithms are the supported compression algorithms of the gRPC server.
+var Algorithms = []string{Gzip, Zstd}
+
+// grpcSettings are the settings of the gRPC compressors, which are registered with gRPC when the
+// package is initialized, as gRPC requires.
+type grpcSettings struct {
+	enabled map[string]bool
+
+	// maxDecompressedSize bounds the memory used to decompress a zstd message. gRPC itself stops reading
+	// a decompressed message once it exceeds the max received message size.
+	maxDecompressedSize uint64
+	zstdDecoders        sync.Pool
+}
+
+var settings atomic.Pointer[grpcSettings]
+
+func init() {
+	settings.Store(&grpcSettings{enabled: map[string]bool{}})
+
+	encoding.RegisterCompressor(&gzipCompressor{})
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// EnableGRPCCompressors enables the compressors of the given algorithms. The server decompresses the
+// requests that are compressed with an enabled algorithm, and compresses their responses with it. Requests
+// compressed with another algorithm are rejected. maxDecompressedSizeInBytes is the maximum size of a
+// decompressed request. It must be called before the server starts.
+func EnableGRPCCompressors(algorithms []string, maxDecompressedSizeInBytes int) error {
+	if maxDecompressedSizeInBytes <= 0 {
+		return fmt.Errorf("the maximum decompressed size must be positive")
+	}
+
+	enabled := make(map[string]bool, len(algorithms))
+	for _, algorithm := range algorithms {
+		switch algorithm {
+		case Gzip, Zstd:
+			enabled[algorithm] = true
+		default:
+			return fmt.Errorf("unsupported compression algorithm '%s'", algorithm)
+		}
+	}
+
+	settings.Store(&grpcSettings{enabled: enabled, maxDecompressedSize: uint64(maxDecompressedSizeInBytes)})
+
+	return nil
+}
+
+func checkEnabled(algorithm string) (*grpcSettings, error) {
+	s := settings.Load()
+	if !s.enabled[algorithm] {
+		return nil, fmt.Errorf("the '%s' compression algorithm is not enabled", algorithm)
+	}
+
+	return s, nil
+}
+
+// NewHTTPHandler returns a handler that compresses the responses of h with zstd or gzip, if the client
+// accepts it, and if the response is at least minSizeInBytes. zstd is preferred over gzip.
+func NewHTTPHandler(h http.Handler, minSizeInBytes int) (http.Handler, error) {
+	wrapper, err := gzhttp.NewWrapper(gzhttp.MinSize(minSizeInBytes))
+	if err != nil {
+		return nil, err
+	}
+	gzipHandler := wrapper(h)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsEncoding(r.Header.Get("Accept-Encoding"), Zstd) {
+			gzipHandler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		zw := &zstdResponseWriter{ResponseWriter: w, minSize: minSizeInBytes}
+		defer func() {
+			_ = zw.close()
+		}()
+
+		h.ServeHTTP(zw, r)
+	}), nil
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header accepts the encoding (e.g. "gzip, zstd;q=0.5").
+func acceptsEncoding(acceptEncoding, encoding string) bool {
+	for _, accepted := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(accepted, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+
+	return false
+}
+
+type gzipCompressor struct {
+	writers sync.Pool
+	readers sync.Pool
+}
+
+var _ encoding.Compressor = (*gzipCompressor)(nil)
+
+func (c *gzipCompressor) Name() string {
+	return Gzip
+}
+
+func (c *gzipCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	gw, ok := c.writers.Get().(*gzip.Writer)
+	if !ok {
+		return &pooledGzipWriter{Writer: gzip.NewWriter(w), pool: &c.writers}, nil
+	}
+
+	gw.Reset(w)
+	return &pooledGzipWriter{Writer: gw, pool: &c.writers}, nil
+}
+
+func (c *gzipCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	if _, err := checkEnabled(Gzip); err != nil {
+		return nil, err
+	}
+
+	gr, ok := c.readers.Get().(*gzip.Reader)
+	if !ok {
+		newReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &pooledGzipReader{Reader: newReader, pool: &c.readers}, nil
+	}
+
+	if err := gr.Reset(r); err != nil {
+		c.readers.Put(gr)
+		return nil, err
+	}
+
+	return &pooledGzipReader{Reader: gr, pool: &c.readers}, nil
+}
+
+type pooledGzipWriter struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+func (w *pooledGzipWriter) Close() error {
+	defer w.pool.Put(w.Writer)
+	return w.Writer.Close()
+}
+
+type pooledGzipReader struct {
+	*gzip.Reader
+	pool *sync.Pool
+}
+
+// Read returns the reader to the pool once the whole message has been read.
+func (r *pooledGzipReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.Reader)
+	}
+
+	return n, err
+}
+
+// zstdEncoders are the zstd encoders of the gRPC responses and of the HTTP responses.
+var zstdEncoders sync.Pool
+
+func getZstdEncoder(w io.Writer) (*zstd.Encoder, error) {
+	encoder, ok := zstdEncoders.Get().(*zstd.Encoder)
+	if !ok {
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	}
+
+	encoder.Reset(w)
+	return encoder, nil
+}
+
+type zstdCompressor struct{}
+
+var _ encoding.Compressor = (*zstdCompressor)(nil)
+
+func (c *zstdCompressor) Name() string {
+	return Zstd
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	encoder, err := getZstdEncoder(w)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pooledZstdWriter{Encoder: encoder}, nil
+}
+
+// Decompress streams the decompressed message, so that gRPC can stop reading it once it exceeds the
+// max received message size. The decoder also rejects messages whose window is larger than that.
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	s, err := checkEnabled(Zstd)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, ok := s.zstdDecoders.Get().(*zstd.Decoder)
+	if !ok {
+		newDecoder, err := zstd.NewReader(r,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxMemory(s.maxDecompressedSize),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &pooledZstdReader{decoder: newDecoder, pool: &s.zstdDecoders}, nil
+	}
+
+	if err := decoder.Reset(r); err != nil {
+		s.zstdDecoders.Put(decoder)
+		return nil, err
+	}
+
+	return &pooledZstdReader{decoder: decoder, pool: &s.zstdDecoders}, nil
+}
+
+type pooledZstdWriter struct {
+	*zstd.Encoder
+}
+
+func (w *pooledZstdWriter) Close() error {
+	defer zstdEncoders.Put(w.Encoder)
+	return w.Encoder.Close()
+}
+
+type pooledZstdReader struct {
+	decoder *zstd.Decoder
+	pool    *sync.Pool
+}
+
+// Read returns the decoder to the pool once the whole message has been read.
+func (r *pooledZstdReader) Read(p []byte) (int, error) {
+	n, err := r.decoder.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.decoder)
+	}
+
+	return n, err
+}
+
+// zstdResponseWriter buffers the response until it reaches the minimum size, and then compresses it
+// with zstd. A smaller response is written uncompressed when the handler returns.
+type zstdResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	status  int
+	buf     []byte
+	started bool
+	encoder *zstd.Encoder
+}
+
+func (z *zstdResponseWriter) WriteHeader(status int) {
+	if z.started || z.status != 0 {
+		return
+	}
+
+	z.status = status
+}
+
+func (z *zstdResponseWriter) Write(p []byte) (int, error) {
+	if z.started {
+		if z.encoder != nil {
+			return z.encoder.Write(p)
+		}
+		return z.ResponseWriter.Write(p)
+	}
+
+	z.buf = append(z.buf, p...)
+	if len(z.buf) < z.minSize {
+		return len(p), nil
+	}
+
+	if err := z.start(true); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush starts compressing a response that is still being buffered, e.g. a streamed response.
+func (z *zstdResponseWriter) Flush() {
+	if !z.started {
+		if err := z.start(true); err != nil {
+			return
+		}
+	}
+
+	if z.encoder != nil {
+		if err := z.encoder.Flush(); err != nil {
+			return
+		}
+	}
+
+	if flusher, ok := z.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// start writes the headers and the buffered response, which is compressed if compress is true and the
+// handler didn't encode it already.
+func (z *zstdResponseWriter) start(compress bool) error {
+	z.started = true
+
+	header := z.Header()
+	if compress && header.Get("Content-Encoding") == "" {
+		encoder, err := getZstdEncoder(z.ResponseWriter)
+		if err != nil {
+			return err
+		}
+
+		header.Set("Content-Encoding", Zstd)
+		header.Del("Content-Length")
+		z.encoder = encoder
+	}
+
+	if z.status != 0 {
+		z.ResponseWriter.WriteHeader(z.status)
+	}
+
+	buf := z.buf
+	z.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	if z.encoder != nil {
+		_, err := z.encoder.Write(buf)
+		return err
+	}
+
+	_, err := z.ResponseWriter.Write(buf)
+	return err
+}
+
+// close writes a response that is smaller than the minimum size uncompressed, or finishes compressing
+// the response.
+func (z *zstdResponseWriter) close() error {
+	if !z.started {
+		return z.start(false)
+	}
+
+	if z.encoder == nil {
+		return nil
+	}
+
+	defer zstdEncoders.Put(z.encoder)
+	return z.encoder.Close()
+}
@@ -0,0 +1,153 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestEnableGRPCCompressors(t *testing.T) {
+	require.NoError(t, EnableGRPCCompressors(Algorithms, 1024*1024))
+
+	message := []byte(strings.Repeat("document:1#viewer@user:anne,", 100))
+
+	for _, algorithm := range Algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			compressor := encoding.GetCompressor(algorithm)
+			require.NotNil(t, compressor)
+
+			// the compressors are reused between messages
+			for i := 0; i < 2; i++ {
+				var compressed bytes.Buffer
+				w, err := compressor.Compress(&compressed)
+				require.NoError(t, err)
+				_, err = w.Write(message)
+				require.NoError(t, err)
+				require.NoError(t, w.Close())
+				require.Less(t, compressed.Len(), len(message))
+
+				r, err := compressor.Decompress(&compressed)
+				require.NoError(t, err)
+				decompressed, err := io.ReadAll(r)
+				require.NoError(t, err)
+				require.Equal(t, message, decompressed)
+			}
+		})
+	}
+
+	require.ErrorContains(t, EnableGRPCCompressors([]string{"brotli"}, 1024), "unsupported compression algorithm 'brotli'")
+
+	t.Run("disabled_algorithm_is_rejected", func(t *testing.T) {
+		require.NoError(t, EnableGRPCCompressors([]string{Zstd}, 1024*1024))
+
+		var compressed bytes.Buffer
+		w, err := encoding.GetCompressor(Gzip).Compress(&compressed)
+		require.NoError(t, err)
+		_, err = w.Write(message)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		_, err = encoding.GetCompressor(Gzip).Decompress(&compressed)
+		require.ErrorContains(t, err, "the 'gzip' compression algorithm is not enabled")
+	})
+
+	t.Run("zstd_message_larger_than_max_size_is_rejected", func(t *testing.T) {
+		require.NoError(t, EnableGRPCCompressors([]string{Zstd}, 1024))
+
+		var compressed bytes.Buffer
+		w, err := encoding.GetCompressor(Zstd).Compress(&compressed)
+		require.NoError(t, err)
+		_, err = w.Write(make([]byte, 64*1024))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		r, err := encoding.GetCompressor(Zstd).Decompress(&compressed)
+		if err == nil {
+			_, err = io.ReadAll(r)
+		}
+		require.Error(t, err)
+	})
+}
+
+func TestNewHTTPHandler(t *testing.T) {
+	body := strings.Repeat("a", 2048)
+	handler, err := NewHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body[:len(r.URL.Query().Get("size"))])
+	}), 1024)
+	require.NoError(t, err)
+
+	t.Run("compressed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?size="+body, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		r, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, body, string(decompressed))
+	})
+
+	t.Run("smaller_than_min_size", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?size=aaa", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Empty(t, w.Header().Get("Content-Encoding"))
+		require.Equal(t, "aaa", w.Body.String())
+	})
+
+	t.Run("zstd", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?size="+body, nil)
+		req.Header.Set("Accept-Encoding", "gzip, zstd")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, "zstd", w.Header().Get("Content-Encoding"))
+		r, err := zstd.NewReader(w.Body)
+		require.NoError(t, err)
+		defer r.Close()
+		decompressed, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, body, string(decompressed))
+	})
+
+	t.Run("zstd_smaller_than_min_size", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?size=aaa", nil)
+		req.Header.Set("Accept-Encoding", "zstd")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Empty(t, w.Header().Get("Content-Encoding"))
+		require.Equal(t, "aaa", w.Body.String())
+	})
+
+	t.Run("zstd_not_accepted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?size="+body, nil)
+		req.Header.Set("Accept-Encoding", "zstd;q=0, gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("not_accepted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?size="+body, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Empty(t, w.Header().Get("Content-Encoding"))
+		require.Equal(t, body, w.Body.String())
+	})
+}
@@ -2,9 +2,13 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/karlseguin/ccache/v3"
@@ -17,7 +21,9 @@ import (
 	"github.com/openfga/openfga/internal/build"
 	"github.com/openfga/openfga/internal/keys"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/openfga/openfga/pkg/tuple"
 )
 
 const (
@@ -38,16 +44,89 @@ var (
 		Name:      "check_cache_hit_count",
 		Help:      "The total number of cache hits for ResolveCheck.",
 	})
+
+	checkCacheStaleHitCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "check_cache_stale_hit_count",
+		Help:      "The total number of expired ResolveCheck cache entries that were served because the datastore was unavailable.",
+	})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "check_cache_entries",
+		Help:      "The number of entries of the Check caches.",
+	}, func() float64 {
+		return openCaches.sum(func(c *CachedCheckResolver) int64 {
+			return int64(c.cache.ItemCount())
+		})
+	})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "check_cache_size_bytes",
+		Help:      "The estimated size in bytes of the entries of the Check caches that are bounded by a maximum number of bytes.",
+	}, func() float64 {
+		return openCaches.sum(func(c *CachedCheckResolver) int64 {
+			if c.maxCacheBytes == 0 {
+				return 0
+			}
+			return c.cache.GetSize()
+		})
+	})
+
+	openCaches = &cacheRegistry{resolvers: map[*CachedCheckResolver]struct{}{}}
 )
 
+// cacheEntryOverhead is the estimated number of bytes of a Check cache entry, in addition to its key.
+var cacheEntryOverhead = int64(unsafe.Sizeof(ccache.Item[*ResolveCheckResponse]{}) +
+	unsafe.Sizeof(ccache.Node[*ccache.Item[*ResolveCheckResponse]]{}) +
+	unsafe.Sizeof(ResolveCheckResponse{}) +
+	unsafe.Sizeof(ResolveCheckResponseMetadata{}) +
+	2*unsafe.Sizeof("")) // the key in the item and in the map of its bucket
+
+// cacheRegistry is the set of the caches allocated by the CachedCheckResolvers that aren't closed,
+// whose usage is reported by the gauges.
+type cacheRegistry struct {
+	mu        sync.Mutex
+	resolvers map[*CachedCheckResolver]struct{}
+}
+
+func (r *cacheRegistry) add(c *CachedCheckResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolvers[c] = struct{}{}
+}
+
+// remove removes the cache from the registry. It must be called before the cache is stopped.
+func (r *cacheRegistry) remove(c *CachedCheckResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.resolvers, c)
+}
+
+func (r *cacheRegistry) sum(value func(c *CachedCheckResolver) int64) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sum int64
+	for c := range r.resolvers {
+		sum += value(c)
+	}
+	return float64(sum)
+}
+
 // CachedCheckResolver attempts to resolve check sub-problems via prior computations before
 // delegating the request to some underlying CheckResolver.
 type CachedCheckResolver struct {
-	delegate     CheckResolver
-	cache        *ccache.Cache[*ResolveCheckResponse]
-	maxCacheSize int64
-	cacheTTL     time.Duration
-	logger       logger.Logger
+	delegate      CheckResolver
+	cache         *ccache.Cache[*ResolveCheckResponse]
+	maxCacheSize  int64
+	maxCacheBytes int64
+	cacheTTL      atomic.Int64 // a time.Duration, which can be changed at runtime with SetCacheTTL
+	negativeTTL   time.Duration
+	serveStale    bool
+	watermarks    *ChangelogWatermarks
+	logger        logger.Logger
 	// allocatedCache is used to denote whether the cache is allocated by this struct.
 	// If so, CachedCheckResolver is responsible for cleaning up.
 	allocatedCache bool
@@ -67,10 +146,45 @@ func WithMaxCacheSize(size int64) CachedCheckResolverOpt {
 	}
 }
 
+// WithMaxCacheBytes bounds the Check resolution cache by the estimated number of bytes of its
+// entries instead of their number, so that the maximum size set by WithMaxCacheSize is ignored.
+// A value of 0 keeps the bound on the number of entries.
+func WithMaxCacheBytes(maxBytes int64) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.maxCacheBytes = maxBytes
+	}
+}
+
 // WithCacheTTL sets the TTL (as a duration) for any single Check cache key value.
 func WithCacheTTL(ttl time.Duration) CachedCheckResolverOpt {
 	return func(ccr *CachedCheckResolver) {
-		ccr.cacheTTL = ttl
+		ccr.cacheTTL.Store(int64(ttl))
+	}
+}
+
+// WithNegativeCacheTTL sets the TTL (as a duration) of the Check cache key values whose result is
+// not allowed, instead of the TTL of WithCacheTTL. A value of 0 caches them with the same TTL.
+func WithNegativeCacheTTL(ttl time.Duration) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.negativeTTL = ttl
+	}
+}
+
+// WithServeStaleOnUnavailable serves the expired cached value of a Check sub-problem, if it is
+// still in the cache, when its resolution fails because the datastore is unavailable (see
+// storage.ErrUnavailable). The RequestMetadata.ServedStale flag of the request is then set.
+func WithServeStaleOnUnavailable() CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.serveStale = true
+	}
+}
+
+// WithChangelogWatermarks caches the Check sub-problems with the changelog watermark of their store,
+// so that they are no longer looked up once its tuples change, whatever their TTL. A sub-problem whose
+// watermark can't be read is resolved without the cache.
+func WithChangelogWatermarks(watermarks *ChangelogWatermarks) CachedCheckResolverOpt {
+	return func(ccr *CachedCheckResolver) {
+		ccr.watermarks = watermarks
 	}
 }
 
@@ -98,20 +212,27 @@ func WithLogger(logger logger.Logger) CachedCheckResolverOpt {
 func NewCachedCheckResolver(opts ...CachedCheckResolverOpt) *CachedCheckResolver {
 	checker := &CachedCheckResolver{
 		maxCacheSize: defaultMaxCacheSize,
-		cacheTTL:     defaultCacheTTL,
 		logger:       logger.NewNoopLogger(),
 	}
 	checker.delegate = checker
+	checker.cacheTTL.Store(int64(defaultCacheTTL))
 
 	for _, opt := range opts {
 		opt(checker)
 	}
 
 	if checker.cache == nil {
+		maxSize := checker.maxCacheSize
+		if checker.maxCacheBytes > 0 {
+			// the size of an entry is its number of bytes, see ResolveCheckResponse.Size
+			maxSize = checker.maxCacheBytes
+		}
+
 		checker.allocatedCache = true
 		checker.cache = ccache.New(
-			ccache.Configure[*ResolveCheckResponse]().MaxSize(checker.maxCacheSize),
+			ccache.Configure[*ResolveCheckResponse]().MaxSize(maxSize),
 		)
+		openCaches.add(checker)
 	}
 
 	return checker
@@ -127,10 +248,33 @@ func (c *CachedCheckResolver) GetDelegate() CheckResolver {
 	return c.delegate
 }
 
+// SetCacheTTL changes the TTL of the Check sub-problems cached from now on. Values that
+// are already cached keep the TTL they were cached with.
+func (c *CachedCheckResolver) SetCacheTTL(ttl time.Duration) {
+	c.cacheTTL.Store(int64(ttl))
+}
+
+// InvalidateStore deletes the cached Check sub-problems of a store, e.g. once its tuples changed, or
+// of every store if store is empty.
+func (c *CachedCheckResolver) InvalidateStore(store string) {
+	if store == "" {
+		c.cache.Clear()
+		return
+	}
+	c.cache.DeletePrefix(store + "/")
+}
+
+// Size returns the number of cached Check sub-problems, including the expired ones that weren't
+// evicted yet.
+func (c *CachedCheckResolver) Size() int {
+	return c.cache.ItemCount()
+}
+
 // Close will deallocate resource allocated by the CachedCheckResolver
 // It will not deallocate cache if it has been passed in from WithExistingCache.
 func (c *CachedCheckResolver) Close() {
 	if c.allocatedCache {
+		openCaches.remove(c)
 		c.cache.Stop()
 	}
 }
@@ -147,6 +291,15 @@ func (c *CachedCheckResolver) ResolveCheck(
 	))
 	defer span.End()
 
+	checkTrace := CheckTraceFromContext(ctx)
+
+	if CheckCacheDisabledFromContext(ctx) {
+		if checkTrace != nil {
+			checkTrace.Add(CheckTraceEvent{Kind: CheckTraceCacheSkipped, TupleKey: tuple.TupleKeyToString(req.GetTupleKey())})
+		}
+		return c.delegate.ResolveCheck(ctx, req)
+	}
+
 	checkCacheTotalCounter.Inc()
 
 	cacheKey, err := CheckRequestCacheKey(req)
@@ -156,17 +309,61 @@ func (c *CachedCheckResolver) ResolveCheck(
 		return nil, err
 	}
 
-	cachedResp := c.cache.Get(cacheKey)
+	if c.watermarks != nil {
+		watermark, err := c.watermarks.Watermark(ctx, req.GetStoreID())
+		if err != nil {
+			c.logger.WarnWithContext(ctx, "failed to read the changelog watermark, resolving the sub-problem without the cache", zap.Error(err))
+			return c.delegate.ResolveCheck(ctx, req)
+		}
+
+		// the watermark follows the store, so that the keys of a store can still be deleted by its prefix
+		store := req.GetStoreID()
+		cacheKey = store + "/" + watermark + cacheKey[len(store):]
+		span.SetAttributes(attribute.String("watermark", watermark))
+	}
+
+	var cachedResp *ccache.Item[*ResolveCheckResponse]
+	if !CheckCacheBypassFromContext(ctx) {
+		cachedResp = c.cache.Get(cacheKey)
+	}
+	// a result that isn't fresh for long enough is neither used nor served stale
+	if minFresh := CheckCacheMinFreshFromContext(ctx); cachedResp != nil && minFresh > 0 && cachedResp.TTL() < minFresh {
+		cachedResp = nil
+	}
 	if cachedResp != nil && !cachedResp.Expired() {
 		checkCacheHitCounter.Inc()
 		span.SetAttributes(attribute.Bool("is_cached", true))
+		telemetry.TraceCacheLookup(span, "check", true)
+		if checkTrace != nil {
+			checkTrace.Add(CheckTraceEvent{Kind: CheckTraceCacheHit, TupleKey: tuple.TupleKeyToString(req.GetTupleKey())})
+		}
+
+		if metadata := req.GetRequestMetadata(); metadata != nil && metadata.CacheHitCounter != nil {
+			metadata.CacheHitCounter.Add(1)
+		}
 
 		// return a copy to avoid races across goroutines
 		return CloneResolveCheckResponse(cachedResp.Value()), nil
 	}
 
+	telemetry.TraceCacheLookup(span, "check", false)
+	if checkTrace != nil {
+		checkTrace.Add(CheckTraceEvent{Kind: CheckTraceCacheMiss, TupleKey: tuple.TupleKeyToString(req.GetTupleKey())})
+	}
+
 	resp, err := c.delegate.ResolveCheck(ctx, req)
 	if err != nil {
+		if c.serveStale && cachedResp != nil && errors.Is(err, storage.ErrUnavailable) {
+			checkCacheStaleHitCounter.Inc()
+			span.SetAttributes(attribute.Bool("is_stale", true))
+
+			if metadata := req.GetRequestMetadata(); metadata != nil && metadata.ServedStale != nil {
+				metadata.ServedStale.Store(true)
+			}
+
+			return CloneResolveCheckResponse(cachedResp.Value()), nil
+		}
+
 		telemetry.TraceError(span, err)
 		return nil, err
 	}
@@ -176,8 +373,25 @@ func (c *CachedCheckResolver) ResolveCheck(
 	// to 0 so it doesn't bias the resolution metadata negatively
 	clonedResp := CloneResolveCheckResponse(resp)
 	clonedResp.ResolutionMetadata.DatastoreQueryCount = 0
+	if c.maxCacheBytes > 0 {
+		clonedResp.cacheSize = int64(len(cacheKey)) + cacheEntryOverhead
+	}
+
+	ttl := time.Duration(c.cacheTTL.Load())
+	override, overridden := CheckCacheTTLFromContext(ctx)
+	if overridden {
+		ttl = override
+	}
+
+	// a negative result is cached with its own TTL, but no longer than the TTL of the context
+	if !resp.GetAllowed() && c.negativeTTL > 0 {
+		ttl = c.negativeTTL
+		if overridden && override < ttl {
+			ttl = override
+		}
+	}
 
-	c.cache.Set(cacheKey, clonedResp, c.cacheTTL)
+	c.cache.Set(cacheKey, clonedResp, ttl)
 	return resp, nil
 }
 
@@ -186,7 +400,8 @@ func (c *CachedCheckResolver) ResolveCheck(
 //
 // For one store and model ID, the same tuple provided with the same contextual tuples and context
 // should produce the same cache key. Contextual tuple order and context parameter order is ignored,
-// only the contents are compared.
+// only the contents are compared. The key is prefixed with the store ID, so that the keys of a store
+// can be deleted by their prefix.
 func CheckRequestCacheKey(req *ResolveCheckRequest) (string, error) {
 	hasher := keys.NewCacheKeyHasher(xxhash.New())
 
@@ -218,5 +433,5 @@ func CheckRequestCacheKey(req *ResolveCheckRequest) (string, error) {
 		}
 	}
 
-	return strconv.FormatUint(hasher.Key().ToUInt64(), 10), nil
+	return req.GetStoreID() + "/" + strconv.FormatUint(hasher.Key().ToUInt64(), 10), nil
 }
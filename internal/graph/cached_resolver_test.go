@@ -514,6 +514,359 @@ func TestResolveCheckExpired(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestResolveCheckCacheBypass(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+		RequestMetadata:      NewCheckRequestMetadata(20),
+	}
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	gomock.InOrder(
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: false}, nil),
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: true}, nil),
+	)
+
+	dut := NewCachedCheckResolver()
+	defer dut.Close()
+
+	dut.SetDelegate(mockResolver)
+
+	actualResult, err := dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.False(t, actualResult.Allowed)
+
+	// the cached result is bypassed, and replaced by the new one
+	actualResult, err = dut.ResolveCheck(ContextWithCheckCacheBypass(ctx), req)
+	require.NoError(t, err)
+	require.True(t, actualResult.Allowed)
+
+	actualResult, err = dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.True(t, actualResult.Allowed)
+}
+
+func TestResolveCheckWithoutCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+		RequestMetadata:      NewCheckRequestMetadata(20),
+	}
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	gomock.InOrder(
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: true}, nil),
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: false}, nil),
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: false}, nil),
+	)
+
+	dut := NewCachedCheckResolver()
+	defer dut.Close()
+
+	dut.SetDelegate(mockResolver)
+
+	actualResult, err := dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.True(t, actualResult.Allowed)
+
+	// the cached result isn't used, and the new one isn't cached
+	actualResult, err = dut.ResolveCheck(ContextWithoutCheckCache(ctx), req)
+	require.NoError(t, err)
+	require.False(t, actualResult.Allowed)
+
+	actualResult, err = dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.True(t, actualResult.Allowed)
+
+	actualResult, err = dut.ResolveCheck(ContextWithoutCheckCache(ctx), req)
+	require.NoError(t, err)
+	require.False(t, actualResult.Allowed)
+}
+
+func TestResolveCheckInvalidateStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request := func(store string) *ResolveCheckRequest {
+		return &ResolveCheckRequest{
+			StoreID:              store,
+			AuthorizationModelID: "33",
+			TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+			RequestMetadata:      NewCheckRequestMetadata(20),
+		}
+	}
+	first, second := request("1"), request("2")
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), first).Times(3).Return(&ResolveCheckResponse{Allowed: true}, nil)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), second).Times(2).Return(&ResolveCheckResponse{Allowed: true}, nil)
+
+	dut := NewCachedCheckResolver()
+	defer dut.Close()
+
+	dut.SetDelegate(mockResolver)
+
+	resolve := func(req *ResolveCheckRequest) {
+		_, err := dut.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+	}
+
+	resolve(first)
+	resolve(second)
+
+	// only the sub-problems of the invalidated store are resolved again
+	dut.InvalidateStore("1")
+	resolve(first)
+	resolve(second)
+
+	// all of them are once every store is invalidated
+	dut.InvalidateStore("")
+	resolve(first)
+	resolve(second)
+}
+
+func TestResolveCheckCacheTTLOverride(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+		RequestMetadata:      NewCheckRequestMetadata(20),
+	}
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(2).Return(&ResolveCheckResponse{Allowed: true}, nil)
+
+	dut := NewCachedCheckResolver(WithCacheTTL(time.Hour))
+	defer dut.Close()
+
+	dut.SetDelegate(mockResolver)
+
+	_, err := dut.ResolveCheck(ContextWithCheckCacheTTL(ctx, time.Millisecond), req)
+	require.NoError(t, err)
+
+	// the result was cached with the TTL of the context, so it expired
+	time.Sleep(5 * time.Millisecond)
+	_, err = dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+
+	// and this one with the TTL of the resolver
+	time.Sleep(5 * time.Millisecond)
+	_, err = dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+}
+
+func TestResolveCheckCacheMinFresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+		RequestMetadata:      NewCheckRequestMetadata(20),
+	}
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	gomock.InOrder(
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: false}, nil),
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: true}, nil),
+	)
+
+	dut := NewCachedCheckResolver(WithCacheTTL(time.Minute))
+	defer dut.Close()
+
+	dut.SetDelegate(mockResolver)
+
+	actualResult, err := dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.False(t, actualResult.Allowed)
+
+	// the cached result is fresh for long enough
+	actualResult, err = dut.ResolveCheck(ContextWithCheckCacheMinFresh(ctx, time.Second), req)
+	require.NoError(t, err)
+	require.False(t, actualResult.Allowed)
+
+	// it isn't, so it is resolved again and replaced by the new one
+	actualResult, err = dut.ResolveCheck(ContextWithCheckCacheMinFresh(ctx, time.Hour), req)
+	require.NoError(t, err)
+	require.True(t, actualResult.Allowed)
+
+	actualResult, err = dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.True(t, actualResult.Allowed)
+}
+
+func TestResolveCheckNegativeCacheTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	allowedReq := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+		RequestMetadata:      NewCheckRequestMetadata(20),
+	}
+	deniedReq := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:ABC"),
+		RequestMetadata:      NewCheckRequestMetadata(20),
+	}
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), allowedReq).Times(1).Return(&ResolveCheckResponse{Allowed: true}, nil)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), deniedReq).Times(4).Return(&ResolveCheckResponse{Allowed: false}, nil)
+
+	dut := NewCachedCheckResolver(WithCacheTTL(time.Hour), WithNegativeCacheTTL(time.Millisecond))
+	defer dut.Close()
+
+	dut.SetDelegate(mockResolver)
+
+	for _, req := range []*ResolveCheckRequest{allowedReq, deniedReq} {
+		_, err := dut.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+	}
+
+	// the negative result expired, but not the positive one
+	time.Sleep(5 * time.Millisecond)
+	for _, req := range []*ResolveCheckRequest{allowedReq, deniedReq} {
+		resp, err := dut.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, req == allowedReq, resp.GetAllowed())
+	}
+
+	// a shorter TTL of the context applies to negative results too
+	time.Sleep(5 * time.Millisecond)
+	dut.negativeTTL = time.Hour
+	_, err := dut.ResolveCheck(ContextWithCheckCacheTTL(ctx, time.Millisecond), deniedReq)
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = dut.ResolveCheck(ctx, deniedReq)
+	require.NoError(t, err)
+}
+
+func TestResolveCheckServeStaleOnUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	req := &ResolveCheckRequest{
+		StoreID:              "12",
+		AuthorizationModelID: "33",
+		TupleKey:             tuple.NewTupleKey("document:abc", "reader", "user:XYZ"),
+		RequestMetadata:      NewCheckRequestMetadata(20),
+	}
+	unavailable := fmt.Errorf("%w: the circuit breaker is open", storage.ErrUnavailable)
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	gomock.InOrder(
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(1).Return(&ResolveCheckResponse{Allowed: true}, nil),
+		mockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Times(3).Return(nil, unavailable),
+	)
+
+	dut := NewCachedCheckResolver(WithCacheTTL(time.Millisecond), WithServeStaleOnUnavailable())
+	defer dut.Close()
+
+	dut.SetDelegate(mockResolver)
+
+	_, err := dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.False(t, req.GetRequestMetadata().ServedStale.Load())
+
+	// the expired result is served while the datastore is unavailable
+	time.Sleep(5 * time.Millisecond)
+	resp, err := dut.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.GetAllowed())
+	require.True(t, req.GetRequestMetadata().ServedStale.Load())
+
+	// but not if the cache is bypassed
+	_, err = dut.ResolveCheck(ContextWithCheckCacheBypass(ctx), req)
+	require.ErrorIs(t, err, storage.ErrUnavailable)
+
+	// nor without the option
+	dut.serveStale = false
+	_, err = dut.ResolveCheck(ctx, req)
+	require.ErrorIs(t, err, storage.ErrUnavailable)
+}
+
+func TestResolveCheckCacheMaxBytes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockResolver := NewMockCheckResolver(ctrl)
+	mockResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).AnyTimes().Return(&ResolveCheckResponse{Allowed: true}, nil)
+
+	request := func(i int) *ResolveCheckRequest {
+		return &ResolveCheckRequest{
+			StoreID:              "12",
+			AuthorizationModelID: "33",
+			TupleKey:             tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "reader", "user:XYZ"),
+			RequestMetadata:      NewCheckRequestMetadata(20),
+		}
+	}
+
+	cacheKey, err := CheckRequestCacheKey(request(0))
+	require.NoError(t, err)
+	entrySize := int64(len(cacheKey)) + cacheEntryOverhead
+
+	dut := NewCachedCheckResolver(WithMaxCacheSize(1), WithMaxCacheBytes(1000*entrySize))
+	defer dut.Close()
+
+	dut.SetDelegate(mockResolver)
+
+	var size int64
+	for i := 0; i < 10; i++ {
+		_, err := dut.ResolveCheck(ctx, request(i))
+		require.NoError(t, err)
+
+		cacheKey, err := CheckRequestCacheKey(request(i))
+		require.NoError(t, err)
+		size += int64(len(cacheKey)) + cacheEntryOverhead
+	}
+	dut.cache.SyncUpdates()
+
+	// the entries are accounted by their bytes, instead of the maximum number of entries
+	require.Equal(t, 10, dut.cache.ItemCount())
+	require.Equal(t, size, dut.cache.GetSize())
+
+	for i := 10; i < 2000; i++ {
+		_, err := dut.ResolveCheck(ctx, request(i))
+		require.NoError(t, err)
+	}
+	dut.cache.SyncUpdates()
+
+	require.LessOrEqual(t, dut.cache.GetSize(), 1000*entrySize)
+	require.Less(t, dut.cache.ItemCount(), 2000)
+}
+
 func TestCachedCheckResolver_CycleDetected(t *testing.T) {
 	cachedCheckResolver := NewCachedCheckResolver()
 	defer cachedCheckResolver.Close()
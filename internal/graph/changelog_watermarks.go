@@ -0,0 +1,140 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// watermarkPageSize is the number of changes of a store read at once to move its watermark.
+const watermarkPageSize = 1000
+
+// watermark is the position of a store in its changelog at the time it was read.
+type watermark struct {
+	// token is the continuation token of the last change of the store that was read.
+	token string
+
+	// generation is the number of invalidations of the store when it was read.
+	generation uint64
+
+	// key is the hash of the token and the generation, so that the cache keys stay short.
+	key string
+
+	read time.Time
+}
+
+// fresh reports whether the watermark can be used without reading the changelog again.
+func (m *watermark) fresh(generation uint64, refreshInterval time.Duration) bool {
+	return m != nil && m.generation == generation && time.Since(m.read) < refreshInterval
+}
+
+// storeWatermark is the watermark of a store. mu serializes the reads of its changelog, while the
+// current watermark is read without it.
+type storeWatermark struct {
+	mu         sync.Mutex
+	current    atomic.Pointer[watermark]
+	generation atomic.Uint64
+}
+
+// ChangelogWatermarks tracks the position of each store in its changelog, its watermark, which moves
+// whenever the tuples of the store change. The Check sub-problems cached with the watermark of their
+// store (see WithChangelogWatermarks) are no longer looked up once it moves, so they can be cached
+// with a long TTL and still be at most about a refresh interval stale.
+//
+// The changelog of a store is read on demand, from the last change read, when its watermark is older
+// than the refresh interval, so only the changelogs of the stores that are checked are read, and the
+// first watermark of a store reads its whole changelog. The changes are read in the order of their
+// ULIDs, which isn't the order they are committed in, so a change committed after a change with a
+// later ULID only moves the watermark with the next change of the store. Invalidate moves it right
+// away, e.g. once this server or another one wrote the tuples of the store.
+type ChangelogWatermarks struct {
+	backend         storage.ChangelogBackend
+	refreshInterval time.Duration
+
+	mu     sync.Mutex
+	stores map[string]*storeWatermark
+}
+
+// NewChangelogWatermarks returns the ChangelogWatermarks of the changelogs of the backend, whose
+// watermarks are refreshed every refreshInterval.
+func NewChangelogWatermarks(backend storage.ChangelogBackend, refreshInterval time.Duration) *ChangelogWatermarks {
+	return &ChangelogWatermarks{
+		backend:         backend,
+		refreshInterval: refreshInterval,
+		stores:          map[string]*storeWatermark{},
+	}
+}
+
+// Watermark returns the key of the watermark of the store, after reading the changes of its
+// changelog since its last watermark, if that is older than the refresh interval or invalidated.
+func (w *ChangelogWatermarks) Watermark(ctx context.Context, store string) (string, error) {
+	w.mu.Lock()
+	sw, ok := w.stores[store]
+	if !ok {
+		sw = &storeWatermark{}
+		w.stores[store] = sw
+	}
+	w.mu.Unlock()
+
+	if current := sw.current.Load(); current.fresh(sw.generation.Load(), w.refreshInterval) {
+		return current.key, nil
+	}
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	// another call may have read the changelog while this one waited
+	current := sw.current.Load()
+	if current.fresh(sw.generation.Load(), w.refreshInterval) {
+		return current.key, nil
+	}
+
+	next := &watermark{read: time.Now()}
+	if current != nil {
+		next.token = current.token
+	}
+
+	for {
+		changes, token, err := w.backend.ReadChanges(ctx, store, storage.ReadChangesFilter{},
+			storage.NewPaginationOptions(watermarkPageSize, next.token), 0)
+		if errors.Is(err, storage.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		next.token = string(token)
+		if len(changes) < watermarkPageSize {
+			break
+		}
+	}
+
+	// the generation is loaded once the changelog is read, so that an invalidation while it was
+	// read still moves the watermark
+	next.generation = sw.generation.Load()
+	next.key = strconv.FormatUint(xxhash.Sum64String(next.token+"#"+strconv.FormatUint(next.generation, 10)), 10)
+
+	sw.current.Store(next)
+	return next.key, nil
+}
+
+// Invalidate moves the watermark of a store, or of every store if store is empty, whether its
+// changelog has new changes or not.
+func (w *ChangelogWatermarks) Invalidate(store string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for name, sw := range w.stores {
+		if store == "" || name == store {
+			sw.generation.Add(1)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestChangelogWatermarks(t *testing.T) {
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	write := func(t *testing.T, store, object string) {
+		err := ds.Write(ctx, store, nil, []*openfgav1.TupleKey{tuple.NewTupleKey(object, "viewer", "user:anne")})
+		require.NoError(t, err)
+	}
+
+	t.Run("moves_with_the_changes_of_the_store", func(t *testing.T) {
+		store := ulid.Make().String()
+		watermarks := NewChangelogWatermarks(ds, time.Nanosecond)
+
+		empty, err := watermarks.Watermark(ctx, store)
+		require.NoError(t, err)
+
+		write(t, store, "document:1")
+		first, err := watermarks.Watermark(ctx, store)
+		require.NoError(t, err)
+		require.NotEqual(t, empty, first)
+
+		again, err := watermarks.Watermark(ctx, store)
+		require.NoError(t, err)
+		require.Equal(t, first, again)
+
+		// the changes of another store don't move it
+		write(t, ulid.Make().String(), "document:1")
+		again, err = watermarks.Watermark(ctx, store)
+		require.NoError(t, err)
+		require.Equal(t, first, again)
+
+		write(t, store, "document:2")
+		second, err := watermarks.Watermark(ctx, store)
+		require.NoError(t, err)
+		require.NotEqual(t, first, second)
+	})
+
+	t.Run("is_read_once_per_refresh_interval", func(t *testing.T) {
+		store := ulid.Make().String()
+		watermarks := NewChangelogWatermarks(ds, time.Hour)
+
+		first, err := watermarks.Watermark(ctx, store)
+		require.NoError(t, err)
+
+		write(t, store, "document:1")
+		again, err := watermarks.Watermark(ctx, store)
+		require.NoError(t, err)
+		require.Equal(t, first, again)
+
+		// an invalidation moves it before the refresh interval
+		watermarks.Invalidate(store)
+		moved, err := watermarks.Watermark(ctx, store)
+		require.NoError(t, err)
+		require.NotEqual(t, first, moved)
+
+		// also without new changes
+		watermarks.Invalidate("")
+		again, err = watermarks.Watermark(ctx, store)
+		require.NoError(t, err)
+		require.NotEqual(t, moved, again)
+	})
+}
+
+func TestCachedCheckResolverWithChangelogWatermarks(t *testing.T) {
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	store := ulid.Make().String()
+	watermarks := NewChangelogWatermarks(ds, time.Hour)
+
+	delegate := &fakePeer{}
+	cached := NewCachedCheckResolver(WithCacheTTL(time.Hour), WithChangelogWatermarks(watermarks))
+	cached.SetDelegate(delegate)
+	t.Cleanup(cached.Close)
+
+	req := &ResolveCheckRequest{
+		StoreID:              store,
+		AuthorizationModelID: ulid.Make().String(),
+		TupleKey:             tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		RequestMetadata:      NewCheckRequestMetadata(defaultResolveNodeLimit),
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := cached.ResolveCheck(ctx, req)
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(1), delegate.resolved.Load())
+
+	err := ds.Write(ctx, store, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")})
+	require.NoError(t, err)
+	watermarks.Invalidate(store)
+
+	_, err = cached.ResolveCheck(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), delegate.resolved.Load())
+
+	// the cached sub-problems of the store can still be flushed
+	cached.InvalidateStore(store)
+	require.Zero(t, cached.Size())
+}
@@ -5,19 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 	"golang.org/x/exp/maps"
 	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/openfga/openfga/internal/build"
 	"github.com/openfga/openfga/internal/condition"
 	"github.com/openfga/openfga/internal/condition/eval"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/middleware/requestid"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/telemetry"
 	"github.com/openfga/openfga/pkg/tuple"
@@ -26,6 +34,18 @@ import (
 
 var tracer = otel.Tracer("internal/graph/check")
 
+var unionShortCircuitHandlerCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "check_union_short_circuit_handler_count",
+	Help:      "The total number of handlers of unions, e.g. dispatches of sub-problems, that were cancelled once a sibling resolved to allowed, by whether they had started and their work was wasted ('wasted') or they never started ('skipped').",
+}, []string{"state"})
+
+var clientDispatchCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "check_client_dispatch_count",
+	Help:      "The total number of sub-problems resolved by the local checker, by the client ID of the authenticated caller of the request they were dispatched for (empty if unknown).",
+}, []string{"client_id"})
+
 type ResolveCheckRequest struct {
 	StoreID              string
 	AuthorizationModelID string
@@ -34,6 +54,62 @@ type ResolveCheckRequest struct {
 	Context              *structpb.Struct
 	RequestMetadata      *ResolveCheckRequestMetadata
 	VisitedPaths         map[string]struct{}
+
+	// path is the sequence of dispatches that led to this request. It is used to report cycles.
+	path *resolutionPathNode
+}
+
+// resolutionPathNode is a node in the sequence of dispatches that led to a request. Each node
+// points to the node of the request that dispatched it, so that sibling requests share their
+// common ancestors.
+type resolutionPathNode struct {
+	object   string
+	relation string
+	parent   *resolutionPathNode
+}
+
+// pathNode returns the node of the request in its resolution path.
+func (r *ResolveCheckRequest) pathNode() *resolutionPathNode {
+	if r.path != nil {
+		return r.path
+	}
+
+	return &resolutionPathNode{
+		object:   r.GetTupleKey().GetObject(),
+		relation: r.GetTupleKey().GetRelation(),
+	}
+}
+
+// cycle returns the first cycle in the resolution path ending in this node as a sequence of
+// `type#relation` nodes that starts and ends with the same node, or nil if there is no cycle.
+// An `object#relation` pair that appears twice in the path is evaluated again with the same
+// user, so the evaluation can only end by exceeding the resolution depth.
+func (n *resolutionPathNode) cycle() []string {
+	var path []*resolutionPathNode
+	for node := n; node != nil; node = node.parent {
+		path = append(path, node)
+	}
+
+	// path is ordered from this node to the root, so walk it backwards
+	seen := make(map[string]int, len(path))
+	for i := len(path) - 1; i >= 0; i-- {
+		key := tuple.ToObjectRelationString(path[i].object, path[i].relation)
+
+		start, ok := seen[key]
+		if !ok {
+			seen[key] = i
+			continue
+		}
+
+		cycle := make([]string, 0, start-i+1)
+		for j := start; j >= i; j-- {
+			cycle = append(cycle, tuple.ToObjectRelationString(tuple.GetType(path[j].object), path[j].relation))
+		}
+
+		return cycle
+	}
+
+	return nil
 }
 
 func clone(r *ResolveCheckRequest) *ResolveCheckRequest {
@@ -44,12 +120,19 @@ func clone(r *ResolveCheckRequest) *ResolveCheckRequest {
 		ContextualTuples:     r.ContextualTuples,
 		Context:              r.Context,
 		RequestMetadata: &ResolveCheckRequestMetadata{
-			DispatchCounter:     r.GetRequestMetadata().DispatchCounter,
-			Depth:               r.GetRequestMetadata().Depth,
-			DatastoreQueryCount: r.GetRequestMetadata().DatastoreQueryCount,
-			WasThrottled:        r.GetRequestMetadata().WasThrottled,
+			DispatchCounter:      r.GetRequestMetadata().DispatchCounter,
+			Depth:                r.GetRequestMetadata().Depth,
+			DatastoreQueryCount:  r.GetRequestMetadata().DatastoreQueryCount,
+			WasThrottled:         r.GetRequestMetadata().WasThrottled,
+			CacheHitCounter:      r.GetRequestMetadata().CacheHitCounter,
+			ServedStale:          r.GetRequestMetadata().ServedStale,
+			ThrottlingDuration:   r.GetRequestMetadata().ThrottlingDuration,
+			DatastoreReadCounter: r.GetRequestMetadata().DatastoreReadCounter,
+			Budget:               r.GetRequestMetadata().Budget,
+			ClientID:             r.GetRequestMetadata().ClientID,
 		},
 		VisitedPaths: maps.Clone(r.VisitedPaths),
+		path:         r.path,
 	}
 }
 
@@ -66,6 +149,7 @@ func CloneResolveCheckResponse(r *ResolveCheckResponse) *ResolveCheckResponse {
 	if r.GetResolutionMetadata() != nil {
 		resolutionMetadata.DatastoreQueryCount = r.GetResolutionMetadata().DatastoreQueryCount
 		resolutionMetadata.CycleDetected = r.GetResolutionMetadata().CycleDetected
+		resolutionMetadata.Cycle = r.GetResolutionMetadata().Cycle
 	}
 
 	return &ResolveCheckResponse{
@@ -77,6 +161,20 @@ func CloneResolveCheckResponse(r *ResolveCheckResponse) *ResolveCheckResponse {
 type ResolveCheckResponse struct {
 	Allowed            bool
 	ResolutionMetadata *ResolveCheckResponseMetadata
+
+	// cacheSize is the estimated number of bytes of the response in a Check cache that is bounded
+	// by bytes, or 0 if it isn't.
+	cacheSize int64
+}
+
+// Size is the size of the response in the Check cache: its number of bytes if the cache is bounded
+// by bytes, and 1 otherwise. It implements the Sized interface of ccache.
+func (r *ResolveCheckResponse) Size() int64 {
+	if r.cacheSize > 0 {
+		return r.cacheSize
+	}
+
+	return 1
 }
 
 func (r *ResolveCheckResponse) GetCycleDetected() bool {
@@ -87,6 +185,16 @@ func (r *ResolveCheckResponse) GetCycleDetected() bool {
 	return false
 }
 
+// GetCycle returns the cycle detected in the evaluation, if it is known, see
+// ResolveCheckResponseMetadata.Cycle.
+func (r *ResolveCheckResponse) GetCycle() []string {
+	if r != nil && r.GetResolutionMetadata() != nil {
+		return r.GetResolutionMetadata().Cycle
+	}
+
+	return nil
+}
+
 func (r *ResolveCheckResponse) GetAllowed() bool {
 	if r != nil {
 		return r.Allowed
@@ -167,6 +275,10 @@ type LocalChecker struct {
 	delegate           CheckResolver
 	concurrencyLimit   uint32
 	maxConcurrentReads uint32
+	planner            *Planner
+	logger             logger.Logger
+	usersetsPageSize   int
+	dispatchPool       *DispatchPool
 }
 
 type LocalCheckerOption func(d *LocalChecker)
@@ -185,6 +297,41 @@ func WithMaxConcurrentReads(limit uint32) LocalCheckerOption {
 	}
 }
 
+// WithPlanner sets the [Planner] that chooses how usersets and tuple to userset rewrites are resolved.
+// If it is not set, they are always resolved with the [ForwardExpansionStrategy].
+func WithPlanner(planner *Planner) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.planner = planner
+	}
+}
+
+// WithLocalCheckerLogger sets the logger of the LocalChecker, which logs every sub-problem that it
+// resolves at the debug level.
+func WithLocalCheckerLogger(logger logger.Logger) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.logger = logger
+	}
+}
+
+// WithUsersetsPageSize see server.WithCheckUsersetsPageSize.
+func WithUsersetsPageSize(pageSize uint32) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.usersetsPageSize = int(pageSize)
+	}
+}
+
+// WithDispatchPoolSize runs the concurrent handlers of the Check resolutions, e.g. the dispatches of
+// the operands of a union, on a DispatchPool of up to size reusable goroutines, which is closed by
+// Close. If it is 0, a goroutine is started for each handler.
+func WithDispatchPoolSize(size int) LocalCheckerOption {
+	return func(d *LocalChecker) {
+		d.dispatchPool = nil
+		if size > 0 {
+			d.dispatchPool = NewDispatchPool(size)
+		}
+	}
+}
+
 // NewLocalChecker constructs a LocalChecker that can be used to evaluate a Check
 // request locally.
 //
@@ -195,6 +342,8 @@ func NewLocalChecker(opts ...LocalCheckerOption) *LocalChecker {
 	checker := &LocalChecker{
 		concurrencyLimit:   serverconfig.DefaultResolveNodeBreadthLimit,
 		maxConcurrentReads: serverconfig.DefaultMaxConcurrentReadsForCheck,
+		logger:             logger.NewNoopLogger(),
+		usersetsPageSize:   serverconfig.DefaultCheckUsersetsPageSize,
 	}
 	// by default, a LocalChecker delegates/dispatchs subproblems to itself (e.g. local dispatch) unless otherwise configured.
 	checker.delegate = checker
@@ -239,12 +388,16 @@ type CheckFuncReducer func(ctx context.Context, concurrencyLimit uint32, handler
 
 // resolver concurrently resolves one or more CheckHandlerFunc and yields the results on the provided resultChan.
 // Callers of the 'resolver' function should be sure to invoke the callback returned from this function to ensure
-// every concurrent check is evaluated. The concurrencyLimit can be set to provide a maximum number of concurrent
-// evaluations in flight at any point.
-func resolver(ctx context.Context, concurrencyLimit uint32, resultChan chan<- checkOutcome, handlers ...CheckHandlerFunc) func() {
+// every concurrent check is evaluated. The callback returns the number of handlers that were started, which is
+// less than the number of handlers if the context was cancelled before the others were. The concurrencyLimit can
+// be set to provide a maximum number of concurrent evaluations in flight at any point. The handlers run on the
+// DispatchPool of the context, if any.
+func resolver(ctx context.Context, concurrencyLimit uint32, resultChan chan<- checkOutcome, handlers ...CheckHandlerFunc) func() int {
 	limiter := make(chan struct{}, concurrencyLimit)
+	pool := dispatchPoolFromContext(ctx)
 
 	var wg sync.WaitGroup
+	var started atomic.Int64
 
 	checker := func(fn CheckHandlerFunc) {
 		defer func() {
@@ -259,10 +412,11 @@ func resolver(ctx context.Context, concurrencyLimit uint32, resultChan chan<- ch
 			return
 		}
 
-		go func() {
-			resp, err := fn(ctx)
+		started.Add(1)
+		pool.Go(func() {
+			resp, err := runCheckHandler(ctx, fn)
 			resolved <- checkOutcome{resp, err}
-		}()
+		})
 
 		select {
 		case <-ctx.Done():
@@ -273,7 +427,7 @@ func resolver(ctx context.Context, concurrencyLimit uint32, resultChan chan<- ch
 	}
 
 	wg.Add(1)
-	go func() {
+	pool.Go(func() {
 	outer:
 		for _, handler := range handlers {
 			fn := handler // capture loop var
@@ -281,21 +435,28 @@ func resolver(ctx context.Context, concurrencyLimit uint32, resultChan chan<- ch
 			select {
 			case limiter <- struct{}{}:
 				wg.Add(1)
-				go checker(fn)
+				pool.Go(func() { checker(fn) })
 			case <-ctx.Done():
 				break outer
 			}
 		}
 
 		wg.Done()
-	}()
+	})
 
-	return func() {
+	return func() int {
 		wg.Wait()
 		close(limiter)
+		return int(started.Load())
 	}
 }
 
+// runCheckHandler runs fn, and returns a panic of fn as a PanicError.
+func runCheckHandler(ctx context.Context, fn CheckHandlerFunc) (resp *ResolveCheckResponse, err error) {
+	defer RecoverPanic(ctx, "check", &err)
+	return fn(ctx)
+}
+
 // union implements a CheckFuncReducer that requires any of the provided CheckHandlerFunc to resolve
 // to an allowed outcome. The first allowed outcome causes premature termination of the reducer.
 func union(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandlerFunc) (*ResolveCheckResponse, error) {
@@ -304,18 +465,30 @@ func union(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandle
 
 	drain := resolver(ctx, concurrencyLimit, resultChan, handlers...)
 
+	// the number of outcomes read, and whether one of them was allowed before the others were read
+	var read int
+	var shortCircuited bool
+
 	defer func() {
+		// the handlers that are still running or waiting, e.g. throttled dispatches, are cancelled
 		cancel()
-		drain()
+		started := drain()
 		close(resultChan)
+
+		if shortCircuited {
+			unionShortCircuitHandlerCounter.WithLabelValues("wasted").Add(float64(started - read))
+			unionShortCircuitHandlerCounter.WithLabelValues("skipped").Add(float64(len(handlers) - started))
+		}
 	}()
 
 	var dbReads uint32
 	var err error
 	var cycleDetected bool
+	var cycle []string
 	for i := 0; i < len(handlers); i++ {
 		select {
 		case result := <-resultChan:
+			read++
 			if result.err != nil {
 				err = result.err
 				continue
@@ -323,12 +496,16 @@ func union(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandle
 
 			if result.resp.GetCycleDetected() {
 				cycleDetected = true
+				if cycle == nil {
+					cycle = result.resp.GetCycle()
+				}
 			}
 
 			dbReads += result.resp.GetResolutionMetadata().DatastoreQueryCount
 
 			if result.resp.GetAllowed() {
 				result.resp.GetResolutionMetadata().DatastoreQueryCount = dbReads
+				shortCircuited = read < len(handlers)
 				return result.resp, nil
 			}
 		case <-ctx.Done():
@@ -345,6 +522,7 @@ func union(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandle
 		ResolutionMetadata: &ResolveCheckResponseMetadata{
 			DatastoreQueryCount: dbReads,
 			CycleDetected:       cycleDetected,
+			Cycle:               cycle,
 		},
 	}, nil
 }
@@ -409,7 +587,7 @@ func intersection(ctx context.Context, concurrencyLimit uint32, handlers ...Chec
 
 // exclusion implements a CheckFuncReducer that requires a 'base' CheckHandlerFunc to resolve to an allowed
 // outcome and a 'sub' CheckHandlerFunc to resolve to a falsey outcome. The base and sub computations are
-// handled concurrently relative to one another.
+// handled concurrently relative to one another, on the DispatchPool of the context, if any.
 func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHandlerFunc) (*ResolveCheckResponse, error) {
 	if len(handlers) != 2 {
 		panic(fmt.Sprintf("expected two rewrite operands for exclusion operator, but got '%d'", len(handlers)))
@@ -418,6 +596,7 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 	span := trace.SpanFromContext(ctx)
 
 	limiter := make(chan struct{}, concurrencyLimit)
+	pool := dispatchPoolFromContext(ctx)
 
 	ctx, cancel := context.WithCancel(ctx)
 	baseChan := make(chan checkOutcome, 1)
@@ -437,21 +616,21 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 
 	limiter <- struct{}{}
 	wg.Add(1)
-	go func() {
-		resp, err := baseHandler(ctx)
+	pool.Go(func() {
+		resp, err := runCheckHandler(ctx, baseHandler)
 		baseChan <- checkOutcome{resp, err}
 		<-limiter
 		wg.Done()
-	}()
+	})
 
 	limiter <- struct{}{}
 	wg.Add(1)
-	go func() {
-		resp, err := subHandler(ctx)
+	pool.Go(func() {
+		resp, err := runCheckHandler(ctx, subHandler)
 		subChan <- checkOutcome{resp, err}
 		<-limiter
 		wg.Done()
-	}()
+	})
 
 	response := &ResolveCheckResponse{
 		Allowed: false,
@@ -481,6 +660,7 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 					ResolutionMetadata: &ResolveCheckResponseMetadata{
 						DatastoreQueryCount: dbReads,
 						CycleDetected:       true,
+						Cycle:               baseResult.resp.GetCycle(),
 					},
 				}, nil
 			}
@@ -505,6 +685,7 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 					ResolutionMetadata: &ResolveCheckResponseMetadata{
 						DatastoreQueryCount: dbReads,
 						CycleDetected:       true,
+						Cycle:               subResult.resp.GetCycle(),
 					},
 				}, nil
 			}
@@ -537,21 +718,48 @@ func exclusion(ctx context.Context, concurrencyLimit uint32, handlers ...CheckHa
 
 // Close is a noop.
 func (c *LocalChecker) Close() {
+	if c.dispatchPool != nil {
+		c.dispatchPool.Close()
+	}
 }
 
 // dispatch clones the parent request, modifies its metadata and tupleKey, and dispatches the new request
 // to the CheckResolver this LocalChecker was constructed with.
 func (c *LocalChecker) dispatch(_ context.Context, parentReq *ResolveCheckRequest, tk *openfgav1.TupleKey) CheckHandlerFunc {
 	return func(ctx context.Context) (*ResolveCheckResponse, error) {
-		parentReq.GetRequestMetadata().DispatchCounter.Add(1)
+		if err := parentReq.GetRequestMetadata().chargeDispatch(); err != nil {
+			return nil, err
+		}
+
 		childRequest := clone(parentReq)
 		childRequest.TupleKey = tk
+		childRequest.path = &resolutionPathNode{
+			object:   tk.GetObject(),
+			relation: tk.GetRelation(),
+			parent:   parentReq.pathNode(),
+		}
 		childRequest.GetRequestMetadata().Depth--
 
+		checkTrace := CheckTraceFromContext(ctx)
+		if checkTrace == nil {
+			return c.delegate.ResolveCheck(ctx, childRequest)
+		}
+
+		key := tuple.TupleKeyToString(tk)
+		depth := childRequest.GetRequestMetadata().Depth
+		checkTrace.Add(CheckTraceEvent{Kind: CheckTraceDispatch, TupleKey: key, Depth: depth})
+
+		start := time.Now()
 		resp, err := c.delegate.ResolveCheck(ctx, childRequest)
+		event := CheckTraceEvent{Kind: CheckTraceResolved, TupleKey: key, Depth: depth, Duration: time.Since(start)}
 		if err != nil {
+			event.Error = err.Error()
+			checkTrace.Add(event)
 			return nil, err
 		}
+		allowed := resp.GetAllowed()
+		event.Allowed = &allowed
+		checkTrace.Add(event)
 		return resp, nil
 	}
 }
@@ -575,7 +783,23 @@ func (c *LocalChecker) ResolveCheck(
 	))
 	defer span.End()
 
+	// the dispatched subrequests share the request ID of the request, so that they can be correlated
+	if requestID, ok := requestid.FromContext(ctx); ok {
+		span.SetAttributes(attribute.String("request_id", requestID))
+	}
+
+	clientID := req.GetRequestMetadata().ClientID
+	if clientID != "" {
+		span.SetAttributes(attribute.String("client_id", clientID))
+	}
+	clientDispatchCounter.WithLabelValues(clientID).Inc()
+
 	if req.GetRequestMetadata().Depth == 0 {
+		if cycle := req.pathNode().cycle(); cycle != nil {
+			span.SetAttributes(attribute.Bool("cycle_detected", true))
+			return nil, &ResolutionCycleError{Cycle: cycle}
+		}
+
 		return nil, ErrResolutionDepthExceeded
 	}
 
@@ -606,12 +830,34 @@ func (c *LocalChecker) ResolveCheck(
 		return nil, fmt.Errorf("relation '%s' undefined for object type '%s'", relation, objectType)
 	}
 
+	if c.dispatchPool != nil {
+		ctx = contextWithDispatchPool(ctx, c.dispatchPool)
+	}
+
 	resp, err := c.checkRewrite(ctx, req, rel.GetRewrite())(ctx)
 	if err != nil {
 		telemetry.TraceError(span, err)
+		c.logger.DebugWithContext(ctx, "check sub-problem failed",
+			zap.String("store_id", req.GetStoreID()),
+			zap.String("client_id", clientID),
+			zap.String("object", object),
+			zap.String("relation", relation),
+			zap.String("user", tupleKey.GetUser()),
+			zap.Error(err),
+		)
 		return nil, err
 	}
 
+	c.logger.DebugWithContext(ctx, "check sub-problem resolved",
+		zap.String("store_id", req.GetStoreID()),
+		zap.String("client_id", clientID),
+		zap.String("object", object),
+		zap.String("relation", relation),
+		zap.String("user", tupleKey.GetUser()),
+		zap.Bool("allowed", resp.GetAllowed()),
+		zap.Uint32("datastore_query_count", resp.GetResolutionMetadata().DatastoreQueryCount),
+	)
+
 	return resp, nil
 }
 
@@ -657,6 +903,10 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 				},
 			}
 
+			if err := req.GetRequestMetadata().chargeDatastoreRead(); err != nil {
+				return nil, err
+			}
+
 			t, err := ds.ReadUserTuple(ctx, storeID, reqTupleKey)
 			if err != nil {
 				if errors.Is(err, storage.ErrNotFound) {
@@ -712,10 +962,15 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 				},
 			}
 
+			if err := req.GetRequestMetadata().chargeDatastoreRead(); err != nil {
+				return nil, err
+			}
+
 			iter, err := ds.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{
 				Object:                      reqTupleKey.GetObject(),
 				Relation:                    reqTupleKey.GetRelation(),
 				AllowedUserTypeRestrictions: directlyRelatedUsersetTypes,
+				PageSize:                    c.usersetsPageSize,
 			})
 			if err != nil {
 				return nil, err
@@ -731,15 +986,59 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 
 			var errs *multierror.Error
 			var handlers []CheckHandlerFunc
+			var tuplesRead, dispatched int
+			var dbReads uint32
+			var cycleDetected bool
+			var cycle []string
+			var unionErr error
+
+			// resolveHandlers resolves the usersets read since the last call, and returns the response
+			// of the first one that allows the user, if any
+			resolveHandlers := func() *ResolveCheckResponse {
+				if len(handlers) == 0 {
+					return nil
+				}
+
+				dispatched += len(handlers)
+				resp, err := union(ctx, c.concurrencyLimit, handlers...)
+				handlers = nil
+				if err != nil {
+					unionErr = err
+					return nil
+				}
+
+				dbReads += resp.GetResolutionMetadata().DatastoreQueryCount
+				cycleDetected = cycleDetected || resp.GetCycleDetected()
+				if cycle == nil {
+					cycle = resp.GetCycle()
+				}
+				if resp.GetAllowed() {
+					resp.GetResolutionMetadata().DatastoreQueryCount = dbReads
+					return resp
+				}
+				return nil
+			}
+
 			for {
+				// the usersets of a page are resolved before the next page is read, so that the
+				// usersets of an object with a huge number of them aren't all read at once
+				if c.usersetsPageSize > 0 && tuplesRead > 0 && tuplesRead%c.usersetsPageSize == 0 {
+					if resp := resolveHandlers(); resp != nil {
+						span.SetAttributes(attribute.Bool("allowed", true))
+						return resp, nil
+					}
+				}
+
 				t, err := filteredIter.Next(ctx)
 				if err != nil {
 					if errors.Is(err, storage.ErrIteratorDone) {
+						c.observe(storeID, cardinalityKey(objectType, relation, ""), tuplesRead)
 						break
 					}
 
 					return nil, err
 				}
+				tuplesRead++
 
 				condEvalResult, err := eval.EvaluateTupleCondition(ctx, t, typesys, req.GetContext())
 				if err != nil {
@@ -784,18 +1083,28 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 				}
 			}
 
-			if len(handlers) == 0 && errs.ErrorOrNil() != nil {
+			if dispatched+len(handlers) == 0 && errs.ErrorOrNil() != nil {
 				telemetry.TraceError(span, errs)
 				return nil, errs
 			}
 
-			resp, err := union(ctx, c.concurrencyLimit, handlers...)
-			if err != nil {
-				telemetry.TraceError(span, err)
-				return nil, multierror.Append(errs, err)
+			if resp := resolveHandlers(); resp != nil {
+				return resp, nil
+			}
+
+			if unionErr != nil {
+				telemetry.TraceError(span, unionErr)
+				return nil, multierror.Append(errs, unionErr)
 			}
 
-			return resp, nil
+			return &ResolveCheckResponse{
+				Allowed: false,
+				ResolutionMetadata: &ResolveCheckResponseMetadata{
+					DatastoreQueryCount: dbReads,
+					CycleDetected:       cycleDetected,
+					Cycle:               cycle,
+				},
+			}, nil
 		}
 
 		var checkFuncs []CheckHandlerFunc
@@ -809,8 +1118,49 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 			checkFuncs = []CheckHandlerFunc{fn1}
 		}
 
+		// the read of the usersets is counted below, unless they are resolved with another strategy
+		countUsersetsRead := len(directlyRelatedUsersetTypes) > 0
+
 		if len(directlyRelatedUsersetTypes) > 0 {
-			checkFuncs = append(checkFuncs, fn2)
+			usersetsFunc := fn2
+
+			lookup, err := c.planUsersetsLookup(ctx, typesys, objectType, relation, directlyRelatedUsersetTypes, reqTupleKey.GetUser())
+			if err != nil {
+				telemetry.TraceError(span, err)
+				return nil, err
+			}
+
+			if lookup != nil {
+				strategy := c.planner.Plan(storeID, lookup.objectKey, lookup.userKeys()...)
+				span.SetAttributes(attribute.String("strategy", strategy.String()))
+
+				if strategy != ForwardExpansionStrategy {
+					lookup.readObject = func(ctx context.Context) (storage.TupleIterator, error) {
+						return ds.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{
+							Object:                      reqTupleKey.GetObject(),
+							Relation:                    reqTupleKey.GetRelation(),
+							AllowedUserTypeRestrictions: directlyRelatedUsersetTypes,
+						})
+					}
+					plannedFunc := c.checkPlannedLookup(typesys, ds, req, relation, lookup, strategy)
+					usersetsFunc = func(ctx context.Context) (*ResolveCheckResponse, error) {
+						resp, err := plannedFunc(ctx)
+						if !errors.Is(err, errPlannedLookupInconclusive) {
+							return resp, err
+						}
+
+						resp, err = fn2(ctx)
+						if err != nil {
+							return nil, err
+						}
+						resp.GetResolutionMetadata().DatastoreQueryCount++
+						return resp, nil
+					}
+					countUsersetsRead = false
+				}
+			}
+
+			checkFuncs = append(checkFuncs, usersetsFunc)
 		}
 
 		resp, err := union(ctx, c.concurrencyLimit, checkFuncs...)
@@ -820,7 +1170,7 @@ func (c *LocalChecker) checkDirect(parentctx context.Context, req *ResolveCheckR
 		}
 
 		// count db reads after they happen in the case that we didn't find 'allowed=false' but we still incurred reads
-		if len(directlyRelatedUsersetTypes) > 0 {
+		if countUsersetsRead {
 			// if we had N userset checks, that was 1 read, not N
 			resp.GetResolutionMetadata().DatastoreQueryCount++
 		}
@@ -882,6 +1232,32 @@ func (c *LocalChecker) checkTTU(parentctx context.Context, req *ResolveCheckRequ
 		span.SetAttributes(attribute.String("tupleset_relation", fmt.Sprintf("%s#%s", tuple.GetType(object), tuplesetRelation)))
 		span.SetAttributes(attribute.String("computed_relation", computedRelation))
 
+		lookup, err := c.planTTULookup(ctx, typesys, tuple.GetType(object), tuplesetRelation, computedRelation, tk.GetUser())
+		if err != nil {
+			telemetry.TraceError(span, err)
+			return nil, err
+		}
+
+		if lookup != nil {
+			strategy := c.planner.Plan(req.GetStoreID(), lookup.objectKey, lookup.userKeys()...)
+			span.SetAttributes(attribute.String("strategy", strategy.String()))
+
+			if strategy != ForwardExpansionStrategy {
+				lookup.readObject = func(ctx context.Context) (storage.TupleIterator, error) {
+					return ds.Read(ctx, req.GetStoreID(), tuple.NewTupleKey(object, tuplesetRelation, ""))
+				}
+				resp, err := c.checkPlannedLookup(typesys, ds, req, tuplesetRelation, lookup, strategy)(ctx)
+				if !errors.Is(err, errPlannedLookupInconclusive) {
+					return resp, err
+				}
+				// fall back to the forward expansion, which reports the condition errors if needed
+			}
+		}
+
+		if err := req.GetRequestMetadata().chargeDatastoreRead(); err != nil {
+			return nil, err
+		}
+
 		iter, err := ds.Read(
 			ctx,
 			req.GetStoreID(),
@@ -901,15 +1277,18 @@ func (c *LocalChecker) checkTTU(parentctx context.Context, req *ResolveCheckRequ
 
 		var errs *multierror.Error
 		var handlers []CheckHandlerFunc
+		var tuplesRead int
 		for {
 			t, err := filteredIter.Next(ctx)
 			if err != nil {
 				if err == storage.ErrIteratorDone {
+					c.observe(req.GetStoreID(), cardinalityKey(tuple.GetType(object), tuplesetRelation, ""), tuplesRead)
 					break
 				}
 
 				return nil, err
 			}
+			tuplesRead++
 
 			condEvalResult, err := eval.EvaluateTupleCondition(ctx, t, typesys, req.GetContext())
 			if err != nil {
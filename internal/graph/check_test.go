@@ -11,6 +11,7 @@ import (
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	parser "github.com/openfga/language/pkg/go/transformer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -391,6 +392,34 @@ func TestExclusionCheckFuncReducer(t *testing.T) {
 		require.Nil(t, resp)
 	})
 
+	t.Run("should_cancel_the_siblings_of_an_allowed_handler", func(t *testing.T) {
+		wasted := testutil.ToFloat64(unionShortCircuitHandlerCounter.WithLabelValues("wasted"))
+		skipped := testutil.ToFloat64(unionShortCircuitHandlerCounter.WithLabelValues("skipped"))
+
+		cancelled := make(chan struct{})
+		blockingHandler := func(ctx context.Context) (*ResolveCheckResponse, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}
+
+		resp, err := union(ctx, 2, blockingHandler, trueHandler, falseHandler, falseHandler)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			require.FailNow(t, "the blocking handler should be cancelled once the union is allowed")
+		}
+
+		// the blocking handler was started, the false handlers may not have been
+		gotWasted := testutil.ToFloat64(unionShortCircuitHandlerCounter.WithLabelValues("wasted")) - wasted
+		gotSkipped := testutil.ToFloat64(unionShortCircuitHandlerCounter.WithLabelValues("skipped")) - skipped
+		require.GreaterOrEqual(t, gotWasted, float64(1))
+		require.InDelta(t, float64(3), gotWasted+gotSkipped, 0)
+	})
+
 	t.Run("return_error_if_context_cancelled_before_resolution", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		t.Cleanup(cancel)
@@ -1581,6 +1610,298 @@ type doc
 	})
 }
 
+func TestCheckUsersetsPageSize(t *testing.T) {
+	ds := memory.New()
+	ctx := storage.ContextWithRelationshipTupleReader(context.Background(), ds)
+
+	storeID := ulid.Make().String()
+
+	model := parser.MustTransformDSLToProto(`model
+  schema 1.1
+
+type user
+
+type group
+  relations
+    define member: [user]
+
+type document
+  relations
+    define viewer: [group#member]
+`)
+
+	tks := []*openfgav1.TupleKey{
+		tuple.NewTupleKey("group:0", "member", "user:jon"),
+		tuple.NewTupleKey("group:9", "member", "user:maria"),
+	}
+	for i := 0; i < 10; i++ {
+		tks = append(tks, tuple.NewTupleKey("document:1", "viewer", fmt.Sprintf("group:%d#member", i)))
+	}
+	err := ds.Write(ctx, storeID, nil, tks)
+	require.NoError(t, err)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+	tests := []struct {
+		name             string
+		pageSize         uint32
+		user             string
+		expectedAllowed  bool
+		expectedDispatch uint32
+	}{
+		{
+			name:             "allowed_by_the_first_page_stops_after_it",
+			pageSize:         3,
+			user:             "user:jon",
+			expectedAllowed:  true,
+			expectedDispatch: 3,
+		},
+		{
+			name:             "allowed_by_the_last_page",
+			pageSize:         3,
+			user:             "user:maria",
+			expectedAllowed:  true,
+			expectedDispatch: 10,
+		},
+		{
+			name:             "not_allowed_resolves_all_the_pages",
+			pageSize:         3,
+			user:             "user:bob",
+			expectedAllowed:  false,
+			expectedDispatch: 10,
+		},
+		{
+			name:             "without_pages_resolves_all_the_usersets",
+			pageSize:         0,
+			user:             "user:jon",
+			expectedAllowed:  true,
+			expectedDispatch: 10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// a breadth limit of 1 resolves the usersets of a page one at a time, in their order
+			checker := NewLocalChecker(WithUsersetsPageSize(test.pageSize), WithResolveNodeBreadthLimit(1))
+
+			checkRequestMetadata := NewCheckRequestMetadata(5)
+			resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+				StoreID:              storeID,
+				AuthorizationModelID: model.GetId(),
+				TupleKey:             tuple.NewTupleKey("document:1", "viewer", test.user),
+				RequestMetadata:      checkRequestMetadata,
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expectedAllowed, resp.GetAllowed())
+			require.Equal(t, test.expectedDispatch, checkRequestMetadata.DispatchCounter.Load())
+		})
+	}
+}
+
+func TestCheckClientID(t *testing.T) {
+	ds := memory.New()
+	ctx := storage.ContextWithRelationshipTupleReader(context.Background(), ds)
+
+	storeID := ulid.Make().String()
+
+	model := parser.MustTransformDSLToProto(`model
+  schema 1.1
+
+type user
+
+type group
+  relations
+    define member: [user]
+
+type document
+  relations
+    define viewer: [group#member]
+`)
+
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:1", "viewer", "group:fga#member"),
+	})
+	require.NoError(t, err)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+	clientID := ulid.Make().String()
+	checker := NewLocalChecker()
+
+	checkRequestMetadata := NewCheckRequestMetadata(5)
+	checkRequestMetadata.ClientID = clientID
+	resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+		StoreID:              storeID,
+		AuthorizationModelID: model.GetId(),
+		TupleKey:             tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+		RequestMetadata:      checkRequestMetadata,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.GetAllowed())
+
+	// the request and the sub-problems of both groups are attributed to the client
+	require.Equal(t, uint32(2), checkRequestMetadata.DispatchCounter.Load())
+	require.InDelta(t, 3, testutil.ToFloat64(clientDispatchCounter.WithLabelValues(clientID)), 0)
+}
+
+func TestCheckResolutionBudget(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	model := parser.MustTransformDSLToProto(`model
+  schema 1.1
+
+type user
+
+type folder
+  relations
+    define viewer: [user] or viewer from parent
+    define parent: [folder]
+
+type doc
+  relations
+    define viewer: [user] or viewer from parent
+    define parent: [folder]
+`)
+
+	ctx := storage.ContextWithRelationshipTupleReader(context.Background(), ds)
+
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("folder:C", "viewer", "user:jon"),
+		tuple.NewTupleKey("folder:B", "parent", "folder:C"),
+		tuple.NewTupleKey("folder:A", "parent", "folder:B"),
+		tuple.NewTupleKey("doc:readme", "parent", "folder:A"),
+	})
+	require.NoError(t, err)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+
+	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+	checker := NewLocalChecker()
+	t.Cleanup(checker.Close)
+
+	resolveCheck := func(budget ResolutionBudget) (*ResolveCheckResponse, error) {
+		checkRequestMetadata := NewCheckRequestMetadata(5)
+		checkRequestMetadata.Budget = budget
+
+		return checker.ResolveCheck(ctx, &ResolveCheckRequest{
+			StoreID:              storeID,
+			AuthorizationModelID: model.GetId(),
+			TupleKey:             tuple.NewTupleKey("doc:readme", "viewer", "user:jon"),
+			RequestMetadata:      checkRequestMetadata,
+		})
+	}
+
+	t.Run("within_budget", func(t *testing.T) {
+		resp, err := resolveCheck(ResolutionBudget{MaxDispatchCount: 3, MaxDatastoreReadCount: 100})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+	})
+
+	t.Run("dispatch_budget_exceeded", func(t *testing.T) {
+		_, err := resolveCheck(ResolutionBudget{MaxDispatchCount: 2})
+		require.ErrorIs(t, err, ErrResolutionBudgetExceeded)
+
+		var budgetErr *ResolutionBudgetExceededError
+		require.ErrorAs(t, err, &budgetErr)
+		require.Equal(t, dispatchBudgetResource, budgetErr.Resource)
+		require.Equal(t, uint32(2), budgetErr.Limit)
+		require.Equal(t, uint32(3), budgetErr.DispatchCount)
+		require.Positive(t, budgetErr.DatastoreReadCount)
+	})
+
+	t.Run("datastore_read_budget_exceeded", func(t *testing.T) {
+		_, err := resolveCheck(ResolutionBudget{MaxDatastoreReadCount: 2})
+		require.ErrorIs(t, err, ErrResolutionBudgetExceeded)
+
+		var budgetErr *ResolutionBudgetExceededError
+		require.ErrorAs(t, err, &budgetErr)
+		require.Equal(t, datastoreReadBudgetResource, budgetErr.Resource)
+		require.Equal(t, uint32(2), budgetErr.Limit)
+		require.Greater(t, budgetErr.DatastoreReadCount, uint32(2))
+	})
+}
+
+func TestCheckReportsResolutionCycle(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	model := parser.MustTransformDSLToProto(`model
+  schema 1.1
+
+type user
+
+type folder
+  relations
+    define viewer: [user] or viewer from parent
+    define parent: [folder]
+
+type doc
+  relations
+    define viewer: [user] or viewer from parent
+    define parent: [folder]
+`)
+
+	ctx := storage.ContextWithRelationshipTupleReader(context.Background(), ds)
+
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("folder:A", "parent", "folder:B"),
+		tuple.NewTupleKey("folder:B", "parent", "folder:A"),
+		tuple.NewTupleKey("doc:readme", "parent", "folder:A"),
+	})
+	require.NoError(t, err)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+
+	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
+
+	checker := NewLocalChecker()
+	t.Cleanup(checker.Close)
+
+	_, err = checker.ResolveCheck(ctx, &ResolveCheckRequest{
+		StoreID:              storeID,
+		AuthorizationModelID: model.GetId(),
+		TupleKey:             tuple.NewTupleKey("doc:readme", "viewer", "user:jon"),
+		RequestMetadata:      NewCheckRequestMetadata(10),
+	})
+	require.ErrorIs(t, err, ErrResolutionDepthExceeded)
+
+	var cycleErr *ResolutionCycleError
+	require.ErrorAs(t, err, &cycleErr)
+	require.Equal(t, []string{"folder#viewer", "folder#viewer", "folder#viewer"}, cycleErr.Cycle)
+}
+
+func TestResolutionPathCycle(t *testing.T) {
+	path := func(objectRelations ...string) *resolutionPathNode {
+		var node *resolutionPathNode
+		for _, objectRelation := range objectRelations {
+			object, relation := tuple.SplitObjectRelation(objectRelation)
+			node = &resolutionPathNode{object: object, relation: relation, parent: node}
+		}
+		return node
+	}
+
+	require.Nil(t, path("document:1#viewer").cycle())
+	require.Nil(t, path("document:1#viewer", "document:1#editor", "group:1#member", "group:2#member").cycle())
+
+	require.Equal(t,
+		[]string{"group#member", "team#member", "group#member"},
+		path("document:1#viewer", "group:1#member", "team:1#member", "group:1#member", "team:1#member").cycle(),
+	)
+}
+
 func TestUnionCheckFuncReducer(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
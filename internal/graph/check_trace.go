@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The kinds of the events of a CheckTrace.
+const (
+	// CheckTraceDispatch is the dispatch of a sub-problem.
+	CheckTraceDispatch = "dispatch"
+	// CheckTraceResolved is the result of a sub-problem resolved by the LocalChecker.
+	CheckTraceResolved = "resolved"
+	// CheckTraceCacheHit and CheckTraceCacheMiss are the lookups of a sub-problem in the Check cache.
+	CheckTraceCacheHit  = "cache_hit"
+	CheckTraceCacheMiss = "cache_miss"
+	// CheckTraceCacheSkipped is a sub-problem resolved without the Check cache, see
+	// ContextWithoutCheckCache.
+	CheckTraceCacheSkipped = "cache_skipped"
+	// CheckTraceDatastoreQuery is a query of the datastore.
+	CheckTraceDatastoreQuery = "datastore_query"
+)
+
+type checkTraceCtxKey struct{}
+
+// CheckTraceEvent is an event of the resolution of a Check request.
+type CheckTraceEvent struct {
+	// Kind is the kind of the event, e.g. CheckTraceDispatch.
+	Kind string `json:"kind"`
+
+	// Offset is the time of the event since the start of the trace.
+	Offset time.Duration `json:"offset_ns"`
+
+	// TupleKey is the sub-problem of the event, as 'object#relation@user', if any.
+	TupleKey string `json:"tuple_key,omitempty"`
+
+	// Depth is the remaining resolution depth of the sub-problem.
+	Depth uint32 `json:"depth,omitempty"`
+
+	// Allowed is the result of a resolved sub-problem.
+	Allowed *bool `json:"allowed,omitempty"`
+
+	// Query is the datastore query: its method and its filter.
+	Query string `json:"query,omitempty"`
+
+	// Duration is how long the resolution of the sub-problem or the query took.
+	Duration time.Duration `json:"duration_ns,omitempty"`
+
+	// Error is the error of the resolution of the sub-problem or of the query, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// CheckTrace records the events of the resolution of a Check request: the dispatches of its
+// sub-problems and their results, the decisions of the Check cache and the datastore queries, e.g.
+// to debug a surprising result. The resolution records them once the trace is set in its context
+// with ContextWithCheckTrace. It is safe for concurrent use.
+type CheckTrace struct {
+	start     time.Time
+	maxEvents int
+
+	mu        sync.Mutex
+	events    []CheckTraceEvent
+	truncated bool
+}
+
+// NewCheckTrace returns a trace that starts now and records up to maxEvents events. The later
+// events are dropped, and the trace marked as truncated.
+func NewCheckTrace(maxEvents int) *CheckTrace {
+	return &CheckTrace{start: time.Now(), maxEvents: maxEvents}
+}
+
+// Add records an event, at the current offset of the trace.
+func (t *CheckTrace) Add(event CheckTraceEvent) {
+	event.Offset = time.Since(t.start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.events) >= t.maxEvents {
+		t.truncated = true
+		return
+	}
+	t.events = append(t.events, event)
+}
+
+// Events returns the events recorded, in their order, and whether some were dropped.
+func (t *CheckTrace) Events() ([]CheckTraceEvent, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]CheckTraceEvent(nil), t.events...), t.truncated
+}
+
+// ContextWithCheckTrace returns a context whose Check resolution is recorded in trace.
+func ContextWithCheckTrace(parent context.Context, trace *CheckTrace) context.Context {
+	return context.WithValue(parent, checkTraceCtxKey{}, trace)
+}
+
+// CheckTraceFromContext returns the trace set with ContextWithCheckTrace, or nil.
+func CheckTraceFromContext(ctx context.Context) *CheckTrace {
+	trace, _ := ctx.Value(checkTraceCtxKey{}).(*CheckTrace)
+	return trace
+}
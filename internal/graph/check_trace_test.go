@@ -0,0 +1,28 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTrace(t *testing.T) {
+	require.Nil(t, CheckTraceFromContext(context.Background()))
+
+	checkTrace := NewCheckTrace(2)
+	ctx := ContextWithCheckTrace(context.Background(), checkTrace)
+	require.Same(t, checkTrace, CheckTraceFromContext(ctx))
+
+	checkTrace.Add(CheckTraceEvent{Kind: CheckTraceDispatch, TupleKey: "document:1#viewer@user:anne"})
+	events, truncated := checkTrace.Events()
+	require.Len(t, events, 1)
+	require.False(t, truncated)
+
+	checkTrace.Add(CheckTraceEvent{Kind: CheckTraceCacheMiss, TupleKey: "document:1#viewer@user:anne"})
+	checkTrace.Add(CheckTraceEvent{Kind: CheckTraceResolved, TupleKey: "document:1#viewer@user:anne"})
+	events, truncated = checkTrace.Events()
+	require.Equal(t, []string{CheckTraceDispatch, CheckTraceCacheMiss}, []string{events[0].Kind, events[1].Kind})
+	require.LessOrEqual(t, events[0].Offset, events[1].Offset)
+	require.True(t, truncated)
+}
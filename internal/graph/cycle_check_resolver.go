@@ -2,6 +2,7 @@ package graph
 
 import (
 	"context"
+	"strings"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -46,11 +47,17 @@ func (c *CycleDetectionCheckResolver) ResolveCheck(
 
 	_, cycleDetected := req.VisitedPaths[key]
 	if cycleDetected {
-		span.SetAttributes(attribute.Bool("cycle_detected", true))
+		// the path of the request reports the cycle, unless the request was dispatched by a peer
+		cycle := req.pathNode().cycle()
+		span.SetAttributes(
+			attribute.Bool("cycle_detected", true),
+			attribute.String("cycle", strings.Join(cycle, " -> ")),
+		)
 		return &ResolveCheckResponse{
 			Allowed: false,
 			ResolutionMetadata: &ResolveCheckResponseMetadata{
 				CycleDetected: true,
+				Cycle:         cycle,
 			},
 		}, nil
 	}
@@ -65,6 +72,7 @@ func (c *CycleDetectionCheckResolver) ResolveCheck(
 		RequestMetadata:      req.GetRequestMetadata(),
 		VisitedPaths:         req.VisitedPaths,
 		Context:              req.GetContext(),
+		path:                 req.path,
 	})
 }
 
@@ -47,6 +47,25 @@ func TestCycleDetectionCheckResolver(t *testing.T) {
 		require.NotNil(t, resp.ResolutionMetadata)
 	})
 
+	t.Run("reports_the_cycle_of_the_path", func(t *testing.T) {
+		cyclicalTuple := tuple.NewTupleKey("document:1", "viewer", "user:will")
+
+		root := &resolutionPathNode{object: "document:1", relation: "viewer"}
+		editor := &resolutionPathNode{object: "document:1", relation: "editor", parent: root}
+
+		resp, err := cycleDetectionCheckResolver.ResolveCheck(ctx, &ResolveCheckRequest{
+			StoreID:         ulid.Make().String(),
+			TupleKey:        cyclicalTuple,
+			RequestMetadata: NewCheckRequestMetadata(defaultResolveNodeLimit),
+			VisitedPaths:    map[string]struct{}{tuple.TupleKeyToString(cyclicalTuple): {}},
+			path:            &resolutionPathNode{object: "document:1", relation: "viewer", parent: editor},
+		})
+
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+		require.Equal(t, []string{"document#viewer", "document#editor", "document#viewer"}, resp.GetCycle())
+	})
+
 	t.Run("no_cycle_detected_delegates_request", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		t.Cleanup(ctrl.Finish)
@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/openfga/openfga/internal/build"
+)
+
+const (
+	dispatchPoolWorkerIdle = "idle"
+	dispatchPoolWorkerBusy = "busy"
+
+	dispatchPoolCtxKey ctxKey = "dispatch-pool"
+)
+
+var (
+	dispatchPoolWorkersGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "check_dispatch_pool_workers",
+		Help:      "The number of goroutines of the Check dispatch pools, by whether they are running a handler ('busy') or waiting for one ('idle').",
+	}, []string{"state"})
+
+	dispatchPoolSaturatedCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "check_dispatch_pool_saturated_count",
+		Help:      "The total number of Check handlers that ran on a new goroutine because all the goroutines of their dispatch pool were busy.",
+	})
+)
+
+// DispatchPool is a bounded pool of reusable goroutines that run the concurrent handlers of the Check
+// resolutions, e.g. the dispatches of the operands of a union, instead of a new goroutine for each of
+// them. The goroutines are started as they are needed, up to the size of the pool, and wait for the
+// next handler once they are done. A handler that is submitted while all of them are busy runs on a
+// new goroutine, since the busy handlers may be waiting for it, e.g. a dispatch waiting for its own
+// dispatches, and is counted as a saturation of the pool.
+type DispatchPool struct {
+	size    int64
+	workers atomic.Int64
+	tasks   chan func()
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewDispatchPool constructs a DispatchPool of up to size goroutines.
+func NewDispatchPool(size int) *DispatchPool {
+	return &DispatchPool{
+		size:  int64(size),
+		tasks: make(chan func()),
+		done:  make(chan struct{}),
+	}
+}
+
+// Go runs fn on an idle goroutine of the pool, on a new goroutine of the pool if it isn't full, or on
+// a new goroutine otherwise. A nil DispatchPool always runs fn on a new goroutine.
+func (p *DispatchPool) Go(fn func()) {
+	if p == nil {
+		go fn()
+		return
+	}
+
+	select {
+	case p.tasks <- fn:
+		return
+	default:
+	}
+
+	if !p.spawn(fn) {
+		dispatchPoolSaturatedCounter.Inc()
+		go fn()
+	}
+}
+
+// spawn starts a goroutine of the pool that runs fn, unless the pool is full or closed.
+func (p *DispatchPool) spawn(fn func()) bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+	}
+
+	for {
+		workers := p.workers.Load()
+		if workers >= p.size {
+			return false
+		}
+
+		if p.workers.CompareAndSwap(workers, workers+1) {
+			break
+		}
+	}
+
+	p.wg.Add(1)
+	dispatchPoolWorkersGauge.WithLabelValues(dispatchPoolWorkerBusy).Inc()
+	go p.work(fn)
+
+	return true
+}
+
+// work runs fn, and then the handlers submitted to the pool, until it is closed.
+func (p *DispatchPool) work(fn func()) {
+	busy := dispatchPoolWorkersGauge.WithLabelValues(dispatchPoolWorkerBusy)
+	idle := dispatchPoolWorkersGauge.WithLabelValues(dispatchPoolWorkerIdle)
+
+	defer func() {
+		idle.Dec()
+		p.wg.Done()
+	}()
+
+	for {
+		fn()
+
+		busy.Dec()
+		idle.Inc()
+
+		select {
+		case fn = <-p.tasks:
+			idle.Dec()
+			busy.Inc()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// Close stops the goroutines of the pool once they are done with their handlers. The handlers that
+// are submitted afterwards run on new goroutines.
+func (p *DispatchPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+	p.wg.Wait()
+}
+
+// contextWithDispatchPool returns a context whose concurrent Check handlers run on the pool.
+func contextWithDispatchPool(parent context.Context, pool *DispatchPool) context.Context {
+	if dispatchPoolFromContext(parent) == pool {
+		return parent
+	}
+	return context.WithValue(parent, dispatchPoolCtxKey, pool)
+}
+
+// dispatchPoolFromContext returns the pool of the concurrent Check handlers of the context, or nil.
+func dispatchPoolFromContext(ctx context.Context) *DispatchPool {
+	pool, _ := ctx.Value(dispatchPoolCtxKey).(*DispatchPool)
+	return pool
+}
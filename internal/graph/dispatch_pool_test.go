@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestDispatchPool(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("reuses_its_goroutines", func(t *testing.T) {
+		pool := NewDispatchPool(2)
+		t.Cleanup(pool.Close)
+
+		for i := 0; i < 100; i++ {
+			done := make(chan struct{})
+			pool.Go(func() { close(done) })
+			<-done
+		}
+
+		require.LessOrEqual(t, pool.workers.Load(), int64(2))
+	})
+
+	t.Run("runs_the_handlers_of_a_saturated_pool_on_new_goroutines", func(t *testing.T) {
+		pool := NewDispatchPool(1)
+		t.Cleanup(pool.Close)
+
+		saturated := testutil.ToFloat64(dispatchPoolSaturatedCounter)
+
+		// the handler of the only goroutine of the pool waits for a handler that it submits
+		done := make(chan struct{})
+		pool.Go(func() {
+			nested := make(chan struct{})
+			pool.Go(func() { close(nested) })
+			<-nested
+			close(done)
+		})
+		<-done
+
+		require.InDelta(t, saturated+1, testutil.ToFloat64(dispatchPoolSaturatedCounter), 0)
+	})
+
+	t.Run("runs_the_handlers_after_it_is_closed", func(t *testing.T) {
+		pool := NewDispatchPool(2)
+		pool.Close()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		pool.Go(wg.Done)
+		wg.Wait()
+
+		require.Zero(t, pool.workers.Load())
+	})
+
+	t.Run("nil_pool", func(t *testing.T) {
+		var pool *DispatchPool
+
+		done := make(chan struct{})
+		pool.Go(func() { close(done) })
+		<-done
+	})
+}
+
+func TestCheckWithDispatchPool(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	err := ds.Write(context.Background(), storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "group:1#member"),
+		tuple.NewTupleKey("document:1", "viewer", "group:2#member"),
+		tuple.NewTupleKey("group:1", "member", "group:1a#member"),
+		tuple.NewTupleKey("group:1", "member", "group:1b#member"),
+		tuple.NewTupleKey("group:2", "member", "group:2a#member"),
+		tuple.NewTupleKey("group:2", "member", "group:2b#member"),
+		tuple.NewTupleKey("group:2b", "member", "user:jon"),
+		tuple.NewTupleKey("document:1", "blocked", "user:bob"),
+	})
+	require.NoError(t, err)
+
+	// a pool of a single goroutine, whose dispatches wait for their own dispatches
+	checker := NewLocalChecker(WithDispatchPoolSize(1))
+	t.Cleanup(checker.Close)
+
+	model := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
+type user
+type group
+  relations
+	define member: [user, group#member]
+type document
+  relations
+	define blocked: [user]
+	define viewer: [group#member] but not blocked`)
+
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesystem.New(model))
+	ctx = storage.ContextWithRelationshipTupleReader(ctx, ds)
+
+	for user, allowed := range map[string]bool{"user:jon": true, "user:bob": false} {
+		resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+			StoreID:         storeID,
+			TupleKey:        tuple.NewTupleKey("document:1", "viewer", user),
+			RequestMetadata: NewCheckRequestMetadata(25),
+		})
+		require.NoError(t, err)
+		require.Equal(t, allowed, resp.GetAllowed(), user)
+	}
+
+	require.EqualValues(t, 1, checker.dispatchPool.workers.Load())
+}
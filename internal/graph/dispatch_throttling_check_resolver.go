@@ -2,11 +2,15 @@ package graph
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/openfga/openfga/internal/build"
 	"github.com/openfga/openfga/pkg/telemetry"
@@ -27,10 +31,13 @@ type DispatchThrottlingCheckResolverConfig struct {
 // This allows a check / list objects request to be gradually throttled.
 type DispatchThrottlingCheckResolver struct {
 	delegate        CheckResolver
+	mu              sync.RWMutex
 	config          DispatchThrottlingCheckResolverConfig
 	ticker          *time.Ticker
 	throttlingQueue chan struct{}
 	done            chan struct{}
+	draining        chan struct{}
+	drainOnce       sync.Once
 }
 
 var _ CheckResolver = (*DispatchThrottlingCheckResolver)(nil)
@@ -44,9 +51,26 @@ var (
 		NativeHistogramBucketFactor:     1.1,
 		NativeHistogramMaxBucketNumber:  100,
 		NativeHistogramMinResetDuration: time.Hour,
-	}, []string{"grpc_service", "grpc_method"})
+	}, []string{"grpc_service", "grpc_method", "client_id"})
 )
 
+// ThrottledTimeoutError is the error of a request whose deadline was exceeded after it was throttled.
+// It wraps ErrThrottledTimeout and the deadline exceeded error.
+type ThrottledTimeoutError struct {
+	// ThrottlingDuration is how long the request waited in the throttling queue.
+	ThrottlingDuration time.Duration
+
+	err error
+}
+
+func (e *ThrottledTimeoutError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrThrottledTimeout, e.err)
+}
+
+func (e *ThrottledTimeoutError) Unwrap() []error {
+	return []error{ErrThrottledTimeout, e.err}
+}
+
 func NewDispatchThrottlingCheckResolver(
 	config DispatchThrottlingCheckResolverConfig) *DispatchThrottlingCheckResolver {
 	dispatchThrottlingCheckResolver := &DispatchThrottlingCheckResolver{
@@ -54,6 +78,7 @@ func NewDispatchThrottlingCheckResolver(
 		ticker:          time.NewTicker(config.Frequency),
 		throttlingQueue: make(chan struct{}),
 		done:            make(chan struct{}),
+		draining:        make(chan struct{}),
 	}
 	dispatchThrottlingCheckResolver.delegate = dispatchThrottlingCheckResolver
 	go dispatchThrottlingCheckResolver.runTicker()
@@ -68,6 +93,26 @@ func (r *DispatchThrottlingCheckResolver) GetDelegate() CheckResolver {
 	return r.delegate
 }
 
+// UpdateConfig changes the frequency and the thresholds used for throttling at runtime.
+// Dispatches that are already waiting in the throttling queue are not affected.
+func (r *DispatchThrottlingCheckResolver) UpdateConfig(config DispatchThrottlingCheckResolverConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if config.Frequency != r.config.Frequency {
+		r.ticker.Reset(config.Frequency)
+	}
+	r.config = config
+}
+
+// Drain stops throttling. Dispatches that are waiting in the throttling queue, and any that arrive
+// later, are resolved immediately so that in-flight requests can finish before the server shuts down.
+func (r *DispatchThrottlingCheckResolver) Drain() {
+	r.drainOnce.Do(func() {
+		close(r.draining)
+	})
+}
+
 func (r *DispatchThrottlingCheckResolver) Close() {
 	r.done <- struct{}{}
 }
@@ -105,12 +150,14 @@ func (r *DispatchThrottlingCheckResolver) ResolveCheck(ctx context.Context,
 	currentNumDispatch := req.GetRequestMetadata().DispatchCounter.Load()
 	span.SetAttributes(attribute.Int("dispatch_count", int(currentNumDispatch)))
 
+	r.mu.RLock()
 	threshold := r.config.DefaultThreshold
 
 	maxThreshold := r.config.MaxThreshold
 	if maxThreshold == 0 {
 		maxThreshold = r.config.DefaultThreshold
 	}
+	r.mu.RUnlock()
 
 	thresholdInCtx := telemetry.DispatchThrottlingThresholdFromContext(ctx)
 
@@ -118,20 +165,52 @@ func (r *DispatchThrottlingCheckResolver) ResolveCheck(ctx context.Context,
 		threshold = min(thresholdInCtx, maxThreshold)
 	}
 
+	var waited time.Duration
 	if currentNumDispatch > threshold {
 		req.GetRequestMetadata().WasThrottled.Store(true)
 
 		start := time.Now()
-		<-r.throttlingQueue
-		end := time.Now()
-		timeWaiting := end.Sub(start).Milliseconds()
+		select {
+		case <-r.throttlingQueue:
+		case <-r.draining:
+		case <-ctx.Done():
+			// the dispatch was cancelled while waiting, e.g. by a sibling of a union that resolved to
+			// allowed, so it leaves the queue without taking a tick from the dispatches still waiting
+		}
+		waited = time.Since(start)
+		if throttlingDuration := req.GetRequestMetadata().ThrottlingDuration; throttlingDuration != nil {
+			throttlingDuration.Add(int64(waited))
+		}
+		timeWaiting := waited.Milliseconds()
+
+		clientID := req.GetRequestMetadata().ClientID
+		span.SetAttributes(attribute.Bool("throttled", true))
+		span.AddEvent("throttled", trace.WithTimestamp(start), trace.WithAttributes(
+			attribute.Int64("throttle_wait_ms", timeWaiting),
+			attribute.Int("threshold", int(threshold)),
+			attribute.String("client_id", clientID),
+		))
 
 		rpcInfo := telemetry.RPCInfoFromContext(ctx)
 		dispatchThrottlingResolverDelayMsHistogram.WithLabelValues(
 			rpcInfo.Service,
 			rpcInfo.Method,
+			clientID,
 		).Observe(float64(timeWaiting))
 	}
 
-	return r.delegate.ResolveCheck(ctx, req)
+	var resp *ResolveCheckResponse
+	err := ctx.Err()
+	if err == nil {
+		resp, err = r.delegate.ResolveCheck(ctx, req)
+	}
+	if err != nil && errors.Is(err, context.DeadlineExceeded) && req.GetRequestMetadata().WasThrottled.Load() &&
+		!errors.Is(err, ErrThrottledTimeout) {
+		if throttlingDuration := req.GetRequestMetadata().ThrottlingDuration; throttlingDuration != nil {
+			waited = time.Duration(throttlingDuration.Load())
+		}
+		return nil, &ThrottledTimeoutError{ThrottlingDuration: waited, err: err}
+	}
+
+	return resp, err
 }
@@ -229,4 +229,123 @@ func TestDispatchThrottlingCheckResolver(t *testing.T) {
 		require.True(t, req.GetRequestMetadata().WasThrottled.Load())
 		require.NoError(t, resolveCheckErr)
 	})
+
+	t.Run("drain_releases_throttled_dispatches", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		dispatchThrottlingCheckResolverConfig := DispatchThrottlingCheckResolverConfig{
+			// We set timer ticker to 1 hour so that only draining can release the dispatch
+			Frequency:        1 * time.Hour,
+			DefaultThreshold: 200,
+			MaxThreshold:     200,
+		}
+		dut := NewDispatchThrottlingCheckResolver(dispatchThrottlingCheckResolverConfig)
+		defer dut.Close()
+
+		initialMockResolver := NewMockCheckResolver(ctrl)
+		dut.SetDelegate(initialMockResolver)
+
+		req := &ResolveCheckRequest{RequestMetadata: NewCheckRequestMetadata(10)}
+		req.GetRequestMetadata().DispatchCounter.Store(201)
+		response := &ResolveCheckResponse{Allowed: true}
+
+		initialMockResolver.EXPECT().ResolveCheck(gomock.Any(), req).Return(response, nil).Times(2)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := dut.ResolveCheck(context.Background(), req)
+			done <- err
+		}()
+
+		select {
+		case <-done:
+			require.FailNow(t, "dispatch should be throttled until the resolver is drained")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		dut.Drain()
+		dut.Drain() // draining more than once is safe
+
+		require.NoError(t, <-done)
+		require.True(t, req.GetRequestMetadata().WasThrottled.Load())
+		require.GreaterOrEqual(t, time.Duration(req.GetRequestMetadata().ThrottlingDuration.Load()), 50*time.Millisecond)
+
+		// dispatches that arrive after draining are not throttled either
+		_, err := dut.ResolveCheck(context.Background(), req)
+		require.NoError(t, err)
+	})
+
+	t.Run("cancelled_dispatch_leaves_the_throttling_queue", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		dut := NewDispatchThrottlingCheckResolver(DispatchThrottlingCheckResolverConfig{
+			// We set timer ticker to 1 hour so that only the cancellation can release the dispatch
+			Frequency:        1 * time.Hour,
+			DefaultThreshold: 200,
+			MaxThreshold:     200,
+		})
+		defer dut.Close()
+
+		// the cancelled dispatch is never resolved
+		initialMockResolver := NewMockCheckResolver(ctrl)
+		dut.SetDelegate(initialMockResolver)
+
+		req := &ResolveCheckRequest{RequestMetadata: NewCheckRequestMetadata(10)}
+		req.GetRequestMetadata().DispatchCounter.Store(201)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			_, err := dut.ResolveCheck(ctx, req)
+			done <- err
+		}()
+
+		select {
+		case <-done:
+			require.FailNow(t, "dispatch should be throttled until it is cancelled")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		cancel()
+
+		select {
+		case err := <-done:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			require.FailNow(t, "the cancelled dispatch should leave the throttling queue")
+		}
+		require.True(t, req.GetRequestMetadata().WasThrottled.Load())
+	})
+
+	t.Run("deadline_exceeded_after_throttling_wraps_throttled_timeout", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		dut := NewDispatchThrottlingCheckResolver(DispatchThrottlingCheckResolverConfig{
+			Frequency:        1 * time.Hour,
+			DefaultThreshold: 200,
+			MaxThreshold:     200,
+		})
+		defer dut.Close()
+		dut.Drain()
+
+		initialMockResolver := NewMockCheckResolver(ctrl)
+		dut.SetDelegate(initialMockResolver)
+		initialMockResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).Return(nil, context.DeadlineExceeded).Times(2)
+
+		req := &ResolveCheckRequest{RequestMetadata: NewCheckRequestMetadata(10)}
+		_, err := dut.ResolveCheck(context.Background(), req)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.NotErrorIs(t, err, ErrThrottledTimeout)
+
+		req.GetRequestMetadata().DispatchCounter.Store(201)
+		_, err = dut.ResolveCheck(context.Background(), req)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorIs(t, err, ErrThrottledTimeout)
+
+		var throttledErr *ThrottledTimeoutError
+		require.ErrorAs(t, err, &throttledErr)
+	})
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 
@@ -17,13 +18,86 @@ import (
 type ctxKey string
 
 const (
-	resolutionDepthCtxKey ctxKey = "resolution-depth"
+	resolutionDepthCtxKey      ctxKey = "resolution-depth"
+	checkCacheBypassCtxKey     ctxKey = "check-cache-bypass"
+	checkCacheDisabledCtxKey   ctxKey = "check-cache-disabled"
+	checkCacheTTLCtxKey        ctxKey = "check-cache-ttl"
+	checkCacheMinFreshCtxKey   ctxKey = "check-cache-min-fresh"
+	checkPlannerDisabledCtxKey ctxKey = "check-planner-disabled"
 )
 
 var (
-	ErrResolutionDepthExceeded = errors.New("resolution depth exceeded")
+	ErrResolutionDepthExceeded  = errors.New("resolution depth exceeded")
+	ErrResolutionBudgetExceeded = errors.New("resolution budget exceeded")
+
+	// ErrThrottledTimeout wraps the deadline exceeded error of a request that was throttled.
+	ErrThrottledTimeout = errors.New("timeout due to throttling on complex request")
+
+	// ErrDelegatedCheckFailed is returned when the external evaluator of a delegated relation failed
+	// or timed out.
+	ErrDelegatedCheckFailed = errors.New("delegated check failed")
+)
+
+const (
+	dispatchBudgetResource      = "dispatches"
+	datastoreReadBudgetResource = "datastore reads"
 )
 
+// ResolutionCycleError is returned by the LocalChecker when a Check request exceeds the resolution
+// depth because its evaluation followed a cycle, for example because of relationship tuples like
+// `folder:a#parent@folder:b` and `folder:b#parent@folder:a`. It wraps ErrResolutionDepthExceeded.
+// The CycleDetectionCheckResolver detects the cycles before, if it resolves the sub-problems: it
+// resolves them to not allowed, as the evaluation of the cycle can't allow the user, and reports
+// them in ResolveCheckResponseMetadata.Cycle.
+type ResolutionCycleError struct {
+	// Cycle is the sequence of `type#relation` nodes in the cycle. It starts and ends with the same node.
+	Cycle []string
+}
+
+func (e *ResolutionCycleError) Error() string {
+	return fmt.Sprintf("%s: the evaluation followed the cycle %s", ErrResolutionDepthExceeded, strings.Join(e.Cycle, " -> "))
+}
+
+func (e *ResolutionCycleError) Unwrap() error {
+	return ErrResolutionDepthExceeded
+}
+
+// ResolutionBudget bounds the work done to resolve a single Check request. Unlike the resolution
+// depth, which only limits how deeply nested a path can be, it also bounds how wide the evaluation
+// can spread. A zero value for a limit means that it is not enforced.
+type ResolutionBudget struct {
+	// MaxDispatchCount is the maximum number of dispatches (subproblems) allowed.
+	MaxDispatchCount uint32
+
+	// MaxDatastoreReadCount is the maximum number of datastore reads allowed.
+	MaxDatastoreReadCount uint32
+}
+
+// ResolutionBudgetExceededError is returned when resolving a Check request exceeds one of the
+// limits of its ResolutionBudget. It reports the work that had been done when the limit was hit.
+type ResolutionBudgetExceededError struct {
+	// Resource is the resource whose limit was exceeded, either "dispatches" or "datastore reads".
+	Resource string
+
+	// Limit is the limit that was exceeded.
+	Limit uint32
+
+	// DispatchCount is the number of dispatches done when the limit was exceeded.
+	DispatchCount uint32
+
+	// DatastoreReadCount is the number of datastore reads done when the limit was exceeded.
+	DatastoreReadCount uint32
+}
+
+func (e *ResolutionBudgetExceededError) Error() string {
+	return fmt.Sprintf("%s: the request exceeded the limit of %d %s (dispatches: %d, datastore reads: %d)",
+		ErrResolutionBudgetExceeded, e.Limit, e.Resource, e.DispatchCount, e.DatastoreReadCount)
+}
+
+func (e *ResolutionBudgetExceededError) Unwrap() error {
+	return ErrResolutionBudgetExceeded
+}
+
 type findEdgeOption int
 
 const (
@@ -42,6 +116,73 @@ func ResolutionDepthFromContext(ctx context.Context) (uint32, bool) {
 	return depth, ok
 }
 
+// ContextWithCheckCacheBypass returns a context whose Check resolutions don't use the cached results
+// of the check query cache, e.g. because they must reflect a write that happened after they were
+// cached. Their results are still cached.
+func ContextWithCheckCacheBypass(parent context.Context) context.Context {
+	return context.WithValue(parent, checkCacheBypassCtxKey, true)
+}
+
+// CheckCacheBypassFromContext reports whether the Check resolutions of the context bypass the check
+// query cache.
+func CheckCacheBypassFromContext(ctx context.Context) bool {
+	bypass, _ := ctx.Value(checkCacheBypassCtxKey).(bool)
+	return bypass
+}
+
+// ContextWithoutCheckCache returns a context whose Check resolutions neither use nor populate the
+// check query cache, e.g. because they are evaluated against the tuples of a past time.
+func ContextWithoutCheckCache(parent context.Context) context.Context {
+	return context.WithValue(parent, checkCacheDisabledCtxKey, true)
+}
+
+// CheckCacheDisabledFromContext reports whether the Check resolutions of the context neither use nor
+// populate the check query cache.
+func CheckCacheDisabledFromContext(ctx context.Context) bool {
+	disabled, _ := ctx.Value(checkCacheDisabledCtxKey).(bool)
+	return disabled
+}
+
+// ContextWithoutCheckPlanner returns a context whose Check resolutions don't use the planner, and
+// always expand from the object, e.g. because the planner isn't enabled for the store of the request.
+func ContextWithoutCheckPlanner(parent context.Context) context.Context {
+	return context.WithValue(parent, checkPlannerDisabledCtxKey, true)
+}
+
+// CheckPlannerDisabledFromContext reports whether the Check resolutions of the context don't use the
+// planner.
+func CheckPlannerDisabledFromContext(ctx context.Context) bool {
+	disabled, _ := ctx.Value(checkPlannerDisabledCtxKey).(bool)
+	return disabled
+}
+
+// ContextWithCheckCacheTTL returns a context whose Check resolutions are cached with the TTL instead
+// of the TTL of the check query cache, e.g. because the store of the request overrides it.
+func ContextWithCheckCacheTTL(parent context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(parent, checkCacheTTLCtxKey, ttl)
+}
+
+// CheckCacheTTLFromContext returns the TTL that the Check resolutions of the context are cached
+// with, if it is overridden.
+func CheckCacheTTLFromContext(ctx context.Context) (time.Duration, bool) {
+	ttl, ok := ctx.Value(checkCacheTTLCtxKey).(time.Duration)
+	return ttl, ok
+}
+
+// ContextWithCheckCacheMinFresh returns a context whose Check resolutions only use the cached results
+// that are still fresh for at least the duration, e.g. because the request asks for it. The other
+// cached results are resolved again, and the new results are cached.
+func ContextWithCheckCacheMinFresh(parent context.Context, minFresh time.Duration) context.Context {
+	return context.WithValue(parent, checkCacheMinFreshCtxKey, minFresh)
+}
+
+// CheckCacheMinFreshFromContext returns the duration that the cached results used by the Check
+// resolutions of the context must still be fresh for, or 0 if any unexpired result is used.
+func CheckCacheMinFreshFromContext(ctx context.Context) time.Duration {
+	minFresh, _ := ctx.Value(checkCacheMinFreshCtxKey).(time.Duration)
+	return minFresh
+}
+
 type ResolveCheckRequestMetadata struct {
 	// Thinking of a Check as a tree of evaluations,
 	// Depth is the current level in the tree in the current path that we are exploring.
@@ -59,14 +200,83 @@ type ResolveCheckRequestMetadata struct {
 
 	// WasThrottled indicates whether the request was throttled
 	WasThrottled *atomic.Bool
+
+	// CacheHitCounter is the address to a shared counter that keeps track of how many subproblems
+	// of the root/parent problem were resolved from the Check query cache.
+	CacheHitCounter *atomic.Uint32
+
+	// ServedStale indicates whether the result of a subproblem of the root/parent problem was an
+	// expired value of the Check query cache, served because the datastore was unavailable.
+	ServedStale *atomic.Bool
+
+	// ThrottlingDuration is the address to a shared counter that keeps track of the total time (in
+	// nanoseconds) that dispatches of the root/parent problem spent waiting in the dispatch throttling queue.
+	// Dispatches can wait concurrently, so this may exceed the duration of the request.
+	ThrottlingDuration *atomic.Int64
+
+	// DatastoreReadCounter is the address to a shared counter that keeps track of how many datastore reads
+	// were done to solve the root/parent problem. Unlike DatastoreQueryCount, it counts the reads of every
+	// path evaluated so far, so it can be checked against the Budget while the problem is being solved.
+	DatastoreReadCounter *atomic.Uint32
+
+	// Budget bounds the number of dispatches and datastore reads allowed to solve the root/parent problem.
+	Budget ResolutionBudget
+
+	// ClientID identifies the authenticated caller of the root/parent problem (see authn.AuthClaims), so
+	// that the dispatches can be attributed to it in metrics, traces and logs. It is empty if the caller
+	// is unknown.
+	ClientID string
+}
+
+// chargeDispatch counts a dispatch against the budget of the request and returns a
+// *ResolutionBudgetExceededError if the dispatch budget is exceeded.
+func (m *ResolveCheckRequestMetadata) chargeDispatch() error {
+	dispatchCount := m.DispatchCounter.Add(1)
+	if m.Budget.MaxDispatchCount > 0 && dispatchCount > m.Budget.MaxDispatchCount {
+		return m.budgetExceeded(dispatchBudgetResource, m.Budget.MaxDispatchCount)
+	}
+
+	return nil
+}
+
+// chargeDatastoreRead counts a datastore read against the budget of the request and returns a
+// *ResolutionBudgetExceededError if the datastore read budget is exceeded.
+func (m *ResolveCheckRequestMetadata) chargeDatastoreRead() error {
+	if m.DatastoreReadCounter == nil {
+		return nil
+	}
+
+	readCount := m.DatastoreReadCounter.Add(1)
+	if m.Budget.MaxDatastoreReadCount > 0 && readCount > m.Budget.MaxDatastoreReadCount {
+		return m.budgetExceeded(datastoreReadBudgetResource, m.Budget.MaxDatastoreReadCount)
+	}
+
+	return nil
+}
+
+func (m *ResolveCheckRequestMetadata) budgetExceeded(resource string, limit uint32) error {
+	err := &ResolutionBudgetExceededError{
+		Resource:      resource,
+		Limit:         limit,
+		DispatchCount: m.DispatchCounter.Load(),
+	}
+	if m.DatastoreReadCounter != nil {
+		err.DatastoreReadCount = m.DatastoreReadCounter.Load()
+	}
+
+	return err
 }
 
 func NewCheckRequestMetadata(maxDepth uint32) *ResolveCheckRequestMetadata {
 	return &ResolveCheckRequestMetadata{
-		Depth:               maxDepth,
-		DatastoreQueryCount: 0,
-		DispatchCounter:     new(atomic.Uint32),
-		WasThrottled:        new(atomic.Bool),
+		Depth:                maxDepth,
+		DatastoreQueryCount:  0,
+		DispatchCounter:      new(atomic.Uint32),
+		WasThrottled:         new(atomic.Bool),
+		CacheHitCounter:      new(atomic.Uint32),
+		ServedStale:          new(atomic.Bool),
+		ThrottlingDuration:   new(atomic.Int64),
+		DatastoreReadCounter: new(atomic.Uint32),
 	}
 }
 
@@ -81,6 +291,10 @@ type ResolveCheckResponseMetadata struct {
 	// Indicates if the ResolveCheck subproblem that was evaluated involved
 	// a cycle in the evaluation.
 	CycleDetected bool
+
+	// Cycle is the first cycle detected by the CycleDetectionCheckResolver, as a sequence of
+	// `type#relation` nodes that starts and ends with the same node, if it is known.
+	Cycle []string
 }
 
 type RelationshipEdgeType int
@@ -432,27 +646,14 @@ func NewLayeredCheckResolver(
 	cacheEnabled bool,
 	cachedResolverOpts []CachedCheckResolverOpt,
 ) (CheckResolver, CheckResolverCloser) {
-	cycleDetectionCheckResolver := NewCycleDetectionCheckResolver()
-	localCheckResolver := NewLocalChecker(localResolverOpts...)
-
-	cycleDetectionCheckResolver.SetDelegate(localCheckResolver)
-
-	var cachedCheckResolver *CachedCheckResolver
+	resolvers := []DelegatingCheckResolver{NewCycleDetectionCheckResolver()}
 	if cacheEnabled {
-		cachedCheckResolver = NewCachedCheckResolver(cachedResolverOpts...)
-		cycleDetectionCheckResolver.SetDelegate(cachedCheckResolver)
-		cachedCheckResolver.SetDelegate(localCheckResolver)
+		resolvers = append(resolvers, NewCachedCheckResolver(cachedResolverOpts...))
 	}
+	resolvers = append(resolvers, NewLocalChecker(localResolverOpts...))
 
-	localCheckResolver.SetDelegate(cycleDetectionCheckResolver)
+	// NewResolverChain only fails without resolvers.
+	checkResolver, closer, _ := NewResolverChain(resolvers...)
 
-	return cycleDetectionCheckResolver, func() {
-		localCheckResolver.Close()
-
-		if cachedCheckResolver != nil {
-			cachedCheckResolver.Close()
-		}
-
-		cycleDetectionCheckResolver.Close()
-	}
+	return checkResolver, closer
 }
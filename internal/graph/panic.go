@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/internal/build"
+)
+
+// ErrPanic is wrapped by the errors of the resolver goroutines that panicked.
+var ErrPanic = errors.New("resolver goroutine panicked")
+
+var resolverPanicCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "resolver_panic_count",
+	Help:      "The total number of panics recovered in the goroutines that resolve the Check, ListObjects and Expand requests, by resolver.",
+}, []string{"resolver"})
+
+// PanicError is the error of a resolver goroutine that panicked. It fails the request the goroutine
+// resolved, and the request is logged with its stack. It wraps ErrPanic.
+type PanicError struct {
+	// Resolver is the name of the resolver the goroutine ran, e.g. 'check'.
+	Resolver string
+
+	// Value is the value the goroutine panicked with.
+	Value any
+
+	// Stack is the stack of the goroutine when it panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s resolver goroutine panicked: %v", e.Resolver, e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	return ErrPanic
+}
+
+// RecoverPanic recovers from a panic of a resolver goroutine and sets err to a PanicError, so that
+// the panic only fails the request of the goroutine instead of crashing the server. It must be
+// deferred directly by the function that may panic, with a pointer to its named error result.
+func RecoverPanic(ctx context.Context, resolver string, err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	panicErr := &PanicError{Resolver: resolver, Value: value, Stack: debug.Stack()}
+	resolverPanicCounter.WithLabelValues(resolver).Inc()
+	trace.SpanFromContext(ctx).RecordError(panicErr)
+
+	*err = panicErr
+}
@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	ctx := context.Background()
+
+	falseHandler := func(context.Context) (*ResolveCheckResponse, error) {
+		return &ResolveCheckResponse{Allowed: false, ResolutionMetadata: &ResolveCheckResponseMetadata{}}, nil
+	}
+	trueHandler := func(context.Context) (*ResolveCheckResponse, error) {
+		return &ResolveCheckResponse{Allowed: true, ResolutionMetadata: &ResolveCheckResponseMetadata{}}, nil
+	}
+	panicHandler := func(context.Context) (*ResolveCheckResponse, error) {
+		panic("boom")
+	}
+
+	for name, reduce := range map[string]func() (*ResolveCheckResponse, error){
+		"union": func() (*ResolveCheckResponse, error) {
+			return union(ctx, 2, falseHandler, panicHandler)
+		},
+		"intersection": func() (*ResolveCheckResponse, error) {
+			return intersection(ctx, 2, trueHandler, panicHandler)
+		},
+		"exclusion_base": func() (*ResolveCheckResponse, error) {
+			return exclusion(ctx, 2, panicHandler, falseHandler)
+		},
+		"exclusion_subtract": func() (*ResolveCheckResponse, error) {
+			return exclusion(ctx, 2, trueHandler, panicHandler)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			before := testutil.ToFloat64(resolverPanicCounter.WithLabelValues("check"))
+
+			_, err := reduce()
+			require.ErrorIs(t, err, ErrPanic)
+
+			var panicErr *PanicError
+			require.ErrorAs(t, err, &panicErr)
+			require.Equal(t, "check", panicErr.Resolver)
+			require.Equal(t, "boom", panicErr.Value)
+			require.Contains(t, string(panicErr.Stack), "panic_test.go")
+
+			require.Equal(t, before+1, testutil.ToFloat64(resolverPanicCounter.WithLabelValues("check")))
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// peerVirtualNodes is the number of points of each member on the hash ring, so that the subproblems
+// are spread evenly across the members, and only those of a member move when it joins or leaves.
+const peerVirtualNodes = 128
+
+var peerDispatchCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "check_peer_dispatch_count",
+	Help:      "The number of Check subproblems routed by the peer dispatch, by outcome: 'local' if the server owns them, 'peer' if the peer that owns them resolved them, and 'fallback' if the peer failed and the server resolved them itself.",
+}, []string{"outcome"})
+
+type peerDispatchedCtxKey struct{}
+
+// ContextWithPeerDispatched returns a context whose Check subproblem with the tuple key is resolved
+// by this server whichever member owns it, e.g. the subproblem a peer dispatched to this server, so
+// that it isn't dispatched again if the members disagree on the owner.
+func ContextWithPeerDispatched(parent context.Context, tupleKey string) context.Context {
+	return context.WithValue(parent, peerDispatchedCtxKey{}, tupleKey)
+}
+
+func peerDispatchedFromContext(ctx context.Context) string {
+	tupleKey, _ := ctx.Value(peerDispatchedCtxKey{}).(string)
+	return tupleKey
+}
+
+// PeerDialer returns the CheckResolver that resolves the Check subproblems on the member of the
+// cluster at the address. It is closed once the member leaves the cluster.
+type PeerDialer func(addr string) (CheckResolver, error)
+
+// hashRing is a consistent hash ring of the members of the cluster.
+type hashRing struct {
+	points  []uint64
+	members map[uint64]string
+}
+
+func newHashRing(members []string) *hashRing {
+	ring := &hashRing{members: make(map[uint64]string, len(members)*peerVirtualNodes)}
+	for _, member := range members {
+		for i := 0; i < peerVirtualNodes; i++ {
+			point := xxhash.Sum64String(member + "#" + strconv.Itoa(i))
+			ring.points = append(ring.points, point)
+			ring.members[point] = member
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+
+	return ring
+}
+
+// owner returns the member that owns the key, the first one clockwise of its hash on the ring.
+func (h *hashRing) owner(key string) string {
+	if len(h.points) == 0 {
+		return ""
+	}
+
+	hash := xxhash.Sum64String(key)
+	i := sort.Search(len(h.points), func(i int) bool { return h.points[i] >= hash })
+	if i == len(h.points) {
+		i = 0
+	}
+
+	return h.members[h.points[i]]
+}
+
+type PeerDispatchCheckResolverOpt func(*PeerDispatchCheckResolver)
+
+// WithPeerDispatchLogger sets the logger of the failures of the peers.
+func WithPeerDispatchLogger(l logger.Logger) PeerDispatchCheckResolverOpt {
+	return func(r *PeerDispatchCheckResolver) {
+		r.logger = l
+	}
+}
+
+// WithPeerDispatchTimeout sets the timeout of the subproblems dispatched to a peer, after which
+// the server resolves them itself. If 0, they only time out with the request.
+func WithPeerDispatchTimeout(timeout time.Duration) PeerDispatchCheckResolverOpt {
+	return func(r *PeerDispatchCheckResolver) {
+		r.timeout = timeout
+	}
+}
+
+// PeerDispatchCheckResolver shards the Check subproblems across the members of a cluster by the
+// consistent hash of their store, model, object and relation, Zanzibar-style, so that the cached
+// result of each subproblem lives on the member that owns it, and the cache hit rate of the cluster
+// grows with its members instead of each member caching every subproblem. The subproblems that this
+// server owns, and those that a peer fails to resolve, are resolved by its delegate.
+type PeerDispatchCheckResolver struct {
+	delegate CheckResolver
+	self     string
+	dial     PeerDialer
+	logger   logger.Logger
+	timeout  time.Duration
+
+	mu    sync.RWMutex
+	ring  *hashRing
+	peers map[string]CheckResolver
+}
+
+var _ CheckResolver = (*PeerDispatchCheckResolver)(nil)
+
+// NewPeerDispatchCheckResolver constructs a PeerDispatchCheckResolver of the server whose address
+// among the members of the cluster is self. It resolves every subproblem itself until the members
+// are set with SetPeers.
+func NewPeerDispatchCheckResolver(self string, dial PeerDialer, opts ...PeerDispatchCheckResolverOpt) *PeerDispatchCheckResolver {
+	r := &PeerDispatchCheckResolver{
+		self:   self,
+		dial:   dial,
+		logger: logger.NewNoopLogger(),
+		ring:   newHashRing([]string{self}),
+		peers:  map[string]CheckResolver{},
+	}
+	r.delegate = r
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *PeerDispatchCheckResolver) SetDelegate(delegate CheckResolver) {
+	r.delegate = delegate
+}
+
+func (r *PeerDispatchCheckResolver) GetDelegate() CheckResolver {
+	return r.delegate
+}
+
+// SetPeers sets the addresses of the members of the cluster, with or without the address of this
+// server. The peers that joined are dialed, and those that left are closed. A peer that can't be
+// dialed is left out of the cluster until the next call.
+func (r *PeerDispatchCheckResolver) SetPeers(addrs []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	members := []string{r.self}
+	peers := make(map[string]CheckResolver, len(addrs))
+	for _, addr := range addrs {
+		if _, ok := peers[addr]; ok || addr == r.self {
+			continue
+		}
+
+		peer, ok := r.peers[addr]
+		if !ok {
+			var err error
+			peer, err = r.dial(addr)
+			if err != nil {
+				r.logger.Error("failed to dial the Check peer", zap.String("peer", addr), zap.Error(err))
+				continue
+			}
+		}
+
+		peers[addr] = peer
+		members = append(members, addr)
+	}
+
+	for addr, peer := range r.peers {
+		if _, ok := peers[addr]; !ok {
+			peer.Close()
+		}
+	}
+
+	r.peers = peers
+	r.ring = newHashRing(members)
+}
+
+// Peers returns the addresses of the peers of the server.
+func (r *PeerDispatchCheckResolver) Peers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	addrs := make([]string, 0, len(r.peers))
+	for addr := range r.peers {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	return addrs
+}
+
+// Close closes the peers.
+func (r *PeerDispatchCheckResolver) Close() {
+	r.SetPeers(nil)
+}
+
+// ResolveCheck implements CheckResolver.
+func (r *PeerDispatchCheckResolver) ResolveCheck(
+	ctx context.Context,
+	req *ResolveCheckRequest,
+) (*ResolveCheckResponse, error) {
+	ctx, span := tracer.Start(ctx, "ResolveCheck")
+	defer span.End()
+	span.SetAttributes(attribute.String("resolver_type", "PeerDispatchCheckResolver"))
+
+	tupleKey := tuple.TupleKeyToString(req.GetTupleKey())
+	if peerDispatchedFromContext(ctx) == tupleKey {
+		return r.delegate.ResolveCheck(ctx, req)
+	}
+
+	key := fmt.Sprintf("%s/%s/%s#%s", req.GetStoreID(), req.GetAuthorizationModelID(),
+		req.GetTupleKey().GetObject(), req.GetTupleKey().GetRelation())
+
+	r.mu.RLock()
+	owner := r.ring.owner(key)
+	peer := r.peers[owner]
+	r.mu.RUnlock()
+
+	if peer == nil {
+		peerDispatchCounter.WithLabelValues("local").Inc()
+		return r.delegate.ResolveCheck(ctx, req)
+	}
+
+	span.SetAttributes(attribute.String("peer", owner))
+
+	peerCtx := ctx
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		peerCtx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	resp, err := peer.ResolveCheck(peerCtx, req)
+	if err == nil {
+		peerDispatchCounter.WithLabelValues("peer").Inc()
+		return resp, nil
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.logger.WarnWithContext(ctx, "the Check peer failed, resolving the subproblem locally",
+		zap.String("peer", owner), zap.Error(err))
+	peerDispatchCounter.WithLabelValues("fallback").Inc()
+	span.SetAttributes(attribute.Bool("fallback", true))
+
+	return r.delegate.ResolveCheck(ctx, req)
+}
@@ -0,0 +1,182 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// fakePeer is a peer that counts the subproblems it resolves.
+type fakePeer struct {
+	err      error
+	resolved atomic.Int32
+	closed   atomic.Bool
+}
+
+func (f *fakePeer) ResolveCheck(_ context.Context, _ *ResolveCheckRequest) (*ResolveCheckResponse, error) {
+	f.resolved.Add(1)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &ResolveCheckResponse{Allowed: true, ResolutionMetadata: &ResolveCheckResponseMetadata{}}, nil
+}
+
+func (f *fakePeer) Close() {
+	f.closed.Store(true)
+}
+
+func TestHashRing(t *testing.T) {
+	ring := newHashRing([]string{"a", "b", "c"})
+
+	owned := map[string]int{}
+	owners := map[string]string{}
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("document:%d#viewer", i)
+		owners[key] = ring.owner(key)
+		owned[owners[key]]++
+	}
+
+	// the keys are spread evenly across the members
+	for _, member := range []string{"a", "b", "c"} {
+		require.Greater(t, owned[member], 600, member)
+	}
+
+	// only the keys of the member that joined move
+	ring = newHashRing([]string{"a", "b", "c", "d"})
+	for key, owner := range owners {
+		if moved := ring.owner(key); moved != owner {
+			require.Equal(t, "d", moved)
+		}
+	}
+
+	require.Empty(t, newHashRing(nil).owner("document:1#viewer"))
+}
+
+func TestPeerDispatchCheckResolver(t *testing.T) {
+	ctx := context.Background()
+
+	newRequest := func(object string) *ResolveCheckRequest {
+		return &ResolveCheckRequest{
+			StoreID:              "store",
+			AuthorizationModelID: "model",
+			TupleKey:             tuple.NewTupleKey(object, "viewer", "user:anne"),
+			RequestMetadata:      NewCheckRequestMetadata(defaultResolveNodeLimit),
+		}
+	}
+
+	// ownedBy returns a request of a subproblem that the member owns
+	ownedBy := func(t *testing.T, r *PeerDispatchCheckResolver, member string) *ResolveCheckRequest {
+		for i := 0; ; i++ {
+			req := newRequest(fmt.Sprintf("document:%d", i))
+			key := fmt.Sprintf("store/model/%s#viewer", req.GetTupleKey().GetObject())
+			if r.ring.owner(key) == member {
+				return req
+			}
+			require.Less(t, i, 1000)
+		}
+	}
+
+	setup := func(t *testing.T, peer *fakePeer) (*PeerDispatchCheckResolver, *fakePeer) {
+		r := NewPeerDispatchCheckResolver("self:8081", func(addr string) (CheckResolver, error) {
+			return peer, nil
+		})
+		local := &fakePeer{}
+		r.SetDelegate(local)
+		t.Cleanup(r.Close)
+
+		return r, local
+	}
+
+	t.Run("resolves_locally_without_peers", func(t *testing.T) {
+		r, local := setup(t, &fakePeer{})
+
+		_, err := r.ResolveCheck(ctx, newRequest("document:1"))
+		require.NoError(t, err)
+		require.Equal(t, int32(1), local.resolved.Load())
+	})
+
+	t.Run("dispatches_to_the_owner", func(t *testing.T) {
+		peer := &fakePeer{}
+		r, local := setup(t, peer)
+		r.SetPeers([]string{"self:8081", "peer:8081"})
+		require.Equal(t, []string{"peer:8081"}, r.Peers())
+
+		resp, err := r.ResolveCheck(ctx, ownedBy(t, r, "peer:8081"))
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+		require.Equal(t, int32(1), peer.resolved.Load())
+		require.Equal(t, int32(0), local.resolved.Load())
+
+		_, err = r.ResolveCheck(ctx, ownedBy(t, r, "self:8081"))
+		require.NoError(t, err)
+		require.Equal(t, int32(1), peer.resolved.Load())
+		require.Equal(t, int32(1), local.resolved.Load())
+	})
+
+	t.Run("resolves_locally_the_subproblem_dispatched_by_a_peer", func(t *testing.T) {
+		peer := &fakePeer{}
+		r, local := setup(t, peer)
+		r.SetPeers([]string{"peer:8081"})
+
+		req := ownedBy(t, r, "peer:8081")
+		_, err := r.ResolveCheck(ContextWithPeerDispatched(ctx, tuple.TupleKeyToString(req.GetTupleKey())), req)
+		require.NoError(t, err)
+		require.Equal(t, int32(0), peer.resolved.Load())
+		require.Equal(t, int32(1), local.resolved.Load())
+	})
+
+	t.Run("falls_back_to_local_when_the_peer_fails", func(t *testing.T) {
+		peer := &fakePeer{err: errors.New("unavailable")}
+		r, local := setup(t, peer)
+		r.SetPeers([]string{"peer:8081"})
+
+		_, err := r.ResolveCheck(ctx, ownedBy(t, r, "peer:8081"))
+		require.NoError(t, err)
+		require.Equal(t, int32(1), peer.resolved.Load())
+		require.Equal(t, int32(1), local.resolved.Load())
+	})
+
+	t.Run("returns_the_error_of_a_cancelled_request", func(t *testing.T) {
+		peer := &fakePeer{err: context.Canceled}
+		r, local := setup(t, peer)
+		r.SetPeers([]string{"peer:8081"})
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := r.ResolveCheck(cancelled, ownedBy(t, r, "peer:8081"))
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, int32(0), local.resolved.Load())
+	})
+
+	t.Run("closes_the_peers_that_left", func(t *testing.T) {
+		peer := &fakePeer{}
+		r, _ := setup(t, peer)
+		r.SetPeers([]string{"peer:8081"})
+		require.False(t, peer.closed.Load())
+
+		r.SetPeers([]string{"self:8081"})
+		require.True(t, peer.closed.Load())
+		require.Empty(t, r.Peers())
+	})
+
+	t.Run("leaves_out_the_peers_that_fail_to_dial", func(t *testing.T) {
+		r := NewPeerDispatchCheckResolver("self:8081", func(addr string) (CheckResolver, error) {
+			if addr == "bad:8081" {
+				return nil, errors.New("failed to dial")
+			}
+			return &fakePeer{}, nil
+		})
+		t.Cleanup(r.Close)
+
+		r.SetPeers([]string{"bad:8081", "peer:8081"})
+		require.Equal(t, []string{"peer:8081"}, r.Peers())
+	})
+}
@@ -0,0 +1,836 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/condition/eval"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+const (
+	plannerStatisticsVersion = 1
+
+	// defaultPlannerMaxStores is the default maximum number of stores whose statistics are kept in memory.
+	defaultPlannerMaxStores = 10000
+
+	// plannerStatisticsTimeout bounds the time spent reading or writing the statistics of a store.
+	plannerStatisticsTimeout = 5 * time.Second
+
+	// readCost is the estimated cost of a datastore read, relative to the cost of reading one tuple.
+	readCost = 1.0
+	// tupleCost is the estimated cost of reading one tuple.
+	tupleCost = 0.05
+
+	// minSmoothingFactor is the weight of a new observation once there are enough samples, so that the
+	// statistics follow changes in the data.
+	minSmoothingFactor = 0.1
+
+	// defaultCardinality is the cardinality assumed for relations without statistics. Assuming a low
+	// cardinality means the planner tries the strategy that reads it at least once, and learns about it.
+	defaultCardinality = 1.0
+)
+
+// errPlannedLookupInconclusive is returned when a planned lookup didn't find a relationship and the
+// conditions of some tuples couldn't be evaluated. The lookup is then resolved with the forward
+// expansion, which reports those errors only if they prevent resolving the Check.
+var errPlannedLookupInconclusive = errors.New("planned lookup is inconclusive")
+
+var plannerStrategyCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "check_planner_strategy_count",
+	Help:      "The total number of userset and tuple to userset evaluations resolved with each strategy chosen by the Check planner.",
+}, []string{"strategy"})
+
+// ResolutionStrategy is a way to resolve whether a user is related to an object through an intermediate
+// userset, e.g. through 'group:eng#member' in 'define viewer: [group#member]' or through 'folder:x#viewer'
+// in 'define viewer: viewer from parent'.
+type ResolutionStrategy int
+
+const (
+	// ForwardExpansionStrategy reads the intermediate usersets related to the object and dispatches a
+	// Check for each one of them.
+	ForwardExpansionStrategy ResolutionStrategy = iota
+
+	// ReverseLookupStrategy reads the intermediate usersets the user is related to and the ones related
+	// to the object, and intersects them.
+	ReverseLookupStrategy
+
+	// DirectTupleProbeStrategy reads the intermediate usersets the user is related to and probes
+	// whether each one of them is related to the object.
+	DirectTupleProbeStrategy
+)
+
+func (s ResolutionStrategy) String() string {
+	switch s {
+	case ForwardExpansionStrategy:
+		return "forward_expansion"
+	case ReverseLookupStrategy:
+		return "reverse_lookup"
+	case DirectTupleProbeStrategy:
+		return "direct_tuple_probe"
+	default:
+		return "unknown"
+	}
+}
+
+// cardinality is the moving average of the number of tuples found by the reads of a relation.
+type cardinality struct {
+	Samples uint64  `json:"samples"`
+	Mean    float64 `json:"mean"`
+}
+
+// plannerStatistics are the persisted statistics of a store.
+type plannerStatistics struct {
+	Version int `json:"version"`
+
+	// Relations maps a cardinality key to the cardinality of the relation.
+	Relations map[string]cardinality `json:"relations"`
+}
+
+// storeStatistics are the statistics of a store kept in memory.
+type storeStatistics struct {
+	relations map[string]cardinality // GUARDED_BY(Planner.mu).
+
+	// changed is true if the statistics changed since they were last persisted.
+	changed bool // GUARDED_BY(Planner.mu).
+
+	// lastUsed is the time, in Unix nanoseconds, that the statistics were last used or observed.
+	lastUsed atomic.Int64
+}
+
+// Planner chooses the [ResolutionStrategy] of usersets and tuple to userset rewrites evaluated by
+// Check, based on statistics about the cardinality of the relations of each store. The statistics are
+// gathered from the tuples read by Check, and are optionally persisted to the datastore so that they
+// survive restarts and are shared by the servers.
+type Planner struct {
+	logger             logger.Logger
+	backend            storage.PlannerStatisticsBackend
+	statisticsInterval time.Duration
+	maxStores          int
+
+	mu     sync.RWMutex
+	stores map[string]*storeStatistics
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// PlannerOption defines an option that can be used to change the behavior of a [Planner].
+type PlannerOption func(p *Planner)
+
+// WithPlannerLogger sets the logger of the [Planner].
+func WithPlannerLogger(l logger.Logger) PlannerOption {
+	return func(p *Planner) {
+		p.logger = l
+	}
+}
+
+// WithPlannerStatisticsBackend sets the datastore that the statistics of a store are loaded from when
+// the [Planner] first uses them, and persisted to every statistics interval and when it is closed.
+// If the backend is nil, which is the default, the statistics are not persisted.
+func WithPlannerStatisticsBackend(backend storage.PlannerStatisticsBackend) PlannerOption {
+	return func(p *Planner) {
+		p.backend = backend
+	}
+}
+
+// WithPlannerStatisticsInterval sets how often the statistics are persisted, if they changed. If the
+// interval is 0, they are only persisted when the [Planner] is closed.
+func WithPlannerStatisticsInterval(interval time.Duration) PlannerOption {
+	return func(p *Planner) {
+		p.statisticsInterval = interval
+	}
+}
+
+// WithPlannerMaxStores sets the maximum number of stores whose statistics are kept in memory. Once it is
+// reached, the statistics of the least recently used store are dropped; the changes to them since they
+// were last persisted are lost.
+func WithPlannerMaxStores(maxStores int) PlannerOption {
+	return func(p *Planner) {
+		p.maxStores = maxStores
+	}
+}
+
+// NewPlanner constructs a [Planner]. You must call Close on it after you are done using it.
+func NewPlanner(opts ...PlannerOption) *Planner {
+	p := &Planner{
+		logger:    logger.NewNoopLogger(),
+		maxStores: defaultPlannerMaxStores,
+		stores:    map[string]*storeStatistics{},
+		stop:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.backend != nil && p.statisticsInterval > 0 {
+		p.done.Add(1)
+		go p.persistPeriodically()
+	}
+
+	return p
+}
+
+// Close stops persisting the statistics periodically and persists them one last time.
+func (p *Planner) Close() {
+	// the statistics of new stores are loaded with p.mu held, so that none is loaded once p.stop is closed
+	p.mu.Lock()
+	close(p.stop)
+	p.mu.Unlock()
+
+	p.done.Wait()
+
+	if p.backend != nil {
+		p.persistStatistics()
+	}
+}
+
+// Plan returns the cheapest strategy to resolve whether a user is related to an object through an
+// intermediate userset. objectKey identifies the relation of the object that relates it to the
+// intermediate usersets, and userKeys the relations of the intermediate usersets that the user can be
+// related to, which are read separately.
+func (p *Planner) Plan(storeID, objectKey string, userKeys ...string) ResolutionStrategy {
+	objectCardinality := p.estimate(storeID, objectKey)
+
+	var userCardinality float64
+	for _, userKey := range userKeys {
+		userCardinality += p.estimate(storeID, userKey)
+	}
+	userReads := float64(len(userKeys))
+
+	// the forward expansion reads the usersets of the object and checks each one of them
+	forwardCost := readCost + objectCardinality*(tupleCost+readCost)
+	// the reverse lookup reads the usersets of the user and the usersets of the object
+	reverseCost := userReads*readCost + readCost + (userCardinality+objectCardinality)*tupleCost
+	// the direct tuple probe reads the usersets of the user and probes each one of them on the object
+	probeCost := userReads*readCost + userCardinality*(tupleCost+readCost)
+
+	strategy := ForwardExpansionStrategy
+	cost := forwardCost
+	if probeCost < cost {
+		strategy, cost = DirectTupleProbeStrategy, probeCost
+	}
+	if reverseCost < cost {
+		strategy = ReverseLookupStrategy
+	}
+
+	plannerStrategyCounter.WithLabelValues(strategy.String()).Inc()
+
+	return strategy
+}
+
+// Observe records that a read of the relation identified by key found count tuples.
+func (p *Planner) Observe(storeID, key string, count int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statistics := p.trackStore(storeID)
+
+	c := statistics.relations[key]
+	c.Samples++
+	smoothingFactor := math.Max(1/float64(c.Samples), minSmoothingFactor)
+	c.Mean += (float64(count) - c.Mean) * smoothingFactor
+	statistics.relations[key] = c
+	statistics.changed = true
+}
+
+// Seed sets the expected number of tuples found by a read of objectType#relation, if there are no
+// statistics about it yet, e.g. from statistics collected by scanning the tuples of the store. If
+// userType is not empty, the reads are the ones filtered by a user of that type. Seeded statistics are
+// refined by the reads observed afterwards.
+func (p *Planner) Seed(storeID, objectType, relation, userType string, mean float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statistics := p.trackStore(storeID)
+
+	key := cardinalityKey(objectType, relation, userType)
+	if _, ok := statistics.relations[key]; ok {
+		return
+	}
+
+	statistics.relations[key] = cardinality{Samples: 1, Mean: mean}
+	statistics.changed = true
+}
+
+// estimate returns the expected number of tuples found by a read of the relation identified by key.
+func (p *Planner) estimate(storeID, key string) float64 {
+	p.mu.RLock()
+	statistics, ok := p.stores[storeID]
+	if ok {
+		statistics.lastUsed.Store(time.Now().UnixNano())
+		c, ok := statistics.relations[key]
+		p.mu.RUnlock()
+		if !ok {
+			return defaultCardinality
+		}
+		return c.Mean
+	}
+	p.mu.RUnlock()
+
+	p.mu.Lock()
+	p.trackStore(storeID)
+	p.mu.Unlock()
+
+	return defaultCardinality
+}
+
+// trackStore returns the statistics of the store, and starts loading the persisted ones if the store
+// is not tracked yet. It drops the statistics of the least recently used store if there are too many.
+// It must be called with p.mu held for writing.
+func (p *Planner) trackStore(storeID string) *storeStatistics {
+	statistics, ok := p.stores[storeID]
+	if ok {
+		statistics.lastUsed.Store(time.Now().UnixNano())
+		return statistics
+	}
+
+	if p.maxStores > 0 && len(p.stores) >= p.maxStores {
+		p.evictLeastRecentlyUsedStore()
+	}
+
+	statistics = &storeStatistics{relations: map[string]cardinality{}}
+	statistics.lastUsed.Store(time.Now().UnixNano())
+	p.stores[storeID] = statistics
+
+	if p.backend != nil {
+		select {
+		case <-p.stop:
+		default:
+			p.done.Add(1)
+			go p.loadStatistics(storeID, statistics)
+		}
+	}
+
+	return statistics
+}
+
+// evictLeastRecentlyUsedStore must be called with p.mu held for writing.
+func (p *Planner) evictLeastRecentlyUsedStore() {
+	var evicted string
+	oldest := int64(math.MaxInt64)
+	for storeID, statistics := range p.stores {
+		if lastUsed := statistics.lastUsed.Load(); lastUsed < oldest {
+			evicted, oldest = storeID, lastUsed
+		}
+	}
+
+	delete(p.stores, evicted)
+}
+
+// loadStatistics loads the persisted statistics of a store into its tracked statistics. The relations
+// observed or seeded meanwhile are kept.
+func (p *Planner) loadStatistics(storeID string, statistics *storeStatistics) {
+	defer p.done.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), plannerStatisticsTimeout)
+	defer cancel()
+
+	data, err := p.backend.ReadPlannerStatistics(ctx, storeID)
+	if err != nil {
+		p.logger.Error("failed to read check planner statistics", zap.String("store_id", storeID), zap.Error(err))
+		return
+	}
+
+	if data == nil {
+		return
+	}
+
+	var persisted plannerStatistics
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		p.logger.Error("failed to decode check planner statistics", zap.String("store_id", storeID), zap.Error(err))
+		return
+	}
+
+	if persisted.Version != plannerStatisticsVersion {
+		p.logger.Warn("ignoring check planner statistics with an unsupported version",
+			zap.String("store_id", storeID), zap.Int("version", persisted.Version))
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, c := range persisted.Relations {
+		if _, ok := statistics.relations[key]; !ok {
+			statistics.relations[key] = c
+		}
+	}
+}
+
+func (p *Planner) persistPeriodically() {
+	defer p.done.Done()
+
+	ticker := time.NewTicker(p.statisticsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.persistStatistics()
+		}
+	}
+}
+
+// persistStatistics writes the statistics of the stores that changed since they were last persisted.
+func (p *Planner) persistStatistics() {
+	changed := map[string][]byte{}
+
+	p.mu.Lock()
+	for storeID, statistics := range p.stores {
+		if !statistics.changed {
+			continue
+		}
+
+		data, err := json.Marshal(plannerStatistics{
+			Version:   plannerStatisticsVersion,
+			Relations: statistics.relations,
+		})
+		if err != nil {
+			p.logger.Error("failed to encode check planner statistics", zap.String("store_id", storeID), zap.Error(err))
+			continue
+		}
+
+		changed[storeID] = data
+		statistics.changed = false
+	}
+	p.mu.Unlock()
+
+	for storeID, data := range changed {
+		if err := p.writeStatistics(storeID, data); err != nil {
+			p.logger.Error("failed to write check planner statistics", zap.String("store_id", storeID), zap.Error(err))
+
+			// write them again next time
+			p.mu.Lock()
+			if statistics, ok := p.stores[storeID]; ok {
+				statistics.changed = true
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+func (p *Planner) writeStatistics(storeID string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), plannerStatisticsTimeout)
+	defer cancel()
+
+	return p.backend.WritePlannerStatistics(ctx, storeID, data)
+}
+
+// cardinalityKey returns the key of the statistics of the reads of objectType#relation, and of the
+// reads of the objectType#relation that a user of userType is related to, if userType is not empty.
+func cardinalityKey(objectType, relation, userType string) string {
+	key := tuple.ToObjectRelationString(objectType, relation)
+	if userType != "" {
+		key += "@" + userType
+	}
+
+	return key
+}
+
+// plannedLookup describes how a user can be related to an object through intermediate usersets, so
+// that it can be resolved with a strategy other than the [ForwardExpansionStrategy].
+type plannedLookup struct {
+	// objectKey is the cardinality key of the relation of the object to the intermediate usersets.
+	objectKey string
+
+	// readObject reads the intermediate usersets related to the object.
+	readObject func(ctx context.Context) (storage.TupleIterator, error)
+
+	// userReads find the intermediate usersets that the user is related to.
+	userReads []plannedUserRead
+
+	// wildcard is the typed wildcard of the type of the user, if it can be related to the object.
+	wildcard string
+}
+
+type plannedUserRead struct {
+	key    string
+	filter storage.ReadStartingWithUserFilter
+
+	// usersetRelation is the relation of the intermediate usersets, e.g. 'member' for 'group:eng#member'.
+	// It is empty for tuple to userset rewrites, which relate the object to the intermediate objects.
+	usersetRelation string
+}
+
+func (l *plannedLookup) userKeys() []string {
+	keys := make([]string, 0, len(l.userReads))
+	for _, userRead := range l.userReads {
+		keys = append(keys, userRead.key)
+	}
+
+	return keys
+}
+
+// planUsersetsLookup returns the lookup of the usersets of objectType#relation, e.g. 'group#member' in
+// 'define viewer: [user, group#member]'. It returns nil if there is no planner or it is disabled in the
+// context, or if the user can be related to some of the usersets other than directly.
+func (c *LocalChecker) planUsersetsLookup(
+	ctx context.Context,
+	typesys *typesystem.TypeSystem,
+	objectType, relation string,
+	directlyRelatedUsersetTypes []*openfgav1.RelationReference,
+	user string,
+) (*plannedLookup, error) {
+	if c.planner == nil || CheckPlannerDisabledFromContext(ctx) || !isPlannableUser(user) {
+		return nil, nil
+	}
+
+	userType := tuple.GetType(user)
+	lookup := &plannedLookup{objectKey: cardinalityKey(objectType, relation, "")}
+
+	for _, ref := range directlyRelatedUsersetTypes {
+		if ref.GetWildcard() != nil {
+			if ref.GetType() == userType {
+				lookup.wildcard = tuple.TypedPublicWildcard(userType)
+			}
+			continue
+		}
+
+		userRead, ok, err := planUserRead(typesys, ref.GetType(), ref.GetRelation(), user)
+		if err != nil || !ok {
+			return nil, err
+		}
+
+		if userRead != nil {
+			userRead.usersetRelation = ref.GetRelation()
+			lookup.userReads = append(lookup.userReads, *userRead)
+		}
+	}
+
+	return lookup, nil
+}
+
+// planTTULookup returns the lookup of the tuple to userset rewrite 'computedRelation from tuplesetRelation'
+// of objectType. It returns nil if there is no planner or it is disabled in the context, or if the user
+// can be related to the computed relation of some of the types of the tupleset relation other than directly.
+func (c *LocalChecker) planTTULookup(
+	ctx context.Context,
+	typesys *typesystem.TypeSystem,
+	objectType, tuplesetRelation, computedRelation string,
+	user string,
+) (*plannedLookup, error) {
+	if c.planner == nil || CheckPlannerDisabledFromContext(ctx) || !isPlannableUser(user) {
+		return nil, nil
+	}
+
+	tuplesetTypes, err := typesys.GetDirectlyRelatedUserTypes(objectType, tuplesetRelation)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := &plannedLookup{objectKey: cardinalityKey(objectType, tuplesetRelation, "")}
+	planned := map[string]struct{}{}
+
+	for _, ref := range tuplesetTypes {
+		if _, ok := planned[ref.GetType()]; ok {
+			continue
+		}
+		planned[ref.GetType()] = struct{}{}
+
+		userRead, ok, err := planUserRead(typesys, ref.GetType(), computedRelation, user)
+		if err != nil {
+			if errors.Is(err, typesystem.ErrRelationUndefined) {
+				continue // the computed relation is not defined on every type of the tupleset relation
+			}
+			return nil, err
+		}
+
+		if !ok {
+			return nil, nil
+		}
+
+		if userRead != nil {
+			lookup.userReads = append(lookup.userReads, *userRead)
+		}
+	}
+
+	return lookup, nil
+}
+
+// isPlannableUser returns true if the user is an object, e.g. 'user:anne', rather than a typed wildcard
+// or a userset, which are only resolved with the [ForwardExpansionStrategy].
+func isPlannableUser(user string) bool {
+	return !tuple.IsTypedWildcard(user) && tuple.GetRelation(user) == ""
+}
+
+// planUserRead returns the read of the objectType#relation objects that the user is directly related to,
+// and true if that is the only way the user can be related to them, e.g. 'define member: [user, user:*]'.
+// It returns a nil read if the user can't be related to them at all.
+func planUserRead(typesys *typesystem.TypeSystem, objectType, relation, user string) (*plannedUserRead, bool, error) {
+	rel, err := typesys.GetRelation(objectType, relation)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, ok := rel.GetRewrite().GetUserset().(*openfgav1.Userset_This); !ok {
+		return nil, false, nil
+	}
+
+	refs, err := typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+	if err != nil {
+		return nil, false, err
+	}
+
+	userType := tuple.GetType(user)
+	var userFilter []*openfgav1.ObjectRelation
+	for _, ref := range refs {
+		if ref.GetRelation() != "" {
+			return nil, false, nil
+		}
+
+		if ref.GetType() != userType {
+			continue
+		}
+
+		if ref.GetWildcard() != nil {
+			userFilter = append(userFilter, &openfgav1.ObjectRelation{Object: tuple.TypedPublicWildcard(userType)})
+		} else {
+			userFilter = append(userFilter, &openfgav1.ObjectRelation{Object: user})
+		}
+	}
+
+	if len(userFilter) == 0 {
+		return nil, true, nil
+	}
+
+	return &plannedUserRead{
+		key: cardinalityKey(objectType, relation, userType),
+		filter: storage.ReadStartingWithUserFilter{
+			ObjectType: objectType,
+			Relation:   relation,
+			UserFilter: userFilter,
+		},
+	}, true, nil
+}
+
+// observe records the number of tuples found by a complete read of the relation identified by key,
+// if there is a planner.
+func (c *LocalChecker) observe(storeID, key string, count int) {
+	if c.planner != nil {
+		c.planner.Observe(storeID, key, count)
+	}
+}
+
+// checkPlannedLookup evaluates whether the user is related to the object through the usersets of the
+// lookup with the given strategy. objectRelation is the relation of the object to the usersets.
+// It returns errPlannedLookupInconclusive if the result depends on conditions that couldn't be evaluated.
+func (c *LocalChecker) checkPlannedLookup(
+	typesys *typesystem.TypeSystem,
+	ds storage.RelationshipTupleReader,
+	req *ResolveCheckRequest,
+	objectRelation string,
+	lookup *plannedLookup,
+	strategy ResolutionStrategy,
+) CheckHandlerFunc {
+	return func(ctx context.Context) (*ResolveCheckResponse, error) {
+		ctx, span := tracer.Start(ctx, "checkPlannedLookup", trace.WithAttributes(
+			attribute.String("strategy", strategy.String()),
+		))
+		defer span.End()
+
+		storeID := req.GetStoreID()
+		object := req.GetTupleKey().GetObject()
+
+		response := &ResolveCheckResponse{
+			Allowed: false,
+			ResolutionMetadata: &ResolveCheckResponseMetadata{
+				DatastoreQueryCount: req.GetRequestMetadata().DatastoreQueryCount,
+			},
+		}
+
+		var inconclusive bool
+		usersets := map[string]struct{}{}
+		if lookup.wildcard != "" {
+			usersets[lookup.wildcard] = struct{}{}
+		}
+
+		for _, userRead := range lookup.userReads {
+			if err := req.GetRequestMetadata().chargeDatastoreRead(); err != nil {
+				return nil, err
+			}
+			response.GetResolutionMetadata().DatastoreQueryCount++
+
+			iter, err := ds.ReadStartingWithUser(ctx, storeID, userRead.filter)
+			if err != nil {
+				telemetry.TraceError(span, err)
+				return nil, err
+			}
+
+			count, err := forEachTupleWithConditionMet(ctx, typesys, req, iter, func(t *openfgav1.TupleKey) bool {
+				userset := t.GetObject()
+				if userRead.usersetRelation != "" {
+					userset = tuple.ToObjectRelationString(userset, userRead.usersetRelation)
+				}
+				usersets[userset] = struct{}{}
+				return true
+			})
+			if err != nil {
+				if !errors.Is(err, errPlannedLookupInconclusive) {
+					telemetry.TraceError(span, err)
+					return nil, err
+				}
+				inconclusive = true
+			}
+
+			c.observe(storeID, userRead.key, count)
+		}
+
+		if len(usersets) == 0 {
+			if inconclusive {
+				return nil, errPlannedLookupInconclusive
+			}
+			return response, nil
+		}
+
+		switch strategy {
+		case DirectTupleProbeStrategy:
+			for userset := range usersets {
+				if err := req.GetRequestMetadata().chargeDatastoreRead(); err != nil {
+					return nil, err
+				}
+				response.GetResolutionMetadata().DatastoreQueryCount++
+
+				t, err := ds.ReadUserTuple(ctx, storeID, tuple.NewTupleKey(object, objectRelation, userset))
+				if err != nil {
+					if errors.Is(err, storage.ErrNotFound) {
+						continue
+					}
+					telemetry.TraceError(span, err)
+					return nil, err
+				}
+
+				if validation.ValidateTuple(typesys, t.GetKey()) != nil {
+					continue
+				}
+
+				_, err = forEachTupleWithConditionMet(ctx, typesys, req, storage.NewStaticTupleIterator([]*openfgav1.Tuple{t}), func(*openfgav1.TupleKey) bool {
+					response.Allowed = true
+					return false
+				})
+				if err != nil {
+					if !errors.Is(err, errPlannedLookupInconclusive) {
+						telemetry.TraceError(span, err)
+						return nil, err
+					}
+					inconclusive = true
+				}
+
+				if response.GetAllowed() {
+					break
+				}
+			}
+		case ReverseLookupStrategy:
+			if err := req.GetRequestMetadata().chargeDatastoreRead(); err != nil {
+				return nil, err
+			}
+			response.GetResolutionMetadata().DatastoreQueryCount++
+
+			iter, err := lookup.readObject(ctx)
+			if err != nil {
+				telemetry.TraceError(span, err)
+				return nil, err
+			}
+
+			count, err := forEachTupleWithConditionMet(ctx, typesys, req, iter, func(t *openfgav1.TupleKey) bool {
+				if _, ok := usersets[t.GetUser()]; ok {
+					response.Allowed = true
+					return false
+				}
+				return true
+			})
+			if err != nil {
+				if !errors.Is(err, errPlannedLookupInconclusive) {
+					telemetry.TraceError(span, err)
+					return nil, err
+				}
+				inconclusive = true
+			}
+
+			if !response.GetAllowed() {
+				c.observe(storeID, lookup.objectKey, count)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected resolution strategy '%s'", strategy)
+		}
+
+		if !response.GetAllowed() && inconclusive {
+			return nil, errPlannedLookupInconclusive
+		}
+
+		span.SetAttributes(attribute.Bool("allowed", response.GetAllowed()))
+		return response, nil
+	}
+}
+
+// forEachTupleWithConditionMet calls fn with each valid tuple yielded by iter whose condition, if any,
+// is met, until fn returns false. It returns the number of valid tuples yielded, and stops the iterator.
+// If fn never returned false and the condition of some tuples couldn't be evaluated, it returns
+// errPlannedLookupInconclusive.
+func forEachTupleWithConditionMet(
+	ctx context.Context,
+	typesys *typesystem.TypeSystem,
+	req *ResolveCheckRequest,
+	iter storage.TupleIterator,
+	fn func(t *openfgav1.TupleKey) bool,
+) (int, error) {
+	// filter out invalid tuples yielded by the database iterator
+	filteredIter := storage.NewFilteredTupleKeyIterator(
+		storage.NewTupleKeyIteratorFromTupleIterator(iter),
+		validation.FilterInvalidTuples(typesys),
+	)
+	defer filteredIter.Stop()
+
+	var count int
+	var inconclusive bool
+	for {
+		t, err := filteredIter.Next(ctx)
+		if err != nil {
+			if errors.Is(err, storage.ErrIteratorDone) {
+				if inconclusive {
+					return count, errPlannedLookupInconclusive
+				}
+				return count, nil
+			}
+			return count, err
+		}
+		count++
+
+		condEvalResult, err := eval.EvaluateTupleCondition(ctx, t, typesys, req.GetContext())
+		if err != nil || len(condEvalResult.MissingParameters) > 0 {
+			inconclusive = true
+			continue
+		}
+
+		if !condEvalResult.ConditionMet {
+			continue
+		}
+
+		if !fn(t) {
+			return count, nil
+		}
+	}
+}
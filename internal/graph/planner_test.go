@@ -0,0 +1,264 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestPlannerPlan(t *testing.T) {
+	const storeID = "store"
+
+	tests := []struct {
+		name              string
+		objectCardinality int
+		userCardinality   int
+		expected          ResolutionStrategy
+	}{
+		{
+			name:     "no_statistics",
+			expected: ForwardExpansionStrategy,
+		},
+		{
+			name:              "few_usersets_of_the_object",
+			objectCardinality: 1,
+			userCardinality:   100,
+			expected:          ForwardExpansionStrategy,
+		},
+		{
+			name:              "few_usersets_of_the_user",
+			objectCardinality: 100,
+			userCardinality:   1,
+			expected:          DirectTupleProbeStrategy,
+		},
+		{
+			name:              "many_usersets",
+			objectCardinality: 100,
+			userCardinality:   100,
+			expected:          ReverseLookupStrategy,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			planner := NewPlanner()
+			t.Cleanup(planner.Close)
+
+			if test.objectCardinality > 0 {
+				planner.Observe(storeID, "document#viewer", test.objectCardinality)
+				planner.Observe(storeID, "group#member@user", test.userCardinality)
+			}
+
+			require.Equal(t, test.expected, planner.Plan(storeID, "document#viewer", "group#member@user"))
+
+			// the statistics are per store
+			require.Equal(t, ForwardExpansionStrategy, planner.Plan("other", "document#viewer", "group#member@user"))
+		})
+	}
+}
+
+func TestPlannerObserve(t *testing.T) {
+	planner := NewPlanner()
+	t.Cleanup(planner.Close)
+
+	require.InDelta(t, defaultCardinality, planner.estimate("store", "document#viewer"), 0)
+
+	planner.Observe("store", "document#viewer", 10)
+	require.InDelta(t, 10, planner.estimate("store", "document#viewer"), 0)
+
+	planner.Observe("store", "document#viewer", 20)
+	require.InDelta(t, 15, planner.estimate("store", "document#viewer"), 0.001)
+
+	// once there are enough samples, new observations have a fixed weight
+	for i := 0; i < 100; i++ {
+		planner.Observe("store", "document#viewer", 15)
+	}
+	planner.Observe("store", "document#viewer", 115)
+	require.InDelta(t, 25, planner.estimate("store", "document#viewer"), 0.001)
+}
+
+func TestPlannerSeed(t *testing.T) {
+	planner := NewPlanner()
+	t.Cleanup(planner.Close)
+
+	planner.Seed("store", "document", "viewer", "", 100)
+	planner.Seed("store", "group", "member", "user", 1)
+	require.InDelta(t, 100, planner.estimate("store", "document#viewer"), 0)
+	require.Equal(t, DirectTupleProbeStrategy, planner.Plan("store", "document#viewer", "group#member@user"))
+
+	// observed statistics are not overwritten, but refine the seeded ones
+	planner.Observe("store", "document#viewer", 50)
+	require.InDelta(t, 75, planner.estimate("store", "document#viewer"), 0.001)
+	planner.Seed("store", "document", "viewer", "", 100)
+	require.InDelta(t, 75, planner.estimate("store", "document#viewer"), 0.001)
+}
+
+func TestPlannerStatisticsPersistence(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	planner := NewPlanner(WithPlannerStatisticsBackend(ds), WithPlannerStatisticsInterval(0))
+	planner.Observe("store", "document#viewer", 100)
+	planner.Observe("store", "group#member@user", 1)
+	planner.Close()
+
+	planner = NewPlanner(WithPlannerStatisticsBackend(ds))
+	t.Cleanup(planner.Close)
+
+	// the statistics of a store are loaded in the background once it is first planned
+	require.Equal(t, ForwardExpansionStrategy, planner.Plan("store", "document#viewer", "group#member@user"))
+	require.Eventually(t, func() bool {
+		return planner.Plan("store", "document#viewer", "group#member@user") == DirectTupleProbeStrategy
+	}, time.Second, 10*time.Millisecond)
+	require.InDelta(t, 100, planner.estimate("store", "document#viewer"), 0)
+}
+
+func TestPlannerMaxStores(t *testing.T) {
+	planner := NewPlanner(WithPlannerMaxStores(2))
+	t.Cleanup(planner.Close)
+
+	planner.Observe("a", "document#viewer", 100)
+	planner.Observe("b", "document#viewer", 100)
+	planner.estimate("a", "document#viewer")
+
+	// the statistics of the least recently used store are dropped
+	planner.Observe("c", "document#viewer", 100)
+	require.Len(t, planner.stores, 2)
+	require.InDelta(t, 100, planner.estimate("a", "document#viewer"), 0)
+	require.InDelta(t, 100, planner.estimate("c", "document#viewer"), 0)
+	require.NotContains(t, planner.stores, "b")
+}
+
+func TestCheckWithPlanner(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user, user:*]
+		type folder
+			relations
+				define viewer: [user, user with xcond]
+		type document
+			relations
+				define parent: [folder]
+				define viewer: [group#member, user:*]
+				define can_view: viewer from parent
+
+		condition xcond(x: int) {
+			x < 100
+		}`)
+
+	err := ds.Write(context.Background(), storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("group:eng", "member", "user:anne"),
+		tuple.NewTupleKey("group:all", "member", "user:*"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:2", "viewer", "group:all#member"),
+		tuple.NewTupleKey("document:3", "viewer", "user:*"),
+		tuple.NewTupleKey("folder:a", "viewer", "user:anne"),
+		tuple.NewTupleKeyWithCondition("folder:b", "viewer", "user:anne", "xcond", nil),
+		tuple.NewTupleKeyWithCondition("folder:b", "viewer", "user:bob", "xcond", nil),
+		tuple.NewTupleKey("document:1", "parent", "folder:a"),
+		tuple.NewTupleKey("document:2", "parent", "folder:b"),
+	})
+	require.NoError(t, err)
+
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesystem.New(model))
+	ctx = storage.ContextWithRelationshipTupleReader(ctx, ds)
+
+	check := func(t *testing.T, checker CheckResolver, tk *openfgav1.TupleKey, x int) (*ResolveCheckResponse, *ResolveCheckRequestMetadata) {
+		checkContext, err := structpb.NewStruct(map[string]interface{}{"x": x})
+		require.NoError(t, err)
+
+		metadata := NewCheckRequestMetadata(25)
+		resp, err := checker.ResolveCheck(ctx, &ResolveCheckRequest{
+			StoreID:         storeID,
+			TupleKey:        tk,
+			Context:         checkContext,
+			RequestMetadata: metadata,
+		})
+		require.NoError(t, err)
+
+		return resp, metadata
+	}
+
+	forwardChecker := NewLocalCheckerWithCycleDetection()
+	t.Cleanup(forwardChecker.Close)
+
+	strategies := map[ResolutionStrategy]struct{ objectCardinality, userCardinality int }{
+		ForwardExpansionStrategy: {1, 100},
+		DirectTupleProbeStrategy: {100, 1},
+		ReverseLookupStrategy:    {100, 100},
+	}
+
+	for strategy, cardinalities := range strategies {
+		t.Run(strategy.String(), func(t *testing.T) {
+			planner := NewPlanner()
+			t.Cleanup(planner.Close)
+
+			for _, key := range []string{"document#viewer", "document#parent"} {
+				planner.Observe(storeID, key, cardinalities.objectCardinality)
+			}
+			for _, key := range []string{"group#member@user", "folder#viewer@user"} {
+				planner.Observe(storeID, key, cardinalities.userCardinality)
+			}
+			require.Equal(t, strategy, planner.Plan(storeID, "document#parent", "folder#viewer@user"))
+
+			checker := NewLocalCheckerWithCycleDetection(WithPlanner(planner))
+			t.Cleanup(checker.Close)
+
+			resp, _ := check(t, checker, tuple.NewTupleKey("document:1", "viewer", "user:anne"), 1)
+			require.True(t, resp.GetAllowed())
+			resp, _ = check(t, checker, tuple.NewTupleKey("document:2", "viewer", "user:bob"), 1)
+			require.True(t, resp.GetAllowed())
+			resp, _ = check(t, checker, tuple.NewTupleKey("document:2", "can_view", "user:anne"), 1)
+			require.True(t, resp.GetAllowed())
+			resp, _ = check(t, checker, tuple.NewTupleKey("document:2", "can_view", "user:anne"), 1000)
+			require.False(t, resp.GetAllowed())
+
+			for _, user := range []string{"user:anne", "user:bob"} {
+				for _, relation := range []string{"viewer", "can_view"} {
+					for _, object := range []string{"document:1", "document:2", "document:3", "document:4"} {
+						for _, x := range []int{1, 1000} {
+							tk := tuple.NewTupleKey(object, relation, user)
+							t.Run(fmt.Sprintf("%s_%d", tuple.TupleKeyToString(tk), x), func(t *testing.T) {
+								expected, _ := check(t, forwardChecker, tk, x)
+								resp, metadata := check(t, checker, tk, x)
+								require.Equal(t, expected.GetAllowed(), resp.GetAllowed())
+
+								if strategy != ForwardExpansionStrategy && relation == "can_view" {
+									require.Zero(t, metadata.DispatchCounter.Load())
+								}
+							})
+						}
+					}
+				}
+			}
+		})
+	}
+}
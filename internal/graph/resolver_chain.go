@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"context"
+	"errors"
+)
+
+// DelegatingCheckResolver is a CheckResolver that dispatches (a part of) its work to a delegate
+// CheckResolver, so that it can be a link of a resolver chain.
+type DelegatingCheckResolver interface {
+	CheckResolver
+
+	// SetDelegate sets the CheckResolver that this resolver dispatches to.
+	SetDelegate(delegate CheckResolver)
+
+	// GetDelegate returns the CheckResolver that this resolver dispatches to.
+	GetDelegate() CheckResolver
+}
+
+var (
+	_ DelegatingCheckResolver = (*CycleDetectionCheckResolver)(nil)
+	_ DelegatingCheckResolver = (*CachedCheckResolver)(nil)
+	_ DelegatingCheckResolver = (*DispatchThrottlingCheckResolver)(nil)
+	_ DelegatingCheckResolver = (*LocalChecker)(nil)
+	_ DelegatingCheckResolver = (*CheckResolverInterceptor)(nil)
+)
+
+// NewResolverChain links the resolvers into a chain: each resolver delegates to the next one, and
+// the last one, which is usually a [LocalChecker], delegates the subproblems back to the first one.
+// For example, the default chain of the server is:
+//
+//	CycleDetectionCheckResolver  <-----------|
+//		DispatchThrottlingCheckResolver        |
+//			CachedCheckResolver                  |
+//				LocalChecker                       |
+//					CycleDetectionCheckResolver -------|
+//
+// Custom resolvers, e.g. ones built with [NewCheckResolverInterceptor], can be inserted anywhere in
+// the chain. A resolver must not appear twice in it.
+//
+// It returns the first resolver, and a CheckResolverCloser that closes all the resolvers of the chain.
+func NewResolverChain(resolvers ...DelegatingCheckResolver) (CheckResolver, CheckResolverCloser, error) {
+	if len(resolvers) == 0 {
+		return nil, nil, errors.New("a resolver chain needs at least one resolver")
+	}
+
+	for i, resolver := range resolvers {
+		resolver.SetDelegate(resolvers[(i+1)%len(resolvers)])
+	}
+
+	return resolvers[0], func() {
+		for _, resolver := range resolvers {
+			resolver.Close()
+		}
+	}, nil
+}
+
+// CheckResolverInterceptorFunc intercepts a ResolveCheck call. It may resolve the request itself, or
+// pass it on to the next resolver of the chain.
+type CheckResolverInterceptorFunc func(
+	ctx context.Context,
+	req *ResolveCheckRequest,
+	next CheckResolver,
+) (*ResolveCheckResponse, error)
+
+// CheckResolverInterceptor is a DelegatingCheckResolver that runs a CheckResolverInterceptorFunc,
+// which lets a middleware be inserted in a resolver chain without implementing the delegation.
+type CheckResolverInterceptor struct {
+	intercept CheckResolverInterceptorFunc
+	delegate  CheckResolver
+}
+
+// NewCheckResolverInterceptor constructs a CheckResolverInterceptor that runs the function.
+func NewCheckResolverInterceptor(intercept CheckResolverInterceptorFunc) *CheckResolverInterceptor {
+	return &CheckResolverInterceptor{intercept: intercept}
+}
+
+// ResolveCheck implements CheckResolver.
+func (c *CheckResolverInterceptor) ResolveCheck(
+	ctx context.Context,
+	req *ResolveCheckRequest,
+) (*ResolveCheckResponse, error) {
+	return c.intercept(ctx, req, c.delegate)
+}
+
+// SetDelegate sets this CheckResolverInterceptor's dispatch delegate.
+func (c *CheckResolverInterceptor) SetDelegate(delegate CheckResolver) {
+	c.delegate = delegate
+}
+
+// GetDelegate returns this CheckResolverInterceptor's dispatch delegate.
+func (c *CheckResolverInterceptor) GetDelegate() CheckResolver {
+	return c.delegate
+}
+
+// Close implements CheckResolver.
+func (*CheckResolverInterceptor) Close() {}
@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestNewResolverChain(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("links_the_resolvers_in_order", func(t *testing.T) {
+		cycleDetectionCheckResolver := NewCycleDetectionCheckResolver()
+		interceptor := NewCheckResolverInterceptor(func(ctx context.Context, req *ResolveCheckRequest, next CheckResolver) (*ResolveCheckResponse, error) {
+			return next.ResolveCheck(ctx, req)
+		})
+		localChecker := NewLocalChecker()
+
+		checkResolver, closer, err := NewResolverChain(cycleDetectionCheckResolver, interceptor, localChecker)
+		require.NoError(t, err)
+		t.Cleanup(closer)
+
+		require.Equal(t, cycleDetectionCheckResolver, checkResolver)
+		require.Equal(t, interceptor, cycleDetectionCheckResolver.GetDelegate())
+		require.Equal(t, localChecker, interceptor.GetDelegate())
+		require.Equal(t, cycleDetectionCheckResolver, localChecker.GetDelegate())
+	})
+
+	t.Run("interceptor_can_resolve_or_pass_on", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		mockCheckResolver := NewMockCheckResolver(ctrl)
+		mockCheckResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).Return(&ResolveCheckResponse{
+			Allowed: true,
+		}, nil).Times(1)
+
+		denied := tuple.NewTupleKey("document:denied", "viewer", "user:anne")
+		var intercepted int
+		interceptor := NewCheckResolverInterceptor(func(ctx context.Context, req *ResolveCheckRequest, next CheckResolver) (*ResolveCheckResponse, error) {
+			intercepted++
+			if req.GetTupleKey().GetObject() == denied.GetObject() {
+				return &ResolveCheckResponse{Allowed: false}, nil
+			}
+			return next.ResolveCheck(ctx, req)
+		})
+		interceptor.SetDelegate(mockCheckResolver)
+
+		resp, err := interceptor.ResolveCheck(context.Background(), &ResolveCheckRequest{
+			StoreID:         ulid.Make().String(),
+			TupleKey:        denied,
+			RequestMetadata: NewCheckRequestMetadata(defaultResolveNodeLimit),
+		})
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+
+		resp, err = interceptor.ResolveCheck(context.Background(), &ResolveCheckRequest{
+			StoreID:         ulid.Make().String(),
+			TupleKey:        tuple.NewTupleKey("document:allowed", "viewer", "user:anne"),
+			RequestMetadata: NewCheckRequestMetadata(defaultResolveNodeLimit),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+		require.Equal(t, 2, intercepted)
+	})
+
+	t.Run("closer_closes_all_resolvers", func(t *testing.T) {
+		_, closer, err := NewResolverChain(
+			NewCycleDetectionCheckResolver(),
+			NewDispatchThrottlingCheckResolver(DispatchThrottlingCheckResolverConfig{
+				Frequency:        1 * time.Hour,
+				DefaultThreshold: 50,
+			}),
+			NewCachedCheckResolver(),
+			NewLocalChecker(),
+		)
+		require.NoError(t, err)
+
+		// goleak verifies that the goroutines of the throttling and cached resolvers are stopped.
+		closer()
+	})
+
+	t.Run("needs_a_resolver", func(t *testing.T) {
+		_, _, err := NewResolverChain()
+		require.Error(t, err)
+	})
+}
@@ -4,17 +4,23 @@ import (
 	"context"
 
 	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"go.uber.org/zap"
 
 	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/pkg/logger"
 )
 
-func AuthFunc(authenticator authn.Authenticator) grpcauth.AuthFunc {
+// AuthFunc returns the function that authenticates the requests with authenticator. The outcome of
+// every authentication is logged at the debug level.
+func AuthFunc(authenticator authn.Authenticator, logger logger.Logger) grpcauth.AuthFunc {
 	return func(ctx context.Context) (context.Context, error) {
 		claims, err := authenticator.Authenticate(ctx)
 		if err != nil {
+			logger.DebugWithContext(ctx, "authentication failed", zap.Error(err))
 			return nil, err
 		}
 
+		logger.DebugWithContext(ctx, "authenticated", zap.String("subject", claims.Subject))
 		return authn.ContextWithAuthClaims(ctx, claims), nil
 	}
 }
@@ -0,0 +1,122 @@
+// Package migrations contains the expand/contract phases of the database migrations, which the
+// migrate command applies and the readiness check of the server reports.
+package migrations
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+const (
+	// PhaseExpand applies only the additive migrations, which are safe to apply while servers that
+	// require an older schema revision are still running.
+	PhaseExpand = "expand"
+
+	// PhaseContract applies all the migrations, including the destructive ones. It must only be run
+	// once no servers that require an older schema revision are running.
+	PhaseContract = "contract"
+
+	// contractAnnotation marks a migration as destructive (e.g. it drops or renames a column that
+	// older servers still read). Such migrations are only applied in the contract phase.
+	contractAnnotation = "-- +openfga contract"
+)
+
+// Migration is a migration embedded in the binary.
+type Migration struct {
+	Version  int64
+	Contract bool
+}
+
+// Collect returns the migrations in dir, in the order that they are applied.
+func Collect(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		version, err := goose.NumericComponent(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		contract, err := isContractMigration(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{Version: version, Contract: contract})
+	}
+
+	return migrations, nil
+}
+
+func isContractMigration(fsys fs.FS, name string) (bool, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == contractAnnotation {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// Plan returns the version to migrate to from the current version in the given phase. A target of 0
+// means the latest version. In the expand phase, downgrades are rejected and upgrades stop right
+// before the first contract migration.
+func Plan(migrations []Migration, current, target int64, phase string) (int64, error) {
+	if target == 0 {
+		for _, m := range migrations {
+			target = max(target, m.Version)
+		}
+	}
+
+	if phase != PhaseExpand {
+		return target, nil
+	}
+
+	if target < current {
+		return 0, fmt.Errorf("migrating down to %d drops schema that running servers may use, it is only allowed with '--phase=%s'", target, PhaseContract)
+	}
+
+	for _, m := range migrations {
+		if m.Contract && m.Version > current && m.Version <= target {
+			return m.Version - 1, nil
+		}
+	}
+
+	return target, nil
+}
+
+// PendingPhase returns the phase that the pending migrations of a schema at the current version are
+// applied in: PhaseExpand while there are additive migrations before the next contract migration,
+// PhaseContract if only the contract migrations and the ones after them are left, and an empty
+// string if the schema is up to date (or newer than the migrations).
+func PendingPhase(migrations []Migration, current int64) string {
+	if expand, err := Plan(migrations, current, 0, PhaseExpand); err == nil && expand > current {
+		return PhaseExpand
+	}
+
+	if latest, _ := Plan(migrations, current, 0, PhaseContract); latest > current {
+		return PhaseContract
+	}
+
+	return ""
+}
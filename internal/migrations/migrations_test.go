@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/assets"
+)
+
+func TestCollect(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/001_initialize_schema.sql": {Data: []byte("-- +goose Up\nCREATE TABLE tuple (store TEXT);\n")},
+		"migrations/002_drop_column.sql":       {Data: []byte("-- +goose Up\n-- +openfga contract\nALTER TABLE tuple DROP COLUMN store;\n")},
+		"migrations/README.md":                 {Data: []byte("-- +openfga contract")},
+	}
+
+	migrations, err := Collect(fsys, "migrations")
+	require.NoError(t, err)
+	require.Equal(t, []Migration{{Version: 1}, {Version: 2, Contract: true}}, migrations)
+
+	for _, dir := range []string{assets.PostgresMigrationDir, assets.MySQLMigrationDir} {
+		migrations, err := Collect(assets.EmbedMigrations, dir)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3, Contract: true}, {Version: 4}}
+
+	tests := map[string]struct {
+		current, target int64
+		phase           string
+		expected        int64
+		expectedErr     string
+	}{
+		"all_to_latest":                {current: 0, target: 0, expected: 4},
+		"contract_to_latest":           {current: 2, target: 0, phase: PhaseContract, expected: 4},
+		"expand_stops_before_contract": {current: 0, target: 0, phase: PhaseExpand, expected: 2},
+		"expand_to_target":             {current: 0, target: 2, phase: PhaseExpand, expected: 2},
+		"expand_after_contract":        {current: 3, target: 0, phase: PhaseExpand, expected: 4},
+		"expand_rejects_downgrade":     {current: 4, target: 1, phase: PhaseExpand, expectedErr: "only allowed with '--phase=contract'"},
+		"contract_allows_downgrade":    {current: 4, target: 1, phase: PhaseContract, expected: 1},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			version, err := Plan(migrations, test.current, test.target, test.phase)
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, version)
+		})
+	}
+}
+
+func TestPendingPhase(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3, Contract: true}, {Version: 4}}
+
+	require.Equal(t, PhaseExpand, PendingPhase(migrations, 0))
+	require.Equal(t, PhaseExpand, PendingPhase(migrations, 1))
+	require.Equal(t, PhaseContract, PendingPhase(migrations, 2))
+	require.Equal(t, PhaseExpand, PendingPhase(migrations, 3))
+	require.Empty(t, PendingPhase(migrations, 4))
+	require.Empty(t, PendingPhase(migrations, 5))
+	require.Empty(t, PendingPhase(nil, 0))
+}
@@ -0,0 +1,62 @@
+package mocks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	otlpcollector "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+)
+
+type mockMetricsServer struct {
+	otlpcollector.UnimplementedMetricsServiceServer
+	metricNames map[string]struct{}
+	serviceMu   sync.Mutex
+	server      *grpc.Server
+}
+
+var _ otlpcollector.MetricsServiceServer = (*mockMetricsServer)(nil)
+
+func (s *mockMetricsServer) Export(_ context.Context, req *otlpcollector.ExportMetricsServiceRequest) (*otlpcollector.ExportMetricsServiceResponse, error) {
+	s.serviceMu.Lock()
+	defer s.serviceMu.Unlock()
+	for _, resourceMetrics := range req.GetResourceMetrics() {
+		for _, scopeMetrics := range resourceMetrics.GetScopeMetrics() {
+			for _, metric := range scopeMetrics.GetMetrics() {
+				s.metricNames[metric.GetName()] = struct{}{}
+			}
+		}
+	}
+	return &otlpcollector.ExportMetricsServiceResponse{}, nil
+}
+
+func NewMockMetricsServer(t testing.TB, port int) *mockMetricsServer {
+	mockServer := &mockMetricsServer{metricNames: map[string]struct{}{}, server: grpc.NewServer()}
+	otlpcollector.RegisterMetricsServiceServer(mockServer.server, mockServer)
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	require.NoError(t, err)
+	t.Cleanup(mockServer.server.Stop)
+
+	go func() {
+		if err := mockServer.server.Serve(listener); err != nil {
+			log.Fatalf("failed to serve: %v", err)
+		}
+		log.Println("server closed")
+	}()
+
+	return mockServer
+}
+
+// HasMetric reports whether a metric with the given name has been exported.
+func (s *mockMetricsServer) HasMetric(name string) bool {
+	s.serviceMu.Lock()
+	defer s.serviceMu.Unlock()
+	_, ok := s.metricNames[name]
+	return ok
+}
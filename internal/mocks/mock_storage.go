@@ -581,9 +581,9 @@ func (mr *MockStoresBackendMockRecorder) GetStore(ctx, id any) *gomock.Call {
 }
 
 // ListStores mocks base method.
-func (m *MockStoresBackend) ListStores(ctx context.Context, paginationOptions storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
+func (m *MockStoresBackend) ListStores(ctx context.Context, filter storage.ListStoresFilter, paginationOptions storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListStores", ctx, paginationOptions)
+	ret := m.ctrl.Call(m, "ListStores", ctx, filter, paginationOptions)
 	ret0, _ := ret[0].([]*openfgav1.Store)
 	ret1, _ := ret[1].([]byte)
 	ret2, _ := ret[2].(error)
@@ -591,9 +591,53 @@ func (m *MockStoresBackend) ListStores(ctx context.Context, paginationOptions st
 }
 
 // ListStores indicates an expected call of ListStores.
-func (mr *MockStoresBackendMockRecorder) ListStores(ctx, paginationOptions any) *gomock.Call {
+func (mr *MockStoresBackendMockRecorder) ListStores(ctx, filter, paginationOptions any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStores", reflect.TypeOf((*MockStoresBackend)(nil).ListStores), ctx, paginationOptions)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStores", reflect.TypeOf((*MockStoresBackend)(nil).ListStores), ctx, filter, paginationOptions)
+}
+
+// ReadStoreLabels mocks base method.
+func (m *MockStoresBackend) ReadStoreLabels(ctx context.Context, stores []string) (map[string]map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadStoreLabels", ctx, stores)
+	ret0, _ := ret[0].(map[string]map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadStoreLabels indicates an expected call of ReadStoreLabels.
+func (mr *MockStoresBackendMockRecorder) ReadStoreLabels(ctx, stores any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStoreLabels", reflect.TypeOf((*MockStoresBackend)(nil).ReadStoreLabels), ctx, stores)
+}
+
+// UpdateStore mocks base method.
+func (m *MockStoresBackend) UpdateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStore", ctx, store)
+	ret0, _ := ret[0].(*openfgav1.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStore indicates an expected call of UpdateStore.
+func (mr *MockStoresBackendMockRecorder) UpdateStore(ctx, store any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStore", reflect.TypeOf((*MockStoresBackend)(nil).UpdateStore), ctx, store)
+}
+
+// WriteStoreLabels mocks base method.
+func (m *MockStoresBackend) WriteStoreLabels(ctx context.Context, store string, labels map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteStoreLabels", ctx, store, labels)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteStoreLabels indicates an expected call of WriteStoreLabels.
+func (mr *MockStoresBackendMockRecorder) WriteStoreLabels(ctx, store, labels any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteStoreLabels", reflect.TypeOf((*MockStoresBackend)(nil).WriteStoreLabels), ctx, store, labels)
 }
 
 // MockAssertionsBackend is a mock of AssertionsBackend interface.
@@ -648,6 +692,251 @@ func (mr *MockAssertionsBackendMockRecorder) WriteAssertions(ctx, store, modelID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteAssertions", reflect.TypeOf((*MockAssertionsBackend)(nil).WriteAssertions), ctx, store, modelID, assertions)
 }
 
+// MockStoreSettingsBackend is a mock of StoreSettingsBackend interface.
+type MockStoreSettingsBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreSettingsBackendMockRecorder
+}
+
+// MockStoreSettingsBackendMockRecorder is the mock recorder for MockStoreSettingsBackend.
+type MockStoreSettingsBackendMockRecorder struct {
+	mock *MockStoreSettingsBackend
+}
+
+// NewMockStoreSettingsBackend creates a new mock instance.
+func NewMockStoreSettingsBackend(ctrl *gomock.Controller) *MockStoreSettingsBackend {
+	mock := &MockStoreSettingsBackend{ctrl: ctrl}
+	mock.recorder = &MockStoreSettingsBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStoreSettingsBackend) EXPECT() *MockStoreSettingsBackendMockRecorder {
+	return m.recorder
+}
+
+// ReadStoreSettings mocks base method.
+func (m *MockStoreSettingsBackend) ReadStoreSettings(ctx context.Context, store string) (*storage.StoreSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadStoreSettings", ctx, store)
+	ret0, _ := ret[0].(*storage.StoreSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadStoreSettings indicates an expected call of ReadStoreSettings.
+func (mr *MockStoreSettingsBackendMockRecorder) ReadStoreSettings(ctx, store any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStoreSettings", reflect.TypeOf((*MockStoreSettingsBackend)(nil).ReadStoreSettings), ctx, store)
+}
+
+// WriteStoreSettings mocks base method.
+func (m *MockStoreSettingsBackend) WriteStoreSettings(ctx context.Context, store string, settings *storage.StoreSettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteStoreSettings", ctx, store, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteStoreSettings indicates an expected call of WriteStoreSettings.
+func (mr *MockStoreSettingsBackendMockRecorder) WriteStoreSettings(ctx, store, settings any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteStoreSettings", reflect.TypeOf((*MockStoreSettingsBackend)(nil).WriteStoreSettings), ctx, store, settings)
+}
+
+// MockPlannerStatisticsBackend is a mock of PlannerStatisticsBackend interface.
+type MockPlannerStatisticsBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockPlannerStatisticsBackendMockRecorder
+}
+
+// MockPlannerStatisticsBackendMockRecorder is the mock recorder for MockPlannerStatisticsBackend.
+type MockPlannerStatisticsBackendMockRecorder struct {
+	mock *MockPlannerStatisticsBackend
+}
+
+// NewMockPlannerStatisticsBackend creates a new mock instance.
+func NewMockPlannerStatisticsBackend(ctrl *gomock.Controller) *MockPlannerStatisticsBackend {
+	mock := &MockPlannerStatisticsBackend{ctrl: ctrl}
+	mock.recorder = &MockPlannerStatisticsBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPlannerStatisticsBackend) EXPECT() *MockPlannerStatisticsBackendMockRecorder {
+	return m.recorder
+}
+
+// ReadPlannerStatistics mocks base method.
+func (m *MockPlannerStatisticsBackend) ReadPlannerStatistics(ctx context.Context, store string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadPlannerStatistics", ctx, store)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadPlannerStatistics indicates an expected call of ReadPlannerStatistics.
+func (mr *MockPlannerStatisticsBackendMockRecorder) ReadPlannerStatistics(ctx, store any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadPlannerStatistics", reflect.TypeOf((*MockPlannerStatisticsBackend)(nil).ReadPlannerStatistics), ctx, store)
+}
+
+// WritePlannerStatistics mocks base method.
+func (m *MockPlannerStatisticsBackend) WritePlannerStatistics(ctx context.Context, store string, statistics []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WritePlannerStatistics", ctx, store, statistics)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WritePlannerStatistics indicates an expected call of WritePlannerStatistics.
+func (mr *MockPlannerStatisticsBackendMockRecorder) WritePlannerStatistics(ctx, store, statistics any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WritePlannerStatistics", reflect.TypeOf((*MockPlannerStatisticsBackend)(nil).WritePlannerStatistics), ctx, store, statistics)
+}
+
+// MockRelationUsageBackend is a mock of RelationUsageBackend interface.
+type MockRelationUsageBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockRelationUsageBackendMockRecorder
+}
+
+// MockRelationUsageBackendMockRecorder is the mock recorder for MockRelationUsageBackend.
+type MockRelationUsageBackendMockRecorder struct {
+	mock *MockRelationUsageBackend
+}
+
+// NewMockRelationUsageBackend creates a new mock instance.
+func NewMockRelationUsageBackend(ctrl *gomock.Controller) *MockRelationUsageBackend {
+	mock := &MockRelationUsageBackend{ctrl: ctrl}
+	mock.recorder = &MockRelationUsageBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRelationUsageBackend) EXPECT() *MockRelationUsageBackendMockRecorder {
+	return m.recorder
+}
+
+// AddRelationUsage mocks base method.
+func (m *MockRelationUsageBackend) AddRelationUsage(ctx context.Context, store string, usage []storage.RelationUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRelationUsage", ctx, store, usage)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRelationUsage indicates an expected call of AddRelationUsage.
+func (mr *MockRelationUsageBackendMockRecorder) AddRelationUsage(ctx, store, usage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRelationUsage", reflect.TypeOf((*MockRelationUsageBackend)(nil).AddRelationUsage), ctx, store, usage)
+}
+
+// ReadRelationUsage mocks base method.
+func (m *MockRelationUsageBackend) ReadRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadRelationUsage", ctx, store)
+	ret0, _ := ret[0].([]storage.RelationUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadRelationUsage indicates an expected call of ReadRelationUsage.
+func (mr *MockRelationUsageBackendMockRecorder) ReadRelationUsage(ctx, store any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRelationUsage", reflect.TypeOf((*MockRelationUsageBackend)(nil).ReadRelationUsage), ctx, store)
+}
+
+// MockChangelogCheckpointBackend is a mock of ChangelogCheckpointBackend interface.
+type MockChangelogCheckpointBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockChangelogCheckpointBackendMockRecorder
+}
+
+// MockChangelogCheckpointBackendMockRecorder is the mock recorder for MockChangelogCheckpointBackend.
+type MockChangelogCheckpointBackendMockRecorder struct {
+	mock *MockChangelogCheckpointBackend
+}
+
+// NewMockChangelogCheckpointBackend creates a new mock instance.
+func NewMockChangelogCheckpointBackend(ctrl *gomock.Controller) *MockChangelogCheckpointBackend {
+	mock := &MockChangelogCheckpointBackend{ctrl: ctrl}
+	mock.recorder = &MockChangelogCheckpointBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChangelogCheckpointBackend) EXPECT() *MockChangelogCheckpointBackendMockRecorder {
+	return m.recorder
+}
+
+// ReadChangelogCheckpoint mocks base method.
+func (m *MockChangelogCheckpointBackend) ReadChangelogCheckpoint(ctx context.Context, store, consumer string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadChangelogCheckpoint", ctx, store, consumer)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadChangelogCheckpoint indicates an expected call of ReadChangelogCheckpoint.
+func (mr *MockChangelogCheckpointBackendMockRecorder) ReadChangelogCheckpoint(ctx, store, consumer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChangelogCheckpoint", reflect.TypeOf((*MockChangelogCheckpointBackend)(nil).ReadChangelogCheckpoint), ctx, store, consumer)
+}
+
+// WriteChangelogCheckpoint mocks base method.
+func (m *MockChangelogCheckpointBackend) WriteChangelogCheckpoint(ctx context.Context, store, consumer, continuationToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteChangelogCheckpoint", ctx, store, consumer, continuationToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteChangelogCheckpoint indicates an expected call of WriteChangelogCheckpoint.
+func (mr *MockChangelogCheckpointBackendMockRecorder) WriteChangelogCheckpoint(ctx, store, consumer, continuationToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteChangelogCheckpoint", reflect.TypeOf((*MockChangelogCheckpointBackend)(nil).WriteChangelogCheckpoint), ctx, store, consumer, continuationToken)
+}
+
+// MockMultiStoreWriteBackend is a mock of MultiStoreWriteBackend interface.
+type MockMultiStoreWriteBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockMultiStoreWriteBackendMockRecorder
+}
+
+// MockMultiStoreWriteBackendMockRecorder is the mock recorder for MockMultiStoreWriteBackend.
+type MockMultiStoreWriteBackendMockRecorder struct {
+	mock *MockMultiStoreWriteBackend
+}
+
+// NewMockMultiStoreWriteBackend creates a new mock instance.
+func NewMockMultiStoreWriteBackend(ctrl *gomock.Controller) *MockMultiStoreWriteBackend {
+	mock := &MockMultiStoreWriteBackend{ctrl: ctrl}
+	mock.recorder = &MockMultiStoreWriteBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMultiStoreWriteBackend) EXPECT() *MockMultiStoreWriteBackendMockRecorder {
+	return m.recorder
+}
+
+// WriteStores mocks base method.
+func (m *MockMultiStoreWriteBackend) WriteStores(ctx context.Context, writes []storage.StoreWrite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteStores", ctx, writes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteStores indicates an expected call of WriteStores.
+func (mr *MockMultiStoreWriteBackendMockRecorder) WriteStores(ctx, writes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteStores", reflect.TypeOf((*MockMultiStoreWriteBackend)(nil).WriteStores), ctx, writes)
+}
+
 // MockChangelogBackend is a mock of ChangelogBackend interface.
 type MockChangelogBackend struct {
 	ctrl     *gomock.Controller
@@ -671,10 +960,25 @@ func (m *MockChangelogBackend) EXPECT() *MockChangelogBackendMockRecorder {
 	return m.recorder
 }
 
+// ChangeExists mocks base method.
+func (m *MockChangelogBackend) ChangeExists(ctx context.Context, store, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeExists", ctx, store, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeExists indicates an expected call of ChangeExists.
+func (mr *MockChangelogBackendMockRecorder) ChangeExists(ctx, store, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeExists", reflect.TypeOf((*MockChangelogBackend)(nil).ChangeExists), ctx, store, id)
+}
+
 // ReadChanges mocks base method.
-func (m *MockChangelogBackend) ReadChanges(ctx context.Context, store, objectType string, paginationOptions storage.PaginationOptions, horizonOffset time.Duration) ([]*openfgav1.TupleChange, []byte, error) {
+func (m *MockChangelogBackend) ReadChanges(ctx context.Context, store string, filter storage.ReadChangesFilter, paginationOptions storage.PaginationOptions, horizonOffset time.Duration) ([]*openfgav1.TupleChange, []byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadChanges", ctx, store, objectType, paginationOptions, horizonOffset)
+	ret := m.ctrl.Call(m, "ReadChanges", ctx, store, filter, paginationOptions, horizonOffset)
 	ret0, _ := ret[0].([]*openfgav1.TupleChange)
 	ret1, _ := ret[1].([]byte)
 	ret2, _ := ret[2].(error)
@@ -682,9 +986,62 @@ func (m *MockChangelogBackend) ReadChanges(ctx context.Context, store, objectTyp
 }
 
 // ReadChanges indicates an expected call of ReadChanges.
-func (mr *MockChangelogBackendMockRecorder) ReadChanges(ctx, store, objectType, paginationOptions, horizonOffset any) *gomock.Call {
+func (mr *MockChangelogBackendMockRecorder) ReadChanges(ctx, store, filter, paginationOptions, horizonOffset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChanges", reflect.TypeOf((*MockChangelogBackend)(nil).ReadChanges), ctx, store, filter, paginationOptions, horizonOffset)
+}
+
+// MockTupleWritersBackend is a mock of TupleWritersBackend interface.
+type MockTupleWritersBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockTupleWritersBackendMockRecorder
+}
+
+// MockTupleWritersBackendMockRecorder is the mock recorder for MockTupleWritersBackend.
+type MockTupleWritersBackendMockRecorder struct {
+	mock *MockTupleWritersBackend
+}
+
+// NewMockTupleWritersBackend creates a new mock instance.
+func NewMockTupleWritersBackend(ctrl *gomock.Controller) *MockTupleWritersBackend {
+	mock := &MockTupleWritersBackend{ctrl: ctrl}
+	mock.recorder = &MockTupleWritersBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTupleWritersBackend) EXPECT() *MockTupleWritersBackendMockRecorder {
+	return m.recorder
+}
+
+// ReadChangeWriters mocks base method.
+func (m *MockTupleWritersBackend) ReadChangeWriters(ctx context.Context, store string, changes []*openfgav1.TupleChange) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadChangeWriters", ctx, store, changes)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadChangeWriters indicates an expected call of ReadChangeWriters.
+func (mr *MockTupleWritersBackendMockRecorder) ReadChangeWriters(ctx, store, changes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChangeWriters", reflect.TypeOf((*MockTupleWritersBackend)(nil).ReadChangeWriters), ctx, store, changes)
+}
+
+// ReadTupleWriters mocks base method.
+func (m *MockTupleWritersBackend) ReadTupleWriters(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadTupleWriters", ctx, store, tupleKeys)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadTupleWriters indicates an expected call of ReadTupleWriters.
+func (mr *MockTupleWritersBackendMockRecorder) ReadTupleWriters(ctx, store, tupleKeys any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChanges", reflect.TypeOf((*MockChangelogBackend)(nil).ReadChanges), ctx, store, objectType, paginationOptions, horizonOffset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadTupleWriters", reflect.TypeOf((*MockTupleWritersBackend)(nil).ReadTupleWriters), ctx, store, tupleKeys)
 }
 
 // MockOpenFGADatastore is a mock of OpenFGADatastore interface.
@@ -710,6 +1067,35 @@ func (m *MockOpenFGADatastore) EXPECT() *MockOpenFGADatastoreMockRecorder {
 	return m.recorder
 }
 
+// AddRelationUsage mocks base method.
+func (m *MockOpenFGADatastore) AddRelationUsage(ctx context.Context, store string, usage []storage.RelationUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRelationUsage", ctx, store, usage)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRelationUsage indicates an expected call of AddRelationUsage.
+func (mr *MockOpenFGADatastoreMockRecorder) AddRelationUsage(ctx, store, usage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRelationUsage", reflect.TypeOf((*MockOpenFGADatastore)(nil).AddRelationUsage), ctx, store, usage)
+}
+
+// ChangeExists mocks base method.
+func (m *MockOpenFGADatastore) ChangeExists(ctx context.Context, store, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeExists", ctx, store, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeExists indicates an expected call of ChangeExists.
+func (mr *MockOpenFGADatastoreMockRecorder) ChangeExists(ctx, store, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeExists", reflect.TypeOf((*MockOpenFGADatastore)(nil).ChangeExists), ctx, store, id)
+}
+
 // Close mocks base method.
 func (m *MockOpenFGADatastore) Close() {
 	m.ctrl.T.Helper()
@@ -797,9 +1183,9 @@ func (mr *MockOpenFGADatastoreMockRecorder) IsReady(ctx any) *gomock.Call {
 }
 
 // ListStores mocks base method.
-func (m *MockOpenFGADatastore) ListStores(ctx context.Context, paginationOptions storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
+func (m *MockOpenFGADatastore) ListStores(ctx context.Context, filter storage.ListStoresFilter, paginationOptions storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListStores", ctx, paginationOptions)
+	ret := m.ctrl.Call(m, "ListStores", ctx, filter, paginationOptions)
 	ret0, _ := ret[0].([]*openfgav1.Store)
 	ret1, _ := ret[1].([]byte)
 	ret2, _ := ret[2].(error)
@@ -807,9 +1193,9 @@ func (m *MockOpenFGADatastore) ListStores(ctx context.Context, paginationOptions
 }
 
 // ListStores indicates an expected call of ListStores.
-func (mr *MockOpenFGADatastoreMockRecorder) ListStores(ctx, paginationOptions any) *gomock.Call {
+func (mr *MockOpenFGADatastoreMockRecorder) ListStores(ctx, filter, paginationOptions any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStores", reflect.TypeOf((*MockOpenFGADatastore)(nil).ListStores), ctx, paginationOptions)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStores", reflect.TypeOf((*MockOpenFGADatastore)(nil).ListStores), ctx, filter, paginationOptions)
 }
 
 // MaxTuplesPerWrite mocks base method.
@@ -901,10 +1287,40 @@ func (mr *MockOpenFGADatastoreMockRecorder) ReadAuthorizationModels(ctx, store,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAuthorizationModels", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadAuthorizationModels), ctx, store, options)
 }
 
+// ReadChangeWriters mocks base method.
+func (m *MockOpenFGADatastore) ReadChangeWriters(ctx context.Context, store string, changes []*openfgav1.TupleChange) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadChangeWriters", ctx, store, changes)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadChangeWriters indicates an expected call of ReadChangeWriters.
+func (mr *MockOpenFGADatastoreMockRecorder) ReadChangeWriters(ctx, store, changes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChangeWriters", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadChangeWriters), ctx, store, changes)
+}
+
+// ReadChangelogCheckpoint mocks base method.
+func (m *MockOpenFGADatastore) ReadChangelogCheckpoint(ctx context.Context, store, consumer string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadChangelogCheckpoint", ctx, store, consumer)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadChangelogCheckpoint indicates an expected call of ReadChangelogCheckpoint.
+func (mr *MockOpenFGADatastoreMockRecorder) ReadChangelogCheckpoint(ctx, store, consumer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChangelogCheckpoint", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadChangelogCheckpoint), ctx, store, consumer)
+}
+
 // ReadChanges mocks base method.
-func (m *MockOpenFGADatastore) ReadChanges(ctx context.Context, store, objectType string, paginationOptions storage.PaginationOptions, horizonOffset time.Duration) ([]*openfgav1.TupleChange, []byte, error) {
+func (m *MockOpenFGADatastore) ReadChanges(ctx context.Context, store string, filter storage.ReadChangesFilter, paginationOptions storage.PaginationOptions, horizonOffset time.Duration) ([]*openfgav1.TupleChange, []byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadChanges", ctx, store, objectType, paginationOptions, horizonOffset)
+	ret := m.ctrl.Call(m, "ReadChanges", ctx, store, filter, paginationOptions, horizonOffset)
 	ret0, _ := ret[0].([]*openfgav1.TupleChange)
 	ret1, _ := ret[1].([]byte)
 	ret2, _ := ret[2].(error)
@@ -912,9 +1328,9 @@ func (m *MockOpenFGADatastore) ReadChanges(ctx context.Context, store, objectTyp
 }
 
 // ReadChanges indicates an expected call of ReadChanges.
-func (mr *MockOpenFGADatastoreMockRecorder) ReadChanges(ctx, store, objectType, paginationOptions, horizonOffset any) *gomock.Call {
+func (mr *MockOpenFGADatastoreMockRecorder) ReadChanges(ctx, store, filter, paginationOptions, horizonOffset any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChanges", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadChanges), ctx, store, objectType, paginationOptions, horizonOffset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChanges", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadChanges), ctx, store, filter, paginationOptions, horizonOffset)
 }
 
 // ReadPage mocks base method.
@@ -933,6 +1349,36 @@ func (mr *MockOpenFGADatastoreMockRecorder) ReadPage(ctx, store, tupleKey, pagin
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadPage", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadPage), ctx, store, tupleKey, paginationOptions)
 }
 
+// ReadPlannerStatistics mocks base method.
+func (m *MockOpenFGADatastore) ReadPlannerStatistics(ctx context.Context, store string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadPlannerStatistics", ctx, store)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadPlannerStatistics indicates an expected call of ReadPlannerStatistics.
+func (mr *MockOpenFGADatastoreMockRecorder) ReadPlannerStatistics(ctx, store any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadPlannerStatistics", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadPlannerStatistics), ctx, store)
+}
+
+// ReadRelationUsage mocks base method.
+func (m *MockOpenFGADatastore) ReadRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadRelationUsage", ctx, store)
+	ret0, _ := ret[0].([]storage.RelationUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadRelationUsage indicates an expected call of ReadRelationUsage.
+func (mr *MockOpenFGADatastoreMockRecorder) ReadRelationUsage(ctx, store any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRelationUsage", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadRelationUsage), ctx, store)
+}
+
 // ReadStartingWithUser mocks base method.
 func (m *MockOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
 	m.ctrl.T.Helper()
@@ -948,6 +1394,51 @@ func (mr *MockOpenFGADatastoreMockRecorder) ReadStartingWithUser(ctx, store, fil
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStartingWithUser", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadStartingWithUser), ctx, store, filter)
 }
 
+// ReadStoreLabels mocks base method.
+func (m *MockOpenFGADatastore) ReadStoreLabels(ctx context.Context, stores []string) (map[string]map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadStoreLabels", ctx, stores)
+	ret0, _ := ret[0].(map[string]map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadStoreLabels indicates an expected call of ReadStoreLabels.
+func (mr *MockOpenFGADatastoreMockRecorder) ReadStoreLabels(ctx, stores any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStoreLabels", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadStoreLabels), ctx, stores)
+}
+
+// ReadStoreSettings mocks base method.
+func (m *MockOpenFGADatastore) ReadStoreSettings(ctx context.Context, store string) (*storage.StoreSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadStoreSettings", ctx, store)
+	ret0, _ := ret[0].(*storage.StoreSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadStoreSettings indicates an expected call of ReadStoreSettings.
+func (mr *MockOpenFGADatastoreMockRecorder) ReadStoreSettings(ctx, store any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStoreSettings", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadStoreSettings), ctx, store)
+}
+
+// ReadTupleWriters mocks base method.
+func (m *MockOpenFGADatastore) ReadTupleWriters(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadTupleWriters", ctx, store, tupleKeys)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadTupleWriters indicates an expected call of ReadTupleWriters.
+func (mr *MockOpenFGADatastoreMockRecorder) ReadTupleWriters(ctx, store, tupleKeys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadTupleWriters", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadTupleWriters), ctx, store, tupleKeys)
+}
+
 // ReadUserTuple mocks base method.
 func (m *MockOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
 	m.ctrl.T.Helper()
@@ -978,6 +1469,21 @@ func (mr *MockOpenFGADatastoreMockRecorder) ReadUsersetTuples(ctx, store, filter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUsersetTuples", reflect.TypeOf((*MockOpenFGADatastore)(nil).ReadUsersetTuples), ctx, store, filter)
 }
 
+// UpdateStore mocks base method.
+func (m *MockOpenFGADatastore) UpdateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStore", ctx, store)
+	ret0, _ := ret[0].(*openfgav1.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStore indicates an expected call of UpdateStore.
+func (mr *MockOpenFGADatastoreMockRecorder) UpdateStore(ctx, store any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStore", reflect.TypeOf((*MockOpenFGADatastore)(nil).UpdateStore), ctx, store)
+}
+
 // Write mocks base method.
 func (m *MockOpenFGADatastore) Write(ctx context.Context, store string, d storage.Deletes, w storage.Writes) error {
 	m.ctrl.T.Helper()
@@ -1019,3 +1525,73 @@ func (mr *MockOpenFGADatastoreMockRecorder) WriteAuthorizationModel(ctx, store,
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteAuthorizationModel", reflect.TypeOf((*MockOpenFGADatastore)(nil).WriteAuthorizationModel), ctx, store, model)
 }
+
+// WriteChangelogCheckpoint mocks base method.
+func (m *MockOpenFGADatastore) WriteChangelogCheckpoint(ctx context.Context, store, consumer, continuationToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteChangelogCheckpoint", ctx, store, consumer, continuationToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteChangelogCheckpoint indicates an expected call of WriteChangelogCheckpoint.
+func (mr *MockOpenFGADatastoreMockRecorder) WriteChangelogCheckpoint(ctx, store, consumer, continuationToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteChangelogCheckpoint", reflect.TypeOf((*MockOpenFGADatastore)(nil).WriteChangelogCheckpoint), ctx, store, consumer, continuationToken)
+}
+
+// WritePlannerStatistics mocks base method.
+func (m *MockOpenFGADatastore) WritePlannerStatistics(ctx context.Context, store string, statistics []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WritePlannerStatistics", ctx, store, statistics)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WritePlannerStatistics indicates an expected call of WritePlannerStatistics.
+func (mr *MockOpenFGADatastoreMockRecorder) WritePlannerStatistics(ctx, store, statistics any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WritePlannerStatistics", reflect.TypeOf((*MockOpenFGADatastore)(nil).WritePlannerStatistics), ctx, store, statistics)
+}
+
+// WriteStoreLabels mocks base method.
+func (m *MockOpenFGADatastore) WriteStoreLabels(ctx context.Context, store string, labels map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteStoreLabels", ctx, store, labels)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteStoreLabels indicates an expected call of WriteStoreLabels.
+func (mr *MockOpenFGADatastoreMockRecorder) WriteStoreLabels(ctx, store, labels any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteStoreLabels", reflect.TypeOf((*MockOpenFGADatastore)(nil).WriteStoreLabels), ctx, store, labels)
+}
+
+// WriteStoreSettings mocks base method.
+func (m *MockOpenFGADatastore) WriteStoreSettings(ctx context.Context, store string, settings *storage.StoreSettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteStoreSettings", ctx, store, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteStoreSettings indicates an expected call of WriteStoreSettings.
+func (mr *MockOpenFGADatastoreMockRecorder) WriteStoreSettings(ctx, store, settings any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteStoreSettings", reflect.TypeOf((*MockOpenFGADatastore)(nil).WriteStoreSettings), ctx, store, settings)
+}
+
+// WriteStores mocks base method.
+func (m *MockOpenFGADatastore) WriteStores(ctx context.Context, writes []storage.StoreWrite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteStores", ctx, writes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteStores indicates an expected call of WriteStores.
+func (mr *MockOpenFGADatastoreMockRecorder) WriteStores(ctx, writes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteStores", reflect.TypeOf((*MockOpenFGADatastore)(nil).WriteStores), ctx, writes)
+}
@@ -6,28 +6,62 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/openfga/openfga/pkg/featureflags"
+	"github.com/openfga/openfga/pkg/logger"
 )
 
 const (
 	DefaultMaxRPCMessageSizeInBytes         = 512 * 1_204 // 512 KB
+	DefaultMaxSendMessageSizeInBytes        = math.MaxInt32
+	DefaultHTTPCompressionMinSizeInBytes    = 1_024
 	DefaultMaxTuplesPerWrite                = 100
 	DefaultMaxTypesPerAuthorizationModel    = 100
 	DefaultMaxAuthorizationModelSizeInBytes = 256 * 1_024
+	DefaultMaxContextualTuples              = 100
+	DefaultMaxContextSizeInBytes            = DefaultWriteContextByteLimit
+
+	DefaultMaxRelationsPerTypeDefinition     = 0 // 0 means no limit
+	DefaultMaxAuthorizationModelRewriteDepth = 0 // 0 means no limit
+	DefaultMaxConditionExpressionSizeInBytes = 0 // 0 means no limit
+
+	DefaultConsistencyTokenTimeout          = 1 * time.Second
 	DefaultChangelogHorizonOffset           = 0
 	DefaultResolveNodeLimit                 = 25
 	DefaultResolveNodeBreadthLimit          = 100
 	DefaultListObjectsDeadline              = 3 * time.Second
 	DefaultListObjectsMaxResults            = 1000
+	DefaultListObjectsShards                = 1
+	DefaultListObjectsShardConcurrency      = 0
 	DefaultMaxConcurrentReadsForCheck       = math.MaxUint32
 	DefaultMaxConcurrentReadsForListObjects = math.MaxUint32
+	DefaultCheckUsersetsPageSize            = 100
+
+	// The gRPC connection defaults are the defaults of grpc-go, the connections are never closed.
+	DefaultGRPCMaxConcurrentStreams        = math.MaxUint32
+	DefaultGRPCMaxConnectionAge            = 0
+	DefaultGRPCMaxConnectionAgeGrace       = 0
+	DefaultGRPCKeepaliveTime               = 2 * time.Hour
+	DefaultGRPCKeepaliveTimeout            = 20 * time.Second
+	DefaultGRPCKeepaliveEnforcementMinTime = 5 * time.Minute
 
 	DefaultWriteContextByteLimit = 32 * 1_024 // 32KB
 	DefaultCheckQueryCacheLimit  = 10000
 	DefaultCheckQueryCacheTTL    = 10 * time.Second
 	DefaultCheckQueryCacheEnable = false
 
+	DefaultCheckQueryCacheWatermarkEnabled         = false
+	DefaultCheckQueryCacheWatermarkRefreshInterval = 1 * time.Second
+
+	DefaultListObjectsCacheEnabled                  = false
+	DefaultListObjectsCacheLimit                    = 1000
+	DefaultListObjectsCacheTTL                      = 1 * time.Minute
+	DefaultListObjectsCacheWatermarkRefreshInterval = 1 * time.Second
+
 	// Care should be taken here - decreasing can cause API compatibility problems with Conditions.
 	DefaultMaxConditionEvaluationCost = 100
 	DefaultInterruptCheckFrequency    = 100
@@ -38,6 +72,124 @@ const (
 	DefaultDispatchThrottlingMaxThreshold     = 0 // 0 means use the default threshold as max
 
 	DefaultRequestTimeout = 3 * time.Second
+	DefaultDrainTimeout   = 10 * time.Second
+
+	DefaultMetricsOTLPExportInterval = 30 * time.Second
+
+	DefaultSlowRequestLogEnabled   = false
+	DefaultSlowRequestLogThreshold = 1 * time.Second
+
+	DefaultAdmissionControlEnabled             = false
+	DefaultAdmissionControlMaxInflightRequests = 0 // 0 means no limit
+	DefaultAdmissionControlMaxGoroutines       = 0 // 0 means no limit
+	DefaultAdmissionControlMaxMemoryBytes      = 0 // 0 means no limit
+	DefaultAdmissionControlLowPriorityRatio    = 0.8
+	DefaultAdmissionControlHighDispatchCount   = 50
+	DefaultAdmissionControlRetryAfter          = 1 * time.Second
+
+	DefaultCheckBudgetMaxDispatchCount      = 0 // 0 means no limit
+	DefaultCheckBudgetMaxDatastoreReadCount = 0 // 0 means no limit
+
+	DefaultTypesystemCacheMaxSize = 5000
+
+	DefaultCheckReadDeduplicationEnabled          = true
+	DefaultCheckReadDeduplicationMaxTuplesPerRead = 1000
+
+	DefaultCheckDispatchPoolEnabled = false
+	DefaultCheckDispatchPoolSize    = 1000
+
+	DefaultCheckPlannerEnabled            = false
+	DefaultCheckPlannerStatisticsInterval = 1 * time.Minute
+	DefaultCheckPlannerMaxStores          = 10000
+
+	DefaultTupleStatisticsEnabled           = false
+	DefaultTupleStatisticsInterval          = 10 * time.Minute
+	DefaultTupleStatisticsMaxTuplesPerStore = 100000
+
+	DefaultRelationUsageEnabled       = false
+	DefaultRelationUsageSampleRatio   = 0.1
+	DefaultRelationUsageFlushInterval = 1 * time.Minute
+
+	DefaultIDGenerationScheme                 = "ulid"
+	DefaultIDGenerationCallerSuppliedStoreIDs = false
+
+	DefaultModelEditorEnabled   = false
+	DefaultModelEditorMaxTuples = 1000
+
+	DefaultWriteAdmissionWebhookTimeout       = 1 * time.Second
+	DefaultWriteAdmissionWebhookFailurePolicy = "fail"
+
+	DefaultWriteGroupsSagaEnabled = false
+
+	DefaultDelegatedCheckOperator = "intersection"
+	DefaultDelegatedCheckTimeout  = 1 * time.Second
+
+	DefaultCheckCanaryEnabled                  = false
+	DefaultCheckCanarySampleRatio              = 1.0
+	DefaultCheckCanaryTimeout                  = 3 * time.Second
+	DefaultCheckCanaryMaxConcurrentEvaluations = 100
+
+	DefaultWriteCoalescingEnabled = false
+	DefaultWriteCoalescingWindow  = 5 * time.Millisecond
+
+	DefaultPeerDispatchEnabled            = false
+	DefaultPeerDispatchDNSRefreshInterval = 30 * time.Second
+	DefaultPeerDispatchTimeout            = 1 * time.Second
+
+	// APIMaxTupleObjectLength and APIMaxTupleUserLength are the maximum numbers of bytes of the object
+	// and of the user of a tuple accepted by the API.
+	APIMaxTupleObjectLength = 256
+	APIMaxTupleUserLength   = 512
+
+	DefaultTupleValidationMaxObjectLength = APIMaxTupleObjectLength
+	DefaultTupleValidationMaxUserLength   = APIMaxTupleUserLength
+
+	DefaultTimeTravelEnabled    = false
+	DefaultTimeTravelMaxChanges = 100000
+	DefaultTimeTravelRetention  = 0
+
+	DefaultImportStoreName = "default"
+
+	DefaultBackupEnabled  = false
+	DefaultBackupInterval = 24 * time.Hour
+	DefaultBackupRegion   = "us-east-1"
+
+	DefaultIntegrityCheckerEnabled           = false
+	DefaultIntegrityCheckerInterval          = 24 * time.Hour
+	DefaultIntegrityCheckerCleanup           = "none"
+	DefaultIntegrityCheckerMaxReportedTuples = 100
+
+	DefaultMeteringEnabled      = false
+	DefaultMeteringWindow       = time.Hour
+	DefaultMeteringRetention    = 24
+	DefaultMeteringExportFormat = "json"
+
+	DefaultWarmupEnabled = false
+	DefaultWarmupTimeout = 30 * time.Second
+
+	DefaultChangePublisherEnabled       = false
+	DefaultChangePublisherInterval      = 1 * time.Second
+	DefaultChangePublisherPageSize      = 100
+	DefaultChangePublisherHorizonOffset = 5 * time.Second
+	DefaultChangePublisherConsumer      = "change-publisher"
+	DefaultChangePublisherTopic         = "openfga.changes"
+	DefaultChangePublisherTimeout       = 10 * time.Second
+
+	DefaultDatastoreHedgingEnabled    = false
+	DefaultDatastoreHedgingPercentile = 95
+	DefaultDatastoreHedgingMinDelay   = 5 * time.Millisecond
+
+	DefaultDatastoreCircuitBreakerEnabled          = false
+	DefaultDatastoreCircuitBreakerFailureRatio     = 0.5
+	DefaultDatastoreCircuitBreakerMinRequests      = 20
+	DefaultDatastoreCircuitBreakerWindow           = 10 * time.Second
+	DefaultDatastoreCircuitBreakerLatencyThreshold = 0
+	DefaultDatastoreCircuitBreakerOpenDuration     = 5 * time.Second
+
+	DefaultDatastoreMemorySnapshotInterval = 1 * time.Minute
+	DefaultDatastoreMemoryLoadSnapshot     = true
+
+	DefaultDatastoreResidencyName = "default"
 
 	additionalUpstreamTimeout = 3 * time.Second
 )
@@ -45,6 +197,115 @@ const (
 type DatastoreMetricsConfig struct {
 	// Enabled enables export of the Datastore metrics.
 	Enabled bool
+
+	// StoreLabelLimit is the maximum number of distinct stores that the per-method datastore
+	// metrics are labeled with. Queries of other stores are labeled as "other". If 0, the
+	// metrics are not labeled with the store ID.
+	StoreLabelLimit int
+}
+
+// DatastoreHedgingConfig defines configurations for hedging datastore reads.
+type DatastoreHedgingConfig struct {
+	// Enabled enables sending a second, identical read to the datastore when a read is slower
+	// than the configured percentile of recently observed read latencies.
+	Enabled bool
+
+	// Percentile is the percentile (between 0 and 100) of the observed read latency after which
+	// a hedged read is sent.
+	Percentile float64
+
+	// MinDelay is the minimum amount of time to wait before sending a hedged read.
+	MinDelay time.Duration
+}
+
+// DatastoreCircuitBreakerConfig defines configurations for the circuit breaker of the datastore calls.
+type DatastoreCircuitBreakerConfig struct {
+	// Enabled enables failing datastore calls fast, with an unavailable error, once too many of the
+	// recent datastore calls failed or were slow, instead of letting requests pile up until they time out.
+	Enabled bool
+
+	// FailureRatio is the ratio (between 0 and 1) of the datastore calls of a window that must fail
+	// for the circuit breaker to open.
+	FailureRatio float64
+
+	// MinRequests is the minimum number of datastore calls of a window before the circuit breaker can open.
+	MinRequests int
+
+	// Window is the duration of the windows the datastore calls and their failures are counted in.
+	Window time.Duration
+
+	// LatencyThreshold is the duration after which a datastore call is counted as failed, even if it
+	// succeeds. If 0, only the errors are counted.
+	LatencyThreshold time.Duration
+
+	// OpenDuration is how long the circuit breaker stays open before a single datastore call is let
+	// through to probe the datastore.
+	OpenDuration time.Duration
+}
+
+// DatastoreMemoryConfig defines configurations specific to the memory datastore engine.
+type DatastoreMemoryConfig struct {
+	// SnapshotPath is the path of the file that the contents of the memory datastore are
+	// persisted to. If empty, the memory datastore is ephemeral.
+	SnapshotPath string
+
+	// SnapshotInterval is how often the contents are persisted to the snapshot file, if they
+	// changed. They are also persisted when the server shuts down. If 0, they are only persisted
+	// when the server shuts down.
+	SnapshotInterval time.Duration
+
+	// LoadSnapshot loads the contents of the snapshot file, if it exists, when the server starts.
+	LoadSnapshot bool
+}
+
+// DatastoreResidencyConfig defines the routing of the data of the stores to additional datastores of
+// the same engine, e.g. regional databases, for data residency. The stores themselves and their
+// labels stay in the default datastore.
+type DatastoreResidencyConfig struct {
+	// Name is the name of the default datastore in the routes of the stores, e.g. 'us'.
+	Name string
+
+	// Datastores are the additional datastores, as 'name=uri' pairs, e.g. 'eu=postgres://...'. They
+	// use the other connection settings of the default datastore, but only the connection pool of the
+	// default datastore is reported by the metrics. With the memory engine, the URIs are ignored and
+	// each datastore is ephemeral.
+	Datastores []string
+
+	// Stores are rules of the form 'storeID=name' that route the data of the stores to the named
+	// datastores. They take precedence over LabelKey.
+	Stores []string
+
+	// LabelKey is the key of the store label whose value is the name of the datastore of the store,
+	// e.g. 'region'. The label must name a datastore. The stores without it use the default datastore.
+	// Changing the datastore of a store doesn't move its data.
+	LabelKey string
+}
+
+// DatastorePostgresConfig defines configuration specific to the postgres datastore engine.
+type DatastorePostgresConfig struct {
+	// StatementCacheCapacity is the number of prepared statements cached per connection. If 0,
+	// the driver default is used.
+	StatementCacheCapacity int
+
+	// QueryExecMode is how queries are executed (e.g. 'cache_statement', 'cache_describe',
+	// 'describe_exec', 'exec' or 'simple_protocol'). Use 'exec' or 'simple_protocol' behind
+	// connection poolers that don't support prepared statements. If empty, the driver default
+	// ('cache_statement') is used.
+	QueryExecMode string
+
+	// NotifyTupleChanges notifies the stores of the writes with NOTIFY and listens to the
+	// notifications of all the servers, so that every server invalidates the Check query cache
+	// entries of the stores written by any of them within milliseconds, and the cache TTL can be
+	// longer. It requires a session, so it doesn't work through a connection pooler in transaction
+	// mode.
+	NotifyTupleChanges bool
+}
+
+// DatastoreMySQLConfig defines configuration specific to the mysql datastore engine.
+type DatastoreMySQLConfig struct {
+	// InterpolateParams interpolates query placeholders on the client instead of preparing
+	// each statement on the server, which saves a round trip per query.
+	InterpolateParams bool
 }
 
 // DatastoreConfig defines OpenFGA server configurations for datastore specific settings.
@@ -76,12 +337,94 @@ type DatastoreConfig struct {
 
 	// Metrics is configuration for the Datastore metrics.
 	Metrics DatastoreMetricsConfig
+
+	// Hedging is configuration for hedging datastore reads.
+	Hedging DatastoreHedgingConfig
+
+	// CircuitBreaker is configuration for the circuit breaker of the datastore calls.
+	CircuitBreaker DatastoreCircuitBreakerConfig
+
+	// Memory is configuration specific to the memory datastore engine.
+	Memory DatastoreMemoryConfig
+
+	// Postgres is configuration specific to the postgres datastore engine.
+	Postgres DatastorePostgresConfig
+
+	// MySQL is configuration specific to the mysql datastore engine.
+	MySQL DatastoreMySQLConfig `mapstructure:"mysql"`
+
+	// RequestIDComments prefixes the SQL queries with a comment with the request ID, e.g.
+	// '/* request_id=... */', so that the queries of a request can be found in the database (slow)
+	// logs. Every query text is then unique, so prepared statements can't be reused between requests.
+	RequestIDComments bool
+
+	// SQLCommenter appends a comment in the sqlcommenter format to the SQL queries, with the RPC
+	// service and method, the request ID and the W3C trace context, so that APM tools can link the
+	// database load to the requests. It takes precedence over RequestIDComments.
+	SQLCommenter bool
+
+	// IteratorBatchSize is the number of tuples that the postgres and mysql engines read ahead of the
+	// consumer of a tuple iterator, e.g. Read or Expand on a relation with a huge number of
+	// tuples. Once a batch is read ahead, the reads wait for the consumer, so that the memory of an
+	// iterator is bounded. If 0, the rows are only read when the next tuple is requested.
+	IteratorBatchSize int
+
+	// ConditionContextEncryptionKeys enables the envelope encryption of the condition contexts of
+	// the tuples in the postgres and mysql engines. The first key encrypts the data keys of the
+	// condition contexts, and all of them decrypt them, so that the keys can be rotated by
+	// prepending a new one. Condition contexts stored in plain text stay readable.
+	ConditionContextEncryptionKeys []string
+
+	// Residency is configuration for the routing of the stores to additional datastores.
+	Residency DatastoreResidencyConfig
 }
 
 // GRPCConfig defines OpenFGA server configurations for grpc server specific settings.
 type GRPCConfig struct {
 	Addr string
 	TLS  *TLSConfig
+
+	// ReflectionEnabled registers the gRPC server reflection service, so that tools like grpcurl
+	// can discover the services without the proto files.
+	ReflectionEnabled bool
+
+	// Compression is the list of compression algorithms ('gzip', 'zstd') that clients can compress
+	// their requests with. The responses are compressed with the algorithm of the request.
+	Compression []string
+
+	// MaxRecvMsgSizeInBytes is the maximum size of a request message.
+	MaxRecvMsgSizeInBytes int
+
+	// MaxSendMsgSizeInBytes is the maximum size of a response message, e.g. of an Expand response on
+	// a large object. It also applies to the responses proxied by the HTTP gateway.
+	MaxSendMsgSizeInBytes int
+
+	// MaxConcurrentStreams is the maximum number of concurrent requests on a client connection.
+	MaxConcurrentStreams uint32
+
+	// MaxConnectionAge is the maximum duration of a client connection. The server then gracefully
+	// closes it, so that the client reconnects, possibly to another server. This rebalances the
+	// long-lived connections of the clients across the servers, e.g. after a scale-out. 0 means
+	// that the connections are never closed.
+	MaxConnectionAge time.Duration
+
+	// MaxConnectionAgeGrace is how long the in-flight requests of a connection that reached
+	// MaxConnectionAge have to finish before it is forcibly closed. 0 means that they always finish.
+	MaxConnectionAgeGrace time.Duration
+
+	// KeepaliveTime is how long a client connection has to be idle before the server pings the
+	// client to check that it is still alive, and KeepaliveTimeout how long the server waits for the
+	// ping to be acknowledged before closing the connection.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	// KeepaliveEnforcementMinTime is the minimum interval between the keepalive pings of a client.
+	// The connections of the clients that ping more often are closed.
+	KeepaliveEnforcementMinTime time.Duration
+
+	// KeepaliveEnforcementPermitWithoutStream allows the clients to send keepalive pings on
+	// connections without in-flight requests.
+	KeepaliveEnforcementPermitWithoutStream bool
 }
 
 // HTTPConfig defines OpenFGA server configurations for HTTP server specific settings.
@@ -96,6 +439,24 @@ type HTTPConfig struct {
 
 	CORSAllowedOrigins []string
 	CORSAllowedHeaders []string
+
+	// CORSExposedHeaders are the response headers that the browsers expose to the allowed origins,
+	// e.g. Openfga-Consistency-Token.
+	CORSExposedHeaders []string
+
+	// CORSMaxAge is how long the browsers cache the responses of the preflight requests. If 0,
+	// they use their default.
+	CORSMaxAge time.Duration
+
+	// OpenAPIEnabled serves the OpenAPI document of the API on /openapi.json, and a minimal API
+	// explorer on /docs.
+	OpenAPIEnabled bool `mapstructure:"openapiEnabled"`
+
+	// CompressionEnabled compresses the responses of clients that accept it with zstd or gzip.
+	CompressionEnabled bool
+
+	// CompressionMinSizeInBytes is the minimum size of a response to compress it.
+	CompressionMinSizeInBytes int
 }
 
 // TLSConfig defines configuration specific to Transport Layer Security (TLS) settings.
@@ -128,6 +489,24 @@ type AuthnPresharedKeyConfig struct {
 	Keys []string
 }
 
+// ControlPlaneConfig defines the gRPC listener of the control-plane RPCs, which create, update and
+// delete the stores and write their models and assertions, e.g. CreateStore and
+// WriteAuthorizationModel. Once it is enabled, the control-plane RPCs are only served on this
+// listener, with its own authentication, and the gRPC and HTTP servers of the data-plane RPCs,
+// e.g. Check and Write, reject them, so that network policies can restrict the control plane to
+// the admin networks.
+type ControlPlaneConfig struct {
+	Enabled bool
+
+	// Addr is the host:port address of the gRPC listener of the control-plane RPCs. It must be
+	// different from the address of the gRPC server.
+	Addr string
+
+	// Authn is the authentication of the control-plane RPCs, which is separate from that of the
+	// data-plane RPCs.
+	Authn AuthnConfig
+}
+
 // LogConfig defines OpenFGA server configurations for log specific settings. For production we
 // recommend using the 'json' log format.
 type LogConfig struct {
@@ -139,6 +518,10 @@ type LogConfig struct {
 
 	// Format of the timestamp in the log output (e.g. 'Unix'(default) or 'ISO8601')
 	TimestampFormat string
+
+	// ModuleLevels are rules of the form 'module=level' that set the log level of a module apart
+	// from Level, e.g. 'graph=debug'. The modules are 'authn', 'graph', 'server' and 'storage'.
+	ModuleLevels []string
 }
 
 type TraceConfig struct {
@@ -163,6 +546,35 @@ type PlaygroundConfig struct {
 	Port    int
 }
 
+// ModelEditorConfig defines the endpoints of the HTTP server that back a local model editor: the
+// validation of a model, the evaluation of Check requests against sample tuples, and the graph of
+// a model.
+type ModelEditorConfig struct {
+	Enabled bool
+
+	// MaxTuples is the maximum number of sample tuples of an evaluation.
+	MaxTuples int
+}
+
+// AdminConfig defines the admin HTTP server, which serves the log levels on '/log/levels' so that
+// they can be changed at runtime, replays Check requests with the trace of their resolution on
+// '/debug/check', serves the feature flags on '/feature-flags' so that they can be changed at
+// runtime, serves the configuration in effect, with the secrets redacted, on '/config', flushes the
+// Check query cache on '/cache/flush', toggles the draining of the server on '/drain' and serves
+// the runtime statistics of the server on '/stats'.
+type AdminConfig struct {
+	Enabled bool
+
+	// Addr is the host:port address of the admin server. It should only be reachable by the
+	// operators.
+	Addr string
+
+	// PresharedKeys are the keys that authenticate the requests of the admin server, as a bearer
+	// token of their Authorization header. They are separate from the keys of the OpenFGA API, so
+	// that the tenants can't operate the server. Without keys, the admin server isn't authenticated.
+	PresharedKeys []string
+}
+
 // ProfilerConfig defines server configurations specific to pprof profiling.
 type ProfilerConfig struct {
 	Enabled bool
@@ -174,6 +586,20 @@ type MetricConfig struct {
 	Enabled             bool
 	Addr                string
 	EnableRPCHistograms bool
+	OTLP                OTLPMetricConfig `mapstructure:"otlp"`
+}
+
+// OTLPMetricConfig defines configurations for exporting metrics to an OpenTelemetry collector.
+// The metrics are exported with the same names as they have on the Prometheus '/metrics' endpoint.
+type OTLPMetricConfig struct {
+	Enabled        bool
+	Endpoint       string
+	TLS            OTLPMetricTLSConfig
+	ExportInterval time.Duration
+}
+
+type OTLPMetricTLSConfig struct {
+	Enabled bool
 }
 
 // CheckQueryCache defines configuration for caching when resolving check.
@@ -181,6 +607,443 @@ type CheckQueryCache struct {
 	Enabled bool
 	Limit   uint32 // (in items)
 	TTL     time.Duration
+
+	// NegativeTTL is the TTL of the cached checks whose result is not allowed, if it isn't 0.
+	// Otherwise they are cached with the TTL.
+	NegativeTTL time.Duration
+
+	// MaxBytes bounds the cache by the estimated number of bytes of its entries instead of their
+	// number, if it isn't 0. The Limit is then ignored.
+	MaxBytes uint64
+
+	// ServeStale serves the expired cached checks, if they are still in the cache, instead of
+	// failing when the datastore is unavailable.
+	ServeStale bool
+
+	// WatermarkEnabled keys the cached checks by the changelog watermark of their store, so that
+	// they are no longer served once its tuples change, and can be cached with a much longer TTL.
+	WatermarkEnabled bool
+
+	// WatermarkRefreshInterval is how often the changelog of a store is read to move its watermark,
+	// which bounds the staleness of the cached checks.
+	WatermarkRefreshInterval time.Duration
+}
+
+// ListObjectsCacheConfig defines configurations for caching the results of the ListObjects requests.
+type ListObjectsCacheConfig struct {
+	// Enabled caches the results by store, model, type, relation, user, contextual tuples and
+	// context, and by the changelog watermark of their store, so that they are no longer served
+	// once its tuples change.
+	Enabled bool
+	Limit   uint32 // (in items)
+	TTL     time.Duration
+
+	// WatermarkRefreshInterval is how often the changelog of a store is read to move its watermark,
+	// which bounds the staleness of the cached results.
+	WatermarkRefreshInterval time.Duration
+}
+
+// SlowRequestLogConfig defines configurations for logging Check and ListObjects requests that take
+// longer than a threshold to resolve.
+type SlowRequestLogConfig struct {
+	Enabled   bool
+	Threshold time.Duration
+}
+
+// AdmissionControlConfig defines configurations for shedding requests while the server is overloaded.
+type AdmissionControlConfig struct {
+	// Enabled enables shedding of requests, with a RESOURCE_EXHAUSTED error and a retry-after header,
+	// while the load of the server exceeds one of the thresholds.
+	Enabled bool
+
+	// MaxInflightRequests, MaxGoroutines and MaxMemoryBytes are the thresholds of the number of
+	// in-flight requests, of the number of goroutines and of the bytes of heap objects from which
+	// requests are shed. A value of 0 doesn't limit the resource.
+	MaxInflightRequests int
+	MaxGoroutines       int
+	MaxMemoryBytes      uint64
+
+	// LowPriorityRatio is the ratio (between 0 and 1) of the thresholds from which the low priority
+	// requests, i.e. ListObjects, StreamedListObjects, Expand and the Check requests of the stores
+	// with a high dispatch count, are shed.
+	LowPriorityRatio float64
+
+	// HighDispatchCount is the average dispatch count of the recent Check requests of a store from
+	// which the Check requests of the store have a low priority. 0 gives them all a high priority.
+	HighDispatchCount uint32
+
+	// RetryAfter is the delay after which the clients are told to retry a shed request.
+	RetryAfter time.Duration
+}
+
+// RequestTimeoutsConfig defines the timeouts of the Check, ListObjects and Write requests, which
+// override Config.RequestTimeout. A value of 0 means the RequestTimeout.
+type RequestTimeoutsConfig struct {
+	Check time.Duration
+
+	// ListObjects is the timeout of the ListObjects and StreamedListObjects requests.
+	ListObjects time.Duration
+	Write       time.Duration
+}
+
+// CheckBudgetConfig defines the maximum amount of work allowed to resolve a single Check request.
+// A Check request that exceeds its budget fails instead of continuing until the resolve node limit is hit.
+// A value of 0 means no limit.
+type CheckBudgetConfig struct {
+	MaxDispatchCount      uint32
+	MaxDatastoreReadCount uint32
+}
+
+// TypesystemCacheConfig defines the cache of the compiled authorization models (TypeSystems), keyed by
+// store and model, that is shared by the requests of all the stores.
+type TypesystemCacheConfig struct {
+	// MaxSize is the maximum number of TypeSystems in the cache. The least recently used ones are
+	// evicted once it is exceeded.
+	MaxSize int
+}
+
+// CheckReadDeduplicationConfig defines the memoization of the datastore reads of each Check request,
+// so that the identical reads of the branches of its resolution only reach the datastore once.
+type CheckReadDeduplicationConfig struct {
+	Enabled bool
+
+	// MaxTuplesPerRead is the maximum number of tuples of a memoized read. The larger reads are
+	// repeated.
+	MaxTuplesPerRead int
+}
+
+// CheckDispatchPoolConfig defines the pool of reusable goroutines that run the concurrent dispatches of
+// the Check resolutions, instead of a new goroutine for each of them.
+type CheckDispatchPoolConfig struct {
+	Enabled bool
+
+	// Size is the maximum number of goroutines of the pool. The dispatches that are started while all of
+	// them are busy run on new goroutines.
+	Size int
+}
+
+// CheckPlannerConfig defines the planner that chooses how Check resolves usersets and tuple to userset
+// rewrites (forward expansion, reverse lookup or direct tuple probe), based on statistics about the
+// cardinality of the relations of each store gathered from the tuples read by Check.
+type CheckPlannerConfig struct {
+	Enabled bool
+
+	// StatisticsInterval is how often the statistics are persisted to the datastore, if they changed.
+	// They are also persisted on shutdown.
+	StatisticsInterval time.Duration
+
+	// MaxStores is the maximum number of stores whose statistics are kept in memory. The statistics of
+	// the least recently used store are dropped once it is reached.
+	MaxStores int
+}
+
+// TupleStatisticsConfig defines the background collection of approximate statistics about the tuples
+// of each store, such as the number of tuples of each relation and their fan-out.
+type TupleStatisticsConfig struct {
+	Enabled bool
+
+	// Interval is how often the statistics are collected.
+	Interval time.Duration
+
+	// MaxTuplesPerStore is the maximum number of tuples scanned per store on each collection. The
+	// statistics of larger stores are sampled. 0 scans all the tuples.
+	MaxTuplesPerStore int
+}
+
+// RelationUsageConfig defines the tracking of how often Check evaluates each relation of each store,
+// and how often it allows it, e.g. to find the relations that are never evaluated.
+type RelationUsageConfig struct {
+	Enabled bool
+
+	// SampleRatio is the fraction (between 0 and 1) of the evaluations that are recorded. The counts
+	// are estimated from them.
+	SampleRatio float64
+
+	// FlushInterval is how often the recorded evaluations are added to the usage kept in the datastore.
+	FlushInterval time.Duration
+}
+
+// IDGenerationConfig defines how the IDs of the stores and authorization models are generated.
+type IDGenerationConfig struct {
+	// Scheme is the scheme of the generated IDs, 'ulid' or 'uuidv7'. Both are encoded as ULIDs.
+	Scheme string
+
+	// CallerSuppliedStoreIDs allows the CreateStore requests to set the ID of the created store with
+	// the 'Openfga-Store-Id' header, e.g. so that a store has the same ID in every environment.
+	CallerSuppliedStoreIDs bool
+}
+
+// BackupConfig defines the scheduled backups of the stores to object storage, which can be restored
+// with the 'restore' command.
+type BackupConfig struct {
+	Enabled bool
+
+	// URL is the location of the snapshots: a directory (e.g. 'file:///var/backups/openfga'), an S3
+	// bucket and prefix (e.g. 's3://bucket/openfga') or a GCS bucket and prefix (e.g. 'gs://bucket/openfga').
+	// The credentials of the buckets are read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+	// environment variables, which are HMAC keys for GCS.
+	URL string
+
+	// Interval is how often the stores are backed up.
+	Interval time.Duration
+
+	// Endpoint is the URL of an S3 compatible API (e.g. of MinIO) that 's3' URLs are written to,
+	// instead of AWS S3.
+	Endpoint string
+
+	// Region is the region of the S3 bucket.
+	Region string
+}
+
+// IntegrityCheckerConfig defines the periodic check of the tuples of the stores against the latest
+// authorization model of their store, which finds the orphaned tuples whose object type, relation,
+// user type or condition no longer exists.
+type IntegrityCheckerConfig struct {
+	Enabled bool
+
+	// Interval is how often the stores are checked.
+	Interval time.Duration
+
+	// Cleanup is what the periodic checks do with the orphaned tuples: 'none' only reports them,
+	// 'delete' deletes them, and 'quarantine' writes them to the QuarantineURL and deletes them.
+	Cleanup string
+
+	// QuarantineURL is the location the orphaned tuples are quarantined to, like the URL of the
+	// backups, with the endpoint and region of the backups.
+	QuarantineURL string
+
+	// MaxReportedTuples is the maximum number of orphaned tuples listed in the report of a store.
+	MaxReportedTuples int
+}
+
+// MeteringConfig defines the metering of the usage of each store, aggregated over windows of time for
+// chargeback. Each instance meters the requests it serves.
+type MeteringConfig struct {
+	Enabled bool
+
+	// Window is the duration of the windows the usage is aggregated over.
+	Window time.Duration
+
+	// Retention is the number of closed windows that are served by the admin server.
+	Retention int
+
+	// Instance is the name of the instance in the windows and in the names of the exported windows. It
+	// is the hostname if empty.
+	Instance string
+
+	// ExportURL is the location the windows are exported to once they close, like the URL of the
+	// backups, with the endpoint and region of the backups. If empty, they aren't exported.
+	ExportURL string
+
+	// ExportFormat is the format of the exported windows, 'json' or 'csv'.
+	ExportFormat string
+}
+
+// WarmupConfig defines the warm-up of the server before it is marked ready, so that the new servers
+// behind a load balancer don't serve their first requests cold.
+type WarmupConfig struct {
+	Enabled bool
+
+	// Stores are the IDs of the stores whose settings, labels and latest models are cached.
+	Stores []string
+
+	// Timeout is the maximum duration of the warm-up, after which the server is marked ready anyway.
+	Timeout time.Duration
+}
+
+// ChangePublisherConfig defines the publisher of the changes of the changelogs of the stores, as
+// change events, to Kafka or NATS. The changes are checkpointed in the datastore once the broker
+// acknowledged them.
+type ChangePublisherConfig struct {
+	Enabled bool
+
+	// URL is the broker the events are published to: Kafka brokers (e.g. 'kafka://user:pass@b1:9092,b2:9092')
+	// or a NATS server (e.g. 'nats://token@localhost:4222?jetstream=true'). 'tls=true' connects with TLS.
+	URL string
+
+	// Interval is how often the changelogs of the stores are read.
+	Interval time.Duration
+
+	// PageSize is the number of changes of a store that are read, published and checkpointed at once.
+	PageSize int
+
+	// HorizonOffset is how old the changes must be to be published.
+	HorizonOffset time.Duration
+
+	// Consumer is the name of the checkpoints of the publisher.
+	Consumer string
+
+	// Topic is the topic of the events without a route. It may have the '{store_id}' and
+	// '{object_type}' placeholders.
+	Topic string
+
+	// Topics are the routes of the events of stores and object types to topics, rules of the form
+	// 'storeID/objectType=topic' where either may be '*', or 'storeID=topic'.
+	Topics []string
+
+	// Timeout is the timeout of the publication of a page of events.
+	Timeout time.Duration
+}
+
+// WriteAdmissionWebhookConfig defines the admission webhook that reviews the tuples of the Write
+// requests before they are committed, and can reject or mutate them.
+type WriteAdmissionWebhookConfig struct {
+	// URL is the endpoint the writes and deletes of each Write request are POSTed to. If empty, the
+	// writes are not reviewed.
+	URL string
+
+	// Timeout is the timeout of a review.
+	Timeout time.Duration
+
+	// FailurePolicy is what happens to the writes when the webhook fails or times out: 'fail' rejects
+	// them, and 'ignore' commits them without review.
+	FailurePolicy string
+}
+
+// WriteGroupsConfig defines how the write groups are applied.
+type WriteGroupsConfig struct {
+	// SagaEnabled allows the groups whose stores can't be written in a single transaction, e.g.
+	// because they are in different datastores, to be applied one store after the other with
+	// compensations. The compensations are only kept in memory, so the stores of a group stay
+	// diverged if the server stops while applying it. If false, these groups are rejected.
+	SagaEnabled bool
+}
+
+// DelegatedCheckConfig defines the relations whose Check is delegated to external evaluators, e.g.
+// OPA policies, for the attribute-based decisions that the conditions of a model can't express.
+type DelegatedCheckConfig struct {
+	// Relations are 'type#relation=url' rules that delegate the relation of the type to the
+	// external evaluator at the URL.
+	Relations []string
+
+	// Operator is how the decision of an evaluator is combined with the rewrite of its relation:
+	// 'intersection', 'union' or 'override'.
+	Operator string
+
+	// Timeout is the timeout of a decision of an evaluator.
+	Timeout time.Duration
+}
+
+// CheckCanaryConfig defines the canary evaluation of the candidate authorization models of the stores
+// (see the candidate_authorization_model_id store setting). The Check requests of a store with a
+// candidate model are also evaluated against it in the background, and the results that differ from
+// those of the model of the requests are logged and counted, while the requests are served the
+// results of their model.
+type CheckCanaryConfig struct {
+	Enabled bool
+
+	// SampleRatio is the fraction (between 0 and 1) of the Check requests of the stores with a
+	// candidate model that are also evaluated against it.
+	SampleRatio float64
+
+	// Timeout is the timeout of the evaluation of a request against the candidate model.
+	Timeout time.Duration
+
+	// MaxConcurrentEvaluations is the maximum number of evaluations against the candidate models in
+	// progress. The requests sampled while it is reached aren't evaluated against them.
+	MaxConcurrentEvaluations int
+}
+
+// WriteCoalescingConfig defines the coalescing of the Write requests of a store into larger datastore
+// transactions, e.g. for the high-throughput ingestion of many small writes.
+type WriteCoalescingConfig struct {
+	// Enabled coalesces the Write requests. Each request then waits up to Window for the other
+	// requests of its store before it is committed.
+	Enabled bool
+
+	// Window is the maximum time a Write request waits for the other requests of its store.
+	Window time.Duration
+}
+
+// PeerDispatchConfig defines the dispatch of the Check subproblems to the peers of a cluster of
+// servers, by the consistent hash of their object and relation.
+type PeerDispatchConfig struct {
+	// Enabled dispatches each Check subproblem to the member of the cluster that owns it.
+	Enabled bool
+
+	// Self is the address of this server among the members of the cluster, e.g. "10.0.0.1:8081".
+	Self string
+
+	// Peers are the static addresses of the members of the cluster, with or without Self. They are
+	// ignored if DNSName is set.
+	Peers []string
+
+	// DNSName is a host and port, e.g. "openfga-headless:8081", whose host resolves to the addresses
+	// of the members of the cluster, e.g. the headless service of a Kubernetes StatefulSet.
+	DNSName string
+
+	// DNSRefreshInterval is how often DNSName is resolved again.
+	DNSRefreshInterval time.Duration
+
+	// Timeout is the timeout of a subproblem dispatched to a peer, after which the server resolves
+	// it itself. If 0, it only times out with the request.
+	Timeout time.Duration
+
+	// PresharedKey authenticates the subproblems that the peers dispatch to each other.
+	PresharedKey string
+}
+
+// ContinuationTokensConfig defines how the continuation tokens of Read, ReadChanges,
+// ReadAuthorizationModels and ListStores are signed and encrypted.
+type ContinuationTokensConfig struct {
+	// SigningKeys are the keys of the HMAC signature of the tokens. The first one signs the tokens,
+	// and all of them verify them, so that the signing key can be rotated. If empty, the tokens are
+	// neither signed nor versioned.
+	SigningKeys []string
+
+	// EncryptionKey is the key the tokens are encrypted with, with AES-GCM. If empty, the tokens are
+	// not encrypted. It requires a signing key.
+	EncryptionKey string
+
+	// ReadChangesLifetime is how long a token of ReadChanges is valid after the last change it was
+	// returned for, as the older changes may have been removed from the changelog. 0 means that the
+	// time travel retention is used, and that the tokens never expire without one.
+	ReadChangesLifetime time.Duration
+}
+
+// TupleValidationConfig defines the deployment-specific rules that the tuples written must follow.
+type TupleValidationConfig struct {
+	// MaxObjectLength and MaxUserLength are the maximum numbers of bytes of the object and of the
+	// user of a tuple written. They can't exceed the limits of the API, 256 and 512 bytes.
+	MaxObjectLength int
+	MaxUserLength   int
+
+	// IDPatterns are rules of the form 'type=pattern' that the IDs of the objects of a type must
+	// match when they are the object or the user of a tuple written. The pattern is either 'uuid'
+	// or a regular expression that the whole ID must match, e.g. 'document=uuid' or 'user=[a-z0-9_]+'.
+	IDPatterns []string
+}
+
+// TimeTravelConfig defines the evaluation of Check and ListObjects requests against the tuples of a
+// store at a past time, reconstructed from its changelog.
+type TimeTravelConfig struct {
+	Enabled bool
+
+	// MaxChanges is the maximum number of changes replayed to reconstruct the tuples of a store at
+	// a past time. 0 means no limit.
+	MaxChanges int
+
+	// Retention is how long the changelog of the deployment is retained. The requests evaluated at
+	// an older time are rejected, as the changelog no longer has all the changes before it. 0 means
+	// that the changelog is retained forever.
+	Retention time.Duration
+}
+
+// ImportConfig defines the authorization model and tuples that are imported into a store when the
+// server starts, so that ephemeral instances (e.g. for development or tests) come up pre-populated.
+type ImportConfig struct {
+	// ModelFile is the path of the authorization model to import, in the DSL or, if the file
+	// has a '.json' extension, in JSON. If empty, nothing is imported.
+	ModelFile string
+
+	// TuplesFile is the path of the tuples to import, in CSV (with a 'user,relation,object' header
+	// and optional 'condition_name' and 'condition_context' columns) or, if the file has a '.jsonl'
+	// extension, with a JSON tuple key per line.
+	TuplesFile string
+
+	// StoreName is the name of the store that is created to import into. If a store with that name
+	// already exists, nothing is imported.
+	StoreName string
 }
 
 // DispatchThrottlingConfig defines configurations for dispatch throttling.
@@ -205,6 +1068,14 @@ type Config struct {
 	// This is to protect the server from misuse of the ListObjects endpoints.
 	ListObjectsMaxResults uint32
 
+	// ListObjectsShards defines the number of worker pools that the objects of a ListObjects
+	// request that need a Check are partitioned into, by the hash of their IDs.
+	ListObjectsShards uint32
+
+	// ListObjectsShardConcurrency defines the number of concurrent Checks of each ListObjects
+	// worker pool. If 0, it is the ResolveNodeBreadthLimit.
+	ListObjectsShardConcurrency uint32
+
 	// MaxTuplesPerWrite defines the maximum number of tuples per Write endpoint.
 	MaxTuplesPerWrite int
 
@@ -216,6 +1087,31 @@ type Config struct {
 	// persisting an Authorization Model.
 	MaxAuthorizationModelSizeInBytes int
 
+	// MaxRelationsPerTypeDefinition defines the maximum number of relations of each type definition of
+	// the authorization models written with WriteAuthorizationModel. If 0, it isn't limited.
+	MaxRelationsPerTypeDefinition int
+
+	// MaxAuthorizationModelRewriteDepth defines the maximum nesting of the unions, intersections and
+	// exclusions of the rewrite of each relation of the authorization models written with
+	// WriteAuthorizationModel, e.g. 'define viewer: [user] or editor' has a depth of 2. If 0, it isn't limited.
+	MaxAuthorizationModelRewriteDepth int
+
+	// MaxConditionExpressionSizeInBytes defines the maximum size in bytes of the expression of each
+	// condition of the authorization models written with WriteAuthorizationModel. If 0, it isn't limited.
+	MaxConditionExpressionSizeInBytes int
+
+	// MaxContextualTuples defines the maximum number of contextual tuples of a Check or
+	// ListObjects request.
+	MaxContextualTuples int
+
+	// MaxContextSizeInBytes defines the maximum size in bytes of the context of a Check or ListObjects
+	// request, and of the condition context of each written or contextual tuple.
+	MaxContextSizeInBytes int
+
+	// ConsistencyTokenTimeout is the maximum amount of time a Check or ListObjects request with a
+	// consistency token waits for the datastore to catch up with the write of the token.
+	ConsistencyTokenTimeout time.Duration
+
 	// MaxConcurrentReadsForListObjects defines the maximum number of concurrent database reads
 	// allowed in ListObjects queries
 	MaxConcurrentReadsForListObjects uint32
@@ -224,6 +1120,11 @@ type Config struct {
 	// Check queries
 	MaxConcurrentReadsForCheck uint32
 
+	// CheckUsersetsPageSize defines the number of usersets of an object that a Check query reads
+	// from the database at once. The usersets of a page are resolved before the next page is read. If
+	// 0, all the usersets are read at once.
+	CheckUsersetsPageSize uint32
+
 	// ChangelogHorizonOffset is an offset in minutes from the current time. Changes that occur
 	// after this offset will not be included in the response of ReadChanges.
 	ChangelogHorizonOffset int
@@ -231,6 +1132,10 @@ type Config struct {
 	// Experimentals is a list of the experimental features to enable in the OpenFGA server.
 	Experimentals []string
 
+	// FeatureFlags are rules of the form 'flag=value' that gate the experimental behaviors per store,
+	// see featureflags.Parse.
+	FeatureFlags []string
+
 	// ResolveNodeLimit indicates how deeply nested an authorization model can be before a query
 	// errors out.
 	ResolveNodeLimit uint32
@@ -243,17 +1148,55 @@ type Config struct {
 	// request timeout will be prioritized
 	RequestTimeout time.Duration
 
+	// RequestTimeouts are the timeouts of the requests of the APIs that don't use RequestTimeout.
+	RequestTimeouts RequestTimeoutsConfig
+
+	// DrainTimeout is the maximum amount of time the server waits for in-flight requests to finish
+	// when shutting down. New requests are rejected while draining.
+	DrainTimeout time.Duration
+
 	Datastore          DatastoreConfig
 	GRPC               GRPCConfig
 	HTTP               HTTPConfig
 	Authn              AuthnConfig
+	ControlPlane       ControlPlaneConfig
 	Log                LogConfig
 	Trace              TraceConfig
 	Playground         PlaygroundConfig
+	ModelEditor        ModelEditorConfig
 	Profiler           ProfilerConfig
+	Admin              AdminConfig
 	Metrics            MetricConfig
 	CheckQueryCache    CheckQueryCache
+	ListObjectsCache   ListObjectsCacheConfig
 	DispatchThrottling DispatchThrottlingConfig
+	SlowRequestLog     SlowRequestLogConfig
+	AdmissionControl   AdmissionControlConfig
+	CheckBudget        CheckBudgetConfig
+	CheckPlanner       CheckPlannerConfig
+	TupleStatistics    TupleStatisticsConfig
+	RelationUsage      RelationUsageConfig
+	IDGeneration       IDGenerationConfig
+	ContinuationTokens ContinuationTokensConfig
+	Import             ImportConfig
+	Backup             BackupConfig
+	ChangePublisher    ChangePublisherConfig
+	IntegrityChecker   IntegrityCheckerConfig
+	Metering           MeteringConfig
+	Warmup             WarmupConfig
+
+	CheckReadDeduplication CheckReadDeduplicationConfig
+	CheckDispatchPool      CheckDispatchPoolConfig
+	TypesystemCache        TypesystemCacheConfig
+
+	WriteAdmissionWebhook WriteAdmissionWebhookConfig
+	WriteGroups           WriteGroupsConfig
+	DelegatedCheck        DelegatedCheckConfig
+	CheckCanary           CheckCanaryConfig
+	WriteCoalescing       WriteCoalescingConfig
+	PeerDispatch          PeerDispatchConfig
+	TupleValidation       TupleValidationConfig
+	TimeTravel            TimeTravelConfig
 
 	RequestDurationDatastoreQueryCountBuckets []string
 	RequestDurationDispatchCountBuckets       []string
@@ -268,10 +1211,18 @@ func (cfg *Config) Verify() error {
 		)
 	}
 
-	if cfg.RequestTimeout > 0 && cfg.ListObjectsDeadline > cfg.RequestTimeout {
+	if cfg.RequestTimeouts.ListObjects > 0 && cfg.ListObjectsDeadline > cfg.RequestTimeouts.ListObjects {
 		return fmt.Errorf(
-			"config 'requestTimeout' (%s) cannot be lower than 'listObjectsDeadline' config (%s)",
-			cfg.RequestTimeout,
+			"config 'requestTimeouts.listObjects' (%s) cannot be lower than 'listObjectsDeadline' config (%s)",
+			cfg.RequestTimeouts.ListObjects,
+			cfg.ListObjectsDeadline,
+		)
+	}
+
+	if cfg.RequestTimeouts.ListObjects == 0 && cfg.RequestTimeout > 0 && cfg.ListObjectsDeadline > cfg.RequestTimeout {
+		return fmt.Errorf(
+			"config 'requestTimeout' (%s) cannot be lower than 'listObjectsDeadline' config (%s)",
+			cfg.RequestTimeout,
 			cfg.ListObjectsDeadline,
 		)
 	}
@@ -296,6 +1247,14 @@ func (cfg *Config) Verify() error {
 		return fmt.Errorf("config 'log.TimestampFormat' must be one of ['Unix', 'ISO8601']")
 	}
 
+	if _, err := logger.ParseModuleLevels(cfg.Log.ModuleLevels); err != nil {
+		return fmt.Errorf("config 'log.moduleLevels' is invalid: %w", err)
+	}
+
+	if _, err := featureflags.Parse(cfg.FeatureFlags); err != nil {
+		return fmt.Errorf("config 'featureFlags' is invalid: %w", err)
+	}
+
 	if cfg.Playground.Enabled {
 		if !cfg.HTTP.Enabled {
 			return errors.New("the HTTP server must be enabled to run the openfga playground")
@@ -306,6 +1265,24 @@ func (cfg *Config) Verify() error {
 		}
 	}
 
+	if cfg.ControlPlane.Enabled {
+		if cfg.ControlPlane.Addr == cfg.GRPC.Addr {
+			return errors.New("'controlPlane.addr' must be different from 'grpc.addr'")
+		}
+
+		if cfg.Playground.Enabled {
+			return errors.New("the playground creates stores and models through the HTTP server, so it can't be enabled with the control plane")
+		}
+	}
+
+	if cfg.ModelEditor.Enabled && !cfg.HTTP.Enabled {
+		return errors.New("the HTTP server must be enabled to serve the model editor endpoints")
+	}
+
+	if cfg.ModelEditor.MaxTuples <= 0 {
+		return errors.New("'modelEditor.maxTuples' must be a positive integer")
+	}
+
 	if cfg.HTTP.TLS.Enabled {
 		if cfg.HTTP.TLS.CertPath == "" || cfg.HTTP.TLS.KeyPath == "" {
 			return errors.New("'http.tls.cert' and 'http.tls.key' configs must be set")
@@ -358,6 +1335,14 @@ func (cfg *Config) Verify() error {
 		return errors.New("requestTimeout must be a non-negative time duration")
 	}
 
+	if cfg.RequestTimeouts.Check < 0 || cfg.RequestTimeouts.ListObjects < 0 || cfg.RequestTimeouts.Write < 0 {
+		return errors.New("'requestTimeouts.check', 'requestTimeouts.listObjects' and 'requestTimeouts.write' must be non-negative time durations")
+	}
+
+	if cfg.DrainTimeout < 0 {
+		return errors.New("drainTimeout must be a non-negative time duration")
+	}
+
 	if cfg.RequestTimeout == 0 && cfg.HTTP.Enabled && cfg.HTTP.UpstreamTimeout < 0 {
 		return errors.New("http.upstreamTimeout must be a non-negative time duration")
 	}
@@ -366,16 +1351,449 @@ func (cfg *Config) Verify() error {
 		return errors.New("listObjectsDeadline must be non-negative time duration")
 	}
 
+	if cfg.ListObjectsShards == 0 {
+		return errors.New("listObjectsShards must be a positive number")
+	}
+
+	if cfg.SlowRequestLog.Enabled && cfg.SlowRequestLog.Threshold <= 0 {
+		return errors.New("'slowRequestLog.threshold' must be a positive time duration")
+	}
+
+	if cfg.AdmissionControl.Enabled {
+		if cfg.AdmissionControl.MaxInflightRequests <= 0 && cfg.AdmissionControl.MaxGoroutines <= 0 && cfg.AdmissionControl.MaxMemoryBytes == 0 {
+			return errors.New("'admissionControl' requires at least one of 'admissionControl.maxInflightRequests', 'admissionControl.maxGoroutines' and 'admissionControl.maxMemoryBytes' to be set")
+		}
+		if cfg.AdmissionControl.MaxInflightRequests < 0 || cfg.AdmissionControl.MaxGoroutines < 0 {
+			return errors.New("'admissionControl.maxInflightRequests' and 'admissionControl.maxGoroutines' must be non-negative")
+		}
+		if cfg.AdmissionControl.LowPriorityRatio <= 0 || cfg.AdmissionControl.LowPriorityRatio > 1 {
+			return errors.New("'admissionControl.lowPriorityRatio' must be greater than 0 and less than or equal to 1")
+		}
+		if cfg.AdmissionControl.RetryAfter <= 0 {
+			return errors.New("'admissionControl.retryAfter' must be a positive time duration")
+		}
+	}
+
+	if cfg.Metrics.OTLP.Enabled && cfg.Metrics.OTLP.ExportInterval <= 0 {
+		return errors.New("'metrics.otlp.exportInterval' must be a positive time duration")
+	}
+
+	if cfg.Datastore.Hedging.Enabled {
+		if cfg.Datastore.Hedging.Percentile <= 0 || cfg.Datastore.Hedging.Percentile > 100 {
+			return errors.New("'datastore.hedging.percentile' must be greater than 0 and less than or equal to 100")
+		}
+		if cfg.Datastore.Hedging.MinDelay < 0 {
+			return errors.New("'datastore.hedging.minDelay' must be a non-negative time duration")
+		}
+	}
+
+	if cfg.Datastore.CircuitBreaker.Enabled {
+		if cfg.Datastore.CircuitBreaker.FailureRatio <= 0 || cfg.Datastore.CircuitBreaker.FailureRatio > 1 {
+			return errors.New("'datastore.circuitBreaker.failureRatio' must be greater than 0 and less than or equal to 1")
+		}
+		if cfg.Datastore.CircuitBreaker.MinRequests < 1 {
+			return errors.New("'datastore.circuitBreaker.minRequests' must be greater than 0")
+		}
+		if cfg.Datastore.CircuitBreaker.Window <= 0 {
+			return errors.New("'datastore.circuitBreaker.window' must be a positive time duration")
+		}
+		if cfg.Datastore.CircuitBreaker.LatencyThreshold < 0 {
+			return errors.New("'datastore.circuitBreaker.latencyThreshold' must be a non-negative time duration")
+		}
+		if cfg.Datastore.CircuitBreaker.OpenDuration <= 0 {
+			return errors.New("'datastore.circuitBreaker.openDuration' must be a positive time duration")
+		}
+	}
+
+	if cfg.Import.TuplesFile != "" && cfg.Import.ModelFile == "" {
+		return errors.New("'import.tuplesFile' requires 'import.modelFile' to be set")
+	}
+
+	if cfg.Import.ModelFile != "" && cfg.Import.StoreName == "" {
+		return errors.New("'import.storeName' must be set to import an authorization model")
+	}
+
+	if cfg.Datastore.Postgres.StatementCacheCapacity < 0 {
+		return errors.New("'datastore.postgres.statementCacheCapacity' must be a non-negative integer")
+	}
+
+	switch cfg.Datastore.Postgres.QueryExecMode {
+	case "", "cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol":
+	default:
+		return fmt.Errorf("config 'datastore.postgres.queryExecMode' must be one of ['cache_statement', 'cache_describe', 'describe_exec', 'exec', 'simple_protocol']")
+	}
+
+	if cfg.Datastore.Metrics.StoreLabelLimit < 0 {
+		return errors.New("'datastore.metrics.storeLabelLimit' must be a non-negative integer")
+	}
+
+	if cfg.Datastore.Memory.SnapshotInterval < 0 {
+		return errors.New("'datastore.memory.snapshotInterval' must be a non-negative time duration")
+	}
+
+	for _, key := range cfg.Datastore.ConditionContextEncryptionKeys {
+		if key == "" {
+			return errors.New("'datastore.conditionContextEncryptionKeys' must not contain empty keys")
+		}
+	}
+
+	if len(cfg.Datastore.ConditionContextEncryptionKeys) > 0 && cfg.Datastore.Engine == "memory" {
+		return errors.New("'datastore.conditionContextEncryptionKeys' is only supported by the postgres and mysql engines")
+	}
+
+	if cfg.Datastore.Residency.Name == "" {
+		return errors.New("'datastore.residency.name' must be set")
+	}
+
+	// the URIs of the datastores may have passwords, so the errors only have their names
+	residencyNames := map[string]struct{}{cfg.Datastore.Residency.Name: {}}
+	for i, datastore := range cfg.Datastore.Residency.Datastores {
+		name, uri, ok := strings.Cut(datastore, "=")
+		if !ok || name == "" || uri == "" {
+			return fmt.Errorf("'datastore.residency.datastores' must be 'name=uri' pairs, the datastore %d isn't", i)
+		}
+
+		if _, ok := residencyNames[name]; ok {
+			return fmt.Errorf("'datastore.residency.datastores' has the name '%s' more than once", name)
+		}
+		residencyNames[name] = struct{}{}
+	}
+
+	for _, route := range cfg.Datastore.Residency.Stores {
+		store, name, ok := strings.Cut(route, "=")
+		if !ok || store == "" {
+			return fmt.Errorf("'datastore.residency.stores' must be 'storeID=name' rules, not '%s'", route)
+		}
+
+		if _, ok := residencyNames[name]; !ok {
+			return fmt.Errorf("'datastore.residency.stores' routes the store '%s' to the unknown datastore '%s'", store, name)
+		}
+	}
+
+	if cfg.ConsistencyTokenTimeout < 0 {
+		return errors.New("'consistencyTokenTimeout' must be a non-negative time duration")
+	}
+
+	if cfg.MaxRelationsPerTypeDefinition < 0 {
+		return errors.New("'maxRelationsPerTypeDefinition' must be a non-negative integer")
+	}
+
+	if cfg.MaxAuthorizationModelRewriteDepth < 0 {
+		return errors.New("'maxAuthorizationModelRewriteDepth' must be a non-negative integer")
+	}
+
+	if cfg.MaxConditionExpressionSizeInBytes < 0 {
+		return errors.New("'maxConditionExpressionSizeInBytes' must be a non-negative integer")
+	}
+
+	if cfg.MaxContextualTuples <= 0 {
+		return errors.New("'maxContextualTuples' must be a positive integer")
+	}
+
+	if cfg.MaxContextSizeInBytes <= 0 {
+		return errors.New("'maxContextSizeInBytes' must be a positive integer")
+	}
+
+	if cfg.TypesystemCache.MaxSize <= 0 {
+		return errors.New("'typesystemCache.maxSize' must be a positive integer")
+	}
+
+	if cfg.CheckReadDeduplication.Enabled && cfg.CheckReadDeduplication.MaxTuplesPerRead <= 0 {
+		return errors.New("'checkReadDeduplication.maxTuplesPerRead' must be a positive integer")
+	}
+
+	if cfg.CheckDispatchPool.Enabled && cfg.CheckDispatchPool.Size <= 0 {
+		return errors.New("'checkDispatchPool.size' must be a positive integer")
+	}
+
+	if cfg.CheckPlanner.StatisticsInterval < 0 {
+		return errors.New("'checkPlanner.statisticsInterval' must be a non-negative time duration")
+	}
+
+	if cfg.CheckPlanner.MaxStores <= 0 {
+		return errors.New("'checkPlanner.maxStores' must be a positive integer")
+	}
+
+	if cfg.TupleStatistics.Enabled && cfg.TupleStatistics.Interval <= 0 {
+		return errors.New("'tupleStatistics.interval' must be a positive time duration")
+	}
+
+	if cfg.TupleStatistics.MaxTuplesPerStore < 0 {
+		return errors.New("'tupleStatistics.maxTuplesPerStore' must be a non-negative integer")
+	}
+
+	if cfg.RelationUsage.Enabled {
+		if cfg.RelationUsage.SampleRatio <= 0 || cfg.RelationUsage.SampleRatio > 1 {
+			return errors.New("'relationUsage.sampleRatio' must be greater than 0 and at most 1")
+		}
+
+		if cfg.RelationUsage.FlushInterval <= 0 {
+			return errors.New("'relationUsage.flushInterval' must be a positive time duration")
+		}
+	}
+
+	if cfg.IDGeneration.Scheme != "ulid" && cfg.IDGeneration.Scheme != "uuidv7" {
+		return fmt.Errorf("config 'idGeneration.scheme' must be one of ['ulid', 'uuidv7']")
+	}
+
+	if cfg.Backup.Enabled {
+		if cfg.Backup.URL == "" {
+			return errors.New("'backup.url' must be set to enable backups")
+		}
+
+		if cfg.Backup.Interval <= 0 {
+			return errors.New("'backup.interval' must be a positive time duration")
+		}
+	}
+
+	if cfg.IntegrityChecker.Enabled && cfg.IntegrityChecker.Interval <= 0 {
+		return errors.New("'integrityChecker.interval' must be a positive time duration")
+	}
+
+	switch cfg.IntegrityChecker.Cleanup {
+	case "none", "delete":
+	case "quarantine":
+		if cfg.IntegrityChecker.QuarantineURL == "" {
+			return errors.New("'integrityChecker.quarantineURL' must be set to quarantine the orphaned tuples")
+		}
+	default:
+		return fmt.Errorf("'integrityChecker.cleanup' must be 'none', 'delete' or 'quarantine', not '%s'", cfg.IntegrityChecker.Cleanup)
+	}
+
+	if cfg.IntegrityChecker.MaxReportedTuples < 0 {
+		return errors.New("'integrityChecker.maxReportedTuples' must be a non-negative integer")
+	}
+
+	if cfg.Metering.Enabled {
+		if cfg.Metering.Window <= 0 {
+			return errors.New("'metering.window' must be a positive time duration")
+		}
+		if cfg.Metering.Retention < 0 {
+			return errors.New("'metering.retention' must be a non-negative integer")
+		}
+	}
+
+	if cfg.Metering.ExportFormat != "json" && cfg.Metering.ExportFormat != "csv" {
+		return fmt.Errorf("'metering.exportFormat' must be 'json' or 'csv', not '%s'", cfg.Metering.ExportFormat)
+	}
+
+	if cfg.Warmup.Enabled && cfg.Warmup.Timeout <= 0 {
+		return errors.New("'warmup.timeout' must be a positive time duration")
+	}
+
+	if cfg.ChangePublisher.Enabled {
+		if cfg.ChangePublisher.URL == "" {
+			return errors.New("'changePublisher.url' must be set to enable the change publisher")
+		}
+
+		if cfg.ChangePublisher.Interval <= 0 {
+			return errors.New("'changePublisher.interval' must be a positive time duration")
+		}
+
+		if cfg.ChangePublisher.PageSize <= 0 {
+			return errors.New("'changePublisher.pageSize' must be a positive integer")
+		}
+
+		if cfg.ChangePublisher.HorizonOffset < 0 {
+			return errors.New("'changePublisher.horizonOffset' must be a non-negative time duration")
+		}
+
+		if cfg.ChangePublisher.Consumer == "" {
+			return errors.New("'changePublisher.consumer' must be set")
+		}
+
+		if cfg.ChangePublisher.Timeout <= 0 {
+			return errors.New("'changePublisher.timeout' must be a positive time duration")
+		}
+
+		if cfg.ChangePublisher.Topic == "" {
+			return errors.New("'changePublisher.topic' must be set")
+		}
+
+		for _, route := range cfg.ChangePublisher.Topics {
+			selector, topic, ok := strings.Cut(route, "=")
+			storeID, objectType, hasObjectType := strings.Cut(selector, "/")
+			if !ok || topic == "" || storeID == "" || (hasObjectType && objectType == "") {
+				return fmt.Errorf("'changePublisher.topics' must be 'storeID/objectType=topic' rules, not '%s'", route)
+			}
+		}
+	}
+
+	if cfg.WriteAdmissionWebhook.URL != "" && cfg.WriteAdmissionWebhook.Timeout <= 0 {
+		return errors.New("'writeAdmissionWebhook.timeout' must be a positive time duration")
+	}
+
+	switch cfg.WriteAdmissionWebhook.FailurePolicy {
+	case "fail", "ignore":
+	default:
+		return fmt.Errorf("'writeAdmissionWebhook.failurePolicy' must be 'fail' or 'ignore', got '%s'", cfg.WriteAdmissionWebhook.FailurePolicy)
+	}
+
+	for _, rule := range cfg.DelegatedCheck.Relations {
+		relation, url, ok := strings.Cut(rule, "=")
+		objectType, name, found := strings.Cut(relation, "#")
+		if !ok || !found || objectType == "" || name == "" || url == "" {
+			return fmt.Errorf("'delegatedCheck.relations' must be 'type#relation=url' rules, not '%s'", rule)
+		}
+	}
+
+	switch cfg.DelegatedCheck.Operator {
+	case "intersection", "union", "override":
+	default:
+		return fmt.Errorf("'delegatedCheck.operator' must be 'intersection', 'union' or 'override', got '%s'", cfg.DelegatedCheck.Operator)
+	}
+
+	if len(cfg.DelegatedCheck.Relations) > 0 && cfg.DelegatedCheck.Timeout <= 0 {
+		return errors.New("'delegatedCheck.timeout' must be a positive time duration")
+	}
+
+	if cfg.CheckCanary.Enabled {
+		if cfg.CheckCanary.SampleRatio < 0 || cfg.CheckCanary.SampleRatio > 1 {
+			return errors.New("'checkCanary.sampleRatio' must be between 0 and 1")
+		}
+		if cfg.CheckCanary.Timeout <= 0 {
+			return errors.New("'checkCanary.timeout' must be a positive time duration")
+		}
+		if cfg.CheckCanary.MaxConcurrentEvaluations <= 0 {
+			return errors.New("'checkCanary.maxConcurrentEvaluations' must be a positive integer")
+		}
+	}
+
+	if cfg.WriteCoalescing.Enabled && cfg.WriteCoalescing.Window <= 0 {
+		return errors.New("'writeCoalescing.window' must be a positive time duration")
+	}
+
+	if cfg.CheckQueryCache.WatermarkEnabled && cfg.CheckQueryCache.WatermarkRefreshInterval <= 0 {
+		return errors.New("'checkQueryCache.watermarkRefreshInterval' must be a positive time duration")
+	}
+
+	if cfg.ListObjectsCache.Enabled {
+		if cfg.ListObjectsCache.TTL <= 0 {
+			return errors.New("'listObjectsCache.ttl' must be a positive time duration")
+		}
+		if cfg.ListObjectsCache.WatermarkRefreshInterval <= 0 {
+			return errors.New("'listObjectsCache.watermarkRefreshInterval' must be a positive time duration")
+		}
+	}
+
+	if cfg.PeerDispatch.Enabled {
+		if cfg.PeerDispatch.Self == "" {
+			return errors.New("'peerDispatch.self' is required when the peer dispatch is enabled")
+		}
+
+		if cfg.PeerDispatch.PresharedKey == "" {
+			return errors.New("'peerDispatch.presharedKey' is required when the peer dispatch is enabled")
+		}
+
+		if len(cfg.PeerDispatch.Peers) == 0 && cfg.PeerDispatch.DNSName == "" {
+			return errors.New("'peerDispatch.peers' or 'peerDispatch.dnsName' is required when the peer dispatch is enabled")
+		}
+
+		if cfg.PeerDispatch.DNSName != "" && cfg.PeerDispatch.DNSRefreshInterval <= 0 {
+			return errors.New("'peerDispatch.dnsRefreshInterval' must be a positive time duration")
+		}
+
+		if cfg.PeerDispatch.Timeout < 0 {
+			return errors.New("'peerDispatch.timeout' must be a non-negative time duration")
+		}
+	}
+
+	if cfg.TupleValidation.MaxObjectLength <= 0 || cfg.TupleValidation.MaxObjectLength > APIMaxTupleObjectLength {
+		return fmt.Errorf("'tupleValidation.maxObjectLength' must be between 1 and %d", APIMaxTupleObjectLength)
+	}
+
+	if cfg.TupleValidation.MaxUserLength <= 0 || cfg.TupleValidation.MaxUserLength > APIMaxTupleUserLength {
+		return fmt.Errorf("'tupleValidation.maxUserLength' must be between 1 and %d", APIMaxTupleUserLength)
+	}
+
+	for _, rule := range cfg.TupleValidation.IDPatterns {
+		objectType, pattern, ok := strings.Cut(rule, "=")
+		if !ok || objectType == "" || pattern == "" {
+			return fmt.Errorf("'tupleValidation.idPatterns' must contain rules of the form 'type=pattern', got '%s'", rule)
+		}
+		if pattern == "uuid" {
+			continue
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("'tupleValidation.idPatterns' contains an invalid pattern for type '%s': %w", objectType, err)
+		}
+	}
+
+	if cfg.TimeTravel.MaxChanges < 0 {
+		return errors.New("'timeTravel.maxChanges' must be non-negative")
+	}
+
+	if cfg.TimeTravel.Retention < 0 {
+		return errors.New("'timeTravel.retention' must be non-negative")
+	}
+
+	for _, key := range cfg.ContinuationTokens.SigningKeys {
+		if key == "" {
+			return errors.New("'continuationTokens.signingKeys' must not contain empty keys")
+		}
+	}
+
+	if cfg.ContinuationTokens.EncryptionKey != "" && len(cfg.ContinuationTokens.SigningKeys) == 0 {
+		return errors.New("'continuationTokens.encryptionKey' requires 'continuationTokens.signingKeys' to be set")
+	}
+
+	if cfg.ContinuationTokens.ReadChangesLifetime < 0 {
+		return errors.New("'continuationTokens.readChangesLifetime' must be non-negative")
+	}
+
+	for _, algorithm := range cfg.GRPC.Compression {
+		if algorithm != "gzip" && algorithm != "zstd" {
+			return fmt.Errorf("config 'grpc.compression' must only contain 'gzip' or 'zstd', got '%s'", algorithm)
+		}
+	}
+
+	if cfg.GRPC.MaxRecvMsgSizeInBytes <= 0 {
+		return errors.New("'grpc.maxRecvMsgSizeInBytes' must be a positive integer")
+	}
+
+	if cfg.GRPC.MaxSendMsgSizeInBytes <= 0 {
+		return errors.New("'grpc.maxSendMsgSizeInBytes' must be a positive integer")
+	}
+
+	if cfg.GRPC.MaxConcurrentStreams == 0 {
+		return errors.New("'grpc.maxConcurrentStreams' must be a positive integer")
+	}
+
+	if cfg.GRPC.MaxConnectionAge < 0 || cfg.GRPC.MaxConnectionAgeGrace < 0 {
+		return errors.New("'grpc.maxConnectionAge' and 'grpc.maxConnectionAgeGrace' must be non-negative durations")
+	}
+
+	if cfg.GRPC.KeepaliveTime <= 0 || cfg.GRPC.KeepaliveTimeout <= 0 {
+		return errors.New("'grpc.keepaliveTime' and 'grpc.keepaliveTimeout' must be positive durations")
+	}
+
+	if cfg.GRPC.KeepaliveEnforcementMinTime < 0 {
+		return errors.New("'grpc.keepaliveEnforcementMinTime' must be a non-negative duration")
+	}
+
+	if cfg.HTTP.CompressionMinSizeInBytes < 0 {
+		return errors.New("'http.compressionMinSizeInBytes' must be a non-negative integer")
+	}
+
+	if cfg.HTTP.CORSMaxAge < 0 {
+		return errors.New("'http.corsMaxAge' must be a non-negative duration")
+	}
+
 	return nil
 }
 
 // DefaultContextTimeout returns the runtime DefaultContextTimeout.
 // If requestTimeout > 0, we should let the middleware take care of the timeout and the
-// runtime.DefaultContextTimeout is used as last resort.
-// Otherwise, use the http upstream timeout if http is enabled.
+// runtime.DefaultContextTimeout is used as last resort, after the longest of the timeouts of the
+// requests. Otherwise, use the http upstream timeout if http is enabled.
 func DefaultContextTimeout(config *Config) time.Duration {
 	if config.RequestTimeout > 0 {
-		return config.RequestTimeout + additionalUpstreamTimeout
+		return max(
+			config.RequestTimeout,
+			config.RequestTimeouts.Check,
+			config.RequestTimeouts.ListObjects,
+			config.RequestTimeouts.Write,
+		) + additionalUpstreamTimeout
 	}
 	if config.HTTP.Enabled && config.HTTP.UpstreamTimeout > 0 {
 		return config.HTTP.UpstreamTimeout
@@ -389,14 +1807,24 @@ func DefaultConfig() *Config {
 		MaxTuplesPerWrite:                         DefaultMaxTuplesPerWrite,
 		MaxTypesPerAuthorizationModel:             DefaultMaxTypesPerAuthorizationModel,
 		MaxAuthorizationModelSizeInBytes:          DefaultMaxAuthorizationModelSizeInBytes,
+		MaxRelationsPerTypeDefinition:             DefaultMaxRelationsPerTypeDefinition,
+		MaxAuthorizationModelRewriteDepth:         DefaultMaxAuthorizationModelRewriteDepth,
+		MaxConditionExpressionSizeInBytes:         DefaultMaxConditionExpressionSizeInBytes,
+		MaxContextualTuples:                       DefaultMaxContextualTuples,
+		MaxContextSizeInBytes:                     DefaultMaxContextSizeInBytes,
+		ConsistencyTokenTimeout:                   DefaultConsistencyTokenTimeout,
 		MaxConcurrentReadsForCheck:                DefaultMaxConcurrentReadsForCheck,
+		CheckUsersetsPageSize:                     DefaultCheckUsersetsPageSize,
 		MaxConcurrentReadsForListObjects:          DefaultMaxConcurrentReadsForListObjects,
 		ChangelogHorizonOffset:                    DefaultChangelogHorizonOffset,
 		ResolveNodeLimit:                          DefaultResolveNodeLimit,
 		ResolveNodeBreadthLimit:                   DefaultResolveNodeBreadthLimit,
 		Experimentals:                             []string{},
+		FeatureFlags:                              []string{},
 		ListObjectsDeadline:                       DefaultListObjectsDeadline,
 		ListObjectsMaxResults:                     DefaultListObjectsMaxResults,
+		ListObjectsShards:                         DefaultListObjectsShards,
+		ListObjectsShardConcurrency:               DefaultListObjectsShardConcurrency,
 		RequestDurationDatastoreQueryCountBuckets: []string{"50", "200"},
 		RequestDurationDispatchCountBuckets:       []string{"50", "200"},
 		Datastore: DatastoreConfig{
@@ -404,10 +1832,45 @@ func DefaultConfig() *Config {
 			MaxCacheSize: 100000,
 			MaxIdleConns: 10,
 			MaxOpenConns: 30,
+			Hedging: DatastoreHedgingConfig{
+				Enabled:    DefaultDatastoreHedgingEnabled,
+				Percentile: DefaultDatastoreHedgingPercentile,
+				MinDelay:   DefaultDatastoreHedgingMinDelay,
+			},
+			CircuitBreaker: DatastoreCircuitBreakerConfig{
+				Enabled:          DefaultDatastoreCircuitBreakerEnabled,
+				FailureRatio:     DefaultDatastoreCircuitBreakerFailureRatio,
+				MinRequests:      DefaultDatastoreCircuitBreakerMinRequests,
+				Window:           DefaultDatastoreCircuitBreakerWindow,
+				LatencyThreshold: DefaultDatastoreCircuitBreakerLatencyThreshold,
+				OpenDuration:     DefaultDatastoreCircuitBreakerOpenDuration,
+			},
+			Memory: DatastoreMemoryConfig{
+				SnapshotInterval: DefaultDatastoreMemorySnapshotInterval,
+				LoadSnapshot:     DefaultDatastoreMemoryLoadSnapshot,
+			},
+			Residency: DatastoreResidencyConfig{
+				Name:       DefaultDatastoreResidencyName,
+				Datastores: []string{},
+				Stores:     []string{},
+			},
 		},
 		GRPC: GRPCConfig{
-			Addr: "0.0.0.0:8081",
-			TLS:  &TLSConfig{Enabled: false},
+			Addr:                  "0.0.0.0:8081",
+			TLS:                   &TLSConfig{Enabled: false},
+			ReflectionEnabled:     true,
+			Compression:           []string{},
+			MaxRecvMsgSizeInBytes: DefaultMaxRPCMessageSizeInBytes,
+			MaxSendMsgSizeInBytes: DefaultMaxSendMessageSizeInBytes,
+
+			MaxConcurrentStreams:        DefaultGRPCMaxConcurrentStreams,
+			MaxConnectionAge:            DefaultGRPCMaxConnectionAge,
+			MaxConnectionAgeGrace:       DefaultGRPCMaxConnectionAgeGrace,
+			KeepaliveTime:               DefaultGRPCKeepaliveTime,
+			KeepaliveTimeout:            DefaultGRPCKeepaliveTimeout,
+			KeepaliveEnforcementMinTime: DefaultGRPCKeepaliveEnforcementMinTime,
+
+			KeepaliveEnforcementPermitWithoutStream: false,
 		},
 		HTTP: HTTPConfig{
 			Enabled:            true,
@@ -416,16 +1879,32 @@ func DefaultConfig() *Config {
 			UpstreamTimeout:    5 * time.Second,
 			CORSAllowedOrigins: []string{"*"},
 			CORSAllowedHeaders: []string{"*"},
+			CORSExposedHeaders: []string{},
+			CORSMaxAge:         0,
+			OpenAPIEnabled:     false,
+
+			CompressionEnabled:        false,
+			CompressionMinSizeInBytes: DefaultHTTPCompressionMinSizeInBytes,
 		},
 		Authn: AuthnConfig{
 			Method:                  "none",
 			AuthnPresharedKeyConfig: &AuthnPresharedKeyConfig{},
 			AuthnOIDCConfig:         &AuthnOIDCConfig{},
 		},
+		ControlPlane: ControlPlaneConfig{
+			Enabled: false,
+			Addr:    "0.0.0.0:8082",
+			Authn: AuthnConfig{
+				Method:                  "none",
+				AuthnPresharedKeyConfig: &AuthnPresharedKeyConfig{},
+				AuthnOIDCConfig:         &AuthnOIDCConfig{},
+			},
+		},
 		Log: LogConfig{
 			Format:          "text",
 			Level:           "info",
 			TimestampFormat: "Unix",
+			ModuleLevels:    []string{},
 		},
 		Trace: TraceConfig{
 			Enabled: false,
@@ -442,19 +1921,44 @@ func DefaultConfig() *Config {
 			Enabled: true,
 			Port:    3000,
 		},
+		ModelEditor: ModelEditorConfig{
+			Enabled:   DefaultModelEditorEnabled,
+			MaxTuples: DefaultModelEditorMaxTuples,
+		},
 		Profiler: ProfilerConfig{
 			Enabled: false,
 			Addr:    ":3001",
 		},
+		Admin: AdminConfig{
+			Enabled: false,
+			Addr:    "127.0.0.1:3002",
+		},
 		Metrics: MetricConfig{
 			Enabled:             true,
 			Addr:                "0.0.0.0:2112",
 			EnableRPCHistograms: false,
+			OTLP: OTLPMetricConfig{
+				Enabled:  false,
+				Endpoint: "0.0.0.0:4317",
+				TLS: OTLPMetricTLSConfig{
+					Enabled: false,
+				},
+				ExportInterval: DefaultMetricsOTLPExportInterval,
+			},
 		},
 		CheckQueryCache: CheckQueryCache{
 			Enabled: DefaultCheckQueryCacheEnable,
 			Limit:   DefaultCheckQueryCacheLimit,
 			TTL:     DefaultCheckQueryCacheTTL,
+
+			WatermarkEnabled:         DefaultCheckQueryCacheWatermarkEnabled,
+			WatermarkRefreshInterval: DefaultCheckQueryCacheWatermarkRefreshInterval,
+		},
+		ListObjectsCache: ListObjectsCacheConfig{
+			Enabled:                  DefaultListObjectsCacheEnabled,
+			Limit:                    DefaultListObjectsCacheLimit,
+			TTL:                      DefaultListObjectsCacheTTL,
+			WatermarkRefreshInterval: DefaultListObjectsCacheWatermarkRefreshInterval,
 		},
 		DispatchThrottling: DispatchThrottlingConfig{
 			Enabled:      DefaultDispatchThrottlingEnabled,
@@ -462,7 +1966,127 @@ func DefaultConfig() *Config {
 			Threshold:    DefaultDispatchThrottlingDefaultThreshold,
 			MaxThreshold: DefaultDispatchThrottlingMaxThreshold,
 		},
+		SlowRequestLog: SlowRequestLogConfig{
+			Enabled:   DefaultSlowRequestLogEnabled,
+			Threshold: DefaultSlowRequestLogThreshold,
+		},
+		AdmissionControl: AdmissionControlConfig{
+			Enabled:             DefaultAdmissionControlEnabled,
+			MaxInflightRequests: DefaultAdmissionControlMaxInflightRequests,
+			MaxGoroutines:       DefaultAdmissionControlMaxGoroutines,
+			MaxMemoryBytes:      DefaultAdmissionControlMaxMemoryBytes,
+			LowPriorityRatio:    DefaultAdmissionControlLowPriorityRatio,
+			HighDispatchCount:   DefaultAdmissionControlHighDispatchCount,
+			RetryAfter:          DefaultAdmissionControlRetryAfter,
+		},
+		CheckBudget: CheckBudgetConfig{
+			MaxDispatchCount:      DefaultCheckBudgetMaxDispatchCount,
+			MaxDatastoreReadCount: DefaultCheckBudgetMaxDatastoreReadCount,
+		},
+		CheckReadDeduplication: CheckReadDeduplicationConfig{
+			Enabled:          DefaultCheckReadDeduplicationEnabled,
+			MaxTuplesPerRead: DefaultCheckReadDeduplicationMaxTuplesPerRead,
+		},
+		CheckDispatchPool: CheckDispatchPoolConfig{
+			Enabled: DefaultCheckDispatchPoolEnabled,
+			Size:    DefaultCheckDispatchPoolSize,
+		},
+		TypesystemCache: TypesystemCacheConfig{
+			MaxSize: DefaultTypesystemCacheMaxSize,
+		},
+		CheckPlanner: CheckPlannerConfig{
+			Enabled:            DefaultCheckPlannerEnabled,
+			StatisticsInterval: DefaultCheckPlannerStatisticsInterval,
+			MaxStores:          DefaultCheckPlannerMaxStores,
+		},
+		TupleStatistics: TupleStatisticsConfig{
+			Enabled:           DefaultTupleStatisticsEnabled,
+			Interval:          DefaultTupleStatisticsInterval,
+			MaxTuplesPerStore: DefaultTupleStatisticsMaxTuplesPerStore,
+		},
+		RelationUsage: RelationUsageConfig{
+			Enabled:       DefaultRelationUsageEnabled,
+			SampleRatio:   DefaultRelationUsageSampleRatio,
+			FlushInterval: DefaultRelationUsageFlushInterval,
+		},
+		IDGeneration: IDGenerationConfig{
+			Scheme:                 DefaultIDGenerationScheme,
+			CallerSuppliedStoreIDs: DefaultIDGenerationCallerSuppliedStoreIDs,
+		},
+		Import: ImportConfig{
+			StoreName: DefaultImportStoreName,
+		},
+		Backup: BackupConfig{
+			Enabled:  DefaultBackupEnabled,
+			Interval: DefaultBackupInterval,
+			Region:   DefaultBackupRegion,
+		},
+		IntegrityChecker: IntegrityCheckerConfig{
+			Enabled:           DefaultIntegrityCheckerEnabled,
+			Interval:          DefaultIntegrityCheckerInterval,
+			Cleanup:           DefaultIntegrityCheckerCleanup,
+			MaxReportedTuples: DefaultIntegrityCheckerMaxReportedTuples,
+		},
+		Metering: MeteringConfig{
+			Enabled:      DefaultMeteringEnabled,
+			Window:       DefaultMeteringWindow,
+			Retention:    DefaultMeteringRetention,
+			ExportFormat: DefaultMeteringExportFormat,
+		},
+		Warmup: WarmupConfig{
+			Enabled: DefaultWarmupEnabled,
+			Timeout: DefaultWarmupTimeout,
+		},
+		ChangePublisher: ChangePublisherConfig{
+			Enabled:       DefaultChangePublisherEnabled,
+			Interval:      DefaultChangePublisherInterval,
+			PageSize:      DefaultChangePublisherPageSize,
+			HorizonOffset: DefaultChangePublisherHorizonOffset,
+			Consumer:      DefaultChangePublisherConsumer,
+			Topic:         DefaultChangePublisherTopic,
+			Topics:        []string{},
+			Timeout:       DefaultChangePublisherTimeout,
+		},
+		WriteAdmissionWebhook: WriteAdmissionWebhookConfig{
+			Timeout:       DefaultWriteAdmissionWebhookTimeout,
+			FailurePolicy: DefaultWriteAdmissionWebhookFailurePolicy,
+		},
+		WriteGroups: WriteGroupsConfig{
+			SagaEnabled: DefaultWriteGroupsSagaEnabled,
+		},
+		DelegatedCheck: DelegatedCheckConfig{
+			Relations: []string{},
+			Operator:  DefaultDelegatedCheckOperator,
+			Timeout:   DefaultDelegatedCheckTimeout,
+		},
+		CheckCanary: CheckCanaryConfig{
+			Enabled:                  DefaultCheckCanaryEnabled,
+			SampleRatio:              DefaultCheckCanarySampleRatio,
+			Timeout:                  DefaultCheckCanaryTimeout,
+			MaxConcurrentEvaluations: DefaultCheckCanaryMaxConcurrentEvaluations,
+		},
+		WriteCoalescing: WriteCoalescingConfig{
+			Enabled: DefaultWriteCoalescingEnabled,
+			Window:  DefaultWriteCoalescingWindow,
+		},
+		PeerDispatch: PeerDispatchConfig{
+			Enabled:            DefaultPeerDispatchEnabled,
+			Peers:              []string{},
+			DNSRefreshInterval: DefaultPeerDispatchDNSRefreshInterval,
+			Timeout:            DefaultPeerDispatchTimeout,
+		},
+		TupleValidation: TupleValidationConfig{
+			MaxObjectLength: DefaultTupleValidationMaxObjectLength,
+			MaxUserLength:   DefaultTupleValidationMaxUserLength,
+			IDPatterns:      []string{},
+		},
+		TimeTravel: TimeTravelConfig{
+			Enabled:    DefaultTimeTravelEnabled,
+			MaxChanges: DefaultTimeTravelMaxChanges,
+			Retention:  DefaultTimeTravelRetention,
+		},
 		RequestTimeout: DefaultRequestTimeout,
+		DrainTimeout:   DefaultDrainTimeout,
 	}
 }
 
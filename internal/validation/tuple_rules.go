@@ -0,0 +1,109 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// UUIDPattern is the name of the built-in pattern of the ID pattern rules that matches UUIDs.
+const UUIDPattern = "uuid"
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// TupleKeyRules are the deployment-specific rules that the tuples written must follow on top of
+// the validation of the API and of the model: maximum lengths of the object and user, and patterns
+// that the IDs of the objects of some types must match. A nil TupleKeyRules accepts all tuples.
+type TupleKeyRules struct {
+	maxObjectLength int
+	maxUserLength   int
+	idPatterns      map[string]*regexp.Regexp
+}
+
+// NewTupleKeyRules returns the TupleKeyRules with the maximum numbers of bytes of the object and of
+// the user of a tuple, 0 meaning no limit, and the ID pattern rules. An ID pattern rule is of the
+// form 'type=pattern', where the pattern is either 'uuid' or a regular expression that the whole ID
+// of the objects of the type must match, e.g. 'document=uuid' or 'user=[a-z0-9_]+'.
+func NewTupleKeyRules(maxObjectLength, maxUserLength int, idPatterns []string) (*TupleKeyRules, error) {
+	rules := &TupleKeyRules{
+		maxObjectLength: maxObjectLength,
+		maxUserLength:   maxUserLength,
+		idPatterns:      make(map[string]*regexp.Regexp, len(idPatterns)),
+	}
+
+	for _, rule := range idPatterns {
+		objectType, pattern, ok := strings.Cut(rule, "=")
+		if !ok || objectType == "" || pattern == "" {
+			return nil, fmt.Errorf("invalid ID pattern rule '%s', expected 'type=pattern'", rule)
+		}
+
+		if _, ok := rules.idPatterns[objectType]; ok {
+			return nil, fmt.Errorf("duplicate ID pattern rule for type '%s'", objectType)
+		}
+
+		if pattern == UUIDPattern {
+			rules.idPatterns[objectType] = uuidRegex
+			continue
+		}
+
+		regex, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID pattern of type '%s': %w", objectType, err)
+		}
+		rules.idPatterns[objectType] = regex
+	}
+
+	return rules, nil
+}
+
+// ValidateTupleKey returns an error if the object or the user of the tuple is longer than allowed,
+// or if the ID of its object or of the object of its user doesn't match the pattern of its type.
+// Typed wildcards (e.g. 'user:*') don't have to match the pattern of their type.
+func (r *TupleKeyRules) ValidateTupleKey(tk *openfgav1.TupleKey) error {
+	if r == nil {
+		return nil
+	}
+
+	if r.maxObjectLength > 0 && len(tk.GetObject()) > r.maxObjectLength {
+		return &tuple.InvalidTupleError{
+			Cause:    fmt.Errorf("the 'object' field exceeds the maximum length of %d bytes", r.maxObjectLength),
+			TupleKey: tk,
+		}
+	}
+
+	if r.maxUserLength > 0 && len(tk.GetUser()) > r.maxUserLength {
+		return &tuple.InvalidTupleError{
+			Cause:    fmt.Errorf("the 'user' field exceeds the maximum length of %d bytes", r.maxUserLength),
+			TupleKey: tk,
+		}
+	}
+
+	if err := r.validateID(tk.GetObject()); err != nil {
+		return &tuple.InvalidTupleError{Cause: fmt.Errorf("the 'object' field %w", err), TupleKey: tk}
+	}
+
+	userObject, _ := tuple.SplitObjectRelation(tk.GetUser())
+	if !tuple.IsWildcard(userObject) {
+		if err := r.validateID(userObject); err != nil {
+			return &tuple.InvalidTupleError{Cause: fmt.Errorf("the 'user' field %w", err), TupleKey: tk}
+		}
+	}
+
+	return nil
+}
+
+// validateID returns an error if the ID of the object doesn't match the pattern of its type, if any.
+func (r *TupleKeyRules) validateID(object string) error {
+	objectType, objectID := tuple.SplitObject(object)
+
+	regex, ok := r.idPatterns[objectType]
+	if !ok || regex.MatchString(objectID) {
+		return nil
+	}
+
+	return fmt.Errorf("has an ID '%s' that doesn't match the ID pattern of type '%s'", objectID, objectType)
+}
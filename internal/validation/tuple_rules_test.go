@@ -0,0 +1,97 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestNewTupleKeyRules(t *testing.T) {
+	_, err := NewTupleKeyRules(0, 0, []string{"document=uuid", "user=[a-z0-9_]+"})
+	require.NoError(t, err)
+
+	_, err = NewTupleKeyRules(0, 0, []string{"document"})
+	require.ErrorContains(t, err, "expected 'type=pattern'")
+
+	_, err = NewTupleKeyRules(0, 0, []string{"document=uuid", "document=[0-9]+"})
+	require.ErrorContains(t, err, "duplicate ID pattern rule for type 'document'")
+
+	_, err = NewTupleKeyRules(0, 0, []string{"document=[0-9"})
+	require.ErrorContains(t, err, "invalid ID pattern of type 'document'")
+}
+
+func TestTupleKeyRulesValidateTupleKey(t *testing.T) {
+	const documentID = "0b5c1a4e-7f3d-4c1e-9a2b-6d8e0f1a2b3c"
+
+	rules, err := NewTupleKeyRules(48, 64, []string{"document=uuid", "user=[a-z]+"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		tuple         []string
+		expectedError string
+	}{
+		{
+			name:  "valid",
+			tuple: []string{"document:" + documentID, "viewer", "user:anne"},
+		},
+		{
+			name:  "types_without_rules",
+			tuple: []string{"folder:1", "viewer", "group:eng#member"},
+		},
+		{
+			name:  "typed_wildcard",
+			tuple: []string{"document:" + documentID, "viewer", "user:*"},
+		},
+		{
+			name:  "userset",
+			tuple: []string{"folder:1", "parent", "document:" + documentID + "#viewer"},
+		},
+		{
+			name:          "object_too_long",
+			tuple:         []string{"folder:" + strings.Repeat("1", 48), "viewer", "user:anne"},
+			expectedError: "the 'object' field exceeds the maximum length of 48 bytes",
+		},
+		{
+			name:          "user_too_long",
+			tuple:         []string{"folder:1", "viewer", "user:" + strings.Repeat("a", 60)},
+			expectedError: "the 'user' field exceeds the maximum length of 64 bytes",
+		},
+		{
+			name:          "object_id_not_matching",
+			tuple:         []string{"document:1", "viewer", "user:anne"},
+			expectedError: "the 'object' field has an ID '1' that doesn't match the ID pattern of type 'document'",
+		},
+		{
+			name:          "user_id_not_matching",
+			tuple:         []string{"folder:1", "viewer", "user:anne1"},
+			expectedError: "the 'user' field has an ID 'anne1' that doesn't match the ID pattern of type 'user'",
+		},
+		{
+			name:          "userset_id_not_matching",
+			tuple:         []string{"folder:1", "parent", "document:1#viewer"},
+			expectedError: "the 'user' field has an ID '1' that doesn't match the ID pattern of type 'document'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := rules.ValidateTupleKey(tuple.NewTupleKey(test.tuple[0], test.tuple[1], test.tuple[2]))
+			if test.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, &tuple.InvalidTupleError{})
+			require.ErrorContains(t, err, test.expectedError)
+		})
+	}
+
+	t.Run("nil_rules", func(t *testing.T) {
+		var rules *TupleKeyRules
+		require.NoError(t, rules.ValidateTupleKey(tuple.NewTupleKey("document:1", "viewer", "user:anne")))
+	})
+}
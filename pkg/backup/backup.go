@@ -0,0 +1,426 @@
+// Package backup exports the data of the stores of a datastore to snapshots in object storage (e.g.
+// S3 or GCS), periodically with a [Scheduler], and restores them, independently of the datastore
+// engine.
+//
+// A snapshot is a gzipped JSON Lines file. Its first line is the [Manifest] of the snapshot, and each
+// of the other lines is a record of the authorization models (from oldest to newest), their
+// assertions, the settings, the labels or a tuple of the store.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// SnapshotVersion is the version of the format of the snapshots that are exported.
+const SnapshotVersion = 1
+
+// maxRecordSize is the maximum size of a line of a snapshot that is restored.
+const maxRecordSize = 16 * 1024 * 1024
+
+// ErrStoreExists is returned by Restore if the store of the snapshot already exists.
+var ErrStoreExists = errors.New("the store already exists")
+
+// HighWaterMark identifies the last change of the changelog of a store that a snapshot includes.
+// The tuples of the snapshot are the result of all the changes up to it, and of none after it.
+type HighWaterMark struct {
+	// ContinuationToken is the continuation token of the datastore after the last change, which
+	// the datastore can read the changes after the snapshot from. It is empty if the store has no
+	// changes.
+	ContinuationToken string `json:"continuation_token,omitempty"`
+
+	// Timestamp is the time of the last change. It is zero if the store has no changes.
+	Timestamp time.Time `json:"timestamp"`
+
+	// ChangeCount is the number of changes up to the last change.
+	ChangeCount int `json:"change_count"`
+}
+
+// Manifest describes a snapshot of a store.
+type Manifest struct {
+	Version                int           `json:"version"`
+	StoreID                string        `json:"store_id"`
+	StoreName              string        `json:"store_name"`
+	CreatedAt              time.Time     `json:"created_at"`
+	ModelCount             int           `json:"model_count"`
+	TupleCount             int           `json:"tuple_count"`
+	ChangelogHighWaterMark HighWaterMark `json:"changelog_high_water_mark"`
+}
+
+type assertionsRecord struct {
+	AuthorizationModelID string            `json:"authorization_model_id"`
+	Assertions           []json.RawMessage `json:"assertions"`
+}
+
+type settingsRecord struct {
+	DefaultAuthorizationModelID   string        `json:"default_authorization_model_id,omitempty"`
+	DefaultConsistency            string        `json:"default_consistency,omitempty"`
+	CheckQueryCacheTTL            time.Duration `json:"check_query_cache_ttl,omitempty"`
+	CandidateAuthorizationModelID string        `json:"candidate_authorization_model_id,omitempty"`
+}
+
+// record is a line of a snapshot after the manifest. Exactly one of its fields is set.
+type record struct {
+	AuthorizationModel json.RawMessage   `json:"authorization_model,omitempty"`
+	Assertions         *assertionsRecord `json:"assertions,omitempty"`
+	Settings           *settingsRecord   `json:"settings,omitempty"`
+	Labels             map[string]string `json:"labels,omitempty"`
+	Tuple              json.RawMessage   `json:"tuple,omitempty"`
+}
+
+// Export writes a snapshot of the store to w, and returns its manifest. The tuples are read by
+// replaying the changelog of the store up to the time the export starts, so that they are consistent
+// with the high-water mark of the manifest even if the store is written to during the export, and
+// they are held in memory until they are written.
+func Export(ctx context.Context, datastore storage.OpenFGADatastore, storeID string, w io.Writer) (*Manifest, error) {
+	store, err := datastore.GetStore(ctx, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+
+	manifest := &Manifest{
+		Version:   SnapshotVersion,
+		StoreID:   store.GetId(),
+		StoreName: store.GetName(),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	tuples, mark, err := replayChanges(ctx, datastore, storeID, manifest.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	manifest.ChangelogHighWaterMark = *mark
+	manifest.TupleCount = len(tuples)
+
+	models, err := readAuthorizationModels(ctx, datastore, storeID)
+	if err != nil {
+		return nil, err
+	}
+	manifest.ModelCount = len(models)
+
+	var records []record
+	for _, model := range models {
+		data, err := protojson.Marshal(model)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record{AuthorizationModel: data})
+
+		assertions, err := datastore.ReadAssertions(ctx, storeID, model.GetId())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read assertions: %w", err)
+		}
+
+		if len(assertions) > 0 {
+			r := &assertionsRecord{AuthorizationModelID: model.GetId()}
+			for _, assertion := range assertions {
+				data, err := protojson.Marshal(assertion)
+				if err != nil {
+					return nil, err
+				}
+				r.Assertions = append(r.Assertions, data)
+			}
+			records = append(records, record{Assertions: r})
+		}
+	}
+
+	settings, err := datastore.ReadStoreSettings(ctx, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store settings: %w", err)
+	}
+
+	if *settings != (storage.StoreSettings{}) {
+		records = append(records, record{Settings: &settingsRecord{
+			DefaultAuthorizationModelID:   settings.DefaultAuthorizationModelID,
+			DefaultConsistency:            settings.DefaultConsistency,
+			CheckQueryCacheTTL:            settings.CheckQueryCacheTTL,
+			CandidateAuthorizationModelID: settings.CandidateAuthorizationModelID,
+		}})
+	}
+
+	labels, err := datastore.ReadStoreLabels(ctx, []string{storeID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store labels: %w", err)
+	}
+
+	if storeLabels := labels[storeID]; len(storeLabels) > 0 {
+		records = append(records, record{Labels: storeLabels})
+	}
+
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+
+	if err := enc.Encode(manifest); err != nil {
+		return nil, err
+	}
+
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tk := range tuples {
+		data, err := protojson.Marshal(tk)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := enc.Encode(record{Tuple: data}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// replayChanges replays the changes of the store made before the cutoff, and returns the resulting
+// tuples, sorted, and the high-water mark of the last change.
+func replayChanges(ctx context.Context, datastore storage.OpenFGADatastore, storeID string, cutoff time.Time) ([]*openfgav1.TupleKey, *HighWaterMark, error) {
+	tuples := map[string]*openfgav1.TupleKey{}
+	mark := &HighWaterMark{}
+
+	for {
+		changes, token, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{
+			PageSize: storage.DefaultPageSize,
+			From:     mark.ContinuationToken,
+		}, time.Since(cutoff).Truncate(time.Millisecond))
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				break
+			}
+			return nil, nil, fmt.Errorf("failed to read changes: %w", err)
+		}
+
+		for _, change := range changes {
+			key := tuple.TupleKeyToString(change.GetTupleKey())
+			if change.GetOperation() == openfgav1.TupleOperation_TUPLE_OPERATION_DELETE {
+				delete(tuples, key)
+			} else {
+				tuples[key] = change.GetTupleKey()
+			}
+
+			mark.Timestamp = change.GetTimestamp().AsTime()
+			mark.ChangeCount++
+		}
+
+		mark.ContinuationToken = string(token)
+	}
+
+	keys := make([]string, 0, len(tuples))
+	for key := range tuples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]*openfgav1.TupleKey, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, tuples[key])
+	}
+
+	return sorted, mark, nil
+}
+
+// readAuthorizationModels returns the models of the store, from oldest to newest.
+func readAuthorizationModels(ctx context.Context, datastore storage.OpenFGADatastore, storeID string) ([]*openfgav1.AuthorizationModel, error) {
+	var models []*openfgav1.AuthorizationModel
+
+	var from string
+	for {
+		page, token, err := datastore.ReadAuthorizationModels(ctx, storeID, storage.PaginationOptions{PageSize: storage.DefaultPageSize, From: from})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read authorization models: %w", err)
+		}
+		models = append(models, page...)
+
+		from = string(token)
+		if from == "" {
+			break
+		}
+	}
+
+	for i, j := 0, len(models)-1; i < j; i, j = i+1, j-1 {
+		models[i], models[j] = models[j], models[i]
+	}
+
+	return models, nil
+}
+
+// RestoreOption defines an option that can be used to change the behavior of Restore.
+type RestoreOption func(o *restoreOptions)
+
+type restoreOptions struct {
+	storeID string
+}
+
+// WithStoreID restores the snapshot into a store with the ID instead of the ID of the store of the
+// snapshot, e.g. to restore a copy of a store that still exists.
+func WithStoreID(storeID string) RestoreOption {
+	return func(o *restoreOptions) {
+		o.storeID = storeID
+	}
+}
+
+// Restore creates the store of the snapshot read from r, with its authorization models, assertions,
+// settings, labels and tuples, and returns the manifest of the snapshot. It returns ErrStoreExists
+// if the store already exists. The store is deleted if the snapshot fails to be restored, so that
+// a failed restore leaves no partially restored store behind. The tuples are written like any other
+// tuples, so the changelog of the restored store starts with their writes.
+func Restore(ctx context.Context, datastore storage.OpenFGADatastore, r io.Reader, opts ...RestoreOption) (*Manifest, error) {
+	var o restoreOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("invalid snapshot: %w", err)
+		}
+		return nil, errors.New("invalid snapshot: missing manifest")
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(scanner.Bytes(), manifest); err != nil {
+		return nil, fmt.Errorf("invalid snapshot manifest: %w", err)
+	}
+
+	if manifest.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", manifest.Version)
+	}
+
+	storeID := manifest.StoreID
+	if o.storeID != "" {
+		storeID = o.storeID
+	}
+
+	_, err = datastore.GetStore(ctx, storeID)
+	switch {
+	case err == nil:
+		return nil, fmt.Errorf("%w: '%s'", ErrStoreExists, storeID)
+	case !errors.Is(err, storage.ErrNotFound):
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+
+	if _, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: manifest.StoreName}); err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+
+	if err := restoreRecords(ctx, datastore, storeID, scanner); err != nil {
+		if deleteErr := datastore.DeleteStore(ctx, storeID); deleteErr != nil {
+			return nil, fmt.Errorf("%w (failed to delete the partially restored store '%s': %v)", err, storeID, deleteErr)
+		}
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// restoreRecords writes the records of the snapshot after its manifest into the store.
+func restoreRecords(ctx context.Context, datastore storage.OpenFGADatastore, storeID string, scanner *bufio.Scanner) error {
+	var writes storage.Writes
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+
+		if err := datastore.Write(ctx, storeID, nil, writes); err != nil {
+			return fmt.Errorf("failed to write tuples: %w", err)
+		}
+		writes = nil
+		return nil
+	}
+
+	line := 1
+	for scanner.Scan() {
+		line++
+
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return fmt.Errorf("invalid snapshot record on line %d: %w", line, err)
+		}
+
+		switch {
+		case r.Tuple != nil:
+			tk := &openfgav1.TupleKey{}
+			if err := protojson.Unmarshal(r.Tuple, tk); err != nil {
+				return fmt.Errorf("invalid tuple on line %d: %w", line, err)
+			}
+
+			writes = append(writes, tk)
+			if len(writes) == datastore.MaxTuplesPerWrite() {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case r.AuthorizationModel != nil:
+			model := &openfgav1.AuthorizationModel{}
+			if err := protojson.Unmarshal(r.AuthorizationModel, model); err != nil {
+				return fmt.Errorf("invalid authorization model on line %d: %w", line, err)
+			}
+
+			if err := datastore.WriteAuthorizationModel(ctx, storeID, model); err != nil {
+				return fmt.Errorf("failed to write authorization model '%s': %w", model.GetId(), err)
+			}
+		case r.Assertions != nil:
+			assertions := make([]*openfgav1.Assertion, 0, len(r.Assertions.Assertions))
+			for _, data := range r.Assertions.Assertions {
+				assertion := &openfgav1.Assertion{}
+				if err := protojson.Unmarshal(data, assertion); err != nil {
+					return fmt.Errorf("invalid assertion on line %d: %w", line, err)
+				}
+				assertions = append(assertions, assertion)
+			}
+
+			if err := datastore.WriteAssertions(ctx, storeID, r.Assertions.AuthorizationModelID, assertions); err != nil {
+				return fmt.Errorf("failed to write assertions: %w", err)
+			}
+		case r.Settings != nil:
+			if err := datastore.WriteStoreSettings(ctx, storeID, &storage.StoreSettings{
+				DefaultAuthorizationModelID:   r.Settings.DefaultAuthorizationModelID,
+				DefaultConsistency:            r.Settings.DefaultConsistency,
+				CheckQueryCacheTTL:            r.Settings.CheckQueryCacheTTL,
+				CandidateAuthorizationModelID: r.Settings.CandidateAuthorizationModelID,
+			}); err != nil {
+				return fmt.Errorf("failed to write store settings: %w", err)
+			}
+		case r.Labels != nil:
+			if err := datastore.WriteStoreLabels(ctx, storeID, r.Labels); err != nil {
+				return fmt.Errorf("failed to write store labels: %w", err)
+			}
+		default:
+			return fmt.Errorf("invalid snapshot record on line %d: unknown record", line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	return flush()
+}
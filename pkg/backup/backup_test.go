@@ -0,0 +1,257 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func readTuples(t *testing.T, datastore storage.OpenFGADatastore, storeID string) []string {
+	t.Helper()
+
+	tuples, _, err := datastore.ReadPage(context.Background(), storeID, &openfgav1.TupleKey{}, storage.NewPaginationOptions(100, ""))
+	require.NoError(t, err)
+
+	keys := make([]string, 0, len(tuples))
+	for _, tk := range tuples {
+		key := tuple.TupleKeyToString(tk.GetKey())
+		if condition := tk.GetKey().GetCondition(); condition != nil {
+			key += " with " + condition.GetName() + " " + condition.GetContext().AsMap()["office"].(string)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func setupStore(t *testing.T, datastore storage.OpenFGADatastore) string {
+	t.Helper()
+	ctx := context.Background()
+
+	store, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "backup"})
+	require.NoError(t, err)
+
+	first := testutils.MustTransformDSLToProtoWithID(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	require.NoError(t, datastore.WriteAuthorizationModel(ctx, store.GetId(), first))
+
+	latest := testutils.MustTransformDSLToProtoWithID(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user, user with in_office]
+condition in_office(office: string) {
+  office == "paris"
+}`)
+	require.NoError(t, datastore.WriteAuthorizationModel(ctx, store.GetId(), latest))
+
+	require.NoError(t, datastore.WriteAssertions(ctx, store.GetId(), latest.GetId(), []*openfgav1.Assertion{
+		{TupleKey: tuple.NewAssertionTupleKey("document:1", "viewer", "user:anne"), Expectation: true},
+	}))
+	require.NoError(t, datastore.WriteStoreSettings(ctx, store.GetId(), &storage.StoreSettings{DefaultAuthorizationModelID: first.GetId()}))
+	require.NoError(t, datastore.WriteStoreLabels(ctx, store.GetId(), map[string]string{"team": "docs"}))
+
+	officeContext, err := structpb.NewStruct(map[string]interface{}{"office": "paris"})
+	require.NoError(t, err)
+
+	require.NoError(t, datastore.Write(ctx, store.GetId(), nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+		tuple.NewTupleKeyWithCondition("document:2", "viewer", "user:carl", "in_office", officeContext),
+	}))
+	require.NoError(t, datastore.Write(ctx, store.GetId(), []*openfgav1.TupleKeyWithoutCondition{
+		tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:bob")),
+	}, nil))
+
+	return store.GetId()
+}
+
+func TestExportAndRestore(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	source := memory.New()
+	t.Cleanup(source.Close)
+	storeID := setupStore(t, source)
+
+	var snapshot bytes.Buffer
+	manifest, err := Export(ctx, source, storeID, &snapshot)
+	require.NoError(t, err)
+	require.Equal(t, storeID, manifest.StoreID)
+	require.Equal(t, "backup", manifest.StoreName)
+	require.Equal(t, 2, manifest.ModelCount)
+	require.Equal(t, 2, manifest.TupleCount)
+	require.Equal(t, 4, manifest.ChangelogHighWaterMark.ChangeCount)
+	require.NotEmpty(t, manifest.ChangelogHighWaterMark.ContinuationToken)
+
+	// the high-water mark is after the last change
+	_, _, err = source.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{From: manifest.ChangelogHighWaterMark.ContinuationToken}, 0)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	target := memory.New()
+	t.Cleanup(target.Close)
+
+	restored, err := Restore(ctx, target, bytes.NewReader(snapshot.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, manifest.StoreID, restored.StoreID)
+	require.Equal(t, manifest.ChangelogHighWaterMark, restored.ChangelogHighWaterMark)
+
+	store, err := target.GetStore(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, "backup", store.GetName())
+
+	require.ElementsMatch(t, readTuples(t, source, storeID), readTuples(t, target, storeID))
+	require.ElementsMatch(t, []string{
+		"document:1#viewer@user:anne",
+		"document:2#viewer@user:carl with in_office paris",
+	}, readTuples(t, target, storeID))
+
+	sourceLatest, err := source.FindLatestAuthorizationModel(ctx, storeID)
+	require.NoError(t, err)
+	targetLatest, err := target.FindLatestAuthorizationModel(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, sourceLatest.GetId(), targetLatest.GetId())
+
+	assertions, err := target.ReadAssertions(ctx, storeID, targetLatest.GetId())
+	require.NoError(t, err)
+	require.Len(t, assertions, 1)
+
+	sourceSettings, err := source.ReadStoreSettings(ctx, storeID)
+	require.NoError(t, err)
+	targetSettings, err := target.ReadStoreSettings(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, sourceSettings, targetSettings)
+
+	labels, err := target.ReadStoreLabels(ctx, []string{storeID})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "docs"}, labels[storeID])
+
+	t.Run("existing_store", func(t *testing.T) {
+		_, err := Restore(ctx, target, bytes.NewReader(snapshot.Bytes()))
+		require.ErrorIs(t, err, ErrStoreExists)
+	})
+
+	t.Run("other_store_id", func(t *testing.T) {
+		otherID := ulid.Make().String()
+		_, err := Restore(ctx, target, bytes.NewReader(snapshot.Bytes()), WithStoreID(otherID))
+		require.NoError(t, err)
+		require.ElementsMatch(t, readTuples(t, source, storeID), readTuples(t, target, otherID))
+	})
+
+	t.Run("failed_restore_deletes_the_store", func(t *testing.T) {
+		otherID := ulid.Make().String()
+		truncated := snapshot.Bytes()[:snapshot.Len()-10]
+		_, err := Restore(ctx, target, bytes.NewReader(truncated), WithStoreID(otherID))
+		require.Error(t, err)
+
+		_, err = target.GetStore(ctx, otherID)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("empty_store", func(t *testing.T) {
+		store, err := source.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "empty"})
+		require.NoError(t, err)
+
+		snapshot.Reset()
+		manifest, err := Export(ctx, source, store.GetId(), &snapshot)
+		require.NoError(t, err)
+		require.Equal(t, HighWaterMark{}, manifest.ChangelogHighWaterMark)
+
+		_, err = Restore(ctx, target, &snapshot)
+		require.NoError(t, err)
+	})
+}
+
+func TestScheduler(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	datastore := memory.New()
+	t.Cleanup(datastore.Close)
+	storeID := setupStore(t, datastore)
+
+	objects, err := OpenObjectStorage("file://" + t.TempDir())
+	require.NoError(t, err)
+
+	scheduler := NewScheduler(datastore, objects, WithInterval(0))
+	t.Cleanup(scheduler.Close)
+
+	require.NoError(t, scheduler.BackupAll(ctx))
+
+	manifest, err := scheduler.BackupStore(ctx, storeID)
+	require.NoError(t, err)
+
+	snapshot, err := objects.Get(ctx, SnapshotName(storeID, manifest.CreatedAt))
+	require.NoError(t, err)
+	defer snapshot.Close()
+
+	target := memory.New()
+	t.Cleanup(target.Close)
+
+	_, err = Restore(ctx, target, snapshot)
+	require.NoError(t, err)
+	require.ElementsMatch(t, readTuples(t, datastore, storeID), readTuples(t, target, storeID))
+
+	t.Run("periodic", func(t *testing.T) {
+		dir := t.TempDir()
+		objects, err := OpenObjectStorage(dir)
+		require.NoError(t, err)
+
+		scheduler := NewScheduler(datastore, objects, WithInterval(10*time.Millisecond))
+		defer scheduler.Close()
+
+		require.Eventually(t, func() bool {
+			entries, err := os.ReadDir(dir + "/" + storeID)
+			return err == nil && len(entries) > 0
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestDirStorage(t *testing.T) {
+	ctx := context.Background()
+
+	objects, err := OpenObjectStorage(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, objects.Put(ctx, "store/snapshot", bytes.NewReader([]byte("data"))))
+
+	r, err := objects.Get(ctx, "store/snapshot")
+	require.NoError(t, err)
+	defer r.Close()
+
+	data := make([]byte, 4)
+	_, err = r.Read(data)
+	require.NoError(t, err)
+	require.Equal(t, "data", string(data))
+
+	_, err = objects.Get(ctx, "store/missing")
+	require.True(t, errors.Is(err, os.ErrNotExist))
+
+	for _, name := range []string{"", "../escape", "store/../../escape", "/absolute"} {
+		require.Error(t, objects.Put(ctx, name, bytes.NewReader(nil)), name)
+	}
+}
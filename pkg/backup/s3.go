@@ -0,0 +1,218 @@
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+
+	gcsEndpoint = "https://storage.googleapis.com"
+	gcsRegion   = "auto"
+
+	// maxErrorBodySize is the maximum number of bytes of the body of an error response that is
+	// reported.
+	maxErrorBodySize = 1024
+)
+
+// Credentials are the keys that the requests to an S3 compatible API are signed with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken is the token of temporary credentials, if they are.
+	SessionToken string
+}
+
+// CredentialsFromEnv returns the credentials of the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN environment variables.
+func CredentialsFromEnv() *Credentials {
+	return &Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// s3Storage stores the objects in a bucket of an S3 compatible API, with path-style requests signed
+// with AWS Signature Version 4.
+type s3Storage struct {
+	endpoint    string
+	bucket      string
+	prefix      string
+	region      string
+	credentials Credentials
+	client      *http.Client
+}
+
+var _ ObjectStorage = (*s3Storage)(nil)
+
+// Put see [Writer].Put.
+func (s *s3Storage) Put(ctx context.Context, name string, body io.ReadSeeker) error {
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return err
+	}
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(name), io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	resp, err := s.do(req, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Get see [Reader].Get.
+func (s *s3Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (s *s3Storage) objectURL(name string) string {
+	key := name
+	if s.prefix != "" {
+		key = s.prefix + "/" + name
+	}
+
+	return s.endpoint + "/" + uriEncode(s.bucket) + "/" + uriEncodePath(key)
+}
+
+// do signs and sends the request, and returns an error if its status isn't 2xx.
+func (s *s3Storage) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	signRequest(req, payloadHash, s.region, s.credentials, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+		err := fmt.Errorf("%s %s: unexpected status %d: %s", req.Method, req.URL.Redacted(), resp.StatusCode, strings.TrimSpace(string(body)))
+		if resp.StatusCode == http.StatusNotFound {
+			err = fmt.Errorf("%w: %w", os.ErrNotExist, err)
+		}
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// emptyPayloadHash is the SHA-256 of an empty payload.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// signRequest signs the request for the S3 service of the region with AWS Signature Version 4. The
+// host and all the headers of the request are signed.
+func signRequest(req *http.Request, payloadHash, region string, credentials Credentials, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncodePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uriEncodePath encodes each segment of the path with uriEncode.
+func uriEncodePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode percent-encodes all the characters of s but the unreserved characters of RFC 3986, as
+// AWS Signature Version 4 requires.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// DefaultInterval is how often the stores are backed up by default.
+const DefaultInterval = 24 * time.Hour
+
+var lastSuccessGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: build.ProjectName,
+	Name:      "backup_last_success_timestamp_seconds",
+	Help:      "The time of the last backup of all the stores that succeeded, in seconds since the Unix epoch.",
+})
+
+// SnapshotName returns the name of the object of the snapshot of the store created at the time, e.g.
+// '01HV3.../20240102T150405Z.jsonl.gz'. The names of the snapshots of a store sort by time.
+func SnapshotName(storeID string, createdAt time.Time) string {
+	return fmt.Sprintf("%s/%s.jsonl.gz", storeID, createdAt.UTC().Format("20060102T150405Z"))
+}
+
+// Scheduler periodically writes a snapshot of every store to object storage.
+type Scheduler struct {
+	datastore storage.OpenFGADatastore
+	writer    Writer
+	logger    logger.Logger
+	interval  time.Duration
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// SchedulerOption defines an option that can be used to change the behavior of a [Scheduler].
+type SchedulerOption func(s *Scheduler)
+
+// WithLogger sets the logger of the [Scheduler].
+func WithLogger(l logger.Logger) SchedulerOption {
+	return func(s *Scheduler) {
+		s.logger = l
+	}
+}
+
+// WithInterval sets how often the stores are backed up. If the interval is 0, they are only backed
+// up when BackupAll is called.
+func WithInterval(interval time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.interval = interval
+	}
+}
+
+// NewScheduler constructs a [Scheduler] of the backups of the stores of the datastore to the writer.
+// If the interval is not 0, the stores are backed up every interval, starting one interval from now
+// so that restarting the server doesn't back them up again. You must call Close on it after you are
+// done using it.
+func NewScheduler(datastore storage.OpenFGADatastore, writer Writer, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		datastore: datastore,
+		writer:    writer,
+		logger:    logger.NewNoopLogger(),
+		interval:  DefaultInterval,
+		stop:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.interval > 0 {
+		s.done.Add(1)
+		go s.backupPeriodically()
+	}
+
+	return s
+}
+
+// Close stops backing up the stores and waits for an ongoing backup to be cancelled.
+func (s *Scheduler) Close() {
+	close(s.stop)
+	s.done.Wait()
+}
+
+func (s *Scheduler) backupPeriodically() {
+	defer s.done.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.BackupAll(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("failed to back up the stores", zap.Error(err))
+		}
+	}
+}
+
+// BackupAll writes a snapshot of every store. A store that fails to be backed up doesn't stop the
+// backup of the others, and the error of the first one is returned.
+func (s *Scheduler) BackupAll(ctx context.Context) error {
+	var firstErr error
+	count := 0
+
+	var continuationToken string
+	for {
+		page, token, err := s.datastore.ListStores(ctx, storage.ListStoresFilter{}, storage.NewPaginationOptions(storage.DefaultPageSize, continuationToken))
+		if err != nil {
+			return fmt.Errorf("failed to list stores: %w", err)
+		}
+
+		for _, store := range page {
+			if _, err := s.BackupStore(ctx, store.GetId()); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				s.logger.Error("failed to back up store", zap.String("store_id", store.GetId()), zap.Error(err))
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to back up store '%s': %w", store.GetId(), err)
+				}
+				continue
+			}
+			count++
+		}
+
+		if len(token) == 0 {
+			break
+		}
+		continuationToken = string(token)
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	lastSuccessGauge.SetToCurrentTime()
+	s.logger.Info("backed up the stores", zap.Int("store_count", count))
+
+	return nil
+}
+
+// BackupStore writes a snapshot of the store, and returns its manifest. The snapshot is written to a
+// temporary file first, so that it isn't held in memory.
+func (s *Scheduler) BackupStore(ctx context.Context, storeID string) (*Manifest, error) {
+	f, err := os.CreateTemp("", "openfga-backup-*.jsonl.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	manifest, err := Export(ctx, s.datastore, storeID, f)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	name := SnapshotName(storeID, manifest.CreatedAt)
+	if err := s.writer.Put(ctx, name, f); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot '%s': %w", name, err)
+	}
+
+	s.logger.Info("backed up store",
+		zap.String("store_id", storeID),
+		zap.String("snapshot", name),
+		zap.Int("tuple_count", manifest.TupleCount),
+		zap.String("changelog_high_water_mark", manifest.ChangelogHighWaterMark.ContinuationToken))
+
+	return manifest, nil
+}
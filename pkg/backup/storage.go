@@ -0,0 +1,193 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Writer writes the snapshots to object storage. Implement it to write them to a storage that isn't
+// built in.
+type Writer interface {
+	// Put writes the object with the name, replacing it if it exists. The name is a relative path
+	// separated by slashes.
+	Put(ctx context.Context, name string, body io.ReadSeeker) error
+}
+
+// Reader reads the snapshots from object storage.
+type Reader interface {
+	// Get opens the object with the name. It returns an error wrapping [os.ErrNotExist] if the object
+	// doesn't exist.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// ObjectStorage is an object storage that snapshots are written to and read from.
+type ObjectStorage interface {
+	Writer
+	Reader
+}
+
+// StorageOption defines an option that can be used to change the behavior of the object storage
+// opened by OpenObjectStorage.
+type StorageOption func(o *storageOptions)
+
+type storageOptions struct {
+	endpoint    string
+	region      string
+	credentials *Credentials
+	client      *http.Client
+}
+
+// WithEndpoint sets the URL of the S3 compatible API of the object storage of 's3' URLs, e.g. of a
+// MinIO server, instead of the AWS endpoint of the region.
+func WithEndpoint(endpoint string) StorageOption {
+	return func(o *storageOptions) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithRegion sets the region of the bucket of 's3' URLs. It is 'us-east-1' by default.
+func WithRegion(region string) StorageOption {
+	return func(o *storageOptions) {
+		o.region = region
+	}
+}
+
+// WithCredentials sets the credentials that the requests to the object storage of 's3' and 'gs'
+// URLs are signed with, instead of the credentials of the environment (see CredentialsFromEnv).
+func WithCredentials(credentials Credentials) StorageOption {
+	return func(o *storageOptions) {
+		o.credentials = &credentials
+	}
+}
+
+// WithHTTPClient sets the client of the requests to the object storage of 's3' and 'gs' URLs.
+func WithHTTPClient(client *http.Client) StorageOption {
+	return func(o *storageOptions) {
+		o.client = client
+	}
+}
+
+// OpenObjectStorage returns the object storage of the URL, whose path is the prefix of the names of
+// the objects:
+//   - 'file:///var/backups/openfga', or a path without a scheme, is a directory of the filesystem
+//   - 's3://bucket/prefix' is a bucket of AWS S3, or of any S3 compatible API with WithEndpoint
+//   - 'gs://bucket/prefix' is a bucket of Google Cloud Storage, through its S3 compatible API,
+//     which requires HMAC keys as the credentials
+func OpenObjectStorage(rawURL string, opts ...StorageOption) (ObjectStorage, error) {
+	o := storageOptions{region: defaultS3Region, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object storage URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		dir := u.Path
+		if u.Scheme == "" {
+			dir = rawURL
+		}
+		if dir == "" {
+			return nil, fmt.Errorf("invalid object storage URL '%s': missing path", rawURL)
+		}
+		return &dirStorage{dir: dir}, nil
+	case "s3", "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid object storage URL '%s': missing bucket", rawURL)
+		}
+
+		credentials := o.credentials
+		if credentials == nil {
+			credentials = CredentialsFromEnv()
+		}
+
+		s := &s3Storage{
+			bucket:      u.Host,
+			prefix:      strings.Trim(u.Path, "/"),
+			region:      o.region,
+			credentials: *credentials,
+			client:      o.client,
+		}
+
+		switch {
+		case o.endpoint != "":
+			s.endpoint = strings.TrimSuffix(o.endpoint, "/")
+		case u.Scheme == "gs":
+			s.endpoint = gcsEndpoint
+			s.region = gcsRegion
+		default:
+			s.endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", s.region)
+		}
+
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported object storage URL scheme '%s'", u.Scheme)
+	}
+}
+
+// dirStorage stores the objects as files of a directory.
+type dirStorage struct {
+	dir string
+}
+
+var _ ObjectStorage = (*dirStorage)(nil)
+
+// Put see [Writer].Put. The object is written to a temporary file first, so that a partially written
+// object is never read.
+func (d *dirStorage) Put(_ context.Context, name string, body io.ReadSeeker) error {
+	target, err := d.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(target), ".tmp-"+filepath.Base(target))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), target)
+}
+
+// Get see [Reader].Get.
+func (d *dirStorage) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	target, err := d.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(target)
+}
+
+// path returns the path of the file of the object, which must be in the directory.
+func (d *dirStorage) path(name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || clean != "/"+name {
+		return "", fmt.Errorf("invalid object name '%s'", name)
+	}
+
+	return filepath.Join(d.dir, filepath.FromSlash(clean)), nil
+}
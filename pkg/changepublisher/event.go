@@ -0,0 +1,123 @@
+// Package changepublisher tails the changelogs of the stores and publishes their changes as
+// normalized events to Kafka or NATS, with a [Publisher].
+//
+// The position of the publisher in the changelog of each store is checkpointed in the datastore once
+// the broker acknowledged the events of a page of changes, so that the publisher resumes where it
+// stopped. The events of a page that was published but not checkpointed, e.g. when the server stopped
+// in between, are published again with the same IDs, so consumers that deduplicate the events by ID
+// see each change once.
+package changepublisher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// EventVersion is the version of the schema of the events that are published.
+const EventVersion = 1
+
+const (
+	// OperationWrite is the operation of the events of the tuples that were written.
+	OperationWrite = "write"
+
+	// OperationDelete is the operation of the events of the tuples that were deleted.
+	OperationDelete = "delete"
+)
+
+// Event is a change of a tuple of a store, as published to the brokers, encoded as JSON.
+type Event struct {
+	Version int `json:"version"`
+
+	// ID identifies the change. It is the same whenever the change is published, so consumers can
+	// deduplicate the events by ID.
+	ID string `json:"id"`
+
+	StoreID string `json:"store_id"`
+
+	// Operation is 'write' or 'delete'.
+	Operation string `json:"operation"`
+
+	// Object is the object of the tuple, e.g. 'document:budget', and ObjectType and ObjectID its parts.
+	Object     string `json:"object"`
+	ObjectType string `json:"object_type"`
+	ObjectID   string `json:"object_id"`
+
+	Relation string `json:"relation"`
+
+	// User is the user of the tuple, e.g. 'user:anne' or 'group:eng#member', and UserType its type.
+	User     string `json:"user"`
+	UserType string `json:"user_type"`
+
+	Condition *EventCondition `json:"condition,omitempty"`
+
+	// Timestamp is the time of the change.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Writer is the identity of the writer of the change, if it was recorded.
+	Writer string `json:"writer,omitempty"`
+}
+
+// EventCondition is the condition of the tuple of an Event.
+type EventCondition struct {
+	Name    string          `json:"name"`
+	Context json.RawMessage `json:"context,omitempty"`
+}
+
+// NewEvent returns the event of a change of the changelog of the store, written by the writer.
+func NewEvent(storeID string, change *openfgav1.TupleChange, writer string) (*Event, error) {
+	tk := change.GetTupleKey()
+	objectType, objectID := tuple.SplitObject(tk.GetObject())
+	userType, _ := tuple.SplitObject(tk.GetUser())
+
+	operation := OperationWrite
+	if change.GetOperation() == openfgav1.TupleOperation_TUPLE_OPERATION_DELETE {
+		operation = OperationDelete
+	}
+
+	event := &Event{
+		Version:    EventVersion,
+		StoreID:    storeID,
+		Operation:  operation,
+		Object:     tk.GetObject(),
+		ObjectType: objectType,
+		ObjectID:   objectID,
+		Relation:   tk.GetRelation(),
+		User:       tk.GetUser(),
+		UserType:   userType,
+		Timestamp:  change.GetTimestamp().AsTime(),
+		Writer:     writer,
+	}
+
+	if condition := tk.GetCondition(); condition != nil {
+		event.Condition = &EventCondition{Name: condition.GetName()}
+		if condition.GetContext() != nil {
+			conditionContext, err := protojson.Marshal(condition.GetContext())
+			if err != nil {
+				return nil, err
+			}
+			event.Condition.Context = conditionContext
+		}
+	}
+
+	event.ID = eventID(storeID, tuple.TupleKeyToString(tk), operation, event.Timestamp)
+
+	return event, nil
+}
+
+// eventID identifies a change by its store, tuple key, operation and timestamp, like the datastores do.
+func eventID(storeID, tupleKey, operation string, timestamp time.Time) string {
+	hash := sha256.New()
+	for _, part := range []string{storeID, tupleKey, operation, strconv.FormatInt(timestamp.UnixNano(), 10)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
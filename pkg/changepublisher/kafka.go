@@ -0,0 +1,80 @@
+package changepublisher
+
+import (
+	"context"
+	"crypto/tls"
+	"net/url"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// kafkaIDHeader is the header of the ID of the messages.
+const kafkaIDHeader = "id"
+
+// kafkaSink produces the messages to the partitions of their topics with a kafka-go Writer, which
+// reads the leaders of the partitions from the metadata of the cluster.
+type kafkaSink struct {
+	writer    *kafka.Writer
+	transport *kafka.Transport
+	timeout   time.Duration
+}
+
+var _ Sink = (*kafkaSink)(nil)
+
+func newKafkaSink(bootstrap []string, user *url.Userinfo, tlsConfig *tls.Config, o *sinkOptions) *kafkaSink {
+	transport := &kafka.Transport{
+		ClientID:    o.clientID,
+		DialTimeout: o.timeout,
+		TLS:         tlsConfig,
+	}
+	if user != nil {
+		password, _ := user.Password()
+		transport.SASL = plain.Mechanism{Username: user.Username(), Password: password}
+	}
+
+	return &kafkaSink{
+		writer: &kafka.Writer{
+			Addr: kafka.TCP(bootstrap...),
+			// the messages of a topic are partitioned by key like the Java client does, so that the
+			// other producers of the topic agree with the sink
+			Balancer:     &kafka.Murmur2Balancer{},
+			RequiredAcks: kafka.RequireAll,
+			// a Publish is acknowledged once all of its messages are, so they aren't held back to
+			// fill bigger batches
+			BatchTimeout: time.Millisecond,
+			ReadTimeout:  o.timeout,
+			WriteTimeout: o.timeout,
+			Transport:    transport,
+		},
+		transport: transport,
+		timeout:   o.timeout,
+	}
+}
+
+// Publish see [Sink].Publish.
+func (s *kafkaSink) Publish(ctx context.Context, messages []Message) error {
+	ctx, cancel := context.WithDeadline(ctx, publishDeadline(ctx, s.timeout))
+	defer cancel()
+
+	records := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		records = append(records, kafka.Message{
+			Topic:   message.Topic,
+			Key:     message.Key,
+			Value:   message.Value,
+			Headers: []kafka.Header{{Key: kafkaIDHeader, Value: []byte(message.ID)}},
+			Time:    message.Timestamp,
+		})
+	}
+
+	return s.writer.WriteMessages(ctx, records...)
+}
+
+// Close see [Sink].Close.
+func (s *kafkaSink) Close() error {
+	err := s.writer.Close()
+	s.transport.CloseIdleConnections()
+	return err
+}
@@ -0,0 +1,154 @@
+package changepublisher
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsSink publishes the messages to the subjects of a NATS server with a nats.go connection. The
+// messages have their ID in the Nats-Msg-Id header, which JetStream deduplicates them by.
+type natsSink struct {
+	url       string
+	options   []nats.Option
+	jetStream bool
+	timeout   time.Duration
+
+	// mu serializes the Publish calls, which share the connection.
+	mu   sync.Mutex
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+var _ Sink = (*natsSink)(nil)
+
+func newNATSSink(address string, user *url.Userinfo, jetStream bool, tlsConfig *tls.Config, o *sinkOptions) *natsSink {
+	options := []nats.Option{
+		nats.Name(o.clientID),
+		nats.Timeout(o.timeout),
+	}
+	if user != nil {
+		if password, ok := user.Password(); ok {
+			options = append(options, nats.UserInfo(user.Username(), password))
+		} else {
+			options = append(options, nats.Token(user.Username()))
+		}
+	}
+	if tlsConfig != nil {
+		options = append(options, nats.Secure(tlsConfig))
+	}
+
+	return &natsSink{
+		url:       "nats://" + address,
+		options:   options,
+		jetStream: jetStream,
+		timeout:   o.timeout,
+	}
+}
+
+// Publish see [Sink].Publish.
+func (s *natsSink) Publish(ctx context.Context, messages []Message) error {
+	for _, message := range messages {
+		if message.Topic == "" || strings.ContainsAny(message.Topic, " \t\r\n") {
+			return fmt.Errorf("invalid NATS subject '%s'", message.Topic)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithDeadline(ctx, publishDeadline(ctx, s.timeout))
+	defer cancel()
+
+	// the connection reconnects by itself, unless it gave up
+	if s.conn == nil || s.conn.IsClosed() {
+		if err := s.connect(); err != nil {
+			return fmt.Errorf("failed to connect to NATS server '%s': %w", s.url, err)
+		}
+	}
+
+	if s.jetStream {
+		return s.publishToJetStream(ctx, messages)
+	}
+
+	for _, message := range messages {
+		msg := &nats.Msg{
+			Subject: message.Topic,
+			Header:  nats.Header{jetstream.MsgIDHeader: []string{message.ID}},
+			Data:    message.Value,
+		}
+		if err := s.conn.PublishMsg(msg); err != nil {
+			return err
+		}
+	}
+
+	// the server answers the flush once it processed the messages before it
+	return s.conn.FlushWithContext(ctx)
+}
+
+func (s *natsSink) connect() error {
+	conn, err := nats.Connect(s.url, s.options...)
+	if err != nil {
+		return err
+	}
+
+	if !conn.HeadersSupported() {
+		conn.Close()
+		return errors.New("the server doesn't support headers, which requires NATS 2.2 or later")
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.conn = conn
+	s.js = js
+	return nil
+}
+
+// publishToJetStream publishes the messages without waiting for the acknowledgement of each, and
+// then waits for the streams of their subjects to acknowledge all of them.
+func (s *natsSink) publishToJetStream(ctx context.Context, messages []Message) error {
+	acks := make([]jetstream.PubAckFuture, 0, len(messages))
+	for _, message := range messages {
+		ack, err := s.js.PublishMsgAsync(&nats.Msg{Subject: message.Topic, Data: message.Value}, jetstream.WithMsgID(message.ID))
+		if err != nil {
+			return err
+		}
+		acks = append(acks, ack)
+	}
+
+	for i, ack := range acks {
+		select {
+		case <-ack.Ok():
+		case err := <-ack.Err():
+			return fmt.Errorf("JetStream rejected a message of subject '%s': %w", messages[i].Topic, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Close see [Sink].Close.
+func (s *natsSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return nil
+}
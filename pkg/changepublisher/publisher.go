@@ -0,0 +1,313 @@
+package changepublisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	// DefaultInterval is how often the changelogs of the stores are read by default.
+	DefaultInterval = 1 * time.Second
+
+	// DefaultPageSize is the number of changes read and published at once by default.
+	DefaultPageSize = 100
+
+	// DefaultHorizonOffset is how old the changes must be to be published by default.
+	DefaultHorizonOffset = 5 * time.Second
+
+	// DefaultConsumer is the name of the changelog checkpoints of the publisher by default.
+	DefaultConsumer = "change-publisher"
+)
+
+var (
+	publishedCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "change_publisher_published_count",
+		Help:      "The total number of change events published and checkpointed by the change publisher.",
+	})
+
+	lastSuccessGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "change_publisher_last_success_timestamp_seconds",
+		Help:      "The time the changes of all the stores were last published without errors, in seconds since the Unix epoch.",
+	})
+)
+
+// Publisher periodically reads the changes of the changelog of every store since its checkpoint,
+// publishes their events to a sink, and checkpoints the changes once the sink acknowledged them.
+//
+// The changes are read in the order of their ULIDs, which isn't the order they are committed in, so
+// only the changes older than the horizon offset are read, and a change committed more than the
+// horizon offset after its ULID was generated may not be published.
+type Publisher struct {
+	datastore     storage.OpenFGADatastore
+	sink          Sink
+	router        *TopicRouter
+	logger        logger.Logger
+	interval      time.Duration
+	pageSize      int
+	horizonOffset time.Duration
+	consumer      string
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// PublisherOption defines an option that can be used to change the behavior of a [Publisher].
+type PublisherOption func(p *Publisher)
+
+// WithLogger sets the logger of the [Publisher].
+func WithLogger(l logger.Logger) PublisherOption {
+	return func(p *Publisher) {
+		p.logger = l
+	}
+}
+
+// WithInterval sets how often the changelogs are read. If the interval is 0, they are only read when
+// PublishAll is called.
+func WithInterval(interval time.Duration) PublisherOption {
+	return func(p *Publisher) {
+		p.interval = interval
+	}
+}
+
+// WithPageSize sets the number of changes of a store that are read, published and checkpointed at once.
+func WithPageSize(pageSize int) PublisherOption {
+	return func(p *Publisher) {
+		p.pageSize = pageSize
+	}
+}
+
+// WithHorizonOffset sets how old the changes must be to be published, so that the changes committed
+// after changes with later ULIDs aren't skipped.
+func WithHorizonOffset(offset time.Duration) PublisherOption {
+	return func(p *Publisher) {
+		p.horizonOffset = offset
+	}
+}
+
+// WithConsumer sets the name of the changelog checkpoints of the [Publisher], so that publishers to
+// different sinks keep their own checkpoints.
+func WithConsumer(consumer string) PublisherOption {
+	return func(p *Publisher) {
+		p.consumer = consumer
+	}
+}
+
+// WithTopicRouter sets the router of the events to the topics. The events are published to
+// DefaultTopic by default.
+func WithTopicRouter(router *TopicRouter) PublisherOption {
+	return func(p *Publisher) {
+		p.router = router
+	}
+}
+
+// NewPublisher constructs a [Publisher] of the changes of the stores of the datastore to the sink. If
+// the interval is not 0, the changes are published every interval. You must call Close on it after
+// you are done using it.
+func NewPublisher(datastore storage.OpenFGADatastore, sink Sink, opts ...PublisherOption) *Publisher {
+	p := &Publisher{
+		datastore:     datastore,
+		sink:          sink,
+		router:        &TopicRouter{template: DefaultTopic},
+		logger:        logger.NewNoopLogger(),
+		interval:      DefaultInterval,
+		pageSize:      DefaultPageSize,
+		horizonOffset: DefaultHorizonOffset,
+		consumer:      DefaultConsumer,
+		stop:          make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.interval > 0 {
+		p.done.Add(1)
+		go p.publishPeriodically()
+	}
+
+	return p
+}
+
+// Close stops publishing the changes, waits for an ongoing publication to be cancelled, and closes
+// the sink.
+func (p *Publisher) Close() {
+	close(p.stop)
+	p.done.Wait()
+
+	if err := p.sink.Close(); err != nil {
+		p.logger.Warn("failed to close the change publisher sink", zap.Error(err))
+	}
+}
+
+func (p *Publisher) publishPeriodically() {
+	defer p.done.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := p.PublishAll(ctx); err != nil && ctx.Err() == nil {
+			p.logger.Error("failed to publish the changes of the stores", zap.Error(err))
+		}
+	}
+}
+
+// PublishAll publishes the changes of every store since its checkpoint. A store whose changes fail to
+// be published doesn't stop the publication of the others, and the error of the first one is returned.
+func (p *Publisher) PublishAll(ctx context.Context) error {
+	var firstErr error
+
+	var continuationToken string
+	for {
+		page, token, err := p.datastore.ListStores(ctx, storage.ListStoresFilter{}, storage.NewPaginationOptions(storage.DefaultPageSize, continuationToken))
+		if err != nil {
+			return fmt.Errorf("failed to list stores: %w", err)
+		}
+
+		for _, store := range page {
+			if _, err := p.PublishStore(ctx, store.GetId()); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				p.logger.Error("failed to publish the changes of store", zap.String("store_id", store.GetId()), zap.Error(err))
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to publish the changes of store '%s': %w", store.GetId(), err)
+				}
+			}
+		}
+
+		if len(token) == 0 {
+			break
+		}
+		continuationToken = string(token)
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	lastSuccessGauge.SetToCurrentTime()
+
+	return nil
+}
+
+// PublishStore publishes the changes of the store since its checkpoint, a page at a time, and returns
+// the number of changes that were published. The checkpoint is written once the sink acknowledged the
+// events of a page, so the events of a page whose checkpoint failed to be written are published again.
+func (p *Publisher) PublishStore(ctx context.Context, storeID string) (int, error) {
+	continuationToken, err := p.datastore.ReadChangelogCheckpoint(ctx, storeID, p.consumer)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the changelog checkpoint: %w", err)
+	}
+
+	count := 0
+	for {
+		changes, token, err := p.datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{},
+			storage.NewPaginationOptions(int32(p.pageSize), continuationToken), p.horizonOffset)
+		if errors.Is(err, storage.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to read changes: %w", err)
+		}
+
+		messages, err := p.messages(ctx, storeID, changes)
+		if err != nil {
+			return count, err
+		}
+
+		if err := p.sink.Publish(ctx, messages); err != nil {
+			return count, fmt.Errorf("failed to publish %d changes: %w", len(messages), err)
+		}
+
+		continuationToken = string(token)
+		if err := p.datastore.WriteChangelogCheckpoint(ctx, storeID, p.consumer, continuationToken); err != nil {
+			return count, fmt.Errorf("failed to write the changelog checkpoint: %w", err)
+		}
+
+		count += len(changes)
+		publishedCounter.Add(float64(len(changes)))
+
+		if len(changes) < p.pageSize {
+			break
+		}
+	}
+
+	if count > 0 {
+		p.logger.Debug("published the changes of store",
+			zap.String("store_id", storeID),
+			zap.Int("change_count", count),
+			zap.String("changelog_checkpoint", continuationToken))
+	}
+
+	return count, nil
+}
+
+// messages returns the messages of the events of the changes of the store. The messages of the
+// changes of an object have the same key, so that Kafka keeps them in order.
+func (p *Publisher) messages(ctx context.Context, storeID string, changes []*openfgav1.TupleChange) ([]Message, error) {
+	writers, err := p.datastore.ReadChangeWriters(ctx, storeID, changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the writers of the changes: %w", err)
+	}
+
+	messages := make([]Message, 0, len(changes))
+	for i, change := range changes {
+		var writer string
+		if i < len(writers) {
+			writer = writers[i]
+		}
+
+		event, err := NewEvent(storeID, change, writer)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, Message{
+			Topic:     p.router.Topic(storeID, event.ObjectType),
+			Key:       []byte(storeID + "/" + event.Object),
+			ID:        event.ID,
+			Value:     value,
+			Timestamp: event.Timestamp,
+		})
+	}
+
+	return messages, nil
+}
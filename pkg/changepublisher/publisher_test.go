@@ -0,0 +1,208 @@
+package changepublisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// recordingSink records the messages it publishes, and fails while err is set.
+type recordingSink struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func (s *recordingSink) Publish(_ context.Context, messages []Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.messages = append(s.messages, messages...)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	return nil
+}
+
+func (s *recordingSink) events(t *testing.T) []Event {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]Event, 0, len(s.messages))
+	for _, message := range s.messages {
+		var event Event
+		require.NoError(t, json.Unmarshal(message.Value, &event))
+		require.Equal(t, event.ID, message.ID)
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestPublisher(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	datastore := memory.New()
+	t.Cleanup(datastore.Close)
+
+	store, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "publisher"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	condition, err := structpb.NewStruct(map[string]any{"office": "london"})
+	require.NoError(t, err)
+
+	err = datastore.Write(storage.ContextWithWriter(ctx, "client:admin"), storeID, nil, storage.Writes{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKeyWithCondition("folder:1", "viewer", "group:eng#member", "in_office", condition),
+	})
+	require.NoError(t, err)
+	err = datastore.Write(ctx, storeID, storage.Deletes{
+		tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:anne")),
+	}, nil)
+	require.NoError(t, err)
+
+	router, err := NewTopicRouter("changes.{store_id}", []string{"*/folder=folders.{object_type}"})
+	require.NoError(t, err)
+
+	sink := &recordingSink{}
+	publisher := NewPublisher(datastore, sink,
+		WithInterval(0),
+		WithHorizonOffset(0),
+		WithPageSize(2),
+		WithTopicRouter(router),
+	)
+	t.Cleanup(publisher.Close)
+
+	require.NoError(t, publisher.PublishAll(ctx))
+
+	events := sink.events(t)
+	require.Len(t, events, 3)
+
+	require.Equal(t, EventVersion, events[0].Version)
+	require.Equal(t, storeID, events[0].StoreID)
+	require.Equal(t, OperationWrite, events[0].Operation)
+	require.Equal(t, "document:1", events[0].Object)
+	require.Equal(t, "document", events[0].ObjectType)
+	require.Equal(t, "1", events[0].ObjectID)
+	require.Equal(t, "viewer", events[0].Relation)
+	require.Equal(t, "user:anne", events[0].User)
+	require.Equal(t, "user", events[0].UserType)
+	require.Equal(t, "client:admin", events[0].Writer)
+	require.Nil(t, events[0].Condition)
+	require.Equal(t, "changes."+storeID, sink.messages[0].Topic)
+	require.Equal(t, []byte(storeID+"/document:1"), sink.messages[0].Key)
+
+	require.Equal(t, "group", events[1].UserType)
+	require.Equal(t, "in_office", events[1].Condition.Name)
+	require.JSONEq(t, `{"office":"london"}`, string(events[1].Condition.Context))
+	require.Equal(t, "folders.folder", sink.messages[1].Topic)
+
+	require.Equal(t, OperationDelete, events[2].Operation)
+	require.Empty(t, events[2].Writer)
+	require.NotEqual(t, events[0].ID, events[2].ID)
+
+	t.Run("publishes_only_the_changes_after_the_checkpoint", func(t *testing.T) {
+		require.NoError(t, publisher.PublishAll(ctx))
+		require.Len(t, sink.events(t), 3)
+
+		err := datastore.Write(ctx, storeID, nil, storage.Writes{tuple.NewTupleKey("document:2", "viewer", "user:bob")})
+		require.NoError(t, err)
+
+		count, err := publisher.PublishStore(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		events := sink.events(t)
+		require.Len(t, events, 4)
+		require.Equal(t, "document:2", events[3].Object)
+	})
+
+	t.Run("publishes_the_changes_again_with_the_same_ids_after_a_failure", func(t *testing.T) {
+		err := datastore.Write(ctx, storeID, nil, storage.Writes{tuple.NewTupleKey("document:3", "viewer", "user:carl")})
+		require.NoError(t, err)
+
+		sink.err = errors.New("broker unavailable")
+		_, err = publisher.PublishStore(ctx, storeID)
+		require.ErrorIs(t, err, sink.err)
+		require.Error(t, publisher.PublishAll(ctx))
+
+		// a publisher that restarts resumes from the checkpoint
+		sink.err = nil
+		restarted := NewPublisher(datastore, sink, WithInterval(0), WithHorizonOffset(0))
+		defer restarted.Close()
+
+		count, err := restarted.PublishStore(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		events := sink.events(t)
+		require.Len(t, events, 5)
+		require.Equal(t, "document:3", events[4].Object)
+		require.Equal(t, DefaultTopic, sink.messages[4].Topic)
+
+		// the event of a change is the same whenever it is published
+		changes, _, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{ObjectType: "document"}, storage.NewPaginationOptions(100, ""), 0)
+		require.NoError(t, err)
+		event, err := NewEvent(storeID, changes[len(changes)-1], "")
+		require.NoError(t, err)
+		require.Equal(t, events[4].ID, event.ID)
+	})
+
+	t.Run("keeps_a_checkpoint_per_consumer", func(t *testing.T) {
+		other := &recordingSink{}
+		publisher := NewPublisher(datastore, other, WithInterval(0), WithHorizonOffset(0), WithConsumer("audit"))
+		defer publisher.Close()
+
+		count, err := publisher.PublishStore(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, 5, count)
+	})
+}
+
+func TestTopicRouter(t *testing.T) {
+	router, err := NewTopicRouter("openfga.{store_id}.{object_type}", []string{
+		"store1/document=documents",
+		"store1=store1",
+		"*/folder=folders",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "documents", router.Topic("store1", "document"))
+	require.Equal(t, "store1", router.Topic("store1", "folder"))
+	require.Equal(t, "folders", router.Topic("store2", "folder"))
+	require.Equal(t, "openfga.store2.document", router.Topic("store2", "document"))
+
+	for _, routes := range [][]string{
+		{"documents"},
+		{"store1/=documents"},
+		{"store1="},
+		{"store1=a", "store1/*=b"},
+	} {
+		_, err := NewTopicRouter("openfga", routes)
+		require.Error(t, err, routes)
+	}
+
+	_, err = NewTopicRouter("", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package changepublisher
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultClientID    = "openfga"
+	defaultSinkTimeout = 10 * time.Second
+)
+
+// Message is an event published to a topic of a broker.
+type Message struct {
+	// Topic is the Kafka topic or the NATS subject of the message.
+	Topic string
+
+	// Key orders the messages: Kafka publishes the messages with the same key to the same partition.
+	Key []byte
+
+	// ID identifies the message, so that the brokers and the consumers can deduplicate it.
+	ID string
+
+	Value []byte
+
+	Timestamp time.Time
+}
+
+// Sink publishes the messages to a broker. Implement it to publish them to a broker that isn't built in.
+type Sink interface {
+	// Publish publishes the messages, in order, and returns once the broker acknowledged all of them.
+	// If it fails, some of the messages may have been published anyway.
+	Publish(ctx context.Context, messages []Message) error
+
+	// Close closes the connections to the broker.
+	Close() error
+}
+
+// SinkOption defines an option that can be used to change the behavior of the sink opened by OpenSink.
+type SinkOption func(o *sinkOptions)
+
+type sinkOptions struct {
+	clientID  string
+	timeout   time.Duration
+	tlsConfig *tls.Config
+}
+
+// WithClientID sets the name the sink identifies itself with to the broker. It is 'openfga' by default.
+func WithClientID(clientID string) SinkOption {
+	return func(o *sinkOptions) {
+		o.clientID = clientID
+	}
+}
+
+// WithTimeout sets how long the sink waits for the broker to connect and to acknowledge the messages
+// of a Publish whose context has no deadline. It is 10 seconds by default.
+func WithTimeout(timeout time.Duration) SinkOption {
+	return func(o *sinkOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the connections of the URLs with 'tls=true', instead of
+// the default one.
+func WithTLSConfig(config *tls.Config) SinkOption {
+	return func(o *sinkOptions) {
+		o.tlsConfig = config
+	}
+}
+
+// OpenSink returns the sink of the URL:
+//   - 'kafka://broker1:9092,broker2:9092' is a Kafka cluster, bootstrapped from the brokers. The
+//     credentials of the URL, if any, authenticate with SASL/PLAIN. The messages of a topic are
+//     partitioned by key like the Java client does, and acknowledged by all the in-sync replicas.
+//   - 'nats://server:4222' is a NATS server. The credentials of the URL, if any, are a user and a
+//     password, or a token. With 'jetstream=true', the messages are acknowledged by the JetStream
+//     streams of their subjects, which deduplicate them by ID; otherwise they are only acknowledged
+//     by the server.
+//
+// With 'tls=true', the connections use TLS.
+func OpenSink(rawURL string, opts ...SinkOption) (Sink, error) {
+	o := &sinkOptions{
+		clientID: defaultClientID,
+		timeout:  defaultSinkTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid change publisher URL: %w", err)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("the change publisher URL '%s' has no host", u.Redacted())
+	}
+
+	useTLS, err := queryBool(u, "tls")
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tls.Config
+	if useTLS {
+		tlsConfig = o.tlsConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+	}
+
+	switch u.Scheme {
+	case "kafka":
+		return newKafkaSink(strings.Split(u.Host, ","), u.User, tlsConfig, o), nil
+	case "nats":
+		jetStream, err := queryBool(u, "jetstream")
+		if err != nil {
+			return nil, err
+		}
+
+		return newNATSSink(u.Host, u.User, jetStream, tlsConfig, o), nil
+	default:
+		return nil, fmt.Errorf("unsupported change publisher URL scheme '%s', use 'kafka' or 'nats'", u.Scheme)
+	}
+}
+
+func queryBool(u *url.URL, name string) (bool, error) {
+	value := u.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid '%s' parameter of the change publisher URL: %w", name, err)
+	}
+	return b, nil
+}
+
+// publishDeadline returns the deadline of the context, or the deadline the timeout sets from now if
+// it has none.
+func publishDeadline(ctx context.Context, timeout time.Duration) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(timeout)
+}
@@ -0,0 +1,79 @@
+package changepublisher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultTopic is the topic of the events of the stores and object types without a route.
+	DefaultTopic = "openfga.changes"
+
+	// wildcard matches any store or object type in a route.
+	wildcard = "*"
+)
+
+// routeKey is the store and object type of a route, either of which may be the wildcard.
+type routeKey struct {
+	storeID    string
+	objectType string
+}
+
+// TopicRouter returns the topic of the events of a store and an object type.
+type TopicRouter struct {
+	template string
+	routes   map[routeKey]string
+}
+
+// NewTopicRouter returns the TopicRouter of the routes, rules of the form 'storeID/objectType=topic'
+// where the store or the object type may be '*', or 'storeID=topic' for all the object types of the
+// store. The most specific route of a store and an object type wins, and the events without a route
+// use the template. The template and the topics of the routes may have the '{store_id}' and
+// '{object_type}' placeholders, e.g. 'openfga.{store_id}.{object_type}'.
+func NewTopicRouter(template string, routes []string) (*TopicRouter, error) {
+	if template == "" {
+		return nil, errors.New("the topic template must be set")
+	}
+
+	r := &TopicRouter{template: template, routes: make(map[routeKey]string, len(routes))}
+	for _, route := range routes {
+		selector, topic, ok := strings.Cut(route, "=")
+		if !ok || selector == "" || topic == "" {
+			return nil, fmt.Errorf("the topic routes must be 'storeID/objectType=topic' rules, not '%s'", route)
+		}
+
+		storeID, objectType, ok := strings.Cut(selector, "/")
+		if !ok {
+			objectType = wildcard
+		}
+		if storeID == "" || objectType == "" {
+			return nil, fmt.Errorf("the topic routes must be 'storeID/objectType=topic' rules, not '%s'", route)
+		}
+
+		key := routeKey{storeID: storeID, objectType: objectType}
+		if _, ok := r.routes[key]; ok {
+			return nil, fmt.Errorf("the topic routes have '%s' more than once", selector)
+		}
+		r.routes[key] = topic
+	}
+
+	return r, nil
+}
+
+// Topic returns the topic of the events of the object type of the store.
+func (r *TopicRouter) Topic(storeID, objectType string) string {
+	topic := r.template
+	for _, key := range []routeKey{
+		{storeID: storeID, objectType: objectType},
+		{storeID: storeID, objectType: wildcard},
+		{storeID: wildcard, objectType: objectType},
+	} {
+		if route, ok := r.routes[key]; ok {
+			topic = route
+			break
+		}
+	}
+
+	return strings.NewReplacer("{store_id}", storeID, "{object_type}", objectType).Replace(topic)
+}
@@ -0,0 +1,51 @@
+// Package checkresolver exposes the resolvers that Check and ListObjects resolve the Check subproblems
+// with, so that applications embedding OpenFGA can insert custom ones in the chain of the server, e.g.
+// for custom caching, tenant throttling or feature flags. See server.WithCheckResolverChain.
+package checkresolver
+
+import (
+	"github.com/openfga/openfga/internal/graph"
+)
+
+type (
+	// CheckResolver resolves a Check subproblem. See [NewChain].
+	CheckResolver = graph.CheckResolver
+
+	// DelegatingCheckResolver is a CheckResolver that dispatches (a part of) its work to a delegate
+	// CheckResolver, so that it can be a link of a resolver chain.
+	DelegatingCheckResolver = graph.DelegatingCheckResolver
+
+	// ResolveCheckRequest is a Check subproblem.
+	ResolveCheckRequest = graph.ResolveCheckRequest
+
+	// ResolveCheckRequestMetadata is the metadata of a Check subproblem, e.g. its depth.
+	ResolveCheckRequestMetadata = graph.ResolveCheckRequestMetadata
+
+	// ResolveCheckResponse is the result of a Check subproblem.
+	ResolveCheckResponse = graph.ResolveCheckResponse
+
+	// ResolveCheckResponseMetadata is the metadata of the result of a Check subproblem, e.g. the
+	// number of datastore queries it took.
+	ResolveCheckResponseMetadata = graph.ResolveCheckResponseMetadata
+
+	// InterceptorFunc intercepts a ResolveCheck call. It may resolve the request itself, or pass it on
+	// to the next resolver of the chain.
+	InterceptorFunc = graph.CheckResolverInterceptorFunc
+
+	// Interceptor is a DelegatingCheckResolver that runs an InterceptorFunc, which lets a middleware be
+	// inserted in a resolver chain without implementing the delegation.
+	Interceptor = graph.CheckResolverInterceptor
+)
+
+// NewInterceptor constructs an Interceptor that runs the function.
+func NewInterceptor(intercept InterceptorFunc) *Interceptor {
+	return graph.NewCheckResolverInterceptor(intercept)
+}
+
+// NewChain links the resolvers into a chain: each resolver delegates to the next one, and the last
+// one delegates the subproblems back to the first one. A resolver must not appear twice in it.
+//
+// It returns the first resolver, and a function that closes all the resolvers of the chain.
+func NewChain(resolvers ...DelegatingCheckResolver) (CheckResolver, func(), error) {
+	return graph.NewResolverChain(resolvers...)
+}
@@ -0,0 +1,285 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openfga/openfga/pkg/server"
+)
+
+// client is an in-process openfgav1.OpenFGAServiceClient.
+type client struct {
+	server *server.Server
+}
+
+var _ openfgav1.OpenFGAServiceClient = (*client)(nil)
+
+// serverContext returns the context that the server is called with: the outgoing metadata of
+// the client is the incoming metadata of the server, and the response headers are recorded for
+// the grpc.Header call options.
+func serverContext(ctx context.Context, opts []grpc.CallOption) (context.Context, func()) {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+
+	headers := &responseHeaders{md: metadata.MD{}}
+	ctx = context.WithValue(ctx, headersCtxKey{}, headers)
+
+	return ctx, func() {
+		for _, opt := range opts {
+			if header, ok := opt.(grpc.HeaderCallOption); ok {
+				*header.HeaderAddr = headers.get()
+			}
+		}
+	}
+}
+
+func (c *client) Read(ctx context.Context, in *openfgav1.ReadRequest, opts ...grpc.CallOption) (*openfgav1.ReadResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.Read(ctx, in)
+}
+
+func (c *client) Write(ctx context.Context, in *openfgav1.WriteRequest, opts ...grpc.CallOption) (*openfgav1.WriteResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.Write(ctx, in)
+}
+
+func (c *client) Check(ctx context.Context, in *openfgav1.CheckRequest, opts ...grpc.CallOption) (*openfgav1.CheckResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.Check(ctx, in)
+}
+
+func (c *client) Expand(ctx context.Context, in *openfgav1.ExpandRequest, opts ...grpc.CallOption) (*openfgav1.ExpandResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.Expand(ctx, in)
+}
+
+func (c *client) ReadAuthorizationModels(ctx context.Context, in *openfgav1.ReadAuthorizationModelsRequest, opts ...grpc.CallOption) (*openfgav1.ReadAuthorizationModelsResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.ReadAuthorizationModels(ctx, in)
+}
+
+func (c *client) ReadAuthorizationModel(ctx context.Context, in *openfgav1.ReadAuthorizationModelRequest, opts ...grpc.CallOption) (*openfgav1.ReadAuthorizationModelResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.ReadAuthorizationModel(ctx, in)
+}
+
+func (c *client) WriteAuthorizationModel(ctx context.Context, in *openfgav1.WriteAuthorizationModelRequest, opts ...grpc.CallOption) (*openfgav1.WriteAuthorizationModelResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.WriteAuthorizationModel(ctx, in)
+}
+
+func (c *client) WriteAssertions(ctx context.Context, in *openfgav1.WriteAssertionsRequest, opts ...grpc.CallOption) (*openfgav1.WriteAssertionsResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.WriteAssertions(ctx, in)
+}
+
+func (c *client) ReadAssertions(ctx context.Context, in *openfgav1.ReadAssertionsRequest, opts ...grpc.CallOption) (*openfgav1.ReadAssertionsResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.ReadAssertions(ctx, in)
+}
+
+func (c *client) ReadChanges(ctx context.Context, in *openfgav1.ReadChangesRequest, opts ...grpc.CallOption) (*openfgav1.ReadChangesResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.ReadChanges(ctx, in)
+}
+
+func (c *client) CreateStore(ctx context.Context, in *openfgav1.CreateStoreRequest, opts ...grpc.CallOption) (*openfgav1.CreateStoreResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.CreateStore(ctx, in)
+}
+
+func (c *client) UpdateStore(ctx context.Context, in *openfgav1.UpdateStoreRequest, opts ...grpc.CallOption) (*openfgav1.UpdateStoreResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.UpdateStore(ctx, in)
+}
+
+func (c *client) DeleteStore(ctx context.Context, in *openfgav1.DeleteStoreRequest, opts ...grpc.CallOption) (*openfgav1.DeleteStoreResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.DeleteStore(ctx, in)
+}
+
+func (c *client) GetStore(ctx context.Context, in *openfgav1.GetStoreRequest, opts ...grpc.CallOption) (*openfgav1.GetStoreResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.GetStore(ctx, in)
+}
+
+func (c *client) ListStores(ctx context.Context, in *openfgav1.ListStoresRequest, opts ...grpc.CallOption) (*openfgav1.ListStoresResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.ListStores(ctx, in)
+}
+
+func (c *client) ListObjects(ctx context.Context, in *openfgav1.ListObjectsRequest, opts ...grpc.CallOption) (*openfgav1.ListObjectsResponse, error) {
+	ctx, setHeaders := serverContext(ctx, opts)
+	defer setHeaders()
+
+	return c.server.ListObjects(ctx, in)
+}
+
+func (c *client) StreamedListObjects(ctx context.Context, in *openfgav1.StreamedListObjectsRequest, opts ...grpc.CallOption) (openfgav1.OpenFGAService_StreamedListObjectsClient, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	ctx, setHeaders := serverContext(ctx, opts)
+
+	stream := &streamedListObjectsStream{
+		ctx:        ctx,
+		responses:  make(chan *openfgav1.StreamedListObjectsResponse),
+		done:       make(chan struct{}),
+		setHeaders: setHeaders,
+	}
+
+	go func() {
+		defer cancel()
+		defer close(stream.done)
+
+		stream.err = c.server.StreamedListObjects(in, stream)
+	}()
+
+	return stream, nil
+}
+
+// streamedListObjectsStream is both ends of an in-process StreamedListObjects stream. The server
+// runs in its own goroutine, and sends the responses to the client through a channel.
+type streamedListObjectsStream struct {
+	ctx       context.Context
+	responses chan *openfgav1.StreamedListObjectsResponse
+
+	// done is closed when the server returned err.
+	done chan struct{}
+	err  error
+
+	// setHeaders sets the response headers of the grpc.Header call options. It is called once, by
+	// Recv when the stream ends, so that they are set on the goroutine of the client.
+	setHeaders     func()
+	setHeadersOnce sync.Once
+}
+
+var (
+	_ openfgav1.OpenFGAService_StreamedListObjectsServer = (*streamedListObjectsStream)(nil)
+	_ openfgav1.OpenFGAService_StreamedListObjectsClient = (*streamedListObjectsStream)(nil)
+)
+
+// Context implements grpc.ServerStream and grpc.ClientStream.
+func (s *streamedListObjectsStream) Context() context.Context {
+	return s.ctx
+}
+
+// Send implements openfgav1.OpenFGAService_StreamedListObjectsServer.
+func (s *streamedListObjectsStream) Send(resp *openfgav1.StreamedListObjectsResponse) error {
+	select {
+	case s.responses <- resp:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// Recv implements openfgav1.OpenFGAService_StreamedListObjectsClient. It returns io.EOF after
+// the last response.
+func (s *streamedListObjectsStream) Recv() (*openfgav1.StreamedListObjectsResponse, error) {
+	select {
+	case resp := <-s.responses:
+		return resp, nil
+	case <-s.done:
+		s.setHeadersOnce.Do(s.setHeaders)
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+}
+
+// SendMsg implements grpc.ServerStream.
+func (s *streamedListObjectsStream) SendMsg(m any) error {
+	resp, ok := m.(*openfgav1.StreamedListObjectsResponse)
+	if !ok {
+		return errors.New("unexpected message type")
+	}
+
+	return s.Send(resp)
+}
+
+// RecvMsg implements grpc.ClientStream.
+func (s *streamedListObjectsStream) RecvMsg(m any) error {
+	msg, ok := m.(*openfgav1.StreamedListObjectsResponse)
+	if !ok {
+		return errors.New("unexpected message type")
+	}
+
+	resp, err := s.Recv()
+	if err != nil {
+		return err
+	}
+
+	proto.Merge(msg, resp)
+	return nil
+}
+
+// Header implements grpc.ClientStream. It returns the response headers that were set so far. They
+// are also returned with the grpc.Header call option, once Recv returned the end of the stream.
+func (s *streamedListObjectsStream) Header() (metadata.MD, error) {
+	if headers, ok := s.ctx.Value(headersCtxKey{}).(*responseHeaders); ok {
+		return headers.get(), nil
+	}
+
+	return metadata.MD{}, nil
+}
+
+// Trailer implements grpc.ClientStream.
+func (s *streamedListObjectsStream) Trailer() metadata.MD {
+	return metadata.MD{}
+}
+
+// CloseSend implements grpc.ClientStream.
+func (s *streamedListObjectsStream) CloseSend() error {
+	return nil
+}
+
+// SetHeader implements grpc.ServerStream.
+func (s *streamedListObjectsStream) SetHeader(metadata.MD) error {
+	return nil
+}
+
+// SendHeader implements grpc.ServerStream.
+func (s *streamedListObjectsStream) SendHeader(metadata.MD) error {
+	return nil
+}
+
+// SetTrailer implements grpc.ServerStream.
+func (s *streamedListObjectsStream) SetTrailer(metadata.MD) {}
@@ -0,0 +1,4 @@
+// Package embedded runs OpenFGA inside a Go application: it constructs the server with a memory,
+// MySQL or Postgres datastore, and provides an in-process client of the OpenFGA API that calls the
+// server directly, without gRPC.
+package embedded
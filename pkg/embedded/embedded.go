@@ -0,0 +1,185 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/gateway"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storage/mysql"
+	"github.com/openfga/openfga/pkg/storage/postgres"
+	"github.com/openfga/openfga/pkg/storage/sqlcommon"
+)
+
+// OpenFGA is an OpenFGA server that runs in-process.
+type OpenFGA struct {
+	server    *server.Server
+	datastore storage.OpenFGADatastore
+
+	// closeDatastore is whether the datastore was created by New, and must be closed with the server.
+	closeDatastore bool
+}
+
+type config struct {
+	engine           string
+	uri              string
+	datastore        storage.OpenFGADatastore
+	memoryOptions    []memory.StorageOption
+	datastoreOptions []sqlcommon.DatastoreOption
+	serverOptions    []server.OpenFGAServiceV1Option
+}
+
+// Option configures an OpenFGA.
+type Option func(*config)
+
+// WithMemoryDatastore stores the data in memory. This is the default.
+func WithMemoryDatastore(opts ...memory.StorageOption) Option {
+	return func(c *config) {
+		c.engine = "memory"
+		c.memoryOptions = opts
+	}
+}
+
+// WithSQLDatastore stores the data in a MySQL or Postgres database, with the engine "mysql" or
+// "postgres". The database must have been migrated, e.g. with the `openfga migrate` command.
+func WithSQLDatastore(engine, uri string, opts ...sqlcommon.DatastoreOption) Option {
+	return func(c *config) {
+		c.engine = engine
+		c.uri = uri
+		c.datastoreOptions = opts
+	}
+}
+
+// WithDatastore uses an existing datastore. It isn't closed with the OpenFGA.
+func WithDatastore(ds storage.OpenFGADatastore) Option {
+	return func(c *config) {
+		c.datastore = ds
+	}
+}
+
+// WithServerOptions configures the server, e.g. with server.WithCheckQueryCacheEnabled.
+func WithServerOptions(opts ...server.OpenFGAServiceV1Option) Option {
+	return func(c *config) {
+		c.serverOptions = append(c.serverOptions, opts...)
+	}
+}
+
+// New constructs an OpenFGA with the options. It must be closed with Close.
+func New(opts ...Option) (*OpenFGA, error) {
+	cfg := &config{engine: "memory"}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	o := &OpenFGA{datastore: cfg.datastore}
+	if o.datastore == nil {
+		datastore, err := newDatastore(cfg)
+		if err != nil {
+			return nil, err
+		}
+		o.datastore = datastore
+		o.closeDatastore = true
+	}
+
+	serverOpts := append([]server.OpenFGAServiceV1Option{
+		server.WithDatastore(o.datastore),
+		server.WithTransport(headerTransport{}),
+	}, cfg.serverOptions...)
+
+	s, err := server.NewServerWithOpts(serverOpts...)
+	if err != nil {
+		if o.closeDatastore {
+			o.datastore.Close()
+		}
+		return nil, err
+	}
+	o.server = s
+
+	return o, nil
+}
+
+func newDatastore(cfg *config) (storage.OpenFGADatastore, error) {
+	switch cfg.engine {
+	case "memory":
+		return memory.New(cfg.memoryOptions...), nil
+	case "mysql":
+		datastore, err := mysql.New(cfg.uri, sqlcommon.NewConfig(cfg.datastoreOptions...))
+		if err != nil {
+			return nil, fmt.Errorf("initialize mysql datastore: %w", err)
+		}
+		return datastore, nil
+	case "postgres":
+		datastore, err := postgres.New(cfg.uri, sqlcommon.NewConfig(cfg.datastoreOptions...))
+		if err != nil {
+			return nil, fmt.Errorf("initialize postgres datastore: %w", err)
+		}
+		return datastore, nil
+	default:
+		return nil, fmt.Errorf("storage engine '%s' is unsupported", cfg.engine)
+	}
+}
+
+// Server returns the server, which implements the OpenFGA API service.
+func (o *OpenFGA) Server() *server.Server {
+	return o.server
+}
+
+// Client returns an in-process client of the OpenFGA API. It calls the server directly, without
+// gRPC, but like a gRPC client, it passes the outgoing metadata of the context as request headers,
+// and returns the response headers with the grpc.Header call option.
+func (o *OpenFGA) Client() openfgav1.OpenFGAServiceClient {
+	return &client{server: o.server}
+}
+
+// Close releases the resources of the server, and closes the datastore if it was created by New.
+func (o *OpenFGA) Close() {
+	o.server.Close()
+
+	if o.closeDatastore {
+		o.datastore.Close()
+	}
+}
+
+type headersCtxKey struct{}
+
+// responseHeaders are the response headers of a call. The server may set them from several
+// goroutines, e.g. while it streams the responses.
+type responseHeaders struct {
+	mu sync.Mutex
+	md metadata.MD
+}
+
+// append appends the value to the header.
+func (h *responseHeaders) append(key, value string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.md.Append(key, value)
+}
+
+// get returns a copy of the headers.
+func (h *responseHeaders) get() metadata.MD {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.md.Copy()
+}
+
+// headerTransport is a gateway.Transport that sets the response headers on the responseHeaders in
+// the context, if any.
+type headerTransport struct{}
+
+var _ gateway.Transport = headerTransport{}
+
+// SetHeader implements gateway.Transport.
+func (headerTransport) SetHeader(ctx context.Context, key, value string) {
+	if headers, ok := ctx.Value(headersCtxKey{}).(*responseHeaders); ok {
+		headers.append(key, value)
+	}
+}
@@ -0,0 +1,201 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func ExampleNew() {
+	openfga, err := New(
+		WithMemoryDatastore(), // or WithSQLDatastore("postgres", uri)
+		WithServerOptions(server.WithCheckQueryCacheEnabled(true)),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer openfga.Close()
+
+	client := openfga.Client()
+
+	store, err := client.CreateStore(context.Background(), &openfgav1.CreateStoreRequest{Name: "demo"})
+	if err != nil {
+		panic(err)
+	}
+
+	model := language.MustTransformDSLToProto(`
+	model
+		schema 1.1
+	type user
+
+	type document
+		relations
+			define reader: [user]`)
+
+	_, err = client.WriteAuthorizationModel(context.Background(), &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+		SchemaVersion:   model.GetSchemaVersion(),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = client.Write(context.Background(), &openfgav1.WriteRequest{
+		StoreId: store.GetId(),
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:budget", "reader", "user:anne")},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	checkResponse, err := client.Check(context.Background(), &openfgav1.CheckRequest{
+		StoreId:  store.GetId(),
+		TupleKey: tuple.NewCheckRequestTupleKey("document:budget", "reader", "user:anne"),
+	})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(checkResponse.GetAllowed())
+
+	// Output: true
+}
+
+func TestClient(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	openfga, err := New()
+	require.NoError(t, err)
+	t.Cleanup(openfga.Close)
+
+	client := openfga.Client()
+
+	store, err := client.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "openfga-test"})
+	require.NoError(t, err)
+
+	_, err = client.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   "1.1",
+		TypeDefinitions: language.MustTransformDSLToProto("model\n  schema 1.1\ntype user\ntype document\n  relations\n    define viewer: [user]").GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	var headers metadata.MD
+	_, err = client.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store.GetId(),
+		Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		}},
+	}, grpc.Header(&headers))
+	require.NoError(t, err)
+
+	t.Run("headers", func(t *testing.T) {
+		token := headers.Get(server.ConsistencyTokenHeader)
+		require.Len(t, token, 1)
+
+		tokenCtx := metadata.AppendToOutgoingContext(ctx, server.ConsistencyTokenHeader, token[0])
+		checkResp, err := client.Check(tokenCtx, &openfgav1.CheckRequest{
+			StoreId:  store.GetId(),
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.True(t, checkResp.GetAllowed())
+
+		invalidCtx := metadata.AppendToOutgoingContext(ctx, server.ConsistencyTokenHeader, "invalid")
+		_, err = client.Check(invalidCtx, &openfgav1.CheckRequest{
+			StoreId:  store.GetId(),
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("validates_requests", func(t *testing.T) {
+		_, err := client.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  "invalid",
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("streamed_list_objects", func(t *testing.T) {
+		var streamHeaders metadata.MD
+		stream, err := client.StreamedListObjects(ctx, &openfgav1.StreamedListObjectsRequest{
+			StoreId:  store.GetId(),
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+		}, grpc.Header(&streamHeaders))
+		require.NoError(t, err)
+		require.Nil(t, streamHeaders)
+
+		var objects []string
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			objects = append(objects, resp.GetObject())
+		}
+		require.ElementsMatch(t, []string{"document:1", "document:2"}, objects)
+		require.NotNil(t, streamHeaders)
+
+		stream, err = client.StreamedListObjects(ctx, &openfgav1.StreamedListObjectsRequest{
+			StoreId:  store.GetId(),
+			Type:     "folder",
+			Relation: "viewer",
+			User:     "user:anne",
+		})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_type_not_found), status.Code(err))
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	t.Run("unsupported_engine", func(t *testing.T) {
+		_, err := New(WithSQLDatastore("sqlite", ""))
+		require.ErrorContains(t, err, "unsupported")
+	})
+
+	t.Run("existing_datastore_is_not_closed", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		openfga, err := New(WithDatastore(ds))
+		require.NoError(t, err)
+		require.False(t, openfga.closeDatastore)
+		openfga.Close()
+	})
+
+	t.Run("invalid_server_options", func(t *testing.T) {
+		_, err := New(WithServerOptions(server.WithRequestDurationByQueryHistogramBuckets(nil)))
+		require.Error(t, err)
+	})
+}
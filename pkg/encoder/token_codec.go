@@ -0,0 +1,148 @@
+package encoder
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/openfga/openfga/pkg/encrypter"
+)
+
+// Ensure TokenCodec implements the Encoder interface.
+var _ Encoder = (*TokenCodec)(nil)
+
+// TokenVersion is the version of the format of the continuation tokens of a TokenCodec. It is the
+// first byte of a token, so that the format can evolve without misreading older tokens.
+const TokenVersion byte = 1
+
+var (
+	// ErrInvalidToken is returned when a continuation token can't be decoded. The other errors of
+	// the TokenCodec wrap it.
+	ErrInvalidToken = errors.New("invalid continuation token")
+
+	// ErrUnsupportedTokenVersion is returned when the version of a continuation token is not TokenVersion.
+	ErrUnsupportedTokenVersion = fmt.Errorf("%w: unsupported version", ErrInvalidToken)
+
+	// ErrTokenSignatureMismatch is returned when a continuation token was not signed with any of the
+	// keys of the TokenCodec, for example because it was tampered with.
+	ErrTokenSignatureMismatch = fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+)
+
+// TokenCodec encodes continuation tokens in a versioned format that is signed with HMAC-SHA256, and
+// optionally encrypted. A token is the encoding of:
+//
+//	version (1 byte) | payload | HMAC-SHA256(version | payload)
+//
+// where the payload is the encrypted data. Tokens that were tampered with, or mangled, are rejected
+// before they reach the datastore.
+type TokenCodec struct {
+	keys      [][]byte
+	encrypter encrypter.Encrypter
+	encoder   Encoder
+}
+
+// TokenCodecOption configures a TokenCodec.
+type TokenCodecOption func(*TokenCodec)
+
+// WithTokenCodecVerificationKeys sets keys that tokens are also verified with, but not signed with,
+// so that the signing key can be rotated without rejecting the tokens signed with the previous one.
+func WithTokenCodecVerificationKeys(keys ...string) TokenCodecOption {
+	return func(c *TokenCodec) {
+		for _, key := range keys {
+			c.keys = append(c.keys, []byte(key))
+		}
+	}
+}
+
+// WithTokenCodecEncrypter sets the encrypter of the payload of the tokens. By default it is not encrypted.
+func WithTokenCodecEncrypter(e encrypter.Encrypter) TokenCodecOption {
+	return func(c *TokenCodec) {
+		c.encrypter = e
+	}
+}
+
+// WithTokenCodecEncoder sets the encoder of the tokens. By default it is a Base64Encoder.
+func WithTokenCodecEncoder(e Encoder) TokenCodecOption {
+	return func(c *TokenCodec) {
+		c.encoder = e
+	}
+}
+
+// NewTokenCodec constructs a TokenCodec that signs the tokens with the signing key.
+func NewTokenCodec(signingKey string, opts ...TokenCodecOption) *TokenCodec {
+	c := &TokenCodec{
+		keys:      [][]byte{[]byte(signingKey)},
+		encrypter: encrypter.NewNoopEncrypter(),
+		encoder:   NewBase64Encoder(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *TokenCodec) sign(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// Decode decodes the token, verifies its version and its signature, and decrypts its payload. An
+// empty token is decoded as empty data.
+func (c *TokenCodec) Decode(s string) ([]byte, error) {
+	if s == "" {
+		return []byte{}, nil
+	}
+
+	decoded, err := c.encoder.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if len(decoded) < 1+sha256.Size {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidToken)
+	}
+
+	if decoded[0] != TokenVersion {
+		return nil, ErrUnsupportedTokenVersion
+	}
+
+	signed, signature := decoded[:len(decoded)-sha256.Size], decoded[len(decoded)-sha256.Size:]
+
+	verified := false
+	for _, key := range c.keys {
+		if hmac.Equal(signature, c.sign(key, signed)) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrTokenSignatureMismatch
+	}
+
+	data, err := c.encrypter.Decrypt(signed[1:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return data, nil
+}
+
+// Encode encrypts the data, and encodes it with the version and the signature. Empty data is
+// encoded as an empty token, which is the token of the last page.
+func (c *TokenCodec) Encode(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	encrypted, err := c.encrypter.Encrypt(data)
+	if err != nil {
+		return "", err
+	}
+
+	signed := append([]byte{TokenVersion}, encrypted...)
+	return c.encoder.Encode(append(signed, c.sign(c.keys[0], signed)...))
+}
@@ -0,0 +1,94 @@
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/encrypter"
+)
+
+func TestTokenCodec(t *testing.T) {
+	gcmEncrypter, err := encrypter.NewGCMEncrypter("encryption")
+	require.NoError(t, err)
+
+	codecs := map[string]*TokenCodec{
+		"signed":           NewTokenCodec("key"),
+		"signed_encrypted": NewTokenCodec("key", WithTokenCodecEncrypter(gcmEncrypter)),
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			data := []byte(`{"ulid":"01HVMMBCMGZNT3SED4Z17ECXCA","object_type":"document"}`)
+
+			token, err := codec.Encode(data)
+			require.NoError(t, err)
+			require.NotContains(t, token, "document")
+
+			decoded, err := codec.Decode(token)
+			require.NoError(t, err)
+			require.Equal(t, data, decoded)
+
+			// the last page has an empty token
+			token, err = codec.Encode(nil)
+			require.NoError(t, err)
+			require.Empty(t, token)
+
+			decoded, err = codec.Decode("")
+			require.NoError(t, err)
+			require.Empty(t, decoded)
+		})
+	}
+
+	t.Run("rejects_tampered_tokens", func(t *testing.T) {
+		codec := NewTokenCodec("key")
+		base64 := NewBase64Encoder()
+
+		token, err := codec.Encode([]byte("01HVMMBCMGZNT3SED4Z17ECXCA"))
+		require.NoError(t, err)
+
+		raw, err := base64.Decode(token)
+		require.NoError(t, err)
+
+		tampered := append([]byte(nil), raw...)
+		tampered[1] ^= 1
+		tamperedToken, err := base64.Encode(tampered)
+		require.NoError(t, err)
+
+		_, err = codec.Decode(tamperedToken)
+		require.ErrorIs(t, err, ErrTokenSignatureMismatch)
+		require.ErrorIs(t, err, ErrInvalidToken)
+
+		_, err = NewTokenCodec("other").Decode(token)
+		require.ErrorIs(t, err, ErrTokenSignatureMismatch)
+
+		versioned := append([]byte(nil), raw...)
+		versioned[0] = TokenVersion + 1
+		versionedToken, err := base64.Encode(versioned)
+		require.NoError(t, err)
+
+		_, err = codec.Decode(versionedToken)
+		require.ErrorIs(t, err, ErrUnsupportedTokenVersion)
+
+		// unsigned tokens, e.g. of a Base64Encoder, are rejected
+		unsigned, err := base64.Encode([]byte("01HVMMBCMGZNT3SED4Z17ECXCA"))
+		require.NoError(t, err)
+		_, err = codec.Decode(unsigned)
+		require.ErrorIs(t, err, ErrInvalidToken)
+
+		_, err = codec.Decode("not base64!")
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("key_rotation", func(t *testing.T) {
+		token, err := NewTokenCodec("old").Encode([]byte("data"))
+		require.NoError(t, err)
+
+		decoded, err := NewTokenCodec("new", WithTokenCodecVerificationKeys("old")).Decode(token)
+		require.NoError(t, err)
+		require.Equal(t, []byte("data"), decoded)
+
+		_, err = NewTokenCodec("new").Decode(token)
+		require.ErrorIs(t, err, ErrTokenSignatureMismatch)
+	})
+}
@@ -0,0 +1,310 @@
+package encrypter
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/karlseguin/ccache/v3"
+)
+
+// Ensure EnvelopeEncrypter implements the Encrypter interface.
+var _ Encrypter = (*EnvelopeEncrypter)(nil)
+
+// Ensure LocalKeyWrapper implements the KeyWrapper interface.
+var _ KeyWrapper = (*LocalKeyWrapper)(nil)
+
+const (
+	envelopeVersion byte = 1
+
+	dataKeySize = 32
+
+	// DefaultDataKeyUsages is the default number of encryptions after which an EnvelopeEncrypter
+	// generates a new data key.
+	DefaultDataKeyUsages = 1 << 20
+
+	// DefaultMaxUnwrappedDataKeys is the default number of unwrapped data keys an EnvelopeEncrypter caches.
+	DefaultMaxUnwrappedDataKeys = 1024
+
+	// unwrappedDataKeyTTL is how long an unwrapped data key stays cached. The cache is bounded by its
+	// size, the TTL only ensures that an unused key is eventually dropped.
+	unwrappedDataKeyTTL = time.Hour
+)
+
+// ErrUnknownKeyID is returned when data was encrypted with a key encryption key that is unknown.
+var ErrUnknownKeyID = errors.New("unknown key encryption key")
+
+// KeyWrapper encrypts (wraps) and decrypts (unwraps) the data keys of an EnvelopeEncrypter with key
+// encryption keys. It is the extension point for key management services (KMS): the key encryption
+// keys never leave it.
+type KeyWrapper interface {
+	// WrapKey encrypts the data key with the current key encryption key, and returns the ID of that key.
+	WrapKey(dataKey []byte) (keyID string, wrapped []byte, err error)
+
+	// UnwrapKey decrypts a data key that was wrapped with the key encryption key with the ID.
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKeyWrapper is a KeyWrapper with local key encryption keys. The first key wraps the data keys,
+// and all of them unwrap them, so that the keys can be rotated: data encrypted with a previous key
+// stays readable as long as that key is configured.
+type LocalKeyWrapper struct {
+	currentID string
+	keys      map[string]*GCMEncrypter
+}
+
+// NewLocalKeyWrapper creates a new instance of LocalKeyWrapper with the provided keys. The ID of a
+// key is derived from it, so that it doesn't depend on the order of the keys.
+func NewLocalKeyWrapper(keys ...string) (*LocalKeyWrapper, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key encryption key is required")
+	}
+
+	w := &LocalKeyWrapper{keys: make(map[string]*GCMEncrypter, len(keys))}
+	for i, key := range keys {
+		if key == "" {
+			return nil, errors.New("key encryption keys must not be empty")
+		}
+
+		e, err := NewGCMEncrypter(key)
+		if err != nil {
+			return nil, err
+		}
+
+		id := localKeyID(key)
+		if i == 0 {
+			w.currentID = id
+		}
+		w.keys[id] = e
+	}
+
+	return w, nil
+}
+
+// localKeyID returns the ID of a key: a hash of it, distinct from the one its AES key is derived with.
+func localKeyID(key string) string {
+	sum := sha256.Sum256([]byte("openfga-key-id:" + key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// WrapKey encrypts the data key with the first key.
+func (w *LocalKeyWrapper) WrapKey(dataKey []byte) (string, []byte, error) {
+	wrapped, err := w.keys[w.currentID].Encrypt(dataKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return w.currentID, wrapped, nil
+}
+
+// UnwrapKey decrypts the data key with the key with the ID.
+func (w *LocalKeyWrapper) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	e, ok := w.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownKeyID, keyID)
+	}
+
+	return e.Decrypt(wrapped)
+}
+
+// dataKey is a data key and its wrapped form.
+type dataKey struct {
+	keyID   string
+	wrapped []byte
+	aead    cipher.AEAD
+	usages  int
+}
+
+// EnvelopeEncrypter is an implementation of the Encrypter interface that uses envelope encryption:
+// the data is encrypted with AES-GCM with a data key, which is stored next to it wrapped by a
+// KeyWrapper. The data key is reused for a number of encryptions, so that the KeyWrapper, e.g. a
+// remote KMS, isn't called for every encryption. The encrypted data is:
+//
+//	version (1 byte) | key ID length (1 byte) | key ID | wrapped key length (2 bytes) | wrapped key | nonce | ciphertext
+type EnvelopeEncrypter struct {
+	wrapper              KeyWrapper
+	dataKeyUsages        int
+	maxUnwrappedDataKeys int
+
+	mu      sync.Mutex
+	current *dataKey
+
+	// unwrapped caches the least recently used unwrapped data keys, by key ID and wrapped key.
+	unwrapped *ccache.Cache[cipher.AEAD]
+}
+
+// EnvelopeEncrypterOption configures an EnvelopeEncrypter.
+type EnvelopeEncrypterOption func(*EnvelopeEncrypter)
+
+// WithDataKeyUsages sets the number of encryptions after which a new data key is generated.
+func WithDataKeyUsages(n int) EnvelopeEncrypterOption {
+	return func(e *EnvelopeEncrypter) {
+		e.dataKeyUsages = n
+	}
+}
+
+// WithMaxUnwrappedDataKeys sets the number of unwrapped data keys that are cached, so that decrypting
+// data encrypted with them doesn't call the KeyWrapper.
+func WithMaxUnwrappedDataKeys(n int) EnvelopeEncrypterOption {
+	return func(e *EnvelopeEncrypter) {
+		e.maxUnwrappedDataKeys = n
+	}
+}
+
+// NewEnvelopeEncrypter creates a new instance of EnvelopeEncrypter that wraps its data keys with the
+// wrapper. It must be closed with Close.
+func NewEnvelopeEncrypter(wrapper KeyWrapper, opts ...EnvelopeEncrypterOption) *EnvelopeEncrypter {
+	e := &EnvelopeEncrypter{
+		wrapper:              wrapper,
+		dataKeyUsages:        DefaultDataKeyUsages,
+		maxUnwrappedDataKeys: DefaultMaxUnwrappedDataKeys,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	e.unwrapped = ccache.New(ccache.Configure[cipher.AEAD]().MaxSize(int64(e.maxUnwrappedDataKeys)))
+
+	return e
+}
+
+// Close stops the cache of the unwrapped data keys.
+func (e *EnvelopeEncrypter) Close() {
+	e.unwrapped.Stop()
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(c)
+}
+
+// dataKey returns the data key to encrypt with, and generates a new one when it was used up.
+func (e *EnvelopeEncrypter) dataKey() (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.current != nil && e.current.usages < e.dataKeyUsages {
+		e.current.usages++
+		return e.current, nil
+	}
+
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	keyID, wrapped, err := e.wrapper.WrapKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+	if len(keyID) > 255 || len(wrapped) > 65535 {
+		return nil, errors.New("wrapped data key is too long")
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	e.current = &dataKey{keyID: keyID, wrapped: wrapped, aead: aead, usages: 1}
+	return e.current, nil
+}
+
+// Encrypt encrypts the given byte array with the current data key.
+func (e *EnvelopeEncrypter) Encrypt(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	dk, err := e.dataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, 4+len(dk.keyID)+len(dk.wrapped)+dk.aead.NonceSize()+len(data)+dk.aead.Overhead())
+	out = append(out, envelopeVersion, byte(len(dk.keyID)))
+	out = append(out, dk.keyID...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(dk.wrapped)))
+	out = append(out, dk.wrapped...)
+
+	nonce := make([]byte, dk.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+
+	return dk.aead.Seal(out, nonce, data, nil), nil
+}
+
+// Decrypt unwraps the data key of the encrypted byte array, and decrypts it.
+func (e *EnvelopeEncrypter) Decrypt(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	if data[0] != envelopeVersion {
+		return nil, fmt.Errorf("unsupported envelope version %d", data[0])
+	}
+
+	errTooShort := errors.New("ciphertext too short")
+
+	rest := data[1:]
+	if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+		return nil, errTooShort
+	}
+	keyID, rest := string(rest[1:1+int(rest[0])]), rest[1+int(rest[0]):]
+
+	if len(rest) < 2 {
+		return nil, errTooShort
+	}
+	wrappedLen := int(binary.BigEndian.Uint16(rest))
+	if len(rest) < 2+wrappedLen {
+		return nil, errTooShort
+	}
+	wrapped, rest := rest[2:2+wrappedLen], rest[2+wrappedLen:]
+
+	aead, err := e.unwrap(keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) < aead.NonceSize() {
+		return nil, errTooShort
+	}
+
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func (e *EnvelopeEncrypter) unwrap(keyID string, wrapped []byte) (cipher.AEAD, error) {
+	cacheKey := keyID + "/" + string(wrapped)
+	if item := e.unwrapped.Get(cacheKey); item != nil && !item.Expired() {
+		return item.Value(), nil
+	}
+
+	key, err := e.wrapper.UnwrapKey(keyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	e.unwrapped.Set(cacheKey, aead, unwrappedDataKeyTTL)
+	return aead, nil
+}
@@ -0,0 +1,156 @@
+package encrypter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingKeyWrapper counts the calls to its wrapper.
+type countingKeyWrapper struct {
+	KeyWrapper
+	wraps, unwraps int
+}
+
+func (w *countingKeyWrapper) WrapKey(dataKey []byte) (string, []byte, error) {
+	w.wraps++
+	return w.KeyWrapper.WrapKey(dataKey)
+}
+
+func (w *countingKeyWrapper) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	w.unwraps++
+	return w.KeyWrapper.UnwrapKey(keyID, wrapped)
+}
+
+func TestEnvelopeEncrypter(t *testing.T) {
+	want := []byte(`{"ip":"192.168.0.1","email":"anne@example.com"}`)
+
+	t.Run("encrypt-decrypt_returns_original", func(t *testing.T) {
+		wrapper, err := NewLocalKeyWrapper("kek")
+		require.NoError(t, err)
+		e := NewEnvelopeEncrypter(wrapper)
+		defer e.Close()
+
+		encrypted, err := e.Encrypt(want)
+		require.NoError(t, err)
+		require.False(t, bytes.Contains(encrypted, []byte("anne")))
+
+		got, err := e.Decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		got, err = e.Encrypt(nil)
+		require.NoError(t, err)
+		require.Empty(t, got)
+
+		got, err = e.Decrypt(nil)
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+
+	t.Run("data_keys_are_reused_and_cached", func(t *testing.T) {
+		local, err := NewLocalKeyWrapper("kek")
+		require.NoError(t, err)
+		wrapper := &countingKeyWrapper{KeyWrapper: local}
+		e := NewEnvelopeEncrypter(wrapper, WithDataKeyUsages(2))
+		defer e.Close()
+
+		var encrypted [][]byte
+		for i := 0; i < 3; i++ {
+			data, err := e.Encrypt(want)
+			require.NoError(t, err)
+			encrypted = append(encrypted, data)
+		}
+		require.Equal(t, 2, wrapper.wraps)
+
+		for i := 0; i < 2; i++ {
+			for _, data := range encrypted {
+				got, err := e.Decrypt(data)
+				require.NoError(t, err)
+				require.Equal(t, want, got)
+			}
+		}
+		require.Equal(t, 2, wrapper.unwraps)
+	})
+
+	t.Run("unwrapped_data_keys_are_bounded", func(t *testing.T) {
+		local, err := NewLocalKeyWrapper("kek")
+		require.NoError(t, err)
+		wrapper := &countingKeyWrapper{KeyWrapper: local}
+		e := NewEnvelopeEncrypter(wrapper, WithDataKeyUsages(1), WithMaxUnwrappedDataKeys(1))
+		defer e.Close()
+
+		var encrypted [][]byte
+		for i := 0; i < 3; i++ {
+			data, err := e.Encrypt(want)
+			require.NoError(t, err)
+			encrypted = append(encrypted, data)
+		}
+
+		for _, data := range encrypted {
+			got, err := e.Decrypt(data)
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		}
+		require.Equal(t, 3, wrapper.unwraps)
+
+		require.Eventually(t, func() bool {
+			return e.unwrapped.ItemCount() <= 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("key_rotation", func(t *testing.T) {
+		oldWrapper, err := NewLocalKeyWrapper("old")
+		require.NoError(t, err)
+		oldEncrypter := NewEnvelopeEncrypter(oldWrapper)
+		defer oldEncrypter.Close()
+		encrypted, err := oldEncrypter.Encrypt(want)
+		require.NoError(t, err)
+
+		rotatedWrapper, err := NewLocalKeyWrapper("new", "old")
+		require.NoError(t, err)
+		rotatedEncrypter := NewEnvelopeEncrypter(rotatedWrapper)
+		defer rotatedEncrypter.Close()
+		got, err := rotatedEncrypter.Decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		newWrapper, err := NewLocalKeyWrapper("new")
+		require.NoError(t, err)
+		newEncrypter := NewEnvelopeEncrypter(newWrapper)
+		defer newEncrypter.Close()
+		_, err = newEncrypter.Decrypt(encrypted)
+		require.ErrorIs(t, err, ErrUnknownKeyID)
+	})
+
+	t.Run("rejects_tampered_data", func(t *testing.T) {
+		wrapper, err := NewLocalKeyWrapper("kek")
+		require.NoError(t, err)
+		e := NewEnvelopeEncrypter(wrapper)
+		defer e.Close()
+
+		encrypted, err := e.Encrypt(want)
+		require.NoError(t, err)
+
+		tampered := append([]byte(nil), encrypted...)
+		tampered[len(tampered)-1] ^= 1
+		_, err = e.Decrypt(tampered)
+		require.Error(t, err)
+
+		_, err = e.Decrypt(encrypted[:10])
+		require.Error(t, err)
+
+		_, err = e.Decrypt([]byte{envelopeVersion + 1})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid_keys", func(t *testing.T) {
+		_, err := NewLocalKeyWrapper()
+		require.Error(t, err)
+
+		_, err = NewLocalKeyWrapper("kek", "")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,147 @@
+// Package featureflags gates the experimental behaviors of the server per store, so that they can be
+// rolled out to some stores, or to a percentage of the stores, before all of them.
+package featureflags
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Flag is a feature flag, which gates an experimental behavior.
+type Flag string
+
+const (
+	// CheckPlanner gates the Check planner, see server.WithCheckPlannerEnabled.
+	CheckPlanner Flag = "check-planner"
+
+	// ListObjectsShards gates the sharding of the Checks of ListObjects, see
+	// server.WithListObjectsShards.
+	ListObjectsShards Flag = "list-objects-shards"
+
+	// ConsistencyTokens gates the consistency tokens of Write, see server.ConsistencyTokenHeader.
+	ConsistencyTokens Flag = "consistency-tokens"
+)
+
+// Flags are the known feature flags.
+var Flags = []Flag{CheckPlanner, ListObjectsShards, ConsistencyTokens}
+
+// The values of a rule other than a store ID or a percentage.
+const (
+	valueOn  = "on"
+	valueOff = "off"
+)
+
+// rollout is the set of stores a flag is on for.
+type rollout struct {
+	all     bool
+	percent int
+	stores  map[string]struct{}
+}
+
+func (r *rollout) enabled(flag Flag, storeID string) bool {
+	if r.all {
+		return true
+	}
+	if _, ok := r.stores[storeID]; ok {
+		return true
+	}
+	// the percentage of a flag doesn't always select the same stores as that of another flag
+	return r.percent > 0 && xxhash.Sum64String(string(flag)+"/"+storeID)%100 < uint64(r.percent)
+}
+
+// FeatureFlags are the feature flags of the server, and the stores each one is on for. A flag without
+// rules is on for all the stores, so that its behavior is only gated by its own configuration, e.g.
+// the check planner by server.WithCheckPlannerEnabled. It is safe for concurrent use.
+type FeatureFlags struct {
+	mu       sync.RWMutex
+	rules    map[Flag][]string
+	rollouts map[Flag]*rollout
+}
+
+// New returns feature flags that are on for all the stores.
+func New() *FeatureFlags {
+	return &FeatureFlags{rules: map[Flag][]string{}, rollouts: map[Flag]*rollout{}}
+}
+
+// Parse returns the feature flags of rules of the form 'flag=value'. The value is 'on', 'off', a store
+// ID, or a percentage of the stores, e.g. 'check-planner=10%'. A flag is on for a store if any of its
+// rules selects the store, so that 'check-planner=10%' and 'check-planner=<store ID>' roll the check
+// planner out to 10% of the stores and to that store.
+func Parse(rules []string) (*FeatureFlags, error) {
+	f := New()
+	for _, rule := range rules {
+		flag, value, ok := strings.Cut(rule, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid feature flag rule '%s', it must be of the form 'flag=value'", rule)
+		}
+		if err := f.Set(Flag(flag), append(f.Rules()[Flag(flag)], value)); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Set replaces the rules of a flag with the values, see Parse. Without values, the flag is on for all
+// the stores.
+func (f *FeatureFlags) Set(flag Flag, values []string) error {
+	if !slices.Contains(Flags, flag) {
+		return fmt.Errorf("unknown feature flag '%s', the flags are %v", flag, Flags)
+	}
+
+	r := &rollout{stores: map[string]struct{}{}}
+	for _, value := range values {
+		switch {
+		case value == valueOn:
+			r.all = true
+		case value == valueOff:
+		case strings.HasSuffix(value, "%"):
+			percent, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil || percent < 0 || percent > 100 {
+				return fmt.Errorf("invalid percentage '%s' of feature flag '%s', it must be between 0%% and 100%%", value, flag)
+			}
+			r.percent = max(r.percent, percent)
+		case value == "":
+			return fmt.Errorf("invalid empty value of feature flag '%s'", flag)
+		default:
+			r.stores[value] = struct{}{}
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(values) == 0 {
+		delete(f.rules, flag)
+		delete(f.rollouts, flag)
+		return nil
+	}
+	f.rules[flag] = slices.Clone(values)
+	f.rollouts[flag] = r
+	return nil
+}
+
+// Enabled reports whether the flag is on for the store.
+func (f *FeatureFlags) Enabled(flag Flag, storeID string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	r, ok := f.rollouts[flag]
+	return !ok || r.enabled(flag, storeID)
+}
+
+// Rules returns the values of the rules of each flag that has some.
+func (f *FeatureFlags) Rules() map[Flag][]string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	rules := make(map[Flag][]string, len(f.rules))
+	for flag, values := range f.rules {
+		rules[flag] = slices.Clone(values)
+	}
+	return rules
+}
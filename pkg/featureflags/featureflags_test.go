@@ -0,0 +1,130 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	const (
+		storeA = "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8A"
+		storeB = "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8B"
+	)
+
+	t.Run("on_for_all_the_stores_without_rules", func(t *testing.T) {
+		f := New()
+		for _, flag := range Flags {
+			require.True(t, f.Enabled(flag, storeA))
+		}
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		f, err := Parse([]string{"check-planner=" + storeA, "list-objects-shards=off", "consistency-tokens=on"})
+		require.NoError(t, err)
+
+		require.True(t, f.Enabled(CheckPlanner, storeA))
+		require.False(t, f.Enabled(CheckPlanner, storeB))
+		require.False(t, f.Enabled(ListObjectsShards, storeA))
+		require.True(t, f.Enabled(ConsistencyTokens, storeB))
+
+		require.Equal(t, map[Flag][]string{
+			CheckPlanner:      {storeA},
+			ListObjectsShards: {"off"},
+			ConsistencyTokens: {"on"},
+		}, f.Rules())
+	})
+
+	t.Run("percentage", func(t *testing.T) {
+		f, err := Parse([]string{"check-planner=30%"})
+		require.NoError(t, err)
+
+		var enabled int
+		for i := 0; i < 1000; i++ {
+			storeID := fmt.Sprintf("store-%d", i)
+			if f.Enabled(CheckPlanner, storeID) {
+				enabled++
+			}
+			// the stores of a percentage are stable
+			require.Equal(t, f.Enabled(CheckPlanner, storeID), f.Enabled(CheckPlanner, storeID))
+		}
+		require.InDelta(t, 300, enabled, 60)
+
+		require.NoError(t, f.Set(CheckPlanner, []string{"0%"}))
+		require.False(t, f.Enabled(CheckPlanner, storeA))
+
+		require.NoError(t, f.Set(CheckPlanner, []string{"100%"}))
+		require.True(t, f.Enabled(CheckPlanner, storeA))
+	})
+
+	t.Run("rules_select_the_union_of_their_stores", func(t *testing.T) {
+		f, err := Parse([]string{"check-planner=0%", "check-planner=" + storeB})
+		require.NoError(t, err)
+
+		require.False(t, f.Enabled(CheckPlanner, storeA))
+		require.True(t, f.Enabled(CheckPlanner, storeB))
+	})
+
+	t.Run("set_without_values_resets_the_flag", func(t *testing.T) {
+		f, err := Parse([]string{"check-planner=off"})
+		require.NoError(t, err)
+
+		require.NoError(t, f.Set(CheckPlanner, nil))
+		require.True(t, f.Enabled(CheckPlanner, storeA))
+		require.Empty(t, f.Rules())
+	})
+
+	t.Run("invalid_rules", func(t *testing.T) {
+		for _, rule := range []string{"check-planner", "check-planner=", "new-planner=on", "check-planner=110%", "check-planner=ten%"} {
+			_, err := Parse([]string{rule})
+			require.Error(t, err, rule)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	const storeID = "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8A"
+
+	f, err := Parse([]string{"check-planner=off"})
+	require.NoError(t, err)
+	handler := Handler(f)
+
+	serve := func(method, target, body string) (*httptest.ResponseRecorder, State) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+		var state State
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&state))
+		}
+		return rec, state
+	}
+
+	rec, state := serve(http.MethodGet, "/feature-flags", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, map[Flag][]string{CheckPlanner: {"off"}}, state.Rules)
+	require.Empty(t, state.Enabled)
+
+	rec, state = serve(http.MethodGet, "/feature-flags?store_id="+storeID, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, map[Flag]bool{CheckPlanner: false, ListObjectsShards: true, ConsistencyTokens: true}, state.Enabled)
+
+	rec, state = serve(http.MethodPut, "/feature-flags?store_id="+storeID, `{"flag": "check-planner", "values": ["`+storeID+`"]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, state.Enabled[CheckPlanner])
+	require.True(t, f.Enabled(CheckPlanner, storeID))
+
+	rec, _ = serve(http.MethodPut, "/feature-flags", `{"flag": "new-planner", "values": ["on"]}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = serve(http.MethodPut, "/feature-flags", `{"flag": `)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = serve(http.MethodDelete, "/feature-flags", "")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
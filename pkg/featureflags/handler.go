@@ -0,0 +1,68 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// State is the state of the feature flags.
+type State struct {
+	// Rules are the values of the rules of each flag that has some. The other flags are on for all
+	// the stores.
+	Rules map[Flag][]string `json:"rules"`
+
+	// StoreID is the store of the request, if any.
+	StoreID string `json:"store_id,omitempty"`
+
+	// Enabled is whether each flag is on for the store of the request, if any.
+	Enabled map[Flag]bool `json:"enabled,omitempty"`
+}
+
+// Change is a change of the rules of a flag.
+type Change struct {
+	// Flag is the changed flag.
+	Flag Flag `json:"flag"`
+
+	// Values are the new values of the rules of the flag, see Parse. Without values, the flag is on
+	// for all the stores.
+	Values []string `json:"values"`
+}
+
+// Handler returns the handler of the feature flags f, to query and change them at runtime. A GET
+// request returns the State of f, and whether each flag is on for the store of its 'store_id' query
+// parameter, if any. A PUT request applies the Change of its body and returns the updated State. An
+// invalid change is rejected with a 400 status.
+func Handler(f *FeatureFlags) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var change Change
+			if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+				http.Error(w, fmt.Sprintf("invalid feature flag change: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			if err := f.Set(change.Flag, change.Values); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		state := State{Rules: f.Rules(), StoreID: r.URL.Query().Get("store_id")}
+		if state.StoreID != "" {
+			state.Enabled = make(map[Flag]bool, len(Flags))
+			for _, flag := range Flags {
+				state.Enabled[flag] = f.Enabled(flag, state.StoreID)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(state)
+	})
+}
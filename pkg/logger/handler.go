@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// Levels are the log levels of a logger and of its module loggers.
+type Levels struct {
+	// Level is the level of the logger.
+	Level string `json:"level"`
+
+	// Modules are the levels of the module loggers whose level was changed, by module.
+	Modules map[string]string `json:"modules"`
+}
+
+// LevelChange is a change of the level of a logger, or of one of its module loggers.
+type LevelChange struct {
+	// Module is the module of the changed module logger, or empty to change the level of the logger.
+	Module string `json:"module"`
+
+	// Level is the new level. An empty level makes a module logger log at the level of the logger.
+	Level string `json:"level"`
+}
+
+// LevelsHandler returns the handler of the log levels of l, to change them at runtime. A GET request
+// returns the Levels of l, and a PUT request applies the LevelChange of its body and returns the
+// updated Levels. An invalid change is rejected with a 400 status.
+func LevelsHandler(l *ZapLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var change LevelChange
+			if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+				http.Error(w, fmt.Sprintf("invalid log level change: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			var err error
+			if change.Module == "" {
+				err = l.SetLevel(change.Level)
+			} else {
+				err = l.SetModuleLevel(change.Module, change.Level)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			l.Info("log level changed", zap.String("module", change.Module), zap.String("level", change.Level))
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Levels{Level: l.Level(), Modules: l.ModuleLevels()})
+	})
+}
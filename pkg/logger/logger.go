@@ -2,12 +2,15 @@ package logger
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/middleware/requestid"
 )
 
 type Logger interface {
@@ -31,7 +34,7 @@ type Logger interface {
 // NewNoopLogger provides a noop logger.
 func NewNoopLogger() *ZapLogger {
 	return &ZapLogger{
-		zap.NewNop(),
+		Logger: zap.NewNop(),
 	}
 }
 
@@ -39,10 +42,120 @@ func NewNoopLogger() *ZapLogger {
 // It provides additional methods such as ones that logs based on context.
 type ZapLogger struct {
 	*zap.Logger
+
+	// level is the level of the underlying logger. It is nil if the level cannot be changed.
+	level *zap.AtomicLevel
+
+	// modules are the levels of the module loggers, and base the logger that they derive from,
+	// which isn't bounded by level. They are nil if the logger has no module loggers.
+	modules *moduleLevels
+	base    *zap.Logger
 }
 
 var _ Logger = (*ZapLogger)(nil)
 
+// SetLevel changes the level of the logger (and of any logger derived from it) at runtime.
+// It returns an error if the level is unknown or if the level of the logger cannot be changed
+// (e.g. the noop logger).
+func (l *ZapLogger) SetLevel(level string) error {
+	if l.level == nil {
+		return errors.New("the log level of this logger cannot be changed")
+	}
+
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.level.SetLevel(lvl)
+	return nil
+}
+
+// Level returns the level of the logger, or "none" if it doesn't log.
+func (l *ZapLogger) Level() string {
+	if l.level == nil {
+		return "none"
+	}
+	return levelString(l.level.Level())
+}
+
+// Module returns the logger of a module (see Modules), whose level can be changed apart from the
+// level of l with SetModuleLevel, e.g. to debug the graph resolution only. Until then, it logs at the
+// level of l. Its logs have a 'module' field. If l has no module loggers, the logger of the module
+// always logs at the level of l.
+func (l *ZapLogger) Module(module string) *ZapLogger {
+	if l.modules == nil {
+		return &ZapLogger{
+			Logger: l.Logger.With(zap.String("module", module)),
+			level:  l.level,
+		}
+	}
+
+	level := l.modules.get(module)
+	return &ZapLogger{
+		Logger: l.base.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newLevelCore(core, level, l.modules.sampling)
+		})).With(zap.String("module", module)),
+		level:   l.level,
+		modules: l.modules,
+		base:    l.base,
+	}
+}
+
+// SetModuleLevel changes the level of the logger of a module at runtime. An empty level makes it log
+// at the level of l again. It returns an error if the module or the level is unknown, or if l has no
+// module loggers.
+func (l *ZapLogger) SetModuleLevel(module, level string) error {
+	if l.modules == nil {
+		return errors.New("the module log levels of this logger cannot be changed")
+	}
+
+	if !slices.Contains(Modules, module) {
+		return fmt.Errorf("unknown log module: %s", module)
+	}
+
+	if level == "" {
+		l.modules.get(module).unset()
+		return nil
+	}
+
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.modules.get(module).set(lvl)
+	return nil
+}
+
+// ModuleLevels returns the levels of the loggers of the modules whose level was changed with
+// SetModuleLevel, by module.
+func (l *ZapLogger) ModuleLevels() map[string]string {
+	levels := map[string]string{}
+	if l.modules == nil {
+		return levels
+	}
+
+	l.modules.mu.Lock()
+	defer l.modules.mu.Unlock()
+
+	for module, level := range l.modules.levels {
+		if level.isSet.Load() {
+			levels[module] = levelString(level.level.Level())
+		}
+	}
+	return levels
+}
+
+// ForModule returns the logger of a module if l is a *ZapLogger (see ZapLogger.Module), and l
+// otherwise.
+func ForModule(l Logger, module string) Logger {
+	if zl, ok := l.(*ZapLogger); ok {
+		return zl.Module(module)
+	}
+	return l
+}
+
 func (l *ZapLogger) With(fields ...zap.Field) {
 	l.Logger = l.Logger.With(fields...)
 }
@@ -71,28 +184,38 @@ func (l *ZapLogger) Fatal(msg string, fields ...zap.Field) {
 	l.Logger.Fatal(msg, fields...)
 }
 
+// withContextFields adds the request ID of the context to the fields, so that the logs of a request
+// can be correlated.
+func withContextFields(ctx context.Context, fields []zap.Field) []zap.Field {
+	if requestID, ok := requestid.FromContext(ctx); ok {
+		return append(fields, zap.String("request_id", requestID))
+	}
+
+	return fields
+}
+
 func (l *ZapLogger) DebugWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Debug(msg, fields...)
+	l.Logger.Debug(msg, withContextFields(ctx, fields)...)
 }
 
 func (l *ZapLogger) InfoWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Info(msg, fields...)
+	l.Logger.Info(msg, withContextFields(ctx, fields)...)
 }
 
 func (l *ZapLogger) WarnWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Warn(msg, fields...)
+	l.Logger.Warn(msg, withContextFields(ctx, fields)...)
 }
 
 func (l *ZapLogger) ErrorWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Error(msg, fields...)
+	l.Logger.Error(msg, withContextFields(ctx, fields)...)
 }
 
 func (l *ZapLogger) PanicWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Panic(msg, fields...)
+	l.Logger.Panic(msg, withContextFields(ctx, fields)...)
 }
 
 func (l *ZapLogger) FatalWithContext(ctx context.Context, msg string, fields ...zap.Field) {
-	l.Logger.Fatal(msg, fields...)
+	l.Logger.Fatal(msg, withContextFields(ctx, fields)...)
 }
 
 // OptionsLogger Implements options for logger.
@@ -100,6 +223,7 @@ type OptionsLogger struct {
 	format          string
 	level           string
 	timestampFormat string
+	moduleLevels    map[string]string
 }
 
 type OptionLogger func(ol *OptionsLogger)
@@ -122,6 +246,13 @@ func WithTimestampFormat(timestampFormat string) OptionLogger {
 	}
 }
 
+// WithModuleLevels sets the levels of the loggers of modules, by module (see ZapLogger.Module).
+func WithModuleLevels(levels map[string]string) OptionLogger {
+	return func(ol *OptionsLogger) {
+		ol.moduleLevels = levels
+	}
+}
+
 func NewLogger(options ...OptionLogger) (*ZapLogger, error) {
 	logOptions := &OptionsLogger{
 		level:           "info",
@@ -143,7 +274,11 @@ func NewLogger(options ...OptionLogger) (*ZapLogger, error) {
 	}
 
 	cfg := zap.NewProductionConfig()
-	cfg.Level = level
+	// the levels are enforced, and the logs sampled, by the cores of the logger and of the module
+	// loggers instead, see newLevelCore
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	sampling := cfg.Sampling
+	cfg.Sampling = nil
 	cfg.EncoderConfig.TimeKey = "timestamp"
 	cfg.EncoderConfig.CallerKey = "" // remove the "caller" field
 	cfg.DisableStacktrace = true
@@ -169,7 +304,27 @@ func NewLogger(options ...OptionLogger) (*ZapLogger, error) {
 		log = log.With(zap.String("build.version", build.Version), zap.String("build.commit", build.Commit))
 	}
 
-	return &ZapLogger{log}, nil
+	zapLogger := newModularLogger(log, level, sampling)
+	for module, moduleLevel := range logOptions.moduleLevels {
+		if err := zapLogger.SetModuleLevel(module, moduleLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	return zapLogger, nil
+}
+
+// newModularLogger returns a logger of log at level, with module loggers, whose logs are sampled as
+// configured by sampling if it isn't nil. The level of log must not be above the debug level.
+func newModularLogger(log *zap.Logger, level zap.AtomicLevel, sampling *zap.SamplingConfig) *ZapLogger {
+	return &ZapLogger{
+		Logger: log.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newLevelCore(core, level, sampling)
+		})),
+		level:   &level,
+		modules: &moduleLevels{global: level, sampling: sampling, levels: map[string]*moduleLevel{}},
+		base:    log,
+	}
 }
 
 func MustNewLogger(logFormat, logLevel, logTimestampFormat string) *ZapLogger {
@@ -2,12 +2,18 @@ package logger
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/openfga/openfga/pkg/middleware/requestid"
 )
 
 func TestWithoutContext(t *testing.T) {
@@ -33,7 +39,7 @@ func TestWithoutContext(t *testing.T) {
 		},
 	} {
 		observerLogger, logs := observer.New(zap.DebugLevel)
-		dut := ZapLogger{zap.New(observerLogger)}
+		dut := ZapLogger{Logger: zap.New(observerLogger)}
 		const testMessage = "ABC"
 		switch tc.name {
 		case "Info":
@@ -82,7 +88,7 @@ func TestWithContext(t *testing.T) {
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			observerLogger, logs := observer.New(zap.DebugLevel)
-			dut := ZapLogger{zap.New(observerLogger)}
+			dut := ZapLogger{Logger: zap.New(observerLogger)}
 			const testMessage = "ABC"
 			switch tc.name {
 			case "InfoWithContext":
@@ -108,9 +114,23 @@ func TestWithContext(t *testing.T) {
 	}
 }
 
+func TestWithContextRequestID(t *testing.T) {
+	observerLogger, logs := observer.New(zap.DebugLevel)
+	dut := ZapLogger{Logger: zap.New(observerLogger)}
+
+	ctx := requestid.NewContext(context.Background(), "abc")
+	dut.InfoWithContext(ctx, "ABC", zap.String("store_id", "1"))
+
+	require.Equal(t, 1, logs.Len())
+	require.Equal(t, map[string]interface{}{
+		"store_id":   "1",
+		"request_id": "abc",
+	}, logs.All()[0].ContextMap())
+}
+
 func TestWithFields(t *testing.T) {
 	observerLogger, logs := observer.New(zap.DebugLevel)
-	logger := ZapLogger{zap.New(observerLogger)}
+	logger := ZapLogger{Logger: zap.New(observerLogger)}
 	logger.With(
 		zap.String("TestOption", "Message"),
 	)
@@ -124,3 +144,121 @@ func TestWithFields(t *testing.T) {
 	}
 	require.Equal(t, expectedZapFields, actualMessage.ContextMap())
 }
+
+func TestSetLevel(t *testing.T) {
+	logger, err := NewLogger(WithLevel("info"), WithFormat("json"))
+	require.NoError(t, err)
+
+	require.False(t, logger.Core().Enabled(zapcore.DebugLevel))
+
+	require.NoError(t, logger.SetLevel("debug"))
+	require.True(t, logger.Core().Enabled(zapcore.DebugLevel))
+
+	require.NoError(t, logger.SetLevel("none"))
+	require.False(t, logger.Core().Enabled(zapcore.FatalLevel))
+
+	require.Error(t, logger.SetLevel("unknown"))
+	require.False(t, logger.Core().Enabled(zapcore.FatalLevel))
+
+	require.Error(t, NewNoopLogger().SetLevel("debug"))
+}
+
+func TestModuleLevels(t *testing.T) {
+	observerLogger, logs := observer.New(zap.DebugLevel)
+	dut := newModularLogger(zap.New(observerLogger), zap.NewAtomicLevelAt(zap.InfoLevel), nil)
+
+	graph := dut.Module(ModuleGraph)
+	storage := dut.Module(ModuleStorage)
+
+	messages := func() []string {
+		var messages []string
+		for _, entry := range logs.TakeAll() {
+			messages = append(messages, entry.Message)
+		}
+		return messages
+	}
+
+	// the module loggers log at the level of the logger until their level is set
+	graph.Debug("graph")
+	dut.Debug("root")
+	require.Empty(t, messages())
+
+	require.NoError(t, dut.SetModuleLevel(ModuleGraph, "debug"))
+	graph.Debug("graph")
+	storage.Debug("storage")
+	dut.Debug("root")
+	require.Equal(t, []string{"graph"}, messages())
+	require.Equal(t, map[string]string{ModuleGraph: "debug"}, dut.ModuleLevels())
+
+	require.NoError(t, dut.SetLevel("warn"))
+	graph.Debug("graph")
+	storage.Info("storage")
+	require.Equal(t, []string{"graph"}, messages())
+
+	require.NoError(t, dut.SetModuleLevel(ModuleGraph, ""))
+	graph.Info("graph")
+	require.Empty(t, messages())
+	require.Empty(t, dut.ModuleLevels())
+
+	storage.Warn("storage")
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, map[string]interface{}{"module": ModuleStorage}, entries[0].ContextMap())
+
+	require.ErrorContains(t, dut.SetModuleLevel("unknown", "debug"), "unknown log module")
+	require.ErrorContains(t, dut.SetModuleLevel(ModuleGraph, "verbose"), "unknown log level")
+	require.Error(t, NewNoopLogger().SetModuleLevel(ModuleGraph, "debug"))
+
+	// the logs of the modules of a logger without module loggers have a 'module' field too
+	withoutModules := &ZapLogger{Logger: zap.New(observerLogger)}
+	withoutModules.Module(ModuleAuthn).Warn("authn")
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, map[string]interface{}{"module": ModuleAuthn}, entries[0].ContextMap())
+}
+
+func TestParseModuleLevels(t *testing.T) {
+	levels, err := ParseModuleLevels([]string{"graph=debug", " storage = none "})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{ModuleGraph: "debug", ModuleStorage: "none"}, levels)
+
+	for _, rule := range []string{"graph", "unknown=debug", "graph=verbose"} {
+		_, err := ParseModuleLevels([]string{rule})
+		require.Error(t, err, rule)
+	}
+}
+
+func TestLevelsHandler(t *testing.T) {
+	observerLogger, _ := observer.New(zap.DebugLevel)
+	dut := newModularLogger(zap.New(observerLogger), zap.NewAtomicLevelAt(zap.InfoLevel), nil)
+	handler := LevelsHandler(dut)
+
+	serve := func(method, body string) (int, Levels) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, "/log/levels", strings.NewReader(body)))
+
+		var levels Levels
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&levels))
+		}
+		return recorder.Code, levels
+	}
+
+	code, levels := serve(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, Levels{Level: "info", Modules: map[string]string{}}, levels)
+
+	code, levels = serve(http.MethodPut, `{"module": "graph", "level": "debug"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, Levels{Level: "info", Modules: map[string]string{ModuleGraph: "debug"}}, levels)
+
+	code, levels = serve(http.MethodPut, `{"level": "none"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "none", levels.Level)
+
+	code, _ = serve(http.MethodPut, `{"module": "unknown", "level": "debug"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = serve(http.MethodPost, "")
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+}
@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// The modules whose loggers have their own level, see ZapLogger.Module.
+const (
+	ModuleAuthn   = "authn"
+	ModuleGraph   = "graph"
+	ModuleServer  = "server"
+	ModuleStorage = "storage"
+)
+
+// Modules are the modules whose loggers have their own level.
+var Modules = []string{ModuleAuthn, ModuleGraph, ModuleServer, ModuleStorage}
+
+// noneLevel is the level of a logger that doesn't log.
+const noneLevel = zapcore.FatalLevel + 1
+
+// parseLevel parses a log level, or "none" for no logs.
+func parseLevel(level string) (zapcore.Level, error) {
+	if level == "none" {
+		return noneLevel, nil
+	}
+
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return lvl, fmt.Errorf("unknown log level: %s, error: %w", level, err)
+	}
+	return lvl, nil
+}
+
+// levelString is the inverse of parseLevel.
+func levelString(level zapcore.Level) string {
+	if level >= noneLevel {
+		return "none"
+	}
+	return level.String()
+}
+
+// ParseModuleLevels parses 'module=level' rules, e.g. 'graph=debug', into the levels of the module
+// loggers by module. It returns an error if a rule is malformed or its module or level is unknown.
+func ParseModuleLevels(rules []string) (map[string]string, error) {
+	levels := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		module, level, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid module log level '%s', it must be 'module=level'", rule)
+		}
+
+		module, level = strings.TrimSpace(module), strings.TrimSpace(level)
+		if !slices.Contains(Modules, module) {
+			return nil, fmt.Errorf("unknown log module '%s' in '%s', it must be one of %v", module, rule, Modules)
+		}
+		if _, err := parseLevel(level); err != nil {
+			return nil, fmt.Errorf("invalid module log level '%s': %w", rule, err)
+		}
+
+		levels[module] = level
+	}
+	return levels, nil
+}
+
+// moduleLevels are the levels of the module loggers of a logger, which are created on first use.
+type moduleLevels struct {
+	global   zap.AtomicLevel
+	sampling *zap.SamplingConfig
+
+	mu     sync.Mutex
+	levels map[string]*moduleLevel
+}
+
+func (m *moduleLevels) get(module string) *moduleLevel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	level, ok := m.levels[module]
+	if !ok {
+		level = &moduleLevel{global: m.global, level: zap.NewAtomicLevel()}
+		m.levels[module] = level
+	}
+	return level
+}
+
+// moduleLevel is the level of a module logger: its own level once set, and the global level of the
+// logger otherwise.
+type moduleLevel struct {
+	global zap.AtomicLevel
+	level  zap.AtomicLevel
+	isSet  atomic.Bool
+}
+
+var _ zapcore.LevelEnabler = (*moduleLevel)(nil)
+
+func (m *moduleLevel) Enabled(level zapcore.Level) bool {
+	if m.isSet.Load() {
+		return m.level.Enabled(level)
+	}
+	return m.global.Enabled(level)
+}
+
+func (m *moduleLevel) set(level zapcore.Level) {
+	m.level.SetLevel(level)
+	m.isSet.Store(true)
+}
+
+func (m *moduleLevel) unset() {
+	m.isSet.Store(false)
+}
+
+// newLevelCore returns core logging at level, sampled as configured by sampling if it isn't nil.
+func newLevelCore(core zapcore.Core, level zapcore.LevelEnabler, sampling *zap.SamplingConfig) zapcore.Core {
+	core = &levelCore{Core: core, level: level}
+	if sampling == nil {
+		return core
+	}
+	return zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+}
+
+// levelCore is a zapcore.Core that logs the entries of its own level, regardless of the level of the
+// core it wraps, so that a module logger can log at a lower level than the logger it derives from.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
@@ -0,0 +1,324 @@
+package admission
+
+import (
+	"context"
+	"runtime"
+	"runtime/metrics"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/internal/build"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+const (
+	// RetryAfterHeader is the header of a shed request with the number of seconds after which it
+	// can be retried.
+	RetryAfterHeader = "retry-after"
+
+	// dispatchCountTag is the ctxtags key the server reports the dispatch count of a Check request with.
+	dispatchCountTag = "dispatch_count"
+
+	// dispatchAverageWeight is the weight of the dispatch count of the latest Check request of a
+	// store in the moving average of the dispatch counts of the store.
+	dispatchAverageWeight = 0.2
+
+	// maxTrackedStores bounds the number of stores whose dispatch counts are tracked.
+	maxTrackedStores = 10000
+
+	// memorySampleInterval is the minimum interval between two reads of the memory in use.
+	memorySampleInterval = 100 * time.Millisecond
+
+	// heapObjectsMetric is the runtime metric of the memory occupied by the heap objects.
+	heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+	defaultLowPriorityRatio  = 0.8
+	defaultHighDispatchCount = 50
+	defaultRetryAfter        = time.Second
+)
+
+// The resources whose load is limited, as reported in the errors and the metrics.
+const (
+	resourceInflightRequests = "inflight_requests"
+	resourceGoroutines       = "goroutines"
+	resourceMemory           = "memory"
+)
+
+var rejectedRequestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "admission_rejected_requests_total",
+	Help:      "The total number of requests that were shed because the load of the server exceeded a threshold, by the resource whose threshold was exceeded.",
+}, []string{"grpc_service", "grpc_method", "resource"})
+
+// lowPriorityMethods are the expensive methods whose requests are shed first.
+var lowPriorityMethods = map[string]struct{}{
+	openfgav1.OpenFGAService_ListObjects_FullMethodName:         {},
+	openfgav1.OpenFGAService_StreamedListObjects_FullMethodName: {},
+	openfgav1.OpenFGAService_Expand_FullMethodName:              {},
+}
+
+// ControllerOption defines an option that can be used to change the behavior of the Controller.
+type ControllerOption func(c *Controller)
+
+// WithMaxInflightRequests sheds the requests while max requests are in-flight. 0 doesn't limit them.
+func WithMaxInflightRequests(limit int) ControllerOption {
+	return func(c *Controller) {
+		c.maxInflight.Store(int64(limit))
+	}
+}
+
+// WithMaxGoroutines sheds the requests while the server runs more than limit goroutines. 0 doesn't
+// limit them.
+func WithMaxGoroutines(limit int) ControllerOption {
+	return func(c *Controller) {
+		c.maxGoroutines.Store(int64(limit))
+	}
+}
+
+// WithMaxMemoryBytes sheds the requests while the heap objects of the server occupy more than limit
+// bytes. 0 doesn't limit them.
+func WithMaxMemoryBytes(limit uint64) ControllerOption {
+	return func(c *Controller) {
+		c.maxMemoryBytes.Store(limit)
+	}
+}
+
+// WithLowPriorityRatio sets the ratio (between 0 and 1) of the thresholds after which the low
+// priority requests are shed.
+func WithLowPriorityRatio(ratio float64) ControllerOption {
+	return func(c *Controller) {
+		c.lowPriorityRatio = ratio
+	}
+}
+
+// WithHighDispatchCount sets the average dispatch count of the recent Check requests of a store
+// from which the Check requests of the store have a low priority. 0 gives all the Check requests
+// a high priority.
+func WithHighDispatchCount(count uint32) ControllerOption {
+	return func(c *Controller) {
+		c.highDispatchCount = count
+	}
+}
+
+// WithRetryAfter sets the delay after which the clients are told to retry a shed request.
+func WithRetryAfter(d time.Duration) ControllerOption {
+	return func(c *Controller) {
+		c.retryAfter = d
+	}
+}
+
+// Controller sheds requests, with a RESOURCE_EXHAUSTED error and a retry-after header, while the
+// number of in-flight requests, the number of goroutines or the memory in use exceeds its threshold,
+// to keep the server responsive under overload.
+//
+// Requests have a high or a low priority. Low priority requests, i.e. ListObjects, StreamedListObjects
+// and Expand requests, and the Check requests of the stores whose recent Check requests had a high
+// dispatch count, are shed as soon as the load exceeds a ratio of the thresholds. The other requests,
+// e.g. Write and the cheaper Check requests, are only shed once the thresholds are exceeded.
+// Requests to the gRPC Health service are never shed.
+type Controller struct {
+	// the thresholds can be changed at runtime with SetThresholds
+	maxInflight    atomic.Int64
+	maxGoroutines  atomic.Int64
+	maxMemoryBytes atomic.Uint64
+
+	lowPriorityRatio  float64
+	highDispatchCount uint32
+	retryAfter        time.Duration
+
+	// goroutines and heapBytes read the load of the server. They are replaced in tests.
+	goroutines func() int
+	heapBytes  func() uint64
+
+	inflight atomic.Int64
+
+	memoryBytes    atomic.Uint64
+	memorySampleAt atomic.Int64
+
+	mu               sync.Mutex
+	dispatchAverages map[string]float64
+}
+
+// NewController returns a Controller. Without thresholds, it doesn't shed any request.
+func NewController(opts ...ControllerOption) *Controller {
+	c := &Controller{
+		lowPriorityRatio:  defaultLowPriorityRatio,
+		highDispatchCount: defaultHighDispatchCount,
+		retryAfter:        defaultRetryAfter,
+		goroutines:        runtime.NumGoroutine,
+		heapBytes:         readHeapBytes,
+		dispatchAverages:  map[string]float64{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// SetThresholds changes the thresholds of the number of in-flight requests, of the number of
+// goroutines and of the bytes of heap objects at runtime, e.g. when the configuration is reloaded. A
+// value of 0 doesn't limit the resource.
+func (c *Controller) SetThresholds(maxInflight, maxGoroutines int, maxMemoryBytes uint64) {
+	c.maxInflight.Store(int64(maxInflight))
+	c.maxGoroutines.Store(int64(maxGoroutines))
+	c.maxMemoryBytes.Store(maxMemoryBytes)
+}
+
+// NewUnaryInterceptor returns a grpc.UnaryServerInterceptor that sheds requests when the server is overloaded.
+func (c *Controller) NewUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var storeID string
+		if r, ok := req.(interface{ GetStoreId() string }); ok {
+			storeID = r.GetStoreId()
+		}
+
+		done, err := c.admit(info.FullMethod, storeID)
+		if err != nil {
+			_ = grpc.SetHeader(ctx, c.retryAfterHeader())
+			return nil, err
+		}
+		defer done()
+
+		resp, err := handler(ctx, req)
+		if err == nil && info.FullMethod == openfgav1.OpenFGAService_Check_FullMethodName {
+			if count, ok := grpc_ctxtags.Extract(ctx).Values()[dispatchCountTag].(float64); ok {
+				c.observeDispatchCount(storeID, count)
+			}
+		}
+
+		return resp, err
+	}
+}
+
+// NewStreamingInterceptor returns a grpc.StreamServerInterceptor that sheds requests when the server
+// is overloaded.
+func (c *Controller) NewStreamingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done, err := c.admit(info.FullMethod, "")
+		if err != nil {
+			_ = stream.SetHeader(c.retryAfterHeader())
+			return err
+		}
+		defer done()
+
+		return handler(srv, stream)
+	}
+}
+
+// admit returns an error if a request of the method and store must be shed. Otherwise, it counts
+// the request as in-flight until the returned function is called.
+func (c *Controller) admit(fullMethod, storeID string) (func(), error) {
+	rpcInfo := telemetry.RPCInfoFromFullMethod(fullMethod)
+	if rpcInfo.Service == healthv1pb.Health_ServiceDesc.ServiceName {
+		return func() {}, nil
+	}
+
+	ratio := 1.0
+	if c.lowPriority(fullMethod, storeID) {
+		ratio = c.lowPriorityRatio
+	}
+
+	inflight := c.inflight.Add(1)
+	if resource := c.exceeded(inflight, ratio); resource != "" {
+		c.inflight.Add(-1)
+		rejectedRequestsCounter.WithLabelValues(rpcInfo.Service, rpcInfo.Method, resource).Inc()
+		return nil, serverErrors.Overloaded(resource, c.retryAfter)
+	}
+
+	return func() { c.inflight.Add(-1) }, nil
+}
+
+// exceeded returns the resource whose load exceeds the ratio of its threshold, if any.
+func (c *Controller) exceeded(inflight int64, ratio float64) string {
+	if maxInflight := c.maxInflight.Load(); maxInflight > 0 && float64(inflight) > ratio*float64(maxInflight) {
+		return resourceInflightRequests
+	}
+
+	if maxGoroutines := c.maxGoroutines.Load(); maxGoroutines > 0 && float64(c.goroutines()) > ratio*float64(maxGoroutines) {
+		return resourceGoroutines
+	}
+
+	if maxMemoryBytes := c.maxMemoryBytes.Load(); maxMemoryBytes > 0 && float64(c.memoryInUse()) > ratio*float64(maxMemoryBytes) {
+		return resourceMemory
+	}
+
+	return ""
+}
+
+// lowPriority returns true if the requests of the method and store are shed first.
+func (c *Controller) lowPriority(fullMethod, storeID string) bool {
+	if _, ok := lowPriorityMethods[fullMethod]; ok {
+		return true
+	}
+
+	if fullMethod != openfgav1.OpenFGAService_Check_FullMethodName || c.highDispatchCount == 0 || storeID == "" {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.dispatchAverages[storeID] >= float64(c.highDispatchCount)
+}
+
+// observeDispatchCount updates the moving average of the dispatch counts of the Check requests of the store.
+func (c *Controller) observeDispatchCount(storeID string, count float64) {
+	if c.highDispatchCount == 0 || storeID == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	average, ok := c.dispatchAverages[storeID]
+	if !ok {
+		if len(c.dispatchAverages) >= maxTrackedStores {
+			// forget the stores that aren't tracked anymore rather than tracking an unbounded number of them
+			clear(c.dispatchAverages)
+		}
+		c.dispatchAverages[storeID] = count
+		return
+	}
+
+	c.dispatchAverages[storeID] = average + dispatchAverageWeight*(count-average)
+}
+
+// memoryInUse returns the memory in use, read at most once every memorySampleInterval.
+func (c *Controller) memoryInUse() uint64 {
+	now := time.Now().UnixNano()
+	sampledAt := c.memorySampleAt.Load()
+	if now-sampledAt >= int64(memorySampleInterval) && c.memorySampleAt.CompareAndSwap(sampledAt, now) {
+		c.memoryBytes.Store(c.heapBytes())
+	}
+
+	return c.memoryBytes.Load()
+}
+
+// retryAfterHeader returns the header with the number of seconds after which a shed request can be retried.
+func (c *Controller) retryAfterHeader() metadata.MD {
+	return metadata.Pairs(RetryAfterHeader, strconv.Itoa(serverErrors.RetryAfterSeconds(c.retryAfter)))
+}
+
+// readHeapBytes returns the number of bytes occupied by the heap objects, without stopping the world.
+func readHeapBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return sample[0].Value.Uint64()
+}
@@ -0,0 +1,135 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+const storeID = "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8Q"
+
+var (
+	checkInfo       = &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Check_FullMethodName}
+	writeInfo       = &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Write_FullMethodName}
+	listObjectsInfo = &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_ListObjects_FullMethodName}
+	healthInfo      = &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+)
+
+func ok(ctx context.Context, req interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func requireOverloaded(t *testing.T, err error, resource string) {
+	t.Helper()
+
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	reason, _ := serverErrors.ReasonFromError(err)
+	require.Equal(t, serverErrors.ReasonOverloaded, reason)
+	require.Contains(t, status.Convert(err).Message(), resource)
+}
+
+func TestController(t *testing.T) {
+	t.Run("inflight_requests", func(t *testing.T) {
+		c := NewController(WithMaxInflightRequests(10), WithLowPriorityRatio(0.5))
+		interceptor := c.NewUnaryInterceptor()
+
+		// hold 5 requests in-flight
+		c.inflight.Add(5)
+
+		_, err := interceptor(context.Background(), &openfgav1.ListObjectsRequest{StoreId: storeID}, listObjectsInfo, ok)
+		requireOverloaded(t, err, resourceInflightRequests)
+
+		_, err = interceptor(context.Background(), &openfgav1.WriteRequest{StoreId: storeID}, writeInfo, ok)
+		require.NoError(t, err)
+
+		c.inflight.Add(5)
+		_, err = interceptor(context.Background(), &openfgav1.WriteRequest{StoreId: storeID}, writeInfo, ok)
+		requireOverloaded(t, err, resourceInflightRequests)
+
+		_, err = interceptor(context.Background(), nil, healthInfo, ok)
+		require.NoError(t, err)
+
+		// the shed requests aren't counted as in-flight
+		require.EqualValues(t, 10, c.inflight.Load())
+	})
+
+	t.Run("goroutines_and_memory", func(t *testing.T) {
+		c := NewController(WithMaxGoroutines(100), WithMaxMemoryBytes(1000))
+		c.goroutines = func() int { return 50 }
+		c.heapBytes = func() uint64 { return 500 }
+		interceptor := c.NewUnaryInterceptor()
+
+		_, err := interceptor(context.Background(), &openfgav1.ListObjectsRequest{StoreId: storeID}, listObjectsInfo, ok)
+		require.NoError(t, err)
+
+		c.goroutines = func() int { return 90 }
+		_, err = interceptor(context.Background(), &openfgav1.ListObjectsRequest{StoreId: storeID}, listObjectsInfo, ok)
+		requireOverloaded(t, err, resourceGoroutines)
+
+		_, err = interceptor(context.Background(), &openfgav1.CheckRequest{StoreId: storeID}, checkInfo, ok)
+		require.NoError(t, err)
+
+		c.goroutines = func() int { return 50 }
+		c.heapBytes = func() uint64 { return 2000 }
+		c.memorySampleAt.Store(0)
+		_, err = interceptor(context.Background(), &openfgav1.CheckRequest{StoreId: storeID}, checkInfo, ok)
+		requireOverloaded(t, err, resourceMemory)
+	})
+
+	t.Run("checks_with_high_dispatch_count_have_low_priority", func(t *testing.T) {
+		c := NewController(WithMaxInflightRequests(10), WithLowPriorityRatio(0.5), WithHighDispatchCount(50))
+		interceptor := c.NewUnaryInterceptor()
+
+		expensiveCheck := func(ctx context.Context, req interface{}) (interface{}, error) {
+			grpc_ctxtags.Extract(ctx).Set(dispatchCountTag, float64(100))
+			return nil, nil
+		}
+
+		ctx := grpc_ctxtags.SetInContext(context.Background(), grpc_ctxtags.NewTags())
+		_, err := interceptor(ctx, &openfgav1.CheckRequest{StoreId: storeID}, checkInfo, expensiveCheck)
+		require.NoError(t, err)
+
+		c.inflight.Add(6)
+		_, err = interceptor(context.Background(), &openfgav1.CheckRequest{StoreId: storeID}, checkInfo, ok)
+		requireOverloaded(t, err, resourceInflightRequests)
+
+		// the checks of the other stores still have a high priority
+		_, err = interceptor(context.Background(), &openfgav1.CheckRequest{StoreId: "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8R"}, checkInfo, ok)
+		require.NoError(t, err)
+	})
+
+	t.Run("thresholds_changed_at_runtime", func(t *testing.T) {
+		c := NewController(WithMaxInflightRequests(10))
+		interceptor := c.NewUnaryInterceptor()
+
+		c.inflight.Add(10)
+		_, err := interceptor(context.Background(), &openfgav1.WriteRequest{StoreId: storeID}, writeInfo, ok)
+		requireOverloaded(t, err, resourceInflightRequests)
+
+		c.SetThresholds(20, 0, 0)
+		_, err = interceptor(context.Background(), &openfgav1.WriteRequest{StoreId: storeID}, writeInfo, ok)
+		require.NoError(t, err)
+	})
+
+	t.Run("without_thresholds", func(t *testing.T) {
+		c := NewController()
+		interceptor := c.NewUnaryInterceptor()
+
+		_, err := interceptor(context.Background(), &openfgav1.ListObjectsRequest{StoreId: storeID}, listObjectsInfo, ok)
+		require.NoError(t, err)
+	})
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	require.Equal(t, []string{"1"}, NewController().retryAfterHeader().Get(RetryAfterHeader))
+	require.Equal(t, []string{"3"}, NewController(WithRetryAfter(2500*time.Millisecond)).retryAfterHeader().Get(RetryAfterHeader))
+}
@@ -0,0 +1,2 @@
+// Package admission contains middleware that sheds requests when the server is overloaded.
+package admission
@@ -0,0 +1,79 @@
+package connections
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/stats"
+
+	"github.com/openfga/openfga/internal/build"
+)
+
+var (
+	openConnectionsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "grpc_open_connections",
+		Help:      "The number of client connections that are currently open.",
+	})
+
+	connectionsCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "grpc_connections_count",
+		Help:      "The total number of client connections that were opened.",
+	})
+
+	connectionDurationHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace:                       build.ProjectName,
+		Name:                            "grpc_connection_duration_seconds",
+		Help:                            "The duration of the client connections, from when they are opened until they are closed.",
+		Buckets:                         []float64{1, 10, 60, 300, 900, 1800, 3600, 7200, 14400, 43200, 86400},
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	})
+)
+
+type ctxKey string
+
+const connBeginCtxKey ctxKey = "conn-begin-context-key"
+
+// Handler is a stats.Handler that reports the number of open connections, the total number of
+// connections and the duration of the connections. Together with the max connection age of the
+// server, they show whether the clients are rebalanced across the servers.
+type Handler struct{}
+
+var _ stats.Handler = (*Handler)(nil)
+
+// NewHandler returns a Handler.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// TagConn records when the connection was opened in its context.
+func (h *Handler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return context.WithValue(ctx, connBeginCtxKey, time.Now())
+}
+
+// HandleConn updates the metrics when a connection is opened and closed.
+func (h *Handler) HandleConn(ctx context.Context, s stats.ConnStats) {
+	switch s.(type) {
+	case *stats.ConnBegin:
+		connectionsCounter.Inc()
+		openConnectionsGauge.Inc()
+	case *stats.ConnEnd:
+		openConnectionsGauge.Dec()
+		if begin, ok := ctx.Value(connBeginCtxKey).(time.Time); ok {
+			connectionDurationHistogram.Observe(time.Since(begin).Seconds())
+		}
+	}
+}
+
+// TagRPC returns the context unchanged, the RPCs are measured by the other interceptors.
+func (h *Handler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+// HandleRPC does nothing.
+func (h *Handler) HandleRPC(context.Context, stats.RPCStats) {}
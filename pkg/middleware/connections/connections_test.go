@@ -0,0 +1,36 @@
+package connections
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/stats"
+)
+
+func TestHandler(t *testing.T) {
+	h := NewHandler()
+
+	openBefore := testutil.ToFloat64(openConnectionsGauge)
+	totalBefore := testutil.ToFloat64(connectionsCounter)
+
+	first := h.TagConn(context.Background(), &stats.ConnTagInfo{})
+	h.HandleConn(first, &stats.ConnBegin{})
+
+	second := h.TagConn(context.Background(), &stats.ConnTagInfo{})
+	h.HandleConn(second, &stats.ConnBegin{})
+
+	require.InDelta(t, openBefore+2, testutil.ToFloat64(openConnectionsGauge), 0)
+	require.InDelta(t, totalBefore+2, testutil.ToFloat64(connectionsCounter), 0)
+
+	h.HandleConn(first, &stats.ConnEnd{})
+
+	require.InDelta(t, openBefore+1, testutil.ToFloat64(openConnectionsGauge), 0)
+	require.InDelta(t, totalBefore+2, testutil.ToFloat64(connectionsCounter), 0)
+	require.Equal(t, 1, testutil.CollectAndCount(connectionDurationHistogram))
+
+	// the RPCs are left untouched
+	ctx := context.Background()
+	require.Equal(t, ctx, h.TagRPC(ctx, &stats.RPCTagInfo{}))
+}
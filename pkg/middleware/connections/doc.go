@@ -0,0 +1,2 @@
+// Package connections contains a gRPC stats handler that reports metrics of the client connections.
+package connections
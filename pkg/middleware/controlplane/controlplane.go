@@ -0,0 +1,70 @@
+package controlplane
+
+import (
+	"context"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+// Methods are the full method names of the control-plane RPCs, which create, update and delete the
+// stores and write their models and assertions. The other RPCs of the OpenFGA service are the
+// data-plane RPCs.
+var Methods = map[string]struct{}{
+	openfgav1.OpenFGAService_CreateStore_FullMethodName:             {},
+	openfgav1.OpenFGAService_UpdateStore_FullMethodName:             {},
+	openfgav1.OpenFGAService_DeleteStore_FullMethodName:             {},
+	openfgav1.OpenFGAService_WriteAuthorizationModel_FullMethodName: {},
+	openfgav1.OpenFGAService_WriteAssertions_FullMethodName:         {},
+}
+
+// openFGAServicePrefix prefixes the full method names of the RPCs of the OpenFGA service. The other
+// services, e.g. the Health service, are served by both planes.
+var openFGAServicePrefix = "/" + openfgav1.OpenFGAService_ServiceDesc.ServiceName + "/"
+
+// IsControlPlaneMethod reports whether fullMethod is a control-plane RPC.
+func IsControlPlaneMethod(fullMethod string) bool {
+	_, ok := Methods[fullMethod]
+	return ok
+}
+
+// served returns an error if the RPC fullMethod isn't served by the listener of the control plane,
+// if controlPlane is true, or else by the listener of the data plane.
+func served(fullMethod string, controlPlane bool) error {
+	if !strings.HasPrefix(fullMethod, openFGAServicePrefix) || IsControlPlaneMethod(fullMethod) == controlPlane {
+		return nil
+	}
+
+	if controlPlane {
+		return serverErrors.MethodNotServed(fullMethod, "control-plane")
+	}
+	return serverErrors.MethodNotServed(fullMethod, "data-plane")
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor that rejects the RPCs of the other plane
+// with the method_not_served error, whose code is codes.PermissionDenied: the data-plane RPCs if
+// controlPlane is true, or else the control-plane RPCs.
+func NewUnaryInterceptor(controlPlane bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := served(info.FullMethod, controlPlane); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor that rejects the RPCs of the other
+// plane, like [NewUnaryInterceptor].
+func NewStreamingInterceptor(controlPlane bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := served(info.FullMethod, controlPlane); err != nil {
+			return err
+		}
+
+		return handler(srv, stream)
+	}
+}
@@ -0,0 +1,68 @@
+package controlplane
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "served", nil
+	}
+
+	intercept := func(controlPlane bool, fullMethod string) error {
+		resp, err := NewUnaryInterceptor(controlPlane)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: fullMethod}, handler)
+		if err == nil {
+			require.Equal(t, "served", resp)
+		}
+		return err
+	}
+
+	tests := []struct {
+		fullMethod   string
+		controlPlane bool
+	}{
+		{openfgav1.OpenFGAService_CreateStore_FullMethodName, true},
+		{openfgav1.OpenFGAService_DeleteStore_FullMethodName, true},
+		{openfgav1.OpenFGAService_WriteAuthorizationModel_FullMethodName, true},
+		{openfgav1.OpenFGAService_Check_FullMethodName, false},
+		{openfgav1.OpenFGAService_Write_FullMethodName, false},
+		{openfgav1.OpenFGAService_Read_FullMethodName, false},
+		{openfgav1.OpenFGAService_ReadAuthorizationModel_FullMethodName, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fullMethod, func(t *testing.T) {
+			require.NoError(t, intercept(test.controlPlane, test.fullMethod))
+
+			err := intercept(!test.controlPlane, test.fullMethod)
+			require.Equal(t, codes.PermissionDenied, status.Code(err))
+		})
+	}
+
+	t.Run("other_services_are_served_by_both_planes", func(t *testing.T) {
+		require.NoError(t, intercept(true, healthv1pb.Health_Check_FullMethodName))
+		require.NoError(t, intercept(false, healthv1pb.Health_Check_FullMethodName))
+	})
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	intercept := func(controlPlane bool) error {
+		info := &grpc.StreamServerInfo{FullMethod: openfgav1.OpenFGAService_StreamedListObjects_FullMethodName}
+		return NewStreamingInterceptor(controlPlane)(nil, nil, info, handler)
+	}
+
+	require.NoError(t, intercept(false))
+	require.Equal(t, codes.PermissionDenied, status.Code(intercept(true)))
+}
@@ -0,0 +1,2 @@
+// Package controlplane contains middleware to serve the control-plane RPCs, which manage the stores and their models, apart from the data-plane RPCs.
+package controlplane
@@ -0,0 +1,2 @@
+// Package drain contains middleware to track in-flight requests and to drain them before the server shuts down.
+package drain
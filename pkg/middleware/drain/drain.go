@@ -0,0 +1,151 @@
+package drain
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+var (
+	inflightRequestsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "inflight_requests",
+		Help:      "The number of requests that are currently being served.",
+	}, []string{"grpc_service", "grpc_method"})
+
+	drainingGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "draining",
+		Help:      "Whether the server is draining in-flight requests before shutting down (1) or not (0).",
+	})
+
+	drainRejectedRequestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "drain_rejected_requests_total",
+		Help:      "The total number of requests rejected because the server was draining.",
+	}, []string{"grpc_service", "grpc_method"})
+)
+
+// Drainer keeps track of the requests that are in-flight. Once Drain is called, new requests are
+// rejected with codes.Unavailable and Drain waits for the in-flight requests to finish.
+// Requests to the gRPC Health service are not tracked and are always served so that the server
+// can report that it is draining.
+type Drainer struct {
+	mu       sync.Mutex
+	draining bool
+	inflight int
+	idle     chan struct{}
+}
+
+// NewDrainer returns a Drainer that is not draining.
+func NewDrainer() *Drainer {
+	return &Drainer{
+		idle: make(chan struct{}),
+	}
+}
+
+// NewUnaryInterceptor returns a grpc.UnaryServerInterceptor that tracks in-flight requests and
+// rejects new requests while draining.
+func (d *Drainer) NewUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		done, err := d.begin(info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer done()
+
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamingInterceptor returns a grpc.StreamServerInterceptor that tracks in-flight requests and
+// rejects new requests while draining.
+func (d *Drainer) NewStreamingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done, err := d.begin(info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer done()
+
+		return handler(srv, stream)
+	}
+}
+
+// Drain stops accepting new requests and waits until all in-flight requests have finished or
+// until the context is done, in which case the number of requests still in-flight is returned
+// along with the context error.
+func (d *Drainer) Drain(ctx context.Context) (int, error) {
+	d.mu.Lock()
+	if !d.draining {
+		d.draining = true
+		drainingGauge.Set(1)
+
+		if d.inflight == 0 {
+			close(d.idle)
+		}
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.idle:
+		return 0, nil
+	case <-ctx.Done():
+		return d.Inflight(), ctx.Err()
+	}
+}
+
+// Draining reports whether Drain has been called.
+func (d *Drainer) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.draining
+}
+
+// Inflight returns the number of requests that are currently being served.
+func (d *Drainer) Inflight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.inflight
+}
+
+func (d *Drainer) begin(fullMethod string) (func(), error) {
+	rpcInfo := telemetry.RPCInfoFromFullMethod(fullMethod)
+	if rpcInfo.Service == healthv1pb.Health_ServiceDesc.ServiceName {
+		return func() {}, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		drainRejectedRequestsCounter.WithLabelValues(rpcInfo.Service, rpcInfo.Method).Inc()
+		return nil, status.Error(codes.Unavailable, "server is draining")
+	}
+
+	d.inflight++
+	inflightRequestsGauge.WithLabelValues(rpcInfo.Service, rpcInfo.Method).Inc()
+
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.inflight--
+		inflightRequestsGauge.WithLabelValues(rpcInfo.Service, rpcInfo.Method).Dec()
+
+		if d.draining && d.inflight == 0 {
+			close(d.idle)
+		}
+	}, nil
+}
@@ -0,0 +1,103 @@
+package drain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDrainer(t *testing.T) {
+	checkInfo := &grpc.UnaryServerInfo{FullMethod: "/openfga.v1.OpenFGAService/Check"}
+	healthInfo := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+
+	t.Run("drain_without_inflight_requests_returns_immediately", func(t *testing.T) {
+		d := NewDrainer()
+
+		remaining, err := d.Drain(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, remaining)
+		require.True(t, d.Draining())
+
+		// draining again is safe
+		_, err = d.Drain(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("drain_waits_for_inflight_requests_and_rejects_new_ones", func(t *testing.T) {
+		d := NewDrainer()
+		interceptor := d.NewUnaryInterceptor()
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		handlerDone := make(chan error, 1)
+		go func() {
+			_, err := interceptor(context.Background(), nil, checkInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+				close(started)
+				<-release
+				return nil, nil
+			})
+			handlerDone <- err
+		}()
+		<-started
+		require.Equal(t, 1, d.Inflight())
+
+		drained := make(chan error, 1)
+		go func() {
+			_, err := d.Drain(context.Background())
+			drained <- err
+		}()
+
+		require.Eventually(t, d.Draining, time.Second, time.Millisecond)
+
+		_, err := interceptor(context.Background(), nil, checkInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		require.Equal(t, codes.Unavailable, status.Code(err))
+
+		// health checks are still served while draining
+		_, err = interceptor(context.Background(), nil, healthInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		require.NoError(t, err)
+
+		select {
+		case <-drained:
+			require.FailNow(t, "drain should wait for the in-flight request")
+		default:
+		}
+
+		close(release)
+		require.NoError(t, <-handlerDone)
+		require.NoError(t, <-drained)
+		require.Zero(t, d.Inflight())
+	})
+
+	t.Run("drain_stops_waiting_when_the_context_is_done", func(t *testing.T) {
+		d := NewDrainer()
+		interceptor := d.NewStreamingInterceptor()
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		go func() {
+			_ = interceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/openfga.v1.OpenFGAService/StreamedListObjects"}, func(srv interface{}, stream grpc.ServerStream) error {
+				close(started)
+				<-release
+				return nil
+			})
+		}()
+		<-started
+		t.Cleanup(func() { close(release) })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		remaining, err := d.Drain(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, 1, remaining)
+	})
+}
@@ -93,6 +93,10 @@ func CustomHTTPErrorHandler(ctx context.Context, w http.ResponseWriter, r *http.
 		}
 	}
 
+	if pb.RetryAfterSeconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(pb.RetryAfterSeconds))
+	}
+
 	// RFC 7230 https://tools.ietf.org/html/rfc7230#section-4.1.2
 	// Unless the request includes a TE header field indicating "trailers"
 	// is acceptable, as described in Section 4.3, a server SHOULD NOT
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
@@ -73,3 +74,21 @@ func TestCustomHTTPErrorHandlerSpecialEncoding(t *testing.T) {
 	expectedData := "{\"code\":\"assertions_too_many_items\",\"message\":\"invalid character '<' looking for beginning of value,\"}"
 	require.Equal(t, expectedData, strings.TrimSpace(string(data)))
 }
+
+func TestCustomHTTPErrorHandlerRetryAfter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/stores/01/check", nil)
+	w := httptest.NewRecorder()
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{
+		HeaderMD:  metadata.New(map[string]string{}),
+		TrailerMD: metadata.New(map[string]string{}),
+	})
+	CustomHTTPErrorHandler(ctx, w, req, errors.EncodeError(errors.ThrottledTimeoutRetryAfter(2*time.Second)))
+	res := w.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, "2", res.Header.Get("Retry-After"))
+
+	data, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "\"retry_after_seconds\":2")
+}
@@ -16,6 +16,7 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/reflect/protoreflect"
 
+	"github.com/openfga/openfga/internal/graph"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/middleware/requestid"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
@@ -31,6 +32,7 @@ const (
 	rawRequestKey      = "raw_request"
 	rawResponseKey     = "raw_response"
 	internalErrorKey   = "internal_error"
+	panicStackKey      = "panic_stack"
 	grpcReqCompleteKey = "grpc_req_complete"
 	userAgentKey       = "user_agent"
 
@@ -66,6 +68,12 @@ func (r *reporter) PostCall(err error, _ time.Duration) {
 		var internalError serverErrors.InternalError
 		if errors.As(err, &internalError) {
 			r.fields = append(r.fields, zap.String(internalErrorKey, internalError.Internal().Error()))
+
+			// a resolver goroutine of the request panicked
+			var panicErr *graph.PanicError
+			if errors.As(internalError.Internal(), &panicErr) {
+				r.fields = append(r.fields, zap.ByteString(panicStackKey, panicErr.Stack))
+			}
 			r.logger.Error(err.Error(), r.fields...)
 		} else {
 			r.fields = append(r.fields, zap.Error(err))
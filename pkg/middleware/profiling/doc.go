@@ -0,0 +1,2 @@
+// Package profiling contains middleware to attach pprof labels to the goroutines that serve a request.
+package profiling
@@ -0,0 +1,82 @@
+package profiling
+
+import (
+	"context"
+	"runtime/pprof"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	grpcServiceLabel = "grpc_service"
+	grpcMethodLabel  = "grpc_method"
+	storeIDLabel     = "store_id"
+)
+
+type hasGetStoreID interface {
+	GetStoreId() string
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor that attaches pprof labels with the RPC
+// service, method and, if the request has one, the store ID to the goroutine serving the request.
+// Goroutines started while resolving the request inherit the labels, so CPU profiles can be
+// attributed to stores and request types.
+func NewUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		pprof.Do(ctx, labels(info.FullMethod, req), func(ctx context.Context) {
+			resp, err = handler(ctx, req)
+		})
+		return resp, err
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor that attaches pprof labels with the
+// RPC service and method to the goroutine serving the request. The store ID label is added once the
+// request message has been received.
+func NewStreamingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		pprof.Do(stream.Context(), labels(info.FullMethod, nil), func(ctx context.Context) {
+			err = handler(srv, &labeledStream{ServerStream: stream, ctx: ctx, fullMethod: info.FullMethod})
+		})
+		return err
+	}
+}
+
+type labeledStream struct {
+	grpc.ServerStream
+	ctx        context.Context
+	fullMethod string
+}
+
+// Context returns the context of the stream, which carries the pprof labels.
+func (s *labeledStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg receives a message and, if it has a store ID, adds it to the pprof labels of the current goroutine.
+func (s *labeledStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if _, ok := m.(hasGetStoreID); ok {
+		s.ctx = pprof.WithLabels(s.ctx, labels(s.fullMethod, m))
+		pprof.SetGoroutineLabels(s.ctx)
+	}
+
+	return nil
+}
+
+func labels(fullMethod string, req interface{}) pprof.LabelSet {
+	service, method := "unknown", "unknown"
+	if parts := strings.SplitN(strings.TrimPrefix(fullMethod, "/"), "/", 2); len(parts) == 2 {
+		service, method = parts[0], parts[1]
+	}
+
+	if r, ok := req.(hasGetStoreID); ok && r.GetStoreId() != "" {
+		return pprof.Labels(grpcServiceLabel, service, grpcMethodLabel, method, storeIDLabel, r.GetStoreId())
+	}
+
+	return pprof.Labels(grpcServiceLabel, service, grpcMethodLabel, method)
+}
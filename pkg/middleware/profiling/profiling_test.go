@@ -0,0 +1,101 @@
+package profiling
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func labelsFromContext(ctx context.Context) map[string]string {
+	labels := map[string]string{}
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		labels[key] = value
+		return true
+	})
+	return labels
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	interceptor := NewUnaryInterceptor()
+
+	var got map[string]string
+	_, err := interceptor(
+		context.Background(),
+		&openfgav1.CheckRequest{StoreId: "01HVMMBCMGZNT3SED4Z17ECXCA"},
+		&grpc.UnaryServerInfo{FullMethod: "/openfga.v1.OpenFGAService/Check"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			got = labelsFromContext(ctx)
+			return nil, nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"grpc_service": "openfga.v1.OpenFGAService",
+		"grpc_method":  "Check",
+		"store_id":     "01HVMMBCMGZNT3SED4Z17ECXCA",
+	}, got)
+
+	_, err = interceptor(
+		context.Background(),
+		&openfgav1.ListStoresRequest{},
+		&grpc.UnaryServerInfo{FullMethod: "/openfga.v1.OpenFGAService/ListStores"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			got = labelsFromContext(ctx)
+			return nil, nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"grpc_service": "openfga.v1.OpenFGAService",
+		"grpc_method":  "ListStores",
+	}, got)
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	msg *openfgav1.StreamedListObjectsRequest
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeServerStream) RecvMsg(m interface{}) error {
+	*m.(*openfgav1.StreamedListObjectsRequest) = openfgav1.StreamedListObjectsRequest{StoreId: f.msg.GetStoreId()}
+	return nil
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	interceptor := NewStreamingInterceptor()
+
+	stream := &fakeServerStream{
+		ctx: context.Background(),
+		msg: &openfgav1.StreamedListObjectsRequest{StoreId: "01HVMMBCMGZNT3SED4Z17ECXCA"},
+	}
+
+	var before, after map[string]string
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/openfga.v1.OpenFGAService/StreamedListObjects"},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			before = labelsFromContext(stream.Context())
+
+			var req openfgav1.StreamedListObjectsRequest
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+
+			after = labelsFromContext(stream.Context())
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"grpc_service": "openfga.v1.OpenFGAService",
+		"grpc_method":  "StreamedListObjects",
+	}, before)
+	require.Equal(t, "01HVMMBCMGZNT3SED4Z17ECXCA", after["store_id"])
+}
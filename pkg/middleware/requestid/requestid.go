@@ -2,6 +2,7 @@ package requestid
 
 import (
 	"context"
+	"regexp"
 
 	"github.com/google/uuid"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
@@ -20,6 +21,10 @@ const (
 	RequestIDHeader = "X-Request-Id"
 )
 
+// validRequestID matches the request IDs that are accepted from clients. They end up in logs and in
+// SQL comments, so they are restricted to characters that are safe there.
+var validRequestID = regexp.MustCompile(`^[a-zA-Z0-9._:-]{1,128}$`)
+
 // FromContext extracts the request-id from the context, if it exists.
 func FromContext(ctx context.Context) (string, bool) {
 	if md, ok := metadata.FromOutgoingContext(ctx); ok {
@@ -31,6 +36,11 @@ func FromContext(ctx context.Context) (string, bool) {
 	return "", false
 }
 
+// NewContext returns a copy of the context with the request-id.
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, requestIDCtxKey, requestID)
+}
+
 // NewUnaryInterceptor creates a grpc.UnaryServerInterceptor which must
 // come after the trace interceptor and before the logging interceptor.
 func NewUnaryInterceptor() grpc.UnaryServerInterceptor {
@@ -45,11 +55,14 @@ func NewStreamingInterceptor() grpc.StreamServerInterceptor {
 
 func reportable() interceptors.CommonReportableFunc {
 	return func(ctx context.Context, c interceptors.CallMeta) (interceptors.Reporter, context.Context) {
-		// TODO use ulid library?
-		id, _ := uuid.NewRandom()
-		requestID := id.String()
+		requestID, ok := fromIncomingContext(ctx)
+		if !ok {
+			// TODO use ulid library?
+			id, _ := uuid.NewRandom()
+			requestID = id.String()
+		}
 
-		ctx = metadata.AppendToOutgoingContext(ctx, requestIDCtxKey, requestID)
+		ctx = NewContext(ctx, requestID)
 
 		trace.SpanFromContext(ctx).SetAttributes(attribute.String(requestIDTraceKey, requestID))
 
@@ -58,3 +71,17 @@ func reportable() interceptors.CommonReportableFunc {
 		return interceptors.NoopReporter{}, ctx
 	}
 }
+
+// fromIncomingContext returns the request ID sent by the client in the X-Request-Id header, if it is valid.
+func fromIncomingContext(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	if vals := md.Get(RequestIDHeader); len(vals) > 0 && validRequestID.MatchString(vals[0]) {
+		return vals[0], true
+	}
+
+	return "", false
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"testing"
 
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 var pingReq = &testpb.PingRequest{Value: "ping"}
@@ -58,3 +60,30 @@ func (s *RequestIDTestSuite) TestStreamingPing() {
 	_, err := s.Client.PingStream(s.SimpleCtx())
 	s.Require().NoError(err)
 }
+
+func TestRequestIDFromClient(t *testing.T) {
+	tests := map[string]struct {
+		requestID string
+		accepted  bool
+	}{
+		"valid":       {requestID: "01HWB5Y1QJ3ZKTQ9X0VYF4N3BZ", accepted: true},
+		"empty":       {requestID: "", accepted: false},
+		"sql_comment": {requestID: "abc */ DROP TABLE tuple; /*", accepted: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, test.requestID))
+			_, ctx = reportable()(ctx, interceptors.CallMeta{})
+
+			requestID, ok := FromContext(ctx)
+			require.True(t, ok)
+			if test.accepted {
+				require.Equal(t, test.requestID, requestID)
+			} else {
+				require.NotEqual(t, test.requestID, requestID)
+				require.NotEmpty(t, requestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package requestlimits contains middleware that rejects the requests whose contextual tuples, writes or contexts exceed the configured limits, before they are handled.
+package requestlimits
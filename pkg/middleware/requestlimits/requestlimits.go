@@ -0,0 +1,180 @@
+package requestlimits
+
+import (
+	"context"
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/internal/build"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+// The limits of the requests, as reported in the errors and the metrics.
+const (
+	LimitContextualTuples   = "max_contextual_tuples"
+	LimitTuplesPerWrite     = "max_tuples_per_write"
+	LimitContextSizeInBytes = "max_context_size_in_bytes"
+)
+
+var rejectedRequestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "request_limit_rejected_requests_total",
+	Help:      "The total number of requests that were rejected because a field of the request exceeded a limit, by the limit that was exceeded.",
+}, []string{"grpc_service", "grpc_method", "limit"})
+
+// contextualRequest is a request with contextual tuples and a context, e.g. a Check request.
+type contextualRequest interface {
+	GetContextualTuples() *openfgav1.ContextualTupleKeys
+	GetContext() *structpb.Struct
+}
+
+// ValidatorOption defines an option that can be used to change the behavior of the Validator.
+type ValidatorOption func(v *Validator)
+
+// WithMaxContextualTuples rejects the Check, ListObjects and StreamedListObjects requests with more
+// than limit contextual tuples. 0 doesn't limit them.
+func WithMaxContextualTuples(limit int) ValidatorOption {
+	return func(v *Validator) {
+		v.maxContextualTuples = limit
+	}
+}
+
+// WithMaxTuplesPerWrite rejects the Write requests with more than limit writes and deletes. 0
+// doesn't limit them.
+func WithMaxTuplesPerWrite(limit int) ValidatorOption {
+	return func(v *Validator) {
+		v.maxTuplesPerWrite = limit
+	}
+}
+
+// WithMaxContextSizeInBytes rejects the requests whose context, or the condition context of one of
+// their written or contextual tuples, is larger than limit bytes. 0 doesn't limit them.
+func WithMaxContextSizeInBytes(limit int) ValidatorOption {
+	return func(v *Validator) {
+		v.maxContextSizeInBytes = limit
+	}
+}
+
+// Validator rejects the requests that exceed its limits, with an error whose BadRequest detail has
+// the field of the request that exceeded a limit, e.g. 'writes.tuple_keys[3].condition.context', so
+// that they fail before any work is done for them, e.g. reading the model of the store.
+type Validator struct {
+	maxContextualTuples   int
+	maxTuplesPerWrite     int
+	maxContextSizeInBytes int
+}
+
+// NewValidator returns a Validator. Without limits, it doesn't reject any request.
+func NewValidator(opts ...ValidatorOption) *Validator {
+	v := &Validator{}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// NewUnaryInterceptor returns a grpc.UnaryServerInterceptor that rejects the requests that exceed the
+// limits of the Validator.
+func (v *Validator) NewUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := v.validate(info.FullMethod, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamingInterceptor returns a grpc.StreamServerInterceptor that rejects the requests that
+// exceed the limits of the Validator as they are received.
+func (v *Validator) NewStreamingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatedStream{ServerStream: stream, validator: v, fullMethod: info.FullMethod})
+	}
+}
+
+// validatedStream validates the requests received on a stream.
+type validatedStream struct {
+	grpc.ServerStream
+	validator  *Validator
+	fullMethod string
+}
+
+func (s *validatedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return s.validator.validate(s.fullMethod, m)
+}
+
+// validate returns an error if the request of the method exceeds a limit, and counts the rejection.
+func (v *Validator) validate(fullMethod string, req interface{}) error {
+	limit, err := v.exceeded(req)
+	if err != nil {
+		rpcInfo := telemetry.RPCInfoFromFullMethod(fullMethod)
+		rejectedRequestsCounter.WithLabelValues(rpcInfo.Service, rpcInfo.Method, limit).Inc()
+	}
+
+	return err
+}
+
+// exceeded returns the limit that the request exceeds, if any, and its error.
+func (v *Validator) exceeded(req interface{}) (string, error) {
+	switch r := req.(type) {
+	case *openfgav1.WriteRequest:
+		writes, deletes := len(r.GetWrites().GetTupleKeys()), len(r.GetDeletes().GetTupleKeys())
+		if v.maxTuplesPerWrite > 0 && writes+deletes > v.maxTuplesPerWrite {
+			field := "deletes.tuple_keys"
+			if writes > v.maxTuplesPerWrite {
+				field = "writes.tuple_keys"
+			}
+			return LimitTuplesPerWrite, serverErrors.ExceededRequestLimit(LimitTuplesPerWrite, field, writes+deletes, v.maxTuplesPerWrite)
+		}
+
+		for i, tk := range r.GetWrites().GetTupleKeys() {
+			if err := v.validateContextSize(fmt.Sprintf("writes.tuple_keys[%d].condition.context", i), tk.GetCondition().GetContext()); err != nil {
+				return LimitContextSizeInBytes, err
+			}
+		}
+	case contextualRequest:
+		tuples := r.GetContextualTuples().GetTupleKeys()
+		if v.maxContextualTuples > 0 && len(tuples) > v.maxContextualTuples {
+			return LimitContextualTuples, serverErrors.ExceededRequestLimit(LimitContextualTuples, "contextual_tuples.tuple_keys", len(tuples), v.maxContextualTuples)
+		}
+
+		for i, tk := range tuples {
+			if err := v.validateContextSize(fmt.Sprintf("contextual_tuples.tuple_keys[%d].condition.context", i), tk.GetCondition().GetContext()); err != nil {
+				return LimitContextSizeInBytes, err
+			}
+		}
+
+		if err := v.validateContextSize("context", r.GetContext()); err != nil {
+			return LimitContextSizeInBytes, err
+		}
+	}
+
+	return "", nil
+}
+
+// validateContextSize returns an error if the context of the field is larger than the limit.
+func (v *Validator) validateContextSize(field string, reqContext *structpb.Struct) error {
+	if v.maxContextSizeInBytes <= 0 || reqContext == nil {
+		return nil
+	}
+
+	if size := proto.Size(reqContext); size > v.maxContextSizeInBytes {
+		return serverErrors.ExceededRequestLimit(LimitContextSizeInBytes, field, size, v.maxContextSizeInBytes)
+	}
+
+	return nil
+}
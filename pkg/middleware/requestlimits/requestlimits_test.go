@@ -0,0 +1,164 @@
+package requestlimits
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+var (
+	checkInfo = &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Check_FullMethodName}
+	writeInfo = &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Write_FullMethodName}
+)
+
+func ok(ctx context.Context, req interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+// requireExceeded requires the error to be a rejection of the field of the request for the limit.
+func requireExceeded(t *testing.T, err error, limit, field string) {
+	t.Helper()
+
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), status.Code(err))
+	reason, _ := serverErrors.ReasonFromError(err)
+	require.Equal(t, serverErrors.ReasonExceededEntityLimit, reason)
+	require.Contains(t, status.Convert(err).Message(), limit)
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = append(violations, badRequest.GetFieldViolations()...)
+		}
+	}
+	require.Len(t, violations, 1)
+	require.Equal(t, field, violations[0].GetField())
+}
+
+func tupleKeys(n int) []*openfgav1.TupleKey {
+	tks := make([]*openfgav1.TupleKey, 0, n)
+	for i := 0; i < n; i++ {
+		tks = append(tks, tuple.NewTupleKey("document:1", "viewer", "user:anne"))
+	}
+	return tks
+}
+
+func newContext(t *testing.T, size int) *structpb.Struct {
+	reqContext, err := structpb.NewStruct(map[string]interface{}{"x": strings.Repeat("a", size)})
+	require.NoError(t, err)
+	return reqContext
+}
+
+func TestValidator(t *testing.T) {
+	v := NewValidator(WithMaxContextualTuples(2), WithMaxTuplesPerWrite(3), WithMaxContextSizeInBytes(64))
+	interceptor := v.NewUnaryInterceptor()
+
+	t.Run("write", func(t *testing.T) {
+		rejected := testutil.ToFloat64(rejectedRequestsCounter.WithLabelValues("openfga.v1.OpenFGAService", "Write", LimitTuplesPerWrite))
+
+		_, err := interceptor(context.Background(), &openfgav1.WriteRequest{
+			Writes:  &openfgav1.WriteRequestWrites{TupleKeys: tupleKeys(2)},
+			Deletes: &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{{Object: "document:1", Relation: "viewer", User: "user:bob"}}},
+		}, writeInfo, ok)
+		require.NoError(t, err)
+
+		_, err = interceptor(context.Background(), &openfgav1.WriteRequest{
+			Writes: &openfgav1.WriteRequestWrites{TupleKeys: tupleKeys(4)},
+		}, writeInfo, ok)
+		requireExceeded(t, err, LimitTuplesPerWrite, "writes.tuple_keys")
+
+		_, err = interceptor(context.Background(), &openfgav1.WriteRequest{
+			Writes:  &openfgav1.WriteRequestWrites{TupleKeys: tupleKeys(2)},
+			Deletes: &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{{}, {}}},
+		}, writeInfo, ok)
+		requireExceeded(t, err, LimitTuplesPerWrite, "deletes.tuple_keys")
+
+		require.InDelta(t, rejected+2, testutil.ToFloat64(rejectedRequestsCounter.WithLabelValues("openfga.v1.OpenFGAService", "Write", LimitTuplesPerWrite)), 0)
+
+		tks := tupleKeys(2)
+		tks[1] = tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:anne", "condition", newContext(t, 100))
+		_, err = interceptor(context.Background(), &openfgav1.WriteRequest{
+			Writes: &openfgav1.WriteRequestWrites{TupleKeys: tks},
+		}, writeInfo, ok)
+		requireExceeded(t, err, LimitContextSizeInBytes, "writes.tuple_keys[1].condition.context")
+	})
+
+	t.Run("check", func(t *testing.T) {
+		_, err := interceptor(context.Background(), &openfgav1.CheckRequest{
+			ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: tupleKeys(2)},
+			Context:          newContext(t, 10),
+		}, checkInfo, ok)
+		require.NoError(t, err)
+
+		_, err = interceptor(context.Background(), &openfgav1.CheckRequest{
+			ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: tupleKeys(3)},
+		}, checkInfo, ok)
+		requireExceeded(t, err, LimitContextualTuples, "contextual_tuples.tuple_keys")
+
+		tks := tupleKeys(1)
+		tks[0].Condition = &openfgav1.RelationshipCondition{Name: "condition", Context: newContext(t, 100)}
+		_, err = interceptor(context.Background(), &openfgav1.CheckRequest{
+			ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: tks},
+		}, checkInfo, ok)
+		requireExceeded(t, err, LimitContextSizeInBytes, "contextual_tuples.tuple_keys[0].condition.context")
+
+		_, err = interceptor(context.Background(), &openfgav1.CheckRequest{
+			Context: newContext(t, 100),
+		}, checkInfo, ok)
+		requireExceeded(t, err, LimitContextSizeInBytes, "context")
+	})
+
+	t.Run("no_limits", func(t *testing.T) {
+		_, err := NewValidator().NewUnaryInterceptor()(context.Background(), &openfgav1.CheckRequest{
+			ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: tupleKeys(1000)},
+			Context:          newContext(t, 100_000),
+		}, checkInfo, ok)
+		require.NoError(t, err)
+	})
+}
+
+// mockServerStream is a stream that receives a request.
+type mockServerStream struct {
+	grpc.ServerStream
+	req *openfgav1.StreamedListObjectsRequest
+}
+
+func (m *mockServerStream) Context() context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.MD{})
+}
+
+func (m *mockServerStream) RecvMsg(msg interface{}) error {
+	msg.(*openfgav1.StreamedListObjectsRequest).ContextualTuples = m.req.GetContextualTuples()
+	return nil
+}
+
+func TestValidatorStreaming(t *testing.T) {
+	interceptor := NewValidator(WithMaxContextualTuples(2)).NewStreamingInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: openfgav1.OpenFGAService_StreamedListObjects_FullMethodName}
+
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return stream.RecvMsg(&openfgav1.StreamedListObjectsRequest{})
+	}
+
+	err := interceptor(nil, &mockServerStream{req: &openfgav1.StreamedListObjectsRequest{
+		ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: tupleKeys(2)},
+	}}, info, handler)
+	require.NoError(t, err)
+
+	err = interceptor(nil, &mockServerStream{req: &openfgav1.StreamedListObjectsRequest{
+		ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: tupleKeys(3)},
+	}}, info, handler)
+	requireExceeded(t, err, LimitContextualTuples, "contextual_tuples.tuple_keys")
+}
@@ -0,0 +1,2 @@
+// Package storelabels contains middleware to add the labels of the store of a request to its context, logs and traces.
+package storelabels
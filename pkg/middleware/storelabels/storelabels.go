@@ -0,0 +1,98 @@
+package storelabels
+
+import (
+	"context"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+type ctxKey string
+
+const (
+	storeLabelsCtxKey ctxKey = "store-labels-context-key"
+
+	// storeLabelsKey is the ctxtags key of the labels, which are logged with the request.
+	storeLabelsKey = "store_labels"
+
+	// storeLabelAttributePrefix prefixes the keys of the labels in the span attributes.
+	storeLabelAttributePrefix = "store_label."
+)
+
+// LookupFunc returns the labels of a store. It is called for every request that has a store ID,
+// so it should be cached.
+type LookupFunc func(ctx context.Context, storeID string) (map[string]string, error)
+
+type hasGetStoreID interface {
+	GetStoreId() string
+}
+
+// FromContext returns the labels of the store of the request, e.g. to use them as dimensions of
+// audit logs or rate limits. The labels are nil if the store has none, or if they couldn't be read.
+func FromContext(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(storeLabelsCtxKey).(map[string]string)
+	return labels
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor that looks up the labels of the store
+// of the request, if it has a store ID, and adds them to the context (see [FromContext]), to the
+// ctxtags logged with the request and to the attributes of the span. The requests are served even
+// if the lookup fails, without the labels.
+func NewUnaryInterceptor(lookup LookupFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withLabels(ctx, lookup, req), req)
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor that adds the labels of the store
+// to the context of the stream once the request message has been received, like [NewUnaryInterceptor].
+func NewStreamingInterceptor(lookup LookupFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &labeledStream{ServerStream: stream, ctx: stream.Context(), lookup: lookup})
+	}
+}
+
+type labeledStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	lookup LookupFunc
+}
+
+// Context returns the context of the stream, which carries the labels of the store.
+func (s *labeledStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg receives a message and, if it has a store ID, adds the labels of the store to the context.
+func (s *labeledStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	s.ctx = withLabels(s.ctx, s.lookup, m)
+	return nil
+}
+
+func withLabels(ctx context.Context, lookup LookupFunc, req interface{}) context.Context {
+	r, ok := req.(hasGetStoreID)
+	if !ok || r.GetStoreId() == "" {
+		return ctx
+	}
+
+	labels, err := lookup(ctx, r.GetStoreId())
+	if err != nil || len(labels) == 0 {
+		return ctx
+	}
+
+	grpc_ctxtags.Extract(ctx).Set(storeLabelsKey, labels)
+
+	attributes := make([]attribute.KeyValue, 0, len(labels))
+	for key, value := range labels {
+		attributes = append(attributes, attribute.String(storeLabelAttributePrefix+key, value))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attributes...)
+
+	return context.WithValue(ctx, storeLabelsCtxKey, labels)
+}
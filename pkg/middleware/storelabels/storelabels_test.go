@@ -0,0 +1,95 @@
+package storelabels
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+const storeID = "01HVMMBCMGZNT3SED4Z17ECXCA"
+
+func lookup(_ context.Context, id string) (map[string]string, error) {
+	switch id {
+	case storeID:
+		return map[string]string{"env": "prod", "team": "iam"}, nil
+	case "unavailable":
+		return nil, errors.New("datastore unavailable")
+	default:
+		return nil, nil
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	interceptor := NewUnaryInterceptor(lookup)
+
+	intercept := func(req interface{}) (map[string]string, map[string]interface{}) {
+		ctx := grpc_ctxtags.SetInContext(context.Background(), grpc_ctxtags.NewTags())
+
+		var labels map[string]string
+		_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			labels = FromContext(ctx)
+			return nil, nil
+		})
+		require.NoError(t, err)
+
+		return labels, grpc_ctxtags.Extract(ctx).Values()
+	}
+
+	labels, tags := intercept(&openfgav1.CheckRequest{StoreId: storeID})
+	require.Equal(t, map[string]string{"env": "prod", "team": "iam"}, labels)
+	require.Equal(t, labels, tags[storeLabelsKey])
+
+	for name, req := range map[string]interface{}{
+		"without_store_id": &openfgav1.ListStoresRequest{},
+		"without_labels":   &openfgav1.CheckRequest{StoreId: "01HVMMBCMGZNT3SED4Z17ECXCB"},
+		"failed_lookup":    &openfgav1.CheckRequest{StoreId: "unavailable"},
+		"empty_store_id":   &openfgav1.CheckRequest{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			labels, tags := intercept(req)
+			require.Nil(t, labels)
+			require.NotContains(t, tags, storeLabelsKey)
+		})
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeServerStream) RecvMsg(m interface{}) error {
+	*m.(*openfgav1.StreamedListObjectsRequest) = openfgav1.StreamedListObjectsRequest{StoreId: storeID}
+	return nil
+}
+
+func TestStreamingInterceptor(t *testing.T) {
+	interceptor := NewStreamingInterceptor(lookup)
+
+	stream := &fakeServerStream{ctx: grpc_ctxtags.SetInContext(context.Background(), grpc_ctxtags.NewTags())}
+
+	var before, after map[string]string
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		before = FromContext(stream.Context())
+
+		var req openfgav1.StreamedListObjectsRequest
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+
+		after = FromContext(stream.Context())
+		return nil
+	})
+	require.NoError(t, err)
+	require.Nil(t, before)
+	require.Equal(t, map[string]string{"env": "prod", "team": "iam"}, after)
+}
@@ -14,15 +14,58 @@ import (
 type TimeoutInterceptor struct {
 	timeout time.Duration
 	logger  logger.Logger
+
+	// methodTimeouts are the timeouts of the methods that don't use the default timeout, by full
+	// method name.
+	methodTimeouts map[string]time.Duration
+}
+
+// TimeoutInterceptorOption defines an option that can be used to change the behavior of a
+// [TimeoutInterceptor].
+type TimeoutInterceptorOption func(h *TimeoutInterceptor)
+
+// WithMethodTimeout sets the timeout of the requests of the method, e.g.
+// '/openfga.v1.OpenFGAService/Check', instead of the default timeout. If the timeout is 0, the
+// requests of the method have no timeout.
+func WithMethodTimeout(fullMethod string, timeout time.Duration) TimeoutInterceptorOption {
+	return func(h *TimeoutInterceptor) {
+		h.methodTimeouts[fullMethod] = timeout
+	}
 }
 
 // NewTimeoutInterceptor returns new TimeoutInterceptor that timeouts request if it
-// exceeds the timeout value.
-func NewTimeoutInterceptor(timeout time.Duration, logger logger.Logger) *TimeoutInterceptor {
-	return &TimeoutInterceptor{
-		timeout: timeout,
-		logger:  logger,
+// exceeds the timeout value. If the timeout is 0, the requests of the methods without a timeout of
+// their own have no timeout.
+func NewTimeoutInterceptor(timeout time.Duration, logger logger.Logger, opts ...TimeoutInterceptorOption) *TimeoutInterceptor {
+	h := &TimeoutInterceptor{
+		timeout:        timeout,
+		logger:         logger,
+		methodTimeouts: map[string]time.Duration{},
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// timeoutOf returns the timeout of the requests of the method.
+func (h *TimeoutInterceptor) timeoutOf(fullMethod string) time.Duration {
+	if timeout, ok := h.methodTimeouts[fullMethod]; ok {
+		return timeout
+	}
+	return h.timeout
+}
+
+// withTimeout returns a copy of ctx whose deadline is the timeout of the method from now, if it has
+// a timeout.
+func (h *TimeoutInterceptor) withTimeout(ctx context.Context, fullMethod string) (context.Context, context.CancelFunc) {
+	timeout := h.timeoutOf(fullMethod)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 // NewUnaryTimeoutInterceptor returns an interceptor that will timeout according to the configured timeout.
@@ -30,7 +73,12 @@ func NewTimeoutInterceptor(timeout time.Duration, logger logger.Logger) *Timeout
 // to return proper error code.
 func (h *TimeoutInterceptor) NewUnaryTimeoutInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		ctx, cancel := context.WithTimeout(ctx, h.timeout)
+		var fullMethod string
+		if info != nil {
+			fullMethod = info.FullMethod
+		}
+
+		ctx, cancel := h.withTimeout(ctx, fullMethod)
 		defer cancel()
 		return handler(ctx, req)
 	}
@@ -42,8 +90,13 @@ func (h *TimeoutInterceptor) NewUnaryTimeoutInterceptor() grpc.UnaryServerInterc
 func (h *TimeoutInterceptor) NewStreamTimeoutInterceptor() grpc.StreamServerInterceptor {
 	validator := grpcvalidator.StreamServerInterceptor()
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		var fullMethod string
+		if info != nil {
+			fullMethod = info.FullMethod
+		}
+
 		return validator(srv, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
-			ctx, cancel := context.WithTimeout(stream.Context(), h.timeout)
+			ctx, cancel := h.withTimeout(stream.Context(), fullMethod)
 			defer cancel()
 
 			return handler(srv, &recvWrapper{
@@ -73,3 +73,57 @@ func TestNewStreamTimeoutInterceptor(t *testing.T) {
 	err := interceptor(nil, mockServerGRPCStream{ctx: context.Background()}, nil, handler)
 	require.ErrorIs(t, err, context.DeadlineExceeded)
 }
+
+func TestTimeoutInterceptorMethodTimeouts(t *testing.T) {
+	timeoutInterceptor := NewTimeoutInterceptor(5*time.Millisecond, logger.NewNoopLogger(),
+		WithMethodTimeout("/test/Slow", time.Hour),
+		WithMethodTimeout("/test/Unbounded", 0),
+	)
+
+	tests := map[string]struct {
+		fullMethod       string
+		expectedDeadline bool
+		expectedTimeout  time.Duration
+	}{
+		"default_timeout": {
+			fullMethod:       "/test/Other",
+			expectedDeadline: true,
+			expectedTimeout:  5 * time.Millisecond,
+		},
+		"method_timeout": {
+			fullMethod:       "/test/Slow",
+			expectedDeadline: true,
+			expectedTimeout:  time.Hour,
+		},
+		"method_without_timeout": {
+			fullMethod:       "/test/Unbounded",
+			expectedDeadline: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assertDeadline := func(ctx context.Context) {
+				deadline, ok := ctx.Deadline()
+				require.Equal(t, test.expectedDeadline, ok)
+				if ok {
+					require.WithinDuration(t, time.Now().Add(test.expectedTimeout), deadline, time.Second)
+				}
+			}
+
+			unary := timeoutInterceptor.NewUnaryTimeoutInterceptor()
+			_, err := unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: test.fullMethod}, func(ctx context.Context, req any) (any, error) {
+				assertDeadline(ctx)
+				return nil, nil
+			})
+			require.NoError(t, err)
+
+			stream := timeoutInterceptor.NewStreamTimeoutInterceptor()
+			err = stream(nil, mockServerGRPCStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: test.fullMethod}, func(srv any, stream grpc.ServerStream) error {
+				assertDeadline(stream.Context())
+				return nil
+			})
+			require.NoError(t, err)
+		})
+	}
+}
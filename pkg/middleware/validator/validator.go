@@ -2,13 +2,21 @@ package validator
 
 import (
 	"context"
+	"fmt"
 
-	grpcvalidator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type ctxKey string
 
+// contextualTuplesField is the name of the contextual tuples field of the requests that have one.
+const contextualTuplesField = "contextual_tuples"
+
 var (
 	requestIsValidatedCtxKey = ctxKey("request-validated")
 )
@@ -24,29 +32,75 @@ func RequestIsValidatedFromContext(ctx context.Context) bool {
 	return validated && ok
 }
 
+// Validate runs the validations of the request defined in the API. The number of contextual tuples is
+// not validated, since its limit is configured on the server, and an invalid contextual tuple is
+// reported with its index. The request is not modified.
+func Validate(req interface{}) error {
+	switch r := req.(type) {
+	case *openfgav1.CheckRequest:
+		return validateWithContextualTuples(r, r.GetContextualTuples())
+	case *openfgav1.ListObjectsRequest:
+		return validateWithContextualTuples(r, r.GetContextualTuples())
+	case *openfgav1.StreamedListObjectsRequest:
+		return validateWithContextualTuples(r, r.GetContextualTuples())
+	case interface{ ValidateAll() error }:
+		return r.ValidateAll()
+	case interface{ Validate() error }:
+		return r.Validate()
+	}
+
+	return nil
+}
+
+func validateWithContextualTuples(req proto.Message, contextualTuples *openfgav1.ContextualTupleKeys) error {
+	// validate a shallow copy of the request without its contextual tuples, so that the request, which
+	// may be read concurrently by the caller, is never modified
+	src := req.ProtoReflect()
+	withoutContextualTuples := src.New()
+	src.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Name() != contextualTuplesField {
+			withoutContextualTuples.Set(fd, v)
+		}
+		return true
+	})
+
+	validatable, ok := withoutContextualTuples.Interface().(interface{ ValidateAll() error })
+	if !ok {
+		return fmt.Errorf("unexpected request type %T", req)
+	}
+
+	if err := validatable.ValidateAll(); err != nil {
+		return err
+	}
+
+	for i, tk := range contextualTuples.GetTupleKeys() {
+		if err := tk.ValidateAll(); err != nil {
+			return fmt.Errorf("invalid contextual tuple at index %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
 // UnaryServerInterceptor returns a new unary server interceptor that runs request validations
 // and injects a bool in the context indicating that validation has been run.
 func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
-	validator := grpcvalidator.UnaryServerInterceptor()
-
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		return validator(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
-			return handler(contextWithRequestIsValidated(ctx), req)
-		})
+		if err := Validate(req); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return handler(contextWithRequestIsValidated(ctx), req)
 	}
 }
 
 // StreamServerInterceptor returns a new streaming server interceptor that runs request validations
 // and injects a bool in the context indicating that validation has been run.
 func StreamServerInterceptor() grpc.StreamServerInterceptor {
-	validator := grpcvalidator.StreamServerInterceptor()
-
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		return validator(srv, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
-			return handler(srv, &recvWrapper{
-				ctx:          contextWithRequestIsValidated(stream.Context()),
-				ServerStream: ss,
-			})
+		return handler(srv, &recvWrapper{
+			ctx:          contextWithRequestIsValidated(stream.Context()),
+			ServerStream: stream,
 		})
 	}
 }
@@ -60,3 +114,16 @@ type recvWrapper struct {
 func (r *recvWrapper) Context() context.Context {
 	return r.ctx
 }
+
+// RecvMsg validates each message received from the stream.
+func (r *recvWrapper) RecvMsg(m interface{}) error {
+	if err := r.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if err := Validate(m); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return nil
+}
@@ -2,12 +2,18 @@ package validator
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+
+	"github.com/openfga/openfga/pkg/tuple"
 )
 
 type pingService struct {
@@ -52,3 +58,58 @@ func (s *ValidatorTestSuite) TestStreamingPing() {
 	_, err := s.Client.PingStream(s.SimpleCtx())
 	s.Require().NoError(err)
 }
+
+func TestValidate(t *testing.T) {
+	contextualTuples := make([]*openfgav1.TupleKey, 0, 25)
+	for i := 0; i < 25; i++ {
+		contextualTuples = append(contextualTuples, tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne"))
+	}
+
+	req := &openfgav1.CheckRequest{
+		StoreId:          "01HVMMBCMGZNT3SED4Z17ECXCA",
+		TupleKey:         tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: contextualTuples},
+	}
+
+	// the number of contextual tuples is limited by the server, not by the API
+	require.Error(t, req.Validate())
+	require.NoError(t, Validate(req))
+	require.Len(t, req.GetContextualTuples().GetTupleKeys(), 25)
+
+	contextualTuples[3] = tuple.NewTupleKey("document:1", "view er", "user:anne")
+	require.ErrorContains(t, Validate(req), "invalid contextual tuple at index 3")
+
+	req.StoreId = "invalid"
+	require.ErrorContains(t, Validate(req), "StoreId")
+
+	require.NoError(t, Validate(&openfgav1.GetStoreRequest{StoreId: "01HVMMBCMGZNT3SED4Z17ECXCA"}))
+	require.Error(t, Validate(&openfgav1.GetStoreRequest{StoreId: "invalid"}))
+}
+
+func TestValidateDoesNotModifyTheRequest(t *testing.T) {
+	contextualTuples := &openfgav1.ContextualTupleKeys{TupleKeys: []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	}}
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:          "01HVMMBCMGZNT3SED4Z17ECXCA",
+		Type:             "document",
+		Relation:         "viewer",
+		User:             "user:anne",
+		ContextualTuples: contextualTuples,
+	}
+
+	// the request is read while it is validated, which the race detector reports if it is modified
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, Validate(req))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Same(t, contextualTuples, req.GetContextualTuples())
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,300 @@
+// Package admission reviews the tuples of the Write requests before they are committed. An
+// admission webhook is an HTTP endpoint that receives the writes and deletes of each request, and
+// can reject them, e.g. to deny the writes to protected objects, or mutate them, e.g. to enforce
+// naming conventions. The review of a dry run Write request has the DryRunHeader, so that the
+// webhook has no side effects, e.g. an audit log of the admitted writes.
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+var tracer = otel.Tracer("openfga/pkg/server/admission")
+
+// FailurePolicy is what a Webhook does with the writes when the webhook fails or times out.
+type FailurePolicy string
+
+const (
+	// FailurePolicyFail rejects the writes. It is the default.
+	FailurePolicyFail FailurePolicy = "fail"
+
+	// FailurePolicyIgnore commits the writes without review.
+	FailurePolicyIgnore FailurePolicy = "ignore"
+)
+
+// DryRunHeader is set to 'true' on the review requests of a Webhook for dry run Write requests,
+// which are not committed.
+const DryRunHeader = "Openfga-Dry-Run"
+
+const (
+	// DefaultTimeout is the default timeout of a review by a Webhook.
+	DefaultTimeout = 1 * time.Second
+
+	// maxReviewSize is the maximum size of the response of a webhook.
+	maxReviewSize = 4 * 1024 * 1024
+)
+
+const (
+	resultAllowed  = "allowed"
+	resultMutated  = "mutated"
+	resultRejected = "rejected"
+	resultError    = "error"
+)
+
+var webhookDurationHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace:                       build.ProjectName,
+	Name:                            "write_admission_webhook_duration_ms",
+	Help:                            "The duration (in ms) of the reviews of the Write requests by the admission webhook, labeled by their result: allowed, mutated, rejected or error.",
+	Buckets:                         []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000},
+	NativeHistogramBucketFactor:     1.1,
+	NativeHistogramMaxBucketNumber:  100,
+	NativeHistogramMinResetDuration: time.Hour,
+}, []string{"result"})
+
+var webhookFailuresIgnoredCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "write_admission_webhook_failures_ignored_count",
+	Help:      "The number of Write requests committed without review because the admission webhook failed and its failure policy is 'ignore'.",
+})
+
+type dryRunCtxKey struct{}
+
+// ContextWithDryRun returns a context for the review of a dry run Write request, whose tuples are
+// not committed.
+func ContextWithDryRun(parent context.Context) context.Context {
+	return context.WithValue(parent, dryRunCtxKey{}, true)
+}
+
+// IsDryRun reports whether the context is for the review of a dry run Write request.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunCtxKey{}).(bool)
+	return dryRun
+}
+
+// Admitter reviews the tuples of a Write request before they are committed. It returns the
+// request to commit, which may differ from the given one, or an error if the request is rejected.
+// The returned request is validated again. IsDryRun reports whether the request is a dry run.
+type Admitter interface {
+	Admit(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error)
+}
+
+// AdmitterFunc is an Admitter that calls the function.
+type AdmitterFunc func(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error)
+
+// Admit implements Admitter.
+func (f AdmitterFunc) Admit(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+	return f(ctx, req)
+}
+
+// Review is the body of the response of an admission webhook.
+type Review struct {
+	// Allowed is whether the tuples of the request can be committed.
+	Allowed bool `json:"allowed"`
+
+	// Reason is why the tuples are rejected. It is returned to the client.
+	Reason string `json:"reason,omitempty"`
+
+	// Writes and Deletes, if set, replace the writes and deletes of the request. They have the
+	// JSON format of the 'writes' and 'deletes' of a Write request.
+	Writes  json.RawMessage `json:"writes,omitempty"`
+	Deletes json.RawMessage `json:"deletes,omitempty"`
+}
+
+// Webhook is an Admitter that POSTs the Write requests, in the JSON format of the HTTP API, to an
+// HTTP endpoint, which responds with a Review.
+type Webhook struct {
+	url           string
+	timeout       time.Duration
+	failurePolicy FailurePolicy
+	client        *http.Client
+	logger        logger.Logger
+}
+
+var _ Admitter = (*Webhook)(nil)
+
+// WebhookOption defines an option that can be used to change the behavior of a [Webhook].
+type WebhookOption func(w *Webhook)
+
+// WithTimeout sets the timeout of a review. A review that times out is a failure of the webhook.
+func WithTimeout(timeout time.Duration) WebhookOption {
+	return func(w *Webhook) {
+		w.timeout = timeout
+	}
+}
+
+// WithFailurePolicy sets what the [Webhook] does with the writes when it fails or times out.
+func WithFailurePolicy(policy FailurePolicy) WebhookOption {
+	return func(w *Webhook) {
+		w.failurePolicy = policy
+	}
+}
+
+// WithHTTPClient sets the HTTP client that calls the webhook.
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(w *Webhook) {
+		w.client = client
+	}
+}
+
+// WithLogger sets the logger of the [Webhook].
+func WithLogger(l logger.Logger) WebhookOption {
+	return func(w *Webhook) {
+		w.logger = l
+	}
+}
+
+// NewWebhook constructs a [Webhook] that calls the URL.
+func NewWebhook(url string, opts ...WebhookOption) (*Webhook, error) {
+	w := &Webhook{
+		url:           url,
+		timeout:       DefaultTimeout,
+		failurePolicy: FailurePolicyFail,
+		client:        http.DefaultClient,
+		logger:        logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.url == "" {
+		return nil, fmt.Errorf("the admission webhook needs a URL")
+	}
+
+	if w.timeout <= 0 {
+		return nil, fmt.Errorf("the admission webhook timeout must be positive")
+	}
+
+	switch w.failurePolicy {
+	case FailurePolicyFail, FailurePolicyIgnore:
+	default:
+		return nil, fmt.Errorf("unknown admission webhook failure policy '%s', it must be '%s' or '%s'", w.failurePolicy, FailurePolicyFail, FailurePolicyIgnore)
+	}
+
+	return w, nil
+}
+
+// Admit implements Admitter.
+func (w *Webhook) Admit(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+	ctx, span := tracer.Start(ctx, "Admit")
+	defer span.End()
+
+	start := time.Now()
+	review, err := w.review(ctx, req)
+
+	admitted := req
+	if err == nil && review.Allowed {
+		admitted, err = mutate(req, review)
+	}
+
+	result := resultError
+	switch {
+	case err != nil:
+	case !review.Allowed:
+		result = resultRejected
+	case admitted != req:
+		result = resultMutated
+	default:
+		result = resultAllowed
+	}
+	webhookDurationHistogram.WithLabelValues(result).Observe(float64(time.Since(start).Milliseconds()))
+
+	if err != nil {
+		if w.failurePolicy == FailurePolicyIgnore {
+			w.logger.WarnWithContext(ctx, "write admission webhook failed, the writes are committed without review", zap.Error(err))
+			webhookFailuresIgnoredCounter.Inc()
+			return req, nil
+		}
+
+		w.logger.ErrorWithContext(ctx, "write admission webhook failed, the writes are rejected", zap.Error(err))
+		return nil, serverErrors.AdmissionWebhookFailed
+	}
+
+	if !review.Allowed {
+		return nil, serverErrors.WriteRejected(review.Reason)
+	}
+
+	return admitted, nil
+}
+
+func (w *Webhook) review(ctx context.Context, req *openfgav1.WriteRequest) (*Review, error) {
+	body, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if IsDryRun(ctx) {
+		httpReq.Header.Set(DryRunHeader, "true")
+	}
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var review Review
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReviewSize)).Decode(&review); err != nil {
+		return nil, fmt.Errorf("invalid review: %w", err)
+	}
+
+	return &review, nil
+}
+
+// mutate returns the request with the writes and deletes of the review, if set.
+func mutate(req *openfgav1.WriteRequest, review *Review) (*openfgav1.WriteRequest, error) {
+	if !isSet(review.Writes) && !isSet(review.Deletes) {
+		return req, nil
+	}
+
+	mutated := proto.Clone(req).(*openfgav1.WriteRequest)
+	if isSet(review.Writes) {
+		mutated.Writes = &openfgav1.WriteRequestWrites{}
+		if err := protojson.Unmarshal(review.Writes, mutated.Writes); err != nil {
+			return nil, fmt.Errorf("invalid writes in review: %w", err)
+		}
+	}
+
+	if isSet(review.Deletes) {
+		mutated.Deletes = &openfgav1.WriteRequestDeletes{}
+		if err := protojson.Unmarshal(review.Deletes, mutated.Deletes); err != nil {
+			return nil, fmt.Errorf("invalid deletes in review: %w", err)
+		}
+	}
+
+	return mutated, nil
+}
+
+func isSet(raw json.RawMessage) bool {
+	return len(raw) > 0 && string(raw) != "null"
+}
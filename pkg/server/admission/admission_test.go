@@ -0,0 +1,155 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestNewWebhook(t *testing.T) {
+	_, err := NewWebhook("")
+	require.Error(t, err)
+
+	_, err = NewWebhook("http://localhost", WithTimeout(0))
+	require.Error(t, err)
+
+	_, err = NewWebhook("http://localhost", WithFailurePolicy("retry"))
+	require.ErrorContains(t, err, "retry")
+
+	webhook, err := NewWebhook("http://localhost")
+	require.NoError(t, err)
+	require.Equal(t, FailurePolicyFail, webhook.failurePolicy)
+	require.Equal(t, DefaultTimeout, webhook.timeout)
+}
+
+func TestWebhookAdmit(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	req := &openfgav1.WriteRequest{
+		StoreId: ulid.Make().String(),
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:Budget", "viewer", "user:anne")},
+		},
+		Deletes: &openfgav1.WriteRequestDeletes{
+			TupleKeys: []*openfgav1.TupleKeyWithoutCondition{
+				tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:roadmap", "viewer", "user:anne")),
+			},
+		},
+	}
+
+	// newWebhook returns a webhook that calls the handler.
+	newWebhook := func(t *testing.T, handler http.HandlerFunc, opts ...WebhookOption) *Webhook {
+		srv := httptest.NewServer(handler)
+		t.Cleanup(srv.Close)
+
+		webhook, err := NewWebhook(srv.URL, opts...)
+		require.NoError(t, err)
+		return webhook
+	}
+
+	respond := func(review string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(review))
+		}
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		webhook := newWebhook(t, func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.Empty(t, r.Header.Get(DryRunHeader))
+
+			var body struct {
+				StoreID string `json:"store_id"`
+				Writes  struct {
+					TupleKeys []map[string]string `json:"tuple_keys"`
+				} `json:"writes"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, req.GetStoreId(), body.StoreID)
+			require.Equal(t, "document:Budget", body.Writes.TupleKeys[0]["object"])
+
+			_, _ = w.Write([]byte(`{"allowed": true}`))
+		})
+
+		admitted, err := webhook.Admit(context.Background(), req)
+		require.NoError(t, err)
+		require.Same(t, req, admitted)
+	})
+
+	t.Run("dry_run", func(t *testing.T) {
+		webhook := newWebhook(t, func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "true", r.Header.Get(DryRunHeader))
+
+			_, _ = w.Write([]byte(`{"allowed": true}`))
+		})
+
+		admitted, err := webhook.Admit(ContextWithDryRun(context.Background()), req)
+		require.NoError(t, err)
+		require.Same(t, req, admitted)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		webhook := newWebhook(t, respond(`{"allowed": false, "reason": "document:Budget is protected"}`),
+			WithFailurePolicy(FailurePolicyIgnore))
+
+		_, err := webhook.Admit(context.Background(), req)
+		require.ErrorIs(t, err, serverErrors.WriteRejected("document:Budget is protected"))
+	})
+
+	t.Run("mutated", func(t *testing.T) {
+		webhook := newWebhook(t, respond(`{
+			"allowed": true,
+			"writes": {"tuple_keys": [{"object": "document:budget", "relation": "viewer", "user": "user:anne"}]},
+			"deletes": null
+		}`))
+
+		admitted, err := webhook.Admit(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, "document:budget", admitted.GetWrites().GetTupleKeys()[0].GetObject())
+		require.Equal(t, protojson.Format(req.GetDeletes()), protojson.Format(admitted.GetDeletes()))
+
+		// the request is not modified
+		require.Equal(t, "document:Budget", req.GetWrites().GetTupleKeys()[0].GetObject())
+	})
+
+	failures := map[string]http.HandlerFunc{
+		"error_status":   func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+		"invalid_review": respond(`not json`),
+		"invalid_writes": respond(`{"allowed": true, "writes": {"tuple_keys": "document:budget"}}`),
+		"timeout": func(http.ResponseWriter, *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		},
+	}
+
+	for name, handler := range failures {
+		t.Run(name+"_with_fail_policy", func(t *testing.T) {
+			webhook := newWebhook(t, handler, WithTimeout(50*time.Millisecond))
+
+			_, err := webhook.Admit(context.Background(), req)
+			require.ErrorIs(t, err, serverErrors.AdmissionWebhookFailed)
+		})
+
+		t.Run(name+"_with_ignore_policy", func(t *testing.T) {
+			webhook := newWebhook(t, handler, WithTimeout(50*time.Millisecond), WithFailurePolicy(FailurePolicyIgnore))
+
+			admitted, err := webhook.Admit(context.Background(), req)
+			require.NoError(t, err)
+			require.Same(t, req, admitted)
+		})
+	}
+}
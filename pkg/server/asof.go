@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/internal/graph"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+)
+
+// AsOfHeader is an RFC 3339 timestamp at which a Check, ListObjects or StreamedListObjects request
+// is evaluated: against the tuples of the store at that time, reconstructed from the changelog of the
+// store, and with the authorization model of the request. The timestamp of a change returned by
+// ReadChanges evaluates a request right after the change. It requires time travel to be enabled.
+const AsOfHeader = "Openfga-As-Of"
+
+// asOfTupleReader returns the tuple reader that a Check or ListObjects request of the store reads
+// from: the datastore or, if the request has the AsOfHeader, the tuples of the store at that time. In
+// the latter case, the returned context doesn't use the check query cache.
+func (s *Server) asOfTupleReader(ctx context.Context, storeID string) (context.Context, storage.RelationshipTupleReader, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(AsOfHeader)
+	if len(values) == 0 || values[0] == "" {
+		return ctx, s.datastore, nil
+	}
+
+	if !s.timeTravelEnabled {
+		return nil, nil, serverErrors.ValidationError(fmt.Errorf("the %s header requires time travel to be enabled", AsOfHeader))
+	}
+
+	asOf, err := time.Parse(time.RFC3339Nano, values[0])
+	if err != nil {
+		return nil, nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header '%s', it must be an RFC 3339 timestamp", AsOfHeader, values[0]))
+	}
+
+	now := time.Now()
+	if asOf.After(now) {
+		return nil, nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header '%s', it must not be in the future", AsOfHeader, values[0]))
+	}
+
+	if s.timeTravelRetention > 0 && asOf.Before(now.Add(-s.timeTravelRetention)) {
+		return nil, nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header '%s', the changelog is only retained for %s", AsOfHeader, values[0], s.timeTravelRetention))
+	}
+
+	ctx, span := tracer.Start(ctx, "asOfTupleReader")
+	defer span.End()
+	span.SetAttributes(attribute.String("as_of", asOf.Format(time.RFC3339Nano)))
+
+	reader, err := storagewrappers.NewAsOfTupleReader(ctx, s.datastore, storeID, asOf, s.timeTravelMaxChanges)
+	if err != nil {
+		if errors.Is(err, storagewrappers.ErrTooManyChanges) {
+			return nil, nil, serverErrors.ValidationError(err)
+		}
+		return nil, nil, serverErrors.HandleError("", err)
+	}
+
+	return graph.ContextWithoutCheckCache(ctx), reader, nil
+}
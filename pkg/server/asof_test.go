@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestAsOf(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+		WithTimeTravelEnabled(true),
+		WithTimeTravelRetention(time.Hour),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "as-of"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	anne := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes:  &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{anne}},
+	})
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond)
+	granted := time.Now()
+	time.Sleep(time.Millisecond)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Deletes: &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(anne)}},
+	})
+	require.NoError(t, err)
+
+	asOf := func(value string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(AsOfHeader, value))
+	}
+
+	check := func(ctx context.Context) (bool, error) {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		return resp.GetAllowed(), err
+	}
+
+	t.Run("check_as_of_a_past_time", func(t *testing.T) {
+		allowed, err := check(ctx)
+		require.NoError(t, err)
+		require.False(t, allowed)
+
+		allowed, err = check(asOf(granted.Format(time.RFC3339Nano)))
+		require.NoError(t, err)
+		require.True(t, allowed)
+
+		// the result of the past time isn't cached
+		allowed, err = check(ctx)
+		require.NoError(t, err)
+		require.False(t, allowed)
+	})
+
+	t.Run("list_objects_as_of_a_past_time", func(t *testing.T) {
+		resp, err := s.ListObjects(asOf(granted.Format(time.RFC3339Nano)), &openfgav1.ListObjectsRequest{
+			StoreId:  storeID,
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:1"}, resp.GetObjects())
+	})
+
+	t.Run("invalid_as_of", func(t *testing.T) {
+		for name, value := range map[string]string{
+			"not_a_timestamp":      "yesterday",
+			"in_the_future":        time.Now().Add(time.Hour).Format(time.RFC3339),
+			"older_than_retention": time.Now().Add(-2 * time.Hour).Format(time.RFC3339),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := check(asOf(value))
+				require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+			})
+		}
+	})
+
+	t.Run("time_travel_disabled", func(t *testing.T) {
+		disabled := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(disabled.Close)
+
+		_, err := disabled.Check(asOf(granted.Format(time.RFC3339Nano)), &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.ErrorContains(t, err, "requires time travel to be enabled")
+	})
+}
@@ -0,0 +1,133 @@
+// Package assertions evaluates the assertions stored for an authorization model, so that a new model can
+// be gated on them (e.g. in CI) before it is used, and reports on the history of the assertions of the
+// models of a store and on how much of a model they cover.
+package assertions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client is the subset of the OpenFGA service that is used to evaluate assertions.
+type Client interface {
+	ReadAssertions(ctx context.Context, in *openfgav1.ReadAssertionsRequest, opts ...grpc.CallOption) (*openfgav1.ReadAssertionsResponse, error)
+	Check(ctx context.Context, in *openfgav1.CheckRequest, opts ...grpc.CallOption) (*openfgav1.CheckResponse, error)
+}
+
+// EvaluateRequest identifies the assertions to evaluate and the model to evaluate them against.
+type EvaluateRequest struct {
+	StoreID string
+
+	// AuthorizationModelID is the model that the assertions are evaluated against.
+	AuthorizationModelID string
+
+	// AssertionsAuthorizationModelID is the model that the assertions are stored for. If empty, the
+	// assertions stored for AuthorizationModelID are evaluated.
+	AssertionsAuthorizationModelID string
+}
+
+// Result is the outcome of evaluating a single assertion.
+type Result struct {
+	User        string `json:"user"`
+	Relation    string `json:"relation"`
+	Object      string `json:"object"`
+	Expectation bool   `json:"expectation"`
+	Allowed     bool   `json:"allowed"`
+	Passed      bool   `json:"passed"`
+
+	// Error is the error of the Check request of the assertion, if it failed. The assertion didn't pass.
+	Error string `json:"error,omitempty"`
+}
+
+// EvaluateResponse is the outcome of evaluating all the assertions.
+type EvaluateResponse struct {
+	Results     []Result `json:"results"`
+	PassedCount int      `json:"passed_count"`
+	FailedCount int      `json:"failed_count"`
+}
+
+// Evaluate runs a Check request for each of the assertions and compares the result with the expectation.
+// An error is only returned if the assertions can't be read.
+func Evaluate(ctx context.Context, client Client, req EvaluateRequest) (*EvaluateResponse, error) {
+	assertionsModelID := req.AssertionsAuthorizationModelID
+	if assertionsModelID == "" {
+		assertionsModelID = req.AuthorizationModelID
+	}
+
+	stored, err := client.ReadAssertions(ctx, &openfgav1.ReadAssertionsRequest{
+		StoreId:              req.StoreID,
+		AuthorizationModelId: assertionsModelID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &EvaluateResponse{Results: make([]Result, 0, len(stored.GetAssertions()))}
+	for _, assertion := range stored.GetAssertions() {
+		tk := assertion.GetTupleKey()
+		result := Result{
+			User:        tk.GetUser(),
+			Relation:    tk.GetRelation(),
+			Object:      tk.GetObject(),
+			Expectation: assertion.GetExpectation(),
+		}
+
+		check, err := client.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              req.StoreID,
+			AuthorizationModelId: req.AuthorizationModelID,
+			TupleKey: &openfgav1.CheckRequestTupleKey{
+				User:     tk.GetUser(),
+				Relation: tk.GetRelation(),
+				Object:   tk.GetObject(),
+			},
+		})
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Allowed = check.GetAllowed()
+			result.Passed = check.GetAllowed() == assertion.GetExpectation()
+		}
+
+		if result.Passed {
+			resp.PassedCount++
+		} else {
+			resp.FailedCount++
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+// NewHTTPHandler returns a handler for the HTTP gateway that evaluates the assertions of the
+// 'store_id' and 'authorization_model_id' path parameters. The assertions stored for another model
+// can be evaluated with the 'assertions_authorization_model_id' query parameter. The Authorization
+// header is forwarded to the client, so that the requests are authenticated like any other request.
+func NewHTTPHandler(mux *runtime.ServeMux, client Client) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+
+		resp, err := Evaluate(ctx, client, EvaluateRequest{
+			StoreID:                        pathParams["store_id"],
+			AuthorizationModelID:           pathParams["authorization_model_id"],
+			AssertionsAuthorizationModelID: r.URL.Query().Get("assertions_authorization_model_id"),
+		})
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
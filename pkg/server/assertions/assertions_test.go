@@ -0,0 +1,107 @@
+package assertions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/testutils/servertest"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func setup(t *testing.T) (*servertest.Client, string, string, string) {
+	client := servertest.New(t)
+
+	storeID, oldModelID := client.CreateStore(t, "assertions", `model
+  schema 1.1
+type user
+type document
+  relations
+    define owner: [user]
+    define viewer: [user] or owner`, tuple.NewTupleKey("document:1", "owner", "user:anne"))
+
+	newModelID := client.WriteModel(t, storeID, `model
+  schema 1.1
+type user
+type document
+  relations
+    define owner: [user]
+    define viewer: [user]`)
+
+	_, err := client.WriteAssertions(context.Background(), &openfgav1.WriteAssertionsRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: oldModelID,
+		Assertions: []*openfgav1.Assertion{
+			{TupleKey: tuple.NewAssertionTupleKey("document:1", "owner", "user:anne"), Expectation: true},
+			{TupleKey: tuple.NewAssertionTupleKey("document:1", "viewer", "user:anne"), Expectation: true},
+		},
+	})
+	require.NoError(t, err)
+
+	return client, storeID, oldModelID, newModelID
+}
+
+func TestEvaluate(t *testing.T) {
+	client, storeID, oldModelID, newModelID := setup(t)
+
+	t.Run("passing", func(t *testing.T) {
+		resp, err := Evaluate(context.Background(), client, EvaluateRequest{StoreID: storeID, AuthorizationModelID: oldModelID})
+		require.NoError(t, err)
+		require.Equal(t, 2, resp.PassedCount)
+		require.Zero(t, resp.FailedCount)
+	})
+
+	t.Run("against_another_model", func(t *testing.T) {
+		resp, err := Evaluate(context.Background(), client, EvaluateRequest{
+			StoreID:                        storeID,
+			AuthorizationModelID:           newModelID,
+			AssertionsAuthorizationModelID: oldModelID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, resp.PassedCount)
+		require.Equal(t, 1, resp.FailedCount)
+		require.Equal(t, Result{
+			User:        "user:anne",
+			Relation:    "viewer",
+			Object:      "document:1",
+			Expectation: true,
+			Allowed:     false,
+			Passed:      false,
+		}, resp.Results[1])
+	})
+
+	t.Run("unknown_model", func(t *testing.T) {
+		_, err := Evaluate(context.Background(), client, EvaluateRequest{StoreID: storeID, AuthorizationModelID: "01HVMMBCMGZNT3SED4Z17ECXCA"})
+		require.Error(t, err)
+	})
+}
+
+func TestHTTPHandler(t *testing.T) {
+	client, storeID, oldModelID, newModelID := setup(t)
+
+	mux := runtime.NewServeMux()
+	require.NoError(t, mux.HandlePath(http.MethodGet, "/stores/{store_id}/assertions/{authorization_model_id}/evaluate", NewHTTPHandler(mux, client)))
+
+	req := httptest.NewRequest(http.MethodGet, "/stores/"+storeID+"/assertions/"+newModelID+"/evaluate?assertions_authorization_model_id="+oldModelID, nil)
+	req.Header.Set("Authorization", "Bearer key")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"Bearer key"}, client.Metadata("authorization"))
+
+	var resp EvaluateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, 1, resp.FailedCount)
+
+	req = httptest.NewRequest(http.MethodGet, "/stores/"+storeID+"/assertions/01HVMMBCMGZNT3SED4Z17ECXCA/evaluate", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.NotEqual(t, http.StatusOK, w.Code)
+}
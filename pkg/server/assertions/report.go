@@ -0,0 +1,330 @@
+package assertions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// ReportClient is the subset of the OpenFGA service that is used to report on the assertions of the
+// models of a store.
+type ReportClient interface {
+	ReadAuthorizationModels(ctx context.Context, in *openfgav1.ReadAuthorizationModelsRequest, opts ...grpc.CallOption) (*openfgav1.ReadAuthorizationModelsResponse, error)
+	ReadAuthorizationModel(ctx context.Context, in *openfgav1.ReadAuthorizationModelRequest, opts ...grpc.CallOption) (*openfgav1.ReadAuthorizationModelResponse, error)
+	ReadAssertions(ctx context.Context, in *openfgav1.ReadAssertionsRequest, opts ...grpc.CallOption) (*openfgav1.ReadAssertionsResponse, error)
+}
+
+// Assertion is an assertion stored for a model.
+type Assertion struct {
+	User        string `json:"user"`
+	Relation    string `json:"relation"`
+	Object      string `json:"object"`
+	Expectation bool   `json:"expectation"`
+}
+
+func newAssertion(assertion *openfgav1.Assertion) Assertion {
+	tk := assertion.GetTupleKey()
+	return Assertion{
+		User:        tk.GetUser(),
+		Relation:    tk.GetRelation(),
+		Object:      tk.GetObject(),
+		Expectation: assertion.GetExpectation(),
+	}
+}
+
+// ModelAssertions are the assertions stored for a model of a store, and how they changed from those
+// of the previous model.
+type ModelAssertions struct {
+	AuthorizationModelID string      `json:"authorization_model_id"`
+	CreatedAt            time.Time   `json:"created_at"`
+	Assertions           []Assertion `json:"assertions"`
+
+	// Added are the assertions that the previous model didn't have, and Removed those of the previous
+	// model that this model doesn't have. The first model of the store only has Added assertions.
+	Added   []Assertion `json:"added"`
+	Removed []Assertion `json:"removed"`
+}
+
+// HistoryResponse is the history of the assertions of a store.
+type HistoryResponse struct {
+	// Models are the models of the store and their assertions, from the latest to the first one.
+	Models []ModelAssertions `json:"models"`
+}
+
+// History returns the assertions stored for each model of a store, so that the assertions of the
+// previous versions of a model are kept in view as it evolves.
+func History(ctx context.Context, client ReportClient, storeID string) (*HistoryResponse, error) {
+	var models []*openfgav1.AuthorizationModel
+	token := ""
+	for {
+		resp, err := client.ReadAuthorizationModels(ctx, &openfgav1.ReadAuthorizationModelsRequest{
+			StoreId:           storeID,
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		models = append(models, resp.GetAuthorizationModels()...)
+		token = resp.GetContinuationToken()
+		if token == "" {
+			break
+		}
+	}
+
+	history := &HistoryResponse{Models: make([]ModelAssertions, len(models))}
+
+	// the models are read from the latest one, and each one is compared with the one before it
+	var previous []Assertion
+	for i := len(models) - 1; i >= 0; i-- {
+		modelID := models[i].GetId()
+
+		resp, err := client.ReadAssertions(ctx, &openfgav1.ReadAssertionsRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: modelID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		assertions := make([]Assertion, 0, len(resp.GetAssertions()))
+		for _, assertion := range resp.GetAssertions() {
+			assertions = append(assertions, newAssertion(assertion))
+		}
+
+		model := ModelAssertions{
+			AuthorizationModelID: modelID,
+			Assertions:           assertions,
+			Added:                difference(assertions, previous),
+			Removed:              difference(previous, assertions),
+		}
+		if id, err := ulid.Parse(modelID); err == nil {
+			model.CreatedAt = ulid.Time(id.Time()).UTC()
+		}
+
+		history.Models[i] = model
+		previous = assertions
+	}
+
+	return history, nil
+}
+
+// difference returns the assertions of a that aren't in b.
+func difference(a, b []Assertion) []Assertion {
+	diff := []Assertion{}
+	for _, assertion := range a {
+		if !slices.Contains(b, assertion) {
+			diff = append(diff, assertion)
+		}
+	}
+	return diff
+}
+
+// RelationCoverage is whether a relation of a model is exercised by its assertions.
+type RelationCoverage struct {
+	Type     string `json:"type"`
+	Relation string `json:"relation"`
+
+	// AssertionCount is the number of assertions on the relation itself.
+	AssertionCount int `json:"assertion_count"`
+
+	// Exercised is true if the relation is evaluated by the Check of an assertion, because the
+	// assertion is on the relation or on a relation that is rewritten with it.
+	Exercised bool `json:"exercised"`
+}
+
+// CoverageResponse is the coverage of the types and relations of a model by its assertions.
+type CoverageResponse struct {
+	AuthorizationModelID string             `json:"authorization_model_id"`
+	Relations            []RelationCoverage `json:"relations"`
+
+	// UncoveredTypes are the types that have relations, none of which is exercised.
+	UncoveredTypes []string `json:"uncovered_types"`
+
+	RelationCount  int `json:"relation_count"`
+	ExercisedCount int `json:"exercised_count"`
+
+	// Coverage is the percentage of the relations that are exercised.
+	Coverage float64 `json:"coverage"`
+}
+
+// Coverage returns which types and relations of a model are exercised by the assertions stored for
+// it. A relation is exercised by an assertion on it, and by an assertion on any relation that is
+// rewritten with it, e.g. `owner` by an assertion on `define viewer: [user] or owner`, or `parent` and
+// `folder#viewer` by an assertion on `define viewer: viewer from parent`.
+func Coverage(ctx context.Context, client ReportClient, storeID, modelID string) (*CoverageResponse, error) {
+	model, err := client.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{
+		StoreId: storeID,
+		Id:      modelID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := client.ReadAssertions(ctx, &openfgav1.ReadAssertionsRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	typesys := typesystem.New(model.GetAuthorizationModel())
+
+	counts := map[string]int{}
+	exercised := map[string]struct{}{}
+	coverage := &CoverageResponse{
+		AuthorizationModelID: modelID,
+		Relations:            []RelationCoverage{},
+		UncoveredTypes:       []string{},
+	}
+	for _, assertion := range stored.GetAssertions() {
+		objectType := tuple.GetType(assertion.GetTupleKey().GetObject())
+		relation := assertion.GetTupleKey().GetRelation()
+
+		counts[tuple.ToObjectRelationString(objectType, relation)]++
+		exercise(typesys, objectType, relation, exercised)
+	}
+
+	// the nodes of the relations of the graph are sorted by type and relation
+	typeExercised := map[string]bool{}
+	var types []string
+	for _, node := range typesys.Graph().Nodes {
+		if node.Kind != typesystem.GraphNodeRelation {
+			continue
+		}
+
+		objectType, relation := tuple.SplitObjectRelation(node.ID)
+		_, ok := exercised[node.ID]
+		coverage.Relations = append(coverage.Relations, RelationCoverage{
+			Type:           objectType,
+			Relation:       relation,
+			AssertionCount: counts[node.ID],
+			Exercised:      ok,
+		})
+
+		coverage.RelationCount++
+		if ok {
+			coverage.ExercisedCount++
+		}
+
+		if _, seen := typeExercised[objectType]; !seen {
+			types = append(types, objectType)
+		}
+		typeExercised[objectType] = typeExercised[objectType] || ok
+	}
+
+	for _, objectType := range types {
+		if !typeExercised[objectType] {
+			coverage.UncoveredTypes = append(coverage.UncoveredTypes, objectType)
+		}
+	}
+
+	if coverage.RelationCount > 0 {
+		coverage.Coverage = 100 * float64(coverage.ExercisedCount) / float64(coverage.RelationCount)
+	}
+
+	return coverage, nil
+}
+
+// exercise adds the relation and the relations that it is rewritten with to exercised.
+func exercise(typesys *typesystem.TypeSystem, objectType, relation string, exercised map[string]struct{}) {
+	key := tuple.ToObjectRelationString(objectType, relation)
+	if _, ok := exercised[key]; ok {
+		return
+	}
+
+	rel, err := typesys.GetRelation(objectType, relation)
+	if err != nil {
+		return
+	}
+	exercised[key] = struct{}{}
+
+	var walk func(rewrite *openfgav1.Userset)
+	walk = func(rewrite *openfgav1.Userset) {
+		switch rw := rewrite.GetUserset().(type) {
+		case *openfgav1.Userset_This:
+			refs, _ := typesys.GetDirectlyRelatedUserTypes(objectType, relation)
+			for _, ref := range refs {
+				if ref.GetRelation() != "" {
+					exercise(typesys, ref.GetType(), ref.GetRelation(), exercised)
+				}
+			}
+		case *openfgav1.Userset_ComputedUserset:
+			exercise(typesys, objectType, rw.ComputedUserset.GetRelation(), exercised)
+		case *openfgav1.Userset_TupleToUserset:
+			tupleset := rw.TupleToUserset.GetTupleset().GetRelation()
+			exercise(typesys, objectType, tupleset, exercised)
+
+			refs, _ := typesys.GetDirectlyRelatedUserTypes(objectType, tupleset)
+			for _, ref := range refs {
+				exercise(typesys, ref.GetType(), rw.TupleToUserset.GetComputedUserset().GetRelation(), exercised)
+			}
+		case *openfgav1.Userset_Union:
+			for _, child := range rw.Union.GetChild() {
+				walk(child)
+			}
+		case *openfgav1.Userset_Intersection:
+			for _, child := range rw.Intersection.GetChild() {
+				walk(child)
+			}
+		case *openfgav1.Userset_Difference:
+			walk(rw.Difference.GetBase())
+			walk(rw.Difference.GetSubtract())
+		}
+	}
+	walk(rel.GetRewrite())
+}
+
+// NewHistoryHTTPHandler returns a handler for the HTTP gateway that returns the History of the
+// assertions of the 'store_id' path parameter. The Authorization header is forwarded to the client,
+// so that the requests are authenticated like any other request.
+func NewHistoryHTTPHandler(mux *runtime.ServeMux, client ReportClient) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+
+		resp, err := History(ctx, client, pathParams["store_id"])
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// NewCoverageHTTPHandler returns a handler for the HTTP gateway that returns the Coverage of the
+// model of the 'store_id' and 'authorization_model_id' path parameters by its assertions. The
+// Authorization header is forwarded to the client.
+func NewCoverageHTTPHandler(mux *runtime.ServeMux, client ReportClient) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+
+		resp, err := Coverage(ctx, client, pathParams["store_id"], pathParams["authorization_model_id"])
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
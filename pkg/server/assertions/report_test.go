@@ -0,0 +1,147 @@
+package assertions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestHistory(t *testing.T) {
+	client, storeID, oldModelID, newModelID := setup(t)
+	ctx := context.Background()
+
+	_, err := client.WriteAssertions(ctx, &openfgav1.WriteAssertionsRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: newModelID,
+		Assertions: []*openfgav1.Assertion{
+			{TupleKey: tuple.NewAssertionTupleKey("document:1", "owner", "user:anne"), Expectation: true},
+			{TupleKey: tuple.NewAssertionTupleKey("document:1", "viewer", "user:anne"), Expectation: false},
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err := History(ctx, client, storeID)
+	require.NoError(t, err)
+	require.Len(t, resp.Models, 2)
+
+	latest, first := resp.Models[0], resp.Models[1]
+	require.Equal(t, newModelID, latest.AuthorizationModelID)
+	require.Equal(t, oldModelID, first.AuthorizationModelID)
+	require.False(t, first.CreatedAt.IsZero())
+
+	require.Len(t, first.Assertions, 2)
+	require.Equal(t, first.Assertions, first.Added)
+	require.Empty(t, first.Removed)
+
+	require.Equal(t, []Assertion{{User: "user:anne", Relation: "viewer", Object: "document:1", Expectation: false}}, latest.Added)
+	require.Equal(t, []Assertion{{User: "user:anne", Relation: "viewer", Object: "document:1", Expectation: true}}, latest.Removed)
+}
+
+func TestCoverage(t *testing.T) {
+	client, storeID, _, _ := setup(t)
+	ctx := context.Background()
+
+	model := testutils.MustTransformDSLToProtoWithID(`model
+  schema 1.1
+type user
+type group
+  relations
+    define member: [user]
+type folder
+  relations
+    define viewer: [group#member]
+type document
+  relations
+    define parent: [folder]
+    define owner: [user]
+    define editor: [user]
+    define viewer: editor or viewer from parent
+type team
+  relations
+    define member: [user]`)
+	written, err := client.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+	modelID := written.GetAuthorizationModelId()
+
+	_, err = client.WriteAssertions(ctx, &openfgav1.WriteAssertionsRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+		Assertions: []*openfgav1.Assertion{
+			{TupleKey: tuple.NewAssertionTupleKey("document:1", "viewer", "user:anne"), Expectation: true},
+			{TupleKey: tuple.NewAssertionTupleKey("document:1", "viewer", "user:bob"), Expectation: false},
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err := Coverage(ctx, client, storeID, modelID)
+	require.NoError(t, err)
+	require.Equal(t, modelID, resp.AuthorizationModelID)
+
+	exercised := map[string]bool{}
+	for _, relation := range resp.Relations {
+		exercised[tuple.ToObjectRelationString(relation.Type, relation.Relation)] = relation.Exercised
+		if relation.Type == "document" && relation.Relation == "viewer" {
+			require.Equal(t, 2, relation.AssertionCount)
+		}
+	}
+	require.Equal(t, map[string]bool{
+		"document#editor": true,
+		"document#owner":  false,
+		"document#parent": true,
+		"document#viewer": true,
+		"folder#viewer":   true,
+		"group#member":    true,
+		"team#member":     false,
+	}, exercised)
+
+	require.Equal(t, []string{"team"}, resp.UncoveredTypes)
+	require.Equal(t, 7, resp.RelationCount)
+	require.Equal(t, 5, resp.ExercisedCount)
+	require.InDelta(t, 100*5.0/7.0, resp.Coverage, 0.001)
+}
+
+func TestReportHTTPHandlers(t *testing.T) {
+	client, storeID, oldModelID, _ := setup(t)
+
+	mux := runtime.NewServeMux()
+	require.NoError(t, mux.HandlePath(http.MethodGet, "/stores/{store_id}/assertions", NewHistoryHTTPHandler(mux, client)))
+	require.NoError(t, mux.HandlePath(http.MethodGet, "/stores/{store_id}/assertions/{authorization_model_id}/coverage", NewCoverageHTTPHandler(mux, client)))
+
+	req := httptest.NewRequest(http.MethodGet, "/stores/"+storeID+"/assertions", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var history HistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Len(t, history.Models, 2)
+
+	req = httptest.NewRequest(http.MethodGet, "/stores/"+storeID+"/assertions/"+oldModelID+"/coverage", nil)
+	req.Header.Set("Authorization", "Bearer key")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"Bearer key"}, client.Metadata("authorization"))
+
+	var coverage CoverageResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &coverage))
+	require.Equal(t, 2, coverage.ExercisedCount)
+
+	req = httptest.NewRequest(http.MethodGet, "/stores/"+storeID+"/assertions/01HVMMBCMGZNT3SED4Z17ECXCA/coverage", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.NotEqual(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,209 @@
+// Package cachewarming pre-warms the Check query cache of a store, e.g. after a deploy, by running
+// the Check requests of patterns of tuple keys or of a list of recent checks, so that the first
+// requests of the clients don't all miss the cache.
+package cachewarming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const (
+	// MaxPatterns is the maximum number of patterns of a request.
+	MaxPatterns = 100
+
+	// MaxUsersPerPattern is the maximum number of users whose Check is run for a pattern.
+	MaxUsersPerPattern = 100
+
+	// MaxChecks is the maximum number of checks of a request.
+	MaxChecks = 1000
+
+	// Concurrency is the number of Check requests of a request that are run concurrently.
+	Concurrency = 10
+
+	readPageSize = 100
+)
+
+// Client is the subset of the OpenFGA service that is used to find the users of the patterns and
+// run their Check requests.
+type Client interface {
+	Read(ctx context.Context, in *openfgav1.ReadRequest, opts ...grpc.CallOption) (*openfgav1.ReadResponse, error)
+	Check(ctx context.Context, in *openfgav1.CheckRequest, opts ...grpc.CallOption) (*openfgav1.CheckResponse, error)
+}
+
+// Pattern is the relation of an object to the users of a type, e.g. 'user' or 'group#member'.
+type Pattern struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	UserType string `json:"user_type"`
+}
+
+// Request is a set of patterns and checks to warm the cache of a store with.
+type Request struct {
+	StoreID              string                            `json:"-"`
+	AuthorizationModelID string                            `json:"authorization_model_id"`
+	Patterns             []Pattern                         `json:"patterns"`
+	Checks               []*openfgav1.CheckRequestTupleKey `json:"checks"`
+}
+
+// Response is the number of Check requests that were run, and how many of them failed.
+type Response struct {
+	Checks int `json:"checks"`
+	Failed int `json:"failed"`
+}
+
+// Warm runs the Check request of each check, and of the relation of the object of each pattern to
+// each of the users of the type that the tuples of the object are related to, up to
+// MaxUsersPerPattern. The Check requests go through the cache like any other, so that their
+// results and the results of their subproblems are cached. A failed Check is only counted, but
+// an error is returned if the users of a pattern can't be read.
+func Warm(ctx context.Context, client Client, req Request) (*Response, error) {
+	if len(req.Patterns) > MaxPatterns {
+		return nil, status.Errorf(codes.InvalidArgument, "the number of patterns must be at most %d", MaxPatterns)
+	}
+
+	if len(req.Checks) > MaxChecks {
+		return nil, status.Errorf(codes.InvalidArgument, "the number of checks must be at most %d", MaxChecks)
+	}
+
+	checks := make([]*openfgav1.CheckRequestTupleKey, 0, len(req.Checks))
+	checks = append(checks, req.Checks...)
+	for _, pattern := range req.Patterns {
+		if pattern.Object == "" || pattern.Relation == "" || pattern.UserType == "" {
+			return nil, status.Error(codes.InvalidArgument, "the object, relation and user_type of a pattern are required")
+		}
+
+		users, err := readUsers(ctx, client, req.StoreID, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range users {
+			checks = append(checks, tuple.NewCheckRequestTupleKey(pattern.Object, pattern.Relation, user))
+		}
+	}
+
+	resp := &Response{Checks: len(checks)}
+	var mu sync.Mutex
+
+	limiter := make(chan struct{}, Concurrency)
+	var wg sync.WaitGroup
+	for _, tk := range checks {
+		tk := tk
+
+		limiter <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-limiter
+				wg.Done()
+			}()
+
+			_, err := client.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:              req.StoreID,
+				AuthorizationModelId: req.AuthorizationModelID,
+				TupleKey:             tk,
+			})
+			if err != nil {
+				mu.Lock()
+				resp.Failed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return resp, nil
+}
+
+// readUsers returns the distinct users of the type of the pattern in the tuples of its object.
+func readUsers(ctx context.Context, client Client, storeID string, pattern Pattern) ([]string, error) {
+	userType, userRelation := tuple.SplitObjectRelation(pattern.UserType)
+
+	seen := map[string]struct{}{}
+	users := make([]string, 0)
+
+	continuationToken := ""
+	for {
+		resp, err := client.Read(ctx, &openfgav1.ReadRequest{
+			StoreId:           storeID,
+			TupleKey:          &openfgav1.ReadRequestTupleKey{Object: pattern.Object},
+			PageSize:          wrapperspb.Int32(readPageSize),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range resp.GetTuples() {
+			user := t.GetKey().GetUser()
+			object, relation := tuple.SplitObjectRelation(user)
+			if tuple.GetType(object) != userType || relation != userRelation {
+				continue
+			}
+
+			if _, ok := seen[user]; ok {
+				continue
+			}
+			seen[user] = struct{}{}
+
+			users = append(users, user)
+			if len(users) == MaxUsersPerPattern {
+				return users, nil
+			}
+		}
+
+		continuationToken = resp.GetContinuationToken()
+		if continuationToken == "" {
+			return users, nil
+		}
+	}
+}
+
+// NewHTTPHandler returns a handler for the HTTP gateway that warms the cache of the 'store_id'
+// path parameter with the patterns and checks of the body. The Authorization header is forwarded
+// to the client, so that the requests are authenticated and validated like any other request.
+func NewHTTPHandler(mux *runtime.ServeMux, client Client) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("the request body is empty")
+			} else {
+				err = fmt.Errorf("invalid request body: %w", err)
+			}
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		req.StoreID = pathParams["store_id"]
+
+		resp, err := Warm(ctx, client, req)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
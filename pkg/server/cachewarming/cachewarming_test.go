@@ -0,0 +1,143 @@
+package cachewarming
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/testutils/servertest"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// headerRecordingTransport records the response headers of the server.
+type headerRecordingTransport struct {
+	mu      sync.Mutex
+	headers map[string]string
+}
+
+func (h *headerRecordingTransport) SetHeader(_ context.Context, key, value string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.headers[key] = value
+}
+
+func setup(t *testing.T) (*servertest.Client, *headerRecordingTransport, string) {
+	transport := &headerRecordingTransport{headers: map[string]string{}}
+	client := servertest.New(t,
+		server.WithTransport(transport),
+		server.WithCheckQueryCacheEnabled(true),
+	)
+
+	storeID, _ := client.CreateStore(t, "cachewarming", `model
+  schema 1.1
+type user
+type group
+  relations
+    define member: [user]
+type document
+  relations
+    define editor: [user, group#member]
+    define viewer: [user] or editor`,
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+		tuple.NewTupleKey("document:1", "editor", "group:eng#member"),
+		tuple.NewTupleKey("group:eng", "member", "user:carl"),
+	)
+
+	return client, transport, storeID
+}
+
+// checkedUsers returns the users of the Check requests of the client.
+func checkedUsers(client *servertest.Client) []string {
+	var users []string
+	for _, check := range client.Checks() {
+		users = append(users, check.GetTupleKey().GetUser())
+	}
+	return users
+}
+
+func TestWarm(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	client, transport, storeID := setup(t)
+	ctx := context.Background()
+
+	resp, err := Warm(ctx, client, Request{
+		StoreID: storeID,
+		Patterns: []Pattern{
+			{Object: "document:1", Relation: "viewer", UserType: "user"},
+			{Object: "document:1", Relation: "viewer", UserType: "group#member"},
+		},
+		Checks: []*openfgav1.CheckRequestTupleKey{
+			tuple.NewCheckRequestTupleKey("document:2", "viewer", "user:carl"),
+			tuple.NewCheckRequestTupleKey("document:1", "owner", "user:carl"),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Response{Checks: 5, Failed: 1}, resp)
+	require.ElementsMatch(t, []string{"user:anne", "user:bob", "group:eng#member", "user:carl", "user:carl"}, checkedUsers(client))
+
+	_, err = client.Server.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:  storeID,
+		TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:bob"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1", transport.headers[server.CacheHitCountHeader])
+
+	t.Run("invalid_pattern", func(t *testing.T) {
+		_, err := Warm(ctx, client, Request{
+			StoreID:  storeID,
+			Patterns: []Pattern{{Object: "document:1", Relation: "viewer"}},
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("too_many_checks", func(t *testing.T) {
+		_, err := Warm(ctx, client, Request{
+			StoreID: storeID,
+			Checks:  make([]*openfgav1.CheckRequestTupleKey, MaxChecks+1),
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestHTTPHandler(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	client, _, storeID := setup(t)
+
+	mux := runtime.NewServeMux()
+	require.NoError(t, mux.HandlePath(http.MethodPost, "/stores/{store_id}/check-cache/warm", NewHTTPHandler(mux, client)))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/stores/"+storeID+"/check-cache/warm", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer key")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(`{"patterns": [{"object": "document:1", "relation": "viewer", "user_type": "user"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.JSONEq(t, `{"checks": 2, "failed": 0}`, rec.Body.String())
+	require.Equal(t, []string{"Bearer key"}, client.Metadata("authorization"))
+
+	require.Equal(t, http.StatusBadRequest, post(``).Code)
+	require.Equal(t, http.StatusBadRequest, post(`{"patterns": {}}`).Code)
+}
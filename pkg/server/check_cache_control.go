@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/graph"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+const (
+	// CheckCacheControlHeader has the comma-separated cache directives of a Check request, like the
+	// Cache-Control header of HTTP requests. They only apply to the request, so that a caller with
+	// strict freshness needs doesn't require the check query cache to be disabled for every request:
+	//   - CacheControlNoStore: the cached results are neither used nor populated.
+	//   - CacheControlNoCache: the cached results aren't used, but the new results are cached.
+	//   - CacheControlMinFresh, e.g. 'min-fresh=30': only the cached results that are still fresh for
+	//     at least the number of seconds are used.
+	CheckCacheControlHeader = "Openfga-Cache-Control"
+
+	CacheControlNoStore  = "no-store"
+	CacheControlNoCache  = "no-cache"
+	CacheControlMinFresh = "min-fresh"
+)
+
+var checkCacheControlCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "check_cache_control_count",
+	Help:      "The total number of Check requests with a cache directive of the Openfga-Cache-Control header, by directive.",
+}, []string{"directive"})
+
+// checkCacheControl are the cache directives of a Check request.
+type checkCacheControl struct {
+	noStore  bool
+	noCache  bool
+	minFresh time.Duration
+}
+
+// parseCheckCacheControl parses the cache directives of the values of the CheckCacheControlHeader.
+// The directives are case-insensitive, and the unknown ones are rejected rather than ignored, as a
+// caller that relies on a misspelled directive would silently get cached results.
+func parseCheckCacheControl(values []string) (checkCacheControl, error) {
+	var cc checkCacheControl
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			name, arg, hasArg := strings.Cut(directive, "=")
+
+			switch {
+			case directive == "":
+			case directive == CacheControlNoStore:
+				cc.noStore = true
+			case directive == CacheControlNoCache:
+				cc.noCache = true
+			case name == CacheControlMinFresh && hasArg:
+				seconds, err := strconv.ParseUint(strings.TrimSpace(arg), 10, 32)
+				if err != nil {
+					return checkCacheControl{}, serverErrors.InvalidCacheControl(value, "the 'min-fresh' directive must be a number of seconds")
+				}
+				cc.minFresh = max(cc.minFresh, time.Duration(seconds)*time.Second)
+			default:
+				return checkCacheControl{}, serverErrors.InvalidCacheControl(value,
+					"the directives must be '"+CacheControlNoStore+"', '"+CacheControlNoCache+"' or '"+CacheControlMinFresh+"=<seconds>'")
+			}
+		}
+	}
+	return cc, nil
+}
+
+// applyCheckCacheControl returns a context whose Check resolutions follow the cache directives of
+// the CheckCacheControlHeader of the request, if any.
+func applyCheckCacheControl(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(CheckCacheControlHeader)
+	if len(values) == 0 {
+		return ctx, nil
+	}
+
+	cc, err := parseCheckCacheControl(values)
+	if err != nil {
+		return nil, err
+	}
+
+	// the strictest directive applies
+	switch {
+	case cc.noStore:
+		checkCacheControlCounter.WithLabelValues(CacheControlNoStore).Inc()
+		ctx = graph.ContextWithoutCheckCache(ctx)
+	case cc.noCache:
+		checkCacheControlCounter.WithLabelValues(CacheControlNoCache).Inc()
+		ctx = graph.ContextWithCheckCacheBypass(ctx)
+	case cc.minFresh > 0:
+		checkCacheControlCounter.WithLabelValues(CacheControlMinFresh).Inc()
+		ctx = graph.ContextWithCheckCacheMinFresh(ctx, cc.minFresh)
+	}
+
+	return ctx, nil
+}
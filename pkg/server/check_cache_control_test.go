@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestParseCheckCacheControl(t *testing.T) {
+	tests := map[string]struct {
+		values   []string
+		expected checkCacheControl
+		err      bool
+	}{
+		"no_store":           {values: []string{"no-store"}, expected: checkCacheControl{noStore: true}},
+		"no_cache":           {values: []string{"No-Cache"}, expected: checkCacheControl{noCache: true}},
+		"min_fresh":          {values: []string{"min-fresh=30"}, expected: checkCacheControl{minFresh: 30 * time.Second}},
+		"several_directives": {values: []string{"no-cache, min-fresh=5", "min-fresh=10"}, expected: checkCacheControl{noCache: true, minFresh: 10 * time.Second}},
+		"empty_directives":   {values: []string{" , no-store,"}, expected: checkCacheControl{noStore: true}},
+		"unknown_directive":  {values: []string{"no-transform"}, err: true},
+		"min_fresh_without":  {values: []string{"min-fresh"}, err: true},
+		"min_fresh_negative": {values: []string{"min-fresh=-1"}, err: true},
+		"min_fresh_duration": {values: []string{"min-fresh=1s"}, err: true},
+		"no_cache_argument":  {values: []string{"no-cache=1"}, err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cc, err := parseCheckCacheControl(test.values)
+			if test.err {
+				require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, cc)
+		})
+	}
+}
+
+func TestCheckCacheControl(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+		WithCheckQueryCacheTTL(time.Minute),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "cache-control"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	write := func(writes, deletes bool) {
+		req := &openfgav1.WriteRequest{StoreId: storeID}
+		if writes {
+			req.Writes = &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tk}}
+		}
+		if deletes {
+			req.Deletes = &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk)}}
+		}
+		_, err := s.Write(ctx, req)
+		require.NoError(t, err)
+	}
+
+	check := func(cacheControl string) bool {
+		ctx := ctx
+		if cacheControl != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(CheckCacheControlHeader, cacheControl))
+		}
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		return resp.GetAllowed()
+	}
+
+	write(true, false)
+	require.True(t, check(""))
+
+	write(false, true)
+
+	// the cached result is returned, as it's still fresh for a second, but not for an hour
+	require.True(t, check(""))
+	require.True(t, check("min-fresh=1"))
+	require.False(t, check("min-fresh=3600"))
+
+	// the new result replaced the cached one
+	require.False(t, check(""))
+
+	write(true, false)
+
+	// with no-store, the cached result isn't used, and the new one isn't cached
+	require.True(t, check("no-store"))
+	require.False(t, check(""))
+
+	// with no-cache, it isn't used either, but the new one is cached
+	require.True(t, check("no-cache"))
+	require.True(t, check(""))
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(CheckCacheControlHeader, "max-age=0"))
+	_, err = s.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:  storeID,
+		TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+	})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+}
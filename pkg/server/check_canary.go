@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+const (
+	checkCanaryResultMatch      = "match"
+	checkCanaryResultDivergence = "divergence"
+	checkCanaryResultError      = "error"
+	checkCanaryResultSkipped    = "skipped"
+)
+
+var checkCanaryEvaluationsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "check_canary_evaluations_total",
+	Help:      "The total number of Check requests evaluated against the candidate model of their store, by result: 'match' if it is the result of the model of the request, 'divergence' if it isn't, 'error' if the evaluation failed, and 'skipped' if too many evaluations were in progress.",
+}, []string{"result"})
+
+// checkCanary evaluates the Check requests of the stores with a candidate model against it, in the
+// background, and reports the results that differ from those served to the requests.
+type checkCanary struct {
+	sampleRatio float64
+	timeout     time.Duration
+
+	// slots bounds the number of evaluations in progress
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newCheckCanary(sampleRatio float64, timeout time.Duration, maxConcurrentEvaluations int) *checkCanary {
+	return &checkCanary{
+		sampleRatio: sampleRatio,
+		timeout:     timeout,
+		slots:       make(chan struct{}, maxConcurrentEvaluations),
+	}
+}
+
+// sampled reports whether a request is evaluated against the candidate model.
+func (c *checkCanary) sampled() bool {
+	return c.sampleRatio >= 1 || rand.Float64() < c.sampleRatio
+}
+
+// wait waits for the evaluations in progress.
+func (c *checkCanary) wait() {
+	c.wg.Wait()
+}
+
+// evaluateCheckCanary evaluates the Check request against the candidate model of its store, if the
+// canary is enabled and the store has one, in the background. The request was resolved with the
+// model and the tuple reader, and allowed is its result.
+func (s *Server) evaluateCheckCanary(ctx context.Context, req *openfgav1.CheckRequest, typesys *typesystem.TypeSystem, ds storage.RelationshipTupleReader, allowed bool) {
+	if s.checkCanary == nil || graph.CheckTraceFromContext(ctx) != nil {
+		return
+	}
+
+	settings, err := s.GetStoreSettings(ctx, req.GetStoreId())
+	if err != nil {
+		return
+	}
+
+	candidateModelID := settings.CandidateAuthorizationModelID
+	if candidateModelID == "" || candidateModelID == typesys.GetAuthorizationModelID() || !s.checkCanary.sampled() {
+		return
+	}
+
+	select {
+	case s.checkCanary.slots <- struct{}{}:
+	default:
+		checkCanaryEvaluationsCounter.WithLabelValues(checkCanaryResultSkipped).Inc()
+		return
+	}
+
+	// the evaluation outlives the request, with its own timeout
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.checkCanary.timeout)
+
+	s.checkCanary.wg.Add(1)
+	go func() {
+		defer func() {
+			cancel()
+			<-s.checkCanary.slots
+			s.checkCanary.wg.Done()
+		}()
+
+		s.compareCheckCanary(ctx, req, typesys.GetAuthorizationModelID(), candidateModelID, ds, allowed)
+	}()
+}
+
+// compareCheckCanary resolves the Check request with the candidate model, and reports whether its
+// result matches the result of the model of the request.
+func (s *Server) compareCheckCanary(ctx context.Context, req *openfgav1.CheckRequest, modelID, candidateModelID string, ds storage.RelationshipTupleReader, allowed bool) {
+	ctx, span := tracer.Start(ctx, "CheckCanary", trace.WithAttributes(
+		attribute.String("store_id", req.GetStoreId()),
+		attribute.String("authorization_model_id", modelID),
+		attribute.String("candidate_authorization_model_id", candidateModelID),
+	))
+	defer span.End()
+
+	fields := []zap.Field{
+		zap.String("store_id", req.GetStoreId()),
+		zap.String("authorization_model_id", modelID),
+		zap.String("candidate_authorization_model_id", candidateModelID),
+		zap.String("tuple_key", tuple.TupleKeyToString(req.GetTupleKey())),
+	}
+
+	candidateAllowed, err := s.resolveCheckCanary(ctx, req, candidateModelID, ds)
+	if err != nil {
+		checkCanaryEvaluationsCounter.WithLabelValues(checkCanaryResultError).Inc()
+		span.SetAttributes(attribute.String("result", checkCanaryResultError))
+		s.logger.WarnWithContext(ctx, "failed to evaluate the Check request against the candidate model", append(fields, zap.Error(err))...)
+		return
+	}
+
+	if candidateAllowed == allowed {
+		checkCanaryEvaluationsCounter.WithLabelValues(checkCanaryResultMatch).Inc()
+		span.SetAttributes(attribute.String("result", checkCanaryResultMatch))
+		return
+	}
+
+	checkCanaryEvaluationsCounter.WithLabelValues(checkCanaryResultDivergence).Inc()
+	span.SetAttributes(attribute.String("result", checkCanaryResultDivergence))
+	s.logger.WarnWithContext(ctx, "the candidate model diverges from the model of the Check request",
+		append(fields, zap.Bool("allowed", allowed), zap.Bool("candidate_allowed", candidateAllowed))...)
+}
+
+// resolveCheckCanary resolves the Check request with the candidate model, without the check query
+// cache, so that the results of the candidate don't evict those of the model of the requests, and
+// without recording the relation usage, since no request is served with them.
+func (s *Server) resolveCheckCanary(ctx context.Context, req *openfgav1.CheckRequest, candidateModelID string, ds storage.RelationshipTupleReader) (bool, error) {
+	typesys, err := s.resolveTypesystem(ctx, req.GetStoreId(), candidateModelID)
+	if err != nil {
+		return false, err
+	}
+
+	ctx = graph.ContextWithoutCheckCache(typesystem.ContextWithTypesystem(ctx, typesys))
+	ctx = contextWithoutRelationUsage(ctx)
+	ctx = storage.ContextWithRelationshipTupleReader(ctx, storagewrappers.NewBoundedConcurrencyTupleReader(
+		storagewrappers.NewCombinedTupleReader(ds, req.GetContextualTuples().GetTupleKeys()),
+		s.maxConcurrentReadsForCheck,
+	))
+
+	checkRequestMetadata := graph.NewCheckRequestMetadata(s.resolveNodeLimit)
+	checkRequestMetadata.Budget = s.checkBudget
+
+	resp, err := s.checkResolver.ResolveCheck(ctx, &graph.ResolveCheckRequest{
+		StoreID:              req.GetStoreId(),
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		TupleKey:             tuple.ConvertCheckRequestTupleKeyToTupleKey(req.GetTupleKey()),
+		ContextualTuples:     req.GetContextualTuples().GetTupleKeys(),
+		Context:              req.GetContext(),
+		RequestMetadata:      checkRequestMetadata,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return resp.GetAllowed(), nil
+}
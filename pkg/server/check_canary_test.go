@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestCheckCanary(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	observerLogger, logs := observer.New(zap.WarnLevel)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithLogger(&logger.ZapLogger{Logger: zap.New(observerLogger)}),
+		WithCheckCanaryEnabled(true),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "canary"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	writeModel := func(dsl string) string {
+		model := language.MustTransformDSLToProto(dsl)
+		resp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		return resp.GetAuthorizationModelId()
+	}
+
+	// the candidate model lets the editors view the documents, but not the latest model
+	candidateModelID := writeModel(`model
+  schema 1.1
+type user
+type document
+  relations
+    define editor: [user]
+    define viewer: [user] or editor`)
+	latestModelID := writeModel(`model
+  schema 1.1
+type user
+type document
+  relations
+    define editor: [user]
+    define viewer: [user]`)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "editor", "user:anne")},
+		},
+	})
+	require.NoError(t, err)
+
+	results := func() map[string]float64 {
+		return map[string]float64{
+			checkCanaryResultMatch:      testutil.ToFloat64(checkCanaryEvaluationsCounter.WithLabelValues(checkCanaryResultMatch)),
+			checkCanaryResultDivergence: testutil.ToFloat64(checkCanaryEvaluationsCounter.WithLabelValues(checkCanaryResultDivergence)),
+			checkCanaryResultError:      testutil.ToFloat64(checkCanaryEvaluationsCounter.WithLabelValues(checkCanaryResultError)),
+		}
+	}
+
+	check := func(user string) bool {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", user),
+		})
+		require.NoError(t, err)
+		s.checkCanary.wait()
+		return resp.GetAllowed()
+	}
+
+	t.Run("without_candidate_model", func(t *testing.T) {
+		before := results()
+		require.False(t, check("user:anne"))
+		require.Equal(t, before, results())
+	})
+
+	err = s.UpdateStoreSettings(ctx, storeID, &storage.StoreSettings{CandidateAuthorizationModelID: candidateModelID})
+	require.NoError(t, err)
+
+	t.Run("divergence", func(t *testing.T) {
+		before := results()
+
+		// the result of the latest model is served
+		require.False(t, check("user:anne"))
+
+		after := results()
+		require.InDelta(t, 1, after[checkCanaryResultDivergence]-before[checkCanaryResultDivergence], 0)
+		require.Equal(t, before[checkCanaryResultMatch], after[checkCanaryResultMatch])
+
+		diverged := logs.FilterMessage("the candidate model diverges from the model of the Check request").All()
+		require.Len(t, diverged, 1)
+		fields := diverged[0].ContextMap()
+		require.Equal(t, storeID, fields["store_id"])
+		require.Equal(t, latestModelID, fields["authorization_model_id"])
+		require.Equal(t, candidateModelID, fields["candidate_authorization_model_id"])
+		require.Equal(t, false, fields["allowed"])
+		require.Equal(t, true, fields["candidate_allowed"])
+	})
+
+	t.Run("match", func(t *testing.T) {
+		before := results()
+		require.False(t, check("user:bob"))
+
+		after := results()
+		require.InDelta(t, 1, after[checkCanaryResultMatch]-before[checkCanaryResultMatch], 0)
+		require.Equal(t, before[checkCanaryResultDivergence], after[checkCanaryResultDivergence])
+	})
+
+	t.Run("request_of_the_candidate_model", func(t *testing.T) {
+		before := results()
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              storeID,
+			AuthorizationModelId: candidateModelID,
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		s.checkCanary.wait()
+		require.Equal(t, before, results())
+	})
+}
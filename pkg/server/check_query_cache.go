@@ -0,0 +1,33 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// CheckQueryCacheFlushResult is the response of the handler of CheckQueryCacheFlushHandler.
+type CheckQueryCacheFlushResult struct {
+	// StoreID is the store whose cached Check sub-problems were deleted, or empty for every store.
+	StoreID string `json:"store_id,omitempty"`
+
+	// Size is the number of entries of the Check query cache after the flush.
+	Size int `json:"size"`
+}
+
+// CheckQueryCacheFlushHandler returns the handler that flushes the Check query cache with a POST
+// request, of the store of its 'store_id' query parameter if any, or else of every store.
+func (s *Server) CheckQueryCacheFlushHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		storeID := r.URL.Query().Get("store_id")
+		s.FlushCheckQueryCache(storeID)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CheckQueryCacheFlushResult{StoreID: storeID, Size: s.CheckQueryCacheSize()})
+	})
+}
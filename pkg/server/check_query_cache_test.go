@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestCheckQueryCacheFlushHandler(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+	)
+	t.Cleanup(s.Close)
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type group
+  relations
+    define member: [user]
+type document
+  relations
+    define viewer: [group#member]`)
+
+	check := func(t *testing.T) string {
+		store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "flush"})
+		require.NoError(t, err)
+
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         store.GetId(),
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+
+		_, err = s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: store.GetId(),
+			Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+				tuple.NewTupleKey("group:eng", "member", "user:anne"),
+			}},
+		})
+		require.NoError(t, err)
+
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  store.GetId(),
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		return store.GetId()
+	}
+
+	flush := func(t *testing.T, target string) CheckQueryCacheFlushResult {
+		rec := httptest.NewRecorder()
+		s.CheckQueryCacheFlushHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var result CheckQueryCacheFlushResult
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+		return result
+	}
+
+	t.Run("flushes_a_store", func(t *testing.T) {
+		first := check(t)
+		cached := s.CheckQueryCacheSize()
+		require.Positive(t, cached)
+
+		second := check(t)
+		require.Greater(t, s.CheckQueryCacheSize(), cached)
+
+		result := flush(t, "/cache/flush?store_id="+first)
+		require.Equal(t, first, result.StoreID)
+		require.Equal(t, cached, result.Size)
+
+		result = flush(t, "/cache/flush?store_id="+second)
+		require.Zero(t, result.Size)
+	})
+
+	t.Run("flushes_every_store", func(t *testing.T) {
+		check(t)
+		check(t)
+		require.Positive(t, s.CheckQueryCacheSize())
+
+		result := flush(t, "/cache/flush")
+		require.Empty(t, result.StoreID)
+		require.Zero(t, result.Size)
+		require.Zero(t, s.CheckQueryCacheSize())
+	})
+
+	t.Run("rejects_other_methods", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.CheckQueryCacheFlushHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cache/flush", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		require.Equal(t, "POST", rec.Header().Get("Allow"))
+	})
+}
+
+func TestCheckQueryCacheWatermark(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	_, err := NewServerWithOpts(WithDatastore(ds), WithCheckQueryCacheEnabled(true),
+		WithCheckQueryCacheWatermarkEnabled(true), WithCheckQueryCacheWatermarkRefreshInterval(0))
+	require.ErrorContains(t, err, "watermark refresh interval")
+
+	// the servers share the datastore, and cache the checks for longer than the test
+	servers := make([]*Server, 2)
+	for i := range servers {
+		servers[i] = MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithCheckQueryCacheEnabled(true),
+			WithCheckQueryCacheTTL(time.Hour),
+			WithCheckQueryCacheWatermarkEnabled(true),
+			WithCheckQueryCacheWatermarkRefreshInterval(50*time.Millisecond),
+		)
+		t.Cleanup(servers[i].Close)
+	}
+
+	store, err := servers[0].CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "watermark"})
+	require.NoError(t, err)
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = servers[0].WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	check := func(t *testing.T, s *Server) bool {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  store.GetId(),
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		return resp.GetAllowed()
+	}
+
+	require.False(t, check(t, servers[0]))
+	require.False(t, check(t, servers[1]))
+
+	_, err = servers[0].Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store.GetId(),
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+		},
+	})
+	require.NoError(t, err)
+
+	// the server that wrote the tuple sees it right away, and the other one once it reads the changelog
+	require.True(t, check(t, servers[0]))
+	require.Eventually(t, func() bool {
+		return check(t, servers[1])
+	}, time.Second, 10*time.Millisecond)
+}
@@ -2,18 +2,28 @@ package commands
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
-	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.uber.org/zap"
 
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/idgen"
 	"github.com/openfga/openfga/pkg/storage"
 )
 
+// maxGeneratedIDAttempts is how many IDs are generated for a store or an authorization model while
+// they collide with the IDs of existing ones.
+const maxGeneratedIDAttempts = 3
+
 type CreateStoreCommand struct {
 	storesBackend storage.StoresBackend
 	logger        logger.Logger
+	labels        map[string]string
+	idGenerator   idgen.Generator
+	id            string
 }
 
 type CreateStoreCmdOption func(*CreateStoreCommand)
@@ -24,6 +34,29 @@ func WithCreateStoreCmdLogger(l logger.Logger) CreateStoreCmdOption {
 	}
 }
 
+// WithCreateStoreCmdLabels sets the labels of the created store.
+func WithCreateStoreCmdLabels(labels map[string]string) CreateStoreCmdOption {
+	return func(c *CreateStoreCommand) {
+		c.labels = labels
+	}
+}
+
+// WithCreateStoreCmdIDGenerator sets the generator of the ID of the created store.
+func WithCreateStoreCmdIDGenerator(generator idgen.Generator) CreateStoreCmdOption {
+	return func(c *CreateStoreCommand) {
+		c.idGenerator = generator
+	}
+}
+
+// WithCreateStoreCmdID sets the ID of the created store instead of generating it, e.g. so that a store
+// has the same ID in every environment. If the ID is the ID of an existing or deleted store, the
+// command fails. An empty ID is generated.
+func WithCreateStoreCmdID(id string) CreateStoreCmdOption {
+	return func(c *CreateStoreCommand) {
+		c.id = id
+	}
+}
+
 func NewCreateStoreCommand(
 	storesBackend storage.StoresBackend,
 	opts ...CreateStoreCmdOption,
@@ -31,6 +64,7 @@ func NewCreateStoreCommand(
 	cmd := &CreateStoreCommand{
 		storesBackend: storesBackend,
 		logger:        logger.NewNoopLogger(),
+		idGenerator:   idgen.Default(),
 	}
 
 	for _, opt := range opts {
@@ -40,12 +74,43 @@ func NewCreateStoreCommand(
 }
 
 func (s *CreateStoreCommand) Execute(ctx context.Context, req *openfgav1.CreateStoreRequest) (*openfgav1.CreateStoreResponse, error) {
-	store, err := s.storesBackend.CreateStore(ctx, &openfgav1.Store{
-		Id:   ulid.Make().String(),
-		Name: req.GetName(),
-	})
-	if err != nil {
-		return nil, serverErrors.HandleError("", err)
+	var store *openfgav1.Store
+	create := func(id string) error {
+		var err error
+		store, err = s.storesBackend.CreateStore(ctx, &openfgav1.Store{
+			Id:   id,
+			Name: req.GetName(),
+		})
+		return err
+	}
+
+	if s.id != "" {
+		if err := idgen.Validate(s.id); err != nil {
+			return nil, serverErrors.ValidationError(fmt.Errorf("invalid store ID: %w", err))
+		}
+
+		err := create(s.id)
+		if errors.Is(err, storage.ErrCollision) {
+			return nil, serverErrors.StoreIDAlreadyExists(s.id)
+		}
+		if err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+	} else {
+		id, err := s.idGenerator.NewStoreID(ctx)
+		if err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+
+		if err := writeWithGeneratedID(ctx, s.logger, id, s.idGenerator.NewStoreID, create); err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+	}
+
+	if len(s.labels) > 0 {
+		if err := s.storesBackend.WriteStoreLabels(ctx, store.GetId(), s.labels); err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
 	}
 
 	return &openfgav1.CreateStoreResponse{
@@ -55,3 +120,21 @@ func (s *CreateStoreCommand) Execute(ctx context.Context, req *openfgav1.CreateS
 		UpdatedAt: store.GetUpdatedAt(),
 	}, nil
 }
+
+// writeWithGeneratedID calls write with the generated ID, and while write fails with
+// [storage.ErrCollision], with another ID of generate, up to maxGeneratedIDAttempts times. A generated
+// ID seldom collides, e.g. with the caller-supplied ID of a store.
+func writeWithGeneratedID(ctx context.Context, l logger.Logger, id string, generate func(context.Context) (string, error), write func(id string) error) error {
+	for attempt := 1; ; attempt++ {
+		err := write(id)
+		if !errors.Is(err, storage.ErrCollision) || attempt == maxGeneratedIDAttempts {
+			return err
+		}
+
+		l.WarnWithContext(ctx, "generated ID collided with an existing one, generating another", zap.String("id", id))
+
+		if id, err = generate(ctx); err != nil {
+			return err
+		}
+	}
+}
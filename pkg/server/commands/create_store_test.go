@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockstorage "github.com/openfga/openfga/internal/mocks"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// sequenceGenerator generates its IDs in order.
+type sequenceGenerator struct {
+	ids []string
+}
+
+func (g *sequenceGenerator) next() (string, error) {
+	id := g.ids[0]
+	g.ids = g.ids[1:]
+	return id, nil
+}
+
+func (g *sequenceGenerator) NewStoreID(context.Context) (string, error) {
+	return g.next()
+}
+
+func (g *sequenceGenerator) NewAuthorizationModelID(context.Context, string) (string, error) {
+	return g.next()
+}
+
+func TestCreateStoreCommand(t *testing.T) {
+	ctx := context.Background()
+
+	newDatastore := func(t *testing.T) *mockstorage.MockOpenFGADatastore {
+		mockController := gomock.NewController(t)
+		t.Cleanup(mockController.Finish)
+		return mockstorage.NewMockOpenFGADatastore(mockController)
+	}
+
+	storeWithID := func(id string) *openfgav1.Store {
+		return &openfgav1.Store{Id: id, Name: "store"}
+	}
+
+	t.Run("generated_id", func(t *testing.T) {
+		id := ulid.Make().String()
+		mockDatastore := newDatastore(t)
+		mockDatastore.EXPECT().CreateStore(gomock.Any(), storeWithID(id)).Return(storeWithID(id), nil)
+
+		cmd := NewCreateStoreCommand(mockDatastore, WithCreateStoreCmdIDGenerator(&sequenceGenerator{ids: []string{id}}))
+		resp, err := cmd.Execute(ctx, &openfgav1.CreateStoreRequest{Name: "store"})
+		require.NoError(t, err)
+		require.Equal(t, id, resp.GetId())
+	})
+
+	t.Run("colliding_generated_ids_are_generated_again", func(t *testing.T) {
+		ids := []string{ulid.Make().String(), ulid.Make().String()}
+		mockDatastore := newDatastore(t)
+		gomock.InOrder(
+			mockDatastore.EXPECT().CreateStore(gomock.Any(), storeWithID(ids[0])).Return(nil, storage.ErrCollision),
+			mockDatastore.EXPECT().CreateStore(gomock.Any(), storeWithID(ids[1])).Return(storeWithID(ids[1]), nil),
+		)
+
+		cmd := NewCreateStoreCommand(mockDatastore, WithCreateStoreCmdIDGenerator(&sequenceGenerator{ids: ids}))
+		resp, err := cmd.Execute(ctx, &openfgav1.CreateStoreRequest{Name: "store"})
+		require.NoError(t, err)
+		require.Equal(t, ids[1], resp.GetId())
+	})
+
+	t.Run("generated_ids_are_generated_up_to_the_maximum_attempts", func(t *testing.T) {
+		mockDatastore := newDatastore(t)
+		mockDatastore.EXPECT().CreateStore(gomock.Any(), gomock.Any()).Times(maxGeneratedIDAttempts).Return(nil, storage.ErrCollision)
+
+		_, err := NewCreateStoreCommand(mockDatastore).Execute(ctx, &openfgav1.CreateStoreRequest{Name: "store"})
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonInternalError, reason)
+	})
+
+	t.Run("caller_supplied_id", func(t *testing.T) {
+		id := ulid.Make().String()
+		mockDatastore := newDatastore(t)
+		mockDatastore.EXPECT().CreateStore(gomock.Any(), storeWithID(id)).Return(storeWithID(id), nil)
+
+		resp, err := NewCreateStoreCommand(mockDatastore, WithCreateStoreCmdID(id)).Execute(ctx, &openfgav1.CreateStoreRequest{Name: "store"})
+		require.NoError(t, err)
+		require.Equal(t, id, resp.GetId())
+	})
+
+	t.Run("colliding_caller_supplied_id", func(t *testing.T) {
+		id := ulid.Make().String()
+		mockDatastore := newDatastore(t)
+		mockDatastore.EXPECT().CreateStore(gomock.Any(), storeWithID(id)).Return(nil, storage.ErrCollision)
+
+		_, err := NewCreateStoreCommand(mockDatastore, WithCreateStoreCmdID(id)).Execute(ctx, &openfgav1.CreateStoreRequest{Name: "store"})
+		require.Equal(t, serverErrors.StoreIDAlreadyExists(id), err)
+	})
+
+	t.Run("invalid_caller_supplied_id", func(t *testing.T) {
+		_, err := NewCreateStoreCommand(newDatastore(t), WithCreateStoreCmdID("my-store")).Execute(ctx, &openfgav1.CreateStoreRequest{Name: "store"})
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonValidationError, reason)
+	})
+}
@@ -3,22 +3,31 @@ package commands
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/openfga/openfga/internal/condition"
+	"github.com/openfga/openfga/internal/condition/eval"
+	"github.com/openfga/openfga/internal/graph"
 	"github.com/openfga/openfga/internal/validation"
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
 	"github.com/openfga/openfga/pkg/typesystem"
 )
 
 // ExpandQuery resolves a target TupleKey into a UsersetTree by expanding type definitions.
 type ExpandQuery struct {
-	logger    logger.Logger
-	datastore storage.OpenFGADatastore
+	logger           logger.Logger
+	datastore        storage.OpenFGADatastore
+	tupleReader      storage.RelationshipTupleReader
+	contextualTuples []*openfgav1.TupleKey
+	context          *structpb.Struct
 }
 
 type ExpandQueryOption func(*ExpandQuery)
@@ -29,6 +38,24 @@ func WithExpandQueryLogger(l logger.Logger) ExpandQueryOption {
 	}
 }
 
+// WithExpandQueryContextualTuples sets tuples that the tree is expanded with as if they were written,
+// like the contextual tuples of a Check.
+func WithExpandQueryContextualTuples(contextualTuples []*openfgav1.TupleKey) ExpandQueryOption {
+	return func(eq *ExpandQuery) {
+		eq.contextualTuples = contextualTuples
+	}
+}
+
+// WithExpandQueryContext sets the context that the conditions of the tuples are evaluated with, like
+// the context of a Check. The tuples whose condition isn't met are left out of the tree, and a tuple
+// whose condition misses parameters of the context fails the request. Without a context, the
+// conditions aren't evaluated and all the tuples are in the tree.
+func WithExpandQueryContext(context *structpb.Struct) ExpandQueryOption {
+	return func(eq *ExpandQuery) {
+		eq.context = context
+	}
+}
+
 // NewExpandQuery creates a new ExpandQuery using the supplied backends for retrieving data.
 func NewExpandQuery(datastore storage.OpenFGADatastore, opts ...ExpandQueryOption) *ExpandQuery {
 	eq := &ExpandQuery{
@@ -39,6 +66,8 @@ func NewExpandQuery(datastore storage.OpenFGADatastore, opts ...ExpandQueryOptio
 	for _, opt := range opts {
 		opt(eq)
 	}
+
+	eq.tupleReader = storagewrappers.NewCombinedTupleReader(datastore, eq.contextualTuples)
 	return eq
 }
 
@@ -82,6 +111,12 @@ func (q *ExpandQuery) Execute(ctx context.Context, req *openfgav1.ExpandRequest)
 		return nil, serverErrors.ValidationError(err)
 	}
 
+	for i, ctxTuple := range q.contextualTuples {
+		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
+			return nil, serverErrors.InvalidContextualTuple(i, err)
+		}
+	}
+
 	objectType := tupleUtils.GetType(object)
 	rel, err := typesys.GetRelation(objectType, relation)
 	if err != nil {
@@ -143,7 +178,7 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 	ctx, span := tracer.Start(ctx, "resolveThis")
 	defer span.End()
 
-	tupleIter, err := q.datastore.Read(ctx, store, tk)
+	tupleIter, err := q.tupleReader.Read(ctx, store, tk)
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
@@ -163,6 +198,15 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 			}
 			return nil, serverErrors.HandleError("", err)
 		}
+
+		conditionMet, err := q.evaluateCondition(ctx, tk, typesys)
+		if err != nil {
+			return nil, err
+		}
+		if !conditionMet {
+			continue
+		}
+
 		distinctUsers[tk.GetUser()] = true
 	}
 
@@ -185,6 +229,28 @@ func (q *ExpandQuery) resolveThis(ctx context.Context, store string, tk *openfga
 	}, nil
 }
 
+// evaluateCondition reports whether the condition of the tuple is met in the context of the query. It
+// is always met without a context, see WithExpandQueryContext.
+func (q *ExpandQuery) evaluateCondition(ctx context.Context, tk *openfgav1.TupleKey, typesys *typesystem.TypeSystem) (bool, error) {
+	if q.context == nil {
+		return true, nil
+	}
+
+	result, err := eval.EvaluateTupleCondition(ctx, tk, typesys, q.context)
+	if err != nil {
+		return false, serverErrors.ValidationError(err)
+	}
+
+	if len(result.MissingParameters) > 0 {
+		return false, serverErrors.ValidationError(condition.NewEvaluationError(
+			tk.GetCondition().GetName(),
+			fmt.Errorf("tuple '%s' is missing context parameters '%v'", tupleUtils.TupleKeyToString(tk), result.MissingParameters),
+		))
+	}
+
+	return result.ConditionMet, nil
+}
+
 // resolveComputedUserset builds a leaf node containing the result of resolving a ComputedUserset rewrite.
 func (q *ExpandQuery) resolveComputedUserset(ctx context.Context, userset *openfgav1.ObjectRelation, tk *openfgav1.TupleKey) (*openfgav1.UsersetTree_Node, error) {
 	_, span := tracer.Start(ctx, "resolveComputedUserset")
@@ -253,7 +319,7 @@ func (q *ExpandQuery) resolveTupleToUserset(
 		tsKey.Relation = tk.GetRelation()
 	}
 
-	tupleIter, err := q.datastore.Read(ctx, store, tsKey)
+	tupleIter, err := q.tupleReader.Read(ctx, store, tsKey)
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
@@ -274,6 +340,15 @@ func (q *ExpandQuery) resolveTupleToUserset(
 			}
 			return nil, serverErrors.HandleError("", err)
 		}
+
+		conditionMet, err := q.evaluateCondition(ctx, tk, typesys)
+		if err != nil {
+			return nil, err
+		}
+		if !conditionMet {
+			continue
+		}
+
 		user := tk.GetUser()
 
 		tObject, tRelation := tupleUtils.SplitObjectRelation(user)
@@ -404,7 +479,9 @@ func (q *ExpandQuery) resolveUsersets(
 	for i, us := range usersets {
 		// https://golang.org/doc/faq#closures_and_goroutines
 		i, us := i, us
-		grp.Go(func() error {
+		grp.Go(func() (err error) {
+			defer graph.RecoverPanic(ctx, "expand", &err)
+
 			node, err := q.resolveUserset(ctx, store, us, tk, typesys)
 			if err != nil {
 				return err
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+// highWaterMarkPrefix starts the continuation tokens of ReadChanges that carry a high-water mark. It
+// never starts the tokens of the datastores, or the tokens bound to filters.
+const highWaterMarkPrefix = "@"
+
+// markContinuationToken prefixes the continuation token with its high-water mark, the time of the
+// last change it was returned for, so that it can be rejected once it is older than the changelog
+// retention.
+func markContinuationToken(token []byte, highWaterMark time.Time) []byte {
+	if len(token) == 0 {
+		return token
+	}
+
+	return append([]byte(highWaterMarkPrefix+strconv.FormatInt(highWaterMark.UnixMilli(), 10)+":"), token...)
+}
+
+// unmarkContinuationToken returns the continuation token of a token returned by
+// markContinuationToken, and its high-water mark. The tokens returned before they had a mark are
+// returned unchanged, with a zero high-water mark.
+func unmarkContinuationToken(token string) (string, time.Time, error) {
+	marked, ok := strings.CutPrefix(token, highWaterMarkPrefix)
+	if !ok {
+		return token, time.Time{}, nil
+	}
+
+	millis, unmarked, ok := strings.Cut(marked, ":")
+	if !ok {
+		return "", time.Time{}, serverErrors.InvalidContinuationToken
+	}
+
+	highWaterMark, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return "", time.Time{}, serverErrors.InvalidContinuationToken
+	}
+
+	return unmarked, time.UnixMilli(highWaterMark), nil
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+// filterHashLength is the length of the hash of the filters that prefixes a continuation token of
+// a filtered query.
+const filterHashLength = 16
+
+// hashFilter returns the hash of the values of the filters of a query, or "" if they are all empty.
+func hashFilter(values ...string) string {
+	empty := true
+	h := sha256.New()
+	for _, value := range values {
+		if value != "" {
+			empty = false
+		}
+
+		// the values are length-prefixed, so that ("ab", "") and ("a", "b") have different hashes
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(value))))
+		h.Write([]byte(value))
+	}
+
+	if empty {
+		return ""
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:filterHashLength]
+}
+
+// bindContinuationToken prefixes the continuation token of the datastore with the hash of the
+// filters it was returned for, if any. The tokens of the queries without filters are unchanged,
+// so that the tokens returned before the filters existed stay valid.
+func bindContinuationToken(token []byte, filterHash string) []byte {
+	if len(token) == 0 || filterHash == "" {
+		return token
+	}
+
+	return append([]byte(filterHash+":"), token...)
+}
+
+// unbindContinuationToken returns the continuation token of the datastore of a token returned by
+// bindContinuationToken. It returns an InvalidContinuationToken error if the token was returned
+// for other filters.
+func unbindContinuationToken(token, filterHash string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+
+	if filterHash == "" {
+		if isBoundContinuationToken(token) {
+			return "", serverErrors.InvalidContinuationToken
+		}
+		return token, nil
+	}
+
+	unbound, ok := strings.CutPrefix(token, filterHash+":")
+	if !ok {
+		return "", serverErrors.InvalidContinuationToken
+	}
+
+	return unbound, nil
+}
+
+// isBoundContinuationToken reports whether the token is prefixed with the hash of filters.
+func isBoundContinuationToken(token string) bool {
+	if len(token) <= filterHashLength || token[filterHashLength] != ':' {
+		return false
+	}
+
+	_, err := hex.DecodeString(token[:filterHashLength])
+	return err == nil
+}
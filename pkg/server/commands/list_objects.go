@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +24,7 @@ import (
 	"github.com/openfga/openfga/internal/graph"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/encoder"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/server/commands/reverseexpand"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
@@ -53,28 +58,107 @@ type ListObjectsQuery struct {
 	resolveNodeLimit        uint32
 	resolveNodeBreadthLimit uint32
 	maxConcurrentReads      uint32
+	shards                  uint32
+	shardConcurrency        uint32
+	shard                   ListObjectsShard
+	continuationToken       string
+	partialResults          bool
+	clientID                string
+	encoder                 encoder.Encoder
+
+	// candidates are the IDs of the objects checked instead of those found by reverse expansion, by
+	// their position, see WithListObjectsCandidates
+	candidates                 map[string]int
+	candidatesMaxTuplesPerRead int
 
 	checkResolver graph.CheckResolver
 }
 
+// ListObjectsShard is a partition of the objects of a ListObjects request, by the hash of their
+// IDs. The zero value is the whole request.
+type ListObjectsShard struct {
+	Index uint32
+	Count uint32
+}
+
+// ParseListObjectsShard parses a shard written as 'index/count', e.g. '0/4' for the first of four
+// shards.
+func ParseListObjectsShard(s string) (ListObjectsShard, error) {
+	index, count, ok := strings.Cut(s, "/")
+	if !ok {
+		return ListObjectsShard{}, fmt.Errorf("the shard '%s' must be written as 'index/count'", s)
+	}
+
+	i, err := strconv.ParseUint(index, 10, 32)
+	if err != nil {
+		return ListObjectsShard{}, fmt.Errorf("the index of the shard '%s' must be a number", s)
+	}
+
+	n, err := strconv.ParseUint(count, 10, 32)
+	if err != nil || n == 0 {
+		return ListObjectsShard{}, fmt.Errorf("the count of the shard '%s' must be a positive number", s)
+	}
+
+	if i >= n {
+		return ListObjectsShard{}, fmt.Errorf("the index of the shard '%s' must be lower than its count", s)
+	}
+
+	return ListObjectsShard{Index: uint32(i), Count: uint32(n)}, nil
+}
+
+func (s ListObjectsShard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// contains reports whether the object belongs to the shard.
+func (s ListObjectsShard) contains(object string) bool {
+	return s.Count == 0 || objectShard(object, s.Count) == s.Index
+}
+
+// objectShard returns the shard of an object among count shards, by the FNV-1a hash of its ID.
+func objectShard(object string, count uint32) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(object))
+	return h.Sum32() % count
+}
+
 type ListObjectsResolutionMetadata struct {
 	// The total number of database reads from reverse_expand and Check (if any) to complete the ListObjects request
 	DatastoreQueryCount *uint32
 
 	// The total number of dispatches aggregated from reverse_expand and check resolutions (if any) to complete the ListObjects request
 	DispatchCount *uint32
+
+	// The total number of Check subproblems resolved from the Check query cache to complete the ListObjects request
+	CacheHitCount *uint32
+
+	// The total time (in nanoseconds) that Check dispatches spent waiting in the dispatch throttling queue
+	ThrottlingDuration *int64
+
+	// Whether the deadline was hit before all the objects were resolved
+	DeadlineExceeded *atomic.Bool
 }
 
 func NewListObjectsResolutionMetadata() *ListObjectsResolutionMetadata {
 	return &ListObjectsResolutionMetadata{
 		DatastoreQueryCount: new(uint32),
 		DispatchCount:       new(uint32),
+		CacheHitCount:       new(uint32),
+		ThrottlingDuration:  new(int64),
+		DeadlineExceeded:    new(atomic.Bool),
 	}
 }
 
 type ListObjectsResponse struct {
 	Objects            []string
 	ResolutionMetadata ListObjectsResolutionMetadata
+
+	// ContinuationToken is set when the ListObjects request of a shard has more results.
+	ContinuationToken string
+
+	// Truncated is set when the deadline was hit before all the objects were resolved, so that
+	// Objects are only those found until then. It is only set with WithListObjectsPartialResults.
+	Truncated bool
 }
 
 type ListObjectsQueryOption func(d *ListObjectsQuery)
@@ -118,6 +202,80 @@ func WithMaxConcurrentReads(limit uint32) ListObjectsQueryOption {
 	}
 }
 
+// WithListObjectsShards sets the number of worker pools that the objects needing a Check are
+// partitioned into by the hash of their IDs. See server.WithListObjectsShards.
+func WithListObjectsShards(shards uint32) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.shards = shards
+	}
+}
+
+// WithListObjectsShardConcurrency sets the number of concurrent Checks of each worker pool. If 0,
+// it is the resolve node breadth limit.
+func WithListObjectsShardConcurrency(concurrency uint32) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.shardConcurrency = concurrency
+	}
+}
+
+// WithListObjectsShard restricts the results to the objects of a shard. The results of a shard are
+// sorted and paginated by Execute, with the listObjectsMaxResults per page.
+func WithListObjectsShard(shard ListObjectsShard) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.shard = shard
+	}
+}
+
+// WithListObjectsContinuationToken sets the continuation token of the next page of the results of
+// a shard.
+func WithListObjectsContinuationToken(token string) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.continuationToken = token
+	}
+}
+
+// WithListObjectsPartialResults makes Execute return the objects found until the deadline is hit,
+// with the Truncated flag of the response, instead of failing the request of a shard, whose page
+// can't be known until all its objects are. Requests without a shard return the objects found
+// until the deadline either way, but only set the flag with this option.
+func WithListObjectsPartialResults(enabled bool) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.partialResults = enabled
+	}
+}
+
+// WithListObjectsClientID sets the client ID of the authenticated caller of the request, which is
+// propagated to the Checks of the candidate objects, see graph.ResolveCheckRequestMetadata.
+func WithListObjectsClientID(clientID string) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.clientID = clientID
+	}
+}
+
+// WithListObjectsCandidates restricts the results to the objects of the type of the request with the
+// given IDs, e.g. the results of a search index, and returns them in the order of the IDs, except the
+// results of a shard, which are sorted. The candidates are checked instead of being found by reverse
+// expansion, which is cheaper when they are few, and their Checks share the memoized reads of the
+// datastore of up to maxTuplesPerRead tuples (see storagewrappers.NewDeduplicatingTupleReader), e.g.
+// those of the groups that grant access to many of them.
+func WithListObjectsCandidates(ids []string, maxTuplesPerRead int) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.candidates = make(map[string]int, len(ids))
+		for _, id := range ids {
+			if _, ok := d.candidates[id]; !ok {
+				d.candidates[id] = len(d.candidates)
+			}
+		}
+		d.candidatesMaxTuplesPerRead = maxTuplesPerRead
+	}
+}
+
+func WithListObjectsQueryEncoder(e encoder.Encoder) ListObjectsQueryOption {
+	return func(d *ListObjectsQuery) {
+		d.encoder = e
+	}
+}
+
 func NewListObjectsQuery(
 	ds storage.RelationshipTupleReader,
 	checkResolver graph.CheckResolver,
@@ -139,6 +297,9 @@ func NewListObjectsQuery(
 		resolveNodeLimit:        serverconfig.DefaultResolveNodeLimit,
 		resolveNodeBreadthLimit: serverconfig.DefaultResolveNodeBreadthLimit,
 		maxConcurrentReads:      serverconfig.DefaultMaxConcurrentReadsForListObjects,
+		shards:                  serverconfig.DefaultListObjectsShards,
+		shardConcurrency:        serverconfig.DefaultListObjectsShardConcurrency,
+		encoder:                 encoder.NewBase64Encoder(),
 		checkResolver:           checkResolver,
 	}
 
@@ -146,6 +307,14 @@ func NewListObjectsQuery(
 		opt(query)
 	}
 
+	if query.shards == 0 {
+		query.shards = 1
+	}
+
+	if query.shardConcurrency == 0 {
+		query.shardConcurrency = query.resolveNodeBreadthLimit
+	}
+
 	query.datastore = storagewrappers.NewBoundedConcurrencyTupleReader(query.datastore, query.maxConcurrentReads)
 
 	return query, nil
@@ -173,6 +342,9 @@ type listObjectsRequest interface {
 // [[reverseexpand.ReverseExpand#Execute]] and resolving the results yielded
 // from it. If any results yielded by reverse expansion require further eval,
 // then these results get dispatched to Check to resolve the residual outcome.
+// The Checks are run by the worker pool of the shard of their object, so that
+// the objects of a wide type are resolved by q.shards pools concurrently. If
+// the query is restricted to a shard, the objects of other shards are skipped.
 //
 // The resultsChan is **always** closed by evaluate when it is done with its work,
 // which is either when all results have been yielded, the deadline has been met,
@@ -196,9 +368,9 @@ func (q *ListObjectsQuery) evaluate(
 		return serverErrors.ValidationError(typesystem.ErrInvalidSchemaVersion)
 	}
 
-	for _, ctxTuple := range req.GetContextualTuples().GetTupleKeys() {
+	for i, ctxTuple := range req.GetContextualTuples().GetTupleKeys() {
 		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
-			return serverErrors.HandleTupleValidateError(err)
+			return serverErrors.InvalidContextualTuple(i, err)
 		}
 	}
 
@@ -247,10 +419,13 @@ func (q *ListObjectsQuery) evaluate(
 		reverseExpandResultsChan := make(chan *reverseexpand.ReverseExpandResult, 1)
 		objectsFound := atomic.Uint32{}
 
-		ds := storagewrappers.NewCombinedTupleReader(
+		var ds storage.RelationshipTupleReader = storagewrappers.NewCombinedTupleReader(
 			q.datastore,
 			req.GetContextualTuples().GetTupleKeys(),
 		)
+		if q.candidates != nil {
+			ds = storagewrappers.NewDeduplicatingTupleReader(ds, q.candidatesMaxTuplesPerRead)
+		}
 
 		reverseExpandQuery := reverseexpand.NewReverseExpandQuery(
 			ds,
@@ -272,6 +447,11 @@ func (q *ListObjectsQuery) evaluate(
 		go func() {
 			defer wg.Done()
 
+			if q.candidates != nil {
+				sendCandidates(cancelCtx, targetObjectType, q.candidates, reverseExpandResultsChan)
+				return
+			}
+
 			err := reverseExpandQuery.Execute(cancelCtx, &reverseexpand.ReverseExpandRequest{
 				StoreID:          req.GetStoreId(),
 				ObjectType:       targetObjectType,
@@ -290,12 +470,16 @@ func (q *ListObjectsQuery) evaluate(
 		ctx = typesystem.ContextWithTypesystem(ctx, typesys)
 		ctx := storage.ContextWithRelationshipTupleReader(ctx, ds)
 
-		concurrencyLimiterCh := make(chan struct{}, q.resolveNodeBreadthLimit)
+		concurrencyLimiters := make([]chan struct{}, q.shards)
+		for i := range concurrencyLimiters {
+			concurrencyLimiters[i] = make(chan struct{}, q.shardConcurrency)
+		}
 
 	ConsumerReadLoop:
 		for {
 			select {
 			case <-ctx.Done():
+				resolutionMetadata.DeadlineExceeded.Store(true)
 				break ConsumerReadLoop
 			case res, channelOpen := <-reverseExpandResultsChan:
 				if !channelOpen {
@@ -306,6 +490,10 @@ func (q *ListObjectsQuery) evaluate(
 					break ConsumerReadLoop
 				}
 
+				if !q.shard.contains(res.Object) {
+					continue
+				}
+
 				if res.ResultStatus == reverseexpand.NoFurtherEvalStatus {
 					noFurtherEvalRequiredCounter.Inc()
 					trySendObject(res.Object, &objectsFound, maxResults, resultsChan)
@@ -314,6 +502,8 @@ func (q *ListObjectsQuery) evaluate(
 
 				furtherEvalRequiredCounter.Inc()
 
+				concurrencyLimiterCh := concurrencyLimiters[objectShard(res.Object, q.shards)]
+
 				wg.Add(1)
 				go func(res *reverseexpand.ReverseExpandResult) {
 					defer func() {
@@ -323,8 +513,9 @@ func (q *ListObjectsQuery) evaluate(
 
 					concurrencyLimiterCh <- struct{}{}
 					checkRequestMetadata := graph.NewCheckRequestMetadata(q.resolveNodeLimit)
+					checkRequestMetadata.ClientID = q.clientID
 
-					resp, err := q.checkResolver.ResolveCheck(ctx, &graph.ResolveCheckRequest{
+					resp, err := q.resolveCheck(ctx, &graph.ResolveCheckRequest{
 						StoreID:              req.GetStoreId(),
 						AuthorizationModelID: req.GetAuthorizationModelId(),
 						TupleKey:             tuple.NewTupleKey(res.Object, req.GetRelation(), req.GetUser()),
@@ -334,8 +525,10 @@ func (q *ListObjectsQuery) evaluate(
 					})
 					if err != nil {
 						if errors.Is(err, graph.ErrResolutionDepthExceeded) {
-							resultsChan <- ListObjectsResult{Err: serverErrors.AuthorizationModelResolutionTooComplex}
-							return
+							err = serverErrors.HandleError("", err)
+						}
+						if ctx.Err() != nil {
+							resolutionMetadata.DeadlineExceeded.Store(true)
 						}
 
 						resultsChan <- ListObjectsResult{Err: err}
@@ -343,6 +536,8 @@ func (q *ListObjectsQuery) evaluate(
 					}
 					atomic.AddUint32(resolutionMetadata.DatastoreQueryCount, resp.GetResolutionMetadata().DatastoreQueryCount)
 					atomic.AddUint32(resolutionMetadata.DispatchCount, checkRequestMetadata.DispatchCounter.Load())
+					atomic.AddUint32(resolutionMetadata.CacheHitCount, checkRequestMetadata.CacheHitCounter.Load())
+					atomic.AddInt64(resolutionMetadata.ThrottlingDuration, checkRequestMetadata.ThrottlingDuration.Load())
 
 					if resp.Allowed {
 						trySendObject(res.Object, &objectsFound, maxResults, resultsChan)
@@ -351,7 +546,7 @@ func (q *ListObjectsQuery) evaluate(
 
 			case err := <-errChan:
 				if errors.Is(err, graph.ErrResolutionDepthExceeded) {
-					err = serverErrors.AuthorizationModelResolutionTooComplex
+					err = serverErrors.HandleError("", err)
 				}
 
 				resultsChan <- ListObjectsResult{Err: err}
@@ -369,6 +564,35 @@ func (q *ListObjectsQuery) evaluate(
 	return nil
 }
 
+// resolveCheck resolves the Check of an object found by the reverse expansion. It runs in a
+// goroutine of the query, so a panic of the resolution is returned as a graph.PanicError.
+func (q *ListObjectsQuery) resolveCheck(ctx context.Context, req *graph.ResolveCheckRequest) (resp *graph.ResolveCheckResponse, err error) {
+	defer graph.RecoverPanic(ctx, "list_objects", &err)
+	return q.checkResolver.ResolveCheck(ctx, req)
+}
+
+// sendCandidates sends the candidates of the type to the resultsChan, in their order, as results that
+// require a Check, and closes it.
+func sendCandidates(ctx context.Context, objectType string, candidates map[string]int, resultsChan chan<- *reverseexpand.ReverseExpandResult) {
+	defer close(resultsChan)
+
+	ids := make([]string, len(candidates))
+	for id, i := range candidates {
+		ids[i] = id
+	}
+
+	for _, id := range ids {
+		select {
+		case <-ctx.Done():
+			return
+		case resultsChan <- &reverseexpand.ReverseExpandResult{
+			Object:       tuple.BuildObject(objectType, id),
+			ResultStatus: reverseexpand.RequiresFurtherEvalStatus,
+		}:
+		}
+	}
+}
+
 func trySendObject(object string, objectsFound *atomic.Uint32, maxResults uint32, resultsChan chan<- ListObjectsResult) {
 	if !(maxResults == 0) {
 		if objectsFound.Add(1) > maxResults {
@@ -379,11 +603,16 @@ func trySendObject(object string, objectsFound *atomic.Uint32, maxResults uint32
 }
 
 // Execute the ListObjectsQuery, returning a list of object IDs up to a maximum of q.listObjectsMaxResults
-// or until q.listObjectsDeadline is hit, whichever happens first.
+// or until q.listObjectsDeadline is hit, whichever happens first. If the query is restricted to a
+// shard, see executeShard.
 func (q *ListObjectsQuery) Execute(
 	ctx context.Context,
 	req *openfgav1.ListObjectsRequest,
 ) (*ListObjectsResponse, error) {
+	if q.shard.Count > 0 {
+		return q.executeShard(ctx, req)
+	}
+
 	resultsChan := make(chan ListObjectsResult, 1)
 	maxResults := q.listObjectsMaxResults
 	if maxResults > 0 {
@@ -433,12 +662,133 @@ func (q *ListObjectsQuery) Execute(
 		return nil, errs
 	}
 
+	if q.candidates != nil {
+		position := func(object string) int {
+			_, id := tuple.SplitObject(object)
+			return q.candidates[id]
+		}
+		sort.SliceStable(objects, func(i, j int) bool {
+			return position(objects[i]) < position(objects[j])
+		})
+	}
+
 	return &ListObjectsResponse{
 		Objects:            objects,
 		ResolutionMetadata: *resolutionMetadata,
+		Truncated:          q.partialResults && resolutionMetadata.DeadlineExceeded.Load(),
 	}, nil
 }
 
+// executeShard executes the ListObjectsQuery of a shard, returning the page of its sorted object
+// IDs that follows the continuation token, of up to q.listObjectsMaxResults objects. Since a page
+// can only be known once all the objects of the shard are, the request fails if q.listObjectsDeadline
+// is hit first, unless partial results are enabled: the page of the objects found until then is
+// returned, Truncated and without a continuation token, as the next page would skip the objects that
+// weren't found. The continuation token of the response is only valid for the same shard of the same
+// request.
+func (q *ListObjectsQuery) executeShard(
+	ctx context.Context,
+	req *openfgav1.ListObjectsRequest,
+) (*ListObjectsResponse, error) {
+	filterHash := hashFilter(req.GetStoreId(), req.GetType(), req.GetRelation(), req.GetUser(), q.shard.String())
+
+	decodedContToken, err := q.encoder.Decode(q.continuationToken)
+	if err != nil {
+		return nil, serverErrors.InvalidContinuationToken
+	}
+
+	after, err := unbindContinuationToken(string(decodedContToken), filterHash)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsChan := make(chan ListObjectsResult, streamedBufferSize)
+
+	timeoutCtx := ctx
+	if q.listObjectsDeadline != 0 {
+		var cancel context.CancelFunc
+		timeoutCtx, cancel = context.WithTimeout(ctx, q.listObjectsDeadline)
+		defer cancel()
+	}
+
+	resolutionMetadata := NewListObjectsResolutionMetadata()
+
+	err = q.evaluate(timeoutCtx, req, resultsChan, 0, resolutionMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]string, 0)
+
+	var errs *multierror.Error
+
+	for result := range resultsChan {
+		if result.Err != nil {
+			if errors.Is(result.Err, serverErrors.AuthorizationModelResolutionTooComplex) {
+				return nil, result.Err
+			}
+
+			if errors.Is(result.Err, condition.ErrEvaluationFailed) {
+				errs = multierror.Append(errs, result.Err)
+				continue
+			}
+
+			if errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded) {
+				continue
+			}
+
+			return nil, serverErrors.HandleError("", result.Err)
+		}
+
+		objects = append(objects, result.ObjectID)
+	}
+
+	// the objects of a page that is missing some objects would be skipped by the next pages
+	truncated := resolutionMetadata.DeadlineExceeded.Load()
+	if truncated && !q.partialResults {
+		return nil, serverErrors.RequestDeadlineExceeded
+	}
+
+	if errs.ErrorOrNil() != nil {
+		return nil, errs
+	}
+
+	sort.Strings(objects)
+
+	start := 0
+	if after != "" {
+		start = sort.Search(len(objects), func(i int) bool { return objects[i] > after })
+	}
+
+	page := make([]string, 0)
+	for _, object := range objects[start:] {
+		if len(page) > 0 && page[len(page)-1] == object {
+			continue
+		}
+
+		if q.listObjectsMaxResults > 0 && len(page) == int(q.listObjectsMaxResults) {
+			break
+		}
+
+		page = append(page, object)
+	}
+
+	response := &ListObjectsResponse{
+		Objects:            page,
+		ResolutionMetadata: *resolutionMetadata,
+		Truncated:          truncated,
+	}
+
+	if !truncated && len(page) > 0 && page[len(page)-1] != objects[len(objects)-1] {
+		response.ContinuationToken, err = q.encoder.Encode(bindContinuationToken([]byte(page[len(page)-1]), filterHash))
+		if err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+	}
+
+	return response, nil
+}
+
 // ExecuteStreamed executes the ListObjectsQuery, returning a stream of object IDs.
 // It ignores the value of q.listObjectsMaxResults and returns all available results
 // until q.listObjectsDeadline is hit.
@@ -2,14 +2,20 @@ package commands
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	parser "github.com/openfga/language/pkg/go/transformer"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 
 	"github.com/openfga/openfga/internal/graph"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
 	"github.com/openfga/openfga/pkg/tuple"
@@ -205,3 +211,406 @@ func TestListObjectsDispatchCount(t *testing.T) {
 		})
 	}
 }
+
+func TestParseListObjectsShard(t *testing.T) {
+	shard, err := ParseListObjectsShard("2/4")
+	require.NoError(t, err)
+	require.Equal(t, ListObjectsShard{Index: 2, Count: 4}, shard)
+	require.Equal(t, "2/4", shard.String())
+
+	for _, invalid := range []string{"", "2", "a/4", "2/b", "0/0", "4/4", "-1/4"} {
+		_, err := ParseListObjectsShard(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestListObjectsShards(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	model := parser.MustTransformDSLToProto(`model
+	schema 1.1
+
+	type user
+
+	type document
+	  relations
+		define owner: [user]
+		define editor: [user]
+		define viewer: owner and editor`)
+
+	var tuples []*openfgav1.TupleKey
+	var expected []string
+	for i := 0; i < 50; i++ {
+		object := fmt.Sprintf("document:%02d", i)
+		tuples = append(tuples,
+			tuple.NewTupleKey(object, "owner", "user:jon"),
+			tuple.NewTupleKey(object, "editor", "user:jon"),
+		)
+		expected = append(expected, object)
+	}
+	err := ds.Write(context.Background(), storeID, nil, tuples)
+	require.NoError(t, err)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesys)
+
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "document",
+		Relation: "viewer",
+		User:     "user:jon",
+	}
+
+	t.Run("worker_pools", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, graph.NewLocalCheckerWithCycleDetection(),
+			WithListObjectsShards(4),
+			WithListObjectsShardConcurrency(2),
+			WithListObjectsMaxResults(0),
+		)
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.ElementsMatch(t, expected, resp.Objects)
+		require.Empty(t, resp.ContinuationToken)
+	})
+
+	t.Run("pages_of_shards", func(t *testing.T) {
+		var objects []string
+		for i := uint32(0); i < 3; i++ {
+			shard := ListObjectsShard{Index: i, Count: 3}
+
+			token := ""
+			for {
+				q, err := NewListObjectsQuery(ds, graph.NewLocalCheckerWithCycleDetection(),
+					WithListObjectsShards(2),
+					WithListObjectsMaxResults(4),
+					WithListObjectsShard(shard),
+					WithListObjectsContinuationToken(token),
+				)
+				require.NoError(t, err)
+
+				resp, err := q.Execute(ctx, req)
+				require.NoError(t, err)
+				require.LessOrEqual(t, len(resp.Objects), 4)
+				require.IsIncreasing(t, resp.Objects)
+				for _, object := range resp.Objects {
+					require.True(t, shard.contains(object))
+				}
+
+				objects = append(objects, resp.Objects...)
+
+				token = resp.ContinuationToken
+				if token == "" {
+					break
+				}
+			}
+		}
+
+		require.ElementsMatch(t, expected, objects)
+	})
+
+	t.Run("continuation_token_of_another_shard", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, graph.NewLocalCheckerWithCycleDetection(),
+			WithListObjectsMaxResults(1),
+			WithListObjectsShard(ListObjectsShard{Index: 0, Count: 2}),
+		)
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.ContinuationToken)
+
+		q, err = NewListObjectsQuery(ds, graph.NewLocalCheckerWithCycleDetection(),
+			WithListObjectsMaxResults(1),
+			WithListObjectsShard(ListObjectsShard{Index: 1, Count: 2}),
+			WithListObjectsContinuationToken(resp.ContinuationToken),
+		)
+		require.NoError(t, err)
+
+		_, err = q.Execute(ctx, req)
+		require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+	})
+}
+
+func TestListObjectsPartialResults(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	model := parser.MustTransformDSLToProto(`model
+	schema 1.1
+
+	type user
+
+	type document
+	  relations
+		define editor: [user]
+		define viewer: [user] and editor`)
+
+	var tuples []*openfgav1.TupleKey
+	for i := 0; i < 10; i++ {
+		object := fmt.Sprintf("document:%d", i)
+		tuples = append(tuples,
+			tuple.NewTupleKey(object, "viewer", "user:jon"),
+			tuple.NewTupleKey(object, "editor", "user:jon"),
+		)
+	}
+	err := ds.Write(context.Background(), storeID, nil, tuples)
+	require.NoError(t, err)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesys)
+
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "document",
+		Relation: "viewer",
+		User:     "user:jon",
+	}
+
+	// the Checks of the documents 0 to 4 are resolved, the others last until the deadline
+	fast := []string{"document:0", "document:1", "document:2", "document:3", "document:4"}
+	newCheckResolver := func(t *testing.T) graph.CheckResolver {
+		mockController := gomock.NewController(t)
+		checkResolver := graph.NewMockCheckResolver(mockController)
+		checkResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, req *graph.ResolveCheckRequest) (*graph.ResolveCheckResponse, error) {
+				if slices.Contains(fast, req.GetTupleKey().GetObject()) {
+					return &graph.ResolveCheckResponse{Allowed: true, ResolutionMetadata: &graph.ResolveCheckResponseMetadata{}}, nil
+				}
+
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).AnyTimes()
+		return checkResolver
+	}
+
+	t.Run("truncated_without_shard", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, newCheckResolver(t),
+			WithListObjectsDeadline(50*time.Millisecond),
+			WithListObjectsMaxResults(0),
+			WithListObjectsPartialResults(true),
+		)
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.ElementsMatch(t, fast, resp.Objects)
+		require.True(t, resp.Truncated)
+	})
+
+	t.Run("not_truncated_without_partial_results", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, newCheckResolver(t),
+			WithListObjectsDeadline(50*time.Millisecond),
+			WithListObjectsMaxResults(0),
+		)
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.ElementsMatch(t, fast, resp.Objects)
+		require.False(t, resp.Truncated)
+	})
+
+	t.Run("not_truncated_before_the_deadline", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, graph.NewLocalCheckerWithCycleDetection(),
+			WithListObjectsMaxResults(0),
+			WithListObjectsPartialResults(true),
+		)
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, resp.Objects, 10)
+		require.False(t, resp.Truncated)
+	})
+
+	t.Run("shard_fails_without_partial_results", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, newCheckResolver(t),
+			WithListObjectsDeadline(50*time.Millisecond),
+			WithListObjectsShard(ListObjectsShard{Index: 0, Count: 1}),
+		)
+		require.NoError(t, err)
+
+		_, err = q.Execute(ctx, req)
+		require.ErrorIs(t, err, serverErrors.RequestDeadlineExceeded)
+	})
+
+	t.Run("truncated_page_of_shard", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, newCheckResolver(t),
+			WithListObjectsDeadline(50*time.Millisecond),
+			WithListObjectsMaxResults(3),
+			WithListObjectsShard(ListObjectsShard{Index: 0, Count: 1}),
+			WithListObjectsPartialResults(true),
+		)
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, fast[:3], resp.Objects)
+		require.True(t, resp.Truncated)
+		require.Empty(t, resp.ContinuationToken)
+	})
+}
+
+// countingUserTupleReader counts the ReadUserTuple calls that reach the datastore.
+type countingUserTupleReader struct {
+	storage.RelationshipTupleReader
+	reads atomic.Int32
+}
+
+func (c *countingUserTupleReader) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	c.reads.Add(1)
+	return c.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey)
+}
+
+func TestListObjectsCandidates(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	model := parser.MustTransformDSLToProto(`model
+	schema 1.1
+
+	type user
+
+	type group
+	  relations
+		define member: [user]
+
+	type document
+	  relations
+		define viewer: [group#member]`)
+
+	tuples := []*openfgav1.TupleKey{tuple.NewTupleKey("group:eng", "member", "user:jon")}
+	for i := 0; i < 10; i++ {
+		tuples = append(tuples, tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "group:eng#member"))
+	}
+	for i := 10; i < 15; i++ {
+		tuples = append(tuples, tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "group:sales#member"))
+	}
+	err := ds.Write(context.Background(), storeID, nil, tuples)
+	require.NoError(t, err)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesys)
+
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "document",
+		Relation: "viewer",
+		User:     "user:jon",
+	}
+
+	t.Run("permitted_candidates_in_their_order", func(t *testing.T) {
+		counting := &countingUserTupleReader{RelationshipTupleReader: ds}
+		q, err := NewListObjectsQuery(counting, graph.NewLocalCheckerWithCycleDetection(),
+			WithListObjectsCandidates([]string{"7", "12", "3", "missing", "3", "0"}, 100),
+		)
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:7", "document:3", "document:0"}, resp.Objects)
+
+		// the membership of user:jon in group:eng is read once for all the candidates
+		require.LessOrEqual(t, counting.reads.Load(), int32(2))
+	})
+
+	t.Run("no_candidates", func(t *testing.T) {
+		q, err := NewListObjectsQuery(ds, graph.NewLocalCheckerWithCycleDetection(),
+			WithListObjectsCandidates([]string{}, 100),
+		)
+		require.NoError(t, err)
+
+		resp, err := q.Execute(ctx, req)
+		require.NoError(t, err)
+		require.Empty(t, resp.Objects)
+	})
+
+	t.Run("shard_of_the_candidates", func(t *testing.T) {
+		var objects []string
+		for i := uint32(0); i < 2; i++ {
+			q, err := NewListObjectsQuery(ds, graph.NewLocalCheckerWithCycleDetection(),
+				WithListObjectsCandidates([]string{"7", "12", "3", "0"}, 100),
+				WithListObjectsShard(ListObjectsShard{Index: i, Count: 2}),
+			)
+			require.NoError(t, err)
+
+			resp, err := q.Execute(ctx, req)
+			require.NoError(t, err)
+			require.IsIncreasing(t, resp.Objects)
+			objects = append(objects, resp.Objects...)
+		}
+
+		require.ElementsMatch(t, []string{"document:7", "document:3", "document:0"}, objects)
+	})
+}
+
+func TestListObjectsResolverPanic(t *testing.T) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+
+	model := parser.MustTransformDSLToProto(`model
+	schema 1.1
+
+	type user
+
+	type document
+	  relations
+		define editor: [user]
+		define viewer: [user] and editor`)
+
+	var tuples []*openfgav1.TupleKey
+	for i := 0; i < 5; i++ {
+		object := fmt.Sprintf("document:%d", i)
+		tuples = append(tuples,
+			tuple.NewTupleKey(object, "viewer", "user:jon"),
+			tuple.NewTupleKey(object, "editor", "user:jon"),
+		)
+	}
+	err := ds.Write(context.Background(), storeID, nil, tuples)
+	require.NoError(t, err)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	ctx := typesystem.ContextWithTypesystem(context.Background(), typesys)
+
+	mockController := gomock.NewController(t)
+	checkResolver := graph.NewMockCheckResolver(mockController)
+	checkResolver.EXPECT().ResolveCheck(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req *graph.ResolveCheckRequest) (*graph.ResolveCheckResponse, error) {
+			if req.GetTupleKey().GetObject() == "document:3" {
+				panic("boom")
+			}
+			return &graph.ResolveCheckResponse{Allowed: true, ResolutionMetadata: &graph.ResolveCheckResponseMetadata{}}, nil
+		}).AnyTimes()
+
+	q, err := NewListObjectsQuery(ds, checkResolver, WithListObjectsMaxResults(0))
+	require.NoError(t, err)
+
+	// the panic only fails the request
+	_, err = q.Execute(ctx, &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "document",
+		Relation: "viewer",
+		User:     "user:jon",
+	})
+	var internalErr serverErrors.InternalError
+	require.ErrorAs(t, err, &internalErr)
+
+	var panicErr *graph.PanicError
+	require.ErrorAs(t, internalErr.Internal(), &panicErr)
+	require.Equal(t, "list_objects", panicErr.Resolver)
+	require.Equal(t, "boom", panicErr.Value)
+	require.NotEmpty(t, panicErr.Stack)
+}
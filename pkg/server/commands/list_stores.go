@@ -2,6 +2,9 @@ package commands
 
 import (
 	"context"
+	"sort"
+	"strings"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 
@@ -15,6 +18,7 @@ type ListStoresQuery struct {
 	storesBackend storage.StoresBackend
 	logger        logger.Logger
 	encoder       encoder.Encoder
+	filter        storage.ListStoresFilter
 }
 
 type ListStoresQueryOption func(*ListStoresQuery)
@@ -31,6 +35,13 @@ func WithListStoresQueryEncoder(e encoder.Encoder) ListStoresQueryOption {
 	}
 }
 
+// WithListStoresQueryFilter restricts the stores to the ones that match the filter.
+func WithListStoresQueryFilter(filter storage.ListStoresFilter) ListStoresQueryOption {
+	return func(q *ListStoresQuery) {
+		q.filter = filter
+	}
+}
+
 func NewListStoresQuery(storesBackend storage.StoresBackend, opts ...ListStoresQueryOption) *ListStoresQuery {
 	q := &ListStoresQuery{
 		storesBackend: storesBackend,
@@ -50,14 +61,20 @@ func (q *ListStoresQuery) Execute(ctx context.Context, req *openfgav1.ListStores
 		return nil, serverErrors.InvalidContinuationToken
 	}
 
-	paginationOptions := storage.NewPaginationOptions(req.GetPageSize().GetValue(), string(decodedContToken))
+	filterHash := hashListStoresFilter(q.filter)
+	contToken, err := unbindContinuationToken(string(decodedContToken), filterHash)
+	if err != nil {
+		return nil, err
+	}
+
+	paginationOptions := storage.NewPaginationOptions(req.GetPageSize().GetValue(), contToken)
 
-	stores, continuationToken, err := q.storesBackend.ListStores(ctx, paginationOptions)
+	stores, continuationToken, err := q.storesBackend.ListStores(ctx, q.filter, paginationOptions)
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
 
-	encodedToken, err := q.encoder.Encode(continuationToken)
+	encodedToken, err := q.encoder.Encode(bindContinuationToken(continuationToken, filterHash))
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
@@ -69,3 +86,21 @@ func (q *ListStoresQuery) Execute(ctx context.Context, req *openfgav1.ListStores
 
 	return resp, nil
 }
+
+// hashListStoresFilter returns the hash of the filter that the continuation tokens are bound to.
+func hashListStoresFilter(filter storage.ListStoresFilter) string {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+
+	labels := make([]string, 0, len(filter.Labels))
+	for key, value := range filter.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+
+	return hashFilter(filter.NameContains, formatTime(filter.CreatedAfter), formatTime(filter.CreatedBefore), strings.Join(labels, ","))
+}
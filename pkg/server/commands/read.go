@@ -2,9 +2,13 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/openfga/openfga/pkg/encoder"
 	"github.com/openfga/openfga/pkg/logger"
@@ -17,11 +21,14 @@ import (
 // Each tupleset specifies keys of a set of relation tuples.
 // The set can include a single tuple key, or all tuples with
 // a given object ID or userset in a type, optionally
-// constrained by a relation name.
+// constrained by a relation name, or all tuples of a user
+// across types.
 type ReadQuery struct {
-	datastore storage.OpenFGADatastore
-	logger    logger.Logger
-	encoder   encoder.Encoder
+	datastore        storage.OpenFGADatastore
+	logger           logger.Logger
+	encoder          encoder.Encoder
+	conditionName    string
+	conditionContext *structpb.Struct
 }
 
 type ReadQueryOption func(*ReadQuery)
@@ -38,6 +45,17 @@ func WithReadQueryEncoder(e encoder.Encoder) ReadQueryOption {
 	}
 }
 
+// WithReadQueryCondition restricts the tuples to those with the condition name and, if
+// conditionContext isn't nil, whose condition context has the values of its fields. The condition
+// name is filtered by the datastore, while the context is matched as the tuples are read, so a page
+// may have fewer tuples than the page size, or none, with a continuation token.
+func WithReadQueryCondition(name string, conditionContext *structpb.Struct) ReadQueryOption {
+	return func(rq *ReadQuery) {
+		rq.conditionName = name
+		rq.conditionContext = conditionContext
+	}
+}
+
 // NewReadQuery creates a ReadQuery using the provided OpenFGA datastore implementation.
 func NewReadQuery(datastore storage.OpenFGADatastore, opts ...ReadQueryOption) *ReadQuery {
 	rq := &ReadQuery{
@@ -58,29 +76,69 @@ func (q *ReadQuery) Execute(ctx context.Context, req *openfgav1.ReadRequest) (*o
 	store := req.GetStoreId()
 	tk := req.GetTupleKey()
 
-	// Restrict our reads due to some compatibility issues in one of our storage implementations.
+	// Restrict our reads to the ones the indexes of the datastores serve: by object, or by user
+	// optionally constrained by an object type and a relation.
 	if tk != nil {
 		objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
-		if objectType == "" || (objectID == "" && tk.GetUser() == "") {
+		if tk.GetObject() != "" && objectType == "" {
+			return nil, serverErrors.ValidationError(
+				fmt.Errorf("the 'tuple_key' field was provided but the object type field is required when an object is given"),
+			)
+		}
+		if objectID == "" && tk.GetUser() == "" {
 			return nil, serverErrors.ValidationError(
-				fmt.Errorf("the 'tuple_key' field was provided but the object type field is required and both the object id and user cannot be empty"),
+				fmt.Errorf("the 'tuple_key' field was provided but the object id and user cannot both be empty"),
 			)
 		}
 	}
 
+	if q.conditionContext != nil && q.conditionName == "" {
+		return nil, serverErrors.ValidationError(errors.New("the condition context filter requires the condition name filter"))
+	}
+
 	decodedContToken, err := q.encoder.Decode(req.GetContinuationToken())
 	if err != nil {
 		return nil, serverErrors.InvalidContinuationToken
 	}
 
-	paginationOptions := storage.NewPaginationOptions(req.GetPageSize().GetValue(), string(decodedContToken))
+	// the datastore binds its continuation tokens to the tuple key, and the query to the condition
+	var conditionContext []byte
+	if q.conditionContext != nil {
+		// the keys of the map are sorted, so that the same fields have the same hash
+		conditionContext, err = json.Marshal(q.conditionContext.AsMap())
+		if err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+	}
+	filterHash := hashFilter(q.conditionName, string(conditionContext))
+	contToken, err := unbindContinuationToken(string(decodedContToken), filterHash)
+	if err != nil {
+		return nil, err
+	}
+
+	paginationOptions := storage.NewPaginationOptions(req.GetPageSize().GetValue(), contToken)
+
+	tupleKey := tupleUtils.ConvertReadRequestTupleKeyToTupleKey(tk)
+	if q.conditionName != "" {
+		tupleKey.Condition = &openfgav1.RelationshipCondition{Name: q.conditionName}
+	}
 
-	tuples, contToken, err := q.datastore.ReadPage(ctx, store, tupleUtils.ConvertReadRequestTupleKeyToTupleKey(tk), paginationOptions)
+	tuples, nextContToken, err := q.datastore.ReadPage(ctx, store, tupleKey, paginationOptions)
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
 
-	encodedContToken, err := q.encoder.Encode(contToken)
+	if q.conditionContext != nil {
+		matches := tuples[:0]
+		for _, t := range tuples {
+			if matchConditionContext(t.GetKey().GetCondition().GetContext(), q.conditionContext) {
+				matches = append(matches, t)
+			}
+		}
+		tuples = matches
+	}
+
+	encodedContToken, err := q.encoder.Encode(bindContinuationToken(nextContToken, filterHash))
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
@@ -90,3 +148,14 @@ func (q *ReadQuery) Execute(ctx context.Context, req *openfgav1.ReadRequest) (*o
 		ContinuationToken: encodedContToken,
 	}, nil
 }
+
+// matchConditionContext reports whether the condition context has the values of the fields of the filter.
+func matchConditionContext(conditionContext, filter *structpb.Struct) bool {
+	for name, value := range filter.GetFields() {
+		if !proto.Equal(conditionContext.GetFields()[name], value) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -19,6 +19,8 @@ type ReadChangesQuery struct {
 	logger        logger.Logger
 	encoder       encoder.Encoder
 	horizonOffset time.Duration
+	filter        storage.ReadChangesFilter
+	tokenLifetime time.Duration
 }
 
 type ReadChangesQueryOption func(*ReadChangesQuery)
@@ -41,6 +43,22 @@ func WithReadChangeQueryHorizonOffset(horizonOffset int) ReadChangesQueryOption
 	}
 }
 
+// WithReadChangesQueryFilter restricts the changes to the objects, relation and user of the filter.
+// The object type of the filter is always the type of the request.
+func WithReadChangesQueryFilter(filter storage.ReadChangesFilter) ReadChangesQueryOption {
+	return func(rq *ReadChangesQuery) {
+		rq.filter = filter
+	}
+}
+
+// WithReadChangesQueryTokenLifetime rejects the continuation tokens whose last change is older than
+// the lifetime with a ContinuationTokenExpired error. 0 means that the tokens never expire.
+func WithReadChangesQueryTokenLifetime(lifetime time.Duration) ReadChangesQueryOption {
+	return func(rq *ReadChangesQuery) {
+		rq.tokenLifetime = lifetime
+	}
+}
+
 // NewReadChangesQuery creates a ReadChangesQuery with specified `ChangelogBackend`.
 func NewReadChangesQuery(backend storage.ChangelogBackend, opts ...ReadChangesQueryOption) *ReadChangesQuery {
 	rq := &ReadChangesQuery{
@@ -62,9 +80,39 @@ func (q *ReadChangesQuery) Execute(ctx context.Context, req *openfgav1.ReadChang
 	if err != nil {
 		return nil, serverErrors.InvalidContinuationToken
 	}
-	paginationOptions := storage.NewPaginationOptions(req.GetPageSize().GetValue(), string(decodedContToken))
 
-	changes, contToken, err := q.backend.ReadChanges(ctx, req.GetStoreId(), req.GetType(), paginationOptions, q.horizonOffset)
+	filter := q.filter
+	filter.ObjectType = req.GetType()
+	if filter.ObjectIDPrefix != "" && filter.ObjectType == "" {
+		return nil, serverErrors.ValidationError(errors.New("the object ID prefix filter requires the type filter"))
+	}
+
+	markedContToken, highWaterMark, err := unmarkContinuationToken(string(decodedContToken))
+	if err != nil {
+		return nil, err
+	}
+	if q.tokenLifetime > 0 && !highWaterMark.IsZero() {
+		// the changes older than the lifetime may have been removed, so the changes between the
+		// token and the oldest change that is kept may be missing
+		if restartFrom := time.Now().Add(-q.tokenLifetime); highWaterMark.Before(restartFrom) {
+			return nil, serverErrors.ContinuationTokenExpired(highWaterMark, restartFrom)
+		}
+	}
+
+	var startTime string
+	if !filter.StartTime.IsZero() {
+		startTime = filter.StartTime.UTC().Format(time.RFC3339Nano)
+	}
+
+	// the datastore binds its continuation tokens to the type, and the query to the other filters
+	filterHash := hashFilter(filter.ObjectIDPrefix, filter.Relation, filter.User, startTime)
+	contToken, err := unbindContinuationToken(markedContToken, filterHash)
+	if err != nil {
+		return nil, err
+	}
+	paginationOptions := storage.NewPaginationOptions(req.GetPageSize().GetValue(), contToken)
+
+	changes, nextContToken, err := q.backend.ReadChanges(ctx, req.GetStoreId(), filter, paginationOptions, q.horizonOffset)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
 			return &openfgav1.ReadChangesResponse{
@@ -74,7 +122,11 @@ func (q *ReadChangesQuery) Execute(ctx context.Context, req *openfgav1.ReadChang
 		return nil, serverErrors.HandleError("", err)
 	}
 
-	encodedContToken, err := q.encoder.Encode(contToken)
+	if len(changes) > 0 {
+		highWaterMark = changes[len(changes)-1].GetTimestamp().AsTime()
+	}
+
+	encodedContToken, err := q.encoder.Encode(markContinuationToken(bindContinuationToken(nextContToken, filterHash), highWaterMark))
 	if err != nil {
 		return nil, serverErrors.HandleError("", err)
 	}
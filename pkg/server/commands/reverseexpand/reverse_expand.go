@@ -196,13 +196,18 @@ func WithLogger(logger logger.Logger) ReverseExpandQueryOption {
 // If no errors occur, then Execute will yield all of the objects on
 // the provided channel and then close the channel to signal that it
 // is done.
+//
+// Execute is run in a goroutine of its caller, so a panic is returned as a
+// graph.PanicError.
 func (c *ReverseExpandQuery) Execute(
 	ctx context.Context,
 	req *ReverseExpandRequest,
 	resultChan chan<- *ReverseExpandResult,
 	resolutionMetadata *ResolutionMetadata,
-) error {
-	err := c.execute(ctx, req, resultChan, false, resolutionMetadata)
+) (err error) {
+	defer graph.RecoverPanic(ctx, "reverse_expand", &err)
+
+	err = c.execute(ctx, req, resultChan, false, resolutionMetadata)
 	if err != nil {
 		return err
 	}
@@ -252,6 +257,15 @@ func (c *ReverseExpandQuery) execute(
 		sourceUserType = val.Object.GetType()
 		sourceUserObj = tuple.BuildObject(sourceUserType, val.Object.GetId())
 		sourceUserRef = typesystem.DirectRelationReference(sourceUserType, "")
+
+		// e.g. 'define viewer: editor but not blocked', resolve the operands and combine them
+		setOperation, err := getSetOperation(c.typesystem, req.ObjectType, req.Relation)
+		if err != nil {
+			return err
+		}
+		if setOperation != nil {
+			return c.reverseExpandSetOperation(ctx, req, setOperation, resultChan, intersectionOrExclusionInPreviousEdges, resolutionMetadata)
+		}
 	}
 
 	// e.g. 'user:*'
@@ -314,7 +328,8 @@ LoopOnEdges:
 		}
 		switch innerLoopEdge.Type {
 		case graph.DirectEdge:
-			pool.Go(func(ctx context.Context) error {
+			pool.Go(func(ctx context.Context) (err error) {
+				defer graph.RecoverPanic(ctx, "reverse_expand", &err)
 				return c.reverseExpandDirect(ctx, r, resultChan, intersectionOrExclusionInPreviousEdges, resolutionMetadata)
 			})
 		case graph.ComputedUsersetEdge:
@@ -332,7 +347,8 @@ LoopOnEdges:
 				break LoopOnEdges
 			}
 		case graph.TupleToUsersetEdge:
-			pool.Go(func(ctx context.Context) error {
+			pool.Go(func(ctx context.Context) (err error) {
+				defer graph.RecoverPanic(ctx, "reverse_expand", &err)
 				return c.reverseExpandTupleToUserset(ctx, r, resultChan, intersectionOrExclusionInPreviousEdges, resolutionMetadata)
 			})
 		default:
@@ -527,7 +543,8 @@ LoopOnIterator:
 			panic("unsupported edge type")
 		}
 
-		pool.Go(func(ctx context.Context) error {
+		pool.Go(func(ctx context.Context) (err error) {
+			defer graph.RecoverPanic(ctx, "reverse_expand", &err)
 			atomic.AddUint32(resolutionMetadata.DispatchCount, 1)
 			return c.execute(ctx, &ReverseExpandRequest{
 				StoreID:    req.StoreID,
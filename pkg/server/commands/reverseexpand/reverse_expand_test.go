@@ -443,3 +443,112 @@ func TestReverseExpandIgnoresInvalidTuples(t *testing.T) {
 		}
 	}
 }
+
+func TestReverseExpandSetOperations(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	storeID := ulid.Make().String()
+
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type folder
+			relations
+				define viewer: [user]
+				define blocked: [user]
+				define restricted: viewer but not blocked
+		type document
+			relations
+				define parent: [folder]
+				define editor: [user]
+				define member: [user, user:*]
+				define blocked: [user] or restricted from parent
+				define can_edit: editor and member
+				define can_view: editor but not blocked
+				define can_comment: can_edit but not blocked`)
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	ctx := context.Background()
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "editor", "user:anne"),
+		tuple.NewTupleKey("document:1", "member", "user:anne"),
+		tuple.NewTupleKey("document:2", "editor", "user:anne"),
+		tuple.NewTupleKey("document:2", "member", "user:*"),
+		tuple.NewTupleKey("document:2", "blocked", "user:anne"),
+		tuple.NewTupleKey("document:3", "editor", "user:anne"),
+		tuple.NewTupleKey("document:3", "parent", "folder:x"),
+		tuple.NewTupleKey("document:3", "member", "user:anne"),
+		tuple.NewTupleKey("document:4", "member", "user:anne"),
+		tuple.NewTupleKey("folder:x", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		relation string
+		expected map[string]ConditionalResultStatus
+	}{
+		{
+			relation: "can_edit",
+			expected: map[string]ConditionalResultStatus{
+				"document:1": NoFurtherEvalStatus,
+				"document:2": NoFurtherEvalStatus,
+				"document:3": NoFurtherEvalStatus,
+			},
+		},
+		{
+			// 'blocked' involves an exclusion, so document:3 may or may not be subtracted
+			relation: "can_view",
+			expected: map[string]ConditionalResultStatus{
+				"document:1": NoFurtherEvalStatus,
+				"document:3": RequiresFurtherEvalStatus,
+			},
+		},
+		{
+			relation: "can_comment",
+			expected: map[string]ConditionalResultStatus{
+				"document:1": NoFurtherEvalStatus,
+				"document:3": RequiresFurtherEvalStatus,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.relation, func(t *testing.T) {
+			resultChan := make(chan *ReverseExpandResult)
+			errChan := make(chan error, 1)
+
+			go func() {
+				reverseExpandQuery := NewReverseExpandQuery(ds, typesystem.New(model))
+				err := reverseExpandQuery.Execute(ctx, &ReverseExpandRequest{
+					StoreID:    storeID,
+					ObjectType: "document",
+					Relation:   test.relation,
+					User:       &UserRefObject{Object: &openfgav1.Object{Type: "user", Id: "anne"}},
+				}, resultChan, NewResolutionMetadata())
+				if err != nil {
+					errChan <- err
+				}
+			}()
+
+			results := map[string]ConditionalResultStatus{}
+			for {
+				select {
+				case res, open := <-resultChan:
+					if !open {
+						require.Equal(t, test.expected, results)
+						return
+					}
+					results[res.Object] = res.ResultStatus
+				case err := <-errChan:
+					require.FailNow(t, "unexpected error received on error channel :%v", err)
+				}
+			}
+		})
+	}
+}
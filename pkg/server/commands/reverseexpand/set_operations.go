@@ -0,0 +1,194 @@
+package reverseexpand
+
+import (
+	"context"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/sourcegraph/conc/pool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// setOperation is an intersection or an exclusion whose operands are all computed usersets, e.g.
+//
+//	define viewer: editor and member
+//	define viewer: editor but not blocked
+//
+// For an exclusion, operands[0] is the base and operands[1] is the subtracted relation.
+type setOperation struct {
+	exclusion bool
+	operands  []string
+}
+
+// getSetOperation returns the set operation that defines objectType#relation, or nil if the relation
+// is not defined by an intersection or exclusion of computed usersets.
+func getSetOperation(ts *typesystem.TypeSystem, objectType, relation string) (*setOperation, error) {
+	rel, err := ts.GetRelation(objectType, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	var op *setOperation
+	var children []*openfgav1.Userset
+
+	switch rewrite := rel.GetRewrite().GetUserset().(type) {
+	case *openfgav1.Userset_Intersection:
+		op = &setOperation{}
+		children = rewrite.Intersection.GetChild()
+	case *openfgav1.Userset_Difference:
+		op = &setOperation{exclusion: true}
+		children = []*openfgav1.Userset{rewrite.Difference.GetBase(), rewrite.Difference.GetSubtract()}
+	default:
+		return nil, nil
+	}
+
+	for _, child := range children {
+		computedUserset, ok := child.GetUserset().(*openfgav1.Userset_ComputedUserset)
+		if !ok {
+			return nil, nil
+		}
+
+		op.operands = append(op.operands, computedUserset.ComputedUserset.GetRelation())
+	}
+
+	return op, nil
+}
+
+// combine intersects or subtracts the objects found for each operand. An object only doesn't require
+// further evaluation if that is true of it in every operand it was found in, and if it wasn't found
+// in the subtracted relation.
+func (o *setOperation) combine(results []map[string]ConditionalResultStatus) map[string]ConditionalResultStatus {
+	combined := make(map[string]ConditionalResultStatus, len(results[0]))
+
+	if o.exclusion {
+		for object, status := range results[0] {
+			if subtractStatus, ok := results[1][object]; ok {
+				if subtractStatus == NoFurtherEvalStatus {
+					continue
+				}
+				status = RequiresFurtherEvalStatus
+			}
+			combined[object] = status
+		}
+
+		return combined
+	}
+
+LoopOnObjects:
+	for object, status := range results[0] {
+		for _, result := range results[1:] {
+			operandStatus, ok := result[object]
+			if !ok {
+				continue LoopOnObjects
+			}
+			if operandStatus == RequiresFurtherEvalStatus {
+				status = RequiresFurtherEvalStatus
+			}
+		}
+		combined[object] = status
+	}
+
+	return combined
+}
+
+// reverseExpandSetOperation reverse expands each operand of the set operation independently and
+// intersects or subtracts the objects found, instead of expanding one operand and leaving each
+// candidate to be checked against the rest of the rewrite. The operands must be fully expanded
+// before any object is sent.
+func (c *ReverseExpandQuery) reverseExpandSetOperation(
+	ctx context.Context,
+	req *ReverseExpandRequest,
+	op *setOperation,
+	resultChan chan<- *ReverseExpandResult,
+	intersectionOrExclusionInPreviousEdges bool,
+	resolutionMetadata *ResolutionMetadata,
+) error {
+	ctx, span := tracer.Start(ctx, "reverseExpandSetOperation", trace.WithAttributes(
+		attribute.Bool("exclusion", op.exclusion),
+		attribute.StringSlice("operands", op.operands),
+	))
+	defer span.End()
+
+	results := make([]map[string]ConditionalResultStatus, len(op.operands))
+
+	pool := pool.New().WithContext(ctx)
+	pool.WithCancelOnError()
+	pool.WithFirstError()
+	pool.WithMaxGoroutines(int(c.resolveNodeBreadthLimit))
+
+	for i, operand := range op.operands {
+		i, operand := i, operand
+		pool.Go(func(ctx context.Context) (err error) {
+			defer graph.RecoverPanic(ctx, "reverse_expand", &err)
+			objects, err := c.collectObjects(ctx, &ReverseExpandRequest{
+				StoreID:          req.StoreID,
+				ObjectType:       req.ObjectType,
+				Relation:         operand,
+				User:             req.User,
+				ContextualTuples: req.ContextualTuples,
+				Context:          req.Context,
+			}, resolutionMetadata)
+			results[i] = objects
+			return err
+		})
+	}
+
+	if err := pool.Wait(); err != nil {
+		telemetry.TraceError(span, err)
+		return err
+	}
+
+	for object, status := range op.combine(results) {
+		requiresFurtherEval := intersectionOrExclusionInPreviousEdges || status == RequiresFurtherEvalStatus
+		if err := c.trySendCandidate(ctx, requiresFurtherEval, object, resultChan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectObjects reverse expands the request with a new query, so that the objects already sent by
+// c are not skipped, and returns all the objects found.
+func (c *ReverseExpandQuery) collectObjects(
+	ctx context.Context,
+	req *ReverseExpandRequest,
+	resolutionMetadata *ResolutionMetadata,
+) (map[string]ConditionalResultStatus, error) {
+	query := &ReverseExpandQuery{
+		logger:                  c.logger,
+		datastore:               c.datastore,
+		typesystem:              c.typesystem,
+		resolveNodeLimit:        c.resolveNodeLimit,
+		resolveNodeBreadthLimit: c.resolveNodeBreadthLimit,
+		candidateObjectsMap:     new(sync.Map),
+		visitedUsersetsMap:      new(sync.Map),
+	}
+
+	resultChan := make(chan *ReverseExpandResult, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		if err := query.Execute(ctx, req, resultChan, resolutionMetadata); err != nil {
+			errChan <- err
+		}
+	}()
+
+	objects := make(map[string]ConditionalResultStatus)
+	for {
+		select {
+		case res, channelOpen := <-resultChan:
+			if !channelOpen {
+				return objects, nil
+			}
+			objects[res.Object] = res.ResultStatus
+		case err := <-errChan:
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+type UpdateStoreCommand struct {
+	storesBackend storage.StoresBackend
+	logger        logger.Logger
+	labels        map[string]string
+}
+
+type UpdateStoreCmdOption func(*UpdateStoreCommand)
+
+func WithUpdateStoreCmdLogger(l logger.Logger) UpdateStoreCmdOption {
+	return func(c *UpdateStoreCommand) {
+		c.logger = l
+	}
+}
+
+// WithUpdateStoreCmdLabels overwrites the labels of the store with the labels. Empty labels remove
+// them all. Without this option, the labels are unchanged.
+func WithUpdateStoreCmdLabels(labels map[string]string) UpdateStoreCmdOption {
+	return func(c *UpdateStoreCommand) {
+		c.labels = labels
+	}
+}
+
+func NewUpdateStoreCommand(
+	storesBackend storage.StoresBackend,
+	opts ...UpdateStoreCmdOption,
+) *UpdateStoreCommand {
+	cmd := &UpdateStoreCommand{
+		storesBackend: storesBackend,
+		logger:        logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(cmd)
+	}
+	return cmd
+}
+
+func (s *UpdateStoreCommand) Execute(ctx context.Context, req *openfgav1.UpdateStoreRequest) (*openfgav1.UpdateStoreResponse, error) {
+	store, err := s.storesBackend.UpdateStore(ctx, &openfgav1.Store{
+		Id:   req.GetStoreId(),
+		Name: req.GetName(),
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, serverErrors.StoreIDNotFound
+		}
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	if s.labels != nil {
+		if err := s.storesBackend.WriteStoreLabels(ctx, store.GetId(), s.labels); err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+	}
+
+	return &openfgav1.UpdateStoreResponse{
+		Id:        store.GetId(),
+		Name:      store.GetName(),
+		CreatedAt: store.GetCreatedAt(),
+		UpdatedAt: store.GetUpdatedAt(),
+	}, nil
+}
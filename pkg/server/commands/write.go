@@ -11,6 +11,7 @@ import (
 	"github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/internal/validation"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/server/admission"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
@@ -22,6 +23,8 @@ type WriteCommand struct {
 	logger                    logger.Logger
 	datastore                 storage.OpenFGADatastore
 	conditionContextByteLimit int
+	admitter                  admission.Admitter
+	tupleKeyRules             *validation.TupleKeyRules
 }
 
 type WriteCommandOption func(*WriteCommand)
@@ -38,6 +41,22 @@ func WithConditionContextByteLimit(limit int) WriteCommandOption {
 	}
 }
 
+// WithWriteCmdAdmitter sets the admission.Admitter that reviews the tuples of the valid requests
+// before they are committed.
+func WithWriteCmdAdmitter(a admission.Admitter) WriteCommandOption {
+	return func(wc *WriteCommand) {
+		wc.admitter = a
+	}
+}
+
+// WithWriteCmdTupleKeyRules sets the deployment-specific validation.TupleKeyRules that the tuples
+// to write must follow.
+func WithWriteCmdTupleKeyRules(rules *validation.TupleKeyRules) WriteCommandOption {
+	return func(wc *WriteCommand) {
+		wc.tupleKeyRules = rules
+	}
+}
+
 // NewWriteCommand creates a WriteCommand with specified storage.OpenFGADatastore to use for storage.
 func NewWriteCommand(datastore storage.OpenFGADatastore, opts ...WriteCommandOption) *WriteCommand {
 	cmd := &WriteCommand{
@@ -54,11 +73,12 @@ func NewWriteCommand(datastore storage.OpenFGADatastore, opts ...WriteCommandOpt
 
 // Execute deletes and writes the specified tuples. Deletes are applied first, then writes.
 func (c *WriteCommand) Execute(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteResponse, error) {
-	if err := c.validateWriteRequest(ctx, req); err != nil {
+	req, err := c.validateAndAdmit(ctx, req)
+	if err != nil {
 		return nil, err
 	}
 
-	err := c.datastore.Write(
+	err = c.datastore.Write(
 		ctx,
 		req.GetStoreId(),
 		req.GetDeletes().GetTupleKeys(),
@@ -71,6 +91,84 @@ func (c *WriteCommand) Execute(ctx context.Context, req *openfgav1.WriteRequest)
 	return &openfgav1.WriteResponse{}, nil
 }
 
+// DryRun validates the request like Execute, and checks that none of the tuples to write exists
+// and all the tuples to delete exist, without committing anything. It returns the request that
+// Execute would commit, with the tuples mutated by the admitter, if any. The admitter reviews the
+// request with a context for which admission.IsDryRun is true.
+func (c *WriteCommand) DryRun(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+	req, err := c.validateAndAdmit(admission.ContextWithDryRun(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.validatePreconditions(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// validateAndAdmit validates the request, and returns the request admitted by the admitter, if any.
+func (c *WriteCommand) validateAndAdmit(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+	if err := c.validateWriteRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if c.admitter == nil {
+		return req, nil
+	}
+
+	admitted, err := c.admitter.Admit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// the tuples mutated by the admitter must be valid too
+	if !proto.Equal(admitted, req) {
+		if err := admitted.Validate(); err != nil {
+			return nil, serverErrors.ValidationError(err)
+		}
+		if err := c.validateWriteRequest(ctx, admitted); err != nil {
+			return nil, err
+		}
+	}
+
+	return admitted, nil
+}
+
+// validatePreconditions returns the error of the datastore Write if a tuple to write already exists
+// or a tuple to delete doesn't.
+func (c *WriteCommand) validatePreconditions(ctx context.Context, req *openfgav1.WriteRequest) error {
+	ctx, span := tracer.Start(ctx, "validatePreconditions")
+	defer span.End()
+
+	store := req.GetStoreId()
+
+	for _, tk := range req.GetDeletes().GetTupleKeys() {
+		_, err := c.datastore.ReadUserTuple(ctx, store, tupleUtils.TupleKeyWithoutConditionToTupleKey(tk))
+		if errors.Is(err, storage.ErrNotFound) {
+			return serverErrors.WriteFailedDueToInvalidInput(
+				storage.InvalidWriteInputError(tk, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE))
+		}
+		if err != nil {
+			return serverErrors.HandleError("", err)
+		}
+	}
+
+	for _, tk := range req.GetWrites().GetTupleKeys() {
+		_, err := c.datastore.ReadUserTuple(ctx, store, tk)
+		if err == nil {
+			return serverErrors.WriteFailedDueToInvalidInput(
+				storage.InvalidWriteInputError(tk, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE))
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return serverErrors.HandleError("", err)
+		}
+	}
+
+	return nil
+}
+
 func (c *WriteCommand) validateWriteRequest(ctx context.Context, req *openfgav1.WriteRequest) error {
 	ctx, span := tracer.Start(ctx, "validateWriteRequest")
 	defer span.End()
@@ -105,6 +203,10 @@ func (c *WriteCommand) validateWriteRequest(ctx context.Context, req *openfgav1.
 				return serverErrors.ValidationError(err)
 			}
 
+			if err := c.tupleKeyRules.ValidateTupleKey(tk); err != nil {
+				return serverErrors.ValidationError(err)
+			}
+
 			err = c.validateNotImplicit(tk)
 			if err != nil {
 				return err
@@ -3,9 +3,12 @@ package commands
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
-	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
@@ -13,6 +16,7 @@ import (
 	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/pkg/logger"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/idgen"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/typesystem"
 )
@@ -22,6 +26,11 @@ type WriteAuthorizationModelCommand struct {
 	backend                          storage.TypeDefinitionWriteBackend
 	logger                           logger.Logger
 	maxAuthorizationModelSizeInBytes int
+	maxRelationsPerTypeDefinition    int
+	maxRewriteDepth                  int
+	maxConditionExpressionSize       int
+	typesystemCache                  *typesystem.MemoizedTypesystemResolver
+	idGenerator                      idgen.Generator
 }
 
 type WriteAuthModelOption func(*WriteAuthorizationModelCommand)
@@ -38,11 +47,52 @@ func WithWriteAuthModelMaxSizeInBytes(size int) WriteAuthModelOption {
 	}
 }
 
+// WithWriteAuthModelMaxRelationsPerTypeDefinition limits the number of relations of each type definition
+// of the model. If 0, it isn't limited.
+func WithWriteAuthModelMaxRelationsPerTypeDefinition(limit int) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.maxRelationsPerTypeDefinition = limit
+	}
+}
+
+// WithWriteAuthModelMaxRewriteDepth limits the nesting of the unions, intersections and exclusions of the
+// rewrite of each relation of the model, e.g. 'define viewer: [user] or editor' has a depth of 2. If 0,
+// it isn't limited.
+func WithWriteAuthModelMaxRewriteDepth(limit int) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.maxRewriteDepth = limit
+	}
+}
+
+// WithWriteAuthModelMaxConditionExpressionSize limits the size in bytes of the expression of each
+// condition of the model. If 0, it isn't limited.
+func WithWriteAuthModelMaxConditionExpressionSize(limit int) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.maxConditionExpressionSize = limit
+	}
+}
+
+// WithWriteAuthModelTypesystemCache memoizes the TypeSystem of the written model in the cache, so that
+// the first Check of the new latest model of the store doesn't construct it again.
+func WithWriteAuthModelTypesystemCache(cache *typesystem.MemoizedTypesystemResolver) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.typesystemCache = cache
+	}
+}
+
+// WithWriteAuthModelIDGenerator sets the generator of the ID of the written model.
+func WithWriteAuthModelIDGenerator(generator idgen.Generator) WriteAuthModelOption {
+	return func(m *WriteAuthorizationModelCommand) {
+		m.idGenerator = generator
+	}
+}
+
 func NewWriteAuthorizationModelCommand(backend storage.TypeDefinitionWriteBackend, opts ...WriteAuthModelOption) *WriteAuthorizationModelCommand {
 	model := &WriteAuthorizationModelCommand{
 		backend:                          backend,
 		logger:                           logger.NewNoopLogger(),
 		maxAuthorizationModelSizeInBytes: serverconfig.DefaultMaxAuthorizationModelSizeInBytes,
+		idGenerator:                      idgen.Default(),
 	}
 
 	for _, opt := range opts {
@@ -63,8 +113,13 @@ func (w *WriteAuthorizationModelCommand) Execute(ctx context.Context, req *openf
 		req.SchemaVersion = typesystem.SchemaVersion1_1
 	}
 
+	modelID, err := w.idGenerator.NewAuthorizationModelID(ctx, req.GetStoreId())
+	if err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
 	model := &openfgav1.AuthorizationModel{
-		Id:              ulid.Make().String(),
+		Id:              modelID,
 		SchemaVersion:   req.GetSchemaVersion(),
 		TypeDefinitions: req.GetTypeDefinitions(),
 		Conditions:      req.GetConditions(),
@@ -79,18 +134,117 @@ func (w *WriteAuthorizationModelCommand) Execute(ctx context.Context, req *openf
 		)
 	}
 
-	_, err := typesystem.NewAndValidate(ctx, model)
+	if err := w.verifyLimits(model); err != nil {
+		return nil, err
+	}
+
+	typesys, err := typesystem.NewAndValidate(ctx, model)
 	if err != nil {
 		return nil, serverErrors.InvalidAuthorizationModelInput(err)
 	}
 
-	err = w.backend.WriteAuthorizationModel(ctx, req.GetStoreId(), model)
+	if cycles := typesys.RuntimeCycles(); len(cycles) > 0 {
+		w.logger.WarnWithContext(ctx, "authorization model can produce cycles at runtime; Check and ListObjects fail if the relationship tuples form one of them",
+			zap.String("store_id", req.GetStoreId()),
+			zap.String("authorization_model_id", model.GetId()),
+			zap.Strings("cycles", formatCycles(cycles)),
+		)
+	}
+
+	generate := func(ctx context.Context) (string, error) {
+		return w.idGenerator.NewAuthorizationModelID(ctx, req.GetStoreId())
+	}
+	err = writeWithGeneratedID(ctx, w.logger, model.GetId(), generate, func(id string) error {
+		model.Id = id
+		return w.backend.WriteAuthorizationModel(ctx, req.GetStoreId(), model)
+	})
 	if err != nil {
 		return nil, serverErrors.
 			HandleError("Error writing authorization model configuration", err)
 	}
 
+	if model.GetId() != typesys.GetAuthorizationModelID() {
+		// the model was written with another ID than the one it was validated with
+		typesys = typesystem.New(model)
+	}
+
+	if w.typesystemCache != nil {
+		w.typesystemCache.Add(req.GetStoreId(), typesys)
+	}
+
 	return &openfgav1.WriteAuthorizationModelResponse{
 		AuthorizationModelId: model.GetId(),
 	}, nil
 }
+
+// verifyLimits returns an error naming the first part of the model that exceeds a limit of the command.
+// It runs before the model is validated, so that the pathological models are rejected cheaply.
+func (w *WriteAuthorizationModelCommand) verifyLimits(model *openfgav1.AuthorizationModel) error {
+	for _, typedef := range model.GetTypeDefinitions() {
+		relations := typedef.GetRelations()
+		if w.maxRelationsPerTypeDefinition > 0 && len(relations) > w.maxRelationsPerTypeDefinition {
+			return serverErrors.ExceededAuthorizationModelLimit("maxRelationsPerTypeDefinition",
+				fmt.Sprintf("type '%s'", typedef.GetType()), len(relations), w.maxRelationsPerTypeDefinition)
+		}
+
+		if w.maxRewriteDepth == 0 {
+			continue
+		}
+
+		// sorted, so that the same relation is reported for the same model
+		names := maps.Keys(relations)
+		sort.Strings(names)
+
+		for _, name := range names {
+			if depth := rewriteDepth(relations[name]); depth > w.maxRewriteDepth {
+				return serverErrors.ExceededAuthorizationModelLimit("maxAuthorizationModelRewriteDepth",
+					fmt.Sprintf("relation '%s#%s'", typedef.GetType(), name), depth, w.maxRewriteDepth)
+			}
+		}
+	}
+
+	if w.maxConditionExpressionSize > 0 {
+		names := maps.Keys(model.GetConditions())
+		sort.Strings(names)
+
+		for _, name := range names {
+			size := len(model.GetConditions()[name].GetExpression())
+			if size > w.maxConditionExpressionSize {
+				return serverErrors.ExceededAuthorizationModelLimit("maxConditionExpressionSizeInBytes",
+					fmt.Sprintf("condition '%s'", name), size, w.maxConditionExpressionSize)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rewriteDepth returns the number of nested rewrites of the rewrite, which is 1 for a direct, computed
+// or tuple to userset rewrite.
+func rewriteDepth(rewrite *openfgav1.Userset) int {
+	var children []*openfgav1.Userset
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_Union:
+		children = rw.Union.GetChild()
+	case *openfgav1.Userset_Intersection:
+		children = rw.Intersection.GetChild()
+	case *openfgav1.Userset_Difference:
+		children = []*openfgav1.Userset{rw.Difference.GetBase(), rw.Difference.GetSubtract()}
+	}
+
+	depth := 0
+	for _, child := range children {
+		depth = max(depth, rewriteDepth(child))
+	}
+
+	return depth + 1
+}
+
+func formatCycles(cycles [][]string) []string {
+	formatted := make([]string, 0, len(cycles))
+	for _, cycle := range cycles {
+		formatted = append(formatted, strings.Join(cycle, " -> "))
+	}
+
+	return formatted
+}
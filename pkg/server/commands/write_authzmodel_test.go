@@ -9,8 +9,15 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	mockstorage "github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/testutils"
 	"github.com/openfga/openfga/pkg/typesystem"
 )
 
@@ -57,3 +64,187 @@ func TestWriteAuthorizationModel(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteAuthorizationModelWarnsAboutRuntimeCycles(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+	mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(nil)
+
+	core, logs := observer.New(zap.WarnLevel)
+	cmd := NewWriteAuthorizationModelCommand(mockDatastore,
+		WithWriteAuthModelLogger(&logger.ZapLogger{Logger: zap.New(core)}),
+	)
+
+	model := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
+type user
+
+type group
+  relations
+	define member: [user, group#member]`)
+
+	_, err := cmd.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		TypeDefinitions: model.GetTypeDefinitions(),
+		SchemaVersion:   model.GetSchemaVersion(),
+	})
+	require.NoError(t, err)
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	require.Equal(t, []interface{}{"group#member -> group#member"}, entries[0].ContextMap()["cycles"])
+}
+
+func TestWriteAuthorizationModelPrecompilesTheModel(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+	mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(nil)
+
+	resolver := typesystem.NewMemoizedTypesystemResolver(mockDatastore)
+	defer resolver.Stop()
+
+	cmd := NewWriteAuthorizationModelCommand(mockDatastore, WithWriteAuthModelTypesystemCache(resolver))
+	res, err := cmd.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "user"}},
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+	})
+	require.NoError(t, err)
+
+	// the model is resolved without reading it from the datastore
+	typesys, err := resolver.Resolve(ctx, storeID, res.GetAuthorizationModelId())
+	require.NoError(t, err)
+	require.Equal(t, res.GetAuthorizationModelId(), typesys.GetAuthorizationModelID())
+}
+
+func TestWriteAuthorizationModelGeneratesCollidingIDsAgain(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+	ids := []string{ulid.Make().String(), ulid.Make().String()}
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+	gomock.InOrder(
+		mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(storage.ErrCollision),
+		mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(nil),
+	)
+
+	resolver := typesystem.NewMemoizedTypesystemResolver(mockDatastore)
+	defer resolver.Stop()
+
+	cmd := NewWriteAuthorizationModelCommand(mockDatastore,
+		WithWriteAuthModelTypesystemCache(resolver),
+		WithWriteAuthModelIDGenerator(&sequenceGenerator{ids: ids}),
+	)
+	res, err := cmd.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		TypeDefinitions: []*openfgav1.TypeDefinition{{Type: "user"}},
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ids[1], res.GetAuthorizationModelId())
+
+	// the model is cached with the ID it was written with
+	typesys, err := resolver.Resolve(ctx, storeID, ids[1])
+	require.NoError(t, err)
+	require.Equal(t, ids[1], typesys.GetAuthorizationModelID())
+}
+
+func TestWriteAuthorizationModelLimits(t *testing.T) {
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().AnyTimes().Return(100)
+	mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).AnyTimes().Return(nil)
+
+	model := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
+type user
+
+type document
+  relations
+	define owner: [user]
+	define editor: [user] or owner
+	define viewer: ([user] or editor) but not blocked
+	define blocked: [user with recent]
+
+condition recent(age: int) {
+  age < 10
+}`)
+
+	testCases := map[string]struct {
+		opts          []WriteAuthModelOption
+		expectedError string
+	}{
+		`within_the_limits`: {
+			opts: []WriteAuthModelOption{
+				WithWriteAuthModelMaxRelationsPerTypeDefinition(4),
+				WithWriteAuthModelMaxRewriteDepth(3),
+				WithWriteAuthModelMaxConditionExpressionSize(len("age < 10")),
+			},
+		},
+		`too_many_relations`: {
+			opts:          []WriteAuthModelOption{WithWriteAuthModelMaxRelationsPerTypeDefinition(3)},
+			expectedError: "model exceeds the 'maxRelationsPerTypeDefinition' limit at type 'document': 4 vs 3",
+		},
+		`too_deep_rewrite`: {
+			opts:          []WriteAuthModelOption{WithWriteAuthModelMaxRewriteDepth(2)},
+			expectedError: "model exceeds the 'maxAuthorizationModelRewriteDepth' limit at relation 'document#viewer': 3 vs 2",
+		},
+		`too_large_condition_expression`: {
+			opts:          []WriteAuthModelOption{WithWriteAuthModelMaxConditionExpressionSize(4)},
+			expectedError: "model exceeds the 'maxConditionExpressionSizeInBytes' limit at condition 'recent': 8 vs 4",
+		},
+	}
+
+	for name, test := range testCases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			cmd := NewWriteAuthorizationModelCommand(mockDatastore, test.opts...)
+			_, err := cmd.Execute(ctx, &openfgav1.WriteAuthorizationModelRequest{
+				StoreId:         storeID,
+				TypeDefinitions: model.GetTypeDefinitions(),
+				SchemaVersion:   model.GetSchemaVersion(),
+				Conditions:      model.GetConditions(),
+			})
+			if test.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Equal(t, codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), status.Code(err))
+			require.ErrorContains(t, err, test.expectedError)
+		})
+	}
+}
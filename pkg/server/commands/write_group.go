@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/pkg/logger"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+)
+
+// WriteGroupMode is how the writes of a group were applied.
+type WriteGroupMode string
+
+const (
+	// WriteGroupAtomic is the mode of the groups committed in a single transaction.
+	WriteGroupAtomic WriteGroupMode = "atomic"
+
+	// WriteGroupSaga is the mode of the groups applied one store after the other, because their
+	// stores can't be written in a single transaction.
+	WriteGroupSaga WriteGroupMode = "saga"
+)
+
+// WriteGroupResult is the outcome of a write group.
+type WriteGroupResult struct {
+	// Mode is how the writes were applied.
+	Mode WriteGroupMode
+
+	// ChangeULIDs are the ULIDs of the first change of the write of each store, by store.
+	ChangeULIDs map[string]string
+}
+
+// WriteGroupCommand applies the writes of several stores as a group, e.g. to keep mirrored stores in
+// sync. Instances may be safely shared by multiple goroutines.
+type WriteGroupCommand struct {
+	logger      logger.Logger
+	datastore   storage.OpenFGADatastore
+	write       *WriteCommand
+	writeOpts   []WriteCommandOption
+	sagaEnabled bool
+}
+
+type WriteGroupCmdOption func(*WriteGroupCommand)
+
+// WithWriteGroupCmdWriteOptions sets the options of the WriteCommand that the writes of the group are
+// validated and admitted like.
+func WithWriteGroupCmdWriteOptions(opts ...WriteCommandOption) WriteGroupCmdOption {
+	return func(c *WriteGroupCommand) {
+		c.writeOpts = append(c.writeOpts, opts...)
+	}
+}
+
+// WithWriteGroupCmdSagaEnabled allows the groups whose stores can't be written in a single
+// transaction to be applied one store after the other, with compensations. The compensations are
+// only kept in memory: if the server stops in the middle of such a group, the stores already written
+// aren't compensated, and stay diverged from the others. If false, these groups fail before any
+// store is written.
+func WithWriteGroupCmdSagaEnabled(enabled bool) WriteGroupCmdOption {
+	return func(c *WriteGroupCommand) {
+		c.sagaEnabled = enabled
+	}
+}
+
+// NewWriteGroupCommand creates a WriteGroupCommand.
+func NewWriteGroupCommand(datastore storage.OpenFGADatastore, opts ...WriteGroupCmdOption) *WriteGroupCommand {
+	cmd := &WriteGroupCommand{
+		datastore: datastore,
+	}
+
+	for _, opt := range opts {
+		opt(cmd)
+	}
+
+	cmd.write = NewWriteCommand(datastore, cmd.writeOpts...)
+	cmd.logger = cmd.write.logger
+	return cmd
+}
+
+// compensation undoes the write of a store: it deletes the tuples that were written, and writes back
+// the tuples that were deleted, with their conditions.
+type compensation struct {
+	store   string
+	deletes storage.Deletes
+	writes  storage.Writes
+}
+
+// Execute validates and admits the write of each store like WriteCommand.Execute, and applies them
+// all or none. Each store appears at most once.
+//
+// The writes are committed in a single transaction if the datastore supports it for their stores,
+// see storage.MultiStoreWriteBackend. Otherwise, if WithWriteGroupCmdSagaEnabled is set to true, they
+// are applied one store after the other, keeping a log in memory of how to compensate each of them, and if a store fails, the stores already applied are
+// compensated in the reverse order. The compensation of a store fails if its tuples were changed in
+// the meantime, in which case the error names the stores that couldn't be compensated. The result is
+// returned once the writes were validated, also if they failed.
+//
+// The first change of the write of each store has a ULID of its own, which the result has, e.g. for
+// the consistency tokens of the writes.
+func (c *WriteGroupCommand) Execute(ctx context.Context, reqs []*openfgav1.WriteRequest) (*WriteGroupResult, error) {
+	ctx, span := tracer.Start(ctx, "WriteGroupCommand.Execute")
+	defer span.End()
+
+	if len(reqs) == 0 {
+		return nil, serverErrors.InvalidWriteInput
+	}
+
+	stores := make(map[string]struct{}, len(reqs))
+	tuples := 0
+	for _, req := range reqs {
+		if _, ok := stores[req.GetStoreId()]; ok {
+			return nil, serverErrors.ValidationError(fmt.Errorf("the store '%s' appears more than once in the write group", req.GetStoreId()))
+		}
+		stores[req.GetStoreId()] = struct{}{}
+		tuples += len(req.GetDeletes().GetTupleKeys()) + len(req.GetWrites().GetTupleKeys())
+	}
+
+	if tuples > c.datastore.MaxTuplesPerWrite() {
+		return nil, serverErrors.ExceededEntityLimit("write operations", c.datastore.MaxTuplesPerWrite())
+	}
+
+	result := &WriteGroupResult{ChangeULIDs: make(map[string]string, len(reqs))}
+	writes := make([]storage.StoreWrite, 0, len(reqs))
+	for _, req := range reqs {
+		admitted, err := c.write.validateAndAdmit(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		changeID := ulid.Make().String()
+		result.ChangeULIDs[admitted.GetStoreId()] = changeID
+
+		writes = append(writes, storage.StoreWrite{
+			Store:       admitted.GetStoreId(),
+			Deletes:     admitted.GetDeletes().GetTupleKeys(),
+			Writes:      admitted.GetWrites().GetTupleKeys(),
+			ChangeULIDs: map[int]string{0: changeID},
+		})
+	}
+
+	result.Mode = WriteGroupAtomic
+	err := c.datastore.WriteStores(ctx, writes)
+	if err == nil {
+		return result, nil
+	}
+	if !errors.Is(err, storage.ErrMultiStoreWriteNotSupported) {
+		return result, serverErrors.HandleError("", err)
+	}
+
+	if !c.sagaEnabled {
+		return nil, serverErrors.WriteGroupNotAtomic()
+	}
+
+	result.Mode = WriteGroupSaga
+	return result, c.executeSaga(ctx, writes)
+}
+
+// executeSaga applies the writes one store after the other, and compensates the stores already
+// applied if one fails.
+func (c *WriteGroupCommand) executeSaga(ctx context.Context, writes []storage.StoreWrite) error {
+	ctx, span := tracer.Start(ctx, "WriteGroupCommand.executeSaga")
+	defer span.End()
+
+	// the tuples to delete are read before anything is written, so that they can be written back
+	// with their conditions, and so that a missing tuple fails the group before any store is written
+	compensations := make([]compensation, 0, len(writes))
+	for _, w := range writes {
+		comp, err := c.compensationOf(ctx, w)
+		if err != nil {
+			return err
+		}
+		compensations = append(compensations, comp)
+	}
+
+	for i, w := range writes {
+		err := c.datastore.Write(storage.ContextWithChangeULIDs(ctx, w.ChangeULIDs), w.Store, w.Deletes, w.Writes)
+		if err == nil {
+			continue
+		}
+
+		c.logger.WarnWithContext(ctx, "the write of a store of a write group failed, compensating the stores already written",
+			zap.String("store_id", w.Store),
+			zap.Int("written_stores", i),
+			zap.Error(err))
+
+		if uncompensated := c.compensate(ctx, compensations[:i]); len(uncompensated) > 0 {
+			return serverErrors.WriteGroupCompensationFailed(w.Store, uncompensated)
+		}
+
+		return serverErrors.HandleError("", err)
+	}
+
+	return nil
+}
+
+// compensationOf returns the compensation of the write of a store, after checking that its tuples to
+// delete exist.
+func (c *WriteGroupCommand) compensationOf(ctx context.Context, w storage.StoreWrite) (compensation, error) {
+	comp := compensation{store: w.Store}
+
+	for _, tk := range w.Deletes {
+		t, err := c.datastore.ReadUserTuple(ctx, w.Store, tupleUtils.TupleKeyWithoutConditionToTupleKey(tk))
+		if errors.Is(err, storage.ErrNotFound) {
+			return comp, serverErrors.WriteFailedDueToInvalidInput(
+				storage.InvalidWriteInputError(tk, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE))
+		}
+		if err != nil {
+			return comp, serverErrors.HandleError("", err)
+		}
+		comp.writes = append(comp.writes, t.GetKey())
+	}
+
+	for _, tk := range w.Writes {
+		comp.deletes = append(comp.deletes, tupleUtils.TupleKeyToTupleKeyWithoutCondition(tk))
+	}
+
+	return comp, nil
+}
+
+// compensate applies the compensations in the reverse order, and returns the stores whose
+// compensation failed. The compensations aren't cancelled with the request, and their changes get
+// ULIDs of their own.
+func (c *WriteGroupCommand) compensate(ctx context.Context, compensations []compensation) []string {
+	ctx = storage.ContextWithChangeULIDs(context.WithoutCancel(ctx), nil)
+
+	var uncompensated []string
+	for i := len(compensations) - 1; i >= 0; i-- {
+		comp := compensations[i]
+
+		if err := c.datastore.Write(ctx, comp.store, comp.deletes, comp.writes); err != nil {
+			c.logger.ErrorWithContext(ctx, "failed to compensate the write of a store of a write group",
+				zap.String("store_id", comp.store),
+				zap.Error(err))
+			uncompensated = append(uncompensated, comp.store)
+			continue
+		}
+
+		c.logger.InfoWithContext(ctx, "compensated the write of a store of a write group", zap.String("store_id", comp.store))
+	}
+
+	return uncompensated
+}
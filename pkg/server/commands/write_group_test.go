@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockstorage "github.com/openfga/openfga/internal/mocks"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// withoutChangeULIDs matches the writes of the stores, ignoring the generated ULIDs of their changes.
+func withoutChangeULIDs(expected []storage.StoreWrite) gomock.Matcher {
+	return gomock.Cond(func(x any) bool {
+		writes, ok := x.([]storage.StoreWrite)
+		if !ok || len(writes) != len(expected) {
+			return false
+		}
+
+		for i, w := range writes {
+			w.ChangeULIDs = nil
+			if !reflect.DeepEqual(w, expected[i]) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestWriteGroupCommand(t *testing.T) {
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+
+	store1 := ulid.Make().String()
+	store2 := ulid.Make().String()
+
+	anne := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	bob := tuple.NewTupleKey("document:1", "viewer", "user:bob")
+
+	reqs := []*openfgav1.WriteRequest{
+		{
+			StoreId:              store1,
+			AuthorizationModelId: model.GetId(),
+			Writes:               &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{anne}},
+		},
+		{
+			StoreId:              store2,
+			AuthorizationModelId: model.GetId(),
+			Writes:               &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{anne}},
+			Deletes:              &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(bob)}},
+		},
+	}
+
+	expectedWrites := []storage.StoreWrite{
+		{Store: store1, Writes: []*openfgav1.TupleKey{anne}},
+		{Store: store2, Deletes: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(bob)}, Writes: []*openfgav1.TupleKey{anne}},
+	}
+
+	newDatastore := func(t *testing.T) *mockstorage.MockOpenFGADatastore {
+		mockController := gomock.NewController(t)
+		t.Cleanup(mockController.Finish)
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().MaxTuplesPerWrite().AnyTimes().Return(100)
+		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), gomock.Any(), model.GetId()).AnyTimes().Return(model, nil)
+		return mockDatastore
+	}
+
+	t.Run("atomic", func(t *testing.T) {
+		mockDatastore := newDatastore(t)
+		mockDatastore.EXPECT().WriteStores(gomock.Any(), withoutChangeULIDs(expectedWrites)).Return(nil)
+
+		res, err := NewWriteGroupCommand(mockDatastore).Execute(context.Background(), reqs)
+		require.NoError(t, err)
+		require.Equal(t, WriteGroupAtomic, res.Mode)
+
+		// each store has its own ULID, since the ULIDs of the changes are unique across the stores
+		require.Len(t, res.ChangeULIDs, 2)
+		require.NotEqual(t, res.ChangeULIDs[store1], res.ChangeULIDs[store2])
+	})
+
+	t.Run("saga", func(t *testing.T) {
+		var changeIDs []string
+		recordChangeID := func(ctx context.Context, _ string, _ storage.Deletes, _ storage.Writes) error {
+			changeIDs = append(changeIDs, storage.ChangeULIDFromContext(ctx))
+			return nil
+		}
+
+		mockDatastore := newDatastore(t)
+		gomock.InOrder(
+			mockDatastore.EXPECT().WriteStores(gomock.Any(), withoutChangeULIDs(expectedWrites)).Return(storage.ErrMultiStoreWriteNotSupported),
+			mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), store2, bob).Return(&openfgav1.Tuple{Key: bob}, nil),
+			mockDatastore.EXPECT().Write(gomock.Any(), store1, nil, expectedWrites[0].Writes).DoAndReturn(recordChangeID),
+			mockDatastore.EXPECT().Write(gomock.Any(), store2, expectedWrites[1].Deletes, expectedWrites[1].Writes).DoAndReturn(recordChangeID),
+		)
+
+		res, err := NewWriteGroupCommand(mockDatastore, WithWriteGroupCmdSagaEnabled(true)).Execute(context.Background(), reqs)
+		require.NoError(t, err)
+		require.Equal(t, WriteGroupSaga, res.Mode)
+		require.Equal(t, []string{res.ChangeULIDs[store1], res.ChangeULIDs[store2]}, changeIDs)
+	})
+
+	t.Run("saga_compensates_the_stores_written_before_the_failed_one", func(t *testing.T) {
+		mockDatastore := newDatastore(t)
+		gomock.InOrder(
+			mockDatastore.EXPECT().WriteStores(gomock.Any(), withoutChangeULIDs(expectedWrites)).Return(storage.ErrMultiStoreWriteNotSupported),
+			mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), store2, bob).Return(&openfgav1.Tuple{Key: bob}, nil),
+			mockDatastore.EXPECT().Write(gomock.Any(), store1, nil, expectedWrites[0].Writes).Return(nil),
+			mockDatastore.EXPECT().Write(gomock.Any(), store2, expectedWrites[1].Deletes, expectedWrites[1].Writes).
+				Return(storage.InvalidWriteInputError(anne, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE)),
+			mockDatastore.EXPECT().Write(gomock.Any(), store1, []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(anne)}, nil).Return(nil),
+		)
+
+		res, err := NewWriteGroupCommand(mockDatastore, WithWriteGroupCmdSagaEnabled(true)).Execute(context.Background(), reqs)
+		require.Equal(t, WriteGroupSaga, res.Mode)
+
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonWriteFailedDueToInvalidInput, reason)
+	})
+
+	t.Run("saga_reports_the_stores_that_could_not_be_compensated", func(t *testing.T) {
+		mockDatastore := newDatastore(t)
+		gomock.InOrder(
+			mockDatastore.EXPECT().WriteStores(gomock.Any(), withoutChangeULIDs(expectedWrites)).Return(storage.ErrMultiStoreWriteNotSupported),
+			mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), store2, bob).Return(&openfgav1.Tuple{Key: bob}, nil),
+			mockDatastore.EXPECT().Write(gomock.Any(), store1, nil, expectedWrites[0].Writes).Return(nil),
+			mockDatastore.EXPECT().Write(gomock.Any(), store2, expectedWrites[1].Deletes, expectedWrites[1].Writes).Return(storage.ErrTransactionalWriteFailed),
+			mockDatastore.EXPECT().Write(gomock.Any(), store1, gomock.Any(), nil).
+				Return(storage.InvalidWriteInputError(anne, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE)),
+		)
+
+		_, err := NewWriteGroupCommand(mockDatastore, WithWriteGroupCmdSagaEnabled(true)).Execute(context.Background(), reqs)
+		require.Equal(t, serverErrors.WriteGroupCompensationFailed(store2, []string{store1}), err)
+	})
+
+	t.Run("saga_fails_before_writing_if_a_tuple_to_delete_does_not_exist", func(t *testing.T) {
+		mockDatastore := newDatastore(t)
+		gomock.InOrder(
+			mockDatastore.EXPECT().WriteStores(gomock.Any(), withoutChangeULIDs(expectedWrites)).Return(storage.ErrMultiStoreWriteNotSupported),
+			mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), store2, bob).Return(nil, storage.ErrNotFound),
+		)
+
+		_, err := NewWriteGroupCommand(mockDatastore, WithWriteGroupCmdSagaEnabled(true)).Execute(context.Background(), reqs)
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonWriteFailedDueToInvalidInput, reason)
+	})
+
+	t.Run("groups_that_can_not_be_atomic_are_rejected_unless_the_sagas_are_enabled", func(t *testing.T) {
+		mockDatastore := newDatastore(t)
+		mockDatastore.EXPECT().WriteStores(gomock.Any(), withoutChangeULIDs(expectedWrites)).Return(storage.ErrMultiStoreWriteNotSupported)
+
+		_, err := NewWriteGroupCommand(mockDatastore).Execute(context.Background(), reqs)
+		require.Equal(t, serverErrors.WriteGroupNotAtomic(), err)
+	})
+
+	t.Run("invalid_groups", func(t *testing.T) {
+		mockDatastore := newDatastore(t)
+		cmd := NewWriteGroupCommand(mockDatastore)
+
+		_, err := cmd.Execute(context.Background(), nil)
+		require.Equal(t, serverErrors.InvalidWriteInput, err)
+
+		_, err = cmd.Execute(context.Background(), []*openfgav1.WriteRequest{reqs[0], reqs[0]})
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonValidationError, reason)
+
+		_, err = cmd.Execute(context.Background(), []*openfgav1.WriteRequest{{
+			StoreId:              store1,
+			AuthorizationModelId: model.GetId(),
+			Writes:               &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("folder:1", "viewer", "user:anne")}},
+		}})
+		reason, _ = serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonValidationError, reason)
+	})
+}
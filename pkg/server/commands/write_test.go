@@ -3,6 +3,7 @@ package commands
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/oklog/ulid/v2"
@@ -16,6 +17,8 @@ import (
 
 	mockstorage "github.com/openfga/openfga/internal/mocks"
 	"github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/server/admission"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/testutils"
@@ -265,7 +268,10 @@ type document
 		},
 	})
 
-	require.ErrorIs(t, err, status.Error(codes.Aborted, storage.ErrTransactionalWriteFailed.Error()))
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.Aborted, st.Code())
+	require.Equal(t, storage.ErrTransactionalWriteFailed.Error(), st.Message())
 	require.Nil(t, resp)
 }
 
@@ -528,3 +534,130 @@ func TestValidateConditionsInTuples(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteWithAdmitter(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	model := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
+type user
+
+type document
+  relations
+	define viewer: [user]`)
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTuplesPerWrite().AnyTimes().Return(10)
+	mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(model, nil)
+
+	storeID := ulid.Make().String()
+	req := &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:Budget", "viewer", "user:jon")},
+		},
+	}
+
+	t.Run("mutated_tuples_are_written", func(t *testing.T) {
+		mockDatastore.EXPECT().
+			Write(gomock.Any(), storeID, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ storage.Deletes, writes storage.Writes) error {
+				require.Len(t, writes, 1)
+				require.Equal(t, "document:budget", writes[0].GetObject())
+				return nil
+			})
+
+		cmd := NewWriteCommand(mockDatastore, WithWriteCmdAdmitter(admission.AdmitterFunc(
+			func(_ context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+				mutated := proto.Clone(req).(*openfgav1.WriteRequest)
+				for _, tk := range mutated.GetWrites().GetTupleKeys() {
+					tk.Object = strings.ToLower(tk.GetObject())
+				}
+				return mutated, nil
+			})))
+
+		_, err := cmd.Execute(context.Background(), req)
+		require.NoError(t, err)
+	})
+
+	t.Run("mutated_tuples_are_validated", func(t *testing.T) {
+		cmd := NewWriteCommand(mockDatastore, WithWriteCmdAdmitter(admission.AdmitterFunc(
+			func(_ context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+				mutated := proto.Clone(req).(*openfgav1.WriteRequest)
+				mutated.GetWrites().GetTupleKeys()[0].Relation = "editor"
+				return mutated, nil
+			})))
+
+		_, err := cmd.Execute(context.Background(), req)
+		require.ErrorContains(t, err, "editor")
+	})
+
+	t.Run("rejected_tuples_are_not_written", func(t *testing.T) {
+		cmd := NewWriteCommand(mockDatastore, WithWriteCmdAdmitter(admission.AdmitterFunc(
+			func(context.Context, *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+				return nil, serverErrors.WriteRejected("protected object")
+			})))
+
+		_, err := cmd.Execute(context.Background(), req)
+		require.ErrorIs(t, err, serverErrors.WriteRejected("protected object"))
+	})
+
+	t.Run("invalid_requests_are_not_admitted", func(t *testing.T) {
+		cmd := NewWriteCommand(mockDatastore, WithWriteCmdAdmitter(admission.AdmitterFunc(
+			func(context.Context, *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+				require.Fail(t, "the admitter must not be called")
+				return nil, nil
+			})))
+
+		_, err := cmd.Execute(context.Background(), &openfgav1.WriteRequest{StoreId: storeID})
+		require.ErrorIs(t, err, serverErrors.InvalidWriteInput)
+	})
+}
+
+func TestWriteWithTupleKeyRules(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	model := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
+type user
+
+type document
+  relations
+	define viewer: [user]`)
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().MaxTuplesPerWrite().AnyTimes().Return(10)
+	mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(model, nil)
+
+	rules, err := validation.NewTupleKeyRules(64, 16, []string{"document=uuid"})
+	require.NoError(t, err)
+	cmd := NewWriteCommand(mockDatastore, WithWriteCmdTupleKeyRules(rules))
+
+	storeID := ulid.Make().String()
+	write := func(tk *openfgav1.TupleKey) error {
+		_, err := cmd.Execute(context.Background(), &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes:  &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tk}},
+		})
+		return err
+	}
+
+	t.Run("valid_tuples_are_written", func(t *testing.T) {
+		mockDatastore.EXPECT().Write(gomock.Any(), storeID, gomock.Any(), gomock.Any()).Return(nil)
+
+		err := write(tuple.NewTupleKey("document:0b5c1a4e-7f3d-4c1e-9a2b-6d8e0f1a2b3c", "viewer", "user:jon"))
+		require.NoError(t, err)
+	})
+
+	t.Run("object_ids_not_matching_the_pattern_are_rejected", func(t *testing.T) {
+		err := write(tuple.NewTupleKey("document:budget", "viewer", "user:jon"))
+		require.ErrorContains(t, err, "doesn't match the ID pattern of type 'document'")
+	})
+
+	t.Run("users_longer_than_the_maximum_length_are_rejected", func(t *testing.T) {
+		err := write(tuple.NewTupleKey("document:0b5c1a4e-7f3d-4c1e-9a2b-6d8e0f1a2b3c", "viewer", "user:"+strings.Repeat("j", 16)))
+		require.ErrorContains(t, err, "the 'user' field exceeds the maximum length of 16 bytes")
+	})
+}
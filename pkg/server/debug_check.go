@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// debugCheckMaxEvents is the maximum number of events of the trace of a debug Check.
+const debugCheckMaxEvents = 10000
+
+// DebugCheckResult is the result of a Check request replayed by DebugCheck.
+type DebugCheckResult struct {
+	// Allowed is the result of the request.
+	Allowed bool `json:"allowed"`
+
+	// Error is the error of the request, if it failed.
+	Error string `json:"error,omitempty"`
+
+	// Duration is how long the request took.
+	Duration time.Duration `json:"duration_ns"`
+
+	// Headers are the response headers of the request, e.g. its DatastoreQueryCountHeader.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Events are the events of the resolution of the request, in their order.
+	Events []graph.CheckTraceEvent `json:"events"`
+
+	// Truncated is true if the request had more events than the trace keeps.
+	Truncated bool `json:"truncated"`
+}
+
+// DebugCheck replays a Check request and returns the trace of its resolution: each dispatch of a
+// sub-problem and its result, the decisions of the Check cache and the datastore queries. If
+// bypassCache is true, the request is resolved without the Check cache. A failed request isn't an
+// error of DebugCheck, its error is returned in the result.
+func (s *Server) DebugCheck(ctx context.Context, req *openfgav1.CheckRequest, bypassCache bool) *DebugCheckResult {
+	checkTrace := graph.NewCheckTrace(debugCheckMaxEvents)
+	ctx = graph.ContextWithCheckTrace(ctx, checkTrace)
+	if bypassCache {
+		ctx = graph.ContextWithoutCheckCache(ctx)
+	}
+
+	// collects the response headers, as the request isn't served over gRPC
+	headers := &debugCheckHeaders{md: metadata.MD{}}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, headers)
+
+	start := time.Now()
+	resp, err := s.Check(ctx, req)
+
+	result := &DebugCheckResult{
+		Allowed:  resp.GetAllowed(),
+		Duration: time.Since(start),
+		Headers:  headers.values(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Events, result.Truncated = checkTrace.Events()
+
+	return result
+}
+
+// DebugCheckRequest is the body of a request of the DebugCheckHandler.
+type DebugCheckRequest struct {
+	// Request is the Check request to replay, in the JSON of the HTTP API.
+	Request json.RawMessage `json:"request"`
+
+	// BypassCache resolves the request without the Check cache.
+	BypassCache bool `json:"bypass_cache"`
+}
+
+// DebugCheckHandler returns the handler that replays the Check request of the DebugCheckRequest
+// body of a POST request with DebugCheck, and returns its DebugCheckResult.
+func (s *Server) DebugCheckHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body DebugCheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, fmt.Sprintf("invalid debug check request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var req openfgav1.CheckRequest
+		if err := protojson.Unmarshal(body.Request, &req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid check request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.DebugCheck(r.Context(), &req, body.BypassCache))
+	})
+}
+
+var _ grpc.ServerTransportStream = (*debugCheckHeaders)(nil)
+
+// debugCheckHeaders is the transport stream of a debug Check, which keeps its headers.
+type debugCheckHeaders struct {
+	mu sync.Mutex
+	md metadata.MD
+}
+
+func (h *debugCheckHeaders) Method() string {
+	return openfgav1.OpenFGAService_Check_FullMethodName
+}
+
+func (h *debugCheckHeaders) SetHeader(md metadata.MD) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.md = metadata.Join(h.md, md)
+	return nil
+}
+
+func (h *debugCheckHeaders) SendHeader(md metadata.MD) error {
+	return h.SetHeader(md)
+}
+
+func (h *debugCheckHeaders) SetTrailer(metadata.MD) error {
+	return nil
+}
+
+// values returns the last value of each header.
+func (h *debugCheckHeaders) values() map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	values := make(map[string]string, len(h.md))
+	for key, value := range h.md {
+		values[key] = value[len(value)-1]
+	}
+	return values
+}
+
+var _ storage.RelationshipTupleReader = (*checkTraceTupleReader)(nil)
+
+// checkTraceTupleReader is a tuple reader that records its queries in the trace of a Check.
+type checkTraceTupleReader struct {
+	storage.RelationshipTupleReader
+
+	checkTrace *graph.CheckTrace
+}
+
+func newCheckTraceTupleReader(inner storage.RelationshipTupleReader, checkTrace *graph.CheckTrace) *checkTraceTupleReader {
+	return &checkTraceTupleReader{RelationshipTupleReader: inner, checkTrace: checkTrace}
+}
+
+// record records the query that started at start.
+func (r *checkTraceTupleReader) record(query string, start time.Time, err error) {
+	event := graph.CheckTraceEvent{
+		Kind:     graph.CheckTraceDatastoreQuery,
+		Query:    query,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	r.checkTrace.Add(event)
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (r *checkTraceTupleReader) Read(ctx context.Context, store string, tk *openfgav1.TupleKey) (storage.TupleIterator, error) {
+	start := time.Now()
+	iter, err := r.RelationshipTupleReader.Read(ctx, store, tk)
+	r.record("Read "+tuple.TupleKeyToString(tk), start, err)
+	return iter, err
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (r *checkTraceTupleReader) ReadUserTuple(ctx context.Context, store string, tk *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	start := time.Now()
+	t, err := r.RelationshipTupleReader.ReadUserTuple(ctx, store, tk)
+	r.record("ReadUserTuple "+tuple.TupleKeyToString(tk), start, err)
+	return t, err
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (r *checkTraceTupleReader) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+	start := time.Now()
+	iter, err := r.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter)
+
+	userTypes := make([]string, 0, len(filter.AllowedUserTypeRestrictions))
+	for _, ref := range filter.AllowedUserTypeRestrictions {
+		userTypes = append(userTypes, tuple.ToObjectRelationString(ref.GetType(), ref.GetRelation()))
+	}
+	r.record(fmt.Sprintf("ReadUsersetTuples %s#%s@[%s]", filter.Object, filter.Relation, strings.Join(userTypes, ",")), start, err)
+	return iter, err
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (r *checkTraceTupleReader) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
+	start := time.Now()
+	iter, err := r.RelationshipTupleReader.ReadStartingWithUser(ctx, store, filter)
+
+	users := make([]string, 0, len(filter.UserFilter))
+	for _, user := range filter.UserFilter {
+		users = append(users, tuple.ToObjectRelationString(user.GetObject(), user.GetRelation()))
+	}
+	r.record(fmt.Sprintf("ReadStartingWithUser %s#%s@[%s]", filter.ObjectType, filter.Relation, strings.Join(users, ",")), start, err)
+	return iter, err
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (r *checkTraceTupleReader) ReadPage(ctx context.Context, store string, tk *openfgav1.TupleKey, opts storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+	start := time.Now()
+	tuples, token, err := r.RelationshipTupleReader.ReadPage(ctx, store, tk, opts)
+	r.record("ReadPage "+tuple.TupleKeyToString(tk), start, err)
+	return tuples, token, err
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestDebugCheck(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "debug-check"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type group
+  relations
+    define member: [user]
+type document
+  relations
+    define viewer: [group#member]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+			tuple.NewTupleKey("group:eng", "member", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	req := &openfgav1.CheckRequest{
+		StoreId:  storeID,
+		TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+	}
+
+	eventKinds := func(result *DebugCheckResult) map[string]int {
+		kinds := map[string]int{}
+		for _, event := range result.Events {
+			kinds[event.Kind]++
+		}
+		return kinds
+	}
+
+	t.Run("traces_the_resolution", func(t *testing.T) {
+		result := s.DebugCheck(ctx, req, false)
+		require.True(t, result.Allowed)
+		require.Empty(t, result.Error)
+		require.False(t, result.Truncated)
+
+		kinds := eventKinds(result)
+		require.Positive(t, kinds[graph.CheckTraceDispatch])
+		require.Equal(t, kinds[graph.CheckTraceDispatch], kinds[graph.CheckTraceResolved])
+		require.Positive(t, kinds[graph.CheckTraceCacheMiss])
+		require.Positive(t, kinds[graph.CheckTraceDatastoreQuery])
+
+		var dispatched bool
+		for _, event := range result.Events {
+			if event.Kind == graph.CheckTraceDispatch && event.TupleKey == "group:eng#member@user:anne" {
+				dispatched = true
+			}
+		}
+		require.True(t, dispatched)
+
+		// the replay has the cached results
+		require.Positive(t, eventKinds(s.DebugCheck(ctx, req, false))[graph.CheckTraceCacheHit])
+	})
+
+	t.Run("bypasses_the_cache", func(t *testing.T) {
+		result := s.DebugCheck(ctx, req, true)
+		require.True(t, result.Allowed)
+
+		kinds := eventKinds(result)
+		require.Zero(t, kinds[graph.CheckTraceCacheHit])
+		require.Positive(t, kinds[graph.CheckTraceCacheSkipped])
+		require.Positive(t, kinds[graph.CheckTraceDatastoreQuery])
+	})
+
+	t.Run("returns_the_error_of_the_request", func(t *testing.T) {
+		result := s.DebugCheck(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "owner", "user:anne"),
+		}, false)
+		require.False(t, result.Allowed)
+		require.Contains(t, result.Error, "owner")
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		handler := s.DebugCheckHandler()
+
+		body := `{"request": {"store_id": "` + storeID + `", "tuple_key": {"object": "document:1", "relation": "viewer", "user": "user:anne"}}, "bypass_cache": true}`
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/check", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var result DebugCheckResult
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+		require.True(t, result.Allowed)
+		require.NotEmpty(t, result.Events)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/check", strings.NewReader(`{"request": {"store_id": 1}}`)))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/check", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
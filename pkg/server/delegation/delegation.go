@@ -0,0 +1,346 @@
+// Package delegation delegates the Check of some relations to an external evaluator, e.g. an OPA
+// policy, for the attribute-based decisions that the conditions of a model can't express. The
+// decision of the evaluator is combined with the rewrite of the relation in the model, so that the
+// other relations that reference a delegated relation, e.g. in a union or an intersection, include
+// it in their evaluation. The external evaluators are only called by the Check resolution, e.g. of
+// the Check requests and of the intersections and exclusions of ListObjects.
+package delegation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+var tracer = otel.Tracer("openfga/pkg/server/delegation")
+
+// Operator is how the decision of the external evaluator of a relation is combined with the
+// evaluation of the rewrite of the relation.
+type Operator string
+
+const (
+	// OperatorIntersection allows a Check if both the rewrite and the evaluator allow it, so that
+	// the evaluator further restricts the relation. The evaluator is only called if the rewrite
+	// allows the Check. It is the default.
+	OperatorIntersection Operator = "intersection"
+
+	// OperatorUnion allows a Check if the rewrite or the evaluator allows it. The evaluator is only
+	// called if the rewrite doesn't allow the Check.
+	OperatorUnion Operator = "union"
+
+	// OperatorOverride allows a Check if the evaluator allows it. The rewrite isn't evaluated.
+	OperatorOverride Operator = "override"
+)
+
+const (
+	// DefaultTimeout is the default timeout of a decision of a Webhook.
+	DefaultTimeout = 1 * time.Second
+
+	// maxDecisionSize is the maximum size of the response of a webhook.
+	maxDecisionSize = 1024 * 1024
+)
+
+const (
+	resultAllowed = "allowed"
+	resultDenied  = "denied"
+	resultError   = "error"
+)
+
+var evaluationDurationHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace:                       build.ProjectName,
+	Name:                            "delegated_check_duration_ms",
+	Help:                            "The duration (in ms) of the decisions of the external evaluators of the delegated relations, labeled by their result: allowed, denied or error.",
+	Buckets:                         []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000},
+	NativeHistogramBucketFactor:     1.1,
+	NativeHistogramMaxBucketNumber:  100,
+	NativeHistogramMinResetDuration: time.Hour,
+}, []string{"result"})
+
+// Request is the Check of a delegated relation that an Evaluator decides.
+type Request struct {
+	StoreID              string                 `json:"store_id"`
+	AuthorizationModelID string                 `json:"authorization_model_id"`
+	Object               string                 `json:"object"`
+	Relation             string                 `json:"relation"`
+	User                 string                 `json:"user"`
+	Context              map[string]interface{} `json:"context,omitempty"`
+}
+
+// Evaluator decides the Check of a delegated relation.
+type Evaluator interface {
+	Evaluate(ctx context.Context, req *Request) (bool, error)
+}
+
+// EvaluatorFunc is an Evaluator that calls the function.
+type EvaluatorFunc func(ctx context.Context, req *Request) (bool, error)
+
+// Evaluate implements Evaluator.
+func (f EvaluatorFunc) Evaluate(ctx context.Context, req *Request) (bool, error) {
+	return f(ctx, req)
+}
+
+// ParseRule parses a rule of the form 'type#relation=url', which delegates the relation of the type
+// to the Webhook at the URL. It returns the 'type#relation' of the rule and the URL.
+func ParseRule(rule string) (string, string, error) {
+	relation, url, ok := strings.Cut(rule, "=")
+	objectType, name, found := strings.Cut(relation, "#")
+	if !ok || !found || objectType == "" || name == "" || url == "" {
+		return "", "", fmt.Errorf("invalid delegated relation rule '%s', it must be of the form 'type#relation=url'", rule)
+	}
+
+	return relation, url, nil
+}
+
+// Decision is the body of the response of a webhook, the format of the responses of the data API of
+// OPA. The result is either a boolean or an object with an 'allowed' boolean. An undefined result
+// denies the Check.
+type Decision struct {
+	Result json.RawMessage `json:"result"`
+}
+
+// allowed returns whether the decision allows the Check.
+func (d *Decision) allowed() (bool, error) {
+	if len(d.Result) == 0 || string(d.Result) == "null" {
+		return false, nil
+	}
+
+	var allowed bool
+	if err := json.Unmarshal(d.Result, &allowed); err == nil {
+		return allowed, nil
+	}
+
+	var result struct {
+		Allowed bool `json:"allowed"`
+	}
+	if err := json.Unmarshal(d.Result, &result); err != nil {
+		return false, fmt.Errorf("invalid decision result: %w", err)
+	}
+
+	return result.Allowed, nil
+}
+
+// Webhook is an Evaluator that POSTs the Request, as the 'input' of a request of the data API of OPA,
+// to an HTTP endpoint, which responds with a Decision.
+type Webhook struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+}
+
+var _ Evaluator = (*Webhook)(nil)
+
+// WebhookOption defines an option that can be used to change the behavior of a [Webhook].
+type WebhookOption func(w *Webhook)
+
+// WithTimeout sets the timeout of a decision. A decision that times out is a failure of the webhook.
+func WithTimeout(timeout time.Duration) WebhookOption {
+	return func(w *Webhook) {
+		w.timeout = timeout
+	}
+}
+
+// WithHTTPClient sets the HTTP client that calls the webhook.
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(w *Webhook) {
+		w.client = client
+	}
+}
+
+// NewWebhook constructs a [Webhook] that calls the URL.
+func NewWebhook(url string, opts ...WebhookOption) (*Webhook, error) {
+	w := &Webhook{
+		url:     url,
+		timeout: DefaultTimeout,
+		client:  http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.url == "" {
+		return nil, fmt.Errorf("the delegated check webhook needs a URL")
+	}
+
+	if w.timeout <= 0 {
+		return nil, fmt.Errorf("the delegated check webhook timeout must be positive")
+	}
+
+	return w, nil
+}
+
+// Evaluate implements Evaluator.
+func (w *Webhook) Evaluate(ctx context.Context, req *Request) (bool, error) {
+	body, err := json.Marshal(struct {
+		Input *Request `json:"input"`
+	}{Input: req})
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var decision Decision
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDecisionSize)).Decode(&decision); err != nil {
+		return false, fmt.Errorf("invalid decision: %w", err)
+	}
+
+	return decision.allowed()
+}
+
+// delegator resolves the Check of the delegated relations with their evaluators.
+type delegator struct {
+	evaluators map[string]Evaluator
+	operator   Operator
+	logger     logger.Logger
+}
+
+// Option defines an option that can be used to change the behavior of the resolver of
+// NewCheckResolver.
+type Option func(d *delegator)
+
+// WithOperator sets how the decisions of the evaluators are combined with the rewrites of the
+// relations.
+func WithOperator(operator Operator) Option {
+	return func(d *delegator) {
+		d.operator = operator
+	}
+}
+
+// WithLogger sets the logger of the failed decisions.
+func WithLogger(l logger.Logger) Option {
+	return func(d *delegator) {
+		d.logger = l
+	}
+}
+
+// NewCheckResolver returns a resolver of a resolver chain that delegates the Check of the relations
+// of the evaluators, by 'type#relation', to the evaluators. It must be placed before the
+// CachedCheckResolver of the chain, so that the decisions of the evaluators aren't cached with the
+// rewrites of the delegated relations, although the relations that reference them are cached as usual.
+// A failed decision fails the Check with graph.ErrDelegatedCheckFailed.
+func NewCheckResolver(evaluators map[string]Evaluator, opts ...Option) (*graph.CheckResolverInterceptor, error) {
+	d := &delegator{
+		evaluators: evaluators,
+		operator:   OperatorIntersection,
+		logger:     logger.NewNoopLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	switch d.operator {
+	case OperatorIntersection, OperatorUnion, OperatorOverride:
+	default:
+		return nil, fmt.Errorf("unknown delegated check operator '%s', it must be '%s', '%s' or '%s'", d.operator, OperatorIntersection, OperatorUnion, OperatorOverride)
+	}
+
+	return graph.NewCheckResolverInterceptor(d.resolveCheck), nil
+}
+
+func (d *delegator) resolveCheck(ctx context.Context, req *graph.ResolveCheckRequest, next graph.CheckResolver) (*graph.ResolveCheckResponse, error) {
+	tk := req.GetTupleKey()
+	evaluator, ok := d.evaluators[tuple.GetType(tk.GetObject())+"#"+tk.GetRelation()]
+	if !ok {
+		return next.ResolveCheck(ctx, req)
+	}
+
+	datastoreQueryCount := req.GetRequestMetadata().DatastoreQueryCount
+	if d.operator != OperatorOverride {
+		resp, err := next.ResolveCheck(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		// the rewrite decides the Check on its own
+		if resp.GetCycleDetected() || resp.GetAllowed() == (d.operator == OperatorUnion) {
+			return resp, nil
+		}
+		datastoreQueryCount = resp.GetResolutionMetadata().DatastoreQueryCount
+	}
+
+	allowed, err := d.evaluate(ctx, evaluator, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &graph.ResolveCheckResponse{
+		Allowed: allowed,
+		ResolutionMetadata: &graph.ResolveCheckResponseMetadata{
+			DatastoreQueryCount: datastoreQueryCount,
+		},
+	}, nil
+}
+
+// evaluate returns the decision of the evaluator of a delegated relation.
+func (d *delegator) evaluate(ctx context.Context, evaluator Evaluator, req *graph.ResolveCheckRequest) (bool, error) {
+	ctx, span := tracer.Start(ctx, "DelegatedCheck")
+	defer span.End()
+
+	tk := req.GetTupleKey()
+	evaluationReq := &Request{
+		StoreID:              req.GetStoreID(),
+		AuthorizationModelID: req.GetAuthorizationModelID(),
+		Object:               tk.GetObject(),
+		Relation:             tk.GetRelation(),
+		User:                 tk.GetUser(),
+	}
+	if req.GetContext() != nil {
+		evaluationReq.Context = req.GetContext().AsMap()
+	}
+
+	start := time.Now()
+	allowed, err := evaluator.Evaluate(ctx, evaluationReq)
+
+	result := resultDenied
+	switch {
+	case err != nil:
+		result = resultError
+	case allowed:
+		result = resultAllowed
+	}
+	evaluationDurationHistogram.WithLabelValues(result).Observe(float64(time.Since(start).Milliseconds()))
+
+	if err != nil {
+		d.logger.ErrorWithContext(ctx, "the external evaluator of a delegated relation failed",
+			zap.String("store_id", evaluationReq.StoreID),
+			zap.String("tuple_key", tuple.TupleKeyToString(tk)),
+			zap.Error(err))
+		return false, fmt.Errorf("%w: %s", graph.ErrDelegatedCheckFailed, err.Error())
+	}
+
+	return allowed, nil
+}
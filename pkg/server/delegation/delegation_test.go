@@ -0,0 +1,225 @@
+package delegation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestParseRule(t *testing.T) {
+	relation, url, err := ParseRule("document#viewer=http://localhost:8181/v1/data/fga/allow?pretty=true")
+	require.NoError(t, err)
+	require.Equal(t, "document#viewer", relation)
+	require.Equal(t, "http://localhost:8181/v1/data/fga/allow?pretty=true", url)
+
+	for _, rule := range []string{"document#viewer", "document=http://localhost", "#viewer=http://localhost", "document#=http://localhost", "document#viewer="} {
+		_, _, err := ParseRule(rule)
+		require.ErrorContains(t, err, "type#relation=url", rule)
+	}
+}
+
+func TestWebhookEvaluate(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	req := &Request{
+		StoreID:  "store",
+		Object:   "document:1",
+		Relation: "viewer",
+		User:     "user:anne",
+		Context:  map[string]interface{}{"ip": "10.0.0.1"},
+	}
+
+	// newWebhook returns a webhook that calls the handler.
+	newWebhook := func(t *testing.T, handler http.HandlerFunc, opts ...WebhookOption) *Webhook {
+		srv := httptest.NewServer(handler)
+		t.Cleanup(srv.Close)
+
+		webhook, err := NewWebhook(srv.URL, opts...)
+		require.NoError(t, err)
+		return webhook
+	}
+
+	// respond returns a handler that responds with the body.
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}
+	}
+
+	t.Run("posts_the_request_as_the_input", func(t *testing.T) {
+		webhook := newWebhook(t, func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Input Request `json:"input"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Input.User != "user:anne" || body.Input.Context["ip"] != "10.0.0.1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"result": true}`))
+		})
+
+		allowed, err := webhook.Evaluate(context.Background(), req)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("decisions", func(t *testing.T) {
+		for body, expected := range map[string]bool{
+			`{"result": false}`:                      false,
+			`{"result": {"allowed": true}}`:          true,
+			`{"result": {"allowed": false, "x": 1}}`: false,
+			`{}`:                                     false,
+		} {
+			allowed, err := newWebhook(t, respond(body)).Evaluate(context.Background(), req)
+			require.NoError(t, err, body)
+			require.Equal(t, expected, allowed, body)
+		}
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		_, err := newWebhook(t, respond(`{"result": "yes"}`)).Evaluate(context.Background(), req)
+		require.ErrorContains(t, err, "invalid decision result")
+
+		_, err = newWebhook(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}).Evaluate(context.Background(), req)
+		require.ErrorContains(t, err, "unexpected status 500")
+
+		_, err = newWebhook(t, func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+		}, WithTimeout(10*time.Millisecond)).Evaluate(context.Background(), req)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// rewriteResolver is the next resolver of the chain, which resolves the rewrites of the relations.
+type rewriteResolver struct {
+	allowed bool
+	calls   int
+}
+
+func (r *rewriteResolver) ResolveCheck(_ context.Context, req *graph.ResolveCheckRequest) (*graph.ResolveCheckResponse, error) {
+	r.calls++
+	return &graph.ResolveCheckResponse{
+		Allowed: r.allowed,
+		ResolutionMetadata: &graph.ResolveCheckResponseMetadata{
+			DatastoreQueryCount: req.GetRequestMetadata().DatastoreQueryCount + 1,
+		},
+	}, nil
+}
+
+func (r *rewriteResolver) Close() {}
+
+func TestNewCheckResolver(t *testing.T) {
+	_, err := NewCheckResolver(nil, WithOperator("exclusion"))
+	require.ErrorContains(t, err, "exclusion")
+
+	reqContext, err := structpb.NewStruct(map[string]interface{}{"ip": "10.0.0.1"})
+	require.NoError(t, err)
+
+	var evaluated []*Request
+	evaluators := map[string]Evaluator{
+		"document#viewer": EvaluatorFunc(func(_ context.Context, req *Request) (bool, error) {
+			evaluated = append(evaluated, req)
+			return req.User == "user:anne", nil
+		}),
+		"document#editor": EvaluatorFunc(func(context.Context, *Request) (bool, error) {
+			return false, errors.New("unreachable")
+		}),
+	}
+
+	// check resolves the Check of the relation of document:1 to the user with the operator, and the
+	// rewrite of the relation allowing it or not.
+	check := func(t *testing.T, operator Operator, relation, user string, rewriteAllowed bool) (*graph.ResolveCheckResponse, *rewriteResolver, error) {
+		resolver, err := NewCheckResolver(evaluators, WithOperator(operator))
+		require.NoError(t, err)
+
+		next := &rewriteResolver{allowed: rewriteAllowed}
+		resolver.SetDelegate(next)
+
+		resp, err := resolver.ResolveCheck(context.Background(), &graph.ResolveCheckRequest{
+			StoreID:         "store",
+			TupleKey:        tuple.NewTupleKey("document:1", relation, user),
+			Context:         reqContext,
+			RequestMetadata: graph.NewCheckRequestMetadata(25),
+		})
+		return resp, next, err
+	}
+
+	t.Run("passes_the_other_relations_on", func(t *testing.T) {
+		resp, next, err := check(t, OperatorOverride, "owner", "user:bob", true)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+		require.Equal(t, 1, next.calls)
+	})
+
+	t.Run("intersection", func(t *testing.T) {
+		evaluated = nil
+
+		resp, _, err := check(t, OperatorIntersection, "viewer", "user:anne", true)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+		require.EqualValues(t, 1, resp.GetResolutionMetadata().DatastoreQueryCount)
+
+		resp, _, err = check(t, OperatorIntersection, "viewer", "user:bob", true)
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+
+		// the evaluator isn't called if the rewrite denies the Check
+		resp, _, err = check(t, OperatorIntersection, "viewer", "user:anne", false)
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+
+		require.Len(t, evaluated, 2)
+		require.Equal(t, &Request{
+			StoreID:  "store",
+			Object:   "document:1",
+			Relation: "viewer",
+			User:     "user:anne",
+			Context:  map[string]interface{}{"ip": "10.0.0.1"},
+		}, evaluated[0])
+	})
+
+	t.Run("union", func(t *testing.T) {
+		evaluated = nil
+
+		resp, _, err := check(t, OperatorUnion, "viewer", "user:bob", true)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+		require.Empty(t, evaluated)
+
+		resp, _, err = check(t, OperatorUnion, "viewer", "user:anne", false)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		resp, _, err = check(t, OperatorUnion, "viewer", "user:bob", false)
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+	})
+
+	t.Run("override", func(t *testing.T) {
+		resp, next, err := check(t, OperatorOverride, "viewer", "user:anne", false)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+		require.Zero(t, next.calls)
+	})
+
+	t.Run("failed_decision", func(t *testing.T) {
+		_, _, err := check(t, OperatorIntersection, "editor", "user:anne", true)
+		require.ErrorIs(t, err, graph.ErrDelegatedCheckFailed)
+		require.ErrorContains(t, err, "unreachable")
+	})
+}
@@ -0,0 +1,180 @@
+package errors
+
+import (
+	"errors"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reason is the machine-readable reason of an error. It is the reason of the google.rpc.ErrorInfo
+// detail of the gRPC errors, and the 'reason' field of the HTTP errors. Reasons are stable: they
+// are never renamed, so that clients can switch on them.
+type Reason string
+
+const (
+	ReasonValidationError                  Reason = "validation_error"
+	ReasonInvalidContinuationToken         Reason = "invalid_continuation_token"
+	ReasonContinuationTokenTypeMismatch    Reason = "continuation_token_type_mismatch"
+	ReasonContinuationTokenExpired         Reason = "continuation_token_expired"
+	ReasonInvalidConsistencyToken          Reason = "invalid_consistency_token"
+	ReasonInvalidConsistency               Reason = "invalid_consistency"
+	ReasonInvalidCacheControl              Reason = "invalid_cache_control"
+	ReasonInvalidWriteInput                Reason = "invalid_write_input"
+	ReasonWriteFailedDueToInvalidInput     Reason = "write_failed_due_to_invalid_input"
+	ReasonDuplicateTupleInWrite            Reason = "duplicate_tuple_in_write"
+	ReasonWriteRejected                    Reason = "write_rejected"
+	ReasonInvalidExpandInput               Reason = "invalid_expand_input"
+	ReasonUnsupportedUserSet               Reason = "unsupported_user_set"
+	ReasonExceededEntityLimit              Reason = "exceeded_entity_limit"
+	ReasonTypeNotFound                     Reason = "type_not_found"
+	ReasonRelationNotFound                 Reason = "relation_not_found"
+	ReasonInvalidTuple                     Reason = "invalid_tuple"
+	ReasonInvalidAuthorizationModel        Reason = "invalid_authorization_model"
+	ReasonAuthorizationModelNotFound       Reason = "authorization_model_not_found"
+	ReasonLatestAuthorizationModelNotFound Reason = "latest_authorization_model_not_found"
+	ReasonAssertionsNotFound               Reason = "assertions_not_found"
+	ReasonStoreNotFound                    Reason = "store_not_found"
+	ReasonStoreIDAlreadyExists             Reason = "store_id_already_exists"
+	ReasonDepthExceeded                    Reason = "depth_exceeded"
+	ReasonResolutionCycle                  Reason = "resolution_cycle"
+	ReasonBudgetExceeded                   Reason = "budget_exceeded"
+	ReasonThrottled                        Reason = "throttled"
+	ReasonTransactionConflict              Reason = "transaction_conflict"
+	ReasonOverloaded                       Reason = "overloaded"
+	ReasonCancelled                        Reason = "cancelled"
+	ReasonDeadlineExceeded                 Reason = "deadline_exceeded"
+	ReasonUnavailable                      Reason = "unavailable"
+	ReasonDatastoreUnavailable             Reason = "datastore_unavailable"
+	ReasonConsistencyTokenNotSatisfied     Reason = "consistency_token_not_satisfied"
+	ReasonAdmissionWebhookFailed           Reason = "admission_webhook_failed"
+	ReasonDelegatedCheckFailed             Reason = "delegated_check_failed"
+	ReasonMethodNotServed                  Reason = "method_not_served"
+	ReasonInternalError                    Reason = "internal_error"
+	ReasonWriteGroupCompensationFailed     Reason = "write_group_compensation_failed"
+	ReasonWriteGroupNotAtomic              Reason = "write_group_not_atomic"
+)
+
+// Code is an entry of the error code catalogue.
+type Code struct {
+	Reason Reason
+
+	// ErrorCode is the OpenFGA error code, sent as the code of the gRPC status.
+	ErrorCode int32
+
+	// GRPCCode and HTTPStatus are the codes the error is reported with by the HTTP gateway.
+	GRPCCode   codes.Code
+	HTTPStatus int
+
+	Description string
+}
+
+// catalogue is in order of preference: when an error has no ErrorInfo detail, its reason is the
+// first one with its error code.
+var catalogue = []Code{
+	{Reason: ReasonValidationError, ErrorCode: int32(openfgav1.ErrorCode_validation_error), Description: "the request is invalid"},
+	{Reason: ReasonInvalidContinuationToken, ErrorCode: int32(openfgav1.ErrorCode_invalid_continuation_token), Description: "the continuation token is invalid"},
+	{Reason: ReasonContinuationTokenExpired, ErrorCode: int32(openfgav1.ErrorCode_invalid_continuation_token), Description: "the continuation token is older than the lifetime of the tokens, and the changes after it may have been removed from the changelog"},
+	{Reason: ReasonContinuationTokenTypeMismatch, ErrorCode: int32(openfgav1.ErrorCode_query_string_type_continuation_token_mismatch), Description: "the type of the request and of the continuation token don't match"},
+	{Reason: ReasonInvalidConsistencyToken, ErrorCode: int32(openfgav1.ErrorCode_validation_error), Description: "the consistency token is invalid, or is for another store"},
+	{Reason: ReasonInvalidConsistency, ErrorCode: int32(openfgav1.ErrorCode_validation_error), Description: "the consistency of the request or of the store settings is unknown"},
+	{Reason: ReasonInvalidCacheControl, ErrorCode: int32(openfgav1.ErrorCode_validation_error), Description: "the cache directives of the request are invalid"},
+	{Reason: ReasonInvalidWriteInput, ErrorCode: int32(openfgav1.ErrorCode_invalid_write_input), Description: "the write request has no writes and no deletes"},
+	{Reason: ReasonWriteFailedDueToInvalidInput, ErrorCode: int32(openfgav1.ErrorCode_write_failed_due_to_invalid_input), Description: "a tuple to write already exists, or a tuple to delete doesn't"},
+	{Reason: ReasonDuplicateTupleInWrite, ErrorCode: int32(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), Description: "the write request has the same tuple more than once"},
+	{Reason: ReasonWriteRejected, ErrorCode: int32(openfgav1.ErrorCode_validation_error), Description: "the write admission webhook rejected the tuples of the write request"},
+	{Reason: ReasonWriteGroupNotAtomic, ErrorCode: int32(openfgav1.ErrorCode_validation_error), Description: "the stores of the write group can't be written in a single transaction, e.g. because they are in different datastores, and the write groups applied one store after the other are disabled"},
+	{Reason: ReasonInvalidExpandInput, ErrorCode: int32(openfgav1.ErrorCode_invalid_expand_input), Description: "the expand request has no object or no relation"},
+	{Reason: ReasonUnsupportedUserSet, ErrorCode: int32(openfgav1.ErrorCode_unsupported_user_set), Description: "the userset is not supported"},
+	{Reason: ReasonExceededEntityLimit, ErrorCode: int32(openfgav1.ErrorCode_exceeded_entity_limit), Description: "the request has too many items"},
+	{Reason: ReasonTypeNotFound, ErrorCode: int32(openfgav1.ErrorCode_type_not_found), Description: "the type is not defined in the authorization model"},
+	{Reason: ReasonRelationNotFound, ErrorCode: int32(openfgav1.ErrorCode_relation_not_found), Description: "the relation is not defined in the authorization model"},
+	{Reason: ReasonInvalidTuple, ErrorCode: int32(openfgav1.ErrorCode_invalid_tuple), Description: "the tuple is invalid for the authorization model"},
+	{Reason: ReasonInvalidAuthorizationModel, ErrorCode: int32(openfgav1.ErrorCode_invalid_authorization_model), Description: "the authorization model is invalid"},
+	{Reason: ReasonAuthorizationModelNotFound, ErrorCode: int32(openfgav1.ErrorCode_authorization_model_not_found), Description: "the authorization model doesn't exist"},
+	{Reason: ReasonLatestAuthorizationModelNotFound, ErrorCode: int32(openfgav1.ErrorCode_latest_authorization_model_not_found), Description: "the store has no authorization model"},
+	{Reason: ReasonAssertionsNotFound, ErrorCode: int32(openfgav1.ErrorCode_authorization_model_assertions_not_found), Description: "the authorization model has no assertions"},
+	{Reason: ReasonStoreNotFound, ErrorCode: int32(openfgav1.NotFoundErrorCode_store_id_not_found), Description: "the store doesn't exist"},
+	{Reason: ReasonStoreIDAlreadyExists, ErrorCode: int32(openfgav1.ErrorCode_validation_error), Description: "the caller-supplied ID of the created store is the ID of an existing or deleted store"},
+	{Reason: ReasonDepthExceeded, ErrorCode: int32(openfgav1.ErrorCode_authorization_model_resolution_too_complex), Description: "the resolution exceeded the maximum depth"},
+	{Reason: ReasonResolutionCycle, ErrorCode: int32(openfgav1.ErrorCode_authorization_model_resolution_too_complex), Description: "the resolution followed a cycle of relationship tuples"},
+	{Reason: ReasonBudgetExceeded, ErrorCode: int32(openfgav1.ErrorCode_authorization_model_resolution_too_complex), Description: "the resolution exceeded its budget of dispatches or datastore reads"},
+	{Reason: ReasonThrottled, ErrorCode: int32(openfgav1.UnprocessableContentErrorCode_throttled_timeout_error), Description: "the request was throttled and timed out"},
+	{Reason: ReasonTransactionConflict, ErrorCode: int32(codes.Aborted), Description: "the write conflicted with a concurrent one and can be retried"},
+	{Reason: ReasonOverloaded, ErrorCode: int32(codes.ResourceExhausted), Description: "the server is overloaded and shed the request, which can be retried after the delay of its retry-after header"},
+	{Reason: ReasonCancelled, ErrorCode: int32(openfgav1.InternalErrorCode_cancelled), Description: "the request was cancelled"},
+	{Reason: ReasonDeadlineExceeded, ErrorCode: int32(openfgav1.InternalErrorCode_deadline_exceeded), Description: "the request timed out"},
+	{Reason: ReasonUnavailable, ErrorCode: int32(openfgav1.InternalErrorCode_unavailable), Description: "the service is temporarily unavailable"},
+	{Reason: ReasonDatastoreUnavailable, ErrorCode: int32(openfgav1.InternalErrorCode_unavailable), Description: "too many of the recent datastore calls failed or were slow, so the request failed fast, and can be retried"},
+	{Reason: ReasonConsistencyTokenNotSatisfied, ErrorCode: int32(openfgav1.InternalErrorCode_unavailable), Description: "the datastore didn't catch up with the write of the consistency token in time, and the request can be retried"},
+	{Reason: ReasonAdmissionWebhookFailed, ErrorCode: int32(openfgav1.InternalErrorCode_unavailable), Description: "the write admission webhook failed or timed out, and the request can be retried"},
+	{Reason: ReasonDelegatedCheckFailed, ErrorCode: int32(openfgav1.InternalErrorCode_unavailable), Description: "the external evaluator of a delegated relation failed or timed out, and the request can be retried"},
+	{Reason: ReasonMethodNotServed, ErrorCode: int32(codes.PermissionDenied), Description: "the method isn't served on this listener: the control-plane methods are only served on the control-plane listener once it is enabled, and the data-plane methods aren't served on it"},
+	{Reason: ReasonInternalError, ErrorCode: int32(openfgav1.InternalErrorCode_internal_error), Description: "an unexpected error occurred"},
+	{Reason: ReasonWriteGroupCompensationFailed, ErrorCode: int32(openfgav1.InternalErrorCode_internal_error), Description: "a store of a write group failed, and the writes of the stores applied before it couldn't all be undone, so those stores must be repaired"},
+}
+
+// catalogueByReason and catalogueByErrorCode index the catalogue. They are initialized before the
+// errors of the package, which look up their error code in them.
+var catalogueByReason, catalogueByErrorCode = indexCatalogue()
+
+func indexCatalogue() (map[Reason]Code, map[int32]Code) {
+	byReason := make(map[Reason]Code, len(catalogue))
+	byErrorCode := make(map[int32]Code, len(catalogue))
+	for i, c := range catalogue {
+		encoded := NewEncodedError(c.ErrorCode, "")
+		c.GRPCCode = encoded.GRPCStatusCode
+		c.HTTPStatus = encoded.HTTPStatusCode
+		catalogue[i] = c
+
+		byReason[c.Reason] = c
+		if _, ok := byErrorCode[c.ErrorCode]; !ok {
+			byErrorCode[c.ErrorCode] = c
+		}
+	}
+
+	return byReason, byErrorCode
+}
+
+// Catalogue returns the codes of the errors returned by the server.
+func Catalogue() []Code {
+	return append([]Code(nil), catalogue...)
+}
+
+// LookupReason returns the entry of the catalogue for the reason.
+func LookupReason(reason Reason) (Code, bool) {
+	c, ok := catalogueByReason[reason]
+	return c, ok
+}
+
+// ReasonFromError returns the reason of the ErrorInfo detail of the error. If it has none, the reason
+// is the one of its error code in the catalogue, or validation_error for the other invalid arguments.
+func ReasonFromError(err error) (Reason, bool) {
+	var internalErr InternalError
+	if errors.As(err, &internalErr) {
+		err = internalErr.public
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return "", false
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return Reason(info.GetReason()), true
+		}
+	}
+
+	if c, ok := catalogueByErrorCode[ConvertToEncodedErrorCode(st)]; ok {
+		return c.Reason, true
+	}
+
+	if NewEncodedError(ConvertToEncodedErrorCode(st), "").GRPCStatusCode == codes.InvalidArgument {
+		return ReasonValidationError, true
+	}
+
+	return "", false
+}
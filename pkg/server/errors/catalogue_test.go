@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+func TestCatalogue(t *testing.T) {
+	reasons := map[Reason]struct{}{}
+	for _, c := range Catalogue() {
+		require.NotContains(t, reasons, c.Reason)
+		reasons[c.Reason] = struct{}{}
+
+		encoded := NewEncodedError(c.ErrorCode, "")
+		require.Equal(t, encoded.GRPCStatusCode, c.GRPCCode, c.Reason)
+		require.Equal(t, encoded.HTTPStatusCode, c.HTTPStatus, c.Reason)
+		require.NotEmpty(t, c.Description)
+
+		looked, ok := LookupReason(c.Reason)
+		require.True(t, ok)
+		require.Equal(t, c, looked)
+	}
+
+	_, ok := LookupReason("unknown")
+	require.False(t, ok)
+
+	throttled, _ := LookupReason(ReasonThrottled)
+	require.Equal(t, codes.ResourceExhausted, throttled.GRPCCode)
+	require.Equal(t, http.StatusUnprocessableEntity, throttled.HTTPStatus)
+
+	conflict, _ := LookupReason(ReasonTransactionConflict)
+	require.Equal(t, codes.Aborted, conflict.GRPCCode)
+	require.Equal(t, http.StatusConflict, conflict.HTTPStatus)
+
+	notServed, _ := LookupReason(ReasonMethodNotServed)
+	require.Equal(t, codes.PermissionDenied, notServed.GRPCCode)
+	require.Equal(t, http.StatusForbidden, notServed.HTTPStatus)
+
+	overloaded, _ := LookupReason(ReasonOverloaded)
+	require.Equal(t, codes.ResourceExhausted, overloaded.GRPCCode)
+	require.Equal(t, http.StatusTooManyRequests, overloaded.HTTPStatus)
+}
+
+func TestReasonFromError(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected Reason
+	}{
+		"depth_exceeded":             {HandleError("", graph.ErrResolutionDepthExceeded), ReasonDepthExceeded},
+		"resolution_cycle":           {HandleError("", &graph.ResolutionCycleError{Cycle: []string{"folder#parent", "folder#parent"}}), ReasonResolutionCycle},
+		"budget_exceeded":            {HandleError("", fmt.Errorf("%w: dispatches", graph.ErrResolutionBudgetExceeded)), ReasonBudgetExceeded},
+		"throttled":                  {HandleError("", fmt.Errorf("%w: %w", graph.ErrThrottledTimeout, context.DeadlineExceeded)), ReasonThrottled},
+		"invalid_continuation_token": {HandleError("", storage.ErrInvalidContinuationToken), ReasonInvalidContinuationToken},
+		"continuation_token_expired": {ContinuationTokenExpired(time.Now().Add(-time.Hour), time.Now()), ReasonContinuationTokenExpired},
+		"transaction_conflict":       {HandleError("", storage.ErrTransactionalWriteFailed), ReasonTransactionConflict},
+		"delegated_check_failed":     {HandleError("", fmt.Errorf("%w: timeout", graph.ErrDelegatedCheckFailed)), ReasonDelegatedCheckFailed},
+		"internal_error":             {HandleError("", errors.New("internal")), ReasonInternalError},
+		"invalid_cache_control":      {InvalidCacheControl("no-transform", "unknown directive"), ReasonInvalidCacheControl},
+		"write_group_compensation":   {WriteGroupCompensationFailed("01JSTORE2", []string{"01JSTORE1"}), ReasonWriteGroupCompensationFailed},
+		"write_group_not_atomic":     {WriteGroupNotAtomic(), ReasonWriteGroupNotAtomic},
+		"exceeded_entity_limit":      {ExceededEntityLimit("write operations", 10), ReasonExceededEntityLimit},
+		"exceeded_model_limit":       {ExceededAuthorizationModelLimit("maxRelationsPerTypeDefinition", "type 'document'", 11, 10), ReasonExceededEntityLimit},
+		"exceeded_request_limit":     {ExceededRequestLimit("max_contextual_tuples", "contextual_tuples.tuple_keys", 11, 10), ReasonExceededEntityLimit},
+		"overloaded":                 {Overloaded("memory", time.Second), ReasonOverloaded},
+		"method_not_served":          {MethodNotServed("Check", "control-plane"), ReasonMethodNotServed},
+		"store_not_found":            {StoreIDNotFound, ReasonStoreNotFound},
+		"store_id_already_exists":    {StoreIDAlreadyExists("01JSTORE1"), ReasonStoreIDAlreadyExists},
+		"without_error_info":         {status.Error(codes.Code(2000), "invalid"), ReasonValidationError},
+		"framework_validation":       {status.Error(codes.InvalidArgument, "invalid CheckRequest.StoreId: value length must be 26 runes"), ReasonValidationError},
+		"cancelled":                  {status.Error(codes.Canceled, "cancelled"), ReasonCancelled},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			reason, ok := ReasonFromError(test.err)
+			require.True(t, ok)
+			require.Equal(t, test.expected, reason)
+
+			c, _ := LookupReason(reason)
+			require.Equal(t, c.HTTPStatus, EncodeError(test.err).HTTPStatus())
+			require.Equal(t, reason, EncodeError(test.err).ActualError.Reason)
+		})
+	}
+
+	_, ok := ReasonFromError(errors.New("not a status"))
+	require.False(t, ok)
+
+	_, ok = ReasonFromError(status.Error(codes.Unauthenticated, "unauthenticated"))
+	require.False(t, ok)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -21,6 +22,11 @@ const (
 type ErrorResponse struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	Reason  Reason `json:"reason,omitempty"`
+
+	// RetryAfterSeconds is the number of seconds after which a throttled or shed request can be retried.
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty"`
+
 	codeInt int32
 }
 
@@ -80,6 +86,28 @@ func NewEncodedError(errorCode int32, message string) *EncodedError {
 				},
 			}
 		}
+		if errorCode == int32(codes.PermissionDenied) {
+			return &EncodedError{
+				HTTPStatusCode: http.StatusForbidden,
+				GRPCStatusCode: codes.PermissionDenied,
+				ActualError: ErrorResponse{
+					Code:    codes.PermissionDenied.String(),
+					Message: sanitizedMessage(message),
+					codeInt: errorCode,
+				},
+			}
+		}
+		if errorCode == int32(codes.ResourceExhausted) {
+			return &EncodedError{
+				HTTPStatusCode: http.StatusTooManyRequests,
+				GRPCStatusCode: codes.ResourceExhausted,
+				ActualError: ErrorResponse{
+					Code:    codes.ResourceExhausted.String(),
+					Message: sanitizedMessage(message),
+					codeInt: errorCode,
+				},
+			}
+		}
 		return &EncodedError{
 			HTTPStatusCode: http.StatusInternalServerError,
 			GRPCStatusCode: codes.Internal,
@@ -129,6 +157,29 @@ func NewEncodedError(errorCode int32, message string) *EncodedError {
 	}
 }
 
+// EncodeError returns the encoded error of the error, with its reason in the catalogue and the
+// error code of that reason.
+func EncodeError(err error) *EncodedError {
+	code := ConvertToEncodedErrorCode(status.Convert(err))
+	reason, ok := ReasonFromError(err)
+	if c, found := catalogueByReason[reason]; ok && found {
+		code = c.ErrorCode
+	}
+
+	encoded := NewEncodedError(code, err.Error())
+	if ok {
+		encoded.ActualError.Reason = reason
+	}
+
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			encoded.ActualError.RetryAfterSeconds = int(info.GetRetryDelay().AsDuration().Seconds())
+		}
+	}
+
+	return encoded
+}
+
 // IsValidEncodedError returns whether the error code is a valid encoded error.
 func IsValidEncodedError(errorCode int32) bool {
 	return errorCode >= cFirstAuthenticationErrorCode
@@ -245,6 +296,8 @@ func ConvertToEncodedErrorCode(statusError *status.Status) int32 {
 		return int32(openfgav1.InternalErrorCode_failed_precondition)
 	case codes.Aborted:
 		return int32(codes.Aborted)
+	case codes.PermissionDenied:
+		return int32(codes.PermissionDenied)
 	case codes.OutOfRange:
 		return int32(openfgav1.InternalErrorCode_out_of_range)
 	case codes.Unimplemented:
@@ -28,6 +28,14 @@ func TestEncodedError(t *testing.T) {
 			expectedCode:           int(codes.Aborted),
 			expectedCodeString:     "Aborted",
 		},
+		{
+			_name:                  "permission_denied_error",
+			errorCode:              int32(codes.PermissionDenied),
+			message:                "error message",
+			expectedHTTPStatusCode: http.StatusForbidden,
+			expectedCode:           int(codes.PermissionDenied),
+			expectedCodeString:     "PermissionDenied",
+		},
 		{
 			_name:                  "invalid_error",
 			errorCode:              20,
@@ -183,6 +191,11 @@ func TestConvertToEncodedErrorCode(t *testing.T) {
 			status:            status.New(codes.ResourceExhausted, "other error"),
 			expectedErrorCode: int32(openfgav1.InternalErrorCode_resource_exhausted),
 		},
+		{
+			_name:             "permission_denied",
+			status:            status.New(codes.PermissionDenied, "other error"),
+			expectedErrorCode: int32(codes.PermissionDenied),
+		},
 		{
 			_name:             "failed_precondition",
 			status:            status.New(codes.FailedPrecondition, "other error"),
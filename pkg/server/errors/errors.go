@@ -2,31 +2,71 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 
+	"github.com/openfga/openfga/internal/graph"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
 )
 
 const InternalServerErrorMsg = "Internal Server Error"
 
+// ErrorDomain is the domain of the google.rpc.ErrorInfo details attached to errors.
+const ErrorDomain = "openfga.dev"
+
+// RetryAfterMetadataKey is the key of the ErrorInfo metadata of the errors of the throttled and shed
+// requests with the number of seconds after which they can be retried. The errors also have a
+// google.rpc.RetryInfo detail with the delay.
+const RetryAfterMetadataKey = "retry_after_seconds"
+
 var (
 	// AuthorizationModelResolutionTooComplex is used to avoid stack overflows.
-	AuthorizationModelResolutionTooComplex = status.Error(codes.Code(openfgav1.ErrorCode_authorization_model_resolution_too_complex), "Authorization Model resolution required too many rewrite rules to be resolved. Check your authorization model for infinite recursion or too much nesting")
-	InvalidWriteInput                      = status.Error(codes.Code(openfgav1.ErrorCode_invalid_write_input), "Invalid input. Make sure you provide at least one write, or at least one delete")
-	InvalidContinuationToken               = status.Error(codes.Code(openfgav1.ErrorCode_invalid_continuation_token), "Invalid continuation token")
-	InvalidExpandInput                     = status.Error(codes.Code(openfgav1.ErrorCode_invalid_expand_input), "Invalid input. Make sure you provide an object and a relation")
-	UnsupportedUserSet                     = status.Error(codes.Code(openfgav1.ErrorCode_unsupported_user_set), "Userset is not supported (right now)")
-	StoreIDNotFound                        = status.Error(codes.Code(openfgav1.NotFoundErrorCode_store_id_not_found), "Store ID not found")
-	MismatchObjectType                     = status.Error(codes.Code(openfgav1.ErrorCode_query_string_type_continuation_token_mismatch), "The type in the querystring and the continuation token don't match")
-	RequestCancelled                       = status.Error(codes.Code(openfgav1.InternalErrorCode_cancelled), "Request Cancelled")
-	RequestDeadlineExceeded                = status.Error(codes.Code(openfgav1.InternalErrorCode_deadline_exceeded), "Request Deadline Exceeded")
-	ThrottledTimeout                       = status.Error(codes.Code(openfgav1.UnprocessableContentErrorCode_throttled_timeout_error), "timeout due to throttling on complex request")
+	AuthorizationModelResolutionTooComplex = newError(ReasonDepthExceeded, "Authorization Model resolution required too many rewrite rules to be resolved. Check your authorization model for infinite recursion or too much nesting", nil)
+	InvalidWriteInput                      = newError(ReasonInvalidWriteInput, "Invalid input. Make sure you provide at least one write, or at least one delete", nil)
+	InvalidContinuationToken               = newError(ReasonInvalidContinuationToken, "Invalid continuation token", nil)
+	InvalidExpandInput                     = newError(ReasonInvalidExpandInput, "Invalid input. Make sure you provide an object and a relation", nil)
+	UnsupportedUserSet                     = newError(ReasonUnsupportedUserSet, "Userset is not supported (right now)", nil)
+	StoreIDNotFound                        = newError(ReasonStoreNotFound, "Store ID not found", nil)
+	MismatchObjectType                     = newError(ReasonContinuationTokenTypeMismatch, "The type in the querystring and the continuation token don't match", nil)
+	RequestCancelled                       = newError(ReasonCancelled, "Request Cancelled", nil)
+	RequestDeadlineExceeded                = newError(ReasonDeadlineExceeded, "Request Deadline Exceeded", nil)
+	ThrottledTimeout                       = ThrottledTimeoutRetryAfter(time.Second)
+	InvalidConsistencyToken                = newError(ReasonInvalidConsistencyToken, "Invalid consistency token", nil)
+	ConsistencyTokenNotSatisfied           = newError(ReasonConsistencyTokenNotSatisfied, "The datastore has not caught up with the consistency token yet, retry the request", nil)
+	AdmissionWebhookFailed                 = newError(ReasonAdmissionWebhookFailed, "The write admission webhook failed, retry the request", nil)
+	DelegatedCheckFailed                   = newError(ReasonDelegatedCheckFailed, "The external evaluator of a delegated relation failed, retry the request", nil)
+)
+
+var (
+	// DatastoreDeadlineExceeded is returned when the deadline of a request was exceeded while it was
+	// waiting for the datastore.
+	DatastoreDeadlineExceeded = newError(ReasonDeadlineExceeded, "Request Deadline Exceeded while waiting for the datastore",
+		map[string]string{"exceeded_in": "datastore"})
+
+	// ResolutionDeadlineExceeded is returned when the deadline of a request was exceeded while it was
+	// being resolved, outside the datastore.
+	ResolutionDeadlineExceeded = newError(ReasonDeadlineExceeded, "Request Deadline Exceeded while resolving the request",
+		map[string]string{"exceeded_in": "resolution"})
+
+	// DatastoreUnavailable is returned when a request failed fast because the datastore is
+	// considered unavailable.
+	DatastoreUnavailable = newError(ReasonDatastoreUnavailable, "The datastore is unavailable, retry the request", nil)
 )
 
 type InternalError struct {
@@ -58,66 +98,281 @@ func NewInternalError(public string, internal error) InternalError {
 	}
 
 	return InternalError{
-		public:   status.Error(codes.Code(openfgav1.InternalErrorCode_internal_error), public),
+		public:   newError(ReasonInternalError, public, nil),
 		internal: internal,
 	}
 }
 
 func ValidationError(cause error) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_validation_error), cause.Error())
+	return newError(ReasonValidationError, cause.Error(), nil)
+}
+
+// AuthorizationModelResolutionCycle is returned when resolving a request followed a cycle of `type#relation` nodes.
+func AuthorizationModelResolutionCycle(cycle []string) error {
+	return newError(ReasonResolutionCycle,
+		fmt.Sprintf("Authorization Model resolution followed the cycle %s. Check your relationship tuples for cycles", strings.Join(cycle, " -> ")),
+		map[string]string{"cycle": strings.Join(cycle, " -> ")})
+}
+
+// ResolutionBudgetExceeded is returned when resolving a request needed more work than its budget allows.
+// The cause describes the work done when the budget was exceeded.
+func ResolutionBudgetExceeded(cause error) error {
+	return newError(ReasonBudgetExceeded, cause.Error(), nil)
 }
 
 func AssertionsNotForAuthorizationModelFound(modelID string) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_authorization_model_assertions_not_found), fmt.Sprintf("No assertions found for authorization model '%s'", modelID))
+	return newError(ReasonAssertionsNotFound, fmt.Sprintf("No assertions found for authorization model '%s'", modelID),
+		map[string]string{"authorization_model_id": modelID})
 }
 
 func AuthorizationModelNotFound(modelID string) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_authorization_model_not_found), fmt.Sprintf("Authorization Model '%s' not found", modelID))
+	return newError(ReasonAuthorizationModelNotFound, fmt.Sprintf("Authorization Model '%s' not found", modelID),
+		map[string]string{"authorization_model_id": modelID})
+}
+
+// InvalidConsistency is returned when the consistency of a request or of the store settings is unknown.
+func InvalidConsistency(consistency string, valid ...string) error {
+	return newError(ReasonInvalidConsistency,
+		fmt.Sprintf("Invalid consistency '%s', it must be one of '%s'", consistency, strings.Join(valid, "', '")),
+		map[string]string{"consistency": consistency})
+}
+
+// InvalidCacheControl is returned when the cache directives of a Check request are invalid.
+func InvalidCacheControl(value, reason string) error {
+	return newError(ReasonInvalidCacheControl,
+		fmt.Sprintf("Invalid cache control '%s': %s", value, reason),
+		map[string]string{"cache_control": value})
+}
+
+// StoreIDAlreadyExists is returned when the caller-supplied ID of a CreateStore request is the ID of
+// an existing or deleted store.
+func StoreIDAlreadyExists(id string) error {
+	return newError(ReasonStoreIDAlreadyExists,
+		fmt.Sprintf("The store ID '%s' is the ID of an existing or deleted store", id),
+		map[string]string{"store_id": id})
+}
+
+// WriteGroupCompensationFailed is returned when the write of a store of a write group failed, and the
+// writes of the stores applied before it couldn't all be undone.
+func WriteGroupCompensationFailed(store string, uncompensated []string) error {
+	return newError(ReasonWriteGroupCompensationFailed,
+		fmt.Sprintf("The write of the store '%s' failed, and the writes of the stores '%s' couldn't be undone", store, strings.Join(uncompensated, "', '")),
+		map[string]string{"store_id": store, "uncompensated_store_ids": strings.Join(uncompensated, ",")})
+}
+
+// WriteGroupNotAtomic is returned when the stores of a write group can't be written in a single
+// transaction, and the write groups applied one store after the other are disabled.
+func WriteGroupNotAtomic() error {
+	return newError(ReasonWriteGroupNotAtomic,
+		"The stores of the write group can't be written in a single transaction, and the non-atomic write groups are disabled", nil)
+}
+
+// WriteRejected is returned when the write admission webhook rejects the tuples of a Write request.
+func WriteRejected(reason string) error {
+	msg := "The write was rejected by the admission webhook"
+	if reason != "" {
+		msg += ": " + reason
+	}
+	return newError(ReasonWriteRejected, msg, map[string]string{"reason": reason})
+}
+
+// ContinuationTokenExpired is returned when a continuation token of ReadChanges is older than the
+// lifetime of the tokens, as the changes after its high-water mark may have been removed from the
+// changelog. The client reads the tuples of the store again, and then the changes from the restart
+// time without a continuation token.
+func ContinuationTokenExpired(highWaterMark, restartFrom time.Time) error {
+	return newError(ReasonContinuationTokenExpired,
+		fmt.Sprintf("The continuation token expired, the changes after %s may have been removed from the changelog. Read the tuples of the store again, then read the changes without a continuation token and with the Openfga-Changes-Start-Time header '%s'",
+			highWaterMark.UTC().Format(time.RFC3339Nano), restartFrom.UTC().Format(time.RFC3339Nano)),
+		map[string]string{
+			"high_water_mark": highWaterMark.UTC().Format(time.RFC3339Nano),
+			"restart_from":    restartFrom.UTC().Format(time.RFC3339Nano),
+		})
 }
 
 func LatestAuthorizationModelNotFound(store string) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_latest_authorization_model_not_found), fmt.Sprintf("No authorization models found for store '%s'", store))
+	return newError(ReasonLatestAuthorizationModelNotFound, fmt.Sprintf("No authorization models found for store '%s'", store),
+		map[string]string{"store_id": store})
+}
+
+// newError returns a status error with the error code of the reason in the catalogue, an ErrorInfo
+// detail with the reason and the given metadata, and the other details. The details are marshalled
+// deterministically, so that errors with the same metadata are equal.
+func newError(reason Reason, msg string, metadata map[string]string, details ...protoadapt.MessageV1) error {
+	p := status.New(codes.Code(catalogueByReason[reason].ErrorCode), msg).Proto()
+
+	details = append([]protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   string(reason),
+		Domain:   ErrorDomain,
+		Metadata: metadata,
+	}}, details...)
+
+	for _, detail := range details {
+		marshalled := &anypb.Any{}
+		if err := anypb.MarshalFrom(marshalled, protoadapt.MessageV2Of(detail), proto.MarshalOptions{Deterministic: true}); err != nil {
+			return status.ErrorProto(p)
+		}
+		p.Details = append(p.Details, marshalled)
+	}
+
+	return status.ErrorProto(p)
 }
 
 func TypeNotFound(objectType string) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_type_not_found), fmt.Sprintf("type '%s' not found", objectType))
+	return newError(ReasonTypeNotFound, fmt.Sprintf("type '%s' not found", objectType),
+		map[string]string{"type": objectType})
 }
 
 func RelationNotFound(relation string, objectType string, tk *openfgav1.TupleKey) error {
 	msg := fmt.Sprintf("relation '%s#%s' not found", objectType, relation)
+	metadata := map[string]string{"type": objectType, "relation": relation}
 	if tk != nil {
 		msg += fmt.Sprintf(" for tuple '%s'", tuple.TupleKeyToString(tk))
+		metadata["tuple_key"] = tuple.TupleKeyToString(tk)
 	}
 
-	return status.Error(codes.Code(openfgav1.ErrorCode_relation_not_found), msg)
+	return newError(ReasonRelationNotFound, msg, metadata)
+}
+
+// InvalidTuple is returned when a tuple of a request is invalid. It has a BadRequest detail with a
+// field violation for the tuple key.
+func InvalidTuple(tk tuple.TupleWithoutCondition, cause error) error {
+	return newError(
+		ReasonInvalidTuple,
+		fmt.Sprintf("Invalid tuple '%s'. Reason: %s", tk, cause.Error()),
+		map[string]string{"tuple_key": tuple.TupleKeyToString(tk)},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "tuple_key", Description: cause.Error()},
+		}},
+	)
 }
 
 func ExceededEntityLimit(entity string, limit int) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_exceeded_entity_limit),
-		fmt.Sprintf("The number of %s exceeds the allowed limit of %d", entity, limit))
+	return newError(ReasonExceededEntityLimit,
+		fmt.Sprintf("The number of %s exceeds the allowed limit of %d", entity, limit),
+		map[string]string{"entity": entity, "limit": strconv.Itoa(limit)})
+}
+
+// ExceededRequestLimit is returned when a field of a request, e.g. its contextual tuples, exceeds the
+// configured limit of the requests. It has a BadRequest detail with a field violation for the field.
+func ExceededRequestLimit(limit, field string, value, maxValue int) error {
+	msg := fmt.Sprintf("'%s' exceeds the '%s' limit: %d vs %d", field, limit, value, maxValue)
+	return newError(ReasonExceededEntityLimit, msg,
+		map[string]string{"limit_name": limit, "field": field, "value": strconv.Itoa(value), "limit": strconv.Itoa(maxValue)},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: msg},
+		}},
+	)
+}
+
+// ExceededAuthorizationModelLimit is returned when a part of a written authorization model, e.g. a type
+// definition, exceeds the configured limit of the authorization models.
+func ExceededAuthorizationModelLimit(limit, location string, value, maxValue int) error {
+	return newError(ReasonExceededEntityLimit,
+		fmt.Sprintf("model exceeds the '%s' limit at %s: %d vs %d", limit, location, value, maxValue),
+		map[string]string{"limit_name": limit, "location": location, "value": strconv.Itoa(value), "limit": strconv.Itoa(maxValue)})
 }
 
 func DuplicateTupleInWrite(tk tuple.TupleWithoutCondition) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_cannot_allow_duplicate_tuples_in_one_request), fmt.Sprintf("duplicate tuple in write: user: '%s', relation: '%s', object: '%s'", tk.GetUser(), tk.GetRelation(), tk.GetObject()))
+	return newError(ReasonDuplicateTupleInWrite,
+		fmt.Sprintf("duplicate tuple in write: user: '%s', relation: '%s', object: '%s'", tk.GetUser(), tk.GetRelation(), tk.GetObject()),
+		map[string]string{"tuple_key": tuple.TupleKeyToString(tk)})
 }
 
 func WriteFailedDueToInvalidInput(err error) error {
 	if err != nil {
-		return status.Error(codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), err.Error())
+		return newError(ReasonWriteFailedDueToInvalidInput, err.Error(), nil)
 	}
-	return status.Error(codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), "Write failed due to invalid input")
+	return newError(ReasonWriteFailedDueToInvalidInput, "Write failed due to invalid input", nil)
 }
 
+// InvalidAuthorizationModelInput is returned when an authorization model is invalid. If the error is
+// about the definition of a type or a relation, the type and the relation are in the metadata of the
+// ErrorInfo detail and in the field of the BadRequest field violation.
 func InvalidAuthorizationModelInput(err error) error {
-	return status.Error(codes.Code(openfgav1.ErrorCode_invalid_authorization_model), err.Error())
+	metadata := map[string]string{}
+	field := "type_definitions"
+
+	var relationErr *typesystem.InvalidRelationError
+	var typeErr *typesystem.InvalidTypeError
+	switch {
+	case errors.As(err, &relationErr):
+		metadata["type"] = relationErr.ObjectType
+		metadata["relation"] = relationErr.Relation
+		field = fmt.Sprintf("type_definitions[%s].relations[%s]", relationErr.ObjectType, relationErr.Relation)
+	case errors.As(err, &typeErr):
+		metadata["type"] = typeErr.ObjectType
+		field = fmt.Sprintf("type_definitions[%s]", typeErr.ObjectType)
+	}
+
+	return newError(ReasonInvalidAuthorizationModel, err.Error(), metadata,
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: err.Error()},
+		}},
+	)
 }
 
-// HandleError is used to surface some errors, and hide others.
+// TransactionConflict is returned when a write conflicted with a concurrent one. It can be retried.
+func TransactionConflict(err error) error {
+	return newError(ReasonTransactionConflict, err.Error(), nil)
+}
+
+// RetryAfterSeconds returns the delay in seconds after which a throttled or shed request can be
+// retried: the delay rounded up, and at least a second.
+func RetryAfterSeconds(d time.Duration) int {
+	return max(int(math.Ceil(d.Seconds())), 1)
+}
+
+func retryInfo(seconds int) *errdetails.RetryInfo {
+	return &errdetails.RetryInfo{RetryDelay: durationpb.New(time.Duration(seconds) * time.Second)}
+}
+
+// ThrottledTimeoutRetryAfter returns the error of a request whose deadline was exceeded after it was
+// throttled, which can be retried after the delay, e.g. how long it was throttled.
+func ThrottledTimeoutRetryAfter(d time.Duration) error {
+	seconds := RetryAfterSeconds(d)
+	return newError(ReasonThrottled, "timeout due to throttling on complex request",
+		map[string]string{RetryAfterMetadataKey: strconv.Itoa(seconds)}, retryInfo(seconds))
+}
+
+// Overloaded returns the error of a request that was shed because the load of the server exceeded
+// the threshold of the resource (e.g. in-flight requests or memory), which can be retried after the delay.
+func Overloaded(resource string, retryAfter time.Duration) error {
+	seconds := RetryAfterSeconds(retryAfter)
+	return newError(ReasonOverloaded, fmt.Sprintf("The server is overloaded (%s), retry the request later", resource),
+		map[string]string{"resource": resource, RetryAfterMetadataKey: strconv.Itoa(seconds)}, retryInfo(seconds))
+}
+
+// MethodNotServed returns the error of a request to a method that isn't served on the listener, e.g.
+// a control-plane method on the listener of the data-plane methods.
+func MethodNotServed(method, listener string) error {
+	return newError(ReasonMethodNotServed, fmt.Sprintf("'%s' isn't served on the %s listener", method, listener),
+		map[string]string{"method": method, "listener": listener})
+}
+
+// HandleError is used to surface some errors, and hide others. The errors of the graph and storage
+// layers are translated to the errors of their reason in the catalogue. An exceeded deadline is
+// reported as exceeded in the datastore if the datastore returned storage.ErrDeadlineExceeded, and
+// as exceeded in the resolution otherwise.
 // Use `public` if you want to return a useful error message to the user.
 func HandleError(public string, err error) error {
+	var cycleErr *graph.ResolutionCycleError
+	var throttledErr *graph.ThrottledTimeoutError
 	switch {
+	case errors.As(err, &cycleErr):
+		return AuthorizationModelResolutionCycle(cycleErr.Cycle)
+	case errors.Is(err, graph.ErrResolutionDepthExceeded):
+		return AuthorizationModelResolutionTooComplex
+	case errors.Is(err, graph.ErrResolutionBudgetExceeded):
+		return ResolutionBudgetExceeded(err)
+	case errors.As(err, &throttledErr):
+		return ThrottledTimeoutRetryAfter(throttledErr.ThrottlingDuration)
+	case errors.Is(err, graph.ErrDelegatedCheckFailed):
+		return DelegatedCheckFailed
+	case errors.Is(err, graph.ErrThrottledTimeout):
+		return ThrottledTimeout
 	case errors.Is(err, storage.ErrTransactionalWriteFailed):
-		return status.Error(codes.Aborted, err.Error())
+		return TransactionConflict(err)
 	case errors.Is(err, storage.ErrInvalidWriteInput):
 		return WriteFailedDueToInvalidInput(err)
 	case errors.Is(err, storage.ErrInvalidContinuationToken):
@@ -126,30 +381,43 @@ func HandleError(public string, err error) error {
 		return MismatchObjectType
 	case errors.Is(err, storage.ErrCancelled):
 		return RequestCancelled
+	case errors.Is(err, storage.ErrUnavailable):
+		return DatastoreUnavailable
 	case errors.Is(err, storage.ErrDeadlineExceeded):
-		return RequestDeadlineExceeded
+		return DatastoreDeadlineExceeded
+	case errors.Is(err, context.DeadlineExceeded):
+		return ResolutionDeadlineExceeded
 	default:
 		return NewInternalError(public, err)
 	}
 }
 
+// InvalidContextualTuple returns the error of HandleTupleValidateError for the contextual tuple at the
+// index of the request, with the index in its message.
+func InvalidContextualTuple(index int, err error) error {
+	err = HandleTupleValidateError(err)
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	p := st.Proto()
+	p.Message = fmt.Sprintf("invalid contextual tuple at index %d: %s", index, p.GetMessage())
+	return status.ErrorProto(p)
+}
+
 // HandleTupleValidateError provide common routines for handling tuples validation error.
 func HandleTupleValidateError(err error) error {
 	switch t := err.(type) {
 	case *tuple.InvalidTupleError:
-		return status.Error(
-			codes.Code(openfgav1.ErrorCode_invalid_tuple),
-			fmt.Sprintf("Invalid tuple '%s'. Reason: %s", t.TupleKey, t.Cause.Error()),
-		)
+		return InvalidTuple(t.TupleKey, t.Cause)
 	case *tuple.TypeNotFoundError:
 		return TypeNotFound(t.TypeName)
 	case *tuple.RelationNotFoundError:
 		return RelationNotFound(t.Relation, t.TypeName, t.TupleKey)
 	case *tuple.InvalidConditionalTupleError:
-		return status.Error(
-			codes.Code(openfgav1.ErrorCode_validation_error),
-			err.Error(),
-		)
+		return ValidationError(err)
 	}
 
 	return HandleError("", err)
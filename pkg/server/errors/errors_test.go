@@ -1,19 +1,32 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/openfga/openfga/internal/graph"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
 )
 
+// statusWithReason returns the status error with the code and message, and the error info of the reason.
+func statusWithReason(t *testing.T, code codes.Code, msg string, reason string) error {
+	st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: "openfga.dev"})
+	require.NoError(t, err)
+
+	return st.Err()
+}
+
 func TestInternalErrorDontLeakInternals(t *testing.T) {
 	err := NewInternalError("public", errors.New("internal"))
 
@@ -46,7 +59,19 @@ func TestHandleStorageErrors(t *testing.T) {
 		},
 		`context_deadline_exceeeded`: {
 			storageErr:              storage.ErrDeadlineExceeded,
-			expectedTranslatedError: RequestDeadlineExceeded,
+			expectedTranslatedError: DatastoreDeadlineExceeded,
+		},
+		`datastore_context_deadline_exceeeded`: {
+			storageErr:              fmt.Errorf("%w: %w", storage.ErrDeadlineExceeded, context.DeadlineExceeded),
+			expectedTranslatedError: DatastoreDeadlineExceeded,
+		},
+		`resolution_context_deadline_exceeeded`: {
+			storageErr:              fmt.Errorf("resolution failed: %w", context.DeadlineExceeded),
+			expectedTranslatedError: ResolutionDeadlineExceeded,
+		},
+		`datastore_unavailable`: {
+			storageErr:              fmt.Errorf("%w: circuit breaker is open", storage.ErrUnavailable),
+			expectedTranslatedError: DatastoreUnavailable,
 		},
 		`invalid_write_input`: {
 			storageErr:              storage.ErrInvalidWriteInput,
@@ -54,7 +79,7 @@ func TestHandleStorageErrors(t *testing.T) {
 		},
 		`transaction_failed`: {
 			storageErr:              storage.ErrTransactionalWriteFailed,
-			expectedTranslatedError: status.Error(codes.Aborted, storage.ErrTransactionalWriteFailed.Error()),
+			expectedTranslatedError: statusWithReason(t, codes.Aborted, storage.ErrTransactionalWriteFailed.Error(), "transaction_conflict"),
 		},
 	}
 	for testName, test := range tests {
@@ -79,11 +104,9 @@ func TestHandleTupleValidateError(t *testing.T) {
 				Cause:    fmt.Errorf("invalid tuple error"),
 				TupleKey: tuple.NewCheckRequestTupleKey("object:x", "relation_y", "user:z"),
 			},
-			expectedTranslatedError: status.Error(
-				codes.Code(openfgav1.ErrorCode_invalid_tuple),
-				fmt.Sprintf("Invalid tuple '%s'. Reason: %s",
-					tuple.NewCheckRequestTupleKey("object:x", "relation_y", "user:z"),
-					fmt.Errorf("invalid tuple error")),
+			expectedTranslatedError: InvalidTuple(
+				tuple.NewCheckRequestTupleKey("object:x", "relation_y", "user:z"),
+				fmt.Errorf("invalid tuple error"),
 			),
 		},
 		`type_not_found`: {
@@ -106,9 +129,10 @@ func TestHandleTupleValidateError(t *testing.T) {
 		},
 		"invalid_tuple_condition": {
 			validateError: &invalidConditionTupleError,
-			expectedTranslatedError: status.Error(
+			expectedTranslatedError: statusWithReason(t,
 				codes.Code(openfgav1.ErrorCode_validation_error),
 				invalidConditionTupleError.Error(),
+				"validation_error",
 			),
 		},
 		"undefined error": {
@@ -122,3 +146,74 @@ func TestHandleTupleValidateError(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorDetails(t *testing.T) {
+	t.Run("invalid_tuple", func(t *testing.T) {
+		err := HandleTupleValidateError(&tuple.InvalidTupleError{
+			Cause:    fmt.Errorf("the 'user' field is malformed"),
+			TupleKey: tuple.NewTupleKey("doc:x", "viewer", "user"),
+		})
+
+		st := status.Convert(err)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_tuple), st.Code())
+		require.Contains(t, st.Message(), "Reason: the 'user' field is malformed")
+
+		details := st.Details()
+		require.Len(t, details, 2)
+		require.Equal(t, "invalid_tuple", details[0].(*errdetails.ErrorInfo).GetReason())
+		require.Equal(t, ErrorDomain, details[0].(*errdetails.ErrorInfo).GetDomain())
+		require.Equal(t, map[string]string{"tuple_key": "doc:x#viewer@user"}, details[0].(*errdetails.ErrorInfo).GetMetadata())
+
+		violations := details[1].(*errdetails.BadRequest).GetFieldViolations()
+		require.Len(t, violations, 1)
+		require.Equal(t, "tuple_key", violations[0].GetField())
+		require.Equal(t, "the 'user' field is malformed", violations[0].GetDescription())
+	})
+
+	t.Run("relation_not_found", func(t *testing.T) {
+		details := status.Convert(RelationNotFound("viewer", "doc", nil)).Details()
+		require.Len(t, details, 1)
+		require.Equal(t, map[string]string{"type": "doc", "relation": "viewer"}, details[0].(*errdetails.ErrorInfo).GetMetadata())
+	})
+
+	t.Run("invalid_relation_in_model", func(t *testing.T) {
+		err := InvalidAuthorizationModelInput(&typesystem.InvalidRelationError{
+			ObjectType: "document",
+			Relation:   "viewer",
+			Cause:      typesystem.ErrNoEntrypoints,
+		})
+
+		st := status.Convert(err)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), st.Code())
+
+		details := st.Details()
+		require.Len(t, details, 2)
+		require.Equal(t, map[string]string{"type": "document", "relation": "viewer"}, details[0].(*errdetails.ErrorInfo).GetMetadata())
+		require.Equal(t, "type_definitions[document].relations[viewer]", details[1].(*errdetails.BadRequest).GetFieldViolations()[0].GetField())
+	})
+
+	t.Run("invalid_model", func(t *testing.T) {
+		details := status.Convert(InvalidAuthorizationModelInput(typesystem.ErrDuplicateTypes)).Details()
+		require.Len(t, details, 2)
+		require.Empty(t, details[0].(*errdetails.ErrorInfo).GetMetadata())
+		require.Equal(t, "type_definitions", details[1].(*errdetails.BadRequest).GetFieldViolations()[0].GetField())
+	})
+	t.Run("retry_after", func(t *testing.T) {
+		details := status.Convert(Overloaded("memory", 1500*time.Millisecond)).Details()
+		require.Len(t, details, 2)
+		require.Equal(t, map[string]string{"resource": "memory", RetryAfterMetadataKey: "2"}, details[0].(*errdetails.ErrorInfo).GetMetadata())
+		require.Equal(t, 2*time.Second, details[1].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+	})
+
+	t.Run("throttled_timeout", func(t *testing.T) {
+		err := HandleError("", &graph.ThrottledTimeoutError{ThrottlingDuration: 3 * time.Second})
+		require.ErrorIs(t, err, ThrottledTimeoutRetryAfter(3*time.Second))
+
+		details := status.Convert(err).Details()
+		require.Len(t, details, 2)
+		require.Equal(t, map[string]string{RetryAfterMetadataKey: "3"}, details[0].(*errdetails.ErrorInfo).GetMetadata())
+		require.Equal(t, 3*time.Second, details[1].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+
+		require.Equal(t, 3, EncodeError(err).ActualError.RetryAfterSeconds)
+	})
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+)
+
+const (
+	// ExpandContextualTuplesHeader has the contextual tuples of an Expand request, as a JSON array of
+	// tuple keys in the format of the HTTP API. The tree is expanded with them as if they were
+	// written, like a Check request with the same contextual tuples.
+	ExpandContextualTuplesHeader = "Openfga-Expand-Contextual-Tuples"
+
+	// ExpandContextHeader has the context of an Expand request, as a JSON object. The conditions of
+	// the tuples are evaluated with it, like in a Check request with the same context: the tuples
+	// whose condition isn't met are left out of the tree, and a tuple whose condition misses
+	// parameters fails the request. Without it, the conditions aren't evaluated.
+	ExpandContextHeader = "Openfga-Expand-Context"
+)
+
+// expandContext returns the contextual tuples and the context of an Expand request, from its
+// ExpandContextualTuplesHeader and ExpandContextHeader.
+func (s *Server) expandContext(ctx context.Context) ([]*openfgav1.TupleKey, *structpb.Struct, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var contextualTuples []*openfgav1.TupleKey
+	if values := md.Get(ExpandContextualTuplesHeader); len(values) > 0 && values[0] != "" {
+		var rawTuples []json.RawMessage
+		if err := json.Unmarshal([]byte(values[0]), &rawTuples); err != nil {
+			return nil, nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header, it must be a JSON array of tuple keys: %w", ExpandContextualTuplesHeader, err))
+		}
+
+		contextualTuples = make([]*openfgav1.TupleKey, 0, len(rawTuples))
+		for i, rawTuple := range rawTuples {
+			tk := &openfgav1.TupleKey{}
+			if err := protojson.Unmarshal(rawTuple, tk); err != nil {
+				return nil, nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header, invalid tuple key at index %d: %w", ExpandContextualTuplesHeader, i, err))
+			}
+			contextualTuples = append(contextualTuples, tk)
+		}
+
+		if err := s.validateContextualTuplesLimit(&openfgav1.ContextualTupleKeys{TupleKeys: contextualTuples}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var conditionContext *structpb.Struct
+	if values := md.Get(ExpandContextHeader); len(values) > 0 && values[0] != "" {
+		conditionContext = &structpb.Struct{}
+		if err := protojson.Unmarshal([]byte(values[0]), conditionContext); err != nil {
+			return nil, nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header, it must be a JSON object: %w", ExpandContextHeader, err))
+		}
+	}
+
+	return contextualTuples, conditionContext, nil
+}
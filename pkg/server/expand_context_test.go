@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestExpandContext(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "expand-context"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user, user with business_hours]
+condition business_hours(hour: int) {
+  hour >= 9 && hour < 17
+}`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+		Conditions:      model.GetConditions(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:anne", "business_hours", nil),
+		}},
+	})
+	require.NoError(t, err)
+
+	expand := func(headers ...string) ([]string, error) {
+		resp, err := s.Expand(metadata.NewIncomingContext(ctx, metadata.Pairs(headers...)), &openfgav1.ExpandRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewExpandRequestTupleKey("document:1", "viewer"),
+		})
+		return resp.GetTree().GetRoot().GetLeaf().GetUsers().GetUsers(), err
+	}
+
+	t.Run("without_headers", func(t *testing.T) {
+		users, err := expand()
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:anne"}, users)
+	})
+
+	t.Run("contextual_tuples", func(t *testing.T) {
+		users, err := expand(
+			ExpandContextualTuplesHeader, `[{"object": "document:1", "relation": "viewer", "user": "user:bob"}]`,
+			ExpandContextHeader, `{"hour": 20}`,
+		)
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:bob"}, users)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		users, err := expand(ExpandContextHeader, `{"hour": 10}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"user:anne"}, users)
+
+		users, err = expand(ExpandContextHeader, `{"hour": 20}`)
+		require.NoError(t, err)
+		require.Empty(t, users)
+	})
+
+	t.Run("invalid_headers", func(t *testing.T) {
+		for name, headers := range map[string][]string{
+			"contextual_tuples_not_an_array":       {ExpandContextualTuplesHeader, `{"object": "document:1"}`},
+			"contextual_tuple_not_a_tuple_key":     {ExpandContextualTuplesHeader, `[{"object": 1}]`},
+			"contextual_tuple_of_unknown_relation": {ExpandContextualTuplesHeader, `[{"object": "document:1", "relation": "owner", "user": "user:bob"}]`},
+			"context_not_an_object":                {ExpandContextHeader, `[1]`},
+			"context_missing_parameters":           {ExpandContextHeader, `{}`},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := expand(headers...)
+				require.Error(t, err)
+				require.NotEqual(t, codes.Internal, status.Code(err))
+			})
+		}
+	})
+
+	t.Run("too_many_contextual_tuples", func(t *testing.T) {
+		limited := MustNewServerWithOpts(WithDatastore(ds), WithMaxContextualTuples(1))
+		t.Cleanup(limited.Close)
+
+		_, err := limited.Expand(metadata.NewIncomingContext(ctx, metadata.Pairs(ExpandContextualTuplesHeader, `[
+			{"object": "document:1", "relation": "viewer", "user": "user:bob"},
+			{"object": "document:1", "relation": "viewer", "user": "user:charlie"}
+		]`)), &openfgav1.ExpandRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewExpandRequestTupleKey("document:1", "viewer"),
+		})
+		require.ErrorContains(t, err, "contextual tuples")
+	})
+}
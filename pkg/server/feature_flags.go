@@ -0,0 +1,29 @@
+package server
+
+import (
+	"context"
+
+	"github.com/openfga/openfga/internal/graph"
+	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/featureflags"
+)
+
+// applyFeatureFlags returns the context of a Check or ListObjects request of the store, whose Check
+// resolutions don't have the experimental behaviors whose feature flag is off for the store.
+func (s *Server) applyFeatureFlags(ctx context.Context, storeID string) context.Context {
+	if s.checkPlanner != nil && !s.featureFlags.Enabled(featureflags.CheckPlanner, storeID) {
+		ctx = graph.ContextWithoutCheckPlanner(ctx)
+	}
+
+	return ctx
+}
+
+// listObjectsShardsOf returns the number of worker pools of the ListObjects requests of the store,
+// see WithListObjectsShards.
+func (s *Server) listObjectsShardsOf(storeID string) uint32 {
+	if !s.featureFlags.Enabled(featureflags.ListObjectsShards, storeID) {
+		return serverconfig.DefaultListObjectsShards
+	}
+
+	return s.listObjectsShards
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/pkg/featureflags"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+func TestApplyFeatureFlags(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	const (
+		rolledOutStore = "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8A"
+		otherStore     = "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8B"
+	)
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	flags, err := featureflags.Parse([]string{
+		"check-planner=" + rolledOutStore,
+		"list-objects-shards=" + rolledOutStore,
+	})
+	require.NoError(t, err)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckPlannerEnabled(true),
+		WithListObjectsShards(4),
+		WithFeatureFlags(flags),
+	)
+	t.Cleanup(s.Close)
+
+	ctx := context.Background()
+
+	require.False(t, graph.CheckPlannerDisabledFromContext(s.applyFeatureFlags(ctx, rolledOutStore)))
+	require.True(t, graph.CheckPlannerDisabledFromContext(s.applyFeatureFlags(ctx, otherStore)))
+
+	require.EqualValues(t, 4, s.listObjectsShardsOf(rolledOutStore))
+	require.EqualValues(t, 1, s.listObjectsShardsOf(otherStore))
+
+	// the flags can be changed while the server runs
+	require.NoError(t, flags.Set(featureflags.CheckPlanner, []string{"on"}))
+	require.False(t, graph.CheckPlannerDisabledFromContext(s.applyFeatureFlags(ctx, otherStore)))
+}
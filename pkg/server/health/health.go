@@ -3,6 +3,10 @@ package health
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
 
 	grpcauth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
 	"google.golang.org/grpc/codes"
@@ -15,10 +19,31 @@ type TargetService interface {
 	IsReady(ctx context.Context) (bool, error)
 }
 
+// DependencyStatus is the health of a single dependency of a TargetService (e.g. the datastore).
+type DependencyStatus struct {
+	Name    string
+	Status  healthv1pb.HealthCheckResponse_ServingStatus
+	Message string
+
+	// Details are machine-readable facts about the dependency (e.g. the schema revision of the
+	// datastore), which are included in the HTTP health reports as they are.
+	Details map[string]any
+}
+
+// DependencyReporter can be implemented by a TargetService to report the health of each of its
+// dependencies individually. If implemented, each dependency can be queried through the Health
+// service by using its name as the service name.
+type DependencyReporter interface {
+	Dependencies(ctx context.Context) []DependencyStatus
+}
+
 type Checker struct {
 	healthv1pb.UnimplementedHealthServer
 	TargetService
 	TargetServiceName string
+
+	starting atomic.Bool
+	draining atomic.Bool
 }
 
 var _ grpcauth.ServiceAuthFuncOverride = (*Checker)(nil)
@@ -28,9 +53,30 @@ func (o *Checker) AuthFuncOverride(ctx context.Context, fullMethodName string) (
 	return ctx, nil
 }
 
+// SetStarting marks the server as still starting up. While starting, the readiness endpoint
+// reports the server as not serving even if all of its dependencies are healthy.
+func (o *Checker) SetStarting(starting bool) {
+	o.starting.Store(starting)
+}
+
+// SetDraining marks the server as draining in-flight requests before shutting down. While draining,
+// the server is reported as not serving so that no new traffic is routed to it.
+func (o *Checker) SetDraining(draining bool) {
+	o.draining.Store(draining)
+}
+
+// Draining reports whether the server is marked as draining.
+func (o *Checker) Draining() bool {
+	return o.draining.Load()
+}
+
 func (o *Checker) Check(ctx context.Context, req *healthv1pb.HealthCheckRequest) (*healthv1pb.HealthCheckResponse, error) {
 	requestedService := req.GetService()
 	if requestedService == "" || requestedService == o.TargetServiceName {
+		if o.draining.Load() {
+			return &healthv1pb.HealthCheckResponse{Status: healthv1pb.HealthCheckResponse_NOT_SERVING}, nil
+		}
+
 		ready, err := o.TargetService.IsReady(ctx)
 		if err != nil {
 			return &healthv1pb.HealthCheckResponse{Status: healthv1pb.HealthCheckResponse_NOT_SERVING}, err
@@ -43,9 +89,145 @@ func (o *Checker) Check(ctx context.Context, req *healthv1pb.HealthCheckRequest)
 		return &healthv1pb.HealthCheckResponse{Status: healthv1pb.HealthCheckResponse_SERVING}, nil
 	}
 
+	for _, dependency := range o.dependencies(ctx) {
+		if dependency.Name == requestedService {
+			return &healthv1pb.HealthCheckResponse{Status: dependency.Status}, nil
+		}
+	}
+
 	return nil, status.Errorf(codes.NotFound, "service '%s' is not registered with the Health server", requestedService)
 }
 
 func (o *Checker) Watch(req *healthv1pb.HealthCheckRequest, server healthv1pb.Health_WatchServer) error {
 	return status.Error(codes.Unimplemented, "unimplemented streaming endpoint")
 }
+
+type dependencyResponse struct {
+	Name    string         `json:"name"`
+	Status  string         `json:"status"`
+	Message string         `json:"message,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type httpResponse struct {
+	Status       string               `json:"status"`
+	Message      string               `json:"message,omitempty"`
+	Dependencies []dependencyResponse `json:"dependencies,omitempty"`
+}
+
+// LivenessHandler returns an HTTP handler (e.g. for /healthz) that reports the health of the
+// server process. The health of each dependency is included in the response, but an unhealthy
+// dependency (e.g. a datastore outage) doesn't fail the liveness check, since restarting the
+// server wouldn't fix it. It responds with 503 only if the server is draining.
+func (o *Checker) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o.serveHTTP(w, r, false)
+	}
+}
+
+// ReadinessHandler returns an HTTP handler (e.g. for /readyz) that reports the health of the
+// server and of each of its dependencies. It responds with 503 if any dependency is not serving,
+// while the server is still starting or if the server is draining.
+func (o *Checker) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o.serveHTTP(w, r, true)
+	}
+}
+
+// DrainState is the draining state of the server, see DrainHandler.
+type DrainState struct {
+	Draining bool `json:"draining"`
+}
+
+// DrainHandler returns an HTTP handler that returns the DrainState of the server with a GET request,
+// and changes it to the DrainState of its body with a PUT request, so that the server can be taken
+// out of the traffic, e.g. before maintenance, and put back without being restarted.
+func (o *Checker) DrainHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var state DrainState
+			if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+				http.Error(w, fmt.Sprintf("invalid drain state: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			o.SetDraining(state.Draining)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(DrainState{Draining: o.Draining()})
+	}
+}
+
+func (o *Checker) serveHTTP(w http.ResponseWriter, r *http.Request, readiness bool) {
+	response := o.report(r.Context())
+	if !readiness {
+		response.Status = healthv1pb.HealthCheckResponse_SERVING.String()
+		response.Message = ""
+	}
+
+	switch {
+	case o.draining.Load():
+		response.Status = healthv1pb.HealthCheckResponse_NOT_SERVING.String()
+		response.Message = "server is draining"
+	case readiness && o.starting.Load():
+		response.Status = healthv1pb.HealthCheckResponse_NOT_SERVING.String()
+		response.Message = "server is starting"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if response.Status != healthv1pb.HealthCheckResponse_SERVING.String() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// report builds the health report of the target service. If the target service reports its
+// dependencies, the overall status is derived from them, otherwise from TargetService.IsReady.
+func (o *Checker) report(ctx context.Context) httpResponse {
+	reporter, ok := o.TargetService.(DependencyReporter)
+	if !ok {
+		ready, err := o.TargetService.IsReady(ctx)
+		if err != nil {
+			return httpResponse{Status: healthv1pb.HealthCheckResponse_NOT_SERVING.String(), Message: err.Error()}
+		}
+
+		if !ready {
+			return httpResponse{Status: healthv1pb.HealthCheckResponse_NOT_SERVING.String()}
+		}
+
+		return httpResponse{Status: healthv1pb.HealthCheckResponse_SERVING.String()}
+	}
+
+	response := httpResponse{Status: healthv1pb.HealthCheckResponse_SERVING.String()}
+	for _, dependency := range reporter.Dependencies(ctx) {
+		if dependency.Status != healthv1pb.HealthCheckResponse_SERVING {
+			response.Status = healthv1pb.HealthCheckResponse_NOT_SERVING.String()
+		}
+
+		response.Dependencies = append(response.Dependencies, dependencyResponse{
+			Name:    dependency.Name,
+			Status:  dependency.Status.String(),
+			Message: dependency.Message,
+			Details: dependency.Details,
+		})
+	}
+
+	return response
+}
+
+func (o *Checker) dependencies(ctx context.Context) []DependencyStatus {
+	reporter, ok := o.TargetService.(DependencyReporter)
+	if !ok {
+		return nil
+	}
+
+	return reporter.Dependencies(ctx)
+}
@@ -0,0 +1,210 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type fakeTargetService struct {
+	dependencies []DependencyStatus
+}
+
+func (f *fakeTargetService) IsReady(context.Context) (bool, error) {
+	for _, dependency := range f.dependencies {
+		if dependency.Status != healthv1pb.HealthCheckResponse_SERVING {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (f *fakeTargetService) Dependencies(context.Context) []DependencyStatus {
+	return f.dependencies
+}
+
+func TestCheckDependency(t *testing.T) {
+	checker := &Checker{
+		TargetService: &fakeTargetService{dependencies: []DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+			{Name: "migrations", Status: healthv1pb.HealthCheckResponse_NOT_SERVING, Message: "datastore requires migrations"},
+		}},
+		TargetServiceName: "openfga.v1.OpenFGAService",
+	}
+
+	resp, err := checker.Check(context.Background(), &healthv1pb.HealthCheckRequest{Service: "openfga.v1.OpenFGAService"})
+	require.NoError(t, err)
+	require.Equal(t, healthv1pb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	resp, err = checker.Check(context.Background(), &healthv1pb.HealthCheckRequest{Service: "datastore"})
+	require.NoError(t, err)
+	require.Equal(t, healthv1pb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	resp, err = checker.Check(context.Background(), &healthv1pb.HealthCheckRequest{Service: "migrations"})
+	require.NoError(t, err)
+	require.Equal(t, healthv1pb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	_, err = checker.Check(context.Background(), &healthv1pb.HealthCheckRequest{Service: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestHTTPHandlers(t *testing.T) {
+	serve := func(t *testing.T, handler http.HandlerFunc) (int, httpResponse) {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		var response httpResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		return recorder.Code, response
+	}
+
+	t.Run("healthy_dependencies", func(t *testing.T) {
+		checker := &Checker{TargetService: &fakeTargetService{dependencies: []DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+		}}}
+
+		code, response := serve(t, checker.LivenessHandler())
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "SERVING", response.Status)
+		require.Equal(t, []dependencyResponse{{Name: "datastore", Status: "SERVING"}}, response.Dependencies)
+
+		code, response = serve(t, checker.ReadinessHandler())
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "SERVING", response.Status)
+	})
+
+	t.Run("unhealthy_dependency", func(t *testing.T) {
+		checker := &Checker{TargetService: &fakeTargetService{dependencies: []DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_NOT_SERVING, Message: "connection refused"},
+			{Name: "migrations", Status: healthv1pb.HealthCheckResponse_UNKNOWN},
+		}}}
+
+		code, response := serve(t, checker.ReadinessHandler())
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, "NOT_SERVING", response.Status)
+		require.Equal(t, []dependencyResponse{
+			{Name: "datastore", Status: "NOT_SERVING", Message: "connection refused"},
+			{Name: "migrations", Status: "UNKNOWN"},
+		}, response.Dependencies)
+
+		// the liveness check only reflects the server process
+		code, response = serve(t, checker.LivenessHandler())
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "SERVING", response.Status)
+		require.Equal(t, []dependencyResponse{
+			{Name: "datastore", Status: "NOT_SERVING", Message: "connection refused"},
+			{Name: "migrations", Status: "UNKNOWN"},
+		}, response.Dependencies)
+	})
+
+	t.Run("dependency_details", func(t *testing.T) {
+		checker := &Checker{TargetService: &fakeTargetService{dependencies: []DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+			{Name: "migrations", Status: healthv1pb.HealthCheckResponse_SERVING, Details: map[string]any{
+				"schema_revision":         12,
+				"pending_migration_phase": "contract",
+			}},
+		}}}
+
+		code, response := serve(t, checker.ReadinessHandler())
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, []dependencyResponse{
+			{Name: "datastore", Status: "SERVING"},
+			{Name: "migrations", Status: "SERVING", Details: map[string]any{
+				"schema_revision":         float64(12),
+				"pending_migration_phase": "contract",
+			}},
+		}, response.Dependencies)
+	})
+
+	t.Run("starting", func(t *testing.T) {
+		checker := &Checker{TargetService: &fakeTargetService{dependencies: []DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+		}}}
+		checker.SetStarting(true)
+
+		code, response := serve(t, checker.LivenessHandler())
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "SERVING", response.Status)
+
+		code, response = serve(t, checker.ReadinessHandler())
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, "NOT_SERVING", response.Status)
+		require.Equal(t, "server is starting", response.Message)
+
+		checker.SetStarting(false)
+
+		code, _ = serve(t, checker.ReadinessHandler())
+		require.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		checker := &Checker{TargetService: &fakeTargetService{dependencies: []DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+		}}}
+		checker.SetDraining(true)
+
+		for _, handler := range []http.HandlerFunc{checker.LivenessHandler(), checker.ReadinessHandler()} {
+			code, response := serve(t, handler)
+			require.Equal(t, http.StatusServiceUnavailable, code)
+			require.Equal(t, "NOT_SERVING", response.Status)
+			require.Equal(t, "server is draining", response.Message)
+		}
+
+		resp, err := checker.Check(context.Background(), &healthv1pb.HealthCheckRequest{})
+		require.NoError(t, err)
+		require.Equal(t, healthv1pb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+	})
+}
+
+func TestDrainHandler(t *testing.T) {
+	checker := &Checker{TargetService: &fakeTargetService{dependencies: []DependencyStatus{
+		{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+	}}}
+
+	drain := func(t *testing.T, method, body string) (int, DrainState) {
+		rec := httptest.NewRecorder()
+		checker.DrainHandler().ServeHTTP(rec, httptest.NewRequest(method, "/drain", strings.NewReader(body)))
+
+		var state DrainState
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&state))
+		}
+		return rec.Code, state
+	}
+
+	code, state := drain(t, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, code)
+	require.False(t, state.Draining)
+
+	code, state = drain(t, http.MethodPut, `{"draining": true}`)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, state.Draining)
+
+	rec := httptest.NewRecorder()
+	checker.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	code, state = drain(t, http.MethodPut, `{"draining": false}`)
+	require.Equal(t, http.StatusOK, code)
+	require.False(t, state.Draining)
+
+	rec = httptest.NewRecorder()
+	checker.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	code, _ = drain(t, http.MethodPut, `{"draining": `)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = drain(t, http.MethodPost, "")
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+}
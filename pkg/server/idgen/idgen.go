@@ -0,0 +1,91 @@
+// Package idgen generates the IDs of the stores and authorization models.
+package idgen
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	// SchemeULID generates ULIDs, the IDs OpenFGA always generated.
+	SchemeULID = "ulid"
+
+	// SchemeUUIDv7 generates version 7 UUIDs, encoded like ULIDs. Both start with the millisecond
+	// timestamp of their generation, so the ID is the 128 bits of the UUID, e.g. to join the stores
+	// with the records of systems that key them by UUIDs.
+	SchemeUUIDv7 = "uuidv7"
+)
+
+// idRegex matches the IDs that the API accepts, i.e. the ULIDs in upper case. The first character
+// is at most 7, since a ULID has 128 bits.
+var idRegex = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+// Generator generates the IDs of the created stores and of the written authorization models.
+//
+// The IDs must be in the format of ULIDs in upper case, which the API validates the IDs of the
+// requests against. The IDs of the models of a store must also increase with time, since the latest
+// model of a store is the one with the greatest ID.
+type Generator interface {
+	NewStoreID(ctx context.Context) (string, error)
+	NewAuthorizationModelID(ctx context.Context, storeID string) (string, error)
+}
+
+// New returns the Generator of the scheme.
+func New(scheme string) (Generator, error) {
+	switch scheme {
+	case SchemeULID:
+		return ulidGenerator{}, nil
+	case SchemeUUIDv7:
+		return uuidv7Generator{}, nil
+	default:
+		return nil, fmt.Errorf("unknown ID scheme '%s', must be one of ['%s', '%s']", scheme, SchemeULID, SchemeUUIDv7)
+	}
+}
+
+// Default returns the Generator of SchemeULID.
+func Default() Generator {
+	return ulidGenerator{}
+}
+
+// Validate returns an error if the ID isn't in the format of the IDs that the API accepts, e.g. if
+// a caller-supplied store ID isn't.
+func Validate(id string) error {
+	if !idRegex.MatchString(id) {
+		return fmt.Errorf("'%s' is not a ULID of 26 upper case characters", id)
+	}
+	return nil
+}
+
+type ulidGenerator struct{}
+
+func (ulidGenerator) NewStoreID(context.Context) (string, error) {
+	return ulid.Make().String(), nil
+}
+
+func (ulidGenerator) NewAuthorizationModelID(context.Context, string) (string, error) {
+	return ulid.Make().String(), nil
+}
+
+type uuidv7Generator struct{}
+
+func (uuidv7Generator) NewStoreID(context.Context) (string, error) {
+	return newUUIDv7()
+}
+
+func (uuidv7Generator) NewAuthorizationModelID(context.Context, string) (string, error) {
+	return newUUIDv7()
+}
+
+// newUUIDv7 returns a version 7 UUID encoded like a ULID. The UUIDs generated by the process are
+// monotonic, like the ULIDs of ulid.Make.
+func newUUIDv7() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate a UUIDv7: %w", err)
+	}
+	return ulid.ULID(id).String(), nil
+}
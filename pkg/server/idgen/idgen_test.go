@@ -0,0 +1,68 @@
+package idgen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerators(t *testing.T) {
+	ctx := context.Background()
+
+	for _, scheme := range []string{SchemeULID, SchemeUUIDv7} {
+		t.Run(scheme, func(t *testing.T) {
+			generator, err := New(scheme)
+			require.NoError(t, err)
+
+			storeID, err := generator.NewStoreID(ctx)
+			require.NoError(t, err)
+			require.NoError(t, Validate(storeID))
+
+			// the IDs of the models of a store increase
+			var previous string
+			for i := 0; i < 100; i++ {
+				modelID, err := generator.NewAuthorizationModelID(ctx, storeID)
+				require.NoError(t, err)
+				require.NoError(t, Validate(modelID))
+				require.Greater(t, modelID, previous)
+				previous = modelID
+			}
+		})
+	}
+
+	t.Run("uuidv7_ids_are_uuids", func(t *testing.T) {
+		generator, err := New(SchemeUUIDv7)
+		require.NoError(t, err)
+
+		id, err := generator.NewStoreID(ctx)
+		require.NoError(t, err)
+
+		parsed, err := ulid.ParseStrict(id)
+		require.NoError(t, err)
+		require.Equal(t, uuid.Version(7), uuid.UUID(parsed).Version())
+		require.Equal(t, uuid.RFC4122, uuid.UUID(parsed).Variant())
+	})
+
+	t.Run("unknown_scheme", func(t *testing.T) {
+		_, err := New("uuidv4")
+		require.ErrorContains(t, err, "unknown ID scheme")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate("01HVMMBCMGZNT3SED4Z17ECXCA"))
+
+	for _, id := range []string{
+		"",
+		"01hvmmbcmgznt3sed4z17ecxca",
+		"01HVMMBCMGZNT3SED4Z17ECXC",
+		"01HVMMBCMGZNT3SED4Z17ECXCAA",
+		"01HVMMBCMGZNT3SED4Z17ECXCU",
+		"81HVMMBCMGZNT3SED4Z17ECXCA",
+	} {
+		require.Error(t, Validate(id), id)
+	}
+}
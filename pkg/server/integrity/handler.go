@@ -0,0 +1,84 @@
+package integrity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// Reports are the reports of the stores returned by the admin handler.
+type Reports struct {
+	Stores []*StoreReport `json:"stores"`
+}
+
+// Handler returns the handler of the admin server that serves the reports of the checker. A GET
+// returns the report of the last check of the store of the 'store_id' query parameter, or of every
+// store without it. A POST checks the store of the 'store_id' query parameter, or every store
+// without it, right away, cleans up the orphaned tuples with the cleanup mode of the 'cleanup' query
+// parameter, which only reports them by default, and returns the reports.
+func (c *Checker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storeID := r.URL.Query().Get("store_id")
+
+		var reports []*StoreReport
+		switch r.Method {
+		case http.MethodGet:
+			if storeID == "" {
+				reports = c.Reports()
+				break
+			}
+
+			report, ok := c.Get(storeID)
+			if !ok {
+				http.Error(w, "the store hasn't been checked yet", http.StatusNotFound)
+				return
+			}
+			reports = []*StoreReport{report}
+		case http.MethodPost:
+			cleanup, err := ParseCleanupMode(r.URL.Query().Get("cleanup"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if cleanup == CleanupQuarantine && c.quarantine == nil {
+				http.Error(w, ErrQuarantineNotConfigured.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if storeID == "" {
+				if err := c.CheckAll(r.Context(), cleanup); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				reports = c.Reports()
+				break
+			}
+
+			if _, err := c.datastore.GetStore(r.Context(), storeID); err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					http.Error(w, fmt.Sprintf("store '%s' not found", storeID), http.StatusNotFound)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			report, err := c.CheckStore(r.Context(), storeID, cleanup)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			reports = []*StoreReport{report}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Reports{Stores: reports})
+	})
+}
@@ -0,0 +1,536 @@
+// Package integrity checks the tuples of each store against the latest authorization model of the
+// store, to find the orphaned tuples: the tuples whose object type, relation, user type or condition
+// no longer exists in the model, e.g. after a type was removed. Orphaned tuples are never returned
+// by the queries, but they still take space and come back to life if the model defines them again.
+// They can be reported only, deleted, or quarantined to object storage and deleted.
+package integrity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/backup"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+const (
+	// DefaultInterval is how often the stores are checked by default.
+	DefaultInterval = 24 * time.Hour
+
+	// DefaultMaxReportedTuples is the default maximum number of orphaned tuples listed in the report
+	// of a store.
+	DefaultMaxReportedTuples = 100
+
+	// Writer is the writer of the deletions of the orphaned tuples, see [storage.ContextWithWriter].
+	Writer = "openfga:integrity-checker"
+
+	readPageSize = 100
+)
+
+// CleanupMode is what is done with the orphaned tuples that are found.
+type CleanupMode string
+
+const (
+	// CleanupNone only reports the orphaned tuples.
+	CleanupNone CleanupMode = "none"
+
+	// CleanupDelete deletes the orphaned tuples.
+	CleanupDelete CleanupMode = "delete"
+
+	// CleanupQuarantine writes the orphaned tuples to the quarantine object storage, as a JSON tuple
+	// key per line that can be imported again, and then deletes them.
+	CleanupQuarantine CleanupMode = "quarantine"
+)
+
+// ParseCleanupMode returns the CleanupMode of the string, which is 'none' if it is empty.
+func ParseCleanupMode(s string) (CleanupMode, error) {
+	switch mode := CleanupMode(s); mode {
+	case "":
+		return CleanupNone, nil
+	case CleanupNone, CleanupDelete, CleanupQuarantine:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid cleanup mode '%s', it must be 'none', 'delete' or 'quarantine'", s)
+	}
+}
+
+// Reason is why a tuple is orphaned.
+type Reason string
+
+const (
+	ReasonObjectTypeNotFound   Reason = "object_type_not_found"
+	ReasonRelationNotFound     Reason = "relation_not_found"
+	ReasonUserTypeNotFound     Reason = "user_type_not_found"
+	ReasonUserRelationNotFound Reason = "user_relation_not_found"
+	ReasonConditionNotFound    Reason = "condition_not_found"
+)
+
+var (
+	orphanedTuplesGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "integrity_orphaned_tuples",
+		Help:      "The number of orphaned tuples found by the last check of the stores, by the reason they are orphaned.",
+	}, []string{"reason"})
+
+	removedTuplesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "integrity_removed_tuple_count",
+		Help:      "The total number of orphaned tuples deleted by the integrity checker, by cleanup mode.",
+	}, []string{"cleanup"})
+
+	lastSuccessGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "integrity_last_success_timestamp_seconds",
+		Help:      "The time of the last check of all the stores that succeeded, in seconds since the Unix epoch.",
+	})
+)
+
+// ErrQuarantineNotConfigured is returned when the orphaned tuples are quarantined without a
+// quarantine object storage.
+var ErrQuarantineNotConfigured = errors.New("the quarantine object storage isn't configured")
+
+// OrphanedTuple is a tuple whose object type, relation, user type or condition doesn't exist in the
+// authorization model.
+type OrphanedTuple struct {
+	Object    string `json:"object"`
+	Relation  string `json:"relation"`
+	User      string `json:"user"`
+	Condition string `json:"condition,omitempty"`
+	Reason    Reason `json:"reason"`
+}
+
+// StoreReport is the result of the check of a store.
+type StoreReport struct {
+	StoreID string `json:"store_id"`
+
+	// AuthorizationModelID is the latest model of the store, that the tuples were checked against.
+	// It is empty if the store has no model, in which case its tuples aren't checked.
+	AuthorizationModelID string    `json:"authorization_model_id"`
+	CheckedAt            time.Time `json:"checked_at"`
+	TupleCount           int64     `json:"tuple_count"`
+	OrphanedTupleCount   int64     `json:"orphaned_tuple_count"`
+
+	// OrphanedTupleCounts are the numbers of orphaned tuples by reason.
+	OrphanedTupleCounts map[Reason]int64 `json:"orphaned_tuple_counts"`
+
+	// OrphanedTuples are the first orphaned tuples, up to the maximum number of reported tuples.
+	OrphanedTuples []OrphanedTuple `json:"orphaned_tuples"`
+
+	Cleanup           CleanupMode `json:"cleanup"`
+	RemovedTupleCount int64       `json:"removed_tuple_count"`
+
+	// QuarantineObject is the name of the object the orphaned tuples were quarantined to, if any.
+	QuarantineObject string `json:"quarantine_object,omitempty"`
+}
+
+// Checker periodically checks the tuples of every store, cleans up the orphaned tuples with its
+// cleanup mode, and keeps the report of the last check of each store.
+type Checker struct {
+	datastore         storage.OpenFGADatastore
+	logger            logger.Logger
+	interval          time.Duration
+	cleanup           CleanupMode
+	quarantine        backup.Writer
+	maxReportedTuples int
+
+	mu     sync.RWMutex
+	stores map[string]*StoreReport
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// CheckerOption defines an option that can be used to change the behavior of a [Checker].
+type CheckerOption func(c *Checker)
+
+// WithLogger sets the logger of the [Checker].
+func WithLogger(l logger.Logger) CheckerOption {
+	return func(c *Checker) {
+		c.logger = l
+	}
+}
+
+// WithInterval sets how often the stores are checked. If the interval is 0, they are only checked
+// when CheckAll or CheckStore is called.
+func WithInterval(interval time.Duration) CheckerOption {
+	return func(c *Checker) {
+		c.interval = interval
+	}
+}
+
+// WithCleanup sets what the periodic checks do with the orphaned tuples. It is CleanupNone by default.
+func WithCleanup(mode CleanupMode) CheckerOption {
+	return func(c *Checker) {
+		c.cleanup = mode
+	}
+}
+
+// WithQuarantine sets the object storage the orphaned tuples are quarantined to. The tuples of a
+// check of a store are written to '<store_id>/<time>.jsonl'.
+func WithQuarantine(writer backup.Writer) CheckerOption {
+	return func(c *Checker) {
+		c.quarantine = writer
+	}
+}
+
+// WithMaxReportedTuples sets the maximum number of orphaned tuples listed in the report of a store.
+func WithMaxReportedTuples(limit int) CheckerOption {
+	return func(c *Checker) {
+		c.maxReportedTuples = limit
+	}
+}
+
+// NewChecker constructs a [Checker] of the stores of the datastore. If the interval is not 0, the
+// stores are checked every interval. You must call Close on it after you are done using it.
+func NewChecker(datastore storage.OpenFGADatastore, opts ...CheckerOption) *Checker {
+	c := &Checker{
+		datastore:         datastore,
+		logger:            logger.NewNoopLogger(),
+		interval:          DefaultInterval,
+		cleanup:           CleanupNone,
+		maxReportedTuples: DefaultMaxReportedTuples,
+		stores:            map[string]*StoreReport{},
+		stop:              make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.interval > 0 {
+		c.done.Add(1)
+		go c.checkPeriodically()
+	}
+
+	return c
+}
+
+// Close stops checking the stores and waits for an ongoing check to be cancelled.
+func (c *Checker) Close() {
+	close(c.stop)
+	c.done.Wait()
+}
+
+// Get returns the report of the last check of the store, or false if it wasn't checked yet.
+func (c *Checker) Get(storeID string) (*StoreReport, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	report, ok := c.stores[storeID]
+	return report, ok
+}
+
+// Reports returns the reports of the last check of every store, sorted by store ID.
+func (c *Checker) Reports() []*StoreReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	reports := make([]*StoreReport, 0, len(c.stores))
+	for _, report := range c.stores {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].StoreID < reports[j].StoreID
+	})
+
+	return reports
+}
+
+func (c *Checker) checkPeriodically() {
+	defer c.done.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.CheckAll(ctx, c.cleanup); err != nil && ctx.Err() == nil {
+			c.logger.Error("failed to check the integrity of the stores", zap.Error(err))
+		}
+	}
+}
+
+// CheckAll checks every store and cleans up its orphaned tuples with the cleanup mode. A store that
+// fails to be checked doesn't stop the check of the others, keeps the report of its previous check,
+// and the error of the first one is returned. The reports of the stores that no longer exist are
+// dropped.
+func (c *Checker) CheckAll(ctx context.Context, cleanup CleanupMode) error {
+	var firstErr error
+	storeIDs := map[string]struct{}{}
+
+	var continuationToken string
+	for {
+		page, token, err := c.datastore.ListStores(ctx, storage.ListStoresFilter{}, storage.NewPaginationOptions(storage.DefaultPageSize, continuationToken))
+		if err != nil {
+			return fmt.Errorf("failed to list stores: %w", err)
+		}
+
+		for _, store := range page {
+			storeIDs[store.GetId()] = struct{}{}
+
+			if _, err := c.CheckStore(ctx, store.GetId(), cleanup); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				c.logger.Error("failed to check the integrity of store", zap.String("store_id", store.GetId()), zap.Error(err))
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to check the integrity of store '%s': %w", store.GetId(), err)
+				}
+			}
+		}
+
+		if len(token) == 0 {
+			break
+		}
+		continuationToken = string(token)
+	}
+
+	c.mu.Lock()
+	for storeID := range c.stores {
+		if _, ok := storeIDs[storeID]; !ok {
+			delete(c.stores, storeID)
+		}
+	}
+	c.mu.Unlock()
+
+	c.updateMetrics()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	lastSuccessGauge.SetToCurrentTime()
+
+	return nil
+}
+
+// CheckStore checks the tuples of the store against its latest authorization model, cleans up the
+// orphaned tuples with the cleanup mode, and returns the report of the check, which is also kept
+// until the next check. The orphaned tuples aren't cleaned up if the latest model of the store
+// changed during the check.
+func (c *Checker) CheckStore(ctx context.Context, storeID string, cleanup CleanupMode) (*StoreReport, error) {
+	if cleanup == CleanupQuarantine && c.quarantine == nil {
+		return nil, ErrQuarantineNotConfigured
+	}
+
+	report := &StoreReport{
+		StoreID:             storeID,
+		CheckedAt:           time.Now().UTC(),
+		OrphanedTupleCounts: map[Reason]int64{},
+		OrphanedTuples:      []OrphanedTuple{},
+		Cleanup:             cleanup,
+	}
+
+	model, err := c.datastore.FindLatestAuthorizationModel(ctx, storeID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read the latest authorization model: %w", err)
+	}
+	if model == nil {
+		// without a model, the store can't have tuples
+		c.keep(report)
+		return report, nil
+	}
+	report.AuthorizationModelID = model.GetId()
+	typesys := typesystem.New(model)
+
+	var orphans []*openfgav1.TupleKey
+	var continuationToken string
+	for {
+		tuples, token, err := c.datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, storage.NewPaginationOptions(readPageSize, continuationToken))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tuples: %w", err)
+		}
+
+		for _, t := range tuples {
+			report.TupleCount++
+
+			tk := t.GetKey()
+			reason, orphaned := orphanReason(typesys, tk)
+			if !orphaned {
+				continue
+			}
+
+			report.OrphanedTupleCount++
+			report.OrphanedTupleCounts[reason]++
+			if len(report.OrphanedTuples) < c.maxReportedTuples {
+				report.OrphanedTuples = append(report.OrphanedTuples, OrphanedTuple{
+					Object:    tk.GetObject(),
+					Relation:  tk.GetRelation(),
+					User:      tk.GetUser(),
+					Condition: tk.GetCondition().GetName(),
+					Reason:    reason,
+				})
+			}
+			if cleanup != CleanupNone {
+				orphans = append(orphans, tk)
+			}
+		}
+
+		if len(token) == 0 {
+			break
+		}
+		continuationToken = string(token)
+	}
+
+	if len(orphans) > 0 {
+		if err := c.clean(ctx, report, orphans); err != nil {
+			return nil, err
+		}
+	}
+
+	c.keep(report)
+
+	if report.OrphanedTupleCount > 0 {
+		c.logger.Info("found orphaned tuples in store",
+			zap.String("store_id", storeID),
+			zap.String("authorization_model_id", report.AuthorizationModelID),
+			zap.Int64("orphaned_tuple_count", report.OrphanedTupleCount),
+			zap.Int64("removed_tuple_count", report.RemovedTupleCount),
+			zap.String("cleanup", string(cleanup)))
+	}
+
+	return report, nil
+}
+
+// clean quarantines the orphaned tuples of the report, if its cleanup mode is CleanupQuarantine, and
+// deletes them.
+func (c *Checker) clean(ctx context.Context, report *StoreReport, orphans []*openfgav1.TupleKey) error {
+	model, err := c.datastore.FindLatestAuthorizationModel(ctx, report.StoreID)
+	if err != nil {
+		return fmt.Errorf("failed to read the latest authorization model: %w", err)
+	}
+	if model.GetId() != report.AuthorizationModelID {
+		return fmt.Errorf("the latest authorization model changed from '%s' to '%s' during the check, the orphaned tuples weren't removed",
+			report.AuthorizationModelID, model.GetId())
+	}
+
+	if report.Cleanup == CleanupQuarantine {
+		var buf bytes.Buffer
+		for _, tk := range orphans {
+			line, err := protojson.Marshal(tk)
+			if err != nil {
+				return err
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+
+		name := fmt.Sprintf("%s/%s.jsonl", report.StoreID, report.CheckedAt.Format("20060102T150405Z"))
+		if err := c.quarantine.Put(ctx, name, bytes.NewReader(buf.Bytes())); err != nil {
+			return fmt.Errorf("failed to quarantine the orphaned tuples: %w", err)
+		}
+		report.QuarantineObject = name
+	}
+
+	ctx = storage.ContextWithWriter(ctx, Writer)
+	batchSize := c.datastore.MaxTuplesPerWrite()
+	for start := 0; start < len(orphans); start += batchSize {
+		end := min(start+batchSize, len(orphans))
+
+		deletes := make(storage.Deletes, 0, end-start)
+		for _, tk := range orphans[start:end] {
+			deletes = append(deletes, tuple.TupleKeyToTupleKeyWithoutCondition(tk))
+		}
+
+		if err := c.datastore.Write(ctx, report.StoreID, deletes, nil); err != nil {
+			return fmt.Errorf("failed to delete the orphaned tuples: %w", err)
+		}
+
+		report.RemovedTupleCount += int64(len(deletes))
+		removedTuplesCounter.WithLabelValues(string(report.Cleanup)).Add(float64(len(deletes)))
+	}
+
+	return nil
+}
+
+func (c *Checker) keep(report *StoreReport) {
+	c.mu.Lock()
+	c.stores[report.StoreID] = report
+	c.mu.Unlock()
+}
+
+// updateMetrics sets the orphaned tuples gauge to the orphaned tuples that remain after the last
+// check of every store.
+func (c *Checker) updateMetrics() {
+	counts := map[Reason]int64{}
+
+	c.mu.RLock()
+	for _, report := range c.stores {
+		if report.RemovedTupleCount > 0 {
+			continue
+		}
+		for reason, count := range report.OrphanedTupleCounts {
+			counts[reason] += count
+		}
+	}
+	c.mu.RUnlock()
+
+	orphanedTuplesGauge.Reset()
+	for reason, count := range counts {
+		orphanedTuplesGauge.WithLabelValues(string(reason)).Set(float64(count))
+	}
+}
+
+// orphanReason returns why the tuple is orphaned in the model of the TypeSystem, or false if it
+// isn't orphaned.
+func orphanReason(typesys *typesystem.TypeSystem, tk *openfgav1.TupleKey) (Reason, bool) {
+	objectType := tuple.GetType(tk.GetObject())
+	if _, ok := typesys.GetTypeDefinition(objectType); !ok {
+		return ReasonObjectTypeNotFound, true
+	}
+
+	if _, err := typesys.GetRelation(objectType, tk.GetRelation()); err != nil {
+		return ReasonRelationNotFound, true
+	}
+
+	userObject, userRelation := tuple.SplitObjectRelation(tk.GetUser())
+	userType := tuple.GetType(userObject)
+	if _, ok := typesys.GetTypeDefinition(userType); !ok {
+		return ReasonUserTypeNotFound, true
+	}
+
+	if userRelation != "" {
+		if _, err := typesys.GetRelation(userType, userRelation); err != nil {
+			return ReasonUserRelationNotFound, true
+		}
+	}
+
+	if name := tk.GetCondition().GetName(); name != "" {
+		if _, ok := typesys.GetConditions()[name]; !ok {
+			return ReasonConditionNotFound, true
+		}
+	}
+
+	return "", false
+}
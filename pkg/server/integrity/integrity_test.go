@@ -0,0 +1,267 @@
+package integrity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// memoryWriter keeps the objects it is written in memory.
+type memoryWriter struct {
+	objects map[string][]byte
+}
+
+func (m *memoryWriter) Put(_ context.Context, name string, body io.ReadSeeker) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	m.objects[name] = data
+	return nil
+}
+
+// setup returns a store whose tuples were written with a model that defined the 'folder' type, the
+// 'document#owner' relation, the 'team' user type and the 'in_office' condition, that its latest
+// model no longer defines.
+func setup(t *testing.T) (storage.OpenFGADatastore, string) {
+	ctx := context.Background()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	store, err := ds.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "integrity"})
+	require.NoError(t, err)
+
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, store.GetId(), testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type team
+			relations
+				define member: [user]
+		type group
+			relations
+				define member: [user, team#member]
+		type folder
+			relations
+				define viewer: [user]
+		type document
+			relations
+				define owner: [user]
+				define viewer: [user, user:*, group#member, user with in_office]
+		condition in_office(office: string) {
+			office == "london"
+		}`)))
+
+	err = ds.Write(ctx, store.GetId(), nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:*"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("group:eng", "member", "user:bob"),
+		tuple.NewTupleKey("folder:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "owner", "user:anne"),
+		tuple.NewTupleKey("group:eng", "member", "team:sre#member"),
+		tuple.NewTupleKeyWithCondition("document:2", "viewer", "user:carl", "in_office", nil),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, store.GetId(), testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type group
+			relations
+				define member: [user]
+		type document
+			relations
+				define viewer: [user, user:*, group#member]`)))
+
+	return ds, store.GetId()
+}
+
+func TestCheckStore(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	t.Run("reports_the_orphaned_tuples", func(t *testing.T) {
+		ds, storeID := setup(t)
+
+		checker := NewChecker(ds, WithInterval(0), WithMaxReportedTuples(3))
+		t.Cleanup(checker.Close)
+
+		_, ok := checker.Get(storeID)
+		require.False(t, ok)
+
+		report, err := checker.CheckStore(ctx, storeID, CleanupNone)
+		require.NoError(t, err)
+
+		latest, err := ds.FindLatestAuthorizationModel(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, latest.GetId(), report.AuthorizationModelID)
+		require.EqualValues(t, 8, report.TupleCount)
+		require.EqualValues(t, 4, report.OrphanedTupleCount)
+		require.Equal(t, map[Reason]int64{
+			ReasonObjectTypeNotFound: 1,
+			ReasonRelationNotFound:   1,
+			ReasonUserTypeNotFound:   1,
+			ReasonConditionNotFound:  1,
+		}, report.OrphanedTupleCounts)
+		require.Len(t, report.OrphanedTuples, 3)
+		require.Zero(t, report.RemovedTupleCount)
+
+		kept, ok := checker.Get(storeID)
+		require.True(t, ok)
+		require.Same(t, report, kept)
+
+		tuples, _, err := ds.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, storage.NewPaginationOptions(100, ""))
+		require.NoError(t, err)
+		require.Len(t, tuples, 8)
+	})
+
+	t.Run("deletes_the_orphaned_tuples", func(t *testing.T) {
+		ds, storeID := setup(t)
+
+		checker := NewChecker(ds, WithInterval(0))
+		t.Cleanup(checker.Close)
+
+		report, err := checker.CheckStore(ctx, storeID, CleanupDelete)
+		require.NoError(t, err)
+		require.EqualValues(t, 4, report.RemovedTupleCount)
+		require.Empty(t, report.QuarantineObject)
+
+		report, err = checker.CheckStore(ctx, storeID, CleanupDelete)
+		require.NoError(t, err)
+		require.EqualValues(t, 4, report.TupleCount)
+		require.Zero(t, report.OrphanedTupleCount)
+
+		changes, _, err := ds.ReadChanges(ctx, storeID, storage.ReadChangesFilter{ObjectType: "folder"}, storage.NewPaginationOptions(100, ""), 0)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		require.Equal(t, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, changes[1].GetOperation())
+	})
+
+	t.Run("quarantines_the_orphaned_tuples", func(t *testing.T) {
+		ds, storeID := setup(t)
+
+		checker := NewChecker(ds, WithInterval(0))
+		t.Cleanup(checker.Close)
+
+		_, err := checker.CheckStore(ctx, storeID, CleanupQuarantine)
+		require.ErrorIs(t, err, ErrQuarantineNotConfigured)
+
+		quarantine := &memoryWriter{objects: map[string][]byte{}}
+		checker = NewChecker(ds, WithInterval(0), WithQuarantine(quarantine))
+		t.Cleanup(checker.Close)
+
+		report, err := checker.CheckStore(ctx, storeID, CleanupQuarantine)
+		require.NoError(t, err)
+		require.EqualValues(t, 4, report.RemovedTupleCount)
+		require.True(t, strings.HasPrefix(report.QuarantineObject, storeID+"/"))
+
+		lines := bytes.Split(bytes.TrimSpace(quarantine.objects[report.QuarantineObject]), []byte("\n"))
+		require.Len(t, lines, 4)
+
+		var objects []string
+		for _, line := range lines {
+			tk := &openfgav1.TupleKey{}
+			require.NoError(t, protojson.Unmarshal(line, tk))
+			objects = append(objects, tk.GetObject())
+			if tk.GetObject() == "document:2" {
+				require.Equal(t, "in_office", tk.GetCondition().GetName())
+			}
+		}
+		require.ElementsMatch(t, []string{"folder:1", "document:1", "group:eng", "document:2"}, objects)
+	})
+
+	t.Run("store_without_model", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		checker := NewChecker(ds, WithInterval(0))
+		t.Cleanup(checker.Close)
+
+		report, err := checker.CheckStore(ctx, ulid.Make().String(), CleanupDelete)
+		require.NoError(t, err)
+		require.Empty(t, report.AuthorizationModelID)
+		require.Zero(t, report.TupleCount)
+	})
+}
+
+func TestCheckAll(t *testing.T) {
+	ds, storeID := setup(t)
+
+	checker := NewChecker(ds, WithInterval(0))
+	t.Cleanup(checker.Close)
+
+	require.NoError(t, checker.CheckAll(context.Background(), CleanupNone))
+	require.Len(t, checker.Reports(), 1)
+
+	require.NoError(t, ds.DeleteStore(context.Background(), storeID))
+	require.NoError(t, checker.CheckAll(context.Background(), CleanupNone))
+	require.Empty(t, checker.Reports())
+}
+
+func TestHandler(t *testing.T) {
+	ds, storeID := setup(t)
+
+	checker := NewChecker(ds, WithInterval(0))
+	t.Cleanup(checker.Close)
+
+	serve := func(method, query string) (*httptest.ResponseRecorder, Reports) {
+		w := httptest.NewRecorder()
+		checker.Handler().ServeHTTP(w, httptest.NewRequest(method, "/integrity?"+query, nil))
+
+		var reports Reports
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reports))
+		}
+		return w, reports
+	}
+
+	w, _ := serve(http.MethodGet, "store_id="+storeID)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w, reports := serve(http.MethodPost, "store_id="+storeID)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, reports.Stores, 1)
+	require.EqualValues(t, 4, reports.Stores[0].OrphanedTupleCount)
+	require.Equal(t, CleanupNone, reports.Stores[0].Cleanup)
+
+	w, reports = serve(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, reports.Stores, 1)
+
+	w, _ = serve(http.MethodPost, "cleanup=quarantine")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = serve(http.MethodPost, "cleanup=purge")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = serve(http.MethodPost, "store_id="+ulid.Make().String())
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w, _ = serve(http.MethodDelete, "")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w, reports = serve(http.MethodPost, "cleanup=delete")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.EqualValues(t, 4, reports.Stores[0].RemovedTupleCount)
+}
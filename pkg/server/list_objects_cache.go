@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/graph"
+	"github.com/openfga/openfga/internal/keys"
+)
+
+var (
+	listObjectsCacheTotalCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "list_objects_cache_total_count",
+		Help:      "The total number of ListObjects requests looked up in the ListObjects cache.",
+	})
+
+	listObjectsCacheHitCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "list_objects_cache_hit_count",
+		Help:      "The total number of ListObjects requests served from the ListObjects cache.",
+	})
+)
+
+// cachedListObjects are the objects of a ListObjects request cached by the ListObjects cache.
+type cachedListObjects struct {
+	objects []string
+}
+
+// listObjectsCacheKey returns the key of the ListObjects cache of a request resolved with a model and
+// the changelog watermark of its store. The contextual tuples and the context are hashed, ignoring
+// their order, like in graph.CheckRequestCacheKey. The key is prefixed with the store ID.
+func listObjectsCacheKey(req *openfgav1.ListObjectsRequest, modelID, watermark string) (string, error) {
+	hasher := keys.NewCacheKeyHasher(xxhash.New())
+
+	key := fmt.Sprintf("%s/%s#%s@%s", modelID, req.GetType(), req.GetRelation(), req.GetUser())
+	if err := hasher.WriteString(key); err != nil {
+		return "", err
+	}
+
+	if contextualTuples := req.GetContextualTuples().GetTupleKeys(); len(contextualTuples) > 0 {
+		if err := keys.NewTupleKeysHasher(contextualTuples...).Append(hasher); err != nil {
+			return "", err
+		}
+	}
+
+	if req.GetContext() != nil {
+		if err := keys.NewContextHasher(req.GetContext()).Append(hasher); err != nil {
+			return "", err
+		}
+	}
+
+	return req.GetStoreId() + "/" + watermark + "/" + strconv.FormatUint(hasher.Key().ToUInt64(), 10), nil
+}
+
+// lookupListObjectsCache returns the key of a ListObjects request resolved with a model in the
+// ListObjects cache, and its cached objects if they were found. The key is empty if the request
+// can't be cached, e.g. because the changelog watermark of its store couldn't be read. The cached
+// objects aren't looked up if the context bypasses the Check cache, e.g. for the higher consistency,
+// but the result of the request is still cached with the key.
+func (s *Server) lookupListObjectsCache(ctx context.Context, req *openfgav1.ListObjectsRequest, modelID string) (string, []string, bool) {
+	watermark, err := s.listObjectsCacheWatermarks.Watermark(ctx, req.GetStoreId())
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "failed to read the changelog watermark, resolving ListObjects without the cache", zap.Error(err))
+		return "", nil, false
+	}
+
+	key, err := listObjectsCacheKey(req, modelID, watermark)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "ListObjects cache key computation failed with error", zap.Error(err))
+		return "", nil, false
+	}
+
+	listObjectsCacheTotalCounter.Inc()
+	if graph.CheckCacheBypassFromContext(ctx) {
+		return key, nil, false
+	}
+
+	item := s.listObjectsCache.Get(key)
+	if item == nil || item.Expired() {
+		return key, nil, false
+	}
+
+	listObjectsCacheHitCounter.Inc()
+	return key, item.Value().objects, true
+}
+
+// ListObjectsCacheSize returns the number of cached ListObjects results, including the expired ones
+// that weren't evicted yet, or 0 if the ListObjects cache is disabled.
+func (s *Server) ListObjectsCacheSize() int {
+	if s.listObjectsCache == nil {
+		return 0
+	}
+	return s.listObjectsCache.ItemCount()
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestListObjectsCache(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	_, err := NewServerWithOpts(WithDatastore(ds), WithListObjectsCacheEnabled(true), WithListObjectsCacheTTL(0))
+	require.ErrorContains(t, err, "list objects cache TTL")
+
+	// the watermarks aren't refreshed from the changelog during the test
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithListObjectsCacheEnabled(true),
+		WithListObjectsCacheWatermarkRefreshInterval(time.Hour),
+	)
+	t.Cleanup(s.Close)
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user, user with in_office]
+condition in_office(office: string) {
+  office == "london"
+}`)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "list-objects-cache"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+		Conditions:      model.GetConditions(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+			tuple.NewTupleKeyWithCondition("document:2", "viewer", "user:anne", "in_office", nil),
+		}},
+	})
+	require.NoError(t, err)
+
+	listObjects := func(t *testing.T, ctx context.Context, office string) []string {
+		reqContext, err := structpb.NewStruct(map[string]interface{}{"office": office})
+		require.NoError(t, err)
+
+		resp, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:  storeID,
+			Type:     "document",
+			Relation: "viewer",
+			User:     "user:anne",
+			Context:  reqContext,
+		})
+		require.NoError(t, err)
+		return resp.GetObjects()
+	}
+
+	// writeBehindTheServer writes a tuple that the watermark of the server doesn't see until the
+	// changelog is read again
+	writeBehindTheServer := func(t *testing.T, object string) {
+		require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+			tuple.NewTupleKey(object, "viewer", "user:anne"),
+		}))
+	}
+
+	t.Run("serves_the_cached_results", func(t *testing.T) {
+		require.ElementsMatch(t, []string{"document:1"}, listObjects(t, ctx, "paris"))
+		require.Equal(t, 1, s.ListObjectsCacheSize())
+
+		writeBehindTheServer(t, "document:3")
+		require.ElementsMatch(t, []string{"document:1"}, listObjects(t, ctx, "paris"))
+	})
+
+	t.Run("caches_by_context", func(t *testing.T) {
+		require.ElementsMatch(t, []string{"document:1", "document:2", "document:3"}, listObjects(t, ctx, "london"))
+		require.Equal(t, 2, s.ListObjectsCacheSize())
+	})
+
+	t.Run("bypasses_the_cache_with_the_higher_consistency", func(t *testing.T) {
+		writeBehindTheServer(t, "document:4")
+
+		higherConsistencyCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ConsistencyHeader, ConsistencyHigherConsistency))
+		require.ElementsMatch(t, []string{"document:1", "document:3", "document:4"}, listObjects(t, higherConsistencyCtx, "paris"))
+
+		// the result of the higher consistency is cached for the other requests
+		require.ElementsMatch(t, []string{"document:1", "document:3", "document:4"}, listObjects(t, ctx, "paris"))
+	})
+
+	t.Run("invalidates_the_results_once_the_server_writes", func(t *testing.T) {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Deletes: &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{
+				tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:anne")),
+			}},
+		})
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, []string{"document:3", "document:4"}, listObjects(t, ctx, "paris"))
+	})
+}
@@ -0,0 +1,59 @@
+package metering
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Windows are the windows returned by the admin handler.
+type Windows struct {
+	Windows []*Window `json:"windows"`
+}
+
+// Handler returns the handler of the admin server that serves the windows of the meter with a GET,
+// the closed windows that are kept followed by the current one. The records are filtered by the store
+// of the 'store_id' query parameter, if any, and the windows are encoded in the format of the
+// 'format' query parameter, 'json' by default. The CSV rows of the windows follow a single header.
+func (m *Meter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		format, err := ParseFormat(r.URL.Query().Get("format"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		windows := m.Windows()
+		if storeID := r.URL.Query().Get("store_id"); storeID != "" {
+			for _, window := range windows {
+				records := []Record{}
+				for _, record := range window.Records {
+					if record.StoreID == storeID {
+						records = append(records, record)
+					}
+				}
+				window.Records = records
+			}
+		}
+
+		if format == FormatCSV {
+			data, err := encodeCSV(windows)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/csv")
+			_, _ = w.Write(data)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Windows{Windows: windows})
+	})
+}
@@ -0,0 +1,501 @@
+// Package metering aggregates the usage of each store, such as the number of requests of each RPC and
+// the datastore queries, dispatches and cache hits they took, over fixed windows of time, so that
+// multi-tenant platforms can charge their tenants back. The windows are served by the admin server
+// and can be exported to object storage as JSON or CSV once they close.
+package metering
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/openfga/openfga/pkg/backup"
+	"github.com/openfga/openfga/pkg/logger"
+)
+
+const (
+	// DefaultWindow is the default duration of a window.
+	DefaultWindow = time.Hour
+
+	// DefaultRetention is the default number of closed windows that are kept.
+	DefaultRetention = 24
+)
+
+// Format is the format the windows are exported in.
+type Format string
+
+const (
+	// FormatJSON exports a window as a JSON object.
+	FormatJSON Format = "json"
+
+	// FormatCSV exports a window as CSV, with a header and a row per store and RPC.
+	FormatCSV Format = "csv"
+)
+
+// ParseFormat returns the Format of the string, which is 'json' if it is empty.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid metering format '%s', it must be 'json' or 'csv'", s)
+	}
+}
+
+// Usage is the usage of a store by the requests of an RPC. The datastore queries, dispatches and
+// cache hits are only reported by the Check and ListObjects requests.
+type Usage struct {
+	RequestCount        int64 `json:"request_count"`
+	ErrorCount          int64 `json:"error_count"`
+	DatastoreQueryCount int64 `json:"datastore_query_count"`
+	DispatchCount       int64 `json:"dispatch_count"`
+	CacheHitCount       int64 `json:"cache_hit_count"`
+}
+
+func (u *Usage) add(other Usage) {
+	u.RequestCount += other.RequestCount
+	u.ErrorCount += other.ErrorCount
+	u.DatastoreQueryCount += other.DatastoreQueryCount
+	u.DispatchCount += other.DispatchCount
+	u.CacheHitCount += other.CacheHitCount
+}
+
+// CacheHitRatio is the share of the lookups of the requests that were served by the caches rather
+// than by the datastore, or 0 if there were none.
+func (u Usage) CacheHitRatio() float64 {
+	lookups := u.CacheHitCount + u.DatastoreQueryCount
+	if lookups == 0 {
+		return 0
+	}
+	return float64(u.CacheHitCount) / float64(lookups)
+}
+
+// Record is the usage of a store by the requests of an RPC during a window.
+type Record struct {
+	StoreID string `json:"store_id"`
+
+	// Method is the name of the RPC, e.g. 'Check'.
+	Method string `json:"method"`
+
+	Usage
+	CacheHitRatio float64 `json:"cache_hit_ratio"`
+
+	// TupleCount is the approximate number of tuples of the store when the window closed, if the
+	// tuple counts are known (see WithTupleCounts).
+	TupleCount *int64 `json:"tuple_count,omitempty"`
+}
+
+// Window is the usage of the stores during a window of time.
+type Window struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// Instance is the name of the server instance that metered the window, if any, since each
+	// instance meters the requests it serves.
+	Instance string `json:"instance,omitempty"`
+
+	// Partial is set on the window that is still open, whose End is the time it was read.
+	Partial bool `json:"partial,omitempty"`
+
+	// Records are sorted by store ID and method.
+	Records []Record `json:"records"`
+}
+
+// csvHeader is the header of the windows exported as CSV.
+var csvHeader = []string{
+	"window_start", "window_end", "instance", "store_id", "method", "request_count", "error_count",
+	"datastore_query_count", "dispatch_count", "cache_hit_count", "cache_hit_ratio", "tuple_count",
+}
+
+// Encode encodes the window in the format.
+func (w *Window) Encode(format Format) ([]byte, error) {
+	if format == FormatCSV {
+		return encodeCSV([]*Window{w})
+	}
+	return json.Marshal(w)
+}
+
+// encodeCSV encodes the records of the windows as CSV rows, which follow a single header.
+func encodeCSV(windows []*Window) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, err
+	}
+
+	for _, w := range windows {
+		start, end := w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339)
+		for _, r := range w.Records {
+			tupleCount := ""
+			if r.TupleCount != nil {
+				tupleCount = strconv.FormatInt(*r.TupleCount, 10)
+			}
+
+			err := writer.Write([]string{
+				start, end, w.Instance, r.StoreID, r.Method,
+				strconv.FormatInt(r.RequestCount, 10),
+				strconv.FormatInt(r.ErrorCount, 10),
+				strconv.FormatInt(r.DatastoreQueryCount, 10),
+				strconv.FormatInt(r.DispatchCount, 10),
+				strconv.FormatInt(r.CacheHitCount, 10),
+				strconv.FormatFloat(r.CacheHitRatio, 'f', 4, 64),
+				tupleCount,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// usageKey is the store and RPC of a Usage.
+type usageKey struct {
+	storeID string
+	method  string
+}
+
+// Meter aggregates the usage of the stores over windows of time, and keeps the last closed windows.
+type Meter struct {
+	logger      logger.Logger
+	instance    string
+	window      time.Duration
+	retention   int
+	export      backup.Writer
+	format      Format
+	tupleCounts func(storeID string) (int64, bool)
+
+	mu      sync.Mutex
+	start   time.Time
+	current map[usageKey]*Usage
+	closed  []*Window
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// MeterOption defines an option that can be used to change the behavior of a [Meter].
+type MeterOption func(m *Meter)
+
+// WithLogger sets the logger of the [Meter].
+func WithLogger(l logger.Logger) MeterOption {
+	return func(m *Meter) {
+		m.logger = l
+	}
+}
+
+// WithInstance sets the name of the server instance, e.g. its hostname, which is set on the windows
+// and in the names of the exported windows, so that the instances can export to the same location.
+func WithInstance(instance string) MeterOption {
+	return func(m *Meter) {
+		m.instance = instance
+	}
+}
+
+// WithWindow sets the duration of the windows, which are aligned on multiples of it since the Unix
+// epoch, e.g. on the hours for an hour. If the window is 0, the windows are only closed when Flush
+// is called.
+func WithWindow(window time.Duration) MeterOption {
+	return func(m *Meter) {
+		m.window = window
+	}
+}
+
+// WithRetention sets the number of closed windows that are kept.
+func WithRetention(retention int) MeterOption {
+	return func(m *Meter) {
+		m.retention = retention
+	}
+}
+
+// WithExport sets the object storage the windows are exported to in the format once they close. A
+// window is written to '<start>-<instance>.json' or '<start>-<instance>.csv', with its start in UTC,
+// e.g. '20240102T150000.000Z-openfga-0.json', or without the instance if it isn't set.
+func WithExport(writer backup.Writer, format Format) MeterOption {
+	return func(m *Meter) {
+		m.export = writer
+		m.format = format
+	}
+}
+
+// WithTupleCounts sets the function that returns the approximate number of tuples of a store, or
+// false if it isn't known, e.g. the tuple counts of the tuple statistics. The records of the stores
+// then have their tuple counts when their window closes.
+func WithTupleCounts(tupleCounts func(storeID string) (int64, bool)) MeterOption {
+	return func(m *Meter) {
+		m.tupleCounts = tupleCounts
+	}
+}
+
+// NewMeter constructs a [Meter]. If the window is not 0, the windows are closed, and exported if
+// configured, at the end of every window. You must call Close on it after you are done using it.
+func NewMeter(opts ...MeterOption) *Meter {
+	m := &Meter{
+		logger:    logger.NewNoopLogger(),
+		window:    DefaultWindow,
+		retention: DefaultRetention,
+		format:    FormatJSON,
+		current:   map[usageKey]*Usage{},
+		stop:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.start = time.Now().UTC()
+	if m.window > 0 {
+		m.start = m.start.Truncate(m.window)
+
+		m.done.Add(1)
+		go m.flushPeriodically()
+	}
+
+	return m
+}
+
+// Close stops closing the windows, and closes and exports the current window, so that the usage of
+// the last requests isn't lost.
+func (m *Meter) Close() {
+	close(m.stop)
+	m.done.Wait()
+
+	if err := m.Flush(context.Background()); err != nil {
+		m.logger.Error("failed to export the last metering window", zap.Error(err))
+	}
+}
+
+// Add adds the usage of a request of an RPC to the usage of its store in the current window.
+func (m *Meter) Add(storeID, method string, usage Usage) {
+	key := usageKey{storeID: storeID, method: method}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.current[key]
+	if !ok {
+		current = &Usage{}
+		m.current[key] = current
+	}
+	current.add(usage)
+}
+
+// Windows returns the closed windows that are kept, from the oldest, followed by the current window,
+// which is partial.
+func (m *Meter) Windows() []*Window {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the windows are copied, so that the caller can change them
+	windows := make([]*Window, 0, len(m.closed)+1)
+	for _, closed := range m.closed {
+		window := *closed
+		windows = append(windows, &window)
+	}
+
+	current := m.windowLocked(time.Now().UTC())
+	current.Partial = true
+	return append(windows, current)
+}
+
+// Flush closes the current window, keeps it, and exports it if configured. An empty window is kept
+// but not exported.
+func (m *Meter) Flush(ctx context.Context) error {
+	return m.flush(ctx, time.Now().UTC())
+}
+
+// flush closes the current window at end, like Flush.
+func (m *Meter) flush(ctx context.Context, end time.Time) error {
+	m.mu.Lock()
+	window := m.windowLocked(end)
+
+	m.closed = append(m.closed, window)
+	if len(m.closed) > m.retention {
+		m.closed = m.closed[len(m.closed)-m.retention:]
+	}
+
+	m.start = end
+	m.current = map[usageKey]*Usage{}
+	m.mu.Unlock()
+
+	if m.export == nil || len(window.Records) == 0 {
+		return nil
+	}
+
+	data, err := window.Encode(m.format)
+	if err != nil {
+		return fmt.Errorf("failed to encode the metering window: %w", err)
+	}
+
+	name := window.Start.Format("20060102T150405.000Z")
+	if m.instance != "" {
+		name += "-" + m.instance
+	}
+	name += "." + string(m.format)
+	if err := m.export.Put(ctx, name, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to export the metering window '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+// windowLocked returns the current window, ending at end. It must be called with the lock held.
+func (m *Meter) windowLocked(end time.Time) *Window {
+	window := &Window{Start: m.start, End: end, Instance: m.instance, Records: make([]Record, 0, len(m.current))}
+
+	tupleCounts := map[string]*int64{}
+	for key, usage := range m.current {
+		record := Record{
+			StoreID:       key.storeID,
+			Method:        key.method,
+			Usage:         *usage,
+			CacheHitRatio: usage.CacheHitRatio(),
+		}
+
+		if m.tupleCounts != nil {
+			count, seen := tupleCounts[key.storeID]
+			if !seen {
+				if n, known := m.tupleCounts(key.storeID); known {
+					count = &n
+				}
+				tupleCounts[key.storeID] = count
+			}
+			record.TupleCount = count
+		}
+
+		window.Records = append(window.Records, record)
+	}
+
+	sort.Slice(window.Records, func(i, j int) bool {
+		a, b := window.Records[i], window.Records[j]
+		if a.StoreID != b.StoreID {
+			return a.StoreID < b.StoreID
+		}
+		return a.Method < b.Method
+	})
+
+	return window
+}
+
+func (m *Meter) flushPeriodically() {
+	defer m.done.Done()
+
+	for {
+		m.mu.Lock()
+		next := m.start.Truncate(m.window).Add(m.window)
+		m.mu.Unlock()
+
+		// the windows end on their boundary, even if the timer fires a bit later
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-m.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := m.flush(context.Background(), next); err != nil {
+			m.logger.Error("failed to export the metering window", zap.Error(err))
+		}
+	}
+}
+
+// usageCtxKey is the context key of the usage of the request being served.
+type usageCtxKey struct{}
+
+// AddUsage adds the datastore queries, dispatches and cache hits of a request to its usage, if the
+// request is metered by the interceptors of the Meter. It is called by the server once it resolved
+// the request.
+func AddUsage(ctx context.Context, usage Usage) {
+	if u, ok := ctx.Value(usageCtxKey{}).(*Usage); ok {
+		u.add(usage)
+	}
+}
+
+type hasGetStoreID interface {
+	GetStoreId() string
+}
+
+// methodName returns the name of the RPC of a full gRPC method, e.g. 'Check' for
+// '/openfga.v1.OpenFGAService/Check'.
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+// NewUnaryInterceptor creates a grpc.UnaryServerInterceptor that adds the usage of the requests with
+// a store ID to the Meter.
+func (m *Meter) NewUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		r, ok := req.(hasGetStoreID)
+		if !ok || r.GetStoreId() == "" {
+			return handler(ctx, req)
+		}
+
+		usage := &Usage{RequestCount: 1}
+		resp, err := handler(context.WithValue(ctx, usageCtxKey{}, usage), req)
+		if err != nil {
+			usage.ErrorCount = 1
+		}
+
+		m.Add(r.GetStoreId(), methodName(info.FullMethod), *usage)
+		return resp, err
+	}
+}
+
+// NewStreamingInterceptor creates a grpc.StreamServerInterceptor that adds the usage of the streams
+// whose request has a store ID to the Meter, like [Meter.NewUnaryInterceptor].
+func (m *Meter) NewStreamingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		usage := &Usage{RequestCount: 1}
+		metered := &meteredStream{ServerStream: stream, ctx: context.WithValue(stream.Context(), usageCtxKey{}, usage)}
+
+		err := handler(srv, metered)
+		if metered.storeID == "" {
+			return err
+		}
+		if err != nil {
+			usage.ErrorCount = 1
+		}
+
+		m.Add(metered.storeID, methodName(info.FullMethod), *usage)
+		return err
+	}
+}
+
+type meteredStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	storeID string
+}
+
+// Context returns the context of the stream, which carries the usage of the stream.
+func (s *meteredStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg receives a message and keeps the store ID of the first one that has one.
+func (s *meteredStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if r, ok := m.(hasGetStoreID); ok && s.storeID == "" {
+		s.storeID = r.GetStoreId()
+	}
+	return nil
+}
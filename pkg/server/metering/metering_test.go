@@ -0,0 +1,200 @@
+package metering
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+)
+
+// memoryWriter keeps the objects it is written in memory.
+type memoryWriter struct {
+	objects map[string][]byte
+}
+
+func (m *memoryWriter) Put(_ context.Context, name string, body io.ReadSeeker) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	m.objects[name] = data
+	return nil
+}
+
+func TestMeter(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	t.Run("aggregates_the_usage_by_store_and_method", func(t *testing.T) {
+		meter := NewMeter(WithWindow(0), WithInstance("openfga-0"), WithTupleCounts(func(storeID string) (int64, bool) {
+			return 42, storeID == "store-a"
+		}))
+		t.Cleanup(meter.Close)
+
+		meter.Add("store-b", "Write", Usage{RequestCount: 1})
+		meter.Add("store-a", "Check", Usage{RequestCount: 1, DatastoreQueryCount: 3, DispatchCount: 2, CacheHitCount: 1})
+		meter.Add("store-a", "Check", Usage{RequestCount: 1, ErrorCount: 1, CacheHitCount: 2})
+
+		windows := meter.Windows()
+		require.Len(t, windows, 1)
+		require.True(t, windows[0].Partial)
+		require.Equal(t, "openfga-0", windows[0].Instance)
+
+		records := windows[0].Records
+		require.Len(t, records, 2)
+		require.Equal(t, "store-a", records[0].StoreID)
+		require.Equal(t, "Check", records[0].Method)
+		require.Equal(t, Usage{RequestCount: 2, ErrorCount: 1, DatastoreQueryCount: 3, DispatchCount: 2, CacheHitCount: 3}, records[0].Usage)
+		require.InDelta(t, 0.5, records[0].CacheHitRatio, 0.0001)
+		require.EqualValues(t, 42, *records[0].TupleCount)
+
+		require.Equal(t, "store-b", records[1].StoreID)
+		require.Nil(t, records[1].TupleCount)
+		require.Zero(t, records[1].CacheHitRatio)
+	})
+
+	t.Run("keeps_the_closed_windows", func(t *testing.T) {
+		meter := NewMeter(WithWindow(0), WithRetention(2))
+		t.Cleanup(meter.Close)
+
+		for i := 0; i < 3; i++ {
+			meter.Add("store", "Check", Usage{RequestCount: int64(i + 1)})
+			require.NoError(t, meter.Flush(ctx))
+		}
+
+		windows := meter.Windows()
+		require.Len(t, windows, 3)
+		require.EqualValues(t, 2, windows[0].Records[0].RequestCount)
+		require.EqualValues(t, 3, windows[1].Records[0].RequestCount)
+		require.False(t, windows[1].Partial)
+		require.Equal(t, windows[0].End, windows[1].Start)
+		require.Empty(t, windows[2].Records)
+		require.True(t, windows[2].Partial)
+	})
+
+	t.Run("exports_the_closed_windows", func(t *testing.T) {
+		export := &memoryWriter{objects: map[string][]byte{}}
+		meter := NewMeter(WithWindow(0), WithInstance("openfga-0"), WithExport(export, FormatCSV))
+
+		// an empty window isn't exported
+		require.NoError(t, meter.Flush(ctx))
+		require.Empty(t, export.objects)
+
+		// the windows are named by the time they start, to the millisecond
+		time.Sleep(2 * time.Millisecond)
+		meter.Add("store", "ListObjects", Usage{RequestCount: 1, DatastoreQueryCount: 1, CacheHitCount: 3})
+		require.NoError(t, meter.Flush(ctx))
+		require.Len(t, export.objects, 1)
+
+		for name, data := range export.objects {
+			require.True(t, strings.HasSuffix(name, "-openfga-0.csv"))
+
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			require.Len(t, lines, 2)
+			require.Equal(t, strings.Join(csvHeader, ","), lines[0])
+			require.True(t, strings.HasSuffix(lines[1], ",openfga-0,store,ListObjects,1,0,1,0,3,0.7500,"))
+		}
+
+		// the current window is exported when the meter is closed
+		time.Sleep(2 * time.Millisecond)
+		meter.Add("store", "Check", Usage{RequestCount: 1})
+		meter.Close()
+		require.Len(t, export.objects, 2)
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("")
+	require.NoError(t, err)
+	require.Equal(t, FormatJSON, format)
+
+	format, err = ParseFormat("csv")
+	require.NoError(t, err)
+	require.Equal(t, FormatCSV, format)
+
+	_, err = ParseFormat("xml")
+	require.ErrorContains(t, err, "invalid metering format")
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	meter := NewMeter(WithWindow(0))
+	t.Cleanup(meter.Close)
+
+	interceptor := meter.NewUnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: openfgav1.OpenFGAService_Check_FullMethodName}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		AddUsage(ctx, Usage{DatastoreQueryCount: 2, DispatchCount: 1})
+		return nil, nil
+	}
+	_, err := interceptor(context.Background(), &openfgav1.CheckRequest{StoreId: "store"}, info, handler)
+	require.NoError(t, err)
+
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	}
+	_, err = interceptor(context.Background(), &openfgav1.CheckRequest{StoreId: "store"}, info, failing)
+	require.Error(t, err)
+
+	// the requests without a store aren't metered
+	_, err = interceptor(context.Background(), &openfgav1.ListStoresRequest{}, info, handler)
+	require.NoError(t, err)
+
+	records := meter.Windows()[0].Records
+	require.Len(t, records, 1)
+	require.Equal(t, "Check", records[0].Method)
+	require.Equal(t, Usage{RequestCount: 2, ErrorCount: 1, DatastoreQueryCount: 2, DispatchCount: 1}, records[0].Usage)
+}
+
+func TestHandler(t *testing.T) {
+	meter := NewMeter(WithWindow(0))
+	t.Cleanup(meter.Close)
+
+	meter.Add("store-a", "Check", Usage{RequestCount: 1})
+	require.NoError(t, meter.Flush(context.Background()))
+	meter.Add("store-a", "Write", Usage{RequestCount: 1})
+	meter.Add("store-b", "Check", Usage{RequestCount: 1})
+
+	serve := func(method, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		meter.Handler().ServeHTTP(w, httptest.NewRequest(method, "/metering?"+query, nil))
+		return w
+	}
+
+	w := serve(http.MethodGet, "store_id=store-b")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var windows Windows
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &windows))
+	require.Len(t, windows.Windows, 2)
+	require.Empty(t, windows.Windows[0].Records)
+	require.Len(t, windows.Windows[1].Records, 1)
+	require.Equal(t, "store-b", windows.Windows[1].Records[0].StoreID)
+
+	// the filter doesn't change the windows of the meter
+	require.Len(t, meter.Windows()[0].Records, 1)
+
+	w = serve(http.MethodGet, "format=csv")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	require.Len(t, strings.Split(strings.TrimSpace(w.Body.String()), "\n"), 4)
+
+	w = serve(http.MethodGet, "format=xml")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(http.MethodPost, "")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,462 @@
+// Package modeleditor serves the endpoints that back a local model editor on the HTTP gateway:
+// the validation of a model in the DSL with the positions of its errors, the evaluation of Check
+// requests against a model and sample tuples, and the graph of the types and relations of a model.
+// The models and tuples of the requests are never stored.
+package modeleditor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/hashicorp/go-multierror"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	parser "github.com/openfga/language/pkg/go/transformer"
+	"github.com/openfga/language/pkg/go/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+const (
+	ValidatePath = "/model-editor/validate"
+	EvaluatePath = "/model-editor/evaluate"
+	GraphPath    = "/model-editor/graph"
+
+	// MaxChecks is the maximum number of checks of an evaluation.
+	MaxChecks = 100
+)
+
+// syntaxErrorRegex matches the errors of the DSL parser, whose line is zero-based.
+var syntaxErrorRegex = regexp.MustCompile(`^syntax error at line=(\d+), column=(\d+): (.*)$`)
+
+// Error is an error of a model. Line and Column are one-based, and zero if the position of the
+// error is unknown.
+type Error struct {
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// ValidateResponse is the outcome of the validation of a model. The model is its JSON
+// representation, if it is valid.
+type ValidateResponse struct {
+	Valid              bool            `json:"valid"`
+	Errors             []Error         `json:"errors"`
+	AuthorizationModel json.RawMessage `json:"authorization_model,omitempty"`
+}
+
+// Validate parses the model and validates it like a WriteAuthorizationModel request would. The
+// model is returned if it is valid.
+func Validate(ctx context.Context, dsl string) (*openfgav1.AuthorizationModel, []Error) {
+	model, err := parser.TransformDSLToProto(dsl)
+	if err != nil {
+		return nil, syntaxErrors(err)
+	}
+
+	if _, err := typesystem.NewAndValidate(ctx, model); err != nil {
+		return nil, []Error{semanticError(strings.Split(dsl, "\n"), err)}
+	}
+
+	return model, nil
+}
+
+func syntaxErrors(err error) []Error {
+	var errs []error
+	var merr *multierror.Error
+	if errors.As(err, &merr) {
+		errs = merr.WrappedErrors()
+	} else {
+		errs = []error{err}
+	}
+
+	result := make([]Error, 0, len(errs))
+	for _, err := range errs {
+		match := syntaxErrorRegex.FindStringSubmatch(err.Error())
+		if match == nil {
+			result = append(result, Error{Message: err.Error()})
+			continue
+		}
+
+		line, _ := strconv.Atoi(match[1])
+		column, _ := strconv.Atoi(match[2])
+		result = append(result, Error{Message: match[3], Line: line + 1, Column: column + 1})
+	}
+
+	return result
+}
+
+// semanticError locates the type, relation or condition the error is about in the lines of the model.
+func semanticError(lines []string, err error) Error {
+	result := Error{Message: err.Error()}
+
+	line := -1
+	var (
+		invalidType       *typesystem.InvalidTypeError
+		invalidRelation   *typesystem.InvalidRelationError
+		undefinedType     *typesystem.ObjectTypeUndefinedError
+		undefinedRelation *typesystem.RelationUndefinedError
+		relationCondition *typesystem.RelationConditionError
+	)
+	switch {
+	case errors.As(err, &invalidRelation):
+		line = relationLine(lines, invalidRelation.ObjectType, invalidRelation.Relation)
+	case errors.As(err, &undefinedRelation):
+		line = relationLine(lines, undefinedRelation.ObjectType, undefinedRelation.Relation)
+	case errors.As(err, &invalidType):
+		line = utils.GetTypeLineNumber(invalidType.ObjectType, lines)
+	case errors.As(err, &undefinedType):
+		line = utils.GetTypeLineNumber(undefinedType.ObjectType, lines)
+	case errors.As(err, &relationCondition):
+		line = utils.GetConditionLineNumber(relationCondition.Condition, lines)
+	}
+
+	if line >= 0 {
+		result.Line = line + 1
+		result.Column = len(lines[line]) - len(strings.TrimLeft(lines[line], " ")) + 1
+	}
+
+	return result
+}
+
+// relationLine returns the zero-based line of the definition of the relation of the type. If the
+// relation isn't defined, it is the first definition of the type that refers to it, or else the
+// line of the type. It is -1 if the type isn't defined either.
+func relationLine(lines []string, objectType, relation string) int {
+	typeLine := utils.GetTypeLineNumber(objectType, lines)
+	if typeLine < 0 || relation == "" {
+		return typeLine
+	}
+
+	reference := regexp.MustCompile(`\b` + regexp.QuoteMeta(relation) + `\b`)
+	referenceLine := -1
+	for i := typeLine + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "type ") || strings.HasPrefix(trimmed, "condition ") {
+			break
+		}
+
+		definition, ok := strings.CutPrefix(trimmed, "define ")
+		if !ok {
+			continue
+		}
+
+		name, rewrite, _ := strings.Cut(definition, ":")
+		if strings.TrimSpace(name) == relation {
+			return i
+		}
+
+		if referenceLine < 0 && reference.MatchString(rewrite) {
+			referenceLine = i
+		}
+	}
+
+	if referenceLine >= 0 {
+		return referenceLine
+	}
+
+	return typeLine
+}
+
+// Check is a Check request of an evaluation.
+type Check struct {
+	User     string           `json:"user"`
+	Relation string           `json:"relation"`
+	Object   string           `json:"object"`
+	Context  *structpb.Struct `json:"-"`
+}
+
+// CheckResult is the outcome of a Check of an evaluation.
+type CheckResult struct {
+	User     string `json:"user"`
+	Relation string `json:"relation"`
+	Object   string `json:"object"`
+	Allowed  bool   `json:"allowed"`
+
+	// Error is the error of the Check, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// EvaluateResponse is the outcome of an evaluation. If the model is invalid, its errors are
+// returned and the checks aren't run.
+type EvaluateResponse struct {
+	Errors  []Error       `json:"errors,omitempty"`
+	Results []CheckResult `json:"results"`
+}
+
+// Evaluate runs the checks against the model and the tuples, with an in-memory datastore that is
+// discarded afterwards. An error is returned if the tuples can't be written.
+func Evaluate(ctx context.Context, dsl string, tuples []*openfgav1.TupleKey, checks []Check) (*EvaluateResponse, error) {
+	model, errs := Validate(ctx, dsl)
+	if errs != nil {
+		return &EvaluateResponse{Errors: errs, Results: []CheckResult{}}, nil
+	}
+
+	ds := memory.New(memory.WithMaxTuplesPerWrite(max(len(tuples), 1)))
+	defer ds.Close()
+
+	svr, err := server.NewServerWithOpts(server.WithDatastore(ds))
+	if err != nil {
+		return nil, err
+	}
+	defer svr.Close()
+
+	store, err := svr.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "model-editor"})
+	if err != nil {
+		return nil, err
+	}
+
+	writeModelResp, err := svr.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+		Conditions:      model.GetConditions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tuples) > 0 {
+		_, err = svr.Write(ctx, &openfgav1.WriteRequest{
+			StoreId:              store.GetId(),
+			AuthorizationModelId: writeModelResp.GetAuthorizationModelId(),
+			Writes:               &openfgav1.WriteRequestWrites{TupleKeys: tuples},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]CheckResult, 0, len(checks))
+	for _, check := range checks {
+		result := CheckResult{User: check.User, Relation: check.Relation, Object: check.Object}
+
+		resp, err := svr.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              store.GetId(),
+			AuthorizationModelId: writeModelResp.GetAuthorizationModelId(),
+			TupleKey: &openfgav1.CheckRequestTupleKey{
+				User:     check.User,
+				Relation: check.Relation,
+				Object:   check.Object,
+			},
+			Context: check.Context,
+		})
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Allowed = resp.GetAllowed()
+		}
+
+		results = append(results, result)
+	}
+
+	return &EvaluateResponse{Results: results}, nil
+}
+
+// dslRequest is the body of the requests about a model.
+type dslRequest struct {
+	DSL string `json:"dsl"`
+}
+
+// evaluateRequest is the body of an evaluation. The tuples and the contexts of the checks are
+// unmarshalled with protojson, like the bodies of Write and Check requests.
+type evaluateRequest struct {
+	DSL    string            `json:"dsl"`
+	Tuples []json.RawMessage `json:"tuples"`
+	Checks []struct {
+		User     string          `json:"user"`
+		Relation string          `json:"relation"`
+		Object   string          `json:"object"`
+		Context  json.RawMessage `json:"context"`
+	} `json:"checks"`
+}
+
+// Handlers serves the endpoints of the model editor. The requests are authenticated with the
+// authenticator of the server.
+type Handlers struct {
+	mux           *runtime.ServeMux
+	authenticator authn.Authenticator
+	maxTuples     int
+}
+
+// NewHandlers returns the handlers of the model editor. maxTuples is the maximum number of tuples
+// of an evaluation.
+func NewHandlers(mux *runtime.ServeMux, authenticator authn.Authenticator, maxTuples int) *Handlers {
+	return &Handlers{mux: mux, authenticator: authenticator, maxTuples: maxTuples}
+}
+
+// Register registers the handlers on the mux.
+func (h *Handlers) Register() error {
+	for path, handler := range map[string]runtime.HandlerFunc{
+		ValidatePath: h.validate,
+		EvaluatePath: h.evaluate,
+		GraphPath:    h.graph,
+	} {
+		if err := h.mux.HandlePath(http.MethodPost, path, handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// authenticate authenticates the request with the Authorization header, like the gRPC server does.
+func (h *Handlers) authenticate(r *http.Request) (context.Context, error) {
+	ctx := r.Context()
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authorization))
+	}
+
+	claims, err := h.authenticator.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return authn.ContextWithAuthClaims(r.Context(), claims), nil
+}
+
+func (h *Handlers) validate(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	ctx, err := h.authenticate(r)
+	if err != nil {
+		runtime.HTTPError(r.Context(), h.mux, &runtime.JSONPb{}, w, r, err)
+		return
+	}
+
+	var body dslRequest
+	if err := decodeBody(r.Body, &body); err != nil {
+		runtime.HTTPError(ctx, h.mux, &runtime.JSONPb{}, w, r, err)
+		return
+	}
+
+	resp := &ValidateResponse{Errors: []Error{}}
+	model, errs := Validate(ctx, body.DSL)
+	if errs != nil {
+		resp.Errors = errs
+	} else {
+		modelJSON, err := protojson.Marshal(model)
+		if err != nil {
+			runtime.HTTPError(ctx, h.mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		resp.Valid = true
+		resp.AuthorizationModel = modelJSON
+	}
+
+	writeJSON(w, resp)
+}
+
+func (h *Handlers) evaluate(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	ctx, err := h.authenticate(r)
+	if err != nil {
+		runtime.HTTPError(r.Context(), h.mux, &runtime.JSONPb{}, w, r, err)
+		return
+	}
+
+	var body evaluateRequest
+	if err := decodeBody(r.Body, &body); err != nil {
+		runtime.HTTPError(ctx, h.mux, &runtime.JSONPb{}, w, r, err)
+		return
+	}
+
+	tuples, checks, err := h.toEvaluation(&body)
+	if err != nil {
+		runtime.HTTPError(ctx, h.mux, &runtime.JSONPb{}, w, r, err)
+		return
+	}
+
+	resp, err := Evaluate(ctx, body.DSL, tuples, checks)
+	if err != nil {
+		runtime.HTTPError(ctx, h.mux, &runtime.JSONPb{}, w, r, err)
+		return
+	}
+
+	writeJSON(w, resp)
+}
+
+func (h *Handlers) toEvaluation(body *evaluateRequest) ([]*openfgav1.TupleKey, []Check, error) {
+	if len(body.Tuples) > h.maxTuples {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "the number of tuples must be at most %d", h.maxTuples)
+	}
+
+	if len(body.Checks) > MaxChecks {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "the number of checks must be at most %d", MaxChecks)
+	}
+
+	tuples := make([]*openfgav1.TupleKey, 0, len(body.Tuples))
+	for i, raw := range body.Tuples {
+		tk := &openfgav1.TupleKey{}
+		if err := protojson.Unmarshal(raw, tk); err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "invalid tuples[%d]: %v", i, err)
+		}
+		tuples = append(tuples, tk)
+	}
+
+	checks := make([]Check, 0, len(body.Checks))
+	for i, c := range body.Checks {
+		check := Check{User: c.User, Relation: c.Relation, Object: c.Object}
+		if len(c.Context) > 0 {
+			check.Context = &structpb.Struct{}
+			if err := protojson.Unmarshal(c.Context, check.Context); err != nil {
+				return nil, nil, status.Errorf(codes.InvalidArgument, "invalid checks[%d].context: %v", i, err)
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	return tuples, checks, nil
+}
+
+func (h *Handlers) graph(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	ctx, err := h.authenticate(r)
+	if err != nil {
+		runtime.HTTPError(r.Context(), h.mux, &runtime.JSONPb{}, w, r, err)
+		return
+	}
+
+	var body dslRequest
+	if err := decodeBody(r.Body, &body); err != nil {
+		runtime.HTTPError(ctx, h.mux, &runtime.JSONPb{}, w, r, err)
+		return
+	}
+
+	model, errs := Validate(ctx, body.DSL)
+	if errs != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(&ValidateResponse{Errors: errs})
+		return
+	}
+
+	writeJSON(w, typesystem.New(model).Graph())
+}
+
+func decodeBody(body io.Reader, v any) error {
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return status.Error(codes.InvalidArgument, "the request body is empty")
+		}
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("invalid request body: %v", err))
+	}
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,201 @@
+package modeleditor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/internal/authn/presharedkey"
+	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const model = `model
+  schema 1.1
+type user
+
+type folder
+  relations
+    define viewer: [user]
+
+type document
+  relations
+    define parent: [folder]
+    define owner: [user]
+    define viewer: owner or viewer from parent`
+
+func TestValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		authorizationModel, errs := Validate(context.Background(), model)
+		require.Nil(t, errs)
+		require.Len(t, authorizationModel.GetTypeDefinitions(), 3)
+	})
+
+	t.Run("syntax_error", func(t *testing.T) {
+		_, errs := Validate(context.Background(), `model
+  schema 1.1
+type user
+  relations
+    define owner [user]`)
+		require.NotEmpty(t, errs)
+		require.Equal(t, 5, errs[0].Line)
+		require.Positive(t, errs[0].Column)
+		require.NotEmpty(t, errs[0].Message)
+	})
+
+	t.Run("undefined_relation", func(t *testing.T) {
+		_, errs := Validate(context.Background(), `model
+  schema 1.1
+type user
+
+type document
+  relations
+    define owner: [user]
+    define viewer: editor`)
+		require.Len(t, errs, 1)
+		require.Equal(t, Error{Message: errs[0].Message, Line: 8, Column: 5}, errs[0])
+		require.Contains(t, errs[0].Message, "editor")
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	resp, err := Evaluate(context.Background(), model, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "parent", "folder:x"),
+		tuple.NewTupleKey("folder:x", "viewer", "user:anne"),
+	}, []Check{
+		{User: "user:anne", Relation: "viewer", Object: "document:1"},
+		{User: "user:bob", Relation: "viewer", Object: "document:1"},
+		{User: "user:anne", Relation: "editor", Object: "document:1"},
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.Errors)
+	require.Len(t, resp.Results, 3)
+	require.True(t, resp.Results[0].Allowed)
+	require.False(t, resp.Results[1].Allowed)
+	require.Empty(t, resp.Results[1].Error)
+	require.NotEmpty(t, resp.Results[2].Error)
+
+	t.Run("invalid_tuple", func(t *testing.T) {
+		_, err := Evaluate(context.Background(), model, []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		}, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid_model", func(t *testing.T) {
+		resp, err := Evaluate(context.Background(), "model", nil, []Check{
+			{User: "user:anne", Relation: "viewer", Object: "document:1"},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Errors)
+		require.Empty(t, resp.Results)
+	})
+}
+
+func TestHTTPHandlers(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	authenticator, err := presharedkey.NewPresharedKeyAuthenticator([]string{"key"})
+	require.NoError(t, err)
+
+	// the errors are encoded like on the HTTP gateway, so that the authentication errors are 401s
+	mux := runtime.NewServeMux(runtime.WithErrorHandler(func(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+		httpmiddleware.CustomHTTPErrorHandler(ctx, w, r, serverErrors.EncodeError(err))
+	}))
+	require.NoError(t, NewHandlers(mux, authenticator, 1).Register())
+
+	post := func(path, authorization, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	dsl, err := json.Marshal(model)
+	require.NoError(t, err)
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		for _, path := range []string{ValidatePath, EvaluatePath, GraphPath} {
+			require.Equal(t, http.StatusUnauthorized, post(path, "", `{}`).Code, path)
+			require.Equal(t, http.StatusUnauthorized, post(path, "Bearer wrong", `{}`).Code, path)
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		rec := post(ValidatePath, "Bearer key", `{"dsl": `+string(dsl)+`}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp ValidateResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.True(t, resp.Valid)
+		require.Empty(t, resp.Errors)
+		require.Contains(t, string(resp.AuthorizationModel), `"schema_version":"1.1"`)
+
+		rec = post(ValidatePath, "Bearer key", `{"dsl": "model\n  schema 1.1\ntype"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.False(t, resp.Valid)
+		require.NotEmpty(t, resp.Errors)
+	})
+
+	t.Run("evaluate", func(t *testing.T) {
+		rec := post(EvaluatePath, "Bearer key", `{
+			"dsl": `+string(dsl)+`,
+			"tuples": [{"object": "document:1", "relation": "owner", "user": "user:anne"}],
+			"checks": [{"object": "document:1", "relation": "viewer", "user": "user:anne"}]
+		}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp EvaluateResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 1)
+		require.True(t, resp.Results[0].Allowed)
+	})
+
+	t.Run("evaluate_too_many_tuples", func(t *testing.T) {
+		rec := post(EvaluatePath, "Bearer key", `{
+			"dsl": `+string(dsl)+`,
+			"tuples": [
+				{"object": "document:1", "relation": "owner", "user": "user:anne"},
+				{"object": "document:1", "relation": "owner", "user": "user:bob"}
+			]
+		}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	})
+
+	t.Run("graph", func(t *testing.T) {
+		rec := post(GraphPath, "Bearer key", `{"dsl": `+string(dsl)+`}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var graph struct {
+			Nodes []map[string]string `json:"nodes"`
+			Edges []map[string]string `json:"edges"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &graph))
+		require.Contains(t, graph.Nodes, map[string]string{"id": "document#viewer", "kind": "relation", "label": "viewer"})
+		require.Contains(t, graph.Edges, map[string]string{
+			"from": "document#viewer/union/1", "to": "folder#viewer", "kind": "tuple_to_userset", "label": "from parent",
+		})
+
+		rec = post(GraphPath, "Bearer key", `{"dsl": "model"}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	})
+}
@@ -0,0 +1,81 @@
+// Package modelgraph serves the graph of the types, relations and rewrites of an authorization model
+// on the HTTP gateway, as JSON nodes and edges or in the DOT language, so that tooling can render
+// model diagrams.
+package modelgraph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+const (
+	FormatJSON = "json"
+	FormatDOT  = "dot"
+)
+
+// Client reads the authorization models, so that the requests are authenticated and validated like
+// any other request.
+type Client interface {
+	ReadAuthorizationModel(ctx context.Context, in *openfgav1.ReadAuthorizationModelRequest, opts ...grpc.CallOption) (*openfgav1.ReadAuthorizationModelResponse, error)
+}
+
+// Graph returns the graph of an authorization model of a store.
+func Graph(ctx context.Context, client Client, storeID, modelID string) (*typesystem.ModelGraph, error) {
+	resp, err := client.ReadAuthorizationModel(ctx, &openfgav1.ReadAuthorizationModelRequest{
+		StoreId: storeID,
+		Id:      modelID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return typesystem.New(resp.GetAuthorizationModel()).Graph(), nil
+}
+
+// NewHTTPHandler returns a handler for the HTTP gateway that returns the graph of the model of the
+// 'store_id' and 'authorization_model_id' path parameters, in the format of the 'format' query
+// parameter: 'json' (the default) or 'dot'. The model is read through the client with the
+// Authorization header of the request.
+func NewHTTPHandler(mux *runtime.ServeMux, client Client) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatJSON
+		}
+		if format != FormatJSON && format != FormatDOT {
+			err := status.Errorf(codes.InvalidArgument, "the format must be '%s' or '%s'", FormatJSON, FormatDOT)
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		graph, err := Graph(ctx, client, pathParams["store_id"], pathParams["authorization_model_id"])
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		if format == FormatDOT {
+			w.Header().Set("Content-Type", "text/vnd.graphviz")
+			_, _ = w.Write([]byte(graph.DOT()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(graph)
+	}
+}
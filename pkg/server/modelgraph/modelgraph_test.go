@@ -0,0 +1,80 @@
+package modelgraph
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/testutils/servertest"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func setup(t *testing.T) (*servertest.Client, string, string) {
+	client := servertest.New(t)
+
+	storeID, modelID := client.CreateStore(t, "modelgraph", `model
+  schema 1.1
+type user
+type document
+  relations
+    define owner: [user with non_expired]
+    define viewer: [user] or owner
+
+condition non_expired(current_time: timestamp, expires_at: timestamp) {
+  current_time < expires_at
+}`)
+
+	return client, storeID, modelID
+}
+
+func TestHTTPHandler(t *testing.T) {
+	client, storeID, modelID := setup(t)
+
+	mux := runtime.NewServeMux()
+	require.NoError(t, mux.HandlePath(http.MethodGet, "/stores/{store_id}/authorization-models/{authorization_model_id}/graph", NewHTTPHandler(mux, client)))
+
+	serve := func(modelID, format string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/stores/"+storeID+"/authorization-models/"+modelID+"/graph?format="+format, nil)
+		req.Header.Set("Authorization", "Bearer key")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("json", func(t *testing.T) {
+		w := serve(modelID, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Equal(t, []string{"Bearer key"}, client.Metadata("authorization"))
+
+		var graph typesystem.ModelGraph
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &graph))
+		require.Contains(t, graph.Edges, &typesystem.GraphEdge{
+			From:      "document#owner",
+			To:        "user",
+			Kind:      typesystem.GraphEdgeDirect,
+			Condition: "non_expired",
+		})
+		require.Equal(t, []*typesystem.GraphCondition{{Name: "non_expired", Expression: "current_time < expires_at"}}, graph.Conditions)
+	})
+
+	t.Run("dot", func(t *testing.T) {
+		w := serve(modelID, FormatDOT)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Equal(t, "text/vnd.graphviz", w.Header().Get("Content-Type"))
+		require.True(t, strings.HasPrefix(w.Body.String(), "digraph model {"))
+		require.Contains(t, w.Body.String(), `"document#owner" -> "user" [label="with non_expired: current_time < expires_at", style=dashed];`)
+	})
+
+	t.Run("unknown_format", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, serve(modelID, "svg").Code)
+	})
+
+	t.Run("unknown_model", func(t *testing.T) {
+		require.NotEqual(t, http.StatusOK, serve("01HVMMBCMGZNT3SED4Z17ECXCA", "").Code)
+	})
+}
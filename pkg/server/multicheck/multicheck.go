@@ -0,0 +1,235 @@
+// Package multicheck resolves whether a user has any or all of several relationships in a single
+// request, e.g. whether they can edit or administer a document, stopping as soon as the outcome is known.
+package multicheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/server"
+)
+
+// MaxTupleKeys is the maximum number of tuple keys of a request.
+const MaxTupleKeys = 10
+
+// Mode is how the results of the tuple keys of a request are combined.
+type Mode string
+
+const (
+	// ModeAny allows the request if any of the tuple keys is allowed.
+	ModeAny Mode = "ANY"
+	// ModeAll allows the request if all of the tuple keys are allowed.
+	ModeAll Mode = "ALL"
+)
+
+// Client is the subset of the OpenFGA service that is used to resolve the tuple keys.
+type Client interface {
+	Check(ctx context.Context, in *openfgav1.CheckRequest, opts ...grpc.CallOption) (*openfgav1.CheckResponse, error)
+}
+
+// Request is a set of tuple keys whose Check results are combined according to the mode. The
+// authorization model, contextual tuples and context are the same for every tuple key.
+type Request struct {
+	StoreID              string
+	AuthorizationModelID string
+	TupleKeys            []*openfgav1.CheckRequestTupleKey
+	Mode                 Mode
+	ContextualTuples     *openfgav1.ContextualTupleKeys
+	Context              *structpb.Struct
+}
+
+// Result is the outcome of the Check of a single tuple key.
+type Result struct {
+	User     string `json:"user"`
+	Relation string `json:"relation"`
+	Object   string `json:"object"`
+	Allowed  bool   `json:"allowed"`
+
+	// Skipped is true if the Check was cancelled because the outcome of the request was already known.
+	Skipped bool `json:"skipped,omitempty"`
+
+	// Error is the error of the Check, if it failed and the outcome of the request didn't depend on it.
+	Error string `json:"error,omitempty"`
+}
+
+// Response is the combined outcome of the tuple keys, and the result of each one, in the order of the request.
+type Response struct {
+	Allowed bool     `json:"allowed"`
+	Results []Result `json:"results"`
+}
+
+// Check runs a Check request for each tuple key concurrently. As soon as one of them is allowed in
+// ModeAny, or denied in ModeAll, the remaining ones are cancelled. An error is returned if the
+// outcome depends on a Check that failed.
+func Check(ctx context.Context, client Client, req Request) (*Response, error) {
+	if len(req.TupleKeys) == 0 || len(req.TupleKeys) > MaxTupleKeys {
+		return nil, status.Errorf(codes.InvalidArgument, "the number of tuple keys must be between 1 and %d", MaxTupleKeys)
+	}
+
+	if req.Mode != ModeAny && req.Mode != ModeAll {
+		return nil, status.Errorf(codes.InvalidArgument, "the mode must be '%s' or '%s'", ModeAny, ModeAll)
+	}
+
+	// in ModeAny the outcome is known once a tuple key is allowed, and in ModeAll once one is denied
+	decisive := req.Mode == ModeAny
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Result, len(req.TupleKeys))
+	errs := make([]error, len(req.TupleKeys))
+	var decided bool
+	var mu sync.Mutex
+
+	var wg sync.WaitGroup
+	for i, tk := range req.TupleKeys {
+		i, tk := i, tk
+		results[i] = Result{User: tk.GetUser(), Relation: tk.GetRelation(), Object: tk.GetObject()}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := client.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:              req.StoreID,
+				AuthorizationModelId: req.AuthorizationModelID,
+				TupleKey:             tk,
+				ContextualTuples:     req.ContextualTuples,
+				Context:              req.Context,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if decided {
+				results[i].Skipped = true
+				return
+			}
+
+			if err != nil {
+				errs[i] = err
+				results[i].Error = err.Error()
+				return
+			}
+
+			results[i].Allowed = resp.GetAllowed()
+			if resp.GetAllowed() == decisive {
+				decided = true
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !decided {
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// the outcome is the decisive result if any tuple key had it, and the opposite otherwise
+	allowed := !decisive
+	if decided {
+		allowed = decisive
+	}
+
+	return &Response{Allowed: allowed, Results: results}, nil
+}
+
+// httpRequest is the body of the HTTP request. The contextual tuples and the context are
+// unmarshalled with protojson, like the body of a Check request.
+type httpRequest struct {
+	AuthorizationModelID string                            `json:"authorization_model_id"`
+	TupleKeys            []*openfgav1.CheckRequestTupleKey `json:"tuple_keys"`
+	Mode                 Mode                              `json:"mode"`
+	ContextualTuples     json.RawMessage                   `json:"contextual_tuples"`
+	Context              json.RawMessage                   `json:"context"`
+}
+
+func (r *httpRequest) toRequest(storeID string) (Request, error) {
+	req := Request{
+		StoreID:              storeID,
+		AuthorizationModelID: r.AuthorizationModelID,
+		TupleKeys:            r.TupleKeys,
+		Mode:                 Mode(strings.ToUpper(string(r.Mode))),
+	}
+
+	if len(r.ContextualTuples) > 0 {
+		req.ContextualTuples = &openfgav1.ContextualTupleKeys{}
+		if err := protojson.Unmarshal(r.ContextualTuples, req.ContextualTuples); err != nil {
+			return req, fmt.Errorf("invalid contextual_tuples: %w", err)
+		}
+	}
+
+	if len(r.Context) > 0 {
+		req.Context = &structpb.Struct{}
+		if err := protojson.Unmarshal(r.Context, req.Context); err != nil {
+			return req, fmt.Errorf("invalid context: %w", err)
+		}
+	}
+
+	return req, nil
+}
+
+// NewHTTPHandler returns a handler for the HTTP gateway that checks the tuple keys of the body for
+// the 'store_id' path parameter. The Authorization header is forwarded to the client, so that the
+// Check requests are authenticated and validated like any other request, and so is the consistency
+// token header.
+func NewHTTPHandler(mux *runtime.ServeMux, client Client) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+		if token := r.Header.Get(server.ConsistencyTokenHeader); token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, server.ConsistencyTokenHeader, token)
+		}
+		if consistency := r.Header.Get(server.ConsistencyHeader); consistency != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, server.ConsistencyHeader, consistency)
+		}
+
+		req, err := decodeHTTPRequest(r.Body, pathParams["store_id"])
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+
+		resp, err := Check(ctx, client, req)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func decodeHTTPRequest(body io.Reader, storeID string) (Request, error) {
+	var httpReq httpRequest
+	if err := json.NewDecoder(body).Decode(&httpReq); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Request{}, errors.New("the request body is empty")
+		}
+		return Request{}, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return httpReq.toRequest(storeID)
+}
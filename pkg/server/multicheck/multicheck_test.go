@@ -0,0 +1,164 @@
+package multicheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/testutils/servertest"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func setup(t *testing.T) (*servertest.Client, string) {
+	client := servertest.New(t)
+
+	storeID, _ := client.CreateStore(t, "multicheck", `model
+  schema 1.1
+type user
+type document
+  relations
+    define admin: [user]
+    define editor: [user]
+    define viewer: [user] or editor`, tuple.NewTupleKey("document:1", "editor", "user:anne"))
+
+	return client, storeID
+}
+
+// fakeClient answers from a map of relations, and fails for the relations that aren't in it.
+type fakeClient map[string]bool
+
+func (c fakeClient) Check(ctx context.Context, in *openfgav1.CheckRequest, _ ...grpc.CallOption) (*openfgav1.CheckResponse, error) {
+	allowed, ok := c[in.GetTupleKey().GetRelation()]
+	if !ok {
+		return nil, status.Error(codes.Internal, "check failed")
+	}
+	return &openfgav1.CheckResponse{Allowed: allowed}, nil
+}
+
+func TestCheck(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	client, storeID := setup(t)
+
+	tupleKeys := func(relations ...string) []*openfgav1.CheckRequestTupleKey {
+		var tks []*openfgav1.CheckRequestTupleKey
+		for _, relation := range relations {
+			tks = append(tks, tuple.NewCheckRequestTupleKey("document:1", relation, "user:anne"))
+		}
+		return tks
+	}
+
+	tests := []struct {
+		name      string
+		relations []string
+		mode      Mode
+		expected  bool
+	}{
+		{name: "any_allowed", relations: []string{"admin", "editor"}, mode: ModeAny, expected: true},
+		{name: "any_denied", relations: []string{"admin"}, mode: ModeAny, expected: false},
+		{name: "all_allowed", relations: []string{"editor", "viewer"}, mode: ModeAll, expected: true},
+		{name: "all_denied", relations: []string{"admin", "editor", "viewer"}, mode: ModeAll, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := Check(context.Background(), client, Request{
+				StoreID:   storeID,
+				TupleKeys: tupleKeys(test.relations...),
+				Mode:      test.mode,
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expected, resp.Allowed)
+			require.Len(t, resp.Results, len(test.relations))
+
+			for i, result := range resp.Results {
+				require.Equal(t, test.relations[i], result.Relation)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		// the outcome doesn't depend on the failed check
+		resp, err := Check(context.Background(), fakeClient{"editor": true}, Request{TupleKeys: tupleKeys("error", "editor"), Mode: ModeAny})
+		require.NoError(t, err)
+		require.True(t, resp.Allowed)
+
+		resp, err = Check(context.Background(), fakeClient{"admin": false}, Request{TupleKeys: tupleKeys("error", "admin"), Mode: ModeAll})
+		require.NoError(t, err)
+		require.False(t, resp.Allowed)
+
+		// the outcome depends on the failed check
+		_, err = Check(context.Background(), fakeClient{"admin": false}, Request{TupleKeys: tupleKeys("error", "admin"), Mode: ModeAny})
+		require.Equal(t, codes.Internal, status.Code(err))
+
+		_, err = Check(context.Background(), fakeClient{"editor": true}, Request{TupleKeys: tupleKeys("error", "editor"), Mode: ModeAll})
+		require.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("invalid_request", func(t *testing.T) {
+		_, err := Check(context.Background(), client, Request{StoreID: storeID, Mode: ModeAny})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = Check(context.Background(), client, Request{StoreID: storeID, TupleKeys: tupleKeys("admin"), Mode: "SOME"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		relations := make([]string, MaxTupleKeys+1)
+		for i := range relations {
+			relations[i] = "admin"
+		}
+		_, err = Check(context.Background(), client, Request{StoreID: storeID, TupleKeys: tupleKeys(relations...), Mode: ModeAny})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestHTTPHandler(t *testing.T) {
+	client, storeID := setup(t)
+
+	mux := runtime.NewServeMux()
+	require.NoError(t, mux.HandlePath(http.MethodPost, "/stores/{store_id}/check-multiple", NewHTTPHandler(mux, client)))
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/stores/"+storeID+"/check-multiple", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer key")
+		req.Header.Set(server.ConsistencyTokenHeader, "token")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(`{
+		"mode": "any",
+		"tuple_keys": [
+			{"user": "user:bob", "relation": "admin", "object": "document:1"},
+			{"user": "user:bob", "relation": "editor", "object": "document:1"}
+		],
+		"contextual_tuples": {"tuple_keys": [{"user": "user:bob", "relation": "editor", "object": "document:1"}]}
+	}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, []string{"Bearer key"}, client.Metadata("authorization"))
+	require.Equal(t, []string{"token"}, client.Metadata(server.ConsistencyTokenHeader))
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.True(t, resp.Allowed)
+	require.Len(t, resp.Results, 2)
+	require.Equal(t, "editor", resp.Results[1].Relation)
+
+	require.Equal(t, http.StatusBadRequest, serve(``).Code)
+	require.Equal(t, http.StatusBadRequest, serve(`{"mode": "any"}`).Code)
+	require.Equal(t, http.StatusBadRequest, serve(`{"mode": "any", "tuple_keys": [{"user": "user:bob", "relation": "admin", "object": "document:1"}], "context": []}`).Code)
+}
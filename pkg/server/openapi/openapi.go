@@ -0,0 +1,469 @@
+// Package openapi serves an OpenAPI document of the HTTP API of the OpenFGA service, and a minimal
+// API explorer that sends requests to it from the browser. The document is generated from the HTTP
+// and OpenAPI annotations of the service, so that it always matches the routes of the gateway.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/openfga/openfga/internal/build"
+)
+
+const (
+	// DocumentPath is the path of the OpenAPI document on the HTTP gateway.
+	DocumentPath = "/openapi.json"
+
+	// ExplorerPath is the path of the API explorer on the HTTP gateway.
+	ExplorerPath = "/docs"
+
+	schemasRef = "#/components/schemas/"
+
+	// statusSchema is the name of the schema of the errors.
+	statusSchema = "Status"
+)
+
+//go:embed explorer.html
+var explorerHTML []byte
+
+// pathParamRegex matches the parameters of a path template, e.g. '{store_id}'.
+var pathParamRegex = regexp.MustCompile(`{([^}=]+)(=[^}]*)?}`)
+
+// Schema is an OpenAPI schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Example              json.RawMessage    `json:"example,omitempty"`
+}
+
+// Parameter is an OpenAPI parameter object.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType is an OpenAPI media type object.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// RequestBody is an OpenAPI request body object.
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is an OpenAPI response object.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Operation is an OpenAPI operation object.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Info is an OpenAPI info object.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components is an OpenAPI components object.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Document is an OpenAPI 3.0 document.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// NewDocument generates the OpenAPI document of the HTTP API of the OpenFGA service.
+func NewDocument() (*Document, error) {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: "OpenFGA", Version: build.Version},
+		Paths:   map[string]map[string]*Operation{},
+		Components: Components{Schemas: map[string]*Schema{
+			statusSchema: {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"code":    {Type: "integer", Format: "int32"},
+					"message": {Type: "string"},
+					"details": {Type: "array", Items: &Schema{Type: "object"}},
+				},
+			},
+		}},
+	}
+
+	service := openfgav1.File_openfga_v1_openfga_service_proto.Services().ByName("OpenFGAService")
+	if service == nil {
+		return nil, fmt.Errorf("the OpenFGA service descriptor is missing")
+	}
+
+	g := &generator{schemas: doc.Components.Schemas}
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+
+		rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+
+		verb, path := httpPattern(rule)
+		if path == "" {
+			continue
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Operation{}
+		}
+		doc.Paths[path][verb] = g.operation(method, path, rule.GetBody())
+	}
+
+	return doc, nil
+}
+
+// httpPattern returns the lowercase HTTP verb and the path of the rule, with the path parameters
+// in the OpenAPI format.
+func httpPattern(rule *annotations.HttpRule) (string, string) {
+	var verb, path string
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		verb, path = http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Post:
+		verb, path = http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Put:
+		verb, path = http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Patch:
+		verb, path = http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Delete:
+		verb, path = http.MethodDelete, pattern.Delete
+	default:
+		return "", ""
+	}
+
+	return strings.ToLower(verb), pathParamRegex.ReplaceAllString(path, "{$1}")
+}
+
+// generator generates the operations and the schemas of the messages they reference.
+type generator struct {
+	schemas map[string]*Schema
+}
+
+func (g *generator) operation(method protoreflect.MethodDescriptor, path, body string) *Operation {
+	op := &Operation{
+		OperationID: string(method.Name()),
+		Responses: map[string]*Response{
+			"default": {
+				Description: "An error response.",
+				Content:     jsonContent(&Schema{Ref: schemasRef + statusSchema}),
+			},
+		},
+	}
+
+	if annotated, ok := proto.GetExtension(method.Options(), options.E_Openapiv2Operation).(*options.Operation); ok && annotated != nil {
+		op.Summary = annotated.GetSummary()
+		op.Description = annotated.GetDescription()
+		op.Tags = annotated.GetTags()
+	}
+
+	input := method.Input()
+	pathParams := map[string]bool{}
+	for _, match := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		name := match[1]
+		pathParams[name] = true
+
+		param := &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		if field := input.Fields().ByName(protoreflect.Name(name)); field != nil {
+			param.Schema = g.fieldSchema(field)
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+
+	switch body {
+	case "*":
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.messageSchema(input, pathParams))}
+	case "":
+		op.Parameters = append(op.Parameters, g.queryParameters(input, pathParams)...)
+	default:
+		if field := input.Fields().ByName(protoreflect.Name(body)); field != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.fieldSchema(field))}
+		}
+	}
+
+	response := &Schema{Ref: g.messageRef(method.Output())}
+	description := "A successful response."
+	if method.IsStreamingServer() {
+		// the gateway streams the responses as newline delimited JSON objects
+		response = &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"result": response,
+				"error":  {Ref: schemasRef + statusSchema},
+			},
+		}
+		description = "A stream of newline delimited results."
+	}
+	op.Responses["200"] = &Response{Description: description, Content: jsonContent(response)}
+
+	return op
+}
+
+// queryParameters returns the query parameters of the fields of the message that aren't path
+// parameters. The gateway only binds the scalar fields, the repeated scalar fields and the
+// wrappers.
+func (g *generator) queryParameters(md protoreflect.MessageDescriptor, exclude map[string]bool) []*Parameter {
+	var params []*Parameter
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if exclude[string(field.Name())] || field.IsMap() {
+			continue
+		}
+		if field.Kind() == protoreflect.MessageKind && !isWrapper(field.Message()) {
+			continue
+		}
+
+		param := &Parameter{Name: string(field.Name()), In: "query", Schema: g.fieldSchema(field)}
+		param.Description = param.Schema.Description
+		param.Schema.Description = ""
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// messageRef returns the reference to the schema of the message, which is generated on first use.
+func (g *generator) messageRef(md protoreflect.MessageDescriptor) string {
+	name := string(md.FullName())
+	if _, ok := g.schemas[name]; !ok {
+		// the schema is registered before it is generated, so that recursive messages terminate
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.messageSchema(md, nil)
+	}
+
+	return schemasRef + name
+}
+
+// messageSchema returns the schema of the message, without the excluded fields.
+func (g *generator) messageSchema(md protoreflect.MessageDescriptor, exclude map[string]bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	if annotated, ok := proto.GetExtension(md.Options(), options.E_Openapiv2Schema).(*options.Schema); ok && annotated != nil {
+		schema.Description = annotated.GetJsonSchema().GetDescription()
+		schema.Example = rawExample(annotated.GetExample())
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if exclude[string(field.Name())] {
+			continue
+		}
+
+		schema.Properties[field.JSONName()] = g.fieldSchema(field)
+		if isRequired(field) {
+			schema.Required = append(schema.Required, field.JSONName())
+		}
+	}
+	sort.Strings(schema.Required)
+
+	return schema
+}
+
+// fieldSchema returns the schema of the field, in the protobuf JSON mapping.
+func (g *generator) fieldSchema(field protoreflect.FieldDescriptor) *Schema {
+	var schema *Schema
+	switch {
+	case field.IsMap():
+		schema = &Schema{Type: "object", AdditionalProperties: g.singularSchema(field.MapValue())}
+	case field.IsList():
+		schema = &Schema{Type: "array", Items: g.singularSchema(field)}
+	default:
+		schema = g.singularSchema(field)
+	}
+
+	if annotated, ok := proto.GetExtension(field.Options(), options.E_Openapiv2Field).(*options.JSONSchema); ok && annotated != nil {
+		if schema.Ref != "" {
+			// the siblings of a reference are ignored, so the reference is wrapped to have a description
+			schema = &Schema{AllOf: []*Schema{schema}}
+		}
+		schema.Description = annotated.GetDescription()
+		schema.Example = rawExample(annotated.GetExample())
+	}
+
+	return schema
+}
+
+// singularSchema returns the schema of a single value of the field.
+func (g *generator) singularSchema(field protoreflect.FieldDescriptor) *Schema {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// the 64-bit integers are strings in the protobuf JSON mapping
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		values := field.Enum().Values()
+		schema := &Schema{Type: "string", Enum: make([]string, 0, values.Len())}
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+		return schema
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if schema := wellKnownSchema(field.Message()); schema != nil {
+			return schema
+		}
+		return &Schema{Ref: g.messageRef(field.Message())}
+	default:
+		return &Schema{}
+	}
+}
+
+// wellKnownSchema returns the schema of a well-known type, which have special JSON mappings, or nil.
+func wellKnownSchema(md protoreflect.MessageDescriptor) *Schema {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return &Schema{Type: "string"}
+	case "google.protobuf.Struct", "google.protobuf.Any", "google.protobuf.Empty":
+		return &Schema{Type: "object"}
+	case "google.protobuf.ListValue":
+		return &Schema{Type: "array", Items: &Schema{}}
+	case "google.protobuf.Value":
+		return &Schema{}
+	case "google.protobuf.BoolValue":
+		return &Schema{Type: "boolean"}
+	case "google.protobuf.Int32Value":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "google.protobuf.UInt32Value":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return &Schema{Type: "string", Format: "int64"}
+	case "google.protobuf.FloatValue":
+		return &Schema{Type: "number", Format: "float"}
+	case "google.protobuf.DoubleValue":
+		return &Schema{Type: "number", Format: "double"}
+	case "google.protobuf.StringValue":
+		return &Schema{Type: "string"}
+	case "google.protobuf.BytesValue":
+		return &Schema{Type: "string", Format: "byte"}
+	default:
+		return nil
+	}
+}
+
+// isWrapper reports whether the message is a wrapper of a scalar, e.g. google.protobuf.Int32Value.
+func isWrapper(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile().Package() == "google.protobuf" && strings.HasSuffix(string(md.Name()), "Value") &&
+		md.Name() != "Value" && md.Name() != "ListValue"
+}
+
+// isRequired reports whether the field has the REQUIRED field behavior.
+func isRequired(field protoreflect.FieldDescriptor) bool {
+	opts, ok := field.Options().(*descriptorpb.FieldOptions)
+	if !ok || opts == nil {
+		return false
+	}
+
+	behaviors, _ := proto.GetExtension(opts, annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+	for _, behavior := range behaviors {
+		if behavior == annotations.FieldBehavior_REQUIRED {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rawExample returns the example of an annotation, which is JSON, or nil if it isn't valid JSON.
+func rawExample(example string) json.RawMessage {
+	if example == "" || !json.Valid([]byte(example)) {
+		return nil
+	}
+
+	return json.RawMessage(example)
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// NewDocumentHandler returns a handler for the HTTP gateway that serves the OpenAPI document.
+func NewDocumentHandler() (runtime.HandlerFunc, error) {
+	doc, err := NewDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}, nil
+}
+
+// NewExplorerHandler returns a handler for the HTTP gateway that serves the API explorer. The
+// explorer reads the OpenAPI document at DocumentPath.
+func NewExplorerHandler() runtime.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+		_, _ = w.Write(explorerHTML)
+	}
+}
@@ -0,0 +1,118 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDocument(t *testing.T) {
+	doc, err := NewDocument()
+	require.NoError(t, err)
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+
+	t.Run("operation_with_body", func(t *testing.T) {
+		check := doc.Paths["/stores/{store_id}/check"]["post"]
+		require.NotNil(t, check)
+		require.Equal(t, "Check", check.OperationID)
+		require.NotEmpty(t, check.Summary)
+		require.Equal(t, []string{"Relationship Queries"}, check.Tags)
+
+		require.Len(t, check.Parameters, 1)
+		require.Equal(t, "store_id", check.Parameters[0].Name)
+		require.Equal(t, "path", check.Parameters[0].In)
+		require.True(t, check.Parameters[0].Required)
+
+		body := check.RequestBody.Content["application/json"].Schema
+		require.NotContains(t, body.Properties, "store_id")
+		require.Contains(t, body.Properties, "tuple_key")
+		require.Contains(t, body.Required, "tuple_key")
+
+		response := check.Responses["200"].Content["application/json"].Schema
+		require.Equal(t, schemasRef+"openfga.v1.CheckResponse", response.Ref)
+		require.Contains(t, doc.Components.Schemas, "openfga.v1.CheckResponse")
+		require.Contains(t, doc.Components.Schemas, "openfga.v1.CheckRequestTupleKey")
+	})
+
+	t.Run("operation_with_query_parameters", func(t *testing.T) {
+		listStores := doc.Paths["/stores"]["get"]
+		require.NotNil(t, listStores)
+		require.Nil(t, listStores.RequestBody)
+
+		params := map[string]*Parameter{}
+		for _, param := range listStores.Parameters {
+			params[param.Name] = param
+		}
+		require.Equal(t, "query", params["continuation_token"].In)
+		require.Equal(t, "integer", params["page_size"].Schema.Type)
+	})
+
+	t.Run("streamed_operation", func(t *testing.T) {
+		streamed := doc.Paths["/stores/{store_id}/streamed-list-objects"]["post"]
+		require.NotNil(t, streamed)
+
+		response := streamed.Responses["200"].Content["application/json"].Schema
+		require.Equal(t, schemasRef+"openfga.v1.StreamedListObjectsResponse", response.Properties["result"].Ref)
+	})
+
+	t.Run("well_known_types", func(t *testing.T) {
+		store := doc.Components.Schemas["openfga.v1.Store"]
+		require.NotNil(t, store)
+		require.Equal(t, "date-time", store.Properties["created_at"].Format)
+	})
+
+	t.Run("all_references_are_defined", func(t *testing.T) {
+		raw, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		var refs []string
+		var walk func(v any)
+		walk = func(v any) {
+			switch v := v.(type) {
+			case map[string]any:
+				for key, value := range v {
+					if ref, ok := value.(string); ok && key == "$ref" {
+						refs = append(refs, ref)
+					}
+					walk(value)
+				}
+			case []any:
+				for _, value := range v {
+					walk(value)
+				}
+			}
+		}
+
+		var generic any
+		require.NoError(t, json.Unmarshal(raw, &generic))
+		walk(generic)
+
+		require.NotEmpty(t, refs)
+		for _, ref := range refs {
+			require.Contains(t, doc.Components.Schemas, ref[len(schemasRef):])
+		}
+	})
+}
+
+func TestHandlers(t *testing.T) {
+	documentHandler, err := NewDocumentHandler()
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	documentHandler(rec, httptest.NewRequest(http.MethodGet, DocumentPath, nil), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var doc Document
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Contains(t, doc.Paths, "/stores")
+
+	rec = httptest.NewRecorder()
+	NewExplorerHandler()(rec, httptest.NewRequest(http.MethodGet, ExplorerPath, nil), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, rec.Body.String(), "openapi.json")
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/internal/graph"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const (
+	// peerDispatchKeyHeader authenticates a Check request as a subproblem dispatched by a peer with
+	// the preshared key of the peer dispatch, so that the other peer dispatch headers are trusted.
+	peerDispatchKeyHeader = "Openfga-Peer-Dispatch-Key"
+
+	// peerDispatchDepthHeader is the resolution depth left to the subproblem dispatched by a peer.
+	peerDispatchDepthHeader = "Openfga-Peer-Dispatch-Depth"
+
+	// peerDispatchVisitedHeader has the tuple keys of the subproblems that led to the subproblem
+	// dispatched by a peer, so that the cycles across peers are detected. It is binary, since the
+	// tuple keys may have characters that aren't allowed in the values of the other headers.
+	peerDispatchVisitedHeader = "Openfga-Peer-Dispatch-Visited-Bin"
+)
+
+// peerDispatchForwardedHeaders are the headers of a request that are forwarded to the peers with its
+// subproblems, so that they are authenticated and evaluated like the request.
+var peerDispatchForwardedHeaders = []string{"authorization", AsOfHeader, ConsistencyHeader, ConsistencyTokenHeader, CheckCacheControlHeader}
+
+// lookupHost resolves the addresses of the members of the cluster of the peer dispatch DNS name.
+var lookupHost = net.DefaultResolver.LookupHost
+
+// peerCheckResolver resolves the Check subproblems on a peer with Check requests.
+type peerCheckResolver struct {
+	conn         *grpc.ClientConn
+	client       openfgav1.OpenFGAServiceClient
+	presharedKey string
+}
+
+var _ graph.CheckResolver = (*peerCheckResolver)(nil)
+
+// ResolveCheck implements graph.CheckResolver. The datastore queries, dispatches and cache hits of
+// the subproblem on the peer are added to those of the request.
+func (p *peerCheckResolver) ResolveCheck(ctx context.Context, req *graph.ResolveCheckRequest) (*graph.ResolveCheckResponse, error) {
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	md := metadata.MD{}
+	for _, header := range peerDispatchForwardedHeaders {
+		if values := incoming.Get(header); len(values) > 0 {
+			md.Set(header, values...)
+		}
+	}
+
+	tupleKey := tuple.TupleKeyToString(req.GetTupleKey())
+	md.Set(peerDispatchKeyHeader, p.presharedKey)
+	md.Set(peerDispatchDepthHeader, strconv.FormatUint(uint64(req.GetRequestMetadata().Depth), 10))
+	for visited := range req.VisitedPaths {
+		// the subproblem itself was visited just before it was dispatched
+		if visited != tupleKey {
+			md.Append(peerDispatchVisitedHeader, visited)
+		}
+	}
+
+	var header metadata.MD
+	resp, err := p.client.Check(metadata.NewOutgoingContext(ctx, md), &openfgav1.CheckRequest{
+		StoreId:              req.GetStoreID(),
+		AuthorizationModelId: req.GetAuthorizationModelID(),
+		TupleKey: tuple.NewCheckRequestTupleKey(
+			req.GetTupleKey().GetObject(),
+			req.GetTupleKey().GetRelation(),
+			req.GetTupleKey().GetUser(),
+		),
+		ContextualTuples: &openfgav1.ContextualTupleKeys{TupleKeys: req.GetContextualTuples()},
+		Context:          req.GetContext(),
+	}, grpc.Header(&header))
+	if err != nil {
+		return nil, err
+	}
+
+	count := func(name string) uint32 {
+		values := header.Get(name)
+		if len(values) == 0 {
+			return 0
+		}
+		n, _ := strconv.ParseUint(values[0], 10, 32)
+		return uint32(n)
+	}
+
+	if requestMetadata := req.GetRequestMetadata(); requestMetadata != nil {
+		if requestMetadata.DispatchCounter != nil {
+			requestMetadata.DispatchCounter.Add(count(DispatchCountHeader))
+		}
+		if requestMetadata.CacheHitCounter != nil {
+			requestMetadata.CacheHitCounter.Add(count(CacheHitCountHeader))
+		}
+	}
+
+	return &graph.ResolveCheckResponse{
+		Allowed: resp.GetAllowed(),
+		ResolutionMetadata: &graph.ResolveCheckResponseMetadata{
+			DatastoreQueryCount: req.GetRequestMetadata().DatastoreQueryCount + count(DatastoreQueryCountHeader),
+		},
+	}, nil
+}
+
+// Close implements graph.CheckResolver.
+func (p *peerCheckResolver) Close() {
+	_ = p.conn.Close()
+}
+
+// dialPeer is the graph.PeerDialer of the peer dispatch.
+func (s *Server) dialPeer(addr string) (graph.CheckResolver, error) {
+	conn, err := grpc.NewClient(addr, s.peerDispatchDialOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &peerCheckResolver{
+		conn:         conn,
+		client:       openfgav1.NewOpenFGAServiceClient(conn),
+		presharedKey: s.peerDispatchPresharedKey,
+	}, nil
+}
+
+// peerDispatchFromContext returns the context, the resolution depth and the visited subproblems of
+// the Check request of the tuple key if it is a subproblem dispatched by a peer, or else the context,
+// the resolve node limit and no visited subproblems.
+func (s *Server) peerDispatchFromContext(ctx context.Context, tupleKey *openfgav1.TupleKey) (context.Context, uint32, map[string]struct{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(peerDispatchKeyHeader)
+	if len(keys) == 0 || s.peerDispatchPresharedKey == "" {
+		return ctx, s.resolveNodeLimit, nil, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(keys[0]), []byte(s.peerDispatchPresharedKey)) != 1 {
+		return nil, 0, nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header", peerDispatchKeyHeader))
+	}
+
+	depth := s.resolveNodeLimit
+	if values := md.Get(peerDispatchDepthHeader); len(values) > 0 {
+		n, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil {
+			return nil, 0, nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header: %w", peerDispatchDepthHeader, err))
+		}
+		depth = min(depth, uint32(n))
+	}
+
+	visited := map[string]struct{}{}
+	for _, value := range md.Get(peerDispatchVisitedHeader) {
+		visited[value] = struct{}{}
+	}
+
+	return graph.ContextWithPeerDispatched(ctx, tuple.TupleKeyToString(tupleKey)), depth, visited, nil
+}
+
+// watchPeerDispatchDNS sets the members of the cluster of the peer dispatch to the addresses the host
+// of its DNS name resolves to, with the port, every refresh interval, until the server is closed.
+func (s *Server) watchPeerDispatchDNS(host, port string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	refresh := func() {
+		lookupCtx, cancel := context.WithTimeout(ctx, s.peerDispatchDNSRefreshInterval)
+		defer cancel()
+
+		ips, err := lookupHost(lookupCtx, host)
+		if err != nil {
+			// the members are kept until the name resolves again
+			s.logger.Warn("failed to resolve the peer dispatch DNS name", zap.String("name", host), zap.Error(err))
+			return
+		}
+
+		addrs := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			addrs = append(addrs, net.JoinHostPort(ip, port))
+		}
+		sort.Strings(addrs)
+
+		s.peerDispatchCheckResolver.SetPeers(addrs)
+	}
+
+	refresh()
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(s.peerDispatchDNSRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+
+	s.stopWatchingPeerDispatchDNS = func() {
+		cancel()
+		<-done
+	}
+}
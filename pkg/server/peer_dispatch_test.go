@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/gateway"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestPeerDispatch(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	_, err := NewServerWithOpts(WithDatastore(ds), WithPeerDispatchEnabled(true), WithPeerDispatchSelf("127.0.0.1:8081"))
+	require.ErrorContains(t, err, "preshared key")
+
+	_, err = NewServerWithOpts(WithDatastore(ds), WithPeerDispatchEnabled(true), WithPeerDispatchSelf("127.0.0.1:8081"),
+		WithPeerDispatchPresharedKey("key"), WithPeerDispatchDNSName("openfga-headless"))
+	require.ErrorContains(t, err, "DNS name")
+
+	listeners := make([]net.Listener, 2)
+	addrs := make([]string, 2)
+	for i := range listeners {
+		listeners[i], err = net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addrs[i] = listeners[i].Addr().String()
+	}
+
+	// dispatched counts the subproblems each server resolved for its peer
+	dispatched := make([]atomic.Int32, 2)
+	servers := make([]*Server, 2)
+	grpcServers := make([]*grpc.Server, 2)
+	for i := range servers {
+		servers[i] = MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithTransport(gateway.NewRPCTransport(logger.NewNoopLogger())),
+			WithCheckQueryCacheEnabled(true),
+			WithPeerDispatchEnabled(true),
+			WithPeerDispatchSelf(addrs[i]),
+			WithPeerDispatchPeers(addrs),
+			WithPeerDispatchPresharedKey("key"),
+			WithPeerDispatchDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		)
+		require.Equal(t, []string{addrs[1-i]}, servers[i].peerDispatchCheckResolver.Peers())
+
+		counter := &dispatched[i]
+		grpcServers[i] = grpc.NewServer(grpc.UnaryInterceptor(
+			func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				if md, _ := metadata.FromIncomingContext(ctx); len(md.Get(peerDispatchKeyHeader)) > 0 {
+					counter.Add(1)
+				}
+				return handler(ctx, req)
+			},
+		))
+		openfgav1.RegisterOpenFGAServiceServer(grpcServers[i], servers[i])
+
+		lis := listeners[i]
+		grpcServer := grpcServers[i]
+		go func() {
+			_ = grpcServer.Serve(lis)
+		}()
+	}
+	t.Cleanup(func() {
+		for i := range servers {
+			grpcServers[i].Stop()
+			servers[i].Close()
+		}
+	})
+
+	store, err := servers[0].CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "peer-dispatch"})
+	require.NoError(t, err)
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type folder
+  relations
+    define viewer: [user]
+type document
+  relations
+    define parent: [folder]
+    define viewer: [user] or viewer from parent`)
+	writeModelResponse, err := servers[0].WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	var tuples []*openfgav1.TupleKey
+	for i := 0; i < 20; i++ {
+		tuples = append(tuples,
+			tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "parent", fmt.Sprintf("folder:%d", i)),
+			tuple.NewTupleKey(fmt.Sprintf("folder:%d", i), "viewer", "user:anne"),
+		)
+	}
+	_, err = servers[0].Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store.GetId(),
+		Writes:  &openfgav1.WriteRequestWrites{TupleKeys: tuples},
+	})
+	require.NoError(t, err)
+
+	checkAll := func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			resp, err := servers[0].Check(ctx, &openfgav1.CheckRequest{
+				StoreId:              store.GetId(),
+				AuthorizationModelId: writeModelResponse.GetAuthorizationModelId(),
+				TupleKey:             tuple.NewCheckRequestTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne"),
+			})
+			require.NoError(t, err)
+			require.True(t, resp.GetAllowed())
+		}
+	}
+
+	t.Run("dispatches_the_subproblems_to_their_owner", func(t *testing.T) {
+		checkAll(t)
+		require.Positive(t, dispatched[1].Load())
+	})
+
+	t.Run("resolves_locally_when_the_peer_is_down", func(t *testing.T) {
+		grpcServers[1].Stop()
+		checkAll(t)
+	})
+
+	t.Run("rejects_a_subproblem_with_an_invalid_key", func(t *testing.T) {
+		md := metadata.Pairs(peerDispatchKeyHeader, "invalid")
+		_, err := servers[0].Check(metadata.NewIncomingContext(ctx, md), &openfgav1.CheckRequest{
+			StoreId:              store.GetId(),
+			AuthorizationModelId: writeModelResponse.GetAuthorizationModelId(),
+			TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.ErrorContains(t, err, peerDispatchKeyHeader)
+	})
+}
+
+func TestPeerDispatchFromContext(t *testing.T) {
+	s := &Server{resolveNodeLimit: 25, peerDispatchPresharedKey: "key"}
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+
+	// a request without the key header isn't a subproblem dispatched by a peer
+	_, depth, visited, err := s.peerDispatchFromContext(context.Background(), tk)
+	require.NoError(t, err)
+	require.Equal(t, uint32(25), depth)
+	require.Nil(t, visited)
+
+	md := metadata.Pairs(
+		peerDispatchKeyHeader, "key",
+		peerDispatchDepthHeader, "10",
+		peerDispatchVisitedHeader, "folder:1#viewer@user:anne",
+	)
+	_, depth, visited, err = s.peerDispatchFromContext(metadata.NewIncomingContext(context.Background(), md), tk)
+	require.NoError(t, err)
+	require.Equal(t, uint32(10), depth)
+	require.Contains(t, visited, "folder:1#viewer@user:anne")
+
+	// the depth of a peer is capped at the resolve node limit
+	md.Set(peerDispatchDepthHeader, "100")
+	_, depth, _, err = s.peerDispatchFromContext(metadata.NewIncomingContext(context.Background(), md), tk)
+	require.NoError(t, err)
+	require.Equal(t, uint32(25), depth)
+}
+
+func TestPeerDispatchDNS(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	var mu sync.Mutex
+	ips := []string{"127.0.0.1", "127.0.0.2"}
+	lookupHost = func(_ context.Context, host string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, "openfga-headless", host)
+		return ips, nil
+	}
+	t.Cleanup(func() {
+		lookupHost = net.DefaultResolver.LookupHost
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithPeerDispatchEnabled(true),
+		WithPeerDispatchSelf("127.0.0.1:8081"),
+		WithPeerDispatchDNSName("openfga-headless:8081"),
+		WithPeerDispatchDNSRefreshInterval(10*time.Millisecond),
+		WithPeerDispatchPresharedKey("key"),
+		WithPeerDispatchDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	t.Cleanup(s.Close)
+
+	require.Equal(t, []string{"127.0.0.2:8081"}, s.peerDispatchCheckResolver.Peers())
+
+	mu.Lock()
+	ips = []string{"127.0.0.1", "127.0.0.3"}
+	mu.Unlock()
+
+	require.Eventually(t, func() bool {
+		peers := s.peerDispatchCheckResolver.Peers()
+		return len(peers) == 1 && peers[0] == "127.0.0.3:8081"
+	}, time.Second, 10*time.Millisecond)
+}
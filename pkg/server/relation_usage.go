@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/internal/graph"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/relationusage"
+	"github.com/openfga/openfga/pkg/telemetry"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+type withoutRelationUsageCtxKey struct{}
+
+// contextWithoutRelationUsage returns a context whose Check evaluations aren't recorded in the
+// relation usage, e.g. those of the candidate models evaluated by the Check canary, which no request
+// was served with.
+func contextWithoutRelationUsage(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutRelationUsageCtxKey{}, true)
+}
+
+// recordRelationUsage intercepts the resolution of the Check requests and of their subproblems, and
+// records the evaluations of their relations in the relation usage.
+func (s *Server) recordRelationUsage(ctx context.Context, req *graph.ResolveCheckRequest, next graph.CheckResolver) (*graph.ResolveCheckResponse, error) {
+	resp, err := next.ResolveCheck(ctx, req)
+	if err != nil || ctx.Value(withoutRelationUsageCtxKey{}) != nil {
+		return resp, err
+	}
+
+	tk := req.GetTupleKey()
+	s.relationUsageTracker.Record(req.GetStoreID(), tuple.GetType(tk.GetObject()), tk.GetRelation(), resp.GetAllowed())
+
+	return resp, nil
+}
+
+// RelationUsage returns how often Check evaluated each relation of the store, and how often it
+// allowed it, with the relations of the model that were never evaluated. If modelID is empty, the
+// relations are those of the latest model. It needs WithRelationUsageEnabled set to true.
+func (s *Server) RelationUsage(ctx context.Context, storeID, modelID string) (*relationusage.StoreUsage, error) {
+	ctx, span := tracer.Start(ctx, "RelationUsage", trace.WithAttributes(
+		attribute.String("store_id", storeID),
+	))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "RelationUsage",
+	})
+
+	if s.relationUsageTracker == nil {
+		return nil, status.Error(codes.FailedPrecondition, "the relation usage is not tracked")
+	}
+
+	typesys, err := s.resolveTypesystem(ctx, storeID, modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.relationUsageTracker.StoreUsage(ctx, storeID, typesys)
+	if err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/server/relationusage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestRelationUsage(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithRelationUsageEnabled(true),
+		WithRelationUsageSampleRatio(1),
+		WithRelationUsageFlushInterval(0),
+	)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "usage"})
+	require.NoError(t, err)
+
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: language.MustTransformDSLToProto("model\n  schema 1.1\ntype user\ntype document\n  relations\n    define editor: [user]\n    define owner: [user]\n    define viewer: [user] or editor").GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store.GetId(),
+		Writes:  &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "editor", "user:anne")}},
+	})
+	require.NoError(t, err)
+
+	for _, user := range []string{"user:anne", "user:bob"} {
+		_, err = s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  store.GetId(),
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", user),
+		})
+		require.NoError(t, err)
+	}
+
+	usage, err := s.RelationUsage(ctx, store.GetId(), "")
+	require.NoError(t, err)
+
+	relations := map[string]relationusage.RelationUsage{}
+	for _, relation := range usage.Relations {
+		relations[relation.Relation] = relation
+	}
+
+	// the relations of the subproblems are evaluated too
+	require.EqualValues(t, 1, relations["viewer"].Allowed)
+	require.EqualValues(t, 1, relations["viewer"].Denied)
+	require.EqualValues(t, 1, relations["editor"].Allowed)
+	require.EqualValues(t, 1, relations["editor"].Denied)
+	require.Zero(t, relations["owner"].Evaluations)
+	require.True(t, relations["owner"].InModel)
+
+	// the evaluations are flushed to the datastore on close
+	s.Close()
+
+	flushed, err := ds.ReadRelationUsage(ctx, store.GetId())
+	require.NoError(t, err)
+	require.Len(t, flushed, 2)
+
+	t.Run("disabled", func(t *testing.T) {
+		s := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(s.Close)
+
+		_, err := s.RelationUsage(ctx, store.GetId(), "")
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
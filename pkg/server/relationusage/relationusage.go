@@ -0,0 +1,400 @@
+// Package relationusage tracks how often Check evaluates each relation of each store, and how often
+// it allows it, so that model owners can find the relations that are never evaluated and the hot
+// paths of their models.
+package relationusage
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+const (
+	// DefaultSampleRatio is the fraction of the evaluations that are recorded by default.
+	DefaultSampleRatio = 0.1
+
+	// DefaultFlushInterval is how often the recorded evaluations are flushed to the datastore by default.
+	DefaultFlushInterval = 1 * time.Minute
+
+	flushTimeout = 10 * time.Second
+)
+
+// RelationUsage describes the evaluations of an object type and relation.
+type RelationUsage struct {
+	ObjectType string `json:"object_type"`
+	Relation   string `json:"relation"`
+
+	// Evaluations, Allowed and Denied are estimated from the sampled evaluations.
+	Evaluations uint64 `json:"evaluations"`
+	Allowed     uint64 `json:"allowed"`
+	Denied      uint64 `json:"denied"`
+
+	// AllowedRatio is the fraction of the evaluations that were allowed, 0 if there were none.
+	AllowedRatio float64 `json:"allowed_ratio"`
+
+	// LastEvaluatedAt is the time of the last sampled evaluation, if any.
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at,omitempty"`
+
+	// InModel is false for the relations that were evaluated but aren't in the authorization model,
+	// e.g. because they were removed from it since.
+	InModel bool `json:"in_model"`
+}
+
+// StoreUsage describes the evaluations of the relations of a store.
+type StoreUsage struct {
+	StoreID              string  `json:"store_id"`
+	AuthorizationModelID string  `json:"authorization_model_id"`
+	SampleRatio          float64 `json:"sample_ratio"`
+
+	// Relations are sorted from the most evaluated to the least, so the relations of the model that
+	// were never evaluated come last.
+	Relations []RelationUsage `json:"relations"`
+}
+
+// pendingUsage are the evaluations of a relation that weren't flushed yet. They are weighted by the
+// inverse of the sample ratio, so they are fractional.
+type pendingUsage struct {
+	allowed         float64
+	denied          float64
+	lastEvaluatedAt time.Time
+}
+
+// Tracker records a sample of the evaluations of the relations of the stores in memory, and
+// periodically adds them to the usage kept in the datastore, so that it survives restarts and adds
+// up the evaluations of all the servers.
+type Tracker struct {
+	backend       storage.RelationUsageBackend
+	logger        logger.Logger
+	sampleRatio   float64
+	flushInterval time.Duration
+
+	mu sync.Mutex
+	// pending maps a store to the object type#relation of the evaluations that weren't flushed yet
+	pending map[string]map[string]*pendingUsage
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// TrackerOption defines an option that can be used to change the behavior of a [Tracker].
+type TrackerOption func(t *Tracker)
+
+// WithLogger sets the logger of the [Tracker].
+func WithLogger(l logger.Logger) TrackerOption {
+	return func(t *Tracker) {
+		t.logger = l
+	}
+}
+
+// WithSampleRatio sets the fraction (between 0 and 1) of the evaluations that are recorded. The
+// counts are estimated from them.
+func WithSampleRatio(ratio float64) TrackerOption {
+	return func(t *Tracker) {
+		t.sampleRatio = ratio
+	}
+}
+
+// WithFlushInterval sets how often the recorded evaluations are flushed to the datastore. If the
+// interval is 0, they are only flushed when Flush or Close is called.
+func WithFlushInterval(interval time.Duration) TrackerOption {
+	return func(t *Tracker) {
+		t.flushInterval = interval
+	}
+}
+
+// NewTracker constructs a [Tracker] that flushes the evaluations to the backend. You must call Close
+// on it after you are done using it.
+func NewTracker(backend storage.RelationUsageBackend, opts ...TrackerOption) *Tracker {
+	t := &Tracker{
+		backend:       backend,
+		logger:        logger.NewNoopLogger(),
+		sampleRatio:   DefaultSampleRatio,
+		flushInterval: DefaultFlushInterval,
+		pending:       map[string]map[string]*pendingUsage{},
+		stop:          make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if t.flushInterval > 0 {
+		t.done.Add(1)
+		go t.flushPeriodically()
+	}
+
+	return t
+}
+
+// Close stops flushing the evaluations periodically and flushes them one last time.
+func (t *Tracker) Close() {
+	close(t.stop)
+	t.done.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	if err := t.Flush(ctx); err != nil {
+		t.logger.Error("failed to flush relation usage", zap.Error(err))
+	}
+}
+
+// SampleRatio returns the fraction of the evaluations that are recorded.
+func (t *Tracker) SampleRatio() float64 {
+	return t.sampleRatio
+}
+
+// Record records an evaluation of objectType#relation in the store, if it is sampled.
+func (t *Tracker) Record(storeID, objectType, relation string, allowed bool) {
+	if t.sampleRatio < 1 && rand.Float64() >= t.sampleRatio {
+		return
+	}
+
+	weight := 1 / t.sampleRatio
+	usage := pendingUsage{lastEvaluatedAt: time.Now().UTC()}
+	if allowed {
+		usage.allowed = weight
+	} else {
+		usage.denied = weight
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.add(storeID, tuple.ToObjectRelationString(objectType, relation), usage)
+}
+
+// add adds the evaluations to the pending ones. t.mu must be held.
+func (t *Tracker) add(storeID, key string, usage pendingUsage) {
+	relations, ok := t.pending[storeID]
+	if !ok {
+		relations = map[string]*pendingUsage{}
+		t.pending[storeID] = relations
+	}
+
+	pending, ok := relations[key]
+	if !ok {
+		pending = &pendingUsage{}
+		relations[key] = pending
+	}
+
+	pending.allowed += usage.allowed
+	pending.denied += usage.denied
+	if usage.lastEvaluatedAt.After(pending.lastEvaluatedAt) {
+		pending.lastEvaluatedAt = usage.lastEvaluatedAt
+	}
+}
+
+func (t *Tracker) flushPeriodically() {
+	defer t.done.Done()
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			if err := t.Flush(ctx); err != nil {
+				t.logger.Error("failed to flush relation usage", zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
+
+// Flush adds the evaluations recorded since the last flush to the usage kept in the datastore. The
+// evaluations of the stores that fail to be flushed are kept, and flushed again next time.
+func (t *Tracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = map[string]map[string]*pendingUsage{}
+	t.mu.Unlock()
+
+	var firstErr error
+	for storeID, relations := range pending {
+		usage, remainders := split(relations)
+
+		if len(usage) > 0 {
+			if err := t.backend.AddRelationUsage(ctx, storeID, usage); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+
+				// flush all of them again next time
+				remainders = relations
+			}
+		}
+
+		t.mu.Lock()
+		for key, remainder := range remainders {
+			t.add(storeID, key, *remainder)
+		}
+		t.mu.Unlock()
+	}
+
+	return firstErr
+}
+
+// split splits the pending evaluations of the relations of a store in their whole parts, which are
+// flushed, and their fractional remainders, which are kept until the next flush.
+func split(relations map[string]*pendingUsage) ([]storage.RelationUsage, map[string]*pendingUsage) {
+	usage := make([]storage.RelationUsage, 0, len(relations))
+	remainders := map[string]*pendingUsage{}
+
+	for key, pending := range relations {
+		allowed, denied := math.Floor(pending.allowed), math.Floor(pending.denied)
+		if allowed+denied > 0 {
+			objectType, relation := tuple.SplitObjectRelation(key)
+			usage = append(usage, storage.RelationUsage{
+				ObjectType:      objectType,
+				Relation:        relation,
+				Allowed:         uint64(allowed),
+				Denied:          uint64(denied),
+				LastEvaluatedAt: pending.lastEvaluatedAt,
+			})
+		}
+
+		if pending.allowed > allowed || pending.denied > denied {
+			remainders[key] = &pendingUsage{allowed: pending.allowed - allowed, denied: pending.denied - denied}
+			if allowed+denied == 0 {
+				remainders[key].lastEvaluatedAt = pending.lastEvaluatedAt
+			}
+		}
+	}
+
+	return usage, remainders
+}
+
+// StoreUsage returns the usage of the relations of the store: the usage kept in the datastore, and
+// the evaluations that weren't flushed yet. It includes the relations of the model that were never
+// evaluated.
+func (t *Tracker) StoreUsage(ctx context.Context, storeID string, typesys *typesystem.TypeSystem) (*StoreUsage, error) {
+	flushed, err := t.backend.ReadRelationUsage(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	relations := map[string]*RelationUsage{}
+	for objectType, typeRelations := range typesys.GetAllRelations() {
+		for relation := range typeRelations {
+			relations[tuple.ToObjectRelationString(objectType, relation)] = &RelationUsage{
+				ObjectType: objectType,
+				Relation:   relation,
+				InModel:    true,
+			}
+		}
+	}
+
+	add := func(objectType, relation string, allowed, denied uint64, lastEvaluatedAt time.Time) {
+		key := tuple.ToObjectRelationString(objectType, relation)
+		usage, ok := relations[key]
+		if !ok {
+			usage = &RelationUsage{ObjectType: objectType, Relation: relation}
+			relations[key] = usage
+		}
+
+		usage.Allowed += allowed
+		usage.Denied += denied
+		if !lastEvaluatedAt.IsZero() && (usage.LastEvaluatedAt == nil || lastEvaluatedAt.After(*usage.LastEvaluatedAt)) {
+			usage.LastEvaluatedAt = &lastEvaluatedAt
+		}
+	}
+
+	for _, usage := range flushed {
+		add(usage.ObjectType, usage.Relation, usage.Allowed, usage.Denied, usage.LastEvaluatedAt)
+	}
+
+	t.mu.Lock()
+	for key, pending := range t.pending[storeID] {
+		objectType, relation := tuple.SplitObjectRelation(key)
+		add(objectType, relation, uint64(math.Round(pending.allowed)), uint64(math.Round(pending.denied)), pending.lastEvaluatedAt)
+	}
+	t.mu.Unlock()
+
+	storeUsage := &StoreUsage{
+		StoreID:              storeID,
+		AuthorizationModelID: typesys.GetAuthorizationModelID(),
+		SampleRatio:          t.sampleRatio,
+		Relations:            make([]RelationUsage, 0, len(relations)),
+	}
+
+	for _, usage := range relations {
+		usage.Evaluations = usage.Allowed + usage.Denied
+		if usage.Evaluations > 0 {
+			usage.AllowedRatio = float64(usage.Allowed) / float64(usage.Evaluations)
+		}
+		storeUsage.Relations = append(storeUsage.Relations, *usage)
+	}
+
+	sort.Slice(storeUsage.Relations, func(i, j int) bool {
+		a, b := storeUsage.Relations[i], storeUsage.Relations[j]
+		if a.Evaluations != b.Evaluations {
+			return a.Evaluations > b.Evaluations
+		}
+		if a.ObjectType != b.ObjectType {
+			return a.ObjectType < b.ObjectType
+		}
+		return a.Relation < b.Relation
+	})
+
+	return storeUsage, nil
+}
+
+// Client is the subset of the OpenFGA service that is used to authorize reading the relation usage
+// of a store.
+type Client interface {
+	GetStore(ctx context.Context, in *openfgav1.GetStoreRequest, opts ...grpc.CallOption) (*openfgav1.GetStoreResponse, error)
+}
+
+// Reader returns the relation usage of a store, with the relations of one of its models. It is
+// implemented by *server.Server.
+type Reader interface {
+	RelationUsage(ctx context.Context, storeID, modelID string) (*StoreUsage, error)
+}
+
+// NewHTTPHandler returns a handler for the HTTP gateway that returns the relation usage of the
+// 'store_id' path parameter, with the relations of the model of the 'authorization_model_id' query
+// parameter, or of the latest model if it is omitted. The store is first read through the client with
+// the Authorization header of the request, so that the request is authenticated like any other request.
+func NewHTTPHandler(mux *runtime.ServeMux, client Client, reader Reader) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+
+		storeID := pathParams["store_id"]
+		if _, err := client.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: storeID}); err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		usage, err := reader.RelationUsage(ctx, storeID, r.URL.Query().Get("authorization_model_id"))
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(usage)
+	}
+}
@@ -0,0 +1,232 @@
+package relationusage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	mockstorage "github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func newTypesystem(t *testing.T) *typesystem.TypeSystem {
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define editor: [user]
+				define owner: [user]
+				define viewer: [user] or editor`)
+
+	typesys, err := typesystem.NewAndValidate(context.Background(), model)
+	require.NoError(t, err)
+	return typesys
+}
+
+func TestTracker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("flush_adds_the_evaluations_to_the_datastore", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		tracker := NewTracker(ds, WithSampleRatio(1), WithFlushInterval(0))
+		t.Cleanup(tracker.Close)
+
+		storeID := ulid.Make().String()
+		tracker.Record(storeID, "document", "viewer", true)
+		tracker.Record(storeID, "document", "viewer", true)
+		tracker.Record(storeID, "document", "viewer", false)
+		tracker.Record(storeID, "document", "editor", false)
+
+		for i := 0; i < 2; i++ {
+			// nothing was recorded since the first flush
+			require.NoError(t, tracker.Flush(ctx))
+
+			usage, err := ds.ReadRelationUsage(ctx, storeID)
+			require.NoError(t, err)
+			require.Len(t, usage, 2)
+			require.Equal(t, "editor", usage[0].Relation)
+			require.EqualValues(t, 0, usage[0].Allowed)
+			require.EqualValues(t, 1, usage[0].Denied)
+			require.Equal(t, "viewer", usage[1].Relation)
+			require.EqualValues(t, 2, usage[1].Allowed)
+			require.EqualValues(t, 1, usage[1].Denied)
+			require.False(t, usage[1].LastEvaluatedAt.IsZero())
+		}
+	})
+
+	t.Run("close_flushes_the_evaluations", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		storeID := ulid.Make().String()
+		tracker := NewTracker(ds, WithSampleRatio(1))
+		tracker.Record(storeID, "document", "viewer", true)
+		tracker.Close()
+
+		usage, err := ds.ReadRelationUsage(ctx, storeID)
+		require.NoError(t, err)
+		require.Len(t, usage, 1)
+	})
+
+	t.Run("failed_flushes_are_retried", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		t.Cleanup(mockController.Finish)
+
+		storeID := ulid.Make().String()
+		backend := mockstorage.NewMockRelationUsageBackend(mockController)
+		gomock.InOrder(
+			backend.EXPECT().AddRelationUsage(gomock.Any(), storeID, gomock.Len(1)).Return(errors.New("unavailable")),
+			backend.EXPECT().AddRelationUsage(gomock.Any(), storeID, gomock.Len(1)).DoAndReturn(
+				func(_ context.Context, _ string, usage []storage.RelationUsage) error {
+					require.EqualValues(t, 2, usage[0].Allowed)
+					return nil
+				}),
+		)
+
+		tracker := NewTracker(backend, WithSampleRatio(1), WithFlushInterval(0))
+		tracker.Record(storeID, "document", "viewer", true)
+		require.Error(t, tracker.Flush(ctx))
+
+		tracker.Record(storeID, "document", "viewer", true)
+		tracker.Close()
+	})
+
+	t.Run("the_sampled_evaluations_are_weighted_by_the_inverse_of_the_sample_ratio", func(t *testing.T) {
+		usage, remainders := split(map[string]*pendingUsage{
+			"document#viewer": {allowed: 3.5, denied: 2},
+			"document#editor": {allowed: 0.5},
+		})
+
+		require.Len(t, usage, 1)
+		require.Equal(t, "viewer", usage[0].Relation)
+		require.EqualValues(t, 3, usage[0].Allowed)
+		require.EqualValues(t, 2, usage[0].Denied)
+
+		// the fractions are flushed once they add up to whole evaluations
+		require.Len(t, remainders, 2)
+		require.InDelta(t, 0.5, remainders["document#viewer"].allowed, 0)
+		require.InDelta(t, 0, remainders["document#viewer"].denied, 0)
+		require.InDelta(t, 0.5, remainders["document#editor"].allowed, 0)
+	})
+
+	t.Run("store_usage_includes_the_relations_never_evaluated", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		tracker := NewTracker(ds, WithSampleRatio(0.5), WithFlushInterval(0))
+		t.Cleanup(tracker.Close)
+
+		storeID := ulid.Make().String()
+		err := ds.AddRelationUsage(ctx, storeID, []storage.RelationUsage{
+			{ObjectType: "document", Relation: "viewer", Allowed: 6, Denied: 2},
+			{ObjectType: "document", Relation: "reader", Allowed: 1},
+		})
+		require.NoError(t, err)
+
+		// the pending evaluations are included before they are flushed
+		tracker.add(storeID, "document#viewer", pendingUsage{allowed: 2})
+
+		typesys := newTypesystem(t)
+		usage, err := tracker.StoreUsage(ctx, storeID, typesys)
+		require.NoError(t, err)
+		require.Equal(t, storeID, usage.StoreID)
+		require.Equal(t, typesys.GetAuthorizationModelID(), usage.AuthorizationModelID)
+		require.InDelta(t, 0.5, usage.SampleRatio, 0)
+
+		require.Equal(t, []RelationUsage{
+			{ObjectType: "document", Relation: "viewer", Evaluations: 10, Allowed: 8, Denied: 2, AllowedRatio: 0.8, InModel: true},
+			{ObjectType: "document", Relation: "reader", Evaluations: 1, Allowed: 1, AllowedRatio: 1},
+			{ObjectType: "document", Relation: "editor", InModel: true},
+			{ObjectType: "document", Relation: "owner", InModel: true},
+		}, withoutLastEvaluatedAt(usage.Relations))
+	})
+}
+
+func withoutLastEvaluatedAt(relations []RelationUsage) []RelationUsage {
+	for i := range relations {
+		relations[i].LastEvaluatedAt = nil
+	}
+	return relations
+}
+
+type storeClient struct {
+	authorization []string
+}
+
+func (c *storeClient) GetStore(ctx context.Context, in *openfgav1.GetStoreRequest, _ ...grpc.CallOption) (*openfgav1.GetStoreResponse, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.authorization = md.Get("authorization")
+
+	if in.GetStoreId() != "known" {
+		return nil, status.Error(codes.NotFound, "store not found")
+	}
+
+	return &openfgav1.GetStoreResponse{Id: in.GetStoreId()}, nil
+}
+
+type readerFunc func(ctx context.Context, storeID, modelID string) (*StoreUsage, error)
+
+func (f readerFunc) RelationUsage(ctx context.Context, storeID, modelID string) (*StoreUsage, error) {
+	return f(ctx, storeID, modelID)
+}
+
+func TestHTTPHandler(t *testing.T) {
+	client := &storeClient{}
+
+	var modelIDs []string
+	reader := readerFunc(func(_ context.Context, storeID, modelID string) (*StoreUsage, error) {
+		modelIDs = append(modelIDs, modelID)
+		return &StoreUsage{StoreID: storeID, AuthorizationModelID: "model", SampleRatio: 1, Relations: []RelationUsage{}}, nil
+	})
+
+	mux := runtime.NewServeMux()
+	require.NoError(t, mux.HandlePath(http.MethodGet, "/stores/{store_id}/relation-usage", NewHTTPHandler(mux, client, reader)))
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer key")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/stores/known/relation-usage")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"Bearer key"}, client.authorization)
+
+	var usage StoreUsage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	require.Equal(t, "known", usage.StoreID)
+	require.Equal(t, "model", usage.AuthorizationModelID)
+
+	w = serve("/stores/known/relation-usage?authorization_model_id=01HVMMBCMGZNT3SED4Z17ECXCA")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"", "01HVMMBCMGZNT3SED4Z17ECXCA"}, modelIDs)
+
+	require.Equal(t, http.StatusNotFound, serve("/stores/unknown/relation-usage").Code)
+}
@@ -3,14 +3,21 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"github.com/karlseguin/ccache/v3"
+	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -18,22 +25,37 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/openfga/openfga/internal/authn"
 	"github.com/openfga/openfga/internal/build"
 	"github.com/openfga/openfga/internal/condition"
 	"github.com/openfga/openfga/internal/graph"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
 	"github.com/openfga/openfga/internal/utils"
 	"github.com/openfga/openfga/internal/validation"
+	"github.com/openfga/openfga/pkg/checkresolver"
 	"github.com/openfga/openfga/pkg/encoder"
+	"github.com/openfga/openfga/pkg/featureflags"
 	"github.com/openfga/openfga/pkg/gateway"
 	"github.com/openfga/openfga/pkg/logger"
 	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
 	"github.com/openfga/openfga/pkg/middleware/validator"
+	"github.com/openfga/openfga/pkg/server/admission"
 	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/server/delegation"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/health"
+	"github.com/openfga/openfga/pkg/server/idgen"
+	"github.com/openfga/openfga/pkg/server/metering"
+	"github.com/openfga/openfga/pkg/server/relationusage"
+	"github.com/openfga/openfga/pkg/server/statistics"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/storagewrappers"
 	"github.com/openfga/openfga/pkg/telemetry"
@@ -46,6 +68,85 @@ type ExperimentalFeatureFlag string
 const (
 	AuthorizationModelIDHeader = "Openfga-Authorization-Model-Id"
 	authorizationModelIDKey    = "authorization_model_id"
+
+	// The following headers report the cost of resolving a Check or ListObjects request.
+	DatastoreQueryCountHeader = "Openfga-Datastore-Query-Count"
+	DispatchCountHeader       = "Openfga-Dispatch-Count"
+	CacheHitCountHeader       = "Openfga-Cache-Hit-Count"
+	ThrottlingDurationHeader  = "Openfga-Throttling-Duration-Ms"
+
+	// StaleResultHeader is set to 'true' on a Check response whose result was resolved from expired
+	// values of the Check query cache, because the datastore was unavailable.
+	StaleResultHeader = "Openfga-Stale-Result"
+
+	// ResolutionCycleHeader is set on a Check response that isn't allowed and whose evaluation
+	// followed a cycle of relationship tuples, e.g. `group:a#member@group:b#member` and
+	// `group:b#member@group:a#member`, to the cycle as `type#relation` nodes joined by ' -> '. The
+	// evaluation of a cycle can't allow the user, so it isn't an error.
+	ResolutionCycleHeader = "Openfga-Resolution-Cycle"
+
+	// ETagHeader is the entity tag of the authorization models read by ReadAuthorizationModel and
+	// ReadAuthorizationModels. If the If-None-Match header of a request matches it, the response is
+	// empty and, on the HTTP API, has the 304 Not Modified status code.
+	ETagHeader        = "ETag"
+	IfNoneMatchHeader = "If-None-Match"
+
+	// The following headers filter the changes returned by ReadChanges, in addition to the type of
+	// the request. The object ID prefix filter requires the type. A continuation token is only valid
+	// with the filters it was returned for.
+	ChangesObjectIDPrefixHeader = "Openfga-Changes-Object-Id-Prefix"
+	ChangesRelationHeader       = "Openfga-Changes-Relation"
+	ChangesUserHeader           = "Openfga-Changes-User"
+
+	// ChangesStartTimeHeader only returns the changes of ReadChanges that occurred at or after its
+	// RFC 3339 time. It is how a client restarts after its continuation token expired. A
+	// continuation token is only valid with the start time it was returned for.
+	ChangesStartTimeHeader = "Openfga-Changes-Start-Time"
+
+	// The following headers filter the tuples returned by Read, in addition to the tuple key of the
+	// request: ReadConditionNameHeader to the tuples with the condition, and ReadConditionContextHeader,
+	// a JSON object, to those whose condition context has the values of its fields. The context filter
+	// requires the condition name filter, and a page may then have fewer tuples than the page size. A
+	// continuation token is only valid with the filters it was returned for.
+	ReadConditionNameHeader    = "Openfga-Read-Condition-Name"
+	ReadConditionContextHeader = "Openfga-Read-Condition-Context"
+
+	// ConsistencyTokenHeader is the consistency token returned by Write. When a Check, ListObjects
+	// or StreamedListObjects request has it, the request is evaluated at or after the write of the
+	// token, even if the datastore reads from a lagging replica or Check results are cached.
+	ConsistencyTokenHeader = "Openfga-Consistency-Token"
+
+	// DryRunHeader makes a Write request with the value 'true' a dry run: the request is validated
+	// and admitted like a Write, and checked against the tuples of the store, but nothing is
+	// committed. The response of a successful dry run has the number of tuples that would be written
+	// and deleted in the DryRunWriteCountHeader and DryRunDeleteCountHeader headers.
+	DryRunHeader            = "Openfga-Dry-Run"
+	DryRunWriteCountHeader  = "Openfga-Dry-Run-Write-Count"
+	DryRunDeleteCountHeader = "Openfga-Dry-Run-Delete-Count"
+
+	// ListObjectsShardHeader restricts the results of a ListObjects or StreamedListObjects request
+	// to the objects of a shard, written as 'index/count', whose IDs have a hash that is the index
+	// modulo the count. The results of a ListObjects request of a shard are sorted and paginated: the
+	// response has the ListObjectsContinuationTokenHeader when the shard has more results, and the
+	// next page is requested with it. A continuation token is only valid for the same shard of the
+	// same request.
+	ListObjectsShardHeader             = "Openfga-List-Objects-Shard"
+	ListObjectsContinuationTokenHeader = "Openfga-List-Objects-Continuation-Token"
+
+	// ListObjectsPartialResultsHeader makes a ListObjects request with the value 'true' return the
+	// objects found until the deadline is hit instead of failing, for the requests of a shard, and
+	// report them with the ListObjectsTruncatedHeader set to 'true'. A truncated page of a shard has
+	// no continuation token, as the next page would skip the objects that weren't found: it should
+	// be requested again with the same continuation token.
+	ListObjectsPartialResultsHeader = "Openfga-List-Objects-Partial-Results"
+	ListObjectsTruncatedHeader      = "Openfga-List-Objects-Truncated"
+
+	// ListObjectsCandidatesHeader makes a ListObjects request return the permitted subset of the
+	// objects of the type of the request with the comma-separated IDs of the header, e.g. the results
+	// of a search index, in their order. The candidates are checked instead of being found by reverse
+	// expansion, and their Checks share the reads of the datastore. There must be at most as many
+	// candidates as the maximum number of results of ListObjects.
+	ListObjectsCandidatesHeader = "Openfga-List-Objects-Candidates"
 )
 
 var tracer = otel.Tracer("openfga/pkg/server")
@@ -99,24 +200,54 @@ type Server struct {
 	transport                        gateway.Transport
 	resolveNodeLimit                 uint32
 	resolveNodeBreadthLimit          uint32
+	checkUsersetsPageSize            uint32
 	changelogHorizonOffset           int
+	changelogTokenLifetime           time.Duration
 	listObjectsDeadline              time.Duration
 	listObjectsMaxResults            uint32
+	listObjectsShards                uint32
+	listObjectsShardConcurrency      uint32
 	maxConcurrentReadsForListObjects uint32
 	maxConcurrentReadsForCheck       uint32
 	maxAuthorizationModelSizeInBytes int
+	maxRelationsPerTypeDefinition    int
+	maxRewriteDepth                  int
+	maxConditionExpressionSize       int
+	maxContextualTuples              int
+	maxContextSizeInBytes            int
+	consistencyTokenTimeout          time.Duration
 	experimentals                    []ExperimentalFeatureFlag
+	featureFlags                     *featureflags.FeatureFlags
 	serviceName                      string
 
-	typesystemResolver     typesystem.TypesystemResolverFunc
-	typesystemResolverStop func()
+	typesystemResolver     *typesystem.MemoizedTypesystemResolver
+	typesystemCacheMaxSize int
+
+	checkQueryCacheEnabled     bool
+	checkQueryCacheLimit       uint32
+	checkQueryCacheTTL         time.Duration
+	checkQueryCacheNegativeTTL time.Duration
+	checkQueryCacheMaxBytes    uint64
+	checkQueryCacheServeStale  bool
+	cachedCheckResolver        *graph.CachedCheckResolver
+
+	checkQueryCacheWatermarkEnabled         bool
+	checkQueryCacheWatermarkRefreshInterval time.Duration
+	changelogWatermarks                     *graph.ChangelogWatermarks
 
-	checkQueryCacheEnabled bool
-	checkQueryCacheLimit   uint32
-	checkQueryCacheTTL     time.Duration
-	cachedCheckResolver    *graph.CachedCheckResolver
+	listObjectsCacheEnabled                  bool
+	listObjectsCacheLimit                    uint32
+	listObjectsCacheTTL                      time.Duration
+	listObjectsCacheWatermarkRefreshInterval time.Duration
+	listObjectsCache                         *ccache.Cache[*cachedListObjects]
+	listObjectsCacheWatermarks               *graph.ChangelogWatermarks
 
-	checkResolver graph.CheckResolver
+	storeSettingsCache *ccache.Cache[*storage.StoreSettings]
+	storeLabelsCache   *ccache.Cache[*cachedStoreLabels]
+
+	checkResolver       graph.CheckResolver
+	checkResolverCloser graph.CheckResolverCloser
+	checkResolverChain  func([]checkresolver.DelegatingCheckResolver) []checkresolver.DelegatingCheckResolver
 
 	requestDurationByQueryHistogramBuckets         []uint
 	requestDurationByDispatchCountHistogramBuckets []uint
@@ -127,6 +258,80 @@ type Server struct {
 	dispatchThrottlingMaxThreshold           uint32
 
 	dispatchThrottlingCheckResolver *graph.DispatchThrottlingCheckResolver
+
+	slowRequestLogEnabled   bool
+	slowRequestLogThreshold time.Duration
+
+	checkBudget graph.ResolutionBudget
+
+	checkReadDeduplicationEnabled          bool
+	checkReadDeduplicationMaxTuplesPerRead int
+	checkDispatchPoolEnabled               bool
+	checkDispatchPoolSize                  int
+
+	checkPlannerEnabled            bool
+	checkPlannerStatisticsInterval time.Duration
+	checkPlannerMaxStores          int
+	checkPlanner                   *graph.Planner
+
+	tupleStatisticsEnabled           bool
+	tupleStatisticsInterval          time.Duration
+	tupleStatisticsMaxTuplesPerStore int
+	tupleStatisticsCollector         *statistics.Collector
+
+	relationUsageEnabled       bool
+	relationUsageSampleRatio   float64
+	relationUsageFlushInterval time.Duration
+	relationUsageTracker       *relationusage.Tracker
+
+	idScheme                      string
+	idGenerator                   idgen.Generator
+	callerSuppliedStoreIDsEnabled bool
+
+	writeGroupSagaEnabled bool
+
+	writeAdmissionWebhookURL           string
+	writeAdmissionWebhookTimeout       time.Duration
+	writeAdmissionWebhookFailurePolicy string
+	writeAdmitter                      admission.Admitter
+
+	delegatedCheckRelations  []string
+	delegatedCheckOperator   string
+	delegatedCheckTimeout    time.Duration
+	delegatedCheckEvaluators map[string]delegation.Evaluator
+
+	checkCanaryEnabled                  bool
+	checkCanarySampleRatio              float64
+	checkCanaryTimeout                  time.Duration
+	checkCanaryMaxConcurrentEvaluations int
+	checkCanary                         *checkCanary
+
+	writeCoalescingEnabled bool
+	writeCoalescingWindow  time.Duration
+	writeDatastore         storage.OpenFGADatastore
+
+	peerDispatchEnabled            bool
+	peerDispatchSelf               string
+	peerDispatchPeers              []string
+	peerDispatchDNSName            string
+	peerDispatchDNSRefreshInterval time.Duration
+	peerDispatchTimeout            time.Duration
+	peerDispatchPresharedKey       string
+	peerDispatchDialOptions        []grpc.DialOption
+	peerDispatchCheckResolver      *graph.PeerDispatchCheckResolver
+	stopWatchingPeerDispatchDNS    func()
+
+	tupleMaxObjectLength int
+	tupleMaxUserLength   int
+	tupleIDPatterns      []string
+	tupleKeyRules        *validation.TupleKeyRules
+
+	timeTravelEnabled    bool
+	timeTravelMaxChanges int
+	timeTravelRetention  time.Duration
+
+	tupleChangeNotifier      storage.TupleChangeNotifier
+	stopWatchingTupleChanges func()
 }
 
 type OpenFGAServiceV1Option func(s *Server)
@@ -180,6 +385,16 @@ func WithResolveNodeBreadthLimit(limit uint32) OpenFGAServiceV1Option {
 	}
 }
 
+// WithCheckUsersetsPageSize sets the number of usersets of an object that a Check reads from the
+// datastore at once, e.g. the groups of a document. The usersets of a page are resolved before the
+// next page is read, so that a Check that is allowed by one of the first usersets of an object with a
+// huge number of them doesn't read them all. If 0, all the usersets are read at once.
+func WithCheckUsersetsPageSize(pageSize uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkUsersetsPageSize = pageSize
+	}
+}
+
 // WithChangelogHorizonOffset sets an offset (in minutes) from the current time.
 // Changes that occur after this offset will not be included in the response of ReadChanges API.
 // If your datastore is eventually consistent or if you have a database with replication delay, we recommend setting this (e.g. 1 minute).
@@ -189,6 +404,16 @@ func WithChangelogHorizonOffset(offset int) OpenFGAServiceV1Option {
 	}
 }
 
+// WithChangelogContinuationTokenLifetime sets how long a continuation token of ReadChanges is valid
+// after the last change it was returned for. An older token is rejected with a
+// ContinuationTokenExpired error, as the changes after it may have been removed from the changelog.
+// 0 means that the time travel retention is used, and that the tokens never expire without one.
+func WithChangelogContinuationTokenLifetime(lifetime time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.changelogTokenLifetime = lifetime
+	}
+}
+
 // WithListObjectsDeadline affect the ListObjects API and Streamed ListObjects API only.
 // It sets the maximum amount of time that the server will spend gathering results.
 func WithListObjectsDeadline(deadline time.Duration) OpenFGAServiceV1Option {
@@ -205,6 +430,24 @@ func WithListObjectsMaxResults(limit uint32) OpenFGAServiceV1Option {
 	}
 }
 
+// WithListObjectsShards affects the ListObjects API and Streamed ListObjects API only.
+// It sets the number of worker pools that the objects needing a Check are partitioned into by the
+// hash of their IDs, so that the Checks of the objects of wide types are spread over the pools.
+func WithListObjectsShards(shards uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsShards = shards
+	}
+}
+
+// WithListObjectsShardConcurrency affects the ListObjects API and Streamed ListObjects API only.
+// It sets the number of concurrent Checks of each worker pool. If 0, it is the resolve node
+// breadth limit.
+func WithListObjectsShardConcurrency(concurrency uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsShardConcurrency = concurrency
+	}
+}
+
 // WithMaxConcurrentReadsForListObjects sets a limit on the number of datastore reads that can be in flight for a given ListObjects call.
 // This number should be set depending on the RPS expected for Check and ListObjects APIs, the number of OpenFGA replicas running,
 // and the number of connections the datastore allows.
@@ -237,6 +480,25 @@ func WithExperimentals(experimentals ...ExperimentalFeatureFlag) OpenFGAServiceV
 	}
 }
 
+// WithFeatureFlags sets the feature flags that gate the experimental behaviors per store: the check
+// planner, the sharding of ListObjects and the consistency tokens. By default, they are on for all
+// the stores. The flags can be changed while the server runs.
+func WithFeatureFlags(flags *featureflags.FeatureFlags) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.featureFlags = flags
+	}
+}
+
+// WithCheckResolverChain customizes the chain of resolvers that Check and ListObjects resolve
+// the Check subproblems with. The function is given the default chain, from the cycle detection
+// resolver down to the local checker, and returns the chain to use, e.g. with custom resolvers
+// built with checkresolver.NewInterceptor inserted between them. See checkresolver.NewChain.
+func WithCheckResolverChain(chain func([]checkresolver.DelegatingCheckResolver) []checkresolver.DelegatingCheckResolver) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkResolverChain = chain
+	}
+}
+
 // WithCheckQueryCacheEnabled enables caching of Check results for the Check and List objects APIs.
 // This cache is shared for all requests.
 // See also WithCheckQueryCacheLimit and WithCheckQueryCacheTTL.
@@ -246,6 +508,14 @@ func WithCheckQueryCacheEnabled(enabled bool) OpenFGAServiceV1Option {
 	}
 }
 
+// WithTypesystemCacheMaxSize bounds the number of TypeSystems of the authorization models, across
+// all the stores, that are kept compiled in memory.
+func WithTypesystemCacheMaxSize(size int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.typesystemCacheMaxSize = size
+	}
+}
+
 // WithCheckQueryCacheLimit sets the cache size limit (in items)
 // Needs WithCheckQueryCacheEnabled set to true.
 func WithCheckQueryCacheLimit(limit uint32) OpenFGAServiceV1Option {
@@ -262,6 +532,91 @@ func WithCheckQueryCacheTTL(ttl time.Duration) OpenFGAServiceV1Option {
 	}
 }
 
+// WithCheckQueryCacheNegativeTTL sets the TTL of cached checks whose result is not allowed, if ttl
+// isn't 0. Otherwise they are cached with the TTL of WithCheckQueryCacheTTL.
+// Needs WithCheckQueryCacheEnabled set to true.
+func WithCheckQueryCacheNegativeTTL(ttl time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheNegativeTTL = ttl
+	}
+}
+
+// WithCheckQueryCacheMaxBytes bounds the cache by the estimated number of bytes of its entries
+// instead of their number, if maxBytes isn't 0. The limit of WithCheckQueryCacheLimit is then ignored.
+// Needs WithCheckQueryCacheEnabled set to true.
+func WithCheckQueryCacheMaxBytes(maxBytes uint64) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheMaxBytes = maxBytes
+	}
+}
+
+// WithCheckQueryCacheServeStale serves the expired cached checks, if they are still in the cache,
+// instead of failing when the datastore is unavailable, e.g. because its circuit breaker is open.
+// Check responses with such results have the StaleResultHeader.
+// Needs WithCheckQueryCacheEnabled set to true.
+func WithCheckQueryCacheServeStale(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheServeStale = enabled
+	}
+}
+
+// WithCheckQueryCacheWatermarkEnabled caches the checks with the changelog watermark of their store,
+// so that they are no longer served once the tuples of the store change, whatever their TTL. The
+// watermark of a store moves with the writes of this server, with the writes notified by
+// WithTupleChangeNotifier, and with the changes read from its changelog every refresh interval.
+// Needs WithCheckQueryCacheEnabled set to true.
+func WithCheckQueryCacheWatermarkEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheWatermarkEnabled = enabled
+	}
+}
+
+// WithListObjectsCacheEnabled caches the results of the ListObjects requests by store, model, type,
+// relation, user, contextual tuples and context, and by the changelog watermark of their store, so
+// that they are no longer served once its tuples change. The watermark of a store moves like with
+// WithCheckQueryCacheWatermarkEnabled. The requests with a shard, a continuation token, partial
+// results or candidates, and the requests of a past state of a store, aren't cached.
+// See also WithListObjectsCacheLimit and WithListObjectsCacheTTL.
+func WithListObjectsCacheEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsCacheEnabled = enabled
+	}
+}
+
+// WithListObjectsCacheLimit sets the size limit (in items) of the ListObjects cache.
+// Needs WithListObjectsCacheEnabled set to true.
+func WithListObjectsCacheLimit(limit uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsCacheLimit = limit
+	}
+}
+
+// WithListObjectsCacheTTL sets the TTL of the cached ListObjects results.
+// Needs WithListObjectsCacheEnabled set to true.
+func WithListObjectsCacheTTL(ttl time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsCacheTTL = ttl
+	}
+}
+
+// WithListObjectsCacheWatermarkRefreshInterval sets how often the changelog of a store is read to
+// move its watermark, which bounds the staleness of the cached ListObjects results.
+// Needs WithListObjectsCacheEnabled set to true.
+func WithListObjectsCacheWatermarkRefreshInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.listObjectsCacheWatermarkRefreshInterval = interval
+	}
+}
+
+// WithCheckQueryCacheWatermarkRefreshInterval sets how often the changelog of a store is read to move
+// its watermark, which bounds the staleness of the cached checks.
+// Needs WithCheckQueryCacheWatermarkEnabled set to true.
+func WithCheckQueryCacheWatermarkRefreshInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkQueryCacheWatermarkRefreshInterval = interval
+	}
+}
+
 // WithRequestDurationByQueryHistogramBuckets sets the buckets used in labelling the requestDurationByQueryAndDispatchHistogram.
 func WithRequestDurationByQueryHistogramBuckets(buckets []uint) OpenFGAServiceV1Option {
 	return func(s *Server) {
@@ -284,6 +639,53 @@ func WithMaxAuthorizationModelSizeInBytes(size int) OpenFGAServiceV1Option {
 	}
 }
 
+// WithMaxRelationsPerTypeDefinition sets the maximum number of relations of each type definition of the
+// authorization models written with WriteAuthorizationModel. If 0, it isn't limited.
+func WithMaxRelationsPerTypeDefinition(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxRelationsPerTypeDefinition = limit
+	}
+}
+
+// WithMaxAuthorizationModelRewriteDepth sets the maximum nesting of the rewrite of each relation of the
+// authorization models written with WriteAuthorizationModel. If 0, it isn't limited.
+func WithMaxAuthorizationModelRewriteDepth(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxRewriteDepth = limit
+	}
+}
+
+// WithMaxConditionExpressionSizeInBytes sets the maximum size in bytes of the expression of each condition
+// of the authorization models written with WriteAuthorizationModel. If 0, it isn't limited.
+func WithMaxConditionExpressionSizeInBytes(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxConditionExpressionSize = limit
+	}
+}
+
+// WithMaxContextualTuples sets the maximum number of contextual tuples of a Check or ListObjects request.
+func WithMaxContextualTuples(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxContextualTuples = limit
+	}
+}
+
+// WithMaxContextSizeInBytes sets the maximum size in bytes of the condition context of each tuple
+// written with Write.
+func WithMaxContextSizeInBytes(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.maxContextSizeInBytes = limit
+	}
+}
+
+// WithConsistencyTokenTimeout sets the maximum amount of time a request with a consistency token
+// waits for the datastore to catch up with the write of the token.
+func WithConsistencyTokenTimeout(timeout time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.consistencyTokenTimeout = timeout
+	}
+}
+
 // WithDispatchThrottlingCheckResolverEnabled sets whether dispatch throttling is enabled.
 // Enabling this feature will prioritize dispatched requests requiring less than the configured dispatch
 // threshold over requests whose dispatch count exceeds the configured threshold.
@@ -321,6 +723,427 @@ func WithDispatchThrottlingCheckResolverMaxThreshold(maxThreshold uint32) OpenFG
 	}
 }
 
+// WithSlowRequestLogEnabled enables logging Check and ListObjects requests that take longer than
+// the slow request log threshold to resolve. See also WithSlowRequestLogThreshold.
+func WithSlowRequestLogEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.slowRequestLogEnabled = enabled
+	}
+}
+
+// WithSlowRequestLogThreshold sets the duration after which a Check or ListObjects request is logged as slow.
+// Needs WithSlowRequestLogEnabled set to true.
+func WithSlowRequestLogThreshold(threshold time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.slowRequestLogThreshold = threshold
+	}
+}
+
+// WithCheckBudgetMaxDispatchCount sets the maximum number of dispatches allowed to resolve a single
+// Check request. 0 means no limit.
+func WithCheckBudgetMaxDispatchCount(max uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkBudget.MaxDispatchCount = max
+	}
+}
+
+// WithCheckBudgetMaxDatastoreReadCount sets the maximum number of datastore reads allowed to resolve a
+// single Check request. 0 means no limit.
+func WithCheckBudgetMaxDatastoreReadCount(max uint32) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkBudget.MaxDatastoreReadCount = max
+	}
+}
+
+// WithCheckReadDeduplicationEnabled memoizes the datastore reads of each Check request, so that the
+// identical reads of the branches of its resolution only reach the datastore once.
+func WithCheckReadDeduplicationEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkReadDeduplicationEnabled = enabled
+	}
+}
+
+// WithCheckReadDeduplicationMaxTuplesPerRead sets the maximum number of tuples of a memoized read of a
+// Check request. The larger reads are repeated. Needs WithCheckReadDeduplicationEnabled set to true.
+func WithCheckReadDeduplicationMaxTuplesPerRead(max int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkReadDeduplicationMaxTuplesPerRead = max
+	}
+}
+
+// WithCheckDispatchPoolEnabled runs the concurrent dispatches of the Check resolutions on a pool of
+// reusable goroutines, instead of a new goroutine for each of them.
+func WithCheckDispatchPoolEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkDispatchPoolEnabled = enabled
+	}
+}
+
+// WithCheckDispatchPoolSize sets the maximum number of goroutines of the Check dispatch pool. The
+// dispatches that are started while all of them are busy run on new goroutines. Needs
+// WithCheckDispatchPoolEnabled set to true.
+func WithCheckDispatchPoolSize(size int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkDispatchPoolSize = size
+	}
+}
+
+// WithCheckPlannerEnabled enables the planner that chooses how Check resolves usersets and tuple to
+// userset rewrites, based on statistics about the cardinality of the relations of each store.
+// The statistics are persisted to the datastore, so that they survive restarts.
+func WithCheckPlannerEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkPlannerEnabled = enabled
+	}
+}
+
+// WithCheckPlannerStatisticsInterval sets how often the Check planner statistics are persisted to the
+// datastore, if they changed. They are also persisted when the server is closed.
+// Needs WithCheckPlannerEnabled set to true.
+func WithCheckPlannerStatisticsInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkPlannerStatisticsInterval = interval
+	}
+}
+
+// WithCheckPlannerMaxStores sets the maximum number of stores whose Check planner statistics are kept in
+// memory. The statistics of the least recently used store are dropped once it is reached.
+// Needs WithCheckPlannerEnabled set to true.
+func WithCheckPlannerMaxStores(maxStores int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkPlannerMaxStores = maxStores
+	}
+}
+
+// WithTupleStatisticsEnabled enables the background collection of approximate statistics about the
+// tuples of each store. If the Check planner is enabled, it uses them for the relations it hasn't
+// observed yet. See also TupleStatistics.
+func WithTupleStatisticsEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.tupleStatisticsEnabled = enabled
+	}
+}
+
+// WithTupleStatisticsInterval sets how often the tuple statistics are collected.
+// Needs WithTupleStatisticsEnabled set to true.
+func WithTupleStatisticsInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.tupleStatisticsInterval = interval
+	}
+}
+
+// WithTupleStatisticsMaxTuplesPerStore sets the maximum number of tuples scanned per store when the
+// tuple statistics are collected. The statistics of larger stores are sampled.
+// Needs WithTupleStatisticsEnabled set to true.
+func WithTupleStatisticsMaxTuplesPerStore(limit int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.tupleStatisticsMaxTuplesPerStore = limit
+	}
+}
+
+// WithRelationUsageEnabled enables the tracking of how often Check evaluates each relation of each
+// store, and how often it allows it. See also RelationUsage.
+func WithRelationUsageEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.relationUsageEnabled = enabled
+	}
+}
+
+// WithRelationUsageSampleRatio sets the fraction (between 0 and 1) of the relation evaluations that
+// are recorded. Needs WithRelationUsageEnabled set to true.
+func WithRelationUsageSampleRatio(ratio float64) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.relationUsageSampleRatio = ratio
+	}
+}
+
+// WithRelationUsageFlushInterval sets how often the recorded relation evaluations are added to the
+// usage kept in the datastore. Needs WithRelationUsageEnabled set to true.
+func WithRelationUsageFlushInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.relationUsageFlushInterval = interval
+	}
+}
+
+// WithIDScheme sets the scheme of the generated store and authorization model IDs, one of
+// [idgen.SchemeULID] and [idgen.SchemeUUIDv7]. It is ignored if WithIDGenerator is set.
+func WithIDScheme(scheme string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.idScheme = scheme
+	}
+}
+
+// WithIDGenerator sets the generator of the store and authorization model IDs, e.g. to derive them
+// from an external system. It takes precedence over WithIDScheme.
+func WithIDGenerator(generator idgen.Generator) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.idGenerator = generator
+	}
+}
+
+// WithWriteGroupSagaEnabled allows the write groups whose stores can't be written in a single
+// transaction to be applied one store after the other with compensations, see
+// commands.WithWriteGroupCmdSagaEnabled. If false, these groups are rejected.
+func WithWriteGroupSagaEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.writeGroupSagaEnabled = enabled
+	}
+}
+
+// WithCallerSuppliedStoreIDsEnabled allows the CreateStore requests to set the ID of the created
+// store with the StoreIDHeader, e.g. so that a store has the same ID in every environment.
+func WithCallerSuppliedStoreIDsEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.callerSuppliedStoreIDsEnabled = enabled
+	}
+}
+
+// WithWriteAdmissionWebhookURL sets the URL of the admission webhook that reviews the tuples of the
+// Write requests before they are committed, and can reject or mutate them. See [admission.Webhook].
+// If empty, the writes are not reviewed.
+func WithWriteAdmissionWebhookURL(url string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.writeAdmissionWebhookURL = url
+	}
+}
+
+// WithWriteAdmissionWebhookTimeout sets the timeout of a review by the write admission webhook.
+// Needs WithWriteAdmissionWebhookURL.
+func WithWriteAdmissionWebhookTimeout(timeout time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.writeAdmissionWebhookTimeout = timeout
+	}
+}
+
+// WithWriteAdmissionWebhookFailurePolicy sets what happens to the writes when the write admission
+// webhook fails or times out: 'fail' rejects them, and 'ignore' commits them without review.
+// Needs WithWriteAdmissionWebhookURL.
+func WithWriteAdmissionWebhookFailurePolicy(policy string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.writeAdmissionWebhookFailurePolicy = policy
+	}
+}
+
+// WithWriteCoalescingEnabled enables the coalescing of the Write requests of a store into larger
+// datastore transactions. Each request then waits up to the window of WithWriteCoalescingWindow for the
+// other requests of its store. See [storagewrappers.NewCoalescingOpenFGADatastore].
+func WithWriteCoalescingEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.writeCoalescingEnabled = enabled
+	}
+}
+
+// WithWriteCoalescingWindow sets the maximum time a Write request waits for the other requests of its
+// store. Needs WithWriteCoalescingEnabled set to true.
+func WithWriteCoalescingWindow(window time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.writeCoalescingWindow = window
+	}
+}
+
+// WithPeerDispatchEnabled enables the dispatch of the Check subproblems to the members of a cluster of
+// servers by the consistent hash of their object and relation, so that the cached result of each
+// subproblem lives on a single member. See [graph.PeerDispatchCheckResolver]. The members are those of
+// WithPeerDispatchPeers or WithPeerDispatchDNSName, and the server is WithPeerDispatchSelf among them.
+func WithPeerDispatchEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.peerDispatchEnabled = enabled
+	}
+}
+
+// WithPeerDispatchSelf sets the address of the server among the members of the cluster of the peer
+// dispatch. Needs WithPeerDispatchEnabled set to true.
+func WithPeerDispatchSelf(addr string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.peerDispatchSelf = addr
+	}
+}
+
+// WithPeerDispatchPeers sets the static list of the gRPC addresses of the members of the cluster of
+// the peer dispatch. Needs WithPeerDispatchEnabled set to true.
+func WithPeerDispatchPeers(addrs []string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.peerDispatchPeers = addrs
+	}
+}
+
+// WithPeerDispatchDNSName sets the 'host:port' name whose host resolves to the addresses of the members
+// of the cluster of the peer dispatch, e.g. a headless service, instead of a static list. Needs
+// WithPeerDispatchEnabled set to true.
+func WithPeerDispatchDNSName(name string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.peerDispatchDNSName = name
+	}
+}
+
+// WithPeerDispatchDNSRefreshInterval sets how often the DNS name of the peer dispatch is resolved
+// again. Needs WithPeerDispatchDNSName.
+func WithPeerDispatchDNSRefreshInterval(interval time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.peerDispatchDNSRefreshInterval = interval
+	}
+}
+
+// WithPeerDispatchTimeout sets the timeout of the subproblems dispatched to a peer, after which the
+// server resolves them itself. Needs WithPeerDispatchEnabled set to true.
+func WithPeerDispatchTimeout(timeout time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.peerDispatchTimeout = timeout
+	}
+}
+
+// WithPeerDispatchPresharedKey sets the key that the members of the cluster of the peer dispatch
+// authenticate the subproblems they dispatch to each other with, which must be the same on every
+// member. Needs WithPeerDispatchEnabled set to true.
+func WithPeerDispatchPresharedKey(key string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.peerDispatchPresharedKey = key
+	}
+}
+
+// WithPeerDispatchDialOptions sets the options the peers of the peer dispatch are dialed with, e.g.
+// their transport credentials. Needs WithPeerDispatchEnabled set to true.
+func WithPeerDispatchDialOptions(opts ...grpc.DialOption) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.peerDispatchDialOptions = opts
+	}
+}
+
+// WithWriteAdmitter sets an admission.Admitter that reviews the tuples of the Write requests before
+// they are committed, e.g. an in-process alternative to the admission webhook. It takes precedence
+// over WithWriteAdmissionWebhookURL.
+func WithWriteAdmitter(a admission.Admitter) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.writeAdmitter = a
+	}
+}
+
+// WithDelegatedCheckRelations delegates the Check of relations to external evaluators, with rules
+// of the form 'type#relation=url' that delegate the relation of the type to the [delegation.Webhook]
+// at the URL.
+func WithDelegatedCheckRelations(rules []string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.delegatedCheckRelations = rules
+	}
+}
+
+// WithDelegatedCheckOperator sets how the decisions of the external evaluators of the delegated
+// relations are combined with the rewrites of the relations: 'intersection', 'union' or 'override'.
+func WithDelegatedCheckOperator(operator string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.delegatedCheckOperator = operator
+	}
+}
+
+// WithDelegatedCheckTimeout sets the timeout of a decision of the webhooks of
+// WithDelegatedCheckRelations.
+func WithDelegatedCheckTimeout(timeout time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.delegatedCheckTimeout = timeout
+	}
+}
+
+// WithDelegatedCheckEvaluators delegates the Check of relations, by 'type#relation', to the
+// evaluators, e.g. in-process alternatives to the webhooks. They take precedence over the rules of
+// WithDelegatedCheckRelations for the same relations.
+func WithDelegatedCheckEvaluators(evaluators map[string]delegation.Evaluator) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.delegatedCheckEvaluators = evaluators
+	}
+}
+
+// WithCheckCanaryEnabled enables the canary evaluation of the candidate authorization models of the
+// stores, see storage.StoreSettings. The Check requests of a store with a candidate model are also
+// evaluated against it in the background, and the results that differ from those of the model of
+// the requests are logged and counted, while the requests are served the results of their model.
+func WithCheckCanaryEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkCanaryEnabled = enabled
+	}
+}
+
+// WithCheckCanarySampleRatio sets the fraction (between 0 and 1) of the Check requests of the stores
+// with a candidate model that are also evaluated against it. Needs WithCheckCanaryEnabled set to true.
+func WithCheckCanarySampleRatio(ratio float64) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkCanarySampleRatio = ratio
+	}
+}
+
+// WithCheckCanaryTimeout sets the timeout of the evaluation of a Check request against the candidate
+// model of its store. Needs WithCheckCanaryEnabled set to true.
+func WithCheckCanaryTimeout(timeout time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkCanaryTimeout = timeout
+	}
+}
+
+// WithCheckCanaryMaxConcurrentEvaluations sets the maximum number of evaluations against the
+// candidate models in progress. The Check requests sampled while it is reached aren't evaluated
+// against them. Needs WithCheckCanaryEnabled set to true.
+func WithCheckCanaryMaxConcurrentEvaluations(maxEvaluations int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.checkCanaryMaxConcurrentEvaluations = maxEvaluations
+	}
+}
+
+// WithTupleMaxObjectLength sets the maximum number of bytes of the object of the tuples written,
+// which can only be lower than the limit of the API. 0 means the limit of the API.
+func WithTupleMaxObjectLength(length int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.tupleMaxObjectLength = length
+	}
+}
+
+// WithTupleMaxUserLength sets the maximum number of bytes of the user of the tuples written,
+// which can only be lower than the limit of the API. 0 means the limit of the API.
+func WithTupleMaxUserLength(length int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.tupleMaxUserLength = length
+	}
+}
+
+// WithTupleIDPatterns sets the 'type=pattern' rules that the IDs of the objects of a type must match
+// when they are the object or the user of a tuple written. See [validation.NewTupleKeyRules].
+func WithTupleIDPatterns(rules ...string) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.tupleIDPatterns = rules
+	}
+}
+
+// WithTimeTravelEnabled sets whether the Check, ListObjects and StreamedListObjects requests with the
+// AsOfHeader are evaluated against the tuples of the store at that time.
+func WithTimeTravelEnabled(enabled bool) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.timeTravelEnabled = enabled
+	}
+}
+
+// WithTimeTravelMaxChanges sets the maximum number of changes replayed to reconstruct the tuples of
+// a store at a past time. 0 means no limit.
+func WithTimeTravelMaxChanges(maxChanges int) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.timeTravelMaxChanges = maxChanges
+	}
+}
+
+// WithTimeTravelRetention sets how long the changelog is retained. The requests evaluated at an
+// older time are rejected. 0 means that the changelog is retained forever.
+func WithTimeTravelRetention(retention time.Duration) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.timeTravelRetention = retention
+	}
+}
+
+// WithTupleChangeNotifier invalidates the Check query cache entries of the stores whose tuples are
+// written, by this or any other server, as notified by notifier. It has no effect if the Check query
+// cache is disabled.
+func WithTupleChangeNotifier(notifier storage.TupleChangeNotifier) OpenFGAServiceV1Option {
+	return func(s *Server) {
+		s.tupleChangeNotifier = notifier
+	}
+}
+
 // MustNewServerWithOpts see NewServerWithOpts.
 func MustNewServerWithOpts(opts ...OpenFGAServiceV1Option) *Server {
 	s, err := NewServerWithOpts(opts...)
@@ -341,18 +1164,32 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		changelogHorizonOffset:           serverconfig.DefaultChangelogHorizonOffset,
 		resolveNodeLimit:                 serverconfig.DefaultResolveNodeLimit,
 		resolveNodeBreadthLimit:          serverconfig.DefaultResolveNodeBreadthLimit,
+		checkUsersetsPageSize:            serverconfig.DefaultCheckUsersetsPageSize,
 		listObjectsDeadline:              serverconfig.DefaultListObjectsDeadline,
 		listObjectsMaxResults:            serverconfig.DefaultListObjectsMaxResults,
+		listObjectsShards:                serverconfig.DefaultListObjectsShards,
+		listObjectsShardConcurrency:      serverconfig.DefaultListObjectsShardConcurrency,
 		maxConcurrentReadsForCheck:       serverconfig.DefaultMaxConcurrentReadsForCheck,
 		maxConcurrentReadsForListObjects: serverconfig.DefaultMaxConcurrentReadsForListObjects,
 		maxAuthorizationModelSizeInBytes: serverconfig.DefaultMaxAuthorizationModelSizeInBytes,
+		maxContextualTuples:              serverconfig.DefaultMaxContextualTuples,
+		maxContextSizeInBytes:            serverconfig.DefaultMaxContextSizeInBytes,
+		consistencyTokenTimeout:          serverconfig.DefaultConsistencyTokenTimeout,
 		experimentals:                    make([]ExperimentalFeatureFlag, 0, 10),
+		featureFlags:                     featureflags.New(),
 
 		checkQueryCacheEnabled: serverconfig.DefaultCheckQueryCacheEnable,
 		checkQueryCacheLimit:   serverconfig.DefaultCheckQueryCacheLimit,
+		typesystemCacheMaxSize: serverconfig.DefaultTypesystemCacheMaxSize,
 		checkQueryCacheTTL:     serverconfig.DefaultCheckQueryCacheTTL,
 		checkResolver:          nil,
 
+		checkQueryCacheWatermarkRefreshInterval: serverconfig.DefaultCheckQueryCacheWatermarkRefreshInterval,
+
+		listObjectsCacheLimit:                    serverconfig.DefaultListObjectsCacheLimit,
+		listObjectsCacheTTL:                      serverconfig.DefaultListObjectsCacheTTL,
+		listObjectsCacheWatermarkRefreshInterval: serverconfig.DefaultListObjectsCacheWatermarkRefreshInterval,
+
 		requestDurationByQueryHistogramBuckets:         []uint{50, 200},
 		requestDurationByDispatchCountHistogramBuckets: []uint{50, 200},
 		serviceName: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
@@ -360,21 +1197,80 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		dispatchThrottlingCheckResolverEnabled:   serverconfig.DefaultDispatchThrottlingEnabled,
 		dispatchThrottlingCheckResolverFrequency: serverconfig.DefaultDispatchThrottlingFrequency,
 		dispatchThrottlingDefaultThreshold:       serverconfig.DefaultDispatchThrottlingDefaultThreshold,
+
+		checkReadDeduplicationEnabled:          serverconfig.DefaultCheckReadDeduplicationEnabled,
+		checkReadDeduplicationMaxTuplesPerRead: serverconfig.DefaultCheckReadDeduplicationMaxTuplesPerRead,
+		checkDispatchPoolEnabled:               serverconfig.DefaultCheckDispatchPoolEnabled,
+		checkDispatchPoolSize:                  serverconfig.DefaultCheckDispatchPoolSize,
+
+		checkPlannerStatisticsInterval: serverconfig.DefaultCheckPlannerStatisticsInterval,
+		checkPlannerMaxStores:          serverconfig.DefaultCheckPlannerMaxStores,
+
+		tupleStatisticsInterval:          serverconfig.DefaultTupleStatisticsInterval,
+		tupleStatisticsMaxTuplesPerStore: serverconfig.DefaultTupleStatisticsMaxTuplesPerStore,
+
+		relationUsageSampleRatio:   serverconfig.DefaultRelationUsageSampleRatio,
+		relationUsageFlushInterval: serverconfig.DefaultRelationUsageFlushInterval,
+
+		idScheme:                      serverconfig.DefaultIDGenerationScheme,
+		callerSuppliedStoreIDsEnabled: serverconfig.DefaultIDGenerationCallerSuppliedStoreIDs,
+		writeGroupSagaEnabled:         serverconfig.DefaultWriteGroupsSagaEnabled,
+
+		writeAdmissionWebhookTimeout:       serverconfig.DefaultWriteAdmissionWebhookTimeout,
+		writeAdmissionWebhookFailurePolicy: serverconfig.DefaultWriteAdmissionWebhookFailurePolicy,
+
+		delegatedCheckOperator: serverconfig.DefaultDelegatedCheckOperator,
+		delegatedCheckTimeout:  serverconfig.DefaultDelegatedCheckTimeout,
+
+		checkCanarySampleRatio:              serverconfig.DefaultCheckCanarySampleRatio,
+		checkCanaryTimeout:                  serverconfig.DefaultCheckCanaryTimeout,
+		checkCanaryMaxConcurrentEvaluations: serverconfig.DefaultCheckCanaryMaxConcurrentEvaluations,
+
+		writeCoalescingWindow: serverconfig.DefaultWriteCoalescingWindow,
+
+		peerDispatchDNSRefreshInterval: serverconfig.DefaultPeerDispatchDNSRefreshInterval,
+		peerDispatchTimeout:            serverconfig.DefaultPeerDispatchTimeout,
+
+		timeTravelMaxChanges: serverconfig.DefaultTimeTravelMaxChanges,
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
-	cycleDetectionCheckResolver := graph.NewCycleDetectionCheckResolver()
-	s.checkResolver = cycleDetectionCheckResolver
+	if s.idGenerator == nil {
+		generator, err := idgen.New(s.idScheme)
+		if err != nil {
+			return nil, err
+		}
+		s.idGenerator = generator
+	}
 
-	localChecker := graph.NewLocalChecker(
+	graphLogger := logger.ForModule(s.logger, logger.ModuleGraph)
+
+	localCheckerOpts := []graph.LocalCheckerOption{
 		graph.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
-	)
+		graph.WithLocalCheckerLogger(graphLogger),
+		graph.WithUsersetsPageSize(s.checkUsersetsPageSize),
+	}
+
+	if s.checkDispatchPoolEnabled {
+		localCheckerOpts = append(localCheckerOpts, graph.WithDispatchPoolSize(s.checkDispatchPoolSize))
+	}
+
+	if s.checkPlannerEnabled {
+		planner := graph.NewPlanner(
+			graph.WithPlannerLogger(graphLogger),
+			graph.WithPlannerStatisticsBackend(s.datastore),
+			graph.WithPlannerStatisticsInterval(s.checkPlannerStatisticsInterval),
+			graph.WithPlannerMaxStores(s.checkPlannerMaxStores),
+		)
+		s.checkPlanner = planner
+
+		localCheckerOpts = append(localCheckerOpts, graph.WithPlanner(planner))
+	}
 
-	cycleDetectionCheckResolver.SetDelegate(localChecker)
-	localChecker.SetDelegate(cycleDetectionCheckResolver)
+	resolvers := []graph.DelegatingCheckResolver{graph.NewCycleDetectionCheckResolver()}
 
 	if s.dispatchThrottlingCheckResolverEnabled {
 		dispatchThrottlingConfig := graph.DispatchThrottlingCheckResolverConfig{
@@ -393,33 +1289,134 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 			zap.Uint32("MaxThreshold", s.dispatchThrottlingMaxThreshold),
 		)
 
-		dispatchThrottlingCheckResolver := graph.NewDispatchThrottlingCheckResolver(dispatchThrottlingConfig)
-		dispatchThrottlingCheckResolver.SetDelegate(localChecker)
-		s.dispatchThrottlingCheckResolver = dispatchThrottlingCheckResolver
+		s.dispatchThrottlingCheckResolver = graph.NewDispatchThrottlingCheckResolver(dispatchThrottlingConfig)
+		resolvers = append(resolvers, s.dispatchThrottlingCheckResolver)
+	}
+
+	if s.peerDispatchEnabled {
+		if s.peerDispatchSelf == "" || s.peerDispatchPresharedKey == "" {
+			return nil, fmt.Errorf("the peer dispatch needs the address of the server and a preshared key")
+		}
+
+		if len(s.peerDispatchPeers) == 0 && s.peerDispatchDNSName == "" {
+			return nil, fmt.Errorf("the peer dispatch needs a list of peers or a DNS name")
+		}
+
+		if s.peerDispatchDNSName != "" {
+			if _, _, err := net.SplitHostPort(s.peerDispatchDNSName); err != nil {
+				return nil, fmt.Errorf("invalid peer dispatch DNS name: %w", err)
+			}
+
+			if s.peerDispatchDNSRefreshInterval <= 0 {
+				return nil, fmt.Errorf("the peer dispatch DNS refresh interval must be a positive time duration")
+			}
+		}
+
+		s.logger.Info("Enabling the dispatch of the Check subproblems to the peers",
+			zap.String("Self", s.peerDispatchSelf),
+			zap.Strings("Peers", s.peerDispatchPeers),
+			zap.String("DNSName", s.peerDispatchDNSName))
+
+		s.peerDispatchCheckResolver = graph.NewPeerDispatchCheckResolver(s.peerDispatchSelf, s.dialPeer,
+			graph.WithPeerDispatchLogger(graphLogger),
+			graph.WithPeerDispatchTimeout(s.peerDispatchTimeout),
+		)
+		resolvers = append(resolvers, s.peerDispatchCheckResolver)
+	}
+
+	if len(s.delegatedCheckRelations) > 0 || len(s.delegatedCheckEvaluators) > 0 {
+		evaluators := map[string]delegation.Evaluator{}
+		for _, rule := range s.delegatedCheckRelations {
+			relation, url, err := delegation.ParseRule(rule)
+			if err != nil {
+				return nil, err
+			}
+
+			webhook, err := delegation.NewWebhook(url, delegation.WithTimeout(s.delegatedCheckTimeout))
+			if err != nil {
+				return nil, err
+			}
+			evaluators[relation] = webhook
+		}
+		for relation, evaluator := range s.delegatedCheckEvaluators {
+			evaluators[relation] = evaluator
+		}
+
+		delegatingResolver, err := delegation.NewCheckResolver(evaluators,
+			delegation.WithOperator(delegation.Operator(s.delegatedCheckOperator)),
+			delegation.WithLogger(graphLogger),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		s.logger.Info("The Check of some relations is delegated to external evaluators",
+			zap.Int("Relations", len(evaluators)),
+			zap.String("Operator", s.delegatedCheckOperator))
+		resolvers = append(resolvers, delegatingResolver)
+	}
+
+	// the evaluations are recorded by the server that resolves them, after they were dispatched to the
+	// peers and to the external evaluators, and before the check query cache so that its hits count
+	if s.relationUsageEnabled {
+		if s.relationUsageSampleRatio <= 0 || s.relationUsageSampleRatio > 1 {
+			return nil, fmt.Errorf("the relation usage sample ratio must be greater than 0 and at most 1")
+		}
 
-		cycleDetectionCheckResolver.SetDelegate(dispatchThrottlingCheckResolver)
+		s.relationUsageTracker = relationusage.NewTracker(s.datastore,
+			relationusage.WithLogger(s.logger),
+			relationusage.WithSampleRatio(s.relationUsageSampleRatio),
+			relationusage.WithFlushInterval(s.relationUsageFlushInterval),
+		)
+		resolvers = append(resolvers, graph.NewCheckResolverInterceptor(s.recordRelationUsage))
 	}
 
 	if s.checkQueryCacheEnabled {
 		s.logger.Info("Check query cache is enabled and may lead to stale query results up to the configured query cache TTL",
 			zap.Duration("CheckQueryCacheTTL", s.checkQueryCacheTTL),
-			zap.Uint32("CheckQueryCacheLimit", s.checkQueryCacheLimit))
+			zap.Duration("CheckQueryCacheNegativeTTL", s.checkQueryCacheNegativeTTL),
+			zap.Uint32("CheckQueryCacheLimit", s.checkQueryCacheLimit),
+			zap.Uint64("CheckQueryCacheMaxBytes", s.checkQueryCacheMaxBytes),
+			zap.Bool("CheckQueryCacheServeStale", s.checkQueryCacheServeStale))
 
-		cachedCheckResolver := graph.NewCachedCheckResolver(
+		cacheOpts := []graph.CachedCheckResolverOpt{
 			graph.WithMaxCacheSize(int64(s.checkQueryCacheLimit)),
-			graph.WithLogger(s.logger),
+			graph.WithMaxCacheBytes(int64(s.checkQueryCacheMaxBytes)),
+			graph.WithLogger(graphLogger),
 			graph.WithCacheTTL(s.checkQueryCacheTTL),
-		)
-		s.cachedCheckResolver = cachedCheckResolver
+			graph.WithNegativeCacheTTL(s.checkQueryCacheNegativeTTL),
+		}
+		if s.checkQueryCacheServeStale {
+			cacheOpts = append(cacheOpts, graph.WithServeStaleOnUnavailable())
+		}
+		if s.checkQueryCacheWatermarkEnabled {
+			if s.checkQueryCacheWatermarkRefreshInterval <= 0 {
+				return nil, fmt.Errorf("the check query cache watermark refresh interval must be a positive time duration")
+			}
 
-		cachedCheckResolver.SetDelegate(localChecker)
-		if s.dispatchThrottlingCheckResolver != nil {
-			s.dispatchThrottlingCheckResolver.SetDelegate(cachedCheckResolver)
-		} else {
-			cycleDetectionCheckResolver.SetDelegate(cachedCheckResolver)
+			s.logger.Info("Check query cache entries are keyed by the changelog watermark of their store",
+				zap.Duration("CheckQueryCacheWatermarkRefreshInterval", s.checkQueryCacheWatermarkRefreshInterval))
+
+			s.changelogWatermarks = graph.NewChangelogWatermarks(s.datastore, s.checkQueryCacheWatermarkRefreshInterval)
+			cacheOpts = append(cacheOpts, graph.WithChangelogWatermarks(s.changelogWatermarks))
 		}
+		s.cachedCheckResolver = graph.NewCachedCheckResolver(cacheOpts...)
+		resolvers = append(resolvers, s.cachedCheckResolver)
+	}
+
+	resolvers = append(resolvers, graph.NewLocalChecker(localCheckerOpts...))
+
+	if s.checkResolverChain != nil {
+		resolvers = s.checkResolverChain(resolvers)
 	}
 
+	checkResolver, checkResolverCloser, err := graph.NewResolverChain(resolvers...)
+	if err != nil {
+		return nil, err
+	}
+	s.checkResolver = checkResolver
+	s.checkResolverCloser = checkResolverCloser
+
 	if s.datastore == nil {
 		return nil, fmt.Errorf("a datastore option must be provided")
 	}
@@ -432,33 +1429,246 @@ func NewServerWithOpts(opts ...OpenFGAServiceV1Option) (*Server, error) {
 		return nil, fmt.Errorf("request duration by dispatch count buckets must not be empty")
 	}
 
-	s.typesystemResolver, s.typesystemResolverStop = typesystem.MemoizedTypesystemResolverFunc(s.datastore)
+	if s.writeAdmitter == nil && s.writeAdmissionWebhookURL != "" {
+		webhook, err := admission.NewWebhook(s.writeAdmissionWebhookURL,
+			admission.WithTimeout(s.writeAdmissionWebhookTimeout),
+			admission.WithFailurePolicy(admission.FailurePolicy(s.writeAdmissionWebhookFailurePolicy)),
+			admission.WithLogger(s.logger),
+		)
+		if err != nil {
+			return nil, err
+		}
+		s.writeAdmitter = webhook
+	}
+
+	s.writeDatastore = s.datastore
+	if s.writeCoalescingEnabled {
+		if s.writeCoalescingWindow <= 0 {
+			return nil, fmt.Errorf("write coalescing window must be a positive time duration")
+		}
+
+		s.logger.Info("Write coalescing is enabled and may delay each Write request up to the configured window",
+			zap.Duration("WriteCoalescingWindow", s.writeCoalescingWindow))
+		s.writeDatastore = storagewrappers.NewCoalescingOpenFGADatastore(s.datastore, s.writeCoalescingWindow)
+	}
+
+	if s.tupleMaxObjectLength > 0 || s.tupleMaxUserLength > 0 || len(s.tupleIDPatterns) > 0 {
+		rules, err := validation.NewTupleKeyRules(s.tupleMaxObjectLength, s.tupleMaxUserLength, s.tupleIDPatterns)
+		if err != nil {
+			return nil, err
+		}
+		s.tupleKeyRules = rules
+	}
+
+	if s.listObjectsCacheEnabled {
+		if s.listObjectsCacheTTL <= 0 {
+			return nil, fmt.Errorf("the list objects cache TTL must be a positive time duration")
+		}
+		if s.listObjectsCacheWatermarkRefreshInterval <= 0 {
+			return nil, fmt.Errorf("the list objects cache watermark refresh interval must be a positive time duration")
+		}
+
+		s.logger.Info("ListObjects cache is enabled and may lead to stale query results up to the configured watermark refresh interval",
+			zap.Uint32("ListObjectsCacheLimit", s.listObjectsCacheLimit),
+			zap.Duration("ListObjectsCacheTTL", s.listObjectsCacheTTL),
+			zap.Duration("ListObjectsCacheWatermarkRefreshInterval", s.listObjectsCacheWatermarkRefreshInterval))
+
+		s.listObjectsCache = ccache.New(ccache.Configure[*cachedListObjects]().MaxSize(int64(s.listObjectsCacheLimit)))
+		s.listObjectsCacheWatermarks = graph.NewChangelogWatermarks(s.datastore, s.listObjectsCacheWatermarkRefreshInterval)
+	}
+
+	if s.checkCanaryEnabled {
+		if s.checkCanarySampleRatio < 0 || s.checkCanarySampleRatio > 1 {
+			return nil, fmt.Errorf("the check canary sample ratio must be between 0 and 1")
+		}
+		if s.checkCanaryTimeout <= 0 {
+			return nil, fmt.Errorf("the check canary timeout must be a positive time duration")
+		}
+		if s.checkCanaryMaxConcurrentEvaluations <= 0 {
+			return nil, fmt.Errorf("the check canary maximum number of concurrent evaluations must be positive")
+		}
+
+		s.logger.Info("Check canary is enabled and evaluates the Check requests of the stores with a candidate model against it",
+			zap.Float64("CheckCanarySampleRatio", s.checkCanarySampleRatio),
+			zap.Duration("CheckCanaryTimeout", s.checkCanaryTimeout),
+			zap.Int("CheckCanaryMaxConcurrentEvaluations", s.checkCanaryMaxConcurrentEvaluations))
+		s.checkCanary = newCheckCanary(s.checkCanarySampleRatio, s.checkCanaryTimeout, s.checkCanaryMaxConcurrentEvaluations)
+	}
+
+	s.typesystemResolver = typesystem.NewMemoizedTypesystemResolver(s.datastore,
+		typesystem.WithTypesystemCacheMaxSize(s.typesystemCacheMaxSize),
+	)
+	s.storeSettingsCache = ccache.New(ccache.Configure[*storage.StoreSettings]())
+	s.storeLabelsCache = ccache.New(ccache.Configure[*cachedStoreLabels]())
+
+	if s.tupleStatisticsEnabled {
+		s.tupleStatisticsCollector = statistics.NewCollector(s.datastore,
+			statistics.WithLogger(s.logger),
+			statistics.WithInterval(s.tupleStatisticsInterval),
+			statistics.WithMaxTuplesPerStore(s.tupleStatisticsMaxTuplesPerStore),
+			statistics.WithListener(s.seedCheckPlanner),
+		)
+	}
+
+	if s.tupleChangeNotifier != nil && (s.cachedCheckResolver != nil || s.listObjectsCache != nil) {
+		s.watchTupleChanges()
+	}
+
+	if s.peerDispatchCheckResolver != nil {
+		if s.peerDispatchDNSName != "" {
+			host, port, _ := net.SplitHostPort(s.peerDispatchDNSName)
+			s.watchPeerDispatchDNS(host, port)
+		} else {
+			s.peerDispatchCheckResolver.SetPeers(s.peerDispatchPeers)
+		}
+	}
 
 	return s, nil
 }
 
 // Close releases the server resources.
 func (s *Server) Close() {
+	if s.stopWatchingTupleChanges != nil {
+		s.stopWatchingTupleChanges()
+	}
+
+	if s.stopWatchingPeerDispatchDNS != nil {
+		s.stopWatchingPeerDispatchDNS()
+	}
+
+	// the canary evaluations in progress still resolve Checks
+	if s.checkCanary != nil {
+		s.checkCanary.wait()
+	}
+
+	if s.checkResolverCloser != nil {
+		s.checkResolverCloser()
+	}
+
+	if s.tupleStatisticsCollector != nil {
+		s.tupleStatisticsCollector.Close()
+	}
+
+	if s.checkPlanner != nil {
+		s.checkPlanner.Close()
+	}
+
+	if s.relationUsageTracker != nil {
+		s.relationUsageTracker.Close()
+	}
+
+	s.typesystemResolver.Stop()
+	s.storeSettingsCache.Stop()
+	s.storeLabelsCache.Stop()
+
+	if s.listObjectsCache != nil {
+		s.listObjectsCache.Stop()
+	}
+}
+
+// TupleStatistics returns the collector of the tuple statistics of the stores, or nil if their
+// collection is disabled.
+func (s *Server) TupleStatistics() *statistics.Collector {
+	return s.tupleStatisticsCollector
+}
+
+// seedCheckPlanner seeds the Check planner with the fan-out of the relations of the store and the
+// fan-in of their users, so that it can choose a strategy before it has observed the relations.
+func (s *Server) seedCheckPlanner(stats *statistics.StoreStatistics) {
+	if s.checkPlanner == nil {
+		return
+	}
+
+	for _, relation := range stats.Relations {
+		s.checkPlanner.Seed(stats.StoreID, relation.ObjectType, relation.Relation, "", relation.MeanTuplesPerObject)
+
+		for _, userType := range relation.UserTypes {
+			// the planner only looks up the usersets of object users, e.g. 'user:anne'
+			if tuple.IsTypedWildcard(userType.UserType) || tuple.IsObjectRelation(userType.UserType) {
+				continue
+			}
+			s.checkPlanner.Seed(stats.StoreID, relation.ObjectType, relation.Relation, userType.UserType, userType.MeanTuplesPerUser)
+		}
+	}
+}
+
+// Drain prepares the server to shut down. Check dispatches that are waiting in the dispatch throttling
+// queue are released so that in-flight requests can finish, and new dispatches are no longer throttled.
+func (s *Server) Drain() {
 	if s.dispatchThrottlingCheckResolver != nil {
-		s.dispatchThrottlingCheckResolver.Close()
+		s.dispatchThrottlingCheckResolver.Drain()
+	}
+}
+
+// SetCheckQueryCacheTTL changes the TTL of the Check query cache at runtime.
+// It has no effect if the Check query cache is not enabled.
+func (s *Server) SetCheckQueryCacheTTL(ttl time.Duration) {
+	if s.cachedCheckResolver == nil {
+		return
+	}
+
+	s.cachedCheckResolver.SetCacheTTL(ttl)
+}
+
+// FlushCheckQueryCache deletes the cached Check sub-problems of a store, or of every store if storeID
+// is empty. It has no effect if the Check query cache is not enabled.
+func (s *Server) FlushCheckQueryCache(storeID string) {
+	if s.cachedCheckResolver == nil {
+		return
+	}
+
+	s.cachedCheckResolver.InvalidateStore(storeID)
+}
+
+// CheckQueryCacheSize returns the number of entries of the Check query cache, or 0 if it is not enabled.
+func (s *Server) CheckQueryCacheSize() int {
+	if s.cachedCheckResolver == nil {
+		return 0
+	}
+
+	return s.cachedCheckResolver.Size()
+}
+
+// SetDispatchThrottlingConfig changes the frequency and thresholds of dispatch throttling at runtime.
+// It has no effect if dispatch throttling is not enabled.
+func (s *Server) SetDispatchThrottlingConfig(frequency time.Duration, threshold, maxThreshold uint32) error {
+	if s.dispatchThrottlingCheckResolver == nil {
+		return nil
 	}
 
-	if s.cachedCheckResolver != nil {
-		s.cachedCheckResolver.Close()
+	if frequency <= 0 {
+		return fmt.Errorf("dispatch throttling frequency must be positive")
 	}
 
-	if s.checkResolver != nil {
-		s.checkResolver.Close()
+	if maxThreshold != 0 && threshold > maxThreshold {
+		return fmt.Errorf("default dispatch throttling threshold must be equal or smaller than max dispatch threshold")
 	}
 
-	s.typesystemResolverStop()
+	s.dispatchThrottlingCheckResolver.UpdateConfig(graph.DispatchThrottlingCheckResolverConfig{
+		Frequency:        frequency,
+		DefaultThreshold: threshold,
+		MaxThreshold:     maxThreshold,
+	})
+
+	return nil
 }
 
-func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (*openfgav1.ListObjectsResponse, error) {
+func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequest) (_ *openfgav1.ListObjectsResponse, err error) {
 	start := time.Now()
 
 	targetObjectType := req.GetType()
 
+	// the slow request is logged when the request returns, also if it fails or times out
+	slow := slowRequest{
+		method:        "ListObjects",
+		storeID:       req.GetStoreId(),
+		clientID:      authn.ClientIDFromContext(ctx),
+		tupleKeyShape: tupleKeyShape(targetObjectType, req.GetRelation(), req.GetUser()),
+	}
+	defer func() {
+		s.logSlowRequest(ctx, start, slow, err)
+	}()
+
 	ctx, span := tracer.Start(ctx, "ListObjects", trace.WithAttributes(
 		attribute.String("object_type", targetObjectType),
 		attribute.String("relation", req.GetRelation()),
@@ -467,11 +1677,15 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 	defer span.End()
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
-		if err := req.Validate(); err != nil {
+		if err := validator.Validate(req); err != nil {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 	}
 
+	if err := s.validateContextualTuplesLimit(req.GetContextualTuples()); err != nil {
+		return nil, err
+	}
+
 	const methodName = "listobjects"
 
 	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
@@ -481,25 +1695,92 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 
 	storeID := req.GetStoreId()
 
+	ctx, err = s.awaitConsistencyToken(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = s.applyStoreSettings(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = s.applyFeatureFlags(ctx, storeID)
+
+	ctx, ds, err := s.asOfTupleReader(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
 	typesys, err := s.resolveTypesystem(ctx, storeID, req.GetAuthorizationModelId())
 	if err != nil {
 		return nil, err
 	}
+	slow.authorizationModelID = typesys.GetAuthorizationModelID()
+	slow.typesystemResolutionDuration = time.Since(start)
 
-	q, err := commands.NewListObjectsQuery(
-		s.datastore,
-		s.checkResolver,
+	shard, err := listObjectsShardFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	continuationToken := listObjectsContinuationTokenFromContext(ctx)
+	if continuationToken != "" && shard.Count == 0 {
+		return nil, serverErrors.ValidationError(fmt.Errorf("the %s header requires the %s header", ListObjectsContinuationTokenHeader, ListObjectsShardHeader))
+	}
+
+	partialResults, err := listObjectsPartialResultsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.listObjectsCandidatesFromContext(ctx, targetObjectType)
+	if err != nil {
+		return nil, err
+	}
+
+	// the results of a shard, of a page or of a past state of the store aren't cached
+	var cacheKey string
+	cacheable := s.listObjectsCache != nil && shard.Count == 0 && !partialResults && candidates == nil &&
+		!graph.CheckCacheDisabledFromContext(ctx)
+	if cacheable {
+		var objects []string
+		var hit bool
+		cacheKey, objects, hit = s.lookupListObjectsCache(ctx, req, typesys.GetAuthorizationModelID())
+		if hit {
+			span.SetAttributes(attribute.Bool("is_cached", true))
+			s.setRequestCostHeaders(ctx, requestCost{cacheHitCount: 1})
+
+			return &openfgav1.ListObjectsResponse{
+				Objects: objects,
+			}, nil
+		}
+	}
+
+	opts := []commands.ListObjectsQueryOption{
 		commands.WithLogger(s.logger),
 		commands.WithListObjectsDeadline(s.listObjectsDeadline),
 		commands.WithListObjectsMaxResults(s.listObjectsMaxResults),
 		commands.WithResolveNodeLimit(s.resolveNodeLimit),
 		commands.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
 		commands.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
-	)
+		commands.WithListObjectsShards(s.listObjectsShardsOf(storeID)),
+		commands.WithListObjectsShardConcurrency(s.listObjectsShardConcurrency),
+		commands.WithListObjectsShard(shard),
+		commands.WithListObjectsContinuationToken(continuationToken),
+		commands.WithListObjectsPartialResults(partialResults),
+		commands.WithListObjectsClientID(authn.ClientIDFromContext(ctx)),
+		commands.WithListObjectsQueryEncoder(s.encoder),
+	}
+	if candidates != nil {
+		opts = append(opts, commands.WithListObjectsCandidates(candidates, s.checkReadDeduplicationMaxTuplesPerRead))
+	}
+
+	q, err := commands.NewListObjectsQuery(ds, s.checkResolver, opts...)
 	if err != nil {
 		return nil, serverErrors.NewInternalError("", err)
 	}
 
+	resolutionStart := time.Now()
 	result, err := q.Execute(
 		typesystem.ContextWithTypesystem(ctx, typesys),
 		&openfgav1.ListObjectsRequest{
@@ -512,6 +1793,7 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 			Context:              req.GetContext(),
 		},
 	)
+	slow.resolutionDuration = time.Since(resolutionStart)
 	if err != nil {
 		telemetry.TraceError(span, err)
 		if errors.Is(err, condition.ErrEvaluationFailed) {
@@ -545,6 +1827,27 @@ func (s *Server) ListObjects(ctx context.Context, req *openfgav1.ListObjectsRequ
 		utils.Bucketize(uint(*result.ResolutionMetadata.DispatchCount), s.requestDurationByDispatchCountHistogramBuckets),
 	).Observe(float64(time.Since(start).Milliseconds()))
 
+	cost := requestCost{
+		datastoreQueryCount: *result.ResolutionMetadata.DatastoreQueryCount,
+		dispatchCount:       *result.ResolutionMetadata.DispatchCount,
+		cacheHitCount:       *result.ResolutionMetadata.CacheHitCount,
+		throttlingDuration:  time.Duration(*result.ResolutionMetadata.ThrottlingDuration),
+	}
+	s.setRequestCostHeaders(ctx, cost)
+	slow.cost = cost
+
+	if result.ContinuationToken != "" {
+		s.transport.SetHeader(ctx, ListObjectsContinuationTokenHeader, result.ContinuationToken)
+	}
+	if result.Truncated {
+		span.SetAttributes(attribute.Bool("truncated", true))
+		s.transport.SetHeader(ctx, ListObjectsTruncatedHeader, "true")
+	}
+
+	if cacheKey != "" && !result.Truncated {
+		s.listObjectsCache.Set(cacheKey, &cachedListObjects{objects: result.Objects}, s.listObjectsCacheTTL)
+	}
+
 	return &openfgav1.ListObjectsResponse{
 		Objects: result.Objects,
 	}, nil
@@ -562,11 +1865,15 @@ func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest,
 	defer span.End()
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
-		if err := req.Validate(); err != nil {
+		if err := validator.Validate(req); err != nil {
 			return status.Error(codes.InvalidArgument, err.Error())
 		}
 	}
 
+	if err := s.validateContextualTuplesLimit(req.GetContextualTuples()); err != nil {
+		return err
+	}
+
 	const methodName = "streamedlistobjects"
 
 	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
@@ -576,13 +1883,34 @@ func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest,
 
 	storeID := req.GetStoreId()
 
+	ctx, err := s.awaitConsistencyToken(ctx, storeID)
+	if err != nil {
+		return err
+	}
+
+	ctx, err = s.applyStoreSettings(ctx, storeID)
+	if err != nil {
+		return err
+	}
+	ctx = s.applyFeatureFlags(ctx, storeID)
+
+	ctx, ds, err := s.asOfTupleReader(ctx, storeID)
+	if err != nil {
+		return err
+	}
+
 	typesys, err := s.resolveTypesystem(ctx, storeID, req.GetAuthorizationModelId())
 	if err != nil {
 		return err
 	}
 
+	shard, err := listObjectsShardFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	q, err := commands.NewListObjectsQuery(
-		s.datastore,
+		ds,
 		s.checkResolver,
 		commands.WithLogger(s.logger),
 		commands.WithListObjectsDeadline(s.listObjectsDeadline),
@@ -590,6 +1918,10 @@ func (s *Server) StreamedListObjects(req *openfgav1.StreamedListObjectsRequest,
 		commands.WithResolveNodeLimit(s.resolveNodeLimit),
 		commands.WithResolveNodeBreadthLimit(s.resolveNodeBreadthLimit),
 		commands.WithMaxConcurrentReads(s.maxConcurrentReadsForListObjects),
+		commands.WithListObjectsShards(s.listObjectsShardsOf(storeID)),
+		commands.WithListObjectsShardConcurrency(s.listObjectsShardConcurrency),
+		commands.WithListObjectsShard(shard),
+		commands.WithListObjectsClientID(authn.ClientIDFromContext(ctx)),
 	)
 	if err != nil {
 		return serverErrors.NewInternalError("", err)
@@ -654,16 +1986,31 @@ func (s *Server) Read(ctx context.Context, req *openfgav1.ReadRequest) (*openfga
 		Method:  "Read",
 	})
 
+	conditionName, conditionContext, err := readConditionFilterFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	q := commands.NewReadQuery(s.datastore,
 		commands.WithReadQueryLogger(s.logger),
 		commands.WithReadQueryEncoder(s.encoder),
+		commands.WithReadQueryCondition(conditionName, conditionContext),
 	)
-	return q.Execute(ctx, &openfgav1.ReadRequest{
+	resp, err := q.Execute(ctx, &openfgav1.ReadRequest{
 		StoreId:           req.GetStoreId(),
 		TupleKey:          tk,
 		PageSize:          req.GetPageSize(),
 		ContinuationToken: req.GetContinuationToken(),
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setReadTupleWritersHeader(ctx, req.GetStoreId(), resp.GetTuples()); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
 }
 
 func (s *Server) Write(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteResponse, error) {
@@ -683,27 +2030,83 @@ func (s *Server) Write(ctx context.Context, req *openfgav1.WriteRequest) (*openf
 
 	storeID := req.GetStoreId()
 
+	dryRun, err := dryRunFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	typesys, err := s.resolveTypesystem(ctx, storeID, req.GetAuthorizationModelId())
 	if err != nil {
 		return nil, err
 	}
 
 	cmd := commands.NewWriteCommand(
-		s.datastore,
+		s.writeDatastore,
 		commands.WithWriteCmdLogger(s.logger),
+		commands.WithWriteCmdAdmitter(s.writeAdmitter),
+		commands.WithWriteCmdTupleKeyRules(s.tupleKeyRules),
+		commands.WithConditionContextByteLimit(s.maxContextSizeInBytes),
 	)
-	return cmd.Execute(ctx, &openfgav1.WriteRequest{
+	writeReq := &openfgav1.WriteRequest{
 		StoreId:              storeID,
 		AuthorizationModelId: typesys.GetAuthorizationModelID(), // the resolved model id
 		Writes:               req.GetWrites(),
 		Deletes:              req.GetDeletes(),
-	})
+	}
+
+	if dryRun {
+		admitted, err := cmd.DryRun(ctx, writeReq)
+		if err != nil {
+			return nil, err
+		}
+
+		s.transport.SetHeader(ctx, DryRunWriteCountHeader, strconv.Itoa(len(admitted.GetWrites().GetTupleKeys())))
+		s.transport.SetHeader(ctx, DryRunDeleteCountHeader, strconv.Itoa(len(admitted.GetDeletes().GetTupleKeys())))
+
+		return &openfgav1.WriteResponse{}, nil
+	}
+
+	// the consistency token names the first change of the write, so that the requests with it can tell
+	// whether the datastore reads the write
+	changeID := ulid.Make().String()
+	resp, err := cmd.Execute(storage.ContextWithChangeULID(contextWithWriter(ctx), changeID), writeReq)
+	if err != nil {
+		return nil, err
+	}
+
+	s.setConsistencyToken(ctx, storeID, changeID)
+	s.invalidateWatermarks(storeID)
+
+	return resp, nil
+}
+
+// invalidateWatermarks invalidates the watermarks of the changelog of a store after a write, so that
+// the checks of this server see its own writes.
+func (s *Server) invalidateWatermarks(storeID string) {
+	if s.changelogWatermarks != nil {
+		s.changelogWatermarks.Invalidate(storeID)
+	}
+	if s.listObjectsCacheWatermarks != nil {
+		s.listObjectsCacheWatermarks.Invalidate(storeID)
+	}
 }
 
-func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openfgav1.CheckResponse, error) {
+func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (_ *openfgav1.CheckResponse, err error) {
 	start := time.Now()
 
 	tk := req.GetTupleKey()
+
+	// the slow request is logged when the request returns, also if it fails or times out
+	slow := slowRequest{
+		method:        "Check",
+		storeID:       req.GetStoreId(),
+		clientID:      authn.ClientIDFromContext(ctx),
+		tupleKeyShape: tupleKeyShape(tuple.GetType(tk.GetObject()), tk.GetRelation(), tk.GetUser()),
+	}
+	defer func() {
+		s.logSlowRequest(ctx, start, slow, err)
+	}()
+
 	ctx, span := tracer.Start(ctx, "Check", trace.WithAttributes(
 		attribute.KeyValue{Key: "store_id", Value: attribute.StringValue(req.GetStoreId())},
 		attribute.KeyValue{Key: "object", Value: attribute.StringValue(tk.GetObject())},
@@ -713,11 +2116,15 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 	defer span.End()
 
 	if !validator.RequestIsValidatedFromContext(ctx) {
-		if err := req.Validate(); err != nil {
+		if err := validator.Validate(req); err != nil {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 	}
 
+	if err := s.validateContextualTuplesLimit(req.GetContextualTuples()); err != nil {
+		return nil, err
+	}
+
 	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
 		Service: s.serviceName,
 		Method:  "Check",
@@ -725,33 +2132,69 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 
 	storeID := req.GetStoreId()
 
+	ctx, err = s.awaitConsistencyToken(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = s.applyStoreSettings(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = applyCheckCacheControl(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = s.applyFeatureFlags(ctx, storeID)
+
+	ctx, ds, err := s.asOfTupleReader(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	if checkTrace := graph.CheckTraceFromContext(ctx); checkTrace != nil {
+		ds = newCheckTraceTupleReader(ds, checkTrace)
+	}
+
 	typesys, err := s.resolveTypesystem(ctx, storeID, req.GetAuthorizationModelId())
 	if err != nil {
 		return nil, err
 	}
+	slow.authorizationModelID = typesys.GetAuthorizationModelID()
+	slow.typesystemResolutionDuration = time.Since(start)
 
 	if err := validation.ValidateUserObjectRelation(typesys, tuple.ConvertCheckRequestTupleKeyToTupleKey(tk)); err != nil {
 		return nil, serverErrors.ValidationError(err)
 	}
 
-	for _, ctxTuple := range req.GetContextualTuples().GetTupleKeys() {
+	for i, ctxTuple := range req.GetContextualTuples().GetTupleKeys() {
 		if err := validation.ValidateTuple(typesys, ctxTuple); err != nil {
-			return nil, serverErrors.HandleTupleValidateError(err)
+			return nil, serverErrors.InvalidContextualTuple(i, err)
 		}
 	}
 
 	ctx = typesystem.ContextWithTypesystem(ctx, typesys)
-	ctx = storage.ContextWithRelationshipTupleReader(ctx,
-		storagewrappers.NewBoundedConcurrencyTupleReader(
-			storagewrappers.NewCombinedTupleReader(
-				s.datastore,
-				req.GetContextualTuples().GetTupleKeys(),
-			),
-			s.maxConcurrentReadsForCheck,
+	var tupleReader storage.RelationshipTupleReader = storagewrappers.NewBoundedConcurrencyTupleReader(
+		storagewrappers.NewCombinedTupleReader(
+			ds,
+			req.GetContextualTuples().GetTupleKeys(),
 		),
+		s.maxConcurrentReadsForCheck,
 	)
+	if s.checkReadDeduplicationEnabled {
+		// the reads waiting for an identical read don't hold a slot of the bounded concurrency
+		tupleReader = storagewrappers.NewDeduplicatingTupleReader(tupleReader, s.checkReadDeduplicationMaxTuplesPerRead)
+	}
+	ctx = storage.ContextWithRelationshipTupleReader(ctx, tupleReader)
+
+	// a subproblem dispatched by a peer goes on with the depth and the visited subproblems of the peer
+	ctx, depth, visitedPaths, err := s.peerDispatchFromContext(ctx, tuple.ConvertCheckRequestTupleKeyToTupleKey(tk))
+	if err != nil {
+		return nil, err
+	}
 
-	checkRequestMetadata := graph.NewCheckRequestMetadata(s.resolveNodeLimit)
+	checkRequestMetadata := graph.NewCheckRequestMetadata(depth)
+	checkRequestMetadata.Budget = s.checkBudget
+	checkRequestMetadata.ClientID = authn.ClientIDFromContext(ctx)
 
 	resolveCheckRequest := graph.ResolveCheckRequest{
 		StoreID:              req.GetStoreId(),
@@ -760,23 +2203,18 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 		ContextualTuples:     req.GetContextualTuples().GetTupleKeys(),
 		Context:              req.GetContext(),
 		RequestMetadata:      checkRequestMetadata,
+		VisitedPaths:         visitedPaths,
 	}
 
+	resolutionStart := time.Now()
 	resp, err := s.checkResolver.ResolveCheck(ctx, &resolveCheckRequest)
+	slow.resolutionDuration = time.Since(resolutionStart)
 	if err != nil {
 		telemetry.TraceError(span, err)
-		if errors.Is(err, graph.ErrResolutionDepthExceeded) {
-			return nil, serverErrors.AuthorizationModelResolutionTooComplex
-		}
-
 		if errors.Is(err, condition.ErrEvaluationFailed) {
 			return nil, serverErrors.ValidationError(err)
 		}
 
-		if errors.Is(err, context.DeadlineExceeded) && resolveCheckRequest.GetRequestMetadata().WasThrottled.Load() {
-			return nil, serverErrors.ThrottledTimeout
-		}
-
 		return nil, serverErrors.HandleError("", err)
 	}
 
@@ -800,12 +2238,35 @@ func (s *Server) Check(ctx context.Context, req *openfgav1.CheckRequest) (*openf
 		methodName,
 	).Observe(dispatchCount)
 
+	cost := requestCost{
+		datastoreQueryCount: resp.GetResolutionMetadata().DatastoreQueryCount,
+		dispatchCount:       rawDispatchCount,
+		cacheHitCount:       checkRequestMetadata.CacheHitCounter.Load(),
+		throttlingDuration:  time.Duration(checkRequestMetadata.ThrottlingDuration.Load()),
+	}
+	s.setRequestCostHeaders(ctx, cost)
+	slow.cost = cost
+
+	if checkRequestMetadata.ServedStale.Load() {
+		span.SetAttributes(attribute.Bool("served_stale", true))
+		s.transport.SetHeader(ctx, StaleResultHeader, "true")
+	}
+
 	res := &openfgav1.CheckResponse{
 		Allowed: resp.Allowed,
 	}
 
 	span.SetAttributes(attribute.KeyValue{Key: "allowed", Value: attribute.BoolValue(res.GetAllowed())})
 
+	if cycle := resp.GetCycle(); !res.GetAllowed() && len(cycle) > 0 {
+		s.transport.SetHeader(ctx, ResolutionCycleHeader, strings.Join(cycle, " -> "))
+	}
+
+	// the subproblems dispatched by a peer aren't requests of a client
+	if visitedPaths == nil {
+		s.evaluateCheckCanary(ctx, req, typesys, ds, res.GetAllowed())
+	}
+
 	requestDurationHistogram.WithLabelValues(
 		s.serviceName,
 		methodName,
@@ -842,7 +2303,16 @@ func (s *Server) Expand(ctx context.Context, req *openfgav1.ExpandRequest) (*ope
 		return nil, err
 	}
 
-	q := commands.NewExpandQuery(s.datastore, commands.WithExpandQueryLogger(s.logger))
+	contextualTuples, conditionContext, err := s.expandContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := commands.NewExpandQuery(s.datastore,
+		commands.WithExpandQueryLogger(s.logger),
+		commands.WithExpandQueryContextualTuples(contextualTuples),
+		commands.WithExpandQueryContext(conditionContext),
+	)
 	return q.Execute(ctx, &openfgav1.ExpandRequest{
 		StoreId:              storeID,
 		AuthorizationModelId: typesys.GetAuthorizationModelID(), // the resolved model id
@@ -868,7 +2338,17 @@ func (s *Server) ReadAuthorizationModel(ctx context.Context, req *openfgav1.Read
 	})
 
 	q := commands.NewReadAuthorizationModelQuery(s.datastore, commands.WithReadAuthModelQueryLogger(s.logger))
-	return q.Execute(ctx, req)
+	res, err := q.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// authorization models are immutable, so their ID is a strong entity tag
+	if s.notModified(ctx, strconv.Quote(res.GetAuthorizationModel().GetId())) {
+		return &openfgav1.ReadAuthorizationModelResponse{}, nil
+	}
+
+	return res, nil
 }
 
 func (s *Server) WriteAuthorizationModel(ctx context.Context, req *openfgav1.WriteAuthorizationModelRequest) (*openfgav1.WriteAuthorizationModelResponse, error) {
@@ -889,6 +2369,11 @@ func (s *Server) WriteAuthorizationModel(ctx context.Context, req *openfgav1.Wri
 	c := commands.NewWriteAuthorizationModelCommand(s.datastore,
 		commands.WithWriteAuthModelLogger(s.logger),
 		commands.WithWriteAuthModelMaxSizeInBytes(s.maxAuthorizationModelSizeInBytes),
+		commands.WithWriteAuthModelMaxRelationsPerTypeDefinition(s.maxRelationsPerTypeDefinition),
+		commands.WithWriteAuthModelMaxRewriteDepth(s.maxRewriteDepth),
+		commands.WithWriteAuthModelMaxConditionExpressionSize(s.maxConditionExpressionSize),
+		commands.WithWriteAuthModelTypesystemCache(s.typesystemResolver),
+		commands.WithWriteAuthModelIDGenerator(s.idGenerator),
 	)
 	res, err := c.Execute(ctx, req)
 	if err != nil {
@@ -919,7 +2404,16 @@ func (s *Server) ReadAuthorizationModels(ctx context.Context, req *openfgav1.Rea
 		commands.WithReadAuthModelsQueryLogger(s.logger),
 		commands.WithReadAuthModelsQueryEncoder(s.encoder),
 	)
-	return c.Execute(ctx, req)
+	res, err := c.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.notModified(ctx, authorizationModelsETag(res)) {
+		return &openfgav1.ReadAuthorizationModelsResponse{}, nil
+	}
+
+	return res, nil
 }
 
 func (s *Server) WriteAssertions(ctx context.Context, req *openfgav1.WriteAssertionsRequest) (*openfgav1.WriteAssertionsResponse, error) {
@@ -1000,12 +2494,38 @@ func (s *Server) ReadChanges(ctx context.Context, req *openfgav1.ReadChangesRequ
 		Method:  "ReadChanges",
 	})
 
+	filter, err := readChangesFilterFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(
+		attribute.String("object_id_prefix", filter.ObjectIDPrefix),
+		attribute.String("relation", filter.Relation),
+		attribute.String("user", filter.User),
+	)
+
+	tokenLifetime := s.changelogTokenLifetime
+	if tokenLifetime == 0 {
+		tokenLifetime = s.timeTravelRetention
+	}
+
 	q := commands.NewReadChangesQuery(s.datastore,
 		commands.WithReadChangesQueryLogger(s.logger),
 		commands.WithReadChangesQueryEncoder(s.encoder),
 		commands.WithReadChangeQueryHorizonOffset(s.changelogHorizonOffset),
+		commands.WithReadChangesQueryFilter(filter),
+		commands.WithReadChangesQueryTokenLifetime(tokenLifetime),
 	)
-	return q.Execute(ctx, req)
+	resp, err := q.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setReadChangesWritersHeader(ctx, req.GetStoreId(), resp.GetChanges()); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
 }
 
 func (s *Server) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequest) (*openfgav1.CreateStoreResponse, error) {
@@ -1023,13 +2543,29 @@ func (s *Server) CreateStore(ctx context.Context, req *openfgav1.CreateStoreRequ
 		Method:  "CreateStore",
 	})
 
-	c := commands.NewCreateStoreCommand(s.datastore, commands.WithCreateStoreCmdLogger(s.logger))
+	labels, err := storeLabelsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	storeID, err := s.storeIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := commands.NewCreateStoreCommand(s.datastore,
+		commands.WithCreateStoreCmdLogger(s.logger),
+		commands.WithCreateStoreCmdLabels(labels),
+		commands.WithCreateStoreCmdIDGenerator(s.idGenerator),
+		commands.WithCreateStoreCmdID(storeID),
+	)
 	res, err := c.Execute(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
 	s.transport.SetHeader(ctx, httpmiddleware.XHttpCode, strconv.Itoa(http.StatusCreated))
+	s.setStoreLabelsHeader(ctx, labels)
 
 	return res, nil
 }
@@ -1076,7 +2612,60 @@ func (s *Server) GetStore(ctx context.Context, req *openfgav1.GetStoreRequest) (
 	})
 
 	q := commands.NewGetStoreQuery(s.datastore, commands.WithGetStoreQueryLogger(s.logger))
-	return q.Execute(ctx, req)
+	res, err := q.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := s.readStoreLabels(ctx, req.GetStoreId())
+	if err != nil {
+		return nil, err
+	}
+	s.setStoreLabelsHeader(ctx, labels)
+
+	return res, nil
+}
+
+func (s *Server) UpdateStore(ctx context.Context, req *openfgav1.UpdateStoreRequest) (*openfgav1.UpdateStoreResponse, error) {
+	ctx, span := tracer.Start(ctx, "UpdateStore")
+	defer span.End()
+
+	if !validator.RequestIsValidatedFromContext(ctx) {
+		if err := req.Validate(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "UpdateStore",
+	})
+
+	labels, err := storeLabelsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := commands.NewUpdateStoreCommand(s.datastore,
+		commands.WithUpdateStoreCmdLogger(s.logger),
+		commands.WithUpdateStoreCmdLabels(labels),
+	)
+	res, err := cmd.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if labels != nil {
+		s.storeLabelsCache.Delete(req.GetStoreId())
+	} else {
+		labels, err = s.readStoreLabels(ctx, req.GetStoreId())
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.setStoreLabelsHeader(ctx, labels)
+
+	return res, nil
 }
 
 func (s *Server) ListStores(ctx context.Context, req *openfgav1.ListStoresRequest) (*openfgav1.ListStoresResponse, error) {
@@ -1094,11 +2683,26 @@ func (s *Server) ListStores(ctx context.Context, req *openfgav1.ListStoresReques
 		Method:  "ListStores",
 	})
 
+	filter, err := listStoresFilterFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	q := commands.NewListStoresQuery(s.datastore,
 		commands.WithListStoresQueryLogger(s.logger),
 		commands.WithListStoresQueryEncoder(s.encoder),
+		commands.WithListStoresQueryFilter(filter),
 	)
-	return q.Execute(ctx, req)
+	res, err := q.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setListStoresLabelsHeader(ctx, res.GetStores()); err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
 // IsReady reports whether the datastore is ready. Please see the implementation of [[storage.OpenFGADatastore.IsReady]]
@@ -1121,13 +2725,434 @@ func (s *Server) IsReady(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
+const (
+	datastoreDependency       = "datastore"
+	migrationsDependency      = "migrations"
+	checkQueryCacheDependency = "check-query-cache"
+)
+
+// Dependencies reports the health of each dependency of the server: whether the datastore is reachable,
+// whether the datastore has the migrations it requires applied (and at which schema revision it is, which
+// revision it requires and which migration phase is pending) and, if enabled, whether the Check query
+// cache is available. It implements [health.DependencyReporter].
+func (s *Server) Dependencies(ctx context.Context) []health.DependencyStatus {
+	datastore := health.DependencyStatus{Name: datastoreDependency, Status: healthv1pb.HealthCheckResponse_SERVING}
+	migrations := health.DependencyStatus{Name: migrationsDependency, Status: healthv1pb.HealthCheckResponse_SERVING}
+
+	status, err := s.datastore.IsReady(ctx)
+	switch {
+	case err != nil:
+		datastore.Status = healthv1pb.HealthCheckResponse_NOT_SERVING
+		datastore.Message = err.Error()
+		// the migrations can't be checked without reaching the datastore
+		migrations.Status = healthv1pb.HealthCheckResponse_UNKNOWN
+	case !status.IsReady:
+		migrations.Status = healthv1pb.HealthCheckResponse_NOT_SERVING
+		migrations.Message = status.Message
+	case status.SchemaRevision > 0:
+		migrations.Message = fmt.Sprintf("at revision '%d', requires '%d'", status.SchemaRevision, build.MinimumSupportedDatastoreSchemaRevision)
+	}
+
+	// report the schema revisions so that operators can verify them before rolling out server versions
+	if err == nil && status.SchemaRevision > 0 {
+		migrations.Details = map[string]any{
+			"schema_revision":          status.SchemaRevision,
+			"required_schema_revision": build.MinimumSupportedDatastoreSchemaRevision,
+			"pending_migration_phase":  status.PendingMigrationPhase,
+		}
+	}
+
+	dependencies := []health.DependencyStatus{datastore, migrations}
+
+	if s.checkQueryCacheEnabled {
+		cache := health.DependencyStatus{Name: checkQueryCacheDependency, Status: healthv1pb.HealthCheckResponse_SERVING}
+		if s.cachedCheckResolver == nil {
+			cache.Status = healthv1pb.HealthCheckResponse_NOT_SERVING
+			cache.Message = "check query cache is not initialized"
+		}
+		dependencies = append(dependencies, cache)
+	}
+
+	return dependencies
+}
+
+// requestCost is the cost of resolving a single Check or ListObjects request.
+type requestCost struct {
+	datastoreQueryCount uint32
+	dispatchCount       uint32
+	cacheHitCount       uint32
+	throttlingDuration  time.Duration
+}
+
+// setRequestCostHeaders reports the cost of resolving a request in the response headers, so that
+// clients can attribute the cost of their own requests, and adds it to the metered usage of the store.
+func (s *Server) setRequestCostHeaders(ctx context.Context, cost requestCost) {
+	metering.AddUsage(ctx, metering.Usage{
+		DatastoreQueryCount: int64(cost.datastoreQueryCount),
+		DispatchCount:       int64(cost.dispatchCount),
+		CacheHitCount:       int64(cost.cacheHitCount),
+	})
+
+	s.transport.SetHeader(ctx, DatastoreQueryCountHeader, strconv.FormatUint(uint64(cost.datastoreQueryCount), 10))
+	s.transport.SetHeader(ctx, DispatchCountHeader, strconv.FormatUint(uint64(cost.dispatchCount), 10))
+	s.transport.SetHeader(ctx, CacheHitCountHeader, strconv.FormatUint(uint64(cost.cacheHitCount), 10))
+	s.transport.SetHeader(ctx, ThrottlingDurationHeader, strconv.FormatInt(cost.throttlingDuration.Milliseconds(), 10))
+}
+
+// authorizationModelsETag returns the entity tag of a page of authorization models. Authorization
+// models are immutable, so the page only changes if its model IDs or its continuation token change.
+func authorizationModelsETag(res *openfgav1.ReadAuthorizationModelsResponse) string {
+	hash := sha256.New()
+	for _, model := range res.GetAuthorizationModels() {
+		hash.Write([]byte(model.GetId()))
+		hash.Write([]byte{0})
+	}
+	hash.Write([]byte(res.GetContinuationToken()))
+
+	return strconv.Quote(hex.EncodeToString(hash.Sum(nil)[:16]))
+}
+
+// notModified sets the entity tag of the response, and reports whether it matches the If-None-Match
+// header of the request. If so, the response has the 304 Not Modified status code on the HTTP API.
+func (s *Server) notModified(ctx context.Context, etag string) bool {
+	s.transport.SetHeader(ctx, ETagHeader, etag)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	// the HTTP gateway forwards the If-None-Match header with its metadata prefix
+	values := append(md.Get(IfNoneMatchHeader), md.Get("grpcgateway-"+IfNoneMatchHeader)...)
+	if !etagMatches(values, etag) {
+		return false
+	}
+
+	s.transport.SetHeader(ctx, httpmiddleware.XHttpCode, strconv.Itoa(http.StatusNotModified))
+	return true
+}
+
+// validateContextualTuplesLimit returns an error if there are more contextual tuples than allowed.
+func (s *Server) validateContextualTuplesLimit(contextualTuples *openfgav1.ContextualTupleKeys) error {
+	if len(contextualTuples.GetTupleKeys()) > s.maxContextualTuples {
+		return serverErrors.ExceededEntityLimit("contextual tuples", s.maxContextualTuples)
+	}
+
+	return nil
+}
+
+// consistencyToken is the payload of the consistency tokens.
+type consistencyToken struct {
+	StoreID string `json:"store_id"`
+
+	// ChangeID is the ULID of the first change of the write.
+	ChangeID string `json:"change_id"`
+}
+
+// setConsistencyToken sets the consistency token header of a Write response to the ULID of the first
+// change of the write, if consistency tokens are on for the store.
+func (s *Server) setConsistencyToken(ctx context.Context, storeID, changeID string) {
+	if token := s.newConsistencyToken(storeID, changeID); token != "" {
+		s.transport.SetHeader(ctx, ConsistencyTokenHeader, token)
+	}
+}
+
+// newConsistencyToken returns the consistency token of the write of a store whose first change has
+// the ULID changeID, or an empty string if consistency tokens are off for the store.
+func (s *Server) newConsistencyToken(storeID, changeID string) string {
+	if !s.featureFlags.Enabled(featureflags.ConsistencyTokens, storeID) {
+		return ""
+	}
+
+	payload, err := json.Marshal(consistencyToken{StoreID: storeID, ChangeID: changeID})
+	if err != nil {
+		return ""
+	}
+
+	token, err := s.encoder.Encode(payload)
+	if err != nil {
+		return ""
+	}
+
+	return token
+}
+
+// awaitConsistencyToken waits until the datastore reads the write of the consistency token of the
+// request, if any and if consistency tokens are on for the store. The ULIDs of the changes are not
+// ordered by commit, so it waits for the change of the write itself rather than for a later one. It
+// returns a context whose Check resolutions bypass the check query cache, which may have results
+// from before that write.
+func (s *Server) awaitConsistencyToken(ctx context.Context, storeID string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(ConsistencyTokenHeader)
+	if len(values) == 0 || values[0] == "" || !s.featureFlags.Enabled(featureflags.ConsistencyTokens, storeID) {
+		return ctx, nil
+	}
+
+	payload, err := s.encoder.Decode(values[0])
+	if err != nil {
+		return nil, serverErrors.InvalidConsistencyToken
+	}
+
+	var token consistencyToken
+	if err := json.Unmarshal(payload, &token); err != nil || token.StoreID != storeID || token.ChangeID == "" {
+		return nil, serverErrors.InvalidConsistencyToken
+	}
+
+	deadline := time.Now().Add(s.consistencyTokenTimeout)
+	wait := 5 * time.Millisecond
+	for {
+		exists, err := s.datastore.ChangeExists(ctx, storeID, token.ChangeID)
+		if err != nil {
+			return nil, serverErrors.HandleError("", err)
+		}
+
+		if exists {
+			return graph.ContextWithCheckCacheBypass(ctx), nil
+		}
+
+		if !time.Now().Add(wait).Before(deadline) {
+			return nil, serverErrors.ConsistencyTokenNotSatisfied
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, serverErrors.RequestDeadlineExceeded
+			}
+			return nil, serverErrors.RequestCancelled
+		case <-time.After(wait):
+		}
+
+		wait = min(2*wait, 100*time.Millisecond)
+	}
+}
+
+// readChangesFilterFromContext returns the ReadChanges filters of the request headers.
+func readChangesFilterFromContext(ctx context.Context) (storage.ReadChangesFilter, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(header string) string {
+		if values := md.Get(header); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	filter := storage.ReadChangesFilter{
+		ObjectIDPrefix: get(ChangesObjectIDPrefixHeader),
+		Relation:       get(ChangesRelationHeader),
+		User:           get(ChangesUserHeader),
+	}
+
+	if startTime := get(ChangesStartTimeHeader); startTime != "" {
+		var err error
+		filter.StartTime, err = time.Parse(time.RFC3339Nano, startTime)
+		if err != nil {
+			return storage.ReadChangesFilter{}, serverErrors.ValidationError(fmt.Errorf("invalid %s header '%s', it must be an RFC 3339 time", ChangesStartTimeHeader, startTime))
+		}
+	}
+
+	return filter, nil
+}
+
+// readConditionFilterFromContext returns the condition name and context filters of the Read request
+// headers.
+func readConditionFilterFromContext(ctx context.Context) (string, *structpb.Struct, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var name string
+	if values := md.Get(ReadConditionNameHeader); len(values) > 0 {
+		name = values[0]
+	}
+
+	values := md.Get(ReadConditionContextHeader)
+	if len(values) == 0 || values[0] == "" {
+		return name, nil, nil
+	}
+
+	conditionContext := &structpb.Struct{}
+	if err := protojson.Unmarshal([]byte(values[0]), conditionContext); err != nil {
+		return "", nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header, it must be a JSON object: %w", ReadConditionContextHeader, err))
+	}
+
+	return name, conditionContext, nil
+}
+
+// dryRunFromContext returns whether the request has the DryRunHeader set to 'true'.
+func dryRunFromContext(ctx context.Context) (bool, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(DryRunHeader)
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, serverErrors.ValidationError(fmt.Errorf("invalid %s header '%s', it must be 'true' or 'false'", DryRunHeader, values[0]))
+	}
+
+	return dryRun, nil
+}
+
+// listObjectsShardFromContext returns the shard of the ListObjectsShardHeader of the request, or
+// the zero shard if it isn't set.
+func listObjectsShardFromContext(ctx context.Context) (commands.ListObjectsShard, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(ListObjectsShardHeader)
+	if len(values) == 0 {
+		return commands.ListObjectsShard{}, nil
+	}
+
+	shard, err := commands.ParseListObjectsShard(values[0])
+	if err != nil {
+		return commands.ListObjectsShard{}, serverErrors.ValidationError(fmt.Errorf("invalid %s header: %w", ListObjectsShardHeader, err))
+	}
+
+	return shard, nil
+}
+
+// listObjectsContinuationTokenFromContext returns the ListObjectsContinuationTokenHeader of the
+// request, or "" if it isn't set.
+func listObjectsContinuationTokenFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(ListObjectsContinuationTokenHeader); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// listObjectsCandidatesFromContext returns the IDs of the ListObjectsCandidatesHeader of the request
+// for the objects of the type, or nil if it isn't set.
+func (s *Server) listObjectsCandidatesFromContext(ctx context.Context, objectType string) ([]string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(ListObjectsCandidatesHeader)
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	candidates := make([]string, 0)
+	for _, value := range values {
+		for _, id := range strings.Split(value, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+
+			if !tuple.IsValidObject(tuple.BuildObject(objectType, id)) {
+				return nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header: invalid object ID '%s'", ListObjectsCandidatesHeader, id))
+			}
+			candidates = append(candidates, id)
+		}
+	}
+
+	if s.listObjectsMaxResults > 0 && len(candidates) > int(s.listObjectsMaxResults) {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header: more than %d candidates", ListObjectsCandidatesHeader, s.listObjectsMaxResults))
+	}
+
+	return candidates, nil
+}
+
+// listObjectsPartialResultsFromContext returns whether the request has the
+// ListObjectsPartialResultsHeader set to 'true'.
+func listObjectsPartialResultsFromContext(ctx context.Context) (bool, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(ListObjectsPartialResultsHeader)
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	partialResults, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, serverErrors.ValidationError(fmt.Errorf("invalid %s header '%s', it must be 'true' or 'false'", ListObjectsPartialResultsHeader, values[0]))
+	}
+
+	return partialResults, nil
+}
+
+// etagMatches reports whether any of the If-None-Match header values matches the entity tag, using
+// the weak comparison of RFC 9110.
+func etagMatches(ifNoneMatch []string, etag string) bool {
+	for _, value := range ifNoneMatch {
+		for _, candidate := range strings.Split(value, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// slowRequest describes a resolved Check or ListObjects request for the slow request log.
+type slowRequest struct {
+	method               string
+	storeID              string
+	clientID             string
+	authorizationModelID string
+	tupleKeyShape        string
+	cost                 requestCost
+
+	typesystemResolutionDuration time.Duration
+	resolutionDuration           time.Duration
+}
+
+// logSlowRequest logs the request if the slow request log is enabled and the request took longer than
+// the slow request log threshold since start. The error of a failed request is logged with it.
+func (s *Server) logSlowRequest(ctx context.Context, start time.Time, req slowRequest, err error) {
+	if !s.slowRequestLogEnabled {
+		return
+	}
+
+	duration := time.Since(start)
+	if duration < s.slowRequestLogThreshold {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("method", req.method),
+		zap.String("store_id", req.storeID),
+		zap.String("client_id", req.clientID),
+		zap.String(authorizationModelIDKey, req.authorizationModelID),
+		zap.String("tuple_key_shape", req.tupleKeyShape),
+		zap.Uint32(dispatchCountHistogramName, req.cost.dispatchCount),
+		zap.Uint32(datastoreQueryCountHistogramName, req.cost.datastoreQueryCount),
+		zap.Uint32("cache_hit_count", req.cost.cacheHitCount),
+		zap.Int64("duration_ms", duration.Milliseconds()),
+		zap.Int64("typesystem_resolution_ms", req.typesystemResolutionDuration.Milliseconds()),
+		zap.Int64("resolution_ms", req.resolutionDuration.Milliseconds()),
+		zap.Int64("throttling_ms", req.cost.throttlingDuration.Milliseconds()),
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+
+	s.logger.WarnWithContext(ctx, "slow request", fields...)
+}
+
+// tupleKeyShape returns the shape of a tuple key, which is the tuple key without the object and user IDs
+// (e.g. "document#viewer@group#member" for "document:1#viewer@group:eng#member").
+func tupleKeyShape(objectType, relation, user string) string {
+	userShape := user
+	if !tuple.IsTypedWildcard(user) {
+		userObject, userRelation := tuple.SplitObjectRelation(user)
+		userShape = tuple.GetType(userObject)
+		if userRelation != "" {
+			userShape = tuple.ToObjectRelationString(userShape, userRelation)
+		}
+	}
+
+	return fmt.Sprintf("%s#%s@%s", objectType, relation, userShape)
+}
+
 // resolveTypesystem resolves the underlying TypeSystem given the storeID and modelID and
 // it sets some response metadata based on the model resolution.
 func (s *Server) resolveTypesystem(ctx context.Context, storeID, modelID string) (*typesystem.TypeSystem, error) {
 	ctx, span := tracer.Start(ctx, "resolveTypesystem")
 	defer span.End()
 
-	typesys, err := s.typesystemResolver(ctx, storeID, modelID)
+	modelID, err := s.defaultAuthorizationModelID(ctx, storeID, modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	typesys, err := s.typesystemResolver.Resolve(ctx, storeID, modelID)
 	if err != nil {
 		if errors.Is(err, typesystem.ErrModelNotFound) {
 			if modelID == "" {
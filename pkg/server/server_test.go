@@ -2,14 +2,17 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"math"
 	"os"
 	"path"
 	"runtime"
+	"slices"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -19,18 +22,35 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthv1pb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/openfga/openfga/cmd/migrate"
 	"github.com/openfga/openfga/cmd/util"
+	"github.com/openfga/openfga/internal/authn"
 	"github.com/openfga/openfga/internal/build"
 	"github.com/openfga/openfga/internal/graph"
 	mockstorage "github.com/openfga/openfga/internal/mocks"
 	serverconfig "github.com/openfga/openfga/internal/server/config"
+	"github.com/openfga/openfga/pkg/checkresolver"
+	"github.com/openfga/openfga/pkg/encoder"
+	"github.com/openfga/openfga/pkg/featureflags"
+	"github.com/openfga/openfga/pkg/logger"
+	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	"github.com/openfga/openfga/pkg/server/admission"
 	"github.com/openfga/openfga/pkg/server/commands"
+	"github.com/openfga/openfga/pkg/server/delegation"
 	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/health"
 	"github.com/openfga/openfga/pkg/server/test"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/memory"
@@ -149,10 +169,117 @@ func TestServerNotReadyDueToDatastoreRevision(t *testing.T) {
 			status, _ := ds.IsReady(context.Background())
 			require.Contains(t, status.Message, fmt.Sprintf("datastore requires migrations: at revision '%d', but requires '%d'.", targetVersion, build.MinimumSupportedDatastoreSchemaRevision))
 			require.False(t, status.IsReady)
+			require.Equal(t, targetVersion, status.SchemaRevision)
+			require.Equal(t, "expand", status.PendingMigrationPhase)
 		})
 	}
 }
 
+func TestServerDependencies(t *testing.T) {
+	t.Run("datastore_unreachable", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().IsReady(gomock.Any()).Return(storage.ReadinessStatus{}, errors.New("connection refused"))
+
+		s := MustNewServerWithOpts(WithDatastore(mockDatastore))
+		t.Cleanup(s.Close)
+
+		require.Equal(t, []health.DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_NOT_SERVING, Message: "connection refused"},
+			{Name: "migrations", Status: healthv1pb.HealthCheckResponse_UNKNOWN},
+		}, s.Dependencies(context.Background()))
+	})
+
+	t.Run("migrations_required", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().IsReady(gomock.Any()).Return(storage.ReadinessStatus{Message: "datastore requires migrations"}, nil)
+
+		s := MustNewServerWithOpts(WithDatastore(mockDatastore))
+		t.Cleanup(s.Close)
+
+		require.Equal(t, []health.DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+			{Name: "migrations", Status: healthv1pb.HealthCheckResponse_NOT_SERVING, Message: "datastore requires migrations"},
+		}, s.Dependencies(context.Background()))
+	})
+
+	t.Run("reports_schema_revision", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().IsReady(gomock.Any()).Return(storage.ReadinessStatus{
+			IsReady:               true,
+			SchemaRevision:        build.MinimumSupportedDatastoreSchemaRevision,
+			PendingMigrationPhase: "contract",
+		}, nil)
+
+		s := MustNewServerWithOpts(WithDatastore(mockDatastore))
+		t.Cleanup(s.Close)
+
+		revision := build.MinimumSupportedDatastoreSchemaRevision
+		require.Equal(t, []health.DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+			{
+				Name:    "migrations",
+				Status:  healthv1pb.HealthCheckResponse_SERVING,
+				Message: fmt.Sprintf("at revision '%d', requires '%d'", revision, revision),
+				Details: map[string]any{
+					"schema_revision":          revision,
+					"required_schema_revision": revision,
+					"pending_migration_phase":  "contract",
+				},
+			},
+		}, s.Dependencies(context.Background()))
+	})
+
+	t.Run("reports_schema_revision_of_outdated_schema", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().IsReady(gomock.Any()).Return(storage.ReadinessStatus{
+			Message:               "datastore requires migrations",
+			SchemaRevision:        8,
+			PendingMigrationPhase: "expand",
+		}, nil)
+
+		s := MustNewServerWithOpts(WithDatastore(mockDatastore))
+		t.Cleanup(s.Close)
+
+		require.Equal(t, []health.DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+			{
+				Name:    "migrations",
+				Status:  healthv1pb.HealthCheckResponse_NOT_SERVING,
+				Message: "datastore requires migrations",
+				Details: map[string]any{
+					"schema_revision":          int64(8),
+					"required_schema_revision": build.MinimumSupportedDatastoreSchemaRevision,
+					"pending_migration_phase":  "expand",
+				},
+			},
+		}, s.Dependencies(context.Background()))
+	})
+
+	t.Run("healthy_with_check_query_cache", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().IsReady(gomock.Any()).Return(storage.ReadinessStatus{IsReady: true}, nil)
+
+		s := MustNewServerWithOpts(WithDatastore(mockDatastore), WithCheckQueryCacheEnabled(true))
+		t.Cleanup(s.Close)
+
+		require.Equal(t, []health.DependencyStatus{
+			{Name: "datastore", Status: healthv1pb.HealthCheckResponse_SERVING},
+			{Name: "migrations", Status: healthv1pb.HealthCheckResponse_SERVING},
+			{Name: "check-query-cache", Status: healthv1pb.HealthCheckResponse_SERVING},
+		}, s.Dependencies(context.Background()))
+	})
+}
+
 func TestServerPanicIfEmptyRequestDurationDatastoreCountBuckets(t *testing.T) {
 	require.PanicsWithError(t, "failed to construct the OpenFGA server: request duration datastore count buckets must not be empty", func() {
 		mockController := gomock.NewController(t)
@@ -967,6 +1094,10 @@ type repo
 	defer mockController.Finish()
 
 	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().
+		ReadStoreSettings(gomock.Any(), storeID).
+		AnyTimes().
+		Return(&storage.StoreSettings{}, nil)
 
 	mockDatastore.EXPECT().
 		ReadAuthorizationModel(gomock.Any(), storeID, modelID).
@@ -1009,7 +1140,18 @@ type repo
 	require.True(t, checkResponse.GetAllowed())
 }
 
-func TestWriteAssertionModelDSError(t *testing.T) {
+type headerRecordingTransport struct {
+	mu      sync.Mutex
+	headers map[string]string
+}
+
+func (h *headerRecordingTransport) SetHeader(_ context.Context, key, value string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.headers[key] = value
+}
+
+func TestCheckReportsRequestCost(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
 	})
@@ -1020,224 +1162,301 @@ func TestWriteAssertionModelDSError(t *testing.T) {
 	modelID := ulid.Make().String()
 
 	typedefs := language.MustTransformDSLToProto(`model
-	schema 1.1
+  schema 1.1
 type user
 
 type repo
   relations
 	define reader: [user]`).GetTypeDefinitions()
 
+	tk := tuple.NewCheckRequestTupleKey("repo:openfga", "reader", "user:mike")
+	returnedTuple := &openfgav1.Tuple{Key: tuple.ConvertCheckRequestTupleKeyToTupleKey(tk)}
+
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
 
-	mockDSOldSchema := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().
+		ReadStoreSettings(gomock.Any(), storeID).
+		AnyTimes().
+		Return(&storage.StoreSettings{}, nil)
 
-	mockDSOldSchema.EXPECT().
+	mockDatastore.EXPECT().
 		ReadAuthorizationModel(gomock.Any(), storeID, modelID).
 		AnyTimes().
 		Return(&openfgav1.AuthorizationModel{
-			SchemaVersion:   typesystem.SchemaVersion1_0,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
 			TypeDefinitions: typedefs,
 		}, nil)
 
-	mockDSBadReadAuthModel := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().
+		ReadUserTuple(gomock.Any(), storeID, gomock.Any()).
+		Times(1).
+		Return(returnedTuple, nil)
 
-	mockDSBadReadAuthModel.EXPECT().
-		ReadAuthorizationModel(gomock.Any(), storeID, modelID).
-		AnyTimes().
-		Return(nil, fmt.Errorf("unable to read"))
+	transport := &headerRecordingTransport{headers: map[string]string{}}
 
-	mockDSBadWriteAssertions := mockstorage.NewMockOpenFGADatastore(mockController)
-	mockDSBadWriteAssertions.EXPECT().
-		ReadAuthorizationModel(gomock.Any(), storeID, modelID).
-		AnyTimes().
-		Return(&openfgav1.AuthorizationModel{
-			SchemaVersion:   typesystem.SchemaVersion1_1,
-			TypeDefinitions: typedefs,
-		}, nil)
-	mockDSBadWriteAssertions.EXPECT().
-		WriteAssertions(gomock.Any(), storeID, modelID, gomock.Any()).
-		AnyTimes().
-		Return(fmt.Errorf("unable to write"))
+	s := MustNewServerWithOpts(
+		WithDatastore(mockDatastore),
+		WithTransport(transport),
+		WithCheckQueryCacheEnabled(true),
+		WithCheckQueryCacheLimit(10),
+		WithCheckQueryCacheTTL(1*time.Minute),
+	)
+	t.Cleanup(s.Close)
 
-	tests := []struct {
-		name          string
-		assertions    []*openfgav1.Assertion
-		mockDatastore *mockstorage.MockOpenFGADatastore
-		expectedError error
-	}{
-		{
-			name:          "unsupported_schema",
-			assertions:    []*openfgav1.Assertion{},
-			mockDatastore: mockDSOldSchema,
-			expectedError: serverErrors.ValidationError(
-				fmt.Errorf("invalid schema version"),
-			),
-		},
-		{
-			name:          "failed_to_read",
-			assertions:    []*openfgav1.Assertion{},
-			mockDatastore: mockDSBadReadAuthModel,
-			expectedError: serverErrors.NewInternalError(
-				"", fmt.Errorf("unable to read"),
-			),
-		},
-		{
-			name:          "failed_to_write",
-			assertions:    []*openfgav1.Assertion{},
-			mockDatastore: mockDSBadWriteAssertions,
-			expectedError: serverErrors.NewInternalError(
-				"", fmt.Errorf("unable to write"),
-			),
-		},
+	checkRequest := &openfgav1.CheckRequest{
+		StoreId:              storeID,
+		TupleKey:             tk,
+		AuthorizationModelId: modelID,
 	}
 
-	for _, curTest := range tests {
-		t.Run(curTest.name, func(t *testing.T) {
-			request := &openfgav1.WriteAssertionsRequest{
-				StoreId:              storeID,
-				Assertions:           curTest.assertions,
-				AuthorizationModelId: modelID,
-			}
+	_, err := s.Check(ctx, checkRequest)
+	require.NoError(t, err)
+	require.Equal(t, "1", transport.headers[DatastoreQueryCountHeader])
+	require.Equal(t, "0", transport.headers[CacheHitCountHeader])
+	require.Equal(t, "0", transport.headers[ThrottlingDurationHeader])
+	require.Contains(t, transport.headers, DispatchCountHeader)
 
-			writeAssertionCmd := commands.NewWriteAssertionsCommand(curTest.mockDatastore)
-			_, err := writeAssertionCmd.Execute(ctx, request)
-			require.ErrorIs(t, curTest.expectedError, err)
-		})
-	}
+	// the same request is now resolved from the cache
+	_, err = s.Check(ctx, checkRequest)
+	require.NoError(t, err)
+	require.Equal(t, "0", transport.headers[DatastoreQueryCountHeader])
+	require.Equal(t, "1", transport.headers[CacheHitCountHeader])
 }
 
-func TestReadAssertionModelDSError(t *testing.T) {
+func TestCheckReportsResolutionCycle(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
 	})
 
 	ctx := context.Background()
 
-	storeID := ulid.Make().String()
-	modelID := ulid.Make().String()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
 
-	mockController := gomock.NewController(t)
-	defer mockController.Finish()
+	transport := &headerRecordingTransport{headers: map[string]string{}}
 
-	mockDSBadReadAssertions := mockstorage.NewMockOpenFGADatastore(mockController)
-	mockDSBadReadAssertions.EXPECT().
-		ReadAssertions(gomock.Any(), storeID, modelID).
-		AnyTimes().
-		Return(nil, fmt.Errorf("unable to read"))
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport))
+	t.Cleanup(s.Close)
 
-	readAssertionQuery := commands.NewReadAssertionsQuery(mockDSBadReadAssertions)
-	_, err := readAssertionQuery.Execute(ctx, storeID, modelID)
-	expectedError := serverErrors.NewInternalError(
-		"", fmt.Errorf("unable to read"),
-	)
-	require.ErrorIs(t, expectedError, err)
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "cycle"})
+	require.NoError(t, err)
+
+	model, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:       store.GetId(),
+		SchemaVersion: typesystem.SchemaVersion1_1,
+		TypeDefinitions: language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+
+type document
+  relations
+	define editor: [user, document#viewer]
+	define viewer: [document#editor] or editor`).GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: store.GetId(),
+		Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "editor", "document:1#viewer"),
+			tuple.NewTupleKey("document:1", "viewer", "document:1#editor"),
+			tuple.NewTupleKey("document:2", "editor", "user:anne"),
+		}},
+	})
+	require.NoError(t, err)
+
+	// the cycle can't allow the user, so the request isn't an error
+	res, err := s.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:              store.GetId(),
+		AuthorizationModelId: model.GetAuthorizationModelId(),
+		TupleKey:             tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+	require.False(t, res.GetAllowed())
+	require.Equal(t, "document#viewer -> document#editor -> document#viewer", transport.headers[ResolutionCycleHeader])
+
+	delete(transport.headers, ResolutionCycleHeader)
+	res, err = s.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:              store.GetId(),
+		AuthorizationModelId: model.GetAuthorizationModelId(),
+		TupleKey:             tuple.NewCheckRequestTupleKey("document:2", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+	require.True(t, res.GetAllowed())
+	require.NotContains(t, transport.headers, ResolutionCycleHeader)
 }
 
-func TestResolveAuthorizationModel(t *testing.T) {
+func TestCheckServesStaleResultWhenDatastoreUnavailable(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
 	})
 
 	ctx := context.Background()
 
-	t.Run("no_latest_authorization_model_id_found", func(t *testing.T) {
-		store := ulid.Make().String()
+	storeID := ulid.Make().String()
+	modelID := ulid.Make().String()
 
-		mockController := gomock.NewController(t)
-		defer mockController.Finish()
+	typedefs := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
 
-		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
-		mockDatastore.EXPECT().FindLatestAuthorizationModel(gomock.Any(), store).Return(nil, storage.ErrNotFound)
+type repo
+  relations
+	define reader: [user]`).GetTypeDefinitions()
 
-		s := MustNewServerWithOpts(
-			WithDatastore(mockDatastore),
-		)
-		t.Cleanup(s.Close)
+	tk := tuple.NewCheckRequestTupleKey("repo:openfga", "reader", "user:mike")
+	returnedTuple := &openfgav1.Tuple{Key: tuple.ConvertCheckRequestTupleKeyToTupleKey(tk)}
 
-		expectedError := serverErrors.LatestAuthorizationModelNotFound(store)
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
 
-		_, err := s.resolveTypesystem(ctx, store, "")
-		require.ErrorIs(t, err, expectedError)
-	})
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDatastore.EXPECT().
+		ReadStoreSettings(gomock.Any(), storeID).
+		AnyTimes().
+		Return(&storage.StoreSettings{}, nil)
 
-	t.Run("read_existing_authorization_model", func(t *testing.T) {
-		store := ulid.Make().String()
-		modelID := ulid.Make().String()
+	mockDatastore.EXPECT().
+		ReadAuthorizationModel(gomock.Any(), storeID, modelID).
+		AnyTimes().
+		Return(&openfgav1.AuthorizationModel{
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: typedefs,
+		}, nil)
 
-		mockController := gomock.NewController(t)
-		defer mockController.Finish()
+	gomock.InOrder(
+		mockDatastore.EXPECT().
+			ReadUserTuple(gomock.Any(), storeID, gomock.Any()).
+			Times(1).
+			Return(returnedTuple, nil),
+		mockDatastore.EXPECT().
+			ReadUserTuple(gomock.Any(), storeID, gomock.Any()).
+			AnyTimes().
+			Return(nil, fmt.Errorf("%w: the circuit breaker is open", storage.ErrUnavailable)),
+	)
 
-		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
-		mockDatastore.EXPECT().FindLatestAuthorizationModel(gomock.Any(), store).Return(
-			&openfgav1.AuthorizationModel{
-				Id:            modelID,
-				SchemaVersion: typesystem.SchemaVersion1_1,
-			},
-			nil,
-		)
+	transport := &headerRecordingTransport{headers: map[string]string{}}
 
-		s := MustNewServerWithOpts(
-			WithDatastore(mockDatastore),
-		)
-		t.Cleanup(s.Close)
+	s := MustNewServerWithOpts(
+		WithDatastore(mockDatastore),
+		WithTransport(transport),
+		WithCheckQueryCacheEnabled(true),
+		WithCheckQueryCacheLimit(10),
+		WithCheckQueryCacheTTL(1*time.Millisecond),
+		WithCheckQueryCacheServeStale(true),
+	)
+	t.Cleanup(s.Close)
 
-		typesys, err := s.resolveTypesystem(ctx, store, "")
-		require.NoError(t, err)
-		require.Equal(t, modelID, typesys.GetAuthorizationModelID())
-	})
+	checkRequest := &openfgav1.CheckRequest{
+		StoreId:              storeID,
+		TupleKey:             tk,
+		AuthorizationModelId: modelID,
+	}
 
-	t.Run("non-valid_modelID_returns_error", func(t *testing.T) {
-		store := ulid.Make().String()
-		modelID := "foo"
-		want := serverErrors.AuthorizationModelNotFound(modelID)
+	_, err := s.Check(ctx, checkRequest)
+	require.NoError(t, err)
+	require.NotContains(t, transport.headers, StaleResultHeader)
 
-		mockController := gomock.NewController(t)
-		defer mockController.Finish()
+	// the cached result expired, and is served while the datastore is unavailable
+	time.Sleep(5 * time.Millisecond)
+	res, err := s.Check(ctx, checkRequest)
+	require.NoError(t, err)
+	require.True(t, res.GetAllowed())
+	require.Equal(t, "true", transport.headers[StaleResultHeader])
 
-		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	// an uncached check fails fast
+	_, err = s.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:              storeID,
+		TupleKey:             tuple.NewCheckRequestTupleKey("repo:openfga", "reader", "user:jon"),
+		AuthorizationModelId: modelID,
+	})
+	require.ErrorIs(t, err, serverErrors.DatastoreUnavailable)
+}
 
-		s := MustNewServerWithOpts(
-			WithDatastore(mockDatastore),
-		)
-		t.Cleanup(s.Close)
+func TestCheckResolutionBudget(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
 
-		_, err := s.resolveTypesystem(ctx, store, modelID)
-		require.Equal(t, want, err)
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckBudgetMaxDispatchCount(2),
+	)
+	t.Cleanup(s.Close)
+
+	createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{
+		Name: "openfga-test",
 	})
-}
+	require.NoError(t, err)
 
-type mockStreamServer struct {
-	grpc.ServerStream
-}
+	storeID := createStoreResp.GetId()
 
-func NewMockStreamServer() *mockStreamServer {
-	return &mockStreamServer{}
-}
+	model := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
 
-func (m *mockStreamServer) Context() context.Context {
-	return context.Background()
-}
+	type user
 
-func (m *mockStreamServer) Send(*openfgav1.StreamedListObjectsResponse) error {
-	return nil
+	type folder
+	  relations
+		define viewer: [user] or viewer from parent
+		define parent: [folder]`)
+
+	writeAuthModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	modelID := writeAuthModelResp.GetAuthorizationModelId()
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("folder:D", "viewer", "user:jon"),
+				tuple.NewTupleKey("folder:C", "parent", "folder:D"),
+				tuple.NewTupleKey("folder:B", "parent", "folder:C"),
+				tuple.NewTupleKey("folder:A", "parent", "folder:B"),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+		TupleKey:             tuple.NewCheckRequestTupleKey("folder:B", "viewer", "user:jon"),
+	})
+	require.NoError(t, err)
+	require.True(t, resp.GetAllowed())
+
+	_, err = s.Check(ctx, &openfgav1.CheckRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: modelID,
+		TupleKey:             tuple.NewCheckRequestTupleKey("folder:A", "viewer", "user:jon"),
+	})
+	require.Error(t, err)
+	e, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_authorization_model_resolution_too_complex), e.Code())
+	require.Contains(t, e.Message(), "exceeded the limit of 2 dispatches")
 }
 
-// This runs ListObjects and StreamedListObjects many times over to ensure no race conditions (see https://github.com/openfga/openfga/pull/762)
-func BenchmarkListObjectsNoRaceCondition(b *testing.B) {
-	b.Cleanup(func() {
-		goleak.VerifyNone(b,
-			// https://github.com/uber-go/goleak/discussions/89
-			goleak.IgnoreTopFunction("testing.(*B).run1"),
-			goleak.IgnoreTopFunction("testing.(*B).doBench"),
-		)
+func TestSlowRequestLog(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
 	})
-	ctx := context.Background()
-	store := ulid.Make().String()
-	modelID := ulid.Make().String()
 
-	mockController := gomock.NewController(b)
-	defer mockController.Finish()
+	storeID := ulid.Make().String()
+	modelID := ulid.Make().String()
 
 	typedefs := language.MustTransformDSLToProto(`model
   schema 1.1
@@ -1245,153 +1464,557 @@ type user
 
 type repo
   relations
-	define allowed: [user]
-	define viewer: [user] and allowed`).GetTypeDefinitions()
+	define reader: [user]`).GetTypeDefinitions()
 
-	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	tk := tuple.NewCheckRequestTupleKey("repo:openfga", "reader", "user:mike")
 
-	mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), store, modelID).AnyTimes().Return(&openfgav1.AuthorizationModel{
-		SchemaVersion:   typesystem.SchemaVersion1_1,
-		TypeDefinitions: typedefs,
-	}, nil)
-	mockDatastore.EXPECT().ReadStartingWithUser(gomock.Any(), store, gomock.Any()).AnyTimes().Return(nil, errors.New("error reading from storage"))
+	tests := []struct {
+		name          string
+		threshold     time.Duration
+		expectLogged  bool
+		readUserDelay time.Duration
+		readUserErr   error
+	}{
+		{
+			name:          "request_slower_than_threshold_is_logged",
+			threshold:     10 * time.Millisecond,
+			readUserDelay: 20 * time.Millisecond,
+			expectLogged:  true,
+		},
+		{
+			name:          "failed_request_slower_than_threshold_is_logged",
+			threshold:     10 * time.Millisecond,
+			readUserDelay: 20 * time.Millisecond,
+			readUserErr:   context.DeadlineExceeded,
+			expectLogged:  true,
+		},
+		{
+			name:          "request_faster_than_threshold_is_not_logged",
+			threshold:     1 * time.Hour,
+			readUserDelay: 0,
+			expectLogged:  false,
+		},
+	}
 
-	s := MustNewServerWithOpts(
-		WithDatastore(mockDatastore),
-	)
-	b.Cleanup(func() {
-		s.Close()
-	})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+			mockDatastore.EXPECT().
+				ReadAuthorizationModel(gomock.Any(), storeID, modelID).
+				Return(&openfgav1.AuthorizationModel{
+					Id:              modelID,
+					SchemaVersion:   typesystem.SchemaVersion1_1,
+					TypeDefinitions: typedefs,
+				}, nil)
+			mockDatastore.EXPECT().
+				ReadUserTuple(gomock.Any(), storeID, gomock.Any()).
+				DoAndReturn(func(context.Context, string, *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+					time.Sleep(test.readUserDelay)
+					if test.readUserErr != nil {
+						return nil, test.readUserErr
+					}
+					return nil, storage.ErrNotFound
+				})
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
-			StoreId:              store,
-			AuthorizationModelId: modelID,
-			Type:                 "repo",
-			Relation:             "viewer",
-			User:                 "user:bob",
-		})
+			observerLogger, logs := observer.New(zap.WarnLevel)
 
-		require.ErrorIs(b, err, serverErrors.NewInternalError("", errors.New("error reading from storage")))
+			s := MustNewServerWithOpts(
+				WithDatastore(mockDatastore),
+				WithLogger(&logger.ZapLogger{Logger: zap.New(observerLogger)}),
+				WithSlowRequestLogEnabled(true),
+				WithSlowRequestLogThreshold(test.threshold),
+			)
+			t.Cleanup(s.Close)
 
-		err = s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
-			StoreId:              store,
-			AuthorizationModelId: modelID,
-			Type:                 "repo",
-			Relation:             "viewer",
-			User:                 "user:bob",
-		}, NewMockStreamServer())
+			ctx := authn.ContextWithAuthClaims(context.Background(), &authn.AuthClaims{ClientID: "client-1"})
+			_, err := s.Check(ctx, &openfgav1.CheckRequest{
+				StoreId:              storeID,
+				TupleKey:             tk,
+				AuthorizationModelId: modelID,
+			})
+			if test.readUserErr != nil {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
 
-		require.ErrorIs(b, err, serverErrors.NewInternalError("", errors.New("error reading from storage")))
+			slowRequests := logs.FilterMessage("slow request").All()
+			if !test.expectLogged {
+				require.Empty(t, slowRequests)
+				return
+			}
+
+			require.Len(t, slowRequests, 1)
+			fields := slowRequests[0].ContextMap()
+			require.Equal(t, "Check", fields["method"])
+			require.Equal(t, storeID, fields["store_id"])
+			require.Equal(t, "client-1", fields["client_id"])
+			require.Equal(t, modelID, fields["authorization_model_id"])
+			require.Equal(t, "repo#reader@user", fields["tuple_key_shape"])
+			require.GreaterOrEqual(t, fields["resolution_ms"], int64(20))
+			if test.readUserErr != nil {
+				require.Contains(t, fields, "error")
+				return
+			}
+			require.EqualValues(t, 1, fields["datastore_query_count"])
+		})
 	}
 }
 
-func TestListObjects_ErrorCases(t *testing.T) {
+func TestCheckDeadlineExceeded(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
 	})
 
-	ctx := context.Background()
-	store := ulid.Make().String()
-
-	mockController := gomock.NewController(t)
-	defer mockController.Finish()
-
-	t.Run("database_errors", func(t *testing.T) {
-		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
-
-		s := MustNewServerWithOpts(
-			WithDatastore(mockDatastore),
-		)
-		t.Cleanup(s.Close)
-
-		modelID := ulid.Make().String()
+	storeID := ulid.Make().String()
+	modelID := ulid.Make().String()
 
-		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), store, modelID).AnyTimes().Return(&openfgav1.AuthorizationModel{
-			SchemaVersion: typesystem.SchemaVersion1_1,
-			TypeDefinitions: language.MustTransformDSLToProto(`model
+	typedefs := language.MustTransformDSLToProto(`model
   schema 1.1
 type user
 
-type document
+type repo
   relations
-	define viewer: [user, user:*]`).GetTypeDefinitions(),
-		}, nil)
+	define reader: [user]`).GetTypeDefinitions()
 
-		mockDatastore.EXPECT().ReadStartingWithUser(gomock.Any(), store, storage.ReadStartingWithUserFilter{
-			ObjectType: "document",
-			Relation:   "viewer",
-			UserFilter: []*openfgav1.ObjectRelation{
-				{Object: "user:*"},
-				{Object: "user:bob"},
-			}}).AnyTimes().Return(nil, errors.New("error reading from storage"))
+	tests := map[string]struct {
+		readUserErr   error
+		expectedError error
+	}{
+		"in_datastore": {
+			readUserErr:   fmt.Errorf("%w: %w", storage.ErrDeadlineExceeded, context.DeadlineExceeded),
+			expectedError: serverErrors.DatastoreDeadlineExceeded,
+		},
+		"in_resolution": {
+			readUserErr:   context.DeadlineExceeded,
+			expectedError: serverErrors.ResolutionDeadlineExceeded,
+		},
+	}
 
-		t.Run("error_listing_objects_from_storage_in_non-streaming_version", func(t *testing.T) {
-			res, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
-				StoreId:              store,
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+
+			mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+			mockDatastore.EXPECT().
+				ReadAuthorizationModel(gomock.Any(), storeID, modelID).
+				Return(&openfgav1.AuthorizationModel{
+					Id:              modelID,
+					SchemaVersion:   typesystem.SchemaVersion1_1,
+					TypeDefinitions: typedefs,
+				}, nil)
+			mockDatastore.EXPECT().
+				ReadUserTuple(gomock.Any(), storeID, gomock.Any()).
+				Return(nil, test.readUserErr)
+
+			s := MustNewServerWithOpts(WithDatastore(mockDatastore))
+			t.Cleanup(s.Close)
+
+			_, err := s.Check(context.Background(), &openfgav1.CheckRequest{
+				StoreId:              storeID,
+				TupleKey:             tuple.NewCheckRequestTupleKey("repo:openfga", "reader", "user:mike"),
 				AuthorizationModelId: modelID,
-				Type:                 "document",
-				Relation:             "viewer",
-				User:                 "user:bob",
 			})
-
-			require.Nil(t, res)
-			require.ErrorIs(t, err, serverErrors.NewInternalError("", errors.New("error reading from storage")))
+			require.ErrorIs(t, err, test.expectedError)
 		})
+	}
+}
 
-		t.Run("error_listing_objects_from_storage_in_streaming_version", func(t *testing.T) {
-			err := s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
-				StoreId:              store,
-				AuthorizationModelId: modelID,
-				Type:                 "document",
-				Relation:             "viewer",
-				User:                 "user:bob",
-			}, NewMockStreamServer())
+func TestTupleKeyShape(t *testing.T) {
+	require.Equal(t, "document#viewer@user", tupleKeyShape("document", "viewer", "user:jon"))
+	require.Equal(t, "document#viewer@user:*", tupleKeyShape("document", "viewer", "user:*"))
+	require.Equal(t, "document#viewer@group#member", tupleKeyShape("document", "viewer", "group:eng#member"))
+}
 
-			require.ErrorIs(t, err, serverErrors.NewInternalError("", errors.New("error reading from storage")))
-		})
+func TestWriteAssertionModelDSError(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
 	})
 
-	t.Run("graph_resolution_errors", func(t *testing.T) {
-		s := MustNewServerWithOpts(
-			WithDatastore(memory.New()),
-			WithResolveNodeLimit(2),
-		)
-		t.Cleanup(s.Close)
+	ctx := context.Background()
 
-		writeModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
-			StoreId:       store,
-			SchemaVersion: typesystem.SchemaVersion1_1,
-			TypeDefinitions: language.MustTransformDSLToProto(`model
-  schema 1.1
+	storeID := ulid.Make().String()
+	modelID := ulid.Make().String()
+
+	typedefs := language.MustTransformDSLToProto(`model
+	schema 1.1
 type user
 
-type group
+type repo
   relations
-	define member: [user, group#member]
+	define reader: [user]`).GetTypeDefinitions()
 
-type document
-  relations
-	define viewer: [group#member]`).GetTypeDefinitions(),
-		})
-		require.NoError(t, err)
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
 
-		_, err = s.Write(ctx, &openfgav1.WriteRequest{
-			StoreId: store,
-			Writes: &openfgav1.WriteRequestWrites{
-				TupleKeys: []*openfgav1.TupleKey{
-					tuple.NewTupleKey("document:1", "viewer", "group:1#member"),
-					tuple.NewTupleKey("group:1", "member", "group:2#member"),
-					tuple.NewTupleKey("group:2", "member", "group:3#member"),
-					tuple.NewTupleKey("group:3", "member", "user:jon"),
-				},
-			},
-		})
-		require.NoError(t, err)
+	mockDSOldSchema := mockstorage.NewMockOpenFGADatastore(mockController)
 
-		t.Run("resolution_depth_exceeded_error_unary", func(t *testing.T) {
-			res, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+	mockDSOldSchema.EXPECT().
+		ReadAuthorizationModel(gomock.Any(), storeID, modelID).
+		AnyTimes().
+		Return(&openfgav1.AuthorizationModel{
+			SchemaVersion:   typesystem.SchemaVersion1_0,
+			TypeDefinitions: typedefs,
+		}, nil)
+
+	mockDSBadReadAuthModel := mockstorage.NewMockOpenFGADatastore(mockController)
+
+	mockDSBadReadAuthModel.EXPECT().
+		ReadAuthorizationModel(gomock.Any(), storeID, modelID).
+		AnyTimes().
+		Return(nil, fmt.Errorf("unable to read"))
+
+	mockDSBadWriteAssertions := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDSBadWriteAssertions.EXPECT().
+		ReadAuthorizationModel(gomock.Any(), storeID, modelID).
+		AnyTimes().
+		Return(&openfgav1.AuthorizationModel{
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: typedefs,
+		}, nil)
+	mockDSBadWriteAssertions.EXPECT().
+		WriteAssertions(gomock.Any(), storeID, modelID, gomock.Any()).
+		AnyTimes().
+		Return(fmt.Errorf("unable to write"))
+
+	tests := []struct {
+		name          string
+		assertions    []*openfgav1.Assertion
+		mockDatastore *mockstorage.MockOpenFGADatastore
+		expectedError error
+	}{
+		{
+			name:          "unsupported_schema",
+			assertions:    []*openfgav1.Assertion{},
+			mockDatastore: mockDSOldSchema,
+			expectedError: serverErrors.ValidationError(
+				fmt.Errorf("invalid schema version"),
+			),
+		},
+		{
+			name:          "failed_to_read",
+			assertions:    []*openfgav1.Assertion{},
+			mockDatastore: mockDSBadReadAuthModel,
+			expectedError: serverErrors.NewInternalError(
+				"", fmt.Errorf("unable to read"),
+			),
+		},
+		{
+			name:          "failed_to_write",
+			assertions:    []*openfgav1.Assertion{},
+			mockDatastore: mockDSBadWriteAssertions,
+			expectedError: serverErrors.NewInternalError(
+				"", fmt.Errorf("unable to write"),
+			),
+		},
+	}
+
+	for _, curTest := range tests {
+		t.Run(curTest.name, func(t *testing.T) {
+			request := &openfgav1.WriteAssertionsRequest{
+				StoreId:              storeID,
+				Assertions:           curTest.assertions,
+				AuthorizationModelId: modelID,
+			}
+
+			writeAssertionCmd := commands.NewWriteAssertionsCommand(curTest.mockDatastore)
+			_, err := writeAssertionCmd.Execute(ctx, request)
+			require.ErrorIs(t, curTest.expectedError, err)
+		})
+	}
+}
+
+func TestReadAssertionModelDSError(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	storeID := ulid.Make().String()
+	modelID := ulid.Make().String()
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDSBadReadAssertions := mockstorage.NewMockOpenFGADatastore(mockController)
+	mockDSBadReadAssertions.EXPECT().
+		ReadAssertions(gomock.Any(), storeID, modelID).
+		AnyTimes().
+		Return(nil, fmt.Errorf("unable to read"))
+
+	readAssertionQuery := commands.NewReadAssertionsQuery(mockDSBadReadAssertions)
+	_, err := readAssertionQuery.Execute(ctx, storeID, modelID)
+	expectedError := serverErrors.NewInternalError(
+		"", fmt.Errorf("unable to read"),
+	)
+	require.ErrorIs(t, expectedError, err)
+}
+
+func TestResolveAuthorizationModel(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	t.Run("no_latest_authorization_model_id_found", func(t *testing.T) {
+		store := ulid.Make().String()
+
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().ReadStoreSettings(gomock.Any(), store).Return(&storage.StoreSettings{}, nil)
+		mockDatastore.EXPECT().FindLatestAuthorizationModel(gomock.Any(), store).Return(nil, storage.ErrNotFound)
+
+		s := MustNewServerWithOpts(
+			WithDatastore(mockDatastore),
+		)
+		t.Cleanup(s.Close)
+
+		expectedError := serverErrors.LatestAuthorizationModelNotFound(store)
+
+		_, err := s.resolveTypesystem(ctx, store, "")
+		require.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("read_existing_authorization_model", func(t *testing.T) {
+		store := ulid.Make().String()
+		modelID := ulid.Make().String()
+
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().ReadStoreSettings(gomock.Any(), store).Return(&storage.StoreSettings{}, nil)
+		mockDatastore.EXPECT().FindLatestAuthorizationModel(gomock.Any(), store).Return(
+			&openfgav1.AuthorizationModel{
+				Id:            modelID,
+				SchemaVersion: typesystem.SchemaVersion1_1,
+			},
+			nil,
+		)
+
+		s := MustNewServerWithOpts(
+			WithDatastore(mockDatastore),
+		)
+		t.Cleanup(s.Close)
+
+		typesys, err := s.resolveTypesystem(ctx, store, "")
+		require.NoError(t, err)
+		require.Equal(t, modelID, typesys.GetAuthorizationModelID())
+	})
+
+	t.Run("non-valid_modelID_returns_error", func(t *testing.T) {
+		store := ulid.Make().String()
+		modelID := "foo"
+		want := serverErrors.AuthorizationModelNotFound(modelID)
+
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+
+		s := MustNewServerWithOpts(
+			WithDatastore(mockDatastore),
+		)
+		t.Cleanup(s.Close)
+
+		_, err := s.resolveTypesystem(ctx, store, modelID)
+		require.Equal(t, want, err)
+	})
+}
+
+type mockStreamServer struct {
+	grpc.ServerStream
+}
+
+func NewMockStreamServer() *mockStreamServer {
+	return &mockStreamServer{}
+}
+
+func (m *mockStreamServer) Context() context.Context {
+	return context.Background()
+}
+
+func (m *mockStreamServer) Send(*openfgav1.StreamedListObjectsResponse) error {
+	return nil
+}
+
+// This runs ListObjects and StreamedListObjects many times over to ensure no race conditions (see https://github.com/openfga/openfga/pull/762)
+func BenchmarkListObjectsNoRaceCondition(b *testing.B) {
+	b.Cleanup(func() {
+		goleak.VerifyNone(b,
+			// https://github.com/uber-go/goleak/discussions/89
+			goleak.IgnoreTopFunction("testing.(*B).run1"),
+			goleak.IgnoreTopFunction("testing.(*B).doBench"),
+		)
+	})
+	ctx := context.Background()
+	store := ulid.Make().String()
+	modelID := ulid.Make().String()
+
+	mockController := gomock.NewController(b)
+	defer mockController.Finish()
+
+	typedefs := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+
+type repo
+  relations
+	define allowed: [user]
+	define viewer: [user] and allowed`).GetTypeDefinitions()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+
+	mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), store, modelID).AnyTimes().Return(&openfgav1.AuthorizationModel{
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: typedefs,
+	}, nil)
+	mockDatastore.EXPECT().ReadStartingWithUser(gomock.Any(), store, gomock.Any()).AnyTimes().Return(nil, errors.New("error reading from storage"))
+
+	s := MustNewServerWithOpts(
+		WithDatastore(mockDatastore),
+	)
+	b.Cleanup(func() {
+		s.Close()
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:              store,
+			AuthorizationModelId: modelID,
+			Type:                 "repo",
+			Relation:             "viewer",
+			User:                 "user:bob",
+		})
+
+		require.ErrorIs(b, err, serverErrors.NewInternalError("", errors.New("error reading from storage")))
+
+		err = s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
+			StoreId:              store,
+			AuthorizationModelId: modelID,
+			Type:                 "repo",
+			Relation:             "viewer",
+			User:                 "user:bob",
+		}, NewMockStreamServer())
+
+		require.ErrorIs(b, err, serverErrors.NewInternalError("", errors.New("error reading from storage")))
+	}
+}
+
+func TestListObjects_ErrorCases(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("database_errors", func(t *testing.T) {
+		mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+
+		s := MustNewServerWithOpts(
+			WithDatastore(mockDatastore),
+		)
+		t.Cleanup(s.Close)
+
+		modelID := ulid.Make().String()
+
+		mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), store, modelID).AnyTimes().Return(&openfgav1.AuthorizationModel{
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+
+type document
+  relations
+	define viewer: [user, user:*]`).GetTypeDefinitions(),
+		}, nil)
+
+		mockDatastore.EXPECT().ReadStartingWithUser(gomock.Any(), store, storage.ReadStartingWithUserFilter{
+			ObjectType: "document",
+			Relation:   "viewer",
+			UserFilter: []*openfgav1.ObjectRelation{
+				{Object: "user:*"},
+				{Object: "user:bob"},
+			}}).AnyTimes().Return(nil, errors.New("error reading from storage"))
+
+		t.Run("error_listing_objects_from_storage_in_non-streaming_version", func(t *testing.T) {
+			res, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+				StoreId:              store,
+				AuthorizationModelId: modelID,
+				Type:                 "document",
+				Relation:             "viewer",
+				User:                 "user:bob",
+			})
+
+			require.Nil(t, res)
+			require.ErrorIs(t, err, serverErrors.NewInternalError("", errors.New("error reading from storage")))
+		})
+
+		t.Run("error_listing_objects_from_storage_in_streaming_version", func(t *testing.T) {
+			err := s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
+				StoreId:              store,
+				AuthorizationModelId: modelID,
+				Type:                 "document",
+				Relation:             "viewer",
+				User:                 "user:bob",
+			}, NewMockStreamServer())
+
+			require.ErrorIs(t, err, serverErrors.NewInternalError("", errors.New("error reading from storage")))
+		})
+	})
+
+	t.Run("graph_resolution_errors", func(t *testing.T) {
+		s := MustNewServerWithOpts(
+			WithDatastore(memory.New()),
+			WithResolveNodeLimit(2),
+		)
+		t.Cleanup(s.Close)
+
+		writeModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       store,
+			SchemaVersion: typesystem.SchemaVersion1_1,
+			TypeDefinitions: language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+
+type group
+  relations
+	define member: [user, group#member]
+
+type document
+  relations
+	define viewer: [group#member]`).GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+
+		_, err = s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: store,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{
+					tuple.NewTupleKey("document:1", "viewer", "group:1#member"),
+					tuple.NewTupleKey("group:1", "member", "group:2#member"),
+					tuple.NewTupleKey("group:2", "member", "group:3#member"),
+					tuple.NewTupleKey("group:3", "member", "user:jon"),
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		t.Run("resolution_depth_exceeded_error_unary", func(t *testing.T) {
+			res, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
 				StoreId:              store,
 				AuthorizationModelId: writeModelResp.GetAuthorizationModelId(),
 				Type:                 "document",
@@ -1399,404 +2022,1519 @@ type document
 				User:                 "user:jon",
 			})
 
-			require.Nil(t, res)
-			require.ErrorIs(t, err, serverErrors.AuthorizationModelResolutionTooComplex)
-		})
+			require.Nil(t, res)
+			require.ErrorIs(t, err, serverErrors.AuthorizationModelResolutionTooComplex)
+		})
+
+		t.Run("resolution_depth_exceeded_error_streaming", func(t *testing.T) {
+			err := s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
+				StoreId:              store,
+				AuthorizationModelId: writeModelResp.GetAuthorizationModelId(),
+				Type:                 "document",
+				Relation:             "viewer",
+				User:                 "user:jon",
+			}, NewMockStreamServer())
+
+			require.ErrorIs(t, err, serverErrors.AuthorizationModelResolutionTooComplex)
+		})
+	})
+}
+
+func TestAuthorizationModelInvalidSchemaVersion(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+	modelID := ulid.Make().String()
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+
+	mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), store, modelID).AnyTimes().Return(&openfgav1.AuthorizationModel{
+		SchemaVersion: typesystem.SchemaVersion1_0,
+		TypeDefinitions: []*openfgav1.TypeDefinition{
+			{
+				Type: "user",
+			},
+			{
+				Type: "team",
+				Relations: map[string]*openfgav1.Userset{
+					"member": typesystem.This(),
+				},
+			},
+		},
+	}, nil)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(mockDatastore),
+	)
+	t.Cleanup(s.Close)
+
+	t.Run("invalid_schema_error_in_check", func(t *testing.T) {
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:              store,
+			AuthorizationModelId: modelID,
+			TupleKey: tuple.NewCheckRequestTupleKey(
+				"team:abc",
+				"member",
+				"user:anne"),
+		})
+		require.Error(t, err)
+		e, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
+	})
+
+	t.Run("invalid_schema_error_in_list_objects", func(t *testing.T) {
+		_, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:              store,
+			AuthorizationModelId: modelID,
+			Type:                 "team",
+			Relation:             "member",
+			User:                 "user:anne",
+		})
+		require.Error(t, err)
+		e, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
+	})
+
+	t.Run("invalid_schema_error_in_streamed_list_objects", func(t *testing.T) {
+		err := s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
+			StoreId:              store,
+			AuthorizationModelId: modelID,
+			Type:                 "team",
+			Relation:             "member",
+			User:                 "user:anne",
+		}, NewMockStreamServer())
+		require.Error(t, err)
+		e, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
+	})
+
+	t.Run("invalid_schema_error_in_write", func(t *testing.T) {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId:              store,
+			AuthorizationModelId: modelID,
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{
+					{
+						Object:   "repo:openfga/openfga",
+						Relation: "reader",
+						User:     "user:anne",
+					},
+				},
+			},
+		})
+		require.Error(t, err)
+		e, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
+	})
+
+	t.Run("invalid_schema_error_in_write_model", func(t *testing.T) {
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().Return(100)
+
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       store,
+			SchemaVersion: typesystem.SchemaVersion1_0,
+			TypeDefinitions: language.MustTransformDSLToProto(`model
+	schema 1.1
+type repo
+`).GetTypeDefinitions(),
+		})
+		require.Error(t, err)
+		e, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), e.Code(), err)
+	})
+
+	t.Run("invalid_schema_error_in_write_assertion", func(t *testing.T) {
+		_, err := s.WriteAssertions(ctx, &openfgav1.WriteAssertionsRequest{
+			StoreId:              store,
+			AuthorizationModelId: modelID,
+			Assertions: []*openfgav1.Assertion{{
+				TupleKey:    tuple.NewAssertionTupleKey("repo:test", "reader", "user:elbuo"),
+				Expectation: false,
+			}},
+		})
+		require.Error(t, err)
+		e, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
+	})
+}
+
+func TestDefaultMaxConcurrentReadSettings(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	cfg := serverconfig.DefaultConfig()
+	require.EqualValues(t, math.MaxUint32, cfg.MaxConcurrentReadsForCheck)
+	require.EqualValues(t, math.MaxUint32, cfg.MaxConcurrentReadsForListObjects)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(memory.New()),
+	)
+	t.Cleanup(s.Close)
+	require.EqualValues(t, math.MaxUint32, s.maxConcurrentReadsForCheck)
+	require.EqualValues(t, math.MaxUint32, s.maxConcurrentReadsForListObjects)
+}
+
+func TestDelegateCheckResolver(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	t.Run("default_check_resolver_alone", func(t *testing.T) {
+		cfg := serverconfig.DefaultConfig()
+		require.False(t, cfg.DispatchThrottling.Enabled)
+		require.False(t, cfg.CheckQueryCache.Enabled)
+
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+		)
+		t.Cleanup(s.Close)
+		require.Nil(t, s.dispatchThrottlingCheckResolver)
+		require.False(t, s.dispatchThrottlingCheckResolverEnabled)
+
+		require.False(t, s.checkQueryCacheEnabled)
+		require.Nil(t, s.cachedCheckResolver)
+
+		require.NotNil(t, s.checkResolver)
+		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+
+		localCheckResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.LocalChecker)
+		require.True(t, ok)
+
+		_, ok = localCheckResolver.GetDelegate().(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+	})
+
+	t.Run("dispatch_throttling_check_resolver_enabled", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+		const dispatchThreshold = 50
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithDispatchThrottlingCheckResolverEnabled(true),
+			WithDispatchThrottlingCheckResolverThreshold(dispatchThreshold),
+		)
+		t.Cleanup(s.Close)
+
+		require.False(t, s.checkQueryCacheEnabled)
+		require.Nil(t, s.cachedCheckResolver)
+
+		require.True(t, s.dispatchThrottlingCheckResolverEnabled)
+		require.EqualValues(t, dispatchThreshold, s.dispatchThrottlingDefaultThreshold)
+		require.EqualValues(t, 0, s.dispatchThrottlingMaxThreshold)
+		require.NotNil(t, s.dispatchThrottlingCheckResolver)
+		require.NotNil(t, s.checkResolver)
+		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+
+		dispatchThrottlingResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.DispatchThrottlingCheckResolver)
+		require.True(t, ok)
+
+		localChecker, ok := dispatchThrottlingResolver.GetDelegate().(*graph.LocalChecker)
+		require.True(t, ok)
+
+		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+	})
+
+	t.Run("dispatch_throttling_check_resolver_enabled_zero_max_threshold", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+		const dispatchThreshold = 50
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithDispatchThrottlingCheckResolverEnabled(true),
+			WithDispatchThrottlingCheckResolverThreshold(dispatchThreshold),
+			WithDispatchThrottlingCheckResolverMaxThreshold(0),
+		)
+		t.Cleanup(s.Close)
+
+		require.False(t, s.checkQueryCacheEnabled)
+		require.Nil(t, s.cachedCheckResolver)
+
+		require.True(t, s.dispatchThrottlingCheckResolverEnabled)
+		require.EqualValues(t, dispatchThreshold, s.dispatchThrottlingDefaultThreshold)
+		require.EqualValues(t, 0, s.dispatchThrottlingMaxThreshold)
+		require.NotNil(t, s.dispatchThrottlingCheckResolver)
+		require.NotNil(t, s.checkResolver)
+		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+
+		dispatchThrottlingResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.DispatchThrottlingCheckResolver)
+		require.True(t, ok)
+
+		localChecker, ok := dispatchThrottlingResolver.GetDelegate().(*graph.LocalChecker)
+		require.True(t, ok)
+
+		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+	})
+
+	t.Run("dispatch_throttling_check_resolver_enabled_non_zero_max_threshold", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+		const dispatchThreshold = 50
+		const maxDispatchThreshold = 60
+
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithDispatchThrottlingCheckResolverEnabled(true),
+			WithDispatchThrottlingCheckResolverThreshold(dispatchThreshold),
+			WithDispatchThrottlingCheckResolverMaxThreshold(maxDispatchThreshold),
+		)
+		t.Cleanup(s.Close)
+
+		require.False(t, s.checkQueryCacheEnabled)
+		require.Nil(t, s.cachedCheckResolver)
+
+		require.True(t, s.dispatchThrottlingCheckResolverEnabled)
+		require.EqualValues(t, dispatchThreshold, s.dispatchThrottlingDefaultThreshold)
+		require.EqualValues(t, maxDispatchThreshold, s.dispatchThrottlingMaxThreshold)
+		require.NotNil(t, s.dispatchThrottlingCheckResolver)
+		require.NotNil(t, s.checkResolver)
+		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+
+		dispatchThrottlingResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.DispatchThrottlingCheckResolver)
+		require.True(t, ok)
+
+		localChecker, ok := dispatchThrottlingResolver.GetDelegate().(*graph.LocalChecker)
+		require.True(t, ok)
+
+		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+	})
+
+	t.Run("cache_check_resolver_enabled", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithCheckQueryCacheEnabled(true),
+		)
+		t.Cleanup(s.Close)
+
+		require.False(t, s.dispatchThrottlingCheckResolverEnabled)
+		require.Nil(t, s.dispatchThrottlingCheckResolver)
+
+		require.True(t, s.checkQueryCacheEnabled)
+		require.NotNil(t, s.cachedCheckResolver)
+		require.NotNil(t, s.checkResolver)
+		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+
+		cachedCheckResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.CachedCheckResolver)
+		require.True(t, ok)
+
+		localChecker, ok := cachedCheckResolver.GetDelegate().(*graph.LocalChecker)
+		require.True(t, ok)
+
+		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+	})
+
+	t.Run("both_dispatch_throttling_and_cache_check_resolver_enabled", func(t *testing.T) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithCheckQueryCacheEnabled(true),
+			WithDispatchThrottlingCheckResolverEnabled(true),
+			WithDispatchThrottlingCheckResolverThreshold(50),
+			WithDispatchThrottlingCheckResolverMaxThreshold(100),
+		)
+		t.Cleanup(s.Close)
+
+		require.True(t, s.dispatchThrottlingCheckResolverEnabled)
+		require.EqualValues(t, 50, s.dispatchThrottlingDefaultThreshold)
+		require.EqualValues(t, 100, s.dispatchThrottlingMaxThreshold)
+		require.NotNil(t, s.dispatchThrottlingCheckResolver)
+		require.NotNil(t, s.checkResolver)
+		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+
+		dispatchThrottlingResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.DispatchThrottlingCheckResolver)
+		require.True(t, ok)
+
+		require.True(t, s.checkQueryCacheEnabled)
+		require.NotNil(t, s.cachedCheckResolver)
+
+		cachedCheckResolver, ok := dispatchThrottlingResolver.GetDelegate().(*graph.CachedCheckResolver)
+		require.True(t, ok)
+
+		localChecker, ok := cachedCheckResolver.GetDelegate().(*graph.LocalChecker)
+		require.True(t, ok)
+
+		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+	})
+
+	t.Run("custom_check_resolver_in_chain", func(t *testing.T) {
+		ctx := context.Background()
+
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		var intercepted atomic.Int32
+		interceptor := checkresolver.NewInterceptor(func(ctx context.Context, req *checkresolver.ResolveCheckRequest, next checkresolver.CheckResolver) (*checkresolver.ResolveCheckResponse, error) {
+			intercepted.Add(1)
+			return next.ResolveCheck(ctx, req)
+		})
+
+		s := MustNewServerWithOpts(
+			WithDatastore(ds),
+			WithCheckQueryCacheEnabled(true),
+			WithCheckResolverChain(func(resolvers []checkresolver.DelegatingCheckResolver) []checkresolver.DelegatingCheckResolver {
+				// insert the interceptor in front of the CachedCheckResolver
+				return slices.Insert(resolvers, len(resolvers)-2, checkresolver.DelegatingCheckResolver(interceptor))
+			}),
+		)
+		t.Cleanup(s.Close)
+
+		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
+		require.True(t, ok)
+		require.Equal(t, interceptor, cycleDetectionCheckResolver.GetDelegate())
+
+		_, ok = interceptor.GetDelegate().(*graph.CachedCheckResolver)
+		require.True(t, ok)
+
+		createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "openfga-test"})
+		require.NoError(t, err)
+		storeID := createStoreResp.GetId()
+
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: language.MustTransformDSLToProto("model\n  schema 1.1\ntype user\ntype document\n  relations\n    define viewer: [user]").GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+
+		checkResp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		require.False(t, checkResp.GetAllowed())
+		require.EqualValues(t, 1, intercepted.Load())
+	})
+}
+
+func TestWriteAuthorizationModelWithSchema12(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+	ctx := context.Background()
+	storeID := ulid.Make().String()
+
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+
+	t.Run("accepts_request_with_schema_version_1.2", func(t *testing.T) {
+		s := MustNewServerWithOpts(
+			WithDatastore(mockDatastore),
+		)
+		defer s.Close()
+
+		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().Return(100)
+		mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(nil)
+
+		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:       storeID,
+			SchemaVersion: typesystem.SchemaVersion1_2,
+			TypeDefinitions: []*openfgav1.TypeDefinition{
+				{
+					Type: "user",
+					Metadata: &openfgav1.Metadata{
+						Relations:  nil,
+						Module:     "usermanagement",
+						SourceInfo: nil,
+					},
+				},
+			},
+		})
+
+		require.NoError(t, err)
+	})
+}
+
+func TestContextualTuplesValidation(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithMaxContextualTuples(30))
+	t.Cleanup(s.Close)
+
+	storeID := ulid.Make().String()
+	model := testutils.MustTransformDSLToProtoWithID(`
+		model
+			schema 1.1
+		type user
+		type document
+			relations
+				define viewer: [user]`)
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+
+	contextualTuples := func(n int) *openfgav1.ContextualTupleKeys {
+		tks := make([]*openfgav1.TupleKey, 0, n)
+		for i := 0; i < n; i++ {
+			tks = append(tks, tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne"))
+		}
+		return &openfgav1.ContextualTupleKeys{TupleKeys: tks}
+	}
+
+	t.Run("more_than_the_api_limit", func(t *testing.T) {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:          storeID,
+			TupleKey:         tuple.NewCheckRequestTupleKey("document:25", "viewer", "user:anne"),
+			ContextualTuples: contextualTuples(30),
+		})
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+	})
+
+	t.Run("more_than_the_server_limit", func(t *testing.T) {
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:          storeID,
+			TupleKey:         tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+			ContextualTuples: contextualTuples(31),
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), status.Code(err))
+
+		_, err = s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:          storeID,
+			Type:             "document",
+			Relation:         "viewer",
+			User:             "user:anne",
+			ContextualTuples: contextualTuples(31),
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_exceeded_entity_limit), status.Code(err))
+	})
+
+	t.Run("invalid_for_the_model", func(t *testing.T) {
+		tks := contextualTuples(3)
+		tks.TupleKeys[2] = tuple.NewTupleKey("document:2", "viewer", "document:1")
+
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:          storeID,
+			TupleKey:         tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+			ContextualTuples: tks,
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_tuple), status.Code(err))
+		require.ErrorContains(t, err, "invalid contextual tuple at index 2")
+
+		_, err = s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
+			StoreId:          storeID,
+			Type:             "document",
+			Relation:         "viewer",
+			User:             "user:anne",
+			ContextualTuples: tks,
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_tuple), status.Code(err))
+		require.ErrorContains(t, err, "invalid contextual tuple at index 2")
+	})
+}
+
+func TestReadChangesWithFilterHeaders(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithChangelogHorizonOffset(0))
+	t.Cleanup(s.Close)
+
+	storeID := ulid.Make().String()
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:org1/a", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:org1/b", "viewer", "user:bob"),
+		tuple.NewTupleKey("document:org2/a", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	filterCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(
+		ChangesObjectIDPrefixHeader, "org1/",
+		ChangesUserHeader, "user:anne",
+	))
+	resp, err := s.ReadChanges(filterCtx, &openfgav1.ReadChangesRequest{StoreId: storeID, Type: "document"})
+	require.NoError(t, err)
+	require.Len(t, resp.GetChanges(), 1)
+	require.Equal(t, "document:org1/a", resp.GetChanges()[0].GetTupleKey().GetObject())
+
+	// the object ID prefix filter requires the type
+	_, err = s.ReadChanges(filterCtx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+	require.Error(t, err)
+
+	// the continuation tokens are bound to the filters
+	resp, err = s.ReadChanges(filterCtx, &openfgav1.ReadChangesRequest{StoreId: storeID, Type: "document", PageSize: wrapperspb.Int32(1)})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.GetContinuationToken())
+
+	_, err = s.ReadChanges(filterCtx, &openfgav1.ReadChangesRequest{StoreId: storeID, Type: "document", ContinuationToken: resp.GetContinuationToken()})
+	require.NoError(t, err)
+
+	otherFilterCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(
+		ChangesObjectIDPrefixHeader, "org2/",
+		ChangesUserHeader, "user:anne",
+	))
+	_, err = s.ReadChanges(otherFilterCtx, &openfgav1.ReadChangesRequest{StoreId: storeID, Type: "document", ContinuationToken: resp.GetContinuationToken()})
+	require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+
+	_, err = s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, Type: "document", ContinuationToken: resp.GetContinuationToken()})
+	require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+}
+
+func TestReadChangesWithExpiredContinuationToken(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithChangelogHorizonOffset(0), WithChangelogContinuationTokenLifetime(100*time.Millisecond))
+	t.Cleanup(s.Close)
+
+	storeID := ulid.Make().String()
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	resp, err := s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, PageSize: wrapperspb.Int32(1)})
+	require.NoError(t, err)
+	require.Len(t, resp.GetChanges(), 1)
+
+	resp, err = s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, PageSize: wrapperspb.Int32(1), ContinuationToken: resp.GetContinuationToken()})
+	require.NoError(t, err)
+	require.Len(t, resp.GetChanges(), 1)
+
+	time.Sleep(200 * time.Millisecond)
+
+	_, err = s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, ContinuationToken: resp.GetContinuationToken()})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_continuation_token), status.Code(err))
+	reason, _ := serverErrors.ReasonFromError(err)
+	require.Equal(t, serverErrors.ReasonContinuationTokenExpired, reason)
+
+	var restartFrom string
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			restartFrom = info.GetMetadata()["restart_from"]
+		}
+	}
+	require.NotEmpty(t, restartFrom)
+
+	// the client restarts from the time of the error
+	err = ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tuple.NewTupleKey("document:3", "viewer", "user:anne")})
+	require.NoError(t, err)
+
+	startTimeCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ChangesStartTimeHeader, restartFrom))
+	resp, err = s.ReadChanges(startTimeCtx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+	require.NoError(t, err)
+	require.Len(t, resp.GetChanges(), 1)
+	require.Equal(t, "document:3", resp.GetChanges()[0].GetTupleKey().GetObject())
+
+	// the continuation tokens are bound to the start time
+	_, err = s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, ContinuationToken: resp.GetContinuationToken()})
+	require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+
+	invalidCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ChangesStartTimeHeader, "yesterday"))
+	_, err = s.ReadChanges(invalidCtx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+	require.ErrorContains(t, err, "invalid Openfga-Changes-Start-Time header")
+}
+
+func TestReadByUser(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	storeID := ulid.Make().String()
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("folder:1", "owner", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+	})
+	require.NoError(t, err)
+
+	read := func(tk *openfgav1.ReadRequestTupleKey) []string {
+		resp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID, TupleKey: tk})
+		require.NoError(t, err)
+
+		var objects []string
+		for _, t := range resp.GetTuples() {
+			objects = append(objects, t.GetKey().GetObject())
+		}
+		return objects
+	}
+
+	require.ElementsMatch(t, []string{"document:1", "folder:1"}, read(&openfgav1.ReadRequestTupleKey{User: "user:anne"}))
+	require.ElementsMatch(t, []string{"folder:1"}, read(&openfgav1.ReadRequestTupleKey{User: "user:anne", Relation: "owner"}))
+	require.ElementsMatch(t, []string{"document:1"}, read(&openfgav1.ReadRequestTupleKey{User: "user:anne", Object: "document:"}))
+
+	// a tuple key needs an object ID or a user
+	_, err = s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID, TupleKey: &openfgav1.ReadRequestTupleKey{Relation: "viewer"}})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+
+	_, err = s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID, TupleKey: &openfgav1.ReadRequestTupleKey{Object: "document:"}})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+}
+
+func TestReadWithConditionFilterHeaders(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	expired, err := structpb.NewStruct(map[string]any{"plan": "trial", "expired": true})
+	require.NoError(t, err)
+	active, err := structpb.NewStruct(map[string]any{"plan": "trial", "expired": false})
+	require.NoError(t, err)
+
+	storeID := ulid.Make().String()
+	err = ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:anne", "trial", expired),
+		tuple.NewTupleKeyWithCondition("document:2", "viewer", "user:anne", "trial", active),
+		tuple.NewTupleKeyWithCondition("document:3", "viewer", "user:bob", "trial", expired),
+		tuple.NewTupleKeyWithCondition("document:4", "viewer", "user:bob", "other", expired),
+		tuple.NewTupleKey("document:5", "viewer", "user:bob"),
+	})
+	require.NoError(t, err)
+
+	read := func(ctx context.Context, req *openfgav1.ReadRequest) ([]string, string) {
+		req.StoreId = storeID
+		resp, err := s.Read(ctx, req)
+		require.NoError(t, err)
+
+		var objects []string
+		for _, t := range resp.GetTuples() {
+			objects = append(objects, t.GetKey().GetObject())
+		}
+		return objects, resp.GetContinuationToken()
+	}
+
+	nameCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ReadConditionNameHeader, "trial"))
+	objects, _ := read(nameCtx, &openfgav1.ReadRequest{})
+	require.ElementsMatch(t, []string{"document:1", "document:2", "document:3"}, objects)
+
+	objects, _ = read(nameCtx, &openfgav1.ReadRequest{TupleKey: &openfgav1.ReadRequestTupleKey{User: "user:bob"}})
+	require.ElementsMatch(t, []string{"document:3"}, objects)
+
+	contextCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(
+		ReadConditionNameHeader, "trial",
+		ReadConditionContextHeader, `{"expired": true}`,
+	))
+	objects, _ = read(contextCtx, &openfgav1.ReadRequest{})
+	require.ElementsMatch(t, []string{"document:1", "document:3"}, objects)
+
+	// the pages of the context filter may be short, and the continuation tokens are bound to the filters
+	var all []string
+	var token string
+	for {
+		var page []string
+		page, token = read(contextCtx, &openfgav1.ReadRequest{PageSize: wrapperspb.Int32(1), ContinuationToken: token})
+		all = append(all, page...)
+		if token == "" {
+			break
+		}
+
+		_, err = s.Read(nameCtx, &openfgav1.ReadRequest{StoreId: storeID, ContinuationToken: token})
+		require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+	}
+	require.ElementsMatch(t, []string{"document:1", "document:3"}, all)
+
+	// the context filter requires the condition name filter and a JSON object
+	_, err = s.Read(metadata.NewIncomingContext(ctx, metadata.Pairs(ReadConditionContextHeader, `{"expired": true}`)),
+		&openfgav1.ReadRequest{StoreId: storeID})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+
+	_, err = s.Read(metadata.NewIncomingContext(ctx, metadata.Pairs(
+		ReadConditionNameHeader, "trial",
+		ReadConditionContextHeader, `["expired"]`,
+	)), &openfgav1.ReadRequest{StoreId: storeID})
+	require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+}
+
+func TestSignedContinuationTokens(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithChangelogHorizonOffset(0),
+		WithTokenEncoder(encoder.NewTokenCodec("key")))
+	t.Cleanup(s.Close)
+
+	storeID := ulid.Make().String()
+	err := ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:a", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:b", "viewer", "user:bob"),
+	})
+	require.NoError(t, err)
+
+	readResp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID, PageSize: wrapperspb.Int32(1)})
+	require.NoError(t, err)
+	require.Len(t, readResp.GetTuples(), 1)
+
+	changesResp, err := s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, PageSize: wrapperspb.Int32(1)})
+	require.NoError(t, err)
+	require.Len(t, changesResp.GetChanges(), 1)
+
+	tamper := func(token string) string {
+		raw, err := base64.URLEncoding.DecodeString(token)
+		require.NoError(t, err)
+		raw[1] ^= 1
+		return base64.URLEncoding.EncodeToString(raw)
+	}
+
+	_, err = s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID, PageSize: wrapperspb.Int32(1), ContinuationToken: tamper(readResp.GetContinuationToken())})
+	require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+
+	_, err = s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, PageSize: wrapperspb.Int32(1), ContinuationToken: tamper(changesResp.GetContinuationToken())})
+	require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+
+	readResp, err = s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID, PageSize: wrapperspb.Int32(1), ContinuationToken: readResp.GetContinuationToken()})
+	require.NoError(t, err)
+	require.Len(t, readResp.GetTuples(), 1)
+
+	changesResp, err = s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID, PageSize: wrapperspb.Int32(1), ContinuationToken: changesResp.GetContinuationToken()})
+	require.NoError(t, err)
+	require.Len(t, changesResp.GetChanges(), 1)
+}
+
+// laggingDatastore is a datastore that doesn't read its writes yet, like a lagging replica.
+type laggingDatastore struct {
+	storage.OpenFGADatastore
+}
+
+func (l *laggingDatastore) ChangeExists(context.Context, string, string) (bool, error) {
+	return false, nil
+}
+
+func TestConsistencyTokens(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	transport := &headerRecordingTransport{headers: map[string]string{}}
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport),
+		WithCheckQueryCacheEnabled(true), WithCheckQueryCacheTTL(time.Minute))
+	t.Cleanup(s.Close)
+
+	createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "openfga-test"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: language.MustTransformDSLToProto("model\n  schema 1.1\ntype user\ntype document\n  relations\n    define viewer: [user]").GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{StoreId: storeID, Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tk}}})
+	require.NoError(t, err)
+	require.NotEmpty(t, transport.headers[ConsistencyTokenHeader])
+
+	checkReq := &openfgav1.CheckRequest{StoreId: storeID, TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne")}
+	checkResp, err := s.Check(ctx, checkReq)
+	require.NoError(t, err)
+	require.True(t, checkResp.GetAllowed())
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{StoreId: storeID, Deletes: &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk)}}})
+	require.NoError(t, err)
+	token := transport.headers[ConsistencyTokenHeader]
+	tokenCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ConsistencyTokenHeader, token))
+
+	// without the token, the cached result from before the delete is returned
+	checkResp, err = s.Check(ctx, checkReq)
+	require.NoError(t, err)
+	require.True(t, checkResp.GetAllowed())
+
+	checkResp, err = s.Check(tokenCtx, checkReq)
+	require.NoError(t, err)
+	require.False(t, checkResp.GetAllowed())
+
+	listResp, err := s.ListObjects(tokenCtx, &openfgav1.ListObjectsRequest{StoreId: storeID, Type: "document", Relation: "viewer", User: "user:anne"})
+	require.NoError(t, err)
+	require.Empty(t, listResp.GetObjects())
+
+	t.Run("invalid_token", func(t *testing.T) {
+		invalidCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ConsistencyTokenHeader, "invalid"))
+		_, err := s.Check(invalidCtx, checkReq)
+		require.ErrorIs(t, err, serverErrors.InvalidConsistencyToken)
 
-		t.Run("resolution_depth_exceeded_error_streaming", func(t *testing.T) {
-			err := s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
-				StoreId:              store,
-				AuthorizationModelId: writeModelResp.GetAuthorizationModelId(),
-				Type:                 "document",
-				Relation:             "viewer",
-				User:                 "user:jon",
-			}, NewMockStreamServer())
+		// the token of a store can't be used for another one
+		otherStoreReq := &openfgav1.CheckRequest{StoreId: ulid.Make().String(), TupleKey: checkReq.GetTupleKey()}
+		_, err = s.Check(tokenCtx, otherStoreReq)
+		require.ErrorIs(t, err, serverErrors.InvalidConsistencyToken)
+	})
 
-			require.ErrorIs(t, err, serverErrors.AuthorizationModelResolutionTooComplex)
-		})
+	t.Run("lagging_datastore", func(t *testing.T) {
+		lagging := MustNewServerWithOpts(WithDatastore(&laggingDatastore{ds}), WithConsistencyTokenTimeout(20*time.Millisecond))
+		t.Cleanup(lagging.Close)
+
+		_, err := lagging.Check(tokenCtx, checkReq)
+		require.ErrorIs(t, err, serverErrors.ConsistencyTokenNotSatisfied)
+
+		_, err = lagging.ListObjects(tokenCtx, &openfgav1.ListObjectsRequest{StoreId: storeID, Type: "document", Relation: "viewer", User: "user:anne"})
+		require.ErrorIs(t, err, serverErrors.ConsistencyTokenNotSatisfied)
+
+		// requests without a token don't wait
+		_, err = lagging.Check(ctx, checkReq)
+		require.NoError(t, err)
+	})
+
+	t.Run("off_for_the_store", func(t *testing.T) {
+		flags, err := featureflags.Parse([]string{"consistency-tokens=" + ulid.Make().String()})
+		require.NoError(t, err)
+
+		transport := &headerRecordingTransport{headers: map[string]string{}}
+		off := MustNewServerWithOpts(WithDatastore(&laggingDatastore{ds}), WithTransport(transport), WithFeatureFlags(flags))
+		t.Cleanup(off.Close)
+
+		// the token is ignored rather than awaited
+		_, err = off.Check(tokenCtx, checkReq)
+		require.NoError(t, err)
+
+		_, err = off.Write(ctx, &openfgav1.WriteRequest{StoreId: storeID, Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tk}}})
+		require.NoError(t, err)
+		require.Empty(t, transport.headers[ConsistencyTokenHeader])
 	})
 }
 
-func TestAuthorizationModelInvalidSchemaVersion(t *testing.T) {
+func TestReadAuthorizationModelETag(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
 	})
 
 	ctx := context.Background()
-	store := ulid.Make().String()
-	modelID := ulid.Make().String()
 
-	mockController := gomock.NewController(t)
-	defer mockController.Finish()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
 
-	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	transport := &headerRecordingTransport{headers: map[string]string{}}
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport))
+	t.Cleanup(s.Close)
 
-	mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), store, modelID).AnyTimes().Return(&openfgav1.AuthorizationModel{
-		SchemaVersion: typesystem.SchemaVersion1_0,
-		TypeDefinitions: []*openfgav1.TypeDefinition{
-			{
-				Type: "user",
-			},
-			{
-				Type: "team",
-				Relations: map[string]*openfgav1.Userset{
-					"member": typesystem.This(),
-				},
-			},
-		},
-	}, nil)
+	createStoreResp, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "openfga-test"})
+	require.NoError(t, err)
+	storeID := createStoreResp.GetId()
+
+	writeModelResp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   typesystem.SchemaVersion1_1,
+		TypeDefinitions: language.MustTransformDSLToProto("model\n  schema 1.1\ntype user").GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+	modelID := writeModelResp.GetAuthorizationModelId()
+
+	t.Run("read_authorization_model", func(t *testing.T) {
+		transport.headers = map[string]string{}
+		req := &openfgav1.ReadAuthorizationModelRequest{StoreId: storeID, Id: modelID}
+
+		resp, err := s.ReadAuthorizationModel(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, modelID, resp.GetAuthorizationModel().GetId())
+		require.Equal(t, `"`+modelID+`"`, transport.headers[ETagHeader])
+		require.NotContains(t, transport.headers, httpmiddleware.XHttpCode)
+
+		matchCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(IfNoneMatchHeader, `"other", W/"`+modelID+`"`))
+		resp, err = s.ReadAuthorizationModel(matchCtx, req)
+		require.NoError(t, err)
+		require.Nil(t, resp.GetAuthorizationModel())
+		require.Equal(t, "304", transport.headers[httpmiddleware.XHttpCode])
+	})
+
+	t.Run("read_authorization_models", func(t *testing.T) {
+		transport.headers = map[string]string{}
+		req := &openfgav1.ReadAuthorizationModelsRequest{StoreId: storeID}
+
+		resp, err := s.ReadAuthorizationModels(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, resp.GetAuthorizationModels(), 1)
+		etag := transport.headers[ETagHeader]
+		require.NotEmpty(t, etag)
+
+		// the HTTP gateway forwards the header with its metadata prefix
+		matchCtx := metadata.NewIncomingContext(ctx, metadata.Pairs("grpcgateway-if-none-match", etag))
+		resp, err = s.ReadAuthorizationModels(matchCtx, req)
+		require.NoError(t, err)
+		require.Empty(t, resp.GetAuthorizationModels())
+		require.Equal(t, "304", transport.headers[httpmiddleware.XHttpCode])
+	})
+}
+
+func TestWriteAdmission(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	_, err := NewServerWithOpts(
+		WithDatastore(ds),
+		WithWriteAdmissionWebhookURL("http://localhost:9090/review"),
+		WithWriteAdmissionWebhookFailurePolicy("retry"),
+	)
+	require.ErrorContains(t, err, "retry")
 
 	s := MustNewServerWithOpts(
-		WithDatastore(mockDatastore),
+		WithDatastore(ds),
+		WithWriteAdmitter(admission.AdmitterFunc(func(_ context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+			for _, tk := range req.GetWrites().GetTupleKeys() {
+				if tk.GetObject() == "document:protected" {
+					return nil, serverErrors.WriteRejected("document:protected is protected")
+				}
+			}
+			return req, nil
+		})),
 	)
 	t.Cleanup(s.Close)
 
-	t.Run("invalid_schema_error_in_check", func(t *testing.T) {
-		_, err := s.Check(ctx, &openfgav1.CheckRequest{
-			StoreId:              store,
-			AuthorizationModelId: modelID,
-			TupleKey: tuple.NewCheckRequestTupleKey(
-				"team:abc",
-				"member",
-				"user:anne"),
-		})
-		require.Error(t, err)
-		e, ok := status.FromError(err)
-		require.True(t, ok)
-		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "admission"})
+	require.NoError(t, err)
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
 	})
+	require.NoError(t, err)
 
-	t.Run("invalid_schema_error_in_list_objects", func(t *testing.T) {
-		_, err := s.ListObjects(ctx, &openfgav1.ListObjectsRequest{
-			StoreId:              store,
-			AuthorizationModelId: modelID,
-			Type:                 "team",
-			Relation:             "member",
-			User:                 "user:anne",
+	write := func(object string) error {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: store.GetId(),
+			Writes: &openfgav1.WriteRequestWrites{
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey(object, "viewer", "user:anne")},
+			},
 		})
-		require.Error(t, err)
-		e, ok := status.FromError(err)
-		require.True(t, ok)
-		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
+		return err
+	}
+
+	require.NoError(t, write("document:public"))
+
+	err = write("document:protected")
+	require.ErrorIs(t, err, serverErrors.WriteRejected("document:protected is protected"))
+}
+
+func TestWriteCoalescing(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
 	})
 
-	t.Run("invalid_schema_error_in_streamed_list_objects", func(t *testing.T) {
-		err := s.StreamedListObjects(&openfgav1.StreamedListObjectsRequest{
-			StoreId:              store,
-			AuthorizationModelId: modelID,
-			Type:                 "team",
-			Relation:             "member",
-			User:                 "user:anne",
-		}, NewMockStreamServer())
-		require.Error(t, err)
-		e, ok := status.FromError(err)
-		require.True(t, ok)
-		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	_, err := NewServerWithOpts(WithDatastore(ds), WithWriteCoalescingEnabled(true), WithWriteCoalescingWindow(0))
+	require.ErrorContains(t, err, "write coalescing window")
+
+	transport := &headerRecordingTransport{headers: map[string]string{}}
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithTransport(transport),
+		WithWriteCoalescingEnabled(true),
+		WithWriteCoalescingWindow(20*time.Millisecond),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "coalescing"})
+	require.NoError(t, err)
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
 	})
+	require.NoError(t, err)
 
-	t.Run("invalid_schema_error_in_write", func(t *testing.T) {
+	write := func(object string) error {
 		_, err := s.Write(ctx, &openfgav1.WriteRequest{
-			StoreId:              store,
-			AuthorizationModelId: modelID,
+			StoreId: store.GetId(),
 			Writes: &openfgav1.WriteRequestWrites{
-				TupleKeys: []*openfgav1.TupleKey{
-					{
-						Object:   "repo:openfga/openfga",
-						Relation: "reader",
-						User:     "user:anne",
-					},
-				},
+				TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey(object, "viewer", "user:anne")},
 			},
 		})
-		require.Error(t, err)
-		e, ok := status.FromError(err)
-		require.True(t, ok)
-		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
-	})
+		return err
+	}
 
-	t.Run("invalid_schema_error_in_write_model", func(t *testing.T) {
-		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().Return(100)
+	require.NoError(t, write("document:existing"))
 
-		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
-			StoreId:       store,
-			SchemaVersion: typesystem.SchemaVersion1_0,
-			TypeDefinitions: language.MustTransformDSLToProto(`model
-	schema 1.1
-type repo
-`).GetTypeDefinitions(),
+	var wg errgroup.Group
+	for i := 0; i < 10; i++ {
+		object := fmt.Sprintf("document:%d", i)
+		wg.Go(func() error {
+			return write(object)
 		})
-		require.Error(t, err)
-		e, ok := status.FromError(err)
-		require.True(t, ok)
-		require.Equal(t, codes.Code(openfgav1.ErrorCode_invalid_authorization_model), e.Code(), err)
-	})
+	}
+	require.NoError(t, wg.Wait())
 
-	t.Run("invalid_schema_error_in_write_assertion", func(t *testing.T) {
-		_, err := s.WriteAssertions(ctx, &openfgav1.WriteAssertionsRequest{
-			StoreId:              store,
-			AuthorizationModelId: modelID,
-			Assertions: []*openfgav1.Assertion{{
-				TupleKey:    tuple.NewAssertionTupleKey("repo:test", "reader", "user:elbuo"),
-				Expectation: false,
-			}},
-		})
-		require.Error(t, err)
-		e, ok := status.FromError(err)
-		require.True(t, ok)
-		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), e.Code())
+	// a request that fails fails on its own
+	err = write("document:existing")
+	require.Error(t, err)
+
+	resp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: store.GetId()})
+	require.NoError(t, err)
+	require.Len(t, resp.GetTuples(), 11)
+
+	// the consistency token of a coalesced request names its change
+	require.NoError(t, write("document:latest"))
+	tokenCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ConsistencyTokenHeader, transport.headers[ConsistencyTokenHeader]))
+	checkResp, err := s.Check(tokenCtx, &openfgav1.CheckRequest{
+		StoreId:  store.GetId(),
+		TupleKey: tuple.NewCheckRequestTupleKey("document:latest", "viewer", "user:anne"),
 	})
+	require.NoError(t, err)
+	require.True(t, checkResp.GetAllowed())
 }
 
-func TestDefaultMaxConcurrentReadSettings(t *testing.T) {
+func TestWriteDryRun(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
 	})
 
-	cfg := serverconfig.DefaultConfig()
-	require.EqualValues(t, math.MaxUint32, cfg.MaxConcurrentReadsForCheck)
-	require.EqualValues(t, math.MaxUint32, cfg.MaxConcurrentReadsForListObjects)
+	ctx := context.Background()
 
-	s := MustNewServerWithOpts(
-		WithDatastore(memory.New()),
-	)
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	transport := &headerRecordingTransport{headers: map[string]string{}}
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport))
 	t.Cleanup(s.Close)
-	require.EqualValues(t, math.MaxUint32, s.maxConcurrentReadsForCheck)
-	require.EqualValues(t, math.MaxUint32, s.maxConcurrentReadsForListObjects)
-}
 
-func TestDelegateCheckResolver(t *testing.T) {
-	t.Cleanup(func() {
-		goleak.VerifyNone(t)
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "dry-run"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
 	})
-	t.Run("default_check_resolver_alone", func(t *testing.T) {
-		cfg := serverconfig.DefaultConfig()
-		require.False(t, cfg.DispatchThrottling.Enabled)
-		require.False(t, cfg.CheckQueryCache.Enabled)
+	require.NoError(t, err)
 
-		ds := memory.New()
-		t.Cleanup(ds.Close)
-		s := MustNewServerWithOpts(
-			WithDatastore(ds),
-		)
-		t.Cleanup(s.Close)
-		require.Nil(t, s.dispatchThrottlingCheckResolver)
-		require.False(t, s.dispatchThrottlingCheckResolverEnabled)
+	existing := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes:  &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{existing}},
+	})
+	require.NoError(t, err)
 
-		require.False(t, s.checkQueryCacheEnabled)
-		require.Nil(t, s.cachedCheckResolver)
+	dryRunCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(DryRunHeader, "true"))
 
-		require.NotNil(t, s.checkResolver)
-		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+	t.Run("valid_request_is_not_committed", func(t *testing.T) {
+		transport.headers = map[string]string{}
 
-		localCheckResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.LocalChecker)
-		require.True(t, ok)
+		_, err := s.Write(dryRunCtx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+				tuple.NewTupleKey("document:3", "viewer", "user:anne"),
+			}},
+			Deletes: &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{
+				tuple.TupleKeyToTupleKeyWithoutCondition(existing),
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "2", transport.headers[DryRunWriteCountHeader])
+		require.Equal(t, "1", transport.headers[DryRunDeleteCountHeader])
+		require.NotContains(t, transport.headers, ConsistencyTokenHeader)
 
-		_, ok = localCheckResolver.GetDelegate().(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+		readResp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, readResp.GetTuples(), 1)
 	})
 
-	t.Run("dispatch_throttling_check_resolver_enabled", func(t *testing.T) {
-		ds := memory.New()
-		t.Cleanup(ds.Close)
-		const dispatchThreshold = 50
-		s := MustNewServerWithOpts(
-			WithDatastore(ds),
-			WithDispatchThrottlingCheckResolverEnabled(true),
-			WithDispatchThrottlingCheckResolverThreshold(dispatchThreshold),
-		)
-		t.Cleanup(s.Close)
+	t.Run("preconditions", func(t *testing.T) {
+		_, err := s.Write(dryRunCtx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes:  &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{existing}},
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), status.Code(err))
 
-		require.False(t, s.checkQueryCacheEnabled)
-		require.Nil(t, s.cachedCheckResolver)
+		_, err = s.Write(dryRunCtx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Deletes: &openfgav1.WriteRequestDeletes{TupleKeys: []*openfgav1.TupleKeyWithoutCondition{
+				tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:2", "viewer", "user:anne")),
+			}},
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_write_failed_due_to_invalid_input), status.Code(err))
+	})
 
-		require.True(t, s.dispatchThrottlingCheckResolverEnabled)
-		require.EqualValues(t, dispatchThreshold, s.dispatchThrottlingDefaultThreshold)
-		require.EqualValues(t, 0, s.dispatchThrottlingMaxThreshold)
-		require.NotNil(t, s.dispatchThrottlingCheckResolver)
-		require.NotNil(t, s.checkResolver)
-		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+	t.Run("validation", func(t *testing.T) {
+		_, err := s.Write(dryRunCtx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:2", "editor", "user:anne"),
+			}},
+		})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
 
-		dispatchThrottlingResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.DispatchThrottlingCheckResolver)
-		require.True(t, ok)
+		invalidCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(DryRunHeader, "maybe"))
+		_, err = s.Write(invalidCtx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+			}},
+		})
+		require.ErrorContains(t, err, DryRunHeader)
+	})
+
+	t.Run("admitter_is_told_about_the_dry_run", func(t *testing.T) {
+		var dryRuns []bool
+		admitting := MustNewServerWithOpts(WithDatastore(ds), WithWriteAdmitter(admission.AdmitterFunc(
+			func(ctx context.Context, req *openfgav1.WriteRequest) (*openfgav1.WriteRequest, error) {
+				dryRuns = append(dryRuns, admission.IsDryRun(ctx))
+				return req, nil
+			})))
+		t.Cleanup(admitting.Close)
+
+		req := &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+				tuple.NewTupleKey("document:4", "viewer", "user:anne"),
+			}},
+		}
+		_, err := admitting.Write(dryRunCtx, req)
+		require.NoError(t, err)
 
-		localChecker, ok := dispatchThrottlingResolver.GetDelegate().(*graph.LocalChecker)
-		require.True(t, ok)
+		_, err = admitting.Write(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, []bool{true, false}, dryRuns)
+	})
+}
 
-		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+func TestListObjectsShards(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
 	})
 
-	t.Run("dispatch_throttling_check_resolver_enabled_zero_max_threshold", func(t *testing.T) {
-		ds := memory.New()
-		t.Cleanup(ds.Close)
-		const dispatchThreshold = 50
-		s := MustNewServerWithOpts(
-			WithDatastore(ds),
-			WithDispatchThrottlingCheckResolverEnabled(true),
-			WithDispatchThrottlingCheckResolverThreshold(dispatchThreshold),
-			WithDispatchThrottlingCheckResolverMaxThreshold(0),
-		)
-		t.Cleanup(s.Close)
+	ctx := context.Background()
 
-		require.False(t, s.checkQueryCacheEnabled)
-		require.Nil(t, s.cachedCheckResolver)
+	ds := memory.New()
+	t.Cleanup(ds.Close)
 
-		require.True(t, s.dispatchThrottlingCheckResolverEnabled)
-		require.EqualValues(t, dispatchThreshold, s.dispatchThrottlingDefaultThreshold)
-		require.EqualValues(t, 0, s.dispatchThrottlingMaxThreshold)
-		require.NotNil(t, s.dispatchThrottlingCheckResolver)
-		require.NotNil(t, s.checkResolver)
-		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+	transport := &headerRecordingTransport{headers: map[string]string{}}
+	s := MustNewServerWithOpts(WithDatastore(ds), WithTransport(transport),
+		WithListObjectsMaxResults(2),
+		WithListObjectsShards(4),
+	)
+	t.Cleanup(s.Close)
 
-		dispatchThrottlingResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.DispatchThrottlingCheckResolver)
-		require.True(t, ok)
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "shards"})
+	require.NoError(t, err)
+	storeID := store.GetId()
 
-		localChecker, ok := dispatchThrottlingResolver.GetDelegate().(*graph.LocalChecker)
-		require.True(t, ok)
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
 
-		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+	var tuples []*openfgav1.TupleKey
+	var expected []string
+	for i := 0; i < 10; i++ {
+		object := fmt.Sprintf("document:%d", i)
+		tuples = append(tuples, tuple.NewTupleKey(object, "viewer", "user:anne"))
+		expected = append(expected, object)
+	}
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes:  &openfgav1.WriteRequestWrites{TupleKeys: tuples},
 	})
+	require.NoError(t, err)
 
-	t.Run("dispatch_throttling_check_resolver_enabled_non_zero_max_threshold", func(t *testing.T) {
-		ds := memory.New()
-		t.Cleanup(ds.Close)
-		const dispatchThreshold = 50
-		const maxDispatchThreshold = 60
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "document",
+		Relation: "viewer",
+		User:     "user:anne",
+	}
 
-		s := MustNewServerWithOpts(
-			WithDatastore(ds),
-			WithDispatchThrottlingCheckResolverEnabled(true),
-			WithDispatchThrottlingCheckResolverThreshold(dispatchThreshold),
-			WithDispatchThrottlingCheckResolverMaxThreshold(maxDispatchThreshold),
-		)
-		t.Cleanup(s.Close)
+	t.Run("pages_of_shards", func(t *testing.T) {
+		var objects []string
+		for _, shard := range []string{"0/2", "1/2"} {
+			token := ""
+			for {
+				md := metadata.Pairs(ListObjectsShardHeader, shard)
+				if token != "" {
+					md.Set(ListObjectsContinuationTokenHeader, token)
+				}
 
-		require.False(t, s.checkQueryCacheEnabled)
-		require.Nil(t, s.cachedCheckResolver)
+				transport.headers = map[string]string{}
+				resp, err := s.ListObjects(metadata.NewIncomingContext(ctx, md), req)
+				require.NoError(t, err)
+				require.LessOrEqual(t, len(resp.GetObjects()), 2)
+				objects = append(objects, resp.GetObjects()...)
 
-		require.True(t, s.dispatchThrottlingCheckResolverEnabled)
-		require.EqualValues(t, dispatchThreshold, s.dispatchThrottlingDefaultThreshold)
-		require.EqualValues(t, maxDispatchThreshold, s.dispatchThrottlingMaxThreshold)
-		require.NotNil(t, s.dispatchThrottlingCheckResolver)
-		require.NotNil(t, s.checkResolver)
-		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+				token = transport.headers[ListObjectsContinuationTokenHeader]
+				if token == "" {
+					break
+				}
+			}
+		}
 
-		dispatchThrottlingResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.DispatchThrottlingCheckResolver)
-		require.True(t, ok)
+		require.ElementsMatch(t, expected, objects)
+	})
 
-		localChecker, ok := dispatchThrottlingResolver.GetDelegate().(*graph.LocalChecker)
-		require.True(t, ok)
+	t.Run("invalid_headers", func(t *testing.T) {
+		_, err := s.ListObjects(metadata.NewIncomingContext(ctx, metadata.Pairs(ListObjectsShardHeader, "2/2")), req)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+		require.ErrorContains(t, err, ListObjectsShardHeader)
 
-		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+		_, err = s.ListObjects(metadata.NewIncomingContext(ctx, metadata.Pairs(ListObjectsContinuationTokenHeader, "token")), req)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+
+		_, err = s.ListObjects(metadata.NewIncomingContext(ctx, metadata.Pairs(ListObjectsPartialResultsHeader, "maybe")), req)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+		require.ErrorContains(t, err, ListObjectsPartialResultsHeader)
 	})
 
-	t.Run("cache_check_resolver_enabled", func(t *testing.T) {
-		ds := memory.New()
-		t.Cleanup(ds.Close)
-		s := MustNewServerWithOpts(
-			WithDatastore(ds),
-			WithCheckQueryCacheEnabled(true),
-		)
-		t.Cleanup(s.Close)
+	t.Run("partial_results_before_the_deadline", func(t *testing.T) {
+		transport.headers = map[string]string{}
+		md := metadata.Pairs(ListObjectsShardHeader, "0/1", ListObjectsPartialResultsHeader, "true")
+		resp, err := s.ListObjects(metadata.NewIncomingContext(ctx, md), req)
+		require.NoError(t, err)
+		require.Len(t, resp.GetObjects(), 2)
+		require.NotEmpty(t, transport.headers[ListObjectsContinuationTokenHeader])
+		require.NotContains(t, transport.headers, ListObjectsTruncatedHeader)
+	})
+}
 
-		require.False(t, s.dispatchThrottlingCheckResolverEnabled)
-		require.Nil(t, s.dispatchThrottlingCheckResolver)
+func TestListObjectsCandidates(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
 
-		require.True(t, s.checkQueryCacheEnabled)
-		require.NotNil(t, s.cachedCheckResolver)
-		require.NotNil(t, s.checkResolver)
-		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+	ctx := context.Background()
 
-		cachedCheckResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.CachedCheckResolver)
-		require.True(t, ok)
+	ds := memory.New()
+	t.Cleanup(ds.Close)
 
-		localChecker, ok := cachedCheckResolver.GetDelegate().(*graph.LocalChecker)
-		require.True(t, ok)
+	s := MustNewServerWithOpts(WithDatastore(ds), WithListObjectsMaxResults(5))
+	t.Cleanup(s.Close)
 
-		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
-	})
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "candidates"})
+	require.NoError(t, err)
+	storeID := store.GetId()
 
-	t.Run("both_dispatch_throttling_and_cache_check_resolver_enabled", func(t *testing.T) {
-		ds := memory.New()
-		t.Cleanup(ds.Close)
-		s := MustNewServerWithOpts(
-			WithDatastore(ds),
-			WithCheckQueryCacheEnabled(true),
-			WithDispatchThrottlingCheckResolverEnabled(true),
-			WithDispatchThrottlingCheckResolverThreshold(50),
-			WithDispatchThrottlingCheckResolverMaxThreshold(100),
-		)
-		t.Cleanup(s.Close)
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
 
-		require.True(t, s.dispatchThrottlingCheckResolverEnabled)
-		require.EqualValues(t, 50, s.dispatchThrottlingDefaultThreshold)
-		require.EqualValues(t, 100, s.dispatchThrottlingMaxThreshold)
-		require.NotNil(t, s.dispatchThrottlingCheckResolver)
-		require.NotNil(t, s.checkResolver)
-		cycleDetectionCheckResolver, ok := s.checkResolver.(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+	var tuples []*openfgav1.TupleKey
+	for i := 0; i < 10; i += 2 {
+		tuples = append(tuples, tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne"))
+	}
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes:  &openfgav1.WriteRequestWrites{TupleKeys: tuples},
+	})
+	require.NoError(t, err)
 
-		dispatchThrottlingResolver, ok := cycleDetectionCheckResolver.GetDelegate().(*graph.DispatchThrottlingCheckResolver)
-		require.True(t, ok)
+	req := &openfgav1.ListObjectsRequest{
+		StoreId:  storeID,
+		Type:     "document",
+		Relation: "viewer",
+		User:     "user:anne",
+	}
 
-		require.True(t, s.checkQueryCacheEnabled)
-		require.NotNil(t, s.cachedCheckResolver)
+	listObjects := func(candidates ...string) (*openfgav1.ListObjectsResponse, error) {
+		md := metadata.Pairs()
+		for _, value := range candidates {
+			md.Append(ListObjectsCandidatesHeader, value)
+		}
+		return s.ListObjects(metadata.NewIncomingContext(ctx, md), req)
+	}
 
-		cachedCheckResolver, ok := dispatchThrottlingResolver.GetDelegate().(*graph.CachedCheckResolver)
-		require.True(t, ok)
+	t.Run("permitted_candidates", func(t *testing.T) {
+		resp, err := listObjects("8, 3,4", "0")
+		require.NoError(t, err)
+		require.Equal(t, []string{"document:8", "document:4", "document:0"}, resp.GetObjects())
+	})
 
-		localChecker, ok := cachedCheckResolver.GetDelegate().(*graph.LocalChecker)
-		require.True(t, ok)
+	t.Run("invalid_candidates", func(t *testing.T) {
+		_, err := listObjects("1,document:2")
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+		require.ErrorContains(t, err, ListObjectsCandidatesHeader)
 
-		_, ok = localChecker.GetDelegate().(*graph.CycleDetectionCheckResolver)
-		require.True(t, ok)
+		_, err = listObjects("1,2,3,4,5,6")
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+		require.ErrorContains(t, err, "more than 5 candidates")
 	})
 }
 
-func TestWriteAuthorizationModelWithSchema12(t *testing.T) {
+func TestDelegatedCheck(t *testing.T) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
 	})
+
 	ctx := context.Background()
-	storeID := ulid.Make().String()
 
-	mockController := gomock.NewController(t)
-	defer mockController.Finish()
+	ds := memory.New()
+	t.Cleanup(ds.Close)
 
-	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+	_, err := NewServerWithOpts(WithDatastore(ds), WithDelegatedCheckRelations([]string{"document#approved"}))
+	require.ErrorContains(t, err, "type#relation=url")
 
-	t.Run("accepts_request_with_schema_version_1.2", func(t *testing.T) {
-		s := MustNewServerWithOpts(
-			WithDatastore(mockDatastore),
-		)
-		defer s.Close()
+	// the approval of a document is decided by the office of the user
+	approved := delegation.EvaluatorFunc(func(_ context.Context, req *delegation.Request) (bool, error) {
+		if req.Context["office"] == nil {
+			return false, errors.New("the office is required")
+		}
+		return req.Context["office"] == "london", nil
+	})
 
-		mockDatastore.EXPECT().MaxTypesPerAuthorizationModel().Return(100)
-		mockDatastore.EXPECT().WriteAuthorizationModel(gomock.Any(), storeID, gomock.Any()).Return(nil)
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+		WithDelegatedCheckOperator(string(delegation.OperatorOverride)),
+		WithDelegatedCheckEvaluators(map[string]delegation.Evaluator{"document#approved": approved}),
+	)
+	t.Cleanup(s.Close)
 
-		_, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
-			StoreId:       storeID,
-			SchemaVersion: typesystem.SchemaVersion1_2,
-			TypeDefinitions: []*openfgav1.TypeDefinition{
-				{
-					Type: "user",
-					Metadata: &openfgav1.Metadata{
-						Relations:  nil,
-						Module:     "usermanagement",
-						SourceInfo: nil,
-					},
-				},
-			},
-		})
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "delegated-check"})
+	require.NoError(t, err)
+	storeID := store.GetId()
 
-		require.NoError(t, err)
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define owner: [user]
+    define approved: [user]
+    define viewer: owner and approved`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId: storeID,
+		Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "owner", "user:anne"),
+		}},
 	})
+	require.NoError(t, err)
+
+	check := func(user string, reqContext map[string]interface{}) (*openfgav1.CheckResponse, error) {
+		checkContext, err := structpb.NewStruct(reqContext)
+		require.NoError(t, err)
+
+		return s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", user),
+			Context:  checkContext,
+		})
+	}
+
+	resp, err := check("user:anne", map[string]interface{}{"office": "london"})
+	require.NoError(t, err)
+	require.True(t, resp.GetAllowed())
+
+	resp, err = check("user:anne", map[string]interface{}{"office": "paris"})
+	require.NoError(t, err)
+	require.False(t, resp.GetAllowed())
+
+	resp, err = check("user:bob", map[string]interface{}{"office": "london"})
+	require.NoError(t, err)
+	require.False(t, resp.GetAllowed())
+
+	_, err = check("user:anne", map[string]interface{}{})
+	require.Equal(t, codes.Code(openfgav1.InternalErrorCode_unavailable), status.Code(err))
+	require.ErrorContains(t, err, "delegated relation")
 }
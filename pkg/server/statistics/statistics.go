@@ -0,0 +1,452 @@
+// Package statistics collects approximate statistics about the tuples of each store, such as the
+// number of tuples of each relation and how many tuples each object and user has, so that operators
+// can spot the hot spots of a model and the Check planner can choose strategies before it has
+// observed the relations itself.
+package statistics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+const (
+	// DefaultInterval is how often the statistics are collected by default.
+	DefaultInterval = 10 * time.Minute
+
+	// DefaultMaxTuplesPerStore is the default maximum number of tuples scanned per store.
+	DefaultMaxTuplesPerStore = 100000
+
+	readPageSize = 100
+)
+
+var relationTuplesGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: build.ProjectName,
+	Name:      "tuple_statistics_relation_tuples",
+	Help:      "The approximate number of tuples of each relation, across all the stores.",
+}, []string{"object_type", "relation"})
+
+var relationMaxTuplesPerObjectGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: build.ProjectName,
+	Name:      "tuple_statistics_relation_max_tuples_per_object",
+	Help:      "The approximate maximum number of tuples of a single object of each relation, across all the stores.",
+}, []string{"object_type", "relation"})
+
+// UserTypeStatistics describes the tuples of a relation whose user is of a given type.
+type UserTypeStatistics struct {
+	// UserType is the type of the users, e.g. 'user', 'user:*' or 'group#member'.
+	UserType   string `json:"user_type"`
+	TupleCount int64  `json:"tuple_count"`
+	UserCount  int64  `json:"user_count"`
+
+	// MeanTuplesPerUser is the mean number of tuples of the relation that a single user has.
+	MeanTuplesPerUser float64 `json:"mean_tuples_per_user"`
+}
+
+// RelationStatistics describes the tuples of an object type and relation.
+type RelationStatistics struct {
+	ObjectType  string `json:"object_type"`
+	Relation    string `json:"relation"`
+	TupleCount  int64  `json:"tuple_count"`
+	ObjectCount int64  `json:"object_count"`
+
+	// UsersetTupleCount is the number of tuples whose user is a userset, e.g. 'group:eng#member'.
+	UsersetTupleCount int64 `json:"userset_tuple_count"`
+
+	// MeanTuplesPerObject and MaxTuplesPerObject describe the fan-out of the relation, i.e. how many
+	// tuples Check reads when it expands the relation of a single object.
+	MeanTuplesPerObject float64 `json:"mean_tuples_per_object"`
+	MaxTuplesPerObject  int64   `json:"max_tuples_per_object"`
+
+	UserTypes []UserTypeStatistics `json:"user_types"`
+}
+
+// StoreStatistics describes the tuples of a store.
+type StoreStatistics struct {
+	StoreID     string    `json:"store_id"`
+	CollectedAt time.Time `json:"collected_at"`
+	TupleCount  int64     `json:"tuple_count"`
+
+	// Sampled is true if the store has more tuples than the maximum number of tuples scanned per store,
+	// in which case the statistics only describe the tuples that were scanned.
+	Sampled bool `json:"sampled"`
+
+	Relations []RelationStatistics `json:"relations"`
+}
+
+// Collector periodically scans the tuples of every store and keeps the statistics of the last scan.
+type Collector struct {
+	datastore         storage.OpenFGADatastore
+	logger            logger.Logger
+	interval          time.Duration
+	maxTuplesPerStore int
+	listeners         []func(*StoreStatistics)
+
+	mu     sync.RWMutex
+	stores map[string]*StoreStatistics
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// CollectorOption defines an option that can be used to change the behavior of a [Collector].
+type CollectorOption func(c *Collector)
+
+// WithLogger sets the logger of the [Collector].
+func WithLogger(l logger.Logger) CollectorOption {
+	return func(c *Collector) {
+		c.logger = l
+	}
+}
+
+// WithInterval sets how often the statistics are collected. If the interval is 0, they are only
+// collected when Collect is called.
+func WithInterval(interval time.Duration) CollectorOption {
+	return func(c *Collector) {
+		c.interval = interval
+	}
+}
+
+// WithMaxTuplesPerStore sets the maximum number of tuples scanned per store, which bounds the cost of
+// a collection. The statistics of larger stores are sampled from the first tuples.
+func WithMaxTuplesPerStore(limit int) CollectorOption {
+	return func(c *Collector) {
+		c.maxTuplesPerStore = limit
+	}
+}
+
+// WithListener registers a function that is called with the statistics of each store whenever they
+// are collected.
+func WithListener(listener func(*StoreStatistics)) CollectorOption {
+	return func(c *Collector) {
+		c.listeners = append(c.listeners, listener)
+	}
+}
+
+// NewCollector constructs a [Collector] of the statistics of the stores of the datastore. If the
+// interval is not 0, the statistics are collected right away and then every interval. You must call
+// Close on it after you are done using it.
+func NewCollector(datastore storage.OpenFGADatastore, opts ...CollectorOption) *Collector {
+	c := &Collector{
+		datastore:         datastore,
+		logger:            logger.NewNoopLogger(),
+		interval:          DefaultInterval,
+		maxTuplesPerStore: DefaultMaxTuplesPerStore,
+		stores:            map[string]*StoreStatistics{},
+		stop:              make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.interval > 0 {
+		c.done.Add(1)
+		go c.collectPeriodically()
+	}
+
+	return c
+}
+
+// Close stops collecting the statistics and waits for an ongoing collection to be cancelled.
+func (c *Collector) Close() {
+	close(c.stop)
+	c.done.Wait()
+}
+
+// Get returns the statistics of the store, or false if they weren't collected yet.
+func (c *Collector) Get(storeID string) (*StoreStatistics, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats, ok := c.stores[storeID]
+	return stats, ok
+}
+
+func (c *Collector) collectPeriodically() {
+	defer c.done.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Collect(ctx); err != nil && ctx.Err() == nil {
+			c.logger.Error("failed to collect tuple statistics", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect scans the tuples of every store and replaces the statistics of the previous collection.
+// The statistics of the stores that no longer exist are dropped.
+func (c *Collector) Collect(ctx context.Context) error {
+	stores := map[string]*StoreStatistics{}
+
+	var continuationToken string
+	for {
+		page, token, err := c.datastore.ListStores(ctx, storage.ListStoresFilter{}, storage.NewPaginationOptions(storage.DefaultPageSize, continuationToken))
+		if err != nil {
+			return err
+		}
+
+		for _, store := range page {
+			stats, err := c.CollectStore(ctx, store.GetId())
+			if err != nil {
+				return err
+			}
+			stores[store.GetId()] = stats
+		}
+
+		if len(token) == 0 {
+			break
+		}
+		continuationToken = string(token)
+	}
+
+	c.mu.Lock()
+	c.stores = stores
+	c.mu.Unlock()
+
+	updateMetrics(stores)
+
+	return nil
+}
+
+// CollectStore scans the tuples of the store, up to the maximum number of tuples per store, and
+// returns their statistics. They are also kept until the next collection.
+func (c *Collector) CollectStore(ctx context.Context, storeID string) (*StoreStatistics, error) {
+	acc := newAccumulator()
+	sampled := false
+
+	var continuationToken string
+	for {
+		tuples, token, err := c.datastore.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, storage.NewPaginationOptions(readPageSize, continuationToken))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tuples {
+			if c.maxTuplesPerStore > 0 && acc.tupleCount >= int64(c.maxTuplesPerStore) {
+				sampled = true
+				break
+			}
+			acc.add(t.GetKey())
+		}
+
+		if sampled || len(token) == 0 {
+			break
+		}
+		continuationToken = string(token)
+	}
+
+	stats := acc.statistics(storeID)
+	stats.Sampled = sampled
+
+	c.mu.Lock()
+	c.stores[storeID] = stats
+	c.mu.Unlock()
+
+	for _, listener := range c.listeners {
+		listener(stats)
+	}
+
+	return stats, nil
+}
+
+func updateMetrics(stores map[string]*StoreStatistics) {
+	relationTuplesGauge.Reset()
+	relationMaxTuplesPerObjectGauge.Reset()
+
+	maxTuplesPerObject := map[[2]string]int64{}
+	for _, stats := range stores {
+		for _, relation := range stats.Relations {
+			relationTuplesGauge.WithLabelValues(relation.ObjectType, relation.Relation).Add(float64(relation.TupleCount))
+
+			key := [2]string{relation.ObjectType, relation.Relation}
+			if relation.MaxTuplesPerObject > maxTuplesPerObject[key] {
+				maxTuplesPerObject[key] = relation.MaxTuplesPerObject
+			}
+		}
+	}
+
+	for key, count := range maxTuplesPerObject {
+		relationMaxTuplesPerObjectGauge.WithLabelValues(key[0], key[1]).Set(float64(count))
+	}
+}
+
+type relationAccumulator struct {
+	tupleCount        int64
+	usersetTupleCount int64
+	tuplesPerObject   map[string]int64
+
+	// tuplesPerUser maps a user type to the number of tuples of each user of the type.
+	tuplesPerUser map[string]map[string]int64
+}
+
+type accumulator struct {
+	tupleCount int64
+	relations  map[string]*relationAccumulator
+}
+
+func newAccumulator() *accumulator {
+	return &accumulator{relations: map[string]*relationAccumulator{}}
+}
+
+func (a *accumulator) add(tk *openfgav1.TupleKey) {
+	objectType := tuple.GetType(tk.GetObject())
+	key := tuple.ToObjectRelationString(objectType, tk.GetRelation())
+
+	relation, ok := a.relations[key]
+	if !ok {
+		relation = &relationAccumulator{
+			tuplesPerObject: map[string]int64{},
+			tuplesPerUser:   map[string]map[string]int64{},
+		}
+		a.relations[key] = relation
+	}
+
+	a.tupleCount++
+	relation.tupleCount++
+	relation.tuplesPerObject[tk.GetObject()]++
+
+	user := tk.GetUser()
+	userObject, userRelation := tuple.SplitObjectRelation(user)
+	userType := tuple.GetType(userObject)
+	switch {
+	case userRelation != "":
+		relation.usersetTupleCount++
+		userType = tuple.ToObjectRelationString(userType, userRelation)
+	case tuple.IsTypedWildcard(user):
+		userType = user
+	}
+
+	users, ok := relation.tuplesPerUser[userType]
+	if !ok {
+		users = map[string]int64{}
+		relation.tuplesPerUser[userType] = users
+	}
+	users[user]++
+}
+
+func (a *accumulator) statistics(storeID string) *StoreStatistics {
+	stats := &StoreStatistics{
+		StoreID:     storeID,
+		CollectedAt: time.Now().UTC(),
+		TupleCount:  a.tupleCount,
+		Relations:   make([]RelationStatistics, 0, len(a.relations)),
+	}
+
+	for key, relation := range a.relations {
+		objectType, relationName := tuple.SplitObjectRelation(key)
+
+		relationStats := RelationStatistics{
+			ObjectType:          objectType,
+			Relation:            relationName,
+			TupleCount:          relation.tupleCount,
+			ObjectCount:         int64(len(relation.tuplesPerObject)),
+			UsersetTupleCount:   relation.usersetTupleCount,
+			MeanTuplesPerObject: float64(relation.tupleCount) / float64(len(relation.tuplesPerObject)),
+			UserTypes:           make([]UserTypeStatistics, 0, len(relation.tuplesPerUser)),
+		}
+
+		for _, count := range relation.tuplesPerObject {
+			if count > relationStats.MaxTuplesPerObject {
+				relationStats.MaxTuplesPerObject = count
+			}
+		}
+
+		for userType, users := range relation.tuplesPerUser {
+			var tupleCount int64
+			for _, count := range users {
+				tupleCount += count
+			}
+
+			relationStats.UserTypes = append(relationStats.UserTypes, UserTypeStatistics{
+				UserType:          userType,
+				TupleCount:        tupleCount,
+				UserCount:         int64(len(users)),
+				MeanTuplesPerUser: float64(tupleCount) / float64(len(users)),
+			})
+		}
+		sort.Slice(relationStats.UserTypes, func(i, j int) bool {
+			return relationStats.UserTypes[i].UserType < relationStats.UserTypes[j].UserType
+		})
+
+		stats.Relations = append(stats.Relations, relationStats)
+	}
+
+	sort.Slice(stats.Relations, func(i, j int) bool {
+		if stats.Relations[i].ObjectType != stats.Relations[j].ObjectType {
+			return stats.Relations[i].ObjectType < stats.Relations[j].ObjectType
+		}
+		return stats.Relations[i].Relation < stats.Relations[j].Relation
+	})
+
+	return stats
+}
+
+// Client is the subset of the OpenFGA service that is used to authorize reading the statistics of a store.
+type Client interface {
+	GetStore(ctx context.Context, in *openfgav1.GetStoreRequest, opts ...grpc.CallOption) (*openfgav1.GetStoreResponse, error)
+}
+
+// NewHTTPHandler returns a handler for the HTTP gateway that returns the statistics of the 'store_id'
+// path parameter. The store is first read through the client with the Authorization header of the
+// request, so that the request is authenticated like any other request.
+func NewHTTPHandler(mux *runtime.ServeMux, client Client, collector *Collector) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+
+		storeID := pathParams["store_id"]
+		if _, err := client.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: storeID}); err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		stats, ok := collector.Get(storeID)
+		if !ok {
+			err := status.Error(codes.NotFound, "the statistics of the store haven't been collected yet")
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stats)
+	}
+}
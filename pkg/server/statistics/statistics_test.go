@@ -0,0 +1,169 @@
+package statistics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func setup(t *testing.T) (storage.OpenFGADatastore, string) {
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	store, err := ds.CreateStore(context.Background(), &openfgav1.Store{Id: ulid.Make().String(), Name: "statistics"})
+	require.NoError(t, err)
+
+	err = ds.Write(context.Background(), store.GetId(), nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:1", "viewer", "user:bob"),
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:2", "viewer", "user:anne"),
+		tuple.NewTupleKey("document:3", "viewer", "user:*"),
+		tuple.NewTupleKey("group:eng", "member", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	return ds, store.GetId()
+}
+
+func TestCollect(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds, storeID := setup(t)
+
+	var listened []*StoreStatistics
+	collector := NewCollector(ds, WithInterval(0), WithListener(func(stats *StoreStatistics) {
+		listened = append(listened, stats)
+	}))
+	t.Cleanup(collector.Close)
+
+	_, ok := collector.Get(storeID)
+	require.False(t, ok)
+
+	require.NoError(t, collector.Collect(context.Background()))
+
+	stats, ok := collector.Get(storeID)
+	require.True(t, ok)
+	require.Equal(t, []*StoreStatistics{stats}, listened)
+	require.Equal(t, storeID, stats.StoreID)
+	require.EqualValues(t, 6, stats.TupleCount)
+	require.False(t, stats.Sampled)
+
+	require.Equal(t, []RelationStatistics{
+		{
+			ObjectType:          "document",
+			Relation:            "viewer",
+			TupleCount:          5,
+			ObjectCount:         3,
+			UsersetTupleCount:   1,
+			MeanTuplesPerObject: 5.0 / 3,
+			MaxTuplesPerObject:  3,
+			UserTypes: []UserTypeStatistics{
+				{UserType: "group#member", TupleCount: 1, UserCount: 1, MeanTuplesPerUser: 1},
+				{UserType: "user", TupleCount: 3, UserCount: 2, MeanTuplesPerUser: 1.5},
+				{UserType: "user:*", TupleCount: 1, UserCount: 1, MeanTuplesPerUser: 1},
+			},
+		},
+		{
+			ObjectType:          "group",
+			Relation:            "member",
+			TupleCount:          1,
+			ObjectCount:         1,
+			MeanTuplesPerObject: 1,
+			MaxTuplesPerObject:  1,
+			UserTypes: []UserTypeStatistics{
+				{UserType: "user", TupleCount: 1, UserCount: 1, MeanTuplesPerUser: 1},
+			},
+		},
+	}, stats.Relations)
+
+	t.Run("sampled", func(t *testing.T) {
+		collector := NewCollector(ds, WithInterval(0), WithMaxTuplesPerStore(2))
+		t.Cleanup(collector.Close)
+
+		stats, err := collector.CollectStore(context.Background(), storeID)
+		require.NoError(t, err)
+		require.True(t, stats.Sampled)
+		require.EqualValues(t, 2, stats.TupleCount)
+	})
+
+	t.Run("deleted_store", func(t *testing.T) {
+		require.NoError(t, ds.DeleteStore(context.Background(), storeID))
+		require.NoError(t, collector.Collect(context.Background()))
+
+		_, ok := collector.Get(storeID)
+		require.False(t, ok)
+	})
+}
+
+// storeClient reads the stores from the datastore, and records the outgoing authorization header.
+type storeClient struct {
+	datastore     storage.OpenFGADatastore
+	authorization []string
+}
+
+func (c *storeClient) GetStore(ctx context.Context, in *openfgav1.GetStoreRequest, _ ...grpc.CallOption) (*openfgav1.GetStoreResponse, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.authorization = md.Get("authorization")
+
+	store, err := c.datastore.GetStore(ctx, in.GetStoreId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &openfgav1.GetStoreResponse{Id: store.GetId(), Name: store.GetName()}, nil
+}
+
+func TestHTTPHandler(t *testing.T) {
+	ds, storeID := setup(t)
+	client := &storeClient{datastore: ds}
+
+	collector := NewCollector(ds, WithInterval(0))
+	t.Cleanup(collector.Close)
+
+	mux := runtime.NewServeMux()
+	require.NoError(t, mux.HandlePath(http.MethodGet, "/stores/{store_id}/statistics", NewHTTPHandler(mux, client, collector)))
+
+	serve := func(storeID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/stores/"+storeID+"/statistics", nil)
+		req.Header.Set("Authorization", "Bearer key")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	// not collected yet
+	require.Equal(t, http.StatusNotFound, serve(storeID).Code)
+
+	require.NoError(t, collector.Collect(context.Background()))
+
+	w := serve(storeID)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"Bearer key"}, client.authorization)
+
+	var stats StoreStatistics
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	require.Equal(t, storeID, stats.StoreID)
+	require.EqualValues(t, 6, stats.TupleCount)
+	require.Len(t, stats.Relations, 2)
+
+	require.Equal(t, http.StatusNotFound, serve(ulid.Make().String()).Code)
+}
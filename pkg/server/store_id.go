@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/metadata"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/idgen"
+)
+
+// StoreIDHeader is the ID of the store created by a CreateStore request, instead of a generated one,
+// e.g. so that the infrastructure-as-code tools create a store with the same ID in every environment.
+// It is a ULID of 26 upper case characters, and can't be the ID of an existing or deleted store. It
+// is rejected unless WithCallerSuppliedStoreIDsEnabled is set to true.
+const StoreIDHeader = "Openfga-Store-Id"
+
+// storeIDFromContext returns the ID of the StoreIDHeader of the request, or an empty ID if the request
+// doesn't have the header.
+func (s *Server) storeIDFromContext(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(StoreIDHeader)
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	if !s.callerSuppliedStoreIDsEnabled {
+		return "", serverErrors.ValidationError(fmt.Errorf("the %s header is not allowed, the caller-supplied store IDs are disabled", StoreIDHeader))
+	}
+
+	if len(values) > 1 {
+		return "", serverErrors.ValidationError(fmt.Errorf("invalid %s header, it must have a single value", StoreIDHeader))
+	}
+
+	if err := idgen.Validate(values[0]); err != nil {
+		return "", serverErrors.ValidationError(fmt.Errorf("invalid %s header, %w", StoreIDHeader, err))
+	}
+
+	return values[0], nil
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/metadata"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/server/idgen"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestCreateStoreWithCallerSuppliedID(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithIDScheme(idgen.SchemeUUIDv7),
+		WithCallerSuppliedStoreIDsEnabled(true),
+	)
+	t.Cleanup(s.Close)
+
+	withStoreID := func(id string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(StoreIDHeader, id))
+	}
+
+	requireReason := func(t *testing.T, err error, expected serverErrors.Reason) {
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, expected, reason)
+	}
+
+	t.Run("generated_ids_are_of_the_scheme", func(t *testing.T) {
+		store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "generated"})
+		require.NoError(t, err)
+
+		model, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         store.GetId(),
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: language.MustTransformDSLToProto("model\n  schema 1.1\ntype user").GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+
+		for _, id := range []string{store.GetId(), model.GetAuthorizationModelId()} {
+			parsed, err := ulid.ParseStrict(id)
+			require.NoError(t, err)
+			require.Equal(t, uuid.Version(7), uuid.UUID(parsed).Version())
+		}
+	})
+
+	t.Run("caller_supplied_id", func(t *testing.T) {
+		id := ulid.Make().String()
+
+		store, err := s.CreateStore(withStoreID(id), &openfgav1.CreateStoreRequest{Name: "supplied"})
+		require.NoError(t, err)
+		require.Equal(t, id, store.GetId())
+
+		_, err = s.CreateStore(withStoreID(id), &openfgav1.CreateStoreRequest{Name: "supplied"})
+		requireReason(t, err, serverErrors.ReasonStoreIDAlreadyExists)
+
+		// the ID of a deleted store isn't reused
+		_, err = s.DeleteStore(ctx, &openfgav1.DeleteStoreRequest{StoreId: id})
+		require.NoError(t, err)
+
+		_, err = s.CreateStore(withStoreID(id), &openfgav1.CreateStoreRequest{Name: "supplied"})
+		requireReason(t, err, serverErrors.ReasonStoreIDAlreadyExists)
+	})
+
+	t.Run("invalid_caller_supplied_ids", func(t *testing.T) {
+		for _, id := range []string{"", "my-store", "01hvmmbcmgznt3sed4z17ecxca"} {
+			_, err := s.CreateStore(withStoreID(id), &openfgav1.CreateStoreRequest{Name: "invalid"})
+			requireReason(t, err, serverErrors.ReasonValidationError)
+		}
+	})
+
+	t.Run("caller_supplied_ids_are_rejected_unless_enabled", func(t *testing.T) {
+		disabled := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(disabled.Close)
+
+		_, err := disabled.CreateStore(withStoreID(ulid.Make().String()), &openfgav1.CreateStoreRequest{Name: "disabled"})
+		requireReason(t, err, serverErrors.ReasonValidationError)
+	})
+
+	t.Run("unknown_scheme", func(t *testing.T) {
+		_, err := NewServerWithOpts(WithDatastore(ds), WithIDScheme("uuidv4"))
+		require.ErrorContains(t, err, "unknown ID scheme")
+	})
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc/metadata"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	// StoreLabelsHeader has the labels of a store, as comma separated 'key=value' pairs. On a
+	// CreateStore or UpdateStore request, it overwrites the labels of the store, and an empty value
+	// removes them. The CreateStore, UpdateStore and GetStore responses have the labels of the store.
+	StoreLabelsHeader = "Openfga-Store-Labels"
+
+	// ListStoresLabelsHeader has the labels of the stores returned by ListStores, as a JSON object of
+	// the labels by store ID. The stores without labels are omitted.
+	ListStoresLabelsHeader = "Openfga-List-Stores-Labels"
+
+	// The following headers filter the stores returned by ListStores. The name filter matches the
+	// stores whose name contains it, ignoring the case. The created at filters are RFC 3339
+	// timestamps: the stores created at or after, and before, them match. The labels filter, in the
+	// format of StoreLabelsHeader, matches the stores that have all of its labels. A continuation
+	// token is only valid with the filters it was returned for.
+	StoresNameContainsHeader  = "Openfga-Stores-Name-Contains"
+	StoresCreatedAfterHeader  = "Openfga-Stores-Created-After"
+	StoresCreatedBeforeHeader = "Openfga-Stores-Created-Before"
+	StoresLabelsHeader        = "Openfga-Stores-Labels"
+
+	// maxStoreLabels is the maximum number of labels of a store.
+	maxStoreLabels = 16
+
+	// storeLabelsCacheTTL is how long the labels of a store are cached by GetStoreLabels. The labels
+	// updated through another server apply after at most this duration.
+	storeLabelsCacheTTL = 10 * time.Second
+
+	// storeLabelsErrorCacheTTL is how long a failed lookup of the labels of a store is cached by
+	// GetStoreLabels, so that every request of a store doesn't hit a failing datastore.
+	storeLabelsErrorCacheTTL = time.Second
+)
+
+var (
+	// The keys and values are up to 63 characters long, like the labels of Kubernetes. The keys are lowercase.
+	storeLabelKeyRegex   = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]{0,61}[a-z0-9])?$`)
+	storeLabelValueRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?$`)
+)
+
+// cachedStoreLabels are the labels of a store, or the error of their lookup, cached by GetStoreLabels.
+type cachedStoreLabels struct {
+	labels map[string]string
+	err    error
+}
+
+// GetStoreLabels returns the labels of a store, which may be cached for up to 10 seconds. A failed
+// lookup is cached for a second. It looks up the labels that the storelabels middleware adds to the
+// requests.
+func (s *Server) GetStoreLabels(ctx context.Context, storeID string) (map[string]string, error) {
+	ctx, span := tracer.Start(ctx, "GetStoreLabels")
+	defer span.End()
+
+	if item := s.storeLabelsCache.Get(storeID); item != nil && !item.Expired() {
+		cached := item.Value()
+		return cached.labels, cached.err
+	}
+
+	labels, err := s.readStoreLabels(ctx, storeID)
+	if err != nil {
+		// the lookup failed because the request was canceled, not because of the datastore
+		if ctx.Err() == nil {
+			s.storeLabelsCache.Set(storeID, &cachedStoreLabels{err: err}, storeLabelsErrorCacheTTL)
+		}
+		return nil, err
+	}
+
+	s.storeLabelsCache.Set(storeID, &cachedStoreLabels{labels: labels}, storeLabelsCacheTTL)
+
+	return labels, nil
+}
+
+// readStoreLabels returns the labels of a store from the datastore.
+func (s *Server) readStoreLabels(ctx context.Context, storeID string) (map[string]string, error) {
+	labels, err := s.datastore.ReadStoreLabels(ctx, []string{storeID})
+	if err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	return labels[storeID], nil
+}
+
+// setListStoresLabelsHeader sets the ListStoresLabelsHeader of the response to the labels of the
+// stores, if any has labels.
+func (s *Server) setListStoresLabelsHeader(ctx context.Context, stores []*openfgav1.Store) error {
+	if len(stores) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(stores))
+	for _, store := range stores {
+		ids = append(ids, store.GetId())
+	}
+
+	labels, err := s.datastore.ReadStoreLabels(ctx, ids)
+	if err != nil {
+		return serverErrors.HandleError("", err)
+	}
+
+	if len(labels) == 0 {
+		return nil
+	}
+
+	header, err := json.Marshal(labels)
+	if err != nil {
+		return serverErrors.HandleError("", err)
+	}
+	s.transport.SetHeader(ctx, ListStoresLabelsHeader, string(header))
+
+	return nil
+}
+
+// parseStoreLabels parses labels in the format of StoreLabelsHeader.
+func parseStoreLabels(header, value string) (map[string]string, error) {
+	labels := map[string]string{}
+	if strings.TrimSpace(value) == "" {
+		return labels, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header, '%s' is not a 'key=value' pair", header, pair))
+		}
+
+		if !storeLabelKeyRegex.MatchString(key) {
+			return nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header, the label key '%s' must be up to 63 lowercase alphanumeric characters, '-', '_' or '.'", header, key))
+		}
+
+		if !storeLabelValueRegex.MatchString(val) {
+			return nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header, the value '%s' of the label '%s' must be up to 63 alphanumeric characters, '-', '_' or '.'", header, val, key))
+		}
+
+		if _, ok := labels[key]; ok {
+			return nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header, the label '%s' is repeated", header, key))
+		}
+		labels[key] = val
+	}
+
+	if len(labels) > maxStoreLabels {
+		return nil, serverErrors.ValidationError(fmt.Errorf("invalid %s header, a store can have up to %d labels", header, maxStoreLabels))
+	}
+
+	return labels, nil
+}
+
+// formatStoreLabels formats labels in the format of StoreLabelsHeader, sorted by key.
+func formatStoreLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// storeLabelsFromContext returns the labels of the StoreLabelsHeader of the request, or nil if the
+// request doesn't have the header.
+func storeLabelsFromContext(ctx context.Context) (map[string]string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(StoreLabelsHeader)
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	return parseStoreLabels(StoreLabelsHeader, strings.Join(values, ","))
+}
+
+// listStoresFilterFromContext returns the ListStores filters of the request headers.
+func listStoresFilterFromContext(ctx context.Context) (storage.ListStoresFilter, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(header string) string {
+		if values := md.Get(header); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	parseTime := func(header string) (time.Time, error) {
+		value := get(header)
+		if value == "" {
+			return time.Time{}, nil
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, serverErrors.ValidationError(fmt.Errorf("invalid %s header '%s', it must be an RFC 3339 timestamp", header, value))
+		}
+		return t, nil
+	}
+
+	createdAfter, err := parseTime(StoresCreatedAfterHeader)
+	if err != nil {
+		return storage.ListStoresFilter{}, err
+	}
+
+	createdBefore, err := parseTime(StoresCreatedBeforeHeader)
+	if err != nil {
+		return storage.ListStoresFilter{}, err
+	}
+
+	filter := storage.ListStoresFilter{
+		NameContains:  get(StoresNameContainsHeader),
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+	}
+
+	if values := md.Get(StoresLabelsHeader); len(values) > 0 {
+		labels, err := parseStoreLabels(StoresLabelsHeader, strings.Join(values, ","))
+		if err != nil {
+			return storage.ListStoresFilter{}, err
+		}
+		filter.Labels = labels
+	}
+
+	return filter, nil
+}
+
+// setStoreLabelsHeader sets the StoreLabelsHeader of the response to the labels, if any.
+func (s *Server) setStoreLabelsHeader(ctx context.Context, labels map[string]string) {
+	if len(labels) > 0 {
+		s.transport.SetHeader(ctx, StoreLabelsHeader, formatStoreLabels(labels))
+	}
+}
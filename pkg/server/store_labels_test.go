@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+func TestParseStoreLabels(t *testing.T) {
+	labels, err := parseStoreLabels(StoreLabelsHeader, " env=prod, team=IAM.core ")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"env": "prod", "team": "IAM.core"}, labels)
+	require.Equal(t, "env=prod,team=IAM.core", formatStoreLabels(labels))
+
+	labels, err = parseStoreLabels(StoreLabelsHeader, "")
+	require.NoError(t, err)
+	require.Empty(t, labels)
+
+	tooMany := make([]string, 0, maxStoreLabels+1)
+	for i := 0; i <= maxStoreLabels; i++ {
+		tooMany = append(tooMany, "key"+strings.Repeat("a", i)+"=value")
+	}
+
+	for name, value := range map[string]string{
+		"missing_value":   "env",
+		"empty_value":     "env=",
+		"uppercase_key":   "Env=prod",
+		"invalid_value":   "env=prod/eu",
+		"long_key":        strings.Repeat("a", 64) + "=prod",
+		"repeated_key":    "env=prod,env=staging",
+		"too_many_labels": strings.Join(tooMany, ","),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseStoreLabels(StoreLabelsHeader, value)
+			require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+		})
+	}
+}
+
+func TestStoreLabels(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	transport := &headerRecordingTransport{headers: map[string]string{}}
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithTransport(transport),
+	)
+	t.Cleanup(s.Close)
+
+	withHeaders := func(kv ...string) context.Context {
+		transport.headers = map[string]string{}
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(kv...))
+	}
+
+	eu, err := s.CreateStore(withHeaders(StoreLabelsHeader, "region=eu,env=prod"), &openfgav1.CreateStoreRequest{Name: "Payments EU"})
+	require.NoError(t, err)
+	require.Equal(t, "env=prod,region=eu", transport.headers[StoreLabelsHeader])
+
+	us, err := s.CreateStore(withHeaders(StoreLabelsHeader, "region=us,env=prod"), &openfgav1.CreateStoreRequest{Name: "payments us"})
+	require.NoError(t, err)
+
+	billing, err := s.CreateStore(withHeaders(), &openfgav1.CreateStoreRequest{Name: "billing"})
+	require.NoError(t, err)
+	require.NotContains(t, transport.headers, StoreLabelsHeader)
+
+	t.Run("invalid_labels_dont_create_the_store", func(t *testing.T) {
+		_, err := s.CreateStore(withHeaders(StoreLabelsHeader, "env"), &openfgav1.CreateStoreRequest{Name: "invalid"})
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+
+		resp, err := s.ListStores(withHeaders(StoresNameContainsHeader, "invalid"), &openfgav1.ListStoresRequest{})
+		require.NoError(t, err)
+		require.Empty(t, resp.GetStores())
+	})
+
+	t.Run("get_store_returns_the_labels", func(t *testing.T) {
+		_, err := s.GetStore(withHeaders(), &openfgav1.GetStoreRequest{StoreId: us.GetId()})
+		require.NoError(t, err)
+		require.Equal(t, "env=prod,region=us", transport.headers[StoreLabelsHeader])
+	})
+
+	listStores := func(t *testing.T, kv ...string) []string {
+		resp, err := s.ListStores(withHeaders(kv...), &openfgav1.ListStoresRequest{})
+		require.NoError(t, err)
+
+		var names []string
+		for _, store := range resp.GetStores() {
+			names = append(names, store.GetName())
+		}
+		return names
+	}
+
+	t.Run("list_stores_with_filters", func(t *testing.T) {
+		require.Equal(t, []string{"Payments EU", "payments us", "billing"}, listStores(t))
+		require.Equal(t, []string{"Payments EU", "payments us"}, listStores(t, StoresNameContainsHeader, "PAYMENTS"))
+		require.Equal(t, []string{"payments us"}, listStores(t, StoresLabelsHeader, "env=prod,region=us"))
+		require.Equal(t, []string{"Payments EU", "payments us", "billing"},
+			listStores(t, StoresCreatedAfterHeader, eu.GetCreatedAt().AsTime().Format(time.RFC3339)))
+		require.Empty(t, listStores(t, StoresCreatedBeforeHeader, eu.GetCreatedAt().AsTime().Add(-time.Second).Format(time.RFC3339)))
+	})
+
+	t.Run("list_stores_continuation_tokens_are_bound_to_the_filters", func(t *testing.T) {
+		filterCtx := withHeaders(StoresNameContainsHeader, "payments")
+		resp, err := s.ListStores(filterCtx, &openfgav1.ListStoresRequest{PageSize: wrapperspb.Int32(1)})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.GetContinuationToken())
+
+		resp, err = s.ListStores(withHeaders(StoresNameContainsHeader, "payments"), &openfgav1.ListStoresRequest{ContinuationToken: resp.GetContinuationToken()})
+		require.NoError(t, err)
+		require.Len(t, resp.GetStores(), 1)
+
+		first, err := s.ListStores(withHeaders(StoresNameContainsHeader, "payments"), &openfgav1.ListStoresRequest{PageSize: wrapperspb.Int32(1)})
+		require.NoError(t, err)
+
+		_, err = s.ListStores(withHeaders(StoresNameContainsHeader, "billing"), &openfgav1.ListStoresRequest{ContinuationToken: first.GetContinuationToken()})
+		require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+
+		_, err = s.ListStores(withHeaders(), &openfgav1.ListStoresRequest{ContinuationToken: first.GetContinuationToken()})
+		require.ErrorIs(t, err, serverErrors.InvalidContinuationToken)
+	})
+
+	t.Run("list_stores_returns_the_labels", func(t *testing.T) {
+		listStores(t)
+
+		var labels map[string]map[string]string
+		require.NoError(t, json.Unmarshal([]byte(transport.headers[ListStoresLabelsHeader]), &labels))
+		require.Equal(t, map[string]map[string]string{
+			eu.GetId(): {"env": "prod", "region": "eu"},
+			us.GetId(): {"env": "prod", "region": "us"},
+		}, labels)
+
+		listStores(t, StoresNameContainsHeader, "billing")
+		require.NotContains(t, transport.headers, ListStoresLabelsHeader)
+	})
+
+	t.Run("list_stores_with_invalid_filters", func(t *testing.T) {
+		for _, header := range []string{StoresCreatedAfterHeader, StoresCreatedBeforeHeader, StoresLabelsHeader} {
+			_, err := s.ListStores(withHeaders(header, "yesterday"), &openfgav1.ListStoresRequest{})
+			require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err), header)
+		}
+	})
+
+	t.Run("update_store", func(t *testing.T) {
+		resp, err := s.UpdateStore(withHeaders(), &openfgav1.UpdateStoreRequest{StoreId: billing.GetId(), Name: "billing v2"})
+		require.NoError(t, err)
+		require.Equal(t, "billing v2", resp.GetName())
+		require.Equal(t, billing.GetCreatedAt().AsTime(), resp.GetCreatedAt().AsTime())
+		require.NotContains(t, transport.headers, StoreLabelsHeader)
+
+		_, err = s.UpdateStore(withHeaders(StoreLabelsHeader, "env=staging"), &openfgav1.UpdateStoreRequest{StoreId: billing.GetId(), Name: "billing v2"})
+		require.NoError(t, err)
+		require.Equal(t, "env=staging", transport.headers[StoreLabelsHeader])
+
+		labels, err := s.GetStoreLabels(ctx, billing.GetId())
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"env": "staging"}, labels)
+
+		// the labels are unchanged without the header
+		_, err = s.UpdateStore(withHeaders(), &openfgav1.UpdateStoreRequest{StoreId: billing.GetId(), Name: "billing v3"})
+		require.NoError(t, err)
+		require.Equal(t, "env=staging", transport.headers[StoreLabelsHeader])
+		require.Equal(t, []string{"billing v3"}, listStores(t, StoresLabelsHeader, "env=staging"))
+
+		// and removed with an empty header
+		_, err = s.UpdateStore(withHeaders(StoreLabelsHeader, ""), &openfgav1.UpdateStoreRequest{StoreId: billing.GetId(), Name: "billing v3"})
+		require.NoError(t, err)
+		require.NotContains(t, transport.headers, StoreLabelsHeader)
+		require.Empty(t, listStores(t, StoresLabelsHeader, "env=staging"))
+
+		// and the cached labels are invalidated
+		labels, err = s.GetStoreLabels(ctx, billing.GetId())
+		require.NoError(t, err)
+		require.Empty(t, labels)
+	})
+
+	t.Run("update_unknown_store", func(t *testing.T) {
+		_, err := s.UpdateStore(withHeaders(), &openfgav1.UpdateStoreRequest{StoreId: ulid.Make().String(), Name: "unknown"})
+		require.ErrorIs(t, err, serverErrors.StoreIDNotFound)
+	})
+}
+
+// failingStoreLabelsDatastore is a datastore whose lookups of the labels of the stores fail.
+type failingStoreLabelsDatastore struct {
+	storage.OpenFGADatastore
+	reads atomic.Int32
+}
+
+func (f *failingStoreLabelsDatastore) ReadStoreLabels(context.Context, []string) (map[string]map[string]string, error) {
+	f.reads.Add(1)
+	return nil, errors.New("datastore unavailable")
+}
+
+func TestGetStoreLabelsCachesErrors(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	failing := &failingStoreLabelsDatastore{OpenFGADatastore: ds}
+	s := MustNewServerWithOpts(WithDatastore(failing))
+	t.Cleanup(s.Close)
+
+	storeID := ulid.Make().String()
+	for i := 0; i < 3; i++ {
+		_, err := s.GetStoreLabels(context.Background(), storeID)
+		require.Error(t, err)
+	}
+	require.Equal(t, int32(1), failing.reads.Load())
+
+	// a canceled lookup isn't cached
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	otherStoreID := ulid.Make().String()
+	_, err := s.GetStoreLabels(canceledCtx, otherStoreID)
+	require.Error(t, err)
+	_, err = s.GetStoreLabels(context.Background(), otherStoreID)
+	require.Error(t, err)
+	require.Equal(t, int32(3), failing.reads.Load())
+
+	// the error expires
+	require.Eventually(t, func() bool {
+		_, err := s.GetStoreLabels(context.Background(), storeID)
+		return err != nil && failing.reads.Load() == 4
+	}, 3*storeLabelsErrorCacheTTL, 50*time.Millisecond)
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/internal/graph"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	// ConsistencyHeader is the consistency of a Check, ListObjects or StreamedListObjects request.
+	// Without it, the default consistency of the store settings applies.
+	ConsistencyHeader = "Openfga-Consistency"
+
+	// ConsistencyMinimizeLatency evaluates the requests with the cached Check results, if the check
+	// query cache is enabled. It is the default.
+	ConsistencyMinimizeLatency = "minimize_latency"
+
+	// ConsistencyHigherConsistency evaluates the requests without the cached Check results, which
+	// may be stale up to the TTL of the check query cache.
+	ConsistencyHigherConsistency = "higher_consistency"
+
+	// storeSettingsCacheTTL is how long the settings of a store are cached. The settings updated
+	// through another server apply after at most this duration.
+	storeSettingsCacheTTL = 10 * time.Second
+)
+
+// GetStoreSettings returns the settings of a store.
+func (s *Server) GetStoreSettings(ctx context.Context, storeID string) (*storage.StoreSettings, error) {
+	ctx, span := tracer.Start(ctx, "GetStoreSettings")
+	defer span.End()
+
+	if item := s.storeSettingsCache.Get(storeID); item != nil && !item.Expired() {
+		settings := *item.Value()
+		return &settings, nil
+	}
+
+	settings, err := s.datastore.ReadStoreSettings(ctx, storeID)
+	if err != nil {
+		return nil, serverErrors.HandleError("", err)
+	}
+
+	cached := *settings
+	s.storeSettingsCache.Set(storeID, &cached, storeSettingsCacheTTL)
+
+	return settings, nil
+}
+
+// UpdateStoreSettings validates and overwrites the settings of a store. The default and the candidate
+// authorization models must be models of the store.
+func (s *Server) UpdateStoreSettings(ctx context.Context, storeID string, settings *storage.StoreSettings) error {
+	ctx, span := tracer.Start(ctx, "UpdateStoreSettings")
+	defer span.End()
+
+	if err := validateConsistency(settings.DefaultConsistency); err != nil {
+		return err
+	}
+
+	if settings.CheckQueryCacheTTL < 0 {
+		return serverErrors.ValidationError(errors.New("the check query cache TTL must not be negative"))
+	}
+
+	for _, modelID := range []string{settings.DefaultAuthorizationModelID, settings.CandidateAuthorizationModelID} {
+		if modelID == "" {
+			continue
+		}
+
+		if _, err := ulid.Parse(modelID); err != nil {
+			return serverErrors.AuthorizationModelNotFound(modelID)
+		}
+
+		if _, err := s.datastore.ReadAuthorizationModel(ctx, storeID, modelID); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return serverErrors.AuthorizationModelNotFound(modelID)
+			}
+			return serverErrors.HandleError("", err)
+		}
+	}
+
+	if err := s.datastore.WriteStoreSettings(ctx, storeID, settings); err != nil {
+		return serverErrors.HandleError("", err)
+	}
+
+	s.storeSettingsCache.Delete(storeID)
+
+	return nil
+}
+
+// applyStoreSettings returns a context whose Check resolutions follow the consistency of the
+// request, or else the default consistency of the store, and the check query cache TTL of the store.
+// Both only apply if the check query cache or the ListObjects cache is enabled.
+func (s *Server) applyStoreSettings(ctx context.Context, storeID string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var consistency string
+	if values := md.Get(ConsistencyHeader); len(values) > 0 {
+		consistency = values[0]
+		if err := validateConsistency(consistency); err != nil {
+			return nil, err
+		}
+	}
+
+	if !s.checkQueryCacheEnabled && s.listObjectsCache == nil {
+		return ctx, nil
+	}
+
+	settings, err := s.GetStoreSettings(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if consistency == "" {
+		consistency = settings.DefaultConsistency
+	}
+
+	if consistency == ConsistencyHigherConsistency {
+		ctx = graph.ContextWithCheckCacheBypass(ctx)
+	}
+
+	if settings.CheckQueryCacheTTL > 0 {
+		ctx = graph.ContextWithCheckCacheTTL(ctx, settings.CheckQueryCacheTTL)
+	}
+
+	return ctx, nil
+}
+
+// defaultAuthorizationModelID returns the model ID of a request, or else the default model ID of
+// the store settings, which is empty if the latest model applies.
+func (s *Server) defaultAuthorizationModelID(ctx context.Context, storeID, modelID string) (string, error) {
+	if modelID != "" {
+		return modelID, nil
+	}
+
+	settings, err := s.GetStoreSettings(ctx, storeID)
+	if err != nil {
+		return "", err
+	}
+
+	return settings.DefaultAuthorizationModelID, nil
+}
+
+func validateConsistency(consistency string) error {
+	switch consistency {
+	case "", ConsistencyMinimizeLatency, ConsistencyHigherConsistency:
+		return nil
+	default:
+		return serverErrors.InvalidConsistency(consistency, ConsistencyMinimizeLatency, ConsistencyHigherConsistency)
+	}
+}
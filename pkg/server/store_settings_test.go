@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestStoreSettings(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "settings"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	writeModel := func(dsl string) string {
+		model := language.MustTransformDSLToProto(dsl)
+		resp, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         storeID,
+			SchemaVersion:   model.GetSchemaVersion(),
+			TypeDefinitions: model.GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+		return resp.GetAuthorizationModelId()
+	}
+
+	pinnedModelID := writeModel(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+
+	// the latest model doesn't have the viewer relation
+	writeModel(`model
+  schema 1.1
+type user
+type document
+  relations
+    define editor: [user]`)
+
+	_, err = s.Write(ctx, &openfgav1.WriteRequest{
+		StoreId:              storeID,
+		AuthorizationModelId: pinnedModelID,
+		Writes: &openfgav1.WriteRequestWrites{
+			TupleKeys: []*openfgav1.TupleKey{tuple.NewTupleKey("document:1", "viewer", "user:anne")},
+		},
+	})
+	require.NoError(t, err)
+
+	check := func(ctx context.Context) (*openfgav1.CheckResponse, error) {
+		return s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+	}
+
+	t.Run("default_settings", func(t *testing.T) {
+		settings, err := s.GetStoreSettings(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, &storage.StoreSettings{}, settings)
+
+		// the latest model applies
+		_, err = check(ctx)
+		require.Error(t, err)
+	})
+
+	t.Run("pinned_model_applies_when_the_request_omits_it", func(t *testing.T) {
+		err := s.UpdateStoreSettings(ctx, storeID, &storage.StoreSettings{
+			DefaultAuthorizationModelID: pinnedModelID,
+			CheckQueryCacheTTL:          time.Minute,
+		})
+		require.NoError(t, err)
+
+		resp, err := check(ctx)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+	})
+
+	t.Run("consistency_header", func(t *testing.T) {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Deletes: &openfgav1.WriteRequestDeletes{
+				TupleKeys: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tuple.NewTupleKey("document:1", "viewer", "user:anne"))},
+			},
+		})
+		require.NoError(t, err)
+
+		// the cached result is returned
+		resp, err := check(ctx)
+		require.NoError(t, err)
+		require.True(t, resp.GetAllowed())
+
+		higherConsistencyCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ConsistencyHeader, ConsistencyHigherConsistency))
+		resp, err = check(higherConsistencyCtx)
+		require.NoError(t, err)
+		require.False(t, resp.GetAllowed())
+
+		invalidCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ConsistencyHeader, "eventual"))
+		_, err = check(invalidCtx)
+		require.Equal(t, codes.Code(openfgav1.ErrorCode_validation_error), status.Code(err))
+	})
+
+	t.Run("invalid_settings", func(t *testing.T) {
+		unknownModelID := ulid.Make().String()
+		err := s.UpdateStoreSettings(ctx, storeID, &storage.StoreSettings{DefaultAuthorizationModelID: unknownModelID})
+		require.ErrorIs(t, err, serverErrors.AuthorizationModelNotFound(unknownModelID))
+
+		err = s.UpdateStoreSettings(ctx, storeID, &storage.StoreSettings{DefaultConsistency: "eventual"})
+		require.Error(t, err)
+
+		err = s.UpdateStoreSettings(ctx, storeID, &storage.StoreSettings{CheckQueryCacheTTL: -time.Second})
+		require.Error(t, err)
+
+		// the settings are unchanged
+		settings, err := s.GetStoreSettings(ctx, storeID)
+		require.NoError(t, err)
+		require.Equal(t, pinnedModelID, settings.DefaultAuthorizationModelID)
+	})
+}
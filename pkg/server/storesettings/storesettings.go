@@ -0,0 +1,130 @@
+// Package storesettings serves the settings of a store on the HTTP gateway: the authorization
+// model that applies when a request doesn't specify one, the default consistency of Check and
+// ListObjects, and the TTL of the check query cache of the store.
+package storesettings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// StoreSettings is the JSON representation of the settings of a store.
+type StoreSettings struct {
+	// DefaultAuthorizationModelID is the model that applies when a request doesn't specify one. If
+	// empty, the latest model applies.
+	DefaultAuthorizationModelID string `json:"default_authorization_model_id"`
+
+	// DefaultConsistency is the consistency of the requests without the Openfga-Consistency header,
+	// 'minimize_latency' or 'higher_consistency'. If empty, it is 'minimize_latency'.
+	DefaultConsistency string `json:"default_consistency"`
+
+	// CheckQueryCacheTTL is the TTL of the check query cache of the store, as a duration such as
+	// '30s'. If empty, the TTL of the server applies.
+	CheckQueryCacheTTL string `json:"check_query_cache_ttl"`
+
+	// CandidateAuthorizationModelID is a model that the Check requests are also evaluated against
+	// when the Check canary of the server is enabled, to compare its results with those of the model
+	// of the requests before promoting it. If empty, the requests aren't.
+	CandidateAuthorizationModelID string `json:"candidate_authorization_model_id"`
+}
+
+// Client reads the stores, so that the requests are authenticated like any other request.
+type Client interface {
+	GetStore(ctx context.Context, in *openfgav1.GetStoreRequest, opts ...grpc.CallOption) (*openfgav1.GetStoreResponse, error)
+}
+
+// Settings reads and writes the settings of the stores. It is implemented by *server.Server.
+type Settings interface {
+	GetStoreSettings(ctx context.Context, storeID string) (*storage.StoreSettings, error)
+	UpdateStoreSettings(ctx context.Context, storeID string, settings *storage.StoreSettings) error
+}
+
+// NewHTTPHandler returns a handler for the HTTP gateway that returns the settings of the 'store_id'
+// path parameter on GET requests, and overwrites them with the StoreSettings of the body on PUT
+// requests. The store is first read through the client with the Authorization header of the request.
+func NewHTTPHandler(mux *runtime.ServeMux, client Client, settings Settings) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+
+		storeID := pathParams["store_id"]
+		if _, err := client.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: storeID}); err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			var body StoreSettings
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				err = status.Errorf(codes.InvalidArgument, "invalid store settings: %v", err)
+				runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+				return
+			}
+
+			update, err := fromJSON(&body)
+			if err != nil {
+				runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+				return
+			}
+
+			if err := settings.UpdateStoreSettings(ctx, storeID, update); err != nil {
+				runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+				return
+			}
+		}
+
+		current, err := settings.GetStoreSettings(ctx, storeID)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(toJSON(current))
+	}
+}
+
+func fromJSON(body *StoreSettings) (*storage.StoreSettings, error) {
+	settings := &storage.StoreSettings{
+		DefaultAuthorizationModelID:   body.DefaultAuthorizationModelID,
+		DefaultConsistency:            body.DefaultConsistency,
+		CandidateAuthorizationModelID: body.CandidateAuthorizationModelID,
+	}
+
+	if body.CheckQueryCacheTTL != "" {
+		ttl, err := time.ParseDuration(body.CheckQueryCacheTTL)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid check_query_cache_ttl '%s'", body.CheckQueryCacheTTL)
+		}
+		settings.CheckQueryCacheTTL = ttl
+	}
+
+	return settings, nil
+}
+
+func toJSON(settings *storage.StoreSettings) *StoreSettings {
+	body := &StoreSettings{
+		DefaultAuthorizationModelID:   settings.DefaultAuthorizationModelID,
+		DefaultConsistency:            settings.DefaultConsistency,
+		CandidateAuthorizationModelID: settings.CandidateAuthorizationModelID,
+	}
+
+	if settings.CheckQueryCacheTTL > 0 {
+		body.CheckQueryCacheTTL = settings.CheckQueryCacheTTL.String()
+	}
+
+	return body
+}
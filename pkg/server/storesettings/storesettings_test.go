@@ -0,0 +1,134 @@
+package storesettings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	"github.com/openfga/openfga/pkg/server"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+type storeClient struct {
+	server        *server.Server
+	authorization []string
+}
+
+func (c *storeClient) GetStore(ctx context.Context, in *openfgav1.GetStoreRequest, _ ...grpc.CallOption) (*openfgav1.GetStoreResponse, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.authorization = md.Get("authorization")
+
+	return c.server.GetStore(ctx, in)
+}
+
+func TestHTTPHandler(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "settings"})
+	require.NoError(t, err)
+
+	model, err := s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   "1.1",
+		TypeDefinitions: language.MustTransformDSLToProto("model\n  schema 1.1\ntype user").GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	client := &storeClient{server: s}
+
+	// the errors are encoded like on the gateway of the server, which maps the error codes to HTTP statuses
+	mux := runtime.NewServeMux(runtime.WithErrorHandler(func(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+		httpmiddleware.CustomHTTPErrorHandler(ctx, w, r, serverErrors.EncodeError(err))
+	}))
+	handler := NewHTTPHandler(mux, client, s)
+	require.NoError(t, mux.HandlePath(http.MethodGet, "/stores/{store_id}/settings", handler))
+	require.NoError(t, mux.HandlePath(http.MethodPut, "/stores/{store_id}/settings", handler))
+
+	serve := func(method, storeID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/stores/"+storeID+"/settings", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer key")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("get_default_settings", func(t *testing.T) {
+		w := serve(http.MethodGet, store.GetId(), "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, []string{"Bearer key"}, client.authorization)
+
+		var settings StoreSettings
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+		require.Equal(t, StoreSettings{}, settings)
+	})
+
+	t.Run("update_settings", func(t *testing.T) {
+		w := serve(http.MethodPut, store.GetId(), `{
+			"default_authorization_model_id": "`+model.GetAuthorizationModelId()+`",
+			"default_consistency": "higher_consistency",
+			"check_query_cache_ttl": "30s",
+			"candidate_authorization_model_id": "`+model.GetAuthorizationModelId()+`"
+		}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		expected := StoreSettings{
+			DefaultAuthorizationModelID:   model.GetAuthorizationModelId(),
+			DefaultConsistency:            server.ConsistencyHigherConsistency,
+			CheckQueryCacheTTL:            "30s",
+			CandidateAuthorizationModelID: model.GetAuthorizationModelId(),
+		}
+
+		var settings StoreSettings
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+		require.Equal(t, expected, settings)
+
+		w = serve(http.MethodGet, store.GetId(), "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+		require.Equal(t, expected, settings)
+	})
+
+	t.Run("invalid_settings", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"malformed_body":      `{`,
+			"invalid_ttl":         `{"check_query_cache_ttl": "soon"}`,
+			"invalid_consistency": `{"default_consistency": "eventual"}`,
+			"unknown_model":       `{"default_authorization_model_id": "` + ulid.Make().String() + `"}`,
+			"unknown_candidate":   `{"candidate_authorization_model_id": "` + ulid.Make().String() + `"}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				w := serve(http.MethodPut, store.GetId(), body)
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			})
+		}
+	})
+
+	t.Run("unknown_store", func(t *testing.T) {
+		w := serve(http.MethodGet, ulid.Make().String(), "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -24,6 +24,7 @@ func TestExpandQuery(t *testing.T, datastore storage.OpenFGADatastore) {
 		name     string
 		model    *openfgav1.AuthorizationModel
 		tuples   []*openfgav1.TupleKey
+		options  []commands.ExpandQueryOption
 		request  *openfgav1.ExpandRequest
 		expected *openfgav1.ExpandResponse
 	}{
@@ -778,6 +779,157 @@ func TestExpandQuery(t *testing.T, datastore storage.OpenFGADatastore) {
 				},
 			},
 		},
+		{
+			name: "1.1_contextual_tuples",
+			model: testutils.MustTransformDSLToProtoWithID(`
+				model
+					schema 1.1
+				type user
+				type repo
+					relations
+						define admin: [user]`),
+			tuples: []*openfgav1.TupleKey{},
+			options: []commands.ExpandQueryOption{
+				commands.WithExpandQueryContextualTuples([]*openfgav1.TupleKey{
+					tuple.NewTupleKey("repo:openfga/foo", "admin", "user:anne"),
+				}),
+			},
+			request: &openfgav1.ExpandRequest{
+				TupleKey: tuple.NewExpandRequestTupleKey("repo:openfga/foo", "admin"),
+			},
+			expected: &openfgav1.ExpandResponse{
+				Tree: &openfgav1.UsersetTree{
+					Root: &openfgav1.UsersetTree_Node{
+						Name: "repo:openfga/foo#admin",
+						Value: &openfgav1.UsersetTree_Node_Leaf{
+							Leaf: &openfgav1.UsersetTree_Leaf{
+								Value: &openfgav1.UsersetTree_Leaf_Users{
+									Users: &openfgav1.UsersetTree_Users{
+										Users: []string{"user:anne"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "1.1_contextual_tuple_to_userset",
+			model: testutils.MustTransformDSLToProtoWithID(`
+				model
+					schema 1.1
+				type user
+				type repo
+					relations
+						define admin: repo_admin from manager
+						define manager: [org]
+				type org
+					relations
+						define repo_admin: [user]`),
+			tuples: []*openfgav1.TupleKey{},
+			options: []commands.ExpandQueryOption{
+				commands.WithExpandQueryContextualTuples([]*openfgav1.TupleKey{
+					tuple.NewTupleKey("repo:openfga/foo", "manager", "org:openfga"),
+				}),
+			},
+			request: &openfgav1.ExpandRequest{
+				TupleKey: tuple.NewExpandRequestTupleKey("repo:openfga/foo", "admin"),
+			},
+			expected: &openfgav1.ExpandResponse{
+				Tree: &openfgav1.UsersetTree{
+					Root: &openfgav1.UsersetTree_Node{
+						Name: "repo:openfga/foo#admin",
+						Value: &openfgav1.UsersetTree_Node_Leaf{
+							Leaf: &openfgav1.UsersetTree_Leaf{
+								Value: &openfgav1.UsersetTree_Leaf_TupleToUserset{
+									TupleToUserset: &openfgav1.UsersetTree_TupleToUserset{
+										Tupleset: "repo:openfga/foo#manager",
+										Computed: []*openfgav1.UsersetTree_Computed{
+											{Userset: "org:openfga#repo_admin"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "1.1_condition_context",
+			model: testutils.MustTransformDSLToProtoWithID(`
+				model
+					schema 1.1
+				type user
+				type repo
+					relations
+						define admin: [user with small]
+				condition small(x: int, limit: int) {
+					x < limit
+				}`),
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKeyWithCondition("repo:openfga/foo", "admin", "user:anne", "small", testutils.MustNewStruct(t, map[string]interface{}{"limit": 10})),
+				tuple.NewTupleKeyWithCondition("repo:openfga/foo", "admin", "user:bob", "small", testutils.MustNewStruct(t, map[string]interface{}{"limit": 1})),
+			},
+			options: []commands.ExpandQueryOption{
+				commands.WithExpandQueryContext(testutils.MustNewStruct(t, map[string]interface{}{"x": 5})),
+			},
+			request: &openfgav1.ExpandRequest{
+				TupleKey: tuple.NewExpandRequestTupleKey("repo:openfga/foo", "admin"),
+			},
+			expected: &openfgav1.ExpandResponse{
+				Tree: &openfgav1.UsersetTree{
+					Root: &openfgav1.UsersetTree_Node{
+						Name: "repo:openfga/foo#admin",
+						Value: &openfgav1.UsersetTree_Node_Leaf{
+							Leaf: &openfgav1.UsersetTree_Leaf{
+								Value: &openfgav1.UsersetTree_Leaf_Users{
+									Users: &openfgav1.UsersetTree_Users{
+										Users: []string{"user:anne"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "1.1_conditions_not_evaluated_without_context",
+			model: testutils.MustTransformDSLToProtoWithID(`
+				model
+					schema 1.1
+				type user
+				type repo
+					relations
+						define admin: [user with small]
+				condition small(x: int, limit: int) {
+					x < limit
+				}`),
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKeyWithCondition("repo:openfga/foo", "admin", "user:bob", "small", testutils.MustNewStruct(t, map[string]interface{}{"limit": 1})),
+			},
+			request: &openfgav1.ExpandRequest{
+				TupleKey: tuple.NewExpandRequestTupleKey("repo:openfga/foo", "admin"),
+			},
+			expected: &openfgav1.ExpandResponse{
+				Tree: &openfgav1.UsersetTree{
+					Root: &openfgav1.UsersetTree_Node{
+						Name: "repo:openfga/foo#admin",
+						Value: &openfgav1.UsersetTree_Node_Leaf{
+							Leaf: &openfgav1.UsersetTree_Leaf{
+								Value: &openfgav1.UsersetTree_Leaf_Users{
+									Users: &openfgav1.UsersetTree_Users{
+										Users: []string{"user:bob"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	ctx := context.Background()
@@ -802,7 +954,7 @@ func TestExpandQuery(t *testing.T, datastore storage.OpenFGADatastore) {
 			test.request.AuthorizationModelId = test.model.GetId()
 
 			// act
-			query := commands.NewExpandQuery(datastore)
+			query := commands.NewExpandQuery(datastore, test.options...)
 			got, err := query.Execute(ctx, test.request)
 			require.NoError(t, err)
 
@@ -819,6 +971,7 @@ func TestExpandQueryErrors(t *testing.T, datastore storage.OpenFGADatastore) {
 		name          string
 		model         *openfgav1.AuthorizationModel
 		tuples        []*openfgav1.TupleKey
+		options       []commands.ExpandQueryOption
 		request       *openfgav1.ExpandRequest
 		allowSchema10 bool
 		expected      error
@@ -940,6 +1093,31 @@ func TestExpandQueryErrors(t *testing.T, datastore storage.OpenFGADatastore) {
 				},
 			),
 		},
+		{
+			name: "1.1_condition_context_missing_parameters",
+			model: testutils.MustTransformDSLToProtoWithID(`
+				model
+					schema 1.1
+				type user
+				type repo
+					relations
+						define admin: [user with small]
+				condition small(x: int, limit: int) {
+					x < limit
+				}`),
+			tuples: []*openfgav1.TupleKey{
+				tuple.NewTupleKeyWithCondition("repo:openfga/foo", "admin", "user:anne", "small", testutils.MustNewStruct(t, map[string]interface{}{"limit": 10})),
+			},
+			options: []commands.ExpandQueryOption{
+				commands.WithExpandQueryContext(testutils.MustNewStruct(t, map[string]interface{}{})),
+			},
+			request: &openfgav1.ExpandRequest{
+				TupleKey: tuple.NewExpandRequestTupleKey("repo:openfga/foo", "admin"),
+			},
+			expected: serverErrors.ValidationError(
+				fmt.Errorf("failed to evaluate relationship condition: 'small' - tuple 'repo:openfga/foo#admin@user:anne' is missing context parameters '[x]'"),
+			),
+		},
 	}
 
 	ctx := context.Background()
@@ -964,7 +1142,7 @@ func TestExpandQueryErrors(t *testing.T, datastore storage.OpenFGADatastore) {
 			test.request.AuthorizationModelId = test.model.GetId()
 
 			// act
-			query := commands.NewExpandQuery(datastore)
+			query := commands.NewExpandQuery(datastore, test.options...)
 			resp, err := query.Execute(ctx, test.request)
 
 			// assert
@@ -438,27 +438,6 @@ func ReadQueryErrorTest(t *testing.T, datastore storage.OpenFGADatastore) {
 				},
 			},
 		},
-		{
-			_name: "ErrorIfRequestHasNoObjectAndThusNoType",
-			model: &openfgav1.AuthorizationModel{
-				Id:            ulid.Make().String(),
-				SchemaVersion: typesystem.SchemaVersion1_0,
-				TypeDefinitions: []*openfgav1.TypeDefinition{
-					{
-						Type: "repo",
-						Relations: map[string]*openfgav1.Userset{
-							"admin": {},
-						},
-					},
-				},
-			},
-			request: &openfgav1.ReadRequest{
-				TupleKey: &openfgav1.ReadRequestTupleKey{
-					Relation: "admin",
-					User:     "github|jonallie",
-				},
-			},
-		},
 		{
 			_name: "ExecuteErrorsIfOneTupleKeyHasNoObjectIdAndNoUserSetButHasAType",
 			model: &openfgav1.AuthorizationModel{
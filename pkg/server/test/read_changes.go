@@ -180,6 +180,29 @@ func TestReadChanges(t *testing.T, datastore storage.OpenFGADatastore) {
 		runTests(t, ctx, testCases, readChangesQuery)
 	})
 
+	t.Run("read_changes_with_filter", func(t *testing.T) {
+		testCases := []testCase{
+			{
+				_name:   "object_id_prefix_and_user_filters_return_the_matching_changes",
+				request: newReadChangesRequest(store, "repo", "", storage.DefaultPageSize),
+				expectedChanges: []*openfgav1.TupleChange{{
+					TupleKey:  tkCraig,
+					Operation: openfgav1.TupleOperation_TUPLE_OPERATION_WRITE,
+				}},
+				expectEmptyContinuationToken: false,
+				expectedError:                nil,
+			},
+		}
+
+		readChangesQuery := commands.NewReadChangesQuery(backend,
+			commands.WithReadChangesQueryFilter(storage.ReadChangesFilter{ObjectIDPrefix: "openfga/", Relation: "admin", User: "craig"}),
+		)
+		runTests(t, ctx, testCases, readChangesQuery)
+
+		_, err := readChangesQuery.Execute(ctx, newReadChangesRequest(store, "", "", storage.DefaultPageSize))
+		require.ErrorContains(t, err, "requires the type filter")
+	})
+
 	t.Run("read_changes_with_horizon_offset", func(t *testing.T) {
 		testCases := []testCase{
 			{
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// watchTupleChanges invalidates the Check query cache entries of the stores notified by the tuple
+// change notifier, or moves their changelog watermarks if the cache is keyed by them, and moves the
+// watermarks of the ListObjects cache, until the server is closed.
+func (s *Server) watchTupleChanges() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	onChange := func(store string) {
+		switch {
+		case s.changelogWatermarks != nil:
+			s.changelogWatermarks.Invalidate(store)
+		case s.cachedCheckResolver != nil:
+			s.cachedCheckResolver.InvalidateStore(store)
+		}
+
+		if s.listObjectsCacheWatermarks != nil {
+			s.listObjectsCacheWatermarks.Invalidate(store)
+		}
+	}
+
+	go func() {
+		defer close(done)
+
+		err := s.tupleChangeNotifier.WatchTupleChanges(ctx, onChange)
+		if err != nil {
+			s.logger.Error("watching the tuple changes failed, the query caches are no longer invalidated", zap.Error(err))
+		}
+	}()
+
+	s.stopWatchingTupleChanges = func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// channelTupleChangeNotifier notifies the stores sent on its channel, and acknowledges each of them
+// once it is handled.
+type channelTupleChangeNotifier struct {
+	stores  chan string
+	handled chan struct{}
+}
+
+func (n *channelTupleChangeNotifier) WatchTupleChanges(ctx context.Context, onChange func(store string)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case store := <-n.stores:
+			onChange(store)
+			n.handled <- struct{}{}
+		}
+	}
+}
+
+func TestTupleChangesInvalidateCheckCache(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	notifier := &channelTupleChangeNotifier{stores: make(chan string), handled: make(chan struct{})}
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithCheckQueryCacheEnabled(true),
+		WithTupleChangeNotifier(notifier),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "tuple-changes"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	anne := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{anne}))
+
+	check := func() bool {
+		resp, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  storeID,
+			TupleKey: tuple.NewCheckRequestTupleKey("document:1", "viewer", "user:anne"),
+		})
+		require.NoError(t, err)
+		return resp.GetAllowed()
+	}
+
+	require.True(t, check())
+
+	// the tuple is deleted through another server, so the result is cached until it is notified
+	require.NoError(t, ds.Write(ctx, storeID, []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(anne)}, nil))
+	require.True(t, check())
+
+	notifier.stores <- storeID
+	<-notifier.handled
+	require.False(t, check())
+}
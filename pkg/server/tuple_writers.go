@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/internal/authn"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// TupleWritersHeader has the writers of the tuples returned by Read, or of the changes returned by
+// ReadChanges, as a JSON array in the order of the tuples or changes. The writer of a tuple or change
+// is the subject of the authenticated request that wrote it, and is empty if unknown. The header is
+// omitted if no writer is known. The timestamps are those of the tuples and changes.
+const TupleWritersHeader = "Openfga-Tuple-Writers"
+
+// contextWithWriter returns a context with the subject of the authenticated request as the writer of
+// the tuples and changes, if any.
+func contextWithWriter(ctx context.Context) context.Context {
+	claims, ok := authn.AuthClaimsFromContext(ctx)
+	if !ok || claims.Subject == "" {
+		return ctx
+	}
+
+	return storage.ContextWithWriter(ctx, claims.Subject)
+}
+
+// setReadTupleWritersHeader sets the TupleWritersHeader of the response to the writers of the tuples.
+func (s *Server) setReadTupleWritersHeader(ctx context.Context, storeID string, tuples []*openfgav1.Tuple) error {
+	if len(tuples) == 0 {
+		return nil
+	}
+
+	tupleKeys := make([]*openfgav1.TupleKey, 0, len(tuples))
+	for _, t := range tuples {
+		tupleKeys = append(tupleKeys, t.GetKey())
+	}
+
+	writers, err := s.datastore.ReadTupleWriters(ctx, storeID, tupleKeys)
+	if err != nil {
+		return serverErrors.HandleError("", err)
+	}
+
+	return s.setTupleWritersHeader(ctx, writers)
+}
+
+// setReadChangesWritersHeader sets the TupleWritersHeader of the response to the writers of the changes.
+func (s *Server) setReadChangesWritersHeader(ctx context.Context, storeID string, changes []*openfgav1.TupleChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	writers, err := s.datastore.ReadChangeWriters(ctx, storeID, changes)
+	if err != nil {
+		return serverErrors.HandleError("", err)
+	}
+
+	return s.setTupleWritersHeader(ctx, writers)
+}
+
+// setTupleWritersHeader sets the TupleWritersHeader of the response to the writers, if any is known.
+func (s *Server) setTupleWritersHeader(ctx context.Context, writers []string) error {
+	known := false
+	for _, writer := range writers {
+		if writer != "" {
+			known = true
+			break
+		}
+	}
+
+	if !known {
+		return nil
+	}
+
+	header, err := json.Marshal(writers)
+	if err != nil {
+		return serverErrors.HandleError("", err)
+	}
+	s.transport.SetHeader(ctx, TupleWritersHeader, string(header))
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/internal/authn"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestTupleWriters(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	transport := &headerRecordingTransport{headers: map[string]string{}}
+	s := MustNewServerWithOpts(
+		WithDatastore(ds),
+		WithTransport(transport),
+	)
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "writers"})
+	require.NoError(t, err)
+	storeID := store.GetId()
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	write := func(ctx context.Context, tk *openfgav1.TupleKey) {
+		_, err := s.Write(ctx, &openfgav1.WriteRequest{
+			StoreId: storeID,
+			Writes:  &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tk}},
+		})
+		require.NoError(t, err)
+	}
+
+	writers := func(t *testing.T) []string {
+		var writers []string
+		require.NoError(t, json.Unmarshal([]byte(transport.headers[TupleWritersHeader]), &writers))
+		return writers
+	}
+
+	write(authn.ContextWithAuthClaims(ctx, &authn.AuthClaims{Subject: "client-1"}), tuple.NewTupleKey("document:1", "viewer", "user:anne"))
+	write(ctx, tuple.NewTupleKey("document:2", "viewer", "user:anne"))
+
+	t.Run("read_returns_the_writers", func(t *testing.T) {
+		transport.headers = map[string]string{}
+		resp, err := s.Read(ctx, &openfgav1.ReadRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, resp.GetTuples(), 2)
+
+		expected := map[string]string{"document:1": "client-1", "document:2": ""}
+		for i, writer := range writers(t) {
+			require.Equal(t, expected[resp.GetTuples()[i].GetKey().GetObject()], writer)
+			require.NotNil(t, resp.GetTuples()[i].GetTimestamp())
+		}
+	})
+
+	t.Run("read_changes_returns_the_writers", func(t *testing.T) {
+		transport.headers = map[string]string{}
+		resp, err := s.ReadChanges(ctx, &openfgav1.ReadChangesRequest{StoreId: storeID})
+		require.NoError(t, err)
+		require.Len(t, resp.GetChanges(), 2)
+		require.Equal(t, []string{"client-1", ""}, writers(t))
+	})
+
+	t.Run("header_is_omitted_without_known_writers", func(t *testing.T) {
+		transport.headers = map[string]string{}
+		_, err := s.Read(ctx, &openfgav1.ReadRequest{
+			StoreId:  storeID,
+			TupleKey: &openfgav1.ReadRequestTupleKey{Object: "document:2"},
+		})
+		require.NoError(t, err)
+		require.NotContains(t, transport.headers, TupleWritersHeader)
+	})
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// warmupConcurrency is the number of stores that Warmup warms up concurrently.
+const warmupConcurrency = 10
+
+// Warmup warms up the server for the requests of the stores, e.g. before it is marked ready, so that
+// the first requests of the stores don't all pay for it. For each store, it caches the settings and
+// the labels, compiles the typesystem of the default model, or of the latest model, and reads the
+// changelog watermarks of the Check query cache and of the ListObjects cache, if they are enabled.
+// The errors of the stores are returned joined, once all the stores were warmed up.
+func (s *Server) Warmup(ctx context.Context, storeIDs []string) error {
+	ctx, span := tracer.Start(ctx, "Warmup")
+	defer span.End()
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+
+	limiter := make(chan struct{}, warmupConcurrency)
+	for _, storeID := range storeIDs {
+		storeID := storeID
+
+		limiter <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-limiter
+				wg.Done()
+			}()
+
+			if err := s.warmupStore(ctx, storeID); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("store '%s': %w", storeID, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// warmupStore warms up the server for the requests of a store.
+func (s *Server) warmupStore(ctx context.Context, storeID string) error {
+	if _, err := s.GetStoreSettings(ctx, storeID); err != nil {
+		return err
+	}
+
+	if _, err := s.GetStoreLabels(ctx, storeID); err != nil {
+		return err
+	}
+
+	if _, err := s.resolveTypesystem(ctx, storeID, ""); err != nil {
+		return err
+	}
+
+	if s.changelogWatermarks != nil {
+		if _, err := s.changelogWatermarks.Watermark(ctx, storeID); err != nil {
+			return err
+		}
+	}
+
+	if s.listObjectsCacheWatermarks != nil {
+		if _, err := s.listObjectsCacheWatermarks.Watermark(ctx, storeID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+)
+
+// countingWarmupDatastore is a datastore that counts the reads of the settings and the labels of
+// the stores.
+type countingWarmupDatastore struct {
+	storage.OpenFGADatastore
+	settingsReads atomic.Int32
+	labelsReads   atomic.Int32
+}
+
+func (c *countingWarmupDatastore) ReadStoreSettings(ctx context.Context, store string) (*storage.StoreSettings, error) {
+	c.settingsReads.Add(1)
+	return c.OpenFGADatastore.ReadStoreSettings(ctx, store)
+}
+
+func (c *countingWarmupDatastore) ReadStoreLabels(ctx context.Context, stores []string) (map[string]map[string]string, error) {
+	c.labelsReads.Add(1)
+	return c.OpenFGADatastore.ReadStoreLabels(ctx, stores)
+}
+
+func TestWarmup(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	counting := &countingWarmupDatastore{OpenFGADatastore: ds}
+	s := MustNewServerWithOpts(WithDatastore(counting), WithCheckQueryCacheEnabled(true))
+	t.Cleanup(s.Close)
+
+	store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "warmup"})
+	require.NoError(t, err)
+
+	model := language.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+    define viewer: [user]`)
+	_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         store.GetId(),
+		SchemaVersion:   model.GetSchemaVersion(),
+		TypeDefinitions: model.GetTypeDefinitions(),
+	})
+	require.NoError(t, err)
+
+	t.Run("caches_the_settings_and_the_labels", func(t *testing.T) {
+		require.NoError(t, s.Warmup(ctx, []string{store.GetId()}))
+
+		settingsReads, labelsReads := counting.settingsReads.Load(), counting.labelsReads.Load()
+		_, err := s.Check(ctx, &openfgav1.CheckRequest{
+			StoreId:  store.GetId(),
+			TupleKey: &openfgav1.CheckRequestTupleKey{Object: "document:1", Relation: "viewer", User: "user:anne"},
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, settingsReads, counting.settingsReads.Load())
+		require.Equal(t, labelsReads, counting.labelsReads.Load())
+	})
+
+	t.Run("returns_the_errors_of_the_stores", func(t *testing.T) {
+		empty, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "empty"})
+		require.NoError(t, err)
+
+		err = s.Warmup(ctx, []string{store.GetId(), empty.GetId()})
+		require.ErrorContains(t, err, "store '"+empty.GetId()+"'")
+		require.NotContains(t, err.Error(), "store '"+store.GetId()+"'")
+	})
+}
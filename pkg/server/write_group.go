@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/telemetry"
+)
+
+const (
+	writeGroupResultApplied       = "applied"
+	writeGroupResultFailed        = "failed"
+	writeGroupResultUncompensated = "uncompensated"
+)
+
+var writeGroupCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "write_group_count",
+	Help:      "The total number of write groups, by the mode they were applied in, 'atomic' or 'saga', and by result: 'applied', 'failed' if none of their writes was applied, and 'uncompensated' if a store failed and the stores written before it couldn't all be compensated.",
+}, []string{"mode", "result"})
+
+// WriteGroupResponse is the outcome of a WriteGroup.
+type WriteGroupResponse struct {
+	// Mode is how the writes were applied: in a single transaction, or one store after the other.
+	Mode commands.WriteGroupMode
+
+	// ConsistencyTokens are the consistency tokens of the writes, by store, for the stores with
+	// consistency tokens on.
+	ConsistencyTokens map[string]string
+}
+
+// WriteGroup applies the Write requests of several stores all or none, e.g. to keep mirrored stores
+// in sync. Each request is validated and admitted like a Write, and each store appears at most once.
+// The writes are committed in a single transaction if the datastore supports it for the stores, e.g.
+// if they are in the same SQL database, and otherwise with compensations if
+// WithWriteGroupSagaEnabled is set to true, see commands.WriteGroupCommand.
+func (s *Server) WriteGroup(ctx context.Context, reqs []*openfgav1.WriteRequest) (*WriteGroupResponse, error) {
+	ctx, span := tracer.Start(ctx, "WriteGroup", trace.WithAttributes(attribute.Int("stores", len(reqs))))
+	defer span.End()
+
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{
+		Service: s.serviceName,
+		Method:  "WriteGroup",
+	})
+
+	writeReqs := make([]*openfgav1.WriteRequest, 0, len(reqs))
+	for _, req := range reqs {
+		if err := req.Validate(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		typesys, err := s.resolveTypesystem(ctx, req.GetStoreId(), req.GetAuthorizationModelId())
+		if err != nil {
+			return nil, err
+		}
+
+		writeReqs = append(writeReqs, &openfgav1.WriteRequest{
+			StoreId:              req.GetStoreId(),
+			AuthorizationModelId: typesys.GetAuthorizationModelID(), // the resolved model id
+			Writes:               req.GetWrites(),
+			Deletes:              req.GetDeletes(),
+		})
+	}
+
+	cmd := commands.NewWriteGroupCommand(
+		s.writeDatastore,
+		commands.WithWriteGroupCmdWriteOptions(
+			commands.WithWriteCmdLogger(s.logger),
+			commands.WithWriteCmdAdmitter(s.writeAdmitter),
+			commands.WithWriteCmdTupleKeyRules(s.tupleKeyRules),
+			commands.WithConditionContextByteLimit(s.maxContextSizeInBytes),
+		),
+		commands.WithWriteGroupCmdSagaEnabled(s.writeGroupSagaEnabled),
+	)
+
+	res, err := cmd.Execute(contextWithWriter(ctx), writeReqs)
+
+	var mode commands.WriteGroupMode
+	if res != nil {
+		mode = res.Mode
+	}
+
+	if err != nil {
+		result := writeGroupResultFailed
+		if reason, _ := serverErrors.ReasonFromError(err); reason == serverErrors.ReasonWriteGroupCompensationFailed {
+			result = writeGroupResultUncompensated
+		}
+		if mode != "" {
+			writeGroupCounter.WithLabelValues(string(mode), result).Inc()
+		}
+
+		// the stores written one after the other may have been changed, and changed back
+		if mode == commands.WriteGroupSaga {
+			for _, req := range writeReqs {
+				s.invalidateWatermarks(req.GetStoreId())
+			}
+		}
+
+		return nil, err
+	}
+
+	writeGroupCounter.WithLabelValues(string(mode), writeGroupResultApplied).Inc()
+	span.SetAttributes(attribute.String("mode", string(mode)))
+
+	resp := &WriteGroupResponse{Mode: mode, ConsistencyTokens: map[string]string{}}
+	for _, req := range writeReqs {
+		// the consistency token of each store names the ULID of the first change of its write
+		if token := s.newConsistencyToken(req.GetStoreId(), res.ChangeULIDs[req.GetStoreId()]); token != "" {
+			resp.ConsistencyTokens[req.GetStoreId()] = token
+		}
+		s.invalidateWatermarks(req.GetStoreId())
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/openfga/openfga/pkg/server/commands"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/storage/storagewrappers"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+func TestWriteGroup(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	// the stores of 'us' and 'eu' are in different datastores, so they are written in a saga
+	us := memory.New()
+	eu := memory.New()
+
+	usStore1 := ulid.Make().String()
+	usStore2 := ulid.Make().String()
+	euStore := ulid.Make().String()
+
+	ds, err := storagewrappers.NewRoutingOpenFGADatastore("us", map[string]storage.OpenFGADatastore{"us": us, "eu": eu},
+		storagewrappers.WithRoutingStores(map[string]string{euStore: "eu"}))
+	require.NoError(t, err)
+	t.Cleanup(ds.Close)
+
+	s := MustNewServerWithOpts(WithDatastore(ds), WithWriteGroupSagaEnabled(true))
+	t.Cleanup(s.Close)
+
+	for _, store := range []string{usStore1, usStore2, euStore} {
+		_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: store, Name: "mirror"})
+		require.NoError(t, err)
+
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         store,
+			SchemaVersion:   typesystem.SchemaVersion1_1,
+			TypeDefinitions: language.MustTransformDSLToProto("model\n  schema 1.1\ntype user\ntype document\n  relations\n    define viewer: [user]").GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+	}
+
+	write := func(store string, tk *openfgav1.TupleKey) *openfgav1.WriteRequest {
+		return &openfgav1.WriteRequest{StoreId: store, Writes: &openfgav1.WriteRequestWrites{TupleKeys: []*openfgav1.TupleKey{tk}}}
+	}
+
+	requireTuple := func(t *testing.T, store string, tk *openfgav1.TupleKey, exists bool) {
+		_, err := ds.ReadUserTuple(ctx, store, tk)
+		if exists {
+			require.NoError(t, err)
+		} else {
+			require.ErrorIs(t, err, storage.ErrNotFound)
+		}
+	}
+
+	count := func(mode commands.WriteGroupMode, result string) float64 {
+		return testutil.ToFloat64(writeGroupCounter.WithLabelValues(string(mode), result))
+	}
+
+	t.Run("atomic", func(t *testing.T) {
+		tk := tuple.NewTupleKey("document:atomic", "viewer", "user:anne")
+		before := count(commands.WriteGroupAtomic, writeGroupResultApplied)
+
+		resp, err := s.WriteGroup(ctx, []*openfgav1.WriteRequest{write(usStore1, tk), write(usStore2, tk)})
+		require.NoError(t, err)
+		require.Equal(t, commands.WriteGroupAtomic, resp.Mode)
+		require.Len(t, resp.ConsistencyTokens, 2)
+		require.InDelta(t, 1, count(commands.WriteGroupAtomic, writeGroupResultApplied)-before, 0)
+
+		requireTuple(t, usStore1, tk, true)
+		requireTuple(t, usStore2, tk, true)
+
+		// the tuple already exists in the second store, so none is written
+		other := tuple.NewTupleKey("document:atomic", "viewer", "user:bob")
+		_, err = s.WriteGroup(ctx, []*openfgav1.WriteRequest{write(usStore1, other), write(usStore2, tk)})
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonWriteFailedDueToInvalidInput, reason)
+		requireTuple(t, usStore1, other, false)
+	})
+
+	t.Run("saga", func(t *testing.T) {
+		tk := tuple.NewTupleKey("document:saga", "viewer", "user:anne")
+		before := count(commands.WriteGroupSaga, writeGroupResultApplied)
+
+		resp, err := s.WriteGroup(ctx, []*openfgav1.WriteRequest{write(usStore1, tk), write(euStore, tk)})
+		require.NoError(t, err)
+		require.Equal(t, commands.WriteGroupSaga, resp.Mode)
+		require.InDelta(t, 1, count(commands.WriteGroupSaga, writeGroupResultApplied)-before, 0)
+
+		requireTuple(t, usStore1, tk, true)
+		requireTuple(t, euStore, tk, true)
+	})
+
+	t.Run("saga_compensates_the_stores_written_before_the_failed_one", func(t *testing.T) {
+		tk := tuple.NewTupleKey("document:compensated", "viewer", "user:anne")
+		_, err := s.Write(ctx, write(euStore, tk))
+		require.NoError(t, err)
+
+		before := count(commands.WriteGroupSaga, writeGroupResultFailed)
+
+		// the first store is written, and then compensated when the write of the existing tuple fails
+		_, err = s.WriteGroup(ctx, []*openfgav1.WriteRequest{write(usStore2, tk), write(euStore, tk)})
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonWriteFailedDueToInvalidInput, reason)
+		require.InDelta(t, 1, count(commands.WriteGroupSaga, writeGroupResultFailed)-before, 0)
+
+		requireTuple(t, usStore2, tk, false)
+
+		changes, _, err := ds.ReadChanges(ctx, usStore2, storage.ReadChangesFilter{ObjectType: "document"}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		require.NoError(t, err)
+		require.Equal(t, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, changes[len(changes)-1].GetOperation())
+	})
+
+	t.Run("saga_is_rejected_unless_enabled", func(t *testing.T) {
+		disabled := MustNewServerWithOpts(WithDatastore(ds))
+		t.Cleanup(disabled.Close)
+
+		tk := tuple.NewTupleKey("document:rejected", "viewer", "user:anne")
+		_, err := disabled.WriteGroup(ctx, []*openfgav1.WriteRequest{write(usStore1, tk), write(euStore, tk)})
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonWriteGroupNotAtomic, reason)
+
+		requireTuple(t, usStore1, tk, false)
+		requireTuple(t, euStore, tk, false)
+	})
+
+	t.Run("the_stores_must_be_distinct", func(t *testing.T) {
+		tk := tuple.NewTupleKey("document:distinct", "viewer", "user:anne")
+		_, err := s.WriteGroup(ctx, []*openfgav1.WriteRequest{write(usStore1, tk), write(usStore1, tk)})
+		reason, _ := serverErrors.ReasonFromError(err)
+		require.Equal(t, serverErrors.ReasonValidationError, reason)
+	})
+}
@@ -0,0 +1,108 @@
+// Package writegroup serves the write groups on the HTTP gateway: the Write requests of several
+// stores applied all or none, e.g. by the platform operations that keep mirrored stores in sync.
+package writegroup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/openfga/openfga/pkg/server"
+)
+
+// Client reads the stores, so that the requests are authenticated like any other request.
+type Client interface {
+	GetStore(ctx context.Context, in *openfgav1.GetStoreRequest, opts ...grpc.CallOption) (*openfgav1.GetStoreResponse, error)
+}
+
+// Writer applies the write groups. It is implemented by *server.Server.
+type Writer interface {
+	WriteGroup(ctx context.Context, reqs []*openfgav1.WriteRequest) (*server.WriteGroupResponse, error)
+}
+
+// httpRequest is the body of the HTTP request. Each write is unmarshalled with protojson, like the
+// body of a Write request with its 'store_id'.
+type httpRequest struct {
+	Writes []json.RawMessage `json:"writes"`
+}
+
+// Response is the body of the HTTP response.
+type Response struct {
+	// Mode is 'atomic' if the writes were committed in a single transaction, and 'saga' if they were
+	// applied one store after the other.
+	Mode string `json:"mode"`
+
+	// ConsistencyTokens are the consistency tokens of the writes, by store, for the stores with
+	// consistency tokens on.
+	ConsistencyTokens map[string]string `json:"consistency_tokens,omitempty"`
+}
+
+// NewHTTPHandler returns a handler for the HTTP gateway that applies the writes of the body as a
+// group. Each of their stores is first read through the client with the Authorization header of the
+// request.
+func NewHTTPHandler(mux *runtime.ServeMux, client Client, writer Writer) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx := r.Context()
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+		}
+
+		reqs, err := decodeHTTPRequest(r.Body)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+
+		for _, req := range reqs {
+			if _, err := client.GetStore(ctx, &openfgav1.GetStoreRequest{StoreId: req.GetStoreId()}); err != nil {
+				runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+				return
+			}
+		}
+
+		resp, err := writer.WriteGroup(ctx, reqs)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Response{Mode: string(resp.Mode), ConsistencyTokens: resp.ConsistencyTokens})
+	}
+}
+
+func decodeHTTPRequest(body io.Reader) ([]*openfgav1.WriteRequest, error) {
+	var httpReq httpRequest
+	if err := json.NewDecoder(body).Decode(&httpReq); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("the request body is empty")
+		}
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	if len(httpReq.Writes) == 0 {
+		return nil, errors.New("the write group has no writes")
+	}
+
+	reqs := make([]*openfgav1.WriteRequest, 0, len(httpReq.Writes))
+	for i, raw := range httpReq.Writes {
+		req := &openfgav1.WriteRequest{}
+		if err := protojson.Unmarshal(raw, req); err != nil {
+			return nil, fmt.Errorf("invalid writes[%d]: %w", i, err)
+		}
+		reqs = append(reqs, req)
+	}
+
+	return reqs, nil
+}
@@ -0,0 +1,122 @@
+package writegroup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	language "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	httpmiddleware "github.com/openfga/openfga/pkg/middleware/http"
+	"github.com/openfga/openfga/pkg/server"
+	serverErrors "github.com/openfga/openfga/pkg/server/errors"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+type storeClient struct {
+	server        *server.Server
+	authorization []string
+}
+
+func (c *storeClient) GetStore(ctx context.Context, in *openfgav1.GetStoreRequest, _ ...grpc.CallOption) (*openfgav1.GetStoreResponse, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.authorization = md.Get("authorization")
+
+	return c.server.GetStore(ctx, in)
+}
+
+func TestHTTPHandler(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	s := server.MustNewServerWithOpts(server.WithDatastore(ds))
+	t.Cleanup(s.Close)
+
+	var stores []string
+	for i := 0; i < 2; i++ {
+		store, err := s.CreateStore(ctx, &openfgav1.CreateStoreRequest{Name: "mirror"})
+		require.NoError(t, err)
+
+		_, err = s.WriteAuthorizationModel(ctx, &openfgav1.WriteAuthorizationModelRequest{
+			StoreId:         store.GetId(),
+			SchemaVersion:   "1.1",
+			TypeDefinitions: language.MustTransformDSLToProto("model\n  schema 1.1\ntype user\ntype document\n  relations\n    define viewer: [user]").GetTypeDefinitions(),
+		})
+		require.NoError(t, err)
+
+		stores = append(stores, store.GetId())
+	}
+
+	client := &storeClient{server: s}
+
+	// the errors are encoded like on the gateway of the server, which maps the error codes to HTTP statuses
+	mux := runtime.NewServeMux(runtime.WithErrorHandler(func(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+		httpmiddleware.CustomHTTPErrorHandler(ctx, w, r, serverErrors.EncodeError(err))
+	}))
+	require.NoError(t, mux.HandlePath(http.MethodPost, "/write-groups", NewHTTPHandler(mux, client, s)))
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/write-groups", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer key")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	writeOf := func(store string) string {
+		return `{"store_id": "` + store + `", "writes": {"tuple_keys": [{"object": "document:1", "relation": "viewer", "user": "user:anne"}]}}`
+	}
+
+	t.Run("write_group", func(t *testing.T) {
+		w := serve(`{"writes": [` + writeOf(stores[0]) + `, ` + writeOf(stores[1]) + `]}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Equal(t, []string{"Bearer key"}, client.authorization)
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, "atomic", resp.Mode)
+		require.Len(t, resp.ConsistencyTokens, 2)
+
+		for _, store := range stores {
+			_, err := ds.ReadUserTuple(ctx, store, tuple.NewTupleKey("document:1", "viewer", "user:anne"))
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("invalid_write_groups", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"empty_body":     ``,
+			"malformed_body": `{`,
+			"no_writes":      `{"writes": []}`,
+			"invalid_write":  `{"writes": [{"store_id": 1}]}`,
+			"existing_tuple": `{"writes": [` + writeOf(stores[0]) + `]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				w := serve(body)
+				require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+			})
+		}
+	})
+
+	t.Run("unknown_store", func(t *testing.T) {
+		w := serve(`{"writes": [` + writeOf(ulid.Make().String()) + `]}`)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -38,6 +38,14 @@ var (
 
 	// ErrNotFound is returned when the object does not exist.
 	ErrNotFound = errors.New("not found")
+
+	// ErrMultiStoreWriteNotSupported is returned when the stores of a WriteStores can't be written
+	// in a single transaction, e.g. because they are kept in different databases.
+	ErrMultiStoreWriteNotSupported = errors.New("the stores can't be written in a single transaction")
+
+	// ErrUnavailable is returned when the datastore is considered unavailable, for example because
+	// too many of the recent datastore calls failed, and the call was rejected without being sent.
+	ErrUnavailable = errors.New("datastore unavailable")
 )
 
 // ExceededMaxTypeDefinitionsLimitError constructs an error indicating that
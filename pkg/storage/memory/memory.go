@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sort"
@@ -12,9 +13,12 @@ import (
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
 	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/telemetry"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
@@ -120,8 +124,43 @@ type MemoryBackend struct {
 	// map: store id => store data
 	stores map[string]*openfgav1.Store // GUARDED_BY(mu_).
 
+	// the ids of the deleted stores, which can't be the ids of new stores, like the soft-deleted
+	// stores of the SQL datastores
+	deletedStores map[string]struct{} // GUARDED_BY(mu_).
+
 	// map: store id | authz model id => assertions
 	assertions map[string][]*openfgav1.Assertion // GUARDED_BY(mu_).
+
+	// map: store id => store settings
+	storeSettings map[string]*storage.StoreSettings // GUARDED_BY(mu_).
+
+	// map: store id => store labels
+	storeLabels map[string]map[string]string // GUARDED_BY(mu_).
+
+	// map: store id => ULIDs of the writes set with storage.ContextWithChangeULID
+	changeULIDs map[string]map[string]struct{} // GUARDED_BY(mu_).
+
+	// map: store id => planner statistics
+	plannerStatistics map[string][]byte // GUARDED_BY(mu_).
+
+	// map: store id => object type#relation => usage of the relation
+	relationUsage map[string]map[string]storage.RelationUsage // GUARDED_BY(mu_).
+
+	// map: store id => change key (see changeKey) => writer, of the changes written with a writer
+	changeWriters map[string]map[string]string // GUARDED_BY(mu_).
+
+	// map: store id => consumer => continuation token of its changelog checkpoint
+	changelogCheckpoints map[string]map[string]string // GUARDED_BY(mu_).
+
+	// changed is true if the contents changed since the last snapshot.
+	changed bool // GUARDED_BY(mu_).
+
+	snapshotPath     string
+	snapshotInterval time.Duration
+	stopSnapshots    chan struct{}
+	snapshotsDone    sync.WaitGroup
+	closeOnce        sync.Once
+	logger           logger.Logger
 }
 
 // Ensures that [MemoryBackend] implements the [storage.OpenFGADatastore] interface.
@@ -136,6 +175,13 @@ type AuthorizationModelEntry struct {
 
 // New creates a new [MemoryBackend] given the options.
 func New(opts ...StorageOption) storage.OpenFGADatastore {
+	ds := newMemoryBackend(opts...)
+	ds.startSnapshots()
+
+	return ds
+}
+
+func newMemoryBackend(opts ...StorageOption) *MemoryBackend {
 	ds := &MemoryBackend{
 		maxTuplesPerWrite:             defaultMaxTuplesPerWrite,
 		maxTypesPerAuthorizationModel: defaultMaxTypesPerAuthorizationModel,
@@ -143,7 +189,17 @@ func New(opts ...StorageOption) storage.OpenFGADatastore {
 		changes:                       make(map[string][]*openfgav1.TupleChange, 0),
 		authorizationModels:           make(map[string]map[string]*AuthorizationModelEntry),
 		stores:                        make(map[string]*openfgav1.Store, 0),
+		deletedStores:                 make(map[string]struct{}, 0),
 		assertions:                    make(map[string][]*openfgav1.Assertion, 0),
+		storeSettings:                 make(map[string]*storage.StoreSettings, 0),
+		storeLabels:                   make(map[string]map[string]string, 0),
+		changeULIDs:                   make(map[string]map[string]struct{}, 0),
+		plannerStatistics:             make(map[string][]byte, 0),
+		relationUsage:                 make(map[string]map[string]storage.RelationUsage, 0),
+		changeWriters:                 make(map[string]map[string]string, 0),
+		changelogCheckpoints:          make(map[string]map[string]string, 0),
+		stopSnapshots:                 make(chan struct{}),
+		logger:                        logger.NewNoopLogger(),
 	}
 
 	for _, opt := range opts {
@@ -167,8 +223,27 @@ func WithMaxTypesPerAuthorizationModel(n int) StorageOption {
 	return func(ds *MemoryBackend) { ds.maxTypesPerAuthorizationModel = n }
 }
 
-// Close does not do anything for [MemoryBackend].
-func (s *MemoryBackend) Close() {}
+// WithLogger returns a [StorageOption] that sets the logger used to report errors persisting snapshots.
+func WithLogger(l logger.Logger) StorageOption {
+	return func(ds *MemoryBackend) { ds.logger = l }
+}
+
+// Close persists a final snapshot if snapshots are enabled (see [WithSnapshotPath]).
+// Otherwise, it does not do anything.
+func (s *MemoryBackend) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stopSnapshots)
+		s.snapshotsDone.Wait()
+
+		if s.snapshotPath == "" {
+			return
+		}
+
+		if err := s.writeSnapshot(); err != nil {
+			s.logger.Error("failed to write memory datastore snapshot", zap.String("path", s.snapshotPath), zap.Error(err))
+		}
+	})
+}
 
 // Read see [storage.RelationshipTupleReader].Read.
 func (s *MemoryBackend) Read(ctx context.Context, store string, key *openfgav1.TupleKey) (storage.TupleIterator, error) {
@@ -199,8 +274,8 @@ func (s *MemoryBackend) ReadPage(
 // ReadChanges see [storage.ChangelogBackend].ReadChanges.
 func (s *MemoryBackend) ReadChanges(
 	ctx context.Context,
-	store,
-	objectType string,
+	store string,
+	filter storage.ReadChangesFilter,
 	paginationOptions storage.PaginationOptions,
 	horizonOffset time.Duration,
 ) ([]*openfgav1.TupleChange, []byte, error) {
@@ -226,14 +301,14 @@ func (s *MemoryBackend) ReadChanges(
 		}
 	}
 
-	if typeInToken != "" && typeInToken != objectType {
+	if typeInToken != "" && typeInToken != filter.ObjectType {
 		return nil, nil, storage.ErrMismatchObjectType
 	}
 
 	var allChanges []*openfgav1.TupleChange
 	now := time.Now().UTC()
 	for _, change := range s.changes[store] {
-		if objectType == "" || (objectType != "" && strings.HasPrefix(change.GetTupleKey().GetObject(), objectType+":")) {
+		if matchChange(change.GetTupleKey(), filter) && !change.GetTimestamp().AsTime().Before(filter.StartTime) {
 			if change.GetTimestamp().AsTime().After(now.Add(-horizonOffset)) {
 				break
 			}
@@ -261,11 +336,39 @@ func (s *MemoryBackend) ReadChanges(
 	if to != len(allChanges) {
 		continuationToken = strconv.Itoa(to)
 	}
-	continuationToken += fmt.Sprintf("|%s", objectType)
+	continuationToken += fmt.Sprintf("|%s", filter.ObjectType)
 
 	return res, []byte(continuationToken), nil
 }
 
+// ChangeExists see [storage.ChangelogBackend].ChangeExists. The changes of the memory datastore have
+// no ULID, so it only knows the ULIDs of the writes that set one with [storage.ContextWithChangeULID].
+func (s *MemoryBackend) ChangeExists(ctx context.Context, store, id string) (bool, error) {
+	_, span := tracer.Start(ctx, "memory.ChangeExists")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.changeULIDs[store][id]
+	return ok, nil
+}
+
+// matchChange reports whether the tuple of a change matches the filter.
+func matchChange(tk *openfgav1.TupleKey, filter storage.ReadChangesFilter) bool {
+	if filter.ObjectType != "" && !strings.HasPrefix(tk.GetObject(), filter.ObjectType+":"+filter.ObjectIDPrefix) {
+		return false
+	}
+	if filter.Relation != "" && tk.GetRelation() != filter.Relation {
+		return false
+	}
+	if filter.User != "" && tk.GetUser() != filter.User {
+		return false
+	}
+
+	return true
+}
+
 func (s *MemoryBackend) read(ctx context.Context, store string, tk *openfgav1.TupleKey, paginationOptions storage.PaginationOptions) (*staticIterator, error) {
 	_, span := tracer.Start(ctx, "memory.read")
 	defer span.End()
@@ -273,13 +376,15 @@ func (s *MemoryBackend) read(ctx context.Context, store string, tk *openfgav1.Tu
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	conditionName := tk.GetCondition().GetName()
+
 	var matches []*storage.TupleRecord
-	if tk.GetObject() == "" && tk.GetRelation() == "" && tk.GetUser() == "" {
+	if tk.GetObject() == "" && tk.GetRelation() == "" && tk.GetUser() == "" && conditionName == "" {
 		matches = make([]*storage.TupleRecord, len(s.tuples[store]))
 		copy(matches, s.tuples[store])
 	} else {
 		for _, t := range s.tuples[store] {
-			if match(t, tk) {
+			if match(t, tk) && (conditionName == "" || t.ConditionName == conditionName) {
 				matches = append(matches, t)
 			}
 		}
@@ -315,12 +420,45 @@ func (s *MemoryBackend) Write(ctx context.Context, store string, deletes storage
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	now := timestamppb.Now()
-
 	if err := validateTuples(s.tuples[store], deletes, writes); err != nil {
 		return err
 	}
 
+	s.write(ctx, store, deletes, writes, timestamppb.Now())
+
+	s.changed = true
+	return nil
+}
+
+// WriteStores see [storage.MultiStoreWriteBackend].WriteStores.
+func (s *MemoryBackend) WriteStores(ctx context.Context, writes []storage.StoreWrite) error {
+	_, span := tracer.Start(ctx, "memory.WriteStores")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// all the stores are validated before any of them is written
+	for _, w := range writes {
+		if err := validateTuples(s.tuples[w.Store], w.Deletes, w.Writes); err != nil {
+			return err
+		}
+	}
+
+	now := timestamppb.Now()
+	for _, w := range writes {
+		s.write(storage.ContextWithChangeULIDs(ctx, w.ChangeULIDs), w.Store, w.Deletes, w.Writes, now)
+	}
+
+	s.changed = true
+	return nil
+}
+
+// write deletes and writes the validated tuples of a store, and appends their changes to its
+// changelog. It must be called with the lock held.
+func (s *MemoryBackend) write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes, now *timestamppb.Timestamp) {
+	writer := storage.WriterFromContext(ctx)
+
 	var records []*storage.TupleRecord
 Delete:
 	for _, tr := range s.tuples[store] {
@@ -328,14 +466,11 @@ Delete:
 		tk := t.GetKey()
 		for _, k := range deletes {
 			if match(tr, tupleUtils.TupleKeyWithoutConditionToTupleKey(k)) {
-				s.changes[store] = append(
-					s.changes[store],
-					&openfgav1.TupleChange{
-						TupleKey:  tupleUtils.NewTupleKey(tk.GetObject(), tk.GetRelation(), tk.GetUser()), // Redact the condition info.
-						Operation: openfgav1.TupleOperation_TUPLE_OPERATION_DELETE,
-						Timestamp: now,
-					},
-				)
+				s.appendChange(store, writer, &openfgav1.TupleChange{
+					TupleKey:  tupleUtils.NewTupleKey(tk.GetObject(), tk.GetRelation(), tk.GetUser()), // Redact the condition info.
+					Operation: openfgav1.TupleOperation_TUPLE_OPERATION_DELETE,
+					Timestamp: now,
+				})
 				continue Delete
 			}
 		}
@@ -369,6 +504,7 @@ Write:
 			ConditionContext: conditionContext,
 			Ulid:             ulid.MustNew(ulid.Timestamp(now.AsTime()), ulid.DefaultEntropy()).String(),
 			InsertedAt:       now.AsTime(),
+			CreatedBy:        writer,
 		})
 
 		tk := tupleUtils.NewTupleKeyWithCondition(
@@ -379,14 +515,78 @@ Write:
 			conditionContext,
 		)
 
-		s.changes[store] = append(s.changes[store], &openfgav1.TupleChange{
+		s.appendChange(store, writer, &openfgav1.TupleChange{
 			TupleKey:  tk,
 			Operation: openfgav1.TupleOperation_TUPLE_OPERATION_WRITE,
 			Timestamp: now,
 		})
 	}
 	s.tuples[store] = records
-	return nil
+
+	for _, id := range storage.ChangeULIDsFromContext(ctx) {
+		if id == "" {
+			continue
+		}
+		if _, ok := s.changeULIDs[store]; !ok {
+			s.changeULIDs[store] = map[string]struct{}{}
+		}
+		s.changeULIDs[store][id] = struct{}{}
+	}
+}
+
+// appendChange appends a change to the changelog of a store, and records its writer, if any.
+func (s *MemoryBackend) appendChange(store, writer string, change *openfgav1.TupleChange) {
+	s.changes[store] = append(s.changes[store], change)
+
+	if writer == "" {
+		return
+	}
+	if _, ok := s.changeWriters[store]; !ok {
+		s.changeWriters[store] = map[string]string{}
+	}
+	s.changeWriters[store][changeKey(change)] = writer
+}
+
+// changeKey identifies a change of a store by its tuple key, operation and timestamp.
+func changeKey(change *openfgav1.TupleChange) string {
+	return fmt.Sprintf("%s|%d|%d", tupleUtils.TupleKeyToString(change.GetTupleKey()), change.GetOperation(), change.GetTimestamp().AsTime().UnixNano())
+}
+
+// ReadTupleWriters see [storage.TupleWritersBackend].ReadTupleWriters.
+func (s *MemoryBackend) ReadTupleWriters(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error) {
+	_, span := tracer.Start(ctx, "memory.ReadTupleWriters")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writers := make([]string, len(tupleKeys))
+	for i, tk := range tupleKeys {
+		for _, record := range s.tuples[store] {
+			if match(record, tupleUtils.NewTupleKey(tk.GetObject(), tk.GetRelation(), tk.GetUser())) {
+				writers[i] = record.CreatedBy
+				break
+			}
+		}
+	}
+
+	return writers, nil
+}
+
+// ReadChangeWriters see [storage.TupleWritersBackend].ReadChangeWriters.
+func (s *MemoryBackend) ReadChangeWriters(ctx context.Context, store string, changes []*openfgav1.TupleChange) ([]string, error) {
+	_, span := tracer.Start(ctx, "memory.ReadChangeWriters")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writers := make([]string, len(changes))
+	for i, change := range changes {
+		writers[i] = s.changeWriters[store][changeKey(change)]
+	}
+
+	return writers, nil
 }
 
 func validateTuples(
@@ -645,6 +845,10 @@ func (s *MemoryBackend) WriteAuthorizationModel(ctx context.Context, store strin
 		s.authorizationModels[store] = make(map[string]*AuthorizationModelEntry)
 	}
 
+	if _, ok := s.authorizationModels[store][model.GetId()]; ok {
+		return storage.ErrCollision
+	}
+
 	for _, entry := range s.authorizationModels[store] {
 		entry.latest = false
 	}
@@ -653,6 +857,7 @@ func (s *MemoryBackend) WriteAuthorizationModel(ctx context.Context, store strin
 		model:  model,
 		latest: true,
 	}
+	s.changed = true
 
 	return nil
 }
@@ -669,6 +874,10 @@ func (s *MemoryBackend) CreateStore(ctx context.Context, newStore *openfgav1.Sto
 		return nil, storage.ErrCollision
 	}
 
+	if _, ok := s.deletedStores[newStore.GetId()]; ok {
+		return nil, storage.ErrCollision
+	}
+
 	now := timestamppb.New(time.Now().UTC())
 	s.stores[newStore.GetId()] = &openfgav1.Store{
 		Id:        newStore.GetId(),
@@ -676,6 +885,7 @@ func (s *MemoryBackend) CreateStore(ctx context.Context, newStore *openfgav1.Sto
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
+	s.changed = true
 
 	return s.stores[newStore.GetId()], nil
 }
@@ -688,10 +898,74 @@ func (s *MemoryBackend) DeleteStore(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	delete(s.stores, id)
+	if _, ok := s.stores[id]; ok {
+		delete(s.stores, id)
+		s.deletedStores[id] = struct{}{}
+	}
+	s.changed = true
 	return nil
 }
 
+// UpdateStore see [storage.StoresBackend].UpdateStore.
+func (s *MemoryBackend) UpdateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	_, span := tracer.Start(ctx, "memory.UpdateStore")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.stores[store.GetId()]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	s.stores[store.GetId()] = &openfgav1.Store{
+		Id:        current.GetId(),
+		Name:      store.GetName(),
+		CreatedAt: current.GetCreatedAt(),
+		UpdatedAt: timestamppb.New(time.Now().UTC()),
+	}
+	s.changed = true
+
+	return s.stores[store.GetId()], nil
+}
+
+// WriteStoreLabels see [storage.StoresBackend].WriteStoreLabels.
+func (s *MemoryBackend) WriteStoreLabels(ctx context.Context, store string, labels map[string]string) error {
+	_, span := tracer.Start(ctx, "memory.WriteStoreLabels")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(labels) == 0 {
+		delete(s.storeLabels, store)
+	} else {
+		s.storeLabels[store] = maps.Clone(labels)
+	}
+	s.changed = true
+
+	return nil
+}
+
+// ReadStoreLabels see [storage.StoresBackend].ReadStoreLabels.
+func (s *MemoryBackend) ReadStoreLabels(ctx context.Context, stores []string) (map[string]map[string]string, error) {
+	_, span := tracer.Start(ctx, "memory.ReadStoreLabels")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	labels := make(map[string]map[string]string, len(stores))
+	for _, store := range stores {
+		if storeLabels, ok := s.storeLabels[store]; ok {
+			labels[store] = maps.Clone(storeLabels)
+		}
+	}
+
+	return labels, nil
+}
+
 // WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
 func (s *MemoryBackend) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
 	_, span := tracer.Start(ctx, "memory.WriteAssertions")
@@ -702,6 +976,7 @@ func (s *MemoryBackend) WriteAssertions(ctx context.Context, store, modelID stri
 
 	assertionsID := fmt.Sprintf("%s|%s", store, modelID)
 	s.assertions[assertionsID] = assertions
+	s.changed = true
 
 	return nil
 }
@@ -722,6 +997,147 @@ func (s *MemoryBackend) ReadAssertions(ctx context.Context, store, modelID strin
 	return assertions, nil
 }
 
+// WriteStoreSettings see [storage.StoreSettingsBackend].WriteStoreSettings.
+func (s *MemoryBackend) WriteStoreSettings(ctx context.Context, store string, settings *storage.StoreSettings) error {
+	_, span := tracer.Start(ctx, "memory.WriteStoreSettings")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *settings
+	s.storeSettings[store] = &copied
+	s.changed = true
+
+	return nil
+}
+
+// ReadStoreSettings see [storage.StoreSettingsBackend].ReadStoreSettings.
+func (s *MemoryBackend) ReadStoreSettings(ctx context.Context, store string) (*storage.StoreSettings, error) {
+	_, span := tracer.Start(ctx, "memory.ReadStoreSettings")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.storeSettings[store]
+	if !ok {
+		return &storage.StoreSettings{}, nil
+	}
+
+	copied := *settings
+	return &copied, nil
+}
+
+// WritePlannerStatistics see [storage.PlannerStatisticsBackend].WritePlannerStatistics.
+func (s *MemoryBackend) WritePlannerStatistics(ctx context.Context, store string, statistics []byte) error {
+	_, span := tracer.Start(ctx, "memory.WritePlannerStatistics")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.plannerStatistics[store] = bytes.Clone(statistics)
+	s.changed = true
+
+	return nil
+}
+
+// ReadPlannerStatistics see [storage.PlannerStatisticsBackend].ReadPlannerStatistics.
+func (s *MemoryBackend) ReadPlannerStatistics(ctx context.Context, store string) ([]byte, error) {
+	_, span := tracer.Start(ctx, "memory.ReadPlannerStatistics")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return bytes.Clone(s.plannerStatistics[store]), nil
+}
+
+// AddRelationUsage see [storage.RelationUsageBackend].AddRelationUsage.
+func (s *MemoryBackend) AddRelationUsage(ctx context.Context, store string, usage []storage.RelationUsage) error {
+	_, span := tracer.Start(ctx, "memory.AddRelationUsage")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	relations, ok := s.relationUsage[store]
+	if !ok {
+		relations = map[string]storage.RelationUsage{}
+		s.relationUsage[store] = relations
+	}
+
+	for _, added := range usage {
+		key := tupleUtils.ToObjectRelationString(added.ObjectType, added.Relation)
+
+		current, ok := relations[key]
+		if !ok {
+			current = storage.RelationUsage{ObjectType: added.ObjectType, Relation: added.Relation}
+		}
+		current.Allowed += added.Allowed
+		current.Denied += added.Denied
+		if added.LastEvaluatedAt.After(current.LastEvaluatedAt) {
+			current.LastEvaluatedAt = added.LastEvaluatedAt.UTC()
+		}
+		relations[key] = current
+	}
+	s.changed = true
+
+	return nil
+}
+
+// ReadRelationUsage see [storage.RelationUsageBackend].ReadRelationUsage.
+func (s *MemoryBackend) ReadRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	_, span := tracer.Start(ctx, "memory.ReadRelationUsage")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := make([]storage.RelationUsage, 0, len(s.relationUsage[store]))
+	for _, relation := range s.relationUsage[store] {
+		usage = append(usage, relation)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].ObjectType != usage[j].ObjectType {
+			return usage[i].ObjectType < usage[j].ObjectType
+		}
+		return usage[i].Relation < usage[j].Relation
+	})
+
+	return usage, nil
+}
+
+// WriteChangelogCheckpoint see [storage.ChangelogCheckpointBackend].WriteChangelogCheckpoint.
+func (s *MemoryBackend) WriteChangelogCheckpoint(ctx context.Context, store, consumer, continuationToken string) error {
+	_, span := tracer.Start(ctx, "memory.WriteChangelogCheckpoint")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.changelogCheckpoints[store]; !ok {
+		s.changelogCheckpoints[store] = make(map[string]string)
+	}
+	s.changelogCheckpoints[store][consumer] = continuationToken
+	s.changed = true
+
+	return nil
+}
+
+// ReadChangelogCheckpoint see [storage.ChangelogCheckpointBackend].ReadChangelogCheckpoint.
+func (s *MemoryBackend) ReadChangelogCheckpoint(ctx context.Context, store, consumer string) (string, error) {
+	_, span := tracer.Start(ctx, "memory.ReadChangelogCheckpoint")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.changelogCheckpoints[store][consumer], nil
+}
+
 // MaxTuplesPerWrite see [storage.RelationshipTupleWriter].MaxTuplesPerWrite.
 func (s *MemoryBackend) MaxTuplesPerWrite() int {
 	return s.maxTuplesPerWrite
@@ -748,7 +1164,11 @@ func (s *MemoryBackend) GetStore(ctx context.Context, storeID string) (*openfgav
 }
 
 // ListStores provides a paginated list of all stores present in the MemoryBackend.
-func (s *MemoryBackend) ListStores(ctx context.Context, paginationOptions storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
+func (s *MemoryBackend) ListStores(
+	ctx context.Context,
+	filter storage.ListStoresFilter,
+	paginationOptions storage.PaginationOptions,
+) ([]*openfgav1.Store, []byte, error) {
 	_, span := tracer.Start(ctx, "memory.ListStores")
 	defer span.End()
 
@@ -757,7 +1177,9 @@ func (s *MemoryBackend) ListStores(ctx context.Context, paginationOptions storag
 
 	stores := make([]*openfgav1.Store, 0, len(s.stores))
 	for _, t := range s.stores {
-		stores = append(stores, t)
+		if s.matchesListStoresFilter(t, filter) {
+			stores = append(stores, t)
+		}
 	}
 
 	// From oldest to newest.
@@ -794,6 +1216,30 @@ func (s *MemoryBackend) ListStores(ctx context.Context, paginationOptions storag
 	return res, []byte(continuationToken), nil
 }
 
+// matchesListStoresFilter reports whether the store matches the filter. It must be called with the lock held.
+func (s *MemoryBackend) matchesListStoresFilter(store *openfgav1.Store, filter storage.ListStoresFilter) bool {
+	if filter.NameContains != "" && !strings.Contains(strings.ToLower(store.GetName()), strings.ToLower(filter.NameContains)) {
+		return false
+	}
+
+	createdAt := store.GetCreatedAt().AsTime()
+	if !filter.CreatedAfter.IsZero() && createdAt.Before(filter.CreatedAfter) {
+		return false
+	}
+	if !filter.CreatedBefore.IsZero() && !createdAt.Before(filter.CreatedBefore) {
+		return false
+	}
+
+	labels := s.storeLabels[store.GetId()]
+	for key, value := range filter.Labels {
+		if current, ok := labels[key]; !ok || current != value {
+			return false
+		}
+	}
+
+	return true
+}
+
 // IsReady see [storage.OpenFGADatastore].IsReady.
 func (s *MemoryBackend) IsReady(context.Context) (storage.ReadinessStatus, error) {
 	return storage.ReadinessStatus{IsReady: true}, nil
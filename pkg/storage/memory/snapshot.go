@@ -0,0 +1,485 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/storage"
+	tupleUtils "github.com/openfga/openfga/pkg/tuple"
+)
+
+const snapshotVersion = 1
+
+// snapshot is the on-disk representation of the contents of a [MemoryBackend]. Protobuf messages are
+// encoded with protojson so that snapshots stay readable and compatible across API versions.
+type snapshot struct {
+	Version              int                                                   `json:"version"`
+	Stores               map[string]json.RawMessage                            `json:"stores"`
+	DeletedStores        []string                                              `json:"deleted_stores,omitempty"`
+	Tuples               map[string][]tupleRecordSnapshot                      `json:"tuples"`
+	Changes              map[string][]json.RawMessage                          `json:"changes"`
+	AuthorizationModels  map[string]map[string]authorizationModelEntrySnapshot `json:"authorization_models"`
+	Assertions           map[string][]json.RawMessage                          `json:"assertions"`
+	StoreSettings        map[string]storeSettingsSnapshot                      `json:"store_settings,omitempty"`
+	StoreLabels          map[string]map[string]string                          `json:"store_labels,omitempty"`
+	PlannerStatistics    map[string][]byte                                     `json:"planner_statistics,omitempty"`
+	RelationUsage        map[string][]relationUsageSnapshot                    `json:"relation_usage,omitempty"`
+	ChangeULIDs          map[string][]string                                   `json:"change_ulids,omitempty"`
+	ChangeWriters        map[string]map[string]string                          `json:"change_writers,omitempty"`
+	ChangelogCheckpoints map[string]map[string]string                          `json:"changelog_checkpoints,omitempty"`
+}
+
+type tupleRecordSnapshot struct {
+	ObjectType       string          `json:"object_type"`
+	ObjectID         string          `json:"object_id"`
+	Relation         string          `json:"relation"`
+	User             string          `json:"user"`
+	ConditionName    string          `json:"condition_name,omitempty"`
+	ConditionContext json.RawMessage `json:"condition_context,omitempty"`
+	Ulid             string          `json:"ulid"`
+	InsertedAt       time.Time       `json:"inserted_at"`
+	CreatedBy        string          `json:"created_by,omitempty"`
+}
+
+type storeSettingsSnapshot struct {
+	DefaultAuthorizationModelID   string        `json:"default_authorization_model_id,omitempty"`
+	DefaultConsistency            string        `json:"default_consistency,omitempty"`
+	CheckQueryCacheTTL            time.Duration `json:"check_query_cache_ttl,omitempty"`
+	CandidateAuthorizationModelID string        `json:"candidate_authorization_model_id,omitempty"`
+}
+
+type relationUsageSnapshot struct {
+	ObjectType      string    `json:"object_type"`
+	Relation        string    `json:"relation"`
+	Allowed         uint64    `json:"allowed"`
+	Denied          uint64    `json:"denied"`
+	LastEvaluatedAt time.Time `json:"last_evaluated_at"`
+}
+
+type authorizationModelEntrySnapshot struct {
+	Model  json.RawMessage `json:"model"`
+	Latest bool            `json:"latest"`
+}
+
+// WithSnapshotPath returns a [StorageOption] that persists the contents of the [MemoryBackend] to a
+// snapshot file at path every snapshot interval (see [WithSnapshotInterval]) and when it is closed.
+// Use [NewFromSnapshot] to load the snapshot again. If the path is empty, which is the default,
+// nothing is persisted.
+func WithSnapshotPath(path string) StorageOption {
+	return func(ds *MemoryBackend) { ds.snapshotPath = path }
+}
+
+// WithSnapshotInterval returns a [StorageOption] that sets how often the contents of the [MemoryBackend]
+// are persisted to the snapshot file, if they changed. If the interval is 0, the contents are only
+// persisted when the [MemoryBackend] is closed.
+func WithSnapshotInterval(interval time.Duration) StorageOption {
+	return func(ds *MemoryBackend) { ds.snapshotInterval = interval }
+}
+
+// NewFromSnapshot creates a new [MemoryBackend] given the options and loads the contents of the snapshot
+// file at path into it. If the file doesn't exist, the [MemoryBackend] starts empty.
+// The same path is used to persist snapshots, unless another one is set with [WithSnapshotPath].
+func NewFromSnapshot(path string, opts ...StorageOption) (storage.OpenFGADatastore, error) {
+	ds := newMemoryBackend(append([]StorageOption{WithSnapshotPath(path)}, opts...)...)
+
+	if err := ds.loadSnapshot(path); err != nil {
+		return nil, fmt.Errorf("load snapshot from '%s': %w", path, err)
+	}
+
+	ds.startSnapshots()
+
+	return ds, nil
+}
+
+// startSnapshots starts persisting snapshots periodically, if enabled.
+func (s *MemoryBackend) startSnapshots() {
+	if s.snapshotPath == "" || s.snapshotInterval <= 0 {
+		return
+	}
+
+	s.snapshotsDone.Add(1)
+	go func() {
+		defer s.snapshotsDone.Done()
+
+		ticker := time.NewTicker(s.snapshotInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopSnapshots:
+				return
+			case <-ticker.C:
+				if err := s.writeSnapshot(); err != nil {
+					s.logger.Error("failed to write memory datastore snapshot", zap.String("path", s.snapshotPath), zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// writeSnapshot persists the contents of the [MemoryBackend] to the snapshot file if they
+// changed since the last snapshot.
+func (s *MemoryBackend) writeSnapshot() error {
+	s.mu.Lock()
+	if !s.changed {
+		s.mu.Unlock()
+		return nil
+	}
+
+	snap, err := s.buildSnapshot()
+	if err == nil {
+		s.changed = false
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return s.snapshotFailed(err)
+	}
+
+	// write to a temporary file and rename it, so that a crash never leaves a partial snapshot behind
+	tmp, err := os.CreateTemp(filepath.Dir(s.snapshotPath), filepath.Base(s.snapshotPath)+".*.tmp")
+	if err != nil {
+		return s.snapshotFailed(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return s.snapshotFailed(err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return s.snapshotFailed(err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.snapshotPath); err != nil {
+		return s.snapshotFailed(err)
+	}
+
+	return nil
+}
+
+// snapshotFailed marks the contents as changed so that the next snapshot is attempted again.
+func (s *MemoryBackend) snapshotFailed(err error) error {
+	s.mu.Lock()
+	s.changed = true
+	s.mu.Unlock()
+
+	return err
+}
+
+// buildSnapshot returns the contents of the [MemoryBackend]. It must be called with s.mu held.
+func (s *MemoryBackend) buildSnapshot() (*snapshot, error) {
+	snap := &snapshot{
+		Version:              snapshotVersion,
+		Stores:               make(map[string]json.RawMessage, len(s.stores)),
+		Tuples:               make(map[string][]tupleRecordSnapshot, len(s.tuples)),
+		Changes:              make(map[string][]json.RawMessage, len(s.changes)),
+		AuthorizationModels:  make(map[string]map[string]authorizationModelEntrySnapshot, len(s.authorizationModels)),
+		Assertions:           make(map[string][]json.RawMessage, len(s.assertions)),
+		StoreSettings:        make(map[string]storeSettingsSnapshot, len(s.storeSettings)),
+		StoreLabels:          make(map[string]map[string]string, len(s.storeLabels)),
+		PlannerStatistics:    make(map[string][]byte, len(s.plannerStatistics)),
+		RelationUsage:        make(map[string][]relationUsageSnapshot, len(s.relationUsage)),
+		ChangeULIDs:          make(map[string][]string, len(s.changeULIDs)),
+		ChangeWriters:        make(map[string]map[string]string, len(s.changeWriters)),
+		ChangelogCheckpoints: make(map[string]map[string]string, len(s.changelogCheckpoints)),
+	}
+
+	for id, store := range s.stores {
+		data, err := protojson.Marshal(store)
+		if err != nil {
+			return nil, err
+		}
+		snap.Stores[id] = data
+	}
+
+	snap.DeletedStores = make([]string, 0, len(s.deletedStores))
+	for id := range s.deletedStores {
+		snap.DeletedStores = append(snap.DeletedStores, id)
+	}
+	sort.Strings(snap.DeletedStores)
+
+	for store, records := range s.tuples {
+		tuples := make([]tupleRecordSnapshot, 0, len(records))
+		for _, record := range records {
+			t := tupleRecordSnapshot{
+				ObjectType:    record.ObjectType,
+				ObjectID:      record.ObjectID,
+				Relation:      record.Relation,
+				User:          record.User,
+				ConditionName: record.ConditionName,
+				Ulid:          record.Ulid,
+				InsertedAt:    record.InsertedAt,
+				CreatedBy:     record.CreatedBy,
+			}
+
+			if record.ConditionContext != nil {
+				data, err := protojson.Marshal(record.ConditionContext)
+				if err != nil {
+					return nil, err
+				}
+				t.ConditionContext = data
+			}
+
+			tuples = append(tuples, t)
+		}
+		snap.Tuples[store] = tuples
+	}
+
+	for store, changes := range s.changes {
+		data, err := marshalMessages(changes)
+		if err != nil {
+			return nil, err
+		}
+		snap.Changes[store] = data
+	}
+
+	for store, entries := range s.authorizationModels {
+		models := make(map[string]authorizationModelEntrySnapshot, len(entries))
+		for id, entry := range entries {
+			data, err := protojson.Marshal(entry.model)
+			if err != nil {
+				return nil, err
+			}
+			models[id] = authorizationModelEntrySnapshot{Model: data, Latest: entry.latest}
+		}
+		snap.AuthorizationModels[store] = models
+	}
+
+	for id, assertions := range s.assertions {
+		data, err := marshalMessages(assertions)
+		if err != nil {
+			return nil, err
+		}
+		snap.Assertions[id] = data
+	}
+
+	for store, settings := range s.storeSettings {
+		snap.StoreSettings[store] = storeSettingsSnapshot{
+			DefaultAuthorizationModelID:   settings.DefaultAuthorizationModelID,
+			DefaultConsistency:            settings.DefaultConsistency,
+			CheckQueryCacheTTL:            settings.CheckQueryCacheTTL,
+			CandidateAuthorizationModelID: settings.CandidateAuthorizationModelID,
+		}
+	}
+
+	for store, labels := range s.storeLabels {
+		snap.StoreLabels[store] = maps.Clone(labels)
+	}
+
+	for store, statistics := range s.plannerStatistics {
+		snap.PlannerStatistics[store] = statistics
+	}
+
+	for store, relations := range s.relationUsage {
+		for _, usage := range relations {
+			snap.RelationUsage[store] = append(snap.RelationUsage[store], relationUsageSnapshot{
+				ObjectType:      usage.ObjectType,
+				Relation:        usage.Relation,
+				Allowed:         usage.Allowed,
+				Denied:          usage.Denied,
+				LastEvaluatedAt: usage.LastEvaluatedAt,
+			})
+		}
+	}
+
+	for store, ids := range s.changeULIDs {
+		snap.ChangeULIDs[store] = maps.Keys(ids)
+	}
+
+	for store, writers := range s.changeWriters {
+		snap.ChangeWriters[store] = maps.Clone(writers)
+	}
+
+	for store, checkpoints := range s.changelogCheckpoints {
+		snap.ChangelogCheckpoints[store] = maps.Clone(checkpoints)
+	}
+
+	return snap, nil
+}
+
+// loadSnapshot replaces the contents of the [MemoryBackend] with the contents of the snapshot file at
+// path. A missing file is not an error.
+func (s *MemoryBackend) loadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, raw := range snap.Stores {
+		store := &openfgav1.Store{}
+		if err := protojson.Unmarshal(raw, store); err != nil {
+			return err
+		}
+		s.stores[id] = store
+	}
+
+	for _, id := range snap.DeletedStores {
+		s.deletedStores[id] = struct{}{}
+	}
+
+	for store, tuples := range snap.Tuples {
+		records := make([]*storage.TupleRecord, 0, len(tuples))
+		for _, t := range tuples {
+			record := &storage.TupleRecord{
+				Store:         store,
+				ObjectType:    t.ObjectType,
+				ObjectID:      t.ObjectID,
+				Relation:      t.Relation,
+				User:          t.User,
+				ConditionName: t.ConditionName,
+				Ulid:          t.Ulid,
+				InsertedAt:    t.InsertedAt,
+				CreatedBy:     t.CreatedBy,
+			}
+
+			if len(t.ConditionContext) > 0 {
+				record.ConditionContext = &structpb.Struct{}
+				if err := protojson.Unmarshal(t.ConditionContext, record.ConditionContext); err != nil {
+					return err
+				}
+			}
+
+			records = append(records, record)
+		}
+		s.tuples[store] = records
+	}
+
+	for store, raw := range snap.Changes {
+		changes, err := unmarshalMessages[openfgav1.TupleChange](raw)
+		if err != nil {
+			return err
+		}
+		s.changes[store] = changes
+	}
+
+	for store, models := range snap.AuthorizationModels {
+		entries := make(map[string]*AuthorizationModelEntry, len(models))
+		for id, m := range models {
+			model := &openfgav1.AuthorizationModel{}
+			if err := protojson.Unmarshal(m.Model, model); err != nil {
+				return err
+			}
+			entries[id] = &AuthorizationModelEntry{model: model, latest: m.Latest}
+		}
+		s.authorizationModels[store] = entries
+	}
+
+	for id, raw := range snap.Assertions {
+		assertions, err := unmarshalMessages[openfgav1.Assertion](raw)
+		if err != nil {
+			return err
+		}
+		s.assertions[id] = assertions
+	}
+
+	for store, settings := range snap.StoreSettings {
+		s.storeSettings[store] = &storage.StoreSettings{
+			DefaultAuthorizationModelID:   settings.DefaultAuthorizationModelID,
+			DefaultConsistency:            settings.DefaultConsistency,
+			CheckQueryCacheTTL:            settings.CheckQueryCacheTTL,
+			CandidateAuthorizationModelID: settings.CandidateAuthorizationModelID,
+		}
+	}
+
+	for store, labels := range snap.StoreLabels {
+		s.storeLabels[store] = labels
+	}
+
+	for store, statistics := range snap.PlannerStatistics {
+		s.plannerStatistics[store] = statistics
+	}
+
+	for store, relations := range snap.RelationUsage {
+		s.relationUsage[store] = make(map[string]storage.RelationUsage, len(relations))
+		for _, usage := range relations {
+			s.relationUsage[store][tupleUtils.ToObjectRelationString(usage.ObjectType, usage.Relation)] = storage.RelationUsage{
+				ObjectType:      usage.ObjectType,
+				Relation:        usage.Relation,
+				Allowed:         usage.Allowed,
+				Denied:          usage.Denied,
+				LastEvaluatedAt: usage.LastEvaluatedAt,
+			}
+		}
+	}
+
+	for store, ids := range snap.ChangeULIDs {
+		s.changeULIDs[store] = make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			s.changeULIDs[store][id] = struct{}{}
+		}
+	}
+
+	for store, writers := range snap.ChangeWriters {
+		s.changeWriters[store] = writers
+	}
+
+	for store, checkpoints := range snap.ChangelogCheckpoints {
+		s.changelogCheckpoints[store] = checkpoints
+	}
+
+	return nil
+}
+
+func marshalMessages[M proto.Message](messages []M) ([]json.RawMessage, error) {
+	data := make([]json.RawMessage, 0, len(messages))
+	for _, m := range messages {
+		b, err := protojson.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b)
+	}
+
+	return data, nil
+}
+
+func unmarshalMessages[T any, M interface {
+	*T
+	proto.Message
+}](data []json.RawMessage) ([]M, error) {
+	messages := make([]M, 0, len(data))
+	for _, b := range data {
+		m := M(new(T))
+		if err := protojson.Unmarshal(b, m); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/testutils"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "openfga.snapshot")
+
+	ds, err := NewFromSnapshot(path)
+	require.NoError(t, err)
+
+	storeID := ulid.Make().String()
+	_, err = ds.CreateStore(ctx, &openfgav1.Store{Id: storeID, Name: "snapshot"})
+	require.NoError(t, err)
+
+	model := testutils.MustTransformDSLToProtoWithID(`model
+	schema 1.1
+type user
+
+type document
+  relations
+	define viewer: [user, user with x_less_than]
+
+condition x_less_than(x: int) {
+	x < 100
+}`)
+	require.NoError(t, ds.WriteAuthorizationModel(ctx, storeID, model))
+
+	conditionContext, err := structpb.NewStruct(map[string]interface{}{"x": 10})
+	require.NoError(t, err)
+
+	tk := tuple.NewTupleKeyWithCondition("document:1", "viewer", "user:jon", "x_less_than", conditionContext)
+	require.NoError(t, ds.Write(storage.ContextWithWriter(ctx, "anne"), storeID, nil, []*openfgav1.TupleKey{tk}))
+
+	assertions := []*openfgav1.Assertion{
+		{TupleKey: tuple.NewAssertionTupleKey("document:1", "viewer", "user:jon"), Expectation: true},
+	}
+	require.NoError(t, ds.WriteAssertions(ctx, storeID, model.GetId(), assertions))
+
+	settings := &storage.StoreSettings{DefaultAuthorizationModelID: model.GetId(), CheckQueryCacheTTL: time.Minute}
+	require.NoError(t, ds.WriteStoreSettings(ctx, storeID, settings))
+
+	labels := map[string]string{"env": "prod"}
+	require.NoError(t, ds.WriteStoreLabels(ctx, storeID, labels))
+
+	// there is no snapshot until the datastore is closed, as periodic snapshots are disabled
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	ds.Close()
+
+	restored, err := NewFromSnapshot(path)
+	require.NoError(t, err)
+	t.Cleanup(restored.Close)
+
+	store, err := restored.GetStore(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, "snapshot", store.GetName())
+
+	latest, err := restored.FindLatestAuthorizationModel(ctx, storeID)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(model, latest))
+
+	got, err := restored.ReadUserTuple(ctx, storeID, tk)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(tk, got.GetKey()))
+
+	changes, _, err := restored.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: 10}, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+
+	tupleWriters, err := restored.ReadTupleWriters(ctx, storeID, []*openfgav1.TupleKey{tk})
+	require.NoError(t, err)
+	require.Equal(t, []string{"anne"}, tupleWriters)
+
+	changeWriters, err := restored.ReadChangeWriters(ctx, storeID, changes)
+	require.NoError(t, err)
+	require.Equal(t, []string{"anne"}, changeWriters)
+
+	gotAssertions, err := restored.ReadAssertions(ctx, storeID, model.GetId())
+	require.NoError(t, err)
+	require.Len(t, gotAssertions, 1)
+	require.True(t, proto.Equal(assertions[0], gotAssertions[0]))
+
+	gotSettings, err := restored.ReadStoreSettings(ctx, storeID)
+	require.NoError(t, err)
+	require.Equal(t, settings, gotSettings)
+
+	gotLabels, err := restored.ReadStoreLabels(ctx, []string{storeID})
+	require.NoError(t, err)
+	require.Equal(t, labels, gotLabels[storeID])
+}
+
+func TestPeriodicSnapshots(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	path := filepath.Join(t.TempDir(), "openfga.snapshot")
+
+	ds := New(WithSnapshotPath(path), WithSnapshotInterval(10*time.Millisecond))
+	t.Cleanup(ds.Close)
+
+	_, err := ds.CreateStore(context.Background(), &openfgav1.Store{Id: ulid.Make().String(), Name: "snapshot"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNewFromSnapshotWithInvalidSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openfga.snapshot")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2}`), 0o600))
+
+	_, err := NewFromSnapshot(path)
+	require.ErrorContains(t, err, "unsupported snapshot version 2")
+}
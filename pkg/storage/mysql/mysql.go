@@ -21,6 +21,8 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/openfga/openfga/assets"
+	"github.com/openfga/openfga/pkg/encrypter"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/sqlcommon"
@@ -38,14 +40,20 @@ type MySQL struct {
 	dbStatsCollector       prometheus.Collector
 	maxTuplesPerWriteField int
 	maxTypesPerModelField  int
+
+	conditionContextEncrypter encrypter.Encrypter
+	iteratorBatchSize         int
 }
 
 // Ensures that MySQL implements the OpenFGADatastore interface.
-var _ storage.OpenFGADatastore = (*MySQL)(nil)
+var (
+	_ storage.OpenFGADatastore     = (*MySQL)(nil)
+	_ storage.ConnectionPoolWarmer = (*MySQL)(nil)
+)
 
 // New creates a new [MySQL] storage.
 func New(uri string, cfg *sqlcommon.Config) (*MySQL, error) {
-	if cfg.Username != "" || cfg.Password != "" {
+	if cfg.Username != "" || cfg.Password != "" || cfg.MySQLInterpolateParams {
 		dsnCfg, err := mysql.ParseDSN(uri)
 		if err != nil {
 			return nil, fmt.Errorf("parse mysql connection dsn: %w", err)
@@ -57,6 +65,9 @@ func New(uri string, cfg *sqlcommon.Config) (*MySQL, error) {
 		if cfg.Password != "" {
 			dsnCfg.Passwd = cfg.Password
 		}
+		if cfg.MySQLInterpolateParams {
+			dsnCfg.InterpolateParams = true
+		}
 
 		uri = dsnCfg.FormatDSN()
 	}
@@ -106,8 +117,12 @@ func New(uri string, cfg *sqlcommon.Config) (*MySQL, error) {
 		}
 	}
 
-	stbl := sq.StatementBuilder.RunWith(db)
-	dbInfo := sqlcommon.NewDBInfo(db, stbl, sq.Expr("NOW()"))
+	stbl := sq.StatementBuilder.RunWith(sqlcommon.NewQueryRunner(db, cfg))
+	dbInfo := sqlcommon.NewDBInfo(
+		db, stbl, sq.Expr("NOW()"),
+		sqlcommon.WithDBInfoQueryComments(cfg),
+		sqlcommon.WithDBInfoConditionContextEncrypter(cfg.ConditionContextEncrypter),
+	)
 
 	return &MySQL{
 		stbl:                   stbl,
@@ -117,6 +132,9 @@ func New(uri string, cfg *sqlcommon.Config) (*MySQL, error) {
 		dbStatsCollector:       collector,
 		maxTuplesPerWriteField: cfg.MaxTuplesPerWriteField,
 		maxTypesPerModelField:  cfg.MaxTypesPerModelField,
+
+		conditionContextEncrypter: cfg.ConditionContextEncrypter,
+		iteratorBatchSize:         cfg.IteratorBatchSize,
 	}, nil
 }
 
@@ -126,6 +144,10 @@ func (m *MySQL) Close() {
 		prometheus.Unregister(m.dbStatsCollector)
 	}
 	m.db.Close()
+
+	if closer, ok := m.conditionContextEncrypter.(interface{ Close() }); ok {
+		closer.Close()
+	}
 }
 
 // Read see [storage.RelationshipTupleReader].Read.
@@ -182,6 +204,9 @@ func (m *MySQL) read(ctx context.Context, store string, tupleKey *openfgav1.Tupl
 	if tupleKey.GetUser() != "" {
 		sb = sb.Where(sq.Eq{"_user": tupleKey.GetUser()})
 	}
+	if tupleKey.GetCondition().GetName() != "" {
+		sb = sb.Where(sq.Eq{"condition_name": tupleKey.GetCondition().GetName()})
+	}
 	if opts != nil && opts.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(opts.From)
 		if err != nil {
@@ -198,7 +223,15 @@ func (m *MySQL) read(ctx context.Context, store string, tupleKey *openfgav1.Tupl
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(rows, m.tupleIteratorOptions()...), nil
+}
+
+// tupleIteratorOptions returns the options of the tuple iterators of the datastore.
+func (m *MySQL) tupleIteratorOptions() []sqlcommon.SQLTupleIteratorOption {
+	return []sqlcommon.SQLTupleIteratorOption{
+		sqlcommon.WithSQLTupleIteratorEncrypter(m.conditionContextEncrypter),
+		sqlcommon.WithSQLTupleIteratorBatchSize(m.iteratorBatchSize),
+	}
 }
 
 // Write see [storage.RelationshipTupleWriter].Write.
@@ -215,6 +248,23 @@ func (m *MySQL) Write(ctx context.Context, store string, deletes storage.Deletes
 	return sqlcommon.Write(ctx, m.dbInfo, store, deletes, writes, now)
 }
 
+// WriteStores see [storage.MultiStoreWriteBackend].WriteStores.
+func (m *MySQL) WriteStores(ctx context.Context, writes []storage.StoreWrite) error {
+	ctx, span := tracer.Start(ctx, "mysql.WriteStores")
+	defer span.End()
+
+	tuples := 0
+	for _, w := range writes {
+		tuples += len(w.Deletes) + len(w.Writes)
+	}
+	if tuples > m.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := time.Now().UTC()
+	return sqlcommon.WriteStores(ctx, m.dbInfo, writes, now)
+}
+
 // ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
 func (m *MySQL) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
 	ctx, span := tracer.Start(ctx, "mysql.ReadUserTuple")
@@ -256,12 +306,9 @@ func (m *MySQL) ReadUserTuple(ctx context.Context, store string, tupleKey *openf
 	if conditionName.String != "" {
 		record.ConditionName = conditionName.String
 
-		if conditionContext != nil {
-			var conditionContextStruct structpb.Struct
-			if err := proto.Unmarshal(conditionContext, &conditionContextStruct); err != nil {
-				return nil, err
-			}
-			record.ConditionContext = &conditionContextStruct
+		record.ConditionContext, err = sqlcommon.UnmarshalConditionContext(conditionContext, m.conditionContextEncrypter)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -308,12 +355,16 @@ func (m *MySQL) ReadUsersetTuples(
 		}
 		sb = sb.Where(orConditions)
 	}
+	if filter.PageSize > 0 {
+		return sqlcommon.NewPagedSQLTupleIterator(ctx, sb, filter.PageSize, m.tupleIteratorOptions()...)
+	}
+
 	rows, err := sb.QueryContext(ctx)
 	if err != nil {
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(rows, m.tupleIteratorOptions()...), nil
 }
 
 // ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
@@ -350,7 +401,7 @@ func (m *MySQL) ReadStartingWithUser(
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(rows, m.tupleIteratorOptions()...), nil
 }
 
 // MaxTuplesPerWrite see [storage.RelationshipTupleWriter].MaxTuplesPerWrite.
@@ -483,7 +534,7 @@ func (m *MySQL) CreateStore(ctx context.Context, store *openfgav1.Store) (*openf
 		Insert("store").
 		Columns("id", "name", "created_at", "updated_at").
 		Values(store.GetId(), store.GetName(), sq.Expr("NOW()"), sq.Expr("NOW()")).
-		RunWith(txn).
+		RunWith(m.dbInfo.TxnRunner(txn)).
 		ExecContext(ctx)
 	if err != nil {
 		return nil, sqlcommon.HandleSQLError(err)
@@ -495,7 +546,7 @@ func (m *MySQL) CreateStore(ctx context.Context, store *openfgav1.Store) (*openf
 		Select("id", "name", "created_at").
 		From("store").
 		Where(sq.Eq{"id": store.GetId()}).
-		RunWith(txn).
+		RunWith(m.dbInfo.TxnRunner(txn)).
 		QueryRowContext(ctx).
 		Scan(&id, &name, &createdAt)
 	if err != nil {
@@ -547,8 +598,12 @@ func (m *MySQL) GetStore(ctx context.Context, id string) (*openfgav1.Store, erro
 	}, nil
 }
 
-// ListStores provides a paginated list of all stores present in the MySQL storage.
-func (m *MySQL) ListStores(ctx context.Context, opts storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
+// ListStores provides a paginated list of the stores present in the MySQL storage that match the filter.
+func (m *MySQL) ListStores(
+	ctx context.Context,
+	filter storage.ListStoresFilter,
+	opts storage.PaginationOptions,
+) ([]*openfgav1.Store, []byte, error) {
 	ctx, span := tracer.Start(ctx, "mysql.ListStores")
 	defer span.End()
 
@@ -558,6 +613,8 @@ func (m *MySQL) ListStores(ctx context.Context, opts storage.PaginationOptions)
 		Where(sq.Eq{"deleted_at": nil}).
 		OrderBy("id")
 
+	sb = sqlcommon.WhereListStoresFilter(sb, filter)
+
 	if opts.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(opts.From)
 		if err != nil {
@@ -625,6 +682,46 @@ func (m *MySQL) DeleteStore(ctx context.Context, id string) error {
 	return nil
 }
 
+// UpdateStore see [sqlcommon.UpdateStore].
+func (m *MySQL) UpdateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	ctx, span := tracer.Start(ctx, "mysql.UpdateStore")
+	defer span.End()
+
+	return sqlcommon.UpdateStore(ctx, m.dbInfo, store)
+}
+
+// WriteStoreLabels see [sqlcommon.WriteStoreLabels].
+func (m *MySQL) WriteStoreLabels(ctx context.Context, store string, labels map[string]string) error {
+	ctx, span := tracer.Start(ctx, "mysql.WriteStoreLabels")
+	defer span.End()
+
+	return sqlcommon.WriteStoreLabels(ctx, m.dbInfo, store, labels)
+}
+
+// ReadStoreLabels see [sqlcommon.ReadStoreLabels].
+func (m *MySQL) ReadStoreLabels(ctx context.Context, stores []string) (map[string]map[string]string, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadStoreLabels")
+	defer span.End()
+
+	return sqlcommon.ReadStoreLabels(ctx, m.dbInfo, stores)
+}
+
+// ReadTupleWriters see [sqlcommon.ReadTupleWriters].
+func (m *MySQL) ReadTupleWriters(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadTupleWriters")
+	defer span.End()
+
+	return sqlcommon.ReadTupleWriters(ctx, m.dbInfo, store, tupleKeys)
+}
+
+// ReadChangeWriters see [sqlcommon.ReadChangeWriters].
+func (m *MySQL) ReadChangeWriters(ctx context.Context, store string, changes []*openfgav1.TupleChange) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadChangeWriters")
+	defer span.End()
+
+	return sqlcommon.ReadChangeWriters(ctx, m.dbInfo, store, changes)
+}
+
 // WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
 func (m *MySQL) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
 	ctx, span := tracer.Start(ctx, "mysql.WriteAssertions")
@@ -679,10 +776,126 @@ func (m *MySQL) ReadAssertions(ctx context.Context, store, modelID string) ([]*o
 	return assertions.GetAssertions(), nil
 }
 
+// WriteStoreSettings see [storage.StoreSettingsBackend].WriteStoreSettings.
+func (m *MySQL) WriteStoreSettings(ctx context.Context, store string, settings *storage.StoreSettings) error {
+	ctx, span := tracer.Start(ctx, "mysql.WriteStoreSettings")
+	defer span.End()
+
+	checkQueryCacheTTLMs := settings.CheckQueryCacheTTL.Milliseconds()
+
+	_, err := m.stbl.
+		Insert("store_settings").
+		Columns("store", "default_authorization_model_id", "default_consistency", "check_query_cache_ttl_ms", "candidate_authorization_model_id", "updated_at").
+		Values(store, settings.DefaultAuthorizationModelID, settings.DefaultConsistency, checkQueryCacheTTLMs, settings.CandidateAuthorizationModelID, sq.Expr("NOW()")).
+		Suffix("ON DUPLICATE KEY UPDATE default_authorization_model_id = ?, default_consistency = ?, check_query_cache_ttl_ms = ?, candidate_authorization_model_id = ?, updated_at = NOW()",
+			settings.DefaultAuthorizationModelID, settings.DefaultConsistency, checkQueryCacheTTLMs, settings.CandidateAuthorizationModelID).
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadStoreSettings see [sqlcommon.ReadStoreSettings].
+func (m *MySQL) ReadStoreSettings(ctx context.Context, store string) (*storage.StoreSettings, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadStoreSettings")
+	defer span.End()
+
+	return sqlcommon.ReadStoreSettings(ctx, m.dbInfo, store)
+}
+
+// WritePlannerStatistics see [storage.PlannerStatisticsBackend].WritePlannerStatistics.
+func (m *MySQL) WritePlannerStatistics(ctx context.Context, store string, statistics []byte) error {
+	ctx, span := tracer.Start(ctx, "mysql.WritePlannerStatistics")
+	defer span.End()
+
+	_, err := m.stbl.
+		Insert("planner_statistics").
+		Columns("store", "statistics", "updated_at").
+		Values(store, statistics, sq.Expr("NOW()")).
+		Suffix("ON DUPLICATE KEY UPDATE statistics = ?, updated_at = NOW()", statistics).
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadPlannerStatistics see [sqlcommon.ReadPlannerStatistics].
+func (m *MySQL) ReadPlannerStatistics(ctx context.Context, store string) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadPlannerStatistics")
+	defer span.End()
+
+	return sqlcommon.ReadPlannerStatistics(ctx, m.dbInfo, store)
+}
+
+// AddRelationUsage see [storage.RelationUsageBackend].AddRelationUsage.
+func (m *MySQL) AddRelationUsage(ctx context.Context, store string, usage []storage.RelationUsage) error {
+	ctx, span := tracer.Start(ctx, "mysql.AddRelationUsage")
+	defer span.End()
+
+	if len(usage) == 0 {
+		return nil
+	}
+
+	insert := m.stbl.
+		Insert("relation_usage").
+		Columns("store", "object_type", "relation", "allowed_count", "denied_count", "last_evaluated_at")
+	for _, relation := range usage {
+		insert = insert.Values(store, relation.ObjectType, relation.Relation, relation.Allowed, relation.Denied, relation.LastEvaluatedAt.UTC())
+	}
+
+	_, err := insert.
+		Suffix("ON DUPLICATE KEY UPDATE allowed_count = allowed_count + VALUES(allowed_count), denied_count = denied_count + VALUES(denied_count), last_evaluated_at = GREATEST(last_evaluated_at, VALUES(last_evaluated_at))").
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadRelationUsage see [sqlcommon.ReadRelationUsage].
+func (m *MySQL) ReadRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadRelationUsage")
+	defer span.End()
+
+	return sqlcommon.ReadRelationUsage(ctx, m.dbInfo, store)
+}
+
+// WriteChangelogCheckpoint see [storage.ChangelogCheckpointBackend].WriteChangelogCheckpoint.
+func (m *MySQL) WriteChangelogCheckpoint(ctx context.Context, store, consumer, continuationToken string) error {
+	ctx, span := tracer.Start(ctx, "mysql.WriteChangelogCheckpoint")
+	defer span.End()
+
+	_, err := m.stbl.
+		Insert("changelog_checkpoint").
+		Columns("store", "consumer", "continuation_token", "updated_at").
+		Values(store, consumer, continuationToken, sq.Expr("NOW()")).
+		Suffix("ON DUPLICATE KEY UPDATE continuation_token = ?, updated_at = NOW()", continuationToken).
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadChangelogCheckpoint see [sqlcommon.ReadChangelogCheckpoint].
+func (m *MySQL) ReadChangelogCheckpoint(ctx context.Context, store, consumer string) (string, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ReadChangelogCheckpoint")
+	defer span.End()
+
+	return sqlcommon.ReadChangelogCheckpoint(ctx, m.dbInfo, store, consumer)
+}
+
 // ReadChanges see [storage.ChangelogBackend].ReadChanges.
 func (m *MySQL) ReadChanges(
 	ctx context.Context,
-	store, objectTypeFilter string,
+	store string,
+	filter storage.ReadChangesFilter,
 	opts storage.PaginationOptions,
 	horizonOffset time.Duration,
 ) ([]*openfgav1.TupleChange, []byte, error) {
@@ -699,15 +912,13 @@ func (m *MySQL) ReadChanges(
 		Where(fmt.Sprintf("inserted_at <= NOW() - INTERVAL %d MICROSECOND", horizonOffset.Microseconds())).
 		OrderBy("ulid asc")
 
-	if objectTypeFilter != "" {
-		sb = sb.Where(sq.Eq{"object_type": objectTypeFilter})
-	}
+	sb = sqlcommon.WhereReadChangesFilter(sb, filter)
 	if opts.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(opts.From)
 		if err != nil {
 			return nil, nil, err
 		}
-		if token.ObjectType != objectTypeFilter {
+		if token.ObjectType != filter.ObjectType {
 			return nil, nil, storage.ErrMismatchObjectType
 		}
 
@@ -747,12 +958,11 @@ func (m *MySQL) ReadChanges(
 			return nil, nil, sqlcommon.HandleSQLError(err)
 		}
 
-		var conditionContextStruct structpb.Struct
+		var conditionContextStruct *structpb.Struct
 		if conditionName.String != "" {
-			if conditionContext != nil {
-				if err := proto.Unmarshal(conditionContext, &conditionContextStruct); err != nil {
-					return nil, nil, err
-				}
+			conditionContextStruct, err = sqlcommon.UnmarshalConditionContext(conditionContext, m.conditionContextEncrypter)
+			if err != nil {
+				return nil, nil, err
 			}
 		}
 
@@ -761,7 +971,7 @@ func (m *MySQL) ReadChanges(
 			relation,
 			user,
 			conditionName.String,
-			&conditionContextStruct,
+			conditionContextStruct,
 		)
 
 		changes = append(changes, &openfgav1.TupleChange{
@@ -775,7 +985,7 @@ func (m *MySQL) ReadChanges(
 		return nil, nil, storage.ErrNotFound
 	}
 
-	contToken, err := json.Marshal(sqlcommon.NewContToken(ulid, objectTypeFilter))
+	contToken, err := json.Marshal(sqlcommon.NewContToken(ulid, filter.ObjectType))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -783,7 +993,20 @@ func (m *MySQL) ReadChanges(
 	return changes, contToken, nil
 }
 
+// ChangeExists see [sqlcommon.ChangeExists].
+func (m *MySQL) ChangeExists(ctx context.Context, store, id string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "mysql.ChangeExists")
+	defer span.End()
+
+	return sqlcommon.ChangeExists(ctx, m.dbInfo, store, id)
+}
+
+// WarmConnectionPool see [sqlcommon.WarmConnectionPool].
+func (m *MySQL) WarmConnectionPool(ctx context.Context, conns int) error {
+	return sqlcommon.WarmConnectionPool(ctx, m.db, conns)
+}
+
 // IsReady see [sqlcommon.IsReady].
 func (m *MySQL) IsReady(ctx context.Context) (storage.ReadinessStatus, error) {
-	return sqlcommon.IsReady(ctx, m.db)
+	return sqlcommon.IsReady(ctx, m.db, assets.MySQLMigrationDir)
 }
@@ -2,6 +2,8 @@ package mysql
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -89,6 +91,52 @@ func TestReadEnsureNoOrder(t *testing.T) {
 	require.Equal(t, secondTuple, curTuple.GetKey())
 }
 
+// TestReadWithIteratorBatchSize asserts that the tuples read ahead of the consumer of an iterator are
+// all returned, in batches smaller than the tuples read.
+func TestReadWithIteratorBatchSize(t *testing.T) {
+	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "mysql")
+
+	uri := testDatastore.GetConnectionURI(true)
+	ds, err := New(uri, sqlcommon.NewConfig(sqlcommon.WithIteratorBatchSize(3)))
+	require.NoError(t, err)
+	defer ds.Close()
+
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	var tks []*openfgav1.TupleKey
+	for i := 0; i < 10; i++ {
+		tks = append(tks, tuple.NewTupleKey("doc:1", "viewer", fmt.Sprintf("user:%d", i)))
+	}
+	err = ds.Write(ctx, store, nil, tks)
+	require.NoError(t, err)
+
+	iter, err := ds.Read(ctx, store, tuple.NewTupleKey("doc:1", "viewer", ""))
+	require.NoError(t, err)
+	defer iter.Stop()
+
+	var read []*openfgav1.TupleKey
+	for {
+		tp, err := iter.Next(ctx)
+		if errors.Is(err, storage.ErrIteratorDone) {
+			break
+		}
+		require.NoError(t, err)
+		read = append(read, tp.GetKey())
+	}
+	require.ElementsMatch(t, tks, read)
+
+	_, err = iter.Next(ctx)
+	require.ErrorIs(t, err, storage.ErrIteratorDone)
+
+	// an iterator stopped before its end stops its reads
+	iter, err = ds.Read(ctx, store, tuple.NewTupleKey("doc:1", "viewer", ""))
+	require.NoError(t, err)
+	_, err = iter.Next(ctx)
+	require.NoError(t, err)
+	iter.Stop()
+}
+
 // TestReadPageEnsureNoOrder asserts that the read page is ordered by ulid.
 func TestReadPageEnsureOrder(t *testing.T) {
 	testDatastore := storagefixtures.RunDatastoreTestContainer(t, "mysql")
@@ -248,7 +296,7 @@ func TestAllowNullCondition(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	changes, _, err := ds.ReadChanges(ctx, "store", "folder", storage.PaginationOptions{}, 0)
+	changes, _, err := ds.ReadChanges(ctx, "store", storage.ReadChangesFilter{ObjectType: "folder"}, storage.PaginationOptions{}, 0)
 	require.NoError(t, err)
 	require.Len(t, changes, 2)
 	require.Equal(t, tk, changes[0].GetTupleKey())
@@ -0,0 +1,357 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TuplePartitioning is how the tuple table is partitioned with the native table partitioning of
+// Postgres, to keep the sizes of its indexes manageable with billions of tuples.
+type TuplePartitioning string
+
+const (
+	// TuplePartitioningNone keeps the tuple table unpartitioned.
+	TuplePartitioningNone TuplePartitioning = "none"
+
+	// TuplePartitioningStore partitions the tuple table by the hash of the store, so that every
+	// query of a store is pruned to a single partition.
+	TuplePartitioningStore TuplePartitioning = "store"
+
+	// TuplePartitioningObjectType partitions the tuple table by the hash of the object type, which
+	// spreads the tuples of a large store over the partitions. The queries that filter by object
+	// type are pruned to a single partition, and the others, e.g. a Read without an object, scan
+	// every partition.
+	TuplePartitioningObjectType TuplePartitioning = "object_type"
+)
+
+// tuplePartitionKeys are the partition keys of the tuple table by partitioning, as returned by
+// pg_get_partkeydef.
+var tuplePartitionKeys = map[TuplePartitioning]string{
+	TuplePartitioningStore:      "HASH (store)",
+	TuplePartitioningObjectType: "HASH (object_type)",
+}
+
+// ParseTuplePartitioning parses a partitioning of the tuple table.
+func ParseTuplePartitioning(s string) (TuplePartitioning, error) {
+	switch p := TuplePartitioning(s); p {
+	case TuplePartitioningNone, TuplePartitioningStore, TuplePartitioningObjectType:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown tuple partitioning '%s', it must be one of 'none', 'store' or 'object_type'", s)
+	}
+}
+
+const (
+	// repartitionedTuples is the table the tuples are copied into, which replaces the tuple table once
+	// they are all copied.
+	repartitionedTuples = "tuple_repartitioned"
+
+	// repartitionedSuffix is the suffix of the names of the indexes and constraints of the
+	// repartitionedTuples table until it replaces the tuple table, since they must be unique in
+	// the schema.
+	repartitionedSuffix = "_repartitioned"
+
+	// tupleULIDIndex is the index of the ULIDs of the tuples, which depends on the partitioning.
+	tupleULIDIndex = "idx_tuple_ulid"
+
+	// tupleKeyColumns are the columns of the primary key of the tuple table, which the tuples are
+	// copied in the order of.
+	tupleKeyColumns = "store, object_type, object_id, relation, _user"
+
+	// tupleCopyBatchSize is the number of tuples copied per transaction.
+	tupleCopyBatchSize = 10000
+)
+
+// tupleULIDIndexes are the indexes of the ULIDs of the tuples, which order the pages of Read, by
+// partitioning. The unique indexes of a partitioned table must include its partition key, so the
+// index of the tables partitioned by object type isn't unique: it is ordered by ULID in every
+// partition, so that the partitions of a page of a store are merged instead of sorted.
+var tupleULIDIndexes = map[TuplePartitioning]string{
+	TuplePartitioningNone:       "CREATE UNIQUE INDEX %s ON %s (ulid)",
+	TuplePartitioningStore:      "CREATE UNIQUE INDEX %s ON %s (store, ulid)",
+	TuplePartitioningObjectType: "CREATE INDEX %s ON %s (store, ulid)",
+}
+
+// indexDefinition matches the definitions of the indexes of pg_indexes, e.g.
+// 'CREATE INDEX idx_user_lookup ON ONLY public.tuple USING btree (store, _user, object_type, relation)'.
+var indexDefinition = regexp.MustCompile(`^CREATE (UNIQUE )?INDEX .+? ON (?:ONLY )?\S+ (USING .*)$`)
+
+// ReadTuplePartitioning returns the partitioning of the tuple table, and its number of partitions.
+func ReadTuplePartitioning(ctx context.Context, db *sql.DB) (TuplePartitioning, int, error) {
+	var partitionKey sql.NullString
+	if err := db.QueryRowContext(ctx, "SELECT pg_get_partkeydef('tuple'::regclass)").Scan(&partitionKey); err != nil {
+		return "", 0, fmt.Errorf("read the partitioning of the tuple table: %w", err)
+	}
+	if !partitionKey.Valid {
+		return TuplePartitioningNone, 0, nil
+	}
+
+	for partitioning, key := range tuplePartitionKeys {
+		if strings.EqualFold(partitionKey.String, key) {
+			var partitions int
+			err := db.QueryRowContext(ctx, "SELECT count(*) FROM pg_inherits WHERE inhparent = 'tuple'::regclass").Scan(&partitions)
+			if err != nil {
+				return "", 0, fmt.Errorf("read the partitions of the tuple table: %w", err)
+			}
+			return partitioning, partitions, nil
+		}
+	}
+
+	return "", 0, fmt.Errorf("the tuple table is partitioned by the unknown key '%s'", partitionKey.String)
+}
+
+// PartitionTupleTable partitions the tuple table by the hash of the key of the partitioning into the
+// given number of partitions, or makes it unpartitioned again with TuplePartitioningNone. It does
+// nothing if the table is already partitioned this way.
+//
+// The tuples are copied online: a new table is created with the primary key, the indexes, the owner
+// and the grants of the tuple table, read from the catalog, and the tuples are copied into it in
+// batches of short transactions while a trigger copies the tuples written and deleted in the
+// meantime. The new table then replaces the tuple table in a transaction that only locks the tuple
+// table while they are renamed.
+func PartitionTupleTable(ctx context.Context, db *sql.DB, partitioning TuplePartitioning, partitions int) error {
+	if partitioning != TuplePartitioningNone && partitions < 1 {
+		return errors.New("the tuple table must have at least one partition")
+	}
+
+	current, currentPartitions, err := ReadTuplePartitioning(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current == partitioning && (partitioning == TuplePartitioningNone || currentPartitions == partitions) {
+		return nil
+	}
+
+	// the table of a repartitioning that failed before
+	if err := dropRepartitionedTuples(ctx, db); err != nil {
+		return err
+	}
+
+	renames, err := createRepartitionedTuples(ctx, db, partitioning, partitions)
+	if err != nil {
+		_ = dropRepartitionedTuples(context.Background(), db)
+		return err
+	}
+
+	if err := copyTuples(ctx, db); err != nil {
+		_ = dropRepartitionedTuples(context.Background(), db)
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	// the partitions and the trigger of the current table are dropped with it
+	stmts := []string{
+		"LOCK TABLE tuple IN ACCESS EXCLUSIVE MODE",
+		"DROP TABLE tuple",
+		"DROP FUNCTION " + repartitionedTuples + "_copy()",
+		"ALTER TABLE " + repartitionedTuples + " RENAME TO tuple",
+	}
+	stmts = append(stmts, renames...)
+
+	for _, stmt := range stmts {
+		if _, err := txn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("replace the tuple table: %w", err)
+		}
+	}
+
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+
+	// the statistics of the planner aren't copied with the tuples
+	if _, err := db.ExecContext(ctx, "ANALYZE tuple"); err != nil {
+		return fmt.Errorf("analyze the tuple table: %w", err)
+	}
+
+	return nil
+}
+
+// createRepartitionedTuples creates the repartitionedTuples table with the partitioning, and the
+// trigger that copies the tuples written to the tuple table into it. It returns the statements that
+// rename its partitions, indexes and constraints once it replaces the tuple table.
+func createRepartitionedTuples(ctx context.Context, db *sql.DB, partitioning TuplePartitioning, partitions int) ([]string, error) {
+	var stmts, renames []string
+	if partitioning == TuplePartitioningNone {
+		stmts = append(stmts, fmt.Sprintf("CREATE TABLE %s (LIKE tuple INCLUDING DEFAULTS)", repartitionedTuples))
+	} else {
+		stmts = append(stmts, fmt.Sprintf("CREATE TABLE %s (LIKE tuple INCLUDING DEFAULTS) PARTITION BY %s", repartitionedTuples, tuplePartitionKeys[partitioning]))
+		for i := 0; i < partitions; i++ {
+			stmts = append(stmts, fmt.Sprintf("CREATE TABLE %s_p%d PARTITION OF %s FOR VALUES WITH (MODULUS %d, REMAINDER %d)", repartitionedTuples, i, repartitionedTuples, partitions, i))
+			renames = append(renames, fmt.Sprintf("ALTER TABLE %s_p%d RENAME TO tuple_p%d", repartitionedTuples, i, i))
+		}
+	}
+
+	table := pgx.Identifier{repartitionedTuples}.Sanitize()
+
+	constraints, err := queryRows(ctx, db, `SELECT conname, pg_get_constraintdef(oid) FROM pg_constraint
+		WHERE conrelid = 'tuple'::regclass AND contype IN ('p', 'u') ORDER BY conname`)
+	if err != nil {
+		return nil, fmt.Errorf("read the constraints of the tuple table: %w", err)
+	}
+	for _, constraint := range constraints {
+		name, definition := constraint[0], constraint[1]
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", table, pgx.Identifier{name + repartitionedSuffix}.Sanitize(), definition))
+		renames = append(renames, fmt.Sprintf("ALTER TABLE tuple RENAME CONSTRAINT %s TO %s", pgx.Identifier{name + repartitionedSuffix}.Sanitize(), pgx.Identifier{name}.Sanitize()))
+	}
+
+	// the indexes of the constraints are created with them
+	indexes, err := queryRows(ctx, db, `SELECT indexname, indexdef FROM pg_indexes
+		WHERE schemaname = current_schema() AND tablename = 'tuple' AND indexname <> $1
+		AND NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conindid = format('%I.%I', schemaname, indexname)::regclass)
+		ORDER BY indexname`, tupleULIDIndex)
+	if err != nil {
+		return nil, fmt.Errorf("read the indexes of the tuple table: %w", err)
+	}
+	for _, index := range indexes {
+		name, definition := index[0], index[1]
+		match := indexDefinition.FindStringSubmatch(definition)
+		if match == nil {
+			return nil, fmt.Errorf("unexpected definition of the index '%s' of the tuple table: %s", name, definition)
+		}
+		stmts = append(stmts, fmt.Sprintf("CREATE %sINDEX %s ON %s %s", match[1], pgx.Identifier{name + repartitionedSuffix}.Sanitize(), table, match[2]))
+		renames = append(renames, fmt.Sprintf("ALTER INDEX %s RENAME TO %s", pgx.Identifier{name + repartitionedSuffix}.Sanitize(), pgx.Identifier{name}.Sanitize()))
+	}
+	stmts = append(stmts, fmt.Sprintf(tupleULIDIndexes[partitioning], tupleULIDIndex+repartitionedSuffix, table))
+	renames = append(renames, fmt.Sprintf("ALTER INDEX %s RENAME TO %s", tupleULIDIndex+repartitionedSuffix, tupleULIDIndex))
+
+	// the owner before the grants, which the owner may have granted
+	var owner string
+	if err := db.QueryRowContext(ctx, "SELECT pg_get_userbyid(relowner) FROM pg_class WHERE oid = 'tuple'::regclass").Scan(&owner); err != nil {
+		return nil, fmt.Errorf("read the owner of the tuple table: %w", err)
+	}
+	stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s OWNER TO %s", table, pgx.Identifier{owner}.Sanitize()))
+	for i := 0; i < partitions && partitioning != TuplePartitioningNone; i++ {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s_p%d OWNER TO %s", repartitionedTuples, i, pgx.Identifier{owner}.Sanitize()))
+	}
+
+	grants, err := queryRows(ctx, db, `SELECT CASE WHEN acl.grantee = 0 THEN '' ELSE pg_get_userbyid(acl.grantee) END,
+		acl.privilege_type, CASE WHEN acl.is_grantable THEN 'true' ELSE 'false' END
+		FROM pg_class, aclexplode(relacl) AS acl WHERE pg_class.oid = 'tuple'::regclass`)
+	if err != nil {
+		return nil, fmt.Errorf("read the grants of the tuple table: %w", err)
+	}
+	for _, grant := range grants {
+		grantee := "PUBLIC"
+		if grant[0] != "" {
+			grantee = pgx.Identifier{grant[0]}.Sanitize()
+		}
+		stmt := fmt.Sprintf("GRANT %s ON %s TO %s", grant[1], table, grantee)
+		if grant[2] == "true" {
+			stmt += " WITH GRANT OPTION"
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	// the tuples written while they are copied are copied by the trigger, which takes a lock that waits
+	// for the transactions that are writing tuples, and the tuples deleted are deleted from both tables
+	stmts = append(stmts,
+		fmt.Sprintf(`CREATE FUNCTION %[1]s_copy() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+	IF TG_OP IN ('DELETE', 'UPDATE') THEN
+		DELETE FROM %[1]s WHERE (%[2]s) = (OLD.store, OLD.object_type, OLD.object_id, OLD.relation, OLD._user);
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		INSERT INTO %[1]s SELECT NEW.* ON CONFLICT DO NOTHING;
+	END IF;
+	RETURN NULL;
+END
+$$`, repartitionedTuples, tupleKeyColumns),
+		fmt.Sprintf("CREATE TRIGGER %[1]s_copy AFTER INSERT OR UPDATE OR DELETE ON tuple FOR EACH ROW EXECUTE FUNCTION %[1]s_copy()", repartitionedTuples),
+	)
+
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("create the repartitioned tuple table: %w", err)
+		}
+	}
+
+	return renames, nil
+}
+
+// copyTuples copies the tuples of the tuple table into the repartitionedTuples table in batches, in
+// the order of their primary key. The tuples of a batch are locked until they are copied, so that a
+// tuple deleted meanwhile is deleted from the repartitionedTuples table by the trigger after it is
+// copied.
+func copyTuples(ctx context.Context, db *sql.DB) error {
+	query := `WITH batch AS (
+		SELECT * FROM tuple %s ORDER BY ` + tupleKeyColumns + ` LIMIT ` + strconv.Itoa(tupleCopyBatchSize) + ` FOR SHARE
+	), copied AS (
+		INSERT INTO ` + repartitionedTuples + ` SELECT * FROM batch ON CONFLICT DO NOTHING
+	)
+	SELECT ` + tupleKeyColumns + ` FROM batch ORDER BY ` + tupleKeyColumns + ` DESC LIMIT 1`
+
+	var last []any
+	for {
+		where := ""
+		if last != nil {
+			where = "WHERE (" + tupleKeyColumns + ") > ($1, $2, $3, $4, $5)"
+		}
+
+		var store, objectType, objectID, relation, user string
+		err := db.QueryRowContext(ctx, fmt.Sprintf(query, where), last...).Scan(&store, &objectType, &objectID, &relation, &user)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("copy the tuples: %w", err)
+		}
+
+		last = []any{store, objectType, objectID, relation, user}
+	}
+}
+
+// dropRepartitionedTuples drops the repartitionedTuples table and its trigger.
+func dropRepartitionedTuples(ctx context.Context, db *sql.DB) error {
+	for _, stmt := range []string{
+		"DROP TRIGGER IF EXISTS " + repartitionedTuples + "_copy ON tuple",
+		"DROP FUNCTION IF EXISTS " + repartitionedTuples + "_copy()",
+		"DROP TABLE IF EXISTS " + repartitionedTuples,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("drop the repartitioned tuple table: %w", err)
+		}
+	}
+	return nil
+}
+
+// queryRows returns the rows of a query of string columns.
+func queryRows(ctx context.Context, db *sql.DB, query string, args ...any) ([][]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var values [][]string
+	for rows.Next() {
+		row := make([]string, len(columns))
+		dest := make([]any, len(columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		values = append(values, row)
+	}
+	return values, rows.Err()
+}
@@ -12,7 +12,8 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/cenkalti/backoff/v4"
-	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver.
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
@@ -22,6 +23,8 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/openfga/openfga/assets"
+	"github.com/openfga/openfga/pkg/encrypter"
 	"github.com/openfga/openfga/pkg/logger"
 	"github.com/openfga/openfga/pkg/storage"
 	"github.com/openfga/openfga/pkg/storage/sqlcommon"
@@ -39,10 +42,23 @@ type Postgres struct {
 	dbStatsCollector       prometheus.Collector
 	maxTuplesPerWriteField int
 	maxTypesPerModelField  int
+
+	conditionContextEncrypter encrypter.Encrypter
+	iteratorBatchSize         int
+
+	// connConfig is the configuration of the connection that listens to the tuple changes.
+	connConfig *pgx.ConnConfig
 }
 
-// Ensures that Postgres implements the OpenFGADatastore interface.
-var _ storage.OpenFGADatastore = (*Postgres)(nil)
+// Ensures that Postgres implements the OpenFGADatastore and TupleChangeNotifier interfaces.
+var (
+	_ storage.OpenFGADatastore     = (*Postgres)(nil)
+	_ storage.TupleChangeNotifier  = (*Postgres)(nil)
+	_ storage.ConnectionPoolWarmer = (*Postgres)(nil)
+)
+
+// tupleChangesChannel is the channel that the stores of the writes are notified on.
+const tupleChangesChannel = "openfga_tuple_changes"
 
 // New creates a new [Postgres] storage.
 func New(uri string, cfg *sqlcommon.Config) (*Postgres, error) {
@@ -75,11 +91,13 @@ func New(uri string, cfg *sqlcommon.Config) (*Postgres, error) {
 		uri = parsed.String()
 	}
 
-	db, err := sql.Open("pgx", uri)
+	connConfig, err := parseConnConfig(uri, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("initialize postgres connection: %w", err)
 	}
 
+	db := stdlib.OpenDB(*connConfig)
+
 	if cfg.MaxOpenConns != 0 {
 		db.SetMaxOpenConns(cfg.MaxOpenConns)
 	}
@@ -119,8 +137,20 @@ func New(uri string, cfg *sqlcommon.Config) (*Postgres, error) {
 			return nil, fmt.Errorf("initialize metrics: %w", err)
 		}
 	}
-	stbl := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).RunWith(db)
-	dbInfo := sqlcommon.NewDBInfo(db, stbl, sq.Expr("NOW()"))
+	stbl := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).RunWith(sqlcommon.NewQueryRunner(db, cfg))
+	dbInfoOpts := []sqlcommon.DBInfoOption{
+		sqlcommon.WithDBInfoQueryComments(cfg),
+		sqlcommon.WithDBInfoConditionContextEncrypter(cfg.ConditionContextEncrypter),
+	}
+	if cfg.PostgresNotifyTupleChanges {
+		dbInfoOpts = append(dbInfoOpts, sqlcommon.WithDBInfoBeforeWriteCommit(notifyTupleChange))
+	}
+	dbInfo := sqlcommon.NewDBInfo(db, stbl, sq.Expr("NOW()"), dbInfoOpts...)
+
+	// the tuple table may not exist yet if the migrations haven't run
+	if partitioning, partitions, err := ReadTuplePartitioning(context.Background(), db); err == nil && partitioning != TuplePartitioningNone {
+		cfg.Logger.Info("the tuple table is partitioned", zap.String("partitioning", string(partitioning)), zap.Int("partitions", partitions))
+	}
 
 	return &Postgres{
 		stbl:                   stbl,
@@ -130,15 +160,112 @@ func New(uri string, cfg *sqlcommon.Config) (*Postgres, error) {
 		dbStatsCollector:       collector,
 		maxTuplesPerWriteField: cfg.MaxTuplesPerWriteField,
 		maxTypesPerModelField:  cfg.MaxTypesPerModelField,
+
+		conditionContextEncrypter: cfg.ConditionContextEncrypter,
+		iteratorBatchSize:         cfg.IteratorBatchSize,
+		connConfig:                connConfig,
 	}, nil
 }
 
+// queryExecModes maps the names of the pgx query execution modes to their values.
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// parseConnConfig parses the connection uri and applies the Postgres specific tuning options of cfg.
+func parseConnConfig(uri string, cfg *sqlcommon.Config) (*pgx.ConnConfig, error) {
+	connConfig, err := pgx.ParseConfig(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.PostgresStatementCacheCapacity != 0 {
+		connConfig.StatementCacheCapacity = cfg.PostgresStatementCacheCapacity
+	}
+
+	if cfg.PostgresQueryExecMode != "" {
+		mode, ok := queryExecModes[cfg.PostgresQueryExecMode]
+		if !ok {
+			return nil, fmt.Errorf("invalid query exec mode '%s'", cfg.PostgresQueryExecMode)
+		}
+		connConfig.DefaultQueryExecMode = mode
+	}
+
+	return connConfig, nil
+}
+
+// notifyTupleChange notifies the store of a Write in its transaction, so that the listeners are only
+// notified once it is committed.
+func notifyTupleChange(ctx context.Context, txn *sql.Tx, store string) error {
+	_, err := txn.ExecContext(ctx, "SELECT pg_notify($1, $2)", tupleChangesChannel, store)
+	return err
+}
+
+// WatchTupleChanges see [storage.TupleChangeNotifier].WatchTupleChanges. The writes are only
+// notified by the servers whose datastore is configured with
+// [sqlcommon.WithPostgresNotifyTupleChanges]. The notifications are listened to on a dedicated
+// connection, which is reconnected with an exponential backoff if it fails. It requires a session,
+// so a connection pooler in transaction mode drops the notifications.
+func (p *Postgres) WatchTupleChanges(ctx context.Context, onChange func(store string)) error {
+	policy := backoff.NewExponentialBackOff()
+	policy.MaxElapsedTime = 0
+
+	for {
+		err := p.listenTupleChanges(ctx, onChange, policy.Reset)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		p.logger.Warn("listening to the tuple changes failed, reconnecting", zap.Error(err))
+
+		// the writes notified until the connection is back are missed
+		onChange("")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(policy.NextBackOff()):
+		}
+	}
+}
+
+// listenTupleChanges listens to the tuple changes until its connection fails or ctx is done.
+// connected is called once it listens.
+func (p *Postgres) listenTupleChanges(ctx context.Context, onChange func(store string), connected func()) error {
+	conn, err := pgx.ConnectConfig(ctx, p.connConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+tupleChangesChannel); err != nil {
+		return err
+	}
+	connected()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		onChange(notification.Payload)
+	}
+}
+
 // Close see [storage.OpenFGADatastore].Close.
 func (p *Postgres) Close() {
 	if p.dbStatsCollector != nil {
 		prometheus.Unregister(p.dbStatsCollector)
 	}
 	p.db.Close()
+
+	if closer, ok := p.conditionContextEncrypter.(interface{ Close() }); ok {
+		closer.Close()
+	}
 }
 
 // Read see [storage.RelationshipTupleReader].Read.
@@ -191,6 +318,9 @@ func (p *Postgres) read(ctx context.Context, store string, tupleKey *openfgav1.T
 	if tupleKey.GetUser() != "" {
 		sb = sb.Where(sq.Eq{"_user": tupleKey.GetUser()})
 	}
+	if tupleKey.GetCondition().GetName() != "" {
+		sb = sb.Where(sq.Eq{"condition_name": tupleKey.GetCondition().GetName()})
+	}
 	if opts != nil && opts.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(opts.From)
 		if err != nil {
@@ -207,7 +337,15 @@ func (p *Postgres) read(ctx context.Context, store string, tupleKey *openfgav1.T
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(rows, p.tupleIteratorOptions()...), nil
+}
+
+// tupleIteratorOptions returns the options of the tuple iterators of the datastore.
+func (p *Postgres) tupleIteratorOptions() []sqlcommon.SQLTupleIteratorOption {
+	return []sqlcommon.SQLTupleIteratorOption{
+		sqlcommon.WithSQLTupleIteratorEncrypter(p.conditionContextEncrypter),
+		sqlcommon.WithSQLTupleIteratorBatchSize(p.iteratorBatchSize),
+	}
 }
 
 // Write see [storage.RelationshipTupleWriter].Write.
@@ -223,6 +361,23 @@ func (p *Postgres) Write(ctx context.Context, store string, deletes storage.Dele
 	return sqlcommon.Write(ctx, p.dbInfo, store, deletes, writes, now)
 }
 
+// WriteStores see [storage.MultiStoreWriteBackend].WriteStores.
+func (p *Postgres) WriteStores(ctx context.Context, writes []storage.StoreWrite) error {
+	ctx, span := tracer.Start(ctx, "postgres.WriteStores")
+	defer span.End()
+
+	tuples := 0
+	for _, w := range writes {
+		tuples += len(w.Deletes) + len(w.Writes)
+	}
+	if tuples > p.MaxTuplesPerWrite() {
+		return storage.ErrExceededWriteBatchLimit
+	}
+
+	now := time.Now().UTC()
+	return sqlcommon.WriteStores(ctx, p.dbInfo, writes, now)
+}
+
 // ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
 func (p *Postgres) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
 	ctx, span := tracer.Start(ctx, "postgres.ReadUserTuple")
@@ -265,12 +420,9 @@ func (p *Postgres) ReadUserTuple(ctx context.Context, store string, tupleKey *op
 	if conditionName.String != "" {
 		record.ConditionName = conditionName.String
 
-		if conditionContext != nil {
-			var conditionContextStruct structpb.Struct
-			if err := proto.Unmarshal(conditionContext, &conditionContextStruct); err != nil {
-				return nil, err
-			}
-			record.ConditionContext = &conditionContextStruct
+		record.ConditionContext, err = sqlcommon.UnmarshalConditionContext(conditionContext, p.conditionContextEncrypter)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -313,12 +465,16 @@ func (p *Postgres) ReadUsersetTuples(ctx context.Context, store string, filter s
 		}
 		sb = sb.Where(orConditions)
 	}
+	if filter.PageSize > 0 {
+		return sqlcommon.NewPagedSQLTupleIterator(ctx, sb, filter.PageSize, p.tupleIteratorOptions()...)
+	}
+
 	rows, err := sb.QueryContext(ctx)
 	if err != nil {
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(rows, p.tupleIteratorOptions()...), nil
 }
 
 // ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
@@ -351,7 +507,7 @@ func (p *Postgres) ReadStartingWithUser(ctx context.Context, store string, opts
 		return nil, sqlcommon.HandleSQLError(err)
 	}
 
-	return sqlcommon.NewSQLTupleIterator(rows), nil
+	return sqlcommon.NewSQLTupleIterator(rows, p.tupleIteratorOptions()...), nil
 }
 
 // MaxTuplesPerWrite see [storage.RelationshipTupleWriter].MaxTuplesPerWrite.
@@ -522,8 +678,12 @@ func (p *Postgres) GetStore(ctx context.Context, id string) (*openfgav1.Store, e
 	}, nil
 }
 
-// ListStores provides a paginated list of all stores present in the Postgres storage.
-func (p *Postgres) ListStores(ctx context.Context, opts storage.PaginationOptions) ([]*openfgav1.Store, []byte, error) {
+// ListStores provides a paginated list of the stores present in the Postgres storage that match the filter.
+func (p *Postgres) ListStores(
+	ctx context.Context,
+	filter storage.ListStoresFilter,
+	opts storage.PaginationOptions,
+) ([]*openfgav1.Store, []byte, error) {
 	ctx, span := tracer.Start(ctx, "postgres.ListStores")
 	defer span.End()
 
@@ -533,6 +693,8 @@ func (p *Postgres) ListStores(ctx context.Context, opts storage.PaginationOption
 		Where(sq.Eq{"deleted_at": nil}).
 		OrderBy("id")
 
+	sb = sqlcommon.WhereListStoresFilter(sb, filter)
+
 	if opts.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(opts.From)
 		if err != nil {
@@ -600,6 +762,46 @@ func (p *Postgres) DeleteStore(ctx context.Context, id string) error {
 	return nil
 }
 
+// UpdateStore see [sqlcommon.UpdateStore].
+func (p *Postgres) UpdateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	ctx, span := tracer.Start(ctx, "postgres.UpdateStore")
+	defer span.End()
+
+	return sqlcommon.UpdateStore(ctx, p.dbInfo, store)
+}
+
+// WriteStoreLabels see [sqlcommon.WriteStoreLabels].
+func (p *Postgres) WriteStoreLabels(ctx context.Context, store string, labels map[string]string) error {
+	ctx, span := tracer.Start(ctx, "postgres.WriteStoreLabels")
+	defer span.End()
+
+	return sqlcommon.WriteStoreLabels(ctx, p.dbInfo, store, labels)
+}
+
+// ReadStoreLabels see [sqlcommon.ReadStoreLabels].
+func (p *Postgres) ReadStoreLabels(ctx context.Context, stores []string) (map[string]map[string]string, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadStoreLabels")
+	defer span.End()
+
+	return sqlcommon.ReadStoreLabels(ctx, p.dbInfo, stores)
+}
+
+// ReadTupleWriters see [sqlcommon.ReadTupleWriters].
+func (p *Postgres) ReadTupleWriters(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadTupleWriters")
+	defer span.End()
+
+	return sqlcommon.ReadTupleWriters(ctx, p.dbInfo, store, tupleKeys)
+}
+
+// ReadChangeWriters see [sqlcommon.ReadChangeWriters].
+func (p *Postgres) ReadChangeWriters(ctx context.Context, store string, changes []*openfgav1.TupleChange) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadChangeWriters")
+	defer span.End()
+
+	return sqlcommon.ReadChangeWriters(ctx, p.dbInfo, store, changes)
+}
+
 // WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
 func (p *Postgres) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
 	ctx, span := tracer.Start(ctx, "postgres.WriteAssertions")
@@ -654,10 +856,126 @@ func (p *Postgres) ReadAssertions(ctx context.Context, store, modelID string) ([
 	return assertions.GetAssertions(), nil
 }
 
+// WriteStoreSettings see [storage.StoreSettingsBackend].WriteStoreSettings.
+func (p *Postgres) WriteStoreSettings(ctx context.Context, store string, settings *storage.StoreSettings) error {
+	ctx, span := tracer.Start(ctx, "postgres.WriteStoreSettings")
+	defer span.End()
+
+	checkQueryCacheTTLMs := settings.CheckQueryCacheTTL.Milliseconds()
+
+	_, err := p.stbl.
+		Insert("store_settings").
+		Columns("store", "default_authorization_model_id", "default_consistency", "check_query_cache_ttl_ms", "candidate_authorization_model_id", "updated_at").
+		Values(store, settings.DefaultAuthorizationModelID, settings.DefaultConsistency, checkQueryCacheTTLMs, settings.CandidateAuthorizationModelID, "NOW()").
+		Suffix("ON CONFLICT (store) DO UPDATE SET default_authorization_model_id = ?, default_consistency = ?, check_query_cache_ttl_ms = ?, candidate_authorization_model_id = ?, updated_at = NOW()",
+			settings.DefaultAuthorizationModelID, settings.DefaultConsistency, checkQueryCacheTTLMs, settings.CandidateAuthorizationModelID).
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadStoreSettings see [sqlcommon.ReadStoreSettings].
+func (p *Postgres) ReadStoreSettings(ctx context.Context, store string) (*storage.StoreSettings, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadStoreSettings")
+	defer span.End()
+
+	return sqlcommon.ReadStoreSettings(ctx, p.dbInfo, store)
+}
+
+// WritePlannerStatistics see [storage.PlannerStatisticsBackend].WritePlannerStatistics.
+func (p *Postgres) WritePlannerStatistics(ctx context.Context, store string, statistics []byte) error {
+	ctx, span := tracer.Start(ctx, "postgres.WritePlannerStatistics")
+	defer span.End()
+
+	_, err := p.stbl.
+		Insert("planner_statistics").
+		Columns("store", "statistics", "updated_at").
+		Values(store, statistics, "NOW()").
+		Suffix("ON CONFLICT (store) DO UPDATE SET statistics = ?, updated_at = NOW()", statistics).
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadPlannerStatistics see [sqlcommon.ReadPlannerStatistics].
+func (p *Postgres) ReadPlannerStatistics(ctx context.Context, store string) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadPlannerStatistics")
+	defer span.End()
+
+	return sqlcommon.ReadPlannerStatistics(ctx, p.dbInfo, store)
+}
+
+// AddRelationUsage see [storage.RelationUsageBackend].AddRelationUsage.
+func (p *Postgres) AddRelationUsage(ctx context.Context, store string, usage []storage.RelationUsage) error {
+	ctx, span := tracer.Start(ctx, "postgres.AddRelationUsage")
+	defer span.End()
+
+	if len(usage) == 0 {
+		return nil
+	}
+
+	insert := p.stbl.
+		Insert("relation_usage").
+		Columns("store", "object_type", "relation", "allowed_count", "denied_count", "last_evaluated_at")
+	for _, relation := range usage {
+		insert = insert.Values(store, relation.ObjectType, relation.Relation, relation.Allowed, relation.Denied, relation.LastEvaluatedAt.UTC())
+	}
+
+	_, err := insert.
+		Suffix("ON CONFLICT (store, object_type, relation) DO UPDATE SET allowed_count = relation_usage.allowed_count + EXCLUDED.allowed_count, denied_count = relation_usage.denied_count + EXCLUDED.denied_count, last_evaluated_at = GREATEST(relation_usage.last_evaluated_at, EXCLUDED.last_evaluated_at)").
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadRelationUsage see [sqlcommon.ReadRelationUsage].
+func (p *Postgres) ReadRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadRelationUsage")
+	defer span.End()
+
+	return sqlcommon.ReadRelationUsage(ctx, p.dbInfo, store)
+}
+
+// WriteChangelogCheckpoint see [storage.ChangelogCheckpointBackend].WriteChangelogCheckpoint.
+func (p *Postgres) WriteChangelogCheckpoint(ctx context.Context, store, consumer, continuationToken string) error {
+	ctx, span := tracer.Start(ctx, "postgres.WriteChangelogCheckpoint")
+	defer span.End()
+
+	_, err := p.stbl.
+		Insert("changelog_checkpoint").
+		Columns("store", "consumer", "continuation_token", "updated_at").
+		Values(store, consumer, continuationToken, "NOW()").
+		Suffix("ON CONFLICT (store, consumer) DO UPDATE SET continuation_token = ?, updated_at = NOW()", continuationToken).
+		ExecContext(ctx)
+	if err != nil {
+		return sqlcommon.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadChangelogCheckpoint see [sqlcommon.ReadChangelogCheckpoint].
+func (p *Postgres) ReadChangelogCheckpoint(ctx context.Context, store, consumer string) (string, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ReadChangelogCheckpoint")
+	defer span.End()
+
+	return sqlcommon.ReadChangelogCheckpoint(ctx, p.dbInfo, store, consumer)
+}
+
 // ReadChanges see [storage.ChangelogBackend].ReadChanges.
 func (p *Postgres) ReadChanges(
 	ctx context.Context,
-	store, objectTypeFilter string,
+	store string,
+	filter storage.ReadChangesFilter,
 	opts storage.PaginationOptions,
 	horizonOffset time.Duration,
 ) ([]*openfgav1.TupleChange, []byte, error) {
@@ -674,15 +992,13 @@ func (p *Postgres) ReadChanges(
 		Where(fmt.Sprintf("inserted_at < NOW() - interval '%dms'", horizonOffset.Milliseconds())).
 		OrderBy("ulid asc")
 
-	if objectTypeFilter != "" {
-		sb = sb.Where(sq.Eq{"object_type": objectTypeFilter})
-	}
+	sb = sqlcommon.WhereReadChangesFilter(sb, filter)
 	if opts.From != "" {
 		token, err := sqlcommon.UnmarshallContToken(opts.From)
 		if err != nil {
 			return nil, nil, err
 		}
-		if token.ObjectType != objectTypeFilter {
+		if token.ObjectType != filter.ObjectType {
 			return nil, nil, storage.ErrMismatchObjectType
 		}
 
@@ -722,12 +1038,11 @@ func (p *Postgres) ReadChanges(
 			return nil, nil, sqlcommon.HandleSQLError(err)
 		}
 
-		var conditionContextStruct structpb.Struct
+		var conditionContextStruct *structpb.Struct
 		if conditionName.String != "" {
-			if conditionContext != nil {
-				if err := proto.Unmarshal(conditionContext, &conditionContextStruct); err != nil {
-					return nil, nil, err
-				}
+			conditionContextStruct, err = sqlcommon.UnmarshalConditionContext(conditionContext, p.conditionContextEncrypter)
+			if err != nil {
+				return nil, nil, err
 			}
 		}
 
@@ -736,7 +1051,7 @@ func (p *Postgres) ReadChanges(
 			relation,
 			user,
 			conditionName.String,
-			&conditionContextStruct,
+			conditionContextStruct,
 		)
 
 		changes = append(changes, &openfgav1.TupleChange{
@@ -750,7 +1065,7 @@ func (p *Postgres) ReadChanges(
 		return nil, nil, storage.ErrNotFound
 	}
 
-	contToken, err := json.Marshal(sqlcommon.NewContToken(ulid, objectTypeFilter))
+	contToken, err := json.Marshal(sqlcommon.NewContToken(ulid, filter.ObjectType))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -758,7 +1073,20 @@ func (p *Postgres) ReadChanges(
 	return changes, contToken, nil
 }
 
+// ChangeExists see [sqlcommon.ChangeExists].
+func (p *Postgres) ChangeExists(ctx context.Context, store, id string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "postgres.ChangeExists")
+	defer span.End()
+
+	return sqlcommon.ChangeExists(ctx, p.dbInfo, store, id)
+}
+
+// WarmConnectionPool see [sqlcommon.WarmConnectionPool].
+func (p *Postgres) WarmConnectionPool(ctx context.Context, conns int) error {
+	return sqlcommon.WarmConnectionPool(ctx, p.db, conns)
+}
+
 // IsReady see [sqlcommon.IsReady].
 func (p *Postgres) IsReady(ctx context.Context) (storage.ReadinessStatus, error) {
-	return sqlcommon.IsReady(ctx, p.db)
+	return sqlcommon.IsReady(ctx, p.db, assets.PostgresMigrationDir)
 }
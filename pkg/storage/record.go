@@ -22,6 +22,10 @@ type TupleRecord struct {
 	ConditionContext *structpb.Struct
 	Ulid             string
 	InsertedAt       time.Time
+
+	// CreatedBy is the writer of the tuple, see [ContextWithWriter]. It is only set by the datastores
+	// that keep their tuples as records, and not converted by AsTuple.
+	CreatedBy string
 }
 
 // AsTuple converts a [TupleRecord] into a [*openfgav1.Tuple].
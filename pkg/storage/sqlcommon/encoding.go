@@ -1,12 +1,23 @@
 package sqlcommon
 
 import (
+	"errors"
+
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/openfga/openfga/pkg/encrypter"
 )
 
+// encryptedConditionContextPrefix prefixes the encrypted condition contexts. A serialized
+// structpb.Struct never starts with a 0 byte (a field number of 0 is invalid), so the condition
+// contexts stored before encryption was enabled stay readable.
+const encryptedConditionContextPrefix byte = 0
+
 func marshalRelationshipCondition(
 	rel *openfgav1.RelationshipCondition,
+	enc encrypter.Encrypter,
 ) (name string, context []byte, err error) {
 	if rel != nil {
 		// Normalize empty context to nil.
@@ -15,6 +26,14 @@ func marshalRelationshipCondition(
 			if err != nil {
 				return name, context, err
 			}
+
+			if enc != nil {
+				encrypted, err := enc.Encrypt(context)
+				if err != nil {
+					return name, nil, err
+				}
+				context = append([]byte{encryptedConditionContextPrefix}, encrypted...)
+			}
 		}
 
 		return rel.GetName(), context, err
@@ -22,3 +41,30 @@ func marshalRelationshipCondition(
 
 	return name, context, err
 }
+
+// UnmarshalConditionContext unmarshals a condition context stored by [Write], decrypting it with
+// enc if it is encrypted. It returns nil for a nil context.
+func UnmarshalConditionContext(data []byte, enc encrypter.Encrypter) (*structpb.Struct, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	if len(data) > 0 && data[0] == encryptedConditionContextPrefix {
+		if enc == nil {
+			return nil, errors.New("condition context is encrypted, but no condition context encryption key is configured")
+		}
+
+		decrypted, err := enc.Decrypt(data[1:])
+		if err != nil {
+			return nil, err
+		}
+		data = decrypted
+	}
+
+	var conditionContext structpb.Struct
+	if err := proto.Unmarshal(data, &conditionContext); err != nil {
+		return nil, err
+	}
+
+	return &conditionContext, nil
+}
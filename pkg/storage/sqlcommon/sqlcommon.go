@@ -6,7 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -14,12 +18,20 @@ import (
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/pressly/goose/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/openfga/openfga/assets"
 	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/internal/migrations"
+	"github.com/openfga/openfga/pkg/encrypter"
 	"github.com/openfga/openfga/pkg/logger"
+	"github.com/openfga/openfga/pkg/middleware/requestid"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/telemetry"
 	tupleUtils "github.com/openfga/openfga/pkg/tuple"
 )
 
@@ -38,6 +50,38 @@ type Config struct {
 	ConnMaxLifetime time.Duration
 
 	ExportMetrics bool
+
+	// PostgresStatementCacheCapacity is the number of prepared statements cached per
+	// Postgres connection. If 0, the driver default is used.
+	PostgresStatementCacheCapacity int
+	// PostgresQueryExecMode is the pgx query execution mode used for Postgres queries
+	// (e.g. 'cache_statement', 'exec', 'simple_protocol'). If empty, the driver default is used.
+	PostgresQueryExecMode string
+
+	// MySQLInterpolateParams interpolates query placeholders on the client, which saves
+	// a round trip per query to prepare the statement.
+	MySQLInterpolateParams bool
+
+	// RequestIDComments prefixes the queries with a comment with the request ID of their context.
+	// Every query text is then unique, so prepared statements can't be reused between requests.
+	RequestIDComments bool
+
+	// SQLCommenter appends a comment in the sqlcommenter format to the queries, with the RPC, the
+	// request ID and the trace context of their context. It takes precedence over RequestIDComments.
+	SQLCommenter bool
+
+	// ConditionContextEncrypter encrypts the condition contexts of the tuples in the database. If
+	// nil, they are stored in plain text. If it has a Close method, it is closed with the datastore.
+	ConditionContextEncrypter encrypter.Encrypter
+
+	// PostgresNotifyTupleChanges notifies the store of every Write with a Postgres NOTIFY, see
+	// [storage.TupleChangeNotifier].
+	PostgresNotifyTupleChanges bool
+
+	// IteratorBatchSize is the number of tuples that the tuple iterators read ahead of their
+	// consumer, see [WithSQLTupleIteratorBatchSize]. If 0, the rows are only read when the next
+	// tuple is requested.
+	IteratorBatchSize int
 }
 
 // DatastoreOption defines a function type
@@ -121,6 +165,70 @@ func WithMetrics() DatastoreOption {
 	}
 }
 
+// WithPostgresStatementCacheCapacity returns a DatastoreOption that sets
+// the number of prepared statements cached per Postgres connection in the Config.
+func WithPostgresStatementCacheCapacity(capacity int) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.PostgresStatementCacheCapacity = capacity
+	}
+}
+
+// WithPostgresQueryExecMode returns a DatastoreOption that sets
+// the pgx query execution mode for Postgres in the Config.
+func WithPostgresQueryExecMode(mode string) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.PostgresQueryExecMode = mode
+	}
+}
+
+// WithMySQLInterpolateParams returns a DatastoreOption that enables
+// client side interpolation of query placeholders for MySQL in the Config.
+func WithMySQLInterpolateParams() DatastoreOption {
+	return func(cfg *Config) {
+		cfg.MySQLInterpolateParams = true
+	}
+}
+
+// WithPostgresNotifyTupleChanges returns a DatastoreOption that notifies the store of every Write
+// with a Postgres NOTIFY.
+func WithPostgresNotifyTupleChanges() DatastoreOption {
+	return func(config *Config) {
+		config.PostgresNotifyTupleChanges = true
+	}
+}
+
+// WithRequestIDComments returns a DatastoreOption that prefixes the queries with a comment with
+// the request ID of their context.
+func WithRequestIDComments() DatastoreOption {
+	return func(config *Config) {
+		config.RequestIDComments = true
+	}
+}
+
+// WithSQLCommenter returns a DatastoreOption that appends a comment in the sqlcommenter format
+// to the queries.
+func WithSQLCommenter() DatastoreOption {
+	return func(config *Config) {
+		config.SQLCommenter = true
+	}
+}
+
+// WithConditionContextEncrypter returns a DatastoreOption that encrypts the condition contexts
+// of the tuples in the database with e.
+func WithConditionContextEncrypter(e encrypter.Encrypter) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.ConditionContextEncrypter = e
+	}
+}
+
+// WithIteratorBatchSize returns a DatastoreOption that makes the tuple iterators read up to size
+// tuples ahead of their consumer.
+func WithIteratorBatchSize(size int) DatastoreOption {
+	return func(cfg *Config) {
+		cfg.IteratorBatchSize = size
+	}
+}
+
 // NewConfig creates a new Config instance with default values
 // and applies any provided DatastoreOption modifications.
 func NewConfig(opts ...DatastoreOption) *Config {
@@ -145,6 +253,60 @@ func NewConfig(opts ...DatastoreOption) *Config {
 	return cfg
 }
 
+// likeEscaper escapes the wildcards of a LIKE pattern, with the default escape character of
+// both postgres and mysql.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// WhereReadChangesFilter adds the conditions of the filter to a query of the changelog table.
+func WhereReadChangesFilter(sb sq.SelectBuilder, filter storage.ReadChangesFilter) sq.SelectBuilder {
+	if filter.ObjectType != "" {
+		sb = sb.Where(sq.Eq{"object_type": filter.ObjectType})
+	}
+	if filter.ObjectIDPrefix != "" {
+		sb = sb.Where(sq.Like{"object_id": likeEscaper.Replace(filter.ObjectIDPrefix) + "%"})
+	}
+	if filter.Relation != "" {
+		sb = sb.Where(sq.Eq{"relation": filter.Relation})
+	}
+	if filter.User != "" {
+		sb = sb.Where(sq.Eq{"_user": filter.User})
+	}
+	if !filter.StartTime.IsZero() {
+		sb = sb.Where(sq.GtOrEq{"inserted_at": filter.StartTime.UTC()})
+	}
+
+	return sb
+}
+
+// WhereListStoresFilter adds the conditions of the filter to a query of the store table.
+func WhereListStoresFilter(sb sq.SelectBuilder, filter storage.ListStoresFilter) sq.SelectBuilder {
+	if filter.NameContains != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(filter.NameContains)) + "%"
+		sb = sb.Where(sq.Like{"LOWER(name)": pattern})
+	}
+	if !filter.CreatedAfter.IsZero() {
+		sb = sb.Where(sq.GtOrEq{"created_at": filter.CreatedAfter})
+	}
+	if !filter.CreatedBefore.IsZero() {
+		sb = sb.Where(sq.Lt{"created_at": filter.CreatedBefore})
+	}
+
+	keys := make([]string, 0, len(filter.Labels))
+	for key := range filter.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // so that the queries with the same filter are the same
+
+	for _, key := range keys {
+		sb = sb.Where(
+			"EXISTS (SELECT 1 FROM store_label WHERE store_label.store = store.id AND store_label.label_key = ? AND store_label.label_value = ?)",
+			key, filter.Labels[key],
+		)
+	}
+
+	return sb
+}
+
 // ContToken represents a continuation token structure used in pagination.
 type ContToken struct {
 	Ulid       string `json:"ulid"`
@@ -170,30 +332,118 @@ func UnmarshallContToken(from string) (*ContToken, error) {
 	return &token, nil
 }
 
+var (
+	tupleIteratorBufferedHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace:                       build.ProjectName,
+		Name:                            "datastore_iterator_buffered_tuples",
+		Help:                            "The number of tuples read ahead by a datastore iterator with a batch size and not consumed yet, each time a tuple is requested from it. It stays close to 0 if the datastore is slower than the consumer of the iterator.",
+		Buckets:                         []float64{0, 1, 10, 50, 100, 500, 1000},
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	})
+
+	tupleIteratorBackpressureCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_iterator_backpressure_count",
+		Help:      "The total number of times a datastore iterator with a batch size stopped reading rows because it had read a full batch ahead of its consumer.",
+	})
+)
+
 // SQLTupleIterator is a struct that implements the storage.TupleIterator
 // interface for iterating over tuples fetched from a SQL database.
 type SQLTupleIterator struct {
-	rows     *sql.Rows
-	resultCh chan *storage.TupleRecord
-	errCh    chan error
+	rows *sql.Rows
+
+	// with a batch size, the rows are read ahead of the consumer into resultCh, and the error that
+	// ended the reads is sent to errCh once resultCh is closed
+	batchSize int
+	resultCh  chan *storage.TupleRecord
+	errCh     chan error
+	err       error
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+
+	conditionContextEncrypter encrypter.Encrypter
 }
 
 // Ensures that SQLTupleIterator implements the TupleIterator interface.
 var _ storage.TupleIterator = (*SQLTupleIterator)(nil)
 
+// SQLTupleIteratorOption defines a function type used for configuring a [SQLTupleIterator].
+type SQLTupleIteratorOption func(*SQLTupleIterator)
+
+// WithSQLTupleIteratorEncrypter returns a SQLTupleIteratorOption that decrypts the encrypted
+// condition contexts with e.
+func WithSQLTupleIteratorEncrypter(e encrypter.Encrypter) SQLTupleIteratorOption {
+	return func(t *SQLTupleIterator) {
+		t.conditionContextEncrypter = e
+	}
+}
+
+// WithSQLTupleIteratorBatchSize returns a SQLTupleIteratorOption that reads the rows in the
+// background, up to size tuples ahead of the consumer of the iterator, so that the consumer doesn't
+// wait for the datastore as long as it is slower than it. Once size tuples are read ahead, the reads
+// wait for the consumer, so that the memory of the iterator is bounded however many rows it has.
+// If size is 0, the rows are only read when the next tuple is requested.
+func WithSQLTupleIteratorBatchSize(size int) SQLTupleIteratorOption {
+	return func(t *SQLTupleIterator) {
+		t.batchSize = size
+	}
+}
+
 // NewSQLTupleIterator returns a SQL tuple iterator.
-func NewSQLTupleIterator(rows *sql.Rows) *SQLTupleIterator {
-	return &SQLTupleIterator{
-		rows:     rows,
-		resultCh: make(chan *storage.TupleRecord, 1),
-		errCh:    make(chan error, 1),
+func NewSQLTupleIterator(rows *sql.Rows, opts ...SQLTupleIteratorOption) *SQLTupleIterator {
+	iter := &SQLTupleIterator{
+		rows: rows,
+		done: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(iter)
+	}
+
+	if iter.batchSize > 0 {
+		iter.resultCh = make(chan *storage.TupleRecord, iter.batchSize)
+		iter.errCh = make(chan error, 1)
+	}
+
+	return iter
+}
+
+// readAhead reads the rows into resultCh until they are all read, the reads fail or the iterator is
+// stopped.
+func (t *SQLTupleIterator) readAhead() {
+	defer close(t.resultCh)
+
+	for {
+		record, err := t.next()
+		if err != nil {
+			t.errCh <- err
+			return
+		}
+
+		select {
+		case t.resultCh <- record:
+			continue
+		default:
+		}
+
+		// a full batch is read ahead, the reads wait for the consumer
+		tupleIteratorBackpressureCounter.Inc()
+		select {
+		case t.resultCh <- record:
+		case <-t.done:
+			return
+		}
 	}
 }
 
 func (t *SQLTupleIterator) next() (*storage.TupleRecord, error) {
 	if !t.rows.Next() {
 		if err := t.rows.Err(); err != nil {
-			return nil, err
+			return nil, HandleSQLError(err)
 		}
 		return nil, storage.ErrIteratorDone
 	}
@@ -218,12 +468,9 @@ func (t *SQLTupleIterator) next() (*storage.TupleRecord, error) {
 
 	record.ConditionName = conditionName.String
 
-	if conditionContext != nil {
-		var conditionContextStruct structpb.Struct
-		if err := proto.Unmarshal(conditionContext, &conditionContextStruct); err != nil {
-			return nil, err
-		}
-		record.ConditionContext = &conditionContextStruct
+	record.ConditionContext, err = UnmarshalConditionContext(conditionContext, t.conditionContextEncrypter)
+	if err != nil {
+		return nil, err
 	}
 
 	return &record, nil
@@ -268,7 +515,11 @@ func (t *SQLTupleIterator) ToArray(
 // Next will return the next available item.
 func (t *SQLTupleIterator) Next(ctx context.Context) (*openfgav1.Tuple, error) {
 	if ctx.Err() != nil {
-		return nil, ctx.Err()
+		return nil, HandleSQLError(ctx.Err())
+	}
+
+	if t.batchSize > 0 {
+		return t.nextReadAhead(ctx)
 	}
 
 	record, err := t.next()
@@ -279,26 +530,144 @@ func (t *SQLTupleIterator) Next(ctx context.Context) (*openfgav1.Tuple, error) {
 	return record.AsTuple(), nil
 }
 
+// nextReadAhead returns the next tuple read ahead, and starts the reads on the first call.
+func (t *SQLTupleIterator) nextReadAhead(ctx context.Context) (*openfgav1.Tuple, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	t.startOnce.Do(func() {
+		go t.readAhead()
+	})
+
+	tupleIteratorBufferedHistogram.Observe(float64(len(t.resultCh)))
+
+	select {
+	case record, ok := <-t.resultCh:
+		if !ok {
+			select {
+			case t.err = <-t.errCh:
+			default:
+				// the reads were stopped by Stop
+				t.err = storage.ErrIteratorDone
+			}
+			return nil, t.err
+		}
+		return record.AsTuple(), nil
+	case <-ctx.Done():
+		return nil, HandleSQLError(ctx.Err())
+	}
+}
+
 // Stop terminates iteration.
 func (t *SQLTupleIterator) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
 	t.rows.Close()
 }
 
+// pagedSQLTupleIterator is an iterator over the rows of a query, read in pages of ordered users.
+type pagedSQLTupleIterator struct {
+	sb       sq.SelectBuilder
+	pageSize int
+	opts     []SQLTupleIteratorOption
+
+	page     *SQLTupleIterator
+	pageRead int
+	lastUser string
+	done     bool
+}
+
+var _ storage.TupleIterator = (*pagedSQLTupleIterator)(nil)
+
+// NewPagedSQLTupleIterator returns an iterator over the rows of the query sb that reads them in pages
+// of pageSize rows ordered by user, so that the pages after the last tuple returned by the iterator
+// are never read. The rows of the query must have distinct users, e.g. those of an object and a
+// relation, and the query must select the columns of a [SQLTupleIterator]. The first page is read
+// right away.
+func NewPagedSQLTupleIterator(ctx context.Context, sb sq.SelectBuilder, pageSize int, opts ...SQLTupleIteratorOption) (storage.TupleIterator, error) {
+	iter := &pagedSQLTupleIterator{sb: sb, pageSize: pageSize, opts: opts}
+	if err := iter.readPage(ctx); err != nil {
+		return nil, err
+	}
+
+	return iter, nil
+}
+
+// readPage reads the page of the users after the last user read.
+func (t *pagedSQLTupleIterator) readPage(ctx context.Context) error {
+	sb := t.sb.OrderBy("_user").Limit(uint64(t.pageSize))
+	if t.lastUser != "" {
+		sb = sb.Where(sq.Gt{"_user": t.lastUser})
+	}
+
+	rows, err := sb.QueryContext(ctx)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	t.page = NewSQLTupleIterator(rows, t.opts...)
+	t.pageRead = 0
+	return nil
+}
+
+// Next see [storage.Iterator].Next.
+func (t *pagedSQLTupleIterator) Next(ctx context.Context) (*openfgav1.Tuple, error) {
+	for {
+		if t.page == nil {
+			if t.done {
+				return nil, storage.ErrIteratorDone
+			}
+			if err := t.readPage(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		tuple, err := t.page.Next(ctx)
+		if err != nil {
+			if !errors.Is(err, storage.ErrIteratorDone) {
+				return nil, err
+			}
+
+			t.page.Stop()
+			t.page = nil
+			// a page shorter than the page size is the last one
+			t.done = t.pageRead < t.pageSize
+			continue
+		}
+
+		t.pageRead++
+		t.lastUser = tuple.GetKey().GetUser()
+		return tuple, nil
+	}
+}
+
+// Stop see [storage.Iterator].Stop.
+func (t *pagedSQLTupleIterator) Stop() {
+	if t.page != nil {
+		t.page.Stop()
+		t.page = nil
+	}
+	t.done = true
+}
+
 // HandleSQLError processes an SQL error and converts it into a more
-// specific error type based on the nature of the SQL error.
+// specific error type based on the nature of the SQL error. An error of the context of the query
+// wraps storage.ErrDeadlineExceeded or storage.ErrCancelled, so that the time spent in the datastore
+// can be told apart from the time spent resolving the request.
 func HandleSQLError(err error, args ...interface{}) error {
-	if errors.Is(err, sql.ErrNoRows) {
+	if errors.Is(err, storage.ErrDeadlineExceeded) || errors.Is(err, storage.ErrCancelled) {
+		return err
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", storage.ErrDeadlineExceeded, err)
+	} else if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("%w: %w", storage.ErrCancelled, err)
+	} else if errors.Is(err, sql.ErrNoRows) {
 		return storage.ErrNotFound
 	} else if errors.Is(err, storage.ErrIteratorDone) {
 		return err
-	} else if strings.Contains(err.Error(), "duplicate key value") { // Postgres.
-		if len(args) > 0 {
-			if tk, ok := args[0].(*openfgav1.TupleKey); ok {
-				return storage.InvalidWriteInputError(tk, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE)
-			}
-		}
-		return storage.ErrCollision
-	} else if me, ok := err.(*mysql.MySQLError); ok && me.Number == 1062 {
+	} else if isDuplicateKeyError(err) {
 		if len(args) > 0 {
 			if tk, ok := args[0].(*openfgav1.TupleKey); ok {
 				return storage.InvalidWriteInputError(tk, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE)
@@ -310,20 +679,155 @@ func HandleSQLError(err error, args ...interface{}) error {
 	return fmt.Errorf("sql error: %w", err)
 }
 
+// isDuplicateKeyError returns whether err is the unique constraint violation of Postgres or MySQL.
+func isDuplicateKeyError(err error) bool {
+	if strings.Contains(err.Error(), "duplicate key value") { // Postgres.
+		return true
+	}
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == 1062
+}
+
+// commentingRunner is a [sq.StdSqlCtx] that comments the queries with information of their context,
+// so that the queries of a request can be found in the database logs.
+type commentingRunner struct {
+	sq.StdSqlCtx
+	comment func(ctx context.Context, query string) string
+}
+
+// NewQueryRunner returns a runner for the statement builders that comments the queries of runner as
+// configured: with a '/* request_id=... */' prefix if RequestIDComments is set, or in the sqlcommenter
+// format if SQLCommenter is set. Otherwise, runner is returned as is.
+func NewQueryRunner(runner sq.StdSqlCtx, cfg *Config) sq.StdSqlCtx {
+	switch {
+	case cfg == nil:
+		return runner
+	case cfg.SQLCommenter:
+		return &commentingRunner{StdSqlCtx: runner, comment: withSQLCommenterComment}
+	case cfg.RequestIDComments:
+		return &commentingRunner{StdSqlCtx: runner, comment: withRequestIDComment}
+	default:
+		return runner
+	}
+}
+
+func (r *commentingRunner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.StdSqlCtx.QueryContext(ctx, r.comment(ctx, query), args...)
+}
+
+func (r *commentingRunner) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.StdSqlCtx.QueryRowContext(ctx, r.comment(ctx, query), args...)
+}
+
+func (r *commentingRunner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.StdSqlCtx.ExecContext(ctx, r.comment(ctx, query), args...)
+}
+
+// withRequestIDComment prefixes the query with a comment with the request ID of the context, if any.
+// The request IDs are validated by the interceptors, but the comment is closed defensively anyway.
+func withRequestIDComment(ctx context.Context, query string) string {
+	requestID, ok := requestid.FromContext(ctx)
+	if !ok {
+		return query
+	}
+
+	return fmt.Sprintf("/* request_id=%s */ %s", strings.ReplaceAll(requestID, "*/", ""), query)
+}
+
+// withSQLCommenterComment appends a comment in the sqlcommenter format (https://google.github.io/sqlcommenter/spec/)
+// to the query, with the service and the method of the RPC, the request ID and the W3C traceparent
+// of the span of the context, so that APM tools can link the database load to the requests.
+func withSQLCommenterComment(ctx context.Context, query string) string {
+	// the queries that aren't issued for an RPC have the 'unknown' service and method
+	rpcInfo := telemetry.RPCInfoFromContext(ctx)
+	tags := map[string]string{
+		"controller": rpcInfo.Service,
+		"action":     rpcInfo.Method,
+	}
+
+	if requestID, ok := requestid.FromContext(ctx); ok {
+		tags["request_id"] = requestID
+	}
+
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		tags["traceparent"] = fmt.Sprintf("00-%s-%s-%s", spanCtx.TraceID(), spanCtx.SpanID(), spanCtx.TraceFlags())
+		if traceState := spanCtx.TraceState().String(); traceState != "" {
+			tags["tracestate"] = traceState
+		}
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// the keys and the values are URL encoded, which also escapes the '*' and '/' of a closing comment
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := strings.ReplaceAll(url.QueryEscape(tags[key]), "+", "%20")
+		pairs = append(pairs, fmt.Sprintf("%s='%s'", url.QueryEscape(key), value))
+	}
+
+	return fmt.Sprintf("%s /*%s*/", query, strings.Join(pairs, ","))
+}
+
 // DBInfo encapsulates DB information for use in common method.
 type DBInfo struct {
 	db      *sql.DB
 	stbl    sq.StatementBuilderType
 	sqlTime interface{}
+	cfg     *Config
+
+	conditionContextEncrypter encrypter.Encrypter
+	beforeWriteCommit         func(ctx context.Context, txn *sql.Tx, store string) error
+}
+
+// DBInfoOption defines a function type used for configuring a [DBInfo] object.
+type DBInfoOption func(*DBInfo)
+
+// WithDBInfoQueryComments returns a DBInfoOption that comments the queries of the transactions as
+// configured in cfg, like the queries of the statement builder (see [NewQueryRunner]).
+func WithDBInfoQueryComments(cfg *Config) DBInfoOption {
+	return func(d *DBInfo) {
+		d.cfg = cfg
+	}
+}
+
+// WithDBInfoConditionContextEncrypter returns a DBInfoOption that encrypts the condition contexts
+// of the written tuples with e.
+func WithDBInfoConditionContextEncrypter(e encrypter.Encrypter) DBInfoOption {
+	return func(d *DBInfo) {
+		d.conditionContextEncrypter = e
+	}
+}
+
+// WithDBInfoBeforeWriteCommit returns a DBInfoOption that calls hook in the transaction of every
+// Write, right before it is committed, once for each of its stores.
+func WithDBInfoBeforeWriteCommit(hook func(ctx context.Context, txn *sql.Tx, store string) error) DBInfoOption {
+	return func(d *DBInfo) {
+		d.beforeWriteCommit = hook
+	}
 }
 
 // NewDBInfo constructs a [DBInfo] object.
-func NewDBInfo(db *sql.DB, stbl sq.StatementBuilderType, sqlTime interface{}) *DBInfo {
-	return &DBInfo{
+func NewDBInfo(db *sql.DB, stbl sq.StatementBuilderType, sqlTime interface{}, opts ...DBInfoOption) *DBInfo {
+	dbInfo := &DBInfo{
 		db:      db,
 		stbl:    stbl,
 		sqlTime: sqlTime,
 	}
+
+	for _, opt := range opts {
+		opt(dbInfo)
+	}
+
+	return dbInfo
+}
+
+// TxnRunner returns the runner of the queries of a transaction.
+func (d *DBInfo) TxnRunner(txn *sql.Tx) sq.BaseRunner {
+	return NewQueryRunner(txn, d.cfg)
 }
 
 // Write provides the common method for writing to database across sql storage.
@@ -335,6 +839,17 @@ func Write(
 	writes storage.Writes,
 	now time.Time,
 ) error {
+	return WriteStores(ctx, dbInfo, []storage.StoreWrite{{
+		Store:       store,
+		Deletes:     deletes,
+		Writes:      writes,
+		ChangeULIDs: storage.ChangeULIDsFromContext(ctx),
+	}}, now)
+}
+
+// WriteStores provides the common method for writing the tuples of several stores in a single
+// transaction across sql storage. See [storage.MultiStoreWriteBackend].
+func WriteStores(ctx context.Context, dbInfo *DBInfo, writes []storage.StoreWrite, now time.Time) error {
 	txn, err := dbInfo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return HandleSQLError(err)
@@ -343,17 +858,63 @@ func Write(
 		_ = txn.Rollback()
 	}()
 
+	for _, w := range writes {
+		if err := writeTuples(storage.ContextWithChangeULIDs(ctx, w.ChangeULIDs), dbInfo, txn, w.Store, w.Deletes, w.Writes, now); err != nil {
+			return err
+		}
+	}
+
+	if dbInfo.beforeWriteCommit != nil {
+		for _, w := range writes {
+			if err := dbInfo.beforeWriteCommit(ctx, txn, w.Store); err != nil {
+				return HandleSQLError(err)
+			}
+		}
+	}
+
+	if err := txn.Commit(); err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// writeTuples deletes and writes the tuples of a store in the transaction txn, and appends their
+// changes to its changelog.
+func writeTuples(
+	ctx context.Context,
+	dbInfo *DBInfo,
+	txn *sql.Tx,
+	store string,
+	deletes storage.Deletes,
+	writes storage.Writes,
+	now time.Time,
+) error {
 	changelogBuilder := dbInfo.stbl.
 		Insert("changelog").
 		Columns(
 			"store", "object_type", "object_id", "relation", "_user",
-			"condition_name", "condition_context", "operation", "ulid", "inserted_at",
+			"condition_name", "condition_context", "operation", "ulid", "inserted_at", "created_by",
 		)
 
 	deleteBuilder := dbInfo.stbl.Delete("tuple")
 
+	writer := storage.WriterFromContext(ctx)
+
+	// the changes use the ULIDs of the context by their index, if any, e.g. the first change
+	changeIDs := storage.ChangeULIDsFromContext(ctx)
+	changeIndex := 0
+	newChangeID := func() string {
+		id := changeIDs[changeIndex]
+		changeIndex++
+		if id != "" {
+			return id
+		}
+		return ulid.MustNew(ulid.Timestamp(now), ulid.DefaultEntropy()).String()
+	}
+
 	for _, tk := range deletes {
-		id := ulid.MustNew(ulid.Timestamp(now), ulid.DefaultEntropy()).String()
+		id := newChangeID()
 		objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
 
 		res, err := deleteBuilder.
@@ -365,7 +926,7 @@ func Write(
 				"_user":       tk.GetUser(),
 				"user_type":   tupleUtils.GetUserTypeFromUser(tk.GetUser()),
 			}).
-			RunWith(txn). // Part of a txn.
+			RunWith(dbInfo.TxnRunner(txn)). // Part of a txn.
 			ExecContext(ctx)
 		if err != nil {
 			return HandleSQLError(err, tk)
@@ -388,7 +949,7 @@ func Write(
 			tk.GetRelation(), tk.GetUser(),
 			"", nil, // Redact condition info for deletes since we only need the base triplet (object, relation, user).
 			openfgav1.TupleOperation_TUPLE_OPERATION_DELETE,
-			id, dbInfo.sqlTime,
+			id, dbInfo.sqlTime, writer,
 		)
 	}
 
@@ -396,36 +957,31 @@ func Write(
 		Insert("tuple").
 		Columns(
 			"store", "object_type", "object_id", "relation", "_user", "user_type",
-			"condition_name", "condition_context", "ulid", "inserted_at",
+			"condition_name", "condition_context", "ulid", "inserted_at", "created_by",
 		)
 
 	for _, tk := range writes {
-		id := ulid.MustNew(ulid.Timestamp(now), ulid.DefaultEntropy()).String()
+		id := newChangeID()
 		objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
 
-		conditionName, conditionContext, err := marshalRelationshipCondition(tk.GetCondition())
+		conditionName, conditionContext, err := marshalRelationshipCondition(tk.GetCondition(), dbInfo.conditionContextEncrypter)
 		if err != nil {
 			return err
 		}
 
-		_, err = insertBuilder.
-			Values(
-				store,
-				objectType,
-				objectID,
-				tk.GetRelation(),
-				tk.GetUser(),
-				tupleUtils.GetUserTypeFromUser(tk.GetUser()),
-				conditionName,
-				conditionContext,
-				id,
-				dbInfo.sqlTime,
-			).
-			RunWith(txn). // Part of a txn.
-			ExecContext(ctx)
-		if err != nil {
-			return HandleSQLError(err, tk)
-		}
+		insertBuilder = insertBuilder.Values(
+			store,
+			objectType,
+			objectID,
+			tk.GetRelation(),
+			tk.GetUser(),
+			tupleUtils.GetUserTypeFromUser(tk.GetUser()),
+			conditionName,
+			conditionContext,
+			id,
+			dbInfo.sqlTime,
+			writer,
+		)
 
 		changelogBuilder = changelogBuilder.Values(
 			store,
@@ -438,21 +994,80 @@ func Write(
 			openfgav1.TupleOperation_TUPLE_OPERATION_WRITE,
 			id,
 			dbInfo.sqlTime,
+			writer,
 		)
 	}
 
+	// The tuples are inserted with a single multi-row statement, which fails as a whole if any
+	// of them already exists.
+	if len(writes) > 0 {
+		_, err := insertBuilder.RunWith(dbInfo.TxnRunner(txn)).ExecContext(ctx) // Part of a txn.
+		if err != nil {
+			if isDuplicateKeyError(err) {
+				return existingTupleError(ctx, dbInfo, store, writes, err)
+			}
+			return HandleSQLError(err)
+		}
+	}
+
 	if len(writes) > 0 || len(deletes) > 0 {
-		_, err := changelogBuilder.RunWith(txn).ExecContext(ctx) // Part of a txn.
+		_, err := changelogBuilder.RunWith(dbInfo.TxnRunner(txn)).ExecContext(ctx) // Part of a txn.
 		if err != nil {
 			return HandleSQLError(err)
 		}
 	}
 
-	if err := txn.Commit(); err != nil {
+	return nil
+}
+
+// existingTupleError returns the error of the first of the writes that already exists in the store,
+// after the duplicate key error err of their insertion. The tuples are looked up outside of the
+// transaction of the insertion, which Postgres aborts on the error. If none exists anymore, e.g.
+// because of a concurrent delete, err is returned as a collision.
+func existingTupleError(ctx context.Context, dbInfo *DBInfo, store string, writes storage.Writes, err error) error {
+	keys := make(sq.Or, 0, len(writes))
+	objectTypes := make([]string, 0, len(writes))
+	for _, tk := range writes {
+		objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
+		objectTypes = append(objectTypes, objectType)
+		keys = append(keys, sq.Eq{
+			"object_type": objectType,
+			"object_id":   objectID,
+			"relation":    tk.GetRelation(),
+			"_user":       tk.GetUser(),
+		})
+	}
+
+	rows, queryErr := dbInfo.stbl.
+		Select("object_type", "object_id", "relation", "_user").
+		From("tuple").
+		Where(sq.Eq{"store": store, "object_type": uniqueObjectTypes(objectTypes)}).
+		Where(keys).
+		QueryContext(ctx)
+	if queryErr != nil {
+		return HandleSQLError(queryErr)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]struct{}, len(writes))
+	for rows.Next() {
+		var objectType, objectID, relation, user string
+		if err := rows.Scan(&objectType, &objectID, &relation, &user); err != nil {
+			return HandleSQLError(err)
+		}
+		existing[tupleUtils.TupleKeyToString(tupleUtils.NewTupleKey(tupleUtils.BuildObject(objectType, objectID), relation, user))] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
 		return HandleSQLError(err)
 	}
 
-	return nil
+	for _, tk := range writes {
+		if _, ok := existing[tupleUtils.TupleKeyToString(tk)]; ok {
+			return storage.InvalidWriteInputError(tk, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE)
+		}
+	}
+
+	return HandleSQLError(err)
 }
 
 // WriteAuthorizationModel writes an authorization model for the given store.
@@ -552,6 +1167,361 @@ func FindLatestAuthorizationModel(
 	return constructAuthorizationModelFromSQLRows(rows)
 }
 
+// ChangeExists reports whether the changelog of the store has the change with the given ULID.
+func ChangeExists(ctx context.Context, dbInfo *DBInfo, store, id string) (bool, error) {
+	var found int
+	err := dbInfo.stbl.
+		Select("1").
+		From("changelog").
+		Where(sq.Eq{"store": store, "ulid": id}).
+		QueryRowContext(ctx).
+		Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, HandleSQLError(err)
+	}
+
+	return true, nil
+}
+
+// ReadStoreSettings returns the settings of the store, or empty settings if they were never written.
+func ReadStoreSettings(ctx context.Context, dbInfo *DBInfo, store string) (*storage.StoreSettings, error) {
+	var settings storage.StoreSettings
+	var checkQueryCacheTTLMs int64
+	err := dbInfo.stbl.
+		Select("default_authorization_model_id", "default_consistency", "check_query_cache_ttl_ms", "candidate_authorization_model_id").
+		From("store_settings").
+		Where(sq.Eq{"store": store}).
+		QueryRowContext(ctx).
+		Scan(&settings.DefaultAuthorizationModelID, &settings.DefaultConsistency, &checkQueryCacheTTLMs, &settings.CandidateAuthorizationModelID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &storage.StoreSettings{}, nil
+	}
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	settings.CheckQueryCacheTTL = time.Duration(checkQueryCacheTTLMs) * time.Millisecond
+	return &settings, nil
+}
+
+// ReadPlannerStatistics returns the planner statistics of the store, or nil if they were never written.
+func ReadPlannerStatistics(ctx context.Context, dbInfo *DBInfo, store string) ([]byte, error) {
+	var statistics []byte
+	err := dbInfo.stbl.
+		Select("statistics").
+		From("planner_statistics").
+		Where(sq.Eq{"store": store}).
+		QueryRowContext(ctx).
+		Scan(&statistics)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return statistics, nil
+}
+
+// ReadRelationUsage returns the usage of the relations of the store, sorted by object type and relation.
+func ReadRelationUsage(ctx context.Context, dbInfo *DBInfo, store string) ([]storage.RelationUsage, error) {
+	rows, err := dbInfo.stbl.
+		Select("object_type", "relation", "allowed_count", "denied_count", "last_evaluated_at").
+		From("relation_usage").
+		Where(sq.Eq{"store": store}).
+		OrderBy("object_type", "relation").
+		QueryContext(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	usage := []storage.RelationUsage{}
+	for rows.Next() {
+		var relation storage.RelationUsage
+		if err := rows.Scan(&relation.ObjectType, &relation.Relation, &relation.Allowed, &relation.Denied, &relation.LastEvaluatedAt); err != nil {
+			return nil, HandleSQLError(err)
+		}
+		relation.LastEvaluatedAt = relation.LastEvaluatedAt.UTC()
+		usage = append(usage, relation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return usage, nil
+}
+
+// ReadChangelogCheckpoint returns the continuation token of the changelog checkpoint of the consumer
+// of the store, or an empty token if it was never written.
+func ReadChangelogCheckpoint(ctx context.Context, dbInfo *DBInfo, store, consumer string) (string, error) {
+	var continuationToken string
+	err := dbInfo.stbl.
+		Select("continuation_token").
+		From("changelog_checkpoint").
+		Where(sq.Eq{"store": store, "consumer": consumer}).
+		QueryRowContext(ctx).
+		Scan(&continuationToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", HandleSQLError(err)
+	}
+
+	return continuationToken, nil
+}
+
+// UpdateStore overwrites the name of the store and returns the updated store, or
+// storage.ErrNotFound if the store doesn't exist or is deleted.
+func UpdateStore(ctx context.Context, dbInfo *DBInfo, store *openfgav1.Store) (*openfgav1.Store, error) {
+	_, err := dbInfo.stbl.
+		Update("store").
+		Set("name", store.GetName()).
+		Set("updated_at", dbInfo.sqlTime).
+		Where(sq.Eq{
+			"id":         store.GetId(),
+			"deleted_at": nil,
+		}).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	// the affected rows aren't checked because mysql doesn't count the rows that are unchanged
+	var id, name string
+	var createdAt, updatedAt time.Time
+	err = dbInfo.stbl.
+		Select("id", "name", "created_at", "updated_at").
+		From("store").
+		Where(sq.Eq{
+			"id":         store.GetId(),
+			"deleted_at": nil,
+		}).
+		QueryRowContext(ctx).
+		Scan(&id, &name, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, HandleSQLError(err)
+	}
+
+	return &openfgav1.Store{
+		Id:        id,
+		Name:      name,
+		CreatedAt: timestamppb.New(createdAt),
+		UpdatedAt: timestamppb.New(updatedAt),
+	}, nil
+}
+
+// WriteStoreLabels replaces the labels of the store in a transaction.
+func WriteStoreLabels(ctx context.Context, dbInfo *DBInfo, store string, labels map[string]string) error {
+	txn, err := dbInfo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	_, err = dbInfo.stbl.
+		Delete("store_label").
+		Where(sq.Eq{"store": store}).
+		RunWith(dbInfo.TxnRunner(txn)). // Part of a txn.
+		ExecContext(ctx)
+	if err != nil {
+		return HandleSQLError(err)
+	}
+
+	if len(labels) > 0 {
+		insertBuilder := dbInfo.stbl.
+			Insert("store_label").
+			Columns("store", "label_key", "label_value")
+		for key, value := range labels {
+			insertBuilder = insertBuilder.Values(store, key, value)
+		}
+
+		_, err = insertBuilder.
+			RunWith(dbInfo.TxnRunner(txn)). // Part of a txn.
+			ExecContext(ctx)
+		if err != nil {
+			return HandleSQLError(err)
+		}
+	}
+
+	if err := txn.Commit(); err != nil {
+		return HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// ReadStoreLabels returns the labels of the stores, by store ID. The stores without labels are not in the map.
+func ReadStoreLabels(ctx context.Context, dbInfo *DBInfo, stores []string) (map[string]map[string]string, error) {
+	labels := map[string]map[string]string{}
+	if len(stores) == 0 {
+		return labels, nil
+	}
+
+	rows, err := dbInfo.stbl.
+		Select("store", "label_key", "label_value").
+		From("store_label").
+		Where(sq.Eq{"store": stores}).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var store, key, value string
+		if err := rows.Scan(&store, &key, &value); err != nil {
+			return nil, HandleSQLError(err)
+		}
+
+		if labels[store] == nil {
+			labels[store] = map[string]string{}
+		}
+		labels[store][key] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	return labels, nil
+}
+
+// uniqueObjectTypes returns the sorted object types without duplicates. The lookups of several tuple
+// keys also filter by their object types, so that a tuple table partitioned by object type is
+// pruned to their partitions before the keys are matched.
+func uniqueObjectTypes(objectTypes []string) []string {
+	slices.Sort(objectTypes)
+	return slices.Compact(objectTypes)
+}
+
+// ReadTupleWriters returns the writers of the tuples of a store, in the order of the tuple keys.
+// The writer of a tuple written without a writer, or that doesn't exist, is empty.
+func ReadTupleWriters(ctx context.Context, dbInfo *DBInfo, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error) {
+	writers := make([]string, len(tupleKeys))
+	if len(tupleKeys) == 0 {
+		return writers, nil
+	}
+
+	conditions := make(sq.Or, 0, len(tupleKeys))
+	objectTypes := make([]string, 0, len(tupleKeys))
+	for _, tk := range tupleKeys {
+		objectType, objectID := tupleUtils.SplitObject(tk.GetObject())
+		objectTypes = append(objectTypes, objectType)
+		conditions = append(conditions, sq.Eq{
+			"object_type": objectType,
+			"object_id":   objectID,
+			"relation":    tk.GetRelation(),
+			"_user":       tk.GetUser(),
+		})
+	}
+
+	rows, err := dbInfo.stbl.
+		Select("object_type", "object_id", "relation", "_user", "created_by").
+		From("tuple").
+		Where(sq.Eq{"store": store, "object_type": uniqueObjectTypes(objectTypes)}).
+		Where(conditions).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	byTupleKey := map[string]string{}
+	for rows.Next() {
+		var objectType, objectID, relation, user string
+		var writer sql.NullString
+		if err := rows.Scan(&objectType, &objectID, &relation, &user, &writer); err != nil {
+			return nil, HandleSQLError(err)
+		}
+
+		tk := tupleUtils.NewTupleKey(tupleUtils.BuildObject(objectType, objectID), relation, user)
+		byTupleKey[tupleUtils.TupleKeyToString(tk)] = writer.String
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	for i, tk := range tupleKeys {
+		writers[i] = byTupleKey[tupleUtils.TupleKeyToString(tk)]
+	}
+
+	return writers, nil
+}
+
+// ReadChangeWriters returns the writers of changes of a store, in their order. A change is identified
+// by its tuple key, operation and timestamp. The writer of a change written without a writer, or
+// that doesn't exist, is empty.
+func ReadChangeWriters(ctx context.Context, dbInfo *DBInfo, store string, changes []*openfgav1.TupleChange) ([]string, error) {
+	writers := make([]string, len(changes))
+	if len(changes) == 0 {
+		return writers, nil
+	}
+
+	changeKey := func(tk *openfgav1.TupleKey, operation openfgav1.TupleOperation, insertedAt time.Time) string {
+		return fmt.Sprintf("%s|%d|%d", tupleUtils.TupleKeyToString(tk), operation, insertedAt.UnixMicro())
+	}
+
+	// the timestamps are compared once read, as their precision depends on the database
+	conditions := make(sq.Or, 0, len(changes))
+	for _, change := range changes {
+		objectType, objectID := tupleUtils.SplitObject(change.GetTupleKey().GetObject())
+		conditions = append(conditions, sq.Eq{
+			"object_type": objectType,
+			"object_id":   objectID,
+			"relation":    change.GetTupleKey().GetRelation(),
+			"_user":       change.GetTupleKey().GetUser(),
+			"operation":   change.GetOperation(),
+		})
+	}
+
+	rows, err := dbInfo.stbl.
+		Select("object_type", "object_id", "relation", "_user", "operation", "inserted_at", "created_by").
+		From("changelog").
+		Where(sq.Eq{"store": store}).
+		Where(conditions).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	byChange := map[string]string{}
+	for rows.Next() {
+		var objectType, objectID, relation, user string
+		var operation int
+		var insertedAt time.Time
+		var writer sql.NullString
+		if err := rows.Scan(&objectType, &objectID, &relation, &user, &operation, &insertedAt, &writer); err != nil {
+			return nil, HandleSQLError(err)
+		}
+
+		tk := tupleUtils.NewTupleKey(tupleUtils.BuildObject(objectType, objectID), relation, user)
+		byChange[changeKey(tk, openfgav1.TupleOperation(operation), insertedAt)] = writer.String
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, HandleSQLError(err)
+	}
+
+	for i, change := range changes {
+		writers[i] = byChange[changeKey(change.GetTupleKey(), change.GetOperation(), change.GetTimestamp().AsTime())]
+	}
+
+	return writers, nil
+}
+
 // ReadAuthorizationModel reads the model corresponding to store and model ID.
 func ReadAuthorizationModel(
 	ctx context.Context,
@@ -573,9 +1543,36 @@ func ReadAuthorizationModel(
 	return constructAuthorizationModelFromSQLRows(rows)
 }
 
+// WarmConnectionPool establishes conns connections of db at once, and pings them, before releasing
+// them to the pool of idle connections of db, which keeps them up to its maximum number of idle
+// connections. The connections that were established are released if one fails.
+func WarmConnectionPool(ctx context.Context, db *sql.DB, conns int) error {
+	established := make([]*sql.Conn, 0, conns)
+	defer func() {
+		for _, conn := range established {
+			_ = conn.Close()
+		}
+	}()
+
+	for i := 0; i < conns; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		established = append(established, conn)
+
+		if err := conn.PingContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // IsReady returns true if the connection to the datastore is successful
-// and the datastore has the latest migration applied.
-func IsReady(ctx context.Context, db *sql.DB) (storage.ReadinessStatus, error) {
+// and the datastore has the latest migration applied. The migrations of the
+// datastore in migrationsDir are used to report the pending migration phase.
+func IsReady(ctx context.Context, db *sql.DB, migrationsDir string) (storage.ReadinessStatus, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
@@ -588,14 +1585,21 @@ func IsReady(ctx context.Context, db *sql.DB) (storage.ReadinessStatus, error) {
 		return storage.ReadinessStatus{}, err
 	}
 
+	files, err := migrations.Collect(assets.EmbedMigrations, migrationsDir)
+	if err != nil {
+		return storage.ReadinessStatus{}, err
+	}
+
+	status := storage.ReadinessStatus{
+		IsReady:               true,
+		SchemaRevision:        revision,
+		PendingMigrationPhase: migrations.PendingPhase(files, revision),
+	}
+
 	if revision < build.MinimumSupportedDatastoreSchemaRevision {
-		return storage.ReadinessStatus{
-			Message: fmt.Sprintf("datastore requires migrations: at revision '%d', but requires '%d'. Run 'openfga migrate'.", revision, build.MinimumSupportedDatastoreSchemaRevision),
-			IsReady: false,
-		}, nil
+		status.IsReady = false
+		status.Message = fmt.Sprintf("datastore requires migrations: at revision '%d', but requires '%d'. Run 'openfga migrate'.", revision, build.MinimumSupportedDatastoreSchemaRevision)
 	}
 
-	return storage.ReadinessStatus{
-		IsReady: true,
-	}, nil
+	return status, nil
 }
@@ -1,18 +1,40 @@
 package sqlcommon
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/go-sql-driver/mysql"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/openfga/openfga/pkg/encrypter"
+	"github.com/openfga/openfga/pkg/middleware/requestid"
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/telemetry"
 )
 
 func TestHandleSQLError(t *testing.T) {
+	t.Run("context_errors_wrap_the_storage_errors", func(t *testing.T) {
+		err := HandleSQLError(fmt.Errorf("query failed: %w", context.DeadlineExceeded))
+		require.ErrorIs(t, err, storage.ErrDeadlineExceeded)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, err, HandleSQLError(err))
+
+		err = HandleSQLError(context.Canceled)
+		require.ErrorIs(t, err, storage.ErrCancelled)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
 	t.Run("duplicate_key_value_error_with_tuple_key_wraps_ErrInvalidWriteInput", func(t *testing.T) {
 		err := HandleSQLError(errors.New("duplicate key value"), &openfgav1.TupleKey{
 			Object:   "object",
@@ -56,3 +78,138 @@ func TestHandleSQLError(t *testing.T) {
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
 }
+
+// recordingRunner records the queries that it runs.
+type recordingRunner struct {
+	sq.StdSqlCtx
+	queries []string
+}
+
+func (r *recordingRunner) ExecContext(_ context.Context, query string, _ ...interface{}) (sql.Result, error) {
+	r.queries = append(r.queries, query)
+	return driver.RowsAffected(1), nil
+}
+
+func TestRequestIDComments(t *testing.T) {
+	runner := &recordingRunner{}
+	stbl := sq.StatementBuilder.RunWith(NewQueryRunner(runner, &Config{RequestIDComments: true}))
+
+	_, err := stbl.Delete("store").Where(sq.Eq{"id": "1"}).ExecContext(context.Background())
+	require.NoError(t, err)
+
+	_, err = stbl.Delete("store").Where(sq.Eq{"id": "1"}).ExecContext(requestid.NewContext(context.Background(), "abc"))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"DELETE FROM store WHERE id = ?",
+		"/* request_id=abc */ DELETE FROM store WHERE id = ?",
+	}, runner.queries)
+}
+
+func TestSQLCommenterComments(t *testing.T) {
+	runner := &recordingRunner{}
+	stbl := sq.StatementBuilder.RunWith(NewQueryRunner(runner, &Config{SQLCommenter: true, RequestIDComments: true}))
+
+	_, err := stbl.Delete("store").ExecContext(context.Background())
+	require.NoError(t, err)
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = telemetry.ContextWithRPCInfo(ctx, telemetry.RPCInfo{Service: "openfga.v1.OpenFGAService", Method: "Check"})
+	ctx = requestid.NewContext(ctx, "a b*/")
+
+	_, err = stbl.Delete("store").ExecContext(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"DELETE FROM store /*action='unknown',controller='unknown'*/",
+		"DELETE FROM store /*action='Check',controller='openfga.v1.OpenFGAService',request_id='a%20b%2A%2F'," +
+			"traceparent='00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01'*/",
+	}, runner.queries)
+}
+
+func TestWhereReadChangesFilter(t *testing.T) {
+	sb := WhereReadChangesFilter(sq.Select("ulid").From("changelog"), storage.ReadChangesFilter{
+		ObjectType:     "document",
+		ObjectIDPrefix: `org_1%\`,
+		Relation:       "viewer",
+		User:           "user:anne",
+	})
+
+	query, args, err := sb.ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "SELECT ulid FROM changelog WHERE object_type = ? AND object_id LIKE ? AND relation = ? AND _user = ?", query)
+	require.Equal(t, []interface{}{"document", `org\_1\%\\%`, "viewer", "user:anne"}, args)
+
+	query, _, err = WhereReadChangesFilter(sq.Select("ulid").From("changelog"), storage.ReadChangesFilter{}).ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "SELECT ulid FROM changelog", query)
+}
+
+func TestWhereListStoresFilter(t *testing.T) {
+	createdAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdBefore := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	sb := WhereListStoresFilter(sq.Select("id").From("store"), storage.ListStoresFilter{
+		NameContains:  "Pay_100%",
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+		Labels:        map[string]string{"region": "eu", "env": "prod"},
+	})
+
+	query, args, err := sb.ToSql()
+	require.NoError(t, err)
+
+	labelCondition := "EXISTS (SELECT 1 FROM store_label WHERE store_label.store = store.id AND store_label.label_key = ? AND store_label.label_value = ?)"
+	require.Equal(t, "SELECT id FROM store WHERE LOWER(name) LIKE ? AND created_at >= ? AND created_at < ? AND "+
+		labelCondition+" AND "+labelCondition, query)
+	require.Equal(t, []interface{}{`%pay\_100\%%`, createdAfter, createdBefore, "env", "prod", "region", "eu"}, args)
+
+	query, _, err = WhereListStoresFilter(sq.Select("id").From("store"), storage.ListStoresFilter{}).ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "SELECT id FROM store", query)
+}
+
+func TestConditionContextEncryption(t *testing.T) {
+	conditionContext, err := structpb.NewStruct(map[string]interface{}{"email": "anne@example.com"})
+	require.NoError(t, err)
+	condition := &openfgav1.RelationshipCondition{Name: "condx", Context: conditionContext}
+
+	keyWrapper, err := encrypter.NewLocalKeyWrapper("key")
+	require.NoError(t, err)
+	enc := encrypter.NewEnvelopeEncrypter(keyWrapper)
+	defer enc.Close()
+
+	_, plain, err := marshalRelationshipCondition(condition, nil)
+	require.NoError(t, err)
+	require.Contains(t, string(plain), "anne@example.com")
+
+	name, encrypted, err := marshalRelationshipCondition(condition, enc)
+	require.NoError(t, err)
+	require.Equal(t, "condx", name)
+	require.NotContains(t, string(encrypted), "anne@example.com")
+
+	got, err := UnmarshalConditionContext(encrypted, enc)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(conditionContext, got))
+
+	// condition contexts stored before encryption was enabled stay readable
+	got, err = UnmarshalConditionContext(plain, enc)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(conditionContext, got))
+
+	_, err = UnmarshalConditionContext(encrypted, nil)
+	require.Error(t, err)
+
+	got, err = UnmarshalConditionContext(nil, enc)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
@@ -31,6 +31,8 @@ const (
 	DefaultPageSize = 50
 
 	relationshipTupleReaderCtxKey ctxKey = "relationship-tuple-reader-context-key"
+	changeULIDCtxKey              ctxKey = "change-ulid-context-key"
+	writerCtxKey                  ctxKey = "writer-context-key"
 )
 
 // ContextWithRelationshipTupleReader sets the provided [[RelationshipTupleReader]]
@@ -53,6 +55,44 @@ func RelationshipTupleReaderFromContext(ctx context.Context) (RelationshipTupleR
 	return reader, ok
 }
 
+// ContextWithChangeULID returns a context whose Write uses the provided ULID as the ULID of its first
+// change, so that the caller can then look the write up with [ChangelogBackend].ChangeExists.
+func ContextWithChangeULID(parent context.Context, id string) context.Context {
+	return ContextWithChangeULIDs(parent, map[int]string{0: id})
+}
+
+// ChangeULIDFromContext returns the ULID of the first change set with [ContextWithChangeULID], or an
+// empty string.
+func ChangeULIDFromContext(ctx context.Context) string {
+	return ChangeULIDsFromContext(ctx)[0]
+}
+
+// ContextWithChangeULIDs returns a context whose Write uses the provided ULIDs as the ULIDs of the
+// changes at their index, the deletes first and then the writes, e.g. so that a Write that coalesces
+// several writes keeps the ULID of the first change of each of them. See [ContextWithChangeULID].
+func ContextWithChangeULIDs(parent context.Context, ids map[int]string) context.Context {
+	return context.WithValue(parent, changeULIDCtxKey, ids)
+}
+
+// ChangeULIDsFromContext returns the ULIDs set with [ContextWithChangeULIDs] by the index of their
+// change, or nil.
+func ChangeULIDsFromContext(ctx context.Context) map[int]string {
+	ids, _ := ctx.Value(changeULIDCtxKey).(map[int]string)
+	return ids
+}
+
+// ContextWithWriter returns a context whose Write records the provided identity, e.g. the subject of
+// the authenticated request, as the writer of its tuples and changes. See [TupleWritersBackend].
+func ContextWithWriter(parent context.Context, writer string) context.Context {
+	return context.WithValue(parent, writerCtxKey, writer)
+}
+
+// WriterFromContext returns the writer set with [ContextWithWriter], or an empty string.
+func WriterFromContext(ctx context.Context) string {
+	writer, _ := ctx.Value(writerCtxKey).(string)
+	return writer
+}
+
 // PaginationOptions holds the settings for pagination in data retrieval operations. It defines
 // the number of items to be included on each page (PageSize) and a marker from where to start
 // the page (From).
@@ -103,6 +143,8 @@ type RelationshipTupleReader interface {
 	// ReadPage functions similarly to Read but includes support for pagination. It takes
 	// mandatory pagination options (pageSize can be zero :/)
 	// and returns a slice of tuples along with a continuation token. This token can be used for retrieving subsequent pages of data.
+	// If the `tupleKey` has a condition name, only the tuples with that condition are returned, whatever
+	// their condition context.
 	ReadPage(
 		ctx context.Context,
 		store string,
@@ -180,6 +222,11 @@ type ReadUsersetTuplesFilter struct {
 	Object                      string                         // Required.
 	Relation                    string                         // Required.
 	AllowedUserTypeRestrictions []*openfgav1.RelationReference // Optional.
+
+	// PageSize is optional. If set, the datastore may read the tuples in pages of PageSize tuples, so
+	// that the pages after the last tuple read from the iterator are never read, e.g. for an object
+	// with a huge number of usersets. The iterator returns all the tuples either way.
+	PageSize int
 }
 
 // AuthorizationModelReadBackend provides a read interface for managing type definitions.
@@ -217,7 +264,36 @@ type StoresBackend interface {
 	CreateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error)
 	DeleteStore(ctx context.Context, id string) error
 	GetStore(ctx context.Context, id string) (*openfgav1.Store, error)
-	ListStores(ctx context.Context, paginationOptions PaginationOptions) ([]*openfgav1.Store, []byte, error)
+
+	// UpdateStore overwrites the name of a store and returns the updated store.
+	// It returns ErrNotFound if the store doesn't exist or is deleted.
+	UpdateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error)
+
+	// ListStores returns the stores that match the filter, in the order of their IDs.
+	ListStores(ctx context.Context, filter ListStoresFilter, paginationOptions PaginationOptions) ([]*openfgav1.Store, []byte, error)
+
+	// WriteStoreLabels overwrites the labels of a store. Empty labels remove them all.
+	WriteStoreLabels(ctx context.Context, store string, labels map[string]string) error
+
+	// ReadStoreLabels returns the labels of the stores, by store ID.
+	// The stores without labels must not be in the returned map.
+	ReadStoreLabels(ctx context.Context, stores []string) (map[string]map[string]string, error)
+}
+
+// ListStoresFilter restricts the stores returned by ListStores. The empty fields don't restrict them.
+// A continuation token must be used with the same filter it was returned for.
+type ListStoresFilter struct {
+	// NameContains only matches the stores whose name contains it, ignoring the case.
+	NameContains string
+
+	// CreatedAfter only matches the stores created at or after it.
+	CreatedAfter time.Time
+
+	// CreatedBefore only matches the stores created before it.
+	CreatedBefore time.Time
+
+	// Labels only matches the stores that have all of these labels, with the same values.
+	Labels map[string]string
 }
 
 // AssertionsBackend is an interface that defines the set of methods for reading and writing assertions.
@@ -230,20 +306,174 @@ type AssertionsBackend interface {
 	ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error)
 }
 
+// StoreSettings are the settings of a store. They apply to the requests of the store that omit the
+// corresponding values. The empty fields use the defaults of the server.
+type StoreSettings struct {
+	// DefaultAuthorizationModelID is the ID of the model that the requests without a model ID use,
+	// instead of the latest model of the store.
+	DefaultAuthorizationModelID string
+
+	// DefaultConsistency is the consistency of the Check and ListObjects requests that don't set one.
+	DefaultConsistency string
+
+	// CheckQueryCacheTTL overrides the TTL that the Check results of the store are cached with.
+	CheckQueryCacheTTL time.Duration
+
+	// CandidateAuthorizationModelID is the ID of a model that the Check requests of the store are
+	// also evaluated against when the Check canary is enabled, to compare its results with those of
+	// the model of the requests before promoting it.
+	CandidateAuthorizationModelID string
+}
+
+// StoreSettingsBackend is an interface for reading and writing the settings of the stores.
+type StoreSettingsBackend interface {
+	// ReadStoreSettings returns the settings of a store.
+	// If no settings were ever written, it must return empty settings.
+	ReadStoreSettings(ctx context.Context, store string) (*StoreSettings, error)
+
+	// WriteStoreSettings overwrites the settings of a store.
+	WriteStoreSettings(ctx context.Context, store string, settings *StoreSettings) error
+}
+
+// PlannerStatisticsBackend is an interface for persisting the statistics of the Check planner of the
+// stores. The statistics are opaque to the datastore.
+type PlannerStatisticsBackend interface {
+	// ReadPlannerStatistics returns the planner statistics of a store.
+	// If no statistics were ever written, it must return nil.
+	ReadPlannerStatistics(ctx context.Context, store string) ([]byte, error)
+
+	// WritePlannerStatistics overwrites the planner statistics of a store.
+	WritePlannerStatistics(ctx context.Context, store string, statistics []byte) error
+}
+
+// RelationUsage is how often a relation of a store was evaluated by Check, and with what result.
+type RelationUsage struct {
+	ObjectType string
+	Relation   string
+
+	// Allowed and Denied are the numbers of evaluations of the relation that were allowed and denied.
+	Allowed uint64
+	Denied  uint64
+
+	// LastEvaluatedAt is the time of the last evaluation of the relation.
+	LastEvaluatedAt time.Time
+}
+
+// RelationUsageBackend is an interface for persisting the usage of the relations of the stores. The
+// usage is added up, so that each server adds the evaluations it observed since it last did.
+type RelationUsageBackend interface {
+	// AddRelationUsage adds the evaluations to the usage of the relations of a store. The last
+	// evaluation of a relation becomes the latest of the stored one and the added one.
+	AddRelationUsage(ctx context.Context, store string, usage []RelationUsage) error
+
+	// ReadRelationUsage returns the usage of the relations of a store, sorted by object type and
+	// relation. If no usage was ever added, it must return an empty list.
+	ReadRelationUsage(ctx context.Context, store string) ([]RelationUsage, error)
+}
+
+// ChangelogCheckpointBackend is an interface for persisting the positions of the consumers of the
+// changelogs of the stores, e.g. of the change publisher, so that they resume where they stopped.
+type ChangelogCheckpointBackend interface {
+	// ReadChangelogCheckpoint returns the continuation token of the changelog of a store that the
+	// consumer checkpointed last. If it never did, it must return an empty token.
+	ReadChangelogCheckpoint(ctx context.Context, store, consumer string) (string, error)
+
+	// WriteChangelogCheckpoint overwrites the checkpoint of the consumer of the changelog of a store
+	// with a continuation token of ReadChanges.
+	WriteChangelogCheckpoint(ctx context.Context, store, consumer, continuationToken string) error
+}
+
+// ReadChangesFilter restricts the changes returned by ReadChanges. The empty fields don't restrict them.
+// A continuation token must be used with the same filter it was returned for.
+type ReadChangesFilter struct {
+	ObjectType string
+
+	// ObjectIDPrefix only matches the objects whose ID starts with it. It requires ObjectType.
+	ObjectIDPrefix string
+
+	Relation string
+
+	// User only matches the changes of tuples with exactly this user, e.g. 'user:anne' or 'group:eng#member'.
+	User string
+
+	// StartTime only matches the changes that occurred at or after it.
+	StartTime time.Time
+}
+
 // ChangelogBackend is an interface for interacting with and managing changelogs.
 type ChangelogBackend interface {
 	// ReadChanges returns the writes and deletes that have occurred for tuples within a store,
 	// in the order that they occurred.
-	// You can optionally provide a filter to filter out changes for objects of a specific type.
+	// You can optionally provide a filter to only return the changes of some objects, relations or users.
 	// The horizonOffset should be specified using a unit no more granular than a millisecond
 	// and should be interpreted as a millisecond duration.
 	ReadChanges(
 		ctx context.Context,
-		store,
-		objectType string,
+		store string,
+		filter ReadChangesFilter,
 		paginationOptions PaginationOptions,
 		horizonOffset time.Duration,
 	) ([]*openfgav1.TupleChange, []byte, error)
+
+	// ChangeExists reports whether the datastore can read the change of a store with the given ULID,
+	// e.g. the first change of a write whose ULID was set with [ContextWithChangeULID]. ULIDs are not
+	// ordered by commit, so a datastore that serves reads from a lagging replica has read a write
+	// only once it reads the change of that write itself.
+	ChangeExists(ctx context.Context, store, id string) (bool, error)
+}
+
+// TupleWritersBackend is an interface for reading the writers of the tuples and of the changes, i.e.
+// the identities set with [ContextWithWriter] in the context of the Write of the tuples and changes.
+type TupleWritersBackend interface {
+	// ReadTupleWriters returns the writers of the tuples of a store, in the order of the tuple keys.
+	// The writer of a tuple that was written without a writer, or that doesn't exist, is empty.
+	ReadTupleWriters(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error)
+
+	// ReadChangeWriters returns the writers of changes returned by ReadChanges, in their order. A
+	// change is identified by its tuple key, operation and timestamp. The writer of a change that was
+	// written without a writer, or that doesn't exist, is empty.
+	ReadChangeWriters(ctx context.Context, store string, changes []*openfgav1.TupleChange) ([]string, error)
+}
+
+// TupleChangeNotifier is implemented by the datastores that notify the writes of tuples to all the
+// servers sharing the datastore, so that they can invalidate what they cached about the tuples.
+type TupleChangeNotifier interface {
+	// WatchTupleChanges calls onChange with the store of every write of tuples, by any server, until
+	// ctx is done. onChange is called with an empty store when writes may have been missed, e.g.
+	// while the datastore was reconnecting, so that any store must be assumed to have changed.
+	WatchTupleChanges(ctx context.Context, onChange func(store string)) error
+}
+
+// ConnectionPoolWarmer is implemented by the datastores with a pool of connections, so that the
+// connections can be established before the server serves its first requests.
+type ConnectionPoolWarmer interface {
+	// WarmConnectionPool establishes up to conns connections to the datastore at once, and keeps
+	// them in the pool of idle connections, up to its maximum size.
+	WarmConnectionPool(ctx context.Context, conns int) error
+}
+
+// StoreWrite is the deletes and the writes of the tuples of a store, see [MultiStoreWriteBackend].
+type StoreWrite struct {
+	Store   string
+	Deletes Deletes
+	Writes  Writes
+
+	// ChangeULIDs are the ULIDs of the changes of the write by their index, like the ULIDs set with
+	// [ContextWithChangeULIDs]. The ULIDs of the changes are unique across the stores, so each store
+	// has its own.
+	ChangeULIDs map[int]string
+}
+
+// MultiStoreWriteBackend is an interface for writing the tuples of several stores atomically, e.g. to
+// keep mirrored stores in sync.
+type MultiStoreWriteBackend interface {
+	// WriteStores applies the deletes and the writes of each store like Write, in a single
+	// transaction: either all of them are applied, or none. Each store appears at most once, and the
+	// ULIDs of its changes are its ChangeULIDs, not the ULIDs set with [ContextWithChangeULIDs].
+	// If there are more than MaxTuplesPerWrite tuples in total, it must return ErrExceededWriteBatchLimit.
+	// If the stores can't be written in a single transaction, e.g. because they are kept in different
+	// databases, it must return ErrMultiStoreWriteNotSupported without applying any of the writes.
+	WriteStores(ctx context.Context, writes []StoreWrite) error
 }
 
 // OpenFGADatastore is an interface that defines a set of methods for interacting
@@ -254,6 +484,12 @@ type OpenFGADatastore interface {
 	StoresBackend
 	AssertionsBackend
 	ChangelogBackend
+	StoreSettingsBackend
+	PlannerStatisticsBackend
+	RelationUsageBackend
+	TupleWritersBackend
+	ChangelogCheckpointBackend
+	MultiStoreWriteBackend
 
 	// IsReady reports whether the datastore is ready to accept traffic.
 	IsReady(ctx context.Context) (ReadinessStatus, error)
@@ -268,4 +504,12 @@ type ReadinessStatus struct {
 	Message string
 
 	IsReady bool
+
+	// SchemaRevision is the revision of the datastore schema, for datastores that are migrated
+	// with 'openfga migrate'. It is 0 for datastores without a schema.
+	SchemaRevision int64
+
+	// PendingMigrationPhase is the phase of 'openfga migrate' that applies the next migrations of the
+	// datastore schema ('expand' or 'contract'). It is empty if the schema is up to date.
+	PendingMigrationPhase string
 }
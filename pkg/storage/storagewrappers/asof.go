@@ -0,0 +1,245 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// asOfReplayPageSize is the number of changes read at once while replaying the changelog.
+const asOfReplayPageSize = 1000
+
+// ErrTooManyChanges is returned by NewAsOfTupleReader when the changelog of the store has more
+// changes to replay than allowed.
+var ErrTooManyChanges = errors.New("too many changes to replay")
+
+var _ storage.RelationshipTupleReader = (*asOfTupleReader)(nil)
+
+// asOfTupleReader reads the tuples of a store as they were at a past time. Its reads ignore the
+// store they are given, as it only has the tuples of one store.
+type asOfTupleReader struct {
+	// tuples are sorted by object, relation and user.
+	tuples []*openfgav1.Tuple
+
+	// byObjectRelation indexes the tuples by their object and relation.
+	byObjectRelation map[string][]*openfgav1.Tuple
+}
+
+// NewAsOfTupleReader returns a [storage.RelationshipTupleReader] that reads the tuples of the store
+// as they were at asOf, which it reconstructs by replaying the changelog of the store up to asOf
+// included. The tuples are only as complete as the changelog: the tuples whose writes are no longer
+// in the changelog are missing. It returns ErrTooManyChanges if more than maxChanges changes must be
+// replayed, 0 meaning no limit.
+func NewAsOfTupleReader(
+	ctx context.Context,
+	changelog storage.ChangelogBackend,
+	store string,
+	asOf time.Time,
+	maxChanges int,
+) (storage.RelationshipTupleReader, error) {
+	tuples := map[string]*openfgav1.Tuple{}
+	replayed := 0
+	from := ""
+
+Replay:
+	for {
+		changes, token, err := changelog.ReadChanges(ctx, store, storage.ReadChangesFilter{}, storage.PaginationOptions{
+			PageSize: asOfReplayPageSize,
+			From:     from,
+		}, 0)
+		if errors.Is(err, storage.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, change := range changes {
+			if change.GetTimestamp().AsTime().After(asOf) {
+				break Replay
+			}
+
+			replayed++
+			if maxChanges > 0 && replayed > maxChanges {
+				return nil, fmt.Errorf("%w: more than %d changes up to %s", ErrTooManyChanges, maxChanges, asOf.Format(time.RFC3339Nano))
+			}
+
+			tk := change.GetTupleKey()
+			key := tuple.TupleKeyToString(tuple.NewTupleKey(tk.GetObject(), tk.GetRelation(), tk.GetUser()))
+			switch change.GetOperation() {
+			case openfgav1.TupleOperation_TUPLE_OPERATION_WRITE:
+				tuples[key] = &openfgav1.Tuple{Key: tk, Timestamp: change.GetTimestamp()}
+			case openfgav1.TupleOperation_TUPLE_OPERATION_DELETE:
+				delete(tuples, key)
+			}
+		}
+
+		if len(changes) < asOfReplayPageSize || len(token) == 0 || string(token) == from {
+			break
+		}
+		from = string(token)
+	}
+
+	r := &asOfTupleReader{
+		tuples:           make([]*openfgav1.Tuple, 0, len(tuples)),
+		byObjectRelation: map[string][]*openfgav1.Tuple{},
+	}
+	for _, t := range tuples {
+		r.tuples = append(r.tuples, t)
+	}
+	sort.Slice(r.tuples, func(i, j int) bool {
+		return tuple.TupleKeyToString(r.tuples[i].GetKey()) < tuple.TupleKeyToString(r.tuples[j].GetKey())
+	})
+	for _, t := range r.tuples {
+		key := tuple.ToObjectRelationString(t.GetKey().GetObject(), t.GetKey().GetRelation())
+		r.byObjectRelation[key] = append(r.byObjectRelation[key], t)
+	}
+
+	return r, nil
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (r *asOfTupleReader) Read(ctx context.Context, store string, tk *openfgav1.TupleKey) (storage.TupleIterator, error) {
+	return storage.NewStaticTupleIterator(r.match(tk)), nil
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (r *asOfTupleReader) ReadPage(
+	ctx context.Context,
+	store string,
+	tk *openfgav1.TupleKey,
+	opts storage.PaginationOptions,
+) ([]*openfgav1.Tuple, []byte, error) {
+	matches := r.match(tk)
+
+	from := 0
+	if opts.From != "" {
+		var err error
+		if from, err = strconv.Atoi(opts.From); err != nil || from < 0 {
+			return nil, nil, storage.ErrInvalidContinuationToken
+		}
+	}
+	matches = matches[min(from, len(matches)):]
+
+	if opts.PageSize > 0 && opts.PageSize < len(matches) {
+		return matches[:opts.PageSize], []byte(strconv.Itoa(from + opts.PageSize)), nil
+	}
+
+	return matches, nil, nil
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (r *asOfTupleReader) ReadUserTuple(ctx context.Context, store string, tk *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	for _, t := range r.byObjectRelation[tuple.ToObjectRelationString(tk.GetObject(), tk.GetRelation())] {
+		if t.GetKey().GetUser() == tk.GetUser() {
+			return t, nil
+		}
+	}
+
+	return nil, storage.ErrNotFound
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (r *asOfTupleReader) ReadUsersetTuples(
+	ctx context.Context,
+	store string,
+	filter storage.ReadUsersetTuplesFilter,
+) (storage.TupleIterator, error) {
+	var matches []*openfgav1.Tuple
+	for _, t := range r.byObjectRelation[tuple.ToObjectRelationString(filter.Object, filter.Relation)] {
+		user := t.GetKey().GetUser()
+		if tuple.GetUserTypeFromUser(user) != tuple.UserSet {
+			continue
+		}
+
+		if len(filter.AllowedUserTypeRestrictions) == 0 {
+			matches = append(matches, t)
+			continue
+		}
+
+		userObject, userRelation := tuple.SplitObjectRelation(user)
+		for _, allowed := range filter.AllowedUserTypeRestrictions {
+			if allowed.GetType() == tuple.GetType(userObject) && allowed.GetRelation() == userRelation {
+				matches = append(matches, t)
+				break
+			}
+		}
+	}
+
+	return storage.NewStaticTupleIterator(matches), nil
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (r *asOfTupleReader) ReadStartingWithUser(
+	ctx context.Context,
+	store string,
+	filter storage.ReadStartingWithUserFilter,
+) (storage.TupleIterator, error) {
+	users := make(map[string]struct{}, len(filter.UserFilter))
+	for _, u := range filter.UserFilter {
+		user := u.GetObject()
+		if u.GetRelation() != "" {
+			user = tuple.ToObjectRelationString(user, u.GetRelation())
+		}
+		users[user] = struct{}{}
+	}
+
+	var matches []*openfgav1.Tuple
+	for _, t := range r.tuples {
+		tk := t.GetKey()
+		if tuple.GetType(tk.GetObject()) != filter.ObjectType || tk.GetRelation() != filter.Relation {
+			continue
+		}
+
+		if _, ok := users[tk.GetUser()]; ok {
+			matches = append(matches, t)
+		}
+	}
+
+	return storage.NewStaticTupleIterator(matches), nil
+}
+
+// match returns the tuples that match the tuple key, whose empty fields match any value and whose
+// object without an ID matches the objects of its type.
+func (r *asOfTupleReader) match(tk *openfgav1.TupleKey) []*openfgav1.Tuple {
+	objectType, objectID := tuple.SplitObject(tk.GetObject())
+
+	candidates := r.tuples
+	if objectID != "" && tk.GetRelation() != "" {
+		candidates = r.byObjectRelation[tuple.ToObjectRelationString(tk.GetObject(), tk.GetRelation())]
+	}
+
+	var matches []*openfgav1.Tuple
+	for _, t := range candidates {
+		key := t.GetKey()
+		if objectType != "" {
+			if objectID == "" && tuple.GetType(key.GetObject()) != objectType {
+				continue
+			}
+			if objectID != "" && key.GetObject() != tk.GetObject() {
+				continue
+			}
+		}
+		if tk.GetRelation() != "" && key.GetRelation() != tk.GetRelation() {
+			continue
+		}
+		if tk.GetUser() != "" && key.GetUser() != tk.GetUser() {
+			continue
+		}
+		if tk.GetCondition().GetName() != "" && key.GetCondition().GetName() != tk.GetCondition().GetName() {
+			continue
+		}
+
+		matches = append(matches, t)
+	}
+
+	return matches
+}
@@ -0,0 +1,121 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestAsOfTupleReader(t *testing.T) {
+	const storeID = "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8Q"
+	ctx := context.Background()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	anne := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	bob := tuple.NewTupleKey("document:1", "viewer", "user:bob")
+	group := tuple.NewTupleKey("document:2", "viewer", "group:eng#member")
+
+	require.NoError(t, ds.Write(ctx, storeID, nil, []*openfgav1.TupleKey{anne, group}))
+	time.Sleep(time.Millisecond)
+	beforeChanges := time.Now()
+	time.Sleep(time.Millisecond)
+
+	require.NoError(t, ds.Write(ctx, storeID, []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(anne)}, []*openfgav1.TupleKey{bob}))
+
+	readUsers := func(t *testing.T, reader storage.RelationshipTupleReader, tk *openfgav1.TupleKey) []string {
+		iter, err := reader.Read(ctx, storeID, tk)
+		require.NoError(t, err)
+		defer iter.Stop()
+
+		var users []string
+		for {
+			next, err := iter.Next(ctx)
+			if err != nil {
+				require.ErrorIs(t, err, storage.ErrIteratorDone)
+				return users
+			}
+			users = append(users, next.GetKey().GetUser())
+		}
+	}
+
+	t.Run("reads_the_tuples_at_the_time", func(t *testing.T) {
+		reader, err := NewAsOfTupleReader(ctx, ds, storeID, beforeChanges, 0)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"user:anne"}, readUsers(t, reader, tuple.NewTupleKey("document:1", "viewer", "")))
+		require.Equal(t, []string{"user:anne", "group:eng#member"}, readUsers(t, reader, tuple.NewTupleKey("document:", "", "")))
+
+		tk, err := reader.ReadUserTuple(ctx, storeID, anne)
+		require.NoError(t, err)
+		require.NotNil(t, tk.GetTimestamp())
+
+		_, err = reader.ReadUserTuple(ctx, storeID, bob)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		iter, err := reader.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{
+			Object:                      "document:2",
+			Relation:                    "viewer",
+			AllowedUserTypeRestrictions: []*openfgav1.RelationReference{{Type: "group", RelationOrWildcard: &openfgav1.RelationReference_Relation{Relation: "member"}}},
+		})
+		require.NoError(t, err)
+		usersetTuple, err := iter.Next(ctx)
+		require.NoError(t, err)
+		require.Equal(t, group.GetUser(), usersetTuple.GetKey().GetUser())
+
+		iter, err = reader.ReadStartingWithUser(ctx, storeID, storage.ReadStartingWithUserFilter{
+			ObjectType: "document",
+			Relation:   "viewer",
+			UserFilter: []*openfgav1.ObjectRelation{{Object: "user:anne"}},
+		})
+		require.NoError(t, err)
+		startingWithUser, err := iter.Next(ctx)
+		require.NoError(t, err)
+		require.Equal(t, anne.GetObject(), startingWithUser.GetKey().GetObject())
+	})
+
+	t.Run("reads_the_latest_tuples_now", func(t *testing.T) {
+		reader, err := NewAsOfTupleReader(ctx, ds, storeID, time.Now(), 0)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"user:bob"}, readUsers(t, reader, tuple.NewTupleKey("document:1", "viewer", "")))
+	})
+
+	t.Run("reads_nothing_before_the_first_change", func(t *testing.T) {
+		reader, err := NewAsOfTupleReader(ctx, ds, storeID, time.Now().Add(-time.Hour), 0)
+		require.NoError(t, err)
+
+		require.Empty(t, readUsers(t, reader, tuple.NewTupleKey("document:1", "", "")))
+	})
+
+	t.Run("too_many_changes", func(t *testing.T) {
+		_, err := NewAsOfTupleReader(ctx, ds, storeID, time.Now(), 3)
+		require.ErrorIs(t, err, ErrTooManyChanges)
+
+		_, err = NewAsOfTupleReader(ctx, ds, storeID, beforeChanges, 3)
+		require.NoError(t, err)
+	})
+
+	t.Run("read_page", func(t *testing.T) {
+		reader, err := NewAsOfTupleReader(ctx, ds, storeID, beforeChanges, 0)
+		require.NoError(t, err)
+
+		tuples, token, err := reader.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, storage.PaginationOptions{PageSize: 1})
+		require.NoError(t, err)
+		require.Len(t, tuples, 1)
+		require.NotEmpty(t, token)
+
+		tuples, token, err = reader.ReadPage(ctx, storeID, &openfgav1.TupleKey{}, storage.PaginationOptions{PageSize: 1, From: string(token)})
+		require.NoError(t, err)
+		require.Len(t, tuples, 1)
+		require.Empty(t, token)
+	})
+}
@@ -7,9 +7,11 @@ import (
 
 	"github.com/karlseguin/ccache/v3"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/telemetry"
 )
 
 const ttl = time.Hour * 168
@@ -37,6 +39,8 @@ func (c *cachedOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, sto
 	cacheKey := fmt.Sprintf("%s:%s", storeID, modelID)
 	cachedEntry := c.cache.Get(cacheKey)
 
+	span := trace.SpanFromContext(ctx)
+	telemetry.TraceCacheLookup(span, "authorization_model", cachedEntry != nil)
 	if cachedEntry != nil {
 		return cachedEntry.Value(), nil
 	}
@@ -0,0 +1,571 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	// defaultCircuitBreakerFailureRatio is the ratio of failed datastore calls of a window after
+	// which the circuit breaker opens.
+	defaultCircuitBreakerFailureRatio = 0.5
+
+	// defaultCircuitBreakerMinRequests is the minimum number of datastore calls of a window before
+	// the circuit breaker can open, so that a few failures of an idle server don't open it.
+	defaultCircuitBreakerMinRequests = 20
+
+	// defaultCircuitBreakerWindow is the duration of the windows the datastore calls are counted in.
+	defaultCircuitBreakerWindow = 10 * time.Second
+
+	// defaultCircuitBreakerOpenDuration is how long the circuit breaker stays open before a
+	// datastore call is let through to probe the datastore.
+	defaultCircuitBreakerOpenDuration = 5 * time.Second
+)
+
+// circuitState is the state of a circuit breaker. Its value is reported by the state gauge.
+type circuitState int
+
+const (
+	// circuitClosed lets all datastore calls through.
+	circuitClosed circuitState = iota
+
+	// circuitOpen rejects all datastore calls.
+	circuitOpen
+
+	// circuitHalfOpen lets a single datastore call through to probe whether the datastore recovered.
+	circuitHalfOpen
+)
+
+var (
+	circuitBreakerStateGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_circuit_breaker_state",
+		Help:      "The state of the datastore circuit breaker: 0 if it is closed, 1 if it is open and 2 if it is half-open.",
+	})
+
+	circuitBreakerTripsCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_circuit_breaker_trips_total",
+		Help:      "The total number of times the datastore circuit breaker opened.",
+	})
+
+	circuitBreakerRejectedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_circuit_breaker_rejected_total",
+		Help:      "The total number of datastore calls that were rejected because the datastore circuit breaker was open, by datastore method.",
+	}, []string{"method"})
+)
+
+var _ storage.OpenFGADatastore = (*circuitBreakerOpenFGADatastore)(nil)
+
+// CircuitBreakerOption defines an option that can be used to change the behavior of the
+// circuit breaker datastore wrapper.
+type CircuitBreakerOption func(c *circuitBreakerOpenFGADatastore)
+
+// WithCircuitBreakerFailureRatio sets the ratio (between 0 and 1) of the datastore calls of a
+// window that must fail for the circuit breaker to open.
+func WithCircuitBreakerFailureRatio(ratio float64) CircuitBreakerOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.failureRatio = ratio
+	}
+}
+
+// WithCircuitBreakerMinRequests sets the minimum number of datastore calls of a window before the
+// circuit breaker can open.
+func WithCircuitBreakerMinRequests(n int) CircuitBreakerOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.minRequests = n
+	}
+}
+
+// WithCircuitBreakerWindow sets the duration of the windows the datastore calls and their
+// failures are counted in.
+func WithCircuitBreakerWindow(window time.Duration) CircuitBreakerOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.window = window
+	}
+}
+
+// WithCircuitBreakerLatencyThreshold counts the datastore calls that take longer than threshold as
+// failed, even if they succeed. A value of 0, which is the default, only counts the errors.
+func WithCircuitBreakerLatencyThreshold(threshold time.Duration) CircuitBreakerOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.latencyThreshold = threshold
+	}
+}
+
+// WithCircuitBreakerOpenDuration sets how long the circuit breaker stays open before a single
+// datastore call is let through to probe the datastore.
+func WithCircuitBreakerOpenDuration(d time.Duration) CircuitBreakerOption {
+	return func(c *circuitBreakerOpenFGADatastore) {
+		c.openDuration = d
+	}
+}
+
+type circuitBreakerOpenFGADatastore struct {
+	storage.OpenFGADatastore
+
+	failureRatio     float64
+	minRequests      int
+	window           time.Duration
+	latencyThreshold time.Duration
+	openDuration     time.Duration
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+
+	mu          sync.Mutex
+	state       circuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+}
+
+// NewCircuitBreakerOpenFGADatastore returns a wrapper over a datastore that stops sending calls to
+// the datastore when too many of the recent ones failed or were slow. While the circuit breaker is
+// open, datastore calls fail fast with an error that wraps storage.ErrUnavailable, instead of piling
+// up until they time out. After the open duration, a single call is let through: the circuit breaker
+// closes if it succeeds, and opens again otherwise.
+//
+// Errors that are expected from a healthy datastore, such as storage.ErrNotFound or a cancelled
+// request, aren't counted as failures.
+func NewCircuitBreakerOpenFGADatastore(inner storage.OpenFGADatastore, opts ...CircuitBreakerOption) *circuitBreakerOpenFGADatastore {
+	c := &circuitBreakerOpenFGADatastore{
+		OpenFGADatastore: inner,
+		failureRatio:     defaultCircuitBreakerFailureRatio,
+		minRequests:      defaultCircuitBreakerMinRequests,
+		window:           defaultCircuitBreakerWindow,
+		openDuration:     defaultCircuitBreakerOpenDuration,
+		now:              time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.windowStart = c.now()
+	circuitBreakerStateGauge.Set(float64(circuitClosed))
+
+	return c
+}
+
+// isCircuitBreakerFailure returns true if err indicates that the datastore is unhealthy.
+func isCircuitBreakerFailure(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, storage.ErrNotFound),
+		errors.Is(err, storage.ErrCollision),
+		errors.Is(err, storage.ErrInvalidWriteInput),
+		errors.Is(err, storage.ErrTransactionalWriteFailed),
+		errors.Is(err, storage.ErrExceededWriteBatchLimit),
+		errors.Is(err, storage.ErrMultiStoreWriteNotSupported),
+		errors.Is(err, storage.ErrInvalidContinuationToken),
+		errors.Is(err, storage.ErrMismatchObjectType),
+		errors.Is(err, storage.ErrCancelled),
+		errors.Is(err, context.Canceled):
+		return false
+	default:
+		return true
+	}
+}
+
+// setState changes the state of the circuit breaker. It must be called with the lock held.
+func (c *circuitBreakerOpenFGADatastore) setState(state circuitState, now time.Time) {
+	c.state = state
+	c.probing = false
+	c.requests = 0
+	c.failures = 0
+	c.windowStart = now
+	if state == circuitOpen {
+		c.openedAt = now
+		circuitBreakerTripsCounter.Inc()
+	}
+
+	circuitBreakerStateGauge.Set(float64(state))
+}
+
+// allow returns an error that wraps storage.ErrUnavailable if the datastore call of method must
+// be rejected.
+func (c *circuitBreakerOpenFGADatastore) allow(method string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.state == circuitOpen && now.Sub(c.openedAt) >= c.openDuration {
+		c.setState(circuitHalfOpen, now)
+	}
+
+	switch c.state {
+	case circuitClosed:
+		return nil
+	case circuitHalfOpen:
+		if !c.probing {
+			c.probing = true
+			return nil
+		}
+	}
+
+	circuitBreakerRejectedCounter.WithLabelValues(method).Inc()
+	return fmt.Errorf("%w: the circuit breaker is open", storage.ErrUnavailable)
+}
+
+// record counts the outcome of a datastore call that started at start.
+func (c *circuitBreakerOpenFGADatastore) record(start time.Time, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	failed := isCircuitBreakerFailure(err) ||
+		(c.latencyThreshold > 0 && now.Sub(start) > c.latencyThreshold)
+
+	switch c.state {
+	case circuitHalfOpen:
+		if failed {
+			c.setState(circuitOpen, now)
+		} else {
+			c.setState(circuitClosed, now)
+		}
+	case circuitClosed:
+		if now.Sub(c.windowStart) >= c.window {
+			c.windowStart = now
+			c.requests = 0
+			c.failures = 0
+		}
+
+		c.requests++
+		if failed {
+			c.failures++
+		}
+
+		if c.requests >= c.minRequests && float64(c.failures) >= c.failureRatio*float64(c.requests) {
+			c.setState(circuitOpen, now)
+		}
+	}
+}
+
+// call runs the datastore call fn of method if the circuit breaker allows it, and counts its outcome.
+func (c *circuitBreakerOpenFGADatastore) call(method string, fn func() error) error {
+	if err := c.allow(method); err != nil {
+		return err
+	}
+
+	start := c.now()
+	err := fn()
+	c.record(start, err)
+	return err
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (c *circuitBreakerOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+	var iter storage.TupleIterator
+	err := c.call("Read", func() (err error) {
+		iter, err = c.OpenFGADatastore.Read(ctx, store, tupleKey)
+		return err
+	})
+	return iter, err
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (c *circuitBreakerOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+	var tuples []*openfgav1.Tuple
+	var token []byte
+	err := c.call("ReadPage", func() (err error) {
+		tuples, token, err = c.OpenFGADatastore.ReadPage(ctx, store, tupleKey, opts)
+		return err
+	})
+	return tuples, token, err
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (c *circuitBreakerOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	var t *openfgav1.Tuple
+	err := c.call("ReadUserTuple", func() (err error) {
+		t, err = c.OpenFGADatastore.ReadUserTuple(ctx, store, tupleKey)
+		return err
+	})
+	return t, err
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (c *circuitBreakerOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+	var iter storage.TupleIterator
+	err := c.call("ReadUsersetTuples", func() (err error) {
+		iter, err = c.OpenFGADatastore.ReadUsersetTuples(ctx, store, filter)
+		return err
+	})
+	return iter, err
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (c *circuitBreakerOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
+	var iter storage.TupleIterator
+	err := c.call("ReadStartingWithUser", func() (err error) {
+		iter, err = c.OpenFGADatastore.ReadStartingWithUser(ctx, store, filter)
+		return err
+	})
+	return iter, err
+}
+
+// Write see [storage.RelationshipTupleWriter].Write.
+func (c *circuitBreakerOpenFGADatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	return c.call("Write", func() error {
+		return c.OpenFGADatastore.Write(ctx, store, deletes, writes)
+	})
+}
+
+// WriteStores see [storage.MultiStoreWriteBackend].WriteStores.
+func (c *circuitBreakerOpenFGADatastore) WriteStores(ctx context.Context, writes []storage.StoreWrite) error {
+	return c.call("WriteStores", func() error {
+		return c.OpenFGADatastore.WriteStores(ctx, writes)
+	})
+}
+
+// ReadAuthorizationModel see [storage.AuthorizationModelReadBackend].ReadAuthorizationModel.
+func (c *circuitBreakerOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, store string, id string) (*openfgav1.AuthorizationModel, error) {
+	var model *openfgav1.AuthorizationModel
+	err := c.call("ReadAuthorizationModel", func() (err error) {
+		model, err = c.OpenFGADatastore.ReadAuthorizationModel(ctx, store, id)
+		return err
+	})
+	return model, err
+}
+
+// ReadAuthorizationModels see [storage.AuthorizationModelReadBackend].ReadAuthorizationModels.
+func (c *circuitBreakerOpenFGADatastore) ReadAuthorizationModels(ctx context.Context, store string, opts storage.PaginationOptions) ([]*openfgav1.AuthorizationModel, []byte, error) {
+	var models []*openfgav1.AuthorizationModel
+	var token []byte
+	err := c.call("ReadAuthorizationModels", func() (err error) {
+		models, token, err = c.OpenFGADatastore.ReadAuthorizationModels(ctx, store, opts)
+		return err
+	})
+	return models, token, err
+}
+
+// FindLatestAuthorizationModel see [storage.AuthorizationModelReadBackend].FindLatestAuthorizationModel.
+func (c *circuitBreakerOpenFGADatastore) FindLatestAuthorizationModel(ctx context.Context, store string) (*openfgav1.AuthorizationModel, error) {
+	var model *openfgav1.AuthorizationModel
+	err := c.call("FindLatestAuthorizationModel", func() (err error) {
+		model, err = c.OpenFGADatastore.FindLatestAuthorizationModel(ctx, store)
+		return err
+	})
+	return model, err
+}
+
+// WriteAuthorizationModel see [storage.TypeDefinitionWriteBackend].WriteAuthorizationModel.
+func (c *circuitBreakerOpenFGADatastore) WriteAuthorizationModel(ctx context.Context, store string, model *openfgav1.AuthorizationModel) error {
+	return c.call("WriteAuthorizationModel", func() error {
+		return c.OpenFGADatastore.WriteAuthorizationModel(ctx, store, model)
+	})
+}
+
+// CreateStore see [storage.StoresBackend].CreateStore.
+func (c *circuitBreakerOpenFGADatastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	var created *openfgav1.Store
+	err := c.call("CreateStore", func() (err error) {
+		created, err = c.OpenFGADatastore.CreateStore(ctx, store)
+		return err
+	})
+	return created, err
+}
+
+// DeleteStore see [storage.StoresBackend].DeleteStore.
+func (c *circuitBreakerOpenFGADatastore) DeleteStore(ctx context.Context, id string) error {
+	return c.call("DeleteStore", func() error {
+		return c.OpenFGADatastore.DeleteStore(ctx, id)
+	})
+}
+
+// GetStore see [storage.StoresBackend].GetStore.
+func (c *circuitBreakerOpenFGADatastore) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	var store *openfgav1.Store
+	err := c.call("GetStore", func() (err error) {
+		store, err = c.OpenFGADatastore.GetStore(ctx, id)
+		return err
+	})
+	return store, err
+}
+
+// ListStores see [storage.StoresBackend].ListStores.
+func (c *circuitBreakerOpenFGADatastore) ListStores(
+	ctx context.Context,
+	filter storage.ListStoresFilter,
+	opts storage.PaginationOptions,
+) ([]*openfgav1.Store, []byte, error) {
+	var stores []*openfgav1.Store
+	var token []byte
+	err := c.call("ListStores", func() (err error) {
+		stores, token, err = c.OpenFGADatastore.ListStores(ctx, filter, opts)
+		return err
+	})
+	return stores, token, err
+}
+
+// UpdateStore see [storage.StoresBackend].UpdateStore.
+func (c *circuitBreakerOpenFGADatastore) UpdateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	var updated *openfgav1.Store
+	err := c.call("UpdateStore", func() (err error) {
+		updated, err = c.OpenFGADatastore.UpdateStore(ctx, store)
+		return err
+	})
+	return updated, err
+}
+
+// WriteStoreLabels see [storage.StoresBackend].WriteStoreLabels.
+func (c *circuitBreakerOpenFGADatastore) WriteStoreLabels(ctx context.Context, store string, labels map[string]string) error {
+	return c.call("WriteStoreLabels", func() error {
+		return c.OpenFGADatastore.WriteStoreLabels(ctx, store, labels)
+	})
+}
+
+// ReadStoreLabels see [storage.StoresBackend].ReadStoreLabels.
+func (c *circuitBreakerOpenFGADatastore) ReadStoreLabels(ctx context.Context, stores []string) (map[string]map[string]string, error) {
+	var labels map[string]map[string]string
+	err := c.call("ReadStoreLabels", func() (err error) {
+		labels, err = c.OpenFGADatastore.ReadStoreLabels(ctx, stores)
+		return err
+	})
+	return labels, err
+}
+
+// ReadTupleWriters see [storage.TupleWritersBackend].ReadTupleWriters.
+func (c *circuitBreakerOpenFGADatastore) ReadTupleWriters(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error) {
+	var writers []string
+	err := c.call("ReadTupleWriters", func() (err error) {
+		writers, err = c.OpenFGADatastore.ReadTupleWriters(ctx, store, tupleKeys)
+		return err
+	})
+	return writers, err
+}
+
+// ReadChangeWriters see [storage.TupleWritersBackend].ReadChangeWriters.
+func (c *circuitBreakerOpenFGADatastore) ReadChangeWriters(ctx context.Context, store string, changes []*openfgav1.TupleChange) ([]string, error) {
+	var writers []string
+	err := c.call("ReadChangeWriters", func() (err error) {
+		writers, err = c.OpenFGADatastore.ReadChangeWriters(ctx, store, changes)
+		return err
+	})
+	return writers, err
+}
+
+// WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
+func (c *circuitBreakerOpenFGADatastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
+	return c.call("WriteAssertions", func() error {
+		return c.OpenFGADatastore.WriteAssertions(ctx, store, modelID, assertions)
+	})
+}
+
+// ReadAssertions see [storage.AssertionsBackend].ReadAssertions.
+func (c *circuitBreakerOpenFGADatastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+	var assertions []*openfgav1.Assertion
+	err := c.call("ReadAssertions", func() (err error) {
+		assertions, err = c.OpenFGADatastore.ReadAssertions(ctx, store, modelID)
+		return err
+	})
+	return assertions, err
+}
+
+// ReadChanges see [storage.ChangelogBackend].ReadChanges.
+func (c *circuitBreakerOpenFGADatastore) ReadChanges(ctx context.Context, store string, filter storage.ReadChangesFilter, opts storage.PaginationOptions, horizonOffset time.Duration) ([]*openfgav1.TupleChange, []byte, error) {
+	var changes []*openfgav1.TupleChange
+	var token []byte
+	err := c.call("ReadChanges", func() (err error) {
+		changes, token, err = c.OpenFGADatastore.ReadChanges(ctx, store, filter, opts, horizonOffset)
+		return err
+	})
+	return changes, token, err
+}
+
+// ChangeExists see [storage.ChangelogBackend].ChangeExists.
+func (c *circuitBreakerOpenFGADatastore) ChangeExists(ctx context.Context, store, id string) (bool, error) {
+	var exists bool
+	err := c.call("ChangeExists", func() (err error) {
+		exists, err = c.OpenFGADatastore.ChangeExists(ctx, store, id)
+		return err
+	})
+	return exists, err
+}
+
+// WriteStoreSettings see [storage.StoreSettingsBackend].WriteStoreSettings.
+func (c *circuitBreakerOpenFGADatastore) WriteStoreSettings(ctx context.Context, store string, settings *storage.StoreSettings) error {
+	return c.call("WriteStoreSettings", func() error {
+		return c.OpenFGADatastore.WriteStoreSettings(ctx, store, settings)
+	})
+}
+
+// ReadStoreSettings see [storage.StoreSettingsBackend].ReadStoreSettings.
+func (c *circuitBreakerOpenFGADatastore) ReadStoreSettings(ctx context.Context, store string) (*storage.StoreSettings, error) {
+	var settings *storage.StoreSettings
+	err := c.call("ReadStoreSettings", func() (err error) {
+		settings, err = c.OpenFGADatastore.ReadStoreSettings(ctx, store)
+		return err
+	})
+	return settings, err
+}
+
+// WritePlannerStatistics see [storage.PlannerStatisticsBackend].WritePlannerStatistics.
+func (c *circuitBreakerOpenFGADatastore) WritePlannerStatistics(ctx context.Context, store string, statistics []byte) error {
+	return c.call("WritePlannerStatistics", func() error {
+		return c.OpenFGADatastore.WritePlannerStatistics(ctx, store, statistics)
+	})
+}
+
+// ReadPlannerStatistics see [storage.PlannerStatisticsBackend].ReadPlannerStatistics.
+func (c *circuitBreakerOpenFGADatastore) ReadPlannerStatistics(ctx context.Context, store string) ([]byte, error) {
+	var statistics []byte
+	err := c.call("ReadPlannerStatistics", func() (err error) {
+		statistics, err = c.OpenFGADatastore.ReadPlannerStatistics(ctx, store)
+		return err
+	})
+	return statistics, err
+}
+
+// AddRelationUsage see [storage.RelationUsageBackend].AddRelationUsage.
+func (c *circuitBreakerOpenFGADatastore) AddRelationUsage(ctx context.Context, store string, usage []storage.RelationUsage) error {
+	return c.call("AddRelationUsage", func() error {
+		return c.OpenFGADatastore.AddRelationUsage(ctx, store, usage)
+	})
+}
+
+// ReadRelationUsage see [storage.RelationUsageBackend].ReadRelationUsage.
+func (c *circuitBreakerOpenFGADatastore) ReadRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	var usage []storage.RelationUsage
+	err := c.call("ReadRelationUsage", func() (err error) {
+		usage, err = c.OpenFGADatastore.ReadRelationUsage(ctx, store)
+		return err
+	})
+	return usage, err
+}
+
+// WriteChangelogCheckpoint see [storage.ChangelogCheckpointBackend].WriteChangelogCheckpoint.
+func (c *circuitBreakerOpenFGADatastore) WriteChangelogCheckpoint(ctx context.Context, store, consumer, continuationToken string) error {
+	return c.call("WriteChangelogCheckpoint", func() error {
+		return c.OpenFGADatastore.WriteChangelogCheckpoint(ctx, store, consumer, continuationToken)
+	})
+}
+
+// ReadChangelogCheckpoint see [storage.ChangelogCheckpointBackend].ReadChangelogCheckpoint.
+func (c *circuitBreakerOpenFGADatastore) ReadChangelogCheckpoint(ctx context.Context, store, consumer string) (string, error) {
+	var continuationToken string
+	err := c.call("ReadChangelogCheckpoint", func() (err error) {
+		continuationToken, err = c.OpenFGADatastore.ReadChangelogCheckpoint(ctx, store, consumer)
+		return err
+	})
+	return continuationToken, err
+}
+
+// Close closes the datastore and cleans up any residual resources.
+func (c *circuitBreakerOpenFGADatastore) Close() {
+	c.OpenFGADatastore.Close()
+}
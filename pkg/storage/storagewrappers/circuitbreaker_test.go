@@ -0,0 +1,138 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestCircuitBreakerOpenFGADatastore(t *testing.T) {
+	const storeID = "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8Q"
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	errDatastore := errors.New("connection refused")
+
+	newBreaker := func(t *testing.T, opts ...CircuitBreakerOption) (*circuitBreakerOpenFGADatastore, *mocks.MockOpenFGADatastore, *time.Time) {
+		mockController := gomock.NewController(t)
+		t.Cleanup(mockController.Finish)
+
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		now := time.Now()
+		opts = append([]CircuitBreakerOption{
+			WithCircuitBreakerMinRequests(4),
+			WithCircuitBreakerFailureRatio(0.5),
+			WithCircuitBreakerWindow(time.Minute),
+			WithCircuitBreakerOpenDuration(time.Second),
+		}, opts...)
+		ds := NewCircuitBreakerOpenFGADatastore(mockDatastore, opts...)
+		ds.now = func() time.Time { return now }
+		ds.windowStart = now
+
+		return ds, mockDatastore, &now
+	}
+
+	t.Run("opens_after_failures_and_fails_fast", func(t *testing.T) {
+		ds, mockDatastore, _ := newBreaker(t)
+
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(2).Return(&openfgav1.Tuple{Key: tk}, nil)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(2).Return(nil, errDatastore)
+
+		for i := 0; i < 4; i++ {
+			_, _ = ds.ReadUserTuple(context.Background(), storeID, tk)
+		}
+		require.Equal(t, circuitOpen, ds.state)
+
+		_, err := ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.ErrorIs(t, err, storage.ErrUnavailable)
+
+		err = ds.Write(context.Background(), storeID, nil, nil)
+		require.ErrorIs(t, err, storage.ErrUnavailable)
+	})
+
+	t.Run("expected_errors_are_not_failures", func(t *testing.T) {
+		ds, mockDatastore, _ := newBreaker(t)
+
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(2).Return(nil, storage.ErrNotFound)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(2).Return(nil, context.Canceled)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(1).Return(nil, errDatastore)
+
+		for i := 0; i < 5; i++ {
+			_, _ = ds.ReadUserTuple(context.Background(), storeID, tk)
+		}
+		require.Equal(t, circuitClosed, ds.state)
+	})
+
+	t.Run("failures_of_a_previous_window_are_forgotten", func(t *testing.T) {
+		ds, mockDatastore, now := newBreaker(t)
+
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(3).Return(nil, errDatastore)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(1).Return(&openfgav1.Tuple{Key: tk}, nil)
+
+		for i := 0; i < 3; i++ {
+			_, _ = ds.ReadUserTuple(context.Background(), storeID, tk)
+		}
+
+		*now = now.Add(time.Minute)
+		_, err := ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.NoError(t, err)
+		require.Equal(t, circuitClosed, ds.state)
+		require.Equal(t, 1, ds.requests)
+	})
+
+	t.Run("slow_calls_are_failures", func(t *testing.T) {
+		ds, mockDatastore, now := newBreaker(t, WithCircuitBreakerLatencyThreshold(100*time.Millisecond))
+
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(4).DoAndReturn(
+			func(ctx context.Context, store string, tk *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+				*now = now.Add(200 * time.Millisecond)
+				return &openfgav1.Tuple{Key: tk}, nil
+			})
+
+		for i := 0; i < 4; i++ {
+			_, err := ds.ReadUserTuple(context.Background(), storeID, tk)
+			require.NoError(t, err)
+		}
+		require.Equal(t, circuitOpen, ds.state)
+	})
+
+	t.Run("half_open_probe", func(t *testing.T) {
+		ds, mockDatastore, now := newBreaker(t)
+
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(5).Return(nil, errDatastore)
+
+		for i := 0; i < 4; i++ {
+			_, _ = ds.ReadUserTuple(context.Background(), storeID, tk)
+		}
+		require.Equal(t, circuitOpen, ds.state)
+
+		// the failed probe opens the circuit breaker again
+		*now = now.Add(time.Second)
+		_, err := ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.ErrorIs(t, err, errDatastore)
+		require.Equal(t, circuitOpen, ds.state)
+
+		_, err = ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.ErrorIs(t, err, storage.ErrUnavailable)
+
+		// other calls are rejected while the probe is in flight, and a successful probe closes it
+		*now = now.Add(time.Second)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(1).DoAndReturn(
+			func(ctx context.Context, store string, tk *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+				_, err := ds.GetStore(ctx, storeID)
+				require.ErrorIs(t, err, storage.ErrUnavailable)
+				return &openfgav1.Tuple{Key: tk}, nil
+			})
+
+		_, err = ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.NoError(t, err)
+		require.Equal(t, circuitClosed, ds.state)
+	})
+}
@@ -0,0 +1,228 @@
+package storagewrappers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+var _ storage.OpenFGADatastore = (*CoalescingOpenFGADatastore)(nil)
+
+var (
+	coalescedWritesHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace:                       build.ProjectName,
+		Name:                            "datastore_coalesced_writes",
+		Help:                            "The number of Write calls coalesced into each datastore transaction.",
+		Buckets:                         []float64{1, 2, 4, 8, 16, 32, 64, 128},
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	})
+
+	coalescedWriteFallbackCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_coalesced_write_fallback_count",
+		Help:      "The number of datastore transactions of coalesced Write calls that failed, after which each call was written on its own.",
+	})
+)
+
+// coalescedWrite is a Write call waiting for the transaction of its batch. done is closed once err is set.
+type coalescedWrite struct {
+	ctx     context.Context
+	deletes storage.Deletes
+	writes  storage.Writes
+	done    chan struct{}
+	err     error
+}
+
+// writeBatch are the Write calls of a store that are committed in the same transaction.
+type writeBatch struct {
+	writer string
+	calls  []*coalescedWrite
+	keys   map[string]struct{}
+	tuples int
+	timer  *time.Timer
+
+	// done is closed once the batch is committed, so that the next batch of the store waits for it.
+	done chan struct{}
+}
+
+// CoalescingOpenFGADatastore is a wrapper over a datastore that coalesces the Write calls of a store
+// into larger datastore transactions, trading a few milliseconds of latency for the throughput of many
+// small writes, e.g. on the SQL engines.
+type CoalescingOpenFGADatastore struct {
+	storage.OpenFGADatastore
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*writeBatch
+
+	// last has the done channel of the last batch of each store that was committed or is committing.
+	last map[string]chan struct{}
+}
+
+// NewCoalescingOpenFGADatastore returns a wrapper over a datastore whose Write waits up to window for
+// the other Write calls of the same store, and writes them in a single transaction of up to
+// MaxTuplesPerWrite tuples. The batches of a store are committed in order, and the calls that touch a
+// tuple of the pending batch, or record another writer (see [storage.ContextWithWriter]), start the
+// next batch, so that the calls are written in the order they were made. Each call keeps the ULID of
+// its first change (see [storage.ContextWithChangeULID]). If the transaction of a batch fails, e.g.
+// because a tuple of one of its calls already exists, each call is written on its own, so that only
+// the calls that fail by themselves fail.
+func NewCoalescingOpenFGADatastore(wrapped storage.OpenFGADatastore, window time.Duration) *CoalescingOpenFGADatastore {
+	return &CoalescingOpenFGADatastore{
+		OpenFGADatastore: wrapped,
+		window:           window,
+		pending:          map[string]*writeBatch{},
+		last:             map[string]chan struct{}{},
+	}
+}
+
+// Write see [storage.RelationshipTupleWriter].Write. It returns once the batch of the call is committed.
+func (c *CoalescingOpenFGADatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	call := &coalescedWrite{ctx: ctx, deletes: deletes, writes: writes, done: make(chan struct{})}
+	writer := storage.WriterFromContext(ctx)
+
+	keys := make([]string, 0, len(deletes)+len(writes))
+	for _, tk := range deletes {
+		keys = append(keys, tuple.TupleKeyToString(tk))
+	}
+	for _, tk := range writes {
+		keys = append(keys, tuple.TupleKeyToString(tk))
+	}
+
+	c.mu.Lock()
+	batch := c.pending[store]
+	if batch != nil && !c.accepts(batch, writer, keys) {
+		c.sealLocked(store, batch)
+		batch = nil
+	}
+
+	if batch == nil {
+		batch = &writeBatch{writer: writer, keys: map[string]struct{}{}, done: make(chan struct{})}
+		c.pending[store] = batch
+
+		sealed := batch
+		batch.timer = time.AfterFunc(c.window, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.pending[store] == sealed {
+				c.sealLocked(store, sealed)
+			}
+		})
+	}
+
+	batch.calls = append(batch.calls, call)
+	batch.tuples += len(keys)
+	for _, key := range keys {
+		batch.keys[key] = struct{}{}
+	}
+
+	if batch.tuples >= c.OpenFGADatastore.MaxTuplesPerWrite() {
+		c.sealLocked(store, batch)
+	}
+	c.mu.Unlock()
+
+	<-call.done
+	return call.err
+}
+
+// accepts reports whether the call of the writer with the keys can join the batch.
+func (c *CoalescingOpenFGADatastore) accepts(batch *writeBatch, writer string, keys []string) bool {
+	if batch.writer != writer || batch.tuples+len(keys) > c.OpenFGADatastore.MaxTuplesPerWrite() {
+		return false
+	}
+
+	for _, key := range keys {
+		if _, ok := batch.keys[key]; ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sealLocked stops the batch from accepting calls and commits it once the previous batch of the store
+// is committed. c.mu must be held.
+func (c *CoalescingOpenFGADatastore) sealLocked(store string, batch *writeBatch) {
+	batch.timer.Stop()
+	delete(c.pending, store)
+
+	previous := c.last[store]
+	c.last[store] = batch.done
+
+	go c.commit(store, batch, previous)
+}
+
+func (c *CoalescingOpenFGADatastore) commit(store string, batch *writeBatch, previous chan struct{}) {
+	if previous != nil {
+		<-previous
+	}
+
+	defer func() {
+		close(batch.done)
+
+		c.mu.Lock()
+		if c.last[store] == batch.done {
+			delete(c.last, store)
+		}
+		c.mu.Unlock()
+	}()
+
+	coalescedWritesHistogram.Observe(float64(len(batch.calls)))
+
+	if len(batch.calls) == 1 {
+		call := batch.calls[0]
+		call.err = c.OpenFGADatastore.Write(call.ctx, store, call.deletes, call.writes)
+		close(call.done)
+		return
+	}
+
+	// the changes of the calls are in the order of the calls, the deletes first and then the writes
+	var deletes storage.Deletes
+	var writes storage.Writes
+	for _, call := range batch.calls {
+		deletes = append(deletes, call.deletes...)
+	}
+
+	changeIDs := map[int]string{}
+	deleteIndex, writeIndex := 0, len(deletes)
+	for _, call := range batch.calls {
+		if id := storage.ChangeULIDFromContext(call.ctx); id != "" {
+			if len(call.deletes) > 0 {
+				changeIDs[deleteIndex] = id
+			} else {
+				changeIDs[writeIndex] = id
+			}
+		}
+
+		deleteIndex += len(call.deletes)
+		writeIndex += len(call.writes)
+		writes = append(writes, call.writes...)
+	}
+
+	// the transaction outlives the call that started the batch if it is cancelled, since the other
+	// calls still wait for it
+	ctx := context.WithoutCancel(batch.calls[0].ctx)
+	ctx = storage.ContextWithChangeULIDs(storage.ContextWithWriter(ctx, batch.writer), changeIDs)
+
+	err := c.OpenFGADatastore.Write(ctx, store, deletes, writes)
+	if err != nil {
+		coalescedWriteFallbackCounter.Inc()
+	}
+
+	for _, call := range batch.calls {
+		if err != nil {
+			call.err = c.OpenFGADatastore.Write(call.ctx, store, call.deletes, call.writes)
+		}
+		close(call.done)
+	}
+}
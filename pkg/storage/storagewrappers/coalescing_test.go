@@ -0,0 +1,162 @@
+package storagewrappers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// countingWriter counts the Write calls that reach the datastore.
+type countingWriter struct {
+	storage.OpenFGADatastore
+	writes atomic.Int32
+}
+
+func (c *countingWriter) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	c.writes.Add(1)
+	return c.OpenFGADatastore.Write(ctx, store, deletes, writes)
+}
+
+func TestCoalescingDatastore(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, window time.Duration) (*countingWriter, *CoalescingOpenFGADatastore) {
+		ds := memory.New()
+		t.Cleanup(ds.Close)
+
+		counting := &countingWriter{OpenFGADatastore: ds}
+		return counting, NewCoalescingOpenFGADatastore(counting, window)
+	}
+
+	readAll := func(t *testing.T, ds storage.OpenFGADatastore, store string) []string {
+		iter, err := ds.Read(ctx, store, nil)
+		require.NoError(t, err)
+		defer iter.Stop()
+
+		var keys []string
+		for {
+			tk, err := iter.Next(ctx)
+			if err != nil {
+				require.ErrorIs(t, err, storage.ErrIteratorDone)
+				return keys
+			}
+			keys = append(keys, tuple.TupleKeyToString(tk.GetKey()))
+		}
+	}
+
+	t.Run("coalesces_the_writes_of_a_store", func(t *testing.T) {
+		counting, coalescing := setup(t, 50*time.Millisecond)
+		store := ulid.Make().String()
+
+		var wg errgroup.Group
+		for i := 0; i < 10; i++ {
+			tk := tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne")
+			wg.Go(func() error {
+				return coalescing.Write(ctx, store, nil, []*openfgav1.TupleKey{tk})
+			})
+		}
+		require.NoError(t, wg.Wait())
+
+		require.Len(t, readAll(t, coalescing, store), 10)
+		require.Less(t, counting.writes.Load(), int32(10))
+	})
+
+	t.Run("keeps_the_change_ulid_of_every_write", func(t *testing.T) {
+		_, coalescing := setup(t, 50*time.Millisecond)
+		store := ulid.Make().String()
+
+		ids := make([]string, 5)
+		var wg errgroup.Group
+		for i := range ids {
+			ids[i] = ulid.Make().String()
+			tk := tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne")
+			writeCtx := storage.ContextWithChangeULID(ctx, ids[i])
+			wg.Go(func() error {
+				return coalescing.Write(writeCtx, store, nil, []*openfgav1.TupleKey{tk})
+			})
+		}
+		require.NoError(t, wg.Wait())
+
+		for _, id := range ids {
+			exists, err := coalescing.ChangeExists(ctx, store, id)
+			require.NoError(t, err)
+			require.True(t, exists)
+		}
+	})
+
+	t.Run("fails_only_the_failing_writes", func(t *testing.T) {
+		counting, coalescing := setup(t, 50*time.Millisecond)
+		store := ulid.Make().String()
+
+		existing := tuple.NewTupleKey("document:existing", "viewer", "user:anne")
+		require.NoError(t, coalescing.Write(ctx, store, nil, []*openfgav1.TupleKey{existing}))
+		counting.writes.Store(0)
+
+		var failed atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			tk := tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne")
+			if i == 2 {
+				tk = existing
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := coalescing.Write(ctx, store, nil, []*openfgav1.TupleKey{tk}); err != nil {
+					require.ErrorIs(t, err, storage.ErrInvalidWriteInput)
+					failed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, int32(1), failed.Load())
+		require.Len(t, readAll(t, coalescing, store), 5)
+	})
+
+	t.Run("writes_the_overlapping_writes_in_order", func(t *testing.T) {
+		_, coalescing := setup(t, 50*time.Millisecond)
+		store := ulid.Make().String()
+
+		tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+		deleted := tuple.TupleKeyToTupleKeyWithoutCondition(tk)
+
+		errs := make(chan error, 3)
+		go func() { errs <- coalescing.Write(ctx, store, nil, []*openfgav1.TupleKey{tk}) }()
+		time.Sleep(5 * time.Millisecond)
+		go func() { errs <- coalescing.Write(ctx, store, storage.Deletes{deleted}, nil) }()
+		time.Sleep(5 * time.Millisecond)
+		go func() { errs <- coalescing.Write(ctx, store, nil, []*openfgav1.TupleKey{tk}) }()
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, <-errs)
+		}
+		require.Equal(t, []string{tuple.TupleKeyToString(tk)}, readAll(t, coalescing, store))
+	})
+
+	t.Run("writes_a_full_batch_without_waiting_for_the_window", func(t *testing.T) {
+		counting, coalescing := setup(t, time.Hour)
+		store := ulid.Make().String()
+
+		writes := make([]*openfgav1.TupleKey, coalescing.MaxTuplesPerWrite())
+		for i := range writes {
+			writes[i] = tuple.NewTupleKey(fmt.Sprintf("document:%d", i), "viewer", "user:anne")
+		}
+
+		require.NoError(t, coalescing.Write(ctx, store, nil, writes))
+		require.Equal(t, int32(1), counting.writes.Load())
+	})
+}
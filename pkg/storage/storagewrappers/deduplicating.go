@@ -0,0 +1,192 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+var _ storage.RelationshipTupleReader = (*deduplicatingTupleReader)(nil)
+
+var deduplicatedReadsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: build.ProjectName,
+	Name:      "datastore_deduplicated_reads_total",
+	Help:      "The number of Read, ReadUserTuple and ReadUsersetTuples calls of a request that were answered by an identical call of the same request instead of the datastore.",
+}, []string{"method"})
+
+// memoizedRead is the outcome of a read. done is closed once the read is over. The tuples are only
+// set if the read succeeded and returned at most the maximum number of tuples.
+type memoizedRead struct {
+	done     chan struct{}
+	tuples   []*openfgav1.Tuple
+	memoized bool
+}
+
+type deduplicatingTupleReader struct {
+	storage.RelationshipTupleReader
+	maxTuplesPerRead int
+
+	mu    sync.Mutex
+	reads map[string]*memoizedRead
+}
+
+// NewDeduplicatingTupleReader returns a wrapper over a datastore that memoizes the results of Read,
+// ReadUserTuple and ReadUsersetTuples, so that identical calls, e.g. of the sibling branches of a
+// Check resolution, only reach the datastore once. A call that is identical to one in progress
+// waits for it. It must only be used for the duration of a single request, since the results are
+// never invalidated. The results of more than maxTuplesPerRead tuples and the errors aren't
+// memoized, and the identical calls read the datastore themselves.
+func NewDeduplicatingTupleReader(wrapped storage.RelationshipTupleReader, maxTuplesPerRead int) *deduplicatingTupleReader {
+	return &deduplicatingTupleReader{
+		RelationshipTupleReader: wrapped,
+		maxTuplesPerRead:        maxTuplesPerRead,
+		reads:                   map[string]*memoizedRead{},
+	}
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (d *deduplicatingTupleReader) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+	key := fmt.Sprintf("Read/%s/%s#%s@%s", store, tupleKey.GetObject(), tupleKey.GetRelation(), tupleKey.GetUser())
+
+	return d.read(ctx, "Read", key, func() (storage.TupleIterator, error) {
+		return d.RelationshipTupleReader.Read(ctx, store, tupleKey)
+	})
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple. A tuple that isn't found is
+// memoized too.
+func (d *deduplicatingTupleReader) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	key := fmt.Sprintf("ReadUserTuple/%s/%s", store, tuple.TupleKeyToString(tupleKey))
+
+	iter, err := d.read(ctx, "ReadUserTuple", key, func() (storage.TupleIterator, error) {
+		t, err := d.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return storage.NewStaticTupleIterator(nil), nil
+			}
+			return nil, err
+		}
+
+		return storage.NewStaticTupleIterator([]*openfgav1.Tuple{t}), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Stop()
+
+	t, err := iter.Next(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrIteratorDone) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (d *deduplicatingTupleReader) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+	restrictions := make([]string, 0, len(filter.AllowedUserTypeRestrictions))
+	for _, ref := range filter.AllowedUserTypeRestrictions {
+		restriction := ref.GetType()
+		switch {
+		case ref.GetWildcard() != nil:
+			restriction = tuple.TypedPublicWildcard(restriction)
+		case ref.GetRelation() != "":
+			restriction = tuple.ToObjectRelationString(restriction, ref.GetRelation())
+		}
+		if ref.GetCondition() != "" {
+			restriction += " with " + ref.GetCondition()
+		}
+		restrictions = append(restrictions, restriction)
+	}
+	sort.Strings(restrictions)
+
+	key := fmt.Sprintf("ReadUsersetTuples/%s/%s#%s/%s", store, filter.Object, filter.Relation, strings.Join(restrictions, ","))
+
+	return d.read(ctx, "ReadUsersetTuples", key, func() (storage.TupleIterator, error) {
+		return d.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter)
+	})
+}
+
+// read returns an iterator over the memoized result of the key, or runs the read and memoizes its
+// result. If an identical read is in progress, it waits for it, and reads the datastore itself if
+// the result of that read isn't memoized.
+func (d *deduplicatingTupleReader) read(ctx context.Context, method, key string, readFn func() (storage.TupleIterator, error)) (storage.TupleIterator, error) {
+	d.mu.Lock()
+	m, ok := d.reads[key]
+	if !ok {
+		m = &memoizedRead{done: make(chan struct{})}
+		d.reads[key] = m
+	}
+	d.mu.Unlock()
+
+	if ok {
+		select {
+		case <-m.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if m.memoized {
+			deduplicatedReadsCounter.WithLabelValues(method).Inc()
+			return storage.NewStaticTupleIterator(m.tuples), nil
+		}
+
+		return readFn()
+	}
+
+	iter, err := readFn()
+	if err != nil {
+		d.forget(key, m)
+		return nil, err
+	}
+
+	tuples := make([]*openfgav1.Tuple, 0)
+	for len(tuples) <= d.maxTuplesPerRead {
+		t, err := iter.Next(ctx)
+		if err != nil {
+			if errors.Is(err, storage.ErrIteratorDone) {
+				iter.Stop()
+
+				m.tuples = tuples
+				m.memoized = true
+				close(m.done)
+
+				return storage.NewStaticTupleIterator(tuples), nil
+			}
+
+			iter.Stop()
+			d.forget(key, m)
+			return nil, err
+		}
+
+		tuples = append(tuples, t)
+	}
+
+	// the result is too large to be memoized, so the tuples read so far are returned with the rest
+	d.forget(key, m)
+	return storage.NewCombinedIterator(storage.NewStaticTupleIterator(tuples), iter), nil
+}
+
+// forget removes the read from the memoized reads, so that the next identical call reads the
+// datastore again, and releases the calls that are waiting for it.
+func (d *deduplicatingTupleReader) forget(key string, m *memoizedRead) {
+	d.mu.Lock()
+	delete(d.reads, key)
+	d.mu.Unlock()
+
+	close(m.done)
+}
@@ -0,0 +1,161 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+	"github.com/openfga/openfga/pkg/typesystem"
+)
+
+// countingTupleReader counts the reads that reach the datastore, and fails them while failing is set.
+type countingTupleReader struct {
+	storage.RelationshipTupleReader
+	reads   atomic.Int32
+	failing atomic.Bool
+}
+
+func (c *countingTupleReader) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+	c.reads.Add(1)
+	if c.failing.Load() {
+		return nil, errors.New("failed")
+	}
+	return c.RelationshipTupleReader.Read(ctx, store, tupleKey)
+}
+
+func (c *countingTupleReader) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	c.reads.Add(1)
+	if c.failing.Load() {
+		return nil, errors.New("failed")
+	}
+	return c.RelationshipTupleReader.ReadUserTuple(ctx, store, tupleKey)
+}
+
+func (c *countingTupleReader) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+	c.reads.Add(1)
+	if c.failing.Load() {
+		return nil, errors.New("failed")
+	}
+	return c.RelationshipTupleReader.ReadUsersetTuples(ctx, store, filter)
+}
+
+func TestDeduplicatingTupleReader(t *testing.T) {
+	ctx := context.Background()
+	store := ulid.Make().String()
+
+	ds := memory.New()
+	t.Cleanup(ds.Close)
+
+	err := ds.Write(ctx, store, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "group:eng#member"),
+		tuple.NewTupleKey("document:1", "viewer", "group:sales#member"),
+		tuple.NewTupleKey("document:1", "viewer", "user:anne"),
+	})
+	require.NoError(t, err)
+
+	readAll := func(iter storage.TupleIterator, err error) []string {
+		require.NoError(t, err)
+		defer iter.Stop()
+
+		var keys []string
+		for {
+			tk, err := iter.Next(ctx)
+			if errors.Is(err, storage.ErrIteratorDone) {
+				return keys
+			}
+			require.NoError(t, err)
+			keys = append(keys, tuple.TupleKeyToString(tk.GetKey()))
+		}
+	}
+
+	groupMembers := storage.ReadUsersetTuplesFilter{
+		Object:                      "document:1",
+		Relation:                    "viewer",
+		AllowedUserTypeRestrictions: []*openfgav1.RelationReference{typesystem.DirectRelationReference("group", "member")},
+	}
+
+	t.Run("identical_reads_are_memoized", func(t *testing.T) {
+		counting := &countingTupleReader{RelationshipTupleReader: ds}
+		reader := NewDeduplicatingTupleReader(counting, 10)
+
+		first := readAll(reader.ReadUsersetTuples(ctx, store, groupMembers))
+		second := readAll(reader.ReadUsersetTuples(ctx, store, groupMembers))
+		require.Equal(t, []string{"document:1#viewer@group:eng#member", "document:1#viewer@group:sales#member"}, first)
+		require.Equal(t, first, second)
+		require.EqualValues(t, 1, counting.reads.Load())
+
+		// other parameters are other reads
+		readAll(reader.ReadUsersetTuples(ctx, store, storage.ReadUsersetTuplesFilter{Object: "document:1", Relation: "viewer"}))
+		readAll(reader.Read(ctx, store, tuple.NewTupleKey("document:1", "viewer", "")))
+		readAll(reader.Read(ctx, store, tuple.NewTupleKey("document:1", "viewer", "")))
+		require.EqualValues(t, 3, counting.reads.Load())
+	})
+
+	t.Run("missing_user_tuple_is_memoized", func(t *testing.T) {
+		counting := &countingTupleReader{RelationshipTupleReader: ds}
+		reader := NewDeduplicatingTupleReader(counting, 10)
+
+		for i := 0; i < 2; i++ {
+			_, err := reader.ReadUserTuple(ctx, store, tuple.NewTupleKey("document:1", "viewer", "user:bob"))
+			require.ErrorIs(t, err, storage.ErrNotFound)
+
+			found, err := reader.ReadUserTuple(ctx, store, tuple.NewTupleKey("document:1", "viewer", "user:anne"))
+			require.NoError(t, err)
+			require.Equal(t, "user:anne", found.GetKey().GetUser())
+		}
+		require.EqualValues(t, 2, counting.reads.Load())
+	})
+
+	t.Run("concurrent_identical_reads_wait_for_the_first_one", func(t *testing.T) {
+		counting := &countingTupleReader{RelationshipTupleReader: mocks.NewMockSlowDataStorage(ds, 100*time.Millisecond)}
+		reader := NewDeduplicatingTupleReader(counting, 10)
+
+		var wg errgroup.Group
+		for i := 0; i < 5; i++ {
+			wg.Go(func() error {
+				iter, err := reader.ReadUsersetTuples(ctx, store, groupMembers)
+				if err != nil {
+					return err
+				}
+				iter.Stop()
+				return nil
+			})
+		}
+		require.NoError(t, wg.Wait())
+		require.EqualValues(t, 1, counting.reads.Load())
+	})
+
+	t.Run("large_reads_are_not_memoized", func(t *testing.T) {
+		counting := &countingTupleReader{RelationshipTupleReader: ds}
+		reader := NewDeduplicatingTupleReader(counting, 1)
+
+		filter := storage.ReadUsersetTuplesFilter{Object: "document:1", Relation: "viewer"}
+		require.Len(t, readAll(reader.ReadUsersetTuples(ctx, store, filter)), 2)
+		require.Len(t, readAll(reader.ReadUsersetTuples(ctx, store, filter)), 2)
+		require.EqualValues(t, 2, counting.reads.Load())
+	})
+
+	t.Run("errors_are_not_memoized", func(t *testing.T) {
+		counting := &countingTupleReader{RelationshipTupleReader: ds}
+		reader := NewDeduplicatingTupleReader(counting, 10)
+
+		counting.failing.Store(true)
+		_, err := reader.ReadUsersetTuples(ctx, store, groupMembers)
+		require.Error(t, err)
+
+		counting.failing.Store(false)
+		require.Len(t, readAll(reader.ReadUsersetTuples(ctx, store, groupMembers)), 2)
+		require.EqualValues(t, 2, counting.reads.Load())
+	})
+}
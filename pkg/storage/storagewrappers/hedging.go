@@ -0,0 +1,315 @@
+package storagewrappers
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+const (
+	// defaultHedgingPercentile is the observed read latency percentile after which
+	// a hedged read is sent.
+	defaultHedgingPercentile = 95
+
+	// defaultHedgingMinDelay is the minimum delay before a hedged read is sent. It is also
+	// the delay used until enough latency samples have been observed.
+	defaultHedgingMinDelay = 5 * time.Millisecond
+
+	// hedgingLatencySamples is the number of most recent read latencies used to compute
+	// the hedging delay.
+	hedgingLatencySamples = 1000
+
+	// hedgingRecomputeInterval is the number of observations after which the hedging
+	// delay is recomputed.
+	hedgingRecomputeInterval = 100
+)
+
+var (
+	hedgedReadsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_hedged_reads_total",
+		Help:      "The total number of datastore reads for which a second, hedged read was sent.",
+	}, []string{"method"})
+
+	hedgedReadsWastedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_hedged_reads_wasted_total",
+		Help:      "The total number of datastore reads whose response was discarded because the other read of a hedged pair responded first.",
+	}, []string{"method"})
+
+	hedgedReadsWonCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_hedged_reads_won_total",
+		Help:      "The total number of hedged datastore reads that responded before the original read.",
+	}, []string{"method"})
+)
+
+var _ storage.OpenFGADatastore = (*hedgedOpenFGADatastore)(nil)
+
+// HedgingOption defines an option that can be used to change the behavior of the
+// hedged datastore wrapper.
+type HedgingOption func(h *hedgedOpenFGADatastore)
+
+// WithHedgingPercentile sets the percentile (between 0 and 100) of the observed read
+// latency after which a second, identical read is sent to the datastore.
+func WithHedgingPercentile(percentile float64) HedgingOption {
+	return func(h *hedgedOpenFGADatastore) {
+		h.percentile = percentile
+	}
+}
+
+// WithHedgingMinDelay sets the minimum delay before a hedged read is sent. This
+// prevents hedging reads that are already fast.
+func WithHedgingMinDelay(delay time.Duration) HedgingOption {
+	return func(h *hedgedOpenFGADatastore) {
+		h.minDelay = delay
+	}
+}
+
+type hedgedOpenFGADatastore struct {
+	storage.OpenFGADatastore
+
+	percentile float64
+	minDelay   time.Duration
+
+	mu           sync.Mutex
+	latencies    []time.Duration
+	next         int
+	observations int
+
+	delay atomic.Int64
+}
+
+// NewHedgedOpenFGADatastore returns a wrapper over a datastore that hedges tuple reads. If a read
+// has not responded after the configured percentile of recently observed read latencies, a second
+// identical read is sent and the first successful response is returned. The other read is cancelled
+// and its response is discarded. This tames tail latency caused by slow or flaky database replicas at the cost of
+// extra datastore load.
+func NewHedgedOpenFGADatastore(inner storage.OpenFGADatastore, opts ...HedgingOption) *hedgedOpenFGADatastore {
+	h := &hedgedOpenFGADatastore{
+		OpenFGADatastore: inner,
+		percentile:       defaultHedgingPercentile,
+		minDelay:         defaultHedgingMinDelay,
+		latencies:        make([]time.Duration, 0, hedgingLatencySamples),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.delay.Store(int64(h.minDelay))
+
+	return h
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (h *hedgedOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+	return hedge(ctx, h, "Read", func(ctx context.Context) (storage.TupleIterator, error) {
+		return h.OpenFGADatastore.Read(ctx, store, tupleKey)
+	}, stopIterator, keepIterator)
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (h *hedgedOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+	type page struct {
+		tuples []*openfgav1.Tuple
+		token  []byte
+	}
+
+	res, err := hedge(ctx, h, "ReadPage", func(ctx context.Context) (page, error) {
+		tuples, token, err := h.OpenFGADatastore.ReadPage(ctx, store, tupleKey, opts)
+		return page{tuples: tuples, token: token}, err
+	}, nil, nil)
+	return res.tuples, res.token, err
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (h *hedgedOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	return hedge(ctx, h, "ReadUserTuple", func(ctx context.Context) (*openfgav1.Tuple, error) {
+		return h.OpenFGADatastore.ReadUserTuple(ctx, store, tupleKey)
+	}, nil, nil)
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (h *hedgedOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+	return hedge(ctx, h, "ReadUsersetTuples", func(ctx context.Context) (storage.TupleIterator, error) {
+		return h.OpenFGADatastore.ReadUsersetTuples(ctx, store, filter)
+	}, stopIterator, keepIterator)
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (h *hedgedOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
+	return hedge(ctx, h, "ReadStartingWithUser", func(ctx context.Context) (storage.TupleIterator, error) {
+		return h.OpenFGADatastore.ReadStartingWithUser(ctx, store, filter)
+	}, stopIterator, keepIterator)
+}
+
+// Close closes the datastore and cleans up any residual resources.
+func (h *hedgedOpenFGADatastore) Close() {
+	h.OpenFGADatastore.Close()
+}
+
+// hedgeDelay returns the current delay after which a hedged read is sent.
+func (h *hedgedOpenFGADatastore) hedgeDelay() time.Duration {
+	return time.Duration(h.delay.Load())
+}
+
+// observe records the latency of a read and periodically recomputes the hedging delay
+// from the most recent samples.
+func (h *hedgedOpenFGADatastore) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.latencies) < hedgingLatencySamples {
+		h.latencies = append(h.latencies, latency)
+	} else {
+		h.latencies[h.next] = latency
+		h.next = (h.next + 1) % hedgingLatencySamples
+	}
+
+	h.observations++
+	if h.observations%hedgingRecomputeInterval != 0 {
+		return
+	}
+
+	sorted := make([]time.Duration, len(h.latencies))
+	copy(sorted, h.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	idx := int(math.Ceil(h.percentile/100*float64(len(sorted)))) - 1
+	idx = max(0, min(idx, len(sorted)-1))
+
+	h.delay.Store(int64(max(sorted[idx], h.minDelay)))
+}
+
+func stopIterator(iter storage.TupleIterator) {
+	if iter != nil {
+		iter.Stop()
+	}
+}
+
+// stopCancelingIterator is the iterator of a hedged read, which cancels the context of the read once
+// it is stopped.
+type stopCancelingIterator struct {
+	storage.TupleIterator
+	cancel context.CancelFunc
+}
+
+func keepIterator(iter storage.TupleIterator, cancel context.CancelFunc) storage.TupleIterator {
+	return &stopCancelingIterator{TupleIterator: iter, cancel: cancel}
+}
+
+// Stop see [storage.TupleIterator].Stop.
+func (s *stopCancelingIterator) Stop() {
+	s.TupleIterator.Stop()
+	s.cancel()
+}
+
+type hedgedResult[T any] struct {
+	val     T
+	err     error
+	attempt int
+}
+
+// hedge runs fn and, if it has not responded after the current hedging delay, runs it a second
+// time. The first successful response is returned, or the error of the last call if both fail.
+// Every call runs with a context of its own, and the calls that are still running are cancelled
+// once a response is returned. Their responses are passed to discard once they arrive so that their
+// resources can be released. The context of the returned response is cancelled when hedge returns,
+// unless keep is set: the response is then passed to keep with the cancel function of its context,
+// e.g. to cancel it once an iterator is stopped, and keep returns the response to return.
+func hedge[T any](
+	ctx context.Context,
+	h *hedgedOpenFGADatastore,
+	method string,
+	fn func(ctx context.Context) (T, error),
+	discard func(T),
+	keep func(T, context.CancelFunc) T,
+) (T, error) {
+	results := make(chan hedgedResult[T], 2)
+	var cancels []context.CancelFunc
+
+	run := func() {
+		attempt := len(cancels)
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+
+		start := time.Now()
+		go func() {
+			val, err := fn(attemptCtx)
+			if err == nil && attempt == 0 {
+				h.observe(time.Since(start))
+			}
+			results <- hedgedResult[T]{val: val, err: err, attempt: attempt}
+		}()
+	}
+
+	run()
+	pending := 1
+
+	timer := time.NewTimer(h.hedgeDelay())
+	defer timer.Stop()
+
+	var zero T
+	var lastErr error
+	for pending > 0 {
+		select {
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				cancels[res.attempt]()
+				lastErr = res.err
+				continue
+			}
+
+			if res.attempt > 0 {
+				hedgedReadsWonCounter.WithLabelValues(method).Inc()
+			}
+			for attempt, cancel := range cancels {
+				if attempt != res.attempt {
+					cancel()
+				}
+			}
+			go drain(results, pending, method, discard)
+
+			if keep != nil {
+				return keep(res.val, cancels[res.attempt]), nil
+			}
+			cancels[res.attempt]()
+			return res.val, nil
+		case <-timer.C:
+			hedgedReadsCounter.WithLabelValues(method).Inc()
+			run()
+			pending++
+		case <-ctx.Done():
+			for _, cancel := range cancels {
+				cancel()
+			}
+			go drain(results, pending, method, discard)
+			return zero, ctx.Err()
+		}
+	}
+
+	return zero, lastErr
+}
+
+// drain waits for the n outstanding results of a hedged read and releases them.
+func drain[T any](results <-chan hedgedResult[T], n int, method string, discard func(T)) {
+	for i := 0; i < n; i++ {
+		res := <-results
+		hedgedReadsWastedCounter.WithLabelValues(method).Inc()
+		if res.err == nil && discard != nil {
+			discard(res.val)
+		}
+	}
+}
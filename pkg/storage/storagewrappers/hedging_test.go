@@ -0,0 +1,202 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openfga/openfga/internal/mocks"
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestHedgedReadUserTuple(t *testing.T) {
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	const storeID = "01HQ3Z4QZ8QZ8QZ8QZ8QZ8QZ8Q"
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	expected := &openfgav1.Tuple{Key: tk}
+
+	t.Run("fast_read_is_not_hedged", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(1).Return(expected, nil)
+
+		ds := NewHedgedOpenFGADatastore(mockDatastore, WithHedgingMinDelay(time.Second))
+
+		got, err := ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.NoError(t, err)
+		require.Equal(t, expected, got)
+	})
+
+	t.Run("slow_read_is_hedged_and_first_response_wins", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		var calls atomic.Int32
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(2).DoAndReturn(
+			func(ctx context.Context, store string, tk *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+				if calls.Add(1) == 1 {
+					// the original read is slow
+					time.Sleep(200 * time.Millisecond)
+					return nil, storage.ErrNotFound
+				}
+				return expected, nil
+			})
+
+		ds := NewHedgedOpenFGADatastore(mockDatastore, WithHedgingMinDelay(10*time.Millisecond))
+
+		start := time.Now()
+		got, err := ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.NoError(t, err)
+		require.Equal(t, expected, got)
+		require.Less(t, time.Since(start), 200*time.Millisecond)
+
+		// wait for the discarded read to finish
+		require.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, 10*time.Millisecond)
+		time.Sleep(250 * time.Millisecond)
+	})
+
+	t.Run("failed_read_falls_back_to_the_other_read", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		var calls atomic.Int32
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(2).DoAndReturn(
+			func(ctx context.Context, store string, tk *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+				if calls.Add(1) == 1 {
+					// the original read fails once the hedged read is sent
+					time.Sleep(50 * time.Millisecond)
+					return nil, errors.New("connection reset")
+				}
+				time.Sleep(100 * time.Millisecond)
+				return expected, nil
+			})
+
+		ds := NewHedgedOpenFGADatastore(mockDatastore, WithHedgingMinDelay(10*time.Millisecond))
+
+		got, err := ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.NoError(t, err)
+		require.Equal(t, expected, got)
+	})
+
+	t.Run("last_error_is_returned_if_both_reads_fail", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		var calls atomic.Int32
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(2).DoAndReturn(
+			func(ctx context.Context, store string, tk *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+				if calls.Add(1) == 1 {
+					time.Sleep(50 * time.Millisecond)
+					return nil, errors.New("connection reset")
+				}
+				time.Sleep(100 * time.Millisecond)
+				return nil, storage.ErrNotFound
+			})
+
+		ds := NewHedgedOpenFGADatastore(mockDatastore, WithHedgingMinDelay(10*time.Millisecond))
+
+		_, err := ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("discarded_read_is_cancelled", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		var calls atomic.Int32
+		cancelled := make(chan struct{})
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().ReadUserTuple(gomock.Any(), storeID, tk).Times(2).DoAndReturn(
+			func(ctx context.Context, store string, tk *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+				if calls.Add(1) == 1 {
+					<-ctx.Done()
+					close(cancelled)
+					return nil, ctx.Err()
+				}
+				return expected, nil
+			})
+
+		ds := NewHedgedOpenFGADatastore(mockDatastore, WithHedgingMinDelay(10*time.Millisecond))
+
+		got, err := ds.ReadUserTuple(context.Background(), storeID, tk)
+		require.NoError(t, err)
+		require.Equal(t, expected, got)
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			require.Fail(t, "the discarded read was not cancelled")
+		}
+	})
+
+	t.Run("discarded_iterator_is_stopped", func(t *testing.T) {
+		mockController := gomock.NewController(t)
+		defer mockController.Finish()
+
+		slowIter := &stopTrackingIterator{TupleIterator: storage.NewStaticTupleIterator(nil)}
+		var calls atomic.Int32
+		mockDatastore := mocks.NewMockOpenFGADatastore(mockController)
+		mockDatastore.EXPECT().Read(gomock.Any(), storeID, tk).Times(2).DoAndReturn(
+			func(ctx context.Context, store string, tk *openfgav1.TupleKey) (storage.TupleIterator, error) {
+				if calls.Add(1) == 1 {
+					time.Sleep(100 * time.Millisecond)
+					return slowIter, nil
+				}
+				return storage.NewStaticTupleIterator([]*openfgav1.Tuple{expected}), nil
+			})
+
+		ds := NewHedgedOpenFGADatastore(mockDatastore, WithHedgingMinDelay(10*time.Millisecond))
+
+		iter, err := ds.Read(context.Background(), storeID, tk)
+		require.NoError(t, err)
+		defer iter.Stop()
+
+		got, err := iter.Next(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expected, got)
+
+		require.Eventually(t, slowIter.stopped.Load, time.Second, 10*time.Millisecond)
+	})
+}
+
+type stopTrackingIterator struct {
+	storage.TupleIterator
+	stopped atomic.Bool
+}
+
+func (s *stopTrackingIterator) Stop() {
+	s.stopped.Store(true)
+	s.TupleIterator.Stop()
+}
+
+func TestHedgedDelayTracksPercentile(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	ds := NewHedgedOpenFGADatastore(mocks.NewMockOpenFGADatastore(mockController),
+		WithHedgingPercentile(90),
+		WithHedgingMinDelay(time.Millisecond),
+	)
+	require.Equal(t, time.Millisecond, ds.hedgeDelay())
+
+	for i := 1; i <= hedgingRecomputeInterval; i++ {
+		ds.observe(time.Duration(i) * time.Millisecond)
+	}
+	require.Equal(t, 90*time.Millisecond, ds.hedgeDelay())
+}
@@ -0,0 +1,421 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/openfga/openfga/internal/build"
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// otherStoresLabel is the store_id label value used for the stores beyond the store label limit.
+const otherStoresLabel = "other"
+
+var (
+	datastoreQueryDurationHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:                       build.ProjectName,
+		Name:                            "datastore_query_duration_ms",
+		Help:                            "The duration (in ms) of datastore queries, by datastore method. For methods that return an iterator, it is the time taken to return the iterator.",
+		Buckets:                         []float64{1, 3, 5, 10, 25, 50, 100, 1000, 5000}, // Milliseconds. Upper bound is config.UpstreamTimeout.
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: time.Hour,
+	}, []string{"method", "store_id"})
+
+	datastoreQueryErrorsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_query_errors_total",
+		Help:      "The total number of datastore queries that failed, by datastore method. Not found errors are not counted.",
+	}, []string{"method", "store_id"})
+
+	datastoreRowsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "datastore_rows_total",
+		Help:      "The total number of rows (tuples, changes, models, stores or assertions) returned by the datastore, by datastore method.",
+	}, []string{"method", "store_id"})
+)
+
+var _ storage.OpenFGADatastore = (*metricsOpenFGADatastore)(nil)
+
+// MetricsOption defines an option that can be used to change the behavior of the
+// datastore metrics wrapper.
+type MetricsOption func(m *metricsOpenFGADatastore)
+
+// WithStoreLabelLimit labels the datastore metrics with the ID of the store of each query, for
+// at most limit distinct stores. Queries of any other store are labeled with "other". This bounds
+// the cardinality of the metrics. If limit is 0, which is the default, metrics are not labeled
+// with the store ID.
+func WithStoreLabelLimit(limit int) MetricsOption {
+	return func(m *metricsOpenFGADatastore) {
+		m.storeLabelLimit = limit
+	}
+}
+
+type metricsOpenFGADatastore struct {
+	storage.OpenFGADatastore
+
+	storeLabelLimit int
+
+	mu          sync.RWMutex
+	storeLabels map[string]struct{}
+}
+
+// NewMetricsOpenFGADatastore returns a wrapper over a datastore that records the latency, the
+// number of errors and the number of rows returned by each datastore method. Optionally, see
+// WithStoreLabelLimit, the metrics are also labeled with the store ID to find the stores that
+// drive the database load.
+func NewMetricsOpenFGADatastore(inner storage.OpenFGADatastore, opts ...MetricsOption) *metricsOpenFGADatastore {
+	m := &metricsOpenFGADatastore{
+		OpenFGADatastore: inner,
+		storeLabels:      map[string]struct{}{},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// storeLabel returns the store_id label value for the provided store.
+func (m *metricsOpenFGADatastore) storeLabel(store string) string {
+	if m.storeLabelLimit <= 0 || store == "" {
+		return ""
+	}
+
+	m.mu.RLock()
+	_, ok := m.storeLabels[store]
+	m.mu.RUnlock()
+	if ok {
+		return store
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.storeLabels[store]; ok {
+		return store
+	}
+
+	if len(m.storeLabels) >= m.storeLabelLimit {
+		return otherStoresLabel
+	}
+
+	m.storeLabels[store] = struct{}{}
+	return store
+}
+
+// observe records the duration and the outcome of a datastore query, and the number of rows it returned.
+func (m *metricsOpenFGADatastore) observe(method, store string, start time.Time, rows int, err error) {
+	label := m.storeLabel(store)
+
+	datastoreQueryDurationHistogram.WithLabelValues(method, label).Observe(float64(time.Since(start).Milliseconds()))
+
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			datastoreQueryErrorsCounter.WithLabelValues(method, label).Inc()
+		}
+		return
+	}
+
+	if rows > 0 {
+		datastoreRowsCounter.WithLabelValues(method, label).Add(float64(rows))
+	}
+}
+
+// observeIterator records the duration and the outcome of a datastore query that returns an iterator.
+// Rows are counted as they are read from the returned iterator.
+func (m *metricsOpenFGADatastore) observeIterator(method, store string, start time.Time, iter storage.TupleIterator, err error) storage.TupleIterator {
+	m.observe(method, store, start, 0, err)
+	if err != nil {
+		return iter
+	}
+
+	return &metricsTupleIterator{
+		TupleIterator: iter,
+		rows:          datastoreRowsCounter.WithLabelValues(method, m.storeLabel(store)),
+	}
+}
+
+type metricsTupleIterator struct {
+	storage.TupleIterator
+	rows prometheus.Counter
+}
+
+// Next see [storage.Iterator].Next.
+func (i *metricsTupleIterator) Next(ctx context.Context) (*openfgav1.Tuple, error) {
+	t, err := i.TupleIterator.Next(ctx)
+	if err == nil {
+		i.rows.Inc()
+	}
+
+	return t, err
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (m *metricsOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+	start := time.Now()
+	iter, err := m.OpenFGADatastore.Read(ctx, store, tupleKey)
+	return m.observeIterator("Read", store, start, iter, err), err
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (m *metricsOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+	start := time.Now()
+	tuples, token, err := m.OpenFGADatastore.ReadPage(ctx, store, tupleKey, opts)
+	m.observe("ReadPage", store, start, len(tuples), err)
+	return tuples, token, err
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (m *metricsOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	start := time.Now()
+	t, err := m.OpenFGADatastore.ReadUserTuple(ctx, store, tupleKey)
+	m.observe("ReadUserTuple", store, start, 1, err)
+	return t, err
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (m *metricsOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+	start := time.Now()
+	iter, err := m.OpenFGADatastore.ReadUsersetTuples(ctx, store, filter)
+	return m.observeIterator("ReadUsersetTuples", store, start, iter, err), err
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (m *metricsOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
+	start := time.Now()
+	iter, err := m.OpenFGADatastore.ReadStartingWithUser(ctx, store, filter)
+	return m.observeIterator("ReadStartingWithUser", store, start, iter, err), err
+}
+
+// Write see [storage.RelationshipTupleWriter].Write.
+func (m *metricsOpenFGADatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.Write(ctx, store, deletes, writes)
+	m.observe("Write", store, start, 0, err)
+	return err
+}
+
+// WriteStores see [storage.MultiStoreWriteBackend].WriteStores.
+func (m *metricsOpenFGADatastore) WriteStores(ctx context.Context, writes []storage.StoreWrite) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.WriteStores(ctx, writes)
+	m.observe("WriteStores", "", start, 0, err)
+	return err
+}
+
+// ReadAuthorizationModel see [storage.AuthorizationModelReadBackend].ReadAuthorizationModel.
+func (m *metricsOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, store string, id string) (*openfgav1.AuthorizationModel, error) {
+	start := time.Now()
+	model, err := m.OpenFGADatastore.ReadAuthorizationModel(ctx, store, id)
+	m.observe("ReadAuthorizationModel", store, start, 1, err)
+	return model, err
+}
+
+// ReadAuthorizationModels see [storage.AuthorizationModelReadBackend].ReadAuthorizationModels.
+func (m *metricsOpenFGADatastore) ReadAuthorizationModels(ctx context.Context, store string, opts storage.PaginationOptions) ([]*openfgav1.AuthorizationModel, []byte, error) {
+	start := time.Now()
+	models, token, err := m.OpenFGADatastore.ReadAuthorizationModels(ctx, store, opts)
+	m.observe("ReadAuthorizationModels", store, start, len(models), err)
+	return models, token, err
+}
+
+// FindLatestAuthorizationModel see [storage.AuthorizationModelReadBackend].FindLatestAuthorizationModel.
+func (m *metricsOpenFGADatastore) FindLatestAuthorizationModel(ctx context.Context, store string) (*openfgav1.AuthorizationModel, error) {
+	start := time.Now()
+	model, err := m.OpenFGADatastore.FindLatestAuthorizationModel(ctx, store)
+	m.observe("FindLatestAuthorizationModel", store, start, 1, err)
+	return model, err
+}
+
+// WriteAuthorizationModel see [storage.TypeDefinitionWriteBackend].WriteAuthorizationModel.
+func (m *metricsOpenFGADatastore) WriteAuthorizationModel(ctx context.Context, store string, model *openfgav1.AuthorizationModel) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.WriteAuthorizationModel(ctx, store, model)
+	m.observe("WriteAuthorizationModel", store, start, 0, err)
+	return err
+}
+
+// CreateStore see [storage.StoresBackend].CreateStore.
+func (m *metricsOpenFGADatastore) CreateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	start := time.Now()
+	created, err := m.OpenFGADatastore.CreateStore(ctx, store)
+	m.observe("CreateStore", store.GetId(), start, 0, err)
+	return created, err
+}
+
+// DeleteStore see [storage.StoresBackend].DeleteStore.
+func (m *metricsOpenFGADatastore) DeleteStore(ctx context.Context, id string) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.DeleteStore(ctx, id)
+	m.observe("DeleteStore", id, start, 0, err)
+	return err
+}
+
+// GetStore see [storage.StoresBackend].GetStore.
+func (m *metricsOpenFGADatastore) GetStore(ctx context.Context, id string) (*openfgav1.Store, error) {
+	start := time.Now()
+	store, err := m.OpenFGADatastore.GetStore(ctx, id)
+	m.observe("GetStore", id, start, 1, err)
+	return store, err
+}
+
+// ListStores see [storage.StoresBackend].ListStores.
+func (m *metricsOpenFGADatastore) ListStores(
+	ctx context.Context,
+	filter storage.ListStoresFilter,
+	opts storage.PaginationOptions,
+) ([]*openfgav1.Store, []byte, error) {
+	start := time.Now()
+	stores, token, err := m.OpenFGADatastore.ListStores(ctx, filter, opts)
+	m.observe("ListStores", "", start, len(stores), err)
+	return stores, token, err
+}
+
+// UpdateStore see [storage.StoresBackend].UpdateStore.
+func (m *metricsOpenFGADatastore) UpdateStore(ctx context.Context, store *openfgav1.Store) (*openfgav1.Store, error) {
+	start := time.Now()
+	updated, err := m.OpenFGADatastore.UpdateStore(ctx, store)
+	m.observe("UpdateStore", store.GetId(), start, 0, err)
+	return updated, err
+}
+
+// WriteStoreLabels see [storage.StoresBackend].WriteStoreLabels.
+func (m *metricsOpenFGADatastore) WriteStoreLabels(ctx context.Context, store string, labels map[string]string) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.WriteStoreLabels(ctx, store, labels)
+	m.observe("WriteStoreLabels", store, start, 0, err)
+	return err
+}
+
+// ReadStoreLabels see [storage.StoresBackend].ReadStoreLabels.
+func (m *metricsOpenFGADatastore) ReadStoreLabels(ctx context.Context, stores []string) (map[string]map[string]string, error) {
+	start := time.Now()
+	labels, err := m.OpenFGADatastore.ReadStoreLabels(ctx, stores)
+	m.observe("ReadStoreLabels", "", start, len(labels), err)
+	return labels, err
+}
+
+// ReadTupleWriters see [storage.TupleWritersBackend].ReadTupleWriters.
+func (m *metricsOpenFGADatastore) ReadTupleWriters(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error) {
+	start := time.Now()
+	writers, err := m.OpenFGADatastore.ReadTupleWriters(ctx, store, tupleKeys)
+	m.observe("ReadTupleWriters", store, start, len(writers), err)
+	return writers, err
+}
+
+// ReadChangeWriters see [storage.TupleWritersBackend].ReadChangeWriters.
+func (m *metricsOpenFGADatastore) ReadChangeWriters(ctx context.Context, store string, changes []*openfgav1.TupleChange) ([]string, error) {
+	start := time.Now()
+	writers, err := m.OpenFGADatastore.ReadChangeWriters(ctx, store, changes)
+	m.observe("ReadChangeWriters", store, start, len(writers), err)
+	return writers, err
+}
+
+// WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
+func (m *metricsOpenFGADatastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.WriteAssertions(ctx, store, modelID, assertions)
+	m.observe("WriteAssertions", store, start, 0, err)
+	return err
+}
+
+// ReadAssertions see [storage.AssertionsBackend].ReadAssertions.
+func (m *metricsOpenFGADatastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+	start := time.Now()
+	assertions, err := m.OpenFGADatastore.ReadAssertions(ctx, store, modelID)
+	m.observe("ReadAssertions", store, start, len(assertions), err)
+	return assertions, err
+}
+
+// ReadChanges see [storage.ChangelogBackend].ReadChanges.
+func (m *metricsOpenFGADatastore) ReadChanges(ctx context.Context, store string, filter storage.ReadChangesFilter, opts storage.PaginationOptions, horizonOffset time.Duration) ([]*openfgav1.TupleChange, []byte, error) {
+	start := time.Now()
+	changes, token, err := m.OpenFGADatastore.ReadChanges(ctx, store, filter, opts, horizonOffset)
+	m.observe("ReadChanges", store, start, len(changes), err)
+	return changes, token, err
+}
+
+// ChangeExists see [storage.ChangelogBackend].ChangeExists.
+func (m *metricsOpenFGADatastore) ChangeExists(ctx context.Context, store, id string) (bool, error) {
+	start := time.Now()
+	exists, err := m.OpenFGADatastore.ChangeExists(ctx, store, id)
+	m.observe("ChangeExists", store, start, 1, err)
+	return exists, err
+}
+
+// WriteStoreSettings see [storage.StoreSettingsBackend].WriteStoreSettings.
+func (m *metricsOpenFGADatastore) WriteStoreSettings(ctx context.Context, store string, settings *storage.StoreSettings) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.WriteStoreSettings(ctx, store, settings)
+	m.observe("WriteStoreSettings", store, start, 0, err)
+	return err
+}
+
+// ReadStoreSettings see [storage.StoreSettingsBackend].ReadStoreSettings.
+func (m *metricsOpenFGADatastore) ReadStoreSettings(ctx context.Context, store string) (*storage.StoreSettings, error) {
+	start := time.Now()
+	settings, err := m.OpenFGADatastore.ReadStoreSettings(ctx, store)
+	m.observe("ReadStoreSettings", store, start, 1, err)
+	return settings, err
+}
+
+// WritePlannerStatistics see [storage.PlannerStatisticsBackend].WritePlannerStatistics.
+func (m *metricsOpenFGADatastore) WritePlannerStatistics(ctx context.Context, store string, statistics []byte) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.WritePlannerStatistics(ctx, store, statistics)
+	m.observe("WritePlannerStatistics", store, start, 0, err)
+	return err
+}
+
+// ReadPlannerStatistics see [storage.PlannerStatisticsBackend].ReadPlannerStatistics.
+func (m *metricsOpenFGADatastore) ReadPlannerStatistics(ctx context.Context, store string) ([]byte, error) {
+	start := time.Now()
+	statistics, err := m.OpenFGADatastore.ReadPlannerStatistics(ctx, store)
+	m.observe("ReadPlannerStatistics", store, start, 1, err)
+	return statistics, err
+}
+
+// AddRelationUsage see [storage.RelationUsageBackend].AddRelationUsage.
+func (m *metricsOpenFGADatastore) AddRelationUsage(ctx context.Context, store string, usage []storage.RelationUsage) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.AddRelationUsage(ctx, store, usage)
+	m.observe("AddRelationUsage", store, start, 0, err)
+	return err
+}
+
+// ReadRelationUsage see [storage.RelationUsageBackend].ReadRelationUsage.
+func (m *metricsOpenFGADatastore) ReadRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	start := time.Now()
+	usage, err := m.OpenFGADatastore.ReadRelationUsage(ctx, store)
+	m.observe("ReadRelationUsage", store, start, len(usage), err)
+	return usage, err
+}
+
+// WriteChangelogCheckpoint see [storage.ChangelogCheckpointBackend].WriteChangelogCheckpoint.
+func (m *metricsOpenFGADatastore) WriteChangelogCheckpoint(ctx context.Context, store, consumer, continuationToken string) error {
+	start := time.Now()
+	err := m.OpenFGADatastore.WriteChangelogCheckpoint(ctx, store, consumer, continuationToken)
+	m.observe("WriteChangelogCheckpoint", store, start, 0, err)
+	return err
+}
+
+// ReadChangelogCheckpoint see [storage.ChangelogCheckpointBackend].ReadChangelogCheckpoint.
+func (m *metricsOpenFGADatastore) ReadChangelogCheckpoint(ctx context.Context, store, consumer string) (string, error) {
+	start := time.Now()
+	continuationToken, err := m.OpenFGADatastore.ReadChangelogCheckpoint(ctx, store, consumer)
+	m.observe("ReadChangelogCheckpoint", store, start, 1, err)
+	return continuationToken, err
+}
+
+// Close closes the datastore and cleans up any residual resources.
+func (m *metricsOpenFGADatastore) Close() {
+	m.OpenFGADatastore.Close()
+}
@@ -0,0 +1,75 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestMetricsOpenFGADatastore(t *testing.T) {
+	ctx := context.Background()
+
+	ds := NewMetricsOpenFGADatastore(memory.New(), WithStoreLabelLimit(1))
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	otherStoreID := ulid.Make().String()
+
+	tuples := []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+		tuple.NewTupleKey("document:2", "viewer", "user:jon"),
+	}
+	require.NoError(t, ds.Write(ctx, storeID, nil, tuples))
+	require.NoError(t, ds.Write(ctx, otherStoreID, nil, tuples))
+
+	iter, err := ds.Read(ctx, storeID, tuple.NewTupleKey("document:", "viewer", ""))
+	require.NoError(t, err)
+	for {
+		_, err := iter.Next(ctx)
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrIteratorDone)
+			break
+		}
+	}
+	iter.Stop()
+
+	_, err = ds.ReadUserTuple(ctx, otherStoreID, tuple.NewTupleKey("document:3", "viewer", "user:jon"))
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	require.InDelta(t, 2, testutil.ToFloat64(datastoreRowsCounter.WithLabelValues("Read", storeID)), 0)
+
+	// the store label limit is 1, so the other store is labeled as "other" and not found errors are not counted
+	var m dto.Metric
+	require.NoError(t, datastoreQueryDurationHistogram.WithLabelValues("ReadUserTuple", otherStoresLabel).(prometheus.Histogram).Write(&m))
+	require.Positive(t, m.GetHistogram().GetSampleCount())
+	require.Zero(t, testutil.ToFloat64(datastoreQueryErrorsCounter.WithLabelValues("ReadUserTuple", otherStoresLabel)))
+}
+
+func TestStoreLabel(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		ds := NewMetricsOpenFGADatastore(memory.New())
+		t.Cleanup(ds.Close)
+
+		require.Empty(t, ds.storeLabel("store"))
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		ds := NewMetricsOpenFGADatastore(memory.New(), WithStoreLabelLimit(2))
+		t.Cleanup(ds.Close)
+
+		require.Equal(t, "a", ds.storeLabel("a"))
+		require.Equal(t, "b", ds.storeLabel("b"))
+		require.Equal(t, otherStoresLabel, ds.storeLabel("c"))
+		require.Equal(t, "a", ds.storeLabel("a"))
+	})
+}
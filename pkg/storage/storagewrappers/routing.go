@@ -0,0 +1,433 @@
+package storagewrappers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/karlseguin/ccache/v3"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// routingCacheTTL is how long the datastore of a store routed by its label is cached. The labels
+// updated through another server apply after at most this duration.
+const routingCacheTTL = 10 * time.Second
+
+var (
+	_ storage.OpenFGADatastore    = (*routingOpenFGADatastore)(nil)
+	_ storage.TupleChangeNotifier = (*routingOpenFGADatastore)(nil)
+)
+
+// RoutingOption defines an option that can be used to change the behavior of the routing
+// datastore wrapper.
+type RoutingOption func(r *routingOpenFGADatastore)
+
+// WithRoutingStores routes the stores with the IDs of the keys to the datastores named by the
+// values. They take precedence over the label of WithRoutingLabelKey.
+func WithRoutingStores(stores map[string]string) RoutingOption {
+	return func(r *routingOpenFGADatastore) {
+		r.stores = stores
+	}
+}
+
+// WithRoutingLabelKey routes the stores with a label of this key, e.g. 'region', to the datastore
+// named by the value of the label.
+func WithRoutingLabelKey(key string) RoutingOption {
+	return func(r *routingOpenFGADatastore) {
+		r.labelKey = key
+	}
+}
+
+type routingOpenFGADatastore struct {
+	// OpenFGADatastore is the default datastore. It has the stores and their labels, and the data of
+	// the stores that aren't routed to another datastore.
+	storage.OpenFGADatastore
+
+	datastores map[string]storage.OpenFGADatastore
+	stores     map[string]string
+	labelKey   string
+
+	lookupGroup singleflight.Group
+	cache       *ccache.Cache[storage.OpenFGADatastore]
+}
+
+// NewRoutingOpenFGADatastore returns a wrapper over datastores, by name, that keeps the data of each
+// store, i.e. its tuples, changelog, models, assertions, settings and planner statistics, in the
+// datastore it is routed to, e.g. a regional database for data residency. The stores themselves and
+// their labels are kept in the default datastore, named defaultName, which also has the data of the
+// stores that aren't routed. Changing the datastore of a store doesn't move its data.
+//
+// The label of a store is validated when it is written: it must name one of the datastores.
+func NewRoutingOpenFGADatastore(defaultName string, datastores map[string]storage.OpenFGADatastore, opts ...RoutingOption) (*routingOpenFGADatastore, error) {
+	defaultDatastore, ok := datastores[defaultName]
+	if !ok {
+		return nil, fmt.Errorf("the default datastore '%s' is missing", defaultName)
+	}
+
+	r := &routingOpenFGADatastore{
+		OpenFGADatastore: defaultDatastore,
+		datastores:       datastores,
+		cache:            ccache.New(ccache.Configure[storage.OpenFGADatastore]()),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for store, name := range r.stores {
+		if _, ok := datastores[name]; !ok {
+			return nil, fmt.Errorf("the store '%s' is routed to the unknown datastore '%s'", store, name)
+		}
+	}
+
+	return r, nil
+}
+
+// names returns the sorted names of the datastores.
+func (r *routingOpenFGADatastore) names() []string {
+	names := make([]string, 0, len(r.datastores))
+	for name := range r.datastores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// route returns the datastore of a store.
+func (r *routingOpenFGADatastore) route(ctx context.Context, store string) (storage.OpenFGADatastore, error) {
+	if name, ok := r.stores[store]; ok {
+		return r.datastores[name], nil
+	}
+
+	if r.labelKey == "" {
+		return r.OpenFGADatastore, nil
+	}
+
+	if item := r.cache.Get(store); item != nil && !item.Expired() {
+		return item.Value(), nil
+	}
+
+	v, err, _ := r.lookupGroup.Do(store, func() (interface{}, error) {
+		labels, err := r.OpenFGADatastore.ReadStoreLabels(ctx, []string{store})
+		if err != nil {
+			return nil, err
+		}
+
+		datastore := r.OpenFGADatastore
+		if name, ok := labels[store][r.labelKey]; ok {
+			datastore, ok = r.datastores[name]
+			if !ok {
+				return nil, fmt.Errorf("the store '%s' is routed to the unknown datastore '%s' by its '%s' label", store, name, r.labelKey)
+			}
+		}
+
+		r.cache.Set(store, datastore, routingCacheTTL)
+		return datastore, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(storage.OpenFGADatastore), nil
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (r *routingOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.Read(ctx, store, tupleKey)
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (r *routingOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	return datastore.ReadPage(ctx, store, tupleKey, opts)
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (r *routingOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadUserTuple(ctx, store, tupleKey)
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (r *routingOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadUsersetTuples(ctx, store, filter)
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (r *routingOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadStartingWithUser(ctx, store, filter)
+}
+
+// Write see [storage.RelationshipTupleWriter].Write.
+func (r *routingOpenFGADatastore) Write(ctx context.Context, store string, deletes storage.Deletes, writes storage.Writes) error {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return err
+	}
+	return datastore.Write(ctx, store, deletes, writes)
+}
+
+// WriteStores see [storage.MultiStoreWriteBackend].WriteStores. The stores must be routed to the same
+// datastore, otherwise it returns [storage.ErrMultiStoreWriteNotSupported].
+func (r *routingOpenFGADatastore) WriteStores(ctx context.Context, writes []storage.StoreWrite) error {
+	var datastore storage.OpenFGADatastore
+	for _, w := range writes {
+		routed, err := r.route(ctx, w.Store)
+		if err != nil {
+			return err
+		}
+		if datastore != nil && routed != datastore {
+			return storage.ErrMultiStoreWriteNotSupported
+		}
+		datastore = routed
+	}
+
+	if datastore == nil {
+		return nil
+	}
+	return datastore.WriteStores(ctx, writes)
+}
+
+// ReadAuthorizationModel see [storage.AuthorizationModelReadBackend].ReadAuthorizationModel.
+func (r *routingOpenFGADatastore) ReadAuthorizationModel(ctx context.Context, store string, id string) (*openfgav1.AuthorizationModel, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadAuthorizationModel(ctx, store, id)
+}
+
+// ReadAuthorizationModels see [storage.AuthorizationModelReadBackend].ReadAuthorizationModels.
+func (r *routingOpenFGADatastore) ReadAuthorizationModels(ctx context.Context, store string, opts storage.PaginationOptions) ([]*openfgav1.AuthorizationModel, []byte, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	return datastore.ReadAuthorizationModels(ctx, store, opts)
+}
+
+// FindLatestAuthorizationModel see [storage.AuthorizationModelReadBackend].FindLatestAuthorizationModel.
+func (r *routingOpenFGADatastore) FindLatestAuthorizationModel(ctx context.Context, store string) (*openfgav1.AuthorizationModel, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.FindLatestAuthorizationModel(ctx, store)
+}
+
+// WriteAuthorizationModel see [storage.TypeDefinitionWriteBackend].WriteAuthorizationModel.
+func (r *routingOpenFGADatastore) WriteAuthorizationModel(ctx context.Context, store string, model *openfgav1.AuthorizationModel) error {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return err
+	}
+	return datastore.WriteAuthorizationModel(ctx, store, model)
+}
+
+// WriteStoreLabels see [storage.StoresBackend].WriteStoreLabels. The routing label must name one of
+// the datastores.
+func (r *routingOpenFGADatastore) WriteStoreLabels(ctx context.Context, store string, labels map[string]string) error {
+	if name, ok := labels[r.labelKey]; ok && r.labelKey != "" {
+		if _, ok := r.datastores[name]; !ok {
+			return fmt.Errorf("%w: the '%s' label of a store must be the name of a datastore, one of %s",
+				storage.ErrInvalidWriteInput, r.labelKey, strings.Join(r.names(), ", "))
+		}
+	}
+
+	err := r.OpenFGADatastore.WriteStoreLabels(ctx, store, labels)
+	r.cache.Delete(store)
+
+	return err
+}
+
+// ReadTupleWriters see [storage.TupleWritersBackend].ReadTupleWriters.
+func (r *routingOpenFGADatastore) ReadTupleWriters(ctx context.Context, store string, tupleKeys []*openfgav1.TupleKey) ([]string, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadTupleWriters(ctx, store, tupleKeys)
+}
+
+// ReadChangeWriters see [storage.TupleWritersBackend].ReadChangeWriters.
+func (r *routingOpenFGADatastore) ReadChangeWriters(ctx context.Context, store string, changes []*openfgav1.TupleChange) ([]string, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadChangeWriters(ctx, store, changes)
+}
+
+// WriteAssertions see [storage.AssertionsBackend].WriteAssertions.
+func (r *routingOpenFGADatastore) WriteAssertions(ctx context.Context, store, modelID string, assertions []*openfgav1.Assertion) error {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return err
+	}
+	return datastore.WriteAssertions(ctx, store, modelID, assertions)
+}
+
+// ReadAssertions see [storage.AssertionsBackend].ReadAssertions.
+func (r *routingOpenFGADatastore) ReadAssertions(ctx context.Context, store, modelID string) ([]*openfgav1.Assertion, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadAssertions(ctx, store, modelID)
+}
+
+// ReadChanges see [storage.ChangelogBackend].ReadChanges.
+func (r *routingOpenFGADatastore) ReadChanges(ctx context.Context, store string, filter storage.ReadChangesFilter, opts storage.PaginationOptions, horizonOffset time.Duration) ([]*openfgav1.TupleChange, []byte, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	return datastore.ReadChanges(ctx, store, filter, opts, horizonOffset)
+}
+
+// ChangeExists see [storage.ChangelogBackend].ChangeExists.
+func (r *routingOpenFGADatastore) ChangeExists(ctx context.Context, store, id string) (bool, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return false, err
+	}
+	return datastore.ChangeExists(ctx, store, id)
+}
+
+// WriteStoreSettings see [storage.StoreSettingsBackend].WriteStoreSettings.
+func (r *routingOpenFGADatastore) WriteStoreSettings(ctx context.Context, store string, settings *storage.StoreSettings) error {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return err
+	}
+	return datastore.WriteStoreSettings(ctx, store, settings)
+}
+
+// ReadStoreSettings see [storage.StoreSettingsBackend].ReadStoreSettings.
+func (r *routingOpenFGADatastore) ReadStoreSettings(ctx context.Context, store string) (*storage.StoreSettings, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadStoreSettings(ctx, store)
+}
+
+// WritePlannerStatistics see [storage.PlannerStatisticsBackend].WritePlannerStatistics.
+func (r *routingOpenFGADatastore) WritePlannerStatistics(ctx context.Context, store string, statistics []byte) error {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return err
+	}
+	return datastore.WritePlannerStatistics(ctx, store, statistics)
+}
+
+// ReadPlannerStatistics see [storage.PlannerStatisticsBackend].ReadPlannerStatistics.
+func (r *routingOpenFGADatastore) ReadPlannerStatistics(ctx context.Context, store string) ([]byte, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadPlannerStatistics(ctx, store)
+}
+
+// AddRelationUsage see [storage.RelationUsageBackend].AddRelationUsage.
+func (r *routingOpenFGADatastore) AddRelationUsage(ctx context.Context, store string, usage []storage.RelationUsage) error {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return err
+	}
+	return datastore.AddRelationUsage(ctx, store, usage)
+}
+
+// ReadRelationUsage see [storage.RelationUsageBackend].ReadRelationUsage.
+func (r *routingOpenFGADatastore) ReadRelationUsage(ctx context.Context, store string) ([]storage.RelationUsage, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return datastore.ReadRelationUsage(ctx, store)
+}
+
+// WriteChangelogCheckpoint see [storage.ChangelogCheckpointBackend].WriteChangelogCheckpoint.
+func (r *routingOpenFGADatastore) WriteChangelogCheckpoint(ctx context.Context, store, consumer, continuationToken string) error {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return err
+	}
+	return datastore.WriteChangelogCheckpoint(ctx, store, consumer, continuationToken)
+}
+
+// ReadChangelogCheckpoint see [storage.ChangelogCheckpointBackend].ReadChangelogCheckpoint.
+func (r *routingOpenFGADatastore) ReadChangelogCheckpoint(ctx context.Context, store, consumer string) (string, error) {
+	datastore, err := r.route(ctx, store)
+	if err != nil {
+		return "", err
+	}
+	return datastore.ReadChangelogCheckpoint(ctx, store, consumer)
+}
+
+// WatchTupleChanges see [storage.TupleChangeNotifier].WatchTupleChanges. It watches the tuple
+// changes of all the datastores that notify them.
+func (r *routingOpenFGADatastore) WatchTupleChanges(ctx context.Context, onChange func(store string)) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, datastore := range r.datastores {
+		if notifier, ok := datastore.(storage.TupleChangeNotifier); ok {
+			g.Go(func() error {
+				return notifier.WatchTupleChanges(ctx, onChange)
+			})
+		}
+	}
+
+	return g.Wait()
+}
+
+// IsReady reports whether all the datastores are ready. The status of a ready datastore is the status
+// of the default datastore.
+func (r *routingOpenFGADatastore) IsReady(ctx context.Context) (storage.ReadinessStatus, error) {
+	for _, name := range r.names() {
+		status, err := r.datastores[name].IsReady(ctx)
+		if err != nil {
+			return status, fmt.Errorf("datastore '%s': %w", name, err)
+		}
+
+		if !status.IsReady {
+			status.Message = fmt.Sprintf("datastore '%s': %s", name, status.Message)
+			return status, nil
+		}
+	}
+
+	return r.OpenFGADatastore.IsReady(ctx)
+}
+
+// Close closes all the datastores.
+func (r *routingOpenFGADatastore) Close() {
+	r.cache.Stop()
+	for _, datastore := range r.datastores {
+		datastore.Close()
+	}
+}
@@ -0,0 +1,125 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestRoutingOpenFGADatastore(t *testing.T) {
+	ctx := context.Background()
+
+	us := memory.New()
+	eu := memory.New()
+	pinned := ulid.Make().String()
+
+	_, err := NewRoutingOpenFGADatastore("us", map[string]storage.OpenFGADatastore{"eu": eu})
+	require.ErrorContains(t, err, "default datastore 'us'")
+
+	_, err = NewRoutingOpenFGADatastore("us", map[string]storage.OpenFGADatastore{"us": us, "eu": eu},
+		WithRoutingStores(map[string]string{pinned: "apac"}))
+	require.ErrorContains(t, err, "unknown datastore 'apac'")
+
+	ds, err := NewRoutingOpenFGADatastore("us", map[string]storage.OpenFGADatastore{"us": us, "eu": eu},
+		WithRoutingStores(map[string]string{pinned: "eu"}),
+		WithRoutingLabelKey("region"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(ds.Close)
+
+	createStore := func(t *testing.T, labels map[string]string) string {
+		store, err := ds.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: "residency"})
+		require.NoError(t, err)
+
+		if labels != nil {
+			require.NoError(t, ds.WriteStoreLabels(ctx, store.GetId(), labels))
+		}
+		return store.GetId()
+	}
+
+	tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+	requireHome := func(t *testing.T, store string, home, other storage.OpenFGADatastore) {
+		require.NoError(t, ds.Write(ctx, store, nil, []*openfgav1.TupleKey{tk}))
+
+		_, err := home.ReadUserTuple(ctx, store, tk)
+		require.NoError(t, err)
+		_, err = other.ReadUserTuple(ctx, store, tk)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = ds.ReadUserTuple(ctx, store, tk)
+		require.NoError(t, err)
+	}
+
+	t.Run("stores_without_the_label_use_the_default_datastore", func(t *testing.T) {
+		requireHome(t, createStore(t, map[string]string{"team": "iam"}), us, eu)
+	})
+
+	t.Run("stores_are_routed_by_their_label", func(t *testing.T) {
+		store := createStore(t, map[string]string{"region": "eu"})
+		requireHome(t, store, eu, us)
+
+		// the stores and their labels stay in the default datastore
+		_, err := us.GetStore(ctx, store)
+		require.NoError(t, err)
+		_, err = eu.GetStore(ctx, store)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("stores_are_routed_by_their_id_first", func(t *testing.T) {
+		_, err := ds.CreateStore(ctx, &openfgav1.Store{Id: pinned, Name: "pinned"})
+		require.NoError(t, err)
+		require.NoError(t, ds.WriteStoreLabels(ctx, pinned, map[string]string{"region": "us"}))
+
+		requireHome(t, pinned, eu, us)
+	})
+
+	t.Run("the_label_must_name_a_datastore", func(t *testing.T) {
+		store := createStore(t, nil)
+
+		err := ds.WriteStoreLabels(ctx, store, map[string]string{"region": "apac"})
+		require.ErrorIs(t, err, storage.ErrInvalidWriteInput)
+		require.ErrorContains(t, err, "eu, us")
+
+		// a label written around the wrapper fails the requests of the store
+		require.NoError(t, us.WriteStoreLabels(ctx, store, map[string]string{"region": "apac"}))
+		_, err = ds.ReadUserTuple(ctx, store, tk)
+		require.ErrorContains(t, err, "unknown datastore 'apac'")
+	})
+
+	t.Run("the_stores_of_a_multi_store_write_must_be_in_the_same_datastore", func(t *testing.T) {
+		store1 := createStore(t, map[string]string{"region": "eu"})
+		store2 := createStore(t, map[string]string{"region": "eu"})
+		store3 := createStore(t, nil)
+
+		err := ds.WriteStores(ctx, []storage.StoreWrite{
+			{Store: store1, Writes: []*openfgav1.TupleKey{tk}},
+			{Store: store2, Writes: []*openfgav1.TupleKey{tk}},
+		})
+		require.NoError(t, err)
+
+		_, err = eu.ReadUserTuple(ctx, store2, tk)
+		require.NoError(t, err)
+
+		err = ds.WriteStores(ctx, []storage.StoreWrite{
+			{Store: store3, Writes: []*openfgav1.TupleKey{tk}},
+			{Store: store1, Deletes: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk)}},
+		})
+		require.ErrorIs(t, err, storage.ErrMultiStoreWriteNotSupported)
+
+		_, err = us.ReadUserTuple(ctx, store3, tk)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("is_ready_if_all_the_datastores_are_ready", func(t *testing.T) {
+		status, err := ds.IsReady(ctx)
+		require.NoError(t, err)
+		require.True(t, status.IsReady)
+	})
+}
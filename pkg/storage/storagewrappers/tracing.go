@@ -0,0 +1,141 @@
+package storagewrappers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+// datastoreQueryEvent is the name of the span event of a datastore query.
+const datastoreQueryEvent = "datastore_query"
+
+var _ storage.OpenFGADatastore = (*tracingOpenFGADatastore)(nil)
+
+type tracingOpenFGADatastore struct {
+	storage.OpenFGADatastore
+}
+
+// NewTracingOpenFGADatastore returns a wrapper over a datastore that adds a 'datastore_query' event
+// to the span of the caller of each tuple read, with the datastore method, the duration of the query
+// and the number of rows it returned. The rows of a query that returns an iterator are the rows read
+// from the iterator, and its event is added when the iterator is stopped.
+func NewTracingOpenFGADatastore(inner storage.OpenFGADatastore) *tracingOpenFGADatastore {
+	return &tracingOpenFGADatastore{OpenFGADatastore: inner}
+}
+
+// addQueryEvent adds the event of the datastore query that started at start to the span.
+func addQueryEvent(span trace.Span, method string, start time.Time, duration time.Duration, rows int, err error) {
+	span.AddEvent(datastoreQueryEvent, trace.WithTimestamp(start), trace.WithAttributes(
+		attribute.String("method", method),
+		attribute.Int64("duration_ms", duration.Milliseconds()),
+		attribute.Int("row_count", rows),
+		attribute.Bool("error", err != nil),
+	))
+}
+
+// traceIterator adds the event of a datastore query that returns an iterator once the iterator is
+// stopped, or right away if the query failed.
+func traceIterator(ctx context.Context, method string, start time.Time, iter storage.TupleIterator, err error) storage.TupleIterator {
+	span := trace.SpanFromContext(ctx)
+	duration := time.Since(start)
+	if err != nil {
+		addQueryEvent(span, method, start, duration, 0, err)
+		return iter
+	}
+
+	if !span.IsRecording() {
+		return iter
+	}
+
+	return &tracingTupleIterator{
+		TupleIterator: iter,
+		span:          span,
+		method:        method,
+		start:         start,
+		duration:      duration,
+	}
+}
+
+type tracingTupleIterator struct {
+	storage.TupleIterator
+
+	span     trace.Span
+	method   string
+	start    time.Time
+	duration time.Duration
+	rows     int
+	stopped  bool
+}
+
+// Next see [storage.Iterator].Next.
+func (i *tracingTupleIterator) Next(ctx context.Context) (*openfgav1.Tuple, error) {
+	t, err := i.TupleIterator.Next(ctx)
+	if err == nil {
+		i.rows++
+	}
+
+	return t, err
+}
+
+// Stop see [storage.Iterator].Stop.
+func (i *tracingTupleIterator) Stop() {
+	if !i.stopped {
+		i.stopped = true
+		addQueryEvent(i.span, i.method, i.start, i.duration, i.rows, nil)
+	}
+
+	i.TupleIterator.Stop()
+}
+
+// Read see [storage.RelationshipTupleReader].Read.
+func (t *tracingOpenFGADatastore) Read(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (storage.TupleIterator, error) {
+	start := time.Now()
+	iter, err := t.OpenFGADatastore.Read(ctx, store, tupleKey)
+	return traceIterator(ctx, "Read", start, iter, err), err
+}
+
+// ReadPage see [storage.RelationshipTupleReader].ReadPage.
+func (t *tracingOpenFGADatastore) ReadPage(ctx context.Context, store string, tupleKey *openfgav1.TupleKey, opts storage.PaginationOptions) ([]*openfgav1.Tuple, []byte, error) {
+	start := time.Now()
+	tuples, token, err := t.OpenFGADatastore.ReadPage(ctx, store, tupleKey, opts)
+	addQueryEvent(trace.SpanFromContext(ctx), "ReadPage", start, time.Since(start), len(tuples), err)
+	return tuples, token, err
+}
+
+// ReadUserTuple see [storage.RelationshipTupleReader].ReadUserTuple.
+func (t *tracingOpenFGADatastore) ReadUserTuple(ctx context.Context, store string, tupleKey *openfgav1.TupleKey) (*openfgav1.Tuple, error) {
+	start := time.Now()
+	tk, err := t.OpenFGADatastore.ReadUserTuple(ctx, store, tupleKey)
+
+	rows := 0
+	if tk != nil {
+		rows = 1
+	}
+	// a tuple that doesn't exist isn't an error of the query
+	queryErr := err
+	if errors.Is(queryErr, storage.ErrNotFound) {
+		queryErr = nil
+	}
+	addQueryEvent(trace.SpanFromContext(ctx), "ReadUserTuple", start, time.Since(start), rows, queryErr)
+	return tk, err
+}
+
+// ReadUsersetTuples see [storage.RelationshipTupleReader].ReadUsersetTuples.
+func (t *tracingOpenFGADatastore) ReadUsersetTuples(ctx context.Context, store string, filter storage.ReadUsersetTuplesFilter) (storage.TupleIterator, error) {
+	start := time.Now()
+	iter, err := t.OpenFGADatastore.ReadUsersetTuples(ctx, store, filter)
+	return traceIterator(ctx, "ReadUsersetTuples", start, iter, err), err
+}
+
+// ReadStartingWithUser see [storage.RelationshipTupleReader].ReadStartingWithUser.
+func (t *tracingOpenFGADatastore) ReadStartingWithUser(ctx context.Context, store string, filter storage.ReadStartingWithUserFilter) (storage.TupleIterator, error) {
+	start := time.Now()
+	iter, err := t.OpenFGADatastore.ReadStartingWithUser(ctx, store, filter)
+	return traceIterator(ctx, "ReadStartingWithUser", start, iter, err), err
+}
@@ -0,0 +1,66 @@
+package storagewrappers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TestTracingOpenFGADatastore(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	ds := NewTracingOpenFGADatastore(memory.New())
+	t.Cleanup(ds.Close)
+
+	storeID := ulid.Make().String()
+	require.NoError(t, ds.Write(context.Background(), storeID, nil, []*openfgav1.TupleKey{
+		tuple.NewTupleKey("document:1", "viewer", "user:jon"),
+		tuple.NewTupleKey("document:2", "viewer", "user:jon"),
+	}))
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "test")
+
+	iter, err := ds.Read(ctx, storeID, tuple.NewTupleKey("document:", "viewer", ""))
+	require.NoError(t, err)
+	for {
+		_, err := iter.Next(ctx)
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrIteratorDone)
+			break
+		}
+	}
+	iter.Stop()
+	iter.Stop()
+
+	_, err = ds.ReadUserTuple(ctx, storeID, tuple.NewTupleKey("document:3", "viewer", "user:jon"))
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	events := spans[0].Events()
+	require.Len(t, events, 2)
+
+	require.Equal(t, datastoreQueryEvent, events[0].Name)
+	require.Contains(t, events[0].Attributes, attribute.String("method", "Read"))
+	require.Contains(t, events[0].Attributes, attribute.Int("row_count", 2))
+	require.Contains(t, events[0].Attributes, attribute.Bool("error", false))
+
+	require.Equal(t, datastoreQueryEvent, events[1].Name)
+	require.Contains(t, events[1].Attributes, attribute.String("method", "ReadUserTuple"))
+	require.Contains(t, events[1].Attributes, attribute.Int("row_count", 0))
+	require.Contains(t, events[1].Attributes, attribute.Bool("error", false))
+}
@@ -34,6 +34,10 @@ func WriteAndReadAuthorizationModelTest(t *testing.T, datastore storage.OpenFGAD
 		if diff := cmp.Diff(model, got, cmpOpts...); diff != "" {
 			t.Errorf("mismatch (-want +got):\n%s", diff)
 		}
+
+		// the model IDs are unique in a store
+		err = datastore.WriteAuthorizationModel(ctx, storeID, model)
+		require.ErrorIs(t, err, storage.ErrCollision)
 	})
 
 	t.Run("trying_to_get_a_model_which_does_not_exist_returns_not_found", func(t *testing.T) {
@@ -0,0 +1,42 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+func ChangelogCheckpointsTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+
+	t.Run("reading_a_checkpoint_that_was_never_written_returns_an_empty_token", func(t *testing.T) {
+		continuationToken, err := datastore.ReadChangelogCheckpoint(ctx, ulid.Make().String(), "publisher")
+		require.NoError(t, err)
+		require.Empty(t, continuationToken)
+	})
+
+	t.Run("writing_twice_overwrites_the_checkpoint_of_the_consumer", func(t *testing.T) {
+		store := ulid.Make().String()
+
+		err := datastore.WriteChangelogCheckpoint(ctx, store, "publisher", "token1")
+		require.NoError(t, err)
+
+		err = datastore.WriteChangelogCheckpoint(ctx, store, "publisher", "token2")
+		require.NoError(t, err)
+
+		err = datastore.WriteChangelogCheckpoint(ctx, store, "other", "token3")
+		require.NoError(t, err)
+
+		continuationToken, err := datastore.ReadChangelogCheckpoint(ctx, store, "publisher")
+		require.NoError(t, err)
+		require.Equal(t, "token2", continuationToken)
+
+		continuationToken, err = datastore.ReadChangelogCheckpoint(ctx, store, "other")
+		require.NoError(t, err)
+		require.Equal(t, "token3", continuationToken)
+	})
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func MultiStoreWriteTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+
+	t.Run("the_tuples_of_all_the_stores_are_written", func(t *testing.T) {
+		store1 := ulid.Make().String()
+		store2 := ulid.Make().String()
+
+		tk1 := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+		tk2 := tuple.NewTupleKey("document:2", "viewer", "user:bob")
+
+		err := datastore.Write(ctx, store2, nil, []*openfgav1.TupleKey{tk2})
+		require.NoError(t, err)
+
+		// each store has the ULID of its first change, which is unique across the stores
+		changeID1 := ulid.Make().String()
+		changeID2 := ulid.Make().String()
+
+		err = datastore.WriteStores(ctx, []storage.StoreWrite{
+			{Store: store1, Writes: []*openfgav1.TupleKey{tk1, tk2}, ChangeULIDs: map[int]string{0: changeID1}},
+			{Store: store2, Deletes: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk2)}, Writes: []*openfgav1.TupleKey{tk1}, ChangeULIDs: map[int]string{0: changeID2}},
+		})
+		require.NoError(t, err)
+
+		for store, changeID := range map[string]string{store1: changeID1, store2: changeID2} {
+			exists, err := datastore.ChangeExists(ctx, store, changeID)
+			require.NoError(t, err)
+			require.True(t, exists)
+		}
+
+		for _, written := range []struct {
+			store string
+			tk    *openfgav1.TupleKey
+		}{{store1, tk1}, {store1, tk2}, {store2, tk1}} {
+			_, err := datastore.ReadUserTuple(ctx, written.store, written.tk)
+			require.NoError(t, err)
+		}
+
+		_, err = datastore.ReadUserTuple(ctx, store2, tk2)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		changes, _, err := datastore.ReadChanges(ctx, store2, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		require.NoError(t, err)
+		require.Len(t, changes, 3)
+		require.Equal(t, openfgav1.TupleOperation_TUPLE_OPERATION_DELETE, changes[1].GetOperation())
+		require.Equal(t, openfgav1.TupleOperation_TUPLE_OPERATION_WRITE, changes[2].GetOperation())
+	})
+
+	t.Run("none_of_the_tuples_are_written_if_a_store_fails", func(t *testing.T) {
+		store1 := ulid.Make().String()
+		store2 := ulid.Make().String()
+
+		tk := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+
+		err := datastore.WriteStores(ctx, []storage.StoreWrite{
+			{Store: store1, Writes: []*openfgav1.TupleKey{tk}},
+			{Store: store2, Deletes: []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk)}},
+		})
+		require.ErrorIs(t, err, storage.ErrInvalidWriteInput)
+
+		_, err = datastore.ReadUserTuple(ctx, store1, tk)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		changes, _, err := datastore.ReadChanges(ctx, store1, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrNotFound)
+		}
+		require.Empty(t, changes)
+	})
+}
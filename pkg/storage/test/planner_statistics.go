@@ -0,0 +1,36 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+func PlannerStatisticsTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+
+	t.Run("reading_statistics_that_were_never_written_returns_nil", func(t *testing.T) {
+		statistics, err := datastore.ReadPlannerStatistics(ctx, ulid.Make().String())
+		require.NoError(t, err)
+		require.Nil(t, statistics)
+	})
+
+	t.Run("writing_twice_overwrites_statistics", func(t *testing.T) {
+		store := ulid.Make().String()
+
+		err := datastore.WritePlannerStatistics(ctx, store, []byte(`{"version":1}`))
+		require.NoError(t, err)
+
+		want := []byte(`{"version":1,"relations":{"document#viewer":{"samples":1,"mean":2}}}`)
+		err = datastore.WritePlannerStatistics(ctx, store, want)
+		require.NoError(t, err)
+
+		statistics, err := datastore.ReadPlannerStatistics(ctx, store)
+		require.NoError(t, err)
+		require.Equal(t, want, statistics)
+	})
+}
@@ -0,0 +1,58 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+func RelationUsageTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+
+	t.Run("reading_usage_that_was_never_added_returns_an_empty_list", func(t *testing.T) {
+		usage, err := datastore.ReadRelationUsage(ctx, ulid.Make().String())
+		require.NoError(t, err)
+		require.Empty(t, usage)
+	})
+
+	t.Run("adding_usage_adds_up_the_evaluations", func(t *testing.T) {
+		store := ulid.Make().String()
+		earlier := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+		later := earlier.Add(time.Minute)
+
+		err := datastore.AddRelationUsage(ctx, store, []storage.RelationUsage{
+			{ObjectType: "document", Relation: "viewer", Allowed: 3, Denied: 1, LastEvaluatedAt: later},
+			{ObjectType: "document", Relation: "editor", Allowed: 1, LastEvaluatedAt: earlier},
+		})
+		require.NoError(t, err)
+
+		err = datastore.AddRelationUsage(ctx, store, []storage.RelationUsage{
+			{ObjectType: "document", Relation: "viewer", Allowed: 2, Denied: 5, LastEvaluatedAt: earlier},
+			{ObjectType: "folder", Relation: "viewer", Denied: 1, LastEvaluatedAt: later},
+		})
+		require.NoError(t, err)
+
+		err = datastore.AddRelationUsage(ctx, store, nil)
+		require.NoError(t, err)
+
+		usage, err := datastore.ReadRelationUsage(ctx, store)
+		require.NoError(t, err)
+		require.Len(t, usage, 3)
+
+		// the last evaluation is the latest one, not the last one added
+		for i := range usage {
+			require.WithinDuration(t, map[string]time.Time{"editor": earlier, "viewer": later}[usage[i].Relation], usage[i].LastEvaluatedAt, time.Second)
+			usage[i].LastEvaluatedAt = time.Time{}
+		}
+		require.Equal(t, []storage.RelationUsage{
+			{ObjectType: "document", Relation: "editor", Allowed: 1},
+			{ObjectType: "document", Relation: "viewer", Allowed: 5, Denied: 6},
+			{ObjectType: "folder", Relation: "viewer", Denied: 1},
+		}, usage)
+	})
+}
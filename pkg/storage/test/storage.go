@@ -42,6 +42,9 @@ func RunAllTests(t *testing.T, ds storage.OpenFGADatastore) {
 	t.Run("ReadTestCorrectnessOfTuples", func(t *testing.T) { ReadTestCorrectnessOfTuples(t, ds) })
 	t.Run("ReadPageTestCorrectnessOfTuples", func(t *testing.T) { ReadPageTestCorrectnessOfTuples(t, ds) })
 
+	// Writers of tuples and changes.
+	t.Run("TestReadTupleAndChangeWriters", func(t *testing.T) { TupleWritersTest(t, ds) })
+
 	// Authorization models.
 	t.Run("TestWriteAndReadAuthorizationModel", func(t *testing.T) { WriteAndReadAuthorizationModelTest(t, ds) })
 	t.Run("TestReadAuthorizationModels", func(t *testing.T) { ReadAuthorizationModelsTest(t, ds) })
@@ -50,6 +53,21 @@ func RunAllTests(t *testing.T, ds storage.OpenFGADatastore) {
 	// Assertions.
 	t.Run("TestWriteAndReadAssertions", func(t *testing.T) { AssertionsTest(t, ds) })
 
+	// Store settings.
+	t.Run("TestWriteAndReadStoreSettings", func(t *testing.T) { StoreSettingsTest(t, ds) })
+
+	// Check planner statistics.
+	t.Run("TestWriteAndReadPlannerStatistics", func(t *testing.T) { PlannerStatisticsTest(t, ds) })
+
+	// Relation usage.
+	t.Run("TestAddAndReadRelationUsage", func(t *testing.T) { RelationUsageTest(t, ds) })
+
+	// Changelog checkpoints.
+	t.Run("TestWriteAndReadChangelogCheckpoints", func(t *testing.T) { ChangelogCheckpointsTest(t, ds) })
+
+	// Writes of several stores.
+	t.Run("TestMultiStoreWrite", func(t *testing.T) { MultiStoreWriteTest(t, ds) })
+
 	// Stores.
 	t.Run("TestStore", func(t *testing.T) { StoreTest(t, ds) })
 }
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+)
+
+func StoreSettingsTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+
+	t.Run("reading_settings_that_were_never_written_returns_empty_settings", func(t *testing.T) {
+		settings, err := datastore.ReadStoreSettings(ctx, ulid.Make().String())
+		require.NoError(t, err)
+		require.Equal(t, &storage.StoreSettings{}, settings)
+	})
+
+	t.Run("writing_twice_overwrites_settings", func(t *testing.T) {
+		store := ulid.Make().String()
+
+		err := datastore.WriteStoreSettings(ctx, store, &storage.StoreSettings{
+			DefaultAuthorizationModelID: ulid.Make().String(),
+			DefaultConsistency:          "higher_consistency",
+			CheckQueryCacheTTL:          time.Minute,
+		})
+		require.NoError(t, err)
+
+		candidate := &storage.StoreSettings{
+			DefaultConsistency:            "minimize_latency",
+			CandidateAuthorizationModelID: ulid.Make().String(),
+		}
+		err = datastore.WriteStoreSettings(ctx, store, candidate)
+		require.NoError(t, err)
+
+		settings, err := datastore.ReadStoreSettings(ctx, store)
+		require.NoError(t, err)
+		require.Equal(t, candidate, settings)
+
+		want := &storage.StoreSettings{CheckQueryCacheTTL: 1500 * time.Millisecond}
+		err = datastore.WriteStoreSettings(ctx, store, want)
+		require.NoError(t, err)
+
+		settings, err = datastore.ReadStoreSettings(ctx, store)
+		require.NoError(t, err)
+		require.Equal(t, want, settings)
+	})
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,13 +40,13 @@ func StoreTest(t *testing.T, datastore storage.OpenFGADatastore) {
 	})
 
 	t.Run("list_stores_succeeds", func(t *testing.T) {
-		gotStores, ct, err := datastore.ListStores(ctx, storage.PaginationOptions{PageSize: 1})
+		gotStores, ct, err := datastore.ListStores(ctx, storage.ListStoresFilter{}, storage.PaginationOptions{PageSize: 1})
 		require.NoError(t, err)
 
 		require.Len(t, gotStores, 1)
 		require.NotEmpty(t, len(ct))
 
-		_, ct, err = datastore.ListStores(ctx, storage.PaginationOptions{PageSize: 100, From: string(ct)})
+		_, ct, err = datastore.ListStores(ctx, storage.ListStoresFilter{}, storage.PaginationOptions{PageSize: 100, From: string(ct)})
 		require.NoError(t, err)
 
 		// This will fail if there are actually over 101 stores in the DB at the time of running.
@@ -73,6 +74,10 @@ func StoreTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		// Should not be able to get the store now.
 		_, err = datastore.GetStore(ctx, store.GetId())
 		require.ErrorIs(t, err, storage.ErrNotFound)
+
+		// The ID of a deleted store can't be the ID of a new store.
+		_, err = datastore.CreateStore(ctx, store)
+		require.ErrorIs(t, err, storage.ErrCollision)
 	})
 
 	t.Run("deleted_store_does_not_appear_in_list", func(t *testing.T) {
@@ -81,11 +86,120 @@ func StoreTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		require.NoError(t, err)
 
 		// Store id should not appear in the list of store ids.
-		gotStores, _, err := datastore.ListStores(ctx, storage.PaginationOptions{PageSize: storage.DefaultPageSize})
+		gotStores, _, err := datastore.ListStores(ctx, storage.ListStoresFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize})
 		require.NoError(t, err)
 
 		for _, s := range gotStores {
 			require.NotEqual(t, store.GetId(), s.GetId())
 		}
 	})
+
+	t.Run("update_store_succeeds", func(t *testing.T) {
+		store := stores[3]
+		updated, err := datastore.UpdateStore(ctx, &openfgav1.Store{Id: store.GetId(), Name: "renamed"})
+		require.NoError(t, err)
+		require.Equal(t, "renamed", updated.GetName())
+
+		gotStore, err := datastore.GetStore(ctx, store.GetId())
+		require.NoError(t, err)
+		require.Equal(t, "renamed", gotStore.GetName())
+		require.False(t, gotStore.GetUpdatedAt().AsTime().Before(gotStore.GetCreatedAt().AsTime()))
+	})
+
+	t.Run("update_deleted_store_returns_not_found", func(t *testing.T) {
+		_, err := datastore.UpdateStore(ctx, &openfgav1.Store{Id: stores[1].GetId(), Name: "renamed"})
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("writing_twice_overwrites_labels", func(t *testing.T) {
+		store := stores[4].GetId()
+		other := stores[5].GetId()
+
+		labels, err := datastore.ReadStoreLabels(ctx, []string{store, other})
+		require.NoError(t, err)
+		require.Empty(t, labels)
+
+		err = datastore.WriteStoreLabels(ctx, store, map[string]string{"env": "prod", "team": "iam"})
+		require.NoError(t, err)
+
+		err = datastore.WriteStoreLabels(ctx, store, map[string]string{"env": "staging"})
+		require.NoError(t, err)
+
+		err = datastore.WriteStoreLabels(ctx, other, map[string]string{"team": "billing"})
+		require.NoError(t, err)
+
+		labels, err = datastore.ReadStoreLabels(ctx, []string{store, other, stores[6].GetId()})
+		require.NoError(t, err)
+		require.Equal(t, map[string]map[string]string{
+			store: {"env": "staging"},
+			other: {"team": "billing"},
+		}, labels)
+
+		err = datastore.WriteStoreLabels(ctx, store, nil)
+		require.NoError(t, err)
+
+		labels, err = datastore.ReadStoreLabels(ctx, []string{store})
+		require.NoError(t, err)
+		require.Empty(t, labels)
+
+		labels, err = datastore.ReadStoreLabels(ctx, nil)
+		require.NoError(t, err)
+		require.Empty(t, labels)
+	})
+
+	t.Run("list_stores_with_filter", func(t *testing.T) {
+		// the names are unique so that the stores of other tests don't match
+		prefix := ulid.Make().String()
+		var filtered []*openfgav1.Store
+		for _, name := range []string{"Payments_EU", "payments_us", "billing"} {
+			store, err := datastore.CreateStore(ctx, &openfgav1.Store{Id: ulid.Make().String(), Name: prefix + name})
+			require.NoError(t, err)
+			filtered = append(filtered, store)
+		}
+
+		err := datastore.WriteStoreLabels(ctx, filtered[0].GetId(), map[string]string{"env": "prod", "region": "eu"})
+		require.NoError(t, err)
+		err = datastore.WriteStoreLabels(ctx, filtered[1].GetId(), map[string]string{"env": "prod", "region": "us"})
+		require.NoError(t, err)
+
+		list := func(filter storage.ListStoresFilter) []string {
+			var names []string
+			var from string
+			for {
+				gotStores, ct, err := datastore.ListStores(ctx, filter, storage.PaginationOptions{PageSize: 1, From: from})
+				require.NoError(t, err)
+				for _, store := range gotStores {
+					names = append(names, strings.TrimPrefix(store.GetName(), prefix))
+				}
+				if len(ct) == 0 {
+					return names
+				}
+				from = string(ct)
+			}
+		}
+
+		require.Equal(t, []string{"Payments_EU", "payments_us"}, list(storage.ListStoresFilter{NameContains: prefix + "PAYMENTS"}))
+		require.Equal(t, []string{"billing"}, list(storage.ListStoresFilter{NameContains: prefix + "bill"}))
+		require.Empty(t, list(storage.ListStoresFilter{NameContains: prefix + "%"}))
+
+		prodFilter := storage.ListStoresFilter{NameContains: prefix, Labels: map[string]string{"env": "prod"}}
+		require.Equal(t, []string{"Payments_EU", "payments_us"}, list(prodFilter))
+
+		prodFilter.Labels["region"] = "us"
+		require.Equal(t, []string{"payments_us"}, list(prodFilter))
+
+		createdAt := filtered[0].GetCreatedAt().AsTime()
+		require.Equal(t, []string{"Payments_EU", "payments_us", "billing"}, list(storage.ListStoresFilter{
+			NameContains: prefix,
+			CreatedAfter: createdAt,
+		}))
+		require.Empty(t, list(storage.ListStoresFilter{
+			NameContains:  prefix,
+			CreatedBefore: createdAt,
+		}))
+		require.Empty(t, list(storage.ListStoresFilter{
+			NameContains: prefix,
+			CreatedAfter: time.Now().Add(time.Hour),
+		}))
+	})
 }
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openfga/openfga/pkg/storage"
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+func TupleWritersTest(t *testing.T, datastore storage.OpenFGADatastore) {
+	ctx := context.Background()
+
+	t.Run("writers_are_returned_in_order_and_empty_when_unknown", func(t *testing.T) {
+		store := ulid.Make().String()
+		tk1 := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+		tk2 := tuple.NewTupleKey("document:2", "viewer", "user:bob")
+		tk3 := tuple.NewTupleKey("document:3", "viewer", "user:carl")
+
+		err := datastore.Write(storage.ContextWithWriter(ctx, "anne"), store, nil, []*openfgav1.TupleKey{tk1})
+		require.NoError(t, err)
+		err = datastore.Write(ctx, store, nil, []*openfgav1.TupleKey{tk2})
+		require.NoError(t, err)
+
+		writers, err := datastore.ReadTupleWriters(ctx, store, []*openfgav1.TupleKey{tk2, tk3, tk1})
+		require.NoError(t, err)
+		require.Equal(t, []string{"", "", "anne"}, writers)
+
+		err = datastore.Write(storage.ContextWithWriter(ctx, "bob"), store, []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk1)}, nil)
+		require.NoError(t, err)
+
+		changes, _, err := datastore.ReadChanges(ctx, store, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		require.NoError(t, err)
+		require.Len(t, changes, 3)
+
+		writers, err = datastore.ReadChangeWriters(ctx, store, changes)
+		require.NoError(t, err)
+		require.Equal(t, []string{"anne", "", "bob"}, writers)
+	})
+
+	t.Run("no_tuples_or_changes", func(t *testing.T) {
+		writers, err := datastore.ReadTupleWriters(ctx, ulid.Make().String(), nil)
+		require.NoError(t, err)
+		require.Empty(t, writers)
+
+		writers, err = datastore.ReadChangeWriters(ctx, ulid.Make().String(), nil)
+		require.NoError(t, err)
+		require.Empty(t, writers)
+	})
+}
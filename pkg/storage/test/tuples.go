@@ -41,7 +41,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk1, tk2})
 		require.NoError(t, err)
 
-		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: 1}, 0)
+		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: 1}, 0)
 		require.NoError(t, err)
 		require.NotEmpty(t, continuationToken)
 
@@ -56,7 +56,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			t.Fatalf("mismatch (-want +got):\n%s", diff)
 		}
 
-		changes, continuationToken, err = datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{
+		changes, continuationToken, err = datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{
 			PageSize: 2,
 			From:     string(continuationToken),
 		},
@@ -79,7 +79,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 	t.Run("read_changes_with_no_changes_should_return_not_found", func(t *testing.T) {
 		storeID := ulid.Make().String()
 
-		_, _, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		_, _, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
 
@@ -100,7 +100,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk1, tk2})
 		require.NoError(t, err)
 
-		_, _, err = datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 1*time.Minute)
+		_, _, err = datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 1*time.Minute)
 		require.ErrorIs(t, err, storage.ErrNotFound)
 	})
 
@@ -121,7 +121,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk1, tk2})
 		require.NoError(t, err)
 
-		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, "folder", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{ObjectType: "folder"}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
 		require.NoError(t, err)
 		require.NotEmpty(t, continuationToken)
 
@@ -136,6 +136,100 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		}
 	})
 
+	t.Run("read_changes_with_object_id_prefix_relation_and_user_filters", func(t *testing.T) {
+		storeID := ulid.Make().String()
+
+		tks := []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:org1/a", "viewer", "user:anne"),
+			tuple.NewTupleKey("document:org1/b", "editor", "user:anne"),
+			tuple.NewTupleKey("document:org1/c", "viewer", "user:bob"),
+			tuple.NewTupleKey("document:org2/a", "viewer", "user:anne"),
+			tuple.NewTupleKey("document:org1_x", "viewer", "user:anne"),
+			tuple.NewTupleKey("folder:org1/a", "viewer", "user:anne"),
+		}
+		err := datastore.Write(ctx, storeID, nil, tks)
+		require.NoError(t, err)
+
+		tests := []struct {
+			name     string
+			filter   storage.ReadChangesFilter
+			expected []*openfgav1.TupleKey
+		}{
+			{
+				name:     "object_id_prefix",
+				filter:   storage.ReadChangesFilter{ObjectType: "document", ObjectIDPrefix: "org1/"},
+				expected: tks[0:3],
+			},
+			{
+				name:     "object_id_prefix_with_like_wildcard",
+				filter:   storage.ReadChangesFilter{ObjectType: "document", ObjectIDPrefix: "org1_"},
+				expected: []*openfgav1.TupleKey{tks[4]},
+			},
+			{
+				name:     "relation",
+				filter:   storage.ReadChangesFilter{ObjectType: "document", Relation: "editor"},
+				expected: []*openfgav1.TupleKey{tks[1]},
+			},
+			{
+				name:     "user",
+				filter:   storage.ReadChangesFilter{User: "user:bob"},
+				expected: []*openfgav1.TupleKey{tks[2]},
+			},
+			{
+				name:     "all",
+				filter:   storage.ReadChangesFilter{ObjectType: "document", ObjectIDPrefix: "org1/", Relation: "viewer", User: "user:anne"},
+				expected: []*openfgav1.TupleKey{tks[0]},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				changes, _, err := datastore.ReadChanges(ctx, storeID, test.filter, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+				require.NoError(t, err)
+
+				var actual []*openfgav1.TupleKey
+				for _, change := range changes {
+					actual = append(actual, change.GetTupleKey())
+				}
+				if diff := cmp.Diff(test.expected, actual, cmpOpts...); diff != "" {
+					t.Errorf("mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+
+		_, _, err = datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{User: "user:charlie"}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("read_changes_with_start_time_filter", func(t *testing.T) {
+		storeID := ulid.Make().String()
+
+		tk1 := tuple.NewTupleKey("document:1", "viewer", "user:anne")
+		tk2 := tuple.NewTupleKey("document:2", "viewer", "user:anne")
+		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk1})
+		require.NoError(t, err)
+
+		changes, _, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		// the timestamps of some datastores have a precision of a second
+		startTime := changes[0].GetTimestamp().AsTime().Add(time.Second)
+
+		time.Sleep(time.Second)
+		err = datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk2})
+		require.NoError(t, err)
+
+		changes, _, err = datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{StartTime: startTime}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		if diff := cmp.Diff(tk2, changes[0].GetTupleKey(), cmpOpts...); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+
+		_, _, err = datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{StartTime: time.Now().Add(time.Hour)}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
 	t.Run("read_changes_returns_deterministic_ordering_and_no_duplicates", func(t *testing.T) {
 		storeID := ulid.Make().String()
 
@@ -152,7 +246,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		var continuationToken []byte
 		var err error
 		for {
-			changes, continuationToken, err = datastore.ReadChanges(context.Background(), storeID, "", storage.PaginationOptions{
+			changes, continuationToken, err = datastore.ReadChanges(context.Background(), storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{
 				PageSize: 10,
 				From:     string(continuationToken),
 			}, 1*time.Millisecond)
@@ -202,7 +296,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err := datastore.Write(ctx, storeID, nil, []*openfgav1.TupleKey{tk1, tk2})
 		require.NoError(t, err)
 
-		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
 		require.NoError(t, err)
 		require.NotEmpty(t, continuationToken)
 
@@ -256,7 +350,7 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 		err = datastore.Write(ctx, storeID, []*openfgav1.TupleKeyWithoutCondition{tk2}, nil)
 		require.NoError(t, err)
 
-		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
+		changes, continuationToken, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{PageSize: storage.DefaultPageSize}, 0)
 		require.NoError(t, err)
 		require.NotEmpty(t, continuationToken)
 
@@ -277,6 +371,36 @@ func ReadChangesTest(t *testing.T, datastore storage.OpenFGADatastore) {
 			t.Fatalf("mismatch (-want +got):\n%s", diff)
 		}
 	})
+
+	t.Run("change_exists", func(t *testing.T) {
+		storeID := ulid.Make().String()
+		writeID := ulid.Make().String()
+
+		exists, err := datastore.ChangeExists(ctx, storeID, writeID)
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		tk := tuple.NewTupleKey("folder:folder1", "viewer", "user:bob")
+		err = datastore.Write(storage.ContextWithChangeULID(ctx, writeID), storeID, nil, []*openfgav1.TupleKey{tk})
+		require.NoError(t, err)
+
+		exists, err = datastore.ChangeExists(ctx, storeID, writeID)
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		deleteID := ulid.Make().String()
+		err = datastore.Write(storage.ContextWithChangeULID(ctx, deleteID), storeID, []*openfgav1.TupleKeyWithoutCondition{tuple.TupleKeyToTupleKeyWithoutCondition(tk)}, nil)
+		require.NoError(t, err)
+
+		exists, err = datastore.ChangeExists(ctx, storeID, deleteID)
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		// the changes of a store are not the changes of the other stores
+		exists, err = datastore.ChangeExists(ctx, ulid.Make().String(), writeID)
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
 }
 
 func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore) {
@@ -377,6 +501,26 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		require.EqualError(t, err, expectedError.Error())
 	})
 
+	t.Run("inserting_a_batch_with_an_existing_tuple_fails_on_that_tuple", func(t *testing.T) {
+		storeID := ulid.Make().String()
+		tks := []*openfgav1.TupleKey{
+			{Object: "doc:readme", Relation: "owner", User: "user:anne"},
+			{Object: "doc:readme", Relation: "owner", User: "user:bob"},
+			{Object: "doc:readme", Relation: "viewer", User: "user:carl"},
+		}
+		expectedError := storage.InvalidWriteInputError(tks[1], openfgav1.TupleOperation_TUPLE_OPERATION_WRITE)
+
+		err := datastore.Write(ctx, storeID, nil, tks[1:2])
+		require.NoError(t, err)
+
+		err = datastore.Write(ctx, storeID, nil, tks)
+		require.EqualError(t, err, expectedError.Error())
+
+		// None of the batch is written.
+		_, err = datastore.ReadUserTuple(ctx, storeID, tks[0])
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
 	t.Run("inserting_a_tuple_twice_either_conditioned_or_not_fails", func(t *testing.T) {
 		storeID := ulid.Make().String()
 		tk := &openfgav1.TupleKey{Object: "doc:readme", Relation: "owner", User: "10"}
@@ -558,6 +702,48 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		require.ErrorIs(t, err, storage.ErrIteratorDone)
 	})
 
+	t.Run("reading_userset_tuples_in_pages_returns_all_of_them", func(t *testing.T) {
+		storeID := ulid.Make().String()
+		tks := []*openfgav1.TupleKey{
+			tuple.NewTupleKey("doc:readme", "owner", "user:*"),
+			tuple.NewTupleKey("doc:readme", "viewer", "group:other#member"),
+		}
+		var want []string
+		for i := 0; i < 6; i++ {
+			tk := tuple.NewTupleKey("doc:readme", "owner", fmt.Sprintf("group:%d#member", i))
+			tks = append(tks, tk)
+			want = append(want, tuple.TupleKeyToString(tk))
+		}
+		want = append(want, tuple.TupleKeyToString(tks[0]))
+
+		err := datastore.Write(ctx, storeID, nil, tks)
+		require.NoError(t, err)
+
+		// the last page read is empty for a page size of 1 and 7, and partial for 3 and 8
+		for _, pageSize := range []int{1, 3, 7, 8} {
+			gotTuples, err := datastore.ReadUsersetTuples(ctx, storeID, storage.ReadUsersetTuplesFilter{
+				Object:   "doc:readme",
+				Relation: "owner",
+				PageSize: pageSize,
+			})
+			require.NoError(t, err)
+
+			var got []string
+			for {
+				tp, err := gotTuples.Next(ctx)
+				if errors.Is(err, storage.ErrIteratorDone) {
+					break
+				}
+				require.NoError(t, err)
+
+				got = append(got, tuple.TupleKeyToString(tp.GetKey()))
+			}
+			gotTuples.Stop()
+
+			require.ElementsMatch(t, want, got, "page size %d", pageSize)
+		}
+	})
+
 	t.Run("reading_userset_tuples_with_filter_made_of_direct_relation_reference", func(t *testing.T) {
 		storeID := ulid.Make().String()
 		tks := []*openfgav1.TupleKey{
@@ -784,7 +970,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		require.NoError(t, err)
 		require.Nil(t, tp.GetKey().GetCondition())
 
-		changes, _, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{}, 0)
+		changes, _, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{}, 0)
 		require.NoError(t, err)
 		require.Len(t, changes, 2)
 		require.Nil(t, changes[0].GetTupleKey().GetCondition())
@@ -869,7 +1055,7 @@ func TupleWritingAndReadingTest(t *testing.T, datastore storage.OpenFGADatastore
 		require.NoError(t, err)
 		require.NotNil(t, tp.GetKey().GetCondition().GetContext())
 
-		changes, _, err := datastore.ReadChanges(ctx, storeID, "", storage.PaginationOptions{}, 0)
+		changes, _, err := datastore.ReadChanges(ctx, storeID, storage.ReadChangesFilter{}, storage.PaginationOptions{}, 0)
 		require.NoError(t, err)
 		require.Len(t, changes, 2)
 		require.NotNil(t, changes[0].GetTupleKey().GetCondition().GetContext())
@@ -1180,6 +1366,31 @@ func ReadTestCorrectnessOfTuples(t *testing.T, datastore storage.OpenFGADatastor
 		require.ElementsMatch(t, expectedTupleKeys, getTupleKeys(tupleIterator, t))
 	})
 
+	t.Run("filter_by_user", func(t *testing.T) {
+		tupleIterator, err := datastore.Read(
+			ctx,
+			storeID,
+			tuple.NewTupleKey("", "", "user:anne"),
+		)
+		require.NoError(t, err)
+		defer tupleIterator.Stop()
+
+		expectedTupleKeys := []*openfgav1.TupleKey{
+			tuple.NewTupleKey("document:1", "reader", "user:anne"),
+			{
+				Object:   "document:2",
+				Relation: "viewer",
+				User:     "user:anne",
+				Condition: &openfgav1.RelationshipCondition{
+					Name:    "condition",
+					Context: &structpb.Struct{},
+				},
+			},
+		}
+
+		require.ElementsMatch(t, expectedTupleKeys, getTupleKeys(tupleIterator, t))
+	})
+
 	t.Run("filter_by_relation_and_objectID", func(t *testing.T) {
 		tupleIterator, err := datastore.Read(
 			ctx,
@@ -1475,6 +1686,36 @@ func ReadPageTestCorrectnessOfTuples(t *testing.T, datastore storage.OpenFGAData
 		requireEqualTuples(t, expectedTuples, gotTuples)
 		require.Empty(t, contToken)
 	})
+
+	t.Run("filter_by_condition_name", func(t *testing.T) {
+		gotTuples, contToken, err := datastore.ReadPage(
+			ctx,
+			storeID,
+			tuple.NewTupleKeyWithCondition("document:", "", "user:anne", "condition", nil),
+			storage.PaginationOptions{
+				PageSize: 50,
+			},
+		)
+		require.NoError(t, err)
+
+		expectedTuples := []*openfgav1.Tuple{
+			{Key: tuple.NewTupleKeyWithCondition("document:2", "viewer", "user:anne", "condition", nil)},
+		}
+
+		requireEqualTuples(t, expectedTuples, gotTuples)
+		require.Empty(t, contToken)
+
+		gotTuples, _, err = datastore.ReadPage(
+			ctx,
+			storeID,
+			tuple.NewTupleKeyWithCondition("", "", "", "other", nil),
+			storage.PaginationOptions{
+				PageSize: 50,
+			},
+		)
+		require.NoError(t, err)
+		require.Empty(t, gotTuples)
+	})
 }
 
 // getObjects returns all the objects from an iterator.
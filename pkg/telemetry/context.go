@@ -2,6 +2,7 @@ package telemetry
 
 import (
 	"context"
+	"strings"
 )
 
 type rpcContextName string
@@ -38,6 +39,22 @@ func RPCInfoFromContext(ctx context.Context) RPCInfo {
 	}
 }
 
+// RPCInfoFromFullMethod returns the method and service of a full gRPC method name, e.g.
+// "/openfga.v1.OpenFGAService/Check".
+func RPCInfoFromFullMethod(fullMethod string) RPCInfo {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return RPCInfo{
+			Method:  fullMethod[i+1:],
+			Service: fullMethod[:i],
+		}
+	}
+	return RPCInfo{
+		Method:  "unknown",
+		Service: "unknown",
+	}
+}
+
 // ContextWithDispatchThrottlingThreshold will save the dispatch throttling threshold in context.
 func ContextWithDispatchThrottlingThreshold(ctx context.Context, threshold uint32) context.Context {
 	return context.WithValue(ctx, dispatchThrottlingThreshold, threshold)
@@ -26,3 +26,15 @@ func TestUnknownRPCInfo(t *testing.T) {
 		Service: "unknown",
 	}, output)
 }
+
+func TestRPCInfoFromFullMethod(t *testing.T) {
+	require.Equal(t, RPCInfo{
+		Method:  "Check",
+		Service: openfgav1.OpenFGAService_ServiceDesc.ServiceName,
+	}, RPCInfoFromFullMethod("/openfga.v1.OpenFGAService/Check"))
+
+	require.Equal(t, RPCInfo{
+		Method:  "unknown",
+		Service: "unknown",
+	}, RPCInfoFromFullMethod("Check"))
+}
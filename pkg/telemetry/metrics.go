@@ -0,0 +1,106 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prometheusbridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+type MeterOption func(m *customMeter)
+
+// WithMeterOTLPEndpoint sets the grpc endpoint of the OpenTelemetry collector that metrics are exported to.
+func WithMeterOTLPEndpoint(endpoint string) MeterOption {
+	return func(m *customMeter) {
+		m.endpoint = endpoint
+	}
+}
+
+func WithMeterOTLPInsecure() MeterOption {
+	return func(m *customMeter) {
+		m.insecure = true
+	}
+}
+
+// WithMeterExportInterval sets how often metrics are exported.
+func WithMeterExportInterval(interval time.Duration) MeterOption {
+	return func(m *customMeter) {
+		m.exportInterval = interval
+	}
+}
+
+// WithMeterGatherer sets the Prometheus gatherer that metrics are read from. Defaults to [prometheus.DefaultGatherer].
+func WithMeterGatherer(gatherer prometheus.Gatherer) MeterOption {
+	return func(m *customMeter) {
+		m.gatherer = gatherer
+	}
+}
+
+func WithMeterAttributes(attrs ...attribute.KeyValue) MeterOption {
+	return func(m *customMeter) {
+		m.attributes = attrs
+	}
+}
+
+type customMeter struct {
+	endpoint       string
+	insecure       bool
+	exportInterval time.Duration
+	gatherer       prometheus.Gatherer
+	attributes     []attribute.KeyValue
+}
+
+// MustNewMeterProvider returns a MeterProvider that periodically exports the metrics registered with
+// Prometheus to an OpenTelemetry collector over OTLP. All metrics are still registered with Prometheus
+// (e.g. through promauto), so they are exported with the same names they have on the '/metrics' endpoint.
+func MustNewMeterProvider(opts ...MeterOption) *sdkmetric.MeterProvider {
+	meter := &customMeter{
+		endpoint:       "",
+		exportInterval: 30 * time.Second,
+		gatherer:       prometheus.DefaultGatherer,
+		attributes:     []attribute.KeyValue{},
+	}
+
+	for _, opt := range opts {
+		opt(meter)
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(meter.attributes...))
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	options := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(meter.endpoint),
+	}
+
+	if meter.insecure {
+		options = append(options, otlpmetricgrpc.WithInsecure())
+	}
+
+	exp, err := otlpmetricgrpc.New(ctx, options...)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create the otlp metric exporter: %v", err))
+	}
+
+	reader := sdkmetric.NewPeriodicReader(exp,
+		sdkmetric.WithInterval(meter.exportInterval),
+		sdkmetric.WithProducer(prometheusbridge.NewMetricProducer(prometheusbridge.WithGatherer(meter.gatherer))),
+	)
+
+	return sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(reader),
+	)
+}
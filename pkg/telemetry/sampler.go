@@ -0,0 +1,54 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// ForceTraceHeader is the header of a request that, if it is 'true', forces the request to be traced
+// whatever the sampling ratio, e.g. to trace a request that is known to be slow.
+const ForceTraceHeader = "Openfga-Force-Trace"
+
+// forceTraceSampler samples the root spans of the requests with the ForceTraceHeader, and the other
+// root spans with its delegate.
+type forceTraceSampler struct {
+	delegate sdktrace.Sampler
+}
+
+// NewForceTraceSampler returns a sampler that samples the requests with the ForceTraceHeader, and
+// the other requests with the sampling ratio. The spans of a request are sampled if its root span
+// is.
+func NewForceTraceSampler(samplingRatio float64) sdktrace.Sampler {
+	root := forceTraceSampler{delegate: sdktrace.TraceIDRatioBased(samplingRatio)}
+	return sdktrace.ParentBased(root,
+		// the trace context sent by a client doesn't change the sampling of the request
+		sdktrace.WithRemoteParentSampled(root),
+		sdktrace.WithRemoteParentNotSampled(root),
+	)
+}
+
+// ShouldSample see [sdktrace.Sampler].ShouldSample.
+func (s forceTraceSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	md, _ := metadata.FromIncomingContext(p.ParentContext)
+	for _, value := range md.Get(ForceTraceHeader) {
+		if strings.EqualFold(value, "true") {
+			return sdktrace.SamplingResult{
+				Decision:   sdktrace.RecordAndSample,
+				Attributes: []attribute.KeyValue{attribute.Bool("force_traced", true)},
+				Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+			}
+		}
+	}
+
+	return s.delegate.ShouldSample(p)
+}
+
+// Description see [sdktrace.Sampler].Description.
+func (s forceTraceSampler) Description() string {
+	return fmt.Sprintf("ForceTraceSampler{%s}", s.delegate.Description())
+}
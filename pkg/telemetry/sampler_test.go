@@ -0,0 +1,39 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestForceTraceSampler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(NewForceTraceSampler(0)),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	tracer := provider.Tracer("test")
+
+	_, span := tracer.Start(context.Background(), "not_forced")
+	require.False(t, span.SpanContext().IsSampled())
+	span.End()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ForceTraceHeader, "true"))
+	ctx, span = tracer.Start(ctx, "forced")
+	require.True(t, span.SpanContext().IsSampled())
+
+	// the child spans of a forced span are sampled too, even without the header
+	_, child := tracer.Start(metadata.NewIncomingContext(ctx, metadata.MD{}), "child")
+	require.True(t, child.SpanContext().IsSampled())
+	child.End()
+	span.End()
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 2)
+	require.Equal(t, "child", ended[0].Name())
+	require.Equal(t, "forced", ended[1].Name())
+}
@@ -89,7 +89,7 @@ func MustNewTracerProvider(opts ...TracerOption) *sdktrace.TracerProvider {
 	}
 
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(tracer.samplingRatio)),
+		sdktrace.WithSampler(NewForceTraceSampler(tracer.samplingRatio)),
 		sdktrace.WithResource(res),
 		sdktrace.WithSpanProcessor(sdktrace.NewBatchSpanProcessor(exp)),
 	)
@@ -101,6 +101,15 @@ func MustNewTracerProvider(opts ...TracerOption) *sdktrace.TracerProvider {
 	return tp
 }
 
+// TraceCacheLookup adds a 'cache_hit' or a 'cache_miss' event of the cache to the span.
+func TraceCacheLookup(span trace.Span, cache string, hit bool) {
+	name := "cache_miss"
+	if hit {
+		name = "cache_hit"
+	}
+	span.AddEvent(name, trace.WithAttributes(attribute.String("cache", cache)))
+}
+
 // TraceError marks the span as having an error, except if the error is context.Canceled,
 // in which case it does nothing.
 func TraceError(span trace.Span, err error) {
@@ -0,0 +1,123 @@
+// Package servertest contains a server of an in-memory datastore for the tests of the packages that
+// are clients of the OpenFGA API.
+package servertest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/openfga/openfga/pkg/server"
+	"github.com/openfga/openfga/pkg/storage/memory"
+	"github.com/openfga/openfga/pkg/testutils"
+)
+
+// Client calls the server directly instead of through gRPC, and records the outgoing metadata of the
+// last call, e.g. to test that the authorization header of a request is forwarded to the server, and
+// the Check requests.
+type Client struct {
+	*server.Server
+
+	mu       sync.Mutex
+	metadata metadata.MD
+	checks   []*openfgav1.CheckRequest
+}
+
+// New returns a client of a new server of an in-memory datastore, which is closed at the end of the
+// test.
+func New(t testing.TB, opts ...server.OpenFGAServiceV1Option) *Client {
+	svr := server.MustNewServerWithOpts(append([]server.OpenFGAServiceV1Option{server.WithDatastore(memory.New())}, opts...)...)
+	t.Cleanup(svr.Close)
+
+	return &Client{Server: svr}
+}
+
+// Metadata returns the values of a key of the outgoing metadata of the last call.
+func (c *Client) Metadata(key string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.metadata.Get(key)
+}
+
+// Checks returns the Check requests, in the order they were called in.
+func (c *Client) Checks() []*openfgav1.CheckRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checks
+}
+
+func (c *Client) record(ctx context.Context) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metadata = md
+}
+
+// CreateStore creates a store with an authorization model in the DSL and the tuples, and returns the
+// IDs of the store and of the model.
+func (c *Client) CreateStore(t testing.TB, name, model string, tuples ...*openfgav1.TupleKey) (string, string) {
+	store, err := c.Server.CreateStore(context.Background(), &openfgav1.CreateStoreRequest{Name: name})
+	require.NoError(t, err)
+
+	modelID := c.WriteModel(t, store.GetId(), model)
+
+	if len(tuples) > 0 {
+		_, err = c.Server.Write(context.Background(), &openfgav1.WriteRequest{
+			StoreId: store.GetId(),
+			Writes:  &openfgav1.WriteRequestWrites{TupleKeys: tuples},
+		})
+		require.NoError(t, err)
+	}
+
+	return store.GetId(), modelID
+}
+
+// WriteModel writes an authorization model in the DSL to a store, and returns its ID.
+func (c *Client) WriteModel(t testing.TB, storeID, model string) string {
+	proto := testutils.MustTransformDSLToProtoWithID(model)
+	resp, err := c.Server.WriteAuthorizationModel(context.Background(), &openfgav1.WriteAuthorizationModelRequest{
+		StoreId:         storeID,
+		SchemaVersion:   proto.GetSchemaVersion(),
+		TypeDefinitions: proto.GetTypeDefinitions(),
+		Conditions:      proto.GetConditions(),
+	})
+	require.NoError(t, err)
+
+	return resp.GetAuthorizationModelId()
+}
+
+func (c *Client) Check(ctx context.Context, in *openfgav1.CheckRequest, _ ...grpc.CallOption) (*openfgav1.CheckResponse, error) {
+	c.record(ctx)
+
+	c.mu.Lock()
+	c.checks = append(c.checks, in)
+	c.mu.Unlock()
+
+	return c.Server.Check(ctx, in)
+}
+
+func (c *Client) ReadAssertions(ctx context.Context, in *openfgav1.ReadAssertionsRequest, _ ...grpc.CallOption) (*openfgav1.ReadAssertionsResponse, error) {
+	c.record(ctx)
+	return c.Server.ReadAssertions(ctx, in)
+}
+
+func (c *Client) ReadAuthorizationModel(ctx context.Context, in *openfgav1.ReadAuthorizationModelRequest, _ ...grpc.CallOption) (*openfgav1.ReadAuthorizationModelResponse, error) {
+	c.record(ctx)
+	return c.Server.ReadAuthorizationModel(ctx, in)
+}
+
+func (c *Client) ReadAuthorizationModels(ctx context.Context, in *openfgav1.ReadAuthorizationModelsRequest, _ ...grpc.CallOption) (*openfgav1.ReadAuthorizationModelsResponse, error) {
+	c.record(ctx)
+	return c.Server.ReadAuthorizationModels(ctx, in)
+}
+
+func (c *Client) Read(ctx context.Context, in *openfgav1.ReadRequest, _ ...grpc.CallOption) (*openfgav1.ReadResponse, error) {
+	c.record(ctx)
+	return c.Server.Read(ctx, in)
+}
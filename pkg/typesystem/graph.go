@@ -0,0 +1,269 @@
+package typesystem
+
+import (
+	"fmt"
+	"strings"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+
+	"github.com/openfga/openfga/pkg/tuple"
+)
+
+// GraphNodeKind is the kind of a node of a ModelGraph.
+type GraphNodeKind string
+
+const (
+	// GraphNodeType is an object type, e.g. `document`.
+	GraphNodeType GraphNodeKind = "type"
+
+	// GraphNodeRelation is a relation of a type, e.g. `document#viewer`.
+	GraphNodeRelation GraphNodeKind = "relation"
+
+	// GraphNodeWildcard is the wildcard of a type, e.g. `user:*`.
+	GraphNodeWildcard GraphNodeKind = "wildcard"
+
+	// GraphNodeUnion, GraphNodeIntersection and GraphNodeExclusion are the operators of the rewrites
+	// of the relations, e.g. `editor or owner`.
+	GraphNodeUnion        GraphNodeKind = "union"
+	GraphNodeIntersection GraphNodeKind = "intersection"
+	GraphNodeExclusion    GraphNodeKind = "exclusion"
+)
+
+// GraphEdgeKind is the kind of an edge of a ModelGraph.
+type GraphEdgeKind string
+
+const (
+	// GraphEdgeRelation links a type to each of its relations.
+	GraphEdgeRelation GraphEdgeKind = "relation"
+
+	// GraphEdgeRewrite links a relation to the operator of its rewrite.
+	GraphEdgeRewrite GraphEdgeKind = "rewrite"
+
+	// GraphEdgeDirect links a directly assignable relation to the types, wildcards and usersets it
+	// can be assigned, e.g. `define viewer: [user, group#member]`.
+	GraphEdgeDirect GraphEdgeKind = "direct"
+
+	// GraphEdgeComputedUserset links a relation to another relation of the same type, e.g.
+	// `define viewer: editor`.
+	GraphEdgeComputedUserset GraphEdgeKind = "computed_userset"
+
+	// GraphEdgeTupleToUserset links a relation to the relation of the types of its tupleset, e.g.
+	// `define viewer: viewer from parent`.
+	GraphEdgeTupleToUserset GraphEdgeKind = "tuple_to_userset"
+)
+
+// GraphNode is a node of a ModelGraph.
+type GraphNode struct {
+	ID    string        `json:"id"`
+	Kind  GraphNodeKind `json:"kind"`
+	Label string        `json:"label"`
+}
+
+// GraphEdge is an edge of a ModelGraph, from a relation or an operator to what it is evaluated with.
+type GraphEdge struct {
+	From string        `json:"from"`
+	To   string        `json:"to"`
+	Kind GraphEdgeKind `json:"kind"`
+
+	// Label describes the edge, e.g. `from parent` for a tuple to userset, or `but not` for the
+	// subtracted operand of an exclusion.
+	Label string `json:"label,omitempty"`
+
+	// Condition is the condition of a direct assignment, if any.
+	Condition string `json:"condition,omitempty"`
+}
+
+// GraphCondition is a condition of the model that the direct edges can be annotated with.
+type GraphCondition struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+// ModelGraph is the graph of the types and relations of an authorization model, and of the rewrites
+// of the relations. It can be rendered to visualize a model, e.g. with DOT.
+type ModelGraph struct {
+	Nodes      []*GraphNode      `json:"nodes"`
+	Edges      []*GraphEdge      `json:"edges"`
+	Conditions []*GraphCondition `json:"conditions"`
+
+	nodes map[string]struct{}
+}
+
+func (g *ModelGraph) addNode(id string, kind GraphNodeKind, label string) {
+	if _, ok := g.nodes[id]; ok {
+		return
+	}
+
+	g.nodes[id] = struct{}{}
+	g.Nodes = append(g.Nodes, &GraphNode{ID: id, Kind: kind, Label: label})
+}
+
+func (g *ModelGraph) addEdge(edge *GraphEdge) {
+	g.Edges = append(g.Edges, edge)
+}
+
+// Graph returns the graph of the types and relations of the model, and of the rewrites of the
+// relations. The nodes and edges are sorted by type and relation.
+func (t *TypeSystem) Graph() *ModelGraph {
+	g := &ModelGraph{
+		Nodes:      []*GraphNode{},
+		Edges:      []*GraphEdge{},
+		Conditions: []*GraphCondition{},
+		nodes:      map[string]struct{}{},
+	}
+
+	// the nodes of the types and relations are added first, so that they are in order
+	for _, objectType := range sortedKeys(t.typeDefinitions) {
+		g.addNode(objectType, GraphNodeType, objectType)
+		for _, relationName := range sortedKeys(t.relations[objectType]) {
+			g.addNode(tuple.ToObjectRelationString(objectType, relationName), GraphNodeRelation, relationName)
+		}
+	}
+
+	for _, objectType := range sortedKeys(t.typeDefinitions) {
+		for _, relationName := range sortedKeys(t.relations[objectType]) {
+			node := tuple.ToObjectRelationString(objectType, relationName)
+			g.addEdge(&GraphEdge{From: objectType, To: node, Kind: GraphEdgeRelation})
+
+			operators := 0
+			t.addRewriteEdges(g, objectType, relationName, node, "", t.relations[objectType][relationName].GetRewrite(), &operators)
+		}
+	}
+
+	for _, name := range sortedKeys(t.conditions) {
+		g.Conditions = append(g.Conditions, &GraphCondition{Name: name, Expression: t.conditions[name].GetExpression()})
+	}
+
+	return g
+}
+
+// addRewriteEdges adds the edges from the node to what the rewrite is evaluated with. The operators
+// are numbered in the order of the rewrite, so that their IDs are stable.
+func (t *TypeSystem) addRewriteEdges(g *ModelGraph, objectType, relationName, from, label string, rewrite *openfgav1.Userset, operators *int) {
+	addOperator := func(kind GraphNodeKind) string {
+		*operators++
+		id := fmt.Sprintf("%s/%s/%d", tuple.ToObjectRelationString(objectType, relationName), kind, *operators)
+		g.addNode(id, kind, string(kind))
+		g.addEdge(&GraphEdge{From: from, To: id, Kind: GraphEdgeRewrite, Label: label})
+		return id
+	}
+
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		refs, _ := t.GetDirectlyRelatedUserTypes(objectType, relationName)
+		for _, ref := range refs {
+			to := ref.GetType()
+			switch {
+			case ref.GetWildcard() != nil:
+				to = tuple.TypedPublicWildcard(ref.GetType())
+				g.addNode(to, GraphNodeWildcard, to)
+			case ref.GetRelation() != "":
+				to = tuple.ToObjectRelationString(ref.GetType(), ref.GetRelation())
+			}
+
+			g.addEdge(&GraphEdge{From: from, To: to, Kind: GraphEdgeDirect, Label: label, Condition: ref.GetCondition()})
+		}
+	case *openfgav1.Userset_ComputedUserset:
+		g.addEdge(&GraphEdge{
+			From:  from,
+			To:    tuple.ToObjectRelationString(objectType, rw.ComputedUserset.GetRelation()),
+			Kind:  GraphEdgeComputedUserset,
+			Label: label,
+		})
+	case *openfgav1.Userset_TupleToUserset:
+		tupleset := rw.TupleToUserset.GetTupleset().GetRelation()
+		computedRelation := rw.TupleToUserset.GetComputedUserset().GetRelation()
+
+		ttuLabel := "from " + tupleset
+		if label != "" {
+			ttuLabel = label + " " + ttuLabel
+		}
+
+		tuplesetTypes, _ := t.GetDirectlyRelatedUserTypes(objectType, tupleset)
+		for _, ref := range tuplesetTypes {
+			if _, err := t.GetRelation(ref.GetType(), computedRelation); err != nil {
+				continue
+			}
+
+			g.addEdge(&GraphEdge{
+				From:  from,
+				To:    tuple.ToObjectRelationString(ref.GetType(), computedRelation),
+				Kind:  GraphEdgeTupleToUserset,
+				Label: ttuLabel,
+			})
+		}
+	case *openfgav1.Userset_Union:
+		operator := addOperator(GraphNodeUnion)
+		for _, child := range rw.Union.GetChild() {
+			t.addRewriteEdges(g, objectType, relationName, operator, "", child, operators)
+		}
+	case *openfgav1.Userset_Intersection:
+		operator := addOperator(GraphNodeIntersection)
+		for _, child := range rw.Intersection.GetChild() {
+			t.addRewriteEdges(g, objectType, relationName, operator, "", child, operators)
+		}
+	case *openfgav1.Userset_Difference:
+		operator := addOperator(GraphNodeExclusion)
+		t.addRewriteEdges(g, objectType, relationName, operator, "", rw.Difference.GetBase(), operators)
+		t.addRewriteEdges(g, objectType, relationName, operator, "but not", rw.Difference.GetSubtract(), operators)
+	}
+}
+
+// dotNodeAttributes are the DOT attributes of the nodes of each kind.
+var dotNodeAttributes = map[GraphNodeKind]string{
+	GraphNodeType:         `shape=box, style=bold`,
+	GraphNodeRelation:     `shape=ellipse`,
+	GraphNodeWildcard:     `shape=box, style=dashed`,
+	GraphNodeUnion:        `shape=diamond`,
+	GraphNodeIntersection: `shape=diamond`,
+	GraphNodeExclusion:    `shape=diamond`,
+}
+
+// DOT renders the graph in the DOT language of Graphviz. The direct edges of a condition are dashed,
+// and labelled with the condition and its expression.
+func (g *ModelGraph) DOT() string {
+	expressions := make(map[string]string, len(g.Conditions))
+	for _, c := range g.Conditions {
+		expressions[c.Name] = c.Expression
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph model {\n  rankdir=LR;\n")
+
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, %s];\n", dotQuote(node.ID), dotQuote(node.Label), dotNodeAttributes[node.Kind])
+	}
+
+	for _, edge := range g.Edges {
+		var attributes []string
+		label := edge.Label
+		if edge.Condition != "" {
+			condition := "with " + edge.Condition
+			if expression := expressions[edge.Condition]; expression != "" {
+				condition += ": " + expression
+			}
+			label = strings.TrimSpace(label + " " + condition)
+			attributes = append(attributes, "style=dashed")
+		}
+		if label != "" {
+			attributes = append([]string{"label=" + dotQuote(label)}, attributes...)
+		}
+		if edge.Kind == GraphEdgeRelation {
+			attributes = append(attributes, "arrowhead=none")
+		}
+
+		fmt.Fprintf(&b, "  %s -> %s", dotQuote(edge.From), dotQuote(edge.To))
+		if len(attributes) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attributes, ", "))
+		}
+		b.WriteString(";\n")
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote returns s as a quoted DOT ID.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
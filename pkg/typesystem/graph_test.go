@@ -0,0 +1,97 @@
+package typesystem
+
+import (
+	"testing"
+
+	parser "github.com/openfga/language/pkg/go/transformer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	model := parser.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+
+type folder
+  relations
+    define viewer: [user, user:*]
+
+type document
+  relations
+    define blocked: [user]
+    define parent: [folder]
+    define owner: [user with non_expired]
+    define viewer: (owner or viewer from parent) but not blocked
+
+condition non_expired(current_time: timestamp, expires_at: timestamp) {
+  current_time < expires_at
+}`)
+
+	g := New(model).Graph()
+
+	var nodes []string
+	kinds := map[string]GraphNodeKind{}
+	for _, node := range g.Nodes {
+		nodes = append(nodes, node.ID)
+		kinds[node.ID] = node.Kind
+	}
+	require.Equal(t, []string{
+		"document", "document#blocked", "document#owner", "document#parent", "document#viewer",
+		"folder", "folder#viewer",
+		"user",
+		"document#viewer/exclusion/1", "document#viewer/union/2", "user:*",
+	}, nodes)
+	require.Equal(t, GraphNodeWildcard, kinds["user:*"])
+
+	edges := map[GraphEdge]bool{}
+	for _, edge := range g.Edges {
+		edges[*edge] = true
+	}
+
+	for _, edge := range []GraphEdge{
+		{From: "document", To: "document#viewer", Kind: GraphEdgeRelation},
+		{From: "document#owner", To: "user", Kind: GraphEdgeDirect, Condition: "non_expired"},
+		{From: "folder#viewer", To: "user:*", Kind: GraphEdgeDirect},
+		{From: "document#viewer", To: "document#viewer/exclusion/1", Kind: GraphEdgeRewrite},
+		{From: "document#viewer/exclusion/1", To: "document#viewer/union/2", Kind: GraphEdgeRewrite},
+		{From: "document#viewer/exclusion/1", To: "document#blocked", Kind: GraphEdgeComputedUserset, Label: "but not"},
+		{From: "document#viewer/union/2", To: "document#owner", Kind: GraphEdgeComputedUserset},
+		{From: "document#viewer/union/2", To: "folder#viewer", Kind: GraphEdgeTupleToUserset, Label: "from parent"},
+	} {
+		require.True(t, edges[edge], "missing edge %+v", edge)
+	}
+
+	require.Equal(t, []*GraphCondition{{Name: "non_expired", Expression: "current_time < expires_at"}}, g.Conditions)
+}
+
+func TestGraphDOT(t *testing.T) {
+	model := parser.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+
+type document
+  relations
+    define owner: [user with non_expired]
+    define viewer: [user:*] or owner
+
+condition non_expired(current_time: timestamp, expires_at: timestamp) {
+  current_time < expires_at
+}`)
+
+	require.Equal(t, `digraph model {
+  rankdir=LR;
+  "document" [label="document", shape=box, style=bold];
+  "document#owner" [label="owner", shape=ellipse];
+  "document#viewer" [label="viewer", shape=ellipse];
+  "user" [label="user", shape=box, style=bold];
+  "document#viewer/union/1" [label="union", shape=diamond];
+  "user:*" [label="user:*", shape=box, style=dashed];
+  "document" -> "document#owner" [arrowhead=none];
+  "document#owner" -> "user" [label="with non_expired: current_time < expires_at", style=dashed];
+  "document" -> "document#viewer" [arrowhead=none];
+  "document#viewer" -> "document#viewer/union/1";
+  "document#viewer/union/1" -> "user:*";
+  "document#viewer/union/1" -> "document#owner";
+}
+`, New(model).Graph().DOT())
+}
@@ -4,73 +4,185 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/karlseguin/ccache/v3"
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/openfga/openfga/internal/build"
 	"github.com/openfga/openfga/pkg/storage"
 )
 
 const (
 	typesystemCacheTTL = 168 * time.Hour // 7 days.
+
+	// DefaultTypesystemCacheMaxSize is the default maximum number of TypeSystems memoized by a
+	// MemoizedTypesystemResolver.
+	DefaultTypesystemCacheMaxSize = 5000
+)
+
+var (
+	typesystemCacheTotalCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "typesystem_cache_total_count",
+		Help:      "The total number of TypeSystem resolutions.",
+	})
+
+	typesystemCacheHitCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "typesystem_cache_hit_count",
+		Help:      "The total number of TypeSystem resolutions served from the TypeSystem cache.",
+	})
+
+	typesystemCacheEvictionCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: build.ProjectName,
+		Name:      "typesystem_cache_eviction_count",
+		Help:      "The total number of TypeSystems evicted from the TypeSystem cache.",
+	})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: build.ProjectName,
+		Name:      "typesystem_cache_entries",
+		Help:      "The number of TypeSystems of the TypeSystem caches.",
+	}, func() float64 {
+		return float64(openTypesystemCaches.itemCount())
+	})
+
+	openTypesystemCaches = &typesystemCacheRegistry{resolvers: map[*MemoizedTypesystemResolver]struct{}{}}
 )
 
+// typesystemCacheRegistry is the set of the MemoizedTypesystemResolvers that aren't stopped, whose
+// usage is reported by the gauge.
+type typesystemCacheRegistry struct {
+	mu        sync.Mutex
+	resolvers map[*MemoizedTypesystemResolver]struct{}
+}
+
+func (r *typesystemCacheRegistry) add(m *MemoizedTypesystemResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolvers[m] = struct{}{}
+}
+
+func (r *typesystemCacheRegistry) remove(m *MemoizedTypesystemResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.resolvers, m)
+}
+
+func (r *typesystemCacheRegistry) itemCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for m := range r.resolvers {
+		count += m.cache.ItemCount()
+	}
+	return count
+}
+
 // TypesystemResolverFunc is a function type that implementations
 // can use to provide lookup and resolution of a Typesystem.
 type TypesystemResolverFunc func(ctx context.Context, storeID, modelID string) (*TypeSystem, error)
 
-// MemoizedTypesystemResolverFunc returns a TypesystemResolverFunc that fetches the provided authorization
-// model (if provided) or looks up the latest authorization model. It then constructs a TypeSystem from
-// the resolved model, and memoizes the type-system resolution. If another lookup of the same model occurs,
-// the earlier constructed TypeSystem will be used.
-//
-// The memoized resolver function is designed for concurrent use.
-func MemoizedTypesystemResolverFunc(datastore storage.AuthorizationModelReadBackend) (TypesystemResolverFunc, func()) {
-	lookupGroup := singleflight.Group{}
+// MemoizedTypesystemResolverOption defines an option that can be used to change the behavior
+// of a MemoizedTypesystemResolver.
+type MemoizedTypesystemResolverOption func(m *MemoizedTypesystemResolver)
 
-	cache := ccache.New(ccache.Configure[*TypeSystem]())
+// WithTypesystemCacheMaxSize bounds the number of memoized TypeSystems, across all the stores. The
+// least recently used ones are evicted once it is exceeded.
+func WithTypesystemCacheMaxSize(size int) MemoizedTypesystemResolverOption {
+	return func(m *MemoizedTypesystemResolver) {
+		m.maxSize = size
+	}
+}
 
-	return func(ctx context.Context, storeID, modelID string) (*TypeSystem, error) {
-		ctx, span := tracer.Start(ctx, "MemoizedTypesystemResolverFunc")
-		defer span.End()
+// MemoizedTypesystemResolver fetches the provided authorization model (if provided) or looks up the
+// latest authorization model. It then constructs a TypeSystem from the resolved model, and memoizes
+// it in an LRU cache keyed by the store and the model. If another lookup of the same model occurs,
+// including a lookup of the latest model of the store, the earlier constructed TypeSystem will be used.
+//
+// The MemoizedTypesystemResolver is designed for concurrent use.
+type MemoizedTypesystemResolver struct {
+	datastore   storage.AuthorizationModelReadBackend
+	lookupGroup singleflight.Group
+	cache       *ccache.Cache[*TypeSystem]
+	maxSize     int
+	stopOnce    sync.Once
+}
 
-		var err error
+// NewMemoizedTypesystemResolver constructs a MemoizedTypesystemResolver that reads the authorization
+// models from the datastore. It must be stopped with Stop.
+func NewMemoizedTypesystemResolver(datastore storage.AuthorizationModelReadBackend, opts ...MemoizedTypesystemResolverOption) *MemoizedTypesystemResolver {
+	m := &MemoizedTypesystemResolver{
+		datastore: datastore,
+		maxSize:   DefaultTypesystemCacheMaxSize,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	// prune a tenth of the cache once it is full, instead of the default 500 TypeSystems, so that
+	// small caches aren't emptied
+	m.cache = ccache.New(ccache.Configure[*TypeSystem]().
+		MaxSize(int64(m.maxSize)).
+		ItemsToPrune(uint32(max(1, m.maxSize/10))).
+		OnDelete(func(*ccache.Item[*TypeSystem]) {
+			typesystemCacheEvictionCounter.Inc()
+		}))
+	openTypesystemCaches.add(m)
+
+	return m
+}
 
-		if modelID != "" {
-			if _, err := ulid.Parse(modelID); err != nil {
-				return nil, ErrModelNotFound
-			}
-		}
+// MemoizedTypesystemResolverFunc returns the Resolve and Stop functions of a new MemoizedTypesystemResolver.
+func MemoizedTypesystemResolverFunc(datastore storage.AuthorizationModelReadBackend, opts ...MemoizedTypesystemResolverOption) (TypesystemResolverFunc, func()) {
+	m := NewMemoizedTypesystemResolver(datastore, opts...)
+	return m.Resolve, m.Stop
+}
 
-		var v interface{}
-		var key string
-		if modelID == "" {
-			v, err, _ = lookupGroup.Do(fmt.Sprintf("FindLatestAuthorizationModel:%s", storeID), func() (interface{}, error) {
-				return datastore.FindLatestAuthorizationModel(ctx, storeID)
-			})
-			if err != nil {
-				if errors.Is(err, storage.ErrNotFound) {
-					return nil, ErrModelNotFound
-				}
+// Resolve returns the TypeSystem of the model of the store, or of its latest model if modelID is empty.
+func (m *MemoizedTypesystemResolver) Resolve(ctx context.Context, storeID, modelID string) (*TypeSystem, error) {
+	ctx, span := tracer.Start(ctx, "MemoizedTypesystemResolverFunc")
+	defer span.End()
 
-				return nil, fmt.Errorf("failed to FindLatestAuthorizationModel: %w", err)
-			}
+	typesystemCacheTotalCounter.Inc()
 
-			model := v.(*openfgav1.AuthorizationModel)
-			key = fmt.Sprintf("%s/%s", storeID, model.GetId())
-		} else {
-			key = fmt.Sprintf("%s/%s", storeID, modelID)
-			item := cache.Get(key)
-			if item != nil {
-				return item.Value(), nil
+	var model *openfgav1.AuthorizationModel
+	if modelID == "" {
+		v, err, _ := m.lookupGroup.Do(fmt.Sprintf("FindLatestAuthorizationModel:%s", storeID), func() (interface{}, error) {
+			return m.datastore.FindLatestAuthorizationModel(ctx, storeID)
+		})
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, ErrModelNotFound
 			}
 
-			v, err, _ = lookupGroup.Do(fmt.Sprintf("ReadAuthorizationModel:%s/%s", storeID, modelID), func() (interface{}, error) {
-				return datastore.ReadAuthorizationModel(ctx, storeID, modelID)
-			})
+			return nil, fmt.Errorf("failed to FindLatestAuthorizationModel: %w", err)
+		}
+
+		model = v.(*openfgav1.AuthorizationModel)
+		modelID = model.GetId()
+	} else if _, err := ulid.Parse(modelID); err != nil {
+		return nil, ErrModelNotFound
+	}
+
+	key := typesystemCacheKey(storeID, modelID)
+	if item := m.cache.Get(key); item != nil {
+		typesystemCacheHitCounter.Inc()
+		return item.Value(), nil
+	}
+
+	v, err, _ := m.lookupGroup.Do(fmt.Sprintf("ReadAuthorizationModel:%s", key), func() (interface{}, error) {
+		if model == nil {
+			var err error
+			model, err = m.datastore.ReadAuthorizationModel(ctx, storeID, modelID)
 			if err != nil {
 				if errors.Is(err, storage.ErrNotFound) {
 					return nil, ErrModelNotFound
@@ -80,15 +192,36 @@ func MemoizedTypesystemResolverFunc(datastore storage.AuthorizationModelReadBack
 			}
 		}
 
-		model := v.(*openfgav1.AuthorizationModel)
-
 		typesys, err := NewAndValidate(ctx, model)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
 		}
 
-		cache.Set(key, typesys, typesystemCacheTTL)
+		m.cache.Set(key, typesys, typesystemCacheTTL)
 
 		return typesys, nil
-	}, cache.Stop
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*TypeSystem), nil
+}
+
+// Add memoizes the TypeSystem of a model of the store that was already constructed, e.g. when the
+// model was written, so that the first resolution of the model doesn't construct it again.
+func (m *MemoizedTypesystemResolver) Add(storeID string, typesys *TypeSystem) {
+	m.cache.Set(typesystemCacheKey(storeID, typesys.GetAuthorizationModelID()), typesys, typesystemCacheTTL)
+}
+
+// Stop stops the cache of the MemoizedTypesystemResolver.
+func (m *MemoizedTypesystemResolver) Stop() {
+	m.stopOnce.Do(func() {
+		openTypesystemCaches.remove(m)
+		m.cache.Stop()
+	})
+}
+
+func typesystemCacheKey(storeID, modelID string) string {
+	return fmt.Sprintf("%s/%s", storeID, modelID)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/oklog/ulid/v2"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
@@ -114,3 +115,82 @@ func TestSingleFlightMemoizedTypesystemResolverFunc(t *testing.T) {
 	err := wg.Wait()
 	require.NoError(t, err)
 }
+
+func TestMemoizedTypesystemResolverCache(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockDatastore := mockstorage.NewMockOpenFGADatastore(mockController)
+
+	storeID := ulid.Make().String()
+
+	typedefs := parser.MustTransformDSLToProto(`model
+  schema 1.1
+type user
+type document
+  relations
+	define viewer: [user]`).GetTypeDefinitions()
+
+	newModel := func() *openfgav1.AuthorizationModel {
+		return &openfgav1.AuthorizationModel{
+			Id:              ulid.Make().String(),
+			SchemaVersion:   SchemaVersion1_1,
+			TypeDefinitions: typedefs,
+		}
+	}
+
+	t.Run("the_latest_model_is_constructed_once", func(t *testing.T) {
+		resolver := NewMemoizedTypesystemResolver(mockDatastore)
+		defer resolver.Stop()
+
+		model := newModel()
+		mockDatastore.EXPECT().FindLatestAuthorizationModel(gomock.Any(), storeID).Return(model, nil).Times(2)
+
+		first, err := resolver.Resolve(context.Background(), storeID, "")
+		require.NoError(t, err)
+
+		second, err := resolver.Resolve(context.Background(), storeID, "")
+		require.NoError(t, err)
+		require.Same(t, first, second)
+
+		// the latest model is memoized for the lookups by its ID too
+		byID, err := resolver.Resolve(context.Background(), storeID, model.GetId())
+		require.NoError(t, err)
+		require.Same(t, first, byID)
+	})
+
+	t.Run("the_written_models_are_precompiled", func(t *testing.T) {
+		resolver := NewMemoizedTypesystemResolver(mockDatastore)
+		defer resolver.Stop()
+
+		model := newModel()
+		typesys, err := NewAndValidate(context.Background(), model)
+		require.NoError(t, err)
+		resolver.Add(storeID, typesys)
+
+		mockDatastore.EXPECT().FindLatestAuthorizationModel(gomock.Any(), storeID).Return(model, nil)
+
+		latest, err := resolver.Resolve(context.Background(), storeID, "")
+		require.NoError(t, err)
+		require.Same(t, typesys, latest)
+	})
+
+	t.Run("the_least_recently_used_models_are_evicted", func(t *testing.T) {
+		const maxSize = 2
+
+		resolver := NewMemoizedTypesystemResolver(mockDatastore, WithTypesystemCacheMaxSize(maxSize))
+		defer resolver.Stop()
+
+		for i := 0; i < 2*maxSize; i++ {
+			model := newModel()
+			mockDatastore.EXPECT().ReadAuthorizationModel(gomock.Any(), storeID, model.GetId()).Return(model, nil)
+
+			_, err := resolver.Resolve(context.Background(), storeID, model.GetId())
+			require.NoError(t, err)
+		}
+
+		require.Eventually(t, func() bool {
+			return resolver.cache.ItemCount() == maxSize
+		}, time.Second, 10*time.Millisecond)
+	})
+}
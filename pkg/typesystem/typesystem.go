@@ -1105,6 +1105,131 @@ func (t *TypeSystem) HasCycle(objectType, relationName string) (bool, error) {
 	return t.hasCycle(objectType, relationName, relation.GetRewrite(), visited)
 }
 
+// RuntimeCycles returns the cycles in the rewrite graph of the model that go through a direct
+// userset type restriction (e.g. `[group#member]`) or a tuple to userset rewrite (e.g. `viewer from parent`).
+// Unlike cycles through computed relations, which are rejected by validation, these can only be
+// followed at runtime if the relationship tuples form a cycle too, for example if `folder:a` is the
+// parent of `folder:b` and `folder:b` is the parent of `folder:a`. Evaluating such a cycle fails
+// once the resolution depth is exceeded.
+//
+// Each cycle is a sequence of `type#relation` nodes that starts and ends with the same node. Only the
+// shortest cycle through each node is returned, and cycles made of the same nodes are only returned once.
+func (t *TypeSystem) RuntimeCycles() [][]string {
+	var nodes []string
+	edges := map[string][]string{}
+	for _, objectType := range sortedKeys(t.relations) {
+		for _, relationName := range sortedKeys(t.relations[objectType]) {
+			node := tuple.ToObjectRelationString(objectType, relationName)
+			nodes = append(nodes, node)
+			edges[node] = t.rewriteEdges(objectType, relationName, t.relations[objectType][relationName].GetRewrite())
+		}
+	}
+
+	var cycles [][]string
+	seen := map[string]struct{}{}
+	for _, node := range nodes {
+		cycle := shortestCycle(node, edges)
+		if cycle == nil {
+			continue
+		}
+
+		members := append([]string(nil), cycle[1:]...)
+		sort.Strings(members)
+		key := fmt.Sprint(members)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		cycles = append(cycles, cycle)
+	}
+
+	return cycles
+}
+
+// rewriteEdges returns the `type#relation` nodes that can be evaluated to resolve the provided rewrite.
+func (t *TypeSystem) rewriteEdges(objectType, relationName string, rewrite *openfgav1.Userset) []string {
+	var edges []string
+
+	switch rw := rewrite.GetUserset().(type) {
+	case *openfgav1.Userset_This:
+		usersets, _ := t.DirectlyRelatedUsersets(objectType, relationName)
+		for _, ref := range usersets {
+			if ref.GetRelation() != "" {
+				edges = append(edges, tuple.ToObjectRelationString(ref.GetType(), ref.GetRelation()))
+			}
+		}
+	case *openfgav1.Userset_ComputedUserset:
+		edges = append(edges, tuple.ToObjectRelationString(objectType, rw.ComputedUserset.GetRelation()))
+	case *openfgav1.Userset_TupleToUserset:
+		tuplesetTypes, _ := t.GetDirectlyRelatedUserTypes(objectType, rw.TupleToUserset.GetTupleset().GetRelation())
+		computedRelation := rw.TupleToUserset.GetComputedUserset().GetRelation()
+		for _, ref := range tuplesetTypes {
+			if _, err := t.GetRelation(ref.GetType(), computedRelation); err == nil {
+				edges = append(edges, tuple.ToObjectRelationString(ref.GetType(), computedRelation))
+			}
+		}
+	case *openfgav1.Userset_Union:
+		for _, child := range rw.Union.GetChild() {
+			edges = append(edges, t.rewriteEdges(objectType, relationName, child)...)
+		}
+	case *openfgav1.Userset_Intersection:
+		for _, child := range rw.Intersection.GetChild() {
+			edges = append(edges, t.rewriteEdges(objectType, relationName, child)...)
+		}
+	case *openfgav1.Userset_Difference:
+		edges = append(edges, t.rewriteEdges(objectType, relationName, rw.Difference.GetBase())...)
+		edges = append(edges, t.rewriteEdges(objectType, relationName, rw.Difference.GetSubtract())...)
+	}
+
+	return edges
+}
+
+// shortestCycle returns the shortest path from start back to itself in the provided graph, or nil if there is none.
+func shortestCycle(start string, edges map[string][]string) []string {
+	previous := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, next := range edges[node] {
+			if next == start {
+				cycle := []string{start}
+				for n := node; n != start; n = previous[n] {
+					cycle = append(cycle, n)
+				}
+				cycle = append(cycle, start)
+
+				// the path was built backwards
+				for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+
+				return cycle
+			}
+
+			if _, ok := previous[next]; ok {
+				continue
+			}
+			previous[next] = node
+			queue = append(queue, next)
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
 // IsTuplesetRelation returns a boolean indicating if the provided relation is defined under a
 // TupleToUserset rewrite as a tupleset relation (i.e. the right hand side of a `X from Y`).
 func (t *TypeSystem) IsTuplesetRelation(objectType, relation string) (bool, error) {
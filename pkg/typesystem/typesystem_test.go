@@ -756,6 +756,85 @@ type document
 	}
 }
 
+func TestRuntimeCycles(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		expected [][]string
+	}{
+		{
+			name: "no_cycles",
+			model: `model
+	schema 1.1
+type user
+
+type folder
+  relations
+	define viewer: [user]
+
+type document
+  relations
+	define parent: [folder]
+	define viewer: [user] or viewer from parent`,
+			expected: nil,
+		},
+		{
+			name: "direct_userset",
+			model: `model
+	schema 1.1
+type user
+
+type group
+  relations
+	define member: [user, group#member]`,
+			expected: [][]string{
+				{"group#member", "group#member"},
+			},
+		},
+		{
+			name: "tuple_to_userset",
+			model: `model
+	schema 1.1
+type user
+
+type folder
+  relations
+	define parent: [folder]
+	define viewer: [user] or viewer from parent`,
+			expected: [][]string{
+				{"folder#viewer", "folder#viewer"},
+			},
+		},
+		{
+			name: "through_computed_userset_and_other_types",
+			model: `model
+	schema 1.1
+type user
+
+type team
+  relations
+	define member: [user, group#member]
+
+type group
+  relations
+	define owner: [user, team#member]
+	define member: owner`,
+			expected: [][]string{
+				{"group#member", "group#owner", "team#member", "group#member"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			typesys, err := NewAndValidate(context.Background(), testutils.MustTransformDSLToProtoWithID(test.model))
+			require.NoError(t, err)
+
+			require.Equal(t, test.expected, typesys.RuntimeCycles())
+		})
+	}
+}
+
 func TestNewAndValidate(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -232,7 +232,12 @@ type document
 				require.NoError(t, err)
 			}
 
-			actualLogs := logs.All()
+			// only the request logs, the logs of the modules (e.g. the debug logs of the authentication
+			// and of the Check resolution) have a 'module' field
+			actualLogs := logs.Filter(func(entry observer.LoggedEntry) bool {
+				_, ok := entry.ContextMap()["module"]
+				return !ok
+			}).All()
 			require.Len(t, actualLogs, 1)
 
 			fields := actualLogs[0].ContextMap()